@@ -0,0 +1,351 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisor
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+
+	"github.com/matrixorigin/matrixcube/storage/stats"
+)
+
+const (
+	defaultHighSyncRate       = 500
+	defaultSmallSyncBytes     = 16 * 1024
+	defaultHighStallRatio     = 0.01
+	defaultHighCompactionDebt = 4 * 1024 * 1024 * 1024
+	defaultHighL0Files        = 20
+	// recommendedGroupCommitWindow the group commit window recommended if the
+	// WAL is synced too frequently
+	recommendedGroupCommitWindow = time.Millisecond
+)
+
+// Options the thresholds of the rules of the Advisor, the zero values are
+// replaced by the defaults.
+type Options struct {
+	// HighSyncRate the WAL fsyncs per second of the LogDB considered too frequent.
+	// Default: 500
+	HighSyncRate float64
+	// SmallSyncBytes the average bytes written per WAL fsync of the LogDB
+	// considered too small. Default: 16KB
+	SmallSyncBytes uint64
+	// HighStallRatio the ratio of the stalled time of the writes to the sampled
+	// window considered too high. Default: 0.01
+	HighStallRatio float64
+	// HighCompactionDebt the estimated bytes to compact considered too high.
+	// Default: 4GB
+	HighCompactionDebt uint64
+	// HighL0Files the number of the L0 files considered too high. Default: 20
+	HighL0Files uint64
+}
+
+func (opts *Options) adjust() {
+	if opts.HighSyncRate <= 0 {
+		opts.HighSyncRate = defaultHighSyncRate
+	}
+	if opts.SmallSyncBytes == 0 {
+		opts.SmallSyncBytes = defaultSmallSyncBytes
+	}
+	if opts.HighStallRatio <= 0 {
+		opts.HighStallRatio = defaultHighStallRatio
+	}
+	if opts.HighCompactionDebt == 0 {
+		opts.HighCompactionDebt = defaultHighCompactionDebt
+	}
+	if opts.HighL0Files == 0 {
+		opts.HighL0Files = defaultHighL0Files
+	}
+}
+
+// Settings the current configuration of the write path of the store
+type Settings struct {
+	// WALDir the `storage.wal-dir` of the store, empty means the WAL shares the
+	// device with the data
+	WALDir string
+	// GroupCommitWindow the `raft.raft-log.group-commit-window` of the store
+	GroupCommitWindow time.Duration
+}
+
+// Sample the stats of the storages of the store at a time, the counters are
+// cumulative.
+type Sample struct {
+	Time time.Time
+	// LogDB the stats of the storage of the LogDB
+	LogDB stats.Stats
+	// Data the stats of the data storages by the shard group
+	Data map[uint64]stats.Stats
+}
+
+// Advice a concrete configuration change recommended by the Advisor
+type Advice struct {
+	// Rule the name of the rule giving the advice
+	Rule string
+	// Group the shard group of the data storage, 0 if the advice is for the
+	// store or the LogDB
+	Group uint64
+	// Key the config key or the storage option to change
+	Key string
+	// Current the current value, empty if unknown
+	Current string
+	// Recommended the recommended value
+	Recommended string
+	// Reason the metrics observed in the sampled window
+	Reason string
+}
+
+func (a Advice) String() string {
+	return fmt.Sprintf("%s: set %s from %q to %q, %s",
+		a.Rule, a.Key, a.Current, a.Recommended, a.Reason)
+}
+
+// window the changes of the stats between two samples
+type window struct {
+	duration time.Duration
+	settings Settings
+	logDB    delta
+	data     map[uint64]delta
+}
+
+// delta the changes of the counters and the current gauges of a storage
+type delta struct {
+	walSyncs     uint64
+	writtenBytes uint64
+	stalls       uint64
+	stalled      time.Duration
+	// the gauges of the current sample
+	current stats.Stats
+	// debtGrowing the compaction debt is larger than the previous sample
+	debtGrowing bool
+}
+
+func newDelta(prev, cur stats.Stats) delta {
+	return delta{
+		walSyncs:     sub(cur.WALSyncs, prev.WALSyncs),
+		writtenBytes: sub(cur.WrittenBytes, prev.WrittenBytes),
+		stalls:       sub(cur.WriteStalls, prev.WriteStalls),
+		stalled:      time.Duration(sub(cur.WriteStallNanos, prev.WriteStallNanos)),
+		current:      cur,
+		debtGrowing:  cur.CompactionDebt > prev.CompactionDebt,
+	}
+}
+
+// sub returns 0 if the counter is reset, e.g. the storage is reopened
+func sub(cur, prev uint64) uint64 {
+	if cur < prev {
+		return 0
+	}
+	return cur - prev
+}
+
+// rule returns the advices of the window
+type rule func(opts Options, w window) []Advice
+
+var rules = []rule{
+	groupCommitRule,
+	walDeviceRule,
+	memTableRule,
+	compactionRule,
+}
+
+// Advisor turns the tuning knowledge of the write path into the rules. It
+// analyzes the stats sampled periodically, e.g. the WAL fsync rate and the
+// batch size, the write stalls and the compaction debt, and gives the concrete
+// configuration changes, e.g. raising the memtable size or moving the WAL to
+// another device.
+type Advisor struct {
+	opts Options
+
+	mu struct {
+		sync.Mutex
+		last    *Sample
+		advices []Advice
+	}
+}
+
+// NewAdvisor returns an Advisor
+func NewAdvisor(opts Options) *Advisor {
+	opts.adjust()
+	return &Advisor{opts: opts}
+}
+
+// Observe analyzes the changes of the stats since the previous sample, and
+// returns the advices, which are kept until the next sample. Nothing is
+// returned for the first sample. The sample is kept by the Advisor, so the Data
+// of the sample must not be modified afterwards.
+func (a *Advisor) Observe(sample Sample, settings Settings) []Advice {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	last := a.mu.last
+	a.mu.last = &sample
+	if last == nil || !sample.Time.After(last.Time) {
+		a.mu.advices = nil
+		return nil
+	}
+
+	w := window{
+		duration: sample.Time.Sub(last.Time),
+		settings: settings,
+		logDB:    newDelta(last.LogDB, sample.LogDB),
+		data:     make(map[uint64]delta, len(sample.Data)),
+	}
+	for group, cur := range sample.Data {
+		w.data[group] = newDelta(last.Data[group], cur)
+	}
+
+	var advices []Advice
+	for _, fn := range rules {
+		advices = append(advices, fn(a.opts, w)...)
+	}
+	sort.SliceStable(advices, func(i, j int) bool {
+		if advices[i].Rule != advices[j].Rule {
+			return advices[i].Rule < advices[j].Rule
+		}
+		return advices[i].Group < advices[j].Group
+	})
+	a.mu.advices = advices
+	return advices
+}
+
+// Advices returns the advices of the last sample
+func (a *Advisor) Advices() []Advice {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Advice(nil), a.mu.advices...)
+}
+
+// groupCommitRule advises the group commit if the WAL of the LogDB is synced
+// too frequently with the small batches, the fsyncs of the appends of the
+// shards are merged by the group commit.
+func groupCommitRule(opts Options, w window) []Advice {
+	if w.settings.GroupCommitWindow > 0 || w.logDB.walSyncs == 0 {
+		return nil
+	}
+	rate := float64(w.logDB.walSyncs) / w.duration.Seconds()
+	batch := w.logDB.writtenBytes / w.logDB.walSyncs
+	if rate < opts.HighSyncRate || batch >= opts.SmallSyncBytes {
+		return nil
+	}
+	return []Advice{{
+		Rule:        "group-commit",
+		Key:         "raft.raft-log.group-commit-window",
+		Current:     w.settings.GroupCommitWindow.String(),
+		Recommended: recommendedGroupCommitWindow.String(),
+		Reason: fmt.Sprintf("the WAL of the LogDB is synced %.0f times per second with %s per fsync",
+			rate, units.BytesSize(float64(batch))),
+	}}
+}
+
+// walDeviceRule advises moving the WAL to another device if the WAL shares the
+// device with the data storages which are stalled or behind the compactions,
+// the WAL fsyncs on the critical path are slowed down by the flushes and the
+// compactions.
+func walDeviceRule(opts Options, w window) []Advice {
+	if w.settings.WALDir != "" || w.logDB.walSyncs == 0 {
+		return nil
+	}
+	var reasons []string
+	for _, group := range sortedGroups(w.data) {
+		d := w.data[group]
+		if d.stalls > 0 {
+			reasons = append(reasons, fmt.Sprintf("group %d stalled %d times", group, d.stalls))
+		} else if d.current.CompactionDebt >= opts.HighCompactionDebt {
+			reasons = append(reasons, fmt.Sprintf("group %d has %s compaction debt",
+				group, units.BytesSize(float64(d.current.CompactionDebt))))
+		}
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+	return []Advice{{
+		Rule:        "wal-device",
+		Key:         "storage.wal-dir",
+		Recommended: "a directory on another device",
+		Reason:      fmt.Sprintf("the WAL shares the device with the busy data storages: %v", reasons),
+	}}
+}
+
+// memTableRule advises raising the memtable size of the storages whose writes
+// are stalled while the L0 is not deep, i.e. the writes are stalled by the
+// memtables waiting to be flushed.
+func memTableRule(opts Options, w window) []Advice {
+	var advices []Advice
+	check := func(group uint64, name string, d delta) {
+		ratio := d.stalled.Seconds() / w.duration.Seconds()
+		if d.stalls == 0 || ratio < opts.HighStallRatio ||
+			d.current.L0Files >= opts.HighL0Files || d.current.MemTableSize == 0 {
+			return
+		}
+		advices = append(advices, Advice{
+			Rule:        "memtable-size",
+			Group:       group,
+			Key:         "pebble.Options.MemTableSize",
+			Current:     units.BytesSize(float64(d.current.MemTableSize)),
+			Recommended: units.BytesSize(float64(2 * d.current.MemTableSize)),
+			Reason: fmt.Sprintf("the writes of the %s are stalled %d times, %.1f%% of the time",
+				name, d.stalls, ratio*100),
+		})
+	}
+	check(0, "LogDB", w.logDB)
+	for _, group := range sortedGroups(w.data) {
+		check(group, fmt.Sprintf("data storage of group %d", group), w.data[group])
+	}
+	return advices
+}
+
+// compactionRule advises raising the compaction concurrency of the data
+// storages whose compactions can not keep up with the writes, i.e. the L0 is
+// too deep, or the compaction debt is high and still growing.
+func compactionRule(opts Options, w window) []Advice {
+	var advices []Advice
+	for _, group := range sortedGroups(w.data) {
+		d := w.data[group]
+		var reason string
+		switch {
+		case d.current.L0Files >= opts.HighL0Files:
+			reason = fmt.Sprintf("%d files in the L0", d.current.L0Files)
+		case d.current.CompactionDebt >= opts.HighCompactionDebt && d.debtGrowing:
+			reason = fmt.Sprintf("the compaction debt grows to %s",
+				units.BytesSize(float64(d.current.CompactionDebt)))
+		default:
+			continue
+		}
+		current := d.current.MaxConcurrentCompactions
+		if current == 0 {
+			current = 1
+		}
+		advices = append(advices, Advice{
+			Rule:        "compaction-concurrency",
+			Group:       group,
+			Key:         "pebble.Options.MaxConcurrentCompactions",
+			Current:     fmt.Sprintf("%d", d.current.MaxConcurrentCompactions),
+			Recommended: fmt.Sprintf("%d", 2*current),
+			Reason:      fmt.Sprintf("the compactions of group %d are behind: %s", group, reason),
+		})
+	}
+	return advices
+}
+
+func sortedGroups(data map[uint64]delta) []uint64 {
+	groups := make([]uint64, 0, len(data))
+	for group := range data {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i] < groups[j] })
+	return groups
+}
@@ -0,0 +1,142 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/matrixorigin/matrixcube/storage/stats"
+)
+
+func getRules(advices []Advice) []string {
+	var values []string
+	for _, a := range advices {
+		values = append(values, a.Rule)
+	}
+	return values
+}
+
+func TestObserveFirstSample(t *testing.T) {
+	a := NewAdvisor(Options{})
+	assert.Empty(t, a.Observe(Sample{Time: time.Now(), LogDB: stats.Stats{WALSyncs: 1000000}}, Settings{}))
+	assert.Empty(t, a.Advices())
+}
+
+func TestGroupCommitRule(t *testing.T) {
+	now := time.Now()
+	a := NewAdvisor(Options{})
+	a.Observe(Sample{Time: now}, Settings{})
+
+	// 1000 fsyncs per second with 1KB per fsync
+	sample := Sample{
+		Time:  now.Add(time.Second * 10),
+		LogDB: stats.Stats{WALSyncs: 10000, WrittenBytes: 10000 * 1024},
+	}
+	advices := a.Observe(sample, Settings{WALDir: "/wal"})
+	require.Equal(t, []string{"group-commit"}, getRules(advices))
+	assert.Equal(t, "raft.raft-log.group-commit-window", advices[0].Key)
+	assert.Equal(t, "1ms", advices[0].Recommended)
+	assert.Equal(t, advices, a.Advices())
+
+	// the group commit is enabled
+	a = NewAdvisor(Options{})
+	a.Observe(Sample{Time: now}, Settings{})
+	assert.Empty(t, a.Observe(sample, Settings{WALDir: "/wal", GroupCommitWindow: time.Millisecond}))
+
+	// large batches
+	a = NewAdvisor(Options{})
+	a.Observe(Sample{Time: now}, Settings{})
+	sample.LogDB.WrittenBytes = 10000 * 1024 * 1024
+	assert.Empty(t, a.Observe(sample, Settings{WALDir: "/wal"}))
+}
+
+func TestWALDeviceRule(t *testing.T) {
+	now := time.Now()
+	a := NewAdvisor(Options{})
+	a.Observe(Sample{Time: now}, Settings{})
+
+	sample := Sample{
+		Time:  now.Add(time.Minute),
+		LogDB: stats.Stats{WALSyncs: 10, WrittenBytes: 10 * 1024 * 1024},
+		Data: map[uint64]stats.Stats{
+			1: {WriteStalls: 1, WriteStallNanos: uint64(time.Millisecond)},
+			2: {CompactionDebt: defaultHighCompactionDebt},
+			3: {},
+		},
+	}
+	advices := a.Observe(sample, Settings{GroupCommitWindow: time.Millisecond})
+	require.Equal(t, []string{"compaction-concurrency", "wal-device"}, getRules(advices))
+	assert.Equal(t, "storage.wal-dir", advices[1].Key)
+	assert.Contains(t, advices[1].Reason, "group 1 stalled 1 times")
+	assert.Contains(t, advices[1].Reason, "group 2 has")
+
+	// the WAL is on another device
+	sample.Time = sample.Time.Add(time.Minute)
+	sample.LogDB.WALSyncs += 10
+	assert.Empty(t, a.Observe(sample, Settings{WALDir: "/wal", GroupCommitWindow: time.Millisecond}))
+}
+
+func TestMemTableRule(t *testing.T) {
+	now := time.Now()
+	a := NewAdvisor(Options{})
+	a.Observe(Sample{Time: now}, Settings{})
+
+	sample := Sample{
+		Time: now.Add(time.Minute),
+		LogDB: stats.Stats{
+			WriteStalls:     1,
+			WriteStallNanos: uint64(time.Millisecond),
+			MemTableSize:    64 * 1024 * 1024,
+		},
+		Data: map[uint64]stats.Stats{
+			// stalled 6s in 1m
+			1: {WriteStalls: 3, WriteStallNanos: uint64(6 * time.Second), MemTableSize: 64 * 1024 * 1024},
+			// stalled by the L0
+			2: {WriteStalls: 3, WriteStallNanos: uint64(6 * time.Second), MemTableSize: 64 * 1024 * 1024,
+				L0Files: defaultHighL0Files},
+		},
+	}
+	advices := a.Observe(sample, Settings{WALDir: "/wal"})
+	require.Equal(t, []string{"compaction-concurrency", "memtable-size"}, getRules(advices))
+	assert.Equal(t, uint64(2), advices[0].Group)
+	assert.Equal(t, uint64(1), advices[1].Group)
+	assert.Equal(t, "pebble.Options.MemTableSize", advices[1].Key)
+	assert.Equal(t, "64MiB", advices[1].Current)
+	assert.Equal(t, "128MiB", advices[1].Recommended)
+}
+
+func TestCompactionRule(t *testing.T) {
+	now := time.Now()
+	a := NewAdvisor(Options{HighCompactionDebt: 1024})
+	a.Observe(Sample{Time: now, Data: map[uint64]stats.Stats{1: {CompactionDebt: 2048}}}, Settings{})
+
+	// the debt is high but not growing
+	sample := Sample{
+		Time: now.Add(time.Minute),
+		Data: map[uint64]stats.Stats{1: {CompactionDebt: 2048, MaxConcurrentCompactions: 2}},
+	}
+	assert.Empty(t, a.Observe(sample, Settings{WALDir: "/wal"}))
+
+	sample.Time = sample.Time.Add(time.Minute)
+	sample.Data = map[uint64]stats.Stats{1: {CompactionDebt: 4096, MaxConcurrentCompactions: 2}}
+	advices := a.Observe(sample, Settings{WALDir: "/wal"})
+	require.Equal(t, []string{"compaction-concurrency"}, getRules(advices))
+	assert.Equal(t, "pebble.Options.MaxConcurrentCompactions", advices[0].Key)
+	assert.Equal(t, "2", advices[0].Current)
+	assert.Equal(t, "4", advices[0].Recommended)
+}
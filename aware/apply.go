@@ -0,0 +1,53 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aware
+
+import (
+	"github.com/matrixorigin/matrixcube/pb/hlcpb"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+// ApplyCommand the write command applied to the data storage
+type ApplyCommand struct {
+	// CmdType the custom type of the command
+	CmdType uint64
+	// Key the route key of the command
+	Key []byte
+}
+
+// ApplyResult the result of a write raft log applied to the data storage of a
+// replica on the current store. It is reported by all replicas of the shard,
+// not only the leader.
+type ApplyResult struct {
+	// Shard the shard which the raft log applied to
+	Shard metapb.Shard
+	// Index the index of the applied raft log
+	Index uint64
+	// Timestamp the HLC timestamp assigned by the leader when the raft log proposed
+	Timestamp hlcpb.Timestamp
+	// Commands all write commands of the raft log, in the proposed order
+	Commands []ApplyCommand
+	// WrittenKeys the number of keys written
+	WrittenKeys uint64
+	// WrittenBytes the approximate number of bytes written, set by the data storage
+	WrittenBytes uint64
+	// DiffBytes the approximate diff of the bytes stored in the shard, set by the
+	// data storage
+	DiffBytes int64
+}
+
+// ApplyResultHandler handles the apply result. It is called in the apply path
+// of the replica, so it should not be blocked, and the Commands must not be
+// retained after it returned.
+type ApplyResultHandler func(ApplyResult)
@@ -0,0 +1,61 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aware
+
+import (
+	"go.etcd.io/etcd/raft/v3/raftpb"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+// WALEntry the committed raft log of a shard, shipped before it's applied to the
+// data storage of the replica on the current store.
+type WALEntry struct {
+	// Shard the shard which the raft log committed to
+	Shard metapb.Shard
+	// Index the index of the raft log
+	Index uint64
+	// Term the term of the raft log
+	Term uint64
+	// Type the type of the raft log, the Data of the normal raft log is the
+	// marshaled rpcpb.RequestBatch, and the Data of the config change raft log
+	// is the marshaled raftpb.ConfChangeV2.
+	Type raftpb.EntryType
+	// Data the payload of the raft log, must not be retained after shipped
+	Data []byte
+}
+
+// WALHook ships the committed raft logs of the shards in a shard group to an
+// external durability system, e.g. for the cross-cluster replication. The noop
+// raft logs are not shipped, and the raft logs included in the snapshots
+// received by the replica are not shipped either. A raft log may be shipped
+// more than once after the store restarted, the Index can be used to dedup.
+type WALHook struct {
+	// Ship ships the raft log. In the sync mode, it's called in the apply path
+	// of the replica, the raft log is applied only after it returned nil, and
+	// it's called again at the next raft tick if an error returned, the writes
+	// to the shard are rejected with ServerIsBusy until shipped. In the async
+	// mode, it's called by a background goroutine of the shard group in the
+	// commit order of each shard, and it's called again after the retry interval
+	// if an error returned.
+	Ship func(WALEntry) error
+	// Async ships the raft logs in the background, the raft logs are applied
+	// without waiting for them shipped.
+	Async bool
+	// MaxPendingEntries the max number of the raft logs of the shard group waiting
+	// to be shipped in the async mode. The writes to the shards of the group are
+	// rejected with ServerIsBusy once it's reached, and the apply is paused until
+	// the raft logs can be queued. 1024 if not set.
+	MaxPendingEntries int
+}
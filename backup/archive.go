@@ -0,0 +1,133 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+// ArchiveName returns the name of the backup which the archived data of the
+// shard is in. An archive is a backup of a single shard, so it's verified and
+// read by the same manifest and snapshot file format.
+func ArchiveName(shardID uint64) string {
+	return fmt.Sprintf("archive-%d", shardID)
+}
+
+// Archive writes the data of the shard into its archive, ErrBackupExists is
+// returned if the shard is already archived. The shard should stop serving the
+// writes before it's archived, the data is captured at the applied index of the
+// shard when its snapshot is created.
+func (m *Manager) Archive(ctx context.Context, shard metapb.Shard,
+	ds storage.ShardBackuper) (ShardBackup, error) {
+	if err := ctx.Err(); err != nil {
+		return ShardBackup{}, err
+	}
+	name := ArchiveName(shard.ID)
+	dir := m.backupDir(name)
+	if _, err := m.opts.FS.Stat(m.opts.FS.PathJoin(dir, manifestFile)); err == nil {
+		return ShardBackup{}, fmt.Errorf("%w: %s", ErrBackupExists, name)
+	} else if !vfs.IsNotExist(err) {
+		return ShardBackup{}, err
+	}
+	if err := m.opts.FS.MkdirAll(dir, 0755); err != nil {
+		return ShardBackup{}, err
+	}
+
+	archived, err := m.doArchive(name, shard, ds)
+	if err != nil {
+		m.logger.Error("fail to archive",
+			log.ShardField("shard", shard),
+			zap.Error(err))
+		if err := m.opts.FS.RemoveAll(dir); err != nil {
+			m.logger.Error("fail to remove the incomplete archive",
+				zap.String("name", name),
+				zap.Error(err))
+		}
+		return ShardBackup{}, err
+	}
+	m.logger.Info("shard archived",
+		log.ShardField("shard", archived.Shard),
+		zap.Uint64("applied-index", archived.AppliedIndex),
+		zap.Uint64("keys", archived.KeyCount))
+	return archived, nil
+}
+
+func (m *Manager) doArchive(name string, shard metapb.Shard,
+	ds storage.ShardBackuper) (ShardBackup, error) {
+	archived, err := m.backupShard(name, Manifest{}, shardSource{shard: shard, ds: ds})
+	if err != nil {
+		return ShardBackup{}, err
+	}
+	return archived, writeManifest(m.opts.FS,
+		m.opts.FS.PathJoin(m.backupDir(name), manifestFile), Manifest{
+			FormatVersion: manifestFormatVersion,
+			Name:          name,
+			CreatedAt:     time.Now(),
+			Shards:        []ShardBackup{archived},
+		})
+}
+
+// GetArchive returns the archived shard, the shard descriptor in it is the
+// shard before it's archived.
+func (m *Manager) GetArchive(shardID uint64) (ShardBackup, error) {
+	manifest, err := m.ReadManifest(ArchiveName(shardID))
+	if err != nil {
+		return ShardBackup{}, err
+	}
+	if len(manifest.Shards) != 1 || manifest.Shards[0].Shard.ID != shardID {
+		return ShardBackup{}, fmt.Errorf("%w: archive of shard %d has unexpected shards",
+			ErrCorruptedBackup, shardID)
+	}
+	return manifest.Shards[0], nil
+}
+
+// ReadArchive verifies the archive of the shard and then passes the key-value
+// pairs in it to the fn, the keys are the origin keys. The key and the value
+// are only valid in the fn.
+func (m *Manager) ReadArchive(ctx context.Context, shardID uint64,
+	ds storage.ShardBackuper, fn func(key, value []byte) error) (ShardBackup, error) {
+	archived, err := m.GetArchive(shardID)
+	if err != nil {
+		return ShardBackup{}, err
+	}
+	if err := m.verifyShard(archived); err != nil {
+		return ShardBackup{}, err
+	}
+	f, err := m.opts.FS.Open(m.shardFile(archived))
+	if err != nil {
+		return ShardBackup{}, err
+	}
+	defer f.Close()
+	return archived, ds.ReadShardData(shardID, f, m.opts.Stream, func(key, value []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fn(key, value)
+	})
+}
+
+// RemoveArchive removes the archive of the shard, it's called after the data
+// of the archive is loaded into the unarchived shard.
+func (m *Manager) RemoveArchive(shardID uint64) error {
+	return m.opts.FS.RemoveAll(m.backupDir(ArchiveName(shardID)))
+}
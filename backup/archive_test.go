@@ -0,0 +1,90 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+func TestArchiveAndRead(t *testing.T) {
+	ctx := context.Background()
+	s := newTestSource(t)
+	shard := metapb.Shard{ID: 1, Start: []byte("b"), End: []byte("m"), Unique: "u1"}
+	s.addShard(t, shard, 10)
+	for i := 0; i < 26; i++ {
+		key := fmt.Sprintf("%c", 'a'+i)
+		s.set(t, key, key)
+	}
+
+	m := newTestManager()
+	ds := s.ds.(storage.ShardBackuper)
+	archived, err := m.Archive(ctx, shard, ds)
+	require.NoError(t, err)
+	assert.Equal(t, shard, archived.Shard)
+	assert.Equal(t, uint64(10), archived.AppliedIndex)
+	assert.Equal(t, uint64(11), archived.KeyCount)
+	_, err = m.Archive(ctx, shard, ds)
+	assert.True(t, errors.Is(err, ErrBackupExists))
+
+	got, err := m.GetArchive(1)
+	require.NoError(t, err)
+	assert.Equal(t, archived, got)
+
+	var keys []string
+	_, err = m.ReadArchive(ctx, 1, ds, func(key, value []byte) error {
+		assert.Equal(t, key, value)
+		keys = append(keys, string(key))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}, keys)
+
+	require.NoError(t, m.RemoveArchive(1))
+	_, err = m.GetArchive(1)
+	assert.True(t, vfs.IsNotExist(err))
+}
+
+func TestReadCorruptedArchive(t *testing.T) {
+	ctx := context.Background()
+	s := newTestSource(t)
+	shard := metapb.Shard{ID: 1}
+	s.addShard(t, shard, 10)
+	s.set(t, "a", "a")
+
+	fs := vfs.NewMemFS()
+	m := NewManager(Options{FS: fs, Dir: "/backups"})
+	ds := s.ds.(storage.ShardBackuper)
+	archived, err := m.Archive(ctx, shard, ds)
+	require.NoError(t, err)
+
+	f, err := fs.Create(m.shardFile(archived))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("corrupted"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	_, err = m.ReadArchive(ctx, 1, ds, func(key, value []byte) error {
+		return nil
+	})
+	assert.True(t, errors.Is(err, ErrCorruptedBackup))
+}
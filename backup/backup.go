@@ -0,0 +1,375 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+const (
+	defaultConcurrency = 4
+)
+
+var (
+	// ErrBackupExists the backup with the same name already exists
+	ErrBackupExists = errors.New("backup already exists")
+	// ErrIncompleteBackup the backed up shards do not cover the whole key range
+	// of the groups, the backup should be retried later.
+	ErrIncompleteBackup = errors.New("incomplete backup")
+	// ErrCorruptedBackup the manifest or the snapshot files of the backup are
+	// corrupted
+	ErrCorruptedBackup = errors.New("corrupted backup")
+	// ErrInvalidBackupName the name of the backup is empty or has the separator
+	ErrInvalidBackupName = errors.New("invalid backup name")
+)
+
+// Source provides the shards to back up, the `raftstore.Store` is a Source and
+// the shards with the leader on the store are backed up.
+type Source interface {
+	// GetLeaderShards returns the shards to back up
+	GetLeaderShards() []metapb.Shard
+	// DataStorageByGroup returns the data storage of the shard group, it must be
+	// a `storage.ShardBackuper`.
+	DataStorageByGroup(group uint64) storage.DataStorage
+}
+
+// Options the options of the backup manager
+type Options struct {
+	// FS the FS of the backups, e.g. the `vfs.S3FS` writes the backups into the
+	// object storage directly.
+	FS vfs.FS
+	// Dir the directory of the backups in the FS, each backup is in the sub
+	// directory of its name.
+	Dir string
+	// Concurrency the max number of the shards backed up or restored concurrently.
+	// Default is 4.
+	Concurrency int
+	// Stream the options of the snapshot streams of the shards
+	Stream storage.SnapshotStreamOptions
+	// Logger the logger
+	Logger *zap.Logger
+}
+
+func (opts *Options) adjust() {
+	if opts.FS == nil {
+		opts.FS = vfs.Default
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultConcurrency
+	}
+	opts.Logger = log.Adjust(opts.Logger).Named("backup")
+}
+
+// BackupOptions the options of a backup
+type BackupOptions struct {
+	// Base the name of the backup which the incremental backup is based on. The
+	// shards whose applied index and epoch are not changed since the base
+	// backup are not backed up again, their snapshot files in the base backups
+	// are referenced. A full backup is created if it's empty.
+	Base string
+	// Groups the shard groups to back up, all the groups of the shards of the
+	// sources are backed up if it's empty.
+	Groups []uint64
+}
+
+// Manager creates the backups of the shards and restores them. Each shard is
+// backed up by a snapshot at the applied index captured when its snapshot is
+// created, so the backup is consistent per shard.
+type Manager struct {
+	opts   Options
+	logger *zap.Logger
+}
+
+// NewManager returns the backup manager
+func NewManager(opts Options) *Manager {
+	opts.adjust()
+	return &Manager{opts: opts, logger: opts.Logger}
+}
+
+func (m *Manager) backupDir(name string) string {
+	return m.opts.FS.PathJoin(m.opts.Dir, name)
+}
+
+// ReadManifest returns the manifest of the backup
+func (m *Manager) ReadManifest(name string) (Manifest, error) {
+	if err := checkName(name); err != nil {
+		return Manifest{}, err
+	}
+	return readManifest(m.opts.FS, m.opts.FS.PathJoin(m.backupDir(name), manifestFile))
+}
+
+// shardSource is a shard with its data storage
+type shardSource struct {
+	shard metapb.Shard
+	ds    storage.ShardBackuper
+}
+
+// Backup creates the backup of the shards of the sources with the name, the
+// shards of all the stores of the cluster must be covered. ErrIncompleteBackup
+// is returned if the shards do not cover the whole key range of the groups,
+// e.g. the shards are split during the backup.
+func (m *Manager) Backup(ctx context.Context, name string, opts BackupOptions,
+	sources ...Source) (Manifest, error) {
+	if err := checkName(name); err != nil {
+		return Manifest{}, err
+	}
+	dir := m.backupDir(name)
+	if _, err := m.opts.FS.Stat(m.opts.FS.PathJoin(dir, manifestFile)); err == nil {
+		return Manifest{}, fmt.Errorf("%w: %s", ErrBackupExists, name)
+	} else if !vfs.IsNotExist(err) {
+		return Manifest{}, err
+	}
+
+	var base Manifest
+	if opts.Base != "" {
+		var err error
+		if base, err = m.ReadManifest(opts.Base); err != nil {
+			return Manifest{}, err
+		}
+	}
+	targets, err := collectShards(sources, opts.Groups)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	m.logger.Info("begin to backup",
+		zap.String("name", name),
+		zap.String("base", opts.Base),
+		zap.Int("shards", len(targets)))
+	if err := m.opts.FS.MkdirAll(dir, 0755); err != nil {
+		return Manifest{}, err
+	}
+	manifest, err := m.doBackup(ctx, name, base, targets, opts.Groups)
+	if err != nil {
+		m.logger.Error("fail to backup",
+			zap.String("name", name),
+			zap.Error(err))
+		if err := m.opts.FS.RemoveAll(dir); err != nil {
+			m.logger.Error("fail to remove the incomplete backup",
+				zap.String("name", name),
+				zap.Error(err))
+		}
+		return Manifest{}, err
+	}
+	m.logger.Info("backup completed",
+		zap.String("name", name),
+		zap.Int("shards", len(manifest.Shards)))
+	return manifest, nil
+}
+
+func (m *Manager) doBackup(ctx context.Context, name string, base Manifest,
+	targets []shardSource, groups []uint64) (Manifest, error) {
+	manifest := Manifest{
+		FormatVersion: manifestFormatVersion,
+		Name:          name,
+		Base:          base.Name,
+		CreatedAt:     time.Now(),
+		Shards:        make([]ShardBackup, len(targets)),
+	}
+	if err := m.forEach(ctx, len(targets), func(i int) error {
+		shard, err := m.backupShard(name, base, targets[i])
+		manifest.Shards[i] = shard
+		return err
+	}); err != nil {
+		return Manifest{}, err
+	}
+
+	sortShards(manifest.Shards)
+	if err := checkCoverage(manifest.Shards, groups); err != nil {
+		return Manifest{}, err
+	}
+	return manifest, writeManifest(m.opts.FS,
+		m.opts.FS.PathJoin(m.backupDir(name), manifestFile), manifest)
+}
+
+// backupShard writes the snapshot of the shard into the backup, the shard in
+// the base backup is referenced if it's not changed.
+func (m *Manager) backupShard(name string, base Manifest, target shardSource) (ShardBackup, error) {
+	if last, ok := base.getShard(target.shard.ID); ok &&
+		last.Shard.Epoch.Generation == target.shard.Epoch.Generation &&
+		last.Shard.Epoch.ConfigVer == target.shard.Epoch.ConfigVer {
+		index, err := target.ds.GetAppliedIndex(target.shard.ID)
+		if err != nil {
+			return ShardBackup{}, err
+		}
+		if index == last.AppliedIndex {
+			return last, nil
+		}
+	}
+
+	file := fmt.Sprintf("shard-%d.snap", target.shard.ID)
+	f, err := m.opts.FS.Create(m.opts.FS.PathJoin(m.backupDir(name), file))
+	if err != nil {
+		return ShardBackup{}, err
+	}
+	w := newChecksumWriter(f)
+	snap, err := target.ds.BackupShard(target.shard.ID, w, m.opts.Stream)
+	if err != nil {
+		_ = f.Close()
+		return ShardBackup{}, err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return ShardBackup{}, err
+	}
+	if err := f.Close(); err != nil {
+		return ShardBackup{}, err
+	}
+
+	var index metapb.LogIndex
+	if err := index.Unmarshal(snap.AppliedIndexValue); err != nil {
+		return ShardBackup{}, err
+	}
+	return ShardBackup{
+		Shard:        snap.Shard,
+		AppliedIndex: index.Index,
+		Backup:       name,
+		File:         file,
+		Size:         w.size,
+		Checksum:     w.h.Sum32(),
+		KeyCount:     snap.KeyCount,
+		ByteCount:    snap.ByteCount,
+	}, nil
+}
+
+// forEach calls the fn with [0, n) concurrently, the first error is returned
+// and the remaining calls are skipped.
+func (m *Manager) forEach(ctx context.Context, n int, fn func(int) error) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+	setErr := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	sem := make(chan struct{}, m.opts.Concurrency)
+	for i := 0; i < n && !failed(); i++ {
+		select {
+		case <-ctx.Done():
+			setErr(ctx.Err())
+		case sem <- struct{}{}:
+			wg.Add(1)
+			go func(i int) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				if err := fn(i); err != nil {
+					setErr(err)
+				}
+			}(i)
+		}
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// collectShards returns the shards of the groups from the sources, the shard
+// with the latest epoch is used if it's returned by more than one source, e.g.
+// the leader is transferred during the collecting.
+func collectShards(sources []Source, groups []uint64) ([]shardSource, error) {
+	included := func(group uint64) bool {
+		if len(groups) == 0 {
+			return true
+		}
+		for _, g := range groups {
+			if g == group {
+				return true
+			}
+		}
+		return false
+	}
+
+	shards := make(map[uint64]shardSource)
+	var ids []uint64
+	for _, source := range sources {
+		for _, shard := range source.GetLeaderShards() {
+			if !included(shard.Group) {
+				continue
+			}
+			ds, ok := source.DataStorageByGroup(shard.Group).(storage.ShardBackuper)
+			if !ok {
+				return nil, storage.ErrBackupNotSupported
+			}
+			if old, ok := shards[shard.ID]; ok {
+				if !isNewerEpoch(shard.Epoch, old.shard.Epoch) {
+					continue
+				}
+			} else {
+				ids = append(ids, shard.ID)
+			}
+			shards[shard.ID] = shardSource{shard: shard, ds: ds}
+		}
+	}
+
+	targets := make([]shardSource, 0, len(ids))
+	for _, id := range ids {
+		targets = append(targets, shards[id])
+	}
+	return targets, nil
+}
+
+func isNewerEpoch(epoch, than metapb.ShardEpoch) bool {
+	return epoch.Generation > than.Generation ||
+		(epoch.Generation == than.Generation && epoch.ConfigVer > than.ConfigVer)
+}
+
+func checkName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("%w: %q", ErrInvalidBackupName, name)
+	}
+	return nil
+}
+
+// checksumWriter computes the size and the checksum of the written data
+type checksumWriter struct {
+	w    io.Writer
+	h    hash.Hash32
+	size int64
+}
+
+func newChecksumWriter(w io.Writer) *checksumWriter {
+	return &checksumWriter{w: w, h: crc32.NewIEEE()}
+}
+
+func (cw *checksumWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.h.Write(p[:n])
+	cw.size += int64(n)
+	return n, err
+}
@@ -0,0 +1,235 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+	"github.com/matrixorigin/matrixcube/storage/kv"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+type testSource struct {
+	shards []metapb.Shard
+	ds     storage.DataStorage
+}
+
+func newTestSource(t *testing.T) *testSource {
+	kvs := mem.NewStorage()
+	ds := kv.NewKVDataStorage(kv.NewBaseStorage(kvs, vfs.NewMemFS()), executor.NewKVExecutor(kvs))
+	t.Cleanup(func() {
+		assert.NoError(t, ds.Close())
+	})
+	return &testSource{ds: ds}
+}
+
+func (s *testSource) GetLeaderShards() []metapb.Shard {
+	return s.shards
+}
+
+func (s *testSource) DataStorageByGroup(group uint64) storage.DataStorage {
+	return s.ds
+}
+
+// addShard adds the shard with the applied index
+func (s *testSource) addShard(t *testing.T, shard metapb.Shard, index uint64) {
+	s.shards = append(s.shards, shard)
+	s.updateShard(t, shard, index)
+}
+
+func (s *testSource) updateShard(t *testing.T, shard metapb.Shard, index uint64) {
+	require.NoError(t, s.ds.SaveShardMetadata([]metapb.ShardMetadata{{
+		ShardID:  shard.ID,
+		LogIndex: index,
+		Metadata: metapb.ShardLocalState{Shard: shard},
+	}}))
+}
+
+func (s *testSource) set(t *testing.T, key, value string) {
+	kvs := s.ds.(storage.KVStorageWrapper).GetKVStorage()
+	require.NoError(t, kvs.Set(keysutil.EncodeDataKey([]byte(key), nil), []byte(value), false))
+}
+
+func (s *testSource) get(t *testing.T, key string) string {
+	kvs := s.ds.(storage.KVStorageWrapper).GetKVStorage()
+	v, err := kvs.Get(keysutil.EncodeDataKey([]byte(key), nil))
+	require.NoError(t, err)
+	return string(v)
+}
+
+func newTestManager() *Manager {
+	return NewManager(Options{FS: vfs.NewMemFS(), Dir: "/backups", Concurrency: 2})
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	s1, s2 := newTestSource(t), newTestSource(t)
+	shard1 := metapb.Shard{ID: 1, End: []byte("m"), Epoch: metapb.ShardEpoch{Generation: 2}}
+	shard2 := metapb.Shard{ID: 2, Start: []byte("m"), Epoch: metapb.ShardEpoch{Generation: 2}}
+	s1.addShard(t, shard1, 10)
+	s2.addShard(t, shard2, 20)
+	for i := 0; i < 26; i++ {
+		key := fmt.Sprintf("%c", 'a'+i)
+		s1.set(t, key, key)
+		s2.set(t, key, key)
+	}
+
+	m := newTestManager()
+	manifest, err := m.Backup(ctx, "full", BackupOptions{}, s1, s2)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(manifest.Shards))
+	assert.Equal(t, "", manifest.Base)
+	assert.Equal(t, uint64(1), manifest.Shards[0].Shard.ID)
+	assert.Equal(t, uint64(10), manifest.Shards[0].AppliedIndex)
+	assert.Equal(t, uint64(12), manifest.Shards[0].KeyCount)
+	assert.Equal(t, uint64(2), manifest.Shards[1].Shard.ID)
+	assert.Equal(t, uint64(20), manifest.Shards[1].AppliedIndex)
+	assert.Equal(t, uint64(14), manifest.Shards[1].KeyCount)
+
+	read, err := m.ReadManifest("full")
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Shards, read.Shards)
+	_, err = m.Backup(ctx, "full", BackupOptions{}, s1, s2)
+	assert.True(t, errors.Is(err, ErrBackupExists))
+
+	assert.Equal(t, []metapb.Shard{{End: []byte("m")}, {Start: []byte("m")}}, manifest.InitShards())
+	target := newTestSource(t)
+	target.set(t, "a", "stale")
+	_, err = m.Restore(ctx, "full", target.DataStorageByGroup)
+	require.NoError(t, err)
+	for i := 0; i < 26; i++ {
+		key := fmt.Sprintf("%c", 'a'+i)
+		assert.Equal(t, key, target.get(t, key))
+	}
+	// only the data is restored
+	states, err := target.ds.GetInitialStates()
+	require.NoError(t, err)
+	assert.Empty(t, states)
+}
+
+func TestIncrementalBackup(t *testing.T) {
+	ctx := context.Background()
+	s := newTestSource(t)
+	shard1 := metapb.Shard{ID: 1, End: []byte("m")}
+	shard2 := metapb.Shard{ID: 2, Start: []byte("m")}
+	s.addShard(t, shard1, 10)
+	s.addShard(t, shard2, 20)
+	s.set(t, "a", "v1")
+	s.set(t, "x", "v1")
+
+	m := newTestManager()
+	full, err := m.Backup(ctx, "full", BackupOptions{}, s)
+	require.NoError(t, err)
+
+	s.set(t, "x", "v2")
+	s.updateShard(t, shard2, 21)
+	inc1, err := m.Backup(ctx, "inc1", BackupOptions{Base: "full"}, s)
+	require.NoError(t, err)
+	assert.Equal(t, "full", inc1.Base)
+	assert.Equal(t, full.Shards[0], inc1.Shards[0])
+	assert.Equal(t, "inc1", inc1.Shards[1].Backup)
+	assert.Equal(t, uint64(21), inc1.Shards[1].AppliedIndex)
+
+	// the unchanged shards reference the base of the base
+	inc2, err := m.Backup(ctx, "inc2", BackupOptions{Base: "inc1"}, s)
+	require.NoError(t, err)
+	assert.Equal(t, "full", inc2.Shards[0].Backup)
+	assert.Equal(t, "inc1", inc2.Shards[1].Backup)
+	_, err = m.Verify(ctx, "inc2")
+	assert.NoError(t, err)
+
+	target := newTestSource(t)
+	_, err = m.Restore(ctx, "inc2", target.DataStorageByGroup)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", target.get(t, "a"))
+	assert.Equal(t, "v2", target.get(t, "x"))
+
+	_, err = m.Backup(ctx, "inc3", BackupOptions{Base: "missing"}, s)
+	assert.True(t, vfs.IsNotExist(err))
+}
+
+func TestIncompleteBackup(t *testing.T) {
+	ctx := context.Background()
+	s := newTestSource(t)
+	s.addShard(t, metapb.Shard{ID: 1, End: []byte("m")}, 10)
+	s.addShard(t, metapb.Shard{ID: 2, Start: []byte("n")}, 10)
+
+	m := newTestManager()
+	_, err := m.Backup(ctx, "b1", BackupOptions{}, s)
+	assert.True(t, errors.Is(err, ErrIncompleteBackup))
+	// the incomplete backup is removed
+	_, err = m.opts.FS.Stat("/backups/b1")
+	assert.True(t, vfs.IsNotExist(err))
+
+	_, err = m.Backup(ctx, "b1", BackupOptions{Groups: []uint64{1}}, s)
+	assert.True(t, errors.Is(err, ErrIncompleteBackup))
+
+	_, err = m.Backup(ctx, "a/b", BackupOptions{}, s)
+	assert.True(t, errors.Is(err, ErrInvalidBackupName))
+}
+
+func TestRestoreCorruptedBackup(t *testing.T) {
+	ctx := context.Background()
+	s := newTestSource(t)
+	s.addShard(t, metapb.Shard{ID: 1}, 10)
+	s.set(t, "a", "v1")
+
+	m := newTestManager()
+	manifest, err := m.Backup(ctx, "full", BackupOptions{}, s)
+	require.NoError(t, err)
+
+	f, err := m.opts.FS.OpenForAppend(m.shardFile(manifest.Shards[0]))
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = m.Verify(ctx, "full")
+	assert.True(t, errors.Is(err, ErrCorruptedBackup))
+	target := newTestSource(t)
+	_, err = m.Restore(ctx, "full", target.DataStorageByGroup)
+	assert.True(t, errors.Is(err, ErrCorruptedBackup))
+	assert.Equal(t, "", target.get(t, "a"))
+}
+
+func TestCollectShards(t *testing.T) {
+	s1, s2 := newTestSource(t), newTestSource(t)
+	s1.shards = []metapb.Shard{
+		{ID: 1, Epoch: metapb.ShardEpoch{Generation: 1, ConfigVer: 2}},
+		{ID: 2, Group: 1},
+	}
+	s2.shards = []metapb.Shard{
+		{ID: 1, Epoch: metapb.ShardEpoch{Generation: 1, ConfigVer: 3}},
+	}
+	targets, err := collectShards([]Source{s1, s2}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(targets))
+	assert.Equal(t, uint64(3), targets[0].shard.Epoch.ConfigVer)
+
+	targets, err = collectShards([]Source{s1, s2}, []uint64{1})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(targets))
+	assert.Equal(t, uint64(2), targets[0].shard.ID)
+}
@@ -0,0 +1,188 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+const (
+	// manifestFile the name of the manifest file in the directory of the backup,
+	// it's written after all the shards are backed up, so the backup without
+	// the manifest is incomplete.
+	manifestFile = "MANIFEST"
+	// manifestFormatVersion the version of the manifest format
+	manifestFormatVersion = 1
+)
+
+// Manifest describes a backup, the data of the shards may be in the base
+// backups if the backup is incremental.
+type Manifest struct {
+	// FormatVersion the version of the manifest format
+	FormatVersion uint32 `json:"formatVersion"`
+	// Name the name of the backup
+	Name string `json:"name"`
+	// Base the name of the backup which the incremental backup is based on, empty
+	// for the full backup
+	Base string `json:"base,omitempty"`
+	// CreatedAt the time the backup is created
+	CreatedAt time.Time `json:"createdAt"`
+	// Shards the backed up shards ordered by the group and the start key
+	Shards []ShardBackup `json:"shards"`
+}
+
+// ShardBackup describes a backed up shard
+type ShardBackup struct {
+	// Shard the shard descriptor captured by the snapshot, with the range and
+	// the epoch of the shard
+	Shard metapb.Shard `json:"shard"`
+	// AppliedIndex the applied index of the shard captured by the snapshot
+	AppliedIndex uint64 `json:"appliedIndex"`
+	// Backup the name of the backup which the snapshot file is in, it's a base
+	// backup if the shard is not changed since it
+	Backup string `json:"backup"`
+	// File the name of the snapshot file in the directory of the Backup
+	File string `json:"file"`
+	// Size and Checksum the size and the crc32 checksum of the snapshot file
+	Size     int64  `json:"size"`
+	Checksum uint32 `json:"checksum"`
+	// KeyCount and ByteCount the stats of the key-value pairs of the shard
+	KeyCount  uint64 `json:"keyCount"`
+	ByteCount uint64 `json:"byteCount"`
+}
+
+// InitShards returns the shards used by the `Customize.CustomInitShardsFactory`
+// to bootstrap the fresh cluster the backup restored into. The shards have the
+// same ranges as the backup and the IDs, the epochs and the replicas are
+// allocated by the fresh cluster. The shard of the built-in system group is
+// excluded, it's always created by the bootstrap if the system group enabled.
+func (m Manifest) InitShards() []metapb.Shard {
+	shards := make([]metapb.Shard, 0, len(m.Shards))
+	for _, s := range m.Shards {
+		if s.Shard.Group == config.SystemGroup {
+			continue
+		}
+		shards = append(shards, metapb.Shard{
+			Start:      s.Shard.Start,
+			End:        s.Shard.End,
+			Group:      s.Shard.Group,
+			Unique:     s.Shard.Unique,
+			RuleGroups: s.Shard.RuleGroups,
+			Labels:     s.Shard.Labels,
+		})
+	}
+	return shards
+}
+
+func (m Manifest) getShard(id uint64) (ShardBackup, bool) {
+	for _, s := range m.Shards {
+		if s.Shard.ID == id {
+			return s, true
+		}
+	}
+	return ShardBackup{}, false
+}
+
+// sortShards sorts the shards by the group and the start key
+func sortShards(shards []ShardBackup) {
+	sort.Slice(shards, func(i, j int) bool {
+		if shards[i].Shard.Group != shards[j].Shard.Group {
+			return shards[i].Shard.Group < shards[j].Shard.Group
+		}
+		return bytes.Compare(shards[i].Shard.Start, shards[j].Shard.Start) < 0
+	})
+}
+
+// checkCoverage checks the sorted shards of each group cover the whole key
+// range without any gap or overlap. The shards may be split or merged during
+// the backup, or the leaders of some shards are not on any of the stores.
+func checkCoverage(shards []ShardBackup, groups []uint64) error {
+	var last *metapb.Shard
+	found := make(map[uint64]struct{})
+	for i := range shards {
+		shard := &shards[i].Shard
+		found[shard.Group] = struct{}{}
+		if last == nil || last.Group != shard.Group {
+			if last != nil && len(last.End) > 0 {
+				return fmt.Errorf("%w: group %d missing range [%+v, )", ErrIncompleteBackup, last.Group, last.End)
+			}
+			if len(shard.Start) > 0 {
+				return fmt.Errorf("%w: group %d missing range [, %+v)", ErrIncompleteBackup, shard.Group, shard.Start)
+			}
+		} else if !bytes.Equal(last.End, shard.Start) {
+			return fmt.Errorf("%w: group %d range [%+v, %+v) of shard %d not follow the shard %d",
+				ErrIncompleteBackup, shard.Group, shard.Start, shard.End, shard.ID, last.ID)
+		}
+		last = shard
+	}
+	if last != nil && len(last.End) > 0 {
+		return fmt.Errorf("%w: group %d missing range [%+v, )", ErrIncompleteBackup, last.Group, last.End)
+	}
+	for _, g := range groups {
+		if _, ok := found[g]; !ok {
+			return fmt.Errorf("%w: group %d not found", ErrIncompleteBackup, g)
+		}
+	}
+	return nil
+}
+
+func writeManifest(fs vfs.FS, file string, m Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := fs.Create(file)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func readManifest(fs vfs.FS, file string) (Manifest, error) {
+	var m Manifest
+	f, err := fs.Open(file)
+	if err != nil {
+		return m, err
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("%w: %v", ErrCorruptedBackup, err)
+	}
+	if m.FormatVersion == 0 || m.FormatVersion > manifestFormatVersion {
+		return m, fmt.Errorf("%w: unsupported manifest format version %d",
+			ErrCorruptedBackup, m.FormatVersion)
+	}
+	return m, nil
+}
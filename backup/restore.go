@@ -0,0 +1,115 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/storage"
+)
+
+// Verify checks the size and the checksum of all the snapshot files of the
+// backup, including the ones in the base backups. ErrCorruptedBackup is
+// returned if any of them is corrupted.
+func (m *Manager) Verify(ctx context.Context, name string) (Manifest, error) {
+	manifest, err := m.ReadManifest(name)
+	if err != nil {
+		return Manifest{}, err
+	}
+	return manifest, m.forEach(ctx, len(manifest.Shards), func(i int) error {
+		return m.verifyShard(manifest.Shards[i])
+	})
+}
+
+// Restore writes the data of the shards in the backup into the data storages
+// of the store which bootstraps the fresh cluster, it must be called before the
+// store is started, e.g. start the fresh cluster with a single store and add
+// the other stores after the backup is restored. The cluster must be
+// bootstrapped with `Manifest.InitShards` of the restored backup as the init
+// shards, so the shard metadata is rebuilt with the same ranges of the backup,
+// and the data is replicated to the other stores by the raft snapshots.
+//
+// The snapshot files are verified before the data is written.
+func (m *Manager) Restore(ctx context.Context, name string,
+	dataStorage func(group uint64) storage.DataStorage) (Manifest, error) {
+	manifest, err := m.ReadManifest(name)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if err := checkCoverage(manifest.Shards, nil); err != nil {
+		return Manifest{}, fmt.Errorf("%w: %v", ErrCorruptedBackup, err)
+	}
+
+	m.logger.Info("begin to restore",
+		zap.String("name", name),
+		zap.Int("shards", len(manifest.Shards)))
+	if err := m.forEach(ctx, len(manifest.Shards), func(i int) error {
+		shard := manifest.Shards[i]
+		ds, ok := dataStorage(shard.Shard.Group).(storage.ShardBackuper)
+		if !ok {
+			return storage.ErrBackupNotSupported
+		}
+		return m.restoreShard(ds, shard)
+	}); err != nil {
+		m.logger.Error("fail to restore",
+			zap.String("name", name),
+			zap.Error(err))
+		return Manifest{}, err
+	}
+	m.logger.Info("restore completed",
+		zap.String("name", name))
+	return manifest, nil
+}
+
+func (m *Manager) restoreShard(ds storage.ShardBackuper, shard ShardBackup) error {
+	if err := m.verifyShard(shard); err != nil {
+		return err
+	}
+	f, err := m.opts.FS.Open(m.shardFile(shard))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return ds.RestoreShardData(shard.Shard.ID, shard.Shard, f, m.opts.Stream)
+}
+
+func (m *Manager) verifyShard(shard ShardBackup) error {
+	if err := checkName(shard.Backup); err != nil {
+		return fmt.Errorf("%w: %v", ErrCorruptedBackup, err)
+	}
+	f, err := m.opts.FS.Open(m.shardFile(shard))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := crc32.NewIEEE()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	if size != shard.Size || h.Sum32() != shard.Checksum {
+		return fmt.Errorf("%w: snapshot file of shard %d in backup %s mismatch",
+			ErrCorruptedBackup, shard.Shard.ID, shard.Backup)
+	}
+	return nil
+}
+
+func (m *Manager) shardFile(shard ShardBackup) string {
+	return m.opts.FS.PathJoin(m.backupDir(shard.Backup), shard.File)
+}
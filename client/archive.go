@@ -0,0 +1,65 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+
+	"github.com/matrixorigin/matrixcube/backup"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util/buf"
+)
+
+const (
+	loadArchiveBatchKeys  = 256
+	loadArchiveBatchBytes = 4 * buf.MB
+)
+
+// LoadArchive writes the key-value pairs in the archive of the shard by the
+// KVClient, it's used to load the data into the shard recreated by
+// `raftstore.Store.UnarchiveShard`. The KVClient must be created with the
+// shard group of the archived shard, and the ds is only used to read the
+// archive. The writes are replicated by raft, and the loading is idempotent, so
+// it can be retried on error. The archive is kept, it's removed by
+// `backup.Manager.RemoveArchive` once the data is loaded.
+func LoadArchive(ctx context.Context, kv KVClient, m *backup.Manager,
+	shardID uint64, ds storage.ShardBackuper) error {
+	var keys, values [][]byte
+	size := 0
+	flush := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+		f := kv.BatchSet(ctx, keys, values)
+		defer f.Close()
+		if err := f.GetError(); err != nil {
+			return err
+		}
+		keys, values, size = nil, nil, 0
+		return nil
+	}
+
+	if _, err := m.ReadArchive(ctx, shardID, ds, func(key, value []byte) error {
+		keys = append(keys, append([]byte(nil), key...))
+		values = append(values, append([]byte(nil), value...))
+		size += len(key) + len(value)
+		if len(keys) >= loadArchiveBatchKeys || size >= loadArchiveBatchBytes {
+			return flush()
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return flush()
+}
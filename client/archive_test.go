@@ -0,0 +1,90 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/matrixorigin/matrixcube/backup"
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/raftstore"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+func TestArchiveAndUnarchive(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode.")
+		return
+	}
+	defer leaktest.AfterTest(t)()
+
+	c := raftstore.NewSingleTestClusterStore(t, raftstore.WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+		cfg.Customize.CustomInitShardsFactory = func() []metapb.Shard {
+			return []metapb.Shard{{End: []byte("k5"), Unique: "u1"}, {Start: []byte("k5"), Unique: "u2"}}
+		}
+	}))
+	c.Start()
+	defer c.Stop()
+	c.WaitLeadersByCount(2, time.Minute)
+
+	s := NewClient(Cfg{Store: c.GetStore(0)})
+	assert.NoError(t, s.Start())
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+	kv := NewKVClient(s, 0, rpcpb.SelectLeader)
+	defer kv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for i := 0; i < 10; i++ {
+		f := kv.Set(ctx, []byte(fmt.Sprintf("k%d", i)), []byte(fmt.Sprintf("v%d", i)))
+		require.NoError(t, f.GetError())
+		f.Close()
+	}
+
+	store := c.GetStore(0)
+	shard := c.GetStore(0).GetRouter().SelectShardByKey(0, []byte("k1"))
+	m := backup.NewManager(backup.Options{FS: vfs.NewMemFS(), Dir: "/archives"})
+	require.NoError(t, store.ArchiveShard(ctx, shard.ID, m))
+	c.WaitRemovedByShardID(shard.ID, time.Minute)
+
+	created, err := store.UnarchiveShard(ctx, shard.ID, m)
+	require.NoError(t, err)
+	assert.NotEqual(t, shard.ID, created.ID)
+	assert.Equal(t, shard.Start, created.Start)
+	assert.Equal(t, shard.End, created.End)
+	assert.Equal(t, "u1", created.Unique)
+
+	ds := store.DataStorageByGroup(0).(storage.ShardBackuper)
+	require.NoError(t, LoadArchive(ctx, kv, m, shard.ID, ds))
+	require.NoError(t, m.RemoveArchive(shard.ID))
+	for i := 0; i < 10; i++ {
+		f := kv.Get(ctx, []byte(fmt.Sprintf("k%d", i)))
+		resp, err := f.GetKVGetResponse()
+		f.Close()
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("v%d", i), string(resp.Value))
+	}
+}
@@ -15,7 +15,9 @@ package client
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/fagongzi/util/hack"
 	"github.com/fagongzi/util/protoc"
@@ -28,6 +30,16 @@ import (
 	"go.uber.org/zap"
 )
 
+var (
+	// ErrReplicaNotFound the shard or the store of the replica is not found in the router
+	ErrReplicaNotFound = errors.New("replica not found")
+	// ErrIncrementFailed the key holds a value which is not a counter, or the counter overflows
+	ErrIncrementFailed = errors.New("increment failed")
+	// ErrEmptyValue the value is encoded as empty by the ValueCodec, which can
+	// not be distinguished from the missing key
+	ErrEmptyValue = errors.New("empty value")
+)
+
 var (
 	futurePool = sync.Pool{
 		New: func() interface{} {
@@ -50,19 +62,19 @@ func releaseFuture(f *Future) {
 }
 
 // Option client option
-type Option func(*rpcpb.Request)
+type Option func(*Future)
 
 // WithShardGroup set shard group to execute the request
 func WithShardGroup(group uint64) Option {
-	return func(req *rpcpb.Request) {
-		req.Group = group
+	return func(f *Future) {
+		f.req.Group = group
 	}
 }
 
 // WithRouteKey use the specified key to route request
 func WithRouteKey(key []byte) Option {
-	return func(req *rpcpb.Request) {
-		req.Key = key
+	return func(f *Future) {
+		f.req.Key = key
 	}
 }
 
@@ -71,29 +83,53 @@ func WithRouteKey(key []byte) Option {
 // to re-route according to KeysRange after the data management scope of the Shard has
 // changed, or if it returns the specified error.
 func WithKeysRange(from, to []byte) Option {
-	return func(req *rpcpb.Request) {
-		req.KeysRange = &rpcpb.Range{From: from, To: to}
+	return func(f *Future) {
+		f.req.KeysRange = &rpcpb.Range{From: from, To: to}
 	}
 }
 
 // WithShard use the specified shard to route request
 func WithShard(shard uint64) Option {
-	return func(req *rpcpb.Request) {
-		req.ToShard = shard
+	return func(f *Future) {
+		f.req.ToShard = shard
 	}
 }
 
 // WithReplicaSelectPolicy set the ReplicaSelectPolicy for request, default is SelectLeader
 func WithReplicaSelectPolicy(policy rpcpb.ReplicaSelectPolicy) Option {
-	return func(req *rpcpb.Request) {
-		req.ReplicaSelectPolicy = policy
+	return func(f *Future) {
+		f.req.ReplicaSelectPolicy = policy
 	}
 }
 
+// WithLearnerRead routes the read request to the non-voting read replicas of the
+// shard, the request is served by any replica if the shard has no learner.
+func WithLearnerRead() Option {
+	return WithReplicaSelectPolicy(rpcpb.SelectLearner)
+}
+
+// WithFollowerRead routes the read request to any replica of the shard, the
+// follower serves the read after confirming the read index with the leader and
+// applying the logs to the read index, so the read is linearizable.
+func WithFollowerRead() Option {
+	return WithReplicaSelectPolicy(rpcpb.SelectRandom)
+}
+
 // WithLease set the Lease for request
 func WithLease(lease *metapb.EpochLease) Option {
-	return func(req *rpcpb.Request) {
-		req.Lease = lease
+	return func(f *Future) {
+		f.req.Lease = lease
+	}
+}
+
+// WithExpectedEpoch set the epoch of the shard expected by the write, the write
+// is rejected with `raftstore.EpochNotMatchErr` instead of being retried if the
+// shard is split, merged or its replicas are changed since the epoch was read,
+// e.g. the application caches the state of the shard by the epoch. It's
+// usually used with WithShard.
+func WithExpectedEpoch(epoch metapb.ShardEpoch) Option {
+	return func(f *Future) {
+		f.req.ExpectedEpoch = &epoch
 	}
 }
 
@@ -114,6 +150,9 @@ type Client interface {
 	Write(ctx context.Context, requestType uint64, payload []byte, opts ...Option) *Future
 	// Read exec the read request, and use the `Future` to get the response
 	Read(ctx context.Context, requestType uint64, payload []byte, opts ...Option) *Future
+	// ReadOnStore is similar to Read, but the request is sent to the replica located
+	// on the specified store, and never retried on other replicas.
+	ReadOnStore(ctx context.Context, storeID uint64, requestType uint64, payload []byte, opts ...Option) *Future
 	// Txn exec the transaction request, and use the `Future` to get the response
 	Txn(ctx context.Context, request txnpb.TxnBatchRequest, opts ...Option) *Future
 
@@ -122,11 +161,17 @@ type Client interface {
 }
 
 var _ Client = (*client)(nil)
+var _ raftstore.RetryBackoff = (*client)(nil)
+var _ raftstore.RouteObserver = (*client)(nil)
 
 // client a tcp application server
 type client struct {
 	logger      *zap.Logger
 	shardsProxy raftstore.ShardsProxy
+	policy      Policy
+	// hedger is nil if the hedged reads are disabled
+	hedger *hedger
+	hooks  Hooks
 
 	mu struct {
 		sync.RWMutex
@@ -142,7 +187,7 @@ func NewClient(cfg Cfg) Client {
 
 // NewClientWithOptions create client with options
 func NewClientWithOptions(options ...CreateOption) Client {
-	c := &client{}
+	c := &client{policy: DefaultPolicy()}
 	for _, opt := range options {
 		opt(c)
 	}
@@ -186,6 +231,29 @@ func (s *client) Read(ctx context.Context, requestType uint64, payload []byte, o
 	return s.exec(ctx, requestType, payload, rpcpb.Read, nil, opts...)
 }
 
+func (s *client) ReadOnStore(ctx context.Context, storeID uint64, requestType uint64, payload []byte, opts ...Option) *Future {
+	f := s.newFuture(ctx, requestType, payload, rpcpb.Read, nil, opts...)
+	f.noRetry = true
+
+	router := s.shardsProxy.Router()
+	var shard raftstore.Shard
+	if f.req.ToShard > 0 {
+		shard = router.GetShard(f.req.ToShard)
+	} else {
+		shard = router.SelectShardByKey(f.req.Group, f.req.Key)
+	}
+	store := router.GetStore(storeID)
+	if shard.ID == 0 || store.ID == 0 {
+		f.done(nil, nil, ErrReplicaNotFound)
+		return f
+	}
+
+	if err := s.shardsProxy.DispatchTo(f.req, shard, store, nil); err != nil {
+		f.done(nil, nil, err)
+	}
+	return f
+}
+
 func (s *client) Admin(ctx context.Context, requestType uint64, payload []byte, opts ...Option) *Future {
 	return s.exec(ctx, requestType, payload, rpcpb.Admin, nil, opts...)
 }
@@ -203,6 +271,20 @@ func (s *client) AddLabelToShard(ctx context.Context, name, value string, shard
 }
 
 func (s *client) exec(ctx context.Context, requestType uint64, payload []byte, cmdType rpcpb.CmdType, txnRequest *txnpb.TxnBatchRequest, opts ...Option) *Future {
+	f := s.newFuture(ctx, requestType, payload, cmdType, txnRequest, opts...)
+	if s.hedger != nil && canHedge(f.req) {
+		if err := s.dispatchHedged(f); err != nil {
+			f.done(nil, nil, err)
+		}
+		return f
+	}
+	if err := s.shardsProxy.Dispatch(f.req); err != nil {
+		f.done(nil, nil, err)
+	}
+	return f
+}
+
+func (s *client) newFuture(ctx context.Context, requestType uint64, payload []byte, cmdType rpcpb.CmdType, txnRequest *txnpb.TxnBatchRequest, opts ...Option) *Future {
 	f := newFuture(ctx)
 	f.req.ID = uuid.NewV4().Bytes()
 	f.req.Type = cmdType
@@ -210,28 +292,39 @@ func (s *client) exec(ctx context.Context, requestType uint64, payload []byte, c
 	f.req.Cmd = payload
 	f.req.TxnBatchRequest = txnRequest
 	for _, opt := range opts {
-		opt(&f.req)
+		opt(f)
 	}
-
-	id := hack.SliceToString(f.req.ID)
-	s.addInfight(id, f)
-	f.cancel = func() {
-		s.deleteInfight(id)
+	if !f.hasClass {
+		f.class = getOperationClass(f.req)
+	}
+	f.policy = s.policy.get(f.class)
+	for _, opt := range f.policyOptions {
+		opt(&f.policy)
 	}
 
 	if len(f.req.Key) > 0 && f.req.ToShard > 0 {
 		s.logger.Fatal("route with key and route with shard cannot be set at the same time")
 	}
-	if _, ok := ctx.Deadline(); !ok {
-		s.logger.Fatal("cube client must use timeout context")
+	// the deadline of the context takes precedence over the timeout of the
+	// class, unless the timeout is specified by the call
+	cancelCtx := func() {}
+	if _, ok := ctx.Deadline(); !ok || f.hasTimeout {
+		if f.policy.Timeout <= 0 {
+			s.logger.Fatal("cube client must use timeout context")
+		}
+		f.ctx, cancelCtx = context.WithTimeout(ctx, f.policy.Timeout)
 	}
 
-	if ce := s.logger.Check(zap.DebugLevel, "begin to send request"); ce != nil {
-		ce.Write(log.RequestIDField(f.req.ID))
+	id := hack.SliceToString(f.req.ID)
+	s.addInfight(id, f)
+	f.cancel = func() {
+		s.deleteInfight(id)
+		cancelCtx()
 	}
 
-	if err := s.shardsProxy.Dispatch(f.req); err != nil {
-		f.done(nil, nil, err)
+	s.observeRequest(f)
+	if ce := s.logger.Check(zap.DebugLevel, "begin to send request"); ce != nil {
+		ce.Write(log.RequestIDField(f.req.ID))
 	}
 	return f
 }
@@ -239,6 +332,7 @@ func (s *client) exec(ctx context.Context, requestType uint64, payload []byte, c
 func (s *client) Retry(requestID []byte) (rpcpb.Request, bool) {
 	if f, ok := s.getInfight(hack.SliceToString(requestID)); ok {
 		if f.canRetry() {
+			s.observeRetry(f)
 			return f.req, true
 		}
 	}
@@ -246,14 +340,28 @@ func (s *client) Retry(requestID []byte) (rpcpb.Request, bool) {
 	return rpcpb.Request{}, false
 }
 
+// RetryInterval returns the retry interval of the request by the Backoff of its
+// policy.
+func (s *client) RetryInterval(requestID []byte) time.Duration {
+	if f, ok := s.getInfight(hack.SliceToString(requestID)); ok {
+		return f.retryInterval()
+	}
+	return 0
+}
+
 func (s *client) done(resp rpcpb.Response) {
 	if ce := s.logger.Check(zap.DebugLevel, "response received"); ce != nil {
 		ce.Write(log.RequestIDField(resp.ID))
 	}
 
 	id := hack.SliceToString(resp.ID)
-	if f, ok := s.getInfight(id); ok {
-		s.deleteInfight(id)
+	// the responses of the hedged read may arrive at the same time, only the
+	// first one takes the future
+	if f, ok := s.takeInfight(id); ok {
+		s.recordReadLatency(f)
+		if resp.Stale {
+			f.markStale()
+		}
 		f.done(resp.Value, resp.TxnBatchResponse, nil)
 	} else {
 		if ce := s.logger.Check(zap.DebugLevel, "response skipped"); ce != nil {
@@ -269,8 +377,12 @@ func (s *client) doneError(requestID []byte, err error) {
 
 	id := hack.SliceToString(requestID)
 	if f, ok := s.getInfight(id); ok {
-		s.deleteInfight(id)
-		f.done(nil, nil, err)
+		if f.failAttempt() {
+			return
+		}
+		if f, ok := s.takeInfight(id); ok {
+			f.done(nil, nil, err)
+		}
 	}
 }
 
@@ -286,6 +398,14 @@ func (s *client) deleteInfight(id string) {
 	delete(s.mu.inflights, id)
 }
 
+func (s *client) takeInfight(id string) (*Future, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.mu.inflights[id]
+	delete(s.mu.inflights, id)
+	return v, ok
+}
+
 func (s *client) getInfight(id string) (*Future, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
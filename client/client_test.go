@@ -134,6 +134,41 @@ func TestKeysRangeNotInShard(t *testing.T) {
 	assert.Empty(t, v)
 }
 
+func TestWriteWithExpectedEpoch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := raftstore.NewSingleTestClusterStore(t)
+	c.Start()
+	defer c.Stop()
+
+	s := NewClient(Cfg{Store: c.GetStore(0)})
+	assert.NoError(t, s.Start())
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+
+	c.WaitShardByCount(1, time.Minute)
+	shard := c.GetShardByIndex(0, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	req := newTestWriteCustomRequest("k", "v")
+	f := s.Write(ctx, req.CmdType, req.Cmd, WithRouteKey(req.Key), WithExpectedEpoch(shard.Epoch))
+	defer f.Close()
+	_, err := f.Get()
+	assert.NoError(t, err)
+
+	// the shard is split or its replicas are changed since the epoch was read
+	expected := shard.Epoch
+	expected.Generation++
+	f2 := s.Write(ctx, req.CmdType, req.Cmd, WithRouteKey(req.Key), WithExpectedEpoch(expected))
+	defer f2.Close()
+	_, err = f2.Get()
+	assert.True(t, raftstore.IsEpochNotMatchErr(err))
+	assert.Equal(t, raftstore.EpochNotMatchErr{ShardID: shard.ID, Expected: expected, Current: shard.Epoch}, err)
+}
+
 func newTestWriteCustomRequest(k, v string) storage.Request {
 	return executor.NewWriteRequest([]byte(k), []byte(v))
 }
@@ -0,0 +1,400 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/raftstore"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+	"github.com/matrixorigin/matrixcube/util/stop"
+)
+
+const (
+	// DefaultConfigWatchInterval the default interval of polling the changes of
+	// the watched namespaces
+	DefaultConfigWatchInterval = time.Second
+
+	configVersionLen = 8
+)
+
+var (
+	// ErrInvalidConfigKey the namespace or the key is empty, or the namespace
+	// contains the separator
+	ErrInvalidConfigKey = errors.New("invalid config key")
+	// ErrConfigVersionMismatch the current version of the config key is not the
+	// expected one
+	ErrConfigVersionMismatch = errors.New("config version mismatch")
+)
+
+// ConfigEntry is a config key with its value and version. The version starts
+// from 1 when the key is created and increases on each update, 0 means the key
+// does not exist.
+type ConfigEntry struct {
+	Namespace string
+	Key       string
+	Value     []byte
+	Version   uint64
+}
+
+// ConfigEventType the type of the config event
+type ConfigEventType int
+
+const (
+	// ConfigPut the key is created or updated
+	ConfigPut ConfigEventType = iota
+	// ConfigDelete the key is deleted, only the Namespace and the Key of the
+	// entry are set
+	ConfigDelete
+)
+
+// ConfigEvent the change of a watched config key
+type ConfigEvent struct {
+	Type  ConfigEventType
+	Entry ConfigEntry
+}
+
+// ConfigStore is a strongly consistent store for the small number of the
+// cluster-wide config keys of the embedder, built on the `raftstore.ConfigKeyspace`
+// of the system shard group, so the system group must be enabled. The keys are
+// grouped by the namespaces, all the operations are on the leader replica.
+type ConfigStore interface {
+	// Get returns the entry of the key, false if the key does not exist.
+	Get(ctx context.Context, namespace, key string) (ConfigEntry, bool, error)
+	// List returns all the entries of the namespace ordered by the key.
+	List(ctx context.Context, namespace string) ([]ConfigEntry, error)
+	// Put sets the value of the key unconditionally, returns the new version.
+	Put(ctx context.Context, namespace, key string, value []byte) (uint64, error)
+	// CompareAndSwap sets the value of the key if its current version is the
+	// expected one, 0 means the key must not exist. Returns the new version, or
+	// ErrConfigVersionMismatch if the version is changed.
+	CompareAndSwap(ctx context.Context, namespace, key string, version uint64, value []byte) (uint64, error)
+	// Delete deletes the key, it's not an error if the key does not exist.
+	Delete(ctx context.Context, namespace, key string) error
+	// Watch returns the events of the changes of the namespace since the call,
+	// until the ctx is done or the ConfigStore is closed, then the channel is
+	// closed. The changes are polled by the interval of the ConfigStore, so the
+	// changes between two polls are coalesced into one event per key, and the
+	// failed polls are retried in the next interval.
+	Watch(ctx context.Context, namespace string) (<-chan ConfigEvent, error)
+	// Close closes the ConfigStore and stops all the watches
+	Close() error
+}
+
+type configStore struct {
+	cli           Client
+	kv            KVClient
+	keyspace      raftstore.SystemKeyspace
+	watchInterval time.Duration
+	stopper       *stop.Stopper
+}
+
+// NewConfigStore returns the ConfigStore with the cube client, the changes of
+// the watched namespaces are polled by the watchInterval, 0 means
+// DefaultConfigWatchInterval.
+func NewConfigStore(cli Client, watchInterval time.Duration) ConfigStore {
+	if watchInterval <= 0 {
+		watchInterval = DefaultConfigWatchInterval
+	}
+	keyspace, ok := raftstore.GetReservedKeyspace(raftstore.ConfigKeyspace)
+	if !ok {
+		panic("config keyspace not reserved")
+	}
+	return &configStore{
+		cli:           cli,
+		kv:            NewKVClient(cli, config.SystemGroup, rpcpb.SelectLeader),
+		keyspace:      keyspace,
+		watchInterval: watchInterval,
+		stopper:       stop.NewStopper("config-store"),
+	}
+}
+
+func (s *configStore) Close() error {
+	s.stopper.Stop()
+	return s.kv.Close()
+}
+
+func (s *configStore) Get(ctx context.Context, namespace, key string) (ConfigEntry, bool, error) {
+	k, err := s.configKey(namespace, key)
+	if err != nil {
+		return ConfigEntry{}, false, err
+	}
+	value, err := s.get(ctx, k)
+	if err != nil || len(value) == 0 {
+		return ConfigEntry{}, false, err
+	}
+	entry, err := decodeConfigEntry(namespace, key, value)
+	return entry, err == nil, err
+}
+
+func (s *configStore) List(ctx context.Context, namespace string) ([]ConfigEntry, error) {
+	if err := checkConfigNamespace(namespace); err != nil {
+		return nil, err
+	}
+	prefix := s.namespacePrefix(namespace)
+	var entries []ConfigEntry
+	err := s.kv.Scan(ctx, prefix, keysEnd(prefix), func(key, value []byte) (bool, error) {
+		entry, err := decodeConfigEntry(namespace, string(key[len(prefix):]), value)
+		if err != nil {
+			return false, err
+		}
+		entries = append(entries, entry)
+		return true, nil
+	}, ScanWithValue())
+	return entries, err
+}
+
+func (s *configStore) Put(ctx context.Context, namespace, key string, value []byte) (uint64, error) {
+	k, err := s.configKey(namespace, key)
+	if err != nil {
+		return 0, err
+	}
+	current, err := s.get(ctx, k)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		version, err := decodeConfigVersion(current)
+		if err != nil {
+			return 0, err
+		}
+		ok, latest, err := s.compareAndSet(ctx, k, current, version+1, value)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return version + 1, nil
+		}
+		current = latest
+	}
+}
+
+func (s *configStore) CompareAndSwap(ctx context.Context, namespace, key string,
+	version uint64, value []byte) (uint64, error) {
+	k, err := s.configKey(namespace, key)
+	if err != nil {
+		return 0, err
+	}
+	current, err := s.get(ctx, k)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		currentVersion, err := decodeConfigVersion(current)
+		if err != nil {
+			return 0, err
+		}
+		if currentVersion != version {
+			return 0, fmt.Errorf("%w: expect %d, current %d",
+				ErrConfigVersionMismatch, version, currentVersion)
+		}
+		ok, latest, err := s.compareAndSet(ctx, k, current, version+1, value)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return version + 1, nil
+		}
+		current = latest
+	}
+}
+
+func (s *configStore) Delete(ctx context.Context, namespace, key string) error {
+	k, err := s.configKey(namespace, key)
+	if err != nil {
+		return err
+	}
+	f := s.kv.Delete(ctx, k)
+	defer f.Close()
+	return f.GetError()
+}
+
+func (s *configStore) Watch(ctx context.Context, namespace string) (<-chan ConfigEvent, error) {
+	entries, err := s.List(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	c := make(chan ConfigEvent, 16)
+	if err := s.stopper.RunNamedTask(ctx, "config-watch", func(ctx context.Context) {
+		defer close(c)
+		last := toConfigEntryMap(entries)
+		ticker := time.NewTicker(s.watchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			entries, err := s.List(ctx, namespace)
+			if err != nil {
+				continue
+			}
+			current := toConfigEntryMap(entries)
+			for _, event := range diffConfigEntries(namespace, last, current) {
+				select {
+				case <-ctx.Done():
+					return
+				case c <- event:
+				}
+			}
+			last = current
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// compareAndSet sets the key to the encoded value with the version if the raw
+// value of the key is the expected one, returns the current raw value if the
+// condition failed.
+func (s *configStore) compareAndSet(ctx context.Context, key, expected []byte,
+	version uint64, value []byte) (bool, []byte, error) {
+	raw := encodeConfigValue(version, value)
+	f := s.cli.Write(ctx,
+		executor.CmdKVCompareAndSet,
+		executor.CompareAndSetRequest{Key: key, Expected: expected, Value: raw}.Marshal(),
+		WithReplicaSelectPolicy(rpcpb.SelectLeader),
+		WithRouteKey(key),
+		WithShardGroup(config.SystemGroup))
+	defer f.Close()
+	data, err := f.Get()
+	if err != nil {
+		return false, nil, err
+	}
+	var resp executor.CompareAndSetResponse
+	if err := resp.Unmarshal(data); err != nil {
+		return false, nil, err
+	}
+	// the retried request already applied sees its own value
+	if !resp.Succeeded && bytes.Equal(resp.Value, raw) {
+		return true, nil, nil
+	}
+	return resp.Succeeded, resp.Value, nil
+}
+
+func (s *configStore) get(ctx context.Context, key []byte) ([]byte, error) {
+	f := s.kv.Get(ctx, key)
+	defer f.Close()
+	resp, err := f.GetKVGetResponse()
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value, nil
+}
+
+func (s *configStore) namespacePrefix(namespace string) []byte {
+	return s.keyspace.Key([]byte(namespace + "/"))
+}
+
+func (s *configStore) configKey(namespace, key string) ([]byte, error) {
+	if err := checkConfigNamespace(namespace); err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return nil, fmt.Errorf("%w: empty key", ErrInvalidConfigKey)
+	}
+	return append(s.namespacePrefix(namespace), key...), nil
+}
+
+func checkConfigNamespace(namespace string) error {
+	if namespace == "" || strings.Contains(namespace, "/") {
+		return fmt.Errorf("%w: namespace %q", ErrInvalidConfigKey, namespace)
+	}
+	return nil
+}
+
+// keysEnd returns the end of the range of the keys with the prefix
+func keysEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	end[len(end)-1]++
+	return end
+}
+
+// encodeConfigValue the stored value is the big endian version followed by the
+// value of the key
+func encodeConfigValue(version uint64, value []byte) []byte {
+	data := make([]byte, configVersionLen+len(value))
+	binary.BigEndian.PutUint64(data, version)
+	copy(data[configVersionLen:], value)
+	return data
+}
+
+func decodeConfigVersion(data []byte) (uint64, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	if len(data) < configVersionLen {
+		return 0, fmt.Errorf("invalid config value with %d bytes", len(data))
+	}
+	return binary.BigEndian.Uint64(data), nil
+}
+
+func decodeConfigEntry(namespace, key string, data []byte) (ConfigEntry, error) {
+	version, err := decodeConfigVersion(data)
+	if err != nil {
+		return ConfigEntry{}, err
+	}
+	return ConfigEntry{
+		Namespace: namespace,
+		Key:       key,
+		Value:     data[configVersionLen:],
+		Version:   version,
+	}, nil
+}
+
+func toConfigEntryMap(entries []ConfigEntry) map[string]ConfigEntry {
+	m := make(map[string]ConfigEntry, len(entries))
+	for _, entry := range entries {
+		m[entry.Key] = entry
+	}
+	return m
+}
+
+// diffConfigEntries returns the events changed the last entries to the current
+// ones, ordered by the key.
+func diffConfigEntries(namespace string, last, current map[string]ConfigEntry) []ConfigEvent {
+	var events []ConfigEvent
+	for key, entry := range current {
+		if old, ok := last[key]; !ok ||
+			old.Version != entry.Version ||
+			!bytes.Equal(old.Value, entry.Value) {
+			events = append(events, ConfigEvent{Type: ConfigPut, Entry: entry})
+		}
+	}
+	for key := range last {
+		if _, ok := current[key]; !ok {
+			events = append(events, ConfigEvent{
+				Type:  ConfigDelete,
+				Entry: ConfigEntry{Namespace: namespace, Key: key},
+			})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Entry.Key < events[j].Entry.Key
+	})
+	return events
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/raftstore"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+)
+
+func newTestConfigStore(t *testing.T, watchInterval time.Duration) (ConfigStore, func()) {
+	c := raftstore.NewSingleTestClusterStore(t,
+		raftstore.WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+			cfg.SystemGroup.Enable = true
+		}))
+	c.Start()
+	c.WaitLeadersByCount(2, time.Minute)
+
+	s := NewClient(Cfg{Store: c.GetStore(0)})
+	require.NoError(t, s.Start())
+	cs := NewConfigStore(s, watchInterval)
+	return cs, func() {
+		assert.NoError(t, cs.Close())
+		assert.NoError(t, s.Stop())
+		c.Stop()
+	}
+}
+
+func TestConfigStore(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	cs, stop := newTestConfigStore(t, 0)
+	defer stop()
+
+	_, ok, err := cs.Get(ctx, "ns1", "k1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	version, err := cs.Put(ctx, "ns1", "k1", []byte("v1"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), version)
+	version, err = cs.Put(ctx, "ns1", "k1", []byte("v2"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), version)
+	entry, ok, err := cs.Get(ctx, "ns1", "k1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, ConfigEntry{Namespace: "ns1", Key: "k1", Value: []byte("v2"), Version: 2}, entry)
+
+	_, err = cs.CompareAndSwap(ctx, "ns1", "k1", 1, []byte("v3"))
+	assert.True(t, errors.Is(err, ErrConfigVersionMismatch))
+	version, err = cs.CompareAndSwap(ctx, "ns1", "k1", 2, []byte("v3"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), version)
+	_, err = cs.CompareAndSwap(ctx, "ns1", "k2", 1, []byte("v1"))
+	assert.True(t, errors.Is(err, ErrConfigVersionMismatch))
+	version, err = cs.CompareAndSwap(ctx, "ns1", "k2", 0, []byte("v1"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), version)
+
+	// the namespaces are isolated, including the namespace with the same prefix
+	_, err = cs.Put(ctx, "ns11", "k1", []byte("v1"))
+	require.NoError(t, err)
+	entries, err := cs.List(ctx, "ns1")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(entries))
+	assert.Equal(t, "k1", entries[0].Key)
+	assert.Equal(t, []byte("v3"), entries[0].Value)
+	assert.Equal(t, "k2", entries[1].Key)
+
+	require.NoError(t, cs.Delete(ctx, "ns1", "k1"))
+	_, ok, err = cs.Get(ctx, "ns1", "k1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = cs.Put(ctx, "a/b", "k1", nil)
+	assert.True(t, errors.Is(err, ErrInvalidConfigKey))
+	_, err = cs.Put(ctx, "ns1", "", nil)
+	assert.True(t, errors.Is(err, ErrInvalidConfigKey))
+}
+
+func TestConfigStoreWatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	cs, stop := newTestConfigStore(t, 10*time.Millisecond)
+	defer stop()
+
+	_, err := cs.Put(ctx, "ns1", "k1", []byte("v1"))
+	require.NoError(t, err)
+	watchCtx, watchCancel := context.WithCancel(ctx)
+	events, err := cs.Watch(watchCtx, "ns1")
+	require.NoError(t, err)
+
+	_, err = cs.Put(ctx, "ns1", "k2", []byte("v1"))
+	require.NoError(t, err)
+	event := <-events
+	assert.Equal(t, ConfigPut, event.Type)
+	assert.Equal(t, ConfigEntry{Namespace: "ns1", Key: "k2", Value: []byte("v1"), Version: 1}, event.Entry)
+
+	require.NoError(t, cs.Delete(ctx, "ns1", "k1"))
+	event = <-events
+	assert.Equal(t, ConfigDelete, event.Type)
+	assert.Equal(t, ConfigEntry{Namespace: "ns1", Key: "k1"}, event.Entry)
+
+	watchCancel()
+	for range events {
+	}
+}
+
+func TestDiffConfigEntries(t *testing.T) {
+	last := toConfigEntryMap([]ConfigEntry{
+		{Key: "k1", Value: []byte("v1"), Version: 1},
+		{Key: "k2", Value: []byte("v1"), Version: 1},
+		{Key: "k3", Value: []byte("v1"), Version: 1},
+	})
+	current := toConfigEntryMap([]ConfigEntry{
+		{Key: "k0", Value: []byte("v1"), Version: 1},
+		{Key: "k1", Value: []byte("v1"), Version: 1},
+		// deleted and created again between the polls
+		{Key: "k2", Value: []byte("v2"), Version: 1},
+	})
+	events := diffConfigEntries("ns", last, current)
+	require.Equal(t, 3, len(events))
+	assert.Equal(t, ConfigEvent{Type: ConfigPut, Entry: current["k0"]}, events[0])
+	assert.Equal(t, ConfigEvent{Type: ConfigPut, Entry: current["k2"]}, events[1])
+	assert.Equal(t, ConfigEvent{Type: ConfigDelete, Entry: ConfigEntry{Namespace: "ns", Key: "k3"}}, events[2])
+}
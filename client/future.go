@@ -16,10 +16,12 @@ package client
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/fagongzi/util/protoc"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/matrixorigin/matrixcube/pb/txnpb"
+	"github.com/matrixorigin/matrixcube/storage/executor"
 )
 
 // Future is used to obtain response data synchronously.
@@ -29,13 +31,36 @@ type Future struct {
 	txnResponse      txnpb.TxnBatchResponse
 	batchGetResponse rpcpb.KVBatchGetResponse
 	err              error
-	ctx              context.Context
-	c                chan struct{}
-	cancel           func()
+	// stale the read is served in the degraded read mode of the shard
+	stale  bool
+	ctx    context.Context
+	c      chan struct{}
+	cancel func()
+	// noRetry the request is sent to the specified replica, it can not be
+	// retried on other replicas
+	noRetry bool
+	// class, policy and the options overriding the policy of the call
+	class         OperationClass
+	hasClass      bool
+	hasTimeout    bool
+	policy        CallPolicy
+	policyOptions []func(*CallPolicy)
+	// client observes the stats of the request when the future is closed, nil
+	// for the futures not created by the client
+	client    *client
+	createdAt time.Time
 
 	mu struct {
 		sync.Mutex
-		closed bool
+		closed  bool
+		retries int
+		// retried the retries allowed by the policy
+		retried int
+		doneAt  time.Time
+		// attempts the outstanding attempts of the hedged read
+		attempts   int
+		firstStore uint64
+		sentAt     time.Time
 	}
 }
 
@@ -51,8 +76,23 @@ func (f *Future) reset() {
 	f.txnResponse.Reset()
 	f.batchGetResponse.Reset()
 	f.err = nil
+	f.stale = false
 	f.ctx = nil
 	f.cancel = nil
+	f.noRetry = false
+	f.class = OpPointRead
+	f.hasClass = false
+	f.hasTimeout = false
+	f.policy = CallPolicy{}
+	f.policyOptions = f.policyOptions[:0]
+	f.client = nil
+	f.createdAt = time.Time{}
+	f.mu.retries = 0
+	f.mu.retried = 0
+	f.mu.doneAt = time.Time{}
+	f.mu.attempts = 0
+	f.mu.firstStore = 0
+	f.mu.sentAt = time.Time{}
 	select {
 	case <-f.c:
 	default:
@@ -81,6 +121,16 @@ func (f *Future) GetError() error {
 	}
 }
 
+// IsStale returns true if the read is served by the replica of the shard which
+// has no leader, i.e. the degraded read is enabled by
+// `raftstore.Store.EnableDegradedRead` after the quorum lost. The value may
+// miss the latest writes. It's called after `Get` returned.
+func (f *Future) IsStale() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stale
+}
+
 // GetTxn get the txn response data synchronously, blocking until `context.Done` or the response is received.
 // This method cannot be called more than once. After calling `Get`, `Close` must be called to close
 // `Future`.
@@ -133,11 +183,48 @@ func (f *Future) GetKVScanResponse() (rpcpb.KVScanResponse, error) {
 	return resp, nil
 }
 
+// GetCompareAndSetResponse get the response of the compare and set or the
+// conditional write
+func (f *Future) GetCompareAndSetResponse() (executor.CompareAndSetResponse, error) {
+	v, err := f.Get()
+	if err != nil {
+		return executor.CompareAndSetResponse{}, err
+	}
+
+	var resp executor.CompareAndSetResponse
+	if err := resp.Unmarshal(v); err != nil {
+		return executor.CompareAndSetResponse{}, err
+	}
+	return resp, nil
+}
+
+// GetIncrementResponse get the response of the increment, the error is
+// returned if the key holds a value which is not a counter or the counter
+// overflows.
+func (f *Future) GetIncrementResponse() (int64, error) {
+	v, err := f.Get()
+	if err != nil {
+		return 0, err
+	}
+
+	var resp executor.IncrementResponse
+	if err := resp.Unmarshal(v); err != nil {
+		return 0, err
+	}
+	if !resp.Succeeded {
+		return 0, ErrIncrementFailed
+	}
+	return resp.Value, nil
+}
+
 // Close close the future.
 func (f *Future) Close() {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
+	if f.client != nil {
+		f.client.observeDone(f.statsLocked())
+	}
 	if f.cancel != nil {
 		f.cancel()
 	}
@@ -145,15 +232,118 @@ func (f *Future) Close() {
 	releaseFuture(f)
 }
 
+// statsLocked returns the stats of the request, the error of the context is
+// used if no response is received.
+func (f *Future) statsLocked() RequestStats {
+	stats := RequestStats{
+		Request: f.req,
+		Class:   f.class,
+		Retries: f.mu.retried,
+	}
+	if f.mu.doneAt.IsZero() {
+		stats.Latency = time.Since(f.createdAt)
+		if stats.Err = f.ctx.Err(); stats.Err == nil {
+			stats.Err = context.Canceled
+		}
+	} else {
+		stats.Latency = f.mu.doneAt.Sub(f.createdAt)
+		stats.Err = f.err
+	}
+	return stats
+}
+
 func (f *Future) canRetry() bool {
+	if f.noRetry {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mu.retries++
+	if f.policy.MaxRetries > 0 && f.mu.retries > f.policy.MaxRetries {
+		return false
+	}
+
 	select {
 	case <-f.ctx.Done():
 		return false
 	default:
+		f.mu.retried++
 		return true
 	}
 }
 
+func (f *Future) getRetried() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.mu.retried
+}
+
+// startAttempt records the first attempt of the hedged read sent to the store
+func (f *Future) startAttempt(storeID uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.mu.attempts = 1
+	f.mu.firstStore = storeID
+	f.mu.sentAt = time.Now()
+}
+
+// addAttempt records the hedge of the request, false is returned if the future
+// is closed or reused by another request since hedgeRequest.
+func (f *Future) addAttempt(id string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.mu.closed || string(f.req.ID) != id {
+		return false
+	}
+	f.mu.attempts++
+	return true
+}
+
+// hedgeRequest returns the request to hedge and the store of the first attempt,
+// false is returned if the future is closed, reused by another request or
+// already hedged.
+func (f *Future) hedgeRequest(id string) (rpcpb.Request, uint64, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.mu.closed || f.mu.attempts != 1 || string(f.req.ID) != id {
+		return rpcpb.Request{}, 0, false
+	}
+	return f.req, f.mu.firstStore, true
+}
+
+// failAttempt returns true if the failed attempt is not the last outstanding
+// attempt of the hedged read, the error should be ignored and the response of
+// the other attempt is waited.
+func (f *Future) failAttempt() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.mu.attempts > 1 {
+		f.mu.attempts--
+		return true
+	}
+	return false
+}
+
+func (f *Future) sentAt() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.mu.sentAt
+}
+
+func (f *Future) retryInterval() time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.policy.Backoff.Next(f.mu.retries)
+}
+
 func (f *Future) done(value []byte, txnRespopnse *txnpb.TxnBatchResponse, err error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -164,6 +354,7 @@ func (f *Future) done(value []byte, txnRespopnse *txnpb.TxnBatchResponse, err er
 		}
 		f.value = value
 		f.err = err
+		f.mu.doneAt = time.Now()
 		select {
 		case f.c <- struct{}{}:
 		default:
@@ -172,12 +363,22 @@ func (f *Future) done(value []byte, txnRespopnse *txnpb.TxnBatchResponse, err er
 	}
 }
 
+func (f *Future) markStale() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.mu.closed {
+		f.stale = true
+	}
+}
+
 func (f *Future) kvBatchGetDone(values [][]byte) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
 	if !f.mu.closed {
 		f.batchGetResponse.Values = values
+		f.mu.doneAt = time.Now()
 		select {
 		case f.c <- struct{}{}:
 		default:
@@ -15,10 +15,12 @@ func TestReuse(t *testing.T) {
 	f := acquireFuture()
 	f.cancel = func() {}
 	f.ctx = ctx
+	f.markStale()
 	f.done([]byte("k1"), nil, nil)
 	v, err := f.Get()
 	assert.NoError(t, err)
 	assert.Equal(t, "k1", string(v))
+	assert.True(t, f.IsStale())
 	f.Close()
 
 	f = acquireFuture()
@@ -28,4 +30,5 @@ func TestReuse(t *testing.T) {
 	v, err = f.Get()
 	assert.NoError(t, err)
 	assert.Equal(t, "k2", string(v))
+	assert.False(t, f.IsStale())
 }
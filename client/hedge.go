@@ -0,0 +1,247 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/raftstore"
+	"github.com/matrixorigin/matrixcube/util"
+	"go.uber.org/zap"
+)
+
+const (
+	hedgeLatencySamples    = 1024
+	hedgeMinSamples        = 100
+	hedgeRecomputeInterval = 64
+	// hedgeMaxBudget caps the hedges saved up by an idle client, so a burst of
+	// slow reads after a quiet period can not double the load.
+	hedgeMaxBudget = 10
+)
+
+// HedgeOptions the options of the hedged reads. A follower read which has not
+// returned within the Percentile latency of the recent follower reads is sent
+// again to another replica of the shard, and the first response is taken.
+type HedgeOptions struct {
+	// Percentile the percentile of the recent read latencies used as the hedge
+	// delay, default is 0.95.
+	Percentile float64
+	// InitialDelay the hedge delay used until enough latencies are sampled,
+	// default is 10ms.
+	InitialDelay time.Duration
+	// MinDelay the lower bound of the hedge delay, default is 1ms.
+	MinDelay time.Duration
+	// Budget the extra reads allowed, as a ratio of the follower reads, default
+	// is 0.05, i.e. at most 5% more reads are sent because of the hedging.
+	Budget float64
+}
+
+func (opts *HedgeOptions) adjust() {
+	if opts.Percentile <= 0 || opts.Percentile >= 1 {
+		opts.Percentile = 0.95
+	}
+	if opts.InitialDelay <= 0 {
+		opts.InitialDelay = 10 * time.Millisecond
+	}
+	if opts.MinDelay <= 0 {
+		opts.MinDelay = time.Millisecond
+	}
+	if opts.Budget <= 0 {
+		opts.Budget = 0.05
+	}
+}
+
+// hedger tracks the latencies of the follower reads and the hedge budget
+type hedger struct {
+	opts HedgeOptions
+
+	mu struct {
+		sync.Mutex
+		latencies []time.Duration
+		next      int
+		recorded  int
+		delay     time.Duration
+		budget    float64
+	}
+}
+
+func newHedger(opts HedgeOptions) *hedger {
+	opts.adjust()
+	h := &hedger{opts: opts}
+	h.mu.latencies = make([]time.Duration, 0, hedgeLatencySamples)
+	h.mu.delay = opts.InitialDelay
+	return h
+}
+
+// delay returns the hedge delay of a new read, and adds the read's share to the
+// budget.
+func (h *hedger) delay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.mu.budget += h.opts.Budget
+	if h.mu.budget > hedgeMaxBudget {
+		h.mu.budget = hedgeMaxBudget
+	}
+	return h.mu.delay
+}
+
+// acquire returns true if the budget allows one more hedged read
+func (h *hedger) acquire() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.mu.budget < 1 {
+		return false
+	}
+	h.mu.budget--
+	return true
+}
+
+func (h *hedger) record(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.mu.latencies) < hedgeLatencySamples {
+		h.mu.latencies = append(h.mu.latencies, latency)
+	} else {
+		h.mu.latencies[h.mu.next] = latency
+		h.mu.next = (h.mu.next + 1) % hedgeLatencySamples
+	}
+	h.mu.recorded++
+	if len(h.mu.latencies) >= hedgeMinSamples &&
+		h.mu.recorded%hedgeRecomputeInterval == 0 {
+		h.mu.delay = h.percentileLocked()
+	}
+}
+
+func (h *hedger) percentileLocked() time.Duration {
+	sorted := make([]time.Duration, len(h.mu.latencies))
+	copy(sorted, h.mu.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	delay := sorted[int(float64(len(sorted)-1)*h.opts.Percentile)]
+	if delay < h.opts.MinDelay {
+		delay = h.opts.MinDelay
+	}
+	return delay
+}
+
+// canHedge returns true if the request can be served by any replica, i.e. the
+// follower reads and the learner reads.
+func canHedge(req rpcpb.Request) bool {
+	return req.Type == rpcpb.Read &&
+		(req.ReplicaSelectPolicy == rpcpb.SelectRandom ||
+			req.ReplicaSelectPolicy == rpcpb.SelectLearner)
+}
+
+// dispatchHedged sends the read to the replica selected by the policy, and
+// schedules the hedge on another replica.
+func (s *client) dispatchHedged(f *Future) error {
+	router := s.shardsProxy.Router()
+	var shard raftstore.Shard
+	var store metapb.Store
+	if f.req.ToShard > 0 {
+		shard = router.GetShard(f.req.ToShard)
+		store, _ = router.SelectReplicaStoreWithPolicy(f.req.ToShard, f.req.ReplicaSelectPolicy)
+	} else {
+		shard, store, _ = router.SelectShardWithPolicy(f.req.Group, f.req.Key, f.req.ReplicaSelectPolicy)
+	}
+
+	f.startAttempt(store.ID)
+	if err := s.shardsProxy.DispatchTo(f.req, shard, store, nil); err != nil {
+		return err
+	}
+	if _, err := util.DefaultTimeoutWheel().Schedule(s.hedger.delay(), s.hedge,
+		string(f.req.ID)); err != nil {
+		s.logger.Error("fail to schedule the hedged read",
+			log.RequestIDField(f.req.ID),
+			zap.Error(err))
+	}
+	return nil
+}
+
+// hedge sends the read which has not returned yet to a replica on another
+// store.
+func (s *client) hedge(arg interface{}) {
+	id := arg.(string)
+	f, ok := s.getInfight(id)
+	if !ok {
+		return
+	}
+	req, firstStore, ok := f.hedgeRequest(id)
+	if !ok {
+		return
+	}
+
+	router := s.shardsProxy.Router()
+	var shard raftstore.Shard
+	if req.ToShard > 0 {
+		shard = router.GetShard(req.ToShard)
+	} else {
+		shard = router.SelectShardByKey(req.Group, req.Key)
+	}
+	store := selectHedgeStore(router, shard, firstStore, req.ReplicaSelectPolicy)
+	if store.ID == 0 || !s.hedger.acquire() {
+		return
+	}
+
+	if !f.addAttempt(id) {
+		return
+	}
+	if ce := s.logger.Check(zap.DebugLevel, "send hedged read"); ce != nil {
+		ce.Write(log.RequestIDField(req.ID),
+			log.ShardIDField(shard.ID),
+			log.StoreIDField(store.ID))
+	}
+	if err := s.shardsProxy.DispatchTo(req, shard, store, nil); err != nil {
+		s.doneError(req.ID, err)
+	}
+}
+
+// selectHedgeStore selects a replica of the shard not on the store of the first
+// attempt, the learners are preferred for the learner reads.
+func selectHedgeStore(router raftstore.Router, shard raftstore.Shard, exclude uint64,
+	policy rpcpb.ReplicaSelectPolicy) metapb.Store {
+	var candidate metapb.Store
+	for _, r := range shard.Replicas {
+		if r.StoreID == exclude {
+			continue
+		}
+		store := router.GetStore(r.StoreID)
+		if store.ID == 0 {
+			continue
+		}
+		if policy == rpcpb.SelectLearner && r.Role == metapb.ReplicaRole_Learner {
+			return store
+		}
+		if candidate.ID == 0 {
+			candidate = store
+		}
+	}
+	return candidate
+}
+
+func (s *client) recordReadLatency(f *Future) {
+	if s.hedger == nil {
+		return
+	}
+	if sent := f.sentAt(); !sent.IsZero() {
+		s.hedger.record(time.Since(sent))
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/raftstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedgerDelayAndBudget(t *testing.T) {
+	h := newHedger(HedgeOptions{Percentile: 0.9, InitialDelay: time.Second, Budget: 0.5})
+	assert.Equal(t, time.Second, h.delay())
+	assert.False(t, h.acquire(), "budget is 0.5")
+	h.delay()
+	assert.True(t, h.acquire())
+	assert.False(t, h.acquire())
+
+	for i := 0; i < 100; i++ {
+		h.delay()
+	}
+	assert.Equal(t, float64(hedgeMaxBudget), h.mu.budget)
+
+	for i := 1; i <= 2*hedgeRecomputeInterval; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 115*time.Millisecond, h.delay())
+
+	for i := 0; i < hedgeRecomputeInterval; i++ {
+		h.record(time.Microsecond)
+	}
+	assert.Equal(t, 108*time.Millisecond, h.delay())
+	for i := 0; i < hedgeLatencySamples; i++ {
+		h.record(time.Microsecond)
+	}
+	assert.Equal(t, time.Millisecond, h.delay(), "bounded by the MinDelay")
+}
+
+func TestHedgedRead(t *testing.T) {
+	router := raftstore.NewMockRouter()
+	addTestShard(router, 1, "10/11,20/21,30/31")
+
+	var lock sync.Mutex
+	attempts := 0
+	handler := func(r rpcpb.Request) (rpcpb.ResponseBatch, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		// the first attempt is lost
+		attempts++
+		if attempts == 1 {
+			return rpcpb.ResponseBatch{}, nil
+		}
+		return rpcpb.ResponseBatch{Responses: []rpcpb.Response{
+			{ID: r.ID, CustomType: r.CustomType, Type: r.Type, Value: []byte("v")},
+		}}, nil
+	}
+	sp, err := raftstore.NewMockShardsProxy(router, handler)
+	require.NoError(t, err)
+	s := NewClientWithOptions(CreateWithShardsProxy(sp),
+		CreateWithHedgedReads(HedgeOptions{InitialDelay: 10 * time.Millisecond, Budget: 1}))
+	require.NoError(t, s.Start())
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	f := s.Read(ctx, 1, nil, WithShard(1), WithFollowerRead())
+	v, err := f.Get()
+	f.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "v", string(v))
+	assert.Equal(t, 2, attempts)
+
+	// leader reads are not hedged
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel2()
+	lock.Lock()
+	attempts = 0
+	lock.Unlock()
+	f = s.Read(ctx2, 1, nil, WithShard(1))
+	_, err = f.Get()
+	f.Close()
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestHedgedReadIgnoresFirstError(t *testing.T) {
+	router := raftstore.NewMockRouter()
+	addTestShard(router, 1, "10/11,20/21,30/31")
+
+	var lock sync.Mutex
+	n := 0
+	var first rpcpb.Request
+	handler := func(r rpcpb.Request) (rpcpb.ResponseBatch, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		// both attempts are pending
+		n++
+		if n == 1 {
+			first = r
+		}
+		return rpcpb.ResponseBatch{}, nil
+	}
+	sp, err := raftstore.NewMockShardsProxy(router, handler)
+	require.NoError(t, err)
+	c := NewClientWithOptions(CreateWithShardsProxy(sp),
+		CreateWithHedgedReads(HedgeOptions{InitialDelay: time.Millisecond, Budget: 1})).(*client)
+	require.NoError(t, c.Start())
+	defer func() {
+		assert.NoError(t, c.Stop())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	f := c.Read(ctx, 1, nil, WithShard(1), WithFollowerRead())
+	defer f.Close()
+	for {
+		lock.Lock()
+		hedged := n == 2
+		lock.Unlock()
+		if hedged {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// the error of one attempt is ignored while the other is outstanding
+	c.doneError(first.ID, errors.New("first attempt failed"))
+	select {
+	case <-f.c:
+		assert.Fail(t, "the error of the first attempt must be ignored")
+	default:
+	}
+	c.done(rpcpb.Response{ID: first.ID, Value: []byte("v")})
+	v, err := f.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "v", string(v))
+}
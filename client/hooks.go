@@ -0,0 +1,108 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/metric"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/raftstore"
+)
+
+// RequestStats the stats of a request passed to the Hooks when its future is
+// closed
+type RequestStats struct {
+	// Request the request, it must not be modified
+	Request rpcpb.Request
+	// Class the class of the request
+	Class OperationClass
+	// Latency the duration since the request is created until the response is
+	// received, or until the future is closed if no response received
+	Latency time.Duration
+	// Retries the number of the retries of the request
+	Retries int
+	// Err the error of the request, it's the error of the context if no
+	// response received, e.g. context.DeadlineExceeded
+	Err error
+}
+
+// Hooks the callbacks for the embedders to attach their own metrics and
+// tracing to the requests, the nil callbacks are skipped. The callbacks are
+// called synchronously by the client, so they must not block. The latency, the
+// retries and the route lookups are also exported by the `metric` package
+// regardless of the hooks.
+type Hooks struct {
+	// OnRequest is called when the request is created, before it's sent
+	OnRequest func(req rpcpb.Request, class OperationClass)
+	// OnRetry is called before the request is retried, the retries starts from 1
+	OnRetry func(req rpcpb.Request, class OperationClass, retries int)
+	// OnRoute is called with the result of each route lookup of the request
+	OnRoute func(requestID []byte, result raftstore.RouteResult)
+	// OnDone is called when the future of the request is closed
+	OnDone func(stats RequestStats)
+}
+
+// CreateWithHooks set the hooks called with the events of the requests
+func CreateWithHooks(hooks Hooks) CreateOption {
+	return func(c *client) {
+		c.hooks = hooks
+	}
+}
+
+// ObserveRoute implements raftstore.RouteObserver
+func (s *client) ObserveRoute(requestID []byte, result raftstore.RouteResult) {
+	metric.IncClientRouteLookups(result.String())
+	if s.hooks.OnRoute != nil {
+		s.hooks.OnRoute(requestID, result)
+	}
+}
+
+func (s *client) observeRequest(f *Future) {
+	f.client = s
+	f.createdAt = time.Now()
+	if s.hooks.OnRequest != nil {
+		s.hooks.OnRequest(f.req, f.class)
+	}
+}
+
+func (s *client) observeRetry(f *Future) {
+	metric.IncClientRequestRetries(f.class.String())
+	if s.hooks.OnRetry != nil {
+		s.hooks.OnRetry(f.req, f.class, f.getRetried())
+	}
+}
+
+func (s *client) observeDone(stats RequestStats) {
+	metric.ObserveClientRequestDuration(stats.Class.String(),
+		requestResult(stats.Err), stats.Latency)
+	if s.hooks.OnDone != nil {
+		s.hooks.OnDone(stats)
+	}
+}
+
+func requestResult(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/pb/errorpb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/raftstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestResult(t *testing.T) {
+	assert.Equal(t, "ok", requestResult(nil))
+	assert.Equal(t, "timeout", requestResult(context.DeadlineExceeded))
+	assert.Equal(t, "canceled", requestResult(context.Canceled))
+	assert.Equal(t, "error", requestResult(errors.New("error")))
+}
+
+func TestHooks(t *testing.T) {
+	router := raftstore.NewMockRouter()
+	addTestShard(router, 1, "10/11")
+
+	var lock sync.Mutex
+	sent := 0
+	sp, err := raftstore.NewMockShardsProxy(router, func(r rpcpb.Request) (rpcpb.ResponseBatch, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		sent++
+		switch sent {
+		case 1:
+			// the route is stale
+			return rpcpb.ResponseBatch{
+				Header:    rpcpb.ResponseBatchHeader{Error: errorpb.Error{Message: "stale epoch", StaleEpoch: &errorpb.StaleEpoch{}}},
+				Responses: []rpcpb.Response{{ID: r.ID}},
+			}, nil
+		case 2:
+			return rpcpb.ResponseBatch{Responses: []rpcpb.Response{{ID: r.ID, Value: []byte("v")}}}, nil
+		default:
+			// the request is lost
+			return rpcpb.ResponseBatch{}, nil
+		}
+	})
+	require.NoError(t, err)
+
+	var requests, retries []OperationClass
+	var routes []raftstore.RouteResult
+	var stats []RequestStats
+	hooks := Hooks{
+		OnRequest: func(req rpcpb.Request, class OperationClass) {
+			requests = append(requests, class)
+		},
+		OnRetry: func(req rpcpb.Request, class OperationClass, n int) {
+			lock.Lock()
+			defer lock.Unlock()
+			assert.Equal(t, len(retries)+1, n)
+			retries = append(retries, class)
+		},
+		OnRoute: func(requestID []byte, result raftstore.RouteResult) {
+			lock.Lock()
+			defer lock.Unlock()
+			routes = append(routes, result)
+		},
+		OnDone: func(s RequestStats) {
+			stats = append(stats, s)
+		},
+	}
+	s := NewClientWithOptions(CreateWithShardsProxy(sp), CreateWithHooks(hooks))
+	require.NoError(t, s.Start())
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	f := s.Write(ctx, 1, nil, WithShard(1))
+	v, err := f.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "v", string(v))
+	f.Close()
+
+	f = s.Read(ctx, 1, nil, WithShard(1), WithTimeout(10*time.Millisecond))
+	assert.Equal(t, context.DeadlineExceeded, f.GetError())
+	f.Close()
+
+	assert.Equal(t, []OperationClass{OpWrite, OpPointRead}, requests)
+	lock.Lock()
+	require.True(t, len(retries) >= 1)
+	assert.Equal(t, OpWrite, retries[0])
+	require.True(t, len(routes) >= 3)
+	assert.Equal(t, []raftstore.RouteResult{raftstore.RouteHit, raftstore.RouteStale, raftstore.RouteHit}, routes[:3])
+	lock.Unlock()
+	require.Equal(t, 2, len(stats))
+	assert.Equal(t, OpWrite, stats[0].Class)
+	assert.Equal(t, 1, stats[0].Retries)
+	assert.NoError(t, stats[0].Err)
+	assert.True(t, stats[0].Latency > 0)
+	assert.Equal(t, OpPointRead, stats[1].Class)
+	assert.Equal(t, context.DeadlineExceeded, stats[1].Err)
+}
@@ -18,11 +18,13 @@ import (
 	"context"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/fagongzi/util/protoc"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/matrixorigin/matrixcube/raftstore"
+	"github.com/matrixorigin/matrixcube/storage/executor"
 	keysutil "github.com/matrixorigin/matrixcube/util/keys"
 	"github.com/matrixorigin/matrixcube/util/stop"
 )
@@ -71,6 +73,17 @@ type KVClient interface {
 	// RangeDelete delete keys in range [start, end) from the underlying storage engine, these
 	// Keys must belong to the same ShardUse Future.GetError to check result.
 	RangeDelete(ctx context.Context, start, end []byte) *Future
+	// CompareAndSet sets the key to the value if its current value is the expected one, the empty
+	// expected value means the key does not exist. Use Future.GetCompareAndSetResponse to get the
+	// result, which has the current value if the condition failed.
+	CompareAndSet(ctx context.Context, key, expected, value []byte) *Future
+	// ConditionalWrite sets or deletes the key if the condition of the request holds, the condition
+	// is evaluated when the request is applied, so no external locking is required. Use
+	// Future.GetCompareAndSetResponse to get the result.
+	ConditionalWrite(ctx context.Context, req executor.ConditionalWriteRequest) *Future
+	// Increment adds the delta to the varint encoded counter stored in the key, the missing key is
+	// a counter of 0. Use Future.GetIncrementResponse to get the new value of the counter.
+	Increment(ctx context.Context, key []byte, delta int64) *Future
 	// Get get the value of the key, use Future.GetKVGetResponse to get response
 	Get(ctx context.Context, key []byte) *Future
 	// BatchGet silimlar to Get, but perform with multi-keys
@@ -82,10 +95,60 @@ type KVClient interface {
 	// ParallelScan similar to Scan, but perform scan in shards parallelly. Since scan is parallel,
 	// there is no guarantee that the ScanHandler's processing of the Key is sequential.
 	ParallelScan(ctx context.Context, start, end []byte, handler ScanHandler, options ...ScanOption) error
+	// ScanChecksum computes the checksum of the keys in the range [start, end) on the replicas of
+	// all shards in the range. Only the replicas located on the stores are used, empty stores means
+	// all replicas. Each replica computes the checksum after its read index, returns the result with
+	// the applied index, so the results of the same shard with the same applied index are comparable.
+	ScanChecksum(ctx context.Context, start, end []byte, stores ...uint64) ([]ReplicaScanChecksum, error)
+	// ScanStats counts the keys and sums the bytes of the values in the range [start, end). The
+	// shards in the range are asked parallelly, by the leaders or the followers according to the
+	// replica select policy. Each shard collects the stats after its read index, so all the writes
+	// completed before the call are counted.
+	ScanStats(ctx context.Context, start, end []byte) (ScanStats, error)
+	// NewReadSnapshot pins a point in time view of the keys in the range [start, end), the views
+	// of the shards are pinned after their read indexes, so all the writes completed before the
+	// call are visible. The views are released after the TTL since the last read, 0 means
+	// executor.DefaultReadSnapshotTTL. The shards are pinned independently, it's consistent for
+	// the writes of a single shard which are the only writes supported by the KVClient.
+	NewReadSnapshot(ctx context.Context, start, end []byte, ttl time.Duration) (ReadSnapshot, error)
 	// Close close the client
 	Close() error
 }
 
+// ReplicaScanChecksum the scan checksum result of a replica
+type ReplicaScanChecksum struct {
+	executor.ScanChecksumResponse
+	// Shard the shard which the replica belongs to
+	Shard raftstore.Shard
+	// Replica the replica computed the checksum
+	Replica metapb.Replica
+	// Err the error if the replica failed to compute the checksum
+	Err error
+}
+
+// ScanStats the stats of the keys in a range, merged from the stats of the
+// sub-ranges served by the shards.
+type ScanStats struct {
+	Keys       uint64
+	ValueBytes uint64
+	// Ranges the stats of the sub-ranges in key order
+	Ranges []RangeScanStats
+}
+
+// RangeScanStats the stats of the sub-range [Start, End) served by a shard
+type RangeScanStats struct {
+	executor.ScanStatsResponse
+	Start []byte
+}
+
+func (s *ScanStats) merge(ranges []RangeScanStats) {
+	for _, r := range ranges {
+		s.Keys += r.Keys
+		s.ValueBytes += r.ValueBytes
+	}
+	s.Ranges = append(s.Ranges, ranges...)
+}
+
 type kvClient struct {
 	shardGroup uint64
 	policy     rpcpb.ReplicaSelectPolicy
@@ -152,7 +215,7 @@ func (c *kvClient) BatchDelete(ctx context.Context, keys [][]byte) *Future {
 	})
 
 	return c.cli.Write(ctx,
-		uint64(rpcpb.CmdKVDelete),
+		uint64(rpcpb.CmdKVBatchDelete),
 		cmd,
 		WithReplicaSelectPolicy(c.policy),
 		WithRouteKey(keys[0]),
@@ -161,7 +224,7 @@ func (c *kvClient) BatchDelete(ctx context.Context, keys [][]byte) *Future {
 }
 
 func (c *kvClient) RangeDelete(ctx context.Context, start, end []byte) *Future {
-	return c.cli.Read(ctx,
+	return c.cli.Write(ctx,
 		uint64(rpcpb.CmdKVRangeDelete),
 		protoc.MustMarshal(&rpcpb.KVRangeDeleteRequest{Start: start, End: end}),
 		WithReplicaSelectPolicy(c.policy),
@@ -169,6 +232,33 @@ func (c *kvClient) RangeDelete(ctx context.Context, start, end []byte) *Future {
 		WithShardGroup(c.shardGroup))
 }
 
+func (c *kvClient) CompareAndSet(ctx context.Context, key, expected, value []byte) *Future {
+	return c.cli.Write(ctx,
+		executor.CmdKVCompareAndSet,
+		executor.CompareAndSetRequest{Key: key, Expected: expected, Value: value}.Marshal(),
+		WithReplicaSelectPolicy(c.policy),
+		WithRouteKey(key),
+		WithShardGroup(c.shardGroup))
+}
+
+func (c *kvClient) ConditionalWrite(ctx context.Context, req executor.ConditionalWriteRequest) *Future {
+	return c.cli.Write(ctx,
+		executor.CmdKVConditionalWrite,
+		req.Marshal(),
+		WithReplicaSelectPolicy(c.policy),
+		WithRouteKey(req.Key),
+		WithShardGroup(c.shardGroup))
+}
+
+func (c *kvClient) Increment(ctx context.Context, key []byte, delta int64) *Future {
+	return c.cli.Write(ctx,
+		executor.CmdKVIncrement,
+		executor.IncrementRequest{Key: key, Delta: delta}.Marshal(),
+		WithReplicaSelectPolicy(c.policy),
+		WithRouteKey(key),
+		WithShardGroup(c.shardGroup))
+}
+
 func (c *kvClient) Get(ctx context.Context, key []byte) *Future {
 	return c.cli.Read(ctx,
 		uint64(rpcpb.CmdKVGet),
@@ -422,6 +512,144 @@ func (c *kvClient) ParallelScan(ctx context.Context,
 	return err
 }
 
+func (c *kvClient) ScanChecksum(ctx context.Context, start, end []byte, stores ...uint64) ([]ReplicaScanChecksum, error) {
+	var shards []raftstore.Shard
+	c.cli.Router().AscendRangeWithoutSelectReplica(c.shardGroup, start, end,
+		func(shard raftstore.Shard) bool {
+			shards = append(shards, shard)
+			return true
+		})
+	if len(shards) == 0 {
+		return nil, ErrReplicaNotFound
+	}
+
+	cmd := executor.ScanChecksumRequest{Start: start, End: end}.Marshal()
+	var results []ReplicaScanChecksum
+	var futures []*Future
+	for _, shard := range shards {
+		for _, r := range shard.Replicas {
+			if len(stores) > 0 && !containsStore(stores, r.StoreID) {
+				continue
+			}
+			results = append(results, ReplicaScanChecksum{Shard: shard, Replica: r})
+			futures = append(futures, c.cli.ReadOnStore(ctx, r.StoreID, executor.CmdKVScanChecksum, cmd,
+				WithShard(shard.ID),
+				WithShardGroup(c.shardGroup),
+				WithReplicaSelectPolicy(rpcpb.SelectRandom)))
+		}
+	}
+
+	for idx, f := range futures {
+		v, err := f.Get()
+		f.Close()
+		if err == nil {
+			err = results[idx].ScanChecksumResponse.Unmarshal(v)
+		}
+		results[idx].Err = err
+	}
+	return results, nil
+}
+
+func (c *kvClient) ScanStats(ctx context.Context, start, end []byte) (ScanStats, error) {
+	var ranges []keyRange
+	c.cli.Router().AscendRangeWithoutSelectReplica(c.shardGroup, start, end,
+		func(shard raftstore.Shard) bool {
+			r := keyRange{start: start, end: end}
+			if len(ranges) > 0 {
+				r.start = shard.Start
+			}
+			if len(shard.End) > 0 && (len(end) == 0 || bytes.Compare(shard.End, end) < 0) {
+				r.end = shard.End
+			}
+			ranges = append(ranges, r)
+			return true
+		})
+	if len(ranges) == 0 {
+		ranges = append(ranges, keyRange{start: start, end: end})
+	}
+
+	// the shards may be split or merged since routed, the sub-ranges are
+	// scanned until the end
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var err error
+	results := make([][]RangeScanStats, len(ranges))
+	for idx := range ranges {
+		wg.Add(1)
+		i := idx
+		if e := c.stopper.RunTask(ctx, func(ctx context.Context) {
+			defer wg.Done()
+			v, e := c.scanStats(ctx, ranges[i].start, ranges[i].end)
+			lock.Lock()
+			defer lock.Unlock()
+			if e != nil && err == nil {
+				err = e
+			}
+			results[i] = v
+		}); e != nil {
+			lock.Lock()
+			if err == nil {
+				err = e
+			}
+			lock.Unlock()
+			wg.Done()
+		}
+	}
+	wg.Wait()
+	if err != nil {
+		return ScanStats{}, err
+	}
+
+	var stats ScanStats
+	for _, v := range results {
+		stats.merge(v)
+	}
+	return stats, nil
+}
+
+// scanStats returns the stats of the shards in [start, end) one by one
+func (c *kvClient) scanStats(ctx context.Context, start, end []byte) ([]RangeScanStats, error) {
+	var ranges []RangeScanStats
+	cmd := executor.ScanChecksumRequest{Start: start, End: end}
+	for {
+		f := c.cli.Read(ctx, executor.CmdKVScanStats, cmd.Marshal(),
+			WithReplicaSelectPolicy(c.policy),
+			WithRouteKey(cmd.Start),
+			WithShardGroup(c.shardGroup))
+		v, err := f.Get()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		r := RangeScanStats{Start: cmd.Start}
+		if err := r.ScanStatsResponse.Unmarshal(v); err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+
+		// r.End >= end, completed
+		if len(r.End) == 0 ||
+			(len(end) > 0 && bytes.Compare(r.End, end) >= 0) {
+			return ranges, nil
+		}
+		cmd.Start = r.End
+	}
+}
+
+func (c *kvClient) NewReadSnapshot(ctx context.Context, start, end []byte, ttl time.Duration) (ReadSnapshot, error) {
+	return newReadSnapshot(ctx, c.cli, c.shardGroup, start, end, ttl)
+}
+
+func containsStore(stores []uint64, id uint64) bool {
+	for _, v := range stores {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
 type keyRange struct {
 	start []byte
 	end   []byte
@@ -0,0 +1,357 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+)
+
+// HashedKeyPrefixLen the length of the bucket prefix of the hashed keys
+const HashedKeyPrefixLen = 4
+
+// KeyHasher maps the user keys into hash buckets. The hashed KVClient prepends
+// the bucket to the user keys, so the sequential keys are spread across the
+// shards of the buckets.
+type KeyHasher interface {
+	// Buckets returns the number of the buckets, it must not be changed once
+	// the keys are written.
+	Buckets() uint32
+	// Bucket returns the bucket of the key, in the range [0, Buckets()).
+	Bucket(key []byte) uint32
+}
+
+type fnvKeyHasher struct {
+	buckets uint32
+}
+
+// NewFNVKeyHasher returns a KeyHasher which maps the keys into the buckets by
+// the FNV-1a hash.
+func NewFNVKeyHasher(buckets uint32) KeyHasher {
+	if buckets == 0 {
+		panic("the number of buckets must be greater than 0")
+	}
+	return fnvKeyHasher{buckets: buckets}
+}
+
+func (h fnvKeyHasher) Buckets() uint32 {
+	return h.buckets
+}
+
+func (h fnvKeyHasher) Bucket(key []byte) uint32 {
+	fh := fnv.New32a()
+	fh.Write(key)
+	return fh.Sum32() % h.buckets
+}
+
+// EncodeHashedKey returns the key with the bucket prefix
+func EncodeHashedKey(hasher KeyHasher, key []byte) []byte {
+	return encodeBucketKey(hasher.Bucket(key), key)
+}
+
+// DecodeHashedKey returns the bucket and the user key of the hashed key
+func DecodeHashedKey(key []byte) (uint32, []byte) {
+	if len(key) < HashedKeyPrefixLen {
+		panic("invalid hashed key")
+	}
+	return binary.BigEndian.Uint32(key), key[HashedKeyPrefixLen:]
+}
+
+// BucketRange returns the key range [start, end) of the bucket
+func BucketRange(bucket uint32) ([]byte, []byte) {
+	return encodeBucketKey(bucket, nil), encodeBucketKey(bucket+1, nil)
+}
+
+// BucketShards returns the shards of the group, one shard per bucket, used to
+// create the initial shards of the hashed group, e.g. by
+// config.Customize.CustomInitShardsFactory. The first shard starts from the
+// min key, the last shard ends at the max key.
+func BucketShards(hasher KeyHasher, group uint64) []metapb.Shard {
+	shards := make([]metapb.Shard, 0, hasher.Buckets())
+	for bucket := uint32(0); bucket < hasher.Buckets(); bucket++ {
+		start, end := BucketRange(bucket)
+		if bucket == 0 {
+			start = nil
+		}
+		if bucket == hasher.Buckets()-1 {
+			end = nil
+		}
+		shards = append(shards, metapb.Shard{Group: group, Start: start, End: end})
+	}
+	return shards
+}
+
+func encodeBucketKey(bucket uint32, key []byte) []byte {
+	v := make([]byte, HashedKeyPrefixLen+len(key))
+	binary.BigEndian.PutUint32(v, bucket)
+	copy(v[HashedKeyPrefixLen:], key)
+	return v
+}
+
+// bucketRange returns the range [start, end) of the user keys in the bucket,
+// the empty end means the end of the bucket.
+func bucketRange(bucket uint32, start, end []byte) ([]byte, []byte) {
+	if len(end) == 0 {
+		_, bucketEnd := BucketRange(bucket)
+		return encodeBucketKey(bucket, start), bucketEnd
+	}
+	return encodeBucketKey(bucket, start), encodeBucketKey(bucket, end)
+}
+
+type hashedKVClient struct {
+	*kvClient
+	hasher KeyHasher
+}
+
+// NewHashedKVClient returns a KVClient of the hashed shard group, all the keys
+// of the group are prepended with the bucket prefix by the hasher, and the
+// prefix is stripped from the keys returned. The point operations are routed
+// to the bucket of the key.
+//
+// The range operations are performed in each bucket, only the keys are sorted
+// within a bucket, the keys in the different buckets are returned in the order
+// of the buckets. The empty end key of the range operations means no upper
+// bound. The keys of the batch writes must be in the same shard, which usually
+// means the same bucket.
+func NewHashedKVClient(cli Client, shardGroup uint64, policy rpcpb.ReplicaSelectPolicy, hasher KeyHasher) KVClient {
+	return &hashedKVClient{
+		kvClient: NewKVClient(cli, shardGroup, policy).(*kvClient),
+		hasher:   hasher,
+	}
+}
+
+func (c *hashedKVClient) Set(ctx context.Context, key, value []byte) *Future {
+	return c.kvClient.Set(ctx, EncodeHashedKey(c.hasher, key), value)
+}
+
+func (c *hashedKVClient) BatchSet(ctx context.Context, keys, values [][]byte) *Future {
+	return c.kvClient.BatchSet(ctx, c.encodeKeys(keys), values)
+}
+
+func (c *hashedKVClient) Delete(ctx context.Context, key []byte) *Future {
+	return c.kvClient.Delete(ctx, EncodeHashedKey(c.hasher, key))
+}
+
+func (c *hashedKVClient) BatchDelete(ctx context.Context, keys [][]byte) *Future {
+	return c.kvClient.BatchDelete(ctx, c.encodeKeys(keys))
+}
+
+func (c *hashedKVClient) RangeDelete(ctx context.Context, start, end []byte) *Future {
+	f := newFuture(ctx)
+	err := c.stopper.RunTask(ctx, func(ctx context.Context) {
+		for bucket := uint32(0); bucket < c.hasher.Buckets(); bucket++ {
+			bucketStart, bucketEnd := bucketRange(bucket, start, end)
+			sf := c.kvClient.RangeDelete(ctx, bucketStart, bucketEnd)
+			err := sf.GetError()
+			sf.Close()
+			if err != nil {
+				f.done(nil, nil, err)
+				return
+			}
+		}
+		f.done(nil, nil, nil)
+	})
+	if err != nil {
+		f.done(nil, nil, err)
+	}
+	return f
+}
+
+func (c *hashedKVClient) CompareAndSet(ctx context.Context, key, expected, value []byte) *Future {
+	return c.kvClient.CompareAndSet(ctx, EncodeHashedKey(c.hasher, key), expected, value)
+}
+
+func (c *hashedKVClient) ConditionalWrite(ctx context.Context, req executor.ConditionalWriteRequest) *Future {
+	req.Key = EncodeHashedKey(c.hasher, req.Key)
+	return c.kvClient.ConditionalWrite(ctx, req)
+}
+
+func (c *hashedKVClient) Increment(ctx context.Context, key []byte, delta int64) *Future {
+	return c.kvClient.Increment(ctx, EncodeHashedKey(c.hasher, key), delta)
+}
+
+func (c *hashedKVClient) Get(ctx context.Context, key []byte) *Future {
+	return c.kvClient.Get(ctx, EncodeHashedKey(c.hasher, key))
+}
+
+// BatchGet the values are returned in the order of the sorted keys, same as
+// the KVClient of the unhashed group.
+func (c *hashedKVClient) BatchGet(ctx context.Context, keys [][]byte) *Future {
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+	hashedKeys := c.encodeKeys(keys)
+	f := newFuture(ctx)
+	err := c.stopper.RunTask(ctx, func(ctx context.Context) {
+		sf := c.kvClient.BatchGet(ctx, hashedKeys)
+		resp, err := sf.GetKVBatchGetResponse()
+		sf.Close()
+		if err != nil {
+			f.done(nil, nil, err)
+			return
+		}
+
+		// the hashed keys are sorted by the bucket
+		m := make(map[string][]byte, len(hashedKeys))
+		for i, key := range hashedKeys {
+			m[string(key)] = resp.Values[i]
+		}
+		values := make([][]byte, 0, len(keys))
+		for _, key := range keys {
+			values = append(values, m[string(EncodeHashedKey(c.hasher, key))])
+		}
+		f.kvBatchGetDone(values)
+	})
+	if err != nil {
+		f.done(nil, nil, err)
+	}
+	return f
+}
+
+func (c *hashedKVClient) Scan(ctx context.Context, start, end []byte, handler ScanHandler, options ...ScanOption) error {
+	return c.scanBuckets(start, end, handler, func(start, end []byte, handler ScanHandler) error {
+		return c.kvClient.Scan(ctx, start, end, handler, options...)
+	})
+}
+
+func (c *hashedKVClient) ScanCount(ctx context.Context, start, end []byte) (uint64, error) {
+	n := uint64(0)
+	for bucket := uint32(0); bucket < c.hasher.Buckets(); bucket++ {
+		bucketStart, bucketEnd := bucketRange(bucket, start, end)
+		v, err := c.kvClient.ScanCount(ctx, bucketStart, bucketEnd)
+		if err != nil {
+			return 0, err
+		}
+		n += v
+	}
+	return n, nil
+}
+
+// ParallelScan the buckets are scanned one by one, the shards of a bucket are
+// scanned parallelly.
+func (c *hashedKVClient) ParallelScan(ctx context.Context, start, end []byte, handler ScanHandler, options ...ScanOption) error {
+	return c.scanBuckets(start, end, handler, func(start, end []byte, handler ScanHandler) error {
+		return c.kvClient.ParallelScan(ctx, start, end, handler, options...)
+	})
+}
+
+func (c *hashedKVClient) ScanChecksum(ctx context.Context, start, end []byte, stores ...uint64) ([]ReplicaScanChecksum, error) {
+	var results []ReplicaScanChecksum
+	for bucket := uint32(0); bucket < c.hasher.Buckets(); bucket++ {
+		bucketStart, bucketEnd := bucketRange(bucket, start, end)
+		v, err := c.kvClient.ScanChecksum(ctx, bucketStart, bucketEnd, stores...)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, v...)
+	}
+	return results, nil
+}
+
+func (c *hashedKVClient) ScanStats(ctx context.Context, start, end []byte) (ScanStats, error) {
+	var stats ScanStats
+	for bucket := uint32(0); bucket < c.hasher.Buckets(); bucket++ {
+		bucketStart, bucketEnd := bucketRange(bucket, start, end)
+		v, err := c.kvClient.ScanStats(ctx, bucketStart, bucketEnd)
+		if err != nil {
+			return ScanStats{}, err
+		}
+		stats.merge(v.Ranges)
+	}
+	return stats, nil
+}
+
+func (c *hashedKVClient) NewReadSnapshot(ctx context.Context, start, end []byte, ttl time.Duration) (ReadSnapshot, error) {
+	// all the buckets are pinned by a read snapshot, the range of it
+	// contains the shards of all the buckets
+	first, _ := BucketRange(0)
+	_, last := BucketRange(c.hasher.Buckets() - 1)
+	rs, err := c.kvClient.NewReadSnapshot(ctx, first, last, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &hashedReadSnapshot{
+		ReadSnapshot: rs,
+		hashed:       c,
+		start:        keysutil.Clone(start),
+		end:          keysutil.Clone(end),
+	}, nil
+}
+
+func (c *hashedKVClient) encodeKeys(keys [][]byte) [][]byte {
+	hashedKeys := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		hashedKeys = append(hashedKeys, EncodeHashedKey(c.hasher, key))
+	}
+	return hashedKeys
+}
+
+// scanBuckets calls the scan func with the range of each bucket, the bucket
+// prefix is stripped before the keys are passed to the handler.
+func (c *hashedKVClient) scanBuckets(start, end []byte, handler ScanHandler,
+	scan func(start, end []byte, handler ScanHandler) error) error {
+	stopped := false
+	stripped := func(key, value []byte) (bool, error) {
+		_, key = DecodeHashedKey(key)
+		next, err := handler(key, value)
+		if err == nil && !next {
+			stopped = true
+		}
+		return next, err
+	}
+	for bucket := uint32(0); bucket < c.hasher.Buckets() && !stopped; bucket++ {
+		bucketStart, bucketEnd := bucketRange(bucket, start, end)
+		if err := scan(bucketStart, bucketEnd, stripped); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashedReadSnapshot the read snapshot of the hashed group, start and end are
+// the range of the user keys.
+type hashedReadSnapshot struct {
+	ReadSnapshot
+	hashed     *hashedKVClient
+	start, end []byte
+}
+
+func (rs *hashedReadSnapshot) Get(ctx context.Context, key []byte) ([]byte, error) {
+	if bytes.Compare(key, rs.start) < 0 ||
+		(len(rs.end) > 0 && bytes.Compare(key, rs.end) >= 0) {
+		return nil, ErrKeyNotInReadSnapshot
+	}
+	return rs.ReadSnapshot.Get(ctx, EncodeHashedKey(rs.hashed.hasher, key))
+}
+
+func (rs *hashedReadSnapshot) Scan(ctx context.Context, start, end []byte, handler ScanHandler, options ...ScanOption) error {
+	if bytes.Compare(start, rs.start) < 0 {
+		start = rs.start
+	}
+	if len(rs.end) > 0 && (len(end) == 0 || bytes.Compare(end, rs.end) > 0) {
+		end = rs.end
+	}
+	return rs.hashed.scanBuckets(start, end, handler, func(start, end []byte, handler ScanHandler) error {
+		return rs.ReadSnapshot.Scan(ctx, start, end, handler, options...)
+	})
+}
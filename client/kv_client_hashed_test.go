@@ -0,0 +1,180 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/raftstore"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashedKey(t *testing.T) {
+	hasher := NewFNVKeyHasher(8)
+	counts := make(map[uint32]int)
+	for i := 0; i < 800; i++ {
+		key := []byte(fmt.Sprintf("k%05d", i))
+		hashed := EncodeHashedKey(hasher, key)
+		bucket, decoded := DecodeHashedKey(hashed)
+		assert.Equal(t, hasher.Bucket(key), bucket)
+		assert.Equal(t, key, decoded)
+		start, end := BucketRange(bucket)
+		assert.True(t, string(hashed) >= string(start) && string(hashed) < string(end))
+		counts[bucket]++
+	}
+	assert.Equal(t, 8, len(counts))
+	for bucket, n := range counts {
+		assert.True(t, n > 50, "bucket %d has %d keys", bucket, n)
+	}
+
+	assert.Panics(t, func() { NewFNVKeyHasher(0) })
+	assert.Panics(t, func() { DecodeHashedKey([]byte{1}) })
+}
+
+func TestBucketShards(t *testing.T) {
+	shards := BucketShards(NewFNVKeyHasher(3), 1)
+	require.Equal(t, 3, len(shards))
+	assert.Equal(t, metapb.Shard{Group: 1, End: []byte{0, 0, 0, 1}}, shards[0])
+	assert.Equal(t, metapb.Shard{Group: 1, Start: []byte{0, 0, 0, 1}, End: []byte{0, 0, 0, 2}}, shards[1])
+	assert.Equal(t, metapb.Shard{Group: 1, Start: []byte{0, 0, 0, 2}}, shards[2])
+
+	shards = BucketShards(NewFNVKeyHasher(1), 0)
+	assert.Equal(t, []metapb.Shard{{}}, shards)
+}
+
+func TestHashedKVClient(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	hasher := NewFNVKeyHasher(4)
+	c := raftstore.NewSingleTestClusterStore(t, raftstore.WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+		cfg.Customize.CustomInitShardsFactory = func() []metapb.Shard {
+			return BucketShards(hasher, 0)
+		}
+	}))
+	c.Start()
+	defer c.Stop()
+	c.WaitShardByCountPerNode(4, time.Second*10)
+
+	s := NewClient(Cfg{Store: c.GetStore(0)})
+	assert.NoError(t, s.Start())
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+
+	kv := NewHashedKVClient(s, 0, rpcpb.SelectLeader, hasher)
+	defer kv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var keys [][]byte
+	for i := 0; i < 20; i++ {
+		key := []byte(fmt.Sprintf("k%02d", i))
+		keys = append(keys, key)
+		f := kv.Set(ctx, key, key)
+		assert.NoError(t, f.GetError())
+		f.Close()
+	}
+
+	f := kv.Get(ctx, keys[1])
+	resp, err := f.GetKVGetResponse()
+	f.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, keys[1], resp.Value)
+
+	// the sequential keys are spread across the shards
+	unhashed := NewKVClient(s, 0, rpcpb.SelectLeader)
+	defer unhashed.Close()
+	for bucket := uint32(0); bucket < hasher.Buckets(); bucket++ {
+		start, end := BucketRange(bucket)
+		n, err := unhashed.ScanCount(ctx, start, end)
+		assert.NoError(t, err)
+		assert.True(t, n > 0, "bucket %d is empty", bucket)
+	}
+
+	f = kv.BatchGet(ctx, [][]byte{keys[3], keys[1], []byte("k99"), keys[2]})
+	batchResp, err := f.GetKVBatchGetResponse()
+	f.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{keys[1], keys[2], keys[3], {}}, batchResp.Values)
+
+	var scanned []string
+	assert.NoError(t, kv.Scan(ctx, []byte("k05"), []byte("k15"), func(key, value []byte) (bool, error) {
+		assert.Equal(t, key, value)
+		scanned = append(scanned, string(key))
+		return true, nil
+	}, ScanWithValue()))
+	sort.Strings(scanned)
+	assert.Equal(t, 10, len(scanned))
+	assert.Equal(t, "k05", scanned[0])
+	assert.Equal(t, "k14", scanned[9])
+
+	n := 0
+	assert.NoError(t, kv.ParallelScan(ctx, nil, nil, func(key, value []byte) (bool, error) {
+		n++
+		return n < 3, nil
+	}))
+	assert.Equal(t, 3, n)
+
+	count, err := kv.ScanCount(ctx, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20), count)
+
+	rs, err := kv.NewReadSnapshot(ctx, []byte("k10"), nil, 0)
+	require.NoError(t, err)
+	_, err = rs.Get(ctx, keys[1])
+	assert.Equal(t, ErrKeyNotInReadSnapshot, err)
+	value, err := rs.Get(ctx, keys[11])
+	assert.NoError(t, err)
+	assert.Equal(t, keys[11], value)
+
+	// the batch writes in the same bucket
+	var sameBucket [][]byte
+	for _, key := range keys[10:] {
+		if hasher.Bucket(key) == hasher.Bucket(keys[10]) {
+			sameBucket = append(sameBucket, key)
+		}
+	}
+	f = kv.BatchDelete(ctx, sameBucket)
+	assert.NoError(t, f.GetError())
+	f.Close()
+	count, err = kv.ScanCount(ctx, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20-len(sameBucket)), count)
+
+	f = kv.RangeDelete(ctx, []byte("k10"), nil)
+	assert.NoError(t, f.GetError())
+	f.Close()
+	count, err = kv.ScanCount(ctx, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), count)
+
+	// the read snapshot is not affected by the writes
+	n = 0
+	assert.NoError(t, rs.Scan(ctx, nil, nil, func(key, value []byte) (bool, error) {
+		n++
+		return true, nil
+	}))
+	assert.Equal(t, 10, n)
+	assert.NoError(t, rs.Release(ctx))
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/matrixorigin/matrixcube/raftstore"
+	"github.com/matrixorigin/matrixcube/storage/executor"
 	"github.com/matrixorigin/matrixcube/util/leaktest"
 	"github.com/stretchr/testify/assert"
 )
@@ -72,6 +73,87 @@ func TestKVSetAndGetAndDelete(t *testing.T) {
 	assert.Empty(t, resp.Value)
 }
 
+func TestKVConditionalWrite(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := raftstore.NewSingleTestClusterStore(t)
+	c.Start()
+	defer c.Stop()
+
+	s := NewClient(Cfg{Store: c.GetStore(0)})
+	assert.NoError(t, s.Start())
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+
+	kv := NewKVClient(s, 0, rpcpb.SelectLeader)
+	defer kv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	k1 := []byte("k1")
+	f := kv.CompareAndSet(ctx, k1, nil, []byte("v1"))
+	resp, err := f.GetCompareAndSetResponse()
+	f.Close()
+	assert.NoError(t, err)
+	assert.True(t, resp.Succeeded)
+
+	f = kv.CompareAndSet(ctx, k1, []byte("v0"), []byte("v2"))
+	resp, err = f.GetCompareAndSetResponse()
+	f.Close()
+	assert.NoError(t, err)
+	assert.False(t, resp.Succeeded)
+	assert.Equal(t, []byte("v1"), resp.Value)
+
+	f = kv.ConditionalWrite(ctx, executor.ConditionalWriteRequest{Key: k1,
+		Condition: executor.ConditionExists, Op: executor.ConditionalDelete})
+	resp, err = f.GetCompareAndSetResponse()
+	f.Close()
+	assert.NoError(t, err)
+	assert.True(t, resp.Succeeded)
+
+	f = kv.Get(ctx, k1)
+	getResp, err := f.GetKVGetResponse()
+	f.Close()
+	assert.NoError(t, err)
+	assert.Empty(t, getResp.Value)
+}
+
+func TestKVIncrement(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := raftstore.NewSingleTestClusterStore(t)
+	c.Start()
+	defer c.Stop()
+
+	s := NewClient(Cfg{Store: c.GetStore(0)})
+	assert.NoError(t, s.Start())
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+
+	kv := NewKVClient(s, 0, rpcpb.SelectLeader)
+	defer kv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for i, delta := range []int64{1, 2, -5} {
+		f := kv.Increment(ctx, []byte("counter"), delta)
+		value, err := f.GetIncrementResponse()
+		f.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1, 3, -2}[i], value)
+	}
+
+	f := kv.Set(ctx, []byte("k1"), []byte("v1"))
+	assert.NoError(t, f.GetError())
+	f.Close()
+	f = kv.Increment(ctx, []byte("k1"), 1)
+	_, err := f.GetIncrementResponse()
+	f.Close()
+	assert.Equal(t, ErrIncrementFailed, err)
+}
+
 func TestKVBatchSetAndBatchGet(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -421,6 +503,59 @@ func TestKVParallelScan(t *testing.T) {
 	}
 }
 
+func TestKVScanStats(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := raftstore.NewSingleTestClusterStore(t, raftstore.WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+		cfg.Customize.CustomInitShardsFactory = func() []metapb.Shard {
+			return []metapb.Shard{
+				{Start: []byte("k1"), End: []byte("k3")},
+				{Start: []byte("k3"), End: []byte("k5")},
+				{Start: []byte("k5"), End: nil},
+			}
+		}
+	}))
+	c.Start()
+	defer c.Stop()
+
+	s := NewClient(Cfg{Store: c.GetStore(0)})
+	assert.NoError(t, s.Start())
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+
+	kv := NewKVClient(s, 0, rpcpb.SelectLeader)
+	defer kv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, k := range []string{"k1", "k2", "k3", "k4", "k5", "k6"} {
+		f := kv.Set(ctx, []byte(k), []byte(k+"v"))
+		assert.NoError(t, f.GetError())
+		f.Close()
+	}
+
+	stats, err := kv.ScanStats(ctx, []byte("k2"), []byte("k6"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), stats.Keys)
+	assert.Equal(t, uint64(12), stats.ValueBytes)
+	assert.Equal(t, 3, len(stats.Ranges))
+	assert.Equal(t, []byte("k2"), stats.Ranges[0].Start)
+	assert.Equal(t, []byte("k3"), stats.Ranges[0].End)
+	assert.Equal(t, uint64(1), stats.Ranges[0].Keys)
+	assert.Equal(t, []byte("k5"), stats.Ranges[2].Start)
+	assert.Equal(t, []byte("k6"), stats.Ranges[2].End)
+	for _, r := range stats.Ranges {
+		assert.True(t, r.AppliedIndex > 0)
+	}
+
+	stats, err = kv.ScanStats(ctx, []byte("k1"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), stats.Keys)
+	assert.Equal(t, uint64(18), stats.ValueBytes)
+	assert.Empty(t, stats.Ranges[len(stats.Ranges)-1].End)
+}
+
 func addTestShard(router raftstore.Router, shardID uint64, shardInfo string) {
 	b := raftstore.NewTestDataBuilder()
 	s := b.CreateShard(shardID, shardInfo)
@@ -439,3 +574,138 @@ func newTestRaftstoreClient(router raftstore.Router, handler func(rpcpb.Request)
 	}
 	return c
 }
+
+func TestKVScanChecksum(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode.")
+		return
+	}
+
+	defer leaktest.AfterTest(t)()
+
+	c := raftstore.NewTestClusterStore(t, raftstore.WithTestClusterNodeCount(3))
+	c.Start()
+	defer c.Stop()
+	c.WaitShardByCountPerNode(1, time.Minute)
+	c.WaitLeadersByCount(1, time.Minute)
+
+	s := NewClient(Cfg{Store: c.GetStore(0)})
+	assert.NoError(t, s.Start())
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+
+	kv := NewKVClient(s, 0, rpcpb.SelectLeader)
+	defer kv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, k := range []string{"a", "b", "c"} {
+		f := kv.Set(ctx, []byte(k), []byte(k))
+		assert.NoError(t, f.GetError())
+		f.Close()
+	}
+
+	var results []ReplicaScanChecksum
+	var err error
+	// wait all replicas applied to the same index
+	for i := 0; i < 100; i++ {
+		results, err = kv.ScanChecksum(ctx, []byte("a"), []byte("c"))
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(results))
+		if results[0].Err == nil && results[0].AppliedIndex == results[1].AppliedIndex &&
+			results[0].AppliedIndex == results[2].AppliedIndex {
+			break
+		}
+		time.Sleep(time.Millisecond * 100)
+	}
+	for _, r := range results {
+		assert.NoError(t, r.Err)
+		assert.Equal(t, uint64(2), r.Keys)
+		assert.Equal(t, results[0].Checksum, r.Checksum)
+	}
+
+	results, err = kv.ScanChecksum(ctx, nil, nil, c.GetStore(1).Meta().ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(results))
+	assert.Equal(t, c.GetStore(1).Meta().ID, results[0].Replica.StoreID)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, uint64(3), results[0].Keys)
+}
+
+func TestKVReadSnapshot(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := raftstore.NewSingleTestClusterStore(t, raftstore.WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+		cfg.Customize.CustomInitShardsFactory = func() []metapb.Shard {
+			return []metapb.Shard{
+				{Start: []byte("k1"), End: []byte("k3")},
+				{Start: []byte("k3"), End: nil},
+			}
+		}
+	}))
+	c.Start()
+	defer c.Stop()
+
+	s := NewClient(Cfg{Store: c.GetStore(0)})
+	assert.NoError(t, s.Start())
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+
+	kv := NewKVClient(s, 0, rpcpb.SelectLeader)
+	defer kv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, k := range []string{"k1", "k2", "k3", "k4"} {
+		f := kv.Set(ctx, []byte(k), []byte(k))
+		assert.NoError(t, f.GetError())
+		f.Close()
+	}
+
+	rs, err := kv.NewReadSnapshot(ctx, nil, nil, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(rs.Shards()))
+	for _, s := range rs.Shards() {
+		assert.True(t, s.AppliedIndex > 0)
+	}
+
+	// the writes after the read snapshot created are invisible
+	f := kv.Set(ctx, []byte("k2"), []byte("x"))
+	assert.NoError(t, f.GetError())
+	f.Close()
+	f = kv.Delete(ctx, []byte("k3"))
+	assert.NoError(t, f.GetError())
+	f.Close()
+	f = kv.Set(ctx, []byte("k5"), []byte("k5"))
+	assert.NoError(t, f.GetError())
+	f.Close()
+
+	v, err := rs.Get(ctx, []byte("k2"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("k2"), v)
+	v, err = rs.Get(ctx, []byte("k5"))
+	assert.NoError(t, err)
+	assert.Empty(t, v)
+
+	for _, options := range [][]ScanOption{{ScanWithValue()}, {ScanWithValue(), ScanWithLimit(1)}} {
+		var keys, values [][]byte
+		assert.NoError(t, rs.Scan(ctx, []byte("k2"), []byte("k9"), func(key, value []byte) (bool, error) {
+			keys = append(keys, key)
+			values = append(values, value)
+			return true, nil
+		}, options...))
+		assert.Equal(t, [][]byte{[]byte("k2"), []byte("k3"), []byte("k4")}, keys)
+		assert.Equal(t, [][]byte{[]byte("k2"), []byte("k3"), []byte("k4")}, values)
+	}
+
+	assert.NoError(t, rs.Release(ctx))
+	_, err = rs.Get(ctx, []byte("k2"))
+	assert.Equal(t, ErrReadSnapshotExpired, err)
+	f = kv.Get(ctx, []byte("k2"))
+	resp, err := f.GetKVGetResponse()
+	f.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("x"), resp.Value)
+}
@@ -0,0 +1,327 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ValueCodec encodes and decodes the values of the TypedKVClient
+type ValueCodec interface {
+	// Encode encodes the value, the encoded value must not be empty, since the
+	// empty value is the missing key.
+	Encode(value interface{}) ([]byte, error)
+	// Decode decodes the data into the value, which is a pointer
+	Decode(data []byte, value interface{}) error
+}
+
+type protoValue interface {
+	Marshal() ([]byte, error)
+	Unmarshal([]byte) error
+}
+
+type defaultValueCodec struct{}
+
+// NewDefaultValueCodec returns the ValueCodec used by the TypedKVClient by
+// default. The []byte and string values are stored as is, the protobuf
+// messages are stored by their Marshal, and the other values are stored as
+// JSON. The value is decoded by the type of the target, so a key must be read
+// with the type it's written with.
+func NewDefaultValueCodec() ValueCodec {
+	return defaultValueCodec{}
+}
+
+func (defaultValueCodec) Encode(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	case protoValue:
+		return v.Marshal()
+	default:
+		return json.Marshal(value)
+	}
+}
+
+func (defaultValueCodec) Decode(data []byte, value interface{}) error {
+	switch v := value.(type) {
+	case *[]byte:
+		*v = append((*v)[:0], data...)
+		return nil
+	case *string:
+		*v = string(data)
+		return nil
+	case protoValue:
+		return v.Unmarshal(data)
+	default:
+		return json.Unmarshal(data, value)
+	}
+}
+
+type jsonValueCodec struct{}
+
+// NewJSONValueCodec returns the ValueCodec storing all the values as JSON
+func NewJSONValueCodec() ValueCodec {
+	return jsonValueCodec{}
+}
+
+func (jsonValueCodec) Encode(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (jsonValueCodec) Decode(data []byte, value interface{}) error {
+	return json.Unmarshal(data, value)
+}
+
+// TypedValue the encoded value returned by the TypedKVClient
+type TypedValue struct {
+	data  []byte
+	codec ValueCodec
+}
+
+// Exists returns false if the key is missing
+func (v TypedValue) Exists() bool {
+	return len(v.data) > 0
+}
+
+// Bytes returns the encoded value
+func (v TypedValue) Bytes() []byte {
+	return v.data
+}
+
+// Decode decodes the value into the target, which is a pointer
+func (v TypedValue) Decode(target interface{}) error {
+	return v.codec.Decode(v.data, target)
+}
+
+// TypedKV the key and the value returned by the TypedKVClient
+type TypedKV struct {
+	Key   []byte
+	Value TypedValue
+}
+
+// TypedScanHandler handles the scanned key and value, returns false to stop
+// the scan
+type TypedScanHandler func(key []byte, value TypedValue) (bool, error)
+
+// TypedKVClient the synchronous KV client with the typed values, which wraps
+// the proposals of the KVClient, waits the futures and encodes and decodes the
+// values by the ValueCodec. All the methods block until the response is
+// received or the context is done, the timeout of the Policy of the Client is
+// used if the context has no deadline. Same as the KVClient, the keys of the
+// batch writes must be in the same shard.
+type TypedKVClient interface {
+	// Get decodes the value of the key into the target, returns false if the key
+	// is missing.
+	Get(ctx context.Context, key []byte, target interface{}) (bool, error)
+	// BatchGet returns the values of the keys in the order of the sorted keys,
+	// the missing keys are returned with the value which does not exist.
+	BatchGet(ctx context.Context, keys [][]byte) ([]TypedKV, error)
+	// Put sets the key to the value
+	Put(ctx context.Context, key []byte, value interface{}) error
+	// BatchPut sets the keys to the values
+	BatchPut(ctx context.Context, keys [][]byte, values []interface{}) error
+	// Delete deletes the key
+	Delete(ctx context.Context, key []byte) error
+	// BatchDelete deletes the keys
+	BatchDelete(ctx context.Context, keys [][]byte) error
+	// DeleteRange deletes the keys in range [start, end)
+	DeleteRange(ctx context.Context, start, end []byte) error
+	// Scan scans the keys and the values in the range [start, end), at most
+	// limit keys are scanned if the limit is greater than 0.
+	Scan(ctx context.Context, start, end []byte, limit uint64, handler TypedScanHandler) error
+	// CompareAndSet sets the key to the value if its current value is the
+	// expected one, the nil expected value means the key does not exist. Returns
+	// false and the current value if the condition failed.
+	CompareAndSet(ctx context.Context, key []byte, expected, value interface{}) (bool, TypedValue, error)
+	// Increment adds the delta to the counter stored in the key, and returns the
+	// new value of the counter. The counter is not encoded by the ValueCodec.
+	Increment(ctx context.Context, key []byte, delta int64) (int64, error)
+	// KVClient returns the underlying KVClient
+	KVClient() KVClient
+	// Close closes the underlying KVClient
+	Close() error
+}
+
+type typedKVClient struct {
+	kv    KVClient
+	codec ValueCodec
+}
+
+// NewTypedKVClient returns a TypedKVClient over the KVClient, e.g. the
+// KVClient returned by NewKVClient or NewHashedKVClient. The
+// NewDefaultValueCodec is used if the codec is nil.
+func NewTypedKVClient(kv KVClient, codec ValueCodec) TypedKVClient {
+	if codec == nil {
+		codec = NewDefaultValueCodec()
+	}
+	return &typedKVClient{kv: kv, codec: codec}
+}
+
+func (c *typedKVClient) KVClient() KVClient {
+	return c.kv
+}
+
+func (c *typedKVClient) Close() error {
+	return c.kv.Close()
+}
+
+func (c *typedKVClient) Get(ctx context.Context, key []byte, target interface{}) (bool, error) {
+	f := c.kv.Get(ctx, key)
+	defer f.Close()
+	resp, err := f.GetKVGetResponse()
+	if err != nil {
+		return false, err
+	}
+
+	value := c.newValue(resp.Value)
+	if !value.Exists() {
+		return false, nil
+	}
+	return true, value.Decode(target)
+}
+
+func (c *typedKVClient) BatchGet(ctx context.Context, keys [][]byte) ([]TypedKV, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	// the keys are sorted by the BatchGet, copy the keys to keep the order of
+	// the caller.
+	sorted := append([][]byte(nil), keys...)
+	f := c.kv.BatchGet(ctx, sorted)
+	defer f.Close()
+	resp, err := f.GetKVBatchGetResponse()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]TypedKV, 0, len(sorted))
+	for i, key := range sorted {
+		var v []byte
+		if i < len(resp.Values) {
+			v = resp.Values[i]
+		}
+		values = append(values, TypedKV{Key: key, Value: c.newValue(v)})
+	}
+	return values, nil
+}
+
+func (c *typedKVClient) Put(ctx context.Context, key []byte, value interface{}) error {
+	data, err := c.encode(value)
+	if err != nil {
+		return err
+	}
+	return c.wait(c.kv.Set(ctx, key, data))
+}
+
+func (c *typedKVClient) BatchPut(ctx context.Context, keys [][]byte, values []interface{}) error {
+	if len(keys) != len(values) {
+		return fmt.Errorf("%d keys and %d values mismatch", len(keys), len(values))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	data := make([][]byte, 0, len(values))
+	for _, value := range values {
+		v, err := c.encode(value)
+		if err != nil {
+			return err
+		}
+		data = append(data, v)
+	}
+	// the keys are sorted by the BatchSet after the request is built, copy the
+	// keys to keep the order of the caller.
+	return c.wait(c.kv.BatchSet(ctx, append([][]byte(nil), keys...), data))
+}
+
+func (c *typedKVClient) Delete(ctx context.Context, key []byte) error {
+	return c.wait(c.kv.Delete(ctx, key))
+}
+
+func (c *typedKVClient) BatchDelete(ctx context.Context, keys [][]byte) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return c.wait(c.kv.BatchDelete(ctx, append([][]byte(nil), keys...)))
+}
+
+func (c *typedKVClient) DeleteRange(ctx context.Context, start, end []byte) error {
+	return c.wait(c.kv.RangeDelete(ctx, start, end))
+}
+
+func (c *typedKVClient) Scan(ctx context.Context, start, end []byte, limit uint64, handler TypedScanHandler) error {
+	n := uint64(0)
+	return c.kv.Scan(ctx, start, end, func(key, value []byte) (bool, error) {
+		n++
+		next, err := handler(key, c.newValue(value))
+		if err != nil || !next {
+			return false, err
+		}
+		return limit == 0 || n < limit, nil
+	}, ScanWithValue())
+}
+
+func (c *typedKVClient) CompareAndSet(ctx context.Context, key []byte, expected, value interface{}) (bool, TypedValue, error) {
+	var expectedData []byte
+	if expected != nil {
+		v, err := c.encode(expected)
+		if err != nil {
+			return false, TypedValue{}, err
+		}
+		expectedData = v
+	}
+	data, err := c.encode(value)
+	if err != nil {
+		return false, TypedValue{}, err
+	}
+
+	f := c.kv.CompareAndSet(ctx, key, expectedData, data)
+	defer f.Close()
+	resp, err := f.GetCompareAndSetResponse()
+	if err != nil {
+		return false, TypedValue{}, err
+	}
+	return resp.Succeeded, c.newValue(resp.Value), nil
+}
+
+func (c *typedKVClient) Increment(ctx context.Context, key []byte, delta int64) (int64, error) {
+	f := c.kv.Increment(ctx, key, delta)
+	defer f.Close()
+	return f.GetIncrementResponse()
+}
+
+func (c *typedKVClient) encode(value interface{}) ([]byte, error) {
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, ErrEmptyValue
+	}
+	return data, nil
+}
+
+func (c *typedKVClient) newValue(data []byte) TypedValue {
+	return TypedValue{data: data, codec: c.codec}
+}
+
+func (c *typedKVClient) wait(f *Future) error {
+	defer f.Close()
+	return f.GetError()
+}
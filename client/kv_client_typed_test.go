@@ -0,0 +1,167 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/raftstore"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTypedValue struct {
+	Name  string
+	Count int
+}
+
+func TestDefaultValueCodec(t *testing.T) {
+	codec := NewDefaultValueCodec()
+
+	data, err := codec.Encode([]byte("v1"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), data)
+	var b []byte
+	require.NoError(t, codec.Decode(data, &b))
+	assert.Equal(t, []byte("v1"), b)
+
+	data, err = codec.Encode("v2")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), data)
+	var s string
+	require.NoError(t, codec.Decode(data, &s))
+	assert.Equal(t, "v2", s)
+
+	shard := metapb.Shard{ID: 1, Start: []byte("a")}
+	data, err = codec.Encode(&shard)
+	require.NoError(t, err)
+	assert.Equal(t, shard, decodeTestShard(t, data))
+	var decodedShard metapb.Shard
+	require.NoError(t, codec.Decode(data, &decodedShard))
+	assert.Equal(t, shard, decodedShard)
+
+	value := testTypedValue{Name: "n1", Count: 2}
+	data, err = codec.Encode(value)
+	require.NoError(t, err)
+	assert.Equal(t, `{"Name":"n1","Count":2}`, string(data))
+	var decoded testTypedValue
+	require.NoError(t, codec.Decode(data, &decoded))
+	assert.Equal(t, value, decoded)
+}
+
+func decodeTestShard(t *testing.T, data []byte) metapb.Shard {
+	var shard metapb.Shard
+	require.NoError(t, shard.Unmarshal(data))
+	return shard
+}
+
+func TestTypedKVClient(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := raftstore.NewSingleTestClusterStore(t)
+	c.Start()
+	defer c.Stop()
+
+	s := NewClient(Cfg{Store: c.GetStore(0)})
+	require.NoError(t, s.Start())
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+
+	kv := NewTypedKVClient(NewKVClient(s, 0, rpcpb.SelectLeader), nil)
+	defer kv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// put and get
+	var value testTypedValue
+	ok, err := kv.Get(ctx, []byte("k1"), &value)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, kv.Put(ctx, []byte("k1"), testTypedValue{Name: "n1", Count: 1}))
+	ok, err = kv.Get(ctx, []byte("k1"), &value)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, testTypedValue{Name: "n1", Count: 1}, value)
+	assert.Equal(t, ErrEmptyValue, kv.Put(ctx, []byte("k1"), ""))
+
+	// compare and set
+	ok, current, err := kv.CompareAndSet(ctx, []byte("k1"), nil, testTypedValue{Name: "n2"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, current.Decode(&value))
+	assert.Equal(t, testTypedValue{Name: "n1", Count: 1}, value)
+	ok, _, err = kv.CompareAndSet(ctx, []byte("k1"), testTypedValue{Name: "n1", Count: 1}, testTypedValue{Name: "n2"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, _, err = kv.CompareAndSet(ctx, []byte("k0"), nil, "v0")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// increment
+	n, err := kv.Increment(ctx, []byte("counter"), 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	n, err = kv.Increment(ctx, []byte("counter"), -1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	// batch put and batch get, the keys of the caller are not sorted
+	keys := [][]byte{[]byte("k3"), []byte("k2")}
+	require.NoError(t, kv.BatchPut(ctx, keys, []interface{}{"v3", "v2"}))
+	assert.Equal(t, [][]byte{[]byte("k3"), []byte("k2")}, keys)
+	assert.Error(t, kv.BatchPut(ctx, keys, []interface{}{"v3"}))
+	values, err := kv.BatchGet(ctx, [][]byte{[]byte("k3"), []byte("k4"), []byte("k2")})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(values))
+	assert.Equal(t, []byte("k2"), values[0].Key)
+	assert.Equal(t, []byte("v2"), values[0].Value.Bytes())
+	assert.Equal(t, []byte("k3"), values[1].Key)
+	assert.Equal(t, []byte("v3"), values[1].Value.Bytes())
+	assert.Equal(t, []byte("k4"), values[2].Key)
+	assert.False(t, values[2].Value.Exists())
+
+	// scan
+	var scanned []string
+	scan := func(limit uint64) {
+		scanned = scanned[:0]
+		require.NoError(t, kv.Scan(ctx, []byte("k"), []byte("l"), limit, func(key []byte, value TypedValue) (bool, error) {
+			var v string
+			if err := value.Decode(&v); err != nil {
+				return false, err
+			}
+			scanned = append(scanned, string(key))
+			return true, nil
+		}))
+	}
+	scan(2)
+	assert.Equal(t, []string{"k0", "k1"}, scanned)
+	scan(0)
+	assert.Equal(t, []string{"k0", "k1", "k2", "k3"}, scanned)
+
+	// delete
+	require.NoError(t, kv.Delete(ctx, []byte("k0")))
+	require.NoError(t, kv.BatchDelete(ctx, [][]byte{[]byte("k3"), []byte("k2")}))
+	scan(0)
+	assert.Equal(t, []string{"k1"}, scanned)
+	require.NoError(t, kv.DeleteRange(ctx, []byte("k"), []byte("l")))
+	scan(0)
+	assert.Empty(t, scanned)
+}
@@ -21,3 +21,19 @@ func CreateWithShardsProxy(shardsProxy raftstore.ShardsProxy) CreateOption {
 		c.shardsProxy = shardsProxy
 	}
 }
+
+// CreateWithPolicy set the timeouts and the retry policies of the requests,
+// default is DefaultPolicy().
+func CreateWithPolicy(policy Policy) CreateOption {
+	return func(c *client) {
+		c.policy = policy
+	}
+}
+
+// CreateWithHedgedReads enables the hedged reads of the follower reads and the
+// learner reads, see HedgeOptions.
+func CreateWithHedgedReads(opts HedgeOptions) CreateOption {
+	return func(c *client) {
+		c.hedger = newHedger(opts)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"time"
+
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+)
+
+// OperationClass the class of the request, the requests of different classes
+// use different timeouts and retry policies.
+type OperationClass int
+
+const (
+	// OpPointRead the read of a few keys, the default class of the read requests
+	OpPointRead OperationClass = iota
+	// OpScan the read of a key range
+	OpScan
+	// OpWrite the write requests and the transaction requests
+	OpWrite
+	// OpAdmin the admin requests
+	OpAdmin
+)
+
+func (c OperationClass) String() string {
+	switch c {
+	case OpPointRead:
+		return "point-read"
+	case OpScan:
+		return "scan"
+	case OpWrite:
+		return "write"
+	case OpAdmin:
+		return "admin"
+	default:
+		return "unknown"
+	}
+}
+
+// Backoff the backoff curve of the retries, the n-th retry is sent after
+// Initial * Multiplier^(n-1), and no later than Max. The zero Backoff uses the
+// retry interval of the ShardsProxy.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+// Next returns the interval before the n-th retry, starting from 1
+func (b Backoff) Next(n int) time.Duration {
+	if b.Initial <= 0 {
+		return 0
+	}
+	interval := float64(b.Initial)
+	for i := 1; i < n && b.Multiplier > 1; i++ {
+		interval *= b.Multiplier
+		if b.Max > 0 && interval >= float64(b.Max) {
+			return b.Max
+		}
+	}
+	if b.Max > 0 && interval > float64(b.Max) {
+		return b.Max
+	}
+	return time.Duration(interval)
+}
+
+// CallPolicy the timeout and the retry policy of a request
+type CallPolicy struct {
+	// Timeout the timeout of the request used if the context of the call has no
+	// deadline, 0 means the context must have a deadline.
+	Timeout time.Duration
+	// MaxRetries the max retries of the request for the retryable errors, 0 means
+	// retrying until the timeout.
+	MaxRetries int
+	// Backoff the backoff curve of the retries
+	Backoff Backoff
+}
+
+// Policy the CallPolicy of each OperationClass. The policy is overridden per
+// call by the options, e.g. WithTimeout.
+type Policy struct {
+	PointRead CallPolicy
+	Scan      CallPolicy
+	Write     CallPolicy
+	Admin     CallPolicy
+}
+
+// DefaultPolicy returns the default Policy, the point reads fail fast, and the
+// scans and the admin requests wait longer.
+func DefaultPolicy() Policy {
+	return Policy{
+		PointRead: CallPolicy{Timeout: 5 * time.Second},
+		Scan:      CallPolicy{Timeout: time.Minute},
+		Write:     CallPolicy{Timeout: 10 * time.Second},
+		Admin:     CallPolicy{Timeout: time.Minute},
+	}
+}
+
+func (p Policy) get(class OperationClass) CallPolicy {
+	switch class {
+	case OpScan:
+		return p.Scan
+	case OpWrite:
+		return p.Write
+	case OpAdmin:
+		return p.Admin
+	default:
+		return p.PointRead
+	}
+}
+
+// getOperationClass returns the class of the request if it is not specified by
+// WithOperationClass.
+func getOperationClass(req rpcpb.Request) OperationClass {
+	switch req.Type {
+	case rpcpb.Write, rpcpb.Txn:
+		return OpWrite
+	case rpcpb.Admin:
+		return OpAdmin
+	}
+	switch req.CustomType {
+	case uint64(rpcpb.CmdKVScan), executor.CmdKVScanChecksum, executor.CmdKVScanStats:
+		return OpScan
+	}
+	return OpPointRead
+}
+
+// WithOperationClass set the class of the request, default is derived from the
+// request type, e.g. the custom read commands are point reads.
+func WithOperationClass(class OperationClass) Option {
+	return func(f *Future) {
+		f.class = class
+		f.hasClass = true
+	}
+}
+
+// WithTimeout overrides the timeout of the request's class
+func WithTimeout(timeout time.Duration) Option {
+	return func(f *Future) {
+		f.policyOptions = append(f.policyOptions, func(p *CallPolicy) {
+			p.Timeout = timeout
+		})
+		f.hasTimeout = true
+	}
+}
+
+// WithMaxRetries overrides the max retries of the request's class
+func WithMaxRetries(retries int) Option {
+	return func(f *Future) {
+		f.policyOptions = append(f.policyOptions, func(p *CallPolicy) {
+			p.MaxRetries = retries
+		})
+	}
+}
+
+// WithBackoff overrides the retry backoff of the request's class
+func WithBackoff(backoff Backoff) Option {
+	return func(f *Future) {
+		f.policyOptions = append(f.policyOptions, func(p *CallPolicy) {
+			p.Backoff = backoff
+		})
+	}
+}
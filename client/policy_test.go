@@ -0,0 +1,134 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/pb/errorpb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/raftstore"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Initial: 10 * time.Millisecond, Max: 50 * time.Millisecond, Multiplier: 2}
+	for n, expected := range []time.Duration{10, 20, 40, 50, 50} {
+		assert.Equal(t, expected*time.Millisecond, b.Next(n+1))
+	}
+	assert.Equal(t, 10*time.Millisecond, Backoff{Initial: 10 * time.Millisecond}.Next(5))
+	assert.Equal(t, time.Duration(0), Backoff{}.Next(1), "use the ShardsProxy interval")
+}
+
+func TestGetOperationClass(t *testing.T) {
+	cases := []struct {
+		req      rpcpb.Request
+		expected OperationClass
+	}{
+		{rpcpb.Request{Type: rpcpb.Read, CustomType: uint64(rpcpb.CmdKVGet)}, OpPointRead},
+		{rpcpb.Request{Type: rpcpb.Read, CustomType: uint64(rpcpb.CmdKVScan)}, OpScan},
+		{rpcpb.Request{Type: rpcpb.Read, CustomType: executor.CmdKVScanChecksum}, OpScan},
+		{rpcpb.Request{Type: rpcpb.Write, CustomType: uint64(rpcpb.CmdKVScan)}, OpWrite},
+		{rpcpb.Request{Type: rpcpb.Txn}, OpWrite},
+		{rpcpb.Request{Type: rpcpb.Admin}, OpAdmin},
+	}
+	for i, c := range cases {
+		assert.Equal(t, c.expected, getOperationClass(c.req), "case %d", i)
+	}
+}
+
+func TestPolicyTimeout(t *testing.T) {
+	router := raftstore.NewMockRouter()
+	addTestShard(router, 1, "10/11")
+	// all requests are lost
+	sp, err := raftstore.NewMockShardsProxy(router, func(r rpcpb.Request) (rpcpb.ResponseBatch, error) {
+		return rpcpb.ResponseBatch{}, nil
+	})
+	require.NoError(t, err)
+	policy := DefaultPolicy()
+	policy.PointRead.Timeout = 10 * time.Millisecond
+	s := NewClientWithOptions(CreateWithShardsProxy(sp), CreateWithPolicy(policy))
+	require.NoError(t, s.Start())
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+
+	f := s.Read(context.Background(), 1, nil, WithShard(1))
+	assert.Equal(t, context.DeadlineExceeded, f.GetError())
+	assert.Equal(t, OpPointRead, f.class)
+	f.Close()
+
+	// the deadline of the context takes precedence
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	f = s.Read(ctx, uint64(rpcpb.CmdKVScan), nil, WithShard(1))
+	deadline, _ := f.ctx.Deadline()
+	assert.True(t, time.Until(deadline) > time.Minute)
+	f.Close()
+
+	// unless the timeout is specified by the call
+	f = s.Read(ctx, 1, nil, WithShard(1), WithOperationClass(OpScan),
+		WithTimeout(10*time.Millisecond))
+	assert.Equal(t, context.DeadlineExceeded, f.GetError())
+	assert.Equal(t, OpScan, f.class)
+	f.Close()
+}
+
+func TestPolicyRetries(t *testing.T) {
+	router := raftstore.NewMockRouter()
+	addTestShard(router, 1, "10/11")
+
+	var lock sync.Mutex
+	var sent []time.Time
+	sp, err := raftstore.NewMockShardsProxy(router, func(r rpcpb.Request) (rpcpb.ResponseBatch, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		sent = append(sent, time.Now())
+		return rpcpb.ResponseBatch{
+			Header:    rpcpb.ResponseBatchHeader{Error: errorpb.Error{Message: "retryable"}},
+			Responses: []rpcpb.Response{{ID: r.ID}},
+		}, nil
+	})
+	require.NoError(t, err)
+	policy := DefaultPolicy()
+	policy.Write.MaxRetries = 2
+	s := NewClientWithOptions(CreateWithShardsProxy(sp), CreateWithPolicy(policy))
+	require.NoError(t, s.Start())
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+
+	f := s.Write(context.Background(), 1, nil, WithShard(1))
+	assert.Error(t, f.GetError())
+	f.Close()
+	lock.Lock()
+	assert.Equal(t, 3, len(sent), "the request and 2 retries")
+	sent = sent[:0]
+	lock.Unlock()
+
+	f = s.Write(context.Background(), 1, nil, WithShard(1), WithMaxRetries(1),
+		WithBackoff(Backoff{Initial: 200 * time.Millisecond}))
+	assert.Error(t, f.GetError())
+	f.Close()
+	lock.Lock()
+	defer lock.Unlock()
+	require.Equal(t, 2, len(sent))
+	// the timeout wheel ticks every 50ms
+	assert.True(t, sent[1].Sub(sent[0]) >= 150*time.Millisecond)
+}
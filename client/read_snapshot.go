@@ -0,0 +1,249 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/fagongzi/util/protoc"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/raftstore"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/util/uuid"
+)
+
+var (
+	// ErrReadSnapshotExpired the read snapshot is released, expired, or the
+	// pinned replica is gone
+	ErrReadSnapshotExpired = errors.New("read snapshot expired")
+	// ErrKeyNotInReadSnapshot the key is out of the range of the read snapshot
+	ErrKeyNotInReadSnapshot = errors.New("key not in the range of the read snapshot")
+)
+
+// ReadSnapshot is a point in time view of the keys in a range, all the reads
+// on it are served by the pinned views of the shards, the writes after the
+// read snapshot created are invisible. The writes are not blocked by the read
+// snapshot, but the pinned views hold the storage resources until released,
+// so the read snapshot must be released after used.
+type ReadSnapshot interface {
+	// Get returns the value of the key in the read snapshot
+	Get(ctx context.Context, key []byte) ([]byte, error)
+	// Scan is similar to KVClient.Scan, but scans the read snapshot, the range
+	// is limited by the range of the read snapshot.
+	Scan(ctx context.Context, start, end []byte, handler ScanHandler, options ...ScanOption) error
+	// Shards returns the pinned shards of the read snapshot
+	Shards() []ReadSnapshotShard
+	// Release releases the pinned views, the read snapshot is unavailable after
+	// released.
+	Release(ctx context.Context) error
+}
+
+// ReadSnapshotShard the pinned shard of the read snapshot
+type ReadSnapshotShard struct {
+	// Shard the shard pinned
+	Shard raftstore.Shard
+	// StoreID the store of the replica which pins the view, all the reads of
+	// the shard are sent to it
+	StoreID uint64
+	// AppliedIndex the applied index of the pinned view
+	AppliedIndex uint64
+}
+
+type readSnapshot struct {
+	id         []byte
+	ttl        time.Duration
+	shardGroup uint64
+	cli        Client
+	shards     []ReadSnapshotShard
+}
+
+// newReadSnapshot pins the views of all the shards in [start, end). Each shard
+// is pinned by the leader after the read index, so all the writes completed
+// before the call are visible.
+func newReadSnapshot(ctx context.Context, cli Client, shardGroup uint64,
+	start, end []byte, ttl time.Duration) (ReadSnapshot, error) {
+	rs := &readSnapshot{
+		id:         uuid.NewV4().Bytes(),
+		ttl:        ttl,
+		shardGroup: shardGroup,
+		cli:        cli,
+	}
+	router := cli.Router()
+	router.AscendRangeWithoutSelectReplica(shardGroup, start, end,
+		func(shard raftstore.Shard) bool {
+			store := router.LeaderReplicaStore(shard.ID)
+			if store.ID == 0 {
+				store = router.RandomReplicaStore(shard.ID)
+			}
+			rs.shards = append(rs.shards, ReadSnapshotShard{Shard: shard, StoreID: store.ID})
+			return true
+		})
+	if len(rs.shards) == 0 {
+		return nil, ErrReplicaNotFound
+	}
+
+	futures := make([]*Future, 0, len(rs.shards))
+	for idx := range rs.shards {
+		futures = append(futures, rs.exec(ctx, &rs.shards[idx], executor.CmdKVCreateReadSnapshot, nil))
+	}
+	var err error
+	for idx, f := range futures {
+		data, e := rs.getResponse(f)
+		f.Close()
+		if e == nil && len(data) != 8 {
+			e = executor.ErrInvalidReadSnapshot
+		}
+		if e != nil {
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+		rs.shards[idx].AppliedIndex = binary.BigEndian.Uint64(data)
+	}
+	if err != nil {
+		_ = rs.Release(ctx)
+		return nil, err
+	}
+	return rs, nil
+}
+
+func (rs *readSnapshot) Shards() []ReadSnapshotShard {
+	return rs.shards
+}
+
+func (rs *readSnapshot) Get(ctx context.Context, key []byte) ([]byte, error) {
+	for idx := range rs.shards {
+		s := &rs.shards[idx]
+		if !containsKey(s.Shard, key) {
+			continue
+		}
+		f := rs.exec(ctx, s, executor.CmdKVReadSnapshotGet,
+			protoc.MustMarshal(&rpcpb.KVGetRequest{Key: key}))
+		data, err := rs.getResponse(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		var resp rpcpb.KVGetResponse
+		protoc.MustUnmarshal(&resp, data)
+		return resp.Value, nil
+	}
+	return nil, ErrKeyNotInReadSnapshot
+}
+
+func (rs *readSnapshot) Scan(ctx context.Context, start, end []byte,
+	handler ScanHandler, options ...ScanOption) error {
+	for idx := range rs.shards {
+		s := &rs.shards[idx]
+		if len(s.Shard.End) > 0 && bytes.Compare(start, s.Shard.End) >= 0 {
+			continue
+		}
+		if len(end) > 0 && bytes.Compare(end, s.Shard.Start) <= 0 {
+			return nil
+		}
+
+		next, err := rs.scanShard(ctx, s, start, end, handler, options...)
+		if err != nil || !next {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rs *readSnapshot) scanShard(ctx context.Context, s *ReadSnapshotShard,
+	start, end []byte, handler ScanHandler, options ...ScanOption) (bool, error) {
+	for {
+		req := rpcpb.KVScanRequest{
+			Start: start,
+			End:   end,
+		}
+		for _, opt := range options {
+			opt(&req)
+		}
+
+		f := rs.exec(ctx, s, executor.CmdKVReadSnapshotScan, protoc.MustMarshal(&req))
+		data, err := rs.getResponse(f)
+		f.Close()
+		if err != nil {
+			return false, err
+		}
+		var resp rpcpb.KVScanResponse
+		protoc.MustUnmarshal(&resp, data)
+
+		for i := uint64(0); i < resp.Count; i++ {
+			var v []byte
+			if len(resp.Values) > 0 {
+				v = resp.Values[i]
+			}
+			next, err := handler(resp.Keys[i], v)
+			if err != nil || !next {
+				return false, err
+			}
+		}
+		if resp.Completed {
+			return true, nil
+		}
+		start = keysutil.NextKey(resp.Keys[resp.Count-1], nil)
+	}
+}
+
+func (rs *readSnapshot) Release(ctx context.Context) error {
+	futures := make([]*Future, 0, len(rs.shards))
+	for idx := range rs.shards {
+		futures = append(futures, rs.exec(ctx, &rs.shards[idx], executor.CmdKVReleaseReadSnapshot, nil))
+	}
+	var err error
+	for _, f := range futures {
+		// released already if not found
+		if _, e := rs.getResponse(f); e != nil && e != ErrReadSnapshotExpired && err == nil {
+			err = e
+		}
+		f.Close()
+	}
+	return err
+}
+
+func (rs *readSnapshot) exec(ctx context.Context, s *ReadSnapshotShard, cmdType uint64, cmd []byte) *Future {
+	req := executor.ReadSnapshotRequest{ID: rs.id, TTL: rs.ttl, Cmd: cmd}
+	return rs.cli.ReadOnStore(ctx, s.StoreID, cmdType, req.Marshal(),
+		WithShard(s.Shard.ID),
+		WithShardGroup(rs.shardGroup),
+		WithReplicaSelectPolicy(rpcpb.SelectRandom))
+}
+
+func (rs *readSnapshot) getResponse(f *Future) ([]byte, error) {
+	v, err := f.Get()
+	if err != nil {
+		return nil, err
+	}
+	var resp executor.ReadSnapshotResponse
+	if err := resp.Unmarshal(v); err != nil {
+		return nil, err
+	}
+	if resp.NotFound {
+		return nil, ErrReadSnapshotExpired
+	}
+	return resp.Data, nil
+}
+
+func containsKey(shard raftstore.Shard, key []byte) bool {
+	return bytes.Compare(key, shard.Start) >= 0 &&
+		(len(shard.End) == 0 || bytes.Compare(key, shard.End) < 0)
+}
@@ -0,0 +1,239 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+var (
+	// ErrUnknownCommand the custom type of the request is not registered
+	ErrUnknownCommand = errors.New("unknown command")
+	// ErrDuplicateCommand the type or the name of the command is already registered
+	ErrDuplicateCommand = errors.New("duplicate command")
+	// ErrInvalidCommand the command is malformed, or the request does not match
+	// the registered command
+	ErrInvalidCommand = errors.New("invalid command")
+)
+
+// Command is a custom command of the read or write requests, identified by the
+// CustomType of the requests.
+type Command struct {
+	// Type the CustomType of the requests
+	Type uint64
+	// Name the name of the command shown by the logs and the debug tools
+	Name string
+	// RequestType the type of the requests of the command, rpcpb.Read or
+	// rpcpb.Write
+	RequestType rpcpb.CmdType
+	// Request the protobuf message of the request payloads, the payloads are
+	// opaque bytes if nil
+	Request proto.Message
+	// Response the protobuf message of the response payloads, the payloads are
+	// opaque bytes if nil
+	Response proto.Message
+}
+
+// Registry the registered custom commands. The store rejects the read and
+// write requests with the unknown custom types once the registry is set, and
+// the debug tools decode the payloads by the registered protobuf messages.
+type Registry struct {
+	mu       sync.RWMutex
+	commands map[uint64]Command
+	names    map[string]uint64
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		commands: make(map[uint64]Command),
+		names:    make(map[string]uint64),
+	}
+}
+
+// Register registers the commands, ErrDuplicateCommand is returned if the
+// type or the name is already registered, and none of the commands are
+// registered if any of them is rejected.
+func (r *Registry) Register(cmds ...Command) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	types := make(map[uint64]struct{}, len(cmds))
+	names := make(map[string]struct{}, len(cmds))
+	for _, cmd := range cmds {
+		if cmd.Name == "" ||
+			(cmd.RequestType != rpcpb.Read && cmd.RequestType != rpcpb.Write) {
+			return fmt.Errorf("%w: %d %q", ErrInvalidCommand, cmd.Type, cmd.Name)
+		}
+		if !isMessagePointer(cmd.Request) || !isMessagePointer(cmd.Response) {
+			return fmt.Errorf("%w: %q requires the pointers of the messages",
+				ErrInvalidCommand, cmd.Name)
+		}
+		_, ok := r.commands[cmd.Type]
+		if _, dup := types[cmd.Type]; ok || dup {
+			return fmt.Errorf("%w: type %d", ErrDuplicateCommand, cmd.Type)
+		}
+		_, ok = r.names[cmd.Name]
+		if _, dup := names[cmd.Name]; ok || dup {
+			return fmt.Errorf("%w: name %q", ErrDuplicateCommand, cmd.Name)
+		}
+		types[cmd.Type] = struct{}{}
+		names[cmd.Name] = struct{}{}
+	}
+
+	for _, cmd := range cmds {
+		r.commands[cmd.Type] = cmd
+		r.names[cmd.Name] = cmd.Type
+	}
+	return nil
+}
+
+// MustRegister is similar to Register, but panics if failed
+func (r *Registry) MustRegister(cmds ...Command) {
+	if err := r.Register(cmds...); err != nil {
+		panic(err)
+	}
+}
+
+// Get returns the command of the custom type
+func (r *Registry) Get(cmdType uint64) (Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cmd, ok := r.commands[cmdType]
+	return cmd, ok
+}
+
+// GetByName returns the command of the name
+func (r *Registry) GetByName(name string) (Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cmdType, ok := r.names[name]
+	if !ok {
+		return Command{}, false
+	}
+	return r.commands[cmdType], true
+}
+
+// Commands returns all the registered commands ordered by the types
+func (r *Registry) Commands() []Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cmds := make([]Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		cmds = append(cmds, cmd)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Type < cmds[j].Type })
+	return cmds
+}
+
+// Validate checks the custom type of the read or write request is registered
+// with the same request type. The other requests, and the requests of the
+// internal reserved commands not registered, e.g. the txn commands, are not
+// checked.
+func (r *Registry) Validate(req rpcpb.Request) error {
+	if req.Type != rpcpb.Read && req.Type != rpcpb.Write {
+		return nil
+	}
+
+	cmd, ok := r.Get(req.CustomType)
+	if !ok {
+		if isInternalCmd(req.CustomType) {
+			return nil
+		}
+		return fmt.Errorf("%w: %d", ErrUnknownCommand, req.CustomType)
+	}
+	if cmd.RequestType != req.Type {
+		return fmt.Errorf("%w: %s is a %s command, but the request is %s",
+			ErrInvalidCommand, cmd.Name, cmd.RequestType, req.Type)
+	}
+	return nil
+}
+
+// DecodeRequest decodes the request payload of the command, nil if the payload
+// of the command is opaque.
+func (r *Registry) DecodeRequest(cmdType uint64, payload []byte) (proto.Message, error) {
+	cmd, ok := r.Get(cmdType)
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownCommand, cmdType)
+	}
+	return decode(cmd.Request, payload)
+}
+
+// DecodeResponse decodes the response payload of the command, nil if the
+// payload of the command is opaque.
+func (r *Registry) DecodeResponse(cmdType uint64, payload []byte) (proto.Message, error) {
+	cmd, ok := r.Get(cmdType)
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownCommand, cmdType)
+	}
+	return decode(cmd.Response, payload)
+}
+
+// FormatRequest returns the text of the request payload of the command, e.g.
+// `kv-set key:"k1" value:"v1"`. The opaque payload, or the payload failed to
+// decode, is formatted in hex.
+func (r *Registry) FormatRequest(cmdType uint64, payload []byte) string {
+	return r.format(cmdType, payload, r.DecodeRequest)
+}
+
+// FormatResponse is similar to FormatRequest, but formats the response payload
+func (r *Registry) FormatResponse(cmdType uint64, payload []byte) string {
+	return r.format(cmdType, payload, r.DecodeResponse)
+}
+
+func (r *Registry) format(cmdType uint64, payload []byte,
+	decodeFunc func(uint64, []byte) (proto.Message, error)) string {
+	name := fmt.Sprintf("%d", cmdType)
+	if cmd, ok := r.Get(cmdType); ok {
+		name = cmd.Name
+	}
+
+	msg, err := decodeFunc(cmdType, payload)
+	if err != nil || msg == nil {
+		return fmt.Sprintf("%s %x", name, payload)
+	}
+	return fmt.Sprintf("%s %s", name, proto.CompactTextString(msg))
+}
+
+func isInternalCmd(cmdType uint64) bool {
+	if cmdType > math.MaxInt32 {
+		return false
+	}
+	_, ok := rpcpb.InternalCmd_name[int32(cmdType)]
+	return ok
+}
+
+func isMessagePointer(msg proto.Message) bool {
+	return msg == nil || reflect.TypeOf(msg).Kind() == reflect.Ptr
+}
+
+func decode(prototype proto.Message, payload []byte) (proto.Message, error) {
+	if prototype == nil {
+		return nil, nil
+	}
+
+	msg := reflect.New(reflect.TypeOf(prototype).Elem()).Interface().(proto.Message)
+	if err := proto.Unmarshal(payload, msg); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCommand, err)
+	}
+	return msg, nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fagongzi/util/protoc"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register(
+		Command{Type: 2, Name: "get", RequestType: rpcpb.Read},
+		Command{Type: 1, Name: "set", RequestType: rpcpb.Write, Request: &rpcpb.KVSetRequest{}},
+	))
+
+	cmd, ok := r.GetByName("set")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), cmd.Type)
+	cmds := r.Commands()
+	require.Equal(t, 2, len(cmds))
+	assert.Equal(t, "set", cmds[0].Name)
+	assert.Equal(t, "get", cmds[1].Name)
+
+	assert.True(t, errors.Is(r.Register(Command{Type: 1, Name: "put", RequestType: rpcpb.Write}), ErrDuplicateCommand))
+	assert.True(t, errors.Is(r.Register(Command{Type: 3, Name: "set", RequestType: rpcpb.Write}), ErrDuplicateCommand))
+	assert.True(t, errors.Is(r.Register(Command{Type: 3, Name: "", RequestType: rpcpb.Write}), ErrInvalidCommand))
+	assert.True(t, errors.Is(r.Register(Command{Type: 3, Name: "admin", RequestType: rpcpb.Admin}), ErrInvalidCommand))
+
+	// all the commands are rejected if any of them is rejected
+	assert.True(t, errors.Is(r.Register(
+		Command{Type: 3, Name: "delete", RequestType: rpcpb.Write},
+		Command{Type: 4, Name: "delete", RequestType: rpcpb.Write},
+	), ErrDuplicateCommand))
+	_, ok = r.Get(3)
+	assert.False(t, ok)
+	assert.Panics(t, func() { r.MustRegister(Command{Type: 1, Name: "set", RequestType: rpcpb.Write}) })
+}
+
+func TestValidate(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(Command{Type: 10000, Name: "set", RequestType: rpcpb.Write})
+
+	assert.NoError(t, r.Validate(rpcpb.Request{Type: rpcpb.Write, CustomType: 10000}))
+	assert.True(t, errors.Is(r.Validate(rpcpb.Request{Type: rpcpb.Write, CustomType: 10001}), ErrUnknownCommand))
+	assert.True(t, errors.Is(r.Validate(rpcpb.Request{Type: rpcpb.Read, CustomType: 10000}), ErrInvalidCommand))
+	assert.NoError(t, r.Validate(rpcpb.Request{Type: rpcpb.Write, CustomType: uint64(rpcpb.CmdUpdateTxnRecord)}),
+		"the internal commands are not checked")
+	assert.NoError(t, r.Validate(rpcpb.Request{Type: rpcpb.Admin, CustomType: 10001}))
+}
+
+func TestDecodeAndFormat(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(Command{Type: 1, Name: "set", RequestType: rpcpb.Write,
+		Request: &rpcpb.KVSetRequest{}, Response: &rpcpb.KVSetResponse{}})
+	r.MustRegister(Command{Type: 2, Name: "opaque", RequestType: rpcpb.Write})
+
+	payload := protoc.MustMarshal(&rpcpb.KVSetRequest{Key: []byte("k1"), Value: []byte("v1")})
+	msg, err := r.DecodeRequest(1, payload)
+	require.NoError(t, err)
+	assert.Equal(t, &rpcpb.KVSetRequest{Key: []byte("k1"), Value: []byte("v1")}, msg)
+	assert.Equal(t, `set key:"k1" value:"v1" `, r.FormatRequest(1, payload))
+
+	msg, err = r.DecodeRequest(2, payload)
+	assert.NoError(t, err)
+	assert.Nil(t, msg)
+	assert.Equal(t, "opaque 0a026b3112027631", r.FormatRequest(2, payload))
+
+	_, err = r.DecodeResponse(3, nil)
+	assert.True(t, errors.Is(err, ErrUnknownCommand))
+	assert.Equal(t, "3 ff", r.FormatResponse(3, []byte{0xff}))
+
+	_, err = r.DecodeRequest(1, []byte{0xff})
+	assert.True(t, errors.Is(err, ErrInvalidCommand))
+	assert.Equal(t, "set ff", r.FormatRequest(1, []byte{0xff}))
+}
@@ -0,0 +1,215 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/util/stop"
+	"go.uber.org/zap"
+)
+
+// Type alert type
+type Type string
+
+const (
+	// StoreDown the store has not sent heartbeats for more than the max store
+	// down time
+	StoreDown = Type("store-down")
+	// StoreLowSpace the available space of the store is low
+	StoreLowSpace = Type("store-low-space")
+	// StoreClockSkewed the clock offset of the store exceeds the max tolerated
+	// offset, the lease based reads are disabled on the store
+	StoreClockSkewed = Type("store-clock-skewed")
+	// ShardMajorityDown the majority of the shard's voters are down, the shard
+	// is unavailable
+	ShardMajorityDown = Type("shard-majority-down")
+	// OperatorStuck the operator has been running for more than the stuck timeout
+	OperatorStuck = Type("operator-stuck")
+
+	pendingBatches = 128
+)
+
+// Alert is a significant event of the cluster. An alert is fired when it's
+// detected first, repeated if it lasts longer than the repeat interval, and
+// fired with Resolved true once it's no longer detected.
+type Alert struct {
+	Type     Type      `json:"type"`
+	StoreID  uint64    `json:"store-id,omitempty"`
+	ShardID  uint64    `json:"shard-id,omitempty"`
+	Message  string    `json:"message"`
+	Resolved bool      `json:"resolved"`
+	FiredAt  time.Time `json:"fired-at"`
+}
+
+func (a Alert) key() string {
+	return fmt.Sprintf("%s/%d/%d", a.Type, a.StoreID, a.ShardID)
+}
+
+// Handler is the go hook called with the alerts to be notified
+type Handler func(alerts []Alert)
+
+type activeAlert struct {
+	alert    Alert
+	notifyAt time.Time
+}
+
+// Notifier notifies the alerts to the webhooks and the handlers. The webhooks
+// are called by POST with the JSON encoded alerts array.
+type Notifier struct {
+	logger         *zap.Logger
+	webhooks       []string
+	handlers       []Handler
+	repeatInterval time.Duration
+	client         *http.Client
+	stopper        *stop.Stopper
+	c              chan []Alert
+
+	mu struct {
+		sync.Mutex
+		active map[string]*activeAlert
+	}
+}
+
+// NewNotifier returns a notifier
+func NewNotifier(webhooks []string, timeout, repeatInterval time.Duration,
+	handlers []Handler, logger *zap.Logger) *Notifier {
+	n := &Notifier{
+		logger:         log.Adjust(logger).Named("alert"),
+		webhooks:       webhooks,
+		handlers:       handlers,
+		repeatInterval: repeatInterval,
+		client:         &http.Client{Timeout: timeout},
+		c:              make(chan []Alert, pendingBatches),
+	}
+	n.stopper = stop.NewStopper("alert-notifier", stop.WithLogger(n.logger))
+	n.mu.active = make(map[string]*activeAlert)
+	return n
+}
+
+// Enabled returns true if any webhook or handler is registered
+func (n *Notifier) Enabled() bool {
+	return n != nil && (len(n.webhooks) > 0 || len(n.handlers) > 0)
+}
+
+// Start starts the notifier
+func (n *Notifier) Start() {
+	if !n.Enabled() {
+		return
+	}
+
+	n.stopper.RunTask(context.Background(), func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case alerts := <-n.c:
+				n.notify(alerts)
+			}
+		}
+	})
+}
+
+// Stop stops the notifier
+func (n *Notifier) Stop() {
+	n.stopper.Stop()
+}
+
+// Update updates the alerts currently detected, the new alerts, the alerts
+// lasting longer than the repeat interval and the resolved alerts are notified
+// asynchronously.
+func (n *Notifier) Update(alerts []Alert, now time.Time) {
+	if !n.Enabled() {
+		return
+	}
+
+	var values []Alert
+	n.mu.Lock()
+	detected := make(map[string]struct{}, len(alerts))
+	for _, a := range alerts {
+		key := a.key()
+		detected[key] = struct{}{}
+		if v, ok := n.mu.active[key]; ok {
+			if n.repeatInterval > 0 && now.Sub(v.notifyAt) >= n.repeatInterval {
+				v.notifyAt = now
+				values = append(values, v.alert)
+			}
+			continue
+		}
+
+		a.FiredAt = now
+		n.mu.active[key] = &activeAlert{alert: a, notifyAt: now}
+		values = append(values, a)
+	}
+	for key, v := range n.mu.active {
+		if _, ok := detected[key]; !ok {
+			delete(n.mu.active, key)
+			v.alert.Resolved = true
+			values = append(values, v.alert)
+		}
+	}
+	n.mu.Unlock()
+
+	if len(values) == 0 {
+		return
+	}
+	select {
+	case n.c <- values:
+	default:
+		n.logger.Warn("too many pending alerts, dropped",
+			zap.Int("alerts", len(values)))
+	}
+}
+
+func (n *Notifier) notify(alerts []Alert) {
+	for _, h := range n.handlers {
+		h(alerts)
+	}
+
+	if len(n.webhooks) == 0 {
+		return
+	}
+	data, err := json.Marshal(alerts)
+	if err != nil {
+		n.logger.Error("fail to marshal alerts",
+			zap.Error(err))
+		return
+	}
+	for _, url := range n.webhooks {
+		if err := n.post(url, data); err != nil {
+			n.logger.Error("fail to call alert webhook",
+				zap.String("webhook", url),
+				zap.Error(err))
+		}
+	}
+}
+
+func (n *Notifier) post(url string, data []byte) error {
+	resp, err := n.client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifierUpdate(t *testing.T) {
+	var notified [][]Alert
+	n := NewNotifier(nil, time.Second, time.Minute, []Handler{func(alerts []Alert) {
+		notified = append(notified, alerts)
+	}}, nil)
+
+	now := time.Now()
+	a1 := Alert{Type: StoreDown, StoreID: 1}
+	a2 := Alert{Type: OperatorStuck, ShardID: 1}
+	n.Update([]Alert{a1, a2}, now)
+	n.Update([]Alert{a1, a2}, now.Add(time.Second))
+	n.Update([]Alert{a1}, now.Add(time.Minute))
+	n.Update(nil, now.Add(time.Minute))
+	close(n.c)
+	for alerts := range n.c {
+		n.notify(alerts)
+	}
+
+	require.Equal(t, 3, len(notified))
+	assert.Equal(t, 2, len(notified[0]))
+	assert.Equal(t, now, notified[0][0].FiredAt)
+	// a1 repeated, a2 resolved
+	require.Equal(t, 2, len(notified[1]))
+	assert.Equal(t, StoreDown, notified[1][0].Type)
+	assert.False(t, notified[1][0].Resolved)
+	assert.Equal(t, OperatorStuck, notified[1][1].Type)
+	assert.True(t, notified[1][1].Resolved)
+	// a1 resolved
+	require.Equal(t, 1, len(notified[2]))
+	assert.Equal(t, StoreDown, notified[2][0].Type)
+	assert.True(t, notified[2][0].Resolved)
+}
+
+func TestNotifierWebhook(t *testing.T) {
+	c := make(chan []Alert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alerts []Alert
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&alerts))
+		c <- alerts
+	}))
+	defer server.Close()
+
+	n := NewNotifier([]string{server.URL}, time.Second, time.Minute, nil, nil)
+	assert.True(t, n.Enabled())
+	n.Start()
+	defer n.Stop()
+
+	n.Update([]Alert{{Type: StoreLowSpace, StoreID: 2, Message: "low space"}}, time.Now())
+	select {
+	case alerts := <-c:
+		require.Equal(t, 1, len(alerts))
+		assert.Equal(t, StoreLowSpace, alerts[0].Type)
+		assert.Equal(t, uint64(2), alerts[0].StoreID)
+		assert.Equal(t, "low space", alerts[0].Message)
+	case <-time.After(time.Second * 10):
+		assert.Fail(t, "webhook not called")
+	}
+}
+
+func TestNotifierDisabled(t *testing.T) {
+	var n *Notifier
+	assert.False(t, n.Enabled())
+
+	n = NewNotifier(nil, time.Second, time.Minute, nil, nil)
+	assert.False(t, n.Enabled())
+	n.Update([]Alert{{Type: StoreDown}}, time.Now())
+	assert.Empty(t, n.mu.active)
+}
@@ -0,0 +1,139 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"go.uber.org/zap"
+)
+
+// Record is an admin operation recorded in the audit log
+type Record struct {
+	Time time.Time `json:"time"`
+	// Operation the name of the operation, e.g. the rpc request type
+	Operation string `json:"operation"`
+	// Caller the identity of the caller, e.g. the remote address
+	Caller string `json:"caller"`
+	// StoreID the store which the caller belongs to, 0 if unknown
+	StoreID uint64 `json:"store-id,omitempty"`
+	// Params the JSON encoded parameters of the operation
+	Params json.RawMessage `json:"params,omitempty"`
+	// Error the error of the operation, empty if succeed
+	Error string `json:"error,omitempty"`
+}
+
+// Handler is the go hook called with every audit record, e.g. to ship the
+// records to a shard group. It's called synchronously with the operation, so
+// it should not block.
+type Handler func(record Record)
+
+// Logger records the admin operations as JSON lines into the append-only
+// audit file, and passes them to the handlers.
+type Logger struct {
+	logger   *zap.Logger
+	handlers []Handler
+	enabled  bool
+
+	mu struct {
+		sync.Mutex
+		f *os.File
+	}
+}
+
+// NewLogger returns an audit logger, the records are appended to the file if
+// it's not empty.
+func NewLogger(file string, handlers []Handler, logger *zap.Logger) (*Logger, error) {
+	l := &Logger{
+		logger:   log.Adjust(logger).Named("audit"),
+		handlers: handlers,
+		enabled:  file != "" || len(handlers) > 0,
+	}
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		l.mu.f = f
+	}
+	return l, nil
+}
+
+// Enabled returns true if the audit file or any handler is set
+func (l *Logger) Enabled() bool {
+	return l != nil && l.enabled
+}
+
+// Log records the operation, the record is synced to the audit file before
+// return.
+func (l *Logger) Log(record Record) {
+	if !l.Enabled() {
+		return
+	}
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+
+	l.write(record)
+	for _, h := range l.handlers {
+		h(record)
+	}
+}
+
+func (l *Logger) write(record Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.mu.f == nil {
+		return
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		l.logger.Error("fail to marshal audit record",
+			zap.String("operation", record.Operation),
+			zap.Error(err))
+		return
+	}
+	data = append(data, '\n')
+	if _, err := l.mu.f.Write(data); err != nil {
+		l.logger.Error("fail to write audit record",
+			zap.ByteString("record", data),
+			zap.Error(err))
+		return
+	}
+	if err := l.mu.f.Sync(); err != nil {
+		l.logger.Error("fail to sync audit record",
+			zap.ByteString("record", data),
+			zap.Error(err))
+	}
+}
+
+// Close closes the audit file
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.mu.f == nil {
+		return nil
+	}
+	err := l.mu.f.Close()
+	l.mu.f = nil
+	return err
+}
@@ -0,0 +1,78 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerAppendsRecords(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.log")
+	var handled []Record
+	handler := func(record Record) {
+		handled = append(handled, record)
+	}
+
+	for i := 0; i < 2; i++ {
+		l, err := NewLogger(file, []Handler{handler}, nil)
+		require.NoError(t, err)
+		assert.True(t, l.Enabled())
+		l.Log(Record{Operation: "remove-shards", Caller: "127.0.0.1:1", StoreID: 1,
+			Params: json.RawMessage(`{"ids":[1]}`)})
+		assert.NoError(t, l.Close())
+		// closed logger doesn't write the file
+		l.write(Record{Operation: "closed"})
+	}
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+	var records []Record
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var record Record
+		require.NoError(t, json.Unmarshal(s.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.NoError(t, s.Err())
+	require.Equal(t, 2, len(records))
+	for _, record := range records {
+		assert.Equal(t, "remove-shards", record.Operation)
+		assert.Equal(t, "127.0.0.1:1", record.Caller)
+		assert.Equal(t, uint64(1), record.StoreID)
+		assert.JSONEq(t, `{"ids":[1]}`, string(record.Params))
+		assert.False(t, record.Time.IsZero())
+	}
+	assert.Equal(t, 2, len(handled))
+}
+
+func TestDisabledLogger(t *testing.T) {
+	l, err := NewLogger("", nil, nil)
+	require.NoError(t, err)
+	assert.False(t, l.Enabled())
+	l.Log(Record{Operation: "remove-shards"})
+	assert.NoError(t, l.Close())
+
+	var nilLogger *Logger
+	assert.False(t, nilLogger.Enabled())
+	nilLogger.Log(Record{Operation: "remove-shards"})
+	assert.NoError(t, nilLogger.Close())
+}
@@ -14,6 +14,7 @@
 package prophet
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"sync"
@@ -22,6 +23,7 @@ import (
 
 	"github.com/RoaringBitmap/roaring/roaring64"
 	"github.com/fagongzi/goetty"
+	"github.com/matrixorigin/matrixcube/components/prophet/codec"
 	"github.com/matrixorigin/matrixcube/components/prophet/util"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
@@ -51,6 +53,11 @@ type Client interface {
 	PutStore(container metapb.Store) error
 	GetStore(containerID uint64) (*metapb.Store, error)
 	ShardHeartbeat(meta metapb.Shard, hb rpcpb.ShardHeartbeatReq) error
+	// ForgetShardHeartbeat forgets the shard descriptor sent by the last full
+	// heartbeat of the shard, it's called when the store stops sending the
+	// heartbeats of the shard, i.e. the shard is destroyed or its leadership is
+	// moved away.
+	ForgetShardHeartbeat(shardID uint64)
 	StoreHeartbeat(hb rpcpb.StoreHeartbeatReq) (rpcpb.StoreHeartbeatRsp, error)
 	AskBatchSplit(res metapb.Shard, count uint32) ([]rpcpb.SplitID, error)
 	NewWatcher(flag uint32) (EventWatcher, error)
@@ -74,6 +81,11 @@ type Client interface {
 
 	// PutPlacementRule put placement rule
 	PutPlacementRule(rule rpcpb.PlacementRule) error
+	// DeletePlacementRule delete the placement rule of the group
+	DeletePlacementRule(group, id string) error
+	// GetPlacementRules returns the placement rules of the group, all the rules
+	// are returned if the group is empty
+	GetPlacementRules(group string) ([]rpcpb.PlacementRule, error)
 	// GetAppliedRules returns applied rules of the resource
 	GetAppliedRules(id uint64) ([]rpcpb.PlacementRule, error)
 
@@ -84,6 +96,96 @@ type Client interface {
 	// GetSchedulingRules get all schedule group rules
 	GetSchedulingRules() ([]metapb.ScheduleGroupRule, error)
 
+	// PlacementDryRun simulates the replica placement after adding and removing
+	// the stores, and returns the expected data movement and the final placement
+	// of the stores. The cluster is not changed, the store id of the added stores
+	// will be assigned if not set.
+	PlacementDryRun(addStores []metapb.Store, removeStores []uint64) (rpcpb.PlacementDryRunRsp, error)
+	// GetCatchUpProgress returns the catch-up progress of the replicas added by
+	// the running operators of the shard, e.g. the snapshot phase and the ETA.
+	// The operators of all the shards are returned if the shard is 0.
+	GetCatchUpProgress(shardID uint64) ([]rpcpb.OperatorCatchUpProgress, error)
+	// GetBalanceReports returns the latest limit balance reports of the shard
+	// group in the order of the time, e.g. the Gini coefficients of the leaders,
+	// the replicas and the size on the stores. All the kept reports are returned
+	// if the limit is 0.
+	GetBalanceReports(group uint64, limit int) ([]rpcpb.BalanceReport, error)
+	// GetShardReplayLog returns the latest limit heartbeats and scheduling
+	// decisions of the shard kept by prophet in the order of the time, it's used
+	// to reconstruct the history of the shard after an incident. All the kept
+	// records are returned if the limit is 0.
+	GetShardReplayLog(shardID uint64, limit int) (rpcpb.ShardReplayLog, error)
+	// GetReplicaDrifts returns the shards whose replica count deviates from the
+	// target of the shard group, e.g. over or under replicated because of the
+	// operators failed in the middle, ordered by the shard id. The drifts are
+	// repaired at the pace of `ReplicaDriftGracePeriod` and `MaxReplicaDriftRepair`.
+	// All the drifts are returned if the limit is 0.
+	GetReplicaDrifts(limit int) ([]rpcpb.ReplicaDrift, error)
+	// ListShards returns a page of the shards of the group in the order of the
+	// start key, the next page is requested with the `NextPageToken` of the
+	// response until it's empty. Only the id and the fields in the field mask
+	// are returned if the field mask is set, so the tools can list the shards of
+	// a huge cluster without transferring the whole metadata.
+	ListShards(req rpcpb.ListShardsReq) (rpcpb.ListShardsRsp, error)
+	// ListStores is the same as ListShards, but returns the stores in the order
+	// of the store id.
+	ListStores(req rpcpb.ListStoresReq) (rpcpb.ListStoresRsp, error)
+
+	// CreateKeyspace creates the keyspace in the shard group, the ID and the
+	// prefix of the returned keyspace are generated by prophet. All the keys of
+	// the keyspace must be prefixed by the prefix.
+	CreateKeyspace(keyspace metapb.Keyspace) (metapb.Keyspace, error)
+	// GetKeyspaces returns all the keyspaces
+	GetKeyspaces() ([]metapb.Keyspace, error)
+	// DeleteKeyspace deletes the keyspace, the shards of the keyspace are kept
+	// and scheduled by the rules of the shard group.
+	DeleteKeyspace(name string) error
+
+	// DropShardGroup moves the shard group to the trash, the requests to the
+	// shards of the group are rejected, and the shards are destroyed after the
+	// retention. The retention configured by prophet is used if it's 0.
+	DropShardGroup(group uint64, retention time.Duration) (rpcpb.TrashedShardGroup, error)
+	// UndropShardGroup restores the shard group from the trash before the shards
+	// are destroyed.
+	UndropShardGroup(group uint64) error
+	// GetTrashedShardGroups returns the shard groups in the trash
+	GetTrashedShardGroups() ([]rpcpb.TrashedShardGroup, error)
+
+	// GetShardLineage returns the split and merge ancestry of the shard, and the
+	// current shards holding the data of the shard. The shard may be already
+	// destroyed, e.g. the shard ID in the old logs.
+	GetShardLineage(shardID uint64) (rpcpb.GetShardLineageRsp, error)
+
+	// GetSchedulers returns the schedulers of the prophet leader
+	GetSchedulers() ([]rpcpb.SchedulerStatus, error)
+	// PauseScheduler pauses the scheduler for the seconds, the scheduler is
+	// resumed if the seconds is 0. All the schedulers are paused or resumed if
+	// the name is "all".
+	PauseScheduler(name string, seconds int64) error
+	// CreateOperator creates an operator of the shard manually, the from store
+	// is only used to move the replica, and the to store is not used to scatter
+	// the shard.
+	CreateOperator(opType rpcpb.ManualOperatorType, shardID, fromStoreID, toStoreID uint64) error
+	// GetOperators returns the running operators of the shard with the steps
+	// and the progress. The operators of all the shards are returned if the
+	// shard is 0.
+	GetOperators(shardID uint64) ([]rpcpb.OperatorStatus, error)
+	// GetHotBuckets returns at most limit hottest buckets of the shards in the
+	// group, ordered by the read and written bytes in the last reported interval.
+	// All the read or written buckets are returned if the limit is 0.
+	GetHotBuckets(group uint64, limit int) ([]metapb.ShardBucket, error)
+	// SetStoreWeight sets the leader and shard balance weights of the store, the
+	// store holds more leaders or shards with the larger weights.
+	SetStoreWeight(storeID uint64, leaderWeight, shardWeight float64) error
+	// SetShardScoreFunction sets the function scoring the stores by the shards
+	// in the balance shard schedulers, the functions supported are "default",
+	// "capacity-ratio", "available" and "count".
+	SetShardScoreFunction(fn string) error
+	// SetMaintenanceWindows sets the daily time windows in which the heavy
+	// schedulers, e.g. balance, merge and hot schedulers, generate operators.
+	// The heavy schedulers are always allowed if no window is set.
+	SetMaintenanceWindows(windows []rpcpb.MaintenanceWindow) error
+
 	// CreateJob create job
 	CreateJob(metapb.Job) error
 	// RemoveJob remove job
@@ -95,9 +197,10 @@ type Client interface {
 type asyncClient struct {
 	opts *options
 
-	containerID uint64
-	id          uint64
-	leaderConn  goetty.IOSession
+	containerID  uint64
+	id           uint64
+	lastFailover int64
+	leaderConn   goetty.IOSession
 
 	resetReadC            chan string
 	resetLeaderConnC      chan struct{}
@@ -115,6 +218,22 @@ type asyncClient struct {
 		sync.RWMutex
 		contexts map[uint64]*ctx
 	}
+
+	// heartbeatsMu the shard descriptors sent in the last full heartbeats
+	heartbeatsMu struct {
+		sync.Mutex
+		sent map[uint64]*sentHeartbeat
+	}
+
+	membersMu struct {
+		sync.Mutex
+		members
+	}
+}
+
+type sentHeartbeat struct {
+	data   []byte
+	deltas int
 }
 
 // NewClient create a prophet client
@@ -134,6 +253,7 @@ func NewClient(opts ...Option) Client {
 	c.stopper = stop.NewStopper("prophet-client", stop.WithLogger(c.opts.logger))
 	c.leaderConn = createConn(c.opts.logger)
 	c.contextsMu.contexts = make(map[uint64]*ctx)
+	c.initMembers()
 	c.start()
 	return c
 }
@@ -192,7 +312,8 @@ func (c *asyncClient) ShardHeartbeat(meta metapb.Shard, hb rpcpb.ShardHeartbeatR
 		return err
 	}
 
-	hb.Shard = data
+	hb.Stats.ShardID = meta.ID
+	hb.Shard = c.maybeDeltaHeartbeat(meta.ID, data)
 	req := &rpcpb.ProphetRequest{}
 	req.Type = rpcpb.TypeShardHeartbeatReq
 	req.ShardHeartbeat = hb
@@ -201,6 +322,42 @@ func (c *asyncClient) ShardHeartbeat(meta metapb.Shard, hb rpcpb.ShardHeartbeatR
 	return nil
 }
 
+// maybeDeltaHeartbeat returns nil if the shard descriptor is not changed since
+// the last full heartbeat, and the full sync interval is not reached.
+func (c *asyncClient) maybeDeltaHeartbeat(shardID uint64, data []byte) []byte {
+	if c.opts.fullHeartbeatInterval <= 0 {
+		return data
+	}
+
+	c.heartbeatsMu.Lock()
+	defer c.heartbeatsMu.Unlock()
+	if c.heartbeatsMu.sent == nil {
+		c.heartbeatsMu.sent = make(map[uint64]*sentHeartbeat)
+	}
+	if s, ok := c.heartbeatsMu.sent[shardID]; ok &&
+		s.deltas < c.opts.fullHeartbeatInterval &&
+		bytes.Equal(s.data, data) {
+		s.deltas++
+		return nil
+	}
+	c.heartbeatsMu.sent[shardID] = &sentHeartbeat{data: data}
+	return data
+}
+
+func (c *asyncClient) ForgetShardHeartbeat(shardID uint64) {
+	c.heartbeatsMu.Lock()
+	defer c.heartbeatsMu.Unlock()
+	delete(c.heartbeatsMu.sent, shardID)
+}
+
+// resetSentHeartbeats makes the next heartbeats of all shards full heartbeats,
+// the new prophet leader may not have the shards in its cache.
+func (c *asyncClient) resetSentHeartbeats() {
+	c.heartbeatsMu.Lock()
+	defer c.heartbeatsMu.Unlock()
+	c.heartbeatsMu.sent = nil
+}
+
 func (c *asyncClient) StoreHeartbeat(hb rpcpb.StoreHeartbeatReq) (rpcpb.StoreHeartbeatRsp, error) {
 	if !c.running() {
 		return rpcpb.StoreHeartbeatRsp{}, ErrClosed
@@ -440,6 +597,41 @@ func (c *asyncClient) PutPlacementRule(rule rpcpb.PlacementRule) error {
 	return nil
 }
 
+func (c *asyncClient) DeletePlacementRule(group, id string) error {
+	if !c.running() {
+		return ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeDeletePlacementRuleReq
+	req.DeletePlacementRule.GroupID = group
+	req.DeletePlacementRule.ID = id
+
+	_, err := c.syncDo(req)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *asyncClient) GetPlacementRules(group string) ([]rpcpb.PlacementRule, error) {
+	if !c.running() {
+		return nil, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeGetPlacementRulesReq
+	req.GetPlacementRules.GroupID = group
+
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return rsp.GetPlacementRules.Rules, nil
+}
+
 func (c *asyncClient) GetAppliedRules(id uint64) ([]rpcpb.PlacementRule, error) {
 	if !c.running() {
 		return nil, ErrClosed
@@ -491,6 +683,324 @@ func (c *asyncClient) GetSchedulingRules() ([]metapb.ScheduleGroupRule, error) {
 	return rsp.GetScheduleGroupRule.Rules, nil
 }
 
+func (c *asyncClient) PlacementDryRun(addStores []metapb.Store, removeStores []uint64) (rpcpb.PlacementDryRunRsp, error) {
+	if !c.running() {
+		return rpcpb.PlacementDryRunRsp{}, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypePlacementDryRunReq
+	req.PlacementDryRun.AddStores = addStores
+	req.PlacementDryRun.RemoveStores = removeStores
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return rpcpb.PlacementDryRunRsp{}, err
+	}
+
+	return rsp.PlacementDryRun, nil
+}
+
+func (c *asyncClient) GetCatchUpProgress(shardID uint64) ([]rpcpb.OperatorCatchUpProgress, error) {
+	if !c.running() {
+		return nil, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeGetCatchUpProgressReq
+	req.GetCatchUpProgress.ShardID = shardID
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.GetCatchUpProgress.Operators, nil
+}
+
+func (c *asyncClient) GetBalanceReports(group uint64, limit int) ([]rpcpb.BalanceReport, error) {
+	if !c.running() {
+		return nil, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeGetBalanceReportsReq
+	req.GetBalanceReports.Group = group
+	req.GetBalanceReports.Limit = uint64(limit)
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.GetBalanceReports.Reports, nil
+}
+
+func (c *asyncClient) GetShardReplayLog(shardID uint64, limit int) (rpcpb.ShardReplayLog, error) {
+	if !c.running() {
+		return rpcpb.ShardReplayLog{}, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeGetShardReplayLogReq
+	req.GetShardReplayLog.ShardID = shardID
+	req.GetShardReplayLog.Limit = uint64(limit)
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return rpcpb.ShardReplayLog{}, err
+	}
+	return rsp.GetShardReplayLog.Log, nil
+}
+
+func (c *asyncClient) GetReplicaDrifts(limit int) ([]rpcpb.ReplicaDrift, error) {
+	if !c.running() {
+		return nil, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeGetReplicaDriftsReq
+	req.GetReplicaDrifts.Limit = uint64(limit)
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.GetReplicaDrifts.Drifts, nil
+}
+
+func (c *asyncClient) ListShards(listReq rpcpb.ListShardsReq) (rpcpb.ListShardsRsp, error) {
+	if !c.running() {
+		return rpcpb.ListShardsRsp{}, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeListShardsReq
+	req.ListShards = listReq
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return rpcpb.ListShardsRsp{}, err
+	}
+	return rsp.ListShards, nil
+}
+
+func (c *asyncClient) ListStores(listReq rpcpb.ListStoresReq) (rpcpb.ListStoresRsp, error) {
+	if !c.running() {
+		return rpcpb.ListStoresRsp{}, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeListStoresReq
+	req.ListStores = listReq
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return rpcpb.ListStoresRsp{}, err
+	}
+	return rsp.ListStores, nil
+}
+
+func (c *asyncClient) CreateKeyspace(keyspace metapb.Keyspace) (metapb.Keyspace, error) {
+	if !c.running() {
+		return metapb.Keyspace{}, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeCreateKeyspaceReq
+	req.CreateKeyspace.Keyspace = keyspace
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return metapb.Keyspace{}, err
+	}
+	return rsp.CreateKeyspace.Keyspace, nil
+}
+
+func (c *asyncClient) GetKeyspaces() ([]metapb.Keyspace, error) {
+	if !c.running() {
+		return nil, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeGetKeyspacesReq
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.GetKeyspaces.Keyspaces, nil
+}
+
+func (c *asyncClient) DeleteKeyspace(name string) error {
+	if !c.running() {
+		return ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeDeleteKeyspaceReq
+	req.DeleteKeyspace.Name = name
+	_, err := c.syncDo(req)
+	return err
+}
+
+func (c *asyncClient) DropShardGroup(group uint64, retention time.Duration) (rpcpb.TrashedShardGroup, error) {
+	if !c.running() {
+		return rpcpb.TrashedShardGroup{}, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeDropShardGroupReq
+	req.DropShardGroup.Group = group
+	req.DropShardGroup.Retention = int64(retention / time.Second)
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return rpcpb.TrashedShardGroup{}, err
+	}
+	return rsp.DropShardGroup.Group, nil
+}
+
+func (c *asyncClient) UndropShardGroup(group uint64) error {
+	if !c.running() {
+		return ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeUndropShardGroupReq
+	req.UndropShardGroup.Group = group
+	_, err := c.syncDo(req)
+	return err
+}
+
+func (c *asyncClient) GetTrashedShardGroups() ([]rpcpb.TrashedShardGroup, error) {
+	if !c.running() {
+		return nil, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeGetTrashedShardGroupsReq
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.GetTrashedShardGroups.Groups, nil
+}
+
+func (c *asyncClient) GetShardLineage(shardID uint64) (rpcpb.GetShardLineageRsp, error) {
+	if !c.running() {
+		return rpcpb.GetShardLineageRsp{}, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeGetShardLineageReq
+	req.GetShardLineage.ShardID = shardID
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return rpcpb.GetShardLineageRsp{}, err
+	}
+	return rsp.GetShardLineage, nil
+}
+
+func (c *asyncClient) GetSchedulers() ([]rpcpb.SchedulerStatus, error) {
+	if !c.running() {
+		return nil, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeGetSchedulersReq
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.GetSchedulers.Schedulers, nil
+}
+
+func (c *asyncClient) PauseScheduler(name string, seconds int64) error {
+	if !c.running() {
+		return ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypePauseSchedulerReq
+	req.PauseScheduler.Name = name
+	req.PauseScheduler.Seconds = seconds
+	_, err := c.syncDo(req)
+	return err
+}
+
+func (c *asyncClient) CreateOperator(opType rpcpb.ManualOperatorType, shardID, fromStoreID, toStoreID uint64) error {
+	if !c.running() {
+		return ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeCreateOperatorReq
+	req.CreateOperator.Type = opType
+	req.CreateOperator.ShardID = shardID
+	req.CreateOperator.FromStoreID = fromStoreID
+	req.CreateOperator.ToStoreID = toStoreID
+	_, err := c.syncDo(req)
+	return err
+}
+
+func (c *asyncClient) GetOperators(shardID uint64) ([]rpcpb.OperatorStatus, error) {
+	if !c.running() {
+		return nil, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeGetOperatorsReq
+	req.GetOperators.ShardID = shardID
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.GetOperators.Operators, nil
+}
+
+func (c *asyncClient) GetHotBuckets(group uint64, limit int) ([]metapb.ShardBucket, error) {
+	if !c.running() {
+		return nil, ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeGetHotBucketsReq
+	req.GetHotBuckets.Group = group
+	req.GetHotBuckets.Limit = uint64(limit)
+	rsp, err := c.syncDo(req)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.GetHotBuckets.Buckets, nil
+}
+
+func (c *asyncClient) SetStoreWeight(storeID uint64, leaderWeight, shardWeight float64) error {
+	if !c.running() {
+		return ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeSetStoreWeightReq
+	req.SetStoreWeight.StoreID = storeID
+	req.SetStoreWeight.LeaderWeight = leaderWeight
+	req.SetStoreWeight.ShardWeight = shardWeight
+	_, err := c.syncDo(req)
+	return err
+}
+
+func (c *asyncClient) SetShardScoreFunction(fn string) error {
+	if !c.running() {
+		return ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeSetShardScoreFunctionReq
+	req.SetShardScoreFunction.Function = fn
+	_, err := c.syncDo(req)
+	return err
+}
+
+func (c *asyncClient) SetMaintenanceWindows(windows []rpcpb.MaintenanceWindow) error {
+	if !c.running() {
+		return ErrClosed
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.Type = rpcpb.TypeSetMaintenanceWindowsReq
+	req.SetMaintenanceWindows.Windows = windows
+	_, err := c.syncDo(req)
+	return err
+}
+
 func (c *asyncClient) CreateJob(job metapb.Job) error {
 	if !c.running() {
 		return ErrClosed
@@ -558,6 +1068,16 @@ func (c *asyncClient) running() bool {
 }
 
 func (c *asyncClient) syncDo(req *rpcpb.ProphetRequest) (*rpcpb.ProphetResponse, error) {
+	// the retryable errors are retried until the rpc timeout, the not leader
+	// errors are retried until the new leader elected. With the request deadline,
+	// the timeouts are retried after failing over to another member, and the last
+	// error is returned once the deadline is reached.
+	now := time.Now()
+	retryDeadline := now.Add(c.opts.rpcTimeout)
+	var deadline time.Time
+	if c.opts.requestDeadline > 0 {
+		deadline = now.Add(c.opts.requestDeadline)
+	}
 	for {
 		ctx := newSyncCtx(req)
 		if err := c.do(ctx); err != nil {
@@ -566,9 +1086,23 @@ func (c *asyncClient) syncDo(req *rpcpb.ProphetRequest) (*rpcpb.ProphetResponse,
 
 		ctx.wait()
 		if ctx.err != nil {
-			if ctx.err == util.ErrNotLeader {
-				time.Sleep(time.Millisecond * 100)
-				continue
+			expired := !deadline.IsZero() && !time.Now().Before(deadline)
+			switch util.ClassifyError(ctx.err) {
+			case util.NotLeaderError:
+				if !expired {
+					time.Sleep(time.Millisecond * 100)
+					continue
+				}
+			case util.RetryableError:
+				if !expired && time.Now().Before(retryDeadline) {
+					time.Sleep(time.Millisecond * 100)
+					continue
+				}
+			default:
+				if ctx.err == ErrTimeout && !deadline.IsZero() && !expired {
+					c.failover()
+					continue
+				}
 			}
 
 			return nil, ctx.err
@@ -697,18 +1231,24 @@ OUTER:
 					}
 
 					resp := msg.(*rpcpb.ProphetResponse)
-					if resp.Error != "" && util.IsNotLeaderError(resp.Error) {
+					if util.ClassifyError(util.CodeError(resp.ErrorCode, resp.Error)) == util.NotLeaderError {
+						if resp.Leader != "" && resp.Leader != leader {
+							c.setLeaderHint(resp.Leader)
+						}
 						if !c.scheduleResetLeaderConn() {
 							return
 						}
 
 						// retry
 						c.requestDoneWithRetry(resp)
+						codec.ReleaseResponse(resp)
 						c.opts.logger.Info("read loop actived, ready to read from leader, exit, not leader")
 						continue OUTER
 					}
 
+					c.maybeResyncShardHeartbeat(resp)
 					if c.maybeAddShardHeartbeatResp(resp) {
+						codec.ReleaseResponse(resp)
 						continue
 					}
 
@@ -735,14 +1275,28 @@ func (c *asyncClient) requestDone(resp *rpcpb.ProphetResponse) {
 
 	if ctx, ok := c.contextsMu.contexts[resp.ID]; ok {
 		delete(c.contextsMu.contexts, resp.ID)
-		if resp.Error != "" {
-			ctx.done(nil, errors.New(resp.Error))
+		if err := util.CodeError(resp.ErrorCode, resp.Error); err != nil {
+			ctx.done(nil, err)
 		} else {
 			ctx.done(resp, nil)
 		}
 	}
 }
 
+// maybeResyncShardHeartbeat makes the next heartbeat of the shard a full
+// heartbeat if its delta heartbeat is rejected, e.g. the prophet leader has
+// not received the full heartbeat of the shard.
+func (c *asyncClient) maybeResyncShardHeartbeat(resp *rpcpb.ProphetResponse) {
+	if resp.Type == rpcpb.TypeShardHeartbeatRsp &&
+		resp.Error != "" &&
+		resp.ShardHeartbeat.ShardID > 0 {
+		c.opts.logger.Info("delta shard heartbeat rejected, resync with full heartbeat",
+			zap.Uint64("shard", resp.ShardHeartbeat.ShardID),
+			zap.String("error", resp.Error))
+		c.ForgetShardHeartbeat(resp.ShardHeartbeat.ShardID)
+	}
+}
+
 func (c *asyncClient) maybeAddShardHeartbeatResp(resp *rpcpb.ProphetResponse) bool {
 	if resp.Type == rpcpb.TypeShardHeartbeatRsp &&
 		resp.Error == "" &&
@@ -766,6 +1320,7 @@ func (c *asyncClient) doWrite(ctx *ctx) {
 
 func (c *asyncClient) resetLeaderConn() error {
 	c.leaderConn.Close()
+	c.resetSentHeartbeats()
 	_, err := c.initLeaderConn(c.leaderConn, c.opts.rpcTimeout, true)
 	return err
 }
@@ -781,11 +1336,7 @@ func (c *asyncClient) initLeaderConn(conn goetty.IOSession, timeout time.Duratio
 		case <-time.After(timeout):
 			return "", ErrTimeout
 		default:
-			leader := c.opts.leaderGetter()
-			if leader != nil {
-				addr = leader.Addr
-			}
-
+			addr = c.nextLeaderAddr()
 			if addr != "" {
 				c.opts.logger.Info("start connect to leader",
 					zap.String("leader", addr))
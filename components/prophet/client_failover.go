@@ -0,0 +1,116 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prophet
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// members the prophet members known by the client. The members are seeded by
+// WithSeedMembers, and every leader learned from the leader getter or from the
+// not leader responses is added. The client connects to the members in turn if
+// the leader is unknown, any member responds with the current leader.
+type members struct {
+	// hint the leader returned by the last not leader response
+	hint  string
+	addrs map[string]struct{}
+	next  int
+}
+
+func (c *asyncClient) initMembers() {
+	c.membersMu.addrs = make(map[string]struct{})
+	for _, addr := range c.opts.seedMembers {
+		c.addMemberLocked(addr)
+	}
+}
+
+func (c *asyncClient) addMemberLocked(addr string) {
+	if addr == "" {
+		return
+	}
+	if _, ok := c.membersMu.addrs[addr]; !ok {
+		c.opts.logger.Info("prophet member discovered",
+			zap.String("member", addr))
+		c.membersMu.addrs[addr] = struct{}{}
+	}
+}
+
+// setLeaderHint records the leader returned by a not leader response, which is
+// tried first on the next connection.
+func (c *asyncClient) setLeaderHint(addr string) {
+	c.membersMu.Lock()
+	defer c.membersMu.Unlock()
+	c.membersMu.hint = addr
+	c.addMemberLocked(addr)
+}
+
+// getMembers returns the sorted addresses of the known members
+func (c *asyncClient) getMembers() []string {
+	c.membersMu.Lock()
+	defer c.membersMu.Unlock()
+	return c.getMembersLocked()
+}
+
+func (c *asyncClient) getMembersLocked() []string {
+	addrs := make([]string, 0, len(c.membersMu.addrs))
+	for addr := range c.membersMu.addrs {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// nextLeaderAddr returns the address to connect. The leader hint is used only
+// once, then the leader of the leader getter, then the known members in turn.
+func (c *asyncClient) nextLeaderAddr() string {
+	c.membersMu.Lock()
+	defer c.membersMu.Unlock()
+	if addr := c.membersMu.hint; addr != "" {
+		c.membersMu.hint = ""
+		return addr
+	}
+	if c.opts.leaderGetter != nil {
+		if leader := c.opts.leaderGetter(); leader != nil && leader.Addr != "" {
+			c.addMemberLocked(leader.Addr)
+			return leader.Addr
+		}
+	}
+	addrs := c.getMembersLocked()
+	if len(addrs) == 0 {
+		return ""
+	}
+	addr := addrs[c.membersMu.next%len(addrs)]
+	c.membersMu.next++
+	return addr
+}
+
+// failover reconnects to the leader if the leader doesn't respond, the
+// connection may be still alive after the leader is partitioned or hung. The
+// connection is closed at most once every rpc timeout, and the read loop
+// reconnects to the leader.
+func (c *asyncClient) failover() {
+	now := time.Now().UnixNano()
+	last := atomic.LoadInt64(&c.lastFailover)
+	if now-last < int64(c.opts.rpcTimeout) ||
+		!atomic.CompareAndSwapInt64(&c.lastFailover, last, now) {
+		return
+	}
+
+	c.opts.logger.Info("leader not responding, failover")
+	c.leaderConn.Close()
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestClientLeaderChange(t *testing.T) {
@@ -118,6 +119,79 @@ func TestAsyncCreateShards(t *testing.T) {
 	}
 }
 
+func TestWatcherResume(t *testing.T) {
+	p := newTestSingleProphet(t, nil)
+	defer p.Stop()
+
+	c := p.GetClient()
+	w, err := c.NewWatcher(event.AllEvent)
+	assert.NoError(t, err)
+	defer w.Close()
+
+	readEvent := func(eventType uint32) rpcpb.EventNotify {
+		for {
+			select {
+			case e := <-w.GetNotify():
+				if e.Type == eventType {
+					return e
+				}
+			case <-time.After(time.Second * 5):
+				assert.FailNow(t, "timeout")
+			}
+		}
+	}
+
+	e := readEvent(event.InitEvent)
+	revision := e.Revision
+	assert.True(t, revision > 0)
+
+	assert.NoError(t, c.PutStore(newTestStoreMeta(1)))
+	_, err = c.StoreHeartbeat(newTestStoreHeartbeat(1, 1))
+	assert.NoError(t, err)
+	e = readEvent(event.StoreEvent)
+	assert.Equal(t, revision+1, e.Revision)
+
+	// reconnect with the revision, the missed events are replayed
+	w.(*watcher).conn.Close()
+	assert.NoError(t, c.PutStore(newTestStoreMeta(2)))
+	_, err = c.StoreHeartbeat(newTestStoreHeartbeat(2, 1))
+	assert.NoError(t, err)
+	e = readEvent(0)
+	assert.Equal(t, revision+1, e.Revision)
+	e = readEvent(event.StoreEvent)
+	assert.Equal(t, revision+2, e.Revision)
+}
+
+func TestWatcherCloseWithoutConsumer(t *testing.T) {
+	p := newTestSingleProphet(t, nil)
+	defer p.Stop()
+
+	c := p.GetClient()
+	w, err := c.NewWatcher(event.AllEvent)
+	assert.NoError(t, err)
+
+	// the events are not consumed, so the read loop is blocked by the full
+	// notify channel
+	assert.NoError(t, c.PutStore(newTestStoreMeta(1)))
+	n := cap(w.GetNotify()) + 1
+	for i := 0; i < n; i++ {
+		_, err = c.StoreHeartbeat(newTestStoreHeartbeat(1, 1))
+		assert.NoError(t, err)
+	}
+	assert.Eventually(t, func() bool {
+		return len(w.GetNotify()) == cap(w.GetNotify())
+	}, time.Second*5, time.Millisecond*10)
+
+	// the blocked read loop is stopped by the close, and the watcher closes the
+	// connection and the notify channel
+	w.Close()
+	require.Eventually(t, func() bool {
+		return !w.(*watcher).conn.Connected()
+	}, time.Second*5, time.Millisecond*10)
+	for range w.GetNotify() {
+	}
+}
+
 func TestCheckShardState(t *testing.T) {
 	p := newTestSingleProphet(t, nil)
 	defer p.Stop()
@@ -154,6 +228,25 @@ func TestScheduleGroupRule(t *testing.T) {
 	assert.Equal(t, 10, len(rules))
 }
 
+func TestPlacementDryRun(t *testing.T) {
+	p := newTestSingleProphet(t, nil)
+	defer p.Stop()
+
+	c := p.GetClient()
+	assert.NoError(t, c.PutStore(newTestStoreMeta(1)))
+
+	rsp, err := c.PlacementDryRun([]metapb.Store{{}}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), rsp.MovedReplicas)
+	if assert.Equal(t, 2, len(rsp.Stores)) {
+		assert.False(t, rsp.Stores[0].Added)
+		assert.True(t, rsp.Stores[1].Added)
+	}
+
+	_, err = c.PlacementDryRun(nil, []uint64{100})
+	assert.Error(t, err)
+}
+
 func TestPutPlacementRule(t *testing.T) {
 	p := newTestSingleProphet(t, nil)
 	defer p.Stop()
@@ -188,6 +281,162 @@ func TestPutPlacementRule(t *testing.T) {
 	assert.Equal(t, 1, len(rules))
 }
 
+func TestDeletePlacementRule(t *testing.T) {
+	p := newTestSingleProphet(t, nil)
+	defer p.Stop()
+
+	c := p.GetClient()
+	rule := rpcpb.PlacementRule{
+		GroupID: "group01",
+		ID:      "rule01",
+		Count:   3,
+	}
+	assert.NoError(t, c.PutPlacementRule(rule))
+
+	rules, err := c.GetPlacementRules("group01")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, rule.ID, rules[0].ID)
+	assert.Equal(t, rule.Count, rules[0].Count)
+
+	// the default rule is included if the group is not specified
+	rules, err = c.GetPlacementRules("")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(rules))
+
+	assert.NoError(t, c.DeletePlacementRule("group01", "rule01"))
+	rules, err = c.GetPlacementRules("group01")
+	assert.NoError(t, err)
+	assert.Empty(t, rules)
+	assert.Error(t, c.DeletePlacementRule("group01", "rule01"))
+}
+
+func TestSchedulerAdmin(t *testing.T) {
+	p := newTestSingleProphet(t, nil)
+	defer p.Stop()
+
+	c := p.GetClient()
+	_, err := c.GetSchedulers()
+	assert.NoError(t, err)
+	assert.Error(t, c.PauseScheduler("not-exist-scheduler", 60))
+
+	assert.Error(t, c.CreateOperator(rpcpb.ManualTransferLeader, 100, 0, 1), "the shard not found")
+	operators, err := c.GetOperators(0)
+	assert.NoError(t, err)
+	assert.Empty(t, operators)
+
+	assert.Error(t, c.SetStoreWeight(100, 1, 1), "the store not found")
+	assert.NoError(t, c.SetShardScoreFunction("count"))
+	assert.Error(t, c.SetShardScoreFunction("not-exist-function"))
+}
+
+func TestSetMaintenanceWindows(t *testing.T) {
+	p := newTestSingleProphet(t, nil)
+	defer p.Stop()
+
+	c := p.GetClient()
+	opts := p.(*defaultProphet).GetRaftCluster().GetOpts()
+	windows := []rpcpb.MaintenanceWindow{{Start: "22:00", End: "06:00"}, {Start: "12:00", End: "13:00"}}
+	assert.NoError(t, c.SetMaintenanceWindows(windows))
+	assert.Equal(t, []config.MaintenanceWindow{{Start: "22:00", End: "06:00"}, {Start: "12:00", End: "13:00"}},
+		opts.GetScheduleConfig().MaintenanceWindows)
+
+	assert.Error(t, c.SetMaintenanceWindows([]rpcpb.MaintenanceWindow{{Start: "25:00", End: "06:00"}}))
+	assert.Equal(t, 2, len(opts.GetScheduleConfig().MaintenanceWindows))
+
+	assert.NoError(t, c.SetMaintenanceWindows(nil))
+	assert.Empty(t, opts.GetScheduleConfig().MaintenanceWindows)
+}
+
+func TestDeltaShardHeartbeat(t *testing.T) {
+	c := &asyncClient{opts: &options{fullHeartbeatInterval: 2}}
+	assert.Equal(t, []byte("v1"), c.maybeDeltaHeartbeat(1, []byte("v1")))
+	assert.Nil(t, c.maybeDeltaHeartbeat(1, []byte("v1")))
+	assert.Nil(t, c.maybeDeltaHeartbeat(1, []byte("v1")))
+	// periodic full sync
+	assert.Equal(t, []byte("v1"), c.maybeDeltaHeartbeat(1, []byte("v1")))
+	// changed
+	assert.Equal(t, []byte("v2"), c.maybeDeltaHeartbeat(1, []byte("v2")))
+	assert.Equal(t, []byte("v3"), c.maybeDeltaHeartbeat(2, []byte("v3")))
+
+	c.resetSentHeartbeats()
+	assert.Equal(t, []byte("v2"), c.maybeDeltaHeartbeat(1, []byte("v2")))
+
+	// the shard is destroyed or its leadership moved away
+	c.ForgetShardHeartbeat(1)
+	assert.Equal(t, []byte("v2"), c.maybeDeltaHeartbeat(1, []byte("v2")))
+	assert.Nil(t, c.maybeDeltaHeartbeat(1, []byte("v2")))
+	c.ForgetShardHeartbeat(1)
+	assert.Empty(t, c.heartbeatsMu.sent)
+
+	c.opts.fullHeartbeatInterval = 0
+	assert.Equal(t, []byte("v2"), c.maybeDeltaHeartbeat(1, []byte("v2")))
+}
+
+func TestDeltaShardHeartbeatReconstruct(t *testing.T) {
+	p := newTestSingleProphet(t, func(c *config.Config) {
+		c.ShardHeartbeatFullSyncInterval = 10
+	})
+	defer p.Stop()
+
+	c := p.GetClient()
+	assert.NoError(t, c.PutStore(newTestStoreMeta(1)))
+	_, err := c.StoreHeartbeat(newTestStoreHeartbeat(1, 1))
+	assert.NoError(t, err)
+
+	peer := metapb.Replica{ID: 1, StoreID: 1}
+	shard := newTestShardMeta(2, peer)
+	assert.NoError(t, c.ShardHeartbeat(shard, rpcpb.ShardHeartbeatReq{
+		StoreID: 1,
+		Leader:  &peer}))
+	assert.NoError(t, c.ShardHeartbeat(shard, rpcpb.ShardHeartbeatReq{
+		StoreID: 1,
+		Leader:  &peer,
+		Stats:   metapb.ShardStats{ApproximateSize: 100}}))
+
+	deadline := time.Now().Add(time.Second * 10)
+	for time.Now().Before(deadline) {
+		if res := p.GetBasicCluster().GetShard(2); res != nil &&
+			res.GetApproximateSize() > 0 {
+			assert.Equal(t, shard.Replicas, res.Meta.Replicas)
+			return
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.FailNow(t, "delta heartbeat not handled")
+}
+
+func TestDeltaShardHeartbeatRejected(t *testing.T) {
+	p := newTestSingleProphet(t, func(c *config.Config) {
+		c.ShardHeartbeatFullSyncInterval = 10
+	})
+	defer p.Stop()
+
+	c := p.GetClient()
+	assert.NoError(t, c.PutStore(newTestStoreMeta(1)))
+	_, err := c.StoreHeartbeat(newTestStoreHeartbeat(1, 1))
+	assert.NoError(t, err)
+
+	// the prophet leader never received the full heartbeat of the shard
+	peer := metapb.Replica{ID: 1, StoreID: 1}
+	shard := newTestShardMeta(2, peer)
+	data, err := shard.Marshal()
+	assert.NoError(t, err)
+	c.(*asyncClient).maybeDeltaHeartbeat(shard.ID, data)
+
+	// the full heartbeat is sent after the rejected delta heartbeat, rather than
+	// the full sync interval
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, c.ShardHeartbeat(shard, rpcpb.ShardHeartbeatReq{
+			StoreID: 1,
+			Leader:  &peer}))
+		time.Sleep(time.Millisecond * 500)
+	}
+	res := p.GetBasicCluster().GetShard(2)
+	require.NotNil(t, res, "delta heartbeat not resynced")
+	assert.Equal(t, shard.Replicas, res.Meta.Replicas)
+}
+
 func TestIssue106(t *testing.T) {
 	clusterSize := 3
 	cluster := newTestClusterProphet(t, clusterSize, func(c *config.Config) {
@@ -297,3 +546,73 @@ func newTestStoreHeartbeat(containerID uint64, resourceCount int, resourceSizes
 		Stats: stats,
 	}
 }
+
+func TestClientNextLeaderAddr(t *testing.T) {
+	var leader *metapb.Member
+	c := &asyncClient{opts: &options{
+		leaderGetter: func() *metapb.Member { return leader },
+		seedMembers:  []string{"m2", "m1"},
+	}}
+	c.opts.adjust()
+	c.initMembers()
+
+	assert.Equal(t, "m1", c.nextLeaderAddr())
+	assert.Equal(t, "m2", c.nextLeaderAddr())
+	assert.Equal(t, "m1", c.nextLeaderAddr())
+
+	leader = &metapb.Member{Addr: "m3"}
+	assert.Equal(t, "m3", c.nextLeaderAddr())
+	assert.Equal(t, []string{"m1", "m2", "m3"}, c.getMembers())
+
+	// the hint of the not leader response is used once
+	c.setLeaderHint("m4")
+	assert.Equal(t, "m4", c.nextLeaderAddr())
+	assert.Equal(t, "m3", c.nextLeaderAddr())
+	assert.Equal(t, []string{"m1", "m2", "m3", "m4"}, c.getMembers())
+}
+
+func TestClientDiscoversLeaderFromMembers(t *testing.T) {
+	cluster := newTestClusterProphet(t, 3, nil)
+	defer func() {
+		for _, p := range cluster {
+			p.Stop()
+		}
+	}()
+
+	leader := cluster[0].GetLeader()
+	assert.NotNil(t, leader)
+	var follower string
+	for _, p := range cluster {
+		if p.GetConfig().Name != leader.Name {
+			follower = p.GetConfig().AdvertiseRPCAddr
+		}
+	}
+
+	c := NewClient(WithSeedMembers(follower), WithRequestDeadline(time.Second*10)).(*asyncClient)
+	defer c.Close()
+	id, err := c.AllocID()
+	assert.NoError(t, err)
+	assert.True(t, id > 0)
+	assert.Contains(t, c.getMembers(), leader.Addr)
+}
+
+func TestClientRequestDeadline(t *testing.T) {
+	p := newTestSingleProphet(t, nil)
+	defer p.Stop()
+
+	c := NewClient(WithLeaderGetter(p.GetLeader),
+		WithRPCTimeout(time.Millisecond*200),
+		WithRequestDeadline(time.Millisecond*500))
+	defer c.Close()
+
+	p.GetConfig().TestContext.EnableSkipResponse()
+	start := time.Now()
+	_, err := c.AllocID()
+	assert.Equal(t, ErrTimeout, err)
+	assert.True(t, time.Since(start) < time.Second*5)
+
+	p.GetConfig().TestContext.DisableSkipResponse()
+	id, err := c.AllocID()
+	assert.NoError(t, err)
+	assert.True(t, id > 0)
+}
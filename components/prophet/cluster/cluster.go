@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/components/prophet/alert"
 	"github.com/matrixorigin/matrixcube/components/prophet/config"
 	"github.com/matrixorigin/matrixcube/components/prophet/core"
 	"github.com/matrixorigin/matrixcube/components/prophet/event"
@@ -33,10 +34,12 @@ import (
 	"github.com/matrixorigin/matrixcube/components/prophet/schedule/placement"
 	"github.com/matrixorigin/matrixcube/components/prophet/statistics"
 	"github.com/matrixorigin/matrixcube/components/prophet/storage"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
 	"github.com/matrixorigin/matrixcube/components/prophet/util/cache"
 	"github.com/matrixorigin/matrixcube/components/prophet/util/keyutil"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/util/watchdog"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.uber.org/zap"
 )
@@ -95,18 +98,36 @@ type RaftCluster struct {
 	prepareChecker *prepareChecker
 	changedEvents  chan rpcpb.EventNotify
 	createShardC   chan struct{}
+	// downStores the stores notified as down, i.e. no heartbeat for the max
+	// store down time
+	downStores map[uint64]struct{}
 
 	labelLevelStats *statistics.LabelStatistics
 	shardStats      *statistics.ShardStatistics
+	hotStat         *statistics.HotCache
+	catchUpStat     *statistics.CatchUpCache
+	balanceReports  *balanceReporter
+	replayLogs      *shardReplayLogs
+	// trashedGroups the dropped shard groups retained in the trash
+	trashedGroups map[uint64]rpcpb.TrashedShardGroup
+	// lineages the split and merge ancestry of the shards
+	lineages *shardLineages
 
 	coordinator      *coordinator
 	suspectShards    *cache.TTLUint64 // suspectShards are shards that may need fix
 	suspectKeyRanges *cache.TTLString // suspect key-range shards that may need fix
 
+	// alerts notifies the significant events, e.g. stores down
+	alerts                *alert.Notifier
+	operatorStuckDuration time.Duration
+	// watchdog monitors the loops of the coordinator, nil if it's disabled
+	watchdog *watchdog.Watchdog
+
 	wg   sync.WaitGroup
 	quit chan struct{}
 
 	ruleManager              *placement.RuleManager
+	destroyedCompactor       *destroyedShardsCompactor
 	etcdClient               *clientv3.Client
 	shardStateChangedHandler func(res *metapb.Shard, from metapb.ShardState, to metapb.ShardState)
 
@@ -147,12 +168,20 @@ func (c *RaftCluster) InitCluster(opt *config.PersistOptions, storage storage.St
 	c.opt = opt
 	c.storage = storage
 	c.labelLevelStats = statistics.NewLabelStatistics()
+	c.hotStat = statistics.NewHotCache()
+	c.catchUpStat = statistics.NewCatchUpCache()
+	c.balanceReports = newBalanceReporter()
+	c.replayLogs = newShardReplayLogs()
+	c.trashedGroups = make(map[uint64]rpcpb.TrashedShardGroup)
+	c.lineages = newShardLineages(c.ctx)
 	c.prepareChecker = newPrepareChecker()
 	c.suspectShards = cache.NewIDTTL(c.ctx, time.Minute, 3*time.Minute)
 	c.suspectKeyRanges = cache.NewStringTTL(c.ctx, time.Minute, 3*time.Minute)
 
 	c.changedEvents = make(chan rpcpb.EventNotify, defaultChangedEventLimit)
+	c.downStores = make(map[uint64]struct{})
 	c.createShardC = make(chan struct{}, 1)
+	c.destroyedCompactor = newDestroyedShardsCompactor(c.logger)
 }
 
 // Start starts a cluster.
@@ -175,6 +204,13 @@ func (c *RaftCluster) Start(s Server) error {
 	}
 
 	c.ruleManager = placement.NewRuleManager(c.storage, c, c.GetLogger())
+	c.ruleManager.SetStorePoolFunc(func(group uint64) string {
+		return c.opt.GetReplicationConfig().GetShardGroupPool(group)
+	})
+	c.ruleManager.SetStorageClassFunc(func(group uint64) string {
+		return c.opt.GetReplicationConfig().GetShardGroupStorageClass(group)
+	})
+	c.ruleManager.SetKeyspaces(c.core.Keyspaces)
 	if c.opt.IsPlacementRulesEnabled() {
 		err = c.ruleManager.Initialize(c.opt.GetMaxReplicas(), c.opt.GetLocationLabels())
 		if err != nil {
@@ -186,6 +222,11 @@ func (c *RaftCluster) Start(s Server) error {
 	c.shardStats = statistics.NewShardStatistics(c.opt, c.ruleManager)
 	c.limiter = NewStoreLimiter(s.GetPersistOptions(), c.logger)
 	c.quit = make(chan struct{})
+	c.alerts = newAlertNotifier(s.GetConfig(), c)
+	if cfg := s.GetConfig(); cfg != nil {
+		c.operatorStuckDuration = cfg.Alert.OperatorStuckDuration.Duration
+	}
+	c.alerts.Start()
 
 	c.wg.Add(2)
 	go c.runCoordinator()
@@ -220,6 +261,11 @@ func (c *RaftCluster) LoadClusterInfo() (*RaftCluster, error) {
 		zap.Int("count", c.GetShardCount()),
 		zap.Duration("cost", time.Since(start)))
 
+	// the records of the compacted destroyed shards are removed
+	if err := c.destroyedCompactor.load(c.storage, c.core); err != nil {
+		return nil, err
+	}
+
 	// load shard group rules
 	start = time.Now()
 	c.storage.LoadScheduleGroupRules(batch, func(rule metapb.ScheduleGroupRule) {
@@ -228,6 +274,40 @@ func (c *RaftCluster) LoadClusterInfo() (*RaftCluster, error) {
 	c.logger.Info("shard group rules loaded",
 		zap.Int("count", c.core.GetShardGroupRuleCount()),
 		zap.Duration("cost", time.Since(start)))
+
+	start = time.Now()
+	if err := c.storage.LoadKeyspaces(batch, func(keyspace metapb.Keyspace) {
+		c.core.Keyspaces.Put(keyspace)
+	}); err != nil {
+		return nil, err
+	}
+	c.logger.Info("keyspaces loaded",
+		zap.Int("count", c.core.Keyspaces.Count()),
+		zap.Duration("cost", time.Since(start)))
+
+	start = time.Now()
+	if err := c.loadTrashedShardGroups(); err != nil {
+		return nil, err
+	}
+	c.logger.Info("trashed shard groups loaded",
+		zap.Int("count", len(c.trashedGroups)),
+		zap.Duration("cost", time.Since(start)))
+
+	start = time.Now()
+	if err := c.loadShardLineages(); err != nil {
+		return nil, err
+	}
+	c.logger.Info("shard lineages loaded",
+		zap.Duration("cost", time.Since(start)))
+
+	if c.opt.IsShardReplayLogPersistEnabled() {
+		start = time.Now()
+		if err := c.loadShardReplayLogs(); err != nil {
+			return nil, err
+		}
+		c.logger.Info("shard replay logs loaded",
+			zap.Duration("cost", time.Since(start)))
+	}
 	return c, nil
 }
 
@@ -257,8 +337,14 @@ func (c *RaftCluster) runBackgroundJobs(interval time.Duration) {
 			return
 		case <-ticker.C:
 			c.checkStores()
+			c.checkStoreStates()
 			c.collectMetrics()
+			c.reportBalance(time.Now())
+			c.persistShardReplayLogs()
 			c.coordinator.opController.PruneHistory()
+			c.purgeTrashedShardGroups(time.Now())
+			c.compactDestroyedShards()
+			c.checkAlerts()
 			c.doNotifyCreateShards()
 		case <-c.createShardC:
 			c.doNotifyCreateShards()
@@ -300,6 +386,9 @@ func (c *RaftCluster) Stop() {
 	c.coordinator.stop()
 	c.Unlock()
 	c.wg.Wait()
+	if c.alerts != nil {
+		c.alerts.Stop()
+	}
 }
 
 // IsRunning return if the cluster is running.
@@ -364,6 +453,12 @@ func (c *RaftCluster) SetStorage(s storage.Storage) {
 	c.storage = s
 }
 
+// SetWatchdog set the watchdog monitoring the loops of the coordinator, it must
+// be called before the cluster is started.
+func (c *RaftCluster) SetWatchdog(w *watchdog.Watchdog) {
+	c.watchdog = w
+}
+
 // GetOpts returns cluster's configuration.
 func (c *RaftCluster) GetOpts() *config.PersistOptions {
 	return c.opt
@@ -378,6 +473,20 @@ func (c *RaftCluster) AddSuspectShards(shardIDs ...uint64) {
 	}
 }
 
+// SetShardReadHint notifies the watchers the replicas that the follower reads
+// of the shard should be routed to.
+func (c *RaftCluster) SetShardReadHint(hint metapb.ShardReadHint) {
+	c.Lock()
+	defer c.Unlock()
+	c.addNotifyLocked(event.NewReadHintEvent(&hint))
+}
+
+// GetHotPeerStats returns the stats of the hot replicas of the flow kind grouped
+// by the stores.
+func (c *RaftCluster) GetHotPeerStats(kind statistics.FlowKind, minHotDegree int) map[uint64][]statistics.HotPeerStat {
+	return c.hotStat.GetHotPeerStats(kind, minHotDegree)
+}
+
 // GetSuspectShards gets all suspect shards.
 func (c *RaftCluster) GetSuspectShards() []uint64 {
 	c.RLock()
@@ -435,7 +544,9 @@ func (c *RaftCluster) HandleStoreHeartbeat(stats *metapb.StoreStats) error {
 	if store == nil {
 		return fmt.Errorf("store %v not found", storeID)
 	}
-	newStore := store.Clone(core.SetStoreStats(stats), core.SetLastHeartbeatTS(time.Now()))
+	newStore := store.Clone(core.SetStoreStats(stats),
+		core.SetLastHeartbeatTS(time.Now()),
+		c.deriveStoreWeight(store, stats))
 	if newStore.IsLowSpace(c.opt.GetLowSpaceRatio()) {
 		c.logger.Warn("store does not have enough disk space, capacity %d, available %d",
 			zap.Uint64("store", newStore.Meta.GetID()),
@@ -456,6 +567,12 @@ func (c *RaftCluster) HandleStoreHeartbeat(stats *metapb.StoreStats) error {
 
 	c.core.PutStore(newStore)
 	c.addNotifyLocked(event.NewStoreStatsEvent(newStore.GetStoreStats()))
+	if _, ok := c.downStores[storeID]; ok {
+		delete(c.downStores, storeID)
+		c.logger.Info("store is up",
+			zap.Uint64("store", storeID))
+		c.addNotifyLocked(event.NewStoreStateEvent(storeID, true))
+	}
 
 	// c.limiter is nil before "start" is called
 	if c.limiter != nil && c.opt.GetStoreLimitMode() == "auto" {
@@ -487,12 +604,16 @@ func (c *RaftCluster) processShardHeartbeat(res *core.CachedShard) error {
 			return err
 		}
 	}
-	if checkMaybeDestroyed.GetState() == metapb.ShardState_Destroyed {
+	if checkMaybeDestroyed.GetState() == metapb.ShardState_Destroyed ||
+		(checkMaybeDestroyed == nil && c.core.AlreadyRemoved(res.Meta.GetID())) {
 		return errShardDestroyed
 	}
+	c.hotStat.Observe(res)
+	c.catchUpStat.Observe(res)
 
 	// Save to storage if meta is updated.
-	// Save to cache if meta or leader is updated, or contains any down/pending peer.
+	// Save to cache if meta or leader is updated, or contains any down/pending peer,
+	// or the freshness of any replica is changed.
 	// Mark isNew if the shard in cache does not have leader.
 	var saveKV, saveCache, isNew bool
 	if origin == nil {
@@ -534,12 +655,18 @@ func (c *RaftCluster) processShardHeartbeat(res *core.CachedShard) error {
 		if !core.SortedPeersEqual(res.GetPendingPeers(), origin.GetPendingPeers()) {
 			saveCache = true
 		}
+		if !core.ReplicaFreshnessEqual(res, origin) {
+			saveCache = true
+		}
 		if len(res.Meta.GetReplicas()) != len(origin.Meta.GetReplicas()) {
 			saveKV, saveCache = true, true
 		}
 		if res.Meta.GetState() != origin.Meta.GetState() {
 			saveKV, saveCache = true, true
 		}
+		if res.IsMergePrepared() != origin.IsMergePrepared() {
+			saveCache = true
+		}
 		if res.GetGroupKey() != origin.GetGroupKey() {
 			saveCache = true
 		}
@@ -575,6 +702,7 @@ func (c *RaftCluster) processShardHeartbeat(res *core.CachedShard) error {
 		}
 	}
 
+	lineageChanged := false
 	if saveCache {
 		// To prevent a concurrent heartbeat of another shard from overriding the up-to-date shard info by a stale one,
 		// check its validation again here.
@@ -586,6 +714,7 @@ func (c *RaftCluster) processShardHeartbeat(res *core.CachedShard) error {
 		}
 
 		overlaps := c.core.PutShard(res)
+		lineageChanged = c.observeShardLineageLocked(res, origin, overlaps)
 		if c.storage != nil {
 			for _, item := range overlaps {
 				if err := c.storage.RemoveShard(item.Meta); err != nil {
@@ -600,6 +729,8 @@ func (c *RaftCluster) processShardHeartbeat(res *core.CachedShard) error {
 				c.shardStats.ClearDefunctShard(item.Meta.GetID())
 			}
 			c.labelLevelStats.ClearDefunctShard(item.Meta.GetID())
+			c.hotStat.Remove(item.Meta.GetID())
+			c.catchUpStat.Remove(item.Meta.GetID())
 		}
 
 		// Update related stores.
@@ -636,6 +767,10 @@ func (c *RaftCluster) processShardHeartbeat(res *core.CachedShard) error {
 
 	c.Unlock()
 
+	if lineageChanged {
+		c.saveShardLineage(res.Meta.GetID())
+	}
+
 	// If there are concurrent heartbeats from the same shard, the last write will win even if
 	// writes to storage in the critical area. So don't use mutex to protect it.
 	if saveKV && c.storage != nil {
@@ -668,6 +803,10 @@ func (c *RaftCluster) processShardHeartbeat(res *core.CachedShard) error {
 			res.GetLeader().GetID(),
 			res.GetLease(),
 			false, false))
+		if leader := res.GetLeader(); leader.GetID() != 0 &&
+			(origin == nil || origin.GetLeader().GetID() != leader.GetID()) {
+			c.addNotifyLocked(event.NewShardLeaderEvent(res.Meta.GetID(), *leader))
+		}
 	}
 	if saveCache {
 		c.addNotifyLocked(event.NewShardStatsEvent(res.GetStat()))
@@ -791,6 +930,8 @@ func (c *RaftCluster) DropCacheShard(id uint64) {
 	if res := c.GetShard(id); res != nil {
 		c.core.RemoveShard(res)
 	}
+	c.hotStat.Remove(id)
+	c.catchUpStat.Remove(id)
 }
 
 // GetCacheCluster gets the cached cluster.
@@ -869,6 +1010,12 @@ func (c *RaftCluster) putStoreImpl(store metapb.Store, force bool) error {
 		// Add a new store.
 		s = core.NewCachedStore(store)
 	} else {
+		// The store is restarted with a data directory copied from another node or
+		// an older incarnation, the newer incarnation may be still running.
+		if store.GetEpoch() < s.Meta.GetEpoch() {
+			return util.WrappedError(util.ErrStaleStoreEpoch,
+				fmt.Sprintf("store %d epoch %d, current %d", store.GetID(), store.GetEpoch(), s.Meta.GetEpoch()))
+		}
 		// Use the given labels to update the store.
 		labels := store.GetLabels()
 		if !force {
@@ -883,6 +1030,8 @@ func (c *RaftCluster) putStoreImpl(store metapb.Store, force bool) error {
 			core.SetStoreLabels(labels),
 			core.SetStoreStartTime(store.GetStartTime()),
 			core.SetStoreDeployPath(store.GetDeployPath()),
+			core.SetStoreEpoch(store.GetEpoch()),
+			core.SetStoreSnapshotFormat(store.GetSnapshotFormat()),
 		)
 	}
 	if err := c.checkStoreLabels(s); err != nil {
@@ -1059,6 +1208,21 @@ func (c *RaftCluster) SetStoreWeight(storeID uint64, leaderWeight, shardWeight f
 	return c.putStoreLocked(newStore)
 }
 
+// deriveStoreWeight derives the leader weight from the disk class label and the
+// shard weight from the capacity of the store in the auto weight mode.
+func (c *RaftCluster) deriveStoreWeight(store *core.CachedStore, stats *metapb.StoreStats) core.StoreCreateOption {
+	if c.opt.GetStoreWeightMode() != "auto" {
+		return core.SetDerivedWeight(1, 1)
+	}
+
+	shardWeight := 1.0
+	if unit := c.opt.GetStoreWeightCapacityUnit(); unit > 0 && stats.GetCapacity() > 0 {
+		shardWeight = float64(stats.GetCapacity()) / float64(unit)
+	}
+	leaderWeight := c.opt.GetDiskClassWeight(store.GetLabelValue(c.opt.GetDiskClassLabel()))
+	return core.SetDerivedWeight(leaderWeight, shardWeight)
+}
+
 func (c *RaftCluster) putStoreLocked(store *core.CachedStore) error {
 	if c.storage != nil {
 		if err := c.storage.PutStore(store.Meta); err != nil {
@@ -1120,6 +1284,32 @@ func (c *RaftCluster) checkStores() {
 	}
 }
 
+// checkStoreStates notifies the stores down if they have no heartbeat for the
+// max store down time, the stores are notified up once the heartbeat received.
+func (c *RaftCluster) checkStoreStates() {
+	c.Lock()
+	defer c.Unlock()
+
+	maxDownTime := c.opt.GetMaxStoreDownTime()
+	for _, store := range c.GetStores() {
+		id := store.Meta.GetID()
+		if store.IsTombstone() {
+			delete(c.downStores, id)
+			continue
+		}
+		if _, ok := c.downStores[id]; ok || store.DownTime() <= maxDownTime {
+			continue
+		}
+
+		c.downStores[id] = struct{}{}
+		c.logger.Warn("store is down",
+			zap.Uint64("store", id),
+			zap.String("store-address", store.Meta.GetClientAddress()),
+			zap.Duration("down-time", store.DownTime()))
+		c.addNotifyLocked(event.NewStoreStateEvent(id, false))
+	}
+}
+
 // RemoveTombStoneRecords removes the tombStone Records.
 func (c *RaftCluster) RemoveTombStoneRecords() error {
 	c.Lock()
@@ -1484,6 +1674,50 @@ func (c *RaftCluster) SetAllStoresLimit(typ limit.Type, ratePerMin float64) erro
 	return nil
 }
 
+// SetShardScoreFunction sets the function scoring the stores in the balance
+// shard schedulers.
+func (c *RaftCluster) SetShardScoreFunction(fn string) error {
+	if _, err := core.StringToShardScoreFunction(fn); err != nil {
+		return err
+	}
+
+	old := c.opt.GetScheduleConfig().Clone()
+	c.opt.SetShardScoreFunction(fn)
+	if err := c.opt.Persist(c.storage); err != nil {
+		// roll back the score function
+		c.opt.SetScheduleConfig(old)
+		c.logger.Error("fail to persist shard score function",
+			zap.Error(err))
+		return err
+	}
+	c.logger.Info("shard score function changed",
+		zap.String("function", fn))
+	return nil
+}
+
+// SetMaintenanceWindows sets the maintenance windows of the heavy schedulers, an
+// empty windows means the schedulers are always allowed.
+func (c *RaftCluster) SetMaintenanceWindows(windows []config.MaintenanceWindow) error {
+	for _, w := range windows {
+		if err := w.Validate(); err != nil {
+			return err
+		}
+	}
+
+	old := c.opt.GetScheduleConfig().Clone()
+	c.opt.SetMaintenanceWindows(windows)
+	if err := c.opt.Persist(c.storage); err != nil {
+		// roll back the maintenance windows
+		c.opt.SetScheduleConfig(old)
+		c.logger.Error("fail to persist maintenance windows",
+			zap.Error(err))
+		return err
+	}
+	c.logger.Info("maintenance windows changed",
+		zap.Any("windows", windows))
+	return nil
+}
+
 // GetClusterVersion returns the current cluster version.
 func (c *RaftCluster) GetClusterVersion() string {
 	return c.opt.GetClusterVersion().String()
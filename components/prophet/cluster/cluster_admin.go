@@ -0,0 +1,239 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/config"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/operator"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+// HandleGetSchedulers returns the schedulers sorted by the name
+func (c *RaftCluster) HandleGetSchedulers(request *rpcpb.ProphetRequest) (*rpcpb.GetSchedulersRsp, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.running {
+		return nil, util.ErrNotLeader
+	}
+
+	names := c.coordinator.getSchedulers()
+	sort.Strings(names)
+	rsp := &rpcpb.GetSchedulersRsp{}
+	for _, name := range names {
+		paused, err := c.coordinator.isSchedulerPaused(name)
+		if err != nil {
+			// removed after listed
+			continue
+		}
+		rsp.Schedulers = append(rsp.Schedulers, rpcpb.SchedulerStatus{
+			Name:   name,
+			Paused: paused,
+		})
+	}
+	return rsp, nil
+}
+
+// HandlePauseScheduler pauses or resumes the scheduler
+func (c *RaftCluster) HandlePauseScheduler(request *rpcpb.ProphetRequest) error {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.running {
+		return util.ErrNotLeader
+	}
+
+	return c.coordinator.pauseOrResumeScheduler(request.PauseScheduler.Name,
+		request.PauseScheduler.Seconds)
+}
+
+// HandleSetStoreWeight sets the leader and shard balance weights of the store
+func (c *RaftCluster) HandleSetStoreWeight(request *rpcpb.ProphetRequest) error {
+	c.RLock()
+	running := c.running
+	c.RUnlock()
+	if !running {
+		return util.ErrNotLeader
+	}
+
+	req := request.SetStoreWeight
+	if req.LeaderWeight < 0 || req.ShardWeight < 0 {
+		return fmt.Errorf("weights of store %d should be nonnegative", req.StoreID)
+	}
+	return c.SetStoreWeight(req.StoreID, req.LeaderWeight, req.ShardWeight)
+}
+
+// HandleSetShardScoreFunction sets the function scoring the stores in the
+// balance shard schedulers
+func (c *RaftCluster) HandleSetShardScoreFunction(request *rpcpb.ProphetRequest) error {
+	c.RLock()
+	running := c.running
+	c.RUnlock()
+	if !running {
+		return util.ErrNotLeader
+	}
+
+	return c.SetShardScoreFunction(request.SetShardScoreFunction.Function)
+}
+
+// HandleSetMaintenanceWindows sets the maintenance windows of the heavy
+// schedulers, the schedulers are always allowed if no window is set.
+func (c *RaftCluster) HandleSetMaintenanceWindows(request *rpcpb.ProphetRequest) error {
+	c.RLock()
+	running := c.running
+	c.RUnlock()
+	if !running {
+		return util.ErrNotLeader
+	}
+
+	windows := make([]config.MaintenanceWindow, 0, len(request.SetMaintenanceWindows.Windows))
+	for _, w := range request.SetMaintenanceWindows.Windows {
+		windows = append(windows, config.MaintenanceWindow{Start: w.Start, End: w.End})
+	}
+	return c.SetMaintenanceWindows(windows)
+}
+
+// HandleCreateOperator creates the operator of the shard and adds it to the
+// operator controller, an error is returned if the operator is not added, e.g.
+// the shard already has a running operator.
+func (c *RaftCluster) HandleCreateOperator(request *rpcpb.ProphetRequest) error {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.running {
+		return util.ErrNotLeader
+	}
+
+	req := request.CreateOperator
+	res := c.GetShard(req.ShardID)
+	if res == nil {
+		return fmt.Errorf("shard %d not found", req.ShardID)
+	}
+	if req.Type != rpcpb.ManualScatterShard && c.GetStore(req.ToStoreID) == nil {
+		return fmt.Errorf("store %d not found", req.ToStoreID)
+	}
+
+	var op *operator.Operator
+	var err error
+	switch req.Type {
+	case rpcpb.ManualTransferLeader:
+		leader := res.GetLeader()
+		if leader == nil {
+			return fmt.Errorf("shard %d has no leader", req.ShardID)
+		}
+		op, err = operator.CreateTransferLeaderOperator("admin-transfer-leader", c, res,
+			leader.StoreID, req.ToStoreID, operator.OpAdmin)
+	case rpcpb.ManualMoveReplica:
+		old, ok := res.GetStorePeer(req.FromStoreID)
+		if !ok {
+			return fmt.Errorf("shard %d has no replica on store %d", req.ShardID, req.FromStoreID)
+		}
+		op, err = operator.CreateMovePeerOperator("admin-move-peer", c, res, operator.OpAdmin,
+			req.FromStoreID, metapb.Replica{StoreID: req.ToStoreID, Role: old.Role})
+	case rpcpb.ManualScatterShard:
+		op, err = c.coordinator.shardScatterer.Scatter(res, "")
+	default:
+		return fmt.Errorf("operator type %s not support", req.Type.String())
+	}
+	if err != nil {
+		return err
+	}
+	if op == nil {
+		return fmt.Errorf("shard %d has nothing to schedule", req.ShardID)
+	}
+	if !c.coordinator.opController.AddOperator(op) {
+		return fmt.Errorf("operator of shard %d not added", req.ShardID)
+	}
+	return nil
+}
+
+// HandleGetOperators returns the running operators sorted by the shard id
+func (c *RaftCluster) HandleGetOperators(request *rpcpb.ProphetRequest) (*rpcpb.GetOperatorsRsp, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.running {
+		return nil, util.ErrNotLeader
+	}
+
+	shardID := request.GetOperators.ShardID
+	rsp := &rpcpb.GetOperatorsRsp{}
+	for _, op := range c.coordinator.opController.GetOperators() {
+		if shardID != 0 && op.ShardID() != shardID {
+			continue
+		}
+		status := rpcpb.OperatorStatus{
+			ShardID:     op.ShardID(),
+			Desc:        op.Desc(),
+			Kind:        op.Kind().String(),
+			Status:      operator.OpStatusToString(op.Status()),
+			CurrentStep: int32(op.CurrentStepIndex()),
+			CreateTime:  uint64(op.GetCreateTime().Unix()),
+		}
+		for i := 0; i < op.Len(); i++ {
+			status.Steps = append(status.Steps, op.Step(i).String())
+		}
+		rsp.Operators = append(rsp.Operators, status)
+	}
+	sort.Slice(rsp.Operators, func(i, j int) bool {
+		return rsp.Operators[i].ShardID < rsp.Operators[j].ShardID
+	})
+	return rsp, nil
+}
+
+// HandleGetHotBuckets returns the hottest buckets of the shards in the group
+func (c *RaftCluster) HandleGetHotBuckets(request *rpcpb.ProphetRequest) (*rpcpb.GetHotBucketsRsp, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.running {
+		return nil, util.ErrNotLeader
+	}
+
+	req := request.GetHotBuckets
+	rsp := &rpcpb.GetHotBucketsRsp{}
+	for _, res := range c.core.GetShards() {
+		if res.Meta.GetGroup() != req.Group {
+			continue
+		}
+		for _, b := range res.GetBuckets() {
+			if core.BucketLoad(b) > 0 {
+				rsp.Buckets = append(rsp.Buckets, b)
+			}
+		}
+	}
+	sort.Slice(rsp.Buckets, func(i, j int) bool {
+		return core.BucketLoad(rsp.Buckets[i]) > core.BucketLoad(rsp.Buckets[j])
+	})
+	if req.Limit > 0 && uint64(len(rsp.Buckets)) > req.Limit {
+		rsp.Buckets = rsp.Buckets[:req.Limit]
+	}
+	return rsp, nil
+}
+
+// HandleGetReplicaDrifts returns the shards whose replica count deviates from
+// the target, they are found by the patrol of the shards.
+func (c *RaftCluster) HandleGetReplicaDrifts(request *rpcpb.ProphetRequest) (*rpcpb.GetReplicaDriftsRsp, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.running {
+		return nil, util.ErrNotLeader
+	}
+
+	limit := int(request.GetReplicaDrifts.Limit)
+	return &rpcpb.GetReplicaDriftsRsp{
+		Drifts: c.coordinator.checkers.GetReplicaDriftChecker().GetDrifts(limit),
+	}, nil
+}
@@ -0,0 +1,246 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/config"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/operator"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedulers"
+	"github.com/matrixorigin/matrixcube/components/prophet/storage"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseScheduler(t *testing.T) {
+	tc, co, cleanup := prepare(t, nil, nil, nil)
+	defer cleanup()
+	tc.coordinator = co
+	tc.running = true
+
+	for _, typ := range []string{schedulers.BalanceLeaderType, schedulers.BalanceShardType} {
+		s, err := schedule.CreateScheduler(typ, co.opController, storage.NewTestStorage(), schedule.ConfigSliceDecoder(typ, []string{"0", "", ""}))
+		require.NoError(t, err)
+		require.NoError(t, co.addScheduler(s))
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	rsp, err := tc.HandleGetSchedulers(req)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(rsp.Schedulers))
+	assert.True(t, rsp.Schedulers[0].Name < rsp.Schedulers[1].Name)
+	assert.False(t, rsp.Schedulers[0].Paused)
+	assert.False(t, rsp.Schedulers[1].Paused)
+
+	req.PauseScheduler = rpcpb.PauseSchedulerReq{Name: "all", Seconds: 60}
+	require.NoError(t, tc.HandlePauseScheduler(req))
+	rsp, err = tc.HandleGetSchedulers(req)
+	require.NoError(t, err)
+	assert.True(t, rsp.Schedulers[0].Paused)
+	assert.True(t, rsp.Schedulers[1].Paused)
+
+	req.PauseScheduler = rpcpb.PauseSchedulerReq{Name: rsp.Schedulers[0].Name}
+	require.NoError(t, tc.HandlePauseScheduler(req))
+	rsp, err = tc.HandleGetSchedulers(req)
+	require.NoError(t, err)
+	assert.False(t, rsp.Schedulers[0].Paused)
+	assert.True(t, rsp.Schedulers[1].Paused)
+
+	req.PauseScheduler = rpcpb.PauseSchedulerReq{Name: "not-exist-scheduler", Seconds: 60}
+	assert.Error(t, tc.HandlePauseScheduler(req))
+}
+
+func TestGetHotBuckets(t *testing.T) {
+	tc, co, cleanup := prepare(t, nil, nil, nil)
+	defer cleanup()
+	tc.coordinator = co
+	tc.running = true
+
+	assert.Nil(t, tc.addShardStore(1, 1))
+	assert.Nil(t, tc.addLeaderShard(1, 1))
+	assert.Nil(t, tc.addLeaderShard(2, 1))
+	assert.Nil(t, tc.addLeaderShard(3, 1))
+	for id, load := range map[uint64]uint64{1: 10, 2: 30, 3: 20} {
+		res := tc.GetShard(id)
+		res = res.Clone(core.WithBuckets([]metapb.ShardBucket{
+			{ShardID: id, Start: res.Meta.Start, End: []byte("m")},
+			{ShardID: id, Start: []byte("m"), End: res.Meta.End},
+		}), core.SetBucketStats([]byte("m"), load, 1, load, 1))
+		tc.core.PutShard(res)
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	req.GetHotBuckets.Limit = 2
+	rsp, err := tc.HandleGetHotBuckets(req)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(rsp.Buckets))
+	assert.Equal(t, uint64(2), rsp.Buckets[0].ShardID)
+	assert.Equal(t, uint64(3), rsp.Buckets[1].ShardID)
+	assert.Equal(t, []byte("m"), rsp.Buckets[0].Start)
+
+	req.GetHotBuckets.Limit = 0
+	rsp, err = tc.HandleGetHotBuckets(req)
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(rsp.Buckets), "the buckets without load are skipped")
+
+	req.GetHotBuckets.Group = 1
+	rsp, err = tc.HandleGetHotBuckets(req)
+	require.NoError(t, err)
+	assert.Empty(t, rsp.Buckets)
+}
+
+func TestCreateOperator(t *testing.T) {
+	tc, co, cleanup := prepare(t, nil, nil, nil)
+	defer cleanup()
+	tc.coordinator = co
+	tc.running = true
+
+	for id := uint64(1); id <= 4; id++ {
+		assert.Nil(t, tc.addShardStore(id, 1))
+	}
+	assert.Nil(t, tc.addLeaderShard(1, 1, 2, 3))
+	assert.Nil(t, tc.addLeaderShard(2, 1, 2, 3))
+
+	req := &rpcpb.ProphetRequest{}
+	req.CreateOperator = rpcpb.CreateOperatorReq{Type: rpcpb.ManualTransferLeader, ShardID: 100, ToStoreID: 2}
+	assert.Error(t, tc.HandleCreateOperator(req), "shard not found")
+	req.CreateOperator = rpcpb.CreateOperatorReq{Type: rpcpb.ManualTransferLeader, ShardID: 1, ToStoreID: 100}
+	assert.Error(t, tc.HandleCreateOperator(req), "store not found")
+	req.CreateOperator = rpcpb.CreateOperatorReq{Type: rpcpb.ManualMoveReplica, ShardID: 2, FromStoreID: 4, ToStoreID: 1}
+	assert.Error(t, tc.HandleCreateOperator(req), "no replica on the from store")
+
+	req.CreateOperator = rpcpb.CreateOperatorReq{Type: rpcpb.ManualTransferLeader, ShardID: 1, ToStoreID: 2}
+	require.NoError(t, tc.HandleCreateOperator(req))
+	assert.Error(t, tc.HandleCreateOperator(req), "the shard has a running operator")
+	req.CreateOperator = rpcpb.CreateOperatorReq{Type: rpcpb.ManualMoveReplica, ShardID: 2, FromStoreID: 3, ToStoreID: 4}
+	require.NoError(t, tc.HandleCreateOperator(req))
+
+	req.GetOperators.ShardID = 0
+	rsp, err := tc.HandleGetOperators(req)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(rsp.Operators))
+	op := rsp.Operators[0]
+	assert.Equal(t, uint64(1), op.ShardID)
+	assert.Equal(t, "admin-transfer-leader", op.Desc)
+	assert.Equal(t, (operator.OpAdmin | operator.OpLeader).String(), op.Kind)
+	assert.Equal(t, 1, len(op.Steps))
+	assert.Equal(t, int32(0), op.CurrentStep)
+	op = rsp.Operators[1]
+	assert.Equal(t, uint64(2), op.ShardID)
+	assert.Equal(t, "admin-move-peer", op.Desc)
+	assert.True(t, len(op.Steps) > 1)
+
+	req.GetOperators.ShardID = 2
+	rsp, err = tc.HandleGetOperators(req)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(rsp.Operators))
+	assert.Equal(t, uint64(2), rsp.Operators[0].ShardID)
+}
+
+func TestSetStoreWeightAndShardScoreFunction(t *testing.T) {
+	tc, co, cleanup := prepare(t, nil, nil, nil)
+	defer cleanup()
+	tc.coordinator = co
+	tc.running = true
+
+	assert.Nil(t, tc.addShardStore(1, 1))
+	req := &rpcpb.ProphetRequest{}
+	req.SetStoreWeight = rpcpb.SetStoreWeightReq{StoreID: 1, LeaderWeight: 2, ShardWeight: 3}
+	require.NoError(t, tc.HandleSetStoreWeight(req))
+	assert.Equal(t, 2.0, tc.GetStore(1).GetLeaderWeight())
+	assert.Equal(t, 3.0, tc.GetStore(1).GetShardWeight())
+
+	req.SetStoreWeight = rpcpb.SetStoreWeightReq{StoreID: 1, LeaderWeight: -1, ShardWeight: 1}
+	assert.Error(t, tc.HandleSetStoreWeight(req))
+	req.SetStoreWeight = rpcpb.SetStoreWeightReq{StoreID: 2, LeaderWeight: 1, ShardWeight: 1}
+	assert.Error(t, tc.HandleSetStoreWeight(req), "store not found")
+
+	assert.Equal(t, core.ByAmplifiedSize, tc.opt.GetShardScoreFunction())
+	req.SetShardScoreFunction.Function = "capacity-ratio"
+	require.NoError(t, tc.HandleSetShardScoreFunction(req))
+	assert.Equal(t, core.ByCapacityRatio, tc.opt.GetShardScoreFunction())
+	req.SetShardScoreFunction.Function = "unknown"
+	assert.Error(t, tc.HandleSetShardScoreFunction(req))
+	assert.Equal(t, core.ByCapacityRatio, tc.opt.GetShardScoreFunction())
+
+	tc.running = false
+	assert.Equal(t, util.ErrNotLeader, tc.HandleSetShardScoreFunction(req))
+}
+
+func TestHandleSetMaintenanceWindows(t *testing.T) {
+	tc, _, cleanup := prepare(t, nil, nil, nil)
+	defer cleanup()
+
+	req := &rpcpb.ProphetRequest{}
+	req.SetMaintenanceWindows.Windows = []rpcpb.MaintenanceWindow{{Start: "22:00", End: "06:00"}}
+	assert.Equal(t, util.ErrNotLeader, tc.HandleSetMaintenanceWindows(req))
+
+	tc.running = true
+	require.NoError(t, tc.HandleSetMaintenanceWindows(req))
+	windows := []config.MaintenanceWindow{{Start: "22:00", End: "06:00"}}
+	assert.Equal(t, windows, tc.opt.GetScheduleConfig().MaintenanceWindows)
+	assert.False(t, tc.opt.IsInMaintenanceWindow(time.Date(2022, 1, 1, 12, 0, 0, 0, time.Local)))
+	assert.True(t, tc.opt.IsInMaintenanceWindow(time.Date(2022, 1, 1, 23, 0, 0, 0, time.Local)))
+
+	req.SetMaintenanceWindows.Windows = []rpcpb.MaintenanceWindow{{Start: "22:00", End: "6"}}
+	assert.Error(t, tc.HandleSetMaintenanceWindows(req))
+	assert.Equal(t, windows, tc.opt.GetScheduleConfig().MaintenanceWindows)
+
+	req.SetMaintenanceWindows.Windows = nil
+	require.NoError(t, tc.HandleSetMaintenanceWindows(req))
+	assert.Empty(t, tc.opt.GetScheduleConfig().MaintenanceWindows)
+}
+
+func TestGetReplicaDrifts(t *testing.T) {
+	tc, co, cleanup := prepare(t, nil, nil, nil)
+	defer cleanup()
+	tc.coordinator = co
+	tc.running = true
+
+	for id := uint64(1); id <= 4; id++ {
+		assert.Nil(t, tc.addShardStore(id, 1))
+	}
+	assert.Nil(t, tc.addLeaderShard(1, 1, 2, 3))
+	assert.Nil(t, tc.addLeaderShard(2, 1, 2))
+	assert.Nil(t, tc.addLeaderShard(3, 1, 2, 3, 4))
+	for id := uint64(1); id <= 3; id++ {
+		co.checkers.CheckShard(tc.GetShard(id))
+	}
+
+	req := &rpcpb.ProphetRequest{}
+	rsp, err := tc.HandleGetReplicaDrifts(req)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(rsp.Drifts))
+	assert.Equal(t, uint64(2), rsp.Drifts[0].ShardID)
+	assert.Equal(t, uint64(2), rsp.Drifts[0].Replicas)
+	assert.Equal(t, uint64(3), rsp.Drifts[0].Target)
+	assert.Equal(t, uint64(3), rsp.Drifts[1].ShardID)
+	assert.Equal(t, uint64(4), rsp.Drifts[1].Replicas)
+
+	req.GetReplicaDrifts.Limit = 1
+	rsp, err = tc.HandleGetReplicaDrifts(req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(rsp.Drifts))
+
+	tc.running = false
+	_, err = tc.HandleGetReplicaDrifts(req)
+	assert.Error(t, err)
+}
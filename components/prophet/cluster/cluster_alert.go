@@ -0,0 +1,107 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/alert"
+	"github.com/matrixorigin/matrixcube/components/prophet/config"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/statistics"
+)
+
+func newAlertNotifier(cfg *config.Config, c *RaftCluster) *alert.Notifier {
+	if cfg == nil {
+		return nil
+	}
+	return alert.NewNotifier(cfg.Alert.Webhooks,
+		cfg.Alert.WebhookTimeout.Duration,
+		cfg.Alert.RepeatInterval.Duration,
+		cfg.AlertHandlers,
+		c.logger)
+}
+
+// checkAlerts detects the significant events of the cluster and notifies them
+func (c *RaftCluster) checkAlerts() {
+	if !c.alerts.Enabled() {
+		return
+	}
+	c.alerts.Update(c.collectAlerts(c.operatorStuckDuration), time.Now())
+}
+
+func (c *RaftCluster) collectAlerts(operatorStuckDuration time.Duration) []alert.Alert {
+	var alerts []alert.Alert
+	for _, s := range c.GetStores() {
+		if s.IsTombstone() {
+			continue
+		}
+
+		if downTime := s.DownTime(); downTime > c.opt.GetMaxStoreDownTime() {
+			alerts = append(alerts, alert.Alert{
+				Type:    alert.StoreDown,
+				StoreID: s.Meta.GetID(),
+				Message: fmt.Sprintf("store %s down for %s", s.Meta.GetClientAddress(), downTime),
+			})
+		} else if s.GetCapacity() > 0 && s.IsLowSpace(c.opt.GetLowSpaceRatio()) {
+			alerts = append(alerts, alert.Alert{
+				Type:    alert.StoreLowSpace,
+				StoreID: s.Meta.GetID(),
+				Message: fmt.Sprintf("store %s available space %d bytes", s.Meta.GetClientAddress(), s.GetAvailable()),
+			})
+		}
+
+		if stats := s.GetStoreStats(); stats.GetClockSkewed() {
+			alerts = append(alerts, alert.Alert{
+				Type:    alert.StoreClockSkewed,
+				StoreID: s.Meta.GetID(),
+				Message: fmt.Sprintf("store %s clock offset %s", s.Meta.GetClientAddress(), time.Duration(stats.GetClockOffset())),
+			})
+		}
+	}
+
+	for _, res := range c.GetShardStatsByType(statistics.DownPeer) {
+		if down, voters := countDownVoters(res); down > 0 && down*2 >= voters {
+			alerts = append(alerts, alert.Alert{
+				Type:    alert.ShardMajorityDown,
+				ShardID: res.Meta.GetID(),
+				Message: fmt.Sprintf("%d of %d voters down", down, voters),
+			})
+		}
+	}
+
+	if co := c.coordinator; co != nil && operatorStuckDuration > 0 {
+		for _, op := range co.opController.GetOperators() {
+			if op.RunningTime() > operatorStuckDuration {
+				alerts = append(alerts, alert.Alert{
+					Type:    alert.OperatorStuck,
+					ShardID: op.ShardID(),
+					Message: fmt.Sprintf("operator %s running for %s", op.String(), op.RunningTime()),
+				})
+			}
+		}
+	}
+	return alerts
+}
+
+func countDownVoters(res *core.CachedShard) (int, int) {
+	down := 0
+	for _, p := range res.GetDownPeers() {
+		if _, ok := res.GetDownVoter(p.Replica.ID); ok {
+			down++
+		}
+	}
+	return down, len(res.GetVoters())
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/alert"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/storage"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectStoreAlerts(t *testing.T) {
+	_, opt, err := newTestScheduleConfig()
+	require.NoError(t, err)
+	cluster := newTestRaftCluster(opt, storage.NewTestStorage(), core.NewBasicCluster(nil))
+
+	stores := newTestStores(3, "2.0.0")
+	// store 1 down
+	require.NoError(t, cluster.putStoreLocked(stores[0].Clone(core.SetLastHeartbeatTS(time.Now().Add(-time.Hour)))))
+	// store 2 low space
+	require.NoError(t, cluster.putStoreLocked(stores[1].Clone(core.SetLastHeartbeatTS(time.Now()),
+		core.SetStoreStats(&metapb.StoreStats{Capacity: 100 * (1 << 20), Available: 1 << 20}))))
+	// store 3 clock skewed
+	require.NoError(t, cluster.putStoreLocked(stores[2].Clone(core.SetLastHeartbeatTS(time.Now()),
+		core.SetStoreStats(&metapb.StoreStats{ClockOffset: int64(time.Second), ClockSkewed: true}))))
+
+	alerts := cluster.collectAlerts(0)
+	require.Equal(t, 3, len(alerts))
+	types := map[uint64]alert.Type{}
+	for _, a := range alerts {
+		types[a.StoreID] = a.Type
+	}
+	assert.Equal(t, map[uint64]alert.Type{1: alert.StoreDown, 2: alert.StoreLowSpace, 3: alert.StoreClockSkewed}, types)
+}
+
+func TestCountDownVoters(t *testing.T) {
+	replicas := []metapb.Replica{
+		{ID: 1, StoreID: 1},
+		{ID: 2, StoreID: 2},
+		{ID: 3, StoreID: 3},
+		{ID: 4, StoreID: 4, Role: metapb.ReplicaRole_Learner},
+	}
+	res := core.NewCachedShard(metapb.Shard{ID: 1, Replicas: replicas}, &replicas[0],
+		core.WithDownPeers([]metapb.ReplicaStats{{Replica: replicas[1]}, {Replica: replicas[3]}}))
+	down, voters := countDownVoters(res)
+	assert.Equal(t, 1, down)
+	assert.Equal(t, 3, voters)
+
+	res = res.Clone(core.WithDownPeers([]metapb.ReplicaStats{{Replica: replicas[1]}, {Replica: replicas[2]}}))
+	down, voters = countDownVoters(res)
+	assert.Equal(t, 2, down)
+	assert.Equal(t, 3, voters)
+}
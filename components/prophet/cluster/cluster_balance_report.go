@@ -0,0 +1,194 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+// balanceReporter keeps the latest balance reports of each shard group, so the
+// operators can check whether the balance is improved by the schedulers over
+// time. The reports are kept in memory, and lost if the prophet leader changed.
+type balanceReporter struct {
+	sync.RWMutex
+	last    time.Time
+	reports map[uint64][]rpcpb.BalanceReport
+}
+
+func newBalanceReporter() *balanceReporter {
+	return &balanceReporter{reports: make(map[uint64][]rpcpb.BalanceReport)}
+}
+
+// add adds the reports of the groups, the oldest reports of a group are dropped
+// if more than history reports are kept, and the groups not reported anymore
+// are dropped.
+func (r *balanceReporter) add(now time.Time, reports []rpcpb.BalanceReport, history int) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.last = now
+	groups := make(map[uint64]struct{}, len(reports))
+	for _, report := range reports {
+		groups[report.Group] = struct{}{}
+		values := append(r.reports[report.Group], report)
+		if history > 0 && len(values) > history {
+			values = append(values[:0:0], values[len(values)-history:]...)
+		}
+		r.reports[report.Group] = values
+	}
+	for group := range r.reports {
+		if _, ok := groups[group]; !ok {
+			delete(r.reports, group)
+		}
+	}
+}
+
+// get returns the latest limit reports of the group ordered by the time, all
+// the reports are returned if the limit is 0.
+func (r *balanceReporter) get(group uint64, limit int) []rpcpb.BalanceReport {
+	r.RLock()
+	defer r.RUnlock()
+
+	values := r.reports[group]
+	if limit > 0 && len(values) > limit {
+		values = values[len(values)-limit:]
+	}
+	return append(values[:0:0], values...)
+}
+
+func (r *balanceReporter) getLastTime() time.Time {
+	r.RLock()
+	defer r.RUnlock()
+	return r.last
+}
+
+// storeBalance the leaders, the replicas and the size of the shards of a group
+// on a store
+type storeBalance struct {
+	leaders  float64
+	replicas float64
+	size     float64
+}
+
+// reportBalance computes the balance reports of all the shard groups, it's
+// called by the background jobs and does nothing until BalanceReportInterval
+// passed since the last report.
+func (c *RaftCluster) reportBalance(now time.Time) {
+	interval := c.opt.GetBalanceReportInterval()
+	if interval <= 0 || now.Sub(c.balanceReports.getLastTime()) < interval {
+		return
+	}
+
+	var stores []uint64
+	for _, s := range c.core.GetStores() {
+		if s.IsUp() {
+			stores = append(stores, s.Meta.GetID())
+		}
+	}
+	groups := make(map[uint64]map[uint64]*storeBalance)
+	for _, res := range c.core.GetShards() {
+		group := res.Meta.GetGroup()
+		balances, ok := groups[group]
+		if !ok {
+			balances = make(map[uint64]*storeBalance, len(stores))
+			for _, id := range stores {
+				balances[id] = &storeBalance{}
+			}
+			groups[group] = balances
+		}
+		if leader := res.GetLeader(); leader != nil {
+			if b, ok := balances[leader.StoreID]; ok {
+				b.leaders++
+			}
+		}
+		for _, p := range res.Meta.GetReplicas() {
+			if b, ok := balances[p.StoreID]; ok {
+				b.replicas++
+				b.size += float64(res.GetApproximateSize())
+			}
+		}
+	}
+
+	reports := make([]rpcpb.BalanceReport, 0, len(groups))
+	for group, balances := range groups {
+		reports = append(reports, newBalanceReport(now, group, balances))
+	}
+	c.balanceReports.add(now, reports, c.opt.GetBalanceReportHistory())
+}
+
+func newBalanceReport(now time.Time, group uint64, balances map[uint64]*storeBalance) rpcpb.BalanceReport {
+	leaders := make([]float64, 0, len(balances))
+	replicas := make([]float64, 0, len(balances))
+	sizes := make([]float64, 0, len(balances))
+	for _, b := range balances {
+		leaders = append(leaders, b.leaders)
+		replicas = append(replicas, b.replicas)
+		sizes = append(sizes, b.size)
+	}
+	report := rpcpb.BalanceReport{
+		Timestamp: now.Unix(),
+		Group:     group,
+		Stores:    uint64(len(balances)),
+	}
+	report.LeaderGini, report.LeaderMaxMinRatio = skew(leaders)
+	report.ReplicaGini, report.ReplicaMaxMinRatio = skew(replicas)
+	report.SizeGini, report.SizeMaxMinRatio = skew(sizes)
+	return report
+}
+
+// skew returns the normalized Gini coefficient and the max/min ratio of the
+// values. The Gini coefficient is normalized by n/(n-1), so it's 1 if all on
+// a single value.
+func skew(values []float64) (gini float64, ratio float64) {
+	n := len(values)
+	if n == 0 {
+		return 0, 0
+	}
+
+	sort.Float64s(values)
+	var sum, weighted float64
+	for i, v := range values {
+		sum += v
+		weighted += float64(i+1) * v
+	}
+	min := values[0]
+	if min < 1 {
+		min = 1
+	}
+	ratio = values[n-1] / min
+	if sum == 0 || n == 1 {
+		return 0, ratio
+	}
+	gini = (2*weighted/(float64(n)*sum) - float64(n+1)/float64(n)) * float64(n) / float64(n-1)
+	return gini, ratio
+}
+
+// HandleGetBalanceReports returns the latest balance reports of the shard group
+func (c *RaftCluster) HandleGetBalanceReports(request *rpcpb.ProphetRequest) (*rpcpb.GetBalanceReportsRsp, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.running {
+		return nil, util.ErrNotLeader
+	}
+
+	req := request.GetBalanceReports
+	return &rpcpb.GetBalanceReportsRsp{
+		Reports: c.balanceReports.get(req.Group, int(req.Limit)),
+	}, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/config"
+	"github.com/matrixorigin/matrixcube/components/prophet/util/typeutil"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkew(t *testing.T) {
+	cases := []struct {
+		values []float64
+		gini   float64
+		ratio  float64
+	}{
+		{values: nil, gini: 0, ratio: 0},
+		{values: []float64{5}, gini: 0, ratio: 1},
+		{values: []float64{0, 0, 0}, gini: 0, ratio: 0},
+		{values: []float64{3, 3, 3}, gini: 0, ratio: 1},
+		{values: []float64{3, 0, 0}, gini: 1, ratio: 3},
+		{values: []float64{1, 3}, gini: 0.5, ratio: 3},
+	}
+	for i, c := range cases {
+		gini, ratio := skew(c.values)
+		assert.InDelta(t, c.gini, gini, 1e-9, "case %d", i)
+		assert.InDelta(t, c.ratio, ratio, 1e-9, "case %d", i)
+	}
+}
+
+func TestReportBalance(t *testing.T) {
+	tc, _, cleanup := prepare(t, func(cfg *config.ScheduleConfig) {
+		cfg.BalanceReportInterval = typeutil.NewDuration(time.Minute)
+		cfg.BalanceReportHistory = 2
+	}, nil, nil)
+	defer cleanup()
+	tc.running = true
+
+	for id := uint64(1); id <= 3; id++ {
+		assert.Nil(t, tc.addShardStore(id, 1))
+	}
+	for id := uint64(1); id <= 3; id++ {
+		assert.Nil(t, tc.addLeaderShard(id, 1, 2, 3))
+	}
+
+	now := time.Now()
+	tc.reportBalance(now)
+	tc.reportBalance(now.Add(time.Second))
+	reports := tc.balanceReports.get(0, 0)
+	require.Equal(t, 1, len(reports), "reported once in the interval")
+	report := reports[0]
+	assert.Equal(t, now.Unix(), report.Timestamp)
+	assert.Equal(t, uint64(3), report.Stores)
+	assert.InDelta(t, 1, report.LeaderGini, 1e-9)
+	assert.InDelta(t, 3, report.LeaderMaxMinRatio, 1e-9)
+	assert.InDelta(t, 0, report.ReplicaGini, 1e-9)
+	assert.InDelta(t, 1, report.ReplicaMaxMinRatio, 1e-9)
+	assert.InDelta(t, 0, report.SizeGini, 1e-9)
+	assert.InDelta(t, 1, report.SizeMaxMinRatio, 1e-9)
+
+	tc.reportBalance(now.Add(time.Minute))
+	tc.reportBalance(now.Add(2 * time.Minute))
+	req := &rpcpb.ProphetRequest{}
+	rsp, err := tc.HandleGetBalanceReports(req)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(rsp.Reports), "bounded by the history")
+	assert.Equal(t, now.Add(time.Minute).Unix(), rsp.Reports[0].Timestamp)
+	assert.Equal(t, now.Add(2*time.Minute).Unix(), rsp.Reports[1].Timestamp)
+
+	req.GetBalanceReports.Limit = 1
+	rsp, err = tc.HandleGetBalanceReports(req)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(rsp.Reports))
+	assert.Equal(t, now.Add(2*time.Minute).Unix(), rsp.Reports[0].Timestamp)
+
+	req.GetBalanceReports.Group = 1
+	rsp, err = tc.HandleGetBalanceReports(req)
+	require.NoError(t, err)
+	assert.Empty(t, rsp.Reports)
+
+	tc.running = false
+	_, err = tc.HandleGetBalanceReports(req)
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"sort"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/operator"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+// HandleGetCatchUpProgress returns the catch-up progress of the replicas added
+// by the running operators, the replicas caught up are not included.
+func (c *RaftCluster) HandleGetCatchUpProgress(request *rpcpb.ProphetRequest) (*rpcpb.GetCatchUpProgressRsp, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.running {
+		return nil, util.ErrNotLeader
+	}
+
+	shardID := request.GetCatchUpProgress.ShardID
+	rsp := &rpcpb.GetCatchUpProgressRsp{}
+	for _, op := range c.coordinator.opController.GetOperators() {
+		if shardID != 0 && op.ShardID() != shardID {
+			continue
+		}
+		res := c.GetShard(op.ShardID())
+		if res == nil {
+			continue
+		}
+		if progress, ok := c.getOperatorCatchUpProgress(op, res); ok {
+			rsp.Operators = append(rsp.Operators, progress)
+		}
+	}
+	sort.Slice(rsp.Operators, func(i, j int) bool {
+		return rsp.Operators[i].ShardID < rsp.Operators[j].ShardID
+	})
+	return rsp, nil
+}
+
+// getOperatorCatchUpProgress returns false if the operator doesn't add any
+// replica. The ETA of the operator is the longest ETA of the replicas.
+func (c *RaftCluster) getOperatorCatchUpProgress(op *operator.Operator, res *core.CachedShard) (rpcpb.OperatorCatchUpProgress, bool) {
+	progress := rpcpb.OperatorCatchUpProgress{
+		ShardID:    op.ShardID(),
+		Desc:       op.Desc(),
+		CreateTime: uint64(op.GetCreateTime().Unix()),
+	}
+	added := false
+	for i := 0; i < op.Len(); i++ {
+		peerID, ok := getAddedPeer(op.Step(i))
+		if !ok {
+			continue
+		}
+		added = true
+
+		stat, ok := c.catchUpStat.Get(res.Meta.GetID(), peerID)
+		if !ok {
+			continue
+		}
+		replica := rpcpb.ReplicaCatchUpProgress{
+			Replica:             stat.Replica,
+			SnapshotPhase:       stat.SnapshotPhase,
+			SnapshotSentBytes:   stat.SnapshotSentBytes,
+			SnapshotTotalBytes:  stat.SnapshotTotalBytes,
+			EntriesBehind:       stat.EntriesBehind,
+			EtaSeconds:          -1,
+			SnapshotFailure:     stat.SnapshotFailure,
+			SnapshotFailures:    stat.SnapshotFailures,
+			SnapshotQuarantined: stat.SnapshotQuarantined,
+		}
+		if eta, ok := stat.ETA(); ok {
+			replica.EtaSeconds = int64(eta.Seconds())
+		}
+		if progress.EtaSeconds >= 0 &&
+			(replica.EtaSeconds < 0 || replica.EtaSeconds > progress.EtaSeconds) {
+			progress.EtaSeconds = replica.EtaSeconds
+		}
+		progress.Replicas = append(progress.Replicas, replica)
+	}
+	return progress, added
+}
+
+// getAddedPeer returns the id of the peer added by the step
+func getAddedPeer(step operator.OpStep) (uint64, bool) {
+	switch s := step.(type) {
+	case operator.AddPeer:
+		return s.PeerID, true
+	case operator.AddLearner:
+		return s.PeerID, true
+	case operator.AddLightPeer:
+		return s.PeerID, true
+	case operator.AddLightLearner:
+		return s.PeerID, true
+	}
+	return 0, false
+}
@@ -0,0 +1,74 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/operator"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCatchUpProgress(t *testing.T) {
+	tc, co, cleanup := prepare(t, nil, nil, nil)
+	defer cleanup()
+	tc.coordinator = co
+	tc.running = true
+
+	for id := uint64(1); id <= 3; id++ {
+		assert.Nil(t, tc.addShardStore(id, 1))
+	}
+	assert.Nil(t, tc.addLeaderShard(1, 1, 2))
+	assert.Nil(t, tc.addLeaderShard(2, 1, 2))
+
+	res := tc.GetShard(1)
+	learner := metapb.Replica{ID: 100, StoreID: 3, Role: metapb.ReplicaRole_Learner}
+	progresses := []metapb.ReplicaProgress{
+		{Replica: *res.GetLeader(), MatchIndex: 100},
+		{Replica: learner, SnapshotPhase: metapb.SnapshotPhase_SendingSnapshot, SnapshotSentBytes: 10, SnapshotTotalBytes: 100},
+	}
+	res = res.Clone(core.WithAddPeer(learner), core.WithReplicaProgresses(progresses))
+	tc.core.PutShard(res)
+	tc.catchUpStat.Observe(res)
+
+	op := newTestOperator(1, res.Meta.GetEpoch(), operator.OpShard,
+		operator.AddLearner{ToStore: 3, PeerID: 100})
+	require.True(t, co.opController.AddOperator(op))
+	op = newTestOperator(2, tc.GetShard(2).Meta.GetEpoch(), operator.OpLeader,
+		operator.TransferLeader{FromStore: 1, ToStore: 2})
+	require.True(t, co.opController.AddOperator(op))
+
+	req := &rpcpb.ProphetRequest{}
+	rsp, err := tc.HandleGetCatchUpProgress(req)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(rsp.Operators), "the operators without the added replicas are skipped")
+	progress := rsp.Operators[0]
+	assert.Equal(t, uint64(1), progress.ShardID)
+	assert.Equal(t, int64(-1), progress.EtaSeconds)
+	require.Equal(t, 1, len(progress.Replicas))
+	assert.Equal(t, learner, progress.Replicas[0].Replica)
+	assert.Equal(t, metapb.SnapshotPhase_SendingSnapshot, progress.Replicas[0].SnapshotPhase)
+	assert.Equal(t, uint64(10), progress.Replicas[0].SnapshotSentBytes)
+	assert.Equal(t, uint64(100), progress.Replicas[0].SnapshotTotalBytes)
+	assert.Equal(t, uint64(100), progress.Replicas[0].EntriesBehind)
+
+	req.GetCatchUpProgress.ShardID = 2
+	rsp, err = tc.HandleGetCatchUpProgress(req)
+	require.NoError(t, err)
+	assert.Empty(t, rsp.Operators)
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"time"
+
+	"github.com/RoaringBitmap/roaring/roaring64"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/storage"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"go.uber.org/zap"
+)
+
+// destroyedShardsCompactor compacts the metadata records of the destroyed
+// shards. The records are kept for the retention after the shard destroyed,
+// and then removed from the storage, only the shard ID is kept in a snapshot
+// bitmap, so prophet can still reject the stale replicas of the shards. It is
+// only accessed by the background jobs goroutine and at loading.
+//
+// The operator history is kept in memory only, and the job records are keyed by
+// the job type and removed with the job, so they don't need to be compacted.
+type destroyedShardsCompactor struct {
+	logger    *zap.Logger
+	compacted *roaring64.Bitmap
+	// seen the time of the destroyed shard first seen by the current prophet
+	// leader. We don't persist the destroyed time, so the retention restarts
+	// after the prophet leader changed, that's safe.
+	seen map[uint64]time.Time
+}
+
+func newDestroyedShardsCompactor(logger *zap.Logger) *destroyedShardsCompactor {
+	return &destroyedShardsCompactor{
+		logger:    logger,
+		compacted: roaring64.NewBitmap(),
+		seen:      make(map[uint64]time.Time),
+	}
+}
+
+func (dc *destroyedShardsCompactor) load(s storage.Storage, bc *core.BasicCluster) error {
+	data, err := s.GetDestroyedShardsSnapshot()
+	if err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		util.MustUnmarshalBM64To(data, dc.compacted)
+		bc.AddRemovedShards(dc.compacted.ToArray()...)
+	}
+	dc.logger.Info("compacted destroyed shards loaded",
+		zap.Uint64("count", dc.compacted.GetCardinality()))
+	return nil
+}
+
+// compact returns the shards need to be compacted now.
+func (dc *destroyedShardsCompactor) compact(destroyed *roaring64.Bitmap,
+	retention time.Duration, now time.Time) []uint64 {
+	destroyed.AndNot(dc.compacted)
+	var expired []uint64
+	itr := destroyed.Iterator()
+	for itr.HasNext() {
+		id := itr.Next()
+		seen, ok := dc.seen[id]
+		if !ok {
+			dc.seen[id] = now
+			continue
+		}
+		if now.Sub(seen) >= retention {
+			expired = append(expired, id)
+		}
+	}
+	return expired
+}
+
+// snapshot returns the snapshot of the compacted shards after the shards
+// compacted
+func (dc *destroyedShardsCompactor) snapshot(ids []uint64) *roaring64.Bitmap {
+	snapshot := dc.compacted.Clone()
+	snapshot.AddMany(ids)
+	return snapshot
+}
+
+// done is called after the records of the shards removed from storage
+func (dc *destroyedShardsCompactor) done(snapshot *roaring64.Bitmap, ids []uint64) {
+	dc.compacted = snapshot
+	for _, id := range ids {
+		delete(dc.seen, id)
+	}
+}
+
+func (c *RaftCluster) compactDestroyedShards() {
+	retention := c.opt.GetDestroyedShardRetention()
+	if retention <= 0 || c.destroyedCompactor == nil {
+		return
+	}
+
+	ids := c.destroyedCompactor.compact(c.core.GetDestroyedShards(),
+		retention, time.Now())
+	if len(ids) == 0 {
+		return
+	}
+	// the records are removed in chunks to keep the etcd txn bounded, each
+	// snapshot only includes the shards whose records are already removed.
+	compacted := 0
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > storage.MaxCompactDestroyedShards {
+			n = storage.MaxCompactDestroyedShards
+		}
+		snapshot := c.destroyedCompactor.snapshot(ids[:n])
+		if err := c.storage.CompactDestroyedShards(util.MustMarshalBM64(snapshot), ids[:n]...); err != nil {
+			c.logger.Error("fail to compact destroyed shards",
+				zap.Int("count", n),
+				zap.Error(err))
+			break
+		}
+		c.destroyedCompactor.done(snapshot, ids[:n])
+		compacted += n
+		ids = ids[n:]
+	}
+	if compacted > 0 {
+		c.logger.Info("destroyed shards compacted",
+			zap.Int("count", compacted),
+			zap.Uint64("total", c.destroyedCompactor.compacted.GetCardinality()))
+	}
+}
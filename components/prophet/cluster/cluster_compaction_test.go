@@ -0,0 +1,99 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RoaringBitmap/roaring/roaring64"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/storage"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDestroyedShardsCompactorRetention(t *testing.T) {
+	dc := newDestroyedShardsCompactor(nil)
+	now := time.Now()
+	ids := dc.compact(roaring64.BitmapOf(1, 2), time.Minute, now)
+	assert.Empty(t, ids)
+
+	ids = dc.compact(roaring64.BitmapOf(1, 2, 3), time.Minute, now.Add(time.Minute))
+	assert.Equal(t, []uint64{1, 2}, ids)
+	snapshot := dc.snapshot(ids)
+	assert.Equal(t, []uint64{1, 2}, snapshot.ToArray())
+	dc.done(snapshot, ids)
+
+	ids = dc.compact(roaring64.BitmapOf(1, 2, 3), time.Minute, now.Add(time.Minute*2))
+	assert.Equal(t, []uint64{3}, ids)
+	assert.Equal(t, []uint64{1, 2, 3}, dc.snapshot(ids).ToArray())
+}
+
+func TestCompactDestroyedShards(t *testing.T) {
+	_, opt, err := newTestScheduleConfig()
+	assert.NoError(t, err)
+	opt.GetScheduleConfig().DestroyedShardRetention.Duration = time.Nanosecond
+
+	s := storage.NewTestStorage()
+	cluster := newTestRaftCluster(opt, s, core.NewBasicCluster(nil))
+	shard := newTestShardMeta(1)
+	shard.SetState(metapb.ShardState_Destroyed)
+	assert.NoError(t, s.PutShardAndExtra(*shard, []byte("extra")))
+	cluster.core.AddRemovedShards(shard.ID)
+
+	// first seen, then compacted
+	cluster.compactDestroyedShards()
+	time.Sleep(time.Millisecond)
+	cluster.compactDestroyedShards()
+	v, err := s.GetShard(shard.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+	extra, err := s.GetShardExtra(shard.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, extra)
+
+	// reload from storage
+	cluster = newTestRaftCluster(opt, s, core.NewBasicCluster(nil))
+	_, err = cluster.LoadClusterInfo()
+	assert.NoError(t, err)
+	assert.True(t, cluster.core.AlreadyRemoved(shard.ID))
+}
+
+func TestCompactDestroyedShardsInChunks(t *testing.T) {
+	_, opt, err := newTestScheduleConfig()
+	assert.NoError(t, err)
+	opt.GetScheduleConfig().DestroyedShardRetention.Duration = time.Nanosecond
+
+	s := storage.NewTestStorage()
+	cluster := newTestRaftCluster(opt, s, core.NewBasicCluster(nil))
+	n := storage.MaxCompactDestroyedShards*2 + 1
+	for i := 1; i <= n; i++ {
+		shard := newTestShardMeta(uint64(i))
+		shard.SetState(metapb.ShardState_Destroyed)
+		assert.NoError(t, s.PutShard(*shard))
+		cluster.core.AddRemovedShards(shard.ID)
+	}
+
+	cluster.compactDestroyedShards()
+	time.Sleep(time.Millisecond)
+	cluster.compactDestroyedShards()
+	for i := 1; i <= n; i++ {
+		v, err := s.GetShard(uint64(i))
+		assert.NoError(t, err)
+		assert.Nil(t, v)
+	}
+	assert.Equal(t, uint64(n), cluster.destroyedCompactor.compacted.GetCardinality())
+	assert.Error(t, s.CompactDestroyedShards(nil, make([]uint64, storage.MaxCompactDestroyedShards+1)...))
+}
@@ -0,0 +1,331 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+// HandlePlacementDryRun simulates the replica placement after the stores in the
+// request are added or removed, the cluster is not changed.
+func (c *RaftCluster) HandlePlacementDryRun(request *rpcpb.ProphetRequest) (*rpcpb.PlacementDryRunRsp, error) {
+	sim, err := c.newPlacementSimulator(request.PlacementDryRun)
+	if err != nil {
+		return nil, err
+	}
+	// the simulation is super-linear, run it without the lock to avoid blocking
+	// the heartbeats
+	if err := sim.run(); err != nil {
+		return nil, err
+	}
+	return sim.result(), nil
+}
+
+// newPlacementSimulator creates the simulator with a snapshot of the stores
+// and shards taken under the lock.
+func (c *RaftCluster) newPlacementSimulator(req rpcpb.PlacementDryRunReq) (*placementSimulator, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.running {
+		return nil, util.ErrNotLeader
+	}
+	locationLabels := append([]string(nil), c.opt.GetLocationLabels()...)
+	return newPlacementSimulator(c.GetStores(), c.GetShards(), locationLabels,
+		req.AddStores, req.RemoveStores)
+}
+
+// placementSimulator places the replicas of the removed stores on the other
+// stores, and then moves replicas from the largest store to the smallest store
+// until the stores are balanced. Like the balance scheduler, a replica is never
+// moved to a store which makes the shard less isolated. The shard size is the
+// approximate size reported by the leader, so the result is an estimation.
+type placementSimulator struct {
+	locationLabels []string
+	stores         map[uint64]*simulatedStore
+	shards         []*simulatedShard
+}
+
+type simulatedStore struct {
+	store  *core.CachedStore
+	added  bool
+	size   uint64
+	weight uint64
+	shards map[int]struct{}
+}
+
+type simulatedShard struct {
+	id       uint64
+	size     uint64
+	original map[uint64]struct{}
+	stores   map[uint64]struct{}
+}
+
+func newPlacementSimulator(stores []*core.CachedStore, shards []*core.CachedShard,
+	locationLabels []string, addStores []metapb.Store, removeStores []uint64) (*placementSimulator, error) {
+	sim := &placementSimulator{
+		locationLabels: locationLabels,
+		stores:         make(map[uint64]*simulatedStore),
+	}
+
+	maxID := uint64(0)
+	for _, s := range stores {
+		if s.Meta.GetID() > maxID {
+			maxID = s.Meta.GetID()
+		}
+		// the offline stores are being removed
+		if s.IsTombstone() || s.IsOffline() {
+			continue
+		}
+		sim.stores[s.Meta.GetID()] = &simulatedStore{store: s, shards: make(map[int]struct{})}
+	}
+	for _, id := range removeStores {
+		if _, ok := sim.stores[id]; !ok {
+			return nil, fmt.Errorf("store %d not found", id)
+		}
+		delete(sim.stores, id)
+	}
+	for _, s := range addStores {
+		if s.ID == 0 {
+			maxID++
+			s.ID = maxID
+		} else if s.ID > maxID {
+			maxID = s.ID
+		}
+		if _, ok := sim.stores[s.ID]; ok {
+			return nil, fmt.Errorf("store %d already exists", s.ID)
+		}
+		sim.stores[s.ID] = &simulatedStore{
+			store:  core.NewCachedStore(s),
+			added:  true,
+			shards: make(map[int]struct{}),
+		}
+	}
+
+	for _, res := range shards {
+		if res.IsDestroyState() {
+			continue
+		}
+		shard := &simulatedShard{
+			id:       res.Meta.GetID(),
+			size:     uint64(res.GetApproximateSize()),
+			original: make(map[uint64]struct{}),
+			stores:   make(map[uint64]struct{}),
+		}
+		for _, r := range res.Meta.GetReplicas() {
+			shard.original[r.StoreID] = struct{}{}
+			shard.stores[r.StoreID] = struct{}{}
+		}
+		sim.shards = append(sim.shards, shard)
+	}
+	return sim, nil
+}
+
+func (sim *placementSimulator) run() error {
+	for idx, shard := range sim.shards {
+		for id := range shard.stores {
+			if s, ok := sim.stores[id]; ok {
+				s.add(idx, shard)
+			}
+		}
+	}
+
+	// re-create the replicas of the removed stores
+	for idx, shard := range sim.shards {
+		for _, id := range sortedIDs(shard.original) {
+			if _, ok := sim.stores[id]; ok {
+				continue
+			}
+			target := sim.selectTarget(shard, id)
+			if target == nil {
+				return fmt.Errorf("no store to place the replica of shard %d on store %d",
+					shard.id, id)
+			}
+			delete(shard.stores, id)
+			target.add(idx, shard)
+			shard.stores[target.store.Meta.GetID()] = struct{}{}
+		}
+	}
+
+	// each step reduces the difference between the largest and the smallest
+	// store, the steps are bounded by the replicas in case of no convergence
+	moves := 0
+	for _, shard := range sim.shards {
+		moves += len(shard.stores)
+	}
+	for i := 0; i < moves; i++ {
+		if !sim.balanceOnce() {
+			break
+		}
+	}
+	return nil
+}
+
+// selectTarget returns the store which has the smallest size in the stores
+// most distinct from the other replicas of the shard.
+func (sim *placementSimulator) selectTarget(shard *simulatedShard, source uint64) *simulatedStore {
+	var target *simulatedStore
+	targetScore := float64(-1)
+	for _, s := range sim.sortedStores() {
+		if _, ok := shard.stores[s.store.Meta.GetID()]; ok {
+			continue
+		}
+		score := sim.distinctScore(shard, source, s)
+		if score > targetScore || (score == targetScore && s.weight < target.weight) {
+			target, targetScore = s, score
+		}
+	}
+	return target
+}
+
+// balanceOnce moves a replica from the largest store to the smallest store
+// which can take it, returns false if no replica can be moved.
+func (sim *placementSimulator) balanceOnce() bool {
+	stores := sim.sortedStores()
+	sort.SliceStable(stores, func(i, j int) bool {
+		return stores[i].weight > stores[j].weight
+	})
+	for i, source := range stores {
+		for j := len(stores) - 1; j > i; j-- {
+			if sim.move(source, stores[j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (sim *placementSimulator) move(source, target *simulatedStore) bool {
+	sourceID, targetID := source.store.Meta.GetID(), target.store.Meta.GetID()
+	best := -1
+	for _, idx := range source.sortedShards() {
+		shard := sim.shards[idx]
+		if _, ok := shard.stores[targetID]; ok {
+			continue
+		}
+		// make sure the move reduces the difference between the two stores
+		if target.weight+shard.weight() > source.weight-shard.weight() {
+			continue
+		}
+		if sim.distinctScore(shard, sourceID, target) < sim.distinctScore(shard, sourceID, source) {
+			continue
+		}
+		if best == -1 || shard.weight() > sim.shards[best].weight() {
+			best = idx
+		}
+	}
+	if best == -1 {
+		return false
+	}
+
+	shard := sim.shards[best]
+	source.remove(best, shard)
+	delete(shard.stores, sourceID)
+	target.add(best, shard)
+	shard.stores[targetID] = struct{}{}
+	return true
+}
+
+// distinctScore returns the isolation score of the store with the replicas of
+// the shard except the source store.
+func (sim *placementSimulator) distinctScore(shard *simulatedShard, source uint64, s *simulatedStore) float64 {
+	var others []*core.CachedStore
+	for id := range shard.stores {
+		if id == source {
+			continue
+		}
+		if other, ok := sim.stores[id]; ok {
+			others = append(others, other.store)
+		}
+	}
+	return core.DistinctScore(sim.locationLabels, others, s.store)
+}
+
+func (sim *placementSimulator) sortedStores() []*simulatedStore {
+	stores := make([]*simulatedStore, 0, len(sim.stores))
+	for _, s := range sim.stores {
+		stores = append(stores, s)
+	}
+	sort.Slice(stores, func(i, j int) bool {
+		return stores[i].store.Meta.GetID() < stores[j].store.Meta.GetID()
+	})
+	return stores
+}
+
+func (sim *placementSimulator) result() *rpcpb.PlacementDryRunRsp {
+	rsp := &rpcpb.PlacementDryRunRsp{}
+	for _, shard := range sim.shards {
+		for id := range shard.stores {
+			if _, ok := shard.original[id]; !ok {
+				rsp.MovedReplicas++
+				rsp.MovedSize += shard.size
+			}
+		}
+	}
+	for _, s := range sim.sortedStores() {
+		rsp.Stores = append(rsp.Stores, rpcpb.StorePlacementResult{
+			StoreID:      s.store.Meta.GetID(),
+			Added:        s.added,
+			ReplicaCount: uint64(len(s.shards)),
+			ReplicaSize:  s.size,
+		})
+	}
+	return rsp
+}
+
+func (s *simulatedStore) add(idx int, shard *simulatedShard) {
+	s.shards[idx] = struct{}{}
+	s.size += shard.size
+	s.weight += shard.weight()
+}
+
+func (s *simulatedStore) remove(idx int, shard *simulatedShard) {
+	delete(s.shards, idx)
+	s.size -= shard.size
+	s.weight -= shard.weight()
+}
+
+func (s *simulatedStore) sortedShards() []int {
+	shards := make([]int, 0, len(s.shards))
+	for idx := range s.shards {
+		shards = append(shards, idx)
+	}
+	sort.Ints(shards)
+	return shards
+}
+
+// weight returns the size used to balance the stores, the empty shards are
+// balanced by count.
+func (shard *simulatedShard) weight() uint64 {
+	if shard.size == 0 {
+		return 1
+	}
+	return shard.size
+}
+
+func sortedIDs(ids map[uint64]struct{}) []uint64 {
+	values := make([]uint64, 0, len(ids))
+	for id := range ids {
+		values = append(values, id)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i] < values[j]
+	})
+	return values
+}
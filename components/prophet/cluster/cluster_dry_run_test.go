@@ -0,0 +1,155 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDryRunStores(zones ...string) []*core.CachedStore {
+	var stores []*core.CachedStore
+	for i, zone := range zones {
+		stores = append(stores, core.NewCachedStore(metapb.Store{
+			ID:     uint64(i + 1),
+			State:  metapb.StoreState_Up,
+			Labels: []metapb.Label{{Key: "zone", Value: zone}},
+		}))
+	}
+	return stores
+}
+
+// newTestDryRunShards creates n shards with a replica on each store.
+func newTestDryRunShards(n int, size uint64, stores ...uint64) []*core.CachedShard {
+	var shards []*core.CachedShard
+	for i := 0; i < n; i++ {
+		var replicas []metapb.Replica
+		for _, id := range stores {
+			replicas = append(replicas, metapb.Replica{ID: uint64(i*10) + id, StoreID: id})
+		}
+		shards = append(shards, core.NewCachedShard(metapb.Shard{ID: uint64(i + 1), Replicas: replicas}, &replicas[0],
+			core.SetApproximateSize(int64(size))))
+	}
+	return shards
+}
+
+func runTestDryRun(t *testing.T, stores []*core.CachedStore, shards []*core.CachedShard,
+	add []metapb.Store, remove []uint64) *rpcpb.PlacementDryRunRsp {
+	sim, err := newPlacementSimulator(stores, shards, []string{"zone"}, add, remove)
+	require.NoError(t, err)
+	require.NoError(t, sim.run())
+	return sim.result()
+}
+
+func TestPlacementDryRunAddStores(t *testing.T) {
+	stores := newTestDryRunStores("z1", "z2", "z3")
+	shards := newTestDryRunShards(12, 100, 1, 2, 3)
+
+	rsp := runTestDryRun(t, stores, shards, nil, nil)
+	assert.Equal(t, uint64(0), rsp.MovedReplicas)
+	assert.Equal(t, uint64(0), rsp.MovedSize)
+
+	add := []metapb.Store{
+		{Labels: []metapb.Label{{Key: "zone", Value: "z1"}}},
+		{Labels: []metapb.Label{{Key: "zone", Value: "z2"}}},
+		{Labels: []metapb.Label{{Key: "zone", Value: "z3"}}},
+	}
+	rsp = runTestDryRun(t, stores, shards, add, nil)
+	assert.Equal(t, uint64(18), rsp.MovedReplicas)
+	assert.Equal(t, uint64(1800), rsp.MovedSize)
+	require.Equal(t, 6, len(rsp.Stores))
+	for i, s := range rsp.Stores {
+		assert.Equal(t, uint64(i+1), s.StoreID)
+		assert.Equal(t, i >= 3, s.Added)
+		assert.Equal(t, uint64(6), s.ReplicaCount)
+		assert.Equal(t, uint64(600), s.ReplicaSize)
+	}
+}
+
+func TestPlacementDryRunKeepsIsolation(t *testing.T) {
+	stores := newTestDryRunStores("z1", "z2", "z3")
+	shards := newTestDryRunShards(12, 100, 1, 2, 3)
+
+	// all replicas are already in different zones, the new store in z1 can
+	// only take the replicas from the store 1
+	rsp := runTestDryRun(t, stores, shards, []metapb.Store{{Labels: []metapb.Label{{Key: "zone", Value: "z1"}}}}, nil)
+	assert.Equal(t, uint64(6), rsp.MovedReplicas)
+	require.Equal(t, 4, len(rsp.Stores))
+	assert.Equal(t, uint64(6), rsp.Stores[0].ReplicaCount)
+	assert.Equal(t, uint64(12), rsp.Stores[1].ReplicaCount)
+	assert.Equal(t, uint64(12), rsp.Stores[2].ReplicaCount)
+	assert.Equal(t, uint64(6), rsp.Stores[3].ReplicaCount)
+}
+
+func TestPlacementDryRunRemoveStore(t *testing.T) {
+	stores := newTestDryRunStores("z1", "z2", "z3", "z3")
+	shards := newTestDryRunShards(10, 100, 1, 2, 3)
+
+	rsp := runTestDryRun(t, stores, shards, nil, []uint64{3})
+	assert.Equal(t, uint64(10), rsp.MovedReplicas)
+	assert.Equal(t, uint64(1000), rsp.MovedSize)
+	require.Equal(t, 3, len(rsp.Stores))
+	assert.Equal(t, uint64(4), rsp.Stores[2].StoreID)
+	assert.Equal(t, uint64(10), rsp.Stores[2].ReplicaCount)
+
+	// no store to place the replicas
+	sim, err := newPlacementSimulator(stores, shards, []string{"zone"}, nil, []uint64{3, 4})
+	require.NoError(t, err)
+	assert.Error(t, sim.run())
+
+	_, err = newPlacementSimulator(stores, shards, []string{"zone"}, nil, []uint64{5})
+	assert.Error(t, err)
+	_, err = newPlacementSimulator(stores, shards, []string{"zone"}, []metapb.Store{{ID: 1}}, nil)
+	assert.Error(t, err)
+}
+
+func TestHandlePlacementDryRunOnSnapshot(t *testing.T) {
+	tc, _, cleanup := prepare(t, nil, nil, nil)
+	defer cleanup()
+
+	req := &rpcpb.ProphetRequest{}
+	_, err := tc.HandlePlacementDryRun(req)
+	assert.Equal(t, util.ErrNotLeader, err)
+
+	tc.running = true
+	for id := uint64(1); id <= 3; id++ {
+		assert.Nil(t, tc.addShardStore(id, 1))
+	}
+	for id := uint64(1); id <= 3; id++ {
+		assert.Nil(t, tc.addLeaderShard(id, 1, 2, 3))
+	}
+
+	sim, err := tc.newPlacementSimulator(req.PlacementDryRun)
+	require.NoError(t, err)
+	// the cluster can be changed during the simulation
+	assert.Nil(t, tc.addShardStore(4, 1))
+	assert.Nil(t, tc.addLeaderShard(4, 1, 2, 4))
+	require.NoError(t, sim.run())
+	rsp := sim.result()
+	assert.Equal(t, uint64(0), rsp.MovedReplicas)
+	require.Equal(t, 3, len(rsp.Stores))
+	for _, s := range rsp.Stores {
+		assert.Equal(t, uint64(3), s.ReplicaCount)
+	}
+
+	rsp, err = tc.HandlePlacementDryRun(req)
+	require.NoError(t, err)
+	assert.Equal(t, 4, len(rsp.Stores))
+}
@@ -0,0 +1,121 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/config"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+// HandleCreateKeyspace creates the keyspace in the shard group. The shards are
+// split at the boundaries of the keyspace by the rule checker, so the shards of
+// the keyspace are scheduled apart from the other keyspaces.
+func (c *RaftCluster) HandleCreateKeyspace(request *rpcpb.ProphetRequest) (*rpcpb.CreateKeyspaceRsp, error) {
+	c.Lock()
+	defer c.Unlock()
+	if !c.running {
+		return nil, util.ErrNotLeader
+	}
+
+	keyspace := request.CreateKeyspace.Keyspace
+	if err := core.ValidateKeyspaceName(keyspace.Name); err != nil {
+		return nil, util.WrappedError(util.ErrReq, err.Error())
+	}
+	if err := config.ValidateLabels(keyspace.Labels); err != nil {
+		return nil, util.WrappedError(util.ErrReq, err.Error())
+	}
+	if _, ok := c.core.Keyspaces.Get(keyspace.Name); ok {
+		return nil, util.WrappedError(util.ErrKeyspaceExisted, keyspace.Name)
+	}
+	if keyspace.MaxReplicas > 0 && len(keyspace.Labels) > 0 {
+		n := 0
+		for _, s := range c.core.GetStores() {
+			if !s.IsTombstone() && hasLabels(s, keyspace.Labels) {
+				n++
+			}
+		}
+		if uint64(n) < keyspace.MaxReplicas {
+			return nil, util.WrappedError(util.ErrReq,
+				fmt.Sprintf("only %d stores with the labels of keyspace %s", n, keyspace.Name))
+		}
+	}
+
+	id, err := c.AllocID()
+	if err != nil {
+		return nil, err
+	}
+	keyspace.ID = id
+	keyspace.Prefix = core.GetKeyspacePrefix(keyspace.Name)
+	if err := c.storage.PutKeyspace(keyspace); err != nil {
+		return nil, err
+	}
+	c.core.Keyspaces.Put(keyspace)
+	c.logger.Info("keyspace created",
+		zap.Uint64("id", keyspace.ID),
+		zap.String("name", keyspace.Name),
+		zap.Uint64("group", keyspace.Group))
+	return &rpcpb.CreateKeyspaceRsp{Keyspace: keyspace}, nil
+}
+
+// HandleGetKeyspaces returns all the keyspaces
+func (c *RaftCluster) HandleGetKeyspaces(request *rpcpb.ProphetRequest) (*rpcpb.GetKeyspacesRsp, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.running {
+		return nil, util.ErrNotLeader
+	}
+
+	return &rpcpb.GetKeyspacesRsp{Keyspaces: c.core.Keyspaces.List()}, nil
+}
+
+// HandleDeleteKeyspace deletes the keyspace, the shards of the keyspace are
+// kept and scheduled by the rules of the shard group, the data of the keyspace
+// should be removed by the application.
+func (c *RaftCluster) HandleDeleteKeyspace(request *rpcpb.ProphetRequest) error {
+	c.Lock()
+	defer c.Unlock()
+	if !c.running {
+		return util.ErrNotLeader
+	}
+
+	name := request.DeleteKeyspace.Name
+	keyspace, ok := c.core.Keyspaces.Get(name)
+	if !ok {
+		return util.WrappedError(util.ErrKeyspaceNotFound, name)
+	}
+	if err := c.storage.RemoveKeyspace(keyspace.ID); err != nil {
+		return err
+	}
+	c.core.Keyspaces.Remove(name)
+	c.logger.Info("keyspace deleted",
+		zap.Uint64("id", keyspace.ID),
+		zap.String("name", keyspace.Name))
+	return nil
+}
+
+func hasLabels(s *core.CachedStore, labels []metapb.Label) bool {
+	for _, label := range labels {
+		if s.GetLabelValue(label.Key) != label.Value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleKeyspaces(t *testing.T) {
+	tc, _, cleanup := prepare(t, nil, nil, nil)
+	defer cleanup()
+	tc.running = true
+
+	tc.core.PutStore(core.NewTestStoreInfoWithLabel(1, 0, map[string]string{"zone": "z1"}))
+	tc.core.PutStore(core.NewTestStoreInfoWithLabel(2, 0, map[string]string{"zone": "z1"}))
+	tc.core.PutStore(core.NewTestStoreInfoWithLabel(3, 0, map[string]string{"zone": "z2"}))
+
+	create := func(keyspace metapb.Keyspace) (metapb.Keyspace, error) {
+		req := &rpcpb.ProphetRequest{}
+		req.CreateKeyspace.Keyspace = keyspace
+		rsp, err := tc.HandleCreateKeyspace(req)
+		if err != nil {
+			return metapb.Keyspace{}, err
+		}
+		return rsp.Keyspace, nil
+	}
+
+	a, err := create(metapb.Keyspace{Name: "a", Group: 1, MaxReplicas: 2,
+		Labels: []metapb.Label{{Key: "zone", Value: "z1"}}})
+	require.NoError(t, err)
+	assert.NotEqual(t, uint64(0), a.ID)
+	assert.Equal(t, []byte("a/"), a.Prefix)
+	_, err = create(metapb.Keyspace{Name: "a", Group: 2})
+	assert.True(t, errors.Is(err, util.ErrKeyspaceExisted))
+	_, err = create(metapb.Keyspace{Name: "a/b", Group: 1})
+	assert.True(t, errors.Is(err, util.ErrReq))
+	_, err = create(metapb.Keyspace{Name: "b", Group: 1, MaxReplicas: 2,
+		Labels: []metapb.Label{{Key: "zone", Value: "z2"}}})
+	assert.True(t, errors.Is(err, util.ErrReq), "not enough stores with the labels")
+	b, err := create(metapb.Keyspace{Name: "b", Group: 1, ShardCapacityBytes: 1024})
+	require.NoError(t, err)
+
+	rsp, err := tc.HandleGetKeyspaces(&rpcpb.ProphetRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []metapb.Keyspace{a, b}, rsp.Keyspaces)
+
+	// the shards of the keyspace are placed by the keyspace
+	res := core.NewCachedShard(metapb.Shard{ID: 1, Group: 1, Start: []byte("a/1"), End: []byte("a/2")}, nil)
+	rules := tc.GetRuleManager().GetRulesForApplyShard(res)
+	require.Equal(t, 1, len(rules))
+	assert.Equal(t, 2, rules[0].Count)
+	assert.Equal(t, [][]byte{[]byte("a/"), []byte("a0"), []byte("b/"), []byte("b0")},
+		tc.GetRuleManager().GetShardSplitKeys(1, nil, nil))
+
+	// reloaded by the new leader
+	bc := core.NewBasicCluster(nil)
+	rc := newTestRaftCluster(tc.opt, tc.storage, bc)
+	_, err = rc.LoadClusterInfo()
+	require.NoError(t, err)
+	assert.Equal(t, []metapb.Keyspace{a, b}, bc.Keyspaces.List())
+
+	req := &rpcpb.ProphetRequest{}
+	req.DeleteKeyspace.Name = "a"
+	require.NoError(t, tc.HandleDeleteKeyspace(req))
+	assert.True(t, errors.Is(tc.HandleDeleteKeyspace(req), util.ErrKeyspaceNotFound))
+	rsp, err = tc.HandleGetKeyspaces(&rpcpb.ProphetRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []metapb.Keyspace{b}, rsp.Keyspaces)
+	rules = tc.GetRuleManager().GetRulesForApplyShard(res)
+	require.Equal(t, 1, len(rules))
+	assert.Equal(t, "default", rules[0].ID)
+
+	tc.running = false
+	_, err = tc.HandleGetKeyspaces(&rpcpb.ProphetRequest{})
+	assert.Error(t, err)
+}
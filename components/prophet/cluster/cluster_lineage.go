@@ -0,0 +1,252 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fagongzi/util/protoc"
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/components/prophet/util/cache"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+const (
+	lineageKindSplit = "split"
+	lineageKindMerge = "merge"
+	// pendingSplitTTL the split shard is remembered for the new shard IDs
+	// allocated by the AskBatchSplit, until the new shards are reported by the
+	// heartbeats
+	pendingSplitTTL = time.Minute * 10
+)
+
+// shardLineages the split and merge ancestry of the shards, so the historical
+// shard IDs, e.g. the shard IDs in the old logs, can be mapped to the current
+// shards. Each event is one ancestor split or merged into one descendant, the
+// events are never removed, since the ancestors are already destroyed.
+type shardLineages struct {
+	sync.RWMutex
+	// byDescendant descendant -> events, the oldest first
+	byDescendant map[uint64][]rpcpb.ShardLineageEvent
+	// byAncestor ancestor -> events, the oldest first
+	byAncestor map[uint64][]rpcpb.ShardLineageEvent
+	// pendingSplits new shard ID -> the metapb.Shard split into the new shard
+	pendingSplits *cache.TTLUint64
+}
+
+func newShardLineages(ctx context.Context) *shardLineages {
+	return &shardLineages{
+		byDescendant:  make(map[uint64][]rpcpb.ShardLineageEvent),
+		byAncestor:    make(map[uint64][]rpcpb.ShardLineageEvent),
+		pendingSplits: cache.NewIDTTL(ctx, time.Minute, pendingSplitTTL),
+	}
+}
+
+// add adds the event, returns false if the event of the ancestor and the
+// descendant is already added
+func (l *shardLineages) add(event rpcpb.ShardLineageEvent) bool {
+	l.Lock()
+	defer l.Unlock()
+	for _, e := range l.byDescendant[event.Descendant] {
+		if e.Ancestor == event.Ancestor {
+			return false
+		}
+	}
+	l.byDescendant[event.Descendant] = append(l.byDescendant[event.Descendant], event)
+	l.byAncestor[event.Ancestor] = append(l.byAncestor[event.Ancestor], event)
+	return true
+}
+
+// load loads the persisted events of the descendant
+func (l *shardLineages) load(value rpcpb.ShardLineage) {
+	for _, event := range value.Events {
+		l.add(event)
+	}
+}
+
+// get returns the events whose descendant is the shard
+func (l *shardLineages) get(shardID uint64) []rpcpb.ShardLineageEvent {
+	l.RLock()
+	defer l.RUnlock()
+	return append([]rpcpb.ShardLineageEvent(nil), l.byDescendant[shardID]...)
+}
+
+// ancestors returns the events of the ancestors of the shard transitively, the
+// nearest first
+func (l *shardLineages) ancestors(shardID uint64) []rpcpb.ShardLineageEvent {
+	l.RLock()
+	defer l.RUnlock()
+	return walkLineage(shardID, l.byDescendant, func(e rpcpb.ShardLineageEvent) uint64 {
+		return e.Ancestor
+	})
+}
+
+// descendants returns the events of the descendants of the shard transitively,
+// the nearest first
+func (l *shardLineages) descendants(shardID uint64) []rpcpb.ShardLineageEvent {
+	l.RLock()
+	defer l.RUnlock()
+	return walkLineage(shardID, l.byAncestor, func(e rpcpb.ShardLineageEvent) uint64 {
+		return e.Descendant
+	})
+}
+
+func walkLineage(shardID uint64, edges map[uint64][]rpcpb.ShardLineageEvent,
+	next func(rpcpb.ShardLineageEvent) uint64) []rpcpb.ShardLineageEvent {
+	var events []rpcpb.ShardLineageEvent
+	visited := map[uint64]struct{}{shardID: {}}
+	queue := []uint64{shardID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, e := range edges[id] {
+			events = append(events, e)
+			if _, ok := visited[next(e)]; !ok {
+				visited[next(e)] = struct{}{}
+				queue = append(queue, next(e))
+			}
+		}
+	}
+	return events
+}
+
+func (l *shardLineages) addPendingSplit(newShardID uint64, shard metapb.Shard) {
+	l.pendingSplits.Put(newShardID, shard)
+}
+
+func (l *shardLineages) takePendingSplit(newShardID uint64) (metapb.Shard, bool) {
+	v, ok := l.pendingSplits.Get(newShardID)
+	if !ok {
+		return metapb.Shard{}, false
+	}
+	l.pendingSplits.Remove(newShardID)
+	return v.(metapb.Shard), true
+}
+
+func newShardLineageEvent(kind string, ancestor, descendant metapb.Shard) rpcpb.ShardLineageEvent {
+	return rpcpb.ShardLineageEvent{
+		Kind:            kind,
+		Ancestor:        ancestor.GetID(),
+		AncestorEpoch:   ancestor.GetEpoch(),
+		Descendant:      descendant.GetID(),
+		DescendantEpoch: descendant.GetEpoch(),
+		Timestamp:       time.Now().UnixNano(),
+	}
+}
+
+// containsRange returns true if the range of the outer shard contains the range
+// of the inner shard
+func containsRange(outer, inner metapb.Shard) bool {
+	if bytes.Compare(outer.GetStart(), inner.GetStart()) > 0 {
+		return false
+	}
+	if len(outer.GetEnd()) == 0 {
+		return true
+	}
+	return len(inner.GetEnd()) > 0 && bytes.Compare(inner.GetEnd(), outer.GetEnd()) <= 0
+}
+
+// observeShardLineageLocked records the lineage events found by the heartbeat
+// of the shard, and returns the descendants whose events are changed. The new
+// shard is split from the shard whose IDs are allocated for the split, or the
+// shard covering the range of the new shard. The shards covered by the range
+// of the shard with a larger generation are merged into the shard.
+func (c *RaftCluster) observeShardLineageLocked(res, origin *core.CachedShard, overlaps []*core.CachedShard) bool {
+	changed := false
+	if origin == nil {
+		if split, ok := c.lineages.takePendingSplit(res.Meta.GetID()); ok {
+			changed = c.lineages.add(newShardLineageEvent(lineageKindSplit, split, res.Meta)) || changed
+		}
+	}
+	for _, item := range overlaps {
+		if item.Meta.GetID() == res.Meta.GetID() ||
+			item.Meta.GetEpoch().Generation >= res.Meta.GetEpoch().Generation {
+			continue
+		}
+
+		outer := containsRange(item.Meta, res.Meta)
+		inner := containsRange(res.Meta, item.Meta)
+		switch {
+		case outer && !inner && origin == nil:
+			changed = c.lineages.add(newShardLineageEvent(lineageKindSplit, item.Meta, res.Meta)) || changed
+		case inner && !outer:
+			changed = c.lineages.add(newShardLineageEvent(lineageKindMerge, item.Meta, res.Meta)) || changed
+		}
+	}
+	return changed
+}
+
+// saveShardLineage saves the events whose descendant is the shard
+func (c *RaftCluster) saveShardLineage(shardID uint64) {
+	if c.storage == nil {
+		return
+	}
+
+	value := rpcpb.ShardLineage{ShardID: shardID, Events: c.lineages.get(shardID)}
+	if err := c.storage.PutShardLineage(shardID, protoc.MustMarshal(&value)); err != nil {
+		c.logger.Error("fail to save shard lineage",
+			log.ShardIDField(shardID),
+			zap.Error(err))
+	}
+}
+
+// loadShardLineages loads the persisted shard lineages
+func (c *RaftCluster) loadShardLineages() error {
+	return c.storage.LoadShardLineages(batch, func(data []byte) {
+		var value rpcpb.ShardLineage
+		protoc.MustUnmarshal(&value, data)
+		c.lineages.load(value)
+	})
+}
+
+// HandleGetShardLineage returns the split and merge ancestry of the shard, and
+// the current shards holding the data of the shard
+func (c *RaftCluster) HandleGetShardLineage(request *rpcpb.ProphetRequest) (*rpcpb.GetShardLineageRsp, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.running {
+		return nil, util.ErrNotLeader
+	}
+
+	shardID := request.GetShardLineage.ShardID
+	rsp := &rpcpb.GetShardLineageRsp{
+		Ancestors:   c.lineages.ancestors(shardID),
+		Descendants: c.lineages.descendants(shardID),
+	}
+	candidates := []uint64{shardID}
+	for _, e := range rsp.Descendants {
+		candidates = append(candidates, e.Descendant)
+	}
+	seen := make(map[uint64]struct{})
+	for _, id := range candidates {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		if res := c.core.GetShard(id); res != nil &&
+			res.Meta.GetState() != metapb.ShardState_Destroying &&
+			res.Meta.GetState() != metapb.ShardState_Destroyed {
+			rsp.Current = append(rsp.Current, id)
+		}
+	}
+	return rsp, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/storage"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLineageCluster(t *testing.T, s storage.Storage) *RaftCluster {
+	_, opt, err := newTestScheduleConfig()
+	require.NoError(t, err)
+	tc := newTestRaftCluster(opt, s, core.NewBasicCluster(nil))
+	tc.running = true
+	return tc
+}
+
+func newTestLineageShard(id uint64, start, end string, generation uint64) *core.CachedShard {
+	replica := metapb.Replica{ID: id * 10, StoreID: 1}
+	return core.NewCachedShard(metapb.Shard{
+		ID:       id,
+		Start:    []byte(start),
+		End:      []byte(end),
+		Epoch:    metapb.ShardEpoch{Generation: generation, ConfigVer: 1},
+		Replicas: []metapb.Replica{replica},
+	}, &replica)
+}
+
+func getTestShardLineage(t *testing.T, tc *RaftCluster, shardID uint64) *rpcpb.GetShardLineageRsp {
+	req := &rpcpb.ProphetRequest{}
+	req.GetShardLineage.ShardID = shardID
+	rsp, err := tc.HandleGetShardLineage(req)
+	require.NoError(t, err)
+	return rsp
+}
+
+func lineageEdges(events []rpcpb.ShardLineageEvent) map[[2]uint64]string {
+	edges := make(map[[2]uint64]string)
+	for _, e := range events {
+		edges[[2]uint64{e.Ancestor, e.Descendant}] = e.Kind
+	}
+	return edges
+}
+
+func TestShardLineage(t *testing.T) {
+	s := storage.NewTestStorage()
+	tc := newTestLineageCluster(t, s)
+
+	shard1 := newTestLineageShard(1, "", "", 1)
+	require.NoError(t, tc.processShardHeartbeat(shard1))
+	assert.Empty(t, getTestShardLineage(t, tc, 1).Ancestors)
+
+	// shard 1 is split into shard 2 and shard 3, the shard 3 is found by the
+	// split IDs, since the shard 1 is already replaced by the shard 2
+	tc.lineages.addPendingSplit(3, shard1.Meta)
+	require.NoError(t, tc.processShardHeartbeat(newTestLineageShard(2, "", "m", 2)))
+	require.NoError(t, tc.processShardHeartbeat(newTestLineageShard(3, "m", "", 2)))
+
+	// shard 2 and shard 3 are merged into shard 4
+	require.NoError(t, tc.processShardHeartbeat(newTestLineageShard(4, "", "", 3)))
+	// the heartbeat again changes nothing
+	require.NoError(t, tc.processShardHeartbeat(newTestLineageShard(4, "", "", 3)))
+
+	expect := map[[2]uint64]string{
+		{1, 2}: lineageKindSplit,
+		{1, 3}: lineageKindSplit,
+		{2, 4}: lineageKindMerge,
+		{3, 4}: lineageKindMerge,
+	}
+	check := func(tc *RaftCluster) {
+		rsp := getTestShardLineage(t, tc, 1)
+		assert.Empty(t, rsp.Ancestors)
+		assert.Equal(t, expect, lineageEdges(rsp.Descendants))
+		assert.Len(t, rsp.Descendants, 4)
+		assert.Equal(t, []uint64{4}, rsp.Current)
+
+		rsp = getTestShardLineage(t, tc, 4)
+		assert.Equal(t, expect, lineageEdges(rsp.Ancestors))
+		assert.Empty(t, rsp.Descendants)
+		assert.Equal(t, []uint64{4}, rsp.Current)
+
+		rsp = getTestShardLineage(t, tc, 2)
+		assert.Equal(t, map[[2]uint64]string{{1, 2}: lineageKindSplit}, lineageEdges(rsp.Ancestors))
+		assert.Equal(t, map[[2]uint64]string{{2, 4}: lineageKindMerge}, lineageEdges(rsp.Descendants))
+		assert.Equal(t, []uint64{4}, rsp.Current)
+	}
+	check(tc)
+
+	// reload from storage
+	tc = newTestLineageCluster(t, s)
+	require.NoError(t, tc.loadShardLineages())
+	require.NoError(t, tc.processShardHeartbeat(newTestLineageShard(4, "", "", 3)))
+	check(tc)
+
+	tc.running = false
+	req := &rpcpb.ProphetRequest{}
+	req.GetShardLineage.ShardID = 1
+	_, err := tc.HandleGetShardLineage(req)
+	assert.True(t, errors.Is(err, util.ErrNotLeader))
+}
+
+func TestContainsRange(t *testing.T) {
+	cases := []struct {
+		outer, inner metapb.Shard
+		expect       bool
+	}{
+		{metapb.Shard{}, metapb.Shard{Start: []byte("a"), End: []byte("b")}, true},
+		{metapb.Shard{Start: []byte("a")}, metapb.Shard{Start: []byte("b")}, true},
+		{metapb.Shard{Start: []byte("a"), End: []byte("c")}, metapb.Shard{Start: []byte("b")}, false},
+		{metapb.Shard{Start: []byte("b")}, metapb.Shard{Start: []byte("a"), End: []byte("c")}, false},
+		{metapb.Shard{Start: []byte("a"), End: []byte("c")}, metapb.Shard{Start: []byte("a"), End: []byte("c")}, true},
+	}
+	for i, c := range cases {
+		assert.Equal(t, c.expect, containsRange(c.outer, c.inner), "index %d", i)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+const (
+	// defaultListPageSize the page size of the listing if the max results is 0
+	defaultListPageSize = 1000
+	// maxListPageSize the max page size of the listing, the larger max results
+	// are truncated, so a request can not make prophet marshal all the shards of
+	// a huge cluster.
+	maxListPageSize = 10000
+)
+
+var (
+	// ErrInvalidPageToken the page token is not returned by the previous page
+	ErrInvalidPageToken = errors.New("invalid page token")
+)
+
+func getListPageSize(maxResults uint64) int {
+	if maxResults == 0 {
+		return defaultListPageSize
+	}
+	if maxResults > maxListPageSize {
+		return maxListPageSize
+	}
+	return int(maxResults)
+}
+
+// HandleListShards returns a page of the shards of the group in the order of
+// the start key. The page token is the start key of the first shard of the
+// page, so the pages are consistent with the splits and the merges between the
+// requests, the shards are never skipped, but a shard merged may be returned
+// twice.
+func (c *RaftCluster) HandleListShards(request *rpcpb.ProphetRequest) (*rpcpb.ListShardsRsp, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.running {
+		return nil, util.ErrNotLeader
+	}
+
+	req := request.ListShards
+	project, err := newShardProjection(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+	size := getListPageSize(req.MaxResults)
+	shards := c.core.ScanRange(req.Group, req.PageToken, nil, size+1)
+	rsp := &rpcpb.ListShardsRsp{}
+	if len(shards) > size {
+		rsp.NextPageToken = shards[size].GetStartKey()
+		shards = shards[:size]
+	}
+	rsp.Shards = make([]metapb.Shard, 0, len(shards))
+	for _, res := range shards {
+		rsp.Shards = append(rsp.Shards, project(res.Meta))
+	}
+	return rsp, nil
+}
+
+// HandleListStores returns a page of the stores in the order of the store id.
+func (c *RaftCluster) HandleListStores(request *rpcpb.ProphetRequest) (*rpcpb.ListStoresRsp, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.running {
+		return nil, util.ErrNotLeader
+	}
+
+	req := request.ListStores
+	project, err := newStoreProjection(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+	var from uint64
+	if len(req.PageToken) > 0 {
+		if len(req.PageToken) != 8 {
+			return nil, ErrInvalidPageToken
+		}
+		from = binary.BigEndian.Uint64(req.PageToken)
+	}
+
+	stores := c.core.GetStores()
+	sort.Slice(stores, func(i, j int) bool {
+		return stores[i].Meta.GetID() < stores[j].Meta.GetID()
+	})
+	idx := sort.Search(len(stores), func(i int) bool {
+		return stores[i].Meta.GetID() >= from
+	})
+	stores = stores[idx:]
+
+	size := getListPageSize(req.MaxResults)
+	rsp := &rpcpb.ListStoresRsp{}
+	if len(stores) > size {
+		rsp.NextPageToken = make([]byte, 8)
+		binary.BigEndian.PutUint64(rsp.NextPageToken, stores[size].Meta.GetID())
+		stores = stores[:size]
+	}
+	rsp.Stores = make([]metapb.Store, 0, len(stores))
+	for _, s := range stores {
+		rsp.Stores = append(rsp.Stores, project(s.Meta))
+	}
+	return rsp, nil
+}
+
+// newShardProjection returns the func keeping the fields of the shard in the
+// field mask, the fields are named as the json names of metapb.Shard. The id
+// is always kept, and all the fields are kept if the field mask is empty.
+func newShardProjection(fields []string) (func(metapb.Shard) metapb.Shard, error) {
+	if len(fields) == 0 {
+		return func(v metapb.Shard) metapb.Shard { return v }, nil
+	}
+
+	var copies []func(dst *metapb.Shard, src metapb.Shard)
+	for _, field := range fields {
+		switch field {
+		case "id":
+		case "start":
+			copies = append(copies, func(dst *metapb.Shard, src metapb.Shard) { dst.Start = src.Start })
+		case "end":
+			copies = append(copies, func(dst *metapb.Shard, src metapb.Shard) { dst.End = src.End })
+		case "epoch":
+			copies = append(copies, func(dst *metapb.Shard, src metapb.Shard) { dst.Epoch = src.Epoch })
+		case "state":
+			copies = append(copies, func(dst *metapb.Shard, src metapb.Shard) { dst.State = src.State })
+		case "replicas":
+			copies = append(copies, func(dst *metapb.Shard, src metapb.Shard) { dst.Replicas = src.Replicas })
+		case "group":
+			copies = append(copies, func(dst *metapb.Shard, src metapb.Shard) { dst.Group = src.Group })
+		case "unique":
+			copies = append(copies, func(dst *metapb.Shard, src metapb.Shard) { dst.Unique = src.Unique })
+		case "ruleGroups":
+			copies = append(copies, func(dst *metapb.Shard, src metapb.Shard) { dst.RuleGroups = src.RuleGroups })
+		case "labels":
+			copies = append(copies, func(dst *metapb.Shard, src metapb.Shard) { dst.Labels = src.Labels })
+		default:
+			return nil, fmt.Errorf("unknown field %s of shard", field)
+		}
+	}
+	return func(src metapb.Shard) metapb.Shard {
+		dst := metapb.Shard{ID: src.ID}
+		for _, fn := range copies {
+			fn(&dst, src)
+		}
+		return dst
+	}, nil
+}
+
+// newStoreProjection is the same as newShardProjection, but for the stores.
+func newStoreProjection(fields []string) (func(metapb.Store) metapb.Store, error) {
+	if len(fields) == 0 {
+		return func(v metapb.Store) metapb.Store { return v }, nil
+	}
+
+	var copies []func(dst *metapb.Store, src metapb.Store)
+	for _, field := range fields {
+		switch field {
+		case "id":
+		case "raftAddress":
+			copies = append(copies, func(dst *metapb.Store, src metapb.Store) { dst.RaftAddress = src.RaftAddress })
+		case "clientAddress":
+			copies = append(copies, func(dst *metapb.Store, src metapb.Store) { dst.ClientAddress = src.ClientAddress })
+		case "labels":
+			copies = append(copies, func(dst *metapb.Store, src metapb.Store) { dst.Labels = src.Labels })
+		case "state":
+			copies = append(copies, func(dst *metapb.Store, src metapb.Store) { dst.State = src.State })
+		case "startTime":
+			copies = append(copies, func(dst *metapb.Store, src metapb.Store) { dst.StartTime = src.StartTime })
+		case "lastHeartbeatTime":
+			copies = append(copies, func(dst *metapb.Store, src metapb.Store) { dst.LastHeartbeatTime = src.LastHeartbeatTime })
+		case "version":
+			copies = append(copies, func(dst *metapb.Store, src metapb.Store) { dst.Version = src.Version })
+		case "commitID":
+			copies = append(copies, func(dst *metapb.Store, src metapb.Store) { dst.CommitID = src.CommitID })
+		case "deployPath":
+			copies = append(copies, func(dst *metapb.Store, src metapb.Store) { dst.DeployPath = src.DeployPath })
+		case "destroyed":
+			copies = append(copies, func(dst *metapb.Store, src metapb.Store) { dst.Destroyed = src.Destroyed })
+		case "epoch":
+			copies = append(copies, func(dst *metapb.Store, src metapb.Store) { dst.Epoch = src.Epoch })
+		case "snapshotFormat":
+			copies = append(copies, func(dst *metapb.Store, src metapb.Store) { dst.SnapshotFormat = src.SnapshotFormat })
+		default:
+			return nil, fmt.Errorf("unknown field %s of store", field)
+		}
+	}
+	return func(src metapb.Store) metapb.Store {
+		dst := metapb.Store{ID: src.ID}
+		for _, fn := range copies {
+			fn(&dst, src)
+		}
+		return dst
+	}, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/storage"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListShards(t *testing.T) {
+	_, opt, err := newTestScheduleConfig()
+	require.NoError(t, err)
+	tc := newTestRaftCluster(opt, storage.NewTestStorage(), core.NewBasicCluster(nil))
+	tc.running = true
+	for _, res := range newTestShards(5, 3) {
+		tc.core.PutShard(res)
+	}
+
+	var ids []uint64
+	req := &rpcpb.ProphetRequest{}
+	req.ListShards.MaxResults = 2
+	req.ListShards.Fields = []string{"start"}
+	for pages := 1; ; pages++ {
+		rsp, err := tc.HandleListShards(req)
+		require.NoError(t, err)
+		for _, shard := range rsp.Shards {
+			ids = append(ids, shard.ID)
+			assert.Equal(t, []byte{byte(shard.ID)}, shard.Start)
+			assert.Empty(t, shard.End)
+			assert.Empty(t, shard.Replicas)
+		}
+		if len(rsp.NextPageToken) == 0 {
+			assert.Equal(t, 3, pages)
+			break
+		}
+		req.ListShards.PageToken = rsp.NextPageToken
+	}
+	assert.Equal(t, []uint64{0, 1, 2, 3, 4}, ids)
+
+	// all the fields are returned without the field mask
+	req.ListShards = rpcpb.ListShardsReq{}
+	rsp, err := tc.HandleListShards(req)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(rsp.Shards))
+	assert.Empty(t, rsp.NextPageToken)
+	assert.Equal(t, tc.GetShard(1).Meta, rsp.Shards[1])
+
+	req.ListShards.Fields = []string{"unknown"}
+	_, err = tc.HandleListShards(req)
+	assert.Error(t, err)
+
+	tc.running = false
+	_, err = tc.HandleListShards(&rpcpb.ProphetRequest{})
+	assert.Error(t, err)
+}
+
+func TestListStores(t *testing.T) {
+	_, opt, err := newTestScheduleConfig()
+	require.NoError(t, err)
+	tc := newTestRaftCluster(opt, storage.NewTestStorage(), core.NewBasicCluster(nil))
+	tc.running = true
+	for _, s := range newTestStores(5, "2.0.0") {
+		require.NoError(t, tc.PutStore(s.Meta))
+	}
+
+	var stores []metapb.Store
+	req := &rpcpb.ProphetRequest{}
+	req.ListStores.MaxResults = 3
+	req.ListStores.Fields = []string{"id", "version"}
+	for {
+		rsp, err := tc.HandleListStores(req)
+		require.NoError(t, err)
+		stores = append(stores, rsp.Stores...)
+		if len(rsp.NextPageToken) == 0 {
+			break
+		}
+		req.ListStores.PageToken = rsp.NextPageToken
+	}
+	require.Equal(t, 5, len(stores))
+	for i, s := range stores {
+		assert.Equal(t, metapb.Store{ID: uint64(i + 1), Version: "2.0.0"}, s)
+	}
+
+	req.ListStores = rpcpb.ListStoresReq{PageToken: []byte{1}}
+	_, err = tc.HandleListStores(req)
+	assert.Equal(t, ErrInvalidPageToken, err)
+	req.ListStores = rpcpb.ListStoresReq{Fields: []string{"unknown"}}
+	_, err = tc.HandleListStores(req)
+	assert.Error(t, err)
+}
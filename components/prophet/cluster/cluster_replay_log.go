@@ -0,0 +1,263 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fagongzi/util/protoc"
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+// replayRing the recent records of a shard, the oldest record is overwritten
+// once the ring is full.
+type replayRing struct {
+	records []rpcpb.ShardReplayRecord
+	// start the index of the oldest record if the ring is full
+	start int
+}
+
+func (r *replayRing) add(record rpcpb.ShardReplayRecord, size int) {
+	if r.start > 0 && len(r.records) != size {
+		// the size is changed, unwrap the ring
+		r.records, r.start = r.list(0), 0
+	}
+	if len(r.records) > size {
+		r.records = append(r.records[:0:0], r.records[len(r.records)-size:]...)
+	}
+	if len(r.records) < size {
+		r.records = append(r.records, record)
+		return
+	}
+	r.records[r.start] = record
+	r.start = (r.start + 1) % size
+}
+
+// list returns the latest limit records ordered by the time, all the records
+// are returned if the limit is 0.
+func (r *replayRing) list(limit int) []rpcpb.ShardReplayRecord {
+	values := make([]rpcpb.ShardReplayRecord, 0, len(r.records))
+	values = append(values, r.records[r.start:]...)
+	values = append(values, r.records[:r.start]...)
+	if limit > 0 && len(values) > limit {
+		values = values[len(values)-limit:]
+	}
+	return values
+}
+
+// shardReplayLogs keeps the recent heartbeats and scheduling decisions of each
+// shard, so the history of a shard can be reconstructed after an incident. The
+// logs are kept in memory, and saved to the storage by the background jobs if
+// EnableShardReplayLogPersist is set.
+type shardReplayLogs struct {
+	sync.RWMutex
+	rings map[uint64]*replayRing
+	// dirty the shards changed since the last persistence
+	dirty map[uint64]struct{}
+}
+
+func newShardReplayLogs() *shardReplayLogs {
+	return &shardReplayLogs{
+		rings: make(map[uint64]*replayRing),
+		dirty: make(map[uint64]struct{}),
+	}
+}
+
+func (l *shardReplayLogs) add(shardID uint64, record rpcpb.ShardReplayRecord, size int) {
+	if size <= 0 {
+		return
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	ring, ok := l.rings[shardID]
+	if !ok {
+		ring = &replayRing{}
+		l.rings[shardID] = ring
+	}
+	ring.add(record, size)
+	l.dirty[shardID] = struct{}{}
+}
+
+func (l *shardReplayLogs) get(shardID uint64, limit int) []rpcpb.ShardReplayRecord {
+	l.RLock()
+	defer l.RUnlock()
+	if ring, ok := l.rings[shardID]; ok {
+		return ring.list(limit)
+	}
+	return nil
+}
+
+// load loads the persisted log, the records are added before the records
+// added since the prophet leader started.
+func (l *shardReplayLogs) load(value rpcpb.ShardReplayLog, size int) {
+	if size <= 0 {
+		return
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	ring := &replayRing{}
+	for _, record := range value.Records {
+		ring.add(record, size)
+	}
+	if current, ok := l.rings[value.ShardID]; ok {
+		for _, record := range current.list(0) {
+			ring.add(record, size)
+		}
+	}
+	if len(ring.records) > 0 {
+		l.rings[value.ShardID] = ring
+	}
+}
+
+// takeDirty returns the logs changed since the last call
+func (l *shardReplayLogs) takeDirty() []rpcpb.ShardReplayLog {
+	l.Lock()
+	defer l.Unlock()
+	logs := make([]rpcpb.ShardReplayLog, 0, len(l.dirty))
+	for id := range l.dirty {
+		if ring, ok := l.rings[id]; ok {
+			logs = append(logs, rpcpb.ShardReplayLog{ShardID: id, Records: ring.list(0)})
+		}
+		delete(l.dirty, id)
+	}
+	return logs
+}
+
+func (l *shardReplayLogs) markDirty(shardID uint64) {
+	l.Lock()
+	defer l.Unlock()
+	l.dirty[shardID] = struct{}{}
+}
+
+// gc drops the logs of the shards not kept, and returns the dropped shards
+func (l *shardReplayLogs) gc(keep func(shardID uint64) bool) []uint64 {
+	l.Lock()
+	defer l.Unlock()
+	var removed []uint64
+	for id := range l.rings {
+		if !keep(id) {
+			delete(l.rings, id)
+			delete(l.dirty, id)
+			removed = append(removed, id)
+		}
+	}
+	return removed
+}
+
+func newShardReplayRecord(res *core.CachedShard, kind, detail string) rpcpb.ShardReplayRecord {
+	record := rpcpb.ShardReplayRecord{
+		Timestamp: time.Now().UnixNano(),
+		Kind:      kind,
+		Detail:    detail,
+	}
+	if res != nil {
+		if leader := res.GetLeader(); leader != nil {
+			record.Leader = leader.ID
+			record.LeaderStore = leader.StoreID
+		}
+		record.Term = res.GetTerm()
+		record.Epoch = res.Meta.GetEpoch()
+		record.State = res.Meta.GetState()
+		record.ApproximateSize = uint64(res.GetApproximateSize())
+		record.ApproximateKeys = uint64(res.GetApproximateKeys())
+		record.DownReplicas = uint64(len(res.GetDownPeers()))
+		record.PendingReplicas = uint64(len(res.GetPendingPeers()))
+	}
+	return record
+}
+
+// recordHeartbeat records the shard heartbeat, the heartbeat rejected is
+// recorded with the error.
+func (c *RaftCluster) recordHeartbeat(res *core.CachedShard, err error) {
+	kind, detail := "heartbeat", ""
+	if err != nil {
+		kind, detail = "heartbeat-rejected", err.Error()
+	}
+	c.replayLogs.add(res.Meta.GetID(),
+		newShardReplayRecord(res, kind, detail),
+		c.opt.GetShardReplayLogSize())
+}
+
+// RecordDecision records the scheduling decision of the shard, with the shard
+// state known by prophet at the moment.
+func (c *RaftCluster) RecordDecision(shardID uint64, kind, detail string) {
+	c.replayLogs.add(shardID,
+		newShardReplayRecord(c.core.GetShard(shardID), kind, detail),
+		c.opt.GetShardReplayLogSize())
+}
+
+// loadShardReplayLogs loads the persisted shard replay logs
+func (c *RaftCluster) loadShardReplayLogs() error {
+	size := c.opt.GetShardReplayLogSize()
+	return c.storage.LoadShardReplayLogs(batch, func(data []byte) {
+		var value rpcpb.ShardReplayLog
+		protoc.MustUnmarshal(&value, data)
+		c.replayLogs.load(value, size)
+	})
+}
+
+// persistShardReplayLogs drops the logs of the removed shards, and saves the
+// changed logs to the storage if the persistence is enabled, it's called by
+// the background jobs.
+func (c *RaftCluster) persistShardReplayLogs() {
+	enabled := c.opt.GetShardReplayLogSize() > 0
+	removed := c.replayLogs.gc(func(shardID uint64) bool {
+		return enabled && c.core.GetShard(shardID) != nil
+	})
+	if !c.opt.IsShardReplayLogPersistEnabled() {
+		return
+	}
+
+	for _, id := range removed {
+		if err := c.storage.RemoveShardReplayLog(id); err != nil {
+			c.logger.Error("fail to remove shard replay log",
+				log.ShardIDField(id),
+				zap.Error(err))
+		}
+	}
+	for _, value := range c.replayLogs.takeDirty() {
+		if err := c.storage.PutShardReplayLog(value.ShardID, protoc.MustMarshal(&value)); err != nil {
+			c.logger.Error("fail to save shard replay log",
+				log.ShardIDField(value.ShardID),
+				zap.Error(err))
+			c.replayLogs.markDirty(value.ShardID)
+		}
+	}
+}
+
+// HandleGetShardReplayLog returns the recent heartbeats and scheduling decisions
+// of the shard
+func (c *RaftCluster) HandleGetShardReplayLog(request *rpcpb.ProphetRequest) (*rpcpb.GetShardReplayLogRsp, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.running {
+		return nil, util.ErrNotLeader
+	}
+
+	req := request.GetShardReplayLog
+	return &rpcpb.GetShardReplayLogRsp{
+		Log: rpcpb.ShardReplayLog{
+			ShardID: req.ShardID,
+			Records: c.replayLogs.get(req.ShardID, int(req.Limit)),
+		},
+	}, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/config"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/operator"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func replayKinds(records []rpcpb.ShardReplayRecord) []string {
+	var kinds []string
+	for _, r := range records {
+		kinds = append(kinds, r.Kind)
+	}
+	return kinds
+}
+
+func TestReplayRing(t *testing.T) {
+	r := &replayRing{}
+	for _, kind := range []string{"a", "b", "c", "d", "e"} {
+		r.add(rpcpb.ShardReplayRecord{Kind: kind}, 3)
+	}
+	assert.Equal(t, []string{"c", "d", "e"}, replayKinds(r.list(0)))
+	assert.Equal(t, []string{"d", "e"}, replayKinds(r.list(2)))
+
+	// shrink the wrapped ring
+	r.add(rpcpb.ShardReplayRecord{Kind: "f"}, 2)
+	assert.Equal(t, []string{"e", "f"}, replayKinds(r.list(0)))
+	// grow the ring
+	r.add(rpcpb.ShardReplayRecord{Kind: "g"}, 4)
+	r.add(rpcpb.ShardReplayRecord{Kind: "h"}, 4)
+	r.add(rpcpb.ShardReplayRecord{Kind: "i"}, 4)
+	assert.Equal(t, []string{"f", "g", "h", "i"}, replayKinds(r.list(0)))
+}
+
+func TestShardReplayLog(t *testing.T) {
+	tc, co, cleanup := prepare(t, func(cfg *config.ScheduleConfig) {
+		cfg.ShardReplayLogSize = 4
+		cfg.EnableShardReplayLogPersist = true
+	}, nil, nil)
+	defer cleanup()
+	tc.running = true
+
+	for id := uint64(1); id <= 3; id++ {
+		assert.Nil(t, tc.addShardStore(id, 1))
+	}
+	assert.Nil(t, tc.addLeaderShard(1, 1, 2, 3))
+	res := tc.GetShard(1)
+	tc.recordHeartbeat(res, nil)
+	tc.recordHeartbeat(res, errors.New("stale heartbeat"))
+	op := newTestOperator(1, res.Meta.GetEpoch(), operator.OpLeader, operator.TransferLeader{FromStore: 1, ToStore: 2})
+	require.True(t, co.opController.AddOperator(op))
+	co.opController.RemoveOperator(op, "test")
+
+	req := &rpcpb.ProphetRequest{}
+	req.GetShardReplayLog.ShardID = 1
+	rsp, err := tc.HandleGetShardReplayLog(req)
+	require.NoError(t, err)
+	records := rsp.Log.Records
+	assert.Equal(t, uint64(1), rsp.Log.ShardID)
+	assert.Equal(t, []string{"heartbeat-rejected", "operator-start", "step", "operator-canceled"}, replayKinds(records),
+		"bounded by the size")
+	assert.Equal(t, "stale heartbeat", records[0].Detail)
+	assert.Equal(t, uint64(1), records[0].LeaderStore)
+	assert.Equal(t, res.Meta.GetEpoch(), records[0].Epoch)
+	assert.Equal(t, uint64(10), records[0].ApproximateSize)
+	for i := 1; i < len(records); i++ {
+		assert.True(t, records[i].Timestamp >= records[i-1].Timestamp)
+	}
+
+	req.GetShardReplayLog.Limit = 1
+	rsp, err = tc.HandleGetShardReplayLog(req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"operator-canceled"}, replayKinds(rsp.Log.Records))
+
+	// the logs are reloaded after the prophet leader changed
+	tc.persistShardReplayLogs()
+	tc.replayLogs = newShardReplayLogs()
+	require.NoError(t, tc.loadShardReplayLogs())
+	assert.Equal(t, records, tc.replayLogs.get(1, 0))
+
+	// the logs of the removed shards are dropped
+	tc.RecordDecision(2, "operator-start", "")
+	tc.persistShardReplayLogs()
+	assert.Empty(t, tc.replayLogs.get(2, 0))
+	tc.replayLogs = newShardReplayLogs()
+	require.NoError(t, tc.loadShardReplayLogs())
+	assert.Empty(t, tc.replayLogs.get(2, 0))
+
+	tc.running = false
+	_, err = tc.HandleGetShardReplayLog(req)
+	assert.Error(t, err)
+}
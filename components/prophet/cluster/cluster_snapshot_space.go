@@ -0,0 +1,59 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+// snapshotRejectDuration how long the store rejected a snapshot for the
+// insufficient space is not selected as the target of the new replicas
+var snapshotRejectDuration = 10 * time.Minute
+
+// handleSnapshotSpaceFailures handles the snapshots rejected by the receivers
+// for the insufficient space. The stores are not selected as the targets of the
+// new replicas for a while, and the operators adding the replicas on them are
+// canceled, so the checkers pick another target.
+func (c *RaftCluster) handleSnapshotSpaceFailures(res *core.CachedShard, oc *schedule.OperatorController) {
+	for _, progress := range res.GetReplicaProgresses() {
+		if progress.SnapshotFailure != metapb.SnapshotFailureReason_InsufficientSpace {
+			continue
+		}
+		storeID := progress.Replica.StoreID
+		if store := c.GetStore(storeID); store != nil && !store.IsRejectingSnapshots() {
+			c.logger.Warn("store rejected the snapshot for insufficient space",
+				zap.Uint64("store", storeID),
+				zap.Uint64("shard", res.Meta.GetID()),
+				zap.Uint64("replica", progress.Replica.ID))
+			c.core.RejectSnapshots(storeID, time.Now().Add(snapshotRejectDuration))
+		}
+
+		op := oc.GetOperator(res.Meta.GetID())
+		if op == nil {
+			continue
+		}
+		for i := 0; i < op.Len(); i++ {
+			if peerID, ok := getAddedPeer(op.Step(i)); ok && peerID == progress.Replica.ID {
+				oc.RemoveOperator(op, "target store rejected the snapshot for insufficient space")
+				break
+			}
+		}
+	}
+}
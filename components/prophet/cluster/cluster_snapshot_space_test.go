@@ -0,0 +1,56 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/operator"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleSnapshotSpaceFailures(t *testing.T) {
+	tc, co, cleanup := prepare(t, nil, nil, nil)
+	defer cleanup()
+
+	for id := uint64(1); id <= 4; id++ {
+		assert.Nil(t, tc.addShardStore(id, 1))
+	}
+	assert.Nil(t, tc.addLeaderShard(1, 1, 2))
+
+	res := tc.GetShard(1)
+	learner := metapb.Replica{ID: 100, StoreID: 3, Role: metapb.ReplicaRole_Learner}
+	res = res.Clone(core.WithAddPeer(learner))
+	op := newTestOperator(1, res.Meta.GetEpoch(), operator.OpShard,
+		operator.AddLearner{ToStore: 3, PeerID: 100})
+	require.True(t, co.opController.AddOperator(op))
+
+	// the other failures are ignored
+	progresses := []metapb.ReplicaProgress{
+		{Replica: *res.GetLeader(), MatchIndex: 100},
+		{Replica: learner, SnapshotFailure: metapb.SnapshotFailureReason_DiskFull},
+	}
+	tc.handleSnapshotSpaceFailures(res.Clone(core.WithReplicaProgresses(progresses)), co.opController)
+	assert.False(t, tc.GetStore(3).IsRejectingSnapshots())
+	assert.NotNil(t, co.opController.GetOperator(1))
+
+	progresses[1].SnapshotFailure = metapb.SnapshotFailureReason_InsufficientSpace
+	tc.handleSnapshotSpaceFailures(res.Clone(core.WithReplicaProgresses(progresses)), co.opController)
+	assert.True(t, tc.GetStore(3).IsRejectingSnapshots())
+	assert.False(t, tc.GetStore(4).IsRejectingSnapshots())
+	assert.Nil(t, co.opController.GetOperator(1))
+}
@@ -16,6 +16,7 @@ package cluster
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"reflect"
@@ -25,10 +26,13 @@ import (
 	"github.com/matrixorigin/matrixcube/components/log"
 	"github.com/matrixorigin/matrixcube/components/prophet/config"
 	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/event"
 	"github.com/matrixorigin/matrixcube/components/prophet/schedule/opt"
 	"github.com/matrixorigin/matrixcube/components/prophet/schedule/placement"
 	"github.com/matrixorigin/matrixcube/components/prophet/storage"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -76,6 +80,59 @@ func TestStoreHeartbeat(t *testing.T) {
 	}
 }
 
+func TestStoreWeightMode(t *testing.T) {
+	cfg, opt, err := newTestScheduleConfig()
+	assert.NoError(t, err)
+	cluster := newTestRaftCluster(opt, storage.NewTestStorage(), core.NewBasicCluster(nil))
+
+	const tb = uint64(1 << 40)
+	stores := newTestStores(2, "2.0.0")
+	stores[1] = stores[1].Clone(core.SetStoreLabels([]metapb.Label{{Key: "disk-class", Value: "nvme"}}))
+	heartbeat := func() {
+		for i, store := range stores {
+			assert.NoError(t, cluster.HandleStoreHeartbeat(&metapb.StoreStats{
+				StoreID:   store.Meta.GetID(),
+				Capacity:  uint64(i*6+2) * tb,
+				Available: tb,
+			}))
+		}
+	}
+	for _, store := range stores {
+		assert.NoError(t, cluster.putStoreLocked(store))
+	}
+
+	// manual mode
+	heartbeat()
+	for _, store := range stores {
+		assert.Equal(t, 1.0, cluster.GetStore(store.Meta.GetID()).GetShardWeight())
+		assert.Equal(t, 1.0, cluster.GetStore(store.Meta.GetID()).GetLeaderWeight())
+	}
+
+	// auto mode
+	cfg = cfg.Clone()
+	cfg.StoreWeightMode = "auto"
+	cfg.DiskClassWeights = map[string]float64{"nvme": 4}
+	assert.NoError(t, cfg.Validate())
+	opt.SetScheduleConfig(cfg)
+	heartbeat()
+	assert.Equal(t, 2.0, cluster.GetStore(1).GetShardWeight())
+	assert.Equal(t, 1.0, cluster.GetStore(1).GetLeaderWeight())
+	assert.Equal(t, 8.0, cluster.GetStore(2).GetShardWeight())
+	assert.Equal(t, 4.0, cluster.GetStore(2).GetLeaderWeight())
+
+	// the manual weight is kept after heartbeat
+	assert.NoError(t, cluster.SetStoreWeight(2, 0.5, 0.5))
+	heartbeat()
+	assert.Equal(t, 4.0, cluster.GetStore(2).GetShardWeight())
+	assert.Equal(t, 2.0, cluster.GetStore(2).GetLeaderWeight())
+
+	cfg = cfg.Clone()
+	cfg.DiskClassWeights["nvme"] = 0
+	assert.Error(t, cfg.Validate())
+	cfg.StoreWeightMode = "unknown"
+	assert.Error(t, cfg.Validate())
+}
+
 func TestFilterUnhealthyStore(t *testing.T) {
 	_, opt, err := newTestScheduleConfig()
 	assert.NoError(t, err)
@@ -154,6 +211,29 @@ func TestSetOfflineStore(t *testing.T) {
 	}
 }
 
+func TestSetMaintenanceWindows(t *testing.T) {
+	_, opt, err := newTestScheduleConfig()
+	assert.NoError(t, err)
+	s := storage.NewTestStorage()
+	cluster := newTestRaftCluster(opt, s, core.NewBasicCluster(nil))
+
+	windows := []config.MaintenanceWindow{{Start: "22:00", End: "06:00"}}
+	assert.NoError(t, cluster.SetMaintenanceWindows(windows))
+	assert.Equal(t, windows, opt.GetScheduleConfig().MaintenanceWindows)
+
+	cfg := &config.Config{}
+	ok, err := s.LoadConfig(cfg)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, windows, cfg.Schedule.MaintenanceWindows)
+
+	assert.Error(t, cluster.SetMaintenanceWindows([]config.MaintenanceWindow{{Start: "24:00", End: "06:00"}}))
+	assert.Equal(t, windows, opt.GetScheduleConfig().MaintenanceWindows)
+
+	assert.NoError(t, cluster.SetMaintenanceWindows(nil))
+	assert.Empty(t, opt.GetScheduleConfig().MaintenanceWindows)
+}
+
 func TestReuseAddress(t *testing.T) {
 	_, opt, err := newTestScheduleConfig()
 	assert.NoError(t, err)
@@ -193,6 +273,40 @@ func TestReuseAddress(t *testing.T) {
 	}
 }
 
+func TestPutStoreWithStaleEpoch(t *testing.T) {
+	_, opt, err := newTestScheduleConfig()
+	assert.NoError(t, err)
+	cluster := newTestRaftCluster(opt, storage.NewTestStorage(), core.NewBasicCluster(nil))
+
+	store := newTestStores(1, "2.0.0")[0].Meta
+	store.Epoch = 2
+	assert.NoError(t, cluster.PutStore(store))
+	assert.Equal(t, uint64(2), cluster.GetStore(1).Meta.GetEpoch())
+
+	store.Epoch = 1
+	err = cluster.PutStore(store)
+	assert.True(t, errors.Is(err, util.ErrStaleStoreEpoch))
+
+	store.Epoch = 3
+	assert.NoError(t, cluster.PutStore(store))
+	assert.Equal(t, uint64(3), cluster.GetStore(1).Meta.GetEpoch())
+}
+
+func TestPutStoreUpdatesSnapshotFormat(t *testing.T) {
+	_, opt, err := newTestScheduleConfig()
+	assert.NoError(t, err)
+	cluster := newTestRaftCluster(opt, storage.NewTestStorage(), core.NewBasicCluster(nil))
+
+	store := newTestStores(1, "2.0.0")[0].Meta
+	assert.NoError(t, cluster.PutStore(store))
+	assert.Equal(t, uint64(0), cluster.GetStore(1).Meta.GetSnapshotFormat())
+
+	// the store is upgraded
+	store.SnapshotFormat = 1
+	assert.NoError(t, cluster.PutStore(store))
+	assert.Equal(t, uint64(1), cluster.GetStore(1).Meta.GetSnapshotFormat())
+}
+
 func TestUpStore(t *testing.T) {
 	_, opt, err := newTestScheduleConfig()
 	assert.NoError(t, err)
@@ -225,6 +339,75 @@ func TestUpStore(t *testing.T) {
 	assert.True(t, strings.Contains(err.Error(), "not found"))
 }
 
+func TestStoreStateEvent(t *testing.T) {
+	_, opt, err := newTestScheduleConfig()
+	assert.NoError(t, err)
+	cluster := newTestRaftCluster(opt, storage.NewTestStorage(), core.NewBasicCluster(nil))
+	for _, store := range newTestStores(2, "2.0.0") {
+		assert.NoError(t, cluster.putStoreLocked(store))
+	}
+	assert.NoError(t, cluster.HandleStoreHeartbeat(&metapb.StoreStats{StoreID: 1}))
+	drainEvents(cluster)
+
+	// store 2 has no heartbeat
+	cluster.checkStoreStates()
+	assert.Equal(t, []rpcpb.EventNotify{event.NewStoreStateEvent(2, false)},
+		drainEvents(cluster, event.StoreStateEvent))
+	cluster.checkStoreStates()
+	assert.Empty(t, drainEvents(cluster, event.StoreStateEvent), "notified once")
+
+	assert.NoError(t, cluster.HandleStoreHeartbeat(&metapb.StoreStats{StoreID: 2}))
+	assert.Equal(t, []rpcpb.EventNotify{event.NewStoreStateEvent(2, true)},
+		drainEvents(cluster, event.StoreStateEvent))
+	assert.NoError(t, cluster.HandleStoreHeartbeat(&metapb.StoreStats{StoreID: 2}))
+	assert.Empty(t, drainEvents(cluster, event.StoreStateEvent))
+}
+
+func TestShardLeaderEvent(t *testing.T) {
+	_, opt, err := newTestScheduleConfig()
+	assert.NoError(t, err)
+	cluster := newTestRaftCluster(opt, storage.NewTestStorage(), core.NewBasicCluster(nil))
+	for _, store := range newTestStores(3, "2.0.0") {
+		assert.NoError(t, cluster.putStoreLocked(store))
+	}
+
+	shard := newTestShards(1, 3)[0]
+	shard = shard.Clone(core.WithLeader(&shard.Meta.Replicas[1]))
+	assert.NoError(t, cluster.processShardHeartbeat(shard))
+	assert.Equal(t, []rpcpb.EventNotify{event.NewShardLeaderEvent(shard.Meta.ID, shard.Meta.Replicas[1])},
+		drainEvents(cluster, event.ShardLeaderEvent))
+
+	// the same leader
+	assert.NoError(t, cluster.processShardHeartbeat(shard.Clone(core.SetWrittenBytes(100))))
+	assert.Empty(t, drainEvents(cluster, event.ShardLeaderEvent))
+
+	newShard := shard.Clone(core.WithLeader(&shard.Meta.Replicas[2]))
+	assert.NoError(t, cluster.processShardHeartbeat(newShard))
+	assert.Equal(t, []rpcpb.EventNotify{event.NewShardLeaderEvent(shard.Meta.ID, shard.Meta.Replicas[2])},
+		drainEvents(cluster, event.ShardLeaderEvent))
+}
+
+// drainEvents returns the notified events of the types, all the events are
+// returned if no type specified
+func drainEvents(cluster *RaftCluster, types ...uint32) []rpcpb.EventNotify {
+	var events []rpcpb.EventNotify
+	for {
+		select {
+		case e := <-cluster.ChangedEventNotifier():
+			if len(types) == 0 {
+				events = append(events, e)
+			}
+			for _, t := range types {
+				if e.Type == t {
+					events = append(events, e)
+				}
+			}
+		default:
+			return events
+		}
+	}
+}
+
 func TestShardHeartbeatWithLease(t *testing.T) {
 	_, opt, err := newTestScheduleConfig()
 	assert.NoError(t, err)
@@ -800,6 +983,7 @@ func newTestCluster(opt *config.PersistOptions) *testCluster {
 	storage := storage.NewTestStorage()
 	rc := newTestRaftCluster(opt, storage, core.NewBasicCluster(nil))
 	rc.ruleManager = placement.NewRuleManager(storage, rc, nil)
+	rc.ruleManager.SetKeyspaces(rc.core.Keyspaces)
 	if opt.IsPlacementRulesEnabled() {
 		err := rc.ruleManager.Initialize(opt.GetMaxReplicas(), opt.GetLocationLabels())
 		if err != nil {
@@ -0,0 +1,221 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fagongzi/util/protoc"
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+const (
+	// removeShardsBatch the max shards of a trashed group marked destroyed in a
+	// storage write, the same as the limit of HandleRemoveShards
+	removeShardsBatch = 4
+	// maxPurgeShards the max shards of the trashed groups destroyed by a round
+	// of the background jobs, so purging a huge group can not block the cluster
+	maxPurgeShards = 256
+)
+
+// HandleDropShardGroup moves the shard group to the trash. The shards of the
+// group are kept, and the space is still used, until the retention expires, the
+// requests to the shards are rejected by the stores in the meantime. The group
+// can be restored by HandleUndropShardGroup before the shards are destroyed.
+func (c *RaftCluster) HandleDropShardGroup(request *rpcpb.ProphetRequest) (*rpcpb.DropShardGroupRsp, error) {
+	c.Lock()
+	defer c.Unlock()
+	if !c.running {
+		return nil, util.ErrNotLeader
+	}
+
+	req := request.DropShardGroup
+	if req.Retention < 0 {
+		return nil, util.WrappedError(util.ErrReq,
+			fmt.Sprintf("invalid retention %d", req.Retention))
+	}
+	if _, ok := c.trashedGroups[req.Group]; ok {
+		return nil, util.WrappedError(util.ErrShardGroupTrashed,
+			fmt.Sprintf("shard group %d", req.Group))
+	}
+	shards := 0
+	c.core.ForeachShards(req.Group, func(res metapb.Shard) { shards++ })
+	if shards == 0 {
+		return nil, util.WrappedError(util.ErrReq,
+			fmt.Sprintf("shard group %d has no shards", req.Group))
+	}
+
+	retention := time.Duration(req.Retention) * time.Second
+	if retention == 0 {
+		retention = c.opt.GetShardGroupTrashRetention()
+	}
+	now := time.Now()
+	trashed := rpcpb.TrashedShardGroup{
+		Group:     req.Group,
+		DroppedAt: now.Unix(),
+		PurgeAt:   now.Add(retention).Unix(),
+	}
+	if err := c.storage.PutTrashedShardGroup(trashed.Group, protoc.MustMarshal(&trashed)); err != nil {
+		return nil, err
+	}
+	c.trashedGroups[trashed.Group] = trashed
+	c.logger.Info("shard group dropped",
+		zap.Uint64("group", trashed.Group),
+		zap.Int("shards", shards),
+		zap.Duration("retention", retention))
+	return &rpcpb.DropShardGroupRsp{Group: trashed}, nil
+}
+
+// HandleUndropShardGroup restores the shard group from the trash. The group
+// can not be restored once the retention expired or the purge started, since
+// some of the shards may be destroyed.
+func (c *RaftCluster) HandleUndropShardGroup(request *rpcpb.ProphetRequest) error {
+	c.Lock()
+	defer c.Unlock()
+	if !c.running {
+		return util.ErrNotLeader
+	}
+
+	group := request.UndropShardGroup.Group
+	trashed, ok := c.trashedGroups[group]
+	if !ok {
+		return util.WrappedError(util.ErrShardGroupNotTrashed,
+			fmt.Sprintf("shard group %d", group))
+	}
+	if trashed.Purging || time.Now().Unix() >= trashed.PurgeAt {
+		return util.WrappedError(util.ErrReq,
+			fmt.Sprintf("shard group %d is purged", group))
+	}
+	if err := c.storage.RemoveTrashedShardGroup(group); err != nil {
+		return err
+	}
+	delete(c.trashedGroups, group)
+	c.logger.Info("shard group undropped",
+		zap.Uint64("group", group))
+	return nil
+}
+
+// HandleGetTrashedShardGroups returns the shard groups in the trash ordered by
+// the group
+func (c *RaftCluster) HandleGetTrashedShardGroups(request *rpcpb.ProphetRequest) (*rpcpb.GetTrashedShardGroupsRsp, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.running {
+		return nil, util.ErrNotLeader
+	}
+
+	return &rpcpb.GetTrashedShardGroupsRsp{Groups: c.getTrashedShardGroupsLocked()}, nil
+}
+
+func (c *RaftCluster) getTrashedShardGroupsLocked() []rpcpb.TrashedShardGroup {
+	groups := make([]rpcpb.TrashedShardGroup, 0, len(c.trashedGroups))
+	for _, v := range c.trashedGroups {
+		groups = append(groups, v)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Group < groups[j].Group
+	})
+	return groups
+}
+
+func (c *RaftCluster) isShardGroupTrashedLocked(group uint64) bool {
+	_, ok := c.trashedGroups[group]
+	return ok
+}
+
+// loadTrashedShardGroups loads the persisted trash records
+func (c *RaftCluster) loadTrashedShardGroups() error {
+	return c.storage.LoadTrashedShardGroups(batch, func(data []byte) {
+		var value rpcpb.TrashedShardGroup
+		protoc.MustUnmarshal(&value, data)
+		c.trashedGroups[value.Group] = value
+	})
+}
+
+// purgeTrashedShardGroups destroys the shards of the trashed groups whose
+// retention expired, the trash record is removed once all the shards of the
+// group are destroyed, so the purge is resumed by the next prophet leader.
+func (c *RaftCluster) purgeTrashedShardGroups(now time.Time) {
+	c.Lock()
+	defer c.Unlock()
+
+	budget := maxPurgeShards
+	for _, trashed := range c.getTrashedShardGroupsLocked() {
+		if budget <= 0 {
+			return
+		}
+		if now.Unix() < trashed.PurgeAt {
+			continue
+		}
+		// the purge is persisted before any shard destroyed, so the group is
+		// never restored partially even if the clock of the next prophet
+		// leader is behind
+		if !trashed.Purging {
+			trashed.Purging = true
+			if err := c.storage.PutTrashedShardGroup(trashed.Group, protoc.MustMarshal(&trashed)); err != nil {
+				c.logger.Error("fail to start purging trashed shard group",
+					zap.Uint64("group", trashed.Group),
+					zap.Error(err))
+				return
+			}
+			c.trashedGroups[trashed.Group] = trashed
+		}
+
+		var ids []uint64
+		c.core.ForeachShards(trashed.Group, func(res metapb.Shard) {
+			ids = append(ids, res.ID)
+		})
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		if len(ids) > budget {
+			ids = ids[:budget]
+		}
+		for start := 0; start < len(ids); start += removeShardsBatch {
+			end := start + removeShardsBatch
+			if end > len(ids) {
+				end = len(ids)
+			}
+			if err := c.removeShardsLocked(ids[start:end]...); err != nil {
+				c.logger.Error("fail to destroy shards of trashed shard group",
+					zap.Uint64("group", trashed.Group),
+					zap.Error(err))
+				return
+			}
+		}
+		if len(ids) > 0 {
+			budget -= len(ids)
+			c.logger.Info("shards of trashed shard group destroyed",
+				zap.Uint64("group", trashed.Group),
+				zap.Int("count", len(ids)))
+			if budget <= 0 {
+				return
+			}
+		}
+
+		if err := c.storage.RemoveTrashedShardGroup(trashed.Group); err != nil {
+			c.logger.Error("fail to remove trashed shard group",
+				zap.Uint64("group", trashed.Group),
+				zap.Error(err))
+			return
+		}
+		delete(c.trashedGroups, trashed.Group)
+		c.logger.Info("trashed shard group purged",
+			zap.Uint64("group", trashed.Group))
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fagongzi/util/protoc"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/storage"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTrashCluster(t *testing.T, s storage.Storage) *RaftCluster {
+	_, opt, err := newTestScheduleConfig()
+	require.NoError(t, err)
+	tc := newTestRaftCluster(opt, s, core.NewBasicCluster(nil))
+	tc.running = true
+	for id := uint64(1); id <= 6; id++ {
+		shard := newTestShardMeta(id)
+		shard.Group = id % 2
+		tc.core.PutShard(core.NewCachedShard(*shard, nil))
+	}
+	return tc
+}
+
+func TestDropAndUndropShardGroup(t *testing.T) {
+	s := storage.NewTestStorage()
+	tc := newTestTrashCluster(t, s)
+
+	req := &rpcpb.ProphetRequest{}
+	req.DropShardGroup.Group = 1
+	rsp, err := tc.HandleDropShardGroup(req)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), rsp.Group.Group)
+	assert.Equal(t, int64(tc.opt.GetShardGroupTrashRetention()/time.Second),
+		rsp.Group.PurgeAt-rsp.Group.DroppedAt)
+	_, err = tc.HandleDropShardGroup(req)
+	assert.True(t, errors.Is(err, util.ErrShardGroupTrashed))
+
+	// the group without shards can not be dropped
+	req.DropShardGroup.Group = 2
+	_, err = tc.HandleDropShardGroup(req)
+	assert.True(t, errors.Is(err, util.ErrReq))
+
+	// the shards can not be created in the trashed group
+	data, err := (&metapb.Shard{Group: 1, Unique: "new"}).Marshal()
+	require.NoError(t, err)
+	req.CreateShards.Shards = [][]byte{data}
+	_, err = tc.HandleCreateShards(req)
+	assert.True(t, errors.Is(err, util.ErrShardGroupTrashed))
+
+	// reload from storage
+	tc = newTestTrashCluster(t, s)
+	require.NoError(t, tc.loadTrashedShardGroups())
+	groups, err := tc.HandleGetTrashedShardGroups(req)
+	require.NoError(t, err)
+	assert.Equal(t, []rpcpb.TrashedShardGroup{rsp.Group}, groups.Groups)
+
+	req.UndropShardGroup.Group = 1
+	require.NoError(t, tc.HandleUndropShardGroup(req))
+	assert.True(t, errors.Is(tc.HandleUndropShardGroup(req), util.ErrShardGroupNotTrashed))
+	groups, err = tc.HandleGetTrashedShardGroups(req)
+	require.NoError(t, err)
+	assert.Empty(t, groups.Groups)
+}
+
+func TestPurgeTrashedShardGroups(t *testing.T) {
+	s := storage.NewTestStorage()
+	tc := newTestTrashCluster(t, s)
+
+	req := &rpcpb.ProphetRequest{}
+	req.DropShardGroup.Group = 1
+	req.DropShardGroup.Retention = 60
+	rsp, err := tc.HandleDropShardGroup(req)
+	require.NoError(t, err)
+
+	// the shards are kept within the retention
+	tc.purgeTrashedShardGroups(time.Unix(rsp.Group.PurgeAt-1, 0))
+	for _, id := range []uint64{1, 3, 5} {
+		assert.NotNil(t, tc.core.GetShard(id))
+	}
+
+	tc.purgeTrashedShardGroups(time.Unix(rsp.Group.PurgeAt, 0))
+	for _, id := range []uint64{1, 3, 5} {
+		assert.Nil(t, tc.core.GetShard(id))
+		assert.True(t, tc.core.AlreadyRemoved(id))
+		v, err := s.GetShard(id)
+		require.NoError(t, err)
+		assert.Equal(t, metapb.ShardState_Destroyed, v.State)
+	}
+	for _, id := range []uint64{2, 4, 6} {
+		assert.NotNil(t, tc.core.GetShard(id))
+	}
+	assert.Empty(t, tc.trashedGroups)
+
+	tc = newTestTrashCluster(t, s)
+	require.NoError(t, tc.loadTrashedShardGroups())
+	assert.Empty(t, tc.trashedGroups)
+}
+
+func TestUndropShardGroupAfterPurgeStarted(t *testing.T) {
+	s := storage.NewTestStorage()
+	tc := newTestTrashCluster(t, s)
+
+	req := &rpcpb.ProphetRequest{}
+	req.DropShardGroup.Group = 1
+	req.DropShardGroup.Retention = 60
+	rsp, err := tc.HandleDropShardGroup(req)
+	require.NoError(t, err)
+
+	// the retention expired
+	trashed := rsp.Group
+	trashed.PurgeAt = time.Now().Unix()
+	tc.trashedGroups[1] = trashed
+	req.UndropShardGroup.Group = 1
+	assert.True(t, errors.Is(tc.HandleUndropShardGroup(req), util.ErrReq))
+
+	// the purge started by the previous leader whose clock is ahead
+	trashed = rsp.Group
+	trashed.Purging = true
+	require.NoError(t, s.PutTrashedShardGroup(1, protoc.MustMarshal(&trashed)))
+	tc = newTestTrashCluster(t, s)
+	require.NoError(t, tc.loadTrashedShardGroups())
+	assert.True(t, errors.Is(tc.HandleUndropShardGroup(req), util.ErrReq))
+	assert.Equal(t, 1, len(tc.trashedGroups))
+
+	trashed.Purging = false
+	tc.trashedGroups[1] = trashed
+	require.NoError(t, tc.HandleUndropShardGroup(req))
+}
@@ -35,7 +35,9 @@ func (c *RaftCluster) HandleShardHeartbeat(res *core.CachedShard) error {
 	co := c.coordinator
 	c.RUnlock()
 
-	if err := c.processShardHeartbeat(res); err != nil {
+	err := c.processShardHeartbeat(res)
+	c.recordHeartbeat(res, err)
+	if err != nil {
 		if err == errShardDestroyed {
 			co.opController.DispatchDestroyDirectly(res, schedule.DispatchFromHeartBeat)
 			return nil
@@ -43,6 +45,7 @@ func (c *RaftCluster) HandleShardHeartbeat(res *core.CachedShard) error {
 		return err
 	}
 
+	c.handleSnapshotSpaceFailures(res, co.opController)
 	co.opController.Dispatch(res, schedule.DispatchFromHeartBeat)
 	return nil
 }
@@ -179,6 +182,7 @@ func (c *RaftCluster) HandleAskBatchSplit(request *rpcpb.ProphetRequest) (*rpcpb
 		}
 
 		recordShards = append(recordShards, newShardID)
+		c.lineages.addPendingSplit(newShardID, *reqShard)
 		splitIDs = append(splitIDs, rpcpb.SplitID{
 			NewID:         newShardID,
 			NewReplicaIDs: peerIDs,
@@ -227,6 +231,10 @@ func (c *RaftCluster) HandleCreateShards(request *rpcpb.ProphetRequest) (*rpcpb.
 		if len(res.GetReplicas()) > 0 {
 			return nil, fmt.Errorf("cann't assign peers in create resources")
 		}
+		if c.isShardGroupTrashedLocked(res.GetGroup()) {
+			return nil, util.WrappedError(util.ErrShardGroupTrashed,
+				fmt.Sprintf("shard group %d", res.GetGroup()))
+		}
 
 		// check recreate
 		create := true
@@ -312,16 +320,25 @@ func (c *RaftCluster) HandleRemoveShards(request *rpcpb.ProphetRequest) (*rpcpb.
 	c.RLock()
 	defer c.RUnlock()
 
+	if err := c.removeShardsLocked(request.RemoveShards.IDs...); err != nil {
+		return nil, err
+	}
+	return &rpcpb.RemoveShardsRsp{}, nil
+}
+
+// removeShardsLocked marks the shards destroyed, the replicas of the shards are
+// destroyed by the stores once notified.
+func (c *RaftCluster) removeShardsLocked(ids ...uint64) error {
 	var targets []metapb.Shard
 	var origin []metapb.Shard
-	for _, id := range request.RemoveShards.IDs {
+	for _, id := range ids {
 		if c.core.AlreadyRemoved(id) {
 			continue
 		}
 
 		v := c.core.GetShard(id)
 		if v == nil {
-			return nil, fmt.Errorf("resource %d not found in prophet", id)
+			return fmt.Errorf("resource %d not found in prophet", id)
 		}
 
 		res := v.Meta // use cloned value
@@ -330,15 +347,14 @@ func (c *RaftCluster) HandleRemoveShards(request *rpcpb.ProphetRequest) (*rpcpb.
 		origin = append(origin, res)
 	}
 	if err := c.storage.PutShards(targets...); err != nil {
-		return nil, err
+		return err
 	}
 
-	c.core.AddRemovedShards(request.RemoveShards.IDs...)
+	c.core.AddRemovedShards(ids...)
 	for _, shard := range origin {
 		c.addNotifyLocked(event.NewShardEvent(shard, 0, nil, true, false))
 	}
-
-	return &rpcpb.RemoveShardsRsp{}, nil
+	return nil
 }
 
 // HandleCheckShardState handle check resource state
@@ -358,6 +374,28 @@ func (c *RaftCluster) HandlePutPlacementRule(request *rpcpb.ProphetRequest) erro
 	return c.GetRuleManager().SetRule(placement.NewRuleFromRPC(request.PutPlacementRule.Rule))
 }
 
+// HandleDeletePlacementRule handle delete placement rule
+func (c *RaftCluster) HandleDeletePlacementRule(request *rpcpb.ProphetRequest) error {
+	group, id := request.DeletePlacementRule.GroupID, request.DeletePlacementRule.ID
+	if c.GetRuleManager().GetRule(group, id) == nil {
+		return fmt.Errorf("placement rule %s/%s not found", group, id)
+	}
+	return c.GetRuleManager().DeleteRule(group, id)
+}
+
+// HandleGetPlacementRules handle get placement rules
+func (c *RaftCluster) HandleGetPlacementRules(request *rpcpb.ProphetRequest) (*rpcpb.GetPlacementRulesRsp, error) {
+	var rules []*placement.Rule
+	if group := request.GetPlacementRules.GroupID; group != "" {
+		rules = c.GetRuleManager().GetRulesByGroup(group)
+	} else {
+		rules = c.GetRuleManager().GetAllRules()
+	}
+	return &rpcpb.GetPlacementRulesRsp{
+		Rules: placement.RPCRules(rules),
+	}, nil
+}
+
 // HandleAppliedRules handle get applied rules
 func (c *RaftCluster) HandleAppliedRules(request *rpcpb.ProphetRequest) (*rpcpb.GetAppliedRulesRsp, error) {
 	res := c.GetShard(request.GetAppliedRules.ShardID)
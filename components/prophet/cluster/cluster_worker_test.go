@@ -138,7 +138,7 @@ func TestRemoveShards(t *testing.T) {
 	// add 1,2,3,4,5,6
 	for i := uint64(1); i < n; i++ {
 		cluster.processShardHeartbeat(shards[i])
-		checkNotifyCount(t, nc, event.ShardEvent, event.ShardStatsEvent)
+		checkNotifyCount(t, nc, event.ShardEvent, event.ShardLeaderEvent, event.ShardStatsEvent)
 	}
 
 	removed := []uint64{1, 2, 3, 4, 5}
@@ -276,3 +276,15 @@ func checkNotifyCount(t *testing.T, nc <-chan rpcpb.EventNotify, expectNotifyTyp
 		}
 	}
 }
+
+func TestSetShardReadHint(t *testing.T) {
+	_, opt, err := newTestScheduleConfig()
+	assert.NoError(t, err)
+	cluster := newTestRaftCluster(opt, storage.NewTestStorage(), core.NewBasicCluster(nil))
+	nc := cluster.ChangedEventNotifier()
+
+	cluster.SetShardReadHint(metapb.ShardReadHint{ShardID: 1, Replicas: []uint64{2, 3}})
+	e := <-nc
+	assert.Equal(t, event.ReadHintEvent, e.Type)
+	assert.Equal(t, &metapb.ShardReadHint{ShardID: 1, Replicas: []uint64{2, 3}}, e.ReadHintEvent)
+}
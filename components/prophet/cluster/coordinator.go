@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/matrixorigin/matrixcube/components/prophet/config"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
 	"github.com/matrixorigin/matrixcube/components/prophet/schedule"
 	"github.com/matrixorigin/matrixcube/components/prophet/schedule/hbstream"
 	"github.com/matrixorigin/matrixcube/components/prophet/schedule/operator"
@@ -61,8 +62,15 @@ type coordinator struct {
 	opController    *schedule.OperatorController
 	hbStreams       *hbstream.HeartbeatStreams
 	pluginInterface *schedule.PluginInterface
+	// consistencyChecks the last consistency check time of the resources, only
+	// accessed by the patrol goroutine
+	consistencyChecks map[uint64]time.Time
 }
 
+// coordinatorComponent the component of the loops of the coordinator monitored
+// by the watchdog
+const coordinatorComponent = "prophet-coordinator"
+
 // newCoordinator creates a new coordinator.
 func newCoordinator(ctx context.Context, cluster *RaftCluster, hbStreams *hbstream.HeartbeatStreams) *coordinator {
 	ctx, cancel := context.WithCancel(ctx)
@@ -78,6 +86,8 @@ func newCoordinator(ctx context.Context, cluster *RaftCluster, hbStreams *hbstre
 		opController:    opController,
 		hbStreams:       hbStreams,
 		pluginInterface: schedule.NewPluginInterface(cluster.GetLogger()),
+
+		consistencyChecks: make(map[uint64]time.Time),
 	}
 }
 
@@ -95,6 +105,9 @@ func (c *coordinator) patrolShards() {
 	timer := time.NewTimer(c.cluster.GetOpts().GetPatrolShardInterval())
 	defer timer.Stop()
 
+	task := c.cluster.watchdog.Register(coordinatorComponent, "patrol-shards")
+	defer c.cluster.watchdog.Unregister(task)
+
 	c.cluster.logger.Info("coordinator starts patrol resources")
 	keys := make(map[uint64][]byte)
 	for _, g := range c.cluster.GetReplicationConfig().Groups {
@@ -111,6 +124,7 @@ func (c *coordinator) patrolShards() {
 			return
 		}
 
+		task.Begin()
 		// Check suspect resources first.
 		c.checkSuspectShards()
 		// Check suspect key ranges
@@ -129,6 +143,7 @@ func (c *coordinator) patrolShards() {
 
 		// check destroying resources
 		c.checkDestroyingShards()
+		task.End()
 	}
 }
 
@@ -164,14 +179,17 @@ func (c *coordinator) doScan(group uint64, keys map[uint64][]byte) {
 	if len(resources) == 0 {
 		// Resets the scan key.
 		keys[group] = nil
+		c.pruneConsistencyChecks()
 		return
 	}
 
+	now := time.Now()
 	for _, res := range resources {
 		// Skips the resource if there is already a pending operator.
 		if c.opController.GetOperator(res.Meta.GetID()) != nil {
 			continue
 		}
+		c.maybeCheckConsistency(res, now)
 
 		ops := c.checkers.CheckShard(res)
 
@@ -192,6 +210,39 @@ func (c *coordinator) doScan(group uint64, keys map[uint64][]byte) {
 	c.cluster.updateShardsLabelLevelStats(resources)
 }
 
+// maybeCheckConsistency sends the consistency check to the leader of the
+// resource every ConsistencyCheckInterval. The first check of a resource is
+// delayed by an interval, so the resources are not checked all at once after
+// the prophet leader changed.
+func (c *coordinator) maybeCheckConsistency(res *core.CachedShard, now time.Time) {
+	interval := c.cluster.GetOpts().GetConsistencyCheckInterval()
+	if interval <= 0 || res.GetLeader() == nil {
+		return
+	}
+
+	id := res.Meta.GetID()
+	last, ok := c.consistencyChecks[id]
+	if !ok {
+		c.consistencyChecks[id] = now
+		return
+	}
+	if now.Sub(last) < interval {
+		return
+	}
+	c.consistencyChecks[id] = now
+	c.opController.DispatchCheckConsistency(res, schedule.DispatchFromConsistencyCheck)
+}
+
+// pruneConsistencyChecks removes the last consistency check time of the
+// removed resources.
+func (c *coordinator) pruneConsistencyChecks() {
+	for id := range c.consistencyChecks {
+		if c.cluster.GetShard(id) == nil {
+			delete(c.consistencyChecks, id)
+		}
+	}
+}
+
 func (c *coordinator) checkSuspectShards() {
 	for _, id := range c.cluster.GetSuspectShards() {
 		res := c.cluster.GetShard(id)
@@ -413,9 +464,12 @@ func (c *coordinator) run() {
 		}
 	}
 
-	// Removes the invalid scheduler config and persist.
-	scheduleCfg.Schedulers = scheduleCfg.Schedulers[:k]
-	c.cluster.opt.SetScheduleConfig(scheduleCfg)
+	// Removes the invalid scheduler config and persist, the other settings
+	// may be changed while the schedulers are created, so only the schedulers
+	// are updated.
+	cfg := c.cluster.opt.GetScheduleConfig().Clone()
+	cfg.Schedulers = scheduleCfg.Schedulers[:k]
+	c.cluster.opt.SetScheduleConfig(cfg)
 	if err := c.cluster.opt.Persist(c.cluster.storage); err != nil {
 		c.cluster.logger.Error("fail to persist schedule config",
 			zap.Error(err))
@@ -631,6 +685,9 @@ func (c *coordinator) runScheduler(s *scheduleController) {
 	timer := time.NewTimer(s.GetInterval())
 	defer timer.Stop()
 
+	task := c.cluster.watchdog.Register(coordinatorComponent, s.GetName())
+	defer c.cluster.watchdog.Unregister(task)
+
 	for {
 		select {
 		case <-timer.C:
@@ -638,6 +695,7 @@ func (c *coordinator) runScheduler(s *scheduleController) {
 			if !s.AllowSchedule() {
 				continue
 			}
+			task.Begin()
 			if op := s.Schedule(); op != nil {
 				added := c.opController.AddWaitingOperator(op...)
 				c.cluster.logger.Debug("operators added",
@@ -645,6 +703,7 @@ func (c *coordinator) runScheduler(s *scheduleController) {
 					zap.Int("added", added),
 					zap.Int("total", len(op)))
 			}
+			task.End()
 
 		case <-s.Ctx().Done():
 			c.cluster.logger.Info("scheduler has been stopped",
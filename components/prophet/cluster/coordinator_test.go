@@ -954,6 +954,39 @@ func TestInterval(t *testing.T) {
 	}
 }
 
+func TestConsistencyCheck(t *testing.T) {
+	tc, co, cleanup := prepare(t, func(cfg *config.ScheduleConfig) {
+		cfg.ConsistencyCheckInterval = typeutil.NewDuration(time.Minute)
+	}, nil, nil)
+	defer cleanup()
+
+	assert.Nil(t, tc.addShardStore(1, 1))
+	assert.Nil(t, tc.addShardStore(2, 1))
+	assert.Nil(t, tc.addLeaderShard(1, 1, 2))
+	stream := mockhbstream.NewHeartbeatStream()
+	shard := tc.GetShard(1)
+	co.hbStreams.BindStream(1, stream)
+
+	// the first check is delayed by an interval
+	now := time.Now()
+	co.maybeCheckConsistency(shard, now)
+	waitNoResponse(t, stream)
+	co.maybeCheckConsistency(shard, now.Add(time.Second))
+	waitNoResponse(t, stream)
+
+	co.maybeCheckConsistency(shard, now.Add(time.Minute))
+	testutil.WaitUntil(t, func(t *testing.T) bool {
+		res := stream.Recv()
+		return res != nil && res.GetShardID() == 1 && res.GetCheckConsistency()
+	})
+	co.maybeCheckConsistency(shard, now.Add(time.Minute+time.Second))
+	waitNoResponse(t, stream)
+
+	tc.core.RemoveShard(shard)
+	co.pruneConsistencyChecks()
+	assert.Empty(t, co.consistencyChecks)
+}
+
 func waitAddLearner(t *testing.T, stream mockhbstream.HeartbeatStream, shard *core.CachedShard, storeID uint64) *core.CachedShard {
 	var res *rpcpb.ShardHeartbeatRsp
 	testutil.WaitUntil(t, func(t *testing.T) bool {
@@ -43,6 +43,14 @@ var (
 			Help:      "Status of the scheduler.",
 		}, []string{"kind", "type"})
 
+	watchdogStalledGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "prophet",
+			Subsystem: "cluster",
+			Name:      "watchdog_stalled_tasks",
+			Help:      "Number of the stalled tasks of the components found by the watchdog.",
+		}, []string{"component"})
+
 	patrolCheckShardsGauge = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Namespace: "prophet",
@@ -83,4 +91,11 @@ func init() {
 	prometheus.MustRegister(clusterStateCPUGauge)
 	prometheus.MustRegister(clusterStateCurrent)
 	prometheus.MustRegister(resourceWaitingListGauge)
+	prometheus.MustRegister(watchdogStalledGauge)
+}
+
+// SetWatchdogStalledTasks set the number of the stalled tasks of the component
+// of the prophet
+func SetWatchdogStalledTasks(component string, stalled int) {
+	watchdogStalledGauge.WithLabelValues(component).Set(float64(stalled))
 }
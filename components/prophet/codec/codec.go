@@ -15,6 +15,7 @@ package codec
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/fagongzi/goetty/buf"
 	gcodec "github.com/fagongzi/goetty/codec"
@@ -25,8 +26,24 @@ import (
 
 var (
 	c = &serverCodec{}
+
+	// requestPool reuses the decoded requests, most of them are the heartbeats
+	// of the shards and stores.
+	requestPool = sync.Pool{
+		New: func() interface{} {
+			return &rpcpb.ProphetRequest{}
+		},
+	}
 )
 
+// ReleaseRequest returns the request decoded by the server codec to the pool
+// after it handled. The fields of the request can still be used, but the request
+// itself and the pointers to its inline fields must not be retained.
+func ReleaseRequest(req *rpcpb.ProphetRequest) {
+	req.Reset()
+	requestPool.Put(req)
+}
+
 // NewServerCodec create server codec
 func NewServerCodec(maxBodySize int) (gcodec.Encoder, gcodec.Decoder) {
 	return length.NewWithSize(c, c, 0, 0, 0, maxBodySize)
@@ -37,9 +54,10 @@ type serverCodec struct {
 
 func (c *serverCodec) Decode(in *buf.ByteBuf) (bool, interface{}, error) {
 	data := in.GetMarkedRemindData()
-	req := &rpcpb.ProphetRequest{}
+	req := requestPool.Get().(*rpcpb.ProphetRequest)
 	err := req.Unmarshal(data)
 	if err != nil {
+		ReleaseRequest(req)
 		return false, nil, err
 	}
 
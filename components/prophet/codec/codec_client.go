@@ -15,6 +15,7 @@ package codec
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/fagongzi/goetty/buf"
 	gcodec "github.com/fagongzi/goetty/codec"
@@ -25,8 +26,22 @@ import (
 
 var (
 	cc = &clientCodec{}
+
+	responsePool = sync.Pool{
+		New: func() interface{} {
+			return &rpcpb.ProphetResponse{}
+		},
+	}
 )
 
+// ReleaseResponse returns the response decoded by the client codec to the pool,
+// only if it is not passed to the caller of the request, e.g. the shard
+// heartbeat responses which are copied to the heartbeat stream.
+func ReleaseResponse(resp *rpcpb.ProphetResponse) {
+	resp.Reset()
+	responsePool.Put(resp)
+}
+
 // NewClientCodec create client side codec
 func NewClientCodec(maxBodySize int) (gcodec.Encoder, gcodec.Decoder) {
 	return length.NewWithSize(cc, cc, 0, 0, 0, maxBodySize)
@@ -37,9 +52,10 @@ type clientCodec struct {
 
 func (c *clientCodec) Decode(in *buf.ByteBuf) (bool, interface{}, error) {
 	data := in.GetMarkedRemindData()
-	resp := &rpcpb.ProphetResponse{}
+	resp := responsePool.Get().(*rpcpb.ProphetResponse)
 	err := resp.Unmarshal(data)
 	if err != nil {
+		ReleaseResponse(resp)
 		return false, nil, err
 	}
 
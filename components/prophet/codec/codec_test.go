@@ -16,6 +16,7 @@ import (
 	"testing"
 
 	"github.com/fagongzi/goetty/buf"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/stretchr/testify/assert"
 )
@@ -41,3 +42,51 @@ func TestCodec(t *testing.T) {
 	assert.True(t, completed, "TestCodec failed")
 	assert.Equal(t, resp.ID, data.(*rpcpb.ProphetRequest).ID, "TestCodec failed")
 }
+
+func TestDecodeReleasedRequest(t *testing.T) {
+	_, sd := NewServerCodec(buf.MB)
+	ce, _ := NewClientCodec(buf.MB)
+	buf := buf.NewByteBuf(32)
+
+	assert.NoError(t, ce.Encode(&rpcpb.ProphetRequest{ID: 1, StoreID: 2}, buf))
+	completed, data, err := sd.Decode(buf)
+	assert.NoError(t, err)
+	assert.True(t, completed)
+	ReleaseRequest(data.(*rpcpb.ProphetRequest))
+
+	buf.Clear()
+	// the released request is reset before reused
+	assert.NoError(t, ce.Encode(&rpcpb.ProphetRequest{ID: 3}, buf))
+	completed, data, err = sd.Decode(buf)
+	assert.NoError(t, err)
+	assert.True(t, completed)
+	assert.Equal(t, uint64(3), data.(*rpcpb.ProphetRequest).ID)
+	assert.Equal(t, uint64(0), data.(*rpcpb.ProphetRequest).StoreID)
+}
+
+func BenchmarkDecodeShardHeartbeat(b *testing.B) {
+	_, sd := NewServerCodec(buf.MB)
+	ce, _ := NewClientCodec(buf.MB)
+	buf := buf.NewByteBuf(1024)
+	req := &rpcpb.ProphetRequest{
+		Type: rpcpb.TypeShardHeartbeatReq,
+		ShardHeartbeat: rpcpb.ShardHeartbeatReq{
+			Shard:  make([]byte, 128),
+			Leader: &metapb.Replica{ID: 1, StoreID: 1},
+		},
+	}
+	assert.NoError(b, ce.Encode(req, buf))
+	data := append([]byte(nil), buf.RawBuf()[buf.GetReaderIndex():buf.GetWriteIndex()]...)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Clear()
+		buf.Write(data)
+		_, v, err := sd.Decode(buf)
+		if err != nil {
+			b.Fatal(err)
+		}
+		ReleaseRequest(v.(*rpcpb.ProphetRequest))
+	}
+}
@@ -21,6 +21,9 @@ import (
 	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/matrixorigin/matrixcube/components/prophet/alert"
+	"github.com/matrixorigin/matrixcube/components/prophet/audit"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
 	"github.com/matrixorigin/matrixcube/components/prophet/limit"
 	"github.com/matrixorigin/matrixcube/components/prophet/metadata"
 	"github.com/matrixorigin/matrixcube/components/prophet/storage"
@@ -38,6 +41,21 @@ type Config struct {
 	RPCAddr          string            `toml:"rpc-addr"`
 	AdvertiseRPCAddr string            `toml:"rpc-advertise-addr"`
 	RPCTimeout       typeutil.Duration `toml:"rpc-timeout"`
+	// RPCMembers the rpc addresses of the prophet nodes, the client discovers the
+	// leader from them if the leader is not known from etcd yet.
+	RPCMembers []string `toml:"rpc-members"`
+	// ClientRequestDeadline the overall deadline of the requests of the prophet
+	// client including the retries and the failovers to the new leader. 0 means
+	// the requests wait until the new leader elected.
+	ClientRequestDeadline typeutil.Duration `toml:"client-request-deadline"`
+	// ShardHeartbeatFullSyncInterval the shard descriptor is omitted from the
+	// shard heartbeats if it's not changed, and prophet reconstructs it from the
+	// cache. A full heartbeat is sent after every ShardHeartbeatFullSyncInterval
+	// delta heartbeats. 0 means always send full heartbeats.
+	ShardHeartbeatFullSyncInterval int `toml:"shard-heartbeat-full-sync-interval"`
+	// ClusterSpecFile the json file of the declarative cluster spec, which is
+	// applied every time the prophet becomes the leader. Empty means disabled.
+	ClusterSpecFile string `toml:"cluster-spec-file"`
 
 	// etcd configuration
 	ProphetNode  bool            `toml:"prophet-node"`
@@ -49,14 +67,24 @@ type Config struct {
 	// and other servers can campaign the leader again.
 	// Etcd only supports seconds TTL, so here is second too.
 	LeaderLease int64 `toml:"lease" json:"lease"`
+	// PreferredLeader the prophet leader transfers the leadership to the
+	// preferred prophet node if it's healthy
+	PreferredLeader PreferredLeaderConfig `toml:"preferred-leader" json:"preferred-leader"`
 
 	Schedule      ScheduleConfig      `toml:"schedule" json:"schedule"`
 	Replication   ReplicationConfig   `toml:"replication" json:"replication"`
 	LabelProperty LabelPropertyConfig `toml:"label-property" json:"label-property"`
+	Alert         AlertConfig         `toml:"alert" json:"alert"`
+	Audit         AuditConfig         `toml:"audit" json:"audit"`
 
 	Handler                     metadata.RoleChangeHandler                                            `toml:"-" json:"-"`
 	ShardStateChangedHandler    func(res *metapb.Shard, from metapb.ShardState, to metapb.ShardState) `toml:"-" json:"-"`
 	StoreHeartbeatDataProcessor StoreHeartbeatDataProcessor                                           `toml:"-" json:"-"`
+	// AlertHandlers the go hooks called with the alerts fired by prophet
+	AlertHandlers []alert.Handler `toml:"-" json:"-"`
+	// AuditHandlers the go hooks called with the audit records of the admin
+	// operations, e.g. to ship the records to a shard group
+	AuditHandlers []audit.Handler `toml:"-" json:"-"`
 
 	// TODO(fagongzi): the following test-related configurations are moved to a separate struct
 	// Only test can change them.
@@ -188,6 +216,57 @@ type ScheduleConfig struct {
 	// MaxStoreDownTime is the max duration after which
 	// a container will be considered to be down if it hasn't reported heartbeats.
 	MaxStoreDownTime typeutil.Duration `toml:"max-container-down-time" json:"max-container-down-time"`
+	// DownStoreRepairGracePeriod is the duration to wait after a container is down
+	// before re-creating its replicas on other containers. Default is MaxStoreDownTime.
+	DownStoreRepairGracePeriod typeutil.Duration `toml:"down-container-repair-grace-period" json:"down-container-repair-grace-period"`
+	// MaxRepairPerFailureDomain is the max coexist operators re-creating the replicas
+	// of the down or offline containers in the same failure domain. The failure domain
+	// of a container is the value of its first location label, or the container itself
+	// if no location labels. 0 means no limit.
+	MaxRepairPerFailureDomain uint64 `toml:"max-repair-per-failure-domain" json:"max-repair-per-failure-domain"`
+	// MaxRepairPerStore is the max coexist operators re-creating the replicas of the
+	// down or offline containers on the same target container. 0 means no limit.
+	MaxRepairPerStore uint64 `toml:"max-repair-per-container" json:"max-repair-per-container"`
+	// ReplicaDriftGracePeriod is the duration to wait after the replica count of a
+	// shard is found deviating from the target before adding or removing replicas
+	// to repair it, so the drifts of the in-flight operators are not repaired. 0
+	// means repaired at once.
+	ReplicaDriftGracePeriod typeutil.Duration `toml:"replica-drift-grace-period" json:"replica-drift-grace-period"`
+	// MaxReplicaDriftRepair is the max coexist operators repairing the replica count
+	// drift of the shards. 0 means no limit.
+	MaxReplicaDriftRepair uint64 `toml:"max-replica-drift-repair" json:"max-replica-drift-repair"`
+	// MaintenanceWindows are the daily time windows, the balance and merge schedulers
+	// only generate operators within the windows, and the schedulers repairing the
+	// replicas are not affected. Empty means no limit.
+	MaintenanceWindows []MaintenanceWindow `toml:"maintenance-windows" json:"maintenance-windows"`
+	// DestroyedShardRetention is the duration to keep the metadata records of the
+	// destroyed shards. After that, the records are compacted into the snapshot
+	// of the destroyed shard IDs. 0 means keep the records forever.
+	DestroyedShardRetention typeutil.Duration `toml:"destroyed-shard-retention" json:"destroyed-shard-retention"`
+	// ConsistencyCheckInterval is the interval of checking the data of the replicas
+	// of each resource are consistent. 0 means the consistency check is disabled.
+	ConsistencyCheckInterval typeutil.Duration `toml:"consistency-check-interval" json:"consistency-check-interval"`
+	// BalanceReportInterval is the interval of reporting the balance of the stores
+	// of each shard group, the reports are computed by the background jobs, so
+	// the interval shorter than the background jobs is not honored. 0 means the
+	// balance report is disabled. Default: 1m
+	BalanceReportInterval typeutil.Duration `toml:"balance-report-interval" json:"balance-report-interval"`
+	// BalanceReportHistory is the max number of the balance reports kept for each
+	// shard group, the oldest reports are dropped. Default: 60
+	BalanceReportHistory uint64 `toml:"balance-report-history" json:"balance-report-history"`
+	// ShardReplayLogSize is the max number of the recent heartbeats and scheduling
+	// decisions kept for each shard, they are used to reconstruct the history of
+	// the shard after an incident. 0 means the replay log is disabled. Default: 64
+	ShardReplayLogSize uint64 `toml:"shard-replay-log-size" json:"shard-replay-log-size"`
+	// EnableShardReplayLogPersist is the option to save the shard replay logs to
+	// the storage by the background jobs, so they survive the prophet leader
+	// changes. Default: false
+	EnableShardReplayLogPersist bool `toml:"enable-shard-replay-log-persist" json:"enable-shard-replay-log-persist,string"`
+	// ShardGroupTrashRetention is the duration the dropped shard groups are kept
+	// in the trash before the shards are destroyed, the dropped groups can be
+	// undropped within the duration. It's used if the retention is not specified
+	// by the drop request. Default: 24h
+	ShardGroupTrashRetention typeutil.Duration `toml:"shard-group-trash-retention" json:"shard-group-trash-retention"`
 	// LeaderScheduleLimit is the max coexist leader schedules.
 	LeaderScheduleLimit uint64 `toml:"leader-schedule-limit" json:"leader-schedule-limit"`
 	// LeaderSchedulePolicy is the option to balance leader, there are some policies supported: ["count", "size"], default: "count"
@@ -222,6 +301,11 @@ type ScheduleConfig struct {
 	HighSpaceRatio float64 `toml:"high-space-ratio" json:"high-space-ratio"`
 	// ShardScoreFormulaVersion is used to control the formula used to calculate resource score.
 	ShardScoreFormulaVersion string `toml:"resource-score-formula-version" json:"resource-score-formula-version"`
+	// ShardScoreFunction is the function scoring the containers by the resources in
+	// the balance resource schedulers, the functions supported: ["default",
+	// "capacity-ratio", "available", "count"], the scores are divided by the
+	// resource weights of the containers. Default: default
+	ShardScoreFunction string `toml:"resource-score-function" json:"resource-score-function"`
 	// SchedulerMaxWaitingOperator is the max coexist operators for each scheduler.
 	SchedulerMaxWaitingOperator uint64 `toml:"scheduler-max-waiting-operator" json:"scheduler-max-waiting-operator"`
 
@@ -253,6 +337,23 @@ type ScheduleConfig struct {
 	// is overwritten, the value is fixed until it is deleted.
 	// Default: manual
 	StoreLimitMode string `toml:"container-limit-mode" json:"container-limit-mode"`
+
+	// StoreWeightMode can be auto or manual, when set to auto, Prophet derives
+	// the balance weights of the containers, the resource weight is the capacity
+	// in StoreWeightCapacityUnit and the leader weight is the weight of the disk
+	// class. The derived weights are multiplied by the weights set manually, so
+	// the containers with different sizes hold the resources in proportion.
+	// Default: manual
+	StoreWeightMode string `toml:"container-weight-mode" json:"container-weight-mode"`
+	// StoreWeightCapacityUnit is the capacity of a container with resource weight 1
+	// in the auto weight mode. Default: 1TB
+	StoreWeightCapacityUnit typeutil.ByteSize `toml:"container-weight-capacity-unit" json:"container-weight-capacity-unit"`
+	// DiskClassLabel is the container label key of the disk class, e.g. `hdd`, `ssd`
+	// or `nvme`. Default: disk-class
+	DiskClassLabel string `toml:"disk-class-label" json:"disk-class-label"`
+	// DiskClassWeights is the leader weight of each disk class in the auto weight
+	// mode, the disk classes not in it have weight 1.
+	DiskClassWeights map[string]float64 `toml:"disk-class-weights" json:"disk-class-weights"`
 }
 
 // SchedulerConfigs is a slice of customized scheduler configuration.
@@ -273,7 +374,7 @@ var DefaultSchedulers = SchedulerConfigs{
 	{Type: "balance-shard"},
 	{Type: "balance-leader"},
 	// TODO: disable hot
-	// {Type: "hot-resource"},
+	// {Type: "hot-shard"},
 	// {Type: "label"},
 }
 
@@ -303,9 +404,18 @@ func (c *ScheduleConfig) Clone() *ScheduleConfig {
 			containerLimit[k] = v
 		}
 	}
+	var diskClassWeights map[string]float64
+	if c.DiskClassWeights != nil {
+		diskClassWeights = make(map[string]float64, len(c.DiskClassWeights))
+		for k, v := range c.DiskClassWeights {
+			diskClassWeights[k] = v
+		}
+	}
 	cfg := *c
 	cfg.StoreLimit = containerLimit
+	cfg.DiskClassWeights = diskClassWeights
 	cfg.Schedulers = schedulers
+	cfg.MaintenanceWindows = append(c.MaintenanceWindows[:0:0], c.MaintenanceWindows...)
 	cfg.SchedulersPayload = nil
 	return &cfg
 }
@@ -353,15 +463,39 @@ func (c *ScheduleConfig) adjust(meta *configMetaData, reloading bool) error {
 	if !meta.IsDefined("leader-schedule-policy") {
 		adjustString(&c.LeaderSchedulePolicy, defaultLeaderSchedulePolicy)
 	}
+	if !meta.IsDefined("resource-score-function") {
+		adjustString(&c.ShardScoreFunction, defaultShardScoreFunction)
+	}
 	if !meta.IsDefined("container-limit-mode") {
 		adjustString(&c.StoreLimitMode, defaultStoreLimitMode)
 	}
+	if !meta.IsDefined("container-weight-mode") {
+		adjustString(&c.StoreWeightMode, defaultStoreWeightMode)
+	}
+	if !meta.IsDefined("container-weight-capacity-unit") && c.StoreWeightCapacityUnit == 0 {
+		c.StoreWeightCapacityUnit = defaultStoreWeightCapacityUnit
+	}
+	if !meta.IsDefined("disk-class-label") {
+		adjustString(&c.DiskClassLabel, defaultDiskClassLabel)
+	}
 	if !meta.IsDefined("enable-joint-consensus") {
 		c.EnableJointConsensus = defaultEnableJointConsensus
 	}
 	if !meta.IsDefined("enable-cross-table-merge") {
 		c.EnableCrossTableMerge = defaultEnableCrossTableMerge
 	}
+	if !meta.IsDefined("balance-report-interval") {
+		adjustDuration(&c.BalanceReportInterval, defaultBalanceReportInterval)
+	}
+	if !meta.IsDefined("balance-report-history") {
+		adjustUint64(&c.BalanceReportHistory, defaultBalanceReportHistory)
+	}
+	if !meta.IsDefined("shard-replay-log-size") {
+		adjustUint64(&c.ShardReplayLogSize, defaultShardReplayLogSize)
+	}
+	if !meta.IsDefined("shard-group-trash-retention") {
+		adjustDuration(&c.ShardGroupTrashRetention, defaultShardGroupTrashRetention)
+	}
 	adjustFloat64(&c.LowSpaceRatio, defaultLowSpaceRatio)
 	adjustFloat64(&c.HighSpaceRatio, defaultHighSpaceRatio)
 
@@ -400,6 +534,25 @@ func (c *ScheduleConfig) Validate() error {
 			return fmt.Errorf("create func of %v is not registered, maybe misspelled", scheduleConfig.Type)
 		}
 	}
+	for _, w := range c.MaintenanceWindows {
+		if err := w.Validate(); err != nil {
+			return err
+		}
+	}
+	if _, err := core.StringToShardScoreFunction(c.ShardScoreFunction); err != nil {
+		return err
+	}
+	if c.StoreWeightMode != "auto" && c.StoreWeightMode != "manual" {
+		return fmt.Errorf("container-weight-mode should be auto or manual, but %q", c.StoreWeightMode)
+	}
+	if c.StoreWeightMode == "auto" && c.StoreWeightCapacityUnit == 0 {
+		return errors.New("container-weight-capacity-unit should be positive")
+	}
+	for class, weight := range c.DiskClassWeights {
+		if weight <= 0 {
+			return fmt.Errorf("weight of disk class %q should be positive", class)
+		}
+	}
 	return nil
 }
 
@@ -431,6 +584,101 @@ type ReplicationConfig struct {
 
 	// Groups shard groups
 	Groups []uint64
+
+	// ShardSizeClasses the shard sizes of the shard groups, which override the
+	// split thresholds of the data storage and the merge thresholds of the
+	// schedule config. The shard groups without size class use the global values.
+	ShardSizeClasses []ShardSizeClass `toml:"shard-size-classes" json:"shard-size-classes"`
+
+	// ShardGroupPools binds the shard groups to the store pools. The replicas of
+	// a bound group are only placed on the stores of its pool, and the stores of
+	// a pool only hold the replicas of the groups bound to the pool.
+	ShardGroupPools []ShardGroupPool `toml:"shard-group-pools" json:"shard-group-pools"`
+
+	// ShardGroupStorageClasses the storage classes requested by the shard groups.
+	// The replicas of a group are only placed on the stores advertising its
+	// storage class, and the stores put the data of the group on the data path of
+	// the class.
+	ShardGroupStorageClasses []ShardGroupStorageClass `toml:"shard-group-storage-classes" json:"shard-group-storage-classes"`
+}
+
+// StorePoolLabel the store label key of the store pool. It's an exclusive label
+// key, so the stores of the pools are not selected by the placement rules which
+// not constrain the label.
+const StorePoolLabel = "$pool"
+
+// ShardGroupPool binds a shard group to the stores labeled with the pool by the
+// StorePoolLabel, e.g. confines the analytics groups to the dedicated stores.
+type ShardGroupPool struct {
+	Group uint64 `toml:"group" json:"group"`
+	Pool  string `toml:"pool" json:"pool"`
+}
+
+// GetShardGroupPool returns the store pool of the group, "" if the group is not
+// bound to any pool.
+func (c *ReplicationConfig) GetShardGroupPool(group uint64) string {
+	for _, binding := range c.ShardGroupPools {
+		if binding.Group == group {
+			return binding.Pool
+		}
+	}
+	return ""
+}
+
+// StorageClassLabelPrefix the prefix of the store label key of the storage
+// classes, a store with the data path of the class `nvme` has the label
+// `storage-class/nvme`. A store may have many storage classes, and the stores
+// with a storage class still hold the groups not requesting it.
+const StorageClassLabelPrefix = "storage-class/"
+
+// StorageClassLabel returns the store label key of the storage class
+func StorageClassLabel(class string) string {
+	return StorageClassLabelPrefix + class
+}
+
+// ShardGroupStorageClass the storage class requested by a shard group, e.g. the
+// hot groups on the nvme devices and the archive groups on the hdd devices.
+type ShardGroupStorageClass struct {
+	Group uint64 `toml:"group" json:"group"`
+	Class string `toml:"class" json:"class"`
+}
+
+// GetShardGroupStorageClass returns the storage class requested by the group, ""
+// if the group has no requirement.
+func (c *ReplicationConfig) GetShardGroupStorageClass(group uint64) string {
+	for _, request := range c.ShardGroupStorageClasses {
+		if request.Group == group {
+			return request.Class
+		}
+	}
+	return ""
+}
+
+// ShardSizeClass is the target shard size of a shard group, e.g. small shards for
+// the metadata groups and large shards for the blob groups. The zero fields use
+// the global values.
+type ShardSizeClass struct {
+	Group uint64 `toml:"group" json:"group"`
+	// ShardCapacityBytes the shard is split if its size exceeds it
+	ShardCapacityBytes typeutil.ByteSize `toml:"shard-capacity-bytes" json:"shard-capacity-bytes"`
+	// ShardSplitCheckBytes the split check is triggered if the approximate size of
+	// the shard exceeds it, 80% of the ShardCapacityBytes if not set.
+	ShardSplitCheckBytes typeutil.ByteSize `toml:"shard-split-check-bytes" json:"shard-split-check-bytes"`
+	// MaxMergeShardSize the shard is merged if its size in MB is smaller than it
+	MaxMergeShardSize uint64 `toml:"max-merge-shard-size" json:"max-merge-shard-size"`
+	// MaxMergeShardKeys the shard is merged if its keys is fewer than it
+	MaxMergeShardKeys uint64 `toml:"max-merge-shard-keys" json:"max-merge-shard-keys"`
+}
+
+// GetShardSizeClass returns the shard size class of the group, false if the group
+// has no size class.
+func (c *ReplicationConfig) GetShardSizeClass(group uint64) (ShardSizeClass, bool) {
+	for _, class := range c.ShardSizeClasses {
+		if class.Group == group {
+			return class, true
+		}
+	}
+	return ShardSizeClass{}, false
 }
 
 // Clone makes a deep copy of the config.
@@ -438,6 +686,9 @@ func (c *ReplicationConfig) Clone() *ReplicationConfig {
 	locationLabels := append(c.LocationLabels[:0:0], c.LocationLabels...)
 	cfg := *c
 	cfg.LocationLabels = locationLabels
+	cfg.ShardSizeClasses = append(c.ShardSizeClasses[:0:0], c.ShardSizeClasses...)
+	cfg.ShardGroupPools = append(c.ShardGroupPools[:0:0], c.ShardGroupPools...)
+	cfg.ShardGroupStorageClasses = append(c.ShardGroupStorageClasses[:0:0], c.ShardGroupStorageClasses...)
 	return &cfg
 }
 
@@ -457,9 +708,55 @@ func (c *ReplicationConfig) Validate() error {
 	if c.IsolationLevel != "" && !foundIsolationLevel {
 		return errors.New("isolation-level must be one of location-labels or empty")
 	}
+
+	groups := make(map[uint64]struct{}, len(c.ShardSizeClasses))
+	for _, class := range c.ShardSizeClasses {
+		if _, ok := groups[class.Group]; ok {
+			return fmt.Errorf("duplicate shard size class of group %d", class.Group)
+		}
+		groups[class.Group] = struct{}{}
+		if class.ShardCapacityBytes > 0 && class.ShardSplitCheckBytes > class.ShardCapacityBytes {
+			return fmt.Errorf("shard-split-check-bytes of group %d must not be greater than shard-capacity-bytes",
+				class.Group)
+		}
+	}
+
+	groups = make(map[uint64]struct{}, len(c.ShardGroupPools))
+	for _, binding := range c.ShardGroupPools {
+		if _, ok := groups[binding.Group]; ok {
+			return fmt.Errorf("duplicate store pool of group %d", binding.Group)
+		}
+		groups[binding.Group] = struct{}{}
+		if binding.Pool == "" {
+			return fmt.Errorf("empty store pool of group %d", binding.Group)
+		}
+		if err := ValidateLabels([]metapb.Label{{Key: StorePoolLabel, Value: binding.Pool}}); err != nil {
+			return err
+		}
+	}
+
+	groups = make(map[uint64]struct{}, len(c.ShardGroupStorageClasses))
+	for _, request := range c.ShardGroupStorageClasses {
+		if _, ok := groups[request.Group]; ok {
+			return fmt.Errorf("duplicate storage class of group %d", request.Group)
+		}
+		groups[request.Group] = struct{}{}
+		if err := ValidateStorageClass(request.Class); err != nil {
+			return fmt.Errorf("storage class of group %d: %w", request.Group, err)
+		}
+	}
 	return nil
 }
 
+// ValidateStorageClass checks the name of the storage class, it's a part of the
+// store label key.
+func ValidateStorageClass(class string) error {
+	if class == "" {
+		return errors.New("empty storage class")
+	}
+	return ValidateLabels([]metapb.Label{{Key: StorageClassLabel(class)}})
+}
+
 func (c *ReplicationConfig) adjust(meta *configMetaData) error {
 	adjustUint64(&c.MaxReplicas, defaultMaxReplicas)
 	if !meta.IsDefined("enable-placement-rules") {
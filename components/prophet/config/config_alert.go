@@ -0,0 +1,48 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/util/typeutil"
+)
+
+const (
+	defaultAlertWebhookTimeout   = time.Second * 3
+	defaultAlertRepeatInterval   = time.Minute * 30
+	defaultOperatorStuckDuration = time.Minute * 10
+)
+
+// AlertConfig the alert notification configuration. The alerts are notified to
+// the webhooks and the Config.AlertHandlers, nothing is checked if neither is
+// set.
+type AlertConfig struct {
+	// Webhooks the urls called by POST with the JSON encoded alerts
+	Webhooks []string `toml:"webhooks" json:"webhooks"`
+	// WebhookTimeout the timeout of calling a webhook
+	WebhookTimeout typeutil.Duration `toml:"webhook-timeout" json:"webhook-timeout"`
+	// RepeatInterval the alerts still detected are notified again after the
+	// interval
+	RepeatInterval typeutil.Duration `toml:"repeat-interval" json:"repeat-interval"`
+	// OperatorStuckDuration the operator running longer than the duration is
+	// considered as stuck
+	OperatorStuckDuration typeutil.Duration `toml:"operator-stuck-duration" json:"operator-stuck-duration"`
+}
+
+func (c *AlertConfig) adjust() {
+	adjustDuration(&c.WebhookTimeout, defaultAlertWebhookTimeout)
+	adjustDuration(&c.RepeatInterval, defaultAlertRepeatInterval)
+	adjustDuration(&c.OperatorStuckDuration, defaultOperatorStuckDuration)
+}
@@ -0,0 +1,22 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+// AuditConfig the audit log configuration. The admin operations are recorded
+// into the file and passed to the Config.AuditHandlers, nothing is recorded if
+// neither is set.
+type AuditConfig struct {
+	// File the append-only file of the JSON encoded audit records
+	File string `toml:"file" json:"file"`
+}
@@ -35,6 +35,17 @@ type JobProcessor interface {
 	Execute([]byte, storage.JobStorage, ShardsAware) ([]byte, error)
 }
 
+// ResumableJobProcessor the job processor saves the intermediate state of the
+// job by JobStorage.PutJobCheckpoint, and resumes the job from the latest
+// checkpoint after the job restarted on the new prophet leader instead of
+// starting the job from scratch.
+type ResumableJobProcessor interface {
+	JobProcessor
+	// Resume restarts the job from the checkpoint, it's called instead of Start
+	// if the job has any checkpoint saved
+	Resume(metapb.Job, metapb.JobCheckpoint, storage.JobStorage, ShardsAware)
+}
+
 // RegisterJobProcessor register job processor
 func (c *Config) RegisterJobProcessor(jobType metapb.JobType, processor JobProcessor) {
 	c.jobRegister.Lock()
@@ -0,0 +1,92 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/util/typeutil"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+const (
+	defaultPreferredLeaderCheckInterval = time.Second * 10
+)
+
+// PreferredLeaderConfig the preferred prophet leader configuration. The prophet
+// leader transfers the leadership to a healthy prophet node matched by the
+// preferred leader, e.g. to keep the scheduling in the zone with the best
+// connectivity. It's disabled if neither the name nor the labels is set.
+type PreferredLeaderConfig struct {
+	// Name the name of the preferred prophet node
+	Name string `toml:"name" json:"name"`
+	// Labels the labels of the preferred prophet nodes in the format of
+	// [["zone", "z1"]], all the labels must be matched by the labels of the
+	// node. The name is used if both are set.
+	Labels [][]string `toml:"labels" json:"labels"`
+	// CheckInterval the interval of checking the preferred leader by the leader
+	CheckInterval typeutil.Duration `toml:"check-interval" json:"check-interval"`
+}
+
+func (c *PreferredLeaderConfig) adjust() error {
+	for _, kv := range c.Labels {
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid preferred leader label %+v, the format is [key, value]", kv)
+		}
+	}
+	if err := ValidateLabels(c.GetLabels()); err != nil {
+		return err
+	}
+	adjustDuration(&c.CheckInterval, defaultPreferredLeaderCheckInterval)
+	return nil
+}
+
+// IsEnabled returns true if the preferred leader is set
+func (c *PreferredLeaderConfig) IsEnabled() bool {
+	return c.Name != "" || len(c.Labels) > 0
+}
+
+// GetLabels returns the preferred labels
+func (c *PreferredLeaderConfig) GetLabels() []metapb.Label {
+	var labels []metapb.Label
+	for _, kv := range c.Labels {
+		labels = append(labels, metapb.Label{Key: kv[0], Value: kv[1]})
+	}
+	return labels
+}
+
+// Match returns true if the prophet node with the name and labels is preferred
+func (c *PreferredLeaderConfig) Match(name string, labels []metapb.Label) bool {
+	if !c.IsEnabled() {
+		return false
+	}
+	if c.Name != "" {
+		return c.Name == name
+	}
+
+	for _, expect := range c.GetLabels() {
+		matched := false
+		for _, label := range labels {
+			if label.Key == expect.Key && label.Value == expect.Value {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+func TestPreferredLeaderMatch(t *testing.T) {
+	labels := []metapb.Label{{Key: "zone", Value: "z1"}, {Key: "host", Value: "h1"}}
+
+	c := PreferredLeaderConfig{}
+	assert.NoError(t, c.adjust())
+	assert.False(t, c.IsEnabled())
+	assert.False(t, c.Match("n1", labels))
+	assert.Equal(t, defaultPreferredLeaderCheckInterval, c.CheckInterval.Duration)
+
+	c = PreferredLeaderConfig{Name: "n1", Labels: [][]string{{"zone", "z2"}}}
+	assert.True(t, c.Match("n1", nil))
+	assert.False(t, c.Match("n2", labels))
+
+	c = PreferredLeaderConfig{Labels: [][]string{{"zone", "z1"}, {"host", "h1"}}}
+	assert.NoError(t, c.adjust())
+	assert.True(t, c.Match("n1", labels))
+	assert.False(t, c.Match("n1", labels[:1]))
+	assert.False(t, c.Match("n1", nil))
+
+	c = PreferredLeaderConfig{Labels: [][]string{{"zone"}}}
+	assert.Error(t, c.adjust())
+}
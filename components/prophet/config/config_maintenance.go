@@ -0,0 +1,68 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const maintenanceWindowLayout = "15:04"
+
+// MaintenanceWindow is a daily time window in the local time of the prophet
+// leader, in the format of "15:04". The window crosses midnight if the end is
+// before the start, e.g. start "22:00" and end "06:00", and it is the whole day
+// if the start equals to the end.
+type MaintenanceWindow struct {
+	Start string `toml:"start" json:"start"`
+	End   string `toml:"end" json:"end"`
+}
+
+// Validate returns an error if the window is malformed.
+func (w MaintenanceWindow) Validate() error {
+	if _, err := parseMaintenanceTime(w.Start); err != nil {
+		return err
+	}
+	if _, err := parseMaintenanceTime(w.End); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Contains returns true if the time is within the window.
+func (w MaintenanceWindow) Contains(now time.Time) bool {
+	start, err := parseMaintenanceTime(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseMaintenanceTime(w.End)
+	if err != nil {
+		return false
+	}
+
+	current := now.Hour()*60 + now.Minute()
+	if start < end {
+		return current >= start && current < end
+	}
+	return current >= start || current < end
+}
+
+// parseMaintenanceTime returns the minutes since midnight.
+func parseMaintenanceTime(value string) (int, error) {
+	t, err := time.Parse(maintenanceWindowLayout, value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid maintenance window time %q, the format is HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
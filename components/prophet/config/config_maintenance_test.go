@@ -0,0 +1,60 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenanceWindowContains(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2022, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	w := MaintenanceWindow{Start: "01:00", End: "05:30"}
+	assert.NoError(t, w.Validate())
+	assert.False(t, w.Contains(at(0, 59)))
+	assert.True(t, w.Contains(at(1, 0)))
+	assert.True(t, w.Contains(at(5, 29)))
+	assert.False(t, w.Contains(at(5, 30)))
+
+	w = MaintenanceWindow{Start: "22:00", End: "06:00"}
+	assert.True(t, w.Contains(at(23, 0)))
+	assert.True(t, w.Contains(at(2, 0)))
+	assert.False(t, w.Contains(at(12, 0)))
+
+	w = MaintenanceWindow{Start: "08:00", End: "08:00"}
+	assert.True(t, w.Contains(at(12, 0)))
+
+	assert.Error(t, MaintenanceWindow{Start: "25:00", End: "06:00"}.Validate())
+	assert.Error(t, MaintenanceWindow{Start: "01:00"}.Validate())
+}
+
+func TestIsInMaintenanceWindow(t *testing.T) {
+	opt := NewTestOptions()
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.Local)
+	assert.True(t, opt.IsInMaintenanceWindow(now))
+
+	opt.SetMaintenanceWindows([]MaintenanceWindow{{Start: "01:00", End: "02:00"}, {Start: "22:00", End: "23:00"}})
+	assert.False(t, opt.IsInMaintenanceWindow(now))
+	assert.True(t, opt.IsInMaintenanceWindow(now.Add(time.Hour*10+time.Minute*30)))
+
+	cfg := opt.GetScheduleConfig().Clone()
+	cfg.MaintenanceWindows[0].Start = "bad"
+	assert.Error(t, cfg.Validate())
+	assert.NoError(t, opt.GetScheduleConfig().Validate())
+}
@@ -44,14 +44,22 @@ const (
 	defaultLowSpaceRatio            = 0.8
 	defaultHighSpaceRatio           = 0.7
 	defaultShardScoreFormulaVersion = "v2"
+	defaultShardScoreFunction       = "default"
 	// defaultHotShardCacheHitsThreshold is the low hit number threshold of the
 	// hot resource.
 	defaultHotShardCacheHitsThreshold  = 3
 	defaultSchedulerMaxWaitingOperator = 5
 	defaultLeaderSchedulePolicy        = "count"
 	defaultStoreLimitMode              = "manual"
+	defaultStoreWeightMode             = "manual"
+	defaultStoreWeightCapacityUnit     = typeutil.ByteSize(1024 * 1024 * 1024 * 1024) // 1TB
+	defaultDiskClassLabel              = "disk-class"
 	defaultEnableJointConsensus        = false
 	defaultEnableCrossTableMerge       = true
+	defaultBalanceReportInterval       = time.Minute
+	defaultBalanceReportHistory        = 60
+	defaultShardReplayLogSize          = 64
+	defaultShardGroupTrashRetention    = 24 * time.Hour
 )
 
 var (
@@ -149,6 +157,9 @@ func (c *Config) Adjust(meta *toml.MetaData, reloading bool) error {
 	}
 
 	adjustInt64(&c.LeaderLease, defaultLeaderLease)
+	if err := c.PreferredLeader.adjust(); err != nil {
+		return err
+	}
 
 	if err := c.Schedule.adjust(configMetaData.Child("schedule"), reloading); err != nil {
 		return err
@@ -157,6 +168,8 @@ func (c *Config) Adjust(meta *toml.MetaData, reloading bool) error {
 		return err
 	}
 
+	c.Alert.adjust()
+
 	if c.TestContext == nil {
 		c.TestContext = NewTestContext()
 	}
@@ -200,6 +200,24 @@ func (o *PersistOptions) GetMaxMergeShardKeys() uint64 {
 	return o.getTTLUintOr(maxMergeShardKeysKey, o.GetScheduleConfig().MaxMergeShardKeys)
 }
 
+// GetMaxMergeShardSizeByGroup returns the max shard size to merge of the shard
+// group, the size class of the group takes precedence over the global value.
+func (o *PersistOptions) GetMaxMergeShardSizeByGroup(group uint64) uint64 {
+	if class, ok := o.GetReplicationConfig().GetShardSizeClass(group); ok && class.MaxMergeShardSize > 0 {
+		return class.MaxMergeShardSize
+	}
+	return o.GetMaxMergeShardSize()
+}
+
+// GetMaxMergeShardKeysByGroup returns the max number of keys to merge of the
+// shard group, the size class of the group takes precedence over the global value.
+func (o *PersistOptions) GetMaxMergeShardKeysByGroup(group uint64) uint64 {
+	if class, ok := o.GetReplicationConfig().GetShardSizeClass(group); ok && class.MaxMergeShardKeys > 0 {
+		return class.MaxMergeShardKeys
+	}
+	return o.GetMaxMergeShardKeys()
+}
+
 // GetSplitMergeInterval returns the interval between finishing split and starting to merge.
 func (o *PersistOptions) GetSplitMergeInterval() time.Duration {
 	return o.GetScheduleConfig().SplitMergeInterval.Duration
@@ -258,6 +276,21 @@ func (o *PersistOptions) SetAllStoresLimit(typ limit.Type, ratePerMin float64) {
 	o.SetScheduleConfig(v)
 }
 
+// SetMaintenanceWindows sets the maintenance windows of the heavy schedulers.
+func (o *PersistOptions) SetMaintenanceWindows(windows []MaintenanceWindow) {
+	v := o.GetScheduleConfig().Clone()
+	v.MaintenanceWindows = append(windows[:0:0], windows...)
+	o.SetScheduleConfig(v)
+}
+
+// SetShardScoreFunction sets the function scoring the stores in the balance
+// shard schedulers.
+func (o *PersistOptions) SetShardScoreFunction(fn string) {
+	v := o.GetScheduleConfig().Clone()
+	v.ShardScoreFunction = fn
+	o.SetScheduleConfig(v)
+}
+
 // IsOneWayMergeEnabled returns if a resource can only be merged into the next resource of it.
 func (o *PersistOptions) IsOneWayMergeEnabled() bool {
 	return o.GetScheduleConfig().EnableOneWayMerge
@@ -273,11 +306,100 @@ func (o *PersistOptions) GetPatrolShardInterval() time.Duration {
 	return o.GetScheduleConfig().PatrolShardInterval.Duration
 }
 
+// GetConsistencyCheckInterval returns the interval of checking the consistency
+// of the replicas of a resource, 0 means disabled.
+func (o *PersistOptions) GetConsistencyCheckInterval() time.Duration {
+	return o.GetScheduleConfig().ConsistencyCheckInterval.Duration
+}
+
+// GetBalanceReportInterval returns the interval of reporting the balance of the
+// stores, 0 means disabled.
+func (o *PersistOptions) GetBalanceReportInterval() time.Duration {
+	return o.GetScheduleConfig().BalanceReportInterval.Duration
+}
+
+// GetBalanceReportHistory returns the max number of the balance reports kept for
+// each shard group.
+func (o *PersistOptions) GetBalanceReportHistory() int {
+	return int(o.GetScheduleConfig().BalanceReportHistory)
+}
+
+// GetShardReplayLogSize returns the max number of the records kept in the replay
+// log of each shard, 0 means disabled.
+func (o *PersistOptions) GetShardReplayLogSize() int {
+	return int(o.GetScheduleConfig().ShardReplayLogSize)
+}
+
+// IsShardReplayLogPersistEnabled returns true if the shard replay logs are
+// saved to the storage.
+func (o *PersistOptions) IsShardReplayLogPersistEnabled() bool {
+	return o.GetScheduleConfig().EnableShardReplayLogPersist
+}
+
 // GetMaxStoreDownTime returns the max down time of a container.
 func (o *PersistOptions) GetMaxStoreDownTime() time.Duration {
 	return o.GetScheduleConfig().MaxStoreDownTime.Duration
 }
 
+// GetDownStoreRepairGracePeriod returns the duration to wait before re-creating
+// the replicas of a down container, the max down time is used if not set.
+func (o *PersistOptions) GetDownStoreRepairGracePeriod() time.Duration {
+	if v := o.GetScheduleConfig().DownStoreRepairGracePeriod.Duration; v > 0 {
+		return v
+	}
+	return o.GetMaxStoreDownTime()
+}
+
+// GetMaxRepairPerFailureDomain returns the max coexist repair operators of
+// the same failure domain.
+func (o *PersistOptions) GetMaxRepairPerFailureDomain() uint64 {
+	return o.GetScheduleConfig().MaxRepairPerFailureDomain
+}
+
+// GetMaxRepairPerStore returns the max coexist repair operators of the same
+// target container.
+func (o *PersistOptions) GetMaxRepairPerStore() uint64 {
+	return o.GetScheduleConfig().MaxRepairPerStore
+}
+
+// GetReplicaDriftGracePeriod returns the duration to wait before repairing the
+// replica count drift of a shard.
+func (o *PersistOptions) GetReplicaDriftGracePeriod() time.Duration {
+	return o.GetScheduleConfig().ReplicaDriftGracePeriod.Duration
+}
+
+// GetMaxReplicaDriftRepair returns the max coexist operators repairing the
+// replica count drift.
+func (o *PersistOptions) GetMaxReplicaDriftRepair() uint64 {
+	return o.GetScheduleConfig().MaxReplicaDriftRepair
+}
+
+// IsInMaintenanceWindow returns true if the time is within any of the maintenance
+// windows, or no maintenance windows configured.
+func (o *PersistOptions) IsInMaintenanceWindow(now time.Time) bool {
+	windows := o.GetScheduleConfig().MaintenanceWindows
+	if len(windows) == 0 {
+		return true
+	}
+	for _, w := range windows {
+		if w.Contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDestroyedShardRetention returns the retention of the destroyed shard records.
+func (o *PersistOptions) GetDestroyedShardRetention() time.Duration {
+	return o.GetScheduleConfig().DestroyedShardRetention.Duration
+}
+
+// GetShardGroupTrashRetention returns the default retention of the dropped
+// shard groups in the trash.
+func (o *PersistOptions) GetShardGroupTrashRetention() time.Duration {
+	return o.GetScheduleConfig().ShardGroupTrashRetention.Duration
+}
+
 // GetLeaderScheduleLimit returns the limit for leader schedule.
 func (o *PersistOptions) GetLeaderScheduleLimit() uint64 {
 	return o.getTTLUintOr(leaderScheduleLimitKey, o.GetScheduleConfig().LeaderScheduleLimit)
@@ -373,6 +495,29 @@ func (o *PersistOptions) GetStoreLimitMode() string {
 	return o.GetScheduleConfig().StoreLimitMode
 }
 
+// GetStoreWeightMode returns the weight mode of container.
+func (o *PersistOptions) GetStoreWeightMode() string {
+	return o.GetScheduleConfig().StoreWeightMode
+}
+
+// GetStoreWeightCapacityUnit returns the capacity of a container with resource weight 1.
+func (o *PersistOptions) GetStoreWeightCapacityUnit() uint64 {
+	return uint64(o.GetScheduleConfig().StoreWeightCapacityUnit)
+}
+
+// GetDiskClassLabel returns the container label key of the disk class.
+func (o *PersistOptions) GetDiskClassLabel() string {
+	return o.GetScheduleConfig().DiskClassLabel
+}
+
+// GetDiskClassWeight returns the leader weight of the disk class, 1 if not configured.
+func (o *PersistOptions) GetDiskClassWeight(class string) float64 {
+	if w, ok := o.GetScheduleConfig().DiskClassWeights[class]; ok {
+		return w
+	}
+	return 1
+}
+
 // GetTolerantSizeRatio gets the tolerant size ratio.
 func (o *PersistOptions) GetTolerantSizeRatio() float64 {
 	return o.GetScheduleConfig().TolerantSizeRatio
@@ -388,6 +533,12 @@ func (o *PersistOptions) GetHighSpaceRatio() float64 {
 	return o.GetScheduleConfig().HighSpaceRatio
 }
 
+// GetShardScoreFunction returns the function scoring the stores by the shards.
+func (o *PersistOptions) GetShardScoreFunction() core.ShardScoreFunction {
+	fn, _ := core.StringToShardScoreFunction(o.GetScheduleConfig().ShardScoreFunction)
+	return fn
+}
+
 // GetShardScoreFormulaVersion returns the formula version config.
 func (o *PersistOptions) GetShardScoreFormulaVersion() string {
 	return o.GetScheduleConfig().ShardScoreFormulaVersion
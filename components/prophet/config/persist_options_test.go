@@ -17,3 +17,67 @@ func TestIssue85(t *testing.T) {
 	s := storage.NewTestStorage()
 	assert.NoError(t, pc.Persist(s))
 }
+
+func TestGetMaxMergeShardByGroup(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Adjust(nil, false)
+	cfg.Replication.ShardSizeClasses = []ShardSizeClass{
+		{Group: 1, MaxMergeShardSize: 1, MaxMergeShardKeys: 10},
+		{Group: 2, MaxMergeShardSize: 100},
+	}
+
+	pc := NewPersistOptions(cfg, nil)
+	assert.Equal(t, pc.GetMaxMergeShardSize(), pc.GetMaxMergeShardSizeByGroup(0))
+	assert.Equal(t, pc.GetMaxMergeShardKeys(), pc.GetMaxMergeShardKeysByGroup(0))
+	assert.Equal(t, uint64(1), pc.GetMaxMergeShardSizeByGroup(1))
+	assert.Equal(t, uint64(10), pc.GetMaxMergeShardKeysByGroup(1))
+	assert.Equal(t, uint64(100), pc.GetMaxMergeShardSizeByGroup(2))
+	assert.Equal(t, pc.GetMaxMergeShardKeys(), pc.GetMaxMergeShardKeysByGroup(2))
+}
+
+func TestValidateShardSizeClasses(t *testing.T) {
+	c := &ReplicationConfig{}
+	c.ShardSizeClasses = []ShardSizeClass{{Group: 1}, {Group: 1}}
+	assert.Error(t, c.Validate())
+
+	c.ShardSizeClasses = []ShardSizeClass{{Group: 1, ShardCapacityBytes: 10, ShardSplitCheckBytes: 20}}
+	assert.Error(t, c.Validate())
+
+	c.ShardSizeClasses = []ShardSizeClass{{Group: 1, ShardCapacityBytes: 10, ShardSplitCheckBytes: 5}, {Group: 2}}
+	assert.NoError(t, c.Validate())
+}
+
+func TestValidateShardGroupPools(t *testing.T) {
+	c := &ReplicationConfig{}
+	c.ShardGroupPools = []ShardGroupPool{{Group: 1, Pool: "batch"}, {Group: 1, Pool: "online"}}
+	assert.Error(t, c.Validate())
+
+	c.ShardGroupPools = []ShardGroupPool{{Group: 1}}
+	assert.Error(t, c.Validate())
+
+	c.ShardGroupPools = []ShardGroupPool{{Group: 1, Pool: "batch pool"}}
+	assert.Error(t, c.Validate())
+
+	c.ShardGroupPools = []ShardGroupPool{{Group: 1, Pool: "batch"}, {Group: 2, Pool: "batch"}}
+	assert.NoError(t, c.Validate())
+	assert.Equal(t, "batch", c.GetShardGroupPool(2))
+	assert.Equal(t, "", c.GetShardGroupPool(3))
+}
+
+func TestValidateShardGroupStorageClasses(t *testing.T) {
+	c := &ReplicationConfig{}
+	c.ShardGroupStorageClasses = []ShardGroupStorageClass{{Group: 1, Class: "nvme"}, {Group: 1, Class: "hdd"}}
+	assert.Error(t, c.Validate())
+
+	c.ShardGroupStorageClasses = []ShardGroupStorageClass{{Group: 1}}
+	assert.Error(t, c.Validate())
+
+	c.ShardGroupStorageClasses = []ShardGroupStorageClass{{Group: 1, Class: "fast disk"}}
+	assert.Error(t, c.Validate())
+
+	c.ShardGroupStorageClasses = []ShardGroupStorageClass{{Group: 1, Class: "nvme"}, {Group: 2, Class: "hdd"}}
+	assert.NoError(t, c.Validate())
+	assert.Equal(t, "hdd", c.GetShardGroupStorageClass(2))
+	assert.Equal(t, "", c.GetShardGroupStorageClass(3))
+	assert.Equal(t, c.ShardGroupStorageClasses, c.Clone().ShardGroupStorageClasses)
+}
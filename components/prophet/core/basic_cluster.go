@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/RoaringBitmap/roaring/roaring64"
 	"github.com/fagongzi/util/protoc"
@@ -40,6 +41,7 @@ type BasicCluster struct {
 	DestroyingStatuses  map[uint64]*metapb.DestroyingStatus
 	ScheduleGroupRules  ScheduleGroupRuleCache
 	ScheduleGroupKeys   map[string]struct{}
+	Keyspaces           *KeyspaceCache
 }
 
 // NewBasicCluster creates a BasicCluster.
@@ -49,6 +51,7 @@ func NewBasicCluster(logger *zap.Logger) *BasicCluster {
 		DestroyingStatuses: make(map[uint64]*metapb.DestroyingStatus),
 		ScheduleGroupKeys:  make(map[string]struct{}),
 		ScheduleGroupRules: NewScheduleGroupRuleCache(),
+		Keyspaces:          NewKeyspaceCache(),
 	}
 	bc.Reset()
 	return bc
@@ -64,6 +67,7 @@ func (bc *BasicCluster) Reset() {
 	bc.DestroyedShards = roaring64.NewBitmap()
 	bc.WaitingCreateShards = make(map[uint64]metapb.Shard)
 	bc.ScheduleGroupRules.Clear()
+	bc.Keyspaces.Reset(nil)
 }
 
 // AddRemovedShards add removed shards
@@ -122,6 +126,14 @@ func (bc *BasicCluster) AlreadyRemoved(id uint64) bool {
 	return bc.DestroyedShards.Contains(id)
 }
 
+// GetDestroyedShards returns all destroyed shards
+func (bc *BasicCluster) GetDestroyedShards() *roaring64.Bitmap {
+	bc.RLock()
+	defer bc.RUnlock()
+
+	return bc.DestroyedShards.Clone()
+}
+
 // GetDestroyShards get destroyed and destroying state shards
 func (bc *BasicCluster) GetDestroyShards(bm *roaring64.Bitmap) (*roaring64.Bitmap, *roaring64.Bitmap) {
 	bc.RLock()
@@ -246,6 +258,13 @@ func (bc *BasicCluster) ResumeLeaderTransfer(containerID uint64) {
 	bc.Stores.ResumeLeaderTransfer(containerID)
 }
 
+// RejectSnapshots marks the container as rejecting the snapshots until the time.
+func (bc *BasicCluster) RejectSnapshots(containerID uint64, until time.Time) {
+	bc.Lock()
+	defer bc.Unlock()
+	bc.Stores.RejectSnapshots(containerID, until)
+}
+
 // AttachAvailableFunc attaches an available function to a specific container.
 func (bc *BasicCluster) AttachAvailableFunc(containerID uint64, limitType limit.Type, f func() bool) {
 	bc.Lock()
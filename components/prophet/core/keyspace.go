@@ -0,0 +1,178 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+const (
+	// KeyspaceSeparator the separator between the keyspace name and the keys
+	KeyspaceSeparator = "/"
+)
+
+var (
+	// ErrInvalidKeyspaceName the keyspace name is invalid
+	ErrInvalidKeyspaceName = errors.New("invalid keyspace name")
+
+	keyspaceNameRe = regexp.MustCompile(`^[0-9a-zA-Z_\-\.]{1,64}$`)
+)
+
+// ValidateKeyspaceName checks the keyspace name, the name must not contain the
+// separator, so the prefix of a keyspace is never the prefix of another one.
+func ValidateKeyspaceName(name string) error {
+	if !keyspaceNameRe.MatchString(name) {
+		return ErrInvalidKeyspaceName
+	}
+	return nil
+}
+
+// GetKeyspacePrefix returns the key prefix of the keyspace
+func GetKeyspacePrefix(name string) []byte {
+	return []byte(name + KeyspaceSeparator)
+}
+
+// GetKeyspaceRange returns the key range [start, end) of the keyspace
+func GetKeyspaceRange(keyspace metapb.Keyspace) ([]byte, []byte) {
+	end := append([]byte(nil), keyspace.Prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return keyspace.Prefix, end[:i+1]
+		}
+	}
+	return keyspace.Prefix, nil
+}
+
+// KeyspaceCache caches the keyspaces by the name, it's concurrent-safe.
+type KeyspaceCache struct {
+	sync.RWMutex
+	keyspaces map[string]metapb.Keyspace
+}
+
+// NewKeyspaceCache creates a cache for the keyspaces.
+func NewKeyspaceCache() *KeyspaceCache {
+	return &KeyspaceCache{keyspaces: make(map[string]metapb.Keyspace)}
+}
+
+// Put adds or updates the keyspace.
+func (c *KeyspaceCache) Put(keyspace metapb.Keyspace) {
+	c.Lock()
+	defer c.Unlock()
+	c.keyspaces[keyspace.Name] = keyspace
+}
+
+// Remove removes the keyspace.
+func (c *KeyspaceCache) Remove(name string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.keyspaces, name)
+}
+
+// Reset replaces all the cached keyspaces.
+func (c *KeyspaceCache) Reset(keyspaces []metapb.Keyspace) {
+	c.Lock()
+	defer c.Unlock()
+	c.keyspaces = make(map[string]metapb.Keyspace, len(keyspaces))
+	for _, keyspace := range keyspaces {
+		c.keyspaces[keyspace.Name] = keyspace
+	}
+}
+
+// Get returns the keyspace by the name.
+func (c *KeyspaceCache) Get(name string) (metapb.Keyspace, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	keyspace, ok := c.keyspaces[name]
+	return keyspace, ok
+}
+
+// Count returns the number of the keyspaces.
+func (c *KeyspaceCache) Count() int {
+	c.RLock()
+	defer c.RUnlock()
+	return len(c.keyspaces)
+}
+
+// List returns all the keyspaces ordered by the ID.
+func (c *KeyspaceCache) List() []metapb.Keyspace {
+	c.RLock()
+	defer c.RUnlock()
+	keyspaces := make([]metapb.Keyspace, 0, len(c.keyspaces))
+	for _, keyspace := range c.keyspaces {
+		keyspaces = append(keyspaces, keyspace)
+	}
+	sort.Slice(keyspaces, func(i, j int) bool { return keyspaces[i].ID < keyspaces[j].ID })
+	return keyspaces
+}
+
+// GetByRange returns the keyspace of the shard group which contains the whole
+// range [start, end), the empty end means the max key.
+func (c *KeyspaceCache) GetByRange(group uint64, start, end []byte) (metapb.Keyspace, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	for _, keyspace := range c.keyspaces {
+		if keyspace.Group != group {
+			continue
+		}
+		ksStart, ksEnd := GetKeyspaceRange(keyspace)
+		if bytes.Compare(start, ksStart) >= 0 &&
+			(len(ksEnd) == 0 || (len(end) > 0 && bytes.Compare(end, ksEnd) <= 0)) {
+			return keyspace, true
+		}
+	}
+	return metapb.Keyspace{}, false
+}
+
+// GetByShard returns the keyspace which contains the shard.
+func (c *KeyspaceCache) GetByShard(res *CachedShard) (metapb.Keyspace, bool) {
+	start, end := res.Meta.GetRange()
+	return c.GetByRange(res.Meta.GetGroup(), start, end)
+}
+
+// GetSplitKeys returns the boundaries of the keyspaces of the shard group in
+// the range (start, end), the shards must be split at the boundaries so each
+// shard is in at most one keyspace.
+func (c *KeyspaceCache) GetSplitKeys(group uint64, start, end []byte) [][]byte {
+	c.RLock()
+	defer c.RUnlock()
+	var keys [][]byte
+	for _, keyspace := range c.keyspaces {
+		if keyspace.Group != group {
+			continue
+		}
+		ksStart, ksEnd := GetKeyspaceRange(keyspace)
+		for _, key := range [][]byte{ksStart, ksEnd} {
+			if len(key) > 0 && bytes.Compare(key, start) > 0 &&
+				(len(end) == 0 || bytes.Compare(key, end) < 0) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	n := 0
+	for i := range keys {
+		if i == 0 || !bytes.Equal(keys[i], keys[n-1]) {
+			keys[n] = keys[i]
+			n++
+		}
+	}
+	return keys[:n]
+}
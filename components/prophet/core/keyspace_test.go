@@ -0,0 +1,78 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateKeyspaceName(t *testing.T) {
+	assert.NoError(t, ValidateKeyspaceName("tenant-1"))
+	assert.NoError(t, ValidateKeyspaceName("tenant_1.a"))
+	assert.Error(t, ValidateKeyspaceName(""))
+	assert.Error(t, ValidateKeyspaceName("a/b"))
+	assert.Error(t, ValidateKeyspaceName("a b"))
+}
+
+func TestGetKeyspaceRange(t *testing.T) {
+	start, end := GetKeyspaceRange(metapb.Keyspace{Prefix: GetKeyspacePrefix("a")})
+	assert.Equal(t, []byte("a/"), start)
+	assert.Equal(t, []byte("a0"), end)
+
+	start, end = GetKeyspaceRange(metapb.Keyspace{Prefix: []byte{1, 0xff}})
+	assert.Equal(t, []byte{1, 0xff}, start)
+	assert.Equal(t, []byte{2}, end)
+
+	_, end = GetKeyspaceRange(metapb.Keyspace{Prefix: []byte{0xff}})
+	assert.Empty(t, end)
+}
+
+func TestKeyspaceCache(t *testing.T) {
+	c := NewKeyspaceCache()
+	c.Put(metapb.Keyspace{ID: 2, Name: "b", Group: 1, Prefix: GetKeyspacePrefix("b")})
+	c.Put(metapb.Keyspace{ID: 1, Name: "a", Group: 1, Prefix: GetKeyspacePrefix("a")})
+	c.Put(metapb.Keyspace{ID: 3, Name: "a1", Group: 2, Prefix: GetKeyspacePrefix("a1")})
+	assert.Equal(t, 3, c.Count())
+	values := c.List()
+	assert.Equal(t, []uint64{1, 2, 3}, []uint64{values[0].ID, values[1].ID, values[2].ID})
+
+	keyspace, ok := c.GetByRange(1, []byte("a/"), []byte("a0"))
+	assert.True(t, ok)
+	assert.Equal(t, "a", keyspace.Name)
+	keyspace, ok = c.GetByRange(1, []byte("a/x"), []byte("a/y"))
+	assert.True(t, ok)
+	assert.Equal(t, "a", keyspace.Name)
+	_, ok = c.GetByRange(1, []byte("a/"), []byte("b/x"))
+	assert.False(t, ok, "across the keyspaces")
+	_, ok = c.GetByRange(1, []byte("a/"), nil)
+	assert.False(t, ok, "to the max key")
+	_, ok = c.GetByRange(2, []byte("a/"), []byte("a0"))
+	assert.False(t, ok, "in the other group")
+
+	assert.Equal(t, [][]byte{[]byte("a/"), []byte("a0"), []byte("b/"), []byte("b0")},
+		c.GetSplitKeys(1, nil, nil))
+	assert.Equal(t, [][]byte{[]byte("a0"), []byte("b/")},
+		c.GetSplitKeys(1, []byte("a/"), []byte("b0")))
+	assert.Equal(t, [][]byte{[]byte("a1/"), []byte("a10")},
+		c.GetSplitKeys(2, nil, nil))
+
+	c.Remove("a")
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	c.Reset(nil)
+	assert.Equal(t, 0, c.Count())
+}
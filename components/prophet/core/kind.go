@@ -15,6 +15,8 @@
 package core
 
 import (
+	"fmt"
+
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 )
 
@@ -75,6 +77,53 @@ func StringToSchedulePolicy(input string) SchedulePolicy {
 	}
 }
 
+// ShardScoreFunction distinguishes the functions scoring the stores by the
+// shards they hold, the balance schedulers move the shards from the stores of
+// the higher scores to the stores of the lower scores.
+type ShardScoreFunction int
+
+const (
+	// ByAmplifiedSize scores by the shard size amplified by the used space
+	ByAmplifiedSize ShardScoreFunction = iota
+	// ByCapacityRatio scores by the shard size per GB of the capacity
+	ByCapacityRatio
+	// ByAvailable scores by the shard size per GB of the available space
+	ByAvailable
+	// ByShardCount scores by the count of the shards
+	ByShardCount
+)
+
+func (f ShardScoreFunction) String() string {
+	switch f {
+	case ByAmplifiedSize:
+		return "default"
+	case ByCapacityRatio:
+		return "capacity-ratio"
+	case ByAvailable:
+		return "available"
+	case ByShardCount:
+		return "count"
+	default:
+		return "unknown"
+	}
+}
+
+// StringToShardScoreFunction creates a shard score function with string.
+func StringToShardScoreFunction(input string) (ShardScoreFunction, error) {
+	switch input {
+	case ByAmplifiedSize.String():
+		return ByAmplifiedSize, nil
+	case ByCapacityRatio.String():
+		return ByCapacityRatio, nil
+	case ByAvailable.String():
+		return ByAvailable, nil
+	case ByShardCount.String():
+		return ByShardCount, nil
+	default:
+		return ByAmplifiedSize, fmt.Errorf("invalid shard score function: %s", input)
+	}
+}
+
 // KeyType distinguishes different kinds of key types
 type KeyType int
 
@@ -99,3 +148,38 @@ func (k KeyType) String() string {
 		return "unknown"
 	}
 }
+
+// ReplicaFreshness distinguishes the data freshness of the replicas reported
+// by the shard leader.
+type ReplicaFreshness int
+
+const (
+	// UnknownFreshness indicates that the leader has not reported the replica
+	UnknownFreshness ReplicaFreshness = iota
+	// UpToDate indicates that the replica has applied all the logs applied by
+	// the leader
+	UpToDate
+	// Behind indicates that the replica is behind the leader and can catch up
+	// from the raft logs
+	Behind
+	// NeedSnapshot indicates that the replica can only catch up by a snapshot
+	NeedSnapshot
+	// Dead indicates that the leader has not heard from the replica for a long
+	// time
+	Dead
+)
+
+func (f ReplicaFreshness) String() string {
+	switch f {
+	case UpToDate:
+		return "up-to-date"
+	case Behind:
+		return "behind"
+	case NeedSnapshot:
+		return "need-snapshot"
+	case Dead:
+		return "dead"
+	default:
+		return "unknown"
+	}
+}
@@ -49,6 +49,14 @@ type CachedShard struct {
 	downReplicas    replicaStatsSlice
 	pendingReplicas replicaSlice
 	stats           metapb.ShardStats
+	// replicaProgresses the replication progress of the replicas reported by
+	// the leader
+	replicaProgresses []metapb.ReplicaProgress
+	// mergePrepared the prepare merge log is applied by all the replicas
+	mergePrepared bool
+	// buckets the read and write stats of the key ranges of the shard reported
+	// by the leader
+	buckets []metapb.ShardBucket
 }
 
 // NewCachedShard creates CachedShard with shard's meta and leader peer.
@@ -110,6 +118,10 @@ func ShardFromHeartbeat(heartbeat rpcpb.ShardHeartbeatReq, meta metapb.Shard) *C
 		pendingReplicas: heartbeat.GetPendingReplicas(),
 		stats:           heartbeat.Stats,
 		lease:           heartbeat.Lease,
+
+		replicaProgresses: heartbeat.GetReplicaProgresses(),
+		mergePrepared:     heartbeat.GetMergePrepared(),
+		buckets:           heartbeat.GetBuckets(),
 	}
 	shard.stats.ApproximateSize = shardSize
 
@@ -147,6 +159,10 @@ func (r *CachedShard) Clone(opts ...ShardCreateOption) *CachedShard {
 		downReplicas:    downReplicas,
 		pendingReplicas: pendingReplicas,
 		stats:           r.stats,
+
+		replicaProgresses: append(r.replicaProgresses[:0:0], r.replicaProgresses...),
+		mergePrepared:     r.mergePrepared,
+		buckets:           append(r.buckets[:0:0], r.buckets...),
 	}
 	res.stats.Interval = proto.Clone(r.stats.Interval).(*metapb.TimeInterval)
 
@@ -382,6 +398,75 @@ func (r *CachedShard) GetPendingPeers() []metapb.Replica {
 	return r.pendingReplicas
 }
 
+// GetReplicaProgresses returns the replication progress of the replicas
+// reported by the leader.
+func (r *CachedShard) GetReplicaProgresses() []metapb.ReplicaProgress {
+	return r.replicaProgresses
+}
+
+// GetBuckets returns the read and write stats of the buckets of the shard in
+// the last reported interval, ordered by the start key.
+func (r *CachedShard) GetBuckets() []metapb.ShardBucket {
+	return r.buckets
+}
+
+// GetHotBucket returns the bucket with the most read and written bytes, false if
+// no bucket of the shard is read or written.
+func (r *CachedShard) GetHotBucket() (metapb.ShardBucket, bool) {
+	var hot metapb.ShardBucket
+	for _, b := range r.buckets {
+		if BucketLoad(b) > BucketLoad(hot) {
+			hot = b
+		}
+	}
+	return hot, BucketLoad(hot) > 0
+}
+
+// BucketLoad returns the read and written bytes of the bucket, which is used
+// to rank the hot buckets.
+func BucketLoad(b metapb.ShardBucket) uint64 {
+	return b.ReadBytes + b.WrittenBytes
+}
+
+// IsMergePrepared returns true if the shard is merging and the prepare merge
+// log is applied by all the replicas, then the target shard can commit the merge.
+func (r *CachedShard) IsMergePrepared() bool {
+	return r.Meta.GetState() == metapb.ShardState_Merging && r.mergePrepared
+}
+
+// GetReplicaProgress returns the replication progress of the replica with
+// specified peer id.
+func (r *CachedShard) GetReplicaProgress(peerID uint64) (metapb.ReplicaProgress, bool) {
+	for _, progress := range r.replicaProgresses {
+		if progress.Replica.ID == peerID {
+			return progress, true
+		}
+	}
+	return metapb.ReplicaProgress{}, false
+}
+
+// GetReplicaFreshness returns the data freshness of the replica with specified
+// peer id. The applied index of the replica is compared with the leader's.
+func (r *CachedShard) GetReplicaFreshness(peerID uint64) ReplicaFreshness {
+	if _, ok := r.GetDownPeer(peerID); ok {
+		return Dead
+	}
+	progress, ok := r.GetReplicaProgress(peerID)
+	if !ok {
+		return UnknownFreshness
+	}
+	if progress.NeedSnapshot {
+		return NeedSnapshot
+	}
+	if r.leader != nil {
+		if leader, ok := r.GetReplicaProgress(r.leader.ID); ok &&
+			progress.AppliedIndex < leader.AppliedIndex {
+			return Behind
+		}
+	}
+	return UpToDate
+}
+
 // GetBytesRead returns the read bytes of the shard.
 func (r *CachedShard) GetBytesRead() uint64 {
 	return r.stats.ReadBytes
@@ -826,6 +911,20 @@ func SortedPeersStatsEqual(peersA, peersB []metapb.ReplicaStats) bool {
 	return true
 }
 
+// ReplicaFreshnessEqual judges whether the freshness of all the replicas in the
+// two shards are equal
+func ReplicaFreshnessEqual(resA, resB *CachedShard) bool {
+	if len(resA.Meta.GetReplicas()) != len(resB.Meta.GetReplicas()) {
+		return false
+	}
+	for _, peer := range resA.Meta.GetReplicas() {
+		if resA.GetReplicaFreshness(peer.ID) != resB.GetReplicaFreshness(peer.ID) {
+			return false
+		}
+	}
+	return true
+}
+
 // shouldRemoveFromSubTree return true when the shard leader changed, peer transferred,
 // new peer was created, learners changed, pendingReplicas changed, and so on.
 func (r *ShardsContainer) shouldRemoveFromSubTree(res *CachedShard, origin *CachedShard) bool {
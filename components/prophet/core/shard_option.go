@@ -15,6 +15,7 @@
 package core
 
 import (
+	"bytes"
 	"sort"
 
 	"github.com/matrixorigin/matrixcube/components/prophet/metadata"
@@ -50,6 +51,45 @@ func WithPendingPeers(pendingReplicas []metapb.Replica) ShardCreateOption {
 	}
 }
 
+// WithReplicaProgresses sets the replication progress of the replicas for the
+// shard.
+func WithReplicaProgresses(progresses []metapb.ReplicaProgress) ShardCreateOption {
+	return func(res *CachedShard) {
+		res.replicaProgresses = append(progresses[:0:0], progresses...)
+	}
+}
+
+// WithMergePrepared sets whether the prepare merge log is applied by all the
+// replicas of the shard.
+func WithMergePrepared(prepared bool) ShardCreateOption {
+	return func(res *CachedShard) {
+		res.mergePrepared = prepared
+	}
+}
+
+// WithBuckets sets the buckets with the read and write stats for the shard.
+func WithBuckets(buckets []metapb.ShardBucket) ShardCreateOption {
+	return func(res *CachedShard) {
+		res.buckets = append(buckets[:0:0], buckets...)
+	}
+}
+
+// SetBucketStats sets the read and write stats of the bucket starting with the
+// start key for the shard.
+func SetBucketStats(start []byte, writtenBytes, writtenKeys, readBytes, readKeys uint64) ShardCreateOption {
+	return func(res *CachedShard) {
+		for i := range res.buckets {
+			if bytes.Equal(res.buckets[i].Start, start) {
+				res.buckets[i].WrittenBytes = writtenBytes
+				res.buckets[i].WrittenKeys = writtenKeys
+				res.buckets[i].ReadBytes = readBytes
+				res.buckets[i].ReadKeys = readKeys
+				return
+			}
+		}
+	}
+}
+
 // WithLearners sets the learners for the shard.
 func WithLearners(learners []metapb.Replica) ShardCreateOption {
 	return func(res *CachedShard) {
@@ -108,6 +108,49 @@ func TestSortedEqual(t *testing.T) {
 	}
 }
 
+func TestReplicaFreshness(t *testing.T) {
+	peers := []metapb.Replica{{ID: 1, StoreID: 1}, {ID: 2, StoreID: 2}, {ID: 3, StoreID: 3}, {ID: 4, StoreID: 4}, {ID: 5, StoreID: 5}}
+	res := NewCachedShard(metapb.Shard{ID: 1, Replicas: peers}, &peers[0],
+		WithDownPeers([]metapb.ReplicaStats{{Replica: peers[4], DownSeconds: 100}}),
+		WithReplicaProgresses([]metapb.ReplicaProgress{
+			{Replica: peers[0], AppliedIndex: 10, MatchIndex: 10},
+			{Replica: peers[1], AppliedIndex: 10, MatchIndex: 10},
+			{Replica: peers[2], AppliedIndex: 8, MatchIndex: 10},
+			{Replica: peers[3], NeedSnapshot: true},
+			{Replica: peers[4], AppliedIndex: 10, MatchIndex: 10},
+		}))
+	assert.Equal(t, UpToDate, res.GetReplicaFreshness(1))
+	assert.Equal(t, UpToDate, res.GetReplicaFreshness(2))
+	assert.Equal(t, Behind, res.GetReplicaFreshness(3))
+	assert.Equal(t, NeedSnapshot, res.GetReplicaFreshness(4))
+	assert.Equal(t, Dead, res.GetReplicaFreshness(5))
+	assert.Equal(t, UnknownFreshness, res.GetReplicaFreshness(6))
+
+	progress, ok := res.GetReplicaProgress(3)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(8), progress.AppliedIndex)
+
+	clone := res.Clone()
+	assert.Equal(t, res.GetReplicaProgresses(), clone.GetReplicaProgresses())
+	assert.True(t, ReplicaFreshnessEqual(res, clone))
+
+	// the applied index changes within the same freshness are ignored
+	clone = res.Clone(WithReplicaProgresses([]metapb.ReplicaProgress{
+		{Replica: peers[0], AppliedIndex: 12, MatchIndex: 12},
+		{Replica: peers[1], AppliedIndex: 12, MatchIndex: 12},
+		{Replica: peers[2], AppliedIndex: 9, MatchIndex: 12},
+		{Replica: peers[3], NeedSnapshot: true},
+	}))
+	assert.True(t, ReplicaFreshnessEqual(res, clone))
+	clone = res.Clone(WithReplicaProgresses([]metapb.ReplicaProgress{
+		{Replica: peers[0], AppliedIndex: 12, MatchIndex: 12},
+		{Replica: peers[1], AppliedIndex: 12, MatchIndex: 12},
+		{Replica: peers[2], AppliedIndex: 12, MatchIndex: 12},
+		{Replica: peers[3], NeedSnapshot: true},
+	}))
+	assert.False(t, ReplicaFreshnessEqual(res, clone))
+}
+
 func TestShardMap(t *testing.T) {
 	var empty *shardMap
 	assert.Equal(t, 0, empty.Len(), "TestShardMap failed")
@@ -280,6 +323,44 @@ func TestShouldRemoveFromSubTree(t *testing.T) {
 	assert.True(t, resources.shouldRemoveFromSubTree(res, origin))
 }
 
+func TestIsMergePrepared(t *testing.T) {
+	peer := metapb.Replica{StoreID: 1, ID: 1}
+	res := NewCachedShard(metapb.Shard{ID: 1, Replicas: []metapb.Replica{peer}}, &peer,
+		WithMergePrepared(true))
+	assert.False(t, res.IsMergePrepared())
+
+	res = res.Clone(WithState(metapb.ShardState_Merging))
+	assert.True(t, res.IsMergePrepared())
+
+	res = res.Clone(WithMergePrepared(false))
+	assert.False(t, res.IsMergePrepared())
+}
+
+func TestShardBuckets(t *testing.T) {
+	peer := metapb.Replica{StoreID: 1, ID: 1}
+	res := NewCachedShard(metapb.Shard{ID: 1, Replicas: []metapb.Replica{peer}}, &peer)
+	_, ok := res.GetHotBucket()
+	assert.False(t, ok)
+
+	buckets := []metapb.ShardBucket{
+		{ShardID: 1, End: []byte("b")},
+		{ShardID: 1, Start: []byte("b"), End: []byte("c")},
+		{ShardID: 1, Start: []byte("c")},
+	}
+	res = res.Clone(WithBuckets(buckets),
+		SetBucketStats([]byte("b"), 10, 1, 100, 2),
+		SetBucketStats([]byte("c"), 50, 5, 0, 0))
+	assert.Equal(t, 3, len(res.GetBuckets()))
+	assert.Equal(t, uint64(0), buckets[1].ReadBytes, "the buckets are copied")
+	hot, ok := res.GetHotBucket()
+	assert.True(t, ok)
+	assert.Equal(t, []byte("b"), hot.Start)
+	assert.Equal(t, uint64(110), BucketLoad(hot))
+
+	res = res.Clone()
+	assert.Equal(t, uint64(2), res.GetBuckets()[1].ReadKeys)
+}
+
 func checkShardMap(t *testing.T, msg string, rm *shardMap, ids ...uint64) {
 	// Check Get.
 	for _, id := range ids {
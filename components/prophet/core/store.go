@@ -50,19 +50,24 @@ type CachedStore struct {
 	lastPersistTime     time.Time
 	leaderWeight        float64
 	shardWeight         float64
+	derivedLeaderWeight float64 // derived from the disk class, multiplied to leaderWeight
+	derivedShardWeight  float64 // derived from the capacity, multiplied to shardWeight
 	available           map[limit.Type]func() bool
+	rejectSnapUntil     time.Time // not allow to be used as target of new replicas until then
 }
 
 // NewCachedStore creates CachedStore with metadata.
 func NewCachedStore(meta metapb.Store, opts ...StoreCreateOption) *CachedStore {
 	store := &CachedStore{
-		Meta:              meta,
-		storeStats:        newStoreStats(),
-		shardInfo:         make(map[string]counterAndSize),
-		leaderInfo:        make(map[string]counterAndSize),
-		pendingPeerCounts: make(map[string]int),
-		leaderWeight:      1.0,
-		shardWeight:       1.0,
+		Meta:                meta,
+		storeStats:          newStoreStats(),
+		shardInfo:           make(map[string]counterAndSize),
+		leaderInfo:          make(map[string]counterAndSize),
+		pendingPeerCounts:   make(map[string]int),
+		leaderWeight:        1.0,
+		shardWeight:         1.0,
+		derivedLeaderWeight: 1.0,
+		derivedShardWeight:  1.0,
 	}
 	for _, opt := range opts {
 		opt(store)
@@ -82,7 +87,10 @@ func (cr *CachedStore) Clone(opts ...StoreCreateOption) *CachedStore {
 		lastPersistTime:     cr.lastPersistTime,
 		leaderWeight:        cr.leaderWeight,
 		shardWeight:         cr.shardWeight,
+		derivedLeaderWeight: cr.derivedLeaderWeight,
+		derivedShardWeight:  cr.derivedShardWeight,
 		available:           cr.available,
+		rejectSnapUntil:     cr.rejectSnapUntil,
 	}
 
 	for k, v := range cr.shardInfo {
@@ -113,7 +121,10 @@ func (cr *CachedStore) ShallowClone(opts ...StoreCreateOption) *CachedStore {
 		lastPersistTime:     cr.lastPersistTime,
 		leaderWeight:        cr.leaderWeight,
 		shardWeight:         cr.shardWeight,
+		derivedLeaderWeight: cr.derivedLeaderWeight,
+		derivedShardWeight:  cr.derivedShardWeight,
 		available:           cr.available,
+		rejectSnapUntil:     cr.rejectSnapUntil,
 	}
 
 	for k, v := range cr.shardInfo {
@@ -138,6 +149,12 @@ func (cr *CachedStore) AllowLeaderTransfer() bool {
 	return !cr.pauseLeaderTransfer
 }
 
+// IsRejectingSnapshots returns true if the store rejected a snapshot for the
+// insufficient space recently.
+func (cr *CachedStore) IsRejectingSnapshots() bool {
+	return time.Now().Before(cr.rejectSnapUntil)
+}
+
 // IsAvailable returns if the store bucket of limitation is available
 func (cr *CachedStore) IsAvailable(limitType limit.Type) bool {
 	if cr.available != nil && cr.available[limitType] != nil {
@@ -204,6 +221,38 @@ func (cr *CachedStore) GetTotalShardCount() int {
 	return n
 }
 
+// ReachReplicaCountLimit returns true if the replica count of the store reaches
+// the max replica count advertised by the store.
+func (cr *CachedStore) ReachReplicaCountLimit() bool {
+	max := cr.GetMaxReplicaCount()
+	return max > 0 && uint64(cr.GetTotalShardCount()) >= max
+}
+
+// ReachLeaderCountLimit returns true if the leader count of the store reaches
+// the max leader count advertised by the store.
+func (cr *CachedStore) ReachLeaderCountLimit() bool {
+	max := cr.GetMaxLeaderCount()
+	return max > 0 && uint64(cr.GetTotalLeaderCount()) >= max
+}
+
+// GetSnapshotLimit returns the max snapshot count of the store, which is the
+// max snapshot count advertised by the store if it's less than the given max.
+func (cr *CachedStore) GetSnapshotLimit(max uint64) uint64 {
+	if advertised := cr.GetMaxSnapshotCount(); advertised > 0 && advertised < max {
+		return advertised
+	}
+	return max
+}
+
+// ReachSnapshotLimit returns true if the sending, receiving or applying
+// snapshot count of the store reaches the snapshot limit.
+func (cr *CachedStore) ReachSnapshotLimit(max uint64) bool {
+	limit := cr.GetSnapshotLimit(max)
+	return limit > 0 && (cr.GetSendingSnapCount() >= limit ||
+		cr.GetReceivingSnapCount() >= limit ||
+		cr.GetApplyingSnapCount() >= limit)
+}
+
 // GetGroupKeys returns the Group Key.
 func (cr *CachedStore) GetGroupKeys() string {
 	var v bytes.Buffer
@@ -252,14 +301,16 @@ func (cr *CachedStore) GetPendingPeerCount() int {
 	return cnt
 }
 
-// GetLeaderWeight returns the leader weight of the store.
+// GetLeaderWeight returns the leader weight of the store, it's the weight set
+// manually multiplied by the derived weight.
 func (cr *CachedStore) GetLeaderWeight() float64 {
-	return cr.leaderWeight
+	return cr.leaderWeight * cr.derivedLeaderWeight
 }
 
-// GetShardWeight returns the Shard weight of the store.
+// GetShardWeight returns the Shard weight of the store, it's the weight set
+// manually multiplied by the derived weight.
 func (cr *CachedStore) GetShardWeight() float64 {
-	return cr.shardWeight
+	return cr.shardWeight * cr.derivedShardWeight
 }
 
 // GetLastHeartbeatTS returns the last heartbeat timestamp of the store.
@@ -316,6 +367,33 @@ func (cr *CachedStore) ShardScore(groupKey string, highSpaceRatio, lowSpaceRatio
 	return score / math.Max(cr.GetShardWeight(), minWeight)
 }
 
+// ShardScoreBy returns the store's shard score calculated by the function, the
+// delta is the shard size in MB added to the store.
+func (cr *CachedStore) ShardScoreBy(fn ShardScoreFunction, groupKey string, highSpaceRatio, lowSpaceRatio float64, delta int64, deviation int) float64 {
+	R := float64(cr.GetShardSize(groupKey) + delta)
+	var score float64
+	switch fn {
+	case ByCapacityRatio:
+		score = R
+		if C := float64(cr.GetCapacity()) / gb; C >= 1 {
+			score = R / C
+		}
+	case ByAvailable:
+		A := float64(float64(cr.GetAvgAvailable())-float64(deviation)*float64(cr.GetAvailableDeviation()))/gb -
+			float64(delta)/1024
+		score = R / math.Max(A, 1)
+	case ByShardCount:
+		score = float64(cr.GetShardCount(groupKey))
+		// the delta is converted to the count by the average shard size
+		if size := cr.GetShardSize(groupKey); size > 0 && score > 0 {
+			score += float64(delta) * score / float64(size)
+		}
+	default:
+		return cr.ShardScore(groupKey, highSpaceRatio, lowSpaceRatio, delta, deviation)
+	}
+	return score / math.Max(cr.GetShardWeight(), minWeight)
+}
+
 // StorageSize returns store's used storage size reported from your storage.
 func (cr *CachedStore) StorageSize() uint64 {
 	return cr.GetUsedSize()
@@ -520,6 +598,13 @@ func (s *StoresContainer) ResumeLeaderTransfer(storeID uint64) {
 	s.stores[storeID] = store.Clone(ResumeLeaderTransfer())
 }
 
+// RejectSnapshots marks the store as rejecting the snapshots until the time.
+func (s *StoresContainer) RejectSnapshots(storeID uint64, until time.Time) {
+	if store, ok := s.stores[storeID]; ok {
+		s.stores[storeID] = store.Clone(RejectSnapshotsUntil(until))
+	}
+}
+
 // AttachAvailableFunc attaches f to a specific store.
 func (s *StoresContainer) AttachAvailableFunc(storeID uint64, limitType limit.Type, f func() bool) {
 	if store, ok := s.stores[storeID]; ok {
@@ -59,6 +59,20 @@ func SetStoreDeployPath(deployPath string) StoreCreateOption {
 	}
 }
 
+// SetStoreEpoch sets the epoch for the cachedStore.
+func SetStoreEpoch(epoch uint64) StoreCreateOption {
+	return func(cachedStore *CachedStore) {
+		cachedStore.Meta.SetEpoch(epoch)
+	}
+}
+
+// SetStoreSnapshotFormat sets the snapshot format advertised by the cachedStore.
+func SetStoreSnapshotFormat(format uint64) StoreCreateOption {
+	return func(cachedStore *CachedStore) {
+		cachedStore.Meta.SetSnapshotFormat(format)
+	}
+}
+
 // OfflineStore offline a cachedStore
 func OfflineStore(physicallyDestroyed bool) StoreCreateOption {
 	return func(cachedStore *CachedStore) {
@@ -97,6 +111,14 @@ func ResumeLeaderTransfer() StoreCreateOption {
 	}
 }
 
+// RejectSnapshotsUntil prevents the cachedStore from been selected as the
+// target of the new replicas until the time.
+func RejectSnapshotsUntil(until time.Time) StoreCreateOption {
+	return func(cachedStore *CachedStore) {
+		cachedStore.rejectSnapUntil = until
+	}
+}
+
 // SetLeaderCount sets the leader count for the cachedStore.
 func SetLeaderCount(groupKey string, leaderCount int) StoreCreateOption {
 	return func(cachedStore *CachedStore) {
@@ -154,6 +176,15 @@ func SetShardWeight(resourceWeight float64) StoreCreateOption {
 	}
 }
 
+// SetDerivedWeight sets the leader and Shard weight derived from the disk class
+// and the capacity for the cachedStore.
+func SetDerivedWeight(leaderWeight, shardWeight float64) StoreCreateOption {
+	return func(cachedStore *CachedStore) {
+		cachedStore.derivedLeaderWeight = leaderWeight
+		cachedStore.derivedShardWeight = shardWeight
+	}
+}
+
 // SetLastHeartbeatTS sets the time of last heartbeat for the cachedStore.
 func SetLastHeartbeatTS(lastHeartbeatTS time.Time) StoreCreateOption {
 	return func(cachedStore *CachedStore) {
@@ -151,6 +151,38 @@ func (ss *storeStats) GetApplyingSnapCount() uint64 {
 	return ss.rawStats.GetApplyingSnapCount()
 }
 
+// GetMaxSnapshotCount returns the max snapshot count the store sends or
+// receives at the same time, 0 means no limit.
+func (ss *storeStats) GetMaxSnapshotCount() uint64 {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.rawStats.GetMaxSnapshotCount()
+}
+
+// GetMaxReplicaCount returns the max replica count the store accepts, 0 means
+// no limit.
+func (ss *storeStats) GetMaxReplicaCount() uint64 {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.rawStats.GetMaxReplicaCount()
+}
+
+// GetMaxLeaderCount returns the max leader count the store accepts, 0 means
+// no limit.
+func (ss *storeStats) GetMaxLeaderCount() uint64 {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.rawStats.GetMaxLeaderCount()
+}
+
+// GetReplicaReadStats returns the read load of the replicas in the store during
+// this period.
+func (ss *storeStats) GetReplicaReadStats() []metapb.ReplicaReadStats {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.rawStats.GetReplicaReadStats()
+}
+
 // GetAvgAvailable returns available size after the spike changes has been smoothed.
 func (ss *storeStats) GetAvgAvailable() uint64 {
 	ss.mu.RLock()
@@ -106,6 +106,69 @@ func TestShardScore(t *testing.T) {
 	assert.False(t, math.IsNaN(score))
 }
 
+func TestShardScoreBy(t *testing.T) {
+	newStore := func(id uint64, capacityGB, availableGB uint64, shardCount int, shardSize int64) *CachedStore {
+		return NewCachedStore(
+			metapb.Store{ID: id},
+			SetStoreStats(&metapb.StoreStats{Capacity: capacityGB * gb, Available: availableGB * gb}),
+			SetShardCount("", shardCount),
+			SetShardSize("", shardSize),
+		)
+	}
+
+	// s2 is twice larger with twice shards, s3 has the same shards of s1 with
+	// less available space
+	s1 := newStore(1, 100, 80, 10, 1000)
+	s2 := newStore(2, 200, 160, 20, 2000)
+	s3 := newStore(3, 100, 40, 10, 1000)
+
+	score := func(s *CachedStore, fn ShardScoreFunction, delta int64) float64 {
+		return s.ShardScoreBy(fn, "", 0.7, 0.8, delta, 0)
+	}
+	assert.Equal(t, s1.ShardScore("", 0.7, 0.8, 0, 0), score(s1, ByAmplifiedSize, 0))
+	assert.Equal(t, score(s1, ByCapacityRatio, 0), score(s2, ByCapacityRatio, 0))
+	assert.True(t, score(s1, ByCapacityRatio, 100) > score(s1, ByCapacityRatio, 0))
+	assert.True(t, score(s3, ByAvailable, 0) > score(s1, ByAvailable, 0))
+	assert.True(t, score(s1, ByAvailable, 100) > score(s1, ByAvailable, 0))
+	assert.Equal(t, 10.0, score(s1, ByShardCount, 0))
+	assert.Equal(t, 11.0, score(s1, ByShardCount, 100), "converted by the average shard size")
+
+	// the scores are divided by the shard weight
+	s2 = s2.Clone(SetShardWeight(2))
+	assert.Equal(t, 10.0, score(s2, ByShardCount, 0))
+
+	fn, err := StringToShardScoreFunction("capacity-ratio")
+	assert.NoError(t, err)
+	assert.Equal(t, ByCapacityRatio, fn)
+	_, err = StringToShardScoreFunction("unknown")
+	assert.Error(t, err)
+}
+
+func TestDerivedWeight(t *testing.T) {
+	const tb = uint64(1 << 40)
+	newStore := func(id, capacity uint64, shardSize int64) *CachedStore {
+		return NewCachedStore(
+			metapb.Store{ID: id},
+			SetStoreStats(&metapb.StoreStats{Capacity: capacity, Available: capacity}),
+			SetShardSize("", shardSize),
+			SetDerivedWeight(2, float64(capacity/tb)),
+		)
+	}
+
+	s1 := newStore(1, 2*tb, 200)
+	s2 := newStore(2, 8*tb, 800)
+	assert.Equal(t, 2.0, s1.GetShardWeight())
+	assert.Equal(t, 8.0, s2.GetShardWeight())
+	assert.Equal(t, 2.0, s1.GetLeaderWeight())
+	assert.Equal(t, s1.ShardScore("", 0.7, 0.8, 0, 0), s2.ShardScore("", 0.7, 0.8, 0, 0))
+
+	// the manual weight is multiplied by the derived weight
+	s1 = s1.Clone(SetShardWeight(0.5), SetLeaderWeight(3))
+	assert.Equal(t, 1.0, s1.GetShardWeight())
+	assert.Equal(t, 6.0, s1.GetLeaderWeight())
+	assert.Equal(t, 1.0, s1.Clone(SetDerivedWeight(1, 2)).GetShardWeight())
+}
+
 func TestLowSpaceRatio(t *testing.T) {
 	container := NewTestStoreInfoWithLabel(1, 20, nil)
 	container.rawStats.Capacity = initialMinSpace << 4
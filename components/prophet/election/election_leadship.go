@@ -100,7 +100,9 @@ func (ls *Leadership) setLease(lease *LeaderLease) {
 	ls.lease.Store(lease)
 }
 
-// ChangeLeaderTo change leader to new leader
+// ChangeLeaderTo change leader to new leader. The new leader is recorded as the
+// expect leader for a lease, and the current leader resigns, so only the new
+// leader can campaign until the lease expired.
 func (ls *Leadership) ChangeLeaderTo(newLeader string) error {
 	err := ls.addExpectLeader(newLeader)
 	if err != nil {
@@ -468,7 +470,7 @@ func (ls *Leadership) addExpectLeader(newLeader string) error {
 	}
 
 	resp, err := util.LeaderTxn(ls.elector.client, ls.leaderKey, ls.nodeValue).
-		Then(clientv3.OpPut(ls.leaderKey,
+		Then(clientv3.OpPut(getPurposeExpectPath(ls.elector.options.leaderPath, ls.purpose),
 			string(newLeader),
 			clientv3.WithLease(leaseResp.ID))).
 		Commit()
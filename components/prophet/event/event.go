@@ -29,16 +29,25 @@ var (
 	ShardStatsEvent uint32 = 1 << 4
 	// StoreStatsEvent store stats
 	StoreStatsEvent uint32 = 1 << 5
+	// ReadHintEvent shard follower read hint
+	ReadHintEvent uint32 = 1 << 6
+	// ShardLeaderEvent shard leader changed
+	ShardLeaderEvent uint32 = 1 << 7
+	// StoreStateEvent store down or up
+	StoreStateEvent uint32 = 1 << 8
 	// AllEvent all event
 	AllEvent uint32 = 0xffffffff
 
 	names = map[uint32]string{
-		InitEvent:       "init",
-		ShardEvent:      "shard",
-		ShardStatsEvent: "shard-stats",
-		StoreEvent:      "store",
-		StoreStatsEvent: "store-stats",
-		AllEvent:        "all",
+		InitEvent:        "init",
+		ShardEvent:       "shard",
+		ShardStatsEvent:  "shard-stats",
+		StoreEvent:       "store",
+		StoreStatsEvent:  "store-stats",
+		ReadHintEvent:    "read-hint",
+		ShardLeaderEvent: "shard-leader",
+		StoreStateEvent:  "store-state",
+		AllEvent:         "all",
 	}
 )
 
@@ -47,6 +56,17 @@ func TypeName(value uint32) string {
 	return names[value]
 }
 
+// Resumable returns true if the event changes the topology of the cluster, it's
+// kept by the prophet leader with a revision and replayed to the reconnecting
+// watchers. The stats events are not kept since they are reported again soon.
+func Resumable(event uint32) bool {
+	switch event {
+	case ShardEvent, StoreEvent, ShardLeaderEvent, StoreStateEvent:
+		return true
+	}
+	return false
+}
+
 // Snapshot cache snapshot
 type Snapshot struct {
 	Shards            []metapb.Shard
@@ -127,6 +147,14 @@ func NewStoreStatsEvent(stats *metapb.StoreStats) rpcpb.EventNotify {
 	}
 }
 
+// NewReadHintEvent create shard read hint event
+func NewReadHintEvent(hint *metapb.ShardReadHint) rpcpb.EventNotify {
+	return rpcpb.EventNotify{
+		Type:          ReadHintEvent,
+		ReadHintEvent: hint,
+	}
+}
+
 // NewStoreEvent create store event
 func NewStoreEvent(target metapb.Store) rpcpb.EventNotify {
 	value, err := target.Marshal()
@@ -141,3 +169,26 @@ func NewStoreEvent(target metapb.Store) rpcpb.EventNotify {
 		},
 	}
 }
+
+// NewShardLeaderEvent create shard leader changed event
+func NewShardLeaderEvent(shardID uint64, leader metapb.Replica) rpcpb.EventNotify {
+	return rpcpb.EventNotify{
+		Type: ShardLeaderEvent,
+		ShardLeaderEvent: &rpcpb.ShardLeaderEventData{
+			ShardID:         shardID,
+			LeaderReplicaID: leader.ID,
+			LeaderStoreID:   leader.StoreID,
+		},
+	}
+}
+
+// NewStoreStateEvent create store down or up event
+func NewStoreStateEvent(storeID uint64, up bool) rpcpb.EventNotify {
+	return rpcpb.EventNotify{
+		Type: StoreStateEvent,
+		StoreStateEvent: &rpcpb.StoreStateEventData{
+			StoreID: storeID,
+			Up:      up,
+		},
+	}
+}
@@ -16,8 +16,10 @@ package prophet
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/fagongzi/goetty"
 	"github.com/matrixorigin/matrixcube/components/log"
@@ -46,6 +48,12 @@ func (wt *watcherSession) notify(evt rpcpb.EventNotify) error {
 	return nil
 }
 
+const (
+	// defaultEventHistoryLimit the max number of the recent topology events kept
+	// to be replayed to the reconnecting watchers
+	defaultEventHistoryLimit = 4096
+)
+
 type eventNotifier struct {
 	sync.Mutex
 
@@ -53,26 +61,73 @@ type eventNotifier struct {
 	watchers map[uint64]*watcherSession
 	cluster  *cluster.RaftCluster
 	stopper  *stop.Stopper
+
+	// baseRevision the revision of the notifier started, the revisions start
+	// from the unix nanos of the start time, so the revisions of the event
+	// notifiers of the different prophet leaders are not overlapped, and the
+	// watcher reconnected to the new leader reloads all the topology.
+	baseRevision uint64
+	revision     uint64
+	// history the recent topology events ordered by the revision
+	history      []rpcpb.EventNotify
+	historyLimit int
 }
 
 func newWatcherNotifier(cluster *cluster.RaftCluster, logger *zap.Logger) *eventNotifier {
 	wn := &eventNotifier{
-		logger:   log.Adjust(logger).Named("watch-notify"),
-		cluster:  cluster,
-		watchers: make(map[uint64]*watcherSession),
+		logger:       log.Adjust(logger).Named("watch-notify"),
+		cluster:      cluster,
+		watchers:     make(map[uint64]*watcherSession),
+		baseRevision: uint64(time.Now().UnixNano()),
+		historyLimit: defaultEventHistoryLimit,
 	}
+	wn.revision = wn.baseRevision
 	wn.stopper = stop.NewStopper("event-notifier", stop.WithLogger(wn.logger))
 	return wn
 }
 
+// handleCreateWatcher adds the watcher and writes the response. The watcher
+// reconnected with the revision of the last received event gets the events
+// after it replayed if they are still kept, otherwise it gets the init event
+// with the snapshot of the cluster to reload the topology.
 func (wn *eventNotifier) handleCreateWatcher(req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse, session goetty.IOSession) error {
 	if wn != nil {
-		wn.logger.Info("watcher added",
-			zap.String("address", session.RemoteAddr()))
-
+		flag := req.CreateWatcher.Flag
 		wn.cluster.RLock()
 		defer wn.cluster.RUnlock()
-		if event.MatchEvent(event.InitEvent, req.CreateWatcher.Flag) {
+
+		wn.Lock()
+		defer wn.Unlock()
+		if wn.watchers == nil {
+			return fmt.Errorf("watcher notifier stopped")
+		}
+
+		wt := &watcherSession{
+			flag:    flag,
+			session: session,
+		}
+		if events, ok := wn.eventsAfterLocked(req.CreateWatcher.Revision); ok {
+			wn.logger.Info("watcher resumed",
+				zap.String("address", session.RemoteAddr()),
+				zap.Uint64("revision", req.CreateWatcher.Revision),
+				zap.Int("replayed", len(events)))
+
+			resp.Event.Revision = req.CreateWatcher.Revision
+			if err := session.WriteAndFlush(resp); err != nil {
+				return err
+			}
+			for _, evt := range events {
+				if err := wt.notify(evt); err != nil {
+					return err
+				}
+			}
+			wn.watchers[session.ID()] = wt
+			return nil
+		}
+
+		wn.logger.Info("watcher added",
+			zap.String("address", session.RemoteAddr()))
+		if event.MatchEvent(event.InitEvent, flag) {
 			snap := event.Snapshot{
 				LeaderReplicasIDs: make(map[uint64]uint64),
 				Leases:            make(map[uint64]*metapb.EpochLease),
@@ -95,25 +150,31 @@ func (wn *eventNotifier) handleCreateWatcher(req *rpcpb.ProphetRequest, resp *rp
 			resp.Event.InitEvent = rsp
 		}
 
-		return wn.addWatcher(req.CreateWatcher.Flag, session)
+		resp.Event.Revision = wn.revision
+		if err := session.WriteAndFlush(resp); err != nil {
+			return err
+		}
+		wn.watchers[session.ID()] = wt
+		return nil
 	}
 
 	return nil
 }
 
-func (wn *eventNotifier) addWatcher(flag uint32, session goetty.IOSession) error {
-	wn.Lock()
-	defer wn.Unlock()
-
-	if wn.watchers == nil {
-		return fmt.Errorf("watcher notifier stopped")
+// eventsAfterLocked returns the kept events after the revision, false if some
+// events after the revision are not kept or the revision is not notified by
+// this notifier.
+func (wn *eventNotifier) eventsAfterLocked(revision uint64) ([]rpcpb.EventNotify, bool) {
+	if revision < wn.baseRevision || revision > wn.revision {
+		return nil, false
 	}
-
-	wn.watchers[session.ID()] = &watcherSession{
-		flag:    flag,
-		session: session,
+	if len(wn.history) > 0 && revision+1 < wn.history[0].Revision {
+		return nil, false
 	}
-	return nil
+	i := sort.Search(len(wn.history), func(i int) bool {
+		return wn.history[i].Revision > revision
+	})
+	return wn.history[i:], true
 }
 
 func (wn *eventNotifier) doClearWatcherLocked(w *watcherSession) {
@@ -127,6 +188,16 @@ func (wn *eventNotifier) doNotify(evt rpcpb.EventNotify) {
 	wn.Lock()
 	defer wn.Unlock()
 
+	if event.Resumable(evt.Type) {
+		wn.revision++
+		evt.Revision = wn.revision
+		if len(wn.history) >= wn.historyLimit {
+			n := copy(wn.history, wn.history[len(wn.history)-wn.historyLimit+1:])
+			wn.history = wn.history[:n]
+		}
+		wn.history = append(wn.history, evt)
+	}
+
 	for _, wt := range wn.watchers {
 		err := wt.notify(evt)
 		if err != nil {
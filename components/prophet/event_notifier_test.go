@@ -0,0 +1,65 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prophet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/event"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+func TestEventNotifierHistory(t *testing.T) {
+	wn := newWatcherNotifier(nil, nil)
+	wn.historyLimit = 3
+	base := wn.revision
+
+	events, ok := wn.eventsAfterLocked(base)
+	assert.True(t, ok)
+	assert.Empty(t, events)
+
+	wn.doNotify(event.NewStoreStateEvent(1, false))
+	wn.doNotify(event.NewStoreStatsEvent(&metapb.StoreStats{StoreID: 1}))
+	wn.doNotify(event.NewStoreStateEvent(1, true))
+	assert.Equal(t, base+2, wn.revision, "the stats events are not kept")
+
+	events, ok = wn.eventsAfterLocked(base)
+	assert.True(t, ok)
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, base+1, events[0].Revision)
+	assert.Equal(t, base+2, events[1].Revision)
+	assert.True(t, events[1].StoreStateEvent.Up)
+
+	events, ok = wn.eventsAfterLocked(base + 2)
+	assert.True(t, ok)
+	assert.Empty(t, events)
+
+	for i := 0; i < 3; i++ {
+		wn.doNotify(event.NewStoreStateEvent(2, i%2 == 0))
+	}
+	assert.Equal(t, 3, len(wn.history))
+	assert.Equal(t, base+3, wn.history[0].Revision)
+	_, ok = wn.eventsAfterLocked(base + 1)
+	assert.False(t, ok, "the event after the revision is not kept")
+	events, ok = wn.eventsAfterLocked(base + 2)
+	assert.True(t, ok)
+	assert.Equal(t, 3, len(events))
+
+	_, ok = wn.eventsAfterLocked(base - 1)
+	assert.False(t, ok, "notified by another notifier")
+	_, ok = wn.eventsAfterLocked(base + 6)
+	assert.False(t, ok, "notified by another notifier")
+}
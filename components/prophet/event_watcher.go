@@ -39,6 +39,9 @@ type watcher struct {
 	client *asyncClient
 	eventC chan rpcpb.EventNotify
 	conn   goetty.IOSession
+	// revision the revision of the last received topology event, sent to the
+	// prophet leader after reconnected to replay the missed events
+	revision uint64
 }
 
 func newWatcher(flag uint32, client *asyncClient, logger *zap.Logger) EventWatcher {
@@ -110,7 +113,8 @@ func (w *watcher) resetConn() error {
 	return w.conn.WriteAndFlush(&rpcpb.ProphetRequest{
 		Type: rpcpb.TypeCreateWatcherReq,
 		CreateWatcher: rpcpb.CreateWatcherReq{
-			Flag: w.flag,
+			Flag:     w.flag,
+			Revision: w.revision,
 		},
 	})
 }
@@ -151,6 +155,14 @@ func (w *watcher) startReadLoop() {
 			zap.Uint64("seq", resp.Event.Seq),
 			zap.Uint32("type", resp.Event.Type))
 		expectSeq = resp.Event.Seq + 1
-		w.eventC <- resp.Event
+		if resp.Event.Revision > 0 {
+			w.revision = resp.Event.Revision
+		}
+		// the consumer may be stopped before the watcher is closed
+		select {
+		case w.eventC <- resp.Event:
+		case <-w.ctx.Done():
+			return
+		}
 	}
 }
@@ -6,6 +6,7 @@ package mockclient
 
 import (
 	reflect "reflect"
+	time "time"
 
 	roaring64 "github.com/RoaringBitmap/roaring/roaring64"
 	gomock "github.com/golang/mock/gomock"
@@ -189,6 +190,78 @@ func (mr *MockClientMockRecorder) CreateJob(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJob", reflect.TypeOf((*MockClient)(nil).CreateJob), arg0)
 }
 
+// CreateKeyspace mocks base method.
+func (m *MockClient) CreateKeyspace(keyspace metapb.Keyspace) (metapb.Keyspace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateKeyspace", keyspace)
+	ret0, _ := ret[0].(metapb.Keyspace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateKeyspace indicates an expected call of CreateKeyspace.
+func (mr *MockClientMockRecorder) CreateKeyspace(keyspace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateKeyspace", reflect.TypeOf((*MockClient)(nil).CreateKeyspace), keyspace)
+}
+
+// CreateOperator mocks base method.
+func (m *MockClient) CreateOperator(opType rpcpb.ManualOperatorType, shardID, fromStoreID, toStoreID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOperator", opType, shardID, fromStoreID, toStoreID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOperator indicates an expected call of CreateOperator.
+func (mr *MockClientMockRecorder) CreateOperator(opType, shardID, fromStoreID, toStoreID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOperator", reflect.TypeOf((*MockClient)(nil).CreateOperator), opType, shardID, fromStoreID, toStoreID)
+}
+
+// DeleteKeyspace mocks base method.
+func (m *MockClient) DeleteKeyspace(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteKeyspace", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteKeyspace indicates an expected call of DeleteKeyspace.
+func (mr *MockClientMockRecorder) DeleteKeyspace(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteKeyspace", reflect.TypeOf((*MockClient)(nil).DeleteKeyspace), name)
+}
+
+// DeletePlacementRule mocks base method.
+func (m *MockClient) DeletePlacementRule(group, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePlacementRule", group, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePlacementRule indicates an expected call of DeletePlacementRule.
+func (mr *MockClientMockRecorder) DeletePlacementRule(group, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePlacementRule", reflect.TypeOf((*MockClient)(nil).DeletePlacementRule), group, id)
+}
+
+// DropShardGroup mocks base method.
+func (m *MockClient) DropShardGroup(group uint64, retention time.Duration) (rpcpb.TrashedShardGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DropShardGroup", group, retention)
+	ret0, _ := ret[0].(rpcpb.TrashedShardGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DropShardGroup indicates an expected call of DropShardGroup.
+func (mr *MockClientMockRecorder) DropShardGroup(group, retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropShardGroup", reflect.TypeOf((*MockClient)(nil).DropShardGroup), group, retention)
+}
+
 // ExecuteJob mocks base method.
 func (m *MockClient) ExecuteJob(arg0 metapb.Job, arg1 []byte) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -204,6 +277,18 @@ func (mr *MockClientMockRecorder) ExecuteJob(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteJob", reflect.TypeOf((*MockClient)(nil).ExecuteJob), arg0, arg1)
 }
 
+// ForgetShardHeartbeat mocks base method.
+func (m *MockClient) ForgetShardHeartbeat(shardID uint64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ForgetShardHeartbeat", shardID)
+}
+
+// ForgetShardHeartbeat indicates an expected call of ForgetShardHeartbeat.
+func (mr *MockClientMockRecorder) ForgetShardHeartbeat(shardID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgetShardHeartbeat", reflect.TypeOf((*MockClient)(nil).ForgetShardHeartbeat), shardID)
+}
+
 // GetAppliedRules mocks base method.
 func (m *MockClient) GetAppliedRules(id uint64) ([]rpcpb.PlacementRule, error) {
 	m.ctrl.T.Helper()
@@ -219,6 +304,36 @@ func (mr *MockClientMockRecorder) GetAppliedRules(id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppliedRules", reflect.TypeOf((*MockClient)(nil).GetAppliedRules), id)
 }
 
+// GetBalanceReports mocks base method.
+func (m *MockClient) GetBalanceReports(group uint64, limit int) ([]rpcpb.BalanceReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceReports", group, limit)
+	ret0, _ := ret[0].([]rpcpb.BalanceReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceReports indicates an expected call of GetBalanceReports.
+func (mr *MockClientMockRecorder) GetBalanceReports(group, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceReports", reflect.TypeOf((*MockClient)(nil).GetBalanceReports), group, limit)
+}
+
+// GetCatchUpProgress mocks base method.
+func (m *MockClient) GetCatchUpProgress(shardID uint64) ([]rpcpb.OperatorCatchUpProgress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCatchUpProgress", shardID)
+	ret0, _ := ret[0].([]rpcpb.OperatorCatchUpProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCatchUpProgress indicates an expected call of GetCatchUpProgress.
+func (mr *MockClientMockRecorder) GetCatchUpProgress(shardID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCatchUpProgress", reflect.TypeOf((*MockClient)(nil).GetCatchUpProgress), shardID)
+}
+
 // GetDestroying mocks base method.
 func (m *MockClient) GetDestroying(id uint64) (*metapb.DestroyingStatus, error) {
 	m.ctrl.T.Helper()
@@ -234,6 +349,96 @@ func (mr *MockClientMockRecorder) GetDestroying(id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDestroying", reflect.TypeOf((*MockClient)(nil).GetDestroying), id)
 }
 
+// GetHotBuckets mocks base method.
+func (m *MockClient) GetHotBuckets(group uint64, limit int) ([]metapb.ShardBucket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHotBuckets", group, limit)
+	ret0, _ := ret[0].([]metapb.ShardBucket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHotBuckets indicates an expected call of GetHotBuckets.
+func (mr *MockClientMockRecorder) GetHotBuckets(group, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHotBuckets", reflect.TypeOf((*MockClient)(nil).GetHotBuckets), group, limit)
+}
+
+// GetKeyspaces mocks base method.
+func (m *MockClient) GetKeyspaces() ([]metapb.Keyspace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeyspaces")
+	ret0, _ := ret[0].([]metapb.Keyspace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeyspaces indicates an expected call of GetKeyspaces.
+func (mr *MockClientMockRecorder) GetKeyspaces() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeyspaces", reflect.TypeOf((*MockClient)(nil).GetKeyspaces))
+}
+
+// GetOperators mocks base method.
+func (m *MockClient) GetOperators(shardID uint64) ([]rpcpb.OperatorStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOperators", shardID)
+	ret0, _ := ret[0].([]rpcpb.OperatorStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOperators indicates an expected call of GetOperators.
+func (mr *MockClientMockRecorder) GetOperators(shardID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOperators", reflect.TypeOf((*MockClient)(nil).GetOperators), shardID)
+}
+
+// GetPlacementRules mocks base method.
+func (m *MockClient) GetPlacementRules(group string) ([]rpcpb.PlacementRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPlacementRules", group)
+	ret0, _ := ret[0].([]rpcpb.PlacementRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPlacementRules indicates an expected call of GetPlacementRules.
+func (mr *MockClientMockRecorder) GetPlacementRules(group interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPlacementRules", reflect.TypeOf((*MockClient)(nil).GetPlacementRules), group)
+}
+
+// GetReplicaDrifts mocks base method.
+func (m *MockClient) GetReplicaDrifts(limit int) ([]rpcpb.ReplicaDrift, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReplicaDrifts", limit)
+	ret0, _ := ret[0].([]rpcpb.ReplicaDrift)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReplicaDrifts indicates an expected call of GetReplicaDrifts.
+func (mr *MockClientMockRecorder) GetReplicaDrifts(limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReplicaDrifts", reflect.TypeOf((*MockClient)(nil).GetReplicaDrifts), limit)
+}
+
+// GetSchedulers mocks base method.
+func (m *MockClient) GetSchedulers() ([]rpcpb.SchedulerStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedulers")
+	ret0, _ := ret[0].([]rpcpb.SchedulerStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedulers indicates an expected call of GetSchedulers.
+func (mr *MockClientMockRecorder) GetSchedulers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedulers", reflect.TypeOf((*MockClient)(nil).GetSchedulers))
+}
+
 // GetSchedulingRules mocks base method.
 func (m *MockClient) GetSchedulingRules() ([]metapb.ScheduleGroupRule, error) {
 	m.ctrl.T.Helper()
@@ -264,6 +469,36 @@ func (mr *MockClientMockRecorder) GetShardHeartbeatRspNotifier() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShardHeartbeatRspNotifier", reflect.TypeOf((*MockClient)(nil).GetShardHeartbeatRspNotifier))
 }
 
+// GetShardLineage mocks base method.
+func (m *MockClient) GetShardLineage(shardID uint64) (rpcpb.GetShardLineageRsp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShardLineage", shardID)
+	ret0, _ := ret[0].(rpcpb.GetShardLineageRsp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShardLineage indicates an expected call of GetShardLineage.
+func (mr *MockClientMockRecorder) GetShardLineage(shardID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShardLineage", reflect.TypeOf((*MockClient)(nil).GetShardLineage), shardID)
+}
+
+// GetShardReplayLog mocks base method.
+func (m *MockClient) GetShardReplayLog(shardID uint64, limit int) (rpcpb.ShardReplayLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShardReplayLog", shardID, limit)
+	ret0, _ := ret[0].(rpcpb.ShardReplayLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShardReplayLog indicates an expected call of GetShardReplayLog.
+func (mr *MockClientMockRecorder) GetShardReplayLog(shardID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShardReplayLog", reflect.TypeOf((*MockClient)(nil).GetShardReplayLog), shardID, limit)
+}
+
 // GetStore mocks base method.
 func (m *MockClient) GetStore(containerID uint64) (*metapb.Store, error) {
 	m.ctrl.T.Helper()
@@ -279,6 +514,51 @@ func (mr *MockClientMockRecorder) GetStore(containerID interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStore", reflect.TypeOf((*MockClient)(nil).GetStore), containerID)
 }
 
+// GetTrashedShardGroups mocks base method.
+func (m *MockClient) GetTrashedShardGroups() ([]rpcpb.TrashedShardGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrashedShardGroups")
+	ret0, _ := ret[0].([]rpcpb.TrashedShardGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrashedShardGroups indicates an expected call of GetTrashedShardGroups.
+func (mr *MockClientMockRecorder) GetTrashedShardGroups() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrashedShardGroups", reflect.TypeOf((*MockClient)(nil).GetTrashedShardGroups))
+}
+
+// ListShards mocks base method.
+func (m *MockClient) ListShards(req rpcpb.ListShardsReq) (rpcpb.ListShardsRsp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListShards", req)
+	ret0, _ := ret[0].(rpcpb.ListShardsRsp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListShards indicates an expected call of ListShards.
+func (mr *MockClientMockRecorder) ListShards(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListShards", reflect.TypeOf((*MockClient)(nil).ListShards), req)
+}
+
+// ListStores mocks base method.
+func (m *MockClient) ListStores(req rpcpb.ListStoresReq) (rpcpb.ListStoresRsp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStores", req)
+	ret0, _ := ret[0].(rpcpb.ListStoresRsp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStores indicates an expected call of ListStores.
+func (mr *MockClientMockRecorder) ListStores(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStores", reflect.TypeOf((*MockClient)(nil).ListStores), req)
+}
+
 // NewWatcher mocks base method.
 func (m *MockClient) NewWatcher(flag uint32) (prophet.EventWatcher, error) {
 	m.ctrl.T.Helper()
@@ -294,6 +574,35 @@ func (mr *MockClientMockRecorder) NewWatcher(flag interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewWatcher", reflect.TypeOf((*MockClient)(nil).NewWatcher), flag)
 }
 
+// PauseScheduler mocks base method.
+func (m *MockClient) PauseScheduler(name string, seconds int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseScheduler", name, seconds)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PauseScheduler indicates an expected call of PauseScheduler.
+func (mr *MockClientMockRecorder) PauseScheduler(name, seconds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseScheduler", reflect.TypeOf((*MockClient)(nil).PauseScheduler), name, seconds)
+}
+
+// PlacementDryRun mocks base method.
+func (m *MockClient) PlacementDryRun(addStores []metapb.Store, removeStores []uint64) (rpcpb.PlacementDryRunRsp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlacementDryRun", addStores, removeStores)
+	ret0, _ := ret[0].(rpcpb.PlacementDryRunRsp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PlacementDryRun indicates an expected call of PlacementDryRun.
+func (mr *MockClientMockRecorder) PlacementDryRun(addStores, removeStores interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlacementDryRun", reflect.TypeOf((*MockClient)(nil).PlacementDryRun), addStores, removeStores)
+}
+
 // PutPlacementRule mocks base method.
 func (m *MockClient) PutPlacementRule(rule rpcpb.PlacementRule) error {
 	m.ctrl.T.Helper()
@@ -351,6 +660,48 @@ func (mr *MockClientMockRecorder) ReportDestroyed(id, replicaID interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportDestroyed", reflect.TypeOf((*MockClient)(nil).ReportDestroyed), id, replicaID)
 }
 
+// SetMaintenanceWindows mocks base method.
+func (m *MockClient) SetMaintenanceWindows(windows []rpcpb.MaintenanceWindow) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMaintenanceWindows", windows)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMaintenanceWindows indicates an expected call of SetMaintenanceWindows.
+func (mr *MockClientMockRecorder) SetMaintenanceWindows(windows interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaintenanceWindows", reflect.TypeOf((*MockClient)(nil).SetMaintenanceWindows), windows)
+}
+
+// SetShardScoreFunction mocks base method.
+func (m *MockClient) SetShardScoreFunction(fn string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetShardScoreFunction", fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetShardScoreFunction indicates an expected call of SetShardScoreFunction.
+func (mr *MockClientMockRecorder) SetShardScoreFunction(fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetShardScoreFunction", reflect.TypeOf((*MockClient)(nil).SetShardScoreFunction), fn)
+}
+
+// SetStoreWeight mocks base method.
+func (m *MockClient) SetStoreWeight(storeID uint64, leaderWeight, shardWeight float64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStoreWeight", storeID, leaderWeight, shardWeight)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStoreWeight indicates an expected call of SetStoreWeight.
+func (mr *MockClientMockRecorder) SetStoreWeight(storeID, leaderWeight, shardWeight interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStoreWeight", reflect.TypeOf((*MockClient)(nil).SetStoreWeight), storeID, leaderWeight, shardWeight)
+}
+
 // ShardHeartbeat mocks base method.
 func (m *MockClient) ShardHeartbeat(meta metapb.Shard, hb rpcpb.ShardHeartbeatReq) error {
 	m.ctrl.T.Helper()
@@ -379,3 +730,17 @@ func (mr *MockClientMockRecorder) StoreHeartbeat(hb interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreHeartbeat", reflect.TypeOf((*MockClient)(nil).StoreHeartbeat), hb)
 }
+
+// UndropShardGroup mocks base method.
+func (m *MockClient) UndropShardGroup(group uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UndropShardGroup", group)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UndropShardGroup indicates an expected call of UndropShardGroup.
+func (mr *MockClientMockRecorder) UndropShardGroup(group interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UndropShardGroup", reflect.TypeOf((*MockClient)(nil).UndropShardGroup), group)
+}
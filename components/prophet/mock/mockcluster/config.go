@@ -86,6 +86,11 @@ func (mc *Cluster) SetShardScoreFormulaVersion(v string) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.ShardScoreFormulaVersion = v })
 }
 
+// SetShardScoreFunction updates the ShardScoreFunction configuration.
+func (mc *Cluster) SetShardScoreFunction(v string) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.ShardScoreFunction = v })
+}
+
 // SetLeaderScheduleLimit updates the LeaderScheduleLimit configuration.
 func (mc *Cluster) SetLeaderScheduleLimit(v int) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.LeaderScheduleLimit = uint64(v) })
@@ -129,6 +134,21 @@ func (mc *Cluster) SetLocationLabels(v []string) {
 	mc.updateReplicationConfig(func(r *config.ReplicationConfig) { r.LocationLabels = v })
 }
 
+// SetShardGroupPools updates the ShardGroupPools configuration.
+func (mc *Cluster) SetShardGroupPools(v ...config.ShardGroupPool) {
+	mc.updateReplicationConfig(func(r *config.ReplicationConfig) { r.ShardGroupPools = v })
+}
+
+// SetShardGroupStorageClasses updates the ShardGroupStorageClasses configuration.
+func (mc *Cluster) SetShardGroupStorageClasses(v ...config.ShardGroupStorageClass) {
+	mc.updateReplicationConfig(func(r *config.ReplicationConfig) { r.ShardGroupStorageClasses = v })
+}
+
+// SetShardSizeClasses updates the ShardSizeClasses configuration.
+func (mc *Cluster) SetShardSizeClasses(v ...config.ShardSizeClass) {
+	mc.updateReplicationConfig(func(r *config.ReplicationConfig) { r.ShardSizeClasses = v })
+}
+
 func (mc *Cluster) updateScheduleConfig(f func(*config.ScheduleConfig)) {
 	s := mc.GetScheduleConfig().Clone()
 	f(s)
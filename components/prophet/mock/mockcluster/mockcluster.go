@@ -45,6 +45,8 @@ type Cluster struct {
 	storage       storage.Storage
 	ID            uint64
 	suspectShards map[uint64]struct{}
+	readHints     map[uint64]metapb.ShardReadHint
+	hotStat       *statistics.HotCache
 
 	supportJointConsensus bool
 }
@@ -56,6 +58,8 @@ func NewCluster(opts *config.PersistOptions) *Cluster {
 		BasicCluster:          core.NewBasicCluster(nil),
 		PersistOptions:        opts,
 		suspectShards:         map[uint64]struct{}{},
+		readHints:             map[uint64]metapb.ShardReadHint{},
+		hotStat:               statistics.NewHotCache(),
 		supportJointConsensus: true,
 	}
 	if clus.PersistOptions.GetReplicationConfig().EnablePlacementRules {
@@ -132,6 +136,13 @@ func (mc *Cluster) AllocPeer(containerID uint64) (metapb.Replica, error) {
 func (mc *Cluster) initRuleManager() {
 	if mc.RuleManager == nil {
 		mc.RuleManager = placement.NewRuleManager(mc.storage, mc, nil)
+		mc.RuleManager.SetStorePoolFunc(func(group uint64) string {
+			return mc.GetReplicationConfig().GetShardGroupPool(group)
+		})
+		mc.RuleManager.SetStorageClassFunc(func(group uint64) string {
+			return mc.GetReplicationConfig().GetShardGroupStorageClass(group)
+		})
+		mc.RuleManager.SetKeyspaces(mc.Keyspaces)
 		mc.RuleManager.Initialize(int(mc.GetReplicationConfig().MaxReplicas), mc.GetReplicationConfig().LocationLabels)
 	}
 }
@@ -195,6 +206,19 @@ func (mc *Cluster) SetStoreBusy(containerID uint64, busy bool) {
 	mc.PutStore(newStore)
 }
 
+// SetStoreCountLimit sets the max replica and leader count of the container.
+func (mc *Cluster) SetStoreCountLimit(containerID uint64, maxReplicaCount, maxLeaderCount uint64) {
+	container := mc.GetStore(containerID)
+	newStats := proto.Clone(container.GetStoreStats()).(*metapb.StoreStats)
+	newStats.MaxReplicaCount = maxReplicaCount
+	newStats.MaxLeaderCount = maxLeaderCount
+	newStore := container.Clone(
+		core.SetStoreStats(newStats),
+		core.SetLastHeartbeatTS(time.Now()),
+	)
+	mc.PutStore(newStore)
+}
+
 // AddLeaderStore adds container with specified count of leader.
 func (mc *Cluster) AddLeaderStore(containerID uint64, leaderCount int, leaderSizes ...int64) {
 	stats := &metapb.StoreStats{}
@@ -583,6 +607,52 @@ func (mc *Cluster) AddSuspectShards(ids ...uint64) {
 	}
 }
 
+// SetShardReadHint mock method
+func (mc *Cluster) SetShardReadHint(hint metapb.ShardReadHint) {
+	if len(hint.Replicas) == 0 {
+		delete(mc.readHints, hint.ShardID)
+		return
+	}
+	mc.readHints[hint.ShardID] = hint
+}
+
+// GetShardReadHint only used for unit test
+func (mc *Cluster) GetShardReadHint(id uint64) (metapb.ShardReadHint, bool) {
+	hint, ok := mc.readHints[id]
+	return hint, ok
+}
+
+// UpdateReplicaReadStats updates the read load of the replicas in the store.
+func (mc *Cluster) UpdateReplicaReadStats(storeID uint64, stats ...metapb.ReplicaReadStats) {
+	container := mc.GetStore(storeID)
+	newStats := proto.Clone(container.GetStoreStats()).(*metapb.StoreStats)
+	newStats.ReplicaReadStats = stats
+	now := time.Now().Unix()
+	newStats.Interval = &metapb.TimeInterval{Start: uint64(now - statistics.StoreHeartBeatReportInterval), End: uint64(now)}
+	newStore := container.Clone(core.SetStoreStats(newStats))
+	mc.PutStore(newStore)
+}
+
+// GetHotPeerStats mock method
+func (mc *Cluster) GetHotPeerStats(kind statistics.FlowKind, minHotDegree int) map[uint64][]statistics.HotPeerStat {
+	return mc.hotStat.GetHotPeerStats(kind, minHotDegree)
+}
+
+// UpdateShardFlow puts the shard with the accumulated flows reported by the
+// leader, and observes the flows since the last update of the shard by the hot
+// cache, the flows are reported with the interval of the shard heartbeat.
+func (mc *Cluster) UpdateShardFlow(res *core.CachedShard, writtenBytes, writtenKeys, readBytes, readKeys uint64) *core.CachedShard {
+	res = res.Clone(
+		core.SetWrittenBytes(writtenBytes),
+		core.SetWrittenKeys(writtenKeys),
+		core.SetReadBytes(readBytes),
+		core.SetReadKeys(readKeys),
+		core.SetReportInterval(statistics.ShardHeartBeatReportInterval))
+	mc.PutShard(res)
+	mc.hotStat.Observe(res)
+	return res
+}
+
 // CheckShardUnderSuspect only used for unit test
 func (mc *Cluster) CheckShardUnderSuspect(id uint64) bool {
 	_, ok := mc.suspectShards[id]
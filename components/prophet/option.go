@@ -31,6 +31,17 @@ type options struct {
 	logger       *zap.Logger
 	leaderGetter func() *metapb.Member
 	rpcTimeout   time.Duration
+	// fullHeartbeatInterval the shard descriptor is omitted from the shard
+	// heartbeat if it is not changed, and a full heartbeat is sent after
+	// fullHeartbeatInterval delta heartbeats. 0 means always full heartbeats.
+	fullHeartbeatInterval int
+	// seedMembers the rpc addresses of the prophet members to discover the
+	// leader if the leader getter doesn't know the leader
+	seedMembers []string
+	// requestDeadline the overall deadline of a request including the retries
+	// and the failovers, 0 means the not leader errors are retried until the
+	// new leader elected.
+	requestDeadline time.Duration
 }
 
 func (opts *options) adjust() {
@@ -62,6 +73,33 @@ func WithRPCTimeout(value time.Duration) Option {
 	}
 }
 
+// WithShardHeartbeatFullSyncInterval enable delta shard heartbeats, a full
+// heartbeat is sent after every value delta heartbeats
+func WithShardHeartbeatFullSyncInterval(value int) Option {
+	return func(opts *options) {
+		opts.fullHeartbeatInterval = value
+	}
+}
+
+// WithSeedMembers set the rpc addresses of the prophet members. The client
+// connects to the members in turn if the leader is unknown, and follows the
+// leader returned by the not leader responses. The new leaders are added to the
+// members automatically.
+func WithSeedMembers(addrs ...string) Option {
+	return func(opts *options) {
+		opts.seedMembers = append(opts.seedMembers, addrs...)
+	}
+}
+
+// WithRequestDeadline set the overall deadline of the requests. The requests
+// are retried and failed over to the new leader within the deadline, and the
+// last error is returned once the deadline is reached.
+func WithRequestDeadline(value time.Duration) Option {
+	return func(opts *options) {
+		opts.requestDeadline = value
+	}
+}
+
 func createConn(logger *zap.Logger) goetty.IOSession {
 	encoder, decoder := codec.NewClientCodec(10 * buf.MB)
 	return goetty.NewIOSession(goetty.WithCodec(encoder, decoder),
@@ -22,6 +22,7 @@ import (
 
 	"github.com/fagongzi/goetty"
 	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/components/prophet/audit"
 	"github.com/matrixorigin/matrixcube/components/prophet/cluster"
 	pconfig "github.com/matrixorigin/matrixcube/components/prophet/config"
 	"github.com/matrixorigin/matrixcube/components/prophet/core"
@@ -37,6 +38,7 @@ import (
 	"github.com/matrixorigin/matrixcube/config"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/util/stop"
+	"github.com/matrixorigin/matrixcube/util/watchdog"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/server/v3/embed"
 	"go.uber.org/zap"
@@ -92,6 +94,12 @@ type defaultProphet struct {
 	storage      storage.Storage
 	basicCluster *core.BasicCluster
 	cluster      *cluster.RaftCluster
+	spec         *ClusterSpec
+
+	// audit log of the admin requests
+	audit *audit.Logger
+	// watchdog monitors the loops of the coordinator, nil if it's disabled
+	watchdog *watchdog.Watchdog
 
 	// rpc
 	hbStreams  *hbstream.HeartbeatStreams
@@ -102,12 +110,15 @@ type defaultProphet struct {
 	// job task ctx
 	jobMu struct {
 		sync.RWMutex
-		jobs map[metapb.JobType]metapb.Job
+		// started the jobs are loaded and started on the leader
+		started bool
+		jobs    map[metapb.JobType]metapb.Job
 	}
 
 	mu struct {
 		sync.RWMutex
-		wn *eventNotifier
+		wn                  *eventNotifier
+		cancelLeaderChecker context.CancelFunc
 	}
 }
 
@@ -150,6 +161,11 @@ func NewProphet(cfg *config.Config) Prophet {
 		logger.Fatal("fail to create elector", zap.Error(err))
 	}
 
+	auditLogger, err := audit.NewLogger(cfg.Prophet.Audit.File, cfg.Prophet.AuditHandlers, logger)
+	if err != nil {
+		logger.Fatal("fail to open audit log", zap.Error(err))
+	}
+
 	p := &defaultProphet{
 		logger:         logger,
 		ctx:            ctx,
@@ -160,6 +176,7 @@ func NewProphet(cfg *config.Config) Prophet {
 		elector:        elector,
 		completeC:      make(chan struct{}),
 		stopper:        stop.NewStopper("prophet", stop.WithLogger(logger)),
+		audit:          auditLogger,
 	}
 
 	p.member = member.NewMember(etcd, elector,
@@ -184,9 +201,23 @@ func (p *defaultProphet) Start() {
 	}
 	p.logger.Info("init cluster id completed")
 
+	if p.cfg.Prophet.ClusterSpecFile != "" {
+		p.spec, err = LoadClusterSpec(p.cfg.FS, p.cfg.Prophet.ClusterSpecFile)
+		if err != nil {
+			p.logger.Fatal("fail to load cluster spec",
+				zap.String("file", p.cfg.Prophet.ClusterSpecFile),
+				zap.Error(err))
+		}
+		p.logger.Info("cluster spec loaded")
+	}
+
 	p.member.InitMemberInfo(p.cfg.Prophet.Name, p.cfg.Prophet.AdvertiseRPCAddr)
 	p.logger.Info("member init completed")
 
+	if p.cfg.Prophet.ProphetNode {
+		p.startMemberRegister()
+	}
+
 	kv := storage.NewEtcdKV(rootPath, p.elector.Client(), p.member.GetLeadership())
 	idGenerator := id.NewEtcdGenerator(rootPath, p.elector.Client(), p.member.GetLeadership())
 	p.storage = storage.NewStorage(rootPath, kv, idGenerator)
@@ -199,6 +230,14 @@ func (p *defaultProphet) Start() {
 		p.ctx, rootPath, p.clusterID, p.elector.Client(),
 		p.cfg.Prophet.ShardStateChangedHandler, p.logger,
 	)
+	if !p.cfg.Watchdog.Disable {
+		p.watchdog = watchdog.New(p.logger,
+			watchdog.WithCheckInterval(p.cfg.Watchdog.CheckDuration.Duration),
+			watchdog.WithDeadline(p.cfg.Watchdog.Deadline.Duration),
+			watchdog.WithStalledFunc(cluster.SetWatchdogStalledTasks))
+		p.watchdog.Start()
+		p.cluster.SetWatchdog(p.watchdog)
+	}
 	p.logger.Info("raft cluster created")
 
 	p.hbStreams = hbstream.NewHeartbeatStreams(p.ctx, p.clusterID, p.cluster, p.logger)
@@ -238,7 +277,14 @@ func (p *defaultProphet) Stop() {
 		p.stopJobs()
 		p.logger.Info("job begin to stopped")
 
+		if err := p.audit.Close(); err != nil {
+			p.logger.Error("fail to close audit log", zap.Error(err))
+		}
+
 		p.stopper.Stop()
+		if p.watchdog != nil {
+			p.watchdog.Stop()
+		}
 		p.logger.Info("prophet stopped")
 	})
 }
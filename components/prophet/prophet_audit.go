@@ -0,0 +1,89 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prophet
+
+import (
+	"encoding/json"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/audit"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"go.uber.org/zap"
+)
+
+// getAuditParams returns the parameters of the admin request, false if the
+// request is not recorded in the audit log.
+func getAuditParams(req *rpcpb.ProphetRequest) (interface{}, bool) {
+	switch req.Type {
+	case rpcpb.TypeCreateShardsReq:
+		return req.CreateShards, true
+	case rpcpb.TypeRemoveShardsReq:
+		return req.RemoveShards, true
+	case rpcpb.TypeCreateDestroyingReq:
+		return req.CreateDestroying, true
+	case rpcpb.TypePutPlacementRuleReq:
+		return req.PutPlacementRule, true
+	case rpcpb.TypeDeletePlacementRuleReq:
+		return req.DeletePlacementRule, true
+	case rpcpb.TypeCreateJobReq:
+		return req.CreateJob, true
+	case rpcpb.TypeRemoveJobReq:
+		return req.RemoveJob, true
+	case rpcpb.TypeExecuteJobReq:
+		return req.ExecuteJob, true
+	case rpcpb.TypeAddScheduleGroupRuleReq:
+		return req.AddScheduleGroupRule, true
+	case rpcpb.TypePauseSchedulerReq:
+		return req.PauseScheduler, true
+	case rpcpb.TypeCreateOperatorReq:
+		return req.CreateOperator, true
+	case rpcpb.TypeSetStoreWeightReq:
+		return req.SetStoreWeight, true
+	case rpcpb.TypeSetShardScoreFunctionReq:
+		return req.SetShardScoreFunction, true
+	case rpcpb.TypeSetMaintenanceWindowsReq:
+		return req.SetMaintenanceWindows, true
+	case rpcpb.TypeCreateKeyspaceReq:
+		return req.CreateKeyspace, true
+	case rpcpb.TypeDeleteKeyspaceReq:
+		return req.DeleteKeyspace, true
+	}
+	return nil, false
+}
+
+// auditRequest records the handled admin request with the caller and the error
+// returned to the caller.
+func (p *defaultProphet) auditRequest(caller string, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) {
+	if !p.audit.Enabled() {
+		return
+	}
+	params, ok := getAuditParams(req)
+	if !ok {
+		return
+	}
+
+	// the operation is recorded even if the parameters can't be encoded
+	data, err := json.Marshal(params)
+	if err != nil {
+		p.logger.Error("fail to encode audit params",
+			zap.String("type", req.Type.String()),
+			zap.Error(err))
+	}
+	p.audit.Log(audit.Record{
+		Operation: req.Type.String(),
+		Caller:    caller,
+		StoreID:   req.StoreID,
+		Params:    data,
+		Error:     resp.Error,
+	})
+}
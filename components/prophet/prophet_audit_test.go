@@ -0,0 +1,47 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prophet
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/components/prophet/audit"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditRequest(t *testing.T) {
+	var records []audit.Record
+	l, err := audit.NewLogger("", []audit.Handler{func(record audit.Record) {
+		records = append(records, record)
+	}}, nil)
+	require.NoError(t, err)
+	p := &defaultProphet{logger: log.GetDefaultZapLogger(), audit: l}
+
+	req := &rpcpb.ProphetRequest{StoreID: 1, Type: rpcpb.TypeRemoveShardsReq}
+	req.RemoveShards.IDs = []uint64{1, 2}
+	p.auditRequest("127.0.0.1:1", req, &rpcpb.ProphetResponse{Error: "not found"})
+	// not an admin request
+	p.auditRequest("127.0.0.1:1", &rpcpb.ProphetRequest{Type: rpcpb.TypeShardHeartbeatReq},
+		&rpcpb.ProphetResponse{})
+
+	require.Equal(t, 1, len(records))
+	assert.Equal(t, rpcpb.TypeRemoveShardsReq.String(), records[0].Operation)
+	assert.Equal(t, "127.0.0.1:1", records[0].Caller)
+	assert.Equal(t, uint64(1), records[0].StoreID)
+	assert.JSONEq(t, `{"ids":[1,2]}`, string(records[0].Params))
+	assert.Equal(t, "not found", records[0].Error)
+}
@@ -16,9 +16,11 @@ package prophet
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/fagongzi/goetty"
 	"github.com/matrixorigin/matrixcube/components/prophet/cluster"
+	"github.com/matrixorigin/matrixcube/components/prophet/codec"
 	"github.com/matrixorigin/matrixcube/components/prophet/core"
 	"github.com/matrixorigin/matrixcube/components/prophet/util"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
@@ -40,6 +42,7 @@ func (hs heartbeatStream) Send(resp *rpcpb.ShardHeartbeatRsp) error {
 
 func (p *defaultProphet) handleRPCRequest(rs goetty.IOSession, data interface{}, received uint64) error {
 	req := data.(*rpcpb.ProphetRequest)
+	defer codec.ReleaseRequest(req)
 	if req.Type == rpcpb.TypeRegisterStore {
 		p.hbStreams.BindStream(req.StoreID, &heartbeatStream{containerID: req.StoreID, rs: rs})
 		p.logger.Info("heartbeat stream binded",
@@ -61,7 +64,7 @@ func (p *defaultProphet) handleRPCRequest(rs goetty.IOSession, data interface{},
 	resp.ID = req.ID
 	rc := p.GetRaftCluster()
 	if p.cfg.Prophet.TestContext.ResponseNotLeader() || rc == nil || (p.member != nil && !p.member.IsLeader()) {
-		resp.Error = util.ErrNotLeader.Error()
+		setResponseError(resp, util.ErrNotLeader)
 		resp.Leader = p.member.GetLeader().GetAddr()
 		return rs.WriteAndFlush(resp)
 	}
@@ -71,55 +74,55 @@ func (p *defaultProphet) handleRPCRequest(rs goetty.IOSession, data interface{},
 		resp.Type = rpcpb.TypePutStoreRsp
 		err := p.handlePutStore(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeShardHeartbeatReq:
 		resp.Type = rpcpb.TypeShardHeartbeatRsp
 		err := p.handleShardHeartbeat(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeStoreHeartbeatReq:
 		resp.Type = rpcpb.TypeStoreHeartbeatRsp
 		err := p.handleStoreHeartbeat(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeCreateDestroyingReq:
 		resp.Type = rpcpb.TypeCreateDestroyingRsp
 		err := p.handleCreateDestroying(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeReportDestroyedReq:
 		resp.Type = rpcpb.TypeReportDestroyedRsp
 		err := p.handleReportDestroyed(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeGetDestroyingReq:
 		resp.Type = rpcpb.TypeGetDestroyingRsp
 		err := p.handleGetDestroying(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeAllocIDReq:
 		resp.Type = rpcpb.TypeAllocIDRsp
 		err := p.handleAllocID(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeGetStoreReq:
 		resp.Type = rpcpb.TypeGetStoreRsp
 		err := p.handleGetStore(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeAskBatchSplitReq:
 		resp.Type = rpcpb.TypeAskBatchSplitRsp
 		err := p.handleAskBatchSplit(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeCreateWatcherReq:
 		resp.Type = rpcpb.TypeEventNotify
@@ -127,6 +130,8 @@ func (p *defaultProphet) handleRPCRequest(rs goetty.IOSession, data interface{},
 		wn := p.mu.wn
 		p.mu.RUnlock()
 		if wn != nil {
+			// the response is written by the notifier before the events
+			doResponse = false
 			err := wn.handleCreateWatcher(req, resp, rs)
 			if err != nil {
 				return err
@@ -138,65 +143,210 @@ func (p *defaultProphet) handleRPCRequest(rs goetty.IOSession, data interface{},
 		resp.Type = rpcpb.TypeCreateShardsRsp
 		err := p.handleCreateShards(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeRemoveShardsReq:
 		resp.Type = rpcpb.TypeRemoveShardsRsp
 		err := p.handleRemoveShards(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeCheckShardStateReq:
 		resp.Type = rpcpb.TypeCheckShardStateRsp
 		err := p.handleCheckShardState(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypePutPlacementRuleReq:
 		resp.Type = rpcpb.TypePutPlacementRuleRsp
 		err := p.handlePutPlacementRule(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeDeletePlacementRuleReq:
+		resp.Type = rpcpb.TypeDeletePlacementRuleRsp
+		err := p.handleDeletePlacementRule(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeGetPlacementRulesReq:
+		resp.Type = rpcpb.TypeGetPlacementRulesRsp
+		err := p.handleGetPlacementRules(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeGetAppliedRulesReq:
 		resp.Type = rpcpb.TypeGetAppliedRulesRsp
 		err := p.handleGetAppliedRule(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeCreateJobReq:
 		resp.Type = rpcpb.TypeCreateJobRsp
 		err := p.handleCreateJob(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeRemoveJobReq:
 		resp.Type = rpcpb.TypeCreateJobRsp
 		err := p.handleRemoveJob(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeExecuteJobReq:
 		resp.Type = rpcpb.TypeExecuteJobRsp
 		err := p.handleExecuteJob(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeAddScheduleGroupRuleReq:
 		resp.Type = rpcpb.TypeAddScheduleGroupRuleRsp
 		err := p.handleAddScheduleGroupRule(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
 		}
 	case rpcpb.TypeGetScheduleGroupRuleReq:
 		resp.Type = rpcpb.TypeGetScheduleGroupRuleRsp
 		err := p.handleGetScheduleGroupRule(rc, req, resp)
 		if err != nil {
-			resp.Error = err.Error()
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypePlacementDryRunReq:
+		resp.Type = rpcpb.TypePlacementDryRunRsp
+		err := p.handlePlacementDryRun(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeGetCatchUpProgressReq:
+		resp.Type = rpcpb.TypeGetCatchUpProgressRsp
+		err := p.handleGetCatchUpProgress(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeGetBalanceReportsReq:
+		resp.Type = rpcpb.TypeGetBalanceReportsRsp
+		err := p.handleGetBalanceReports(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeCreateKeyspaceReq:
+		resp.Type = rpcpb.TypeCreateKeyspaceRsp
+		err := p.handleCreateKeyspace(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeGetKeyspacesReq:
+		resp.Type = rpcpb.TypeGetKeyspacesRsp
+		err := p.handleGetKeyspaces(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeDeleteKeyspaceReq:
+		resp.Type = rpcpb.TypeDeleteKeyspaceRsp
+		err := p.handleDeleteKeyspace(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeGetShardReplayLogReq:
+		resp.Type = rpcpb.TypeGetShardReplayLogRsp
+		err := p.handleGetShardReplayLog(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeGetReplicaDriftsReq:
+		resp.Type = rpcpb.TypeGetReplicaDriftsRsp
+		err := p.handleGetReplicaDrifts(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeListShardsReq:
+		resp.Type = rpcpb.TypeListShardsRsp
+		err := p.handleListShards(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeListStoresReq:
+		resp.Type = rpcpb.TypeListStoresRsp
+		err := p.handleListStores(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeDropShardGroupReq:
+		resp.Type = rpcpb.TypeDropShardGroupRsp
+		err := p.handleDropShardGroup(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeUndropShardGroupReq:
+		resp.Type = rpcpb.TypeUndropShardGroupRsp
+		err := p.handleUndropShardGroup(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeGetTrashedShardGroupsReq:
+		resp.Type = rpcpb.TypeGetTrashedShardGroupsRsp
+		err := p.handleGetTrashedShardGroups(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeGetShardLineageReq:
+		resp.Type = rpcpb.TypeGetShardLineageRsp
+		err := p.handleGetShardLineage(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeGetSchedulersReq:
+		resp.Type = rpcpb.TypeGetSchedulersRsp
+		err := p.handleGetSchedulers(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypePauseSchedulerReq:
+		resp.Type = rpcpb.TypePauseSchedulerRsp
+		err := p.handlePauseScheduler(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeCreateOperatorReq:
+		resp.Type = rpcpb.TypeCreateOperatorRsp
+		err := p.handleCreateOperator(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeGetOperatorsReq:
+		resp.Type = rpcpb.TypeGetOperatorsRsp
+		err := p.handleGetOperators(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeGetHotBucketsReq:
+		resp.Type = rpcpb.TypeGetHotBucketsRsp
+		err := p.handleGetHotBuckets(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeSetStoreWeightReq:
+		resp.Type = rpcpb.TypeSetStoreWeightRsp
+		err := p.handleSetStoreWeight(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeSetShardScoreFunctionReq:
+		resp.Type = rpcpb.TypeSetShardScoreFunctionRsp
+		err := p.handleSetShardScoreFunction(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
+		}
+	case rpcpb.TypeSetMaintenanceWindowsReq:
+		resp.Type = rpcpb.TypeSetMaintenanceWindowsRsp
+		err := p.handleSetMaintenanceWindows(rc, req, resp)
+		if err != nil {
+			setResponseError(resp, err)
 		}
 	default:
 		return fmt.Errorf("type %s not support", req.Type.String())
 	}
+	p.auditRequest(rs.RemoteAddr(), req, resp)
 
 	if doResponse {
 		p.logger.Debug("send rpc response",
@@ -209,6 +359,13 @@ func (p *defaultProphet) handleRPCRequest(rs goetty.IOSession, data interface{},
 	return nil
 }
 
+// setResponseError sets the error and the code of the error to the response,
+// the client converts the code back to the error by util.CodeError.
+func setResponseError(resp *rpcpb.ProphetResponse, err error) {
+	resp.Error = err.Error()
+	resp.ErrorCode = util.ErrorCode(err)
+}
+
 func (p *defaultProphet) handlePutStore(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
 	meta := metapb.Store{}
 	err := meta.Unmarshal(req.PutStore.Store)
@@ -229,8 +386,19 @@ func (p *defaultProphet) handlePutStore(rc *cluster.RaftCluster, req *rpcpb.Prop
 
 func (p *defaultProphet) handleShardHeartbeat(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
 	meta := metapb.Shard{}
-	err := meta.Unmarshal(req.ShardHeartbeat.Shard)
-	if err != nil {
+	if len(req.ShardHeartbeat.Shard) == 0 {
+		// delta heartbeat, the shard descriptor is not changed since the last full
+		// heartbeat, reconstruct it from the cache.
+		cached := rc.GetShard(req.ShardHeartbeat.Stats.ShardID)
+		if cached == nil {
+			// the shard id of the rejected delta heartbeat makes the client send a
+			// full heartbeat next time
+			resp.ShardHeartbeat.ShardID = req.ShardHeartbeat.Stats.ShardID
+			return fmt.Errorf("delta heartbeat of shard %d without full heartbeat",
+				req.ShardHeartbeat.Stats.ShardID)
+		}
+		meta = *cached.Meta.Clone()
+	} else if err := meta.Unmarshal(req.ShardHeartbeat.Shard); err != nil {
 		return err
 	}
 
@@ -262,11 +430,15 @@ func (p *defaultProphet) handleShardHeartbeat(rc *cluster.RaftCluster, req *rpcp
 }
 
 func (p *defaultProphet) handleStoreHeartbeat(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	// used by the store to measure the clock offset
+	resp.StoreHeartbeat.Timestamp = time.Now().UnixNano()
 	if err := checkStore(rc, req.StoreHeartbeat.Stats.StoreID); err != nil {
 		return err
 	}
 
-	err := rc.HandleStoreHeartbeat(&req.StoreHeartbeat.Stats)
+	// the stats is retained by the cluster, but the request is reused
+	stats := req.StoreHeartbeat.Stats
+	err := rc.HandleStoreHeartbeat(&stats)
 	if err != nil {
 		return err
 	}
@@ -381,6 +553,20 @@ func (p *defaultProphet) handlePutPlacementRule(rc *cluster.RaftCluster, req *rp
 	return rc.HandlePutPlacementRule(req)
 }
 
+func (p *defaultProphet) handleDeletePlacementRule(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	return rc.HandleDeletePlacementRule(req)
+}
+
+func (p *defaultProphet) handleGetPlacementRules(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandleGetPlacementRules(req)
+	if err != nil {
+		return err
+	}
+
+	resp.GetPlacementRules = *rsp
+	return nil
+}
+
 func (p *defaultProphet) handleGetAppliedRule(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
 	rsp, err := rc.HandleAppliedRules(req)
 	if err != nil {
@@ -408,6 +594,15 @@ func (p *defaultProphet) handleGetScheduleGroupRule(rc *cluster.RaftCluster, req
 	return nil
 }
 
+func (p *defaultProphet) handlePlacementDryRun(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandlePlacementDryRun(req)
+	if err != nil {
+		return err
+	}
+	resp.PlacementDryRun = *rsp
+	return nil
+}
+
 // checkStore returns an error response if the store exists and is in tombstone state.
 // It returns nil if it can't get the store.
 func checkStore(rc *cluster.RaftCluster, storeID uint64) error {
@@ -419,3 +614,157 @@ func checkStore(rc *cluster.RaftCluster, storeID uint64) error {
 	}
 	return nil
 }
+
+func (p *defaultProphet) handleGetCatchUpProgress(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandleGetCatchUpProgress(req)
+	if err != nil {
+		return err
+	}
+	resp.GetCatchUpProgress = *rsp
+	return nil
+}
+
+func (p *defaultProphet) handleGetBalanceReports(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandleGetBalanceReports(req)
+	if err != nil {
+		return err
+	}
+	resp.GetBalanceReports = *rsp
+	return nil
+}
+
+func (p *defaultProphet) handleCreateKeyspace(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandleCreateKeyspace(req)
+	if err != nil {
+		return err
+	}
+	resp.CreateKeyspace = *rsp
+	return nil
+}
+
+func (p *defaultProphet) handleGetKeyspaces(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandleGetKeyspaces(req)
+	if err != nil {
+		return err
+	}
+	resp.GetKeyspaces = *rsp
+	return nil
+}
+
+func (p *defaultProphet) handleDeleteKeyspace(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	return rc.HandleDeleteKeyspace(req)
+}
+
+func (p *defaultProphet) handleGetShardReplayLog(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandleGetShardReplayLog(req)
+	if err != nil {
+		return err
+	}
+	resp.GetShardReplayLog = *rsp
+	return nil
+}
+
+func (p *defaultProphet) handleGetReplicaDrifts(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandleGetReplicaDrifts(req)
+	if err != nil {
+		return err
+	}
+	resp.GetReplicaDrifts = *rsp
+	return nil
+}
+
+func (p *defaultProphet) handleListShards(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandleListShards(req)
+	if err != nil {
+		return err
+	}
+	resp.ListShards = *rsp
+	return nil
+}
+
+func (p *defaultProphet) handleListStores(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandleListStores(req)
+	if err != nil {
+		return err
+	}
+	resp.ListStores = *rsp
+	return nil
+}
+
+func (p *defaultProphet) handleDropShardGroup(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandleDropShardGroup(req)
+	if err != nil {
+		return err
+	}
+	resp.DropShardGroup = *rsp
+	return nil
+}
+
+func (p *defaultProphet) handleUndropShardGroup(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	return rc.HandleUndropShardGroup(req)
+}
+
+func (p *defaultProphet) handleGetTrashedShardGroups(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandleGetTrashedShardGroups(req)
+	if err != nil {
+		return err
+	}
+	resp.GetTrashedShardGroups = *rsp
+	return nil
+}
+
+func (p *defaultProphet) handleGetShardLineage(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandleGetShardLineage(req)
+	if err != nil {
+		return err
+	}
+	resp.GetShardLineage = *rsp
+	return nil
+}
+
+func (p *defaultProphet) handleGetSchedulers(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandleGetSchedulers(req)
+	if err != nil {
+		return err
+	}
+	resp.GetSchedulers = *rsp
+	return nil
+}
+
+func (p *defaultProphet) handlePauseScheduler(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	return rc.HandlePauseScheduler(req)
+}
+
+func (p *defaultProphet) handleCreateOperator(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	return rc.HandleCreateOperator(req)
+}
+
+func (p *defaultProphet) handleGetOperators(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandleGetOperators(req)
+	if err != nil {
+		return err
+	}
+	resp.GetOperators = *rsp
+	return nil
+}
+
+func (p *defaultProphet) handleGetHotBuckets(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	rsp, err := rc.HandleGetHotBuckets(req)
+	if err != nil {
+		return err
+	}
+	resp.GetHotBuckets = *rsp
+	return nil
+}
+
+func (p *defaultProphet) handleSetStoreWeight(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	return rc.HandleSetStoreWeight(req)
+}
+
+func (p *defaultProphet) handleSetShardScoreFunction(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	return rc.HandleSetShardScoreFunction(req)
+}
+
+func (p *defaultProphet) handleSetMaintenanceWindows(rc *cluster.RaftCluster, req *rpcpb.ProphetRequest, resp *rpcpb.ProphetResponse) error {
+	return rc.HandleSetMaintenanceWindows(req)
+}
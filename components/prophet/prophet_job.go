@@ -18,12 +18,16 @@ import (
 	"fmt"
 
 	"github.com/matrixorigin/matrixcube/components/prophet/cluster"
+	"github.com/matrixorigin/matrixcube/components/prophet/config"
 	"github.com/matrixorigin/matrixcube/components/prophet/util"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"go.uber.org/zap"
 )
 
+// startJobs restarts the jobs saved by the previous prophet leaders. The jobs
+// completed but not removed are removed again, the working jobs are resumed
+// from the latest checkpoints if their processors support.
 func (p *defaultProphet) startJobs() {
 	p.stopper.RunTask(context.Background(), func(ctx context.Context) {
 		p.logger.Info("start jobs")
@@ -59,7 +63,12 @@ func (p *defaultProphet) startJobs() {
 			default:
 			}
 
+			processor := p.cfg.Prophet.GetJobProcessor(job.Type)
 			if job.State == metapb.JobState_Completed {
+				// the previous leader failed before the completed job removed
+				if processor != nil {
+					processor.Remove(job, p.storage, p.basicCluster)
+				}
 				err := p.GetStorage().RemoveJob(job.Type)
 				if err != nil {
 					p.logger.Error("fail to remove completed job",
@@ -71,9 +80,8 @@ func (p *defaultProphet) startJobs() {
 				continue
 			}
 
-			processor := p.cfg.Prophet.GetJobProcessor(job.Type)
 			if processor != nil {
-				processor.Start(job, p.storage, p.basicCluster)
+				p.startJobLocked(job, processor)
 				p.updateJobStatus(job, metapb.JobState_Working)
 				continue
 			}
@@ -81,9 +89,30 @@ func (p *defaultProphet) startJobs() {
 			p.logger.Error("missing job processor",
 				zap.String("type", job.Type.String()))
 		}
+		p.jobMu.started = true
 	})
 }
 
+// startJobLocked starts the job, the job is resumed from the latest checkpoint
+// if the processor supports and the checkpoint was saved.
+func (p *defaultProphet) startJobLocked(job metapb.Job, processor config.JobProcessor) {
+	if rp, ok := processor.(config.ResumableJobProcessor); ok {
+		checkpoint, err := p.storage.GetJobCheckpoint(job.Type)
+		if err != nil {
+			p.logger.Error("fail to load job checkpoint, restart the job",
+				zap.String("type", job.Type.String()),
+				zap.Error(err))
+		} else if checkpoint.Seq > 0 {
+			p.logger.Info("resume job",
+				zap.String("type", job.Type.String()),
+				zap.Uint64("checkpoint", checkpoint.Seq))
+			rp.Resume(job, checkpoint, p.storage, p.basicCluster)
+			return
+		}
+	}
+	processor.Start(job, p.storage, p.basicCluster)
+}
+
 func (p *defaultProphet) stopJobs() {
 	p.stopper.RunTask(context.Background(), func(ctx context.Context) {
 		p.logger.Info("stop jobs")
@@ -92,6 +121,7 @@ func (p *defaultProphet) stopJobs() {
 		p.jobMu.Lock()
 		defer p.jobMu.Unlock()
 
+		p.jobMu.started = false
 		p.logger.Info("stop jobs", zap.Int("count", len(p.jobMu.jobs)))
 		for _, job := range p.jobMu.jobs {
 			processor := p.cfg.Prophet.GetJobProcessor(job.Type)
@@ -120,6 +150,12 @@ func (p *defaultProphet) handleCreateJob(rc *cluster.RaftCluster, req *rpcpb.Pro
 		)
 	}
 
+	if !p.jobMu.started {
+		return util.WrappedError(
+			util.ErrJobProcessorStopped, "jobs not started",
+		)
+	}
+
 	if _, ok := p.jobMu.jobs[job.Type]; ok {
 		return nil
 	}
@@ -146,16 +182,32 @@ func (p *defaultProphet) handleRemoveJob(rc *cluster.RaftCluster, req *rpcpb.Pro
 		)
 	}
 
+	// the jobs are not loaded, the completion must not be treated as duplicated
+	if !p.jobMu.started {
+		return util.WrappedError(
+			util.ErrJobProcessorStopped, "jobs not started",
+		)
+	}
+
+	// the completion is reported again, e.g. retried after timeout or the
+	// prophet leader changed
 	if _, ok := p.jobMu.jobs[job.Type]; !ok {
 		return nil
 	}
 
+	// the completion is saved before the processor removes the job, so the
+	// removing is finished by the next leader if the current leader fails
 	if err := p.updateJobStatus(job, metapb.JobState_Completed); err != nil {
 		return err
 	}
 
 	processor.Remove(job, p.storage, p.basicCluster)
 	delete(p.jobMu.jobs, job.Type)
+	if err := p.GetStorage().RemoveJob(job.Type); err != nil {
+		p.logger.Error("fail to remove completed job, remove later",
+			zap.String("type", job.Type.String()),
+			zap.Error(err))
+	}
 	return nil
 }
 
@@ -168,7 +220,16 @@ func (p *defaultProphet) handleExecuteJob(rc *cluster.RaftCluster, req *rpcpb.Pr
 		)
 	}
 
-	if _, ok := p.jobMu.jobs[job.Type]; !ok {
+	p.jobMu.RLock()
+	started := p.jobMu.started
+	_, ok := p.jobMu.jobs[job.Type]
+	p.jobMu.RUnlock()
+	if !started {
+		return util.WrappedError(
+			util.ErrJobProcessorStopped, "jobs not started",
+		)
+	}
+	if !ok {
 		return util.WrappedError(
 			util.ErrJobNotFound, fmt.Sprintf("not created or not started, job type = %d", job.Type),
 		)
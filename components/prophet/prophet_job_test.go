@@ -14,12 +14,14 @@
 package prophet
 
 import (
+	"errors"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/matrixorigin/matrixcube/components/prophet/config"
 	"github.com/matrixorigin/matrixcube/components/prophet/storage"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/stretchr/testify/assert"
@@ -152,3 +154,136 @@ func TestStartAndStopAndRemoveJobs(t *testing.T) {
 		&rpcpb.ProphetResponse{Type: rpcpb.TypeRemoveJobRsp}))
 	assert.Equal(t, 3, jp.removeNum())
 }
+
+type testResumableJobProcessor struct {
+	*testJobProcessor
+	resumes     map[metapb.JobType]metapb.JobCheckpoint
+	removeCalls map[metapb.JobType]int
+}
+
+func newTestResumableJobProcessor() *testResumableJobProcessor {
+	return &testResumableJobProcessor{
+		testJobProcessor: newTestJobProcessor(),
+		resumes:          make(map[metapb.JobType]metapb.JobCheckpoint),
+		removeCalls:      make(map[metapb.JobType]int),
+	}
+}
+
+func (p *testResumableJobProcessor) Resume(job metapb.Job, checkpoint metapb.JobCheckpoint, s storage.JobStorage, aware config.ShardsAware) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.resumes[job.Type] = checkpoint
+	delete(p.stops, job.Type)
+}
+
+func (p *testResumableJobProcessor) Remove(job metapb.Job, s storage.JobStorage, aware config.ShardsAware) {
+	p.testJobProcessor.Remove(job, s, aware)
+
+	p.Lock()
+	defer p.Unlock()
+	p.removeCalls[job.Type]++
+}
+
+func (p *testResumableJobProcessor) getResume(jobType metapb.JobType) (metapb.JobCheckpoint, bool) {
+	p.RLock()
+	defer p.RUnlock()
+	checkpoint, ok := p.resumes[jobType]
+	return checkpoint, ok
+}
+
+func (p *testResumableJobProcessor) getRemoveCalls(jobType metapb.JobType) int {
+	p.RLock()
+	defer p.RUnlock()
+	return p.removeCalls[jobType]
+}
+
+func waitJobsStarted(t *testing.T, p *defaultProphet, started bool) {
+	for i := 0; i < 100; i++ {
+		p.jobMu.RLock()
+		v := p.jobMu.started
+		p.jobMu.RUnlock()
+		if v == started {
+			return
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+	assert.FailNow(t, "wait jobs started timeout")
+}
+
+func TestResumeJobs(t *testing.T) {
+	p := newTestSingleProphet(t, nil).(*defaultProphet)
+	defer p.Stop()
+	waitJobsStarted(t, p, true)
+
+	rc := p.GetRaftCluster()
+	jp := newTestResumableJobProcessor()
+	p.cfg.Prophet.RegisterJobProcessor(metapb.JobType(1), jp)
+	p.cfg.Prophet.RegisterJobProcessor(metapb.JobType(2), jp)
+	for _, jobType := range []metapb.JobType{1, 2} {
+		assert.NoError(t, p.handleCreateJob(rc,
+			&rpcpb.ProphetRequest{Type: rpcpb.TypeCreateJobReq,
+				CreateJob: rpcpb.CreateJobReq{Job: metapb.Job{Type: jobType}}},
+			&rpcpb.ProphetResponse{Type: rpcpb.TypeCreateJobRsp}))
+	}
+	assert.Equal(t, 2, jp.startNum())
+	assert.NoError(t, p.GetStorage().PutJobCheckpoint(metapb.JobCheckpoint{Type: 1, Seq: 1, Data: []byte("c1")}))
+
+	p.stopJobs()
+	waitJobsStarted(t, p, false)
+	assert.Equal(t, 2, jp.stopNum())
+
+	p.startJobs()
+	waitJobsStarted(t, p, true)
+	checkpoint, ok := jp.getResume(1)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("c1"), checkpoint.Data)
+	_, ok = jp.getResume(2)
+	assert.False(t, ok, "no checkpoint saved")
+	assert.Equal(t, 1, jp.startNum())
+	assert.Equal(t, 0, jp.stopNum())
+}
+
+func TestRemoveJobOnce(t *testing.T) {
+	p := newTestSingleProphet(t, nil).(*defaultProphet)
+	defer p.Stop()
+	waitJobsStarted(t, p, true)
+
+	rc := p.GetRaftCluster()
+	jp := newTestResumableJobProcessor()
+	p.cfg.Prophet.RegisterJobProcessor(metapb.JobType(1), jp)
+	p.cfg.Prophet.RegisterJobProcessor(metapb.JobType(2), jp)
+	req := &rpcpb.ProphetRequest{Type: rpcpb.TypeCreateJobReq,
+		CreateJob: rpcpb.CreateJobReq{Job: metapb.Job{Type: metapb.JobType(1)}}}
+	assert.NoError(t, p.handleCreateJob(rc, req, &rpcpb.ProphetResponse{}))
+	assert.NoError(t, p.GetStorage().PutJobCheckpoint(metapb.JobCheckpoint{Type: 1, Seq: 1}))
+
+	// the completion reported twice
+	req = &rpcpb.ProphetRequest{Type: rpcpb.TypeRemoveJobReq,
+		RemoveJob: rpcpb.RemoveJobReq{Job: metapb.Job{Type: metapb.JobType(1)}}}
+	assert.NoError(t, p.handleRemoveJob(rc, req, &rpcpb.ProphetResponse{}))
+	assert.NoError(t, p.handleRemoveJob(rc, req, &rpcpb.ProphetResponse{}))
+	assert.Equal(t, 1, jp.getRemoveCalls(1))
+	c := 0
+	assert.NoError(t, p.GetStorage().LoadJobs(16, func(job metapb.Job) { c++ }))
+	assert.Equal(t, 0, c)
+	checkpoint, err := p.GetStorage().GetJobCheckpoint(1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), checkpoint.Seq)
+
+	// the reports are rejected before the jobs loaded
+	p.stopJobs()
+	waitJobsStarted(t, p, false)
+	err = p.handleRemoveJob(rc, req, &rpcpb.ProphetResponse{})
+	assert.True(t, errors.Is(err, util.ErrJobProcessorStopped))
+
+	// the previous leader failed after the completion saved
+	assert.NoError(t, p.GetStorage().PutJob(metapb.Job{Type: metapb.JobType(2), State: metapb.JobState_Completed}))
+	p.startJobs()
+	waitJobsStarted(t, p, true)
+	assert.Equal(t, 1, jp.getRemoveCalls(2))
+	assert.Equal(t, 1, jp.startNum(), "the completed job is not started")
+	c = 0
+	assert.NoError(t, p.GetStorage().LoadJobs(16, func(job metapb.Job) { c++ }))
+	assert.Equal(t, 0, c)
+}
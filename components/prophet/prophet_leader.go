@@ -37,6 +37,8 @@ func (p *defaultProphet) becomeLeader() error {
 	p.notifyElectionComplete()
 	p.startJobs()
 	p.startCustom()
+	p.startApplyClusterSpec()
+	p.startPreferredLeaderChecker()
 	p.cfg.Prophet.Handler.ProphetBecomeLeader()
 	return nil
 }
@@ -50,6 +52,7 @@ func (p *defaultProphet) becomeFollower() error {
 	p.notifyElectionComplete()
 	p.stopJobs()
 	p.stopCustom()
+	p.stopPreferredLeaderChecker()
 	p.cfg.Prophet.Handler.ProphetBecomeFollower()
 	return nil
 }
@@ -90,9 +93,16 @@ func (p *defaultProphet) stopEventNotifer() {
 
 func (p *defaultProphet) initClient() {
 	p.clientOnce.Do(func() {
+		seeds := p.cfg.Prophet.RPCMembers
+		if p.cfg.Prophet.ProphetNode {
+			seeds = append([]string{p.cfg.Prophet.AdvertiseRPCAddr}, seeds...)
+		}
 		p.client = NewClient(
 			WithRPCTimeout(p.cfg.Prophet.RPCTimeout.Duration),
 			WithLeaderGetter(p.GetLeader),
+			WithSeedMembers(seeds...),
+			WithRequestDeadline(p.cfg.Prophet.ClientRequestDeadline.Duration),
+			WithShardHeartbeatFullSyncInterval(p.cfg.Prophet.ShardHeartbeatFullSyncInterval),
 			WithLogger(p.logger))
 	})
 }
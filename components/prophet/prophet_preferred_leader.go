@@ -0,0 +1,195 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prophet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/option"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+var (
+	membersPath = "/prophet/members"
+
+	memberRegisterRetryInterval = time.Second
+)
+
+// memberInfo the info of the prophet node registered with a lease, it's removed
+// if the prophet node is not alive.
+type memberInfo struct {
+	Name   string         `json:"name"`
+	Addr   string         `json:"addr"`
+	Labels []metapb.Label `json:"labels"`
+	// Value the member value of the prophet node in the leader election
+	Value string `json:"value"`
+}
+
+func getMemberPath(name string) string {
+	return fmt.Sprintf("%s/%s", membersPath, name)
+}
+
+// startMemberRegister registers the prophet node and keeps it alive, the
+// prophet leader transfers the leadership to the registered preferred node.
+func (p *defaultProphet) startMemberRegister() {
+	info := memberInfo{
+		Name:   p.cfg.Prophet.Name,
+		Addr:   p.cfg.Prophet.AdvertiseRPCAddr,
+		Labels: p.cfg.GetLabels(),
+		Value:  p.member.MemberValue(),
+	}
+	value, err := json.Marshal(info)
+	if err != nil {
+		p.logger.Fatal("fail to marshal prophet member info", zap.Error(err))
+	}
+
+	p.stopper.RunNamedTask(p.ctx, "prophet-member-register", func(ctx context.Context) {
+		for {
+			if err := p.registerMember(ctx, getMemberPath(info.Name), string(value)); err != nil {
+				p.logger.Error("fail to register prophet member, retry later",
+					zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(memberRegisterRetryInterval):
+			}
+		}
+	})
+}
+
+// registerMember puts the member info with a lease, and returns after the lease
+// is not kept alive.
+func (p *defaultProphet) registerMember(ctx context.Context, key, value string) error {
+	client := p.elector.Client()
+	grantCtx, cancel := context.WithTimeout(ctx, option.DefaultRequestTimeout)
+	lease, err := client.Grant(grantCtx, p.cfg.Prophet.LeaderLease)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	putCtx, cancel := context.WithTimeout(ctx, option.DefaultRequestTimeout)
+	_, err = client.Put(putCtx, key, value, clientv3.WithLease(lease.ID))
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	ch, err := client.KeepAlive(ctx, lease.ID)
+	if err != nil {
+		return err
+	}
+	for range ch {
+	}
+	return nil
+}
+
+// loadMembers returns the alive prophet nodes sorted by the names
+func (p *defaultProphet) loadMembers() ([]memberInfo, error) {
+	resp, err := util.GetEtcdResp(p.elector.Client(), membersPath+"/", clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]memberInfo, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var info memberInfo
+		if err := json.Unmarshal(kv.Value, &info); err != nil {
+			return nil, err
+		}
+		members = append(members, info)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Name < members[j].Name
+	})
+	return members, nil
+}
+
+func (p *defaultProphet) startPreferredLeaderChecker() {
+	cfg := p.cfg.Prophet.PreferredLeader
+	if !cfg.IsEnabled() {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopPreferredLeaderCheckerLocked()
+
+	ctx, cancel := context.WithCancel(p.ctx)
+	p.mu.cancelLeaderChecker = cancel
+	p.stopper.RunNamedTask(ctx, "preferred-leader-checker", func(ctx context.Context) {
+		ticker := time.NewTicker(cfg.CheckInterval.Duration)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.checkPreferredLeader(); err != nil {
+					p.logger.Error("fail to check preferred leader",
+						zap.Error(err))
+				}
+			}
+		}
+	})
+}
+
+func (p *defaultProphet) stopPreferredLeaderChecker() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopPreferredLeaderCheckerLocked()
+}
+
+func (p *defaultProphet) stopPreferredLeaderCheckerLocked() {
+	if p.mu.cancelLeaderChecker != nil {
+		p.mu.cancelLeaderChecker()
+		p.mu.cancelLeaderChecker = nil
+	}
+}
+
+// checkPreferredLeader transfers the leadership to the alive preferred prophet
+// node if the current leader is not preferred.
+func (p *defaultProphet) checkPreferredLeader() error {
+	cfg := &p.cfg.Prophet.PreferredLeader
+	if !p.member.IsLeader() ||
+		cfg.Match(p.cfg.Prophet.Name, p.cfg.GetLabels()) {
+		return nil
+	}
+
+	members, err := p.loadMembers()
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		if m.Name == p.cfg.Prophet.Name || !cfg.Match(m.Name, m.Labels) {
+			continue
+		}
+
+		p.logger.Info("transfer prophet leader to the preferred node",
+			zap.String("to", m.Name),
+			zap.String("addr", m.Addr))
+		return p.member.GetLeadership().ChangeLeaderTo(m.Value)
+	}
+	return nil
+}
@@ -0,0 +1,415 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prophet
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/cluster"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/placement"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRuleGroupID   = "prophet"
+	defaultRuleID        = "default"
+	learnerRuleID        = "learner"
+	clusterSpecUnique    = "cluster-spec"
+	createdGroupPrefix   = "cluster-spec-created-group-"
+	maxCreateShardsBatch = 4
+	applySpecRetryPeriod = time.Second
+)
+
+// ClusterSpec is the declarative spec of the cluster, prophet applies it every
+// time it becomes the leader. Applying the spec is idempotent, the rule groups
+// are reset to the spec, and the preset shards of a group are created only once.
+type ClusterSpec struct {
+	// MaxReplicas the replicas count of the default placement rule, 0 means
+	// keep the count of the replication config.
+	MaxReplicas int `json:"max_replicas,omitempty"`
+	// RuleGroups the placement rule groups and their rules
+	RuleGroups []placement.GroupBundle `json:"rule_groups,omitempty"`
+	// Groups the shard groups created at bootstrap
+	Groups []ShardGroupSpec `json:"groups,omitempty"`
+}
+
+// ShardGroupSpec is the spec of a shard group. The shards of the group are
+// created by the split keys, N split keys produce N+1 shards.
+type ShardGroupSpec struct {
+	Group uint64 `json:"group"`
+	// MaxReplicas the replicas count of the shards in the group, a rule group
+	// only applied to the shards of the group is created if it's not 0.
+	MaxReplicas int `json:"max_replicas,omitempty"`
+	// SplitKeys the hex format split keys, must be in ascending order
+	SplitKeys []string `json:"split_keys,omitempty"`
+	// Labels the labels of the shards in the group
+	Labels []metapb.Label `json:"labels,omitempty"`
+	// RuleGroups the rule groups applied to the shards in the group
+	RuleGroups []string `json:"rule_groups,omitempty"`
+	// Learners the count of the non-voting read replicas of the shards in the
+	// group. The learners are placed by a dedicated learner rule, they are
+	// never promoted to voters and are maintained independently from the voters.
+	Learners int `json:"learners,omitempty"`
+	// LearnerLabelConstraints the label constraints of the stores placing the
+	// learners, e.g. the stores in the read-only zone.
+	LearnerLabelConstraints []placement.LabelConstraint `json:"learner_label_constraints,omitempty"`
+
+	splitKeys [][]byte
+}
+
+// LoadClusterSpec loads the cluster spec from the json file
+func LoadClusterSpec(fs vfs.FS, file string) (*ClusterSpec, error) {
+	f, err := fs.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &ClusterSpec{}
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, err
+	}
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+func (s *ClusterSpec) validate() error {
+	if s.MaxReplicas < 0 {
+		return fmt.Errorf("invalid max replicas %d", s.MaxReplicas)
+	}
+
+	ruleGroups := make(map[string]struct{})
+	for _, g := range s.RuleGroups {
+		if g.ID == "" {
+			return fmt.Errorf("rule group ID should not be empty")
+		}
+		if _, ok := ruleGroups[g.ID]; ok {
+			return fmt.Errorf("duplicate rule group %s", g.ID)
+		}
+		ruleGroups[g.ID] = struct{}{}
+	}
+
+	groups := make(map[uint64]struct{})
+	for idx := range s.Groups {
+		g := &s.Groups[idx]
+		if _, ok := groups[g.Group]; ok {
+			return fmt.Errorf("duplicate shard group %d", g.Group)
+		}
+		groups[g.Group] = struct{}{}
+
+		if g.MaxReplicas < 0 {
+			return fmt.Errorf("invalid max replicas %d of shard group %d",
+				g.MaxReplicas, g.Group)
+		}
+		if g.Learners < 0 {
+			return fmt.Errorf("invalid learners %d of shard group %d",
+				g.Learners, g.Group)
+		}
+		if g.Learners == 0 && len(g.LearnerLabelConstraints) > 0 {
+			return fmt.Errorf("learner label constraints of shard group %d without learners",
+				g.Group)
+		}
+		for _, id := range g.RuleGroups {
+			if _, ok := ruleGroups[id]; !ok {
+				return fmt.Errorf("rule group %s of shard group %d not defined",
+					id, g.Group)
+			}
+		}
+
+		g.splitKeys = g.splitKeys[:0]
+		for _, v := range g.SplitKeys {
+			key, err := hex.DecodeString(v)
+			if err != nil {
+				return fmt.Errorf("invalid split key %s of shard group %d: %w",
+					v, g.Group, err)
+			}
+			if len(key) == 0 {
+				return fmt.Errorf("empty split key of shard group %d", g.Group)
+			}
+			if n := len(g.splitKeys); n > 0 && bytes.Compare(g.splitKeys[n-1], key) >= 0 {
+				return fmt.Errorf("split keys of shard group %d must be in ascending order",
+					g.Group)
+			}
+			g.splitKeys = append(g.splitKeys, key)
+		}
+	}
+	return nil
+}
+
+// ruleGroupID returns the ID of the rule group created by the MaxReplicas and
+// the Learners
+func (g *ShardGroupSpec) ruleGroupID() string {
+	return fmt.Sprintf("shard-group-%d", g.Group)
+}
+
+func (g *ShardGroupSpec) hasRuleGroup() bool {
+	return g.MaxReplicas > 0 || g.Learners > 0
+}
+
+// rules returns the rules of the rule group created by the MaxReplicas and the
+// Learners, the voters use the default count if the MaxReplicas is not set.
+func (g *ShardGroupSpec) rules(defaultVoters int, locationLabels []string) []*placement.Rule {
+	id := g.ruleGroupID()
+	voters := g.MaxReplicas
+	if voters == 0 {
+		voters = defaultVoters
+	}
+	rules := []*placement.Rule{
+		{
+			GroupID:        id,
+			ID:             defaultRuleID,
+			Role:           placement.Voter,
+			Count:          voters,
+			LocationLabels: locationLabels,
+		},
+	}
+	if g.Learners > 0 {
+		rules = append(rules, &placement.Rule{
+			GroupID:          id,
+			ID:               learnerRuleID,
+			Role:             placement.Learner,
+			Count:            g.Learners,
+			LabelConstraints: g.LearnerLabelConstraints,
+			LocationLabels:   locationLabels,
+		})
+	}
+	return rules
+}
+
+func (g *ShardGroupSpec) uniquePrefix() string {
+	return fmt.Sprintf("%s/%d/", clusterSpecUnique, g.Group)
+}
+
+func (g *ShardGroupSpec) ruleGroups() []string {
+	var values []string
+	if g.hasRuleGroup() {
+		values = append(values, g.ruleGroupID())
+	}
+	return append(values, g.RuleGroups...)
+}
+
+// shards returns the preset shards of the group, the unique of the shard is
+// derived from the index to avoid creating the shard repeatedly.
+func (g *ShardGroupSpec) shards() []metapb.Shard {
+	var shards []metapb.Shard
+	var start []byte
+	for idx := 0; idx <= len(g.splitKeys); idx++ {
+		var end []byte
+		if idx < len(g.splitKeys) {
+			end = g.splitKeys[idx]
+		}
+
+		shard := metapb.NewShard()
+		shard.Group = g.Group
+		shard.Start = start
+		shard.End = end
+		shard.Unique = fmt.Sprintf("%s%d", g.uniquePrefix(), idx)
+		shard.RuleGroups = g.ruleGroups()
+		shard.Labels = append([]metapb.Label(nil), g.Labels...)
+		shards = append(shards, *shard)
+		start = end
+	}
+	return shards
+}
+
+func (p *defaultProphet) startApplyClusterSpec() {
+	if p.spec == nil {
+		return
+	}
+
+	p.stopper.RunTask(context.Background(), func(ctx context.Context) {
+		p.logger.Info("start to apply cluster spec")
+		ticker := time.NewTicker(applySpecRetryPeriod)
+		defer ticker.Stop()
+
+		for {
+			if !p.member.IsLeader() {
+				p.logger.Info("stop applying cluster spec, not leader")
+				return
+			}
+
+			rc := p.GetRaftCluster()
+			if rc != nil {
+				err := p.applyClusterSpec(rc)
+				if err == nil {
+					p.logger.Info("cluster spec applied")
+					return
+				}
+				p.logger.Info("fail to apply cluster spec, retry later",
+					zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+func (p *defaultProphet) applyClusterSpec(rc *cluster.RaftCluster) error {
+	if err := p.applySpecRules(rc.GetRuleManager()); err != nil {
+		return err
+	}
+
+	created, err := p.loadSpecCreatedGroups()
+	if err != nil {
+		return err
+	}
+	for idx := range p.spec.Groups {
+		g := &p.spec.Groups[idx]
+		if _, ok := created[g.Group]; ok {
+			continue
+		}
+		if err := p.createSpecShards(rc, g); err != nil {
+			return err
+		}
+		// the preset shards may be merged later, mark the group as created to
+		// avoid creating the merged shards again.
+		if err := p.storage.PutCustomData(createdGroupKey(g.Group), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *defaultProphet) loadSpecCreatedGroups() (map[uint64]struct{}, error) {
+	created := make(map[uint64]struct{})
+	err := p.storage.LoadCustomData(16, func(k, v []byte) error {
+		key := string(k)
+		if !strings.HasPrefix(key, createdGroupPrefix) {
+			return nil
+		}
+		if group, err := strconv.ParseUint(key[len(createdGroupPrefix):], 10, 64); err == nil {
+			created[group] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func createdGroupKey(group uint64) []byte {
+	return []byte(fmt.Sprintf("%s%d", createdGroupPrefix, group))
+}
+
+func (p *defaultProphet) applySpecRules(m *placement.RuleManager) error {
+	if m == nil || !m.IsInitialized() {
+		return fmt.Errorf("rule manager not initialized")
+	}
+
+	if p.spec.MaxReplicas > 0 {
+		rule := m.GetRule(defaultRuleGroupID, defaultRuleID)
+		if rule != nil && rule.Count != p.spec.MaxReplicas {
+			rule = cloneRule(rule)
+			rule.Count = p.spec.MaxReplicas
+			if err := m.SetRule(rule); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, g := range p.spec.RuleGroups {
+		if err := m.SetGroupBundle(cloneGroupBundle(g)); err != nil {
+			return err
+		}
+	}
+
+	replication := p.cfg.Prophet.Replication
+	for _, g := range p.spec.Groups {
+		if !g.hasRuleGroup() {
+			continue
+		}
+
+		if err := m.SetGroupBundle(placement.GroupBundle{
+			ID:    g.ruleGroupID(),
+			Rules: g.rules(int(replication.MaxReplicas), replication.LocationLabels),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createSpecShards creates the preset shards of the group. The group is skipped
+// if it has shards not created by the spec, e.g. the shards created at the
+// stores bootstrap.
+func (p *defaultProphet) createSpecShards(rc *cluster.RaftCluster, g *ShardGroupSpec) error {
+	prefix := g.uniquePrefix()
+	for _, shard := range rc.GetShards() {
+		if shard.Meta.GetGroup() == g.Group &&
+			!strings.HasPrefix(shard.Meta.GetUnique(), prefix) {
+			p.logger.Info("skip creating shards of cluster spec, group already exists",
+				zap.Uint64("group", g.Group))
+			return nil
+		}
+	}
+
+	shards := g.shards()
+	for len(shards) > 0 {
+		n := len(shards)
+		if n > maxCreateShardsBatch {
+			n = maxCreateShardsBatch
+		}
+
+		req := &rpcpb.ProphetRequest{}
+		for _, shard := range shards[:n] {
+			data, err := shard.Marshal()
+			if err != nil {
+				return err
+			}
+			req.CreateShards.Shards = append(req.CreateShards.Shards, data)
+		}
+		if _, err := rc.HandleCreateShards(req); err != nil {
+			return err
+		}
+		shards = shards[n:]
+	}
+	return nil
+}
+
+func cloneGroupBundle(g placement.GroupBundle) placement.GroupBundle {
+	rules := make([]*placement.Rule, 0, len(g.Rules))
+	for _, r := range g.Rules {
+		rules = append(rules, cloneRule(r))
+	}
+	g.Rules = rules
+	return g
+}
+
+func cloneRule(r *placement.Rule) *placement.Rule {
+	v := *r
+	return &v
+}
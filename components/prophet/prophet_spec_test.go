@@ -0,0 +1,189 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prophet
+
+import (
+	"testing"
+	"time"
+
+	pconfig "github.com/matrixorigin/matrixcube/components/prophet/config"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/placement"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testClusterSpec = `{
+	"max_replicas": 3,
+	"rule_groups": [
+		{
+			"group_id": "ssd",
+			"rules": [
+				{"id": "default", "role": "voter", "count": 1}
+			]
+		}
+	],
+	"groups": [
+		{
+			"group": 1,
+			"max_replicas": 2,
+			"split_keys": ["61", "62"],
+			"labels": [{"key": "table", "value": "t1"}],
+			"rule_groups": ["ssd"]
+		}
+	]
+}`
+)
+
+func writeTestClusterSpec(t *testing.T, fs vfs.FS, spec string) string {
+	dir := t.TempDir()
+	require.NoError(t, fs.MkdirAll(dir, 0755))
+	file := fs.PathJoin(dir, "spec.json")
+	f, err := fs.Create(file)
+	require.NoError(t, err)
+	_, err = f.Write([]byte(spec))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	return file
+}
+
+func TestLoadClusterSpec(t *testing.T) {
+	cases := []struct {
+		spec string
+		ok   bool
+	}{
+		{spec: testClusterSpec, ok: true},
+		{spec: `{}`, ok: true},
+		{spec: `{"max_replicas": -1}`, ok: false},
+		{spec: `{"rule_groups": [{"group_id": ""}]}`, ok: false},
+		{spec: `{"rule_groups": [{"group_id": "g"}, {"group_id": "g"}]}`, ok: false},
+		{spec: `{"groups": [{"group": 1}, {"group": 1}]}`, ok: false},
+		{spec: `{"groups": [{"group": 1, "rule_groups": ["g"]}]}`, ok: false},
+		{spec: `{"groups": [{"group": 1, "split_keys": ["zz"]}]}`, ok: false},
+		{spec: `{"groups": [{"group": 1, "split_keys": [""]}]}`, ok: false},
+		{spec: `{"groups": [{"group": 1, "split_keys": ["62", "61"]}]}`, ok: false},
+		{spec: `{"groups": [{"group": 1, "split_keys": ["61", "61"]}]}`, ok: false},
+		{spec: `{"groups": [{"group": 1, "learners": -1}]}`, ok: false},
+		{spec: `{"groups": [{"group": 1, "learner_label_constraints": [{"key": "zone", "op": "in", "values": ["z1"]}]}]}`, ok: false},
+	}
+
+	fs := vfs.NewMemFS()
+	for i, c := range cases {
+		_, err := LoadClusterSpec(fs, writeTestClusterSpec(t, fs, c.spec))
+		assert.Equal(t, c.ok, err == nil, "index %d", i)
+	}
+}
+
+func TestClusterSpecShards(t *testing.T) {
+	fs := vfs.NewMemFS()
+	spec, err := LoadClusterSpec(fs, writeTestClusterSpec(t, fs, testClusterSpec))
+	require.NoError(t, err)
+
+	shards := spec.Groups[0].shards()
+	require.Equal(t, 3, len(shards))
+	assert.Equal(t, []byte(nil), shards[0].Start)
+	assert.Equal(t, []byte("a"), shards[0].End)
+	assert.Equal(t, []byte("a"), shards[1].Start)
+	assert.Equal(t, []byte("b"), shards[1].End)
+	assert.Equal(t, []byte("b"), shards[2].Start)
+	assert.Equal(t, []byte(nil), shards[2].End)
+	for idx, shard := range shards {
+		assert.Equal(t, uint64(1), shard.Group)
+		assert.Equal(t, []string{"shard-group-1", "ssd"}, shard.RuleGroups)
+		assert.Equal(t, []metapb.Label{{Key: "table", Value: "t1"}}, shard.Labels)
+		for _, other := range shards[idx+1:] {
+			assert.NotEqual(t, shard.Unique, other.Unique)
+		}
+	}
+}
+
+func TestClusterSpecLearners(t *testing.T) {
+	fs := vfs.NewMemFS()
+	spec, err := LoadClusterSpec(fs, writeTestClusterSpec(t, fs, `{
+	"groups": [
+		{
+			"group": 1,
+			"learners": 2,
+			"learner_label_constraints": [{"key": "zone", "op": "in", "values": ["read"]}]
+		}
+	]
+}`))
+	require.NoError(t, err)
+
+	g := spec.Groups[0]
+	assert.Equal(t, []string{"shard-group-1"}, g.shards()[0].RuleGroups)
+	rules := g.rules(3, []string{"zone"})
+	require.Equal(t, 2, len(rules))
+	assert.Equal(t, placement.Voter, rules[0].Role)
+	assert.Equal(t, 3, rules[0].Count, "default voters")
+	assert.Equal(t, placement.Learner, rules[1].Role)
+	assert.Equal(t, 2, rules[1].Count)
+	assert.Equal(t, g.LearnerLabelConstraints, rules[1].LabelConstraints)
+	assert.Equal(t, "shard-group-1", rules[1].GroupID)
+}
+
+func TestApplyClusterSpec(t *testing.T) {
+	fs := vfs.GetTestFS()
+	file := writeTestClusterSpec(t, fs, testClusterSpec)
+
+	c := pconfig.NewConfig()
+	c.ProphetNode = true
+	c.TestContext = pconfig.NewTestContext()
+	c.ClusterSpecFile = file
+	p := newTestProphet(t, c, fs)
+	defer p.Stop()
+
+	client := p.GetClient()
+	for id := uint64(1); id <= 3; id++ {
+		assert.NoError(t, client.PutStore(newTestStoreMeta(id)))
+		_, err := client.StoreHeartbeat(newTestStoreHeartbeat(id, 1))
+		assert.NoError(t, err)
+	}
+
+	dp := p.(*defaultProphet)
+	rc := dp.GetRaftCluster()
+	require.NotNil(t, rc)
+	waitingShards := func() []metapb.Shard {
+		var shards []metapb.Shard
+		rc.GetCacheCluster().ForeachWaitingCreateShards(func(res metapb.Shard) {
+			shards = append(shards, res)
+		})
+		return shards
+	}
+	for i := 0; i < 50; i++ {
+		if len(waitingShards()) == 3 {
+			break
+		}
+		time.Sleep(time.Millisecond * 100)
+	}
+	assert.Equal(t, 3, len(waitingShards()))
+
+	m := rc.GetRuleManager()
+	assert.Equal(t, 3, m.GetRule(defaultRuleGroupID, defaultRuleID).Count)
+	assert.NotNil(t, m.GetRuleGroup("ssd"))
+	assert.Equal(t, 1, len(m.GetRulesByGroup("ssd")))
+	require.Equal(t, 1, len(m.GetRulesByGroup("shard-group-1")))
+	assert.Equal(t, 2, m.GetRulesByGroup("shard-group-1")[0].Count)
+
+	created, err := dp.loadSpecCreatedGroups()
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint64]struct{}{1: {}}, created)
+
+	// apply again
+	assert.NoError(t, dp.applyClusterSpec(rc))
+	assert.Equal(t, 3, len(waitingShards()))
+	assert.Equal(t, 1, len(m.GetRulesByGroup("ssd")))
+}
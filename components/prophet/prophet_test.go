@@ -62,6 +62,35 @@ func TestClusterStart(t *testing.T) {
 	assert.Equal(t, 3, followerCount)
 }
 
+func TestPreferredLeader(t *testing.T) {
+	cluster := newTestClusterProphet(t, 3, func(c *pconfig.Config) {
+		c.PreferredLeader.Name = "n-2"
+		c.PreferredLeader.CheckInterval.Duration = time.Millisecond * 100
+	})
+	defer func() {
+		for _, p := range cluster {
+			p.Stop()
+		}
+	}()
+
+	timeout := time.After(time.Second * 30)
+	for {
+		transferred := cluster[2].GetMember().IsLeader()
+		for _, p := range cluster {
+			transferred = transferred && p.GetLeader().GetName() == "n-2"
+		}
+		if transferred {
+			break
+		}
+
+		select {
+		case <-timeout:
+			assert.FailNow(t, "timeout to transfer prophet leader to the preferred node")
+		case <-time.After(time.Millisecond * 100):
+		}
+	}
+}
+
 func newTestSingleProphet(t *testing.T, adjustFunc func(*pconfig.Config)) Prophet {
 	c := pconfig.NewConfig()
 	c.ProphetNode = true
@@ -52,7 +52,8 @@ func (r *LeaseChecker) Check(res *core.CachedShard) *operator.Operator {
 		return nil
 	}
 
-	if len(res.Meta.GetReplicas()) != r.opts.GetMaxReplicas() {
+	// the learners are not counted, the read replicas never hold the lease
+	if len(res.GetVoters()) != r.opts.GetMaxReplicas() {
 		return nil
 	}
 
@@ -42,3 +42,19 @@ func TestTransferLeaseWithLeaseAndLeaderIsSame(t *testing.T) {
 	op := lc.Check(shard.Clone(core.WithLease(&metapb.EpochLease{Epoch: 1, ReplicaID: shard.Meta.Replicas[0].ID})))
 	assert.Nil(t, op)
 }
+
+func TestTransferLeaseWithLearner(t *testing.T) {
+	cfg := config.NewTestOptions()
+	cluster := mockcluster.NewCluster(cfg)
+	lc := NewLeaseChecker(cluster)
+
+	for i := uint64(1); i <= 4; i++ {
+		cluster.AddShardStore(i, 1)
+	}
+	cluster.AddLeaderShard(1, 1, 2, 3)
+
+	// the read replica is not counted as the replicas
+	shard := cluster.GetShard(1)
+	shard = shard.Clone(core.WithAddPeer(metapb.Replica{ID: 100, StoreID: 4, Role: metapb.ReplicaRole_Learner}))
+	assert.NotNil(t, lc.Check(shard))
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/matrixorigin/matrixcube/components/prophet/schedule/opt"
 	"github.com/matrixorigin/matrixcube/components/prophet/schedule/placement"
 	"github.com/matrixorigin/matrixcube/components/prophet/util"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"go.uber.org/zap"
 )
 
@@ -88,8 +89,9 @@ func (m *MergeChecker) Check(res *core.CachedShard) []*operator.Operator {
 	}
 
 	// resource is not small enough
-	if res.GetApproximateSize() > int64(m.opts.GetMaxMergeShardSize()) ||
-		res.GetApproximateKeys() > int64(m.opts.GetMaxMergeShardKeys()) {
+	group := res.Meta.GetGroup()
+	if res.GetApproximateSize() > int64(m.opts.GetMaxMergeShardSizeByGroup(group)) ||
+		res.GetApproximateKeys() > int64(m.opts.GetMaxMergeShardKeysByGroup(group)) {
 		checkerCounter.WithLabelValues("merge_checker", "no-need").Inc()
 		return nil
 	}
@@ -122,7 +124,7 @@ func (m *MergeChecker) Check(res *core.CachedShard) []*operator.Operator {
 		return nil
 	}
 
-	if target.GetApproximateSize() > maxTargetShardSize {
+	if target.GetApproximateSize() > m.maxTargetShardSize(group) {
 		checkerCounter.WithLabelValues("merge_checker", "target-too-large").Inc()
 		return nil
 	}
@@ -145,8 +147,20 @@ func (m *MergeChecker) Check(res *core.CachedShard) []*operator.Operator {
 	return ops
 }
 
+// maxTargetShardSize returns the max size in MB of the merge target, which is
+// the shard capacity if the shard group has a size class.
+func (m *MergeChecker) maxTargetShardSize(group uint64) int64 {
+	if class, ok := m.opts.GetReplicationConfig().GetShardSizeClass(group); ok && class.ShardCapacityBytes > 0 {
+		return int64(class.ShardCapacityBytes / (1 << 20))
+	}
+	return maxTargetShardSize
+}
+
+// checkTarget returns false if the adjacent shard is merging into another
+// shard, it's frozen and can't commit any merge.
 func (m *MergeChecker) checkTarget(region, adjacent *core.CachedShard) bool {
 	return adjacent != nil && !m.splitCache.Exists(adjacent.Meta.GetID()) &&
+		adjacent.Meta.GetState() != metapb.ShardState_Merging &&
 		AllowMerge(m.cluster, region, adjacent) && opt.IsShardHealthy(m.cluster, adjacent) &&
 		opt.IsShardReplicated(m.cluster, adjacent)
 }
@@ -166,7 +180,7 @@ func AllowMerge(cluster opt.Cluster, res *core.CachedShard, adjacent *core.Cache
 			GetRuleManager() *placement.RuleManager
 		}
 		cl, ok := cluster.(withRuleManager)
-		if !ok || len(cl.GetRuleManager().GetSplitKeys(start, end)) > 0 {
+		if !ok || len(cl.GetRuleManager().GetShardSplitKeys(res.Meta.GetGroup(), start, end)) > 0 {
 			return false
 		}
 	}
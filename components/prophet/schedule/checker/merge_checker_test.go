@@ -225,6 +225,55 @@ func TestBasic(t *testing.T) {
 	assert.Nil(t, ops)
 }
 
+func TestSkipMergingTarget(t *testing.T) {
+	s := &testMergeChecker{}
+	s.setup()
+	defer s.tearDown()
+
+	s.cluster.SetSplitMergeInterval(0)
+	s.cluster.PutShard(s.resources[1].Clone(core.SetApproximateSize(200)))
+	ops := s.mc.Check(s.resources[2])
+	assert.NotNil(t, ops)
+	assert.Equal(t, s.resources[1].Meta.GetID(), ops[1].ShardID())
+
+	// the target shard is merging into another shard
+	s.cluster.PutShard(s.resources[1].Clone(core.SetApproximateSize(200),
+		core.WithState(metapb.ShardState_Merging)))
+	ops = s.mc.Check(s.resources[2])
+	assert.Empty(t, ops)
+}
+
+func TestMergeWithShardSizeClass(t *testing.T) {
+	s := &testMergeChecker{}
+	s.setup()
+	defer s.tearDown()
+
+	s.cluster.SetSplitMergeInterval(0)
+	s.mc.startTime = time.Now().Add(-2 * time.Hour)
+
+	// resource 2 is too large to merge by the global value
+	ops := s.mc.Check(s.resources[1])
+	assert.Empty(t, ops)
+
+	s.cluster.SetShardSizeClasses(config.ShardSizeClass{Group: 0, MaxMergeShardSize: 300, MaxMergeShardKeys: 300})
+	ops = s.mc.Check(s.resources[1])
+	assert.NotEmpty(t, ops)
+	assert.Equal(t, s.resources[1].Meta.GetID(), ops[0].ShardID())
+	assert.Equal(t, s.resources[2].Meta.GetID(), ops[1].ShardID())
+
+	// resource 2 is too large to be the target by the shard capacity
+	ops = s.mc.Check(s.resources[2])
+	assert.NotEmpty(t, ops)
+	s.cluster.SetShardSizeClasses(config.ShardSizeClass{Group: 0, ShardCapacityBytes: 100 * (1 << 20)})
+	ops = s.mc.Check(s.resources[2])
+	assert.Empty(t, ops)
+
+	// the size class of the other group doesn't take effect
+	s.cluster.SetShardSizeClasses(config.ShardSizeClass{Group: 1, MaxMergeShardSize: 300, MaxMergeShardKeys: 300})
+	ops = s.mc.Check(s.resources[1])
+	assert.Empty(t, ops)
+}
+
 func TestMatchPeers(t *testing.T) {
 	s := &testMergeChecker{}
 	s.setup()
@@ -36,6 +36,19 @@ const (
 	downStatus    = "down"
 )
 
+// IsRepairOperator returns true if the operator is created by the replica checker
+// or the rule checker to re-create a replica of a down or offline container.
+func IsRepairOperator(op *operator.Operator) bool {
+	switch op.Desc() {
+	case "replace-" + downStatus + "-replica",
+		"replace-" + offlineStatus + "-replica",
+		"replace-rule-" + downStatus + "-peer",
+		"replace-rule-" + offlineStatus + "-peer":
+		return true
+	}
+	return false
+}
+
 // ReplicaChecker ensures resource has the best replicas.
 // Including the following:
 // Replica number management.
@@ -138,11 +151,16 @@ func (r *ReplicaChecker) checkDownPeer(res *core.CachedShard) *operator.Operator
 				zap.Uint64("container", containerID))
 			return nil
 		}
-		if container.DownTime() < r.opts.GetMaxStoreDownTime() {
-			continue
-		}
-		if stats.GetDownSeconds() < uint64(r.opts.GetMaxStoreDownTime().Seconds()) {
-			continue
+		// the down peer which can only catch up by a snapshot is repaired without
+		// waiting for the grace period, the snapshot is required even if the
+		// container recovered.
+		if !needSnapshot(res, peer.ID) {
+			if container.DownTime() < r.opts.GetDownStoreRepairGracePeriod() {
+				continue
+			}
+			if stats.GetDownSeconds() < uint64(r.opts.GetDownStoreRepairGracePeriod().Seconds()) {
+				continue
+			}
 		}
 
 		return r.fixPeer(res, containerID, downStatus)
@@ -193,7 +211,7 @@ func (r *ReplicaChecker) checkMakeUpReplica(res *core.CachedShard) *operator.Ope
 		log.ResourceField(res.Meta.GetID()),
 		zap.Int("peers", len(res.Meta.GetReplicas())))
 	resourceStores := r.cluster.GetShardStores(res)
-	target := r.strategy(res).SelectStoreToAdd(resourceStores)
+	target := r.strategy(res).SelectStoreToRepair(resourceStores)
 	if target == 0 {
 		r.cluster.GetLogger().Debug("no container to add replica for resource",
 			log.ResourceField(res.Meta.GetID()))
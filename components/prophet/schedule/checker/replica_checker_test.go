@@ -205,6 +205,31 @@ func TestFillReplicas(t *testing.T) {
 	assert.Equal(t, rc.cluster.GetOpts().GetMaxReplicas(), len(res.Meta.GetReplicas()))
 }
 
+func TestReplicaCheckerCountLimit(t *testing.T) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+	rc := NewReplicaChecker(tc, cache.NewDefaultCache(10))
+
+	tc.AddShardStore(1, 4)
+	tc.AddShardStore(2, 3)
+	tc.AddShardStore(3, 2)
+	tc.AddShardStore(4, 1)
+	tc.SetStoreCountLimit(4, 1, 0)
+
+	// the missing replica is repaired regardless of the limit
+	tc.AddLeaderShard(1, 1, 2)
+	testutil.CheckAddPeer(t, rc.Check(tc.GetShard(1)), operator.OpReplica, 4)
+
+	// the new shard is not placed on the container reached the limit
+	res := core.NewTestCachedShard(nil, nil)
+	assert.NoError(t, rc.FillReplicas(res, 0))
+	assert.Equal(t, []uint64{3, 2, 1}, []uint64{
+		res.Meta.GetReplicas()[0].StoreID,
+		res.Meta.GetReplicas()[1].StoreID,
+		res.Meta.GetReplicas()[2].StoreID,
+	})
+}
+
 func TestDownPeer(t *testing.T) {
 	s := &testReplicaChecker{}
 	s.setup()
@@ -558,3 +583,33 @@ func TestOpts(t *testing.T) {
 	tc.SetEnableReplaceOfflineReplica(false)
 	assert.Nil(t, rc.Check(resource))
 }
+
+func TestDownPeerNeedSnapshot(t *testing.T) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+	rc := NewReplicaChecker(tc, cache.NewDefaultCache(10))
+
+	tc.AddShardStore(1, 100)
+	tc.AddShardStore(2, 100)
+	tc.AddShardStore(3, 100)
+	tc.AddShardStore(4, 100)
+	tc.AddLeaderShard(1, 1, 2, 3)
+
+	// the peer is down recently, wait for the grace period
+	resource := tc.GetShard(1)
+	p, ok := resource.GetStorePeer(2)
+	assert.True(t, ok)
+	resource = resource.Clone(core.WithDownPeers([]metapb.ReplicaStats{
+		{
+			Replica:     p,
+			DownSeconds: 60,
+		},
+	}))
+	assert.Nil(t, rc.Check(resource))
+
+	// the down peer can only catch up by a snapshot, repair it directly
+	resource = resource.Clone(core.WithReplicaProgresses([]metapb.ReplicaProgress{
+		{Replica: p, NeedSnapshot: true},
+	}))
+	testutil.CheckTransferPeer(t, rc.Check(resource), operator.OpReplica, 2, 4)
+}
@@ -0,0 +1,144 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/config"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/operator"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/opt"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/placement"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+// IsDriftRepairOperator returns true if the operator is created by the replica
+// checker or the rule checker to add or remove a replica because the replica
+// count of the shard deviates from the target.
+func IsDriftRepairOperator(op *operator.Operator) bool {
+	switch op.Desc() {
+	case "make-up-replica",
+		"remove-extra-replica",
+		"add-rule-peer",
+		"remove-orphan-peer":
+		return true
+	}
+	return false
+}
+
+// ReplicaDriftChecker detects the shards whose replica count deviates from the
+// target of the shard group, e.g. over or under replicated because of the
+// operators failed in the middle. The drifts are repaired by the replica checker
+// or the rule checker, this checker records since when each shard drifts, so the
+// repairs can be paced, and the drifted shards can be listed.
+type ReplicaDriftChecker struct {
+	cluster     opt.Cluster
+	opts        *config.PersistOptions
+	ruleManager *placement.RuleManager
+
+	mu     sync.RWMutex
+	drifts map[uint64]rpcpb.ReplicaDrift
+}
+
+// NewReplicaDriftChecker creates a replica drift checker.
+func NewReplicaDriftChecker(cluster opt.Cluster, ruleManager *placement.RuleManager) *ReplicaDriftChecker {
+	return &ReplicaDriftChecker{
+		cluster:     cluster,
+		opts:        cluster.GetOpts(),
+		ruleManager: ruleManager,
+		drifts:      make(map[uint64]rpcpb.ReplicaDrift),
+	}
+}
+
+// GetType return ReplicaDriftChecker's type
+func (c *ReplicaDriftChecker) GetType() string {
+	return "replica-drift-checker"
+}
+
+// Check updates the drift of the shard, returns the drift and true if the
+// replica count of the shard deviates from the target.
+func (c *ReplicaDriftChecker) Check(res *core.CachedShard) (rpcpb.ReplicaDrift, bool) {
+	checkerCounter.WithLabelValues("replica_drift_checker", "check").Inc()
+	id := res.Meta.GetID()
+	current := uint64(len(res.Meta.GetReplicas()))
+	target := c.getTarget(res)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if target == 0 || current == target || res.IsDestroyState() {
+		delete(c.drifts, id)
+		return rpcpb.ReplicaDrift{}, false
+	}
+
+	drift, ok := c.drifts[id]
+	if !ok {
+		checkerCounter.WithLabelValues("replica_drift_checker", "drift-found").Inc()
+		drift = rpcpb.ReplicaDrift{ShardID: id, Since: time.Now().UnixNano()}
+	}
+	drift.Group = res.Meta.GetGroup()
+	drift.Replicas = current
+	drift.Target = target
+	c.drifts[id] = drift
+	return drift, true
+}
+
+// GetDrift returns the drift of the shard found by the last check
+func (c *ReplicaDriftChecker) GetDrift(id uint64) (rpcpb.ReplicaDrift, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	drift, ok := c.drifts[id]
+	return drift, ok
+}
+
+// GetDrifts returns the drifts of the shards ordered by the shard id, the drifts
+// of the removed shards are dropped. All the drifts are returned if the limit is
+// 0.
+func (c *ReplicaDriftChecker) GetDrifts(limit int) []rpcpb.ReplicaDrift {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	drifts := make([]rpcpb.ReplicaDrift, 0, len(c.drifts))
+	for id, drift := range c.drifts {
+		if c.cluster.GetShard(id) == nil {
+			delete(c.drifts, id)
+			continue
+		}
+		drifts = append(drifts, drift)
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].ShardID < drifts[j].ShardID
+	})
+	if limit > 0 && len(drifts) > limit {
+		drifts = drifts[:limit]
+	}
+	return drifts
+}
+
+// getTarget returns the replica count of the shard required by the placement
+// rules if enabled, otherwise the max replicas.
+func (c *ReplicaDriftChecker) getTarget(res *core.CachedShard) uint64 {
+	if c.opts.IsPlacementRulesEnabled() {
+		if c.ruleManager == nil {
+			return 0
+		}
+		target := 0
+		for _, rule := range c.ruleManager.GetRulesForApplyShard(res) {
+			target += rule.Count
+		}
+		return uint64(target)
+	}
+	return uint64(c.opts.GetMaxReplicas())
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/config"
+	"github.com/matrixorigin/matrixcube/components/prophet/mock/mockcluster"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/operator"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/placement"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func driftShards(drifts []rpcpb.ReplicaDrift) []uint64 {
+	var ids []uint64
+	for _, d := range drifts {
+		ids = append(ids, d.ShardID)
+	}
+	return ids
+}
+
+func TestReplicaDriftChecker(t *testing.T) {
+	cluster := mockcluster.NewCluster(config.NewTestOptions())
+	for id := uint64(1); id <= 4; id++ {
+		cluster.AddLeaderStore(id, 1)
+	}
+	c := NewReplicaDriftChecker(cluster, cluster.RuleManager)
+
+	_, ok := c.Check(cluster.AddLeaderShard(1, 1, 2, 3))
+	assert.False(t, ok)
+	under, ok := c.Check(cluster.AddLeaderShard(2, 1, 2))
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), under.Replicas)
+	assert.Equal(t, uint64(3), under.Target)
+	over, ok := c.Check(cluster.AddLeaderShard(3, 1, 2, 3, 4))
+	require.True(t, ok)
+	assert.Equal(t, uint64(4), over.Replicas)
+	assert.Equal(t, []uint64{2, 3}, driftShards(c.GetDrifts(0)))
+	assert.Equal(t, []uint64{2}, driftShards(c.GetDrifts(1)))
+
+	// the time found is kept until the drift is repaired
+	drift, ok := c.Check(cluster.GetShard(2))
+	require.True(t, ok)
+	assert.Equal(t, under.Since, drift.Since)
+	_, ok = c.Check(cluster.AddLeaderShard(2, 1, 2, 3))
+	assert.False(t, ok)
+	_, ok = c.GetDrift(2)
+	assert.False(t, ok)
+
+	// the drifts of the removed shards are dropped
+	cluster.RemoveShard(cluster.GetShard(3))
+	assert.Empty(t, c.GetDrifts(0))
+
+	// the target is the replica count required by the placement rules
+	cluster.SetEnablePlacementRules(true)
+	c = NewReplicaDriftChecker(cluster, cluster.RuleManager)
+	_, ok = c.Check(cluster.GetShard(1))
+	assert.False(t, ok)
+	require.NoError(t, cluster.RuleManager.SetRule(&placement.Rule{
+		GroupID: "test",
+		ID:      "learner",
+		Role:    placement.Learner,
+		Count:   1,
+	}))
+	drift, ok = c.Check(cluster.GetShard(1))
+	require.True(t, ok)
+	assert.Equal(t, uint64(4), drift.Target)
+}
+
+func TestIsDriftRepairOperator(t *testing.T) {
+	newOp := func(desc string) *operator.Operator {
+		return operator.NewOperator(desc, "test", 1, metapb.ShardEpoch{}, operator.OpReplica,
+			operator.AddLearner{ToStore: 1})
+	}
+	for _, desc := range []string{"make-up-replica", "remove-extra-replica", "add-rule-peer", "remove-orphan-peer"} {
+		assert.True(t, IsDriftRepairOperator(newOp(desc)), desc)
+	}
+	for _, desc := range []string{"replace-down-replica", "move-to-better-location", "remove-extra-down-replica"} {
+		assert.False(t, IsDriftRepairOperator(newOp(desc)), desc)
+	}
+}
@@ -43,6 +43,16 @@ type ReplicaStrategy struct {
 // Meanwhile, we need to provide more constraints to ensure that the isolation
 // level cannot be reduced after replacement.
 func (s *ReplicaStrategy) SelectStoreToAdd(coLocationStores []*core.CachedStore, extraFilters ...filter.Filter) uint64 {
+	return s.selectStoreToAdd(coLocationStores, false, extraFilters...)
+}
+
+// SelectStoreToRepair same as SelectStoreToAdd, but the replica count limits of
+// the containers are ignored. It's used to make up the missing replicas.
+func (s *ReplicaStrategy) SelectStoreToRepair(coLocationStores []*core.CachedStore, extraFilters ...filter.Filter) uint64 {
+	return s.selectStoreToAdd(coLocationStores, true, extraFilters...)
+}
+
+func (s *ReplicaStrategy) selectStoreToAdd(coLocationStores []*core.CachedStore, repair bool, extraFilters ...filter.Filter) uint64 {
 	// The selection process uses a two-stage fashion. The first stage
 	// ignores the temporary state of the containers and selects the containers
 	// with the highest score according to the location label. The second
@@ -55,7 +65,7 @@ func (s *ReplicaStrategy) SelectStoreToAdd(coLocationStores []*core.CachedStore,
 		filter.NewExcludedFilter(s.checkerName, nil, s.resource.GetStoreIDs()),
 		filter.NewStorageThresholdFilter(s.checkerName),
 		filter.NewSpecialUseFilter(s.checkerName),
-		&filter.StoreStateFilter{ActionScope: s.checkerName, MoveShard: true, AllowTemporaryStates: true},
+		&filter.StoreStateFilter{ActionScope: s.checkerName, MoveShard: true, AllowTemporaryStates: true, AllowExceedCountLimit: repair},
 	}
 	if len(s.locationLabels) > 0 && s.isolationLevel != "" {
 		filters = append(filters, filter.NewIsolationFilter(s.checkerName, s.isolationLevel, s.locationLabels, coLocationStores))
@@ -68,7 +78,7 @@ func (s *ReplicaStrategy) SelectStoreToAdd(coLocationStores []*core.CachedStore,
 	}
 
 	isolationComparer := filter.IsolationComparer(s.locationLabels, coLocationStores)
-	strictStateFilter := &filter.StoreStateFilter{ActionScope: s.checkerName, MoveShard: true}
+	strictStateFilter := &filter.StoreStateFilter{ActionScope: s.checkerName, MoveShard: true, AllowExceedCountLimit: repair}
 	target := filter.NewCandidates(s.cluster.GetStores()).
 		FilterTarget(s.cluster.GetOpts(), filters...).
 		Sort(isolationComparer).Reverse().Top(isolationComparer).                       // greater isolation score is better
@@ -81,13 +91,14 @@ func (s *ReplicaStrategy) SelectStoreToAdd(coLocationStores []*core.CachedStore,
 }
 
 // SelectStoreToReplace returns a container to replace oldStore. The location
-// placement after scheduling should be not worse than original.
+// placement after scheduling should be not worse than original. It's used to
+// replace the unhealthy replicas, so the replica count limits are ignored.
 func (s *ReplicaStrategy) SelectStoreToReplace(coLocationStores []*core.CachedStore, old uint64) uint64 {
 	// trick to avoid creating a slice with `old` removed.
 	s.swapStoreToFirst(coLocationStores, old)
 	safeGuard := filter.NewLocationSafeguard(s.checkerName, s.locationLabels, coLocationStores,
 		s.cluster.GetStore(old))
-	return s.SelectStoreToAdd(coLocationStores[1:], safeGuard)
+	return s.SelectStoreToRepair(coLocationStores[1:], safeGuard)
 }
 
 // SelectStoreToImprove returns a container to replace oldStore. The location
@@ -104,10 +104,10 @@ func (c *RuleChecker) Check(res *core.CachedShard) *operator.Operator {
 	checkerCounter.WithLabelValues("rule_checker", "check").Inc()
 
 	fit := c.cluster.FitShard(res)
-	if len(fit.RuleFits) == 0 {
+	if len(fit.RuleFits) == 0 || c.ruleManager.IsAcrossKeyspaces(res) {
 		checkerCounter.WithLabelValues("rule_checker", "fix-range").Inc()
 		// If the resource matches no rules, the most possible reason is it spans across
-		// multiple rules.
+		// multiple rules. The shards across the keyspaces are split as well.
 		return c.fixRange(res)
 	}
 
@@ -143,7 +143,7 @@ func (c *RuleChecker) fixRange(res *core.CachedShard) *operator.Operator {
 		return nil
 	}
 
-	keys := c.ruleManager.GetSplitKeys(res.GetStartKey(), res.GetEndKey())
+	keys := c.ruleManager.GetShardSplitKeys(res.Meta.GetGroup(), res.GetStartKey(), res.GetEndKey())
 	if len(keys) == 0 {
 		return nil
 	}
@@ -191,7 +191,7 @@ func (c *RuleChecker) fixRulePeer(res *core.CachedShard, fit *placement.ShardFit
 func (c *RuleChecker) addRulePeer(res *core.CachedShard, rf *placement.RuleFit) (*operator.Operator, error) {
 	checkerCounter.WithLabelValues("rule_checker", "add-rule-peer").Inc()
 	ruleStores := c.getRuleFitStores(rf)
-	container := c.strategy(res, rf.Rule).SelectStoreToAdd(ruleStores)
+	container := c.strategy(res, rf.Rule).SelectStoreToRepair(ruleStores)
 	if container == 0 {
 		checkerCounter.WithLabelValues("rule_checker", "no-container-add").Inc()
 		c.resourceWaitingList.Put(res.Meta.GetID(), nil)
@@ -261,7 +261,7 @@ func (c *RuleChecker) allowLeader(fit *placement.ShardFit, peer metapb.Replica)
 	if s == nil {
 		return false
 	}
-	stateFilter := &filter.StoreStateFilter{ActionScope: "rule-checker", TransferLeader: true}
+	stateFilter := &filter.StoreStateFilter{ActionScope: "rule-checker", TransferLeader: true, AllowExceedCountLimit: true}
 	if !stateFilter.Target(c.cluster.GetOpts(), s) {
 		return false
 	}
@@ -328,12 +328,12 @@ func (c *RuleChecker) isDownPeer(res *core.CachedShard, peer metapb.Replica) boo
 				zap.Uint64("container", containerID))
 			return false
 		}
-		if !res.IsDestroyState() &&
-			container.DownTime() < c.cluster.GetOpts().GetMaxStoreDownTime() {
+		if !res.IsDestroyState() && !needSnapshot(res, peer.ID) &&
+			container.DownTime() < c.cluster.GetOpts().GetDownStoreRepairGracePeriod() {
 			continue
 		}
-		if !res.IsDestroyState() &&
-			stats.GetDownSeconds() < uint64(c.cluster.GetOpts().GetMaxStoreDownTime().Seconds()) {
+		if !res.IsDestroyState() && !needSnapshot(res, peer.ID) &&
+			stats.GetDownSeconds() < uint64(c.cluster.GetOpts().GetDownStoreRepairGracePeriod().Seconds()) {
 			continue
 		}
 		return true
@@ -341,6 +341,13 @@ func (c *RuleChecker) isDownPeer(res *core.CachedShard, peer metapb.Replica) boo
 	return false
 }
 
+// needSnapshot returns true if the leader reports that the peer can only catch
+// up by a snapshot.
+func needSnapshot(res *core.CachedShard, peerID uint64) bool {
+	progress, ok := res.GetReplicaProgress(peerID)
+	return ok && progress.NeedSnapshot
+}
+
 func (c *RuleChecker) isOfflinePeer(res *core.CachedShard, peer metapb.Replica) bool {
 	container := c.cluster.GetStore(peer.StoreID)
 	if container == nil {
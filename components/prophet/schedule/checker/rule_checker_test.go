@@ -16,6 +16,7 @@ package checker
 
 import (
 	"encoding/hex"
+	"sort"
 	"testing"
 
 	"github.com/matrixorigin/matrixcube/components/prophet/config"
@@ -66,6 +67,25 @@ func TestFixRange(t *testing.T) {
 	assert.Equal(t, "ff", hex.EncodeToString(splitKeys[1]))
 }
 
+func TestFixRangeByKeyspace(t *testing.T) {
+	s := &testRuleChecker{}
+	s.setup()
+
+	s.cluster.AddLeaderStore(1, 1)
+	s.cluster.AddLeaderStore(2, 1)
+	s.cluster.AddLeaderStore(3, 1)
+	s.cluster.Keyspaces.Put(metapb.Keyspace{Name: "a", Group: 1, Prefix: core.GetKeyspacePrefix("a")})
+	s.cluster.AddLeaderShardWithRange(1, "", "", 1, 2, 3)
+	assert.Nil(t, s.rc.Check(s.cluster.GetShard(1)), "the keyspaces of the other groups are ignored")
+
+	res := s.cluster.GetShard(1).Clone()
+	res.Meta.Group = 1
+	op := s.rc.Check(res)
+	assert.NotNil(t, op)
+	splitKeys := op.Step(0).(operator.SplitShard).SplitKeys
+	assert.Equal(t, [][]byte{[]byte("a/"), []byte("a0")}, splitKeys)
+}
+
 func TestAddRulePeer(t *testing.T) {
 	s := &testRuleChecker{}
 	s.setup()
@@ -99,6 +119,88 @@ func TestFillReplicasWithRule(t *testing.T) {
 	assert.Equal(t, s.rc.cluster.GetOpts().GetMaxReplicas(), len(res.Meta.GetReplicas()))
 }
 
+func TestFillReplicasWithStorePool(t *testing.T) {
+	s := &testRuleChecker{}
+	s.setup()
+
+	for id := uint64(1); id <= 3; id++ {
+		s.cluster.AddLabelsStore(id, 1, nil)
+	}
+	for id := uint64(4); id <= 6; id++ {
+		s.cluster.AddLabelsStore(id, 1, map[string]string{config.StorePoolLabel: "batch"})
+	}
+	s.cluster.SetShardGroupPools(config.ShardGroupPool{Group: 1, Pool: "batch"})
+
+	storeIDs := func(res *core.CachedShard) []uint64 {
+		var ids []uint64
+		for _, p := range res.Meta.GetReplicas() {
+			ids = append(ids, p.StoreID)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return ids
+	}
+
+	res := core.NewTestCachedShard(nil, nil)
+	res.Meta.Group = 1
+	assert.NoError(t, s.rc.FillReplicas(res, 0))
+	assert.Equal(t, []uint64{4, 5, 6}, storeIDs(res))
+
+	res = core.NewTestCachedShard(nil, nil)
+	assert.NoError(t, s.rc.FillReplicas(res, 0))
+	assert.Equal(t, []uint64{1, 2, 3}, storeIDs(res))
+
+	// the replicas of the bound group are moved to the stores of the pool
+	s.cluster.AddLeaderShardWithRange(1, "", "", 1, 2, 4)
+	res = s.cluster.GetShard(1).Clone()
+	res.Meta.Group = 1
+	s.cluster.PutShard(res)
+	op := s.rc.Check(s.cluster.GetShard(1))
+	assert.NotNil(t, op)
+	assert.Contains(t, []uint64{5, 6}, op.Step(0).(operator.AddLearner).ToStore)
+}
+
+func TestFillReplicasWithStorageClass(t *testing.T) {
+	s := &testRuleChecker{}
+	s.setup()
+
+	for id := uint64(1); id <= 3; id++ {
+		s.cluster.AddLabelsStore(id, 1, map[string]string{config.StorageClassLabel("hdd"): "true"})
+	}
+	for id := uint64(4); id <= 6; id++ {
+		s.cluster.AddLabelsStore(id, 1, map[string]string{config.StorageClassLabel("nvme"): "true"})
+	}
+	s.cluster.SetShardGroupStorageClasses(config.ShardGroupStorageClass{Group: 1, Class: "nvme"},
+		config.ShardGroupStorageClass{Group: 2, Class: "hdd"})
+
+	storeIDs := func(res *core.CachedShard) []uint64 {
+		var ids []uint64
+		for _, p := range res.Meta.GetReplicas() {
+			ids = append(ids, p.StoreID)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return ids
+	}
+
+	res := core.NewTestCachedShard(nil, nil)
+	res.Meta.Group = 1
+	assert.NoError(t, s.rc.FillReplicas(res, 0))
+	assert.Equal(t, []uint64{4, 5, 6}, storeIDs(res))
+
+	res = core.NewTestCachedShard(nil, nil)
+	res.Meta.Group = 2
+	assert.NoError(t, s.rc.FillReplicas(res, 0))
+	assert.Equal(t, []uint64{1, 2, 3}, storeIDs(res))
+
+	// the replicas on the stores without the class are moved
+	s.cluster.AddLeaderShardWithRange(1, "", "", 4, 5, 1)
+	res = s.cluster.GetShard(1).Clone()
+	res.Meta.Group = 1
+	s.cluster.PutShard(res)
+	op := s.rc.Check(s.cluster.GetShard(1))
+	assert.NotNil(t, op)
+	assert.Equal(t, uint64(6), op.Step(0).(operator.AddLearner).ToStore)
+}
+
 func TestAddRulePeerWithIsolationLevel(t *testing.T) {
 	s := &testRuleChecker{}
 	s.setup()
@@ -137,6 +239,50 @@ func TestAddRulePeerWithIsolationLevel(t *testing.T) {
 	assert.Equal(t, uint64(4), op.Step(0).(operator.AddLearner).ToStore)
 }
 
+func TestAddRuleLearnerWithLabelConstraints(t *testing.T) {
+	s := &testRuleChecker{}
+	s.setup()
+
+	s.cluster.AddLabelsStore(1, 1, map[string]string{"zone": "z1"})
+	s.cluster.AddLabelsStore(2, 1, map[string]string{"zone": "z2"})
+	s.cluster.AddLabelsStore(3, 1, map[string]string{"zone": "z3"})
+	s.cluster.AddLabelsStore(4, 1, map[string]string{"zone": "z1"})
+	s.cluster.AddLabelsStore(5, 1, map[string]string{"zone": "analytics"})
+	s.cluster.AddLeaderShardWithRange(1, "", "", 1, 2, 3)
+	// three voters across the zones and a learner in the analytics zone
+	assert.NoError(t, s.ruleManager.SetRules([]*placement.Rule{
+		{
+			GroupID:        "prophet",
+			ID:             "default",
+			Role:           placement.Voter,
+			Count:          3,
+			LocationLabels: []string{"zone"},
+			IsolationLevel: "zone",
+			LabelConstraints: []placement.LabelConstraint{
+				{Key: "zone", Op: "notIn", Values: []string{"analytics"}},
+			},
+		},
+		{
+			GroupID: "prophet",
+			ID:      "analytics",
+			Role:    placement.Learner,
+			Count:   1,
+			LabelConstraints: []placement.LabelConstraint{
+				{Key: "zone", Op: "in", Values: []string{"analytics"}},
+			},
+		},
+	}))
+	op := s.rc.Check(s.cluster.GetShard(1))
+	assert.NotNil(t, op)
+	assert.Equal(t, "add-rule-peer", op.Desc())
+	assert.Equal(t, uint64(5), op.Step(0).(operator.AddLearner).ToStore)
+	assert.Equal(t, 1, op.Len())
+
+	r := s.cluster.GetShard(1).Clone(core.WithAddPeer(metapb.Replica{ID: 5, StoreID: 5, Role: metapb.ReplicaRole_Learner}))
+	s.cluster.PutShard(r)
+	assert.Nil(t, s.rc.Check(s.cluster.GetShard(1)))
+}
+
 func TestFixPeer(t *testing.T) {
 	s := &testRuleChecker{}
 	s.setup()
@@ -16,6 +16,7 @@ package schedule
 
 import (
 	"context"
+	"time"
 
 	"github.com/matrixorigin/matrixcube/components/prophet/config"
 	"github.com/matrixorigin/matrixcube/components/prophet/core"
@@ -40,6 +41,7 @@ type CheckerController struct {
 	ruleChecker         *checker.RuleChecker
 	mergeChecker        *checker.MergeChecker
 	jointStateChecker   *checker.JointStateChecker
+	replicaDriftChecker *checker.ReplicaDriftChecker
 	resourceWaitingList cache.Cache
 }
 
@@ -57,6 +59,7 @@ func NewCheckerController(ctx context.Context, cluster opt.Cluster, ruleManager
 		mergeChecker:        checker.NewMergeChecker(ctx, cluster),
 		jointStateChecker:   checker.NewJointStateChecker(cluster),
 		leaseChecker:        checker.NewLeaseChecker(cluster),
+		replicaDriftChecker: checker.NewReplicaDriftChecker(cluster, ruleManager),
 		resourceWaitingList: resourceWaitingList,
 	}
 }
@@ -75,6 +78,7 @@ func (c *CheckerController) CheckShard(res *core.CachedShard) []*operator.Operat
 	// If PD has restarted, it need to check learners added before and promote them.
 	// Don't check isRaftLearnerEnabled cause it maybe disable learner feature but there are still some learners to promote.
 	opController := c.opController
+	c.replicaDriftChecker.Check(res)
 
 	if op := c.jointStateChecker.Check(res); op != nil {
 		return []*operator.Operator{op}
@@ -82,26 +86,40 @@ func (c *CheckerController) CheckShard(res *core.CachedShard) []*operator.Operat
 
 	if c.opts.IsPlacementRulesEnabled() {
 		if op := c.ruleChecker.Check(res); op != nil {
-			if opController.OperatorCount(operator.OpReplica) < c.opts.GetReplicaScheduleLimit() {
+			if placement.IsSystemShard(res) {
+				// the shards of the system group are fixed prior to the other shards,
+				// regardless of the replica schedule limit and the repair pacing.
+				op.SetPriorityLevel(core.HighPriority)
 				return []*operator.Operator{op}
 			}
-			operator.OperatorLimitCounter.WithLabelValues(c.ruleChecker.GetType(), operator.OpReplica.String()).Inc()
-			c.resourceWaitingList.Put(res.Meta.GetID(), nil)
+			if !c.allowRepair(op) || !c.allowDriftRepair(res, op) {
+				c.resourceWaitingList.Put(res.Meta.GetID(), nil)
+			} else if opController.OperatorCount(operator.OpReplica) < c.opts.GetReplicaScheduleLimit() {
+				return []*operator.Operator{op}
+			} else {
+				operator.OperatorLimitCounter.WithLabelValues(c.ruleChecker.GetType(), operator.OpReplica.String()).Inc()
+				c.resourceWaitingList.Put(res.Meta.GetID(), nil)
+			}
 		}
 	} else {
 		if op := c.learnerChecker.Check(res); op != nil {
 			return []*operator.Operator{op}
 		}
 		if op := c.replicaChecker.Check(res); op != nil {
-			if opController.OperatorCount(operator.OpReplica) < c.opts.GetReplicaScheduleLimit() {
+			if !c.allowRepair(op) || !c.allowDriftRepair(res, op) {
+				c.resourceWaitingList.Put(res.Meta.GetID(), nil)
+			} else if opController.OperatorCount(operator.OpReplica) < c.opts.GetReplicaScheduleLimit() {
 				return []*operator.Operator{op}
+			} else {
+				operator.OperatorLimitCounter.WithLabelValues(c.replicaChecker.GetType(), operator.OpReplica.String()).Inc()
+				c.resourceWaitingList.Put(res.Meta.GetID(), nil)
 			}
-			operator.OperatorLimitCounter.WithLabelValues(c.replicaChecker.GetType(), operator.OpReplica.String()).Inc()
-			c.resourceWaitingList.Put(res.Meta.GetID(), nil)
 		}
 	}
 
-	if c.mergeChecker != nil && opController.OperatorCount(operator.OpMerge) < c.opts.GetMergeScheduleLimit() {
+	// merge is heavy, only allowed in the maintenance windows
+	if c.mergeChecker != nil && opController.OperatorCount(operator.OpMerge) < c.opts.GetMergeScheduleLimit() &&
+		c.opts.IsInMaintenanceWindow(time.Now()) {
 		allowed := opController.OperatorCount(operator.OpMerge) < c.opts.GetMergeScheduleLimit()
 		if !allowed {
 			operator.OperatorLimitCounter.WithLabelValues(c.mergeChecker.GetType(), operator.OpMerge.String()).Inc()
@@ -120,6 +138,11 @@ func (c *CheckerController) CheckShard(res *core.CachedShard) []*operator.Operat
 	return nil
 }
 
+// GetReplicaDriftChecker returns the replica drift checker.
+func (c *CheckerController) GetReplicaDriftChecker() *checker.ReplicaDriftChecker {
+	return c.replicaDriftChecker
+}
+
 // GetMergeChecker returns the merge checker.
 func (c *CheckerController) GetMergeChecker() *checker.MergeChecker {
 	return c.mergeChecker
@@ -306,6 +306,9 @@ type StoreStateFilter struct {
 	ScatterShard bool
 	// Set true if allows temporary states.
 	AllowTemporaryStates bool
+	// Set true if allows the target to exceed the replica and leader count
+	// limits of the store, e.g. repair the missing replicas.
+	AllowExceedCountLimit bool
 	// Reason is used to distinguish the reason of container state filter
 	Reason string
 }
@@ -366,9 +369,10 @@ func (f *StoreStateFilter) exceedAddLimit(opt *config.PersistOptions, container
 
 func (f *StoreStateFilter) tooManySnapshots(opt *config.PersistOptions, container *core.CachedStore) bool {
 	f.Reason = "too-many-snapshot"
-	return !f.AllowTemporaryStates && (uint64(container.GetSendingSnapCount()) > opt.GetMaxSnapshotCount() ||
-		uint64(container.GetReceivingSnapCount()) > opt.GetMaxSnapshotCount() ||
-		uint64(container.GetApplyingSnapCount()) > opt.GetMaxSnapshotCount())
+	max := container.GetSnapshotLimit(opt.GetMaxSnapshotCount())
+	return !f.AllowTemporaryStates && (container.GetSendingSnapCount() > max ||
+		container.GetReceivingSnapCount() > max ||
+		container.GetApplyingSnapCount() > max)
 }
 
 func (f *StoreStateFilter) tooManyPendingPeers(opt *config.PersistOptions, container *core.CachedStore) bool {
@@ -378,6 +382,21 @@ func (f *StoreStateFilter) tooManyPendingPeers(opt *config.PersistOptions, conta
 		container.GetPendingPeerCount() > int(opt.GetMaxPendingPeerCount())
 }
 
+func (f *StoreStateFilter) isRejectingSnapshots(opt *config.PersistOptions, container *core.CachedStore) bool {
+	f.Reason = "rejecting-snapshot"
+	return !f.AllowTemporaryStates && container.IsRejectingSnapshots()
+}
+
+func (f *StoreStateFilter) reachReplicaCountLimit(opt *config.PersistOptions, container *core.CachedStore) bool {
+	f.Reason = "reach-replica-count-limit"
+	return !f.AllowExceedCountLimit && container.ReachReplicaCountLimit()
+}
+
+func (f *StoreStateFilter) reachLeaderCountLimit(opt *config.PersistOptions, container *core.CachedStore) bool {
+	f.Reason = "reach-leader-count-limit"
+	return !f.AllowExceedCountLimit && container.ReachLeaderCountLimit()
+}
+
 func (f *StoreStateFilter) hasRejectLeaderProperty(opts *config.PersistOptions, container *core.CachedStore) bool {
 	f.Reason = "reject-leader"
 	return opts.CheckLabelProperty(opt.RejectLeader, container.Meta.GetLabels())
@@ -388,13 +407,17 @@ func (f *StoreStateFilter) hasRejectLeaderProperty(opts *config.PersistOptions,
 // N: the condition is expected to be true for a long time.
 // X means when the condition is true, the container CANNOT be selected.
 //
-// Condition      Down Offline Tomb Pause Disconn Busy RmLimit AddLimit Snap Pending Reject
-// IsTemporary    N    N       N    N     Y       Y    Y       Y        Y    Y       N
+// Condition      Down Offline Tomb Pause Disconn Busy RmLimit AddLimit Snap Pending SnapRej Reject Count
+// IsTemporary    N    N       N    N     Y       Y    Y       Y        Y    Y       Y       N      N
 //
 // LeaderSource   X            X    X     X
 // ShardSource                                  X    X                X
-// LeaderTarget   X    X       X    X     X       X                                  X
-// ShardTarget X    X       X          X       X            X        X    X
+// LeaderTarget   X    X       X    X     X       X                                          X      X
+// ShardTarget X    X       X          X       X            X        X    X       X              X
+//
+// The Count condition is ignored if AllowExceedCountLimit is set. The SnapRej
+// condition is true for a while after the store rejected a snapshot for the
+// insufficient space.
 
 const (
 	leaderSource = iota
@@ -413,12 +436,14 @@ func (f *StoreStateFilter) anyConditionMatch(typ int, opt *config.PersistOptions
 		funcs = []conditionFunc{f.isBusy, f.exceedRemoveLimit, f.tooManySnapshots}
 	case leaderTarget:
 		funcs = []conditionFunc{f.isTombstone, f.isOffline, f.isDown, f.pauseLeaderTransfer,
-			f.isDisconnected, f.isBusy, f.hasRejectLeaderProperty}
+			f.isDisconnected, f.isBusy, f.hasRejectLeaderProperty, f.reachLeaderCountLimit}
 	case resourceTarget:
 		funcs = []conditionFunc{f.isTombstone, f.isOffline, f.isDown, f.isDisconnected, f.isBusy,
-			f.exceedAddLimit, f.tooManySnapshots, f.tooManyPendingPeers}
+			f.exceedAddLimit, f.tooManySnapshots, f.tooManyPendingPeers, f.isRejectingSnapshots,
+			f.reachReplicaCountLimit}
 	case scatterShardTarget:
-		funcs = []conditionFunc{f.isTombstone, f.isOffline, f.isDown, f.isDisconnected, f.isBusy}
+		funcs = []conditionFunc{f.isTombstone, f.isOffline, f.isDown, f.isDisconnected, f.isBusy,
+			f.reachReplicaCountLimit}
 
 	}
 	for _, cf := range funcs {
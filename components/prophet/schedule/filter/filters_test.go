@@ -175,6 +175,63 @@ func TestStoreStateFilter(t *testing.T) {
 		{3, true, true},
 	}
 	check(container, testCases)
+
+	// SnapRej
+	container = container.Clone(core.SetStoreStats(&metapb.StoreStats{}),
+		core.RejectSnapshotsUntil(time.Now().Add(time.Minute)))
+	testCases = []testCase{
+		{0, true, true},
+		{1, true, false},
+		{2, true, false},
+		{3, true, true},
+	}
+	check(container, testCases)
+}
+
+func TestStoreCountLimitFilter(t *testing.T) {
+	leader := &StoreStateFilter{TransferLeader: true}
+	move := &StoreStateFilter{MoveShard: true}
+	scatter := &StoreStateFilter{MoveShard: true, ScatterShard: true}
+	repair := &StoreStateFilter{TransferLeader: true, MoveShard: true, AllowExceedCountLimit: true}
+	opt := config.NewTestOptions()
+	container := core.NewTestStoreInfoWithLabel(1, 0, map[string]string{}).
+		Clone(core.SetLastHeartbeatTS(time.Now()),
+			core.SetShardCount("", 10),
+			core.SetLeaderCount("", 5),
+			core.SetStoreStats(&metapb.StoreStats{MaxReplicaCount: 11, MaxLeaderCount: 6}))
+	for _, f := range []Filter{leader, move, scatter, repair} {
+		assert.True(t, f.Target(opt, container))
+	}
+
+	container = container.Clone(core.SetStoreStats(&metapb.StoreStats{MaxReplicaCount: 10}))
+	assert.True(t, leader.Target(opt, container))
+	assert.False(t, move.Target(opt, container))
+	assert.False(t, scatter.Target(opt, container))
+	assert.True(t, repair.Target(opt, container))
+	assert.True(t, move.Source(opt, container))
+
+	container = container.Clone(core.SetStoreStats(&metapb.StoreStats{MaxLeaderCount: 5}))
+	assert.False(t, leader.Target(opt, container))
+	assert.True(t, move.Target(opt, container))
+	assert.True(t, repair.Target(opt, container))
+	assert.True(t, leader.Source(opt, container))
+}
+
+func TestStoreSnapshotLimitFilter(t *testing.T) {
+	move := &StoreStateFilter{MoveShard: true}
+	opt := config.NewTestOptions()
+	container := core.NewTestStoreInfoWithLabel(1, 0, map[string]string{}).
+		Clone(core.SetLastHeartbeatTS(time.Now()),
+			core.SetStoreStats(&metapb.StoreStats{ReceivingSnapCount: 2}))
+	assert.True(t, move.Target(opt, container))
+
+	// the max snapshot count advertised by the store is used if it's smaller
+	container = container.Clone(core.SetStoreStats(&metapb.StoreStats{ReceivingSnapCount: 2, MaxSnapshotCount: 1}))
+	assert.False(t, move.Target(opt, container))
+	container = container.Clone(core.SetStoreStats(&metapb.StoreStats{ReceivingSnapCount: 2, MaxSnapshotCount: 100}))
+	assert.True(t, move.Target(opt, container))
+	container = container.Clone(core.SetStoreStats(&metapb.StoreStats{SendingSnapCount: 2, MaxSnapshotCount: 1}))
+	assert.False(t, move.Source(opt, container))
 }
 
 func TestIsolationFilter(t *testing.T) {
@@ -0,0 +1,151 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"bytes"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/operator"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"go.uber.org/zap"
+)
+
+// mergeRollback the merge to rollback. The source shard is frozen after the
+// merge prepared, if the merge operator is failed, timeout or canceled, the
+// target shard rejects the merge first, then the source shard is unfrozen.
+type mergeRollback struct {
+	source metapb.Shard
+	// targets the epochs of the possible target shards when the merge prepared,
+	// the commit merge log is rejected once the epoch of the target shard
+	// changed. The target is removed after the merge rejected by it.
+	targets map[uint64]metapb.ShardEpoch
+}
+
+// addMergeRollback adds the merge to rollback if the merge operator is ended
+// without the merge committed.
+func (oc *OperatorController) addMergeRollback(op *operator.Operator) {
+	for i := 0; i < op.Len(); i++ {
+		st, ok := op.Step(i).(operator.MergeShard)
+		if !ok || st.IsPassive {
+			continue
+		}
+
+		source := oc.cluster.GetShard(st.FromShard.GetID())
+		if source == nil || source.Meta.GetState() != metapb.ShardState_Merging {
+			return
+		}
+
+		oc.mergeRollbackMu.Lock()
+		oc.mergeRollbacks[source.Meta.GetID()] = &mergeRollback{
+			source: source.Meta,
+			targets: map[uint64]metapb.ShardEpoch{
+				st.ToShard.GetID(): st.ToShard.GetEpoch(),
+			},
+		}
+		oc.mergeRollbackMu.Unlock()
+		oc.cluster.GetLogger().Info("resource merge rollback added",
+			log.ResourceField(source.Meta.GetID()),
+			zap.Uint64("target", st.ToShard.GetID()))
+		return
+	}
+}
+
+// checkMergeRollback drives the merge rollback by the heartbeat of the source
+// shard or the target shards.
+func (oc *OperatorController) checkMergeRollback(res *core.CachedShard) {
+	id := res.Meta.GetID()
+	merging := res.Meta.GetState() == metapb.ShardState_Merging
+	var adjacent []*core.CachedShard
+	orphan := merging && oc.GetOperator(id) == nil
+	if orphan {
+		prev, next := oc.cluster.GetAdjacentShards(res)
+		adjacent = append(adjacent, prev, next)
+	}
+
+	var rollbackSource bool
+	var fences []metapb.Shard
+	oc.mergeRollbackMu.Lock()
+	if orphan && oc.mergeRollbacks[id] == nil {
+		// the merge operator is lost, e.g. the prophet leader changed, all the
+		// adjacent shards may be the target shard.
+		r := &mergeRollback{source: res.Meta, targets: make(map[uint64]metapb.ShardEpoch)}
+		for _, target := range adjacent {
+			if target != nil {
+				r.targets[target.Meta.GetID()] = target.Meta.GetEpoch()
+			}
+		}
+		oc.mergeRollbacks[id] = r
+	}
+	if r, ok := oc.mergeRollbacks[id]; ok {
+		if !merging {
+			delete(oc.mergeRollbacks, id)
+		} else {
+			r.source = res.Meta
+			rollbackSource = len(r.targets) == 0
+		}
+	}
+	for sourceID, r := range oc.mergeRollbacks {
+		epoch, ok := r.targets[id]
+		if !ok {
+			continue
+		}
+		switch {
+		case shardContains(res.Meta, r.source):
+			// the merge is committed, the source shard will be destroyed
+			delete(oc.mergeRollbacks, sourceID)
+		case epoch.Generation != res.Meta.Epoch.Generation ||
+			epoch.ConfigVer != res.Meta.Epoch.ConfigVer:
+			// the merge is rejected by the target shard
+			delete(r.targets, id)
+		default:
+			fences = append(fences, r.source)
+		}
+	}
+	oc.mergeRollbackMu.Unlock()
+
+	for _, source := range fences {
+		oc.sendRollbackMerge(res, source)
+	}
+	if rollbackSource {
+		oc.sendRollbackMerge(res, res.Meta)
+	}
+}
+
+// sendRollbackMerge sends the rollback merge command to the leader of the
+// shard, the shard is the source shard or the target shard of the merge.
+func (oc *OperatorController) sendRollbackMerge(res *core.CachedShard, source metapb.Shard) {
+	data, _ := source.Marshal()
+	oc.cluster.GetLogger().Info("resource send rollback merge command",
+		log.ResourceField(res.Meta.GetID()),
+		zap.Uint64("source", source.GetID()))
+	oc.hbStreams.SendMsg(res, &rpcpb.ShardHeartbeatRsp{
+		Merge: &rpcpb.Merge{
+			Source:   data,
+			Rollback: true,
+		},
+	})
+}
+
+// shardContains returns true if the key range of the shard contains the key
+// range of the other shard.
+func shardContains(shard, other metapb.Shard) bool {
+	if bytes.Compare(other.GetStart(), shard.GetStart()) < 0 {
+		return false
+	}
+	return len(shard.GetEnd()) == 0 ||
+		(len(other.GetEnd()) > 0 && bytes.Compare(other.GetEnd(), shard.GetEnd()) <= 0)
+}
@@ -85,6 +85,22 @@ var (
 			Help:      "Counter of region scatter operators.",
 		}, []string{"type", "event"})
 
+	repairOperatorCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "prophet",
+			Subsystem: "schedule",
+			Name:      "repair_operators_count",
+			Help:      "Counter of the operators re-creating replicas of down or offline containers.",
+		}, []string{"failure_domain", "event"})
+
+	driftRepairOperatorCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "prophet",
+			Subsystem: "schedule",
+			Name:      "drift_repair_operators_count",
+			Help:      "Counter of the operators repairing the replica count drift of shards.",
+		}, []string{"event"})
+
 	scatterDistributionCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "prophet",
@@ -104,4 +120,6 @@ func init() {
 	prometheus.MustRegister(operatorWaitCounter)
 	prometheus.MustRegister(scatterCounter)
 	prometheus.MustRegister(scatterDistributionCounter)
+	prometheus.MustRegister(repairOperatorCounter)
+	prometheus.MustRegister(driftRepairOperatorCounter)
 }
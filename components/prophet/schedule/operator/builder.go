@@ -754,7 +754,9 @@ func (b *Builder) allowLeader(peer metapb.Replica, ignoreClusterLimit bool) bool
 		return true
 	}
 
-	stateFilter := &filter.StoreStateFilter{ActionScope: "operator-builder", TransferLeader: true}
+	// the leader must be moved out from the removed replica, so the leader count
+	// limits of the stores are ignored.
+	stateFilter := &filter.StoreStateFilter{ActionScope: "operator-builder", TransferLeader: true, AllowExceedCountLimit: true}
 	// container state filter
 	if !stateFilter.Target(b.cluster.GetOpts(), container) {
 		return false
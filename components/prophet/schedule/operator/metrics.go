@@ -34,9 +34,18 @@ var (
 			Name:      "operator_limit",
 			Help:      "Counter of operator meeting limit",
 		}, []string{"type", "name"})
+
+	operatorStepTimeoutCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "prophet",
+			Subsystem: "schedule",
+			Name:      "operator_step_timeout",
+			Help:      "Counter of operators canceled by the step timeout.",
+		}, []string{"desc", "step"})
 )
 
 func init() {
 	prometheus.MustRegister(operatorStepDuration)
 	prometheus.MustRegister(OperatorLimitCounter)
+	prometheus.MustRegister(operatorStepTimeoutCounter)
 }
@@ -49,6 +49,8 @@ type Operator struct {
 	steps            []OpStep
 	stepsTime        []int64 // step finish time
 	currentStep      int32
+	pendingTime      int64 // the time the added learner of current step is found pending
+	cancelReason     atomic.Value
 	status           OpStatusTracker
 	level            core.PriorityLevel
 	Counters         []prometheus.Counter
@@ -89,6 +91,9 @@ func (o *Operator) String() string {
 	if o.CheckTimeout() {
 		s = s + " timeout"
 	}
+	if reason := o.CancelReason(); reason != "" {
+		s = s + " canceled: " + reason
+	}
 	return s
 }
 
@@ -188,6 +193,23 @@ func (o *Operator) Cancel() bool {
 	return o.status.To(CANCELED)
 }
 
+// CancelWithReason marks the operator canceled and records the reason.
+func (o *Operator) CancelWithReason(reason string) bool {
+	if o.status.To(CANCELED) {
+		o.cancelReason.Store(reason)
+		return true
+	}
+	return false
+}
+
+// CancelReason returns the reason recorded by CancelWithReason.
+func (o *Operator) CancelReason() string {
+	if v, ok := o.cancelReason.Load().(string); ok {
+		return v
+	}
+	return ""
+}
+
 // Replace marks the operator replaced.
 func (o *Operator) Replace() bool {
 	return o.status.To(REPLACED)
@@ -222,8 +244,20 @@ func (o *Operator) Step(i int) OpStep {
 	return nil
 }
 
+// CurrentStep returns the step to take action, nil if all steps are finished.
+func (o *Operator) CurrentStep() OpStep {
+	return o.Step(int(atomic.LoadInt32(&o.currentStep)))
+}
+
+// CurrentStepIndex returns the index of the step to take action, equals to
+// the steps count if all steps are finished.
+func (o *Operator) CurrentStepIndex() int {
+	return int(atomic.LoadInt32(&o.currentStep))
+}
+
 // Check checks if current step is finished, returns next step to take action.
-// If operator is at an end status, check returns nil.
+// If operator is at an end status, check returns nil. The operator is canceled
+// if the current step runs longer than its step timeout.
 // It's safe to be called by multiple goroutine concurrently.
 func (o *Operator) Check(res *core.CachedShard) OpStep {
 	if o.IsEnd() {
@@ -244,7 +278,11 @@ func (o *Operator) Check(res *core.CachedShard) OpStep {
 					Observe(time.Unix(0, atomic.LoadInt64(&o.stepsTime[step])).Sub(startTime).Seconds())
 			}
 			atomic.StoreInt32(&o.currentStep, step+1)
+			atomic.StoreInt64(&o.pendingTime, 0)
 		} else {
+			if o.checkStepTimeout(step, res) {
+				return nil
+			}
 			return o.steps[int(step)]
 		}
 	}
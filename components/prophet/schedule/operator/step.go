@@ -813,6 +813,34 @@ func (tl DestroyDirectly) CheckSafety(res *core.CachedShard) error {
 func (tl DestroyDirectly) Influence(opInfluence OpInfluence, res *core.CachedShard) {
 }
 
+// CheckConsistency is an OpStep that checks the data of the replicas of the
+// shard are consistent.
+type CheckConsistency struct {
+}
+
+// ConfVerChanged returns the delta value for version increased by this step.
+func (cc CheckConsistency) ConfVerChanged(res *core.CachedShard) uint64 {
+	return 0 // CheckConsistency never change the conf version
+}
+
+func (cc CheckConsistency) String() string {
+	return "check consistency"
+}
+
+// IsFinish checks if current step is finished.
+func (cc CheckConsistency) IsFinish(res *core.CachedShard) bool {
+	return true
+}
+
+// CheckSafety checks if the step meets the safety properties.
+func (cc CheckConsistency) CheckSafety(res *core.CachedShard) error {
+	return nil
+}
+
+// Influence calculates the container difference that current step makes.
+func (cc CheckConsistency) Influence(opInfluence OpInfluence, res *core.CachedShard) {
+}
+
 // TransferLease is an OpStep that transfers a shard's lease.
 type TransferLease struct {
 	LeaseEpoch, ToReplicaID uint64
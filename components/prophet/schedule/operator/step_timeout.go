@@ -0,0 +1,87 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+)
+
+var (
+	// AddLearnerStepTimeout is the duration that when the learner of the
+	// AddLearner step is not added after it, the operator is canceled.
+	AddLearnerStepTimeout = time.Minute
+	// SendSnapshotStepTimeout is the duration that when the added learner is
+	// still pending after it, the snapshot is considered stuck and the operator
+	// is canceled.
+	SendSnapshotStepTimeout = 5 * time.Minute
+	// PromoteLearnerStepTimeout is the duration that when the learner of the
+	// PromoteLearner step is not promoted after it, the operator is canceled.
+	PromoteLearnerStepTimeout = time.Minute
+	// TransferLeaderStepTimeout is the duration that when the leader of the
+	// TransferLeader step is not transferred after it, the operator is canceled.
+	TransferLeaderStepTimeout = 30 * time.Second
+)
+
+// checkStepTimeout cancels the operator if the step runs longer than its step
+// timeout, returns true if the operator is canceled.
+func (o *Operator) checkStepTimeout(step int32, res *core.CachedShard) bool {
+	start := o.GetStartTime()
+	if start.IsZero() {
+		return false
+	}
+	if step > 0 {
+		if finished := atomic.LoadInt64(&o.stepsTime[step-1]); finished > 0 {
+			start = time.Unix(0, finished)
+		}
+	}
+
+	var phase string
+	var timeout time.Duration
+	switch st := o.steps[int(step)].(type) {
+	case AddLearner:
+		phase, timeout, start = o.addLearnerPhase(st.ToStore, st.PeerID, start, res)
+	case AddLightLearner:
+		phase, timeout, start = o.addLearnerPhase(st.ToStore, st.PeerID, start, res)
+	case PromoteLearner:
+		phase, timeout = "promote-learner", PromoteLearnerStepTimeout
+	case TransferLeader:
+		phase, timeout = "transfer-leader", TransferLeaderStepTimeout
+	default:
+		return false
+	}
+
+	if elapsed := time.Since(start); elapsed >= timeout {
+		if o.CancelWithReason(fmt.Sprintf("%s step timeout after %s", phase, elapsed.Truncate(time.Millisecond))) {
+			operatorStepTimeoutCounter.WithLabelValues(o.desc, phase).Inc()
+		}
+		return true
+	}
+	return false
+}
+
+// addLearnerPhase returns the phase of the AddLearner step, the added learner
+// is pending until the snapshot is received and applied.
+func (o *Operator) addLearnerPhase(storeID, peerID uint64, start time.Time,
+	res *core.CachedShard) (string, time.Duration, time.Time) {
+	peer, ok := res.GetStoreLearner(storeID)
+	if !ok || peer.ID != peerID {
+		return "add-learner", AddLearnerStepTimeout, start
+	}
+	atomic.CompareAndSwapInt64(&o.pendingTime, 0, time.Now().UnixNano())
+	return "send-snapshot", SendSnapshotStepTimeout, time.Unix(0, atomic.LoadInt64(&o.pendingTime))
+}
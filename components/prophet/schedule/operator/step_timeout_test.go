@@ -0,0 +1,96 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferLeaderStepTimeout(t *testing.T) {
+	s := &testOperator{}
+	s.setup()
+
+	resource := s.newTestShard(1, 1, [2]uint64{1, 1}, [2]uint64{2, 2})
+	op := s.newTestOperator(1, OpLeader, TransferLeader{FromStore: 1, ToStore: 2})
+	assert.True(t, op.Start())
+	assert.NotNil(t, op.Check(resource))
+	assert.Equal(t, STARTED, op.Status())
+
+	SetOperatorStatusReachTime(op, STARTED, time.Now().Add(-TransferLeaderStepTimeout))
+	assert.Nil(t, op.Check(resource))
+	assert.Equal(t, CANCELED, op.Status())
+	assert.True(t, strings.HasPrefix(op.CancelReason(), "transfer-leader step timeout"))
+	assert.Contains(t, op.String(), "canceled: transfer-leader")
+}
+
+func TestStepTimeoutFromPreviousStep(t *testing.T) {
+	s := &testOperator{}
+	s.setup()
+
+	resource := s.newTestShard(1, 1, [2]uint64{1, 1}, [2]uint64{2, 2})
+	op := s.newTestOperator(1, OpShard,
+		RemovePeer{FromStore: 4},
+		PromoteLearner{ToStore: 3, PeerID: 3})
+	assert.True(t, op.Start())
+	SetOperatorStatusReachTime(op, STARTED, time.Now().Add(-2*PromoteLearnerStepTimeout))
+	assert.NotNil(t, op.Check(resource))
+	assert.Equal(t, STARTED, op.Status())
+
+	// the promote step starts when the previous step finished
+	atomic.StoreInt64(&op.stepsTime[0], time.Now().Add(-PromoteLearnerStepTimeout).UnixNano())
+	assert.Nil(t, op.Check(resource))
+	assert.Equal(t, CANCELED, op.Status())
+	assert.True(t, strings.HasPrefix(op.CancelReason(), "promote-learner step timeout"))
+}
+
+func TestAddLearnerStepTimeout(t *testing.T) {
+	s := &testOperator{}
+	s.setup()
+
+	resource := s.newTestShard(1, 1, [2]uint64{1, 1})
+	op := s.newTestOperator(1, OpShard, AddLearner{ToStore: 2, PeerID: 2})
+	assert.True(t, op.Start())
+	SetOperatorStatusReachTime(op, STARTED, time.Now().Add(-AddLearnerStepTimeout))
+	assert.Nil(t, op.Check(resource))
+	assert.Equal(t, CANCELED, op.Status())
+	assert.True(t, strings.HasPrefix(op.CancelReason(), "add-learner step timeout"))
+	assert.Equal(t, AddLearner{ToStore: 2, PeerID: 2}, op.CurrentStep())
+}
+
+func TestSendSnapshotStepTimeout(t *testing.T) {
+	s := &testOperator{}
+	s.setup()
+
+	learner := metapb.Replica{ID: 2, StoreID: 2, Role: metapb.ReplicaRole_Learner}
+	resource := s.newTestShard(1, 1, [2]uint64{1, 1})
+	resource = resource.Clone(core.WithAddPeer(learner), core.WithPendingPeers([]metapb.Replica{learner}))
+	op := s.newTestOperator(1, OpShard, AddLearner{ToStore: 2, PeerID: 2})
+	assert.True(t, op.Start())
+	// the learner is added, waiting for the snapshot
+	SetOperatorStatusReachTime(op, STARTED, time.Now().Add(-AddLearnerStepTimeout))
+	assert.NotNil(t, op.Check(resource))
+	assert.Equal(t, STARTED, op.Status())
+
+	atomic.StoreInt64(&op.pendingTime, time.Now().Add(-SendSnapshotStepTimeout).UnixNano())
+	assert.Nil(t, op.Check(resource))
+	assert.Equal(t, CANCELED, op.Status())
+	assert.True(t, strings.HasPrefix(op.CancelReason(), "send-snapshot step timeout"))
+}
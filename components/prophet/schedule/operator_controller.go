@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -37,9 +38,10 @@ import (
 
 // The source of dispatched resource.
 const (
-	DispatchFromHeartBeat     = "heartbeat"
-	DispatchFromNotifierQueue = "active push"
-	DispatchFromCreate        = "create"
+	DispatchFromHeartBeat        = "heartbeat"
+	DispatchFromNotifierQueue    = "active push"
+	DispatchFromCreate           = "create"
+	DispatchFromConsistencyCheck = "consistency check"
 )
 
 var (
@@ -52,6 +54,12 @@ var (
 	StoreBalanceBaseTime float64 = 60
 )
 
+// DecisionRecorder records the scheduling decisions of the shards, it's
+// implemented by the cluster keeping the shard replay logs.
+type DecisionRecorder interface {
+	RecordDecision(shardID uint64, kind, detail string)
+}
+
 // OperatorController is used to limit the speed of scheduling.
 type OperatorController struct {
 	sync.RWMutex
@@ -66,6 +74,10 @@ type OperatorController struct {
 	wop             WaitingOperator
 	wopStatus       *WaitingOperatorStatus
 	opNotifierQueue operatorQueue
+	// mergeRollbacks the merges to rollback, keyed by the source shard. It has
+	// its own lock since the operators are buried with or without oc locked.
+	mergeRollbackMu sync.Mutex
+	mergeRollbacks  map[uint64]*mergeRollback
 }
 
 // NewOperatorController creates a OperatorController.
@@ -82,6 +94,7 @@ func NewOperatorController(ctx context.Context, cluster opt.Cluster, hbStreams *
 		wop:             NewRandBuckets(),
 		wopStatus:       NewWaitingOperatorStatus(),
 		opNotifierQueue: make(operatorQueue, 0),
+		mergeRollbacks:  make(map[uint64]*mergeRollback),
 	}
 }
 
@@ -104,8 +117,15 @@ func (oc *OperatorController) DispatchDestroyDirectly(res *core.CachedShard, sou
 	oc.SendScheduleCommand(res, operator.DestroyDirectly{}, source)
 }
 
+// DispatchCheckConsistency send CheckConsistency cmd to the leader of the
+// resource, the replicas compare the hashes of their data.
+func (oc *OperatorController) DispatchCheckConsistency(res *core.CachedShard, source string) {
+	oc.SendScheduleCommand(res, operator.CheckConsistency{}, source)
+}
+
 // Dispatch is used to dispatch the operator of a resource.
 func (oc *OperatorController) Dispatch(res *core.CachedShard, source string) {
+	oc.checkMergeRollback(res)
 	// Check existed operator.
 	if op := oc.GetOperator(res.Meta.GetID()); op != nil {
 		// Update operator status:
@@ -119,6 +139,10 @@ func (oc *OperatorController) Dispatch(res *core.CachedShard, source string) {
 			if source == DispatchFromHeartBeat && oc.checkStaleOperator(op, step, res) {
 				return
 			}
+			if oc.snapshotThrottled(res, step) {
+				operatorCounter.WithLabelValues(op.Desc(), "snapshot-throttled").Inc()
+				return
+			}
 			oc.SendScheduleCommand(res, step, source)
 		case operator.SUCCESS:
 			oc.pushHistory(op)
@@ -131,6 +155,13 @@ func (oc *OperatorController) Dispatch(res *core.CachedShard, source string) {
 				operatorCounter.WithLabelValues(op.Desc(), "promote-timeout").Inc()
 				oc.PromoteWaitingOperator()
 			}
+		case operator.CANCELED:
+			// canceled by the step timeout in Check
+			if op.CancelReason() != "" && oc.RemoveOperator(op, op.CancelReason()) {
+				oc.removeOrphanLearner(op, res)
+				operatorWaitCounter.WithLabelValues(op.Desc(), "promote-step-timeout").Inc()
+				oc.PromoteWaitingOperator()
+			}
 		default:
 			if oc.removeOperatorWithoutBury(op) {
 				// CREATED, EXPIRED must not appear.
@@ -150,6 +181,39 @@ func (oc *OperatorController) Dispatch(res *core.CachedShard, source string) {
 	}
 }
 
+// removeOrphanLearner adds an operator to remove the learner added by the
+// canceled operator, otherwise the learner is left in the shard.
+func (oc *OperatorController) removeOrphanLearner(op *operator.Operator, res *core.CachedShard) {
+	var storeID, peerID uint64
+	switch st := op.CurrentStep().(type) {
+	case operator.AddLearner:
+		storeID, peerID = st.ToStore, st.PeerID
+	case operator.AddLightLearner:
+		storeID, peerID = st.ToStore, st.PeerID
+	default:
+		return
+	}
+	if peer, ok := res.GetStoreLearner(storeID); !ok || peer.ID != peerID {
+		return
+	}
+
+	rmOp, err := operator.CreateRemovePeerOperator("remove-orphan-learner", oc.cluster,
+		operator.OpShard, res, storeID)
+	if err != nil {
+		oc.cluster.GetLogger().Warn("fail to create remove orphan learner operator",
+			log.ResourceField(res.Meta.GetID()),
+			zap.Uint64("store", storeID),
+			zap.Error(err))
+		return
+	}
+	if oc.AddOperator(rmOp) {
+		oc.cluster.GetLogger().Info("resource orphan learner scheduled to remove",
+			log.ResourceField(res.Meta.GetID()),
+			zap.Uint64("store", storeID),
+			zap.Uint64("peer", peerID))
+	}
+}
+
 func (oc *OperatorController) checkStaleOperator(op *operator.Operator, step operator.OpStep, res *core.CachedShard) bool {
 	err := step.CheckSafety(res)
 	if err != nil {
@@ -182,6 +246,39 @@ func (oc *OperatorController) checkStaleOperator(op *operator.Operator, step ope
 	return false
 }
 
+// snapshotThrottled returns true if the step adds a replica which needs a
+// snapshot from the leader, and the leader store or the target store reaches its
+// snapshot limit. The step is dispatched again by the later heartbeats or the
+// pushes, once the stores report the snapshots are done.
+func (oc *OperatorController) snapshotThrottled(res *core.CachedShard, step operator.OpStep) bool {
+	var to uint64
+	switch st := step.(type) {
+	case operator.AddPeer:
+		to = st.ToStore
+	case operator.AddLearner:
+		to = st.ToStore
+	case operator.AddLightLearner:
+		to = st.ToStore
+	default:
+		return false
+	}
+	if _, ok := res.GetStorePeer(to); ok {
+		// the replica is added, and the snapshot is in progress
+		return false
+	}
+
+	max := oc.cluster.GetOpts().GetMaxSnapshotCount()
+	if store := oc.cluster.GetStore(to); store != nil && store.ReachSnapshotLimit(max) {
+		return true
+	}
+	if leader := res.GetLeader(); leader != nil {
+		if store := oc.cluster.GetStore(leader.StoreID); store != nil && store.ReachSnapshotLimit(max) {
+			return true
+		}
+	}
+	return false
+}
+
 func (oc *OperatorController) getNextPushOperatorTime(step operator.OpStep, now time.Time) time.Time {
 	nextTime := slowNotifyInterval
 	switch step.(type) {
@@ -447,6 +544,7 @@ func (oc *OperatorController) addOperatorLocked(op *operator.Operator) bool {
 		return false
 	}
 	oc.operators[resID] = op
+	oc.recordDecision(resID, "operator-start", op.String())
 	operatorCounter.WithLabelValues(op.Desc(), "start").Inc()
 	operatorWaitDuration.WithLabelValues(op.Desc()).Observe(op.ElapsedTime().Seconds())
 	opInfluence := NewTotalOpInfluence([]*operator.Operator{op}, oc.cluster)
@@ -471,7 +569,7 @@ func (oc *OperatorController) addOperatorLocked(op *operator.Operator) bool {
 
 	var step operator.OpStep
 	if res := oc.cluster.GetShard(op.ShardID()); res != nil {
-		if step = op.Check(res); step != nil {
+		if step = op.Check(res); step != nil && !oc.snapshotThrottled(res, step) {
 			oc.SendScheduleCommand(res, step, DispatchFromCreate)
 		}
 	}
@@ -569,9 +667,24 @@ func (oc *OperatorController) buryOperator(op *operator.Operator, extra string)
 		operatorCounter.WithLabelValues(op.Desc(), "cancel").Inc()
 	}
 
+	if st != operator.SUCCESS {
+		oc.addMergeRollback(op)
+	}
+
+	detail := op.String()
+	if extra != "" {
+		detail += ", " + extra
+	}
+	oc.recordDecision(op.ShardID(), "operator-"+strings.ToLower(operator.OpStatusToString(op.Status())), detail)
 	oc.opRecords.Put(op)
 }
 
+func (oc *OperatorController) recordDecision(shardID uint64, kind, detail string) {
+	if r, ok := oc.cluster.(DecisionRecorder); ok {
+		r.RecordDecision(shardID, kind, detail)
+	}
+}
+
 // GetOperatorStatus gets the operator and its status with the specify id.
 func (oc *OperatorController) GetOperatorStatus(id uint64) *OperatorWithStatus {
 	oc.Lock()
@@ -615,6 +728,7 @@ func (oc *OperatorController) SendScheduleCommand(res *core.CachedShard, step op
 		log.ResourceField(res.Meta.GetID()),
 		zap.Stringer("step", step),
 		zap.String("source", source))
+	oc.recordDecision(res.Meta.GetID(), "step", step.String()+" from "+source)
 
 	var cmd *rpcpb.ShardHeartbeatRsp
 	switch st := step.(type) {
@@ -622,6 +736,10 @@ func (oc *OperatorController) SendScheduleCommand(res *core.CachedShard, step op
 		cmd = &rpcpb.ShardHeartbeatRsp{
 			DestroyDirectly: true,
 		}
+	case operator.CheckConsistency:
+		cmd = &rpcpb.ShardHeartbeatRsp{
+			CheckConsistency: true,
+		}
 	case operator.TransferLeader:
 		p, _ := res.GetStorePeer(st.ToStore)
 		cmd = &rpcpb.ShardHeartbeatRsp{
@@ -733,6 +851,13 @@ func (oc *OperatorController) SendScheduleCommand(res *core.CachedShard, step op
 			return
 		}
 
+		// the source shard is frozen after the merge is prepared, the target
+		// shard commits the merge once all the source replicas are prepared
+		if res.Meta.GetState() == metapb.ShardState_Merging {
+			oc.sendCommitMerge(res, st.ToShard)
+			return
+		}
+
 		data, _ := st.ToShard.Marshal()
 		cmd = &rpcpb.ShardHeartbeatRsp{
 			Merge: &rpcpb.Merge{
@@ -762,6 +887,32 @@ func (oc *OperatorController) SendScheduleCommand(res *core.CachedShard, step op
 	oc.hbStreams.SendMsg(res, cmd)
 }
 
+// sendCommitMerge sends the merge command with the source shard to the leader
+// of the target shard if the prepare merge log is applied by all the replicas
+// of the source shard. The prepared is the target shard when the merge
+// prepared, the target shard rejects the merge if its epoch changed since then.
+func (oc *OperatorController) sendCommitMerge(source *core.CachedShard, prepared metapb.Shard) {
+	if !source.IsMergePrepared() {
+		return
+	}
+	target := oc.cluster.GetShard(prepared.GetID())
+	if target == nil {
+		return
+	}
+
+	data, _ := source.Meta.Marshal()
+	targetData, _ := prepared.Marshal()
+	oc.cluster.GetLogger().Info("resource send commit merge command",
+		log.ResourceField(prepared.GetID()),
+		zap.Uint64("source", source.Meta.GetID()))
+	oc.hbStreams.SendMsg(target, &rpcpb.ShardHeartbeatRsp{
+		Merge: &rpcpb.Merge{
+			Source: data,
+			Target: targetData,
+		},
+	})
+}
+
 func (oc *OperatorController) pushHistory(op *operator.Operator) {
 	oc.Lock()
 	defer oc.Unlock()
@@ -170,6 +170,43 @@ func TestFastFailOperator(t *testing.T) {
 	assert.Nil(t, oc.GetOperator(res.Meta.GetID()))
 }
 
+func TestStepTimeoutRemoveOrphanLearner(t *testing.T) {
+	s := &testOperatorController{}
+	s.setup(t)
+	defer s.tearDown()
+	defer func(timeout time.Duration) {
+		operator.SendSnapshotStepTimeout = timeout
+	}(operator.SendSnapshotStepTimeout)
+	operator.SendSnapshotStepTimeout = 0
+
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+	stream := hbstream.NewTestHeartbeatStreams(s.ctx, tc.ID, tc, false /* no need to run */, nil)
+	oc := NewOperatorController(s.ctx, tc, stream)
+	tc.AddLeaderStore(1, 1)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderStore(3, 0)
+	tc.AddLeaderShard(1, 1, 2)
+	learner := metapb.Replica{ID: 10, StoreID: 3, Role: metapb.ReplicaRole_Learner}
+	res := tc.GetShard(1).Clone(core.WithAddPeer(learner), core.WithPendingPeers([]metapb.Replica{learner}))
+	tc.PutShard(res)
+
+	op := operator.NewOperator("test", "test", 1, res.Meta.GetEpoch(), operator.OpShard,
+		operator.AddLearner{ToStore: 3, PeerID: 10},
+		operator.PromoteLearner{ToStore: 3, PeerID: 10})
+	assert.True(t, op.Start())
+	oc.SetOperator(op)
+	oc.Dispatch(res, DispatchFromHeartBeat)
+	assert.Equal(t, operator.CANCELED, op.Status())
+	assert.Contains(t, op.CancelReason(), "send-snapshot")
+
+	rmOp := oc.GetOperator(1)
+	if assert.NotNil(t, rmOp) {
+		assert.Equal(t, "remove-orphan-learner", rmOp.Desc())
+		assert.Equal(t, operator.RemovePeer{FromStore: 3, PeerID: 10}, rmOp.Step(0))
+	}
+}
+
 func TestCheckAddUnexpectedStatus(t *testing.T) {
 	s := &testOperatorController{}
 	s.setup(t)
@@ -599,6 +636,50 @@ func TestDispatchUnfinishedStep(t *testing.T) {
 	}
 }
 
+func TestDispatchSnapshotThrottled(t *testing.T) {
+	s := &testOperatorController{}
+	s.setup(t)
+	defer s.tearDown()
+
+	cluster := mockcluster.NewCluster(config.NewTestOptions())
+	stream := hbstream.NewTestHeartbeatStreams(s.ctx, cluster.ID, cluster, false /* no need to run */, nil)
+	controller := NewOperatorController(s.ctx, cluster, stream)
+
+	cluster.AddLeaderStore(1, 1)
+	cluster.AddLeaderStore(2, 0)
+	cluster.AddLeaderStore(3, 0)
+	cluster.SetMaxSnapshotCount(2)
+	epoch := metapb.ShardEpoch{ConfigVer: 0, Generation: 0}
+	res := cluster.MockCachedShard(1, 1, []uint64{2}, []uint64{}, epoch)
+	cluster.PutShard(res)
+
+	// the target store reaches the max snapshot count of prophet
+	cluster.UpdateSnapshotCount(3, 2)
+	op := operator.NewOperator("test", "test", 1, epoch, operator.OpShard,
+		operator.AddLearner{ToStore: 3, PeerID: 3})
+	assert.True(t, controller.AddOperator(op))
+	assert.Equal(t, 0, stream.MsgLength())
+	controller.Dispatch(res, DispatchFromHeartBeat)
+	assert.Equal(t, 0, stream.MsgLength())
+
+	// the leader store reaches the max snapshot count advertised by itself
+	cluster.UpdateSnapshotCount(3, 0)
+	leader := cluster.GetStore(1)
+	cluster.PutStore(leader.Clone(core.SetStoreStats(&metapb.StoreStats{SendingSnapCount: 1, MaxSnapshotCount: 1})))
+	controller.Dispatch(res, DispatchFromHeartBeat)
+	assert.Equal(t, 0, stream.MsgLength())
+
+	cluster.PutStore(leader.Clone(core.SetStoreStats(&metapb.StoreStats{SendingSnapCount: 1})))
+	controller.Dispatch(res, DispatchFromHeartBeat)
+	assert.Equal(t, 1, stream.MsgLength())
+
+	// the snapshot of the added learner is in progress
+	cluster.UpdateSnapshotCount(3, 2)
+	res = res.Clone(core.WithAddPeer(metapb.Replica{ID: 3, StoreID: 3, Role: metapb.ReplicaRole_Learner}),
+		core.WithPendingPeers([]metapb.Replica{{ID: 3, StoreID: 3, Role: metapb.ReplicaRole_Learner}}))
+	assert.False(t, controller.snapshotThrottled(res, operator.AddLearner{ToStore: 3, PeerID: 3}))
+}
+
 func TestStoreLimitWithMerge(t *testing.T) {
 	s := &testOperatorController{}
 	s.setup(t)
@@ -713,6 +794,87 @@ func TestAddWaitingOperator(t *testing.T) {
 	assert.Equal(t, 0, controller.AddWaitingOperator(addPeerOp(0)))
 }
 
+func TestRollbackMergeAfterOperatorCanceled(t *testing.T) {
+	s := &testOperatorController{}
+	s.setup(t)
+	defer s.tearDown()
+
+	cluster := mockcluster.NewCluster(config.NewTestOptions())
+	stream := hbstream.NewTestHeartbeatStreams(s.ctx, cluster.ID, cluster, false /* no need to run */, nil)
+	controller := NewOperatorController(s.ctx, cluster, stream)
+	cluster.AddLeaderStore(1, 0)
+	cluster.AddLeaderStore(2, 0)
+	cluster.AddLeaderShardWithRange(1, "a", "b", 1, 2)
+	cluster.AddLeaderShardWithRange(2, "b", "c", 1, 2)
+
+	ops, err := operator.CreateMergeShardOperator("merge-resource", cluster,
+		cluster.GetShard(1), cluster.GetShard(2), operator.OpMerge)
+	assert.NoError(t, err)
+	assert.True(t, controller.AddOperator(ops...))
+	assert.NoError(t, stream.Drain(stream.MsgLength()))
+
+	// the prepare merge log is applied, then the operator is canceled
+	source := cluster.GetShard(1).Clone(core.WithState(metapb.ShardState_Merging))
+	cluster.PutShard(source)
+	assert.True(t, controller.RemoveOperator(ops[0], ""))
+	assert.True(t, controller.RemoveOperator(ops[1], ""))
+	assert.Equal(t, 1, len(controller.mergeRollbacks))
+
+	// the source shard keeps frozen until the target shard rejects the merge
+	controller.Dispatch(source, DispatchFromHeartBeat)
+	assert.Equal(t, 0, stream.MsgLength())
+
+	target := cluster.GetShard(2)
+	controller.Dispatch(target, DispatchFromHeartBeat)
+	assert.Equal(t, 1, stream.MsgLength())
+	assert.NoError(t, stream.Drain(1))
+
+	target = target.Clone(core.WithIncVersion())
+	cluster.PutShard(target)
+	controller.Dispatch(target, DispatchFromHeartBeat)
+	assert.Equal(t, 0, stream.MsgLength())
+	assert.Empty(t, controller.mergeRollbacks[1].targets)
+
+	controller.Dispatch(source, DispatchFromHeartBeat)
+	assert.Equal(t, 1, stream.MsgLength())
+	assert.NoError(t, stream.Drain(1))
+
+	source = source.Clone(core.WithState(metapb.ShardState_Running))
+	cluster.PutShard(source)
+	controller.Dispatch(source, DispatchFromHeartBeat)
+	assert.Equal(t, 0, stream.MsgLength())
+	assert.Empty(t, controller.mergeRollbacks)
+}
+
+func TestRollbackMergeOfOrphanMergingShard(t *testing.T) {
+	s := &testOperatorController{}
+	s.setup(t)
+	defer s.tearDown()
+
+	cluster := mockcluster.NewCluster(config.NewTestOptions())
+	stream := hbstream.NewTestHeartbeatStreams(s.ctx, cluster.ID, cluster, false /* no need to run */, nil)
+	controller := NewOperatorController(s.ctx, cluster, stream)
+	cluster.AddLeaderStore(1, 0)
+	cluster.AddLeaderStore(2, 0)
+	cluster.AddLeaderShardWithRange(1, "a", "b", 1, 2)
+	cluster.AddLeaderShardWithRange(2, "b", "c", 1, 2)
+	cluster.AddLeaderShardWithRange(3, "c", "d", 1, 2)
+
+	// no merge operator, e.g. the prophet leader changed
+	source := cluster.GetShard(2).Clone(core.WithState(metapb.ShardState_Merging))
+	cluster.PutShard(source)
+	controller.Dispatch(source, DispatchFromHeartBeat)
+	assert.Equal(t, 0, stream.MsgLength())
+	assert.Equal(t, 2, len(controller.mergeRollbacks[2].targets))
+
+	// the merge is committed by the shard 3
+	target := cluster.GetShard(3).Clone(core.WithStartKey([]byte("b")), core.WithIncVersion())
+	cluster.PutShard(target)
+	controller.Dispatch(target, DispatchFromHeartBeat)
+	assert.Equal(t, 0, stream.MsgLength())
+	assert.Empty(t, controller.mergeRollbacks)
+}
+
 func checkRemoveOperatorSuccess(t *testing.T, oc *OperatorController, op *operator.Operator) {
 	assert.True(t, oc.RemoveOperator(op, ""))
 	assert.True(t, op.IsEnd())
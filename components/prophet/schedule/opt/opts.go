@@ -18,6 +18,8 @@ import (
 	"github.com/matrixorigin/matrixcube/components/prophet/config"
 	"github.com/matrixorigin/matrixcube/components/prophet/core"
 	"github.com/matrixorigin/matrixcube/components/prophet/schedule/placement"
+	"github.com/matrixorigin/matrixcube/components/prophet/statistics"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"go.uber.org/zap"
 )
@@ -42,6 +44,13 @@ type Cluster interface {
 	FitShard(*core.CachedShard) *placement.ShardFit
 	RemoveScheduler(name string) error
 	AddSuspectShards(ids ...uint64)
+	// SetShardReadHint sets the replicas that the follower reads of the shard
+	// should be routed to, the hint is removed if no replica in it.
+	SetShardReadHint(hint metapb.ShardReadHint)
+	// GetHotPeerStats returns the rolling flow stats of the hot replicas of the
+	// flow kind grouped by the stores, the replicas with the hot degree less than
+	// the minHotDegree are excluded.
+	GetHotPeerStats(kind statistics.FlowKind, minHotDegree int) map[uint64][]statistics.HotPeerStat
 
 	// just for test
 	DisableJointConsensus()
@@ -124,11 +124,51 @@ type StoreSet interface {
 
 // FitShard tries to fit peers of a resource to the rules.
 func FitShard(containers StoreSet, res *core.CachedShard, rules []*Rule) *ShardFit {
-	w := newFitWorker(containers, res, rules)
+	// The learner rules are fitted first, otherwise the learners kept by the
+	// learner rules are taken by the voter rules as the loose matched peers and
+	// promoted once a voter is missing. The rule fits keep the order of the rules.
+	fitRules, order := learnerRulesFirst(rules)
+	w := newFitWorker(containers, res, fitRules)
 	w.run()
+	if order != nil {
+		ruleFits := make([]*RuleFit, len(rules))
+		for i, idx := range order {
+			ruleFits[idx] = w.bestFit.RuleFits[i]
+		}
+		w.bestFit.RuleFits = ruleFits
+	}
 	return &w.bestFit
 }
 
+// learnerRulesFirst returns the rules with the learner rules moved to the front,
+// and the original indexes of the returned rules. The order is nil if the rules
+// are not reordered.
+func learnerRulesFirst(rules []*Rule) ([]*Rule, []int) {
+	hasLearner, hasOther := false, false
+	for _, r := range rules {
+		if r.Role == Learner {
+			hasLearner = true
+		} else {
+			hasOther = true
+		}
+	}
+	if !hasLearner || !hasOther {
+		return rules, nil
+	}
+
+	values := make([]*Rule, 0, len(rules))
+	order := make([]int, 0, len(rules))
+	for _, learner := range []bool{true, false} {
+		for idx, r := range rules {
+			if (r.Role == Learner) == learner {
+				values = append(values, r)
+				order = append(order, idx)
+			}
+		}
+	}
+	return values, order
+}
+
 type fitWorker struct {
 	containers []*core.CachedStore
 	bestFit    ShardFit   // update during execution
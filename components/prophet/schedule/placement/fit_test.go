@@ -134,6 +134,9 @@ func TestFitresource(t *testing.T) {
 		{"1111,1112,1113,1114", []string{"3/voter//", "1/voter/id=id1/"}, "1112,1113,1114/1111"},
 		{"1111,2211,3111,3112", []string{"3/voter//zone", "1/voter/rack=rack2/"}, "1111,2211,3111//3112"},
 		{"1111,2211,3111,3112", []string{"1/voter/rack=rack2/", "3/voter//zone"}, "2211/1111,3111,3112"},
+		// test learner rule, the learners are not taken by the voter rule
+		{"1111,1112,2111_learner", []string{"3/voter//", "1/learner/zone=zone2/"}, "1111,1112/2111"},
+		{"1111,2111_learner,2112_learner", []string{"3/voter//", "1/learner/zone=zone2/"}, "1111,2112/2111"},
 	}
 
 	for _, cc := range cases {
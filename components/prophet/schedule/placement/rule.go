@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"sort"
 
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 )
@@ -78,6 +79,25 @@ func (s ReplicaRoleType) RPCPeerRole() rpcpb.ReplicaRoleType {
 	return rpcpb.Voter
 }
 
+// SystemRuleGroupID the rule group of the built-in system shard group. Unlike
+// the other rule groups, the rules of the group only apply to the shards which
+// declared the group in the rule groups.
+const SystemRuleGroupID = "system"
+
+// KeyspaceRuleGroupID the rule group of the rules generated by the scheduling
+// config of the keyspaces, the rules are not persisted.
+const KeyspaceRuleGroupID = "keyspace"
+
+// IsSystemShard returns true if the shard is placed by the system rule group
+func IsSystemShard(res *core.CachedShard) bool {
+	for _, g := range res.Meta.GetRuleGroups() {
+		if g == SystemRuleGroupID {
+			return true
+		}
+	}
+	return false
+}
+
 // Rule is the placement rule that can be checked against a resource. When
 // applying rules (apply means schedule resources to match selected rules), the
 // apply order is defined by the tuple [GroupIndex, GroupID, Index, ID].
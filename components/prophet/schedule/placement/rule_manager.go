@@ -26,8 +26,10 @@ import (
 	"sync"
 
 	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/components/prophet/config"
 	"github.com/matrixorigin/matrixcube/components/prophet/core"
 	"github.com/matrixorigin/matrixcube/components/prophet/storage"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"go.uber.org/zap"
 )
 
@@ -48,6 +50,13 @@ type RuleManager struct {
 
 	// used for rule validation
 	containerSetInformer core.StoreSetInformer
+	// storePool returns the store pool of the shard group
+	storePool func(group uint64) string
+	// storageClass returns the storage class requested by the shard group
+	storageClass func(group uint64) string
+	// keyspaces the keyspaces whose shards are placed by the scheduling config
+	// of the keyspaces
+	keyspaces *core.KeyspaceCache
 }
 
 // NewRuleManager creates a RuleManager instance.
@@ -301,13 +310,93 @@ func (m *RuleManager) GetRulesByKey(key []byte) []*Rule {
 	return m.ruleList.getRulesByKey(key)
 }
 
+// SetStorePoolFunc sets the func which returns the store pool of the shard
+// group, the rules applied to the shards of the groups bound to the pools are
+// constrained to the stores of the pools.
+func (m *RuleManager) SetStorePoolFunc(f func(group uint64) string) {
+	m.Lock()
+	defer m.Unlock()
+	m.storePool = f
+}
+
+// SetStorageClassFunc sets the func which returns the storage class requested
+// by the shard group, the rules applied to the shards of the groups requesting
+// the storage classes are constrained to the stores with the classes.
+func (m *RuleManager) SetStorageClassFunc(f func(group uint64) string) {
+	m.Lock()
+	defer m.Unlock()
+	m.storageClass = f
+}
+
+// SetKeyspaces sets the keyspaces, the rules applied to the shards of the
+// keyspaces are generated by the scheduling config of the keyspaces, and the
+// shards are split at the boundaries of the keyspaces.
+func (m *RuleManager) SetKeyspaces(keyspaces *core.KeyspaceCache) {
+	m.Lock()
+	defer m.Unlock()
+	m.keyspaces = keyspaces
+}
+
+// GetShardSplitKeys returns all split keys of the shard group in the range
+// (start, end), including the boundaries of the rules and the keyspaces.
+func (m *RuleManager) GetShardSplitKeys(group uint64, start, end []byte) [][]byte {
+	m.RLock()
+	defer m.RUnlock()
+	keys := m.ruleList.getSplitKeys(start, end)
+	if m.keyspaces == nil {
+		return keys
+	}
+	ksKeys := m.keyspaces.GetSplitKeys(group, start, end)
+	if len(ksKeys) == 0 {
+		return keys
+	}
+	keys = append(keys, ksKeys...)
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	n := 0
+	for i := range keys {
+		if i == 0 || !bytes.Equal(keys[i], keys[n-1]) {
+			keys[n] = keys[i]
+			n++
+		}
+	}
+	return keys[:n]
+}
+
+// IsAcrossKeyspaces returns true if the shard spans across the boundaries of
+// the keyspaces, such shards must be split before placed by the keyspaces.
+func (m *RuleManager) IsAcrossKeyspaces(res *core.CachedShard) bool {
+	m.RLock()
+	defer m.RUnlock()
+	if m.keyspaces == nil {
+		return false
+	}
+	start, end := res.Meta.GetRange()
+	return len(m.keyspaces.GetSplitKeys(res.Meta.GetGroup(), start, end)) > 0
+}
+
 // GetRulesForApplyShard returns the rules list that should be applied to a resource.
 func (m *RuleManager) GetRulesForApplyShard(res *core.CachedShard) []*Rule {
 	m.RLock()
 	defer m.RUnlock()
 
 	start, end := res.Meta.GetRange()
-	return filterRules(m.ruleList.getRulesForApplyShard(start, end), res)
+	rules := filterRules(m.ruleList.getRulesForApplyShard(start, end), res)
+	if m.keyspaces != nil && len(res.Meta.GetRuleGroups()) == 0 {
+		if keyspace, ok := m.keyspaces.GetByShard(res); ok {
+			rules = applyKeyspace(rules, keyspace)
+		}
+	}
+	if m.storageClass != nil {
+		if class := m.storageClass(res.Meta.GetGroup()); class != "" {
+			rules = constrainStorageClass(rules, class)
+		}
+	}
+	if m.storePool != nil {
+		if pool := m.storePool(res.Meta.GetGroup()); pool != "" {
+			return constrainStorePool(rules, pool)
+		}
+	}
+	return rules
 }
 
 // FitShard fits a resource to the rules it matches.
@@ -698,7 +787,7 @@ func checkRule(rule *Rule, containers []*core.CachedStore) bool {
 func filterRules(src []*Rule, res *core.CachedShard) []*Rule {
 	targets := res.Meta.GetRuleGroups()
 	if len(targets) == 0 {
-		return src
+		return excludeSystemRules(src)
 	}
 
 	var values []*Rule
@@ -712,3 +801,122 @@ func filterRules(src []*Rule, res *core.CachedShard) []*Rule {
 
 	return values
 }
+
+// constrainStorePool returns the copies of the rules which only match the stores
+// of the pool, the constraints of the other pools are replaced.
+func constrainStorePool(src []*Rule, pool string) []*Rule {
+	values := make([]*Rule, 0, len(src))
+	for _, r := range src {
+		rule := *r
+		rule.LabelConstraints = make([]LabelConstraint, 0, len(r.LabelConstraints)+1)
+		for _, c := range r.LabelConstraints {
+			if c.Key != config.StorePoolLabel {
+				rule.LabelConstraints = append(rule.LabelConstraints, c)
+			}
+		}
+		rule.LabelConstraints = append(rule.LabelConstraints, LabelConstraint{
+			Key:    config.StorePoolLabel,
+			Op:     In,
+			Values: []string{pool},
+		})
+		values = append(values, &rule)
+	}
+	return values
+}
+
+// constrainStorageClass returns the copies of the rules which only match the
+// stores with the storage class.
+func constrainStorageClass(src []*Rule, class string) []*Rule {
+	label := config.StorageClassLabel(class)
+	values := make([]*Rule, 0, len(src))
+	for _, r := range src {
+		rule := *r
+		rule.LabelConstraints = make([]LabelConstraint, 0, len(r.LabelConstraints)+1)
+		for _, c := range r.LabelConstraints {
+			if c.Key != label {
+				rule.LabelConstraints = append(rule.LabelConstraints, c)
+			}
+		}
+		rule.LabelConstraints = append(rule.LabelConstraints, LabelConstraint{
+			Key: label,
+			Op:  Exists,
+		})
+		values = append(values, &rule)
+	}
+	return values
+}
+
+// applyKeyspace returns the rules generated by the scheduling config of the
+// keyspace. If the number of the replicas is specified, the rules are replaced
+// by a voter rule with the count, the location labels are kept. The replicas
+// are constrained to the stores with the labels of the keyspace.
+func applyKeyspace(src []*Rule, keyspace metapb.Keyspace) []*Rule {
+	if keyspace.MaxReplicas == 0 && len(keyspace.Labels) == 0 {
+		return src
+	}
+
+	if keyspace.MaxReplicas > 0 {
+		rule := &Rule{
+			GroupID: KeyspaceRuleGroupID,
+			ID:      keyspace.Name,
+			Role:    Voter,
+			Count:   int(keyspace.MaxReplicas),
+		}
+		for _, r := range src {
+			if len(r.LocationLabels) > 0 {
+				rule.LocationLabels = r.LocationLabels
+				rule.IsolationLevel = r.IsolationLevel
+				break
+			}
+		}
+		src = []*Rule{rule}
+	}
+
+	values := make([]*Rule, 0, len(src))
+	for _, r := range src {
+		rule := *r
+		rule.LabelConstraints = make([]LabelConstraint, 0, len(r.LabelConstraints)+len(keyspace.Labels))
+		for _, c := range r.LabelConstraints {
+			if !hasLabel(keyspace.Labels, c.Key) {
+				rule.LabelConstraints = append(rule.LabelConstraints, c)
+			}
+		}
+		for _, label := range keyspace.Labels {
+			rule.LabelConstraints = append(rule.LabelConstraints, LabelConstraint{
+				Key:    label.Key,
+				Op:     In,
+				Values: []string{label.Value},
+			})
+		}
+		values = append(values, &rule)
+	}
+	return values
+}
+
+func hasLabel(labels []metapb.Label, key string) bool {
+	for _, label := range labels {
+		if label.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeSystemRules excludes the rules of the system rule group, which only
+// apply to the shards declared the system rule group.
+func excludeSystemRules(src []*Rule) []*Rule {
+	for idx, r := range src {
+		if r.GroupID != SystemRuleGroupID {
+			continue
+		}
+
+		values := append([]*Rule(nil), src[:idx]...)
+		for _, r := range src[idx+1:] {
+			if r.GroupID != SystemRuleGroupID {
+				values = append(values, r)
+			}
+		}
+		return values
+	}
+	return src
+}
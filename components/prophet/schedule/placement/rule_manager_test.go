@@ -17,8 +17,10 @@ package placement
 import (
 	"encoding/hex"
 	"reflect"
+	"sort"
 	"testing"
 
+	"github.com/matrixorigin/matrixcube/components/prophet/config"
 	"github.com/matrixorigin/matrixcube/components/prophet/core"
 	"github.com/matrixorigin/matrixcube/components/prophet/storage"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
@@ -96,6 +98,158 @@ func TestApplyRule(t *testing.T) {
 	assert.Equal(t, "id2", rules[1].ID)
 }
 
+func TestApplySystemRule(t *testing.T) {
+	s := &testManager{}
+	s.setup(t)
+
+	assert.NoError(t, s.manager.SetRule(&Rule{
+		GroupID: SystemRuleGroupID,
+		ID:      "default",
+		Role:    "voter",
+		Count:   5,
+	}))
+
+	shard := core.NewCachedShard(metapb.Shard{
+		ID: 1, Replicas: []metapb.Replica{{ID: 1, StoreID: 1}},
+	}, nil)
+	rules := s.manager.GetRulesForApplyShard(shard)
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, "prophet", rules[0].GroupID)
+	assert.False(t, IsSystemShard(shard))
+
+	shard = core.NewCachedShard(metapb.Shard{
+		ID: 2, Replicas: []metapb.Replica{{ID: 2, StoreID: 1}},
+		RuleGroups: []string{SystemRuleGroupID},
+	}, nil)
+	rules = s.manager.GetRulesForApplyShard(shard)
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, SystemRuleGroupID, rules[0].GroupID)
+	assert.Equal(t, 5, rules[0].Count)
+	assert.True(t, IsSystemShard(shard))
+}
+
+func TestApplyStorePoolRule(t *testing.T) {
+	s := &testManager{}
+	s.setup(t)
+	s.manager.SetStorePoolFunc(func(group uint64) string {
+		if group == 1 {
+			return "batch"
+		}
+		return ""
+	})
+
+	stores := core.NewCachedStores()
+	stores.SetStore(core.NewTestStoreInfoWithLabel(1, 0, map[string]string{"zone": "z1"}))
+	stores.SetStore(core.NewTestStoreInfoWithLabel(2, 0, map[string]string{"zone": "z2"}))
+	stores.SetStore(core.NewTestStoreInfoWithLabel(3, 0, map[string]string{"zone": "z1", config.StorePoolLabel: "batch"}))
+	stores.SetStore(core.NewTestStoreInfoWithLabel(4, 0, map[string]string{"zone": "z2", config.StorePoolLabel: "batch"}))
+	stores.SetStore(core.NewTestStoreInfoWithLabel(5, 0, map[string]string{"zone": "z3", config.StorePoolLabel: "other"}))
+	matched := func(rule *Rule) []uint64 {
+		var ids []uint64
+		for _, store := range stores.GetStores() {
+			if MatchLabelConstraints(store, rule.LabelConstraints) {
+				ids = append(ids, store.Meta.GetID())
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return ids
+	}
+
+	shard := core.NewCachedShard(metapb.Shard{ID: 1, Group: 1}, nil)
+	rules := s.manager.GetRulesForApplyShard(shard)
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, []uint64{3, 4}, matched(rules[0]), "only placed on the stores of the pool")
+	assert.Empty(t, s.manager.GetRule("prophet", "default").LabelConstraints, "the rule is not changed")
+
+	shard = core.NewCachedShard(metapb.Shard{ID: 2, Group: 2}, nil)
+	rules = s.manager.GetRulesForApplyShard(shard)
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, []uint64{1, 2}, matched(rules[0]), "the stores of the pools are excluded")
+}
+
+func TestApplyKeyspaceRule(t *testing.T) {
+	s := &testManager{}
+	s.setup(t)
+	keyspaces := core.NewKeyspaceCache()
+	keyspaces.Put(metapb.Keyspace{Name: "a", Group: 1, Prefix: core.GetKeyspacePrefix("a"),
+		MaxReplicas: 5, Labels: []metapb.Label{{Key: "zone", Value: "z1"}}})
+	keyspaces.Put(metapb.Keyspace{Name: "b", Group: 1, Prefix: core.GetKeyspacePrefix("b"),
+		Labels: []metapb.Label{{Key: "zone", Value: "z2"}}})
+	s.manager.SetKeyspaces(keyspaces)
+
+	shard := core.NewCachedShard(metapb.Shard{ID: 1, Group: 1, Start: []byte("a/1"), End: []byte("a/2")}, nil)
+	rules := s.manager.GetRulesForApplyShard(shard)
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, KeyspaceRuleGroupID, rules[0].GroupID)
+	assert.Equal(t, "a", rules[0].ID)
+	assert.Equal(t, 5, rules[0].Count)
+	assert.Equal(t, []LabelConstraint{{Key: "zone", Op: In, Values: []string{"z1"}}}, rules[0].LabelConstraints)
+
+	shard = core.NewCachedShard(metapb.Shard{ID: 2, Group: 1, Start: []byte("b/"), End: []byte("b0")}, nil)
+	rules = s.manager.GetRulesForApplyShard(shard)
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, "default", rules[0].ID, "the replicas of the group are used")
+	assert.Equal(t, 3, rules[0].Count)
+	assert.Equal(t, []LabelConstraint{{Key: "zone", Op: In, Values: []string{"z2"}}}, rules[0].LabelConstraints)
+	assert.Empty(t, s.manager.GetRule("prophet", "default").LabelConstraints, "the rule is not changed")
+
+	shard = core.NewCachedShard(metapb.Shard{ID: 3, Group: 2, Start: []byte("a/1"), End: []byte("a/2")}, nil)
+	rules = s.manager.GetRulesForApplyShard(shard)
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, "default", rules[0].ID, "the keyspaces of the other groups are ignored")
+	assert.Empty(t, rules[0].LabelConstraints)
+
+	assert.Equal(t, [][]byte{[]byte("a/"), []byte("a0")}, s.manager.GetShardSplitKeys(1, nil, []byte("b/")))
+	assert.Empty(t, s.manager.GetShardSplitKeys(2, nil, nil))
+	assert.True(t, s.manager.IsAcrossKeyspaces(core.NewCachedShard(metapb.Shard{Group: 1, End: []byte("a/1")}, nil)))
+	assert.False(t, s.manager.IsAcrossKeyspaces(core.NewCachedShard(metapb.Shard{Group: 1, Start: []byte("a/1"), End: []byte("a/2")}, nil)))
+}
+
+func TestApplyStorageClassRule(t *testing.T) {
+	s := &testManager{}
+	s.setup(t)
+	s.manager.SetStorageClassFunc(func(group uint64) string {
+		if group == 1 {
+			return "nvme"
+		}
+		return ""
+	})
+	s.manager.SetStorePoolFunc(func(group uint64) string {
+		if group == 1 {
+			return "batch"
+		}
+		return ""
+	})
+
+	nvme := config.StorageClassLabel("nvme")
+	stores := core.NewCachedStores()
+	stores.SetStore(core.NewTestStoreInfoWithLabel(1, 0, map[string]string{"zone": "z1"}))
+	stores.SetStore(core.NewTestStoreInfoWithLabel(2, 0, map[string]string{"zone": "z2", nvme: "true"}))
+	stores.SetStore(core.NewTestStoreInfoWithLabel(3, 0, map[string]string{"zone": "z1", nvme: "true", config.StorePoolLabel: "batch"}))
+	stores.SetStore(core.NewTestStoreInfoWithLabel(4, 0, map[string]string{"zone": "z2", config.StorePoolLabel: "batch"}))
+	matched := func(rule *Rule) []uint64 {
+		var ids []uint64
+		for _, store := range stores.GetStores() {
+			if MatchLabelConstraints(store, rule.LabelConstraints) {
+				ids = append(ids, store.Meta.GetID())
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return ids
+	}
+
+	shard := core.NewCachedShard(metapb.Shard{ID: 1, Group: 1}, nil)
+	rules := s.manager.GetRulesForApplyShard(shard)
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, []uint64{3}, matched(rules[0]), "the stores of the pool with the class")
+	assert.Empty(t, s.manager.GetRule("prophet", "default").LabelConstraints, "the rule is not changed")
+
+	shard = core.NewCachedShard(metapb.Shard{ID: 2, Group: 2}, nil)
+	rules = s.manager.GetRulesForApplyShard(shard)
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, []uint64{1, 2}, matched(rules[0]), "the stores with the classes are not exclusive")
+}
+
 func TestAdjustRule(t *testing.T) {
 	s := &testManager{}
 	s.setup(t)
@@ -0,0 +1,135 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/checker"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/operator"
+)
+
+// allowRepair returns false if the operator re-creates a replica of a down or
+// offline container, and there are too many repair operators running in the
+// same failure domain or on the same target container. So that the replicas of a
+// failed failure domain are rebuilt gradually instead of all at once.
+func (c *CheckerController) allowRepair(op *operator.Operator) bool {
+	if !checker.IsRepairOperator(op) {
+		return true
+	}
+
+	source, target := getRepairStores(op)
+	domain := c.getFailureDomain(source)
+	maxPerDomain := c.opts.GetMaxRepairPerFailureDomain()
+	maxPerStore := c.opts.GetMaxRepairPerStore()
+	if maxPerDomain > 0 || maxPerStore > 0 {
+		domainCount, storeCount := uint64(0), uint64(0)
+		for _, running := range c.opController.GetOperators() {
+			if !checker.IsRepairOperator(running) {
+				continue
+			}
+			s, t := getRepairStores(running)
+			if c.getFailureDomain(s) == domain {
+				domainCount++
+			}
+			if t == target {
+				storeCount++
+			}
+		}
+
+		if maxPerDomain > 0 && domainCount >= maxPerDomain {
+			repairOperatorCounter.WithLabelValues(domain, "paced-by-failure-domain").Inc()
+			return false
+		}
+		if maxPerStore > 0 && storeCount >= maxPerStore {
+			repairOperatorCounter.WithLabelValues(domain, "paced-by-target-container").Inc()
+			return false
+		}
+	}
+
+	repairOperatorCounter.WithLabelValues(domain, "allowed").Inc()
+	return true
+}
+
+// allowDriftRepair returns false if the operator repairs the replica count drift
+// of the shard, and the drift is found within the grace period, or there are too
+// many drift repair operators running. So that the drifts left by the failed
+// operators are repaired at a steady pace, and the drifts of the in-flight
+// operators are not repaired.
+func (c *CheckerController) allowDriftRepair(res *core.CachedShard, op *operator.Operator) bool {
+	if !checker.IsDriftRepairOperator(op) {
+		return true
+	}
+	drift, ok := c.replicaDriftChecker.GetDrift(res.Meta.GetID())
+	if !ok {
+		return true
+	}
+
+	if grace := c.opts.GetReplicaDriftGracePeriod(); grace > 0 &&
+		time.Since(time.Unix(0, drift.Since)) < grace {
+		driftRepairOperatorCounter.WithLabelValues("paced-by-grace-period").Inc()
+		return false
+	}
+	if limit := c.opts.GetMaxReplicaDriftRepair(); limit > 0 {
+		count := uint64(0)
+		for _, running := range c.opController.GetOperators() {
+			if checker.IsDriftRepairOperator(running) {
+				count++
+			}
+		}
+		if count >= limit {
+			driftRepairOperatorCounter.WithLabelValues("paced-by-limit").Inc()
+			return false
+		}
+	}
+
+	driftRepairOperatorCounter.WithLabelValues("allowed").Inc()
+	return true
+}
+
+// getFailureDomain returns the value of the first location label of the
+// container, or the container itself if no location labels.
+func (c *CheckerController) getFailureDomain(containerID uint64) string {
+	if labels := c.opts.GetLocationLabels(); len(labels) > 0 {
+		if container := c.cluster.GetStore(containerID); container != nil {
+			if v := container.GetLabelValue(labels[0]); v != "" {
+				return fmt.Sprintf("%s=%s", labels[0], v)
+			}
+		}
+	}
+	return fmt.Sprintf("container-%d", containerID)
+}
+
+// getRepairStores returns the container whose replica is replaced, and the
+// container which the new replica is created on.
+func getRepairStores(op *operator.Operator) (uint64, uint64) {
+	var source, target uint64
+	for i := 0; i < op.Len(); i++ {
+		switch step := op.Step(i).(type) {
+		case operator.RemovePeer:
+			source = step.FromStore
+		case operator.AddPeer:
+			target = step.ToStore
+		case operator.AddLearner:
+			target = step.ToStore
+		case operator.AddLightPeer:
+			target = step.ToStore
+		case operator.AddLightLearner:
+			target = step.ToStore
+		}
+	}
+	return source, target
+}
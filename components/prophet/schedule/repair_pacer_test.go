@@ -0,0 +1,133 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/config"
+	"github.com/matrixorigin/matrixcube/components/prophet/mock/mockcluster"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/operator"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowRepair(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opt := config.NewTestOptions()
+	rep := opt.GetReplicationConfig().Clone()
+	rep.LocationLabels = []string{"zone"}
+	opt.SetReplicationConfig(rep)
+	tc := mockcluster.NewCluster(opt)
+	tc.AddLabelsStore(1, 0, map[string]string{"zone": "z1"})
+	tc.AddLabelsStore(2, 0, map[string]string{"zone": "z1"})
+	tc.AddLabelsStore(3, 0, map[string]string{"zone": "z2"})
+	tc.AddLabelsStore(4, 0, map[string]string{"zone": "z3"})
+	tc.AddLabelsStore(5, 0, map[string]string{"zone": "z3"})
+	oc := NewOperatorController(ctx, tc, nil)
+	c := NewCheckerController(ctx, tc, nil, oc)
+
+	newRepair := func(id, from, to uint64) *operator.Operator {
+		return operator.NewOperator("replace-down-replica", "test", id, metapb.ShardEpoch{}, operator.OpReplica,
+			operator.AddLearner{ToStore: to}, operator.RemovePeer{FromStore: from})
+	}
+
+	running := newRepair(1, 1, 4)
+	assert.True(t, running.Start())
+	oc.SetOperator(running)
+
+	// no limit
+	assert.True(t, c.allowRepair(newRepair(2, 2, 4)))
+
+	cfg := opt.GetScheduleConfig().Clone()
+	cfg.MaxRepairPerFailureDomain = 1
+	opt.SetScheduleConfig(cfg)
+	assert.False(t, c.allowRepair(newRepair(2, 2, 5)))
+	assert.True(t, c.allowRepair(newRepair(2, 3, 4)))
+	// not a repair operator
+	assert.True(t, c.allowRepair(operator.NewOperator("test", "test", 2, metapb.ShardEpoch{}, operator.OpReplica,
+		operator.AddLearner{ToStore: 5}, operator.RemovePeer{FromStore: 2})))
+
+	cfg = opt.GetScheduleConfig().Clone()
+	cfg.MaxRepairPerStore = 1
+	opt.SetScheduleConfig(cfg)
+	assert.False(t, c.allowRepair(newRepair(2, 3, 4)))
+	assert.True(t, c.allowRepair(newRepair(2, 3, 5)))
+}
+
+func TestAllowDriftRepair(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+	for id := uint64(1); id <= 4; id++ {
+		tc.AddLeaderStore(id, 1)
+	}
+	oc := NewOperatorController(ctx, tc, nil)
+	c := NewCheckerController(ctx, tc, tc.RuleManager, oc)
+	newMakeUp := func(id, to uint64) *operator.Operator {
+		return operator.NewOperator("make-up-replica", "test", id, metapb.ShardEpoch{}, operator.OpReplica,
+			operator.AddLearner{ToStore: to})
+	}
+
+	res := tc.AddLeaderShard(1, 1, 2)
+	// no drift found yet
+	assert.True(t, c.allowDriftRepair(res, newMakeUp(1, 3)))
+	_, ok := c.replicaDriftChecker.Check(res)
+	assert.True(t, ok)
+	assert.True(t, c.allowDriftRepair(res, newMakeUp(1, 3)))
+
+	cfg := opt.GetScheduleConfig().Clone()
+	cfg.ReplicaDriftGracePeriod.Duration = time.Hour
+	opt.SetScheduleConfig(cfg)
+	assert.False(t, c.allowDriftRepair(res, newMakeUp(1, 3)))
+	// not a drift repair operator
+	assert.True(t, c.allowDriftRepair(res, operator.NewOperator("test", "test", 1, metapb.ShardEpoch{}, operator.OpReplica,
+		operator.AddLearner{ToStore: 3})))
+
+	cfg = opt.GetScheduleConfig().Clone()
+	cfg.ReplicaDriftGracePeriod.Duration = 0
+	cfg.MaxReplicaDriftRepair = 1
+	opt.SetScheduleConfig(cfg)
+	running := newMakeUp(2, 4)
+	assert.True(t, running.Start())
+	oc.SetOperator(running)
+	assert.False(t, c.allowDriftRepair(res, newMakeUp(1, 3)))
+	cfg = opt.GetScheduleConfig().Clone()
+	cfg.MaxReplicaDriftRepair = 2
+	opt.SetScheduleConfig(cfg)
+	assert.True(t, c.allowDriftRepair(res, newMakeUp(1, 3)))
+}
+
+func TestGetFailureDomain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+	tc.AddLabelsStore(1, 0, map[string]string{"zone": "z1"})
+	c := NewCheckerController(ctx, tc, nil, NewOperatorController(ctx, tc, nil))
+	assert.Equal(t, "container-1", c.getFailureDomain(1))
+
+	rep := opt.GetReplicationConfig().Clone()
+	rep.LocationLabels = []string{"zone"}
+	opt.SetReplicationConfig(rep)
+	assert.Equal(t, "zone=z1", c.getFailureDomain(1))
+	assert.Equal(t, "container-2", c.getFailureDomain(2))
+}
@@ -0,0 +1,262 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/filter"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/operator"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/opt"
+	"github.com/matrixorigin/matrixcube/components/prophet/storage"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"go.uber.org/zap"
+)
+
+const (
+	// BalanceReadName is balance read scheduler name.
+	BalanceReadName = "balance-read-scheduler"
+	// BalanceReadType is balance read scheduler type.
+	BalanceReadType = "balance-read"
+
+	// balanceReadTolerantRatio the read load of the hottest store should exceed
+	// the average read load by the ratio before balancing.
+	balanceReadTolerantRatio = 1.2
+)
+
+func init() {
+	schedule.RegisterSliceDecoderBuilder(BalanceReadType, func(args []string) schedule.ConfigDecoder {
+		return func(v interface{}) error {
+			conf, ok := v.(*balanceReadSchedulerConfig)
+			if !ok {
+				return errors.New("scheduler error configuration")
+			}
+			conf.Name = BalanceReadName
+			return nil
+		}
+	})
+
+	schedule.RegisterScheduler(BalanceReadType, func(opController *schedule.OperatorController, storage storage.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
+		conf := &balanceReadSchedulerConfig{}
+		if err := decoder(conf); err != nil {
+			return nil, err
+		}
+		return newBalanceReadScheduler(opController, conf), nil
+	})
+}
+
+type balanceReadSchedulerConfig struct {
+	Name string `json:"name"`
+}
+
+// storeReadLoad the read load of a store, the load is the read requests per
+// second served by all the replicas in the store.
+type storeReadLoad struct {
+	store    *core.CachedStore
+	load     float64
+	replicas []metapb.ReplicaReadStats
+}
+
+type balanceReadScheduler struct {
+	*BaseScheduler
+	conf    *balanceReadSchedulerConfig
+	filters []filter.Filter
+	// hints the shards whose follower reads are hinted away from the hot store
+	hints map[uint64]struct{}
+}
+
+// newBalanceReadScheduler creates a scheduler that balances the read load of
+// the replicas between stores. The leader reads of a hot store are moved by
+// transferring the leader to a colder follower, and the follower reads are
+// moved by the read hint, which tells the routers to send the follower reads
+// to the replicas on the colder stores.
+func newBalanceReadScheduler(opController *schedule.OperatorController, conf *balanceReadSchedulerConfig) schedule.Scheduler {
+	filters := []filter.Filter{
+		&filter.StoreStateFilter{ActionScope: conf.Name, TransferLeader: true},
+		filter.NewSpecialUseFilter(conf.Name),
+	}
+	return &balanceReadScheduler{
+		BaseScheduler: NewBaseScheduler(opController),
+		conf:          conf,
+		filters:       filters,
+		hints:         make(map[uint64]struct{}),
+	}
+}
+
+func (s *balanceReadScheduler) GetName() string {
+	return s.conf.Name
+}
+
+func (s *balanceReadScheduler) GetType() string {
+	return BalanceReadType
+}
+
+func (s *balanceReadScheduler) EncodeConfig() ([]byte, error) {
+	return schedule.EncodeConfig(s.conf)
+}
+
+func (s *balanceReadScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
+	if !isInMaintenanceWindow(cluster, s.GetName()) {
+		return false
+	}
+	allowed := s.OpController.OperatorCount(operator.OpLeader) < cluster.GetOpts().GetLeaderScheduleLimit()
+	if !allowed {
+		operator.OperatorLimitCounter.WithLabelValues(s.GetType(), operator.OpLeader.String()).Inc()
+	}
+	return allowed
+}
+
+func (s *balanceReadScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+
+	loads, avg := s.getStoreReadLoads(cluster)
+	if len(loads) < 2 || loads[0].load <= avg*balanceReadTolerantRatio {
+		// the read load is balanced, all the hints are useless now
+		schedulerCounter.WithLabelValues(s.GetName(), "balanced").Inc()
+		s.clearReadHints(cluster)
+		return nil
+	}
+
+	source := loads[0]
+	targets := make(map[uint64]float64, len(loads))
+	for _, l := range loads[1:] {
+		if l.load < avg && filter.Target(cluster.GetOpts(), l.store, s.filters) {
+			targets[l.store.Meta.GetID()] = l.load
+		}
+	}
+	if len(targets) == 0 {
+		schedulerCounter.WithLabelValues(s.GetName(), "no-target-store").Inc()
+		return nil
+	}
+
+	for _, stats := range source.replicas {
+		shard := cluster.GetShard(stats.ShardID)
+		if shard == nil {
+			continue
+		}
+		if _, ok := s.hints[shard.Meta.GetID()]; ok {
+			continue
+		}
+
+		if shard.GetLeader().GetID() == stats.ReplicaID {
+			if op := s.transferLeader(cluster, shard, source.store, targets); op != nil {
+				return []*operator.Operator{op}
+			}
+			continue
+		}
+
+		if s.setReadHint(cluster, shard, source.store, targets) {
+			return nil
+		}
+	}
+	schedulerCounter.WithLabelValues(s.GetName(), "no-replica").Inc()
+	return nil
+}
+
+// transferLeader moves the leader reads of the shard to the follower in the
+// coldest target store.
+func (s *balanceReadScheduler) transferLeader(cluster opt.Cluster, shard *core.CachedShard,
+	source *core.CachedStore, targets map[uint64]float64) *operator.Operator {
+	var target uint64
+	for _, p := range shard.GetFollowers() {
+		load, ok := targets[p.StoreID]
+		if ok && (target == 0 || load < targets[target]) {
+			target = p.StoreID
+		}
+	}
+	if target == 0 {
+		return nil
+	}
+
+	op, err := operator.CreateTransferLeaderOperator(BalanceReadType, cluster, shard,
+		source.Meta.GetID(), target, operator.OpHotShard)
+	if err != nil {
+		cluster.GetLogger().Error("fail to create balance read operator",
+			balanceReadField,
+			zap.Error(err))
+		return nil
+	}
+	op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
+	return op
+}
+
+// setReadHint moves the follower reads of the shard to the replicas in the
+// target stores.
+func (s *balanceReadScheduler) setReadHint(cluster opt.Cluster, shard *core.CachedShard,
+	source *core.CachedStore, targets map[uint64]float64) bool {
+	var replicas []uint64
+	for _, p := range shard.Meta.GetReplicas() {
+		if _, ok := targets[p.StoreID]; ok && p.StoreID != source.Meta.GetID() {
+			replicas = append(replicas, p.ID)
+		}
+	}
+	if len(replicas) == 0 {
+		return false
+	}
+
+	cluster.SetShardReadHint(metapb.ShardReadHint{
+		ShardID:  shard.Meta.GetID(),
+		Replicas: replicas,
+	})
+	s.hints[shard.Meta.GetID()] = struct{}{}
+	schedulerCounter.WithLabelValues(s.GetName(), "new-read-hint").Inc()
+	return true
+}
+
+func (s *balanceReadScheduler) clearReadHints(cluster opt.Cluster) {
+	for id := range s.hints {
+		cluster.SetShardReadHint(metapb.ShardReadHint{ShardID: id})
+		delete(s.hints, id)
+	}
+}
+
+// getStoreReadLoads returns the read loads of the up stores sorted from the
+// hottest, and the average read load.
+func (s *balanceReadScheduler) getStoreReadLoads(cluster opt.Cluster) ([]storeReadLoad, float64) {
+	var loads []storeReadLoad
+	var total float64
+	for _, store := range cluster.GetStores() {
+		if !store.IsUp() {
+			continue
+		}
+
+		stats := store.GetStoreStats()
+		interval := stats.GetInterval().GetEnd() - stats.GetInterval().GetStart()
+		if interval == 0 {
+			interval = 1
+		}
+		l := storeReadLoad{store: store}
+		for _, rs := range store.GetReplicaReadStats() {
+			l.load += float64(rs.ReadKeys)
+			l.replicas = append(l.replicas, rs)
+		}
+		l.load /= float64(interval)
+		sort.Slice(l.replicas, func(i, j int) bool {
+			return l.replicas[i].ReadKeys > l.replicas[j].ReadKeys
+		})
+		loads = append(loads, l)
+		total += l.load
+	}
+	if len(loads) == 0 {
+		return nil, 0
+	}
+
+	sort.Slice(loads, func(i, j int) bool {
+		return loads[i].load > loads[j].load
+	})
+	return loads, total / float64(len(loads))
+}
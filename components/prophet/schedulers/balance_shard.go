@@ -134,6 +134,9 @@ func (s *balanceShardScheduler) EncodeConfig() ([]byte, error) {
 }
 
 func (s *balanceShardScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
+	if !isInMaintenanceWindow(cluster, s.GetName()) {
+		return false
+	}
 	allowed := s.opController.OperatorCount(operator.OpShard)-s.opController.OperatorCount(operator.OpMerge) < cluster.GetOpts().GetShardScheduleLimit()
 	if !allowed {
 		operator.OperatorLimitCounter.WithLabelValues(s.GetType(), operator.OpShard.String()).Inc()
@@ -167,8 +170,8 @@ func (s *balanceShardScheduler) scheduleByGroup(groupKey string, cluster opt.Clu
 	sort.Slice(stores, func(i, j int) bool {
 		iOp := opInfluence.GetStoreInfluence(stores[i].Meta.GetID()).ShardProperty(kind, groupKey)
 		jOp := opInfluence.GetStoreInfluence(stores[j].Meta.GetID()).ShardProperty(kind, groupKey)
-		return stores[i].ShardScore(groupKey, opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), iOp, -1) >
-			stores[j].ShardScore(groupKey, opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), jOp, -1)
+		return stores[i].ShardScoreBy(opts.GetShardScoreFunction(), groupKey, opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), iOp, -1) >
+			stores[j].ShardScoreBy(opts.GetShardScoreFunction(), groupKey, opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), jOp, -1)
 	})
 
 	groupID := util.DecodeGroupKey(groupKey)
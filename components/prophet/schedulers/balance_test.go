@@ -925,6 +925,41 @@ func TestStoreWeight(t *testing.T) {
 	testutil.CheckTransferPeer(t, sb.Schedule(tc)[0], operator.OpKind(0), 1, 3)
 }
 
+func TestShardScoreFunction(t *testing.T) {
+	s := &testBalanceresourceScheduler{}
+	s.setup()
+	defer s.tearDown()
+
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+	tc.SetPlacementRuleEnabled(false)
+	tc.DisableJointConsensus()
+	oc := schedule.NewOperatorController(s.ctx, tc, nil)
+
+	sb, err := schedule.CreateScheduler(BalanceShardType, oc, storage.NewTestStorage(), schedule.ConfigSliceDecoder(BalanceShardType, []string{"0", "", ""}))
+	assert.NoError(t, err)
+	opt.SetMaxReplicas(1)
+
+	for id := uint64(1); id <= 4; id++ {
+		tc.AddShardStore(id, 10)
+	}
+	// the capacity of the store 4 is 4 times of the others
+	store := tc.GetStore(4)
+	stats := &metapb.StoreStats{
+		Capacity: store.GetCapacity() * 4,
+		UsedSize: store.GetUsedSize(),
+	}
+	stats.Available = stats.Capacity - stats.UsedSize
+	tc.PutStore(store.Clone(core.SetStoreStats(stats)))
+	tc.AddLeaderShard(1, 1)
+
+	// the stores hold the same size of the shards
+	assert.Empty(t, sb.Schedule(tc))
+
+	tc.SetShardScoreFunction("capacity-ratio")
+	testutil.CheckTransferPeer(t, sb.Schedule(tc)[0], operator.OpKind(0), 1, 4)
+}
+
 func TestReplacePendingresource(t *testing.T) {
 	s := &testBalanceresourceScheduler{}
 	s.setup()
@@ -1268,3 +1303,54 @@ func TestBalanceWhenresourceNotHeartbeat(t *testing.T) {
 		schedule.ApplyOperator(tc, ops[0])
 	}
 }
+
+func TestBalanceReadSchedule(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+	oc := schedule.NewOperatorController(ctx, tc, nil)
+
+	sb, err := schedule.CreateScheduler(BalanceReadType, oc, storage.NewTestStorage(), schedule.ConfigSliceDecoder(BalanceReadType, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, BalanceReadName, sb.GetName())
+
+	for i := uint64(1); i <= 3; i++ {
+		tc.AddLeaderStore(i, 1)
+	}
+	shard1 := tc.AddLeaderShard(1, 1, 2, 3)
+	shard2 := tc.AddLeaderShard(2, 2, 1, 3)
+	replica := func(shard *core.CachedShard, storeID uint64) uint64 {
+		p, ok := shard.GetStorePeer(storeID)
+		assert.True(t, ok)
+		return p.ID
+	}
+
+	// no read load
+	assert.Empty(t, sb.Schedule(tc))
+
+	// the leader reads of the hot store are moved by transferring the leader
+	tc.UpdateReplicaReadStats(1,
+		metapb.ReplicaReadStats{ShardID: 1, ReplicaID: replica(shard1, 1), ReadKeys: 1000},
+		metapb.ReplicaReadStats{ShardID: 2, ReplicaID: replica(shard2, 1), ReadKeys: 500})
+	tc.UpdateReplicaReadStats(2, metapb.ReplicaReadStats{ShardID: 2, ReplicaID: replica(shard2, 2), ReadKeys: 20})
+	tc.UpdateReplicaReadStats(3, metapb.ReplicaReadStats{ShardID: 1, ReplicaID: replica(shard1, 3), ReadKeys: 10})
+	ops := sb.Schedule(tc)
+	assert.Len(t, ops, 1)
+	testutil.CheckTransferLeader(t, ops[0], operator.OpHotShard, 1, 3)
+
+	// the follower reads of the hot store are moved by the read hint
+	tc.UpdateReplicaReadStats(1, metapb.ReplicaReadStats{ShardID: 2, ReplicaID: replica(shard2, 1), ReadKeys: 1000})
+	assert.Empty(t, sb.Schedule(tc))
+	hint, ok := tc.GetShardReadHint(2)
+	assert.True(t, ok)
+	assert.ElementsMatch(t, []uint64{replica(shard2, 2), replica(shard2, 3)}, hint.Replicas)
+
+	// the hints are removed after the read load balanced
+	for i := uint64(1); i <= 3; i++ {
+		tc.UpdateReplicaReadStats(i, metapb.ReplicaReadStats{ShardID: 2, ReplicaID: replica(shard2, i), ReadKeys: 100})
+	}
+	assert.Empty(t, sb.Schedule(tc))
+	_, ok = tc.GetShardReadHint(2)
+	assert.False(t, ok)
+}
@@ -49,6 +49,7 @@ var (
 	shuffleLeaderField   = zap.String("schedule-type", "shuffle leader")
 	shuffleHotField      = zap.String("schedule-type", "shuffle hot resource")
 	randomMergeField     = zap.String("schedule-type", "random merge")
+	balanceReadField     = zap.String("schedule-type", "balance read")
 )
 
 // intervalGrow calculates the next interval of balance.
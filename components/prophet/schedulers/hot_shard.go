@@ -0,0 +1,420 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/filter"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/operator"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/opt"
+	"github.com/matrixorigin/matrixcube/components/prophet/statistics"
+	"github.com/matrixorigin/matrixcube/components/prophet/storage"
+	"github.com/matrixorigin/matrixcube/components/prophet/util/typeutil"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"go.uber.org/zap"
+)
+
+const (
+	// HotShardName is hot shard scheduler name.
+	HotShardName = "hot-shard-scheduler"
+	// HotShardType is hot shard scheduler type.
+	HotShardType = "hot-shard"
+
+	defaultHotMinByteRate         = 100 * 1024
+	defaultHotMinKeyRate          = 100
+	defaultHotToleranceRatio      = 1.2
+	defaultHotMinScheduleInterval = time.Second
+	defaultHotMaxScheduleInterval = 20 * time.Second
+)
+
+func init() {
+	schedule.RegisterSliceDecoderBuilder(HotShardType, func(args []string) schedule.ConfigDecoder {
+		return func(v interface{}) error {
+			conf, ok := v.(*hotShardSchedulerConfig)
+			if !ok {
+				return errors.New("scheduler error configuration")
+			}
+			conf.Name = HotShardName
+			return conf.parseArgs(args)
+		}
+	})
+
+	schedule.RegisterScheduler(HotShardType, func(opController *schedule.OperatorController, storage storage.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
+		conf := &hotShardSchedulerConfig{}
+		if err := decoder(conf); err != nil {
+			return nil, err
+		}
+		conf.adjust()
+		return newHotShardScheduler(opController, conf), nil
+	})
+}
+
+// hotShardSchedulerConfig the config of the hot shard scheduler, the args of
+// the scheduler are the `key=value` pairs of the json names of the fields, e.g.
+// `min-hot-byte-rate=1048576` and `max-schedule-interval=30s`.
+type hotShardSchedulerConfig struct {
+	Name string `json:"name"`
+	// MinHotByteRate and MinHotKeyRate the replica is hot if either of its flow
+	// rates per second reaches the threshold
+	MinHotByteRate float64 `json:"min-hot-byte-rate"`
+	MinHotKeyRate  float64 `json:"min-hot-key-rate"`
+	// MinHotDegree the replica is hot if it's reported hot continuously by the
+	// times, 0 means the `hot-resource-cache-hits-threshold` of the schedule
+	// config.
+	MinHotDegree int `json:"min-hot-degree"`
+	// ToleranceRatio the hot load of the hottest store should exceed the average
+	// by the ratio before balancing
+	ToleranceRatio float64 `json:"tolerance-ratio"`
+	// MinScheduleInterval and MaxScheduleInterval the interval of the scheduler
+	// grows from the min to the max if no operator is created
+	MinScheduleInterval typeutil.Duration `json:"min-schedule-interval"`
+	MaxScheduleInterval typeutil.Duration `json:"max-schedule-interval"`
+}
+
+func (conf *hotShardSchedulerConfig) adjust() {
+	if conf.Name == "" {
+		conf.Name = HotShardName
+	}
+	if conf.MinHotByteRate <= 0 {
+		conf.MinHotByteRate = defaultHotMinByteRate
+	}
+	if conf.MinHotKeyRate <= 0 {
+		conf.MinHotKeyRate = defaultHotMinKeyRate
+	}
+	if conf.ToleranceRatio <= 1 {
+		conf.ToleranceRatio = defaultHotToleranceRatio
+	}
+	if conf.MinScheduleInterval.Duration <= 0 {
+		conf.MinScheduleInterval.Duration = defaultHotMinScheduleInterval
+	}
+	if conf.MaxScheduleInterval.Duration <= 0 {
+		conf.MaxScheduleInterval.Duration = defaultHotMaxScheduleInterval
+	}
+	if conf.MaxScheduleInterval.Duration < conf.MinScheduleInterval.Duration {
+		conf.MaxScheduleInterval.Duration = conf.MinScheduleInterval.Duration
+	}
+}
+
+func (conf *hotShardSchedulerConfig) parseArgs(args []string) error {
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid hot shard scheduler arg %q", arg)
+		}
+		var err error
+		switch kv[0] {
+		case "min-hot-byte-rate":
+			conf.MinHotByteRate, err = strconv.ParseFloat(kv[1], 64)
+		case "min-hot-key-rate":
+			conf.MinHotKeyRate, err = strconv.ParseFloat(kv[1], 64)
+		case "min-hot-degree":
+			conf.MinHotDegree, err = strconv.Atoi(kv[1])
+		case "tolerance-ratio":
+			conf.ToleranceRatio, err = strconv.ParseFloat(kv[1], 64)
+		case "min-schedule-interval":
+			conf.MinScheduleInterval.Duration, err = time.ParseDuration(kv[1])
+		case "max-schedule-interval":
+			conf.MaxScheduleInterval.Duration, err = time.ParseDuration(kv[1])
+		default:
+			return fmt.Errorf("unknown hot shard scheduler arg %q", arg)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid hot shard scheduler arg %q: %v", arg, err)
+		}
+	}
+	return nil
+}
+
+// storeHotLoad the hot load of a store, the load is the sum of the byte rates
+// of the hot replicas in the store.
+type storeHotLoad struct {
+	store      *core.CachedStore
+	leaderLoad float64
+	peerLoad   float64
+	// leaders and peers the hot replicas sorted from the hottest
+	leaders []statistics.HotPeerStat
+	peers   []statistics.HotPeerStat
+}
+
+type hotShardScheduler struct {
+	*BaseScheduler
+	conf *hotShardSchedulerConfig
+}
+
+// newHotShardScheduler creates a scheduler that balances the hot replicas of
+// the write and read flows between stores by the rolling flow stats of the
+// shard heartbeats. The hot leaders of the hottest store are transferred to
+// the followers in the colder stores first, then the hot replicas are moved to
+// the colder stores without the replicas of the shards.
+func newHotShardScheduler(opController *schedule.OperatorController, conf *hotShardSchedulerConfig) schedule.Scheduler {
+	return &hotShardScheduler{
+		BaseScheduler: NewBaseScheduler(opController),
+		conf:          conf,
+	}
+}
+
+func (s *hotShardScheduler) GetName() string {
+	return s.conf.Name
+}
+
+func (s *hotShardScheduler) GetType() string {
+	return HotShardType
+}
+
+func (s *hotShardScheduler) EncodeConfig() ([]byte, error) {
+	return schedule.EncodeConfig(s.conf)
+}
+
+func (s *hotShardScheduler) GetMinInterval() time.Duration {
+	return s.conf.MinScheduleInterval.Duration
+}
+
+func (s *hotShardScheduler) GetNextInterval(interval time.Duration) time.Duration {
+	return intervalGrow(interval, s.conf.MaxScheduleInterval.Duration, exponentialGrowth)
+}
+
+func (s *hotShardScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
+	if !isInMaintenanceWindow(cluster, s.GetName()) {
+		return false
+	}
+	allowed := uint64(s.OpController.OperatorCount(operator.OpHotShard)) < cluster.GetOpts().GetHotShardScheduleLimit()
+	if !allowed {
+		operator.OperatorLimitCounter.WithLabelValues(s.GetType(), operator.OpHotShard.String()).Inc()
+	}
+	return allowed
+}
+
+func (s *hotShardScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	for _, kind := range []statistics.FlowKind{statistics.WriteFlow, statistics.ReadFlow} {
+		loads := s.getStoreHotLoads(cluster, kind)
+		if len(loads) < 2 {
+			continue
+		}
+		if op := s.balanceHotLeaders(cluster, kind, loads); op != nil {
+			return []*operator.Operator{op}
+		}
+		if op := s.balanceHotPeers(cluster, kind, loads); op != nil {
+			return []*operator.Operator{op}
+		}
+	}
+	schedulerCounter.WithLabelValues(s.GetName(), "balanced").Inc()
+	return nil
+}
+
+// balanceHotLeaders transfers the hot leader of the store with the highest hot
+// leader load to the follower in the coldest store.
+func (s *hotShardScheduler) balanceHotLeaders(cluster opt.Cluster, kind statistics.FlowKind,
+	loads []storeHotLoad) *operator.Operator {
+	sortStoreHotLoads(loads, func(l storeHotLoad) float64 { return l.leaderLoad })
+	source := loads[0]
+	if !s.shouldBalance(source.leaderLoad, loads, func(l storeHotLoad) float64 { return l.leaderLoad }) {
+		return nil
+	}
+
+	sourceID := source.store.Meta.GetID()
+	for _, stat := range source.leaders {
+		shard := s.getScheduleShard(cluster, stat)
+		if shard == nil || shard.GetLeader().GetStoreID() != sourceID {
+			continue
+		}
+
+		filters := []filter.Filter{
+			&filter.StoreStateFilter{ActionScope: s.GetName(), TransferLeader: true},
+			filter.NewSpecialUseFilter(s.GetName()),
+			filter.NewPlacementLeaderSafeguard(s.GetName(), cluster, shard, source.store),
+		}
+		target := s.selectTarget(cluster, source.leaderLoad, stat.ByteRate, loads, filters,
+			func(l storeHotLoad) (float64, bool) {
+				_, ok := shard.GetStoreVoter(l.store.Meta.GetID())
+				return l.leaderLoad, ok && l.store.Meta.GetID() != sourceID
+			})
+		if target == 0 {
+			continue
+		}
+
+		op, err := operator.CreateTransferLeaderOperator(HotShardType, cluster, shard,
+			sourceID, target, operator.OpHotShard)
+		if err != nil {
+			cluster.GetLogger().Error("fail to create hot leader operator",
+				rebalanceHotField,
+				shardField(shard.Meta.GetID()),
+				zap.Error(err))
+			schedulerCounter.WithLabelValues(s.GetName(), "create-operator-fail").Inc()
+			return nil
+		}
+		op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-"+kind.String()+"-leader-operator"))
+		return op
+	}
+	return nil
+}
+
+// balanceHotPeers moves the hot replica of the store with the highest hot load
+// to the coldest store without the replica of the shard.
+func (s *hotShardScheduler) balanceHotPeers(cluster opt.Cluster, kind statistics.FlowKind,
+	loads []storeHotLoad) *operator.Operator {
+	sortStoreHotLoads(loads, func(l storeHotLoad) float64 { return l.peerLoad })
+	source := loads[0]
+	if !s.shouldBalance(source.peerLoad, loads, func(l storeHotLoad) float64 { return l.peerLoad }) {
+		return nil
+	}
+
+	sourceID := source.store.Meta.GetID()
+	for _, stat := range source.peers {
+		shard := s.getScheduleShard(cluster, stat)
+		if shard == nil {
+			continue
+		}
+		oldPeer, ok := shard.GetStorePeer(sourceID)
+		if !ok {
+			continue
+		}
+
+		filters := []filter.Filter{
+			filter.NewExcludedFilter(s.GetName(), nil, shard.GetStoreIDs()),
+			filter.NewPlacementSafeguard(s.GetName(), cluster, shard, source.store),
+			filter.NewSpecialUseFilter(s.GetName()),
+			&filter.StoreStateFilter{ActionScope: s.GetName(), MoveShard: true},
+		}
+		target := s.selectTarget(cluster, source.peerLoad, stat.ByteRate, loads, filters,
+			func(l storeHotLoad) (float64, bool) {
+				return l.peerLoad, true
+			})
+		if target == 0 {
+			continue
+		}
+
+		newPeer := metapb.Replica{StoreID: target, Role: oldPeer.Role}
+		var op *operator.Operator
+		var err error
+		if stat.IsLeader && kind == statistics.ReadFlow {
+			op, err = operator.CreateMoveLeaderOperator(HotShardType, cluster, shard, operator.OpHotShard, sourceID, newPeer)
+		} else {
+			op, err = operator.CreateMovePeerOperator(HotShardType, cluster, shard, operator.OpHotShard, sourceID, newPeer)
+		}
+		if err != nil {
+			cluster.GetLogger().Error("fail to create hot peer operator",
+				rebalanceHotField,
+				shardField(shard.Meta.GetID()),
+				zap.Error(err))
+			schedulerCounter.WithLabelValues(s.GetName(), "create-operator-fail").Inc()
+			return nil
+		}
+		op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-"+kind.String()+"-peer-operator"))
+		return op
+	}
+	return nil
+}
+
+// shouldBalance returns true if the load of the source exceeds the average by
+// the tolerance ratio
+func (s *hotShardScheduler) shouldBalance(sourceLoad float64, loads []storeHotLoad,
+	load func(storeHotLoad) float64) bool {
+	var total float64
+	for _, l := range loads {
+		total += load(l)
+	}
+	avg := total / float64(len(loads))
+	return sourceLoad > 0 && sourceLoad > avg*s.conf.ToleranceRatio
+}
+
+// selectTarget returns the coldest candidate store which is still colder than
+// the source after the hot replica of the rate is moved to it, 0 if no store is
+// selected.
+func (s *hotShardScheduler) selectTarget(cluster opt.Cluster, sourceLoad, rate float64,
+	loads []storeHotLoad, filters []filter.Filter, candidate func(storeHotLoad) (float64, bool)) uint64 {
+	var target uint64
+	var targetLoad float64
+	for _, l := range loads {
+		load, ok := candidate(l)
+		if !ok || load+rate >= sourceLoad-rate ||
+			!filter.Target(cluster.GetOpts(), l.store, filters) {
+			continue
+		}
+		if target == 0 || load < targetLoad {
+			target, targetLoad = l.store.Meta.GetID(), load
+		}
+	}
+	return target
+}
+
+// getScheduleShard returns the healthy shard of the hot replica without the
+// running operator
+func (s *hotShardScheduler) getScheduleShard(cluster opt.Cluster, stat statistics.HotPeerStat) *core.CachedShard {
+	shard := cluster.GetShard(stat.ShardID)
+	if shard == nil || shard.GetLeader() == nil || shard.IsDestroyState() ||
+		s.OpController.GetOperator(stat.ShardID) != nil ||
+		!opt.IsShardHealthy(cluster, shard) {
+		return nil
+	}
+	return shard
+}
+
+// getStoreHotLoads returns the hot loads of the flow kind of the up stores
+func (s *hotShardScheduler) getStoreHotLoads(cluster opt.Cluster, kind statistics.FlowKind) []storeHotLoad {
+	minHotDegree := s.conf.MinHotDegree
+	if minHotDegree <= 0 {
+		minHotDegree = cluster.GetOpts().GetHotShardCacheHitsThreshold()
+	}
+	stats := cluster.GetHotPeerStats(kind, minHotDegree)
+
+	var loads []storeHotLoad
+	for _, store := range cluster.GetStores() {
+		if !store.IsUp() {
+			continue
+		}
+		l := storeHotLoad{store: store}
+		for _, stat := range stats[store.Meta.GetID()] {
+			if !stat.IsHot(s.conf.MinHotByteRate, s.conf.MinHotKeyRate) {
+				continue
+			}
+			l.peerLoad += stat.ByteRate
+			l.peers = append(l.peers, stat)
+			if stat.IsLeader {
+				l.leaderLoad += stat.ByteRate
+				l.leaders = append(l.leaders, stat)
+			}
+		}
+		sortHotPeerStats(l.leaders)
+		sortHotPeerStats(l.peers)
+		loads = append(loads, l)
+	}
+	return loads
+}
+
+func sortStoreHotLoads(loads []storeHotLoad, load func(storeHotLoad) float64) {
+	sort.Slice(loads, func(i, j int) bool {
+		if load(loads[i]) != load(loads[j]) {
+			return load(loads[i]) > load(loads[j])
+		}
+		return loads[i].store.Meta.GetID() < loads[j].store.Meta.GetID()
+	})
+}
+
+func sortHotPeerStats(stats []statistics.HotPeerStat) {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].ByteRate != stats[j].ByteRate {
+			return stats[i].ByteRate > stats[j].ByteRate
+		}
+		return stats[i].ShardID < stats[j].ShardID
+	})
+}
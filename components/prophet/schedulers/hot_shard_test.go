@@ -0,0 +1,151 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/config"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/mock/mockcluster"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule"
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/operator"
+	"github.com/matrixorigin/matrixcube/components/prophet/statistics"
+	"github.com/matrixorigin/matrixcube/components/prophet/storage"
+	"github.com/matrixorigin/matrixcube/components/prophet/testutil"
+)
+
+const testHotRate = 200 * 1024
+
+// hotFlows reports the accumulated flows of the shards to the mock cluster
+type hotFlows struct {
+	tc     *mockcluster.Cluster
+	shards []*core.CachedShard
+	writes []uint64
+	reads  []uint64
+}
+
+// report reports the flows of the shards by the rates multiple of testHotRate
+// for the times
+func (f *hotFlows) report(times int, writeRates, readRates []float64) {
+	for i := 0; i < times; i++ {
+		for idx, shard := range f.shards {
+			f.writes[idx] += uint64(writeRates[idx] * testHotRate * statistics.ShardHeartBeatReportInterval)
+			f.reads[idx] += uint64(readRates[idx] * testHotRate * statistics.ShardHeartBeatReportInterval)
+			f.shards[idx] = f.tc.UpdateShardFlow(shard, f.writes[idx], f.writes[idx]/1024, f.reads[idx], f.reads[idx]/1024)
+		}
+	}
+}
+
+func newTestHotShardScheduler(t *testing.T, args ...string) (*mockcluster.Cluster, schedule.Scheduler, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tc := mockcluster.NewCluster(config.NewTestOptions())
+	oc := schedule.NewOperatorController(ctx, tc, nil)
+	hs, err := schedule.CreateScheduler(HotShardType, oc, storage.NewTestStorage(), schedule.ConfigSliceDecoder(HotShardType, args))
+	require.NoError(t, err)
+	for i := uint64(1); i <= 4; i++ {
+		tc.AddShardStore(i, 3)
+	}
+	return tc, hs, cancel
+}
+
+func TestHotShardScheduleWriteLeader(t *testing.T) {
+	tc, hs, cancel := newTestHotShardScheduler(t)
+	defer cancel()
+
+	f := &hotFlows{tc: tc, writes: make([]uint64, 3), reads: make([]uint64, 3)}
+	f.shards = []*core.CachedShard{
+		tc.AddLeaderShard(1, 1, 2, 3),
+		tc.AddLeaderShard(2, 1, 2, 3),
+		tc.AddLeaderShard(3, 1, 2, 3),
+	}
+	rates := []float64{1, 1, 1}
+	// not hot enough before the hot degree reaches the threshold
+	f.report(tc.GetHotShardCacheHitsThreshold(), rates, make([]float64, 3))
+	assert.Empty(t, hs.Schedule(tc))
+
+	f.report(1, rates, make([]float64, 3))
+	ops := hs.Schedule(tc)
+	require.Len(t, ops, 1)
+	testutil.CheckTransferLeader(t, ops[0], operator.OpHotShard, 1, 2)
+}
+
+func TestHotShardScheduleWritePeer(t *testing.T) {
+	tc, hs, cancel := newTestHotShardScheduler(t)
+	defer cancel()
+
+	f := &hotFlows{tc: tc, writes: make([]uint64, 3), reads: make([]uint64, 3)}
+	f.shards = []*core.CachedShard{
+		tc.AddLeaderShard(1, 1, 2, 3),
+		tc.AddLeaderShard(2, 2, 1, 3),
+		tc.AddLeaderShard(3, 3, 1, 2),
+	}
+	// the hot leaders can't be balanced by the followers, the hottest follower
+	// of the hottest store is moved to the cold store
+	f.report(tc.GetHotShardCacheHitsThreshold()+1, []float64{1, 1.5, 1}, make([]float64, 3))
+	ops := hs.Schedule(tc)
+	require.Len(t, ops, 1)
+	assert.Equal(t, uint64(2), ops[0].ShardID())
+	testutil.CheckTransferPeer(t, ops[0], operator.OpHotShard, 1, 4)
+}
+
+func TestHotShardScheduleRead(t *testing.T) {
+	tc, hs, cancel := newTestHotShardScheduler(t, "min-hot-degree=1")
+	defer cancel()
+
+	f := &hotFlows{tc: tc, writes: make([]uint64, 2), reads: make([]uint64, 2)}
+	f.shards = []*core.CachedShard{
+		tc.AddLeaderShard(1, 1, 2, 3),
+		tc.AddLeaderShard(2, 1, 2, 3),
+	}
+	// moving the hottest shard 2 makes the target hotter than the source, so
+	// shard 1 is moved
+	f.report(2, make([]float64, 2), []float64{1, 2})
+	ops := hs.Schedule(tc)
+	require.Len(t, ops, 1)
+	assert.Equal(t, uint64(1), ops[0].ShardID())
+	testutil.CheckTransferLeader(t, ops[0], operator.OpHotShard, 1, 2)
+}
+
+func TestHotShardSchedulerConfig(t *testing.T) {
+	_, hs, cancel := newTestHotShardScheduler(t, "min-hot-byte-rate=1024", "min-schedule-interval=2s",
+		"max-schedule-interval=30s")
+	defer cancel()
+
+	conf := hs.(*hotShardScheduler).conf
+	assert.Equal(t, HotShardName, hs.GetName())
+	assert.Equal(t, float64(1024), conf.MinHotByteRate)
+	assert.Equal(t, float64(defaultHotMinKeyRate), conf.MinHotKeyRate)
+	assert.Equal(t, 2*time.Second, hs.GetMinInterval())
+	assert.Equal(t, 30*time.Second, hs.GetNextInterval(time.Minute))
+
+	data, err := hs.EncodeConfig()
+	require.NoError(t, err)
+	decoded := &hotShardSchedulerConfig{}
+	require.NoError(t, schedule.DecodeConfig(data, decoded))
+	assert.Equal(t, conf, decoded)
+
+	ctx, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	tc := mockcluster.NewCluster(config.NewTestOptions())
+	oc := schedule.NewOperatorController(ctx, tc, nil)
+	_, err = schedule.CreateScheduler(HotShardType, oc, storage.NewTestStorage(),
+		schedule.ConfigSliceDecoder(HotShardType, []string{"unknown=1"}))
+	assert.Error(t, err)
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"github.com/matrixorigin/matrixcube/components/prophet/schedule/opt"
 	"testing"
+	"time"
 
 	"github.com/matrixorigin/matrixcube/components/prophet/config"
 	"github.com/matrixorigin/matrixcube/components/prophet/core"
@@ -380,3 +381,46 @@ func TestBalanceLeaderWithConflictRule(t *testing.T) {
 		}
 	}
 }
+
+func TestMaintenanceWindow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opts)
+	oc := schedule.NewOperatorController(ctx, tc, nil)
+	var heavy []schedule.Scheduler
+	for typ, args := range map[string][]string{
+		BalanceLeaderType: {"0", "", ""},
+		BalanceShardType:  {"0", "", ""},
+		RandomMergeType:   {"0", "", ""},
+		HotShardType:      nil,
+		BalanceReadType:   nil,
+	} {
+		s, err := schedule.CreateScheduler(typ, oc, storage.NewTestStorage(), schedule.ConfigSliceDecoder(typ, args))
+		assert.NoError(t, err)
+		heavy = append(heavy, s)
+	}
+	evict, err := schedule.CreateScheduler(EvictLeaderType, oc, storage.NewTestStorage(), schedule.ConfigSliceDecoder(EvictLeaderType, []string{"1"}))
+	assert.NoError(t, err)
+	tc.AddLeaderStore(1, 0)
+
+	for _, s := range heavy {
+		assert.True(t, s.IsScheduleAllowed(tc), s.GetType())
+	}
+
+	now := time.Now()
+	format := func(d time.Duration) string {
+		return now.Add(d).Format("15:04")
+	}
+	tc.SetMaintenanceWindows([]config.MaintenanceWindow{{Start: format(time.Hour), End: format(time.Hour * 2)}})
+	for _, s := range heavy {
+		assert.False(t, s.IsScheduleAllowed(tc), s.GetType())
+	}
+	assert.True(t, evict.IsScheduleAllowed(tc))
+
+	tc.SetMaintenanceWindows([]config.MaintenanceWindow{{Start: format(-time.Hour), End: format(time.Hour)}})
+	for _, s := range heavy {
+		assert.True(t, s.IsScheduleAllowed(tc), s.GetType())
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"math"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/matrixorigin/matrixcube/components/log"
 	"github.com/matrixorigin/matrixcube/components/prophet/core"
@@ -68,8 +69,8 @@ func shouldBalance(cluster opt.Cluster,
 		sourceScore = source.LeaderScore(res.GetGroupKey(), kind.Policy, sourceDelta)
 		targetScore = target.LeaderScore(res.GetGroupKey(), kind.Policy, targetDelta)
 	case metapb.ShardType_AllShards:
-		sourceScore = source.ShardScore(res.GetGroupKey(), opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), sourceDelta, -1)
-		targetScore = target.ShardScore(res.GetGroupKey(), opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), targetDelta, 1)
+		sourceScore = source.ShardScoreBy(opts.GetShardScoreFunction(), res.GetGroupKey(), opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), sourceDelta, -1)
+		targetScore = target.ShardScoreBy(opts.GetShardScoreFunction(), res.GetGroupKey(), opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), targetDelta, 1)
 	}
 	if opts.IsDebugMetricsEnabled() {
 		opInfluenceStatus.WithLabelValues(scheduleName, strconv.FormatUint(sourceID, 10), "source").Set(float64(sourceInfluence))
@@ -149,6 +150,16 @@ func adjustBalanceLimit(groupKey string, cluster opt.Cluster, kind metapb.ShardT
 	return typeutil.MaxUint64(1, uint64(limit))
 }
 
+// isInMaintenanceWindow returns false if the heavy schedulers, like balance and
+// merge, are not allowed to generate operators now.
+func isInMaintenanceWindow(cluster opt.Cluster, name string) bool {
+	if cluster.GetOpts().IsInMaintenanceWindow(time.Now()) {
+		return true
+	}
+	schedulerCounter.WithLabelValues(name, "out-of-maintenance-window").Inc()
+	return false
+}
+
 func getKeyRanges(args []string) ([]core.KeyRange, error) {
 	var ranges []core.KeyRange
 	for len(args) > 1 {
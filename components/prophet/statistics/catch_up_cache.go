@@ -0,0 +1,206 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/util/movingaverage"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+const (
+	// catchUpRateDecay the decay of the moving average of the catch-up rates,
+	// the recent reports are weighted more since the rates change quickly
+	catchUpRateDecay = 0.5
+)
+
+// CatchUpStat the catch-up statistics of a replica behind the leader of the
+// shard, e.g. the replica added by the operators.
+type CatchUpStat struct {
+	ShardID uint64
+	Replica metapb.Replica
+	// SnapshotPhase, SnapshotSentBytes and SnapshotTotalBytes the progress of
+	// the snapshot sent to the replica
+	SnapshotPhase      metapb.SnapshotPhase
+	SnapshotSentBytes  uint64
+	SnapshotTotalBytes uint64
+	// SnapshotFailure, SnapshotFailures and SnapshotQuarantined the consecutive
+	// failures of the snapshots sent to the replica
+	SnapshotFailure     metapb.SnapshotFailureReason
+	SnapshotFailures    uint64
+	SnapshotQuarantined bool
+	// EntriesBehind the number of the raft log entries behind the leader
+	EntriesBehind uint64
+	// ByteRate the snapshot bytes sent per second
+	ByteRate float64
+	// EntryRate the decrease of the entries behind per second
+	EntryRate      float64
+	LastUpdateTime time.Time
+
+	byteRate  *movingaverage.EMA
+	entryRate *movingaverage.EMA
+}
+
+func newCatchUpStat(shardID uint64, replica metapb.Replica) *CatchUpStat {
+	return &CatchUpStat{
+		ShardID:   shardID,
+		Replica:   replica,
+		byteRate:  movingaverage.NewEMA(catchUpRateDecay),
+		entryRate: movingaverage.NewEMA(catchUpRateDecay),
+	}
+}
+
+// ETA returns the estimated duration of the replica caught up, false if it
+// can't be estimated, e.g. the snapshot is not sent yet.
+func (s *CatchUpStat) ETA() (time.Duration, bool) {
+	switch s.SnapshotPhase {
+	case metapb.SnapshotPhase_NoSnapshot:
+		if s.EntriesBehind == 0 {
+			return 0, true
+		}
+		if s.EntryRate > 0 {
+			return rateDuration(float64(s.EntriesBehind), s.EntryRate), true
+		}
+	case metapb.SnapshotPhase_SendingSnapshot:
+		if s.ByteRate > 0 && s.SnapshotTotalBytes >= s.SnapshotSentBytes {
+			return rateDuration(float64(s.SnapshotTotalBytes-s.SnapshotSentBytes), s.ByteRate), true
+		}
+	}
+	return 0, false
+}
+
+func rateDuration(remaining, rate float64) time.Duration {
+	return time.Duration(remaining / rate * float64(time.Second))
+}
+
+func (s *CatchUpStat) update(progress metapb.ReplicaProgress, behind uint64, now time.Time) {
+	if !s.LastUpdateTime.IsZero() {
+		if interval := now.Sub(s.LastUpdateTime).Seconds(); interval > 0 {
+			if progress.SnapshotPhase == metapb.SnapshotPhase_SendingSnapshot &&
+				s.SnapshotPhase == metapb.SnapshotPhase_SendingSnapshot &&
+				progress.SnapshotSentBytes >= s.SnapshotSentBytes {
+				s.byteRate.Add(float64(progress.SnapshotSentBytes-s.SnapshotSentBytes) / interval)
+			}
+			if progress.SnapshotPhase == metapb.SnapshotPhase_NoSnapshot &&
+				s.SnapshotPhase == metapb.SnapshotPhase_NoSnapshot {
+				var caughtUp float64
+				if s.EntriesBehind > behind {
+					caughtUp = float64(s.EntriesBehind - behind)
+				}
+				s.entryRate.Add(caughtUp / interval)
+			}
+		}
+	}
+
+	// the rates of the previous snapshot are not used by the next one
+	if progress.SnapshotPhase != s.SnapshotPhase &&
+		progress.SnapshotPhase == metapb.SnapshotPhase_SendingSnapshot {
+		s.byteRate.Reset()
+	}
+	s.Replica = progress.Replica
+	s.SnapshotPhase = progress.SnapshotPhase
+	s.SnapshotSentBytes = progress.SnapshotSentBytes
+	s.SnapshotTotalBytes = progress.SnapshotTotalBytes
+	s.SnapshotFailure = progress.SnapshotFailure
+	s.SnapshotFailures = progress.SnapshotFailures
+	s.SnapshotQuarantined = progress.SnapshotQuarantined
+	s.EntriesBehind = behind
+	s.ByteRate = s.byteRate.Get()
+	s.EntryRate = s.entryRate.Get()
+	s.LastUpdateTime = now
+}
+
+// CatchUpCache tracks the catch-up statistics of the replicas behind the
+// leaders by the replica progresses reported in the shard heartbeats. The
+// replicas caught up are removed.
+type CatchUpCache struct {
+	sync.RWMutex
+	shards map[uint64]map[uint64]*CatchUpStat // shard id -> replica id -> stat
+}
+
+// NewCatchUpCache creates a catch-up cache
+func NewCatchUpCache() *CatchUpCache {
+	return &CatchUpCache{
+		shards: make(map[uint64]map[uint64]*CatchUpStat),
+	}
+}
+
+// Observe updates the catch-up statistics of the replicas of the shard
+func (c *CatchUpCache) Observe(res *core.CachedShard) {
+	c.observe(res, time.Now())
+}
+
+func (c *CatchUpCache) observe(res *core.CachedShard, now time.Time) {
+	id := res.Meta.GetID()
+	leader := res.GetLeader()
+	var leaderProgress metapb.ReplicaProgress
+	ok := leader != nil
+	if ok {
+		leaderProgress, ok = res.GetReplicaProgress(leader.ID)
+	}
+	if !ok {
+		c.Remove(id)
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	old := c.shards[id]
+	stats := make(map[uint64]*CatchUpStat)
+	for _, progress := range res.GetReplicaProgresses() {
+		if progress.Replica.ID == leader.ID {
+			continue
+		}
+		var behind uint64
+		if leaderProgress.MatchIndex > progress.MatchIndex {
+			behind = leaderProgress.MatchIndex - progress.MatchIndex
+		}
+		if behind == 0 && progress.SnapshotPhase == metapb.SnapshotPhase_NoSnapshot {
+			continue
+		}
+
+		stat, ok := old[progress.Replica.ID]
+		if !ok {
+			stat = newCatchUpStat(id, progress.Replica)
+		}
+		stat.update(progress, behind, now)
+		stats[progress.Replica.ID] = stat
+	}
+	if len(stats) == 0 {
+		delete(c.shards, id)
+		return
+	}
+	c.shards[id] = stats
+}
+
+// Get returns the catch-up statistics of the replica, false if the replica is
+// caught up or not reported.
+func (c *CatchUpCache) Get(shardID, replicaID uint64) (CatchUpStat, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	if stat, ok := c.shards[shardID][replicaID]; ok {
+		return *stat, true
+	}
+	return CatchUpStat{}, false
+}
+
+// Remove removes the catch-up statistics of the shard
+func (c *CatchUpCache) Remove(shardID uint64) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.shards, shardID)
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+func newTestCatchUpShard(leaderIndex uint64, progresses ...metapb.ReplicaProgress) *core.CachedShard {
+	leader := metapb.Replica{ID: 10, StoreID: 1}
+	meta := metapb.Shard{ID: 1, Replicas: []metapb.Replica{leader}}
+	all := []metapb.ReplicaProgress{{Replica: leader, MatchIndex: leaderIndex}}
+	for _, p := range progresses {
+		meta.Replicas = append(meta.Replicas, p.Replica)
+		all = append(all, p)
+	}
+	return core.NewCachedShard(meta, &leader, core.WithReplicaProgresses(all))
+}
+
+func TestCatchUpCacheSendingSnapshot(t *testing.T) {
+	c := NewCatchUpCache()
+	replica := metapb.Replica{ID: 20, StoreID: 2, Role: metapb.ReplicaRole_Learner}
+	now := time.Now()
+
+	c.observe(newTestCatchUpShard(100, metapb.ReplicaProgress{
+		Replica:            replica,
+		SnapshotPhase:      metapb.SnapshotPhase_SendingSnapshot,
+		SnapshotSentBytes:  0,
+		SnapshotTotalBytes: 3000,
+	}), now)
+	stat, ok := c.Get(1, 20)
+	require.True(t, ok)
+	assert.Equal(t, uint64(100), stat.EntriesBehind)
+	_, ok = stat.ETA()
+	assert.False(t, ok, "the rate is unknown")
+
+	c.observe(newTestCatchUpShard(100, metapb.ReplicaProgress{
+		Replica:            replica,
+		SnapshotPhase:      metapb.SnapshotPhase_SendingSnapshot,
+		SnapshotSentBytes:  1000,
+		SnapshotTotalBytes: 3000,
+	}), now.Add(time.Second*10))
+	stat, ok = c.Get(1, 20)
+	require.True(t, ok)
+	assert.Equal(t, float64(100), stat.ByteRate)
+	eta, ok := stat.ETA()
+	assert.True(t, ok)
+	assert.Equal(t, time.Second*20, eta)
+
+	c.observe(newTestCatchUpShard(100, metapb.ReplicaProgress{
+		Replica:       replica,
+		SnapshotPhase: metapb.SnapshotPhase_ApplyingSnapshot,
+	}), now.Add(time.Second*20))
+	stat, ok = c.Get(1, 20)
+	require.True(t, ok)
+	_, ok = stat.ETA()
+	assert.False(t, ok)
+}
+
+func TestCatchUpCacheEntriesBehind(t *testing.T) {
+	c := NewCatchUpCache()
+	replica := metapb.Replica{ID: 20, StoreID: 2}
+	now := time.Now()
+
+	c.observe(newTestCatchUpShard(100, metapb.ReplicaProgress{Replica: replica, MatchIndex: 50}), now)
+	c.observe(newTestCatchUpShard(110, metapb.ReplicaProgress{Replica: replica, MatchIndex: 90}), now.Add(time.Second*10))
+	stat, ok := c.Get(1, 20)
+	require.True(t, ok)
+	assert.Equal(t, uint64(20), stat.EntriesBehind)
+	assert.Equal(t, float64(3), stat.EntryRate)
+	eta, ok := stat.ETA()
+	assert.True(t, ok)
+	assert.True(t, eta > time.Second*6 && eta < time.Second*7)
+
+	// the caught up replicas are removed
+	c.observe(newTestCatchUpShard(110, metapb.ReplicaProgress{Replica: replica, MatchIndex: 110}), now.Add(time.Second*20))
+	_, ok = c.Get(1, 20)
+	assert.False(t, ok)
+
+	c.observe(newTestCatchUpShard(100, metapb.ReplicaProgress{Replica: replica, MatchIndex: 50}), now)
+	c.Remove(1)
+	_, ok = c.Get(1, 20)
+	assert.False(t, ok)
+}
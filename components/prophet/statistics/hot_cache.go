@@ -0,0 +1,267 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/components/prophet/util/movingaverage"
+)
+
+const (
+	// HotCacheMinByteRate and HotCacheMinKeyRate the min flow rates per second of
+	// the replicas tracked by the hot cache, the schedulers use their own higher
+	// thresholds to select the hot replicas.
+	HotCacheMinByteRate = 1024
+	HotCacheMinKeyRate  = 8
+
+	// hotPeerAntiCount the number of the reports below the thresholds before the
+	// replica is removed from the hot cache
+	hotPeerAntiCount = 2
+	// hotPeerMfSize the size of the median filter of the hot replica rates
+	hotPeerMfSize = 3
+)
+
+// FlowKind the kind of the flows of the shards
+type FlowKind int
+
+// The kinds of the flows
+const (
+	WriteFlow FlowKind = iota
+	ReadFlow
+)
+
+func (k FlowKind) String() string {
+	switch k {
+	case WriteFlow:
+		return "write"
+	case ReadFlow:
+		return "read"
+	}
+	return "unknown"
+}
+
+// HotPeerStat the rolling flow statistics of a replica of the hot shard. The
+// write flow is on all the replicas of the shard, and the read flow is on the
+// leader only.
+type HotPeerStat struct {
+	ShardID uint64
+	StoreID uint64
+	Kind    FlowKind
+	// IsLeader the replica is the leader of the shard
+	IsLeader bool
+	// ByteRate and KeyRate the rolling flow rates per second
+	ByteRate float64
+	KeyRate  float64
+	// HotDegree the number of the continuous reports above the thresholds, it's
+	// decreased by the reports below the thresholds.
+	HotDegree int
+	// AntiCount the number of the remaining reports below the thresholds before
+	// the replica is removed
+	AntiCount      int
+	LastUpdateTime time.Time
+
+	byteRate *movingaverage.TimeMedian
+	keyRate  *movingaverage.TimeMedian
+}
+
+func newHotPeerStat(shardID, storeID uint64, kind FlowKind) *HotPeerStat {
+	interval := ShardHeartBeatReportInterval * time.Second
+	return &HotPeerStat{
+		ShardID:  shardID,
+		StoreID:  storeID,
+		Kind:     kind,
+		byteRate: movingaverage.NewTimeMedian(DefaultAotSize, hotPeerMfSize, interval),
+		keyRate:  movingaverage.NewTimeMedian(DefaultAotSize, hotPeerMfSize, interval),
+	}
+}
+
+// IsHot returns true if the rates are above the thresholds
+func (s *HotPeerStat) IsHot(minByteRate, minKeyRate float64) bool {
+	return s.ByteRate >= minByteRate || s.KeyRate >= minKeyRate
+}
+
+func (s *HotPeerStat) add(bytes, keys float64, interval time.Duration, now time.Time) {
+	s.byteRate.Add(bytes, interval)
+	s.keyRate.Add(keys, interval)
+	s.ByteRate = s.byteRate.Get()
+	s.KeyRate = s.keyRate.Get()
+	// the moving average is not filled by the first reports, use the rates of the
+	// reports until it's filled
+	if s.ByteRate == 0 && s.KeyRate == 0 {
+		s.ByteRate = s.byteRate.GetInstantaneous()
+		s.KeyRate = s.keyRate.GetInstantaneous()
+	}
+	s.LastUpdateTime = now
+	if s.IsHot(HotCacheMinByteRate, HotCacheMinKeyRate) {
+		s.HotDegree++
+		s.AntiCount = hotPeerAntiCount
+		return
+	}
+	s.HotDegree--
+	s.AntiCount--
+}
+
+// shardFlow the accumulated flows reported by the leader of the shard
+type shardFlow struct {
+	leader       uint64
+	writtenBytes uint64
+	writtenKeys  uint64
+	readBytes    uint64
+	readKeys     uint64
+}
+
+// HotCache maintains the rolling flow statistics of the hot replicas per store
+// by the shard heartbeats. The flows in the shard heartbeats are accumulated
+// since the replica becomes the leader, so the rates are computed by the deltas
+// of the continuous heartbeats of the same leader.
+type HotCache struct {
+	sync.RWMutex
+	flows map[uint64]shardFlow
+	// peers kind -> shard -> store -> stat
+	peers map[FlowKind]map[uint64]map[uint64]*HotPeerStat
+}
+
+// NewHotCache returns the hot cache
+func NewHotCache() *HotCache {
+	return &HotCache{
+		flows: make(map[uint64]shardFlow),
+		peers: map[FlowKind]map[uint64]map[uint64]*HotPeerStat{
+			WriteFlow: make(map[uint64]map[uint64]*HotPeerStat),
+			ReadFlow:  make(map[uint64]map[uint64]*HotPeerStat),
+		},
+	}
+}
+
+// Observe updates the hot replicas of the shard by its heartbeat
+func (c *HotCache) Observe(shard *core.CachedShard) {
+	leader := shard.GetLeader()
+	if leader == nil || leader.ID == 0 {
+		return
+	}
+	id := shard.Meta.GetID()
+	current := shardFlow{
+		leader:       leader.ID,
+		writtenBytes: shard.GetBytesWritten(),
+		writtenKeys:  shard.GetKeysWritten(),
+		readBytes:    shard.GetBytesRead(),
+		readKeys:     shard.GetKeysRead(),
+	}
+	interval := shard.GetInterval()
+	seconds := interval.GetEnd() - interval.GetStart()
+	if interval.GetEnd() < interval.GetStart() {
+		seconds = 0
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	last, ok := c.flows[id]
+	c.flows[id] = current
+	// the leader is changed or the flows are reset, the deltas are unknown
+	if !ok || seconds == 0 || last.leader != current.leader ||
+		current.writtenBytes < last.writtenBytes || current.writtenKeys < last.writtenKeys ||
+		current.readBytes < last.readBytes || current.readKeys < last.readKeys {
+		return
+	}
+
+	now := time.Now()
+	d := time.Duration(seconds) * time.Second
+	stores := make([]uint64, 0, len(shard.Meta.GetReplicas()))
+	for _, p := range shard.Meta.GetReplicas() {
+		stores = append(stores, p.StoreID)
+	}
+	c.update(WriteFlow, shard, stores, float64(current.writtenBytes-last.writtenBytes),
+		float64(current.writtenKeys-last.writtenKeys), d, now)
+	c.update(ReadFlow, shard, []uint64{leader.StoreID}, float64(current.readBytes-last.readBytes),
+		float64(current.readKeys-last.readKeys), d, now)
+}
+
+func (c *HotCache) update(kind FlowKind, shard *core.CachedShard, stores []uint64,
+	bytes, keys float64, interval time.Duration, now time.Time) {
+	id := shard.Meta.GetID()
+	old := c.peers[kind][id]
+	peers := make(map[uint64]*HotPeerStat, len(stores))
+	// the replicas moved to the new stores inherit the stats of the removed ones
+	var removed []*HotPeerStat
+	for storeID, stat := range old {
+		if !containsStore(stores, storeID) {
+			removed = append(removed, stat)
+		}
+	}
+	for _, storeID := range stores {
+		stat, ok := old[storeID]
+		if !ok {
+			if len(removed) > 0 {
+				stat, removed = removed[0], removed[1:]
+				stat.StoreID = storeID
+			} else if bytes/interval.Seconds() >= HotCacheMinByteRate ||
+				keys/interval.Seconds() >= HotCacheMinKeyRate {
+				stat = newHotPeerStat(id, storeID, kind)
+			} else {
+				continue
+			}
+		}
+		stat.IsLeader = storeID == shard.GetLeader().GetStoreID()
+		stat.add(bytes, keys, interval, now)
+		if stat.AntiCount > 0 {
+			peers[storeID] = stat
+		}
+	}
+	if len(peers) == 0 {
+		delete(c.peers[kind], id)
+		return
+	}
+	c.peers[kind][id] = peers
+}
+
+// Remove removes the stats of the shard, e.g. the shard is destroyed or merged
+func (c *HotCache) Remove(shardID uint64) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.flows, shardID)
+	for _, peers := range c.peers {
+		delete(peers, shardID)
+	}
+}
+
+// GetHotPeerStats returns the copies of the stats of the hot replicas of the
+// kind grouped by the stores, the replicas with the hot degree less than the
+// minHotDegree are excluded.
+func (c *HotCache) GetHotPeerStats(kind FlowKind, minHotDegree int) map[uint64][]HotPeerStat {
+	c.RLock()
+	defer c.RUnlock()
+	stats := make(map[uint64][]HotPeerStat)
+	for _, peers := range c.peers[kind] {
+		for storeID, stat := range peers {
+			if stat.HotDegree < minHotDegree {
+				continue
+			}
+			v := *stat
+			v.byteRate, v.keyRate = nil, nil
+			stats[storeID] = append(stats[storeID], v)
+		}
+	}
+	return stats
+}
+
+func containsStore(stores []uint64, id uint64) bool {
+	for _, v := range stores {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
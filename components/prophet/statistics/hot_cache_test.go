@@ -0,0 +1,110 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+func newTestHotShard(leader uint64, stores ...uint64) *core.CachedShard {
+	meta := metapb.Shard{ID: 1}
+	var leaderReplica *metapb.Replica
+	for _, id := range stores {
+		meta.Replicas = append(meta.Replicas, metapb.Replica{ID: id * 10, StoreID: id})
+		if id == leader {
+			leaderReplica = &metapb.Replica{ID: id * 10, StoreID: id}
+		}
+	}
+	return core.NewCachedShard(meta, leaderReplica)
+}
+
+// observeFlow observes the accumulated flows of the shard reported by the leader
+func observeFlow(c *HotCache, shard *core.CachedShard, written, read uint64) *core.CachedShard {
+	shard = shard.Clone(
+		core.SetWrittenBytes(written),
+		core.SetWrittenKeys(written/1024),
+		core.SetReadBytes(read),
+		core.SetReadKeys(read/1024),
+		core.SetReportInterval(ShardHeartBeatReportInterval))
+	c.Observe(shard)
+	return shard
+}
+
+func TestHotCacheObserve(t *testing.T) {
+	const rate = 100 * 1024
+	c := NewHotCache()
+	shard := newTestHotShard(1, 1, 2, 3)
+
+	// the first heartbeat is the base of the deltas
+	observeFlow(c, shard, 1<<30, 0)
+	assert.Empty(t, c.GetHotPeerStats(WriteFlow, 0))
+
+	written := uint64(1 << 30)
+	for i := 1; i <= 3; i++ {
+		written += rate * ShardHeartBeatReportInterval
+		observeFlow(c, shard, written, 0)
+	}
+	stats := c.GetHotPeerStats(WriteFlow, 3)
+	require.Equal(t, 3, len(stats))
+	for storeID, peers := range stats {
+		require.Equal(t, 1, len(peers))
+		assert.Equal(t, storeID, peers[0].StoreID)
+		assert.Equal(t, storeID == 1, peers[0].IsLeader)
+		assert.Equal(t, 3, peers[0].HotDegree)
+		assert.InDelta(t, rate, peers[0].ByteRate, 1)
+	}
+	assert.Empty(t, c.GetHotPeerStats(WriteFlow, 4))
+	assert.Empty(t, c.GetHotPeerStats(ReadFlow, 0))
+
+	// the replica moved to the new store inherits the stats
+	shard = newTestHotShard(1, 1, 2, 4)
+	written += rate * ShardHeartBeatReportInterval
+	observeFlow(c, shard, written, 0)
+	stats = c.GetHotPeerStats(WriteFlow, 4)
+	require.Equal(t, 3, len(stats))
+	assert.Equal(t, uint64(4), stats[4][0].StoreID)
+
+	// the leader changed, the deltas are unknown
+	shard = newTestHotShard(2, 1, 2, 4)
+	observeFlow(c, shard, 0, 0)
+	assert.Equal(t, 3, len(c.GetHotPeerStats(WriteFlow, 4)))
+
+	// the rates are rolling, the cold replicas are removed after the rolling
+	// rates are below the thresholds by the anti count
+	observeFlow(c, shard, 0, 0)
+	assert.Equal(t, 3, len(c.GetHotPeerStats(WriteFlow, 0)))
+	for i := 0; i < 10 && len(c.GetHotPeerStats(WriteFlow, 0)) > 0; i++ {
+		observeFlow(c, shard, 0, 0)
+	}
+	assert.Empty(t, c.GetHotPeerStats(WriteFlow, 0))
+
+	// the read flow is on the leader only
+	read := uint64(0)
+	for i := 0; i < 2; i++ {
+		read += rate * ShardHeartBeatReportInterval
+		observeFlow(c, shard, 0, read)
+	}
+	stats = c.GetHotPeerStats(ReadFlow, 0)
+	require.Equal(t, 1, len(stats))
+	assert.True(t, stats[2][0].IsLeader)
+
+	c.Remove(shard.Meta.GetID())
+	assert.Empty(t, c.GetHotPeerStats(ReadFlow, 0))
+}
@@ -21,6 +21,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/fagongzi/util/format"
 	"github.com/fagongzi/util/protoc"
@@ -29,6 +30,14 @@ import (
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 )
 
+const (
+	// maxTxnOps the default max number of operations of an etcd txn
+	maxTxnOps = 128
+	// MaxCompactDestroyedShards the max number of destroyed shards compacted in
+	// one txn, the txn puts the snapshot and removes 2 records of each shard.
+	MaxCompactDestroyedShards = (maxTxnOps - 1) / 2
+)
+
 // JobStorage job  storage
 type JobStorage interface {
 	// PutJob puts the job metadata to the storage
@@ -44,6 +53,13 @@ type JobStorage interface {
 	GetJobData(metapb.JobType) ([]byte, error)
 	// RemoveJobData removes job data
 	RemoveJobData(metapb.JobType) error
+
+	// PutJobCheckpoint saves the checkpoint of the job, ErrStaleJobCheckpoint is
+	// returned if the seq is not greater than the seq of the saved checkpoint
+	PutJobCheckpoint(metapb.JobCheckpoint) error
+	// GetJobCheckpoint returns the latest checkpoint of the job, the seq is 0
+	// if no checkpoint saved
+	GetJobCheckpoint(metapb.JobType) (metapb.JobCheckpoint, error)
 }
 
 // RuleStorage rule storage
@@ -100,6 +116,43 @@ type ShardStorage interface {
 
 	PutScheduleGroupRule(metapb.ScheduleGroupRule) error
 	LoadScheduleGroupRules(limit int64, do func(metapb.ScheduleGroupRule)) error
+
+	// PutKeyspace puts the keyspace to the storage
+	PutKeyspace(metapb.Keyspace) error
+	// RemoveKeyspace removes the keyspace from the storage
+	RemoveKeyspace(id uint64) error
+	// LoadKeyspaces load all keyspaces
+	LoadKeyspaces(limit int64, do func(metapb.Keyspace)) error
+
+	// PutShardReplayLog puts the marshaled replay log of the shard to the storage
+	PutShardReplayLog(id uint64, data []byte) error
+	// RemoveShardReplayLog removes the replay log of the shard from the storage
+	RemoveShardReplayLog(id uint64) error
+	// LoadShardReplayLogs load all the marshaled shard replay logs
+	LoadShardReplayLogs(limit int64, do func(data []byte)) error
+
+	// PutTrashedShardGroup puts the marshaled trash record of the dropped shard
+	// group to the storage
+	PutTrashedShardGroup(group uint64, data []byte) error
+	// RemoveTrashedShardGroup removes the trash record of the shard group from
+	// the storage
+	RemoveTrashedShardGroup(group uint64) error
+	// LoadTrashedShardGroups load all the marshaled trash records
+	LoadTrashedShardGroups(limit int64, do func(data []byte)) error
+
+	// PutShardLineage puts the marshaled lineage events whose descendant is the
+	// shard to the storage
+	PutShardLineage(shardID uint64, data []byte) error
+	// LoadShardLineages load all the marshaled shard lineages
+	LoadShardLineages(limit int64, do func(data []byte)) error
+
+	// CompactDestroyedShards atomically saves the snapshot of all compacted
+	// destroyed shard IDs, and removes the records of the shards. At most
+	// MaxCompactDestroyedShards shards can be compacted in one call.
+	CompactDestroyedShards(snapshot []byte, ids ...uint64) error
+	// GetDestroyedShardsSnapshot returns the snapshot of the compacted destroyed
+	// shard IDs
+	GetDestroyedShardsSnapshot() ([]byte, error)
 }
 
 // ConfigStorage  config storage
@@ -167,7 +220,12 @@ type storage struct {
 	resourcePath             string
 	resourceExtraPath        string
 	resourceLeaseEpochPath   string
+	destroyedSnapshotPath    string
 	scheduleGroupRulePath    string
+	keyspacePath             string
+	shardReplayLogPath       string
+	trashedShardGroupPath    string
+	shardLineagePath         string
 	containerPath            string
 	rulePath                 string
 	ruleGroupPath            string
@@ -176,7 +234,11 @@ type storage struct {
 	schedulePath             string
 	jobPath                  string
 	jobDataPath              string
+	jobCheckpointPath        string
 	customDataPath           string
+
+	// checkpointMu serializes the checks of the seq of the job checkpoints
+	checkpointMu sync.Mutex
 }
 
 // NewTestStorage create test storage
@@ -194,7 +256,12 @@ func NewStorage(rootPath string, kv KV, idGen id.Generator) Storage {
 		resourcePath:             fmt.Sprintf("%s/resources", rootPath),
 		resourceExtraPath:        fmt.Sprintf("%s/resources-extra", rootPath),
 		resourceLeaseEpochPath:   fmt.Sprintf("%s/resources-lease-epoch", rootPath),
+		destroyedSnapshotPath:    fmt.Sprintf("%s/destroyed-resources", rootPath),
 		scheduleGroupRulePath:    fmt.Sprintf("%s/schdule-group-rules", rootPath),
+		keyspacePath:             fmt.Sprintf("%s/keyspaces", rootPath),
+		shardReplayLogPath:       fmt.Sprintf("%s/shard-replay-logs", rootPath),
+		trashedShardGroupPath:    fmt.Sprintf("%s/trashed-shard-groups", rootPath),
+		shardLineagePath:         fmt.Sprintf("%s/shard-lineages", rootPath),
 		containerPath:            fmt.Sprintf("%s/containers", rootPath),
 		rulePath:                 fmt.Sprintf("%s/rules", rootPath),
 		ruleGroupPath:            fmt.Sprintf("%s/rule-groups", rootPath),
@@ -203,6 +270,7 @@ func NewStorage(rootPath string, kv KV, idGen id.Generator) Storage {
 		schedulePath:             fmt.Sprintf("%s/schedule", rootPath),
 		jobPath:                  fmt.Sprintf("%s/jobs", rootPath),
 		jobDataPath:              fmt.Sprintf("%s/job-data", rootPath),
+		jobCheckpointPath:        fmt.Sprintf("%s/job-checkpoints", rootPath),
 		customDataPath:           fmt.Sprintf("%s/custom", rootPath),
 	}
 }
@@ -341,6 +409,64 @@ func (s *storage) LoadScheduleGroupRules(limit int64, do func(metapb.ScheduleGro
 	})
 }
 
+func (s *storage) PutKeyspace(keyspace metapb.Keyspace) error {
+	return s.kv.Save(s.getKey(keyspace.ID, s.keyspacePath), string(protoc.MustMarshal(&keyspace)))
+}
+
+func (s *storage) RemoveKeyspace(id uint64) error {
+	return s.kv.Remove(s.getKey(id, s.keyspacePath))
+}
+
+func (s *storage) LoadKeyspaces(limit int64, do func(metapb.Keyspace)) error {
+	return s.LoadRangeByPrefix(limit, s.keyspacePath+"/", func(k, v string) error {
+		var keyspace metapb.Keyspace
+		protoc.MustUnmarshal(&keyspace, []byte(v))
+		do(keyspace)
+		return nil
+	})
+}
+
+func (s *storage) PutShardReplayLog(id uint64, data []byte) error {
+	return s.kv.Save(s.getKey(id, s.shardReplayLogPath), string(data))
+}
+
+func (s *storage) RemoveShardReplayLog(id uint64) error {
+	return s.kv.Remove(s.getKey(id, s.shardReplayLogPath))
+}
+
+func (s *storage) LoadShardReplayLogs(limit int64, do func(data []byte)) error {
+	return s.LoadRangeByPrefix(limit, s.shardReplayLogPath+"/", func(k, v string) error {
+		do([]byte(v))
+		return nil
+	})
+}
+
+func (s *storage) PutTrashedShardGroup(group uint64, data []byte) error {
+	return s.kv.Save(s.getKey(group, s.trashedShardGroupPath), string(data))
+}
+
+func (s *storage) RemoveTrashedShardGroup(group uint64) error {
+	return s.kv.Remove(s.getKey(group, s.trashedShardGroupPath))
+}
+
+func (s *storage) LoadTrashedShardGroups(limit int64, do func(data []byte)) error {
+	return s.LoadRangeByPrefix(limit, s.trashedShardGroupPath+"/", func(k, v string) error {
+		do([]byte(v))
+		return nil
+	})
+}
+
+func (s *storage) PutShardLineage(shardID uint64, data []byte) error {
+	return s.kv.Save(s.getKey(shardID, s.shardLineagePath), string(data))
+}
+
+func (s *storage) LoadShardLineages(limit int64, do func(data []byte)) error {
+	return s.LoadRangeByPrefix(limit, s.shardLineagePath+"/", func(k, v string) error {
+		do([]byte(v))
+		return nil
+	})
+}
+
 func (s *storage) PutShardAndExtra(res metapb.Shard, extra []byte) error {
 	data, err := res.Marshal()
 	if err != nil {
@@ -385,6 +511,31 @@ func (s *storage) RemoveShard(meta metapb.Shard) error {
 	return s.kv.Remove(s.getKey(meta.GetID(), s.resourcePath))
 }
 
+func (s *storage) CompactDestroyedShards(snapshot []byte, ids ...uint64) error {
+	if len(ids) > MaxCompactDestroyedShards {
+		return fmt.Errorf("too many destroyed shards to compact, %d > %d",
+			len(ids), MaxCompactDestroyedShards)
+	}
+
+	batch := &Batch{}
+	batch.SaveKeys = append(batch.SaveKeys, s.destroyedSnapshotPath)
+	batch.SaveValues = append(batch.SaveValues, string(snapshot))
+	for _, id := range ids {
+		batch.RemoveKeys = append(batch.RemoveKeys,
+			s.getKey(id, s.resourcePath),
+			s.getKey(id, s.resourceExtraPath))
+	}
+	return s.kv.Batch(batch)
+}
+
+func (s *storage) GetDestroyedShardsSnapshot() ([]byte, error) {
+	data, err := s.kv.Load(s.destroyedSnapshotPath)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(data), nil
+}
+
 func (s *storage) GetShard(id uint64) (*metapb.Shard, error) {
 	key := s.getKey(id, s.resourcePath)
 	data, err := s.kv.Load(key)
@@ -493,6 +644,7 @@ func (s *storage) RemoveJob(jobType metapb.JobType) error {
 	b := &Batch{}
 	b.RemoveKeys = append(b.RemoveKeys, s.jobKey(jobType))
 	b.RemoveKeys = append(b.RemoveKeys, s.jobDataKey(jobType))
+	b.RemoveKeys = append(b.RemoveKeys, s.jobCheckpointKey(jobType))
 	return s.kv.Batch(b)
 }
 
@@ -522,6 +674,34 @@ func (s *storage) RemoveJobData(jobType metapb.JobType) error {
 	return s.kv.Remove(s.jobDataKey(jobType))
 }
 
+func (s *storage) PutJobCheckpoint(checkpoint metapb.JobCheckpoint) error {
+	s.checkpointMu.Lock()
+	defer s.checkpointMu.Unlock()
+
+	current, err := s.GetJobCheckpoint(checkpoint.Type)
+	if err != nil {
+		return err
+	}
+	if checkpoint.Seq <= current.Seq {
+		return util.WrappedError(util.ErrStaleJobCheckpoint,
+			fmt.Sprintf("job type = %d, seq %d <= %d", checkpoint.Type, checkpoint.Seq, current.Seq))
+	}
+	return s.kv.Save(s.jobCheckpointKey(checkpoint.Type),
+		string(protoc.MustMarshal(&checkpoint)))
+}
+
+func (s *storage) GetJobCheckpoint(jobType metapb.JobType) (metapb.JobCheckpoint, error) {
+	checkpoint := metapb.JobCheckpoint{Type: jobType}
+	v, err := s.kv.Load(s.jobCheckpointKey(jobType))
+	if err != nil {
+		return checkpoint, err
+	}
+	if len(v) > 0 {
+		protoc.MustUnmarshal(&checkpoint, []byte(v))
+	}
+	return checkpoint, nil
+}
+
 func (s *storage) PutCustomData(key []byte, data []byte) error {
 	return s.kv.Save(path.Join(s.customDataPath, string(key)), string(data))
 }
@@ -620,6 +800,10 @@ func (s *storage) jobDataKey(jobType metapb.JobType) string {
 	return path.Join(s.jobDataPath, string(format.Uint64ToString(uint64(jobType))))
 }
 
+func (s *storage) jobCheckpointKey(jobType metapb.JobType) string {
+	return path.Join(s.jobCheckpointPath, string(format.Uint64ToString(uint64(jobType))))
+}
+
 // AllocID implement id.Generator interface
 func (s *storage) AllocID() (uint64, error) {
 	return s.idGen.AllocID()
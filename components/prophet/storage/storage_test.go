@@ -15,6 +15,8 @@
 package storage
 
 import (
+	"errors"
+	"strconv"
 	"testing"
 	"time"
 
@@ -22,6 +24,7 @@ import (
 	"github.com/matrixorigin/matrixcube/components/prophet/election"
 	"github.com/matrixorigin/matrixcube/components/prophet/id"
 	"github.com/matrixorigin/matrixcube/components/prophet/mock"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/stretchr/testify/assert"
 )
@@ -255,6 +258,36 @@ func TestPutAndDeleteAndLoadJobs(t *testing.T) {
 	assert.Equal(t, 0, c)
 }
 
+func TestJobCheckpoint(t *testing.T) {
+	storage := NewTestStorage()
+	jobType := metapb.JobType(1)
+
+	checkpoint, err := storage.GetJobCheckpoint(jobType)
+	assert.NoError(t, err)
+	assert.Equal(t, metapb.JobCheckpoint{Type: jobType}, checkpoint)
+
+	assert.NoError(t, storage.PutJobCheckpoint(metapb.JobCheckpoint{Type: jobType, Seq: 1, Data: []byte("c1")}))
+	assert.NoError(t, storage.PutJobCheckpoint(metapb.JobCheckpoint{Type: jobType, Seq: 3, Data: []byte("c3")}))
+	err = storage.PutJobCheckpoint(metapb.JobCheckpoint{Type: jobType, Seq: 2, Data: []byte("c2")})
+	assert.True(t, errors.Is(err, util.ErrStaleJobCheckpoint))
+	err = storage.PutJobCheckpoint(metapb.JobCheckpoint{Type: jobType, Seq: 3, Data: []byte("c3")})
+	assert.True(t, errors.Is(err, util.ErrStaleJobCheckpoint))
+	assert.NoError(t, storage.PutJobCheckpoint(metapb.JobCheckpoint{Type: metapb.JobType(2), Seq: 1}))
+
+	checkpoint, err = storage.GetJobCheckpoint(jobType)
+	assert.NoError(t, err)
+	assert.Equal(t, metapb.JobCheckpoint{Type: jobType, Seq: 3, Data: []byte("c3")}, checkpoint)
+
+	// removed with the job
+	assert.NoError(t, storage.RemoveJob(jobType))
+	checkpoint, err = storage.GetJobCheckpoint(jobType)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), checkpoint.Seq)
+	checkpoint, err = storage.GetJobCheckpoint(metapb.JobType(2))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), checkpoint.Seq)
+}
+
 func TestScheduleGroupRule(t *testing.T) {
 	stopC, port := mock.StartTestSingleEtcd(t)
 	defer close(stopC)
@@ -301,6 +334,62 @@ func TestScheduleGroupRule(t *testing.T) {
 	assert.Equal(t, ruleCache.RuleCount(), 10)
 }
 
+func TestKeyspace(t *testing.T) {
+	storage := NewTestStorage()
+	for id := uint64(1); id <= 3; id++ {
+		assert.NoError(t, storage.PutKeyspace(metapb.Keyspace{ID: id, Name: "k" + strconv.FormatUint(id, 10)}))
+	}
+	assert.NoError(t, storage.RemoveKeyspace(2))
+
+	var values []metapb.Keyspace
+	assert.NoError(t, storage.LoadKeyspaces(10, func(keyspace metapb.Keyspace) {
+		values = append(values, keyspace)
+	}))
+	assert.Equal(t, []metapb.Keyspace{{ID: 1, Name: "k1"}, {ID: 3, Name: "k3"}}, values)
+}
+
+func TestShardReplayLog(t *testing.T) {
+	storage := NewTestStorage()
+	for id := uint64(1); id <= 3; id++ {
+		assert.NoError(t, storage.PutShardReplayLog(id, []byte{byte(id)}))
+	}
+	assert.NoError(t, storage.RemoveShardReplayLog(2))
+
+	var values [][]byte
+	assert.NoError(t, storage.LoadShardReplayLogs(10, func(data []byte) {
+		values = append(values, data)
+	}))
+	assert.Equal(t, [][]byte{{1}, {3}}, values)
+}
+
+func TestTrashedShardGroup(t *testing.T) {
+	storage := NewTestStorage()
+	for group := uint64(1); group <= 3; group++ {
+		assert.NoError(t, storage.PutTrashedShardGroup(group, []byte{byte(group)}))
+	}
+	assert.NoError(t, storage.RemoveTrashedShardGroup(2))
+
+	var values [][]byte
+	assert.NoError(t, storage.LoadTrashedShardGroups(10, func(data []byte) {
+		values = append(values, data)
+	}))
+	assert.Equal(t, [][]byte{{1}, {3}}, values)
+}
+
+func TestShardLineage(t *testing.T) {
+	storage := NewTestStorage()
+	for id := uint64(1); id <= 3; id++ {
+		assert.NoError(t, storage.PutShardLineage(id, []byte{byte(id)}))
+	}
+	assert.NoError(t, storage.PutShardLineage(2, []byte{4}))
+
+	var values [][]byte
+	assert.NoError(t, storage.LoadShardLineages(10, func(data []byte) {
+		values = append(values, data)
+	}))
+	assert.Equal(t, [][]byte{{1}, {4}, {3}}, values)
+}
+
 func TestPutAndDeleteAndLoadCustomData(t *testing.T) {
 	stopC, port := mock.StartTestSingleEtcd(t)
 	defer close(stopC)
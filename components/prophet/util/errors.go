@@ -17,6 +17,8 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 )
 
 var (
@@ -31,6 +33,9 @@ var (
 	ErrStaleShard = errors.New("stale resource")
 	// ErrTombstoneStore t ombstone container
 	ErrTombstoneStore = errors.New("container is tombstone")
+	// ErrStaleStoreEpoch the store is started with a data directory of an older
+	// incarnation
+	ErrStaleStoreEpoch = errors.New("stale store epoch")
 
 	// ErrSchedulerExisted error with scheduler is existed
 	ErrSchedulerExisted = errors.New("scheduler is existed")
@@ -43,14 +48,137 @@ var (
 	ErrJobProcessorStopped  = errors.New("job processor stopped")
 	ErrJobInvalidCommand    = errors.New("invalid job command")
 	ErrJobNotFound          = errors.New("job not found")
+	// ErrStaleJobCheckpoint the checkpoint is older than the saved one, e.g. it's
+	// saved by the job processor started by the previous prophet leader
+	ErrStaleJobCheckpoint = errors.New("stale job checkpoint")
+
+	// ErrKeyspaceExisted the keyspace with the same name is existed
+	ErrKeyspaceExisted = errors.New("keyspace is existed")
+	// ErrKeyspaceNotFound the keyspace is not found
+	ErrKeyspaceNotFound = errors.New("keyspace is not found")
+	// ErrShardGroupTrashed the shard group is dropped and retained in the trash
+	ErrShardGroupTrashed = errors.New("shard group is trashed")
+	// ErrShardGroupNotTrashed the shard group is not in the trash
+	ErrShardGroupNotTrashed = errors.New("shard group is not trashed")
+)
+
+// codeErrors the errors with the codes carried in the prophet rpc responses
+var codeErrors = []struct {
+	code rpcpb.ErrorCode
+	err  error
+}{
+	{rpcpb.ErrorCodeNotLeader, ErrNotLeader},
+	{rpcpb.ErrorCodeNotBootstrapped, ErrNotBootstrapped},
+	{rpcpb.ErrorCodeInvalidRequest, ErrReq},
+	{rpcpb.ErrorCodeStaleShard, ErrStaleShard},
+	{rpcpb.ErrorCodeTombstoneStore, ErrTombstoneStore},
+	{rpcpb.ErrorCodeStaleStoreEpoch, ErrStaleStoreEpoch},
+	{rpcpb.ErrorCodeSchedulerExisted, ErrSchedulerExisted},
+	{rpcpb.ErrorCodeSchedulerNotFound, ErrSchedulerNotFound},
+	{rpcpb.ErrorCodeJobProcessorNotFound, ErrJobProcessorNotFound},
+	{rpcpb.ErrorCodeJobProcessorStopped, ErrJobProcessorStopped},
+	{rpcpb.ErrorCodeJobInvalidCommand, ErrJobInvalidCommand},
+	{rpcpb.ErrorCodeJobNotFound, ErrJobNotFound},
+	{rpcpb.ErrorCodeKeyspaceExisted, ErrKeyspaceExisted},
+	{rpcpb.ErrorCodeKeyspaceNotFound, ErrKeyspaceNotFound},
+	{rpcpb.ErrorCodeShardGroupTrashed, ErrShardGroupTrashed},
+	{rpcpb.ErrorCodeShardGroupNotTrashed, ErrShardGroupNotTrashed},
+}
+
+// ErrorCode returns the code of the error, the wrapped errors are matched by
+// errors.Is. ErrorCodeUnknown is returned if the error has no code.
+func ErrorCode(err error) rpcpb.ErrorCode {
+	if err == nil {
+		return rpcpb.ErrorCodeOK
+	}
+	for _, ce := range codeErrors {
+		if errors.Is(err, ce.err) {
+			return ce.code
+		}
+	}
+	return rpcpb.ErrorCodeUnknown
+}
+
+// CodeError returns the error of the code and the message received in the
+// prophet rpc response, nil if there is no error. The returned error matches the
+// error of the code by errors.Is, and keeps the message as is.
+func CodeError(code rpcpb.ErrorCode, msg string) error {
+	if code == rpcpb.ErrorCodeOK {
+		if msg == "" {
+			return nil
+		}
+		// sent by the prophet without the error codes
+		if msg == ErrNotLeader.Error() {
+			return ErrNotLeader
+		}
+		return errors.New(msg)
+	}
+	for _, ce := range codeErrors {
+		if ce.code == code {
+			if msg == ce.err.Error() {
+				return ce.err
+			}
+			return &codeError{cause: ce.err, msg: msg}
+		}
+	}
+	return errors.New(msg)
+}
+
+type codeError struct {
+	cause error
+	msg   string
+}
+
+func (e *codeError) Error() string {
+	return e.msg
+}
+
+func (e *codeError) Unwrap() error {
+	return e.cause
+}
+
+// ErrorClass the retry classification of the errors returned by prophet
+type ErrorClass int
+
+const (
+	// FatalError the request must not be retried
+	FatalError ErrorClass = iota
+	// RetryableError the request can be retried later, e.g. the prophet is
+	// bootstrapping
+	RetryableError
+	// NotLeaderError the request must be retried on the new prophet leader
+	NotLeaderError
 )
 
+// ClassifyError returns the retry classification of the error
+func ClassifyError(err error) ErrorClass {
+	switch {
+	case errors.Is(err, ErrNotLeader):
+		return NotLeaderError
+	case errors.Is(err, ErrNotBootstrapped),
+		errors.Is(err, ErrJobProcessorStopped):
+		return RetryableError
+	}
+	return FatalError
+}
+
 // IsNotLeaderError is not leader error
+//
+// Deprecated: use errors.Is with ErrNotLeader
 func IsNotLeaderError(err string) bool {
 	return err == ErrNotLeader.Error()
 }
 
+// IsStaleStoreEpochError is stale store epoch error
+//
+// Deprecated: use errors.Is with ErrStaleStoreEpoch
+func IsStaleStoreEpochError(err string) bool {
+	return strings.Contains(err, ErrStaleStoreEpoch.Error())
+}
+
 // IsJobProcessorNotFoundErr check error via its string content
+//
+// Deprecated: use errors.Is with ErrJobProcessorNotFound
 func IsJobProcessorNotFoundErr(err string) bool {
 	return strings.Contains(err, ErrJobProcessorNotFound.Error())
 }
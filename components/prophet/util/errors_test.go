@@ -1,10 +1,13 @@
 package util
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 )
 
 func TestIsJobProcessorNotFoundErr(t *testing.T) {
@@ -15,3 +18,36 @@ func TestIsJobProcessorNotFoundErr(t *testing.T) {
 		IsJobProcessorNotFoundErr(wrappedErr.Error()),
 	)
 }
+
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, rpcpb.ErrorCodeOK, ErrorCode(nil))
+	assert.Nil(t, CodeError(rpcpb.ErrorCodeOK, ""))
+	assert.Equal(t, rpcpb.ErrorCodeUnknown, ErrorCode(errors.New("unknown")))
+
+	for _, ce := range codeErrors {
+		assert.Equal(t, ce.code, ErrorCode(ce.err))
+		assert.Equal(t, ce.err, CodeError(ce.code, ce.err.Error()))
+
+		wrapped := WrappedError(ce.err, "detail")
+		assert.Equal(t, ce.code, ErrorCode(wrapped))
+		err := CodeError(ce.code, wrapped.Error())
+		assert.True(t, errors.Is(err, ce.err))
+		assert.Equal(t, wrapped.Error(), err.Error())
+	}
+
+	err := CodeError(rpcpb.ErrorCodeUnknown, "unknown")
+	assert.Equal(t, "unknown", err.Error())
+	assert.Equal(t, rpcpb.ErrorCodeUnknown, ErrorCode(err))
+	// sent by the prophet without the error codes
+	assert.Equal(t, ErrNotLeader, CodeError(rpcpb.ErrorCodeOK, ErrNotLeader.Error()))
+}
+
+func TestClassifyError(t *testing.T) {
+	assert.Equal(t, NotLeaderError, ClassifyError(ErrNotLeader))
+	assert.Equal(t, NotLeaderError,
+		ClassifyError(CodeError(rpcpb.ErrorCodeNotLeader, ErrNotLeader.Error())))
+	assert.Equal(t, RetryableError, ClassifyError(WrappedError(ErrNotBootstrapped, "detail")))
+	assert.Equal(t, RetryableError, ClassifyError(ErrJobProcessorStopped))
+	assert.Equal(t, FatalError, ClassifyError(ErrStaleStoreEpoch))
+	assert.Equal(t, FatalError, ClassifyError(errors.New("unknown")))
+}
@@ -14,18 +14,23 @@
 package config
 
 import (
+	"fmt"
+	"math"
 	"path"
 	"time"
 
 	"github.com/matrixorigin/matrixcube/aware"
+	"github.com/matrixorigin/matrixcube/command"
 	"github.com/matrixorigin/matrixcube/components/log"
 	pconfig "github.com/matrixorigin/matrixcube/components/prophet/config"
 	"github.com/matrixorigin/matrixcube/components/prophet/util/typeutil"
 	"github.com/matrixorigin/matrixcube/metric"
+	"github.com/matrixorigin/matrixcube/migration"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/matrixorigin/matrixcube/storage"
 	"github.com/matrixorigin/matrixcube/transport"
+	"github.com/matrixorigin/matrixcube/util/hlc"
 	"github.com/matrixorigin/matrixcube/vfs"
 	"go.uber.org/zap"
 )
@@ -34,27 +39,63 @@ var (
 	kb = 1024
 	mb = 1024 * kb
 
-	defaultSendRaftBatchSize        uint64 = 64
-	defaultMaxConcurrencySnapChunks uint64 = 8
-	defaultSnapChunkSize                   = 4 * mb
-	defaultRaftMaxWorkers           uint64 = 64
-	defaultRaftElectionTick                = 10
-	defaultRaftHeartbeatTick               = 2
-	defaultShardStateCheckDuration         = time.Second * 60
-	defaultCompactLogCheckDuration         = time.Second * 60
-	defaultMaxEntryBytes                   = 10 * mb
-	defaultMaxAllowTransferLag      uint64 = 2
-	defaultCompactThreshold         uint64 = 256
-	defaultRaftTickDuration                = time.Second
-	defaultMaxPeerDownTime                 = time.Minute * 30
-	defaultShardHeartbeatDuration          = time.Second * 2
-	defaultStoreHeartbeatDuration          = time.Second * 10
-	defaultMaxInflightMsgs                 = 8
-	defaultDataPath                        = "/tmp/matrixcube"
-	defaultSnapshotDirName                 = "snapshots"
-	defaultProphetDirName                  = "prophet"
-	defaultRaftAddr                        = "127.0.0.1:20001"
-	defaultRPCAddr                         = "127.0.0.1:20002"
+	defaultSendRaftBatchSize         uint64 = 64
+	defaultMaxConcurrencySnapChunks  uint64 = 8
+	defaultSnapChunkSize                    = 4 * mb
+	defaultSnapshotRetryBaseDelay           = time.Second
+	defaultSnapshotRetryMaxDelay            = time.Minute
+	defaultSnapshotMaxRetries        uint64 = 5
+	defaultSnapshotQuarantine               = time.Minute * 10
+	defaultSnapshotMaxCreates        uint64 = 8
+	defaultSnapshotGCInterval               = time.Minute * 10
+	defaultRaftMaxWorkers            uint64 = 64
+	defaultSplitCheckWorkers         uint64 = 2
+	defaultRaftElectionTick                 = 10
+	defaultRaftHeartbeatTick                = 2
+	defaultShardStateCheckDuration          = time.Second * 60
+	defaultCompactLogCheckDuration          = time.Second * 60
+	defaultShardStatsPersistDuration        = time.Second * 60
+	defaultShardStatsHalfLife               = time.Minute * 10
+	defaultLazyOpenTimeout                  = time.Minute * 5
+	defaultMaxEntryBytes                    = 10 * mb
+	defaultMaxMessageBatchSize              = 8 * mb
+	defaultReadyStageBudget                 = time.Millisecond * 100
+	defaultMaxAllowTransferLag       uint64 = 2
+	defaultCompactThreshold          uint64 = 256
+	defaultRaftTickDuration                 = time.Second
+	defaultMaxPeerDownTime                  = time.Minute * 30
+	defaultShardHeartbeatDuration           = time.Second * 2
+	defaultStoreHeartbeatDuration           = time.Second * 10
+	defaultMaxClockOffset                   = time.Millisecond * 500
+	defaultMaxInflightMsgs                  = 8
+	defaultSystemGroupMaxReplicas           = 5
+	defaultReadCacheMaxEntries              = 1024
+	defaultReadCacheMaxValueBytes           = 64 * kb
+	defaultRequestLogSampleRate             = 1000
+	defaultRequestLogKeyPrefixLen           = 8
+	defaultCompactionCheckDuration          = time.Minute
+	defaultCompactionColdDuration           = time.Minute * 30
+	defaultCompactionMinInterval            = time.Hour * 24
+	defaultCompactionPace                   = time.Second * 10
+	defaultCompactionMaxShards              = 16
+	defaultSizeRefreshDuration              = time.Minute
+	defaultWatchdogCheckDuration            = time.Second * 5
+	defaultWatchdogDeadline                 = time.Minute
+	defaultLocalityZoneLabel                = "zone"
+	defaultUnreachableStoreTimeout          = time.Second * 10
+	defaultDataPath                         = "/tmp/matrixcube"
+	defaultSnapshotDirName                  = "snapshots"
+	defaultProphetDirName                   = "prophet"
+	defaultRaftAddr                         = "127.0.0.1:20001"
+	defaultRPCAddr                          = "127.0.0.1:20002"
+)
+
+const (
+	// SystemGroup the shard group id of the built-in system shard group, which is
+	// used by matrixcube's own subsystems to store their metadata. The
+	// Storage.DataStorageFactory must return a DataStorage for it if the system
+	// group is enabled.
+	SystemGroup = uint64(math.MaxUint64)
 )
 
 // Config matrixcube config
@@ -68,6 +109,9 @@ type Config struct {
 	Version             string     `toml:"version"`
 	GitHash             string     `toml:"githash"`
 	Labels              [][]string `toml:"labels"`
+	// Pool the store pool, the store only holds the replicas of the shard groups
+	// bound to the pool by the Prophet.Replication.ShardGroupPools.
+	Pool string `toml:"pool"`
 	// Capacity max capacity can use
 	Capacity           typeutil.ByteSize `toml:"capacity"`
 	UseMemoryAsStorage bool              `toml:"use-memory-as-storage"`
@@ -77,6 +121,18 @@ type Config struct {
 	Raft RaftConfig `toml:"raft"`
 	// Worker worker config
 	Worker WorkerConfig `toml:"worker"`
+	// SystemGroup system shard group config
+	SystemGroup SystemGroupConfig `toml:"system-group"`
+	// Locality locality routing config of the client
+	Locality LocalityConfig `toml:"locality"`
+	// ReadCache the per-shard cache of the read responses
+	ReadCache ReadCacheConfig `toml:"read-cache"`
+	// RequestLog the sampled log of the requests received by the store
+	RequestLog RequestLogConfig `toml:"request-log"`
+
+	Compaction CompactionConfig `toml:"compaction"`
+	// Watchdog the watchdog of the critical loops of the store and the prophet
+	Watchdog WatchdogConfig `toml:"watchdog"`
 	// Prophet prophet config
 	Prophet pconfig.Config `toml:"prophet"`
 	// Storage config
@@ -89,6 +145,14 @@ type Config struct {
 	Metric metric.Cfg `toml:"metric"`
 	// FS used in MatrixCube
 	FS vfs.FS `json:"-" toml:"-"`
+	// ForceStartWithStaleIdentity starts the store even if the store identity in
+	// the data directory is fenced, e.g. the admin restores the store from a
+	// backup on purpose and the newer incarnation is gone.
+	ForceStartWithStaleIdentity bool `toml:"force-start-with-stale-identity"`
+	// EnableFaultInjection enables the fault injection admin API of the store,
+	// which injects failures on the live store for the resilience drills. Never
+	// enable it in the production clusters.
+	EnableFaultInjection bool `toml:"enable-fault-injection"`
 	// Test only used in testing
 	Test TestConfig
 }
@@ -138,6 +202,12 @@ func (c *Config) Adjust() {
 		panic(err)
 	}
 	(&c.Worker).adjust()
+	(&c.SystemGroup).adjust()
+	(&c.Locality).adjust(c.Labels)
+	(&c.ReadCache).adjust()
+	(&c.RequestLog).adjust()
+	(&c.Compaction).adjust()
+	(&c.Watchdog).adjust()
 
 	if c.Test.ShardStateAware != nil {
 		if c.Customize.CustomShardStateAwareFactory != nil {
@@ -160,6 +230,18 @@ func (c *Config) Adjust() {
 			c.Prophet.Replication.Groups = append(c.Prophet.Replication.Groups, g)
 		})
 	}
+	if c.SystemGroup.Enable {
+		c.Prophet.Replication.Groups = appendGroup(c.Prophet.Replication.Groups, SystemGroup)
+	}
+}
+
+func appendGroup(groups []uint64, group uint64) []uint64 {
+	for _, g := range groups {
+		if g == group {
+			return groups
+		}
+	}
+	return append(groups, group)
 }
 
 func (c *Config) validate() {
@@ -170,6 +252,20 @@ func (c *Config) validate() {
 	if c.Storage.ForeachDataStorageFunc == nil {
 		panic("missing Config.Storage.ForeachDataStorageFunc")
 	}
+
+	classes := make(map[string]struct{}, len(c.Storage.Classes))
+	for _, class := range c.Storage.Classes {
+		if err := pconfig.ValidateStorageClass(class.Name); err != nil {
+			panic(err)
+		}
+		if _, ok := classes[class.Name]; ok {
+			panic(fmt.Sprintf("duplicate storage class %s", class.Name))
+		}
+		classes[class.Name] = struct{}{}
+		if class.Path == "" {
+			panic(fmt.Sprintf("missing data path of storage class %s", class.Name))
+		}
+	}
 }
 
 // SnapshotDir returns snapshot dir
@@ -190,6 +286,35 @@ type ReplicationConfig struct {
 	ShardStateCheckDuration typeutil.Duration `toml:"shard-state-check-duration"`
 	CompactLogCheckDuration typeutil.Duration `toml:"compact-log-check-duration"`
 	AllowRemoveLeader       bool              `toml:"allow-remove-leader"`
+	// LazyOpenReplica only keeps the descriptors of the follower replicas in
+	// memory after restart, and opens the replica on its first access, e.g. the
+	// raft message from the leader, the request or the prophet's schedule. The
+	// replicas held the lease or without lease are always opened on restart. A
+	// lazy replica is also opened once prophet reports the local store holds the
+	// lease of the shard, or the shard stays leaderless for a while.
+	LazyOpenReplica bool `toml:"lazy-open-replica"`
+	// LazyOpenTimeout all lazy replicas which are not opened within the timeout
+	// since restart are opened.
+	LazyOpenTimeout typeutil.Duration `toml:"lazy-open-timeout"`
+	// MaxClockOffset the max tolerated offset of the store's clock to the prophet
+	// leader's clock, which is measured by the store heartbeats. The lease based
+	// reads are rejected on the store whose clock offset exceeds it, and the store
+	// is reported to prophet as clock skewed.
+	MaxClockOffset typeutil.Duration `toml:"max-clock-offset"`
+	// MaxReplicaCount the max replica count of the store, which is advertised to
+	// prophet by the store heartbeats. Prophet doesn't place more replicas on the
+	// store except for repairing the missing replicas. 0 means no limit.
+	MaxReplicaCount uint64 `toml:"max-replica-count"`
+	// MaxLeaderCount same as MaxReplicaCount, but for the leaders.
+	MaxLeaderCount uint64 `toml:"max-leader-count"`
+	// ShardStatsPersistDuration the interval to persist the read, write and the
+	// approximate size stats of the replicas, the stats are restored after the
+	// store restarted, so prophet doesn't see the shards idle and empty.
+	ShardStatsPersistDuration typeutil.Duration `toml:"shard-stats-persist-duration"`
+	// ShardStatsHalfLife the read and write stats restored after restart are
+	// halved every ShardStatsHalfLife since they persisted, since the old load
+	// is less likely to continue the longer the store was down.
+	ShardStatsHalfLife typeutil.Duration `toml:"shard-stats-half-life"`
 }
 
 func (c *ReplicationConfig) adjust() {
@@ -212,12 +337,53 @@ func (c *ReplicationConfig) adjust() {
 	if c.CompactLogCheckDuration.Duration == 0 {
 		c.CompactLogCheckDuration.Duration = defaultCompactLogCheckDuration
 	}
+
+	if c.LazyOpenTimeout.Duration == 0 {
+		c.LazyOpenTimeout.Duration = defaultLazyOpenTimeout
+	}
+
+	if c.ShardStatsPersistDuration.Duration == 0 {
+		c.ShardStatsPersistDuration.Duration = defaultShardStatsPersistDuration
+	}
+
+	if c.ShardStatsHalfLife.Duration == 0 {
+		c.ShardStatsHalfLife.Duration = defaultShardStatsHalfLife
+	}
+
+	if c.MaxClockOffset.Duration == 0 {
+		c.MaxClockOffset.Duration = defaultMaxClockOffset
+	}
 }
 
 // SnapshotConfig snapshot config
 type SnapshotConfig struct {
 	MaxConcurrencySnapChunks uint64            `toml:"max-concurrency-snap-chunks"`
 	SnapChunkSize            typeutil.ByteSize `toml:"snap-chunk-size"`
+	// RetryBaseDelay the delay before resending the snapshot to the replica
+	// after the first failure, the delay is doubled after each consecutive failure
+	// and capped by RetryMaxDelay.
+	RetryBaseDelay typeutil.Duration `toml:"retry-base-delay"`
+	RetryMaxDelay  typeutil.Duration `toml:"retry-max-delay"`
+	// MaxRetries the replica is quarantined after MaxRetries consecutive
+	// failures, the leader only probes the quarantined replica with a snapshot
+	// once every QuarantineDuration until the replica reports healthy.
+	MaxRetries         uint64            `toml:"max-retries"`
+	QuarantineDuration typeutil.Duration `toml:"quarantine-duration"`
+	// MaxConcurrentCreates the max number of the snapshots created by the store
+	// at the same time, the raft requests the snapshot again later if exceeded.
+	MaxConcurrentCreates uint64 `toml:"max-concurrent-creates"`
+	// MaxConcurrentTransfers the max number of the snapshots sent and the max
+	// number of the snapshots received by the store at the same time. It's
+	// advertised to prophet in the store heartbeats, so prophet doesn't dispatch
+	// more operators needing the snapshots to the store than it can transfer.
+	// 0 means the default limits of the transport, which are not advertised.
+	MaxConcurrentTransfers uint64 `toml:"max-concurrent-transfers"`
+	// MaxDiskUsage no more snapshots are created if the snapshot dirs of the
+	// store used more than MaxDiskUsage, 0 means no limit.
+	MaxDiskUsage typeutil.ByteSize `toml:"max-disk-usage"`
+	// GCInterval the interval of removing the snapshot dirs of the replicas not
+	// on the store anymore, and the temp dirs left by the aborted snapshots.
+	GCInterval typeutil.Duration `toml:"gc-interval"`
 }
 
 func (c *SnapshotConfig) adjust() {
@@ -228,17 +394,247 @@ func (c *SnapshotConfig) adjust() {
 	if c.SnapChunkSize == 0 {
 		c.SnapChunkSize = typeutil.ByteSize(defaultSnapChunkSize)
 	}
+
+	if c.RetryBaseDelay.Duration == 0 {
+		c.RetryBaseDelay.Duration = defaultSnapshotRetryBaseDelay
+	}
+
+	if c.RetryMaxDelay.Duration == 0 {
+		c.RetryMaxDelay.Duration = defaultSnapshotRetryMaxDelay
+	}
+
+	if c.MaxRetries == 0 {
+		c.MaxRetries = defaultSnapshotMaxRetries
+	}
+
+	if c.QuarantineDuration.Duration == 0 {
+		c.QuarantineDuration.Duration = defaultSnapshotQuarantine
+	}
+
+	if c.MaxConcurrentCreates == 0 {
+		c.MaxConcurrentCreates = defaultSnapshotMaxCreates
+	}
+
+	if c.GCInterval.Duration == 0 {
+		c.GCInterval.Duration = defaultSnapshotGCInterval
+	}
+}
+
+// SystemGroupConfig the config of the built-in system shard group. The shard of
+// the system group is created when the cluster is bootstrapped, and the replicas
+// of the system group are placed by a dedicated placement rule, and repaired
+// prior to the other shards.
+type SystemGroupConfig struct {
+	// Enable enables the system shard group, it must be enabled before the cluster
+	// bootstrapped.
+	Enable bool `toml:"enable"`
+	// MaxReplicas the number of the replicas of each shard in the system group
+	MaxReplicas int `toml:"max-replicas"`
+}
+
+func (c *SystemGroupConfig) adjust() {
+	if c.MaxReplicas == 0 {
+		c.MaxReplicas = defaultSystemGroupMaxReplicas
+	}
+}
+
+// ReadCacheConfig the per-shard cache of the responses of the expensive
+// deterministic custom read commands, to absorb the read hot spots. The read
+// commands are cached only if the Customize.CustomReadCacheKeyRangeFunc returns
+// their key ranges, and the cached responses are invalidated once the writes
+// overlapping the key ranges are applied.
+type ReadCacheConfig struct {
+	// Enable enables the read cache
+	Enable bool `toml:"enable"`
+	// MaxEntries the max number of the cached responses of each shard, the least
+	// recently used responses are evicted.
+	MaxEntries int `toml:"max-entries"`
+	// MaxValueBytes the responses larger than it are not cached
+	MaxValueBytes typeutil.ByteSize `toml:"max-value-bytes"`
+}
+
+func (c *ReadCacheConfig) adjust() {
+	if c.MaxEntries == 0 {
+		c.MaxEntries = defaultReadCacheMaxEntries
+	}
+	if c.MaxValueBytes == 0 {
+		c.MaxValueBytes = typeutil.ByteSize(defaultReadCacheMaxValueBytes)
+	}
+}
+
+// RequestLogConfig the sampled log of the requests received by the store, so
+// that the representative traffic can be seen without logging all the requests.
+// Only the command types, the key prefixes, the sizes and the latencies of the
+// requests are logged, the values are never logged.
+type RequestLogConfig struct {
+	// Enable enables the request log
+	Enable bool `toml:"enable"`
+	// SampleRate 1 in the SampleRate requests is logged
+	SampleRate uint64 `toml:"sample-rate"`
+	// KeyPrefixLength the max length of the logged key prefix, the keys are
+	// truncated after redacted by the Customize.CustomRequestLogRedactFunc.
+	KeyPrefixLength int `toml:"key-prefix-length"`
+}
+
+func (c *RequestLogConfig) adjust() {
+	if c.SampleRate == 0 {
+		c.SampleRate = uint64(defaultRequestLogSampleRate)
+	}
+	if c.KeyPrefixLength == 0 {
+		c.KeyPrefixLength = defaultRequestLogKeyPrefixLen
+	}
+}
+
+// CompactionConfig the offline compaction of the store. The data of the cold
+// shards, which have no writes for a while, is compacted manually in the idle
+// windows, to reclaim the space of the deleted ranges without the operators.
+// The shards deleted most since the last compaction are compacted first.
+type CompactionConfig struct {
+	// Enable enables the offline compaction
+	Enable bool `toml:"enable"`
+	// Windows the daily windows in the local time of the store to run the
+	// compactions, nothing is compacted if no window is configured.
+	Windows []pconfig.MaintenanceWindow `toml:"windows"`
+	// CheckDuration duration to pick the shards to compact in the windows
+	CheckDuration typeutil.Duration `toml:"check-duration"`
+	// ColdDuration the shard is cold if it has no writes in this duration
+	ColdDuration typeutil.Duration `toml:"cold-duration"`
+	// MinInterval the shard is not compacted again in this duration
+	MinInterval typeutil.Duration `toml:"min-interval"`
+	// Pace the pause between the compactions of two shards, to leave the IO
+	// bandwidth for the requests.
+	Pace typeutil.Duration `toml:"pace"`
+	// MaxShardsPerRound the max number of the shards compacted in each check
+	MaxShardsPerRound int `toml:"max-shards-per-round"`
+	// SizeRefreshDuration duration to refresh the approximate sizes of the
+	// shards changed by the range deletions, the merges or the gc of the mvcc
+	// data. The shards are compacted and their sizes are estimated by the
+	// underlying storage, regardless of the windows. At most MaxShardsPerRound
+	// shards are refreshed in each check.
+	SizeRefreshDuration typeutil.Duration `toml:"size-refresh-duration"`
+}
+
+func (c *CompactionConfig) adjust() {
+	for _, w := range c.Windows {
+		if err := w.Validate(); err != nil {
+			panic(err)
+		}
+	}
+	if c.CheckDuration.Duration == 0 {
+		c.CheckDuration.Duration = defaultCompactionCheckDuration
+	}
+	if c.ColdDuration.Duration == 0 {
+		c.ColdDuration.Duration = defaultCompactionColdDuration
+	}
+	if c.MinInterval.Duration == 0 {
+		c.MinInterval.Duration = defaultCompactionMinInterval
+	}
+	if c.Pace.Duration == 0 {
+		c.Pace.Duration = defaultCompactionPace
+	}
+	if c.MaxShardsPerRound == 0 {
+		c.MaxShardsPerRound = defaultCompactionMaxShards
+	}
+	if c.SizeRefreshDuration.Duration == 0 {
+		c.SizeRefreshDuration.Duration = defaultSizeRefreshDuration
+	}
+}
+
+// InWindow returns true if the time is within any of the windows
+func (c *CompactionConfig) InWindow(now time.Time) bool {
+	for _, w := range c.Windows {
+		if w.Contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// WatchdogConfig the watchdog monitors the critical loops, e.g. the raft
+// workers, the timer tasks of the store and the prophet coordinator. The
+// goroutine stacks of a loop are logged if an iteration of the loop is not
+// finished within the deadline, and the number of the stalled loops of each
+// component is exported by the metrics.
+type WatchdogConfig struct {
+	// Disable disables the watchdog
+	Disable bool `toml:"disable"`
+	// CheckDuration duration to check the stalled loops
+	CheckDuration typeutil.Duration `toml:"check-duration"`
+	// Deadline an iteration of a loop is stalled if it's not finished within
+	// the deadline
+	Deadline typeutil.Duration `toml:"deadline"`
+}
+
+func (c *WatchdogConfig) adjust() {
+	if c.CheckDuration.Duration == 0 {
+		c.CheckDuration.Duration = defaultWatchdogCheckDuration
+	}
+	if c.Deadline.Duration == 0 {
+		c.Deadline.Duration = defaultWatchdogDeadline
+	}
+}
+
+// LocalityConfig the locality routing config. The follower reads are routed to
+// the replicas in the same zone as the client first to cut the cross zone
+// traffic, and routed to the replicas in other zones if no replica in the zone
+// is reachable.
+type LocalityConfig struct {
+	// Enable enables the locality routing
+	Enable bool `toml:"enable"`
+	// ZoneLabel the store label of the zone, default is "zone"
+	ZoneLabel string `toml:"zone-label"`
+	// Zone the zone of the client, it's detected from the labels of the store
+	// by the ZoneLabel if not set.
+	Zone string `toml:"zone"`
+	// UnreachableStoreTimeout how long the follower reads skip the store after
+	// failed to send requests to it.
+	UnreachableStoreTimeout typeutil.Duration `toml:"unreachable-store-timeout"`
+}
+
+func (c *LocalityConfig) adjust(labels [][]string) {
+	if c.ZoneLabel == "" {
+		c.ZoneLabel = defaultLocalityZoneLabel
+	}
+
+	if c.Zone == "" {
+		for _, kv := range labels {
+			if len(kv) == 2 && kv[0] == c.ZoneLabel {
+				c.Zone = kv[1]
+			}
+		}
+	}
+
+	if c.UnreachableStoreTimeout.Duration == 0 {
+		c.UnreachableStoreTimeout.Duration = defaultUnreachableStoreTimeout
+	}
 }
 
 // WorkerConfig worker config
 type WorkerConfig struct {
 	RaftEventWorkers uint64 `toml:"raft-event-workers"`
+	// ReplicaStartWorkers how many workers to initialize the replicas concurrently
+	// when the store restarts. The replicas which held the lease are initialized
+	// first, and the store starts serving requests after all replicas initialized.
+	// Note that the ShardStateAware.Created may be called concurrently if it is
+	// greater than 1.
+	ReplicaStartWorkers uint64 `toml:"replica-start-workers"`
+	// SplitCheckWorkers how many split checks run concurrently, the split check
+	// scans the data of the shard if the size can't be estimated.
+	SplitCheckWorkers uint64 `toml:"split-check-workers"`
 }
 
 func (c *WorkerConfig) adjust() {
 	if c.RaftEventWorkers == 0 {
 		c.RaftEventWorkers = defaultRaftMaxWorkers
 	}
+
+	if c.ReplicaStartWorkers == 0 {
+		c.ReplicaStartWorkers = 1
+	}
+
+	if c.SplitCheckWorkers == 0 {
+		c.SplitCheckWorkers = defaultSplitCheckWorkers
+	}
 }
 
 // ShardConfig shard config
@@ -270,6 +666,54 @@ type RaftConfig struct {
 	RaftLog RaftLogConfig `toml:"raft-log"`
 	// LimitRequestBytesPerShard request's bytes per second limit
 	LimitRequestBytesPerShard typeutil.ByteSize `toml:"send-raft-batch-size"`
+	// ReadIndexBatchTicks the leader collects the read index requests received in
+	// the window of ReadIndexBatchTicks ticks, and confirms them by a single read
+	// index round when the window closed. The round is carried by the next
+	// heartbeats to the followers instead of an extra broadcast, so the reads
+	// wait up to HeartbeatTicks more. 0 means every read batch is confirmed by
+	// its own read index round.
+	ReadIndexBatchTicks int `toml:"read-index-batch-ticks"`
+	// MaxPendingRequestsPerShard the replica rejects the new requests with the
+	// ServerIsBusy error if so many requests are waiting in its queue, the error
+	// carries the queue depth and the estimated wait as the load hints to the
+	// client. 0 means no limit.
+	MaxPendingRequestsPerShard int `toml:"max-pending-requests-per-shard"`
+	// MaxInflightProposalBytesPerShard the leader rejects the writes with the
+	// ShardBusy error if the bytes of its proposals not applied reach the limit,
+	// so a hot shard can not grow the raft log faster than it's applied. 0 means
+	// no limit.
+	MaxInflightProposalBytesPerShard typeutil.ByteSize `toml:"max-inflight-proposal-bytes-per-shard"`
+	// MaxApplyLagPerShard the leader rejects the writes with the ShardBusy error
+	// if the quorum of the replicas has not applied so many entries of its raft
+	// log. The applied indexes of the followers are reported by the raft
+	// messages. 0 means no limit.
+	MaxApplyLagPerShard uint64 `toml:"max-apply-lag-per-shard"`
+	// MessageBatchWindow the raft messages of all shards sent to the same store
+	// within the window are sent in one transport frame, it is also the max
+	// latency added to a message. 0 means the messages are sent as soon as they
+	// arrive, only the messages already queued are sent together.
+	MessageBatchWindow typeutil.Duration `toml:"message-batch-window"`
+	// MaxMessageBatchSize max bytes of a transport frame, the frame is sent
+	// immediately once the size is reached.
+	MaxMessageBatchSize typeutil.ByteSize `toml:"max-message-batch-size"`
+	// EnableUDPHeartbeat is an experimental option to send the heartbeat messages
+	// in udp datagrams on the RaftAddr, so that the heartbeats are not blocked by
+	// the appends and snapshots on the lossy links. All stores in the cluster
+	// should enable it together, the lost heartbeats are recovered by the next
+	// heartbeat tick.
+	EnableUDPHeartbeat bool `toml:"enable-udp-heartbeat"`
+	// EnableLeaseRead the leader confirms the read index by its raft lease instead
+	// of a heartbeat round to the quorum, the follower reads are confirmed by the
+	// leader in the same way. It relies on the bounded clock drift between the
+	// stores, the reads fall back to the heartbeat round to the quorum while the
+	// clock of the store is skewed.
+	EnableLeaseRead bool `toml:"enable-lease-read"`
+	// ReadyStageBudget the budget of each stage of handling a raft ready, i.e.
+	// appending the logs, sending the messages and dispatching the committed
+	// entries to apply. A replica exceeding the budget blocks the other replicas
+	// handled by the same event worker, so the slow stage is logged with the
+	// shard detail.
+	ReadyStageBudget typeutil.Duration `toml:"ready-stage-budget"`
 }
 
 // GetElectionTimeoutDuration returns ElectionTimeoutTicks * TickInterval
@@ -307,6 +751,14 @@ func (c *RaftConfig) adjust() {
 		c.MaxEntryBytes = typeutil.ByteSize(defaultMaxEntryBytes)
 	}
 
+	if c.MaxMessageBatchSize == 0 {
+		c.MaxMessageBatchSize = typeutil.ByteSize(defaultMaxMessageBatchSize)
+	}
+
+	if c.ReadyStageBudget.Duration == 0 {
+		c.ReadyStageBudget.Duration = defaultReadyStageBudget
+	}
+
 	if c.LimitRequestBytesPerShard == 0 {
 		c.LimitRequestBytesPerShard = typeutil.ByteSize(1 << 30)
 	}
@@ -319,6 +771,13 @@ type RaftLogConfig struct {
 	DisableSync         bool   `toml:"disable-sync"`
 	CompactThreshold    uint64 `toml:"compact-threshold"`
 	MaxAllowTransferLag uint64 `toml:"max-allow-transfer-lag"`
+	// GroupCommitWindow the raft log appends of different shards arrived within
+	// the window share one fsync, it's also the max latency added to an append.
+	// 0 means disable group commit, every append is synced by itself.
+	GroupCommitWindow typeutil.Duration `toml:"group-commit-window"`
+	// GroupCommitMaxBatch the fsync is issued immediately if so many appends are
+	// waiting in the window. 0 means no limit.
+	GroupCommitMaxBatch int `toml:"group-commit-max-batch"`
 }
 
 func (c *RaftLogConfig) adjust() {
@@ -333,6 +792,21 @@ func (c *RaftLogConfig) adjust() {
 
 // StorageConfig storage config
 type StorageConfig struct {
+	// WALDir the directory of the WAL of the LogDB, default is in the directory
+	// of the LogDB. The WAL fsync is on the critical path of the raft appends, it
+	// should be put on a separate device from the data and the snapshots, which
+	// are written by the flushes, compactions and the snapshot transfers.
+	WALDir string `toml:"wal-dir"`
+	// DisableWALRecycle deletes the obsolete WAL files of the LogDB instead of
+	// reusing them as the new WAL files. The WAL files are recycled by default,
+	// which avoids the file system metadata updates on the WAL fsync.
+	DisableWALRecycle bool `toml:"disable-wal-recycle"`
+	// Classes the named data paths of the store on the different devices, e.g.
+	// nvme, ssd and hdd. The store advertises the classes to Prophet, the shard
+	// groups requesting a class by Prophet.Replication.ShardGroupStorageClasses
+	// are only placed on the stores with the class, and the DataStorageFactory
+	// should create the data storage of the group under GetDataPath(group).
+	Classes []StorageClass `toml:"classes"`
 
 	// DataStorageFactory is a storage factory  to store application's data
 	DataStorageFactory func(group uint64) storage.DataStorage `json:"-" toml:"-"`
@@ -340,6 +814,28 @@ type StorageConfig struct {
 	ForeachDataStorageFunc func(cb func(uint64, storage.DataStorage)) `json:"-" toml:"-"`
 }
 
+// StorageClass the data path of a storage class
+type StorageClass struct {
+	Name string `toml:"name"`
+	Path string `toml:"path"`
+}
+
+// GetDataPath returns the data path of the shard group, it's the path of the
+// storage class requested by the group if the store has the class, otherwise
+// the DataPath.
+func (c *Config) GetDataPath(group uint64) string {
+	class := c.Prophet.Replication.GetShardGroupStorageClass(group)
+	if class == "" {
+		return c.DataPath
+	}
+	for _, sc := range c.Storage.Classes {
+		if sc.Name == class {
+			return sc.Path
+		}
+	}
+	return c.DataPath
+}
+
 // CustomizeConfig customize config
 type CustomizeConfig struct {
 	// CustomShardStateAwareFactory is a factory func to create aware.ShardStateAware to handled shard life cycle.
@@ -360,6 +856,36 @@ type CustomizeConfig struct {
 		lease metapb.EpochLease,
 		req rpcpb.Request,
 		cb func(resp []byte, err error)) error `json:"-" toml:"-"`
+	// CustomApplyResultHandler is called after each write raft log applied, with the commands,
+	// written keys and bytes, used to maintain the application-level statistics incrementally.
+	CustomApplyResultHandler aware.ApplyResultHandler `json:"-" toml:"-"`
+	// CustomClock the HLC clock used to assign timestamps to the proposals, a clock
+	// based on the local wall clock is used if not set. It can be shared with the
+	// transaction client to get causally consistent timestamps.
+	CustomClock hlc.Clock `json:"-" toml:"-"`
+	// CustomReadCacheKeyRangeFunc returns the key range [start, end) touched by
+	// the read or write request, the end is nil if only the start key is touched.
+	// The read requests are cached only if ok is true, and the write requests
+	// without the key ranges invalidate all the cached responses of the shard.
+	CustomReadCacheKeyRangeFunc func(req storage.Request) (start, end []byte, ok bool) `json:"-" toml:"-"`
+	// CustomRequestLogRedactFunc returns the key of the request logged by the
+	// sampled request log, e.g. with the sensitive part of the key masked. The key
+	// is not logged if nil is returned, and the key of the request is logged if
+	// not set.
+	CustomRequestLogRedactFunc func(req rpcpb.Request) []byte `json:"-" toml:"-"`
+	// CustomWALHookFactory returns the WAL hook shipping the committed raft logs
+	// of the shard group before applied, nil if the raft logs of the group are
+	// not shipped. It's called once for each shard group.
+	CustomWALHookFactory func(group uint64) *aware.WALHook `json:"-" toml:"-"`
+	// CustomCommandRegistry the registered custom commands, the read and write
+	// requests with the unknown custom types are rejected by the store, and the
+	// payloads of the sampled requests are logged by the command names. The
+	// requests are not checked if not set.
+	CustomCommandRegistry *command.Registry `json:"-" toml:"-"`
+	// CustomMigrationRegistry the registered migrations of the on-disk data, the
+	// pending migrations are run in the order of the versions when the store is
+	// created, before any replica is started.
+	CustomMigrationRegistry *migration.Registry `json:"-" toml:"-"`
 }
 
 // GetLabels returns lables
@@ -371,6 +897,18 @@ func (c *Config) GetLabels() []metapb.Label {
 			Value: kv[1],
 		})
 	}
+	if c.Pool != "" {
+		labels = append(labels, metapb.Label{
+			Key:   pconfig.StorePoolLabel,
+			Value: c.Pool,
+		})
+	}
+	for _, class := range c.Storage.Classes {
+		labels = append(labels, metapb.Label{
+			Key:   pconfig.StorageClassLabel(class.Name),
+			Value: "true",
+		})
+	}
 
 	return labels
 }
@@ -0,0 +1,139 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+const (
+	// ZoneLabel the store label of the zone
+	ZoneLabel = "zone"
+	// NodeLabel the store label of the kubernetes node
+	NodeLabel = "node"
+
+	// ZoneEnv the env of the zone, e.g. set by the downward API from a pod label
+	ZoneEnv = "MATRIXCUBE_ZONE"
+	// NodeEnv the env of the node, e.g. set by the downward API from `spec.nodeName`
+	NodeEnv = "MATRIXCUBE_NODE"
+
+	// TopologyZoneLabel the well-known kubernetes label of the zone
+	TopologyZoneLabel = "topology.kubernetes.io/zone"
+	// HostnameLabel the well-known kubernetes label of the node hostname
+	HostnameLabel = "kubernetes.io/hostname"
+)
+
+// ParsePodLabels parses the pod labels file projected by the downward API
+// volume, each line of the file is `key="value"`.
+func ParsePodLabels(data []byte) (map[string]string, error) {
+	labels := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		idx := strings.Index(line, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid pod label line %q", line)
+		}
+		value, err := strconv.Unquote(line[idx+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pod label line %q: %w", line, err)
+		}
+		labels[line[:idx]] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// LoadStoreLabels returns the zone and node labels of the store. The labels
+// are read from the pod labels file first if it's not empty, and overwritten
+// by the envs if they're set.
+func LoadStoreLabels(fs vfs.FS, podLabelsFile string) (map[string]string, error) {
+	labels := make(map[string]string)
+	if podLabelsFile != "" {
+		podLabels, err := readPodLabels(fs, podLabelsFile)
+		if err != nil {
+			return nil, err
+		}
+		if v, ok := podLabels[TopologyZoneLabel]; ok {
+			labels[ZoneLabel] = v
+		}
+		if v, ok := podLabels[HostnameLabel]; ok {
+			labels[NodeLabel] = v
+		}
+	}
+
+	if v, ok := os.LookupEnv(ZoneEnv); ok && v != "" {
+		labels[ZoneLabel] = v
+	}
+	if v, ok := os.LookupEnv(NodeEnv); ok && v != "" {
+		labels[NodeLabel] = v
+	}
+	return labels, nil
+}
+
+func readPodLabels(fs vfs.FS, file string) (map[string]string, error) {
+	f, err := fs.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePodLabels(data)
+}
+
+// AdjustLabels adds the labels to the store config, the labels already in the
+// config are kept.
+func AdjustLabels(cfg *config.Config, labels map[string]string) {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if hasLabel(cfg, k) {
+			continue
+		}
+		cfg.Labels = append(cfg.Labels, []string{k, labels[k]})
+	}
+}
+
+func hasLabel(cfg *config.Config, key string) bool {
+	for _, kv := range cfg.Labels {
+		if len(kv) > 0 && kv[0] == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"os"
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePodLabels(t *testing.T) {
+	labels, err := ParsePodLabels([]byte("app=\"cube\"\n\ntopology.kubernetes.io/zone=\"z1\"\nk=\"a\\\"b\"\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"app":             "cube",
+		TopologyZoneLabel: "z1",
+		"k":               "a\"b",
+	}, labels)
+
+	_, err = ParsePodLabels([]byte("app"))
+	assert.Error(t, err)
+	_, err = ParsePodLabels([]byte("=\"v\""))
+	assert.Error(t, err)
+	_, err = ParsePodLabels([]byte("app=cube"))
+	assert.Error(t, err)
+}
+
+func TestLoadStoreLabels(t *testing.T) {
+	fs := vfs.NewMemFS()
+	f, err := fs.Create("labels")
+	require.NoError(t, err)
+	_, err = f.Write([]byte("topology.kubernetes.io/zone=\"z1\"\nkubernetes.io/hostname=\"h1\"\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	labels, err := LoadStoreLabels(fs, "")
+	assert.NoError(t, err)
+	assert.Empty(t, labels)
+
+	labels, err = LoadStoreLabels(fs, "labels")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{ZoneLabel: "z1", NodeLabel: "h1"}, labels)
+
+	require.NoError(t, os.Setenv(NodeEnv, "n1"))
+	defer os.Unsetenv(NodeEnv)
+	labels, err = LoadStoreLabels(fs, "labels")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{ZoneLabel: "z1", NodeLabel: "n1"}, labels)
+
+	_, err = LoadStoreLabels(fs, "missing")
+	assert.Error(t, err)
+}
+
+func TestAdjustLabels(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Labels = [][]string{{ZoneLabel, "z0"}}
+	AdjustLabels(cfg, map[string]string{ZoneLabel: "z1", NodeLabel: "n1", "rack": "r1"})
+	assert.Equal(t, [][]string{{ZoneLabel, "z0"}, {NodeLabel, "n1"}, {"rack", "r1"}}, cfg.Labels)
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/matrixorigin/matrixcube/components/prophet"
+	"github.com/matrixorigin/matrixcube/raftstore"
+)
+
+const (
+	// LivenessPath the http path of the store liveness probe
+	LivenessPath = "/livez"
+	// ReadinessPath the http path of the store readiness probe
+	ReadinessPath = "/readyz"
+	// ProphetReadinessPath the http path of the prophet readiness probe
+	ProphetReadinessPath = "/prophet/readyz"
+)
+
+var (
+	// ErrNoProphetLeader the prophet leader is not elected
+	ErrNoProphetLeader = errors.New("prophet leader not elected")
+)
+
+// Checker returns nil if the component is healthy
+type Checker func() error
+
+// NewProbeHandler returns a http handler for the kubernetes probes, it responds
+// 200 if the checker passed, otherwise 503 with the error as the body.
+func NewProbeHandler(check Checker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := check(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+}
+
+// StoreLiveness returns the liveness checker of the store, it fails only if the
+// store is stopped, a starting store should not be restarted.
+func StoreLiveness(s raftstore.Store) Checker {
+	return func() error {
+		if err := s.Ready(); errors.Is(err, raftstore.ErrStoreStopped) {
+			return err
+		}
+		return nil
+	}
+}
+
+// StoreReadiness returns the readiness checker of the store
+func StoreReadiness(s raftstore.Store) Checker {
+	return s.Ready
+}
+
+// ProphetReadiness returns the readiness checker of the prophet, it passes if
+// the prophet leader is elected.
+func ProphetReadiness(p prophet.Prophet) Checker {
+	return func() error {
+		if p.GetLeader() == nil {
+			return ErrNoProphetLeader
+		}
+		return nil
+	}
+}
+
+// RegisterProbes registers the probe handlers of the store to the mux, and the
+// prophet readiness probe if the store is a prophet node.
+func RegisterProbes(mux *http.ServeMux, s raftstore.Store) {
+	mux.Handle(LivenessPath, NewProbeHandler(StoreLiveness(s)))
+	mux.Handle(ReadinessPath, NewProbeHandler(StoreReadiness(s)))
+	if s.GetConfig().Prophet.ProphetNode {
+		mux.Handle(ProphetReadinessPath, NewProbeHandler(func() error {
+			// the prophet is created while the store starting
+			if err := s.Ready(); errors.Is(err, raftstore.ErrStoreNotStarted) ||
+				errors.Is(err, raftstore.ErrStoreStopped) {
+				return err
+			}
+			return ProphetReadiness(s.Prophet())()
+		}))
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/raftstore"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStore struct {
+	raftstore.Store
+
+	cfg     *config.Config
+	ready   error
+	drain   error
+	drained bool
+	stopped bool
+}
+
+func newTestStore() *testStore {
+	return &testStore{cfg: &config.Config{}}
+}
+
+func (s *testStore) GetConfig() *config.Config {
+	return s.cfg
+}
+
+func (s *testStore) Ready() error {
+	return s.ready
+}
+
+func (s *testStore) DrainLeaders(ctx context.Context) error {
+	s.drained = true
+	return s.drain
+}
+
+func (s *testStore) Stop() {
+	s.stopped = true
+}
+
+func doTestProbe(mux *http.ServeMux, path string) int {
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w.Code
+}
+
+func TestStoreProbes(t *testing.T) {
+	s := newTestStore()
+	mux := http.NewServeMux()
+	RegisterProbes(mux, s)
+
+	s.ready = raftstore.ErrStoreNotStarted
+	assert.Equal(t, http.StatusOK, doTestProbe(mux, LivenessPath))
+	assert.Equal(t, http.StatusServiceUnavailable, doTestProbe(mux, ReadinessPath))
+	assert.Equal(t, http.StatusNotFound, doTestProbe(mux, ProphetReadinessPath))
+
+	s.ready = nil
+	assert.Equal(t, http.StatusOK, doTestProbe(mux, LivenessPath))
+	assert.Equal(t, http.StatusOK, doTestProbe(mux, ReadinessPath))
+
+	s.ready = raftstore.ErrStoreStopped
+	assert.Equal(t, http.StatusServiceUnavailable, doTestProbe(mux, LivenessPath))
+	assert.Equal(t, http.StatusServiceUnavailable, doTestProbe(mux, ReadinessPath))
+}
+
+func TestProphetProbeNotStarted(t *testing.T) {
+	s := newTestStore()
+	s.cfg.Prophet.ProphetNode = true
+	s.ready = raftstore.ErrStoreNotStarted
+	mux := http.NewServeMux()
+	RegisterProbes(mux, s)
+	assert.Equal(t, http.StatusServiceUnavailable, doTestProbe(mux, ProphetReadinessPath))
+}
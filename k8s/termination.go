@@ -0,0 +1,70 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/raftstore"
+)
+
+const (
+	// PreStopPath the http path of the preStop hook
+	PreStopPath = "/prestop"
+)
+
+// GracefulStop drains the leaders of the store and then stops the store. The
+// timeout should be less than the `terminationGracePeriodSeconds` of the pod,
+// the store is stopped even if the leaders are not drained in time.
+func GracefulStop(s raftstore.Store, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := s.DrainLeaders(ctx)
+	s.Stop()
+	return err
+}
+
+// NewPreStopHandler returns the http handler of the preStop hook, it responds
+// after the leaders of the store are drained or timeout. The store is not
+// stopped, it's stopped after the SIGTERM sent by the kubelet.
+func NewPreStopHandler(s raftstore.Store, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		if err := s.DrainLeaders(ctx); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+}
+
+// StopOnSignal blocks until SIGTERM or SIGINT received, then stops the store
+// gracefully by GracefulStop.
+func StopOnSignal(s raftstore.Store, timeout time.Duration) error {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(c)
+
+	<-c
+	return GracefulStop(s, timeout)
+}
@@ -0,0 +1,51 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGracefulStop(t *testing.T) {
+	s := newTestStore()
+	assert.NoError(t, GracefulStop(s, time.Second))
+	assert.True(t, s.drained)
+	assert.True(t, s.stopped)
+
+	s = newTestStore()
+	s.drain = context.DeadlineExceeded
+	assert.Error(t, GracefulStop(s, time.Second))
+	assert.True(t, s.stopped)
+}
+
+func TestPreStopHandler(t *testing.T) {
+	s := newTestStore()
+	h := NewPreStopHandler(s, time.Second)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, PreStopPath, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, s.drained)
+	assert.False(t, s.stopped)
+
+	s.drain = context.DeadlineExceeded
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, PreStopPath, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -11,6 +11,24 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package keys defines the key layout used by cube.
+//
+// The keys written by cube into the KVStorage of the store are in the local
+// range prefixed by 0x01, except the key used to force a sync of the WAL:
+//
+//	store ident:    0x01 | 0x01
+//	raft data:      0x01 | 0x02 | shard id (8 bytes) | suffix [| index (8 bytes)]
+//	shard gc:       0x01 | 0x03 | shard id (8 bytes)
+//	forced sync:    0xFF * 10
+//
+// where the suffix of the raft data is one of the raft log, max index, hard
+// state, applied index, metadata, snapshot and shard stats. The metadata and
+// the applied index keys are also written into the DataStorage with the meta
+// prefix, the user data is written with the data prefix, see util/keys.
+//
+// Inside the user key space of the shard groups, the keys no less than the
+// system key prefix are reserved by cube, the writes of the embedders to them
+// are rejected before proposed, see ValidateUserKey.
 package keys
 
 import (
@@ -25,6 +43,7 @@ const (
 	appliedIndexSuffix = 0x07
 	metadataSuffix     = 0x08
 	snapshotSuffix     = 0x09
+	shardStatsSuffix   = 0x0A
 )
 
 // data is in (z, z+1)
@@ -46,6 +65,12 @@ var (
 	// with different prefixes.
 	raftPrefix    byte = 0x02
 	raftPrefixKey      = []byte{localPrefix, raftPrefix}
+	// the data ranges of the destroyed shards waiting to be removed in
+	// background
+	shardGCPrefix    byte = 0x03
+	shardGCPrefixKey      = []byte{localPrefix, shardGCPrefix}
+	// the version of the on-disk data layout of the store
+	storeSchemaKey = []byte{localPrefix, 0x04}
 )
 
 var (
@@ -64,6 +89,11 @@ func GetStoreIdentKey() []byte {
 	return storeIdentKey
 }
 
+// GetStoreSchemaKey return key of StoreSchema
+func GetStoreSchemaKey() []byte {
+	return storeSchemaKey
+}
+
 // GetSnapshotKey returns the key used to store snapshot metadata in LogDB.
 func GetSnapshotKey(shardID uint64, index uint64, key []byte) []byte {
 	key = getKeySlice(key, indexedIDKeyLength)
@@ -92,6 +122,13 @@ func GetAppliedIndexKey(shardID uint64, key []byte) []byte {
 	return getIDKey(appliedIndexSuffix, shardID, key)
 }
 
+// GetShardStatsKey returns key that used to store the persisted `metapb.ShardStats`
+// of the replica
+func GetShardStatsKey(shardID uint64, key []byte) []byte {
+	key = getKeySlice(key, idKeyLength)
+	return getIDKey(shardStatsSuffix, shardID, key)
+}
+
 // GetShardIDFromAppliedIndexKey returns shard id
 func GetShardIDFromAppliedIndexKey(key []byte) (uint64, error) {
 	if !IsAppliedIndexKey(key) {
@@ -132,6 +169,16 @@ func IsRaftLogKey(key []byte) bool {
 	return isRaftSuffixKey(key, raftLogSuffix) && len(key) == indexedIDKeyLength
 }
 
+// GetShardGCKey returns the key used to store the data range of the destroyed
+// shard which is not removed yet
+func GetShardGCKey(shardID uint64) []byte {
+	key := make([]byte, 10)
+	key[0] = shardGCPrefixKey[0]
+	key[1] = shardGCPrefixKey[1]
+	writeUint64(shardID, key[2:])
+	return key
+}
+
 func GetRaftPrefix(shardID uint64) []byte {
 	key := make([]byte, 10)
 	key[0] = raftPrefixKey[0]
@@ -14,6 +14,7 @@
 package keys
 
 import (
+	"errors"
 	"math"
 	"testing"
 
@@ -243,6 +244,48 @@ func TestGetMaxIndexKey(t *testing.T) {
 	assert.Equal(t, key1, key4)
 }
 
+func TestGetShardStatsKey(t *testing.T) {
+	key1 := GetShardStatsKey(10, make([]byte, indexedIDKeyLength))
+	key2 := GetShardStatsKey(10, nil)
+	assert.Equal(t, key1, key2)
+	assert.Equal(t, GetRaftPrefix(10), key1[:len(key1)-1])
+	assert.False(t, IsAppliedIndexKey(key1))
+}
+
+func TestSystemKeys(t *testing.T) {
+	key := GetSystemKey([]byte("k"))
+	assert.True(t, IsSystemKey(key))
+	assert.True(t, IsSystemKey(GetSystemKeyPrefix()))
+	assert.True(t, IsSystemKey([]byte{0xFF, 0xFF, 0xFF}))
+	assert.False(t, IsSystemKey([]byte("k")))
+	assert.False(t, IsSystemKey([]byte{0xFF, 0xFF}))
+
+	start, end := GetUserKeyRange()
+	assert.Empty(t, start)
+	assert.Equal(t, GetSystemKeyPrefix(), end)
+	start, end = GetSystemKeyRange()
+	assert.Equal(t, GetSystemKeyPrefix(), start)
+	assert.Empty(t, end)
+
+	assert.NoError(t, ValidateUserKey([]byte("k")))
+	assert.True(t, errors.Is(ValidateUserKey(key), ErrReservedKey))
+
+	tests := []struct {
+		start, end []byte
+		ok         bool
+	}{
+		{[]byte("a"), []byte("b"), true},
+		{nil, GetSystemKeyPrefix(), true},
+		{nil, nil, false},
+		{[]byte("a"), key, false},
+		{key, nil, false},
+	}
+	for idx, tt := range tests {
+		err := ValidateUserKeyRange(tt.start, tt.end)
+		assert.Equal(t, tt.ok, err == nil, "index %d", idx)
+	}
+}
+
 func TestGetRaftLogKey(t *testing.T) {
 	keyL := make([]byte, indexedIDKeyLength*2)
 	keyI := make([]byte, indexedIDKeyLength)
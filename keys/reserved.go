@@ -0,0 +1,83 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrReservedKey the user key is in the range reserved by cube
+	ErrReservedKey = errors.New("key is reserved by cube")
+)
+
+var (
+	// systemKeyPrefix the user keys no less than it are reserved by cube for the
+	// system data kept in the shards. They are sorted after all the user data,
+	// so the last shard of a group holds them without splitting the user range.
+	systemKeyPrefix = []byte{0xFF, 0xFF, 'c', 'u', 'b', 'e'}
+)
+
+// GetSystemKeyPrefix returns the prefix of the system keys reserved by cube,
+// any user key no less than it is reserved. The returned slice must not be
+// modified.
+func GetSystemKeyPrefix() []byte {
+	return systemKeyPrefix
+}
+
+// GetSystemKey returns the reserved user key of the system data
+func GetSystemKey(key []byte) []byte {
+	v := make([]byte, 0, len(systemKeyPrefix)+len(key))
+	v = append(v, systemKeyPrefix...)
+	return append(v, key...)
+}
+
+// GetUserKeyRange returns the range [start, end) of the user keys which can be
+// written by the embedders, it's all the keys before the reserved system keys.
+func GetUserKeyRange() ([]byte, []byte) {
+	return nil, systemKeyPrefix
+}
+
+// GetSystemKeyRange returns the range [start, end) of the reserved system keys,
+// the empty end means the max key.
+func GetSystemKeyRange() ([]byte, []byte) {
+	return systemKeyPrefix, nil
+}
+
+// IsSystemKey returns true if the user key is reserved by cube
+func IsSystemKey(key []byte) bool {
+	return bytes.Compare(key, systemKeyPrefix) >= 0
+}
+
+// ValidateUserKey returns ErrReservedKey if the user key is reserved by cube,
+// the writes of the embedders to the reserved keys are rejected before they
+// are proposed.
+func ValidateUserKey(key []byte) error {
+	if IsSystemKey(key) {
+		return fmt.Errorf("%w: %q", ErrReservedKey, key)
+	}
+	return nil
+}
+
+// ValidateUserKeyRange returns ErrReservedKey if the user key range [start, end)
+// overlaps the reserved system keys, the empty end means the max key.
+func ValidateUserKeyRange(start, end []byte) error {
+	if IsSystemKey(start) ||
+		len(end) == 0 || bytes.Compare(end, systemKeyPrefix) > 0 {
+		return fmt.Errorf("%w: range [%q, %q)", ErrReservedKey, start, end)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util"
+)
+
+// Option KVLogDB option
+type Option func(*KVLogDB)
+
+// WithGroupCommit enables the group commit. The raft states of different shards
+// are written to the store without fsync, and all writes arrived within the
+// window share one fsync. The window is also the max latency added to a write,
+// the fsync is issued immediately once maxBatch writes are waiting, 0 means no
+// limit.
+func WithGroupCommit(window time.Duration, maxBatch int) Option {
+	return func(l *KVLogDB) {
+		if window > 0 {
+			l.committer = newGroupCommitter(l.ms, window, maxBatch)
+		}
+	}
+}
+
+type syncRound struct {
+	count    int
+	err      error
+	full     chan struct{}
+	done     chan struct{}
+	fullOnce sync.Once
+}
+
+// groupCommitter merges the fsync of concurrent writes. All writes joined a
+// round are written to the store before the round's fsync issued, so they are
+// all persistent once the fsync completed.
+type groupCommitter struct {
+	ms       storage.KVMetadataStore
+	window   time.Duration
+	maxBatch int
+
+	mu struct {
+		sync.Mutex
+		current *syncRound
+	}
+}
+
+func newGroupCommitter(ms storage.KVMetadataStore, window time.Duration, maxBatch int) *groupCommitter {
+	return &groupCommitter{
+		ms:       ms,
+		window:   window,
+		maxBatch: maxBatch,
+	}
+}
+
+func (c *groupCommitter) write(wb util.WriteBatch) error {
+	if err := c.ms.Write(wb, false); err != nil {
+		return err
+	}
+	return c.sync()
+}
+
+func (c *groupCommitter) sync() error {
+	c.mu.Lock()
+	leader := false
+	r := c.mu.current
+	if r == nil {
+		r = &syncRound{
+			full: make(chan struct{}),
+			done: make(chan struct{}),
+		}
+		c.mu.current = r
+		leader = true
+	}
+	r.count++
+	if c.maxBatch > 0 && r.count >= c.maxBatch {
+		// no more writes can join the full round
+		c.mu.current = nil
+		r.fullOnce.Do(func() { close(r.full) })
+	}
+	c.mu.Unlock()
+
+	if leader {
+		timer := time.NewTimer(c.window)
+		select {
+		case <-timer.C:
+		case <-r.full:
+			timer.Stop()
+		}
+		c.mu.Lock()
+		if c.mu.current == r {
+			c.mu.current = nil
+		}
+		c.mu.Unlock()
+
+		r.err = c.ms.Sync()
+		close(r.done)
+	}
+
+	<-r.done
+	return r.err
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logdb
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+func TestGroupCommitShareFsync(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.GetTestFS()
+	defer func() {
+		assert.NoError(t, fs.RemoveAll(testStorageDir))
+	}()
+	kv := getTestStorage(fs)
+	defer kv.Close()
+
+	shards := 8
+	db := NewKVLogDB(kv, log.GetPanicZapLogger(), WithGroupCommit(time.Second, shards))
+	before := kv.(storage.StatsKeeper).Stats().SyncCount
+
+	var wg sync.WaitGroup
+	for i := 0; i < shards; i++ {
+		wg.Add(1)
+		go func(shardID uint64) {
+			defer wg.Done()
+			rd := raft.Ready{
+				Entries: []raftpb.Entry{{Index: 1, Term: 1}},
+			}
+			assert.NoError(t, db.SaveRaftState(shardID, 1, rd, db.NewWorkerContext()))
+		}(uint64(i + 1))
+	}
+	wg.Wait()
+
+	assert.Equal(t, before+1, kv.(storage.StatsKeeper).Stats().SyncCount)
+	for i := 0; i < shards; i++ {
+		index, err := db.getMaxIndex(uint64(i+1), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), index)
+	}
+}
+
+func TestGroupCommitLatencyCap(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.GetTestFS()
+	defer func() {
+		assert.NoError(t, fs.RemoveAll(testStorageDir))
+	}()
+	kv := getTestStorage(fs)
+	defer kv.Close()
+
+	db := NewKVLogDB(kv, log.GetPanicZapLogger(), WithGroupCommit(time.Millisecond*10, 0))
+	rd := raft.Ready{
+		Entries: []raftpb.Entry{{Index: 1, Term: 1}},
+	}
+	start := time.Now()
+	assert.NoError(t, db.SaveRaftState(testShardID, testReplicaID, rd, db.NewWorkerContext()))
+	assert.True(t, time.Since(start) < time.Second)
+}
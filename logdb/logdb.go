@@ -119,17 +119,22 @@ type LogDB interface {
 
 // KVLogDB is a LogDB implementation built on top of a Key-Value store.
 type KVLogDB struct {
-	logger *zap.Logger
-	ms     storage.KVMetadataStore
+	logger    *zap.Logger
+	ms        storage.KVMetadataStore
+	committer *groupCommitter
 }
 
 var _ LogDB = (*KVLogDB)(nil)
 
-func NewKVLogDB(ms storage.KVMetadataStore, logger *zap.Logger) *KVLogDB {
-	return &KVLogDB{
+func NewKVLogDB(ms storage.KVMetadataStore, logger *zap.Logger, opts ...Option) *KVLogDB {
+	l := &KVLogDB{
 		logger: logger,
 		ms:     ms,
 	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
 }
 
 func (l *KVLogDB) Name() string {
@@ -232,6 +237,9 @@ func (l *KVLogDB) SaveRaftState(shardID uint64,
 			buf.Uint64ToBytesTo(rd.Entries[len(rd.Entries)-1].Index, value)
 		})
 	}
+	if l.committer != nil {
+		return l.committer.write(ctx.wb)
+	}
 	return l.ms.Write(ctx.wb, true)
 }
 
@@ -357,6 +365,8 @@ func (l *KVLogDB) RemoveReplicaData(shardID uint64) error {
 	wc.wb.DeleteRange(fk, lk)
 	// max index
 	wc.wb.Delete(keys.GetMaxIndexKey(shardID, nil))
+	// persisted stats
+	wc.wb.Delete(keys.GetShardStatsKey(shardID, nil))
 
 	return l.ms.Write(wc.wb, true)
 }
@@ -464,6 +464,7 @@ func TestLogDBRemoveReplicaData(t *testing.T) {
 		if err := db.SaveRaftState(testShardID, testReplicaID, rd1, wc); err != nil {
 			t.Fatalf("failed to save raft state, %v", err)
 		}
+		assert.NoError(t, db.ms.Set(keys.GetShardStatsKey(testShardID, nil), []byte{1}, false))
 		assert.NoError(t, db.RemoveReplicaData(testShardID))
 		first, length, err := db.getRange(testShardID, testReplicaID, 0)
 		assert.NoError(t, err)
@@ -480,6 +481,10 @@ func TestLogDBRemoveReplicaData(t *testing.T) {
 		v, err := db.ms.Get(keys.GetHardStateKey(testShardID, testReplicaID, nil))
 		assert.NoError(t, err)
 		assert.Equal(t, 0, len(v))
+
+		v, err = db.ms.Get(keys.GetShardStatsKey(testShardID, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(v))
 	}
 	fs := vfs.GetTestFS()
 	runLogDBTest(t, tf, fs)
@@ -19,10 +19,31 @@ import (
 
 // Cfg metric cfg
 type Cfg struct {
-	Addr     string `toml:"addr"`
-	Interval int    `toml:"interval"`
+	// Sink the sink which the metrics are exported to, see SinkType. Default is
+	// PrometheusSink.
+	Sink SinkType `toml:"sink"`
+	// Addr the address of the prometheus pushgateway, or the udp address of the
+	// statsd server if the Sink is StatsdSink.
+	Addr string `toml:"addr"`
+	// Interval the interval in seconds to push or send the metrics.
+	Interval int `toml:"interval"`
+	// Job the prometheus job, or the prefix of the metric names if the Sink is
+	// StatsdSink.
 	Job      string `toml:"job"`
 	Instance string `toml:"instance"`
+	// ShardLevel the aggregation level of the shard metrics, see ShardMetricLevel.
+	// Default is ShardMetricStore.
+	ShardLevel ShardMetricLevel `toml:"shard-level"`
+	// ShardTopN the number of the busiest shards exported individually if the
+	// ShardLevel is ShardMetricTopN. Default is 10.
+	ShardTopN int `toml:"shard-top-n"`
+}
+
+func (c Cfg) sink() SinkType {
+	if c.Sink == "" {
+		return PrometheusSink
+	}
+	return c.Sink
 }
 
 func (c Cfg) instance() string {
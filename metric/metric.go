@@ -31,17 +31,36 @@ func init() {
 	registry.MustRegister(batchGauge)
 	registry.MustRegister(storeStorageGauge)
 	registry.MustRegister(shardCountGauge)
+	registry.MustRegister(storeClockOffsetGauge)
+	registry.MustRegister(shardStatsGauge)
+	registry.MustRegister(watchdogStalledGauge)
 
 	registry.MustRegister(raftReadyCounter)
 	registry.MustRegister(raftMsgsCounter)
 	registry.MustRegister(raftCommandCounter)
 	registry.MustRegister(raftAdminCommandCounter)
+	registry.MustRegister(splitCheckCounter)
+	registry.MustRegister(readCacheCounter)
+	registry.MustRegister(snapshotFormatDowngradeCounter)
+	registry.MustRegister(consistencyCheckCounter)
+	registry.MustRegister(shardBusyCounter)
+	registry.MustRegister(clientRequestRetryCounter)
+	registry.MustRegister(clientRouteCounter)
 
 	registry.MustRegister(raftLogLagHistogram)
 	registry.MustRegister(raftLogAppendDurationHistogram)
 	registry.MustRegister(raftLogApplyDurationHistogram)
+	registry.MustRegister(raftReadyStageDurationHistogram)
 	registry.MustRegister(raftProposalSizeHistogram)
 	registry.MustRegister(snapshotSizeHistogram)
 	registry.MustRegister(snapshotBuildingDurationHistogram)
 	registry.MustRegister(snapshotSendingDurationHistogram)
+	registry.MustRegister(splitCheckDurationHistogram)
+	registry.MustRegister(clientRequestDurationHistogram)
+
+	registry.MustRegister(storageWriteBatchSizeHistogram)
+	registry.MustRegister(storageWriteBatchKeysHistogram)
+	registry.MustRegister(storageSnapshotDurationHistogram)
+	registry.MustRegister(storageSnapshotSizeHistogram)
+	registry.MustRegister(storageCompactionDurationHistogram)
 }
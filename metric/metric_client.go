@@ -0,0 +1,64 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metric
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	clientRequestDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "matrixcube",
+			Subsystem: "client",
+			Name:      "request_duration_seconds",
+			Help:      "Bucketed histogram of the duration of the client requests.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2.0, 20),
+		}, []string{"class", "result"})
+
+	clientRequestRetryCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "matrixcube",
+			Subsystem: "client",
+			Name:      "request_retries_total",
+			Help:      "Total number of the retries of the client requests.",
+		}, []string{"class"})
+
+	clientRouteCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "matrixcube",
+			Subsystem: "client",
+			Name:      "route_lookups_total",
+			Help:      "Total number of the route lookups of the client requests.",
+		}, []string{"result"})
+)
+
+// ObserveClientRequestDuration observe the duration of a client request of the
+// class, the result is ok, error, timeout or canceled.
+func ObserveClientRequestDuration(class, result string, elapsed time.Duration) {
+	clientRequestDurationHistogram.WithLabelValues(class, result).Observe(elapsed.Seconds())
+}
+
+// IncClientRequestRetries increases the retries of the client requests of the class
+func IncClientRequestRetries(class string) {
+	clientRequestRetryCounter.WithLabelValues(class).Inc()
+}
+
+// IncClientRouteLookups increases the route lookups of the client requests with
+// the result, the route cache hit rate is the rate of the hit lookups.
+func IncClientRouteLookups(result string) {
+	clientRouteCounter.WithLabelValues(result).Inc()
+}
@@ -14,6 +14,8 @@
 package metric
 
 import (
+	"strconv"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -49,6 +51,46 @@ var (
 			Name:      "command_admin_total",
 			Help:      "Total number of admin commands processed.",
 		}, []string{"type", "status"})
+
+	splitCheckCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "matrixcube",
+			Subsystem: "raftstore",
+			Name:      "split_check_total",
+			Help:      "Total number of split checks.",
+		}, []string{"result"})
+
+	readCacheCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "matrixcube",
+			Subsystem: "raftstore",
+			Name:      "read_cache_total",
+			Help:      "Total number of the cacheable reads looked up in the read cache.",
+		}, []string{"result"})
+
+	snapshotFormatDowngradeCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "matrixcube",
+			Subsystem: "transport",
+			Name:      "snapshot_format_downgrade_total",
+			Help:      "Total number of the snapshots sent in an older format supported by the receiver.",
+		}, []string{"format"})
+
+	consistencyCheckCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "matrixcube",
+			Subsystem: "raftstore",
+			Name:      "consistency_check_total",
+			Help:      "Total number of the replica consistency checks.",
+		}, []string{"result"})
+
+	shardBusyCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "matrixcube",
+			Subsystem: "raftstore",
+			Name:      "shard_busy_total",
+			Help:      "Total number of the writes rejected by the flow control of the shards.",
+		}, []string{"reason"})
 )
 
 // IncComandCount inc the command received
@@ -126,6 +168,11 @@ func AddRaftProposalReadIndexCount(value uint64) {
 	raftMsgsCounter.WithLabelValues("read-index").Add(float64(value))
 }
 
+// AddRaftProposalStaleReadCount add stale read
+func AddRaftProposalStaleReadCount(value uint64) {
+	raftMsgsCounter.WithLabelValues("stale-read").Add(float64(value))
+}
+
 // AddRaftProposalNormalCount add normal
 func AddRaftProposalNormalCount(value uint64) {
 	raftMsgsCounter.WithLabelValues("normal").Add(float64(value))
@@ -175,3 +222,28 @@ func AddRaftAdminCommandCompactCount(value uint64) {
 func AddRaftAdminCommandCompactSucceedCount(value uint64) {
 	raftAdminCommandCounter.WithLabelValues("compact", "succeed").Add(float64(value))
 }
+
+// IncSplitCheckCount inc the split checks finished or canceled
+func IncSplitCheckCount(result string) {
+	splitCheckCounter.WithLabelValues(result).Inc()
+}
+
+// IncReadCacheCount inc the cacheable reads hit or missed the read cache
+func IncReadCacheCount(result string) {
+	readCacheCounter.WithLabelValues(result).Inc()
+}
+
+// IncSnapshotFormatDowngradeCount inc the snapshots sent in the older format
+func IncSnapshotFormatDowngradeCount(format uint64) {
+	snapshotFormatDowngradeCounter.WithLabelValues(strconv.FormatUint(format, 10)).Inc()
+}
+
+// IncConsistencyCheckCount inc the consistency checks matched or mismatched
+func IncConsistencyCheckCount(result string) {
+	consistencyCheckCounter.WithLabelValues(result).Inc()
+}
+
+// IncShardBusyCount inc the writes rejected by the flow control of the shards
+func IncShardBusyCount(reason string) {
+	shardBusyCounter.WithLabelValues(reason).Inc()
+}
@@ -14,6 +14,8 @@
 package metric
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -49,6 +51,22 @@ var (
 			Name:      "store_storage_bytes",
 			Help:      "Size of raftstore storage.",
 		}, []string{"type"})
+
+	storeClockOffsetGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "matrixcube",
+			Subsystem: "raftstore",
+			Name:      "store_clock_offset_seconds",
+			Help:      "Offset of the store's clock to the prophet leader's clock.",
+		})
+
+	watchdogStalledGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "matrixcube",
+			Subsystem: "raftstore",
+			Name:      "watchdog_stalled_tasks",
+			Help:      "Number of the stalled tasks of the components found by the watchdog.",
+		}, []string{"component"})
 )
 
 // SetRaftMsgQueueMetric set send raft message queue size
@@ -81,6 +99,13 @@ func SetRaftApplyResultQueueMetric(size int64) {
 	queueGauge.WithLabelValues("raft-apply-result").Set(float64(size))
 }
 
+// SetSplitCheckQueueMetric set the number of the split checks waiting and
+// running
+func SetSplitCheckQueueMetric(waiting, running int64) {
+	queueGauge.WithLabelValues("split-check-waiting").Set(float64(waiting))
+	queueGauge.WithLabelValues("split-check-running").Set(float64(running))
+}
+
 // SetRaftSnapQueueMetric set send raft snapshot queue size
 func SetRaftSnapQueueMetric(size int64) {
 	queueGauge.WithLabelValues("sent-snap").Set(float64(size))
@@ -91,6 +116,13 @@ func SetRaftProposalBatchMetric(size int64) {
 	batchGauge.WithLabelValues("proposal").Set(float64(size))
 }
 
+// SetShardsStartProgress set the count of the replicas started and to be started
+// on the current store while restarting
+func SetShardsStartProgress(started int, total int) {
+	shardCountGauge.WithLabelValues("starting").Set(float64(total - started))
+	shardCountGauge.WithLabelValues("started").Set(float64(started))
+}
+
 // SetShardsOnStore set the shards count  and leader shards count on the current store
 func SetShardsOnStore(leader int, count int) {
 	shardCountGauge.WithLabelValues("shards").Set(float64(count))
@@ -102,3 +134,13 @@ func SetStorageOnStore(total uint64, free uint64) {
 	storeStorageGauge.WithLabelValues("total").Set(float64(total))
 	storeStorageGauge.WithLabelValues("free").Set(float64(free))
 }
+
+// SetClockOffsetOnStore set the clock offset of the current store
+func SetClockOffsetOnStore(offset time.Duration) {
+	storeClockOffsetGauge.Set(offset.Seconds())
+}
+
+// SetWatchdogStalledTasks set the number of the stalled tasks of the component
+func SetWatchdogStalledTasks(component string, stalled int) {
+	watchdogStalledGauge.WithLabelValues(component).Set(float64(stalled))
+}
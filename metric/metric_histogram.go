@@ -38,6 +38,15 @@ var (
 			Buckets:   prometheus.ExponentialBuckets(0.0005, 2.0, 20),
 		})
 
+	raftReadyStageDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "matrixcube",
+			Subsystem: "raftstore",
+			Name:      "raft_ready_stage_duration_seconds",
+			Help:      "Bucketed histogram of the duration of the raft ready handling stages.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2.0, 20),
+		}, []string{"stage"})
+
 	raftLogApplyDurationHistogram = prometheus.NewHistogram(
 		prometheus.HistogramOpts{
 			Namespace: "matrixcube",
@@ -108,6 +117,12 @@ func ObserveRaftLogAppendDuration(start time.Time) {
 	raftLogAppendDurationHistogram.Observe(time.Since(start).Seconds())
 }
 
+// ObserveRaftReadyStageDuration observe the duration of a raft ready handling
+// stage, e.g. append, send and apply.
+func ObserveRaftReadyStageDuration(stage string, elapsed time.Duration) {
+	raftReadyStageDurationHistogram.WithLabelValues(stage).Observe(elapsed.Seconds())
+}
+
 // ObserveRaftLogApplyDuration observe seconds raft log apply
 func ObserveRaftLogApplyDuration(start time.Time) {
 	raftLogApplyDurationHistogram.Observe(time.Since(start).Seconds())
@@ -0,0 +1,167 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metric
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ShardMetricLevel the aggregation level of the shard metrics. The number of the
+// series of the shard metrics is decided by the level, exporting all the shards
+// individually explodes the cardinality when there are lots of shards.
+type ShardMetricLevel string
+
+const (
+	// ShardMetricStore all the shards on the store are aggregated
+	ShardMetricStore = ShardMetricLevel("store")
+	// ShardMetricGroup the shards are aggregated by shard group
+	ShardMetricGroup = ShardMetricLevel("group")
+	// ShardMetricTopN the top N busiest shards are exported individually, and the
+	// others are aggregated
+	ShardMetricTopN = ShardMetricLevel("top-n")
+	// ShardMetricShard all the shards are exported individually
+	ShardMetricShard = ShardMetricLevel("shard")
+
+	defaultShardTopN = 10
+)
+
+var (
+	shardStatsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "matrixcube",
+			Subsystem: "raftstore",
+			Name:      "shard_stats",
+			Help:      "Stats of the shards aggregated by scope.",
+		}, []string{"scope", "type"})
+
+	// shardDetailDeadline the unix nano time until which the shard metrics are
+	// exported individually
+	shardDetailDeadline int64
+)
+
+// ShardStats the stats of a shard
+type ShardStats struct {
+	ShardID         uint64
+	Group           uint64
+	WrittenBytes    uint64
+	WrittenKeys     uint64
+	ReadBytes       uint64
+	ReadKeys        uint64
+	ApproximateSize uint64
+	ApproximateKeys uint64
+	// InflightProposalBytes the bytes of the proposals not applied by the leader
+	InflightProposalBytes uint64
+	// ApplyLag the number of the log entries not applied by the quorum
+	ApplyLag uint64
+	// BusyRejectedWrites the number of the writes rejected by the flow control
+	// since the last heartbeat
+	BusyRejectedWrites uint64
+}
+
+func (s *ShardStats) add(v ShardStats) {
+	s.WrittenBytes += v.WrittenBytes
+	s.WrittenKeys += v.WrittenKeys
+	s.ReadBytes += v.ReadBytes
+	s.ReadKeys += v.ReadKeys
+	s.ApproximateSize += v.ApproximateSize
+	s.ApproximateKeys += v.ApproximateKeys
+	s.InflightProposalBytes += v.InflightProposalBytes
+	s.ApplyLag += v.ApplyLag
+	s.BusyRejectedWrites += v.BusyRejectedWrites
+}
+
+// EnableShardDetail exports all the shard metrics individually in the next
+// duration regardless of the configured level, used to debug a specified shard
+// temporarily.
+func EnableShardDetail(duration time.Duration) {
+	atomic.StoreInt64(&shardDetailDeadline, time.Now().Add(duration).UnixNano())
+}
+
+// DisableShardDetail restores the configured level of the shard metrics
+func DisableShardDetail() {
+	atomic.StoreInt64(&shardDetailDeadline, 0)
+}
+
+func getShardMetricLevel(cfg Cfg, now time.Time) ShardMetricLevel {
+	if now.UnixNano() < atomic.LoadInt64(&shardDetailDeadline) {
+		return ShardMetricShard
+	}
+	if cfg.ShardLevel == "" {
+		return ShardMetricStore
+	}
+	return cfg.ShardLevel
+}
+
+// SetShardStats set the stats of the shards on the current store, the stats are
+// aggregated by the level of the cfg.
+func SetShardStats(cfg Cfg, stats []ShardStats) {
+	shardStatsGauge.Reset()
+	for scope, v := range aggregateShardStats(getShardMetricLevel(cfg, time.Now()), cfg.ShardTopN, stats) {
+		shardStatsGauge.WithLabelValues(scope, "written-bytes").Set(float64(v.WrittenBytes))
+		shardStatsGauge.WithLabelValues(scope, "written-keys").Set(float64(v.WrittenKeys))
+		shardStatsGauge.WithLabelValues(scope, "read-bytes").Set(float64(v.ReadBytes))
+		shardStatsGauge.WithLabelValues(scope, "read-keys").Set(float64(v.ReadKeys))
+		shardStatsGauge.WithLabelValues(scope, "approximate-size").Set(float64(v.ApproximateSize))
+		shardStatsGauge.WithLabelValues(scope, "approximate-keys").Set(float64(v.ApproximateKeys))
+		shardStatsGauge.WithLabelValues(scope, "inflight-proposal-bytes").Set(float64(v.InflightProposalBytes))
+		shardStatsGauge.WithLabelValues(scope, "apply-lag").Set(float64(v.ApplyLag))
+		shardStatsGauge.WithLabelValues(scope, "busy-rejected-writes").Set(float64(v.BusyRejectedWrites))
+	}
+}
+
+func aggregateShardStats(level ShardMetricLevel, topN int, stats []ShardStats) map[string]ShardStats {
+	values := make(map[string]ShardStats)
+	add := func(scope string, v ShardStats) {
+		s := values[scope]
+		s.add(v)
+		values[scope] = s
+	}
+
+	switch level {
+	case ShardMetricGroup:
+		for _, v := range stats {
+			add(fmt.Sprintf("group-%d", v.Group), v)
+		}
+	case ShardMetricTopN:
+		if topN <= 0 {
+			topN = defaultShardTopN
+		}
+		sorted := make([]ShardStats, len(stats))
+		copy(sorted, stats)
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i].WrittenBytes+sorted[i].ReadBytes > sorted[j].WrittenBytes+sorted[j].ReadBytes
+		})
+		for i, v := range sorted {
+			if i < topN {
+				add(fmt.Sprintf("shard-%d", v.ShardID), v)
+			} else {
+				add("others", v)
+			}
+		}
+	case ShardMetricShard:
+		for _, v := range stats {
+			add(fmt.Sprintf("shard-%d", v.ShardID), v)
+		}
+	default:
+		for _, v := range stats {
+			add("store", v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateShardStats(t *testing.T) {
+	stats := []ShardStats{
+		{ShardID: 1, Group: 0, WrittenBytes: 1, ReadBytes: 1},
+		{ShardID: 2, Group: 1, WrittenBytes: 10, ReadBytes: 10},
+		{ShardID: 3, Group: 1, WrittenBytes: 5, ReadBytes: 100},
+	}
+
+	values := aggregateShardStats(ShardMetricStore, 0, stats)
+	assert.Equal(t, 1, len(values))
+	assert.Equal(t, uint64(16), values["store"].WrittenBytes)
+
+	values = aggregateShardStats(ShardMetricGroup, 0, stats)
+	assert.Equal(t, 2, len(values))
+	assert.Equal(t, uint64(1), values["group-0"].WrittenBytes)
+	assert.Equal(t, uint64(15), values["group-1"].WrittenBytes)
+
+	values = aggregateShardStats(ShardMetricTopN, 1, stats)
+	assert.Equal(t, 2, len(values))
+	assert.Equal(t, uint64(5), values["shard-3"].WrittenBytes)
+	assert.Equal(t, uint64(11), values["others"].WrittenBytes)
+
+	values = aggregateShardStats(ShardMetricShard, 0, stats)
+	assert.Equal(t, 3, len(values))
+}
+
+func TestShardDetailSwitch(t *testing.T) {
+	defer DisableShardDetail()
+
+	cfg := Cfg{ShardLevel: ShardMetricGroup}
+	now := time.Now()
+	assert.Equal(t, ShardMetricGroup, getShardMetricLevel(cfg, now))
+	assert.Equal(t, ShardMetricStore, getShardMetricLevel(Cfg{}, now))
+
+	EnableShardDetail(time.Minute)
+	assert.Equal(t, ShardMetricShard, getShardMetricLevel(cfg, now))
+	assert.Equal(t, ShardMetricGroup, getShardMetricLevel(cfg, now.Add(time.Minute*2)))
+
+	stats := []ShardStats{{ShardID: 1}, {ShardID: 2}}
+	SetShardStats(cfg, stats)
+	assert.Equal(t, 18, testutil.CollectAndCount(shardStatsGauge))
+
+	DisableShardDetail()
+	SetShardStats(cfg, stats)
+	assert.Equal(t, 9, testutil.CollectAndCount(shardStatsGauge))
+}
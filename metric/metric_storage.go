@@ -0,0 +1,203 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metric
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/storage/stats"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// SnapshotCreate the snapshot is created by the leader
+	SnapshotCreate = "create"
+	// SnapshotApply the received snapshot is applied
+	SnapshotApply = "apply"
+)
+
+var (
+	storageWriteBatchSizeHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "matrixcube",
+			Subsystem: "storage",
+			Name:      "write_batch_bytes",
+			Help:      "Bucketed histogram of the bytes written by per write batch.",
+			Buckets:   prometheus.ExponentialBuckets(64, 4.0, 12),
+		}, []string{"store"})
+
+	storageWriteBatchKeysHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "matrixcube",
+			Subsystem: "storage",
+			Name:      "write_batch_keys",
+			Help:      "Bucketed histogram of the keys written by per write batch.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2.0, 14),
+		}, []string{"store"})
+
+	storageSnapshotDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "matrixcube",
+			Subsystem: "storage",
+			Name:      "snapshot_duration_seconds",
+			Help:      "Bucketed histogram of the snapshot create and apply duration.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2.0, 18),
+		}, []string{"store", "scope", "op"})
+
+	storageSnapshotSizeHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "matrixcube",
+			Subsystem: "storage",
+			Name:      "snapshot_bytes",
+			Help:      "Bucketed histogram of the size of the created and applied snapshots.",
+			Buckets:   prometheus.ExponentialBuckets(1024.0, 4.0, 14),
+		}, []string{"store", "scope", "op"})
+
+	storageCompactionDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "matrixcube",
+			Subsystem: "storage",
+			Name:      "compaction_duration_seconds",
+			Help:      "Bucketed histogram of the offline compaction duration of the shards.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2.0, 20),
+		}, []string{"store", "result"})
+
+	splitCheckDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "matrixcube",
+			Subsystem: "raftstore",
+			Name:      "split_check_duration_seconds",
+			Help:      "Bucketed histogram of the split check duration.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2.0, 18),
+		}, []string{"store", "scope", "result"})
+)
+
+// ObserveStorageWriteBatch observe the keys and bytes written by a write batch
+func ObserveStorageWriteBatch(storeID uint64, keys, bytes uint64) {
+	store := storeLabel(storeID)
+	storageWriteBatchKeysHistogram.WithLabelValues(store).Observe(float64(keys))
+	storageWriteBatchSizeHistogram.WithLabelValues(store).Observe(float64(bytes))
+}
+
+// ObserveStorageSnapshot observe the duration and the size of the snapshot
+// created or applied, the shard is labeled by the scope of the cfg.
+func ObserveStorageSnapshot(cfg Cfg, storeID, shardID, group uint64, op string,
+	start time.Time, size int64) {
+	now := time.Now()
+	store, scope := storeLabel(storeID), shardScope(cfg, shardID, group, now)
+	storageSnapshotDurationHistogram.WithLabelValues(store, scope, op).Observe(now.Sub(start).Seconds())
+	storageSnapshotSizeHistogram.WithLabelValues(store, scope, op).Observe(float64(size))
+}
+
+// ObserveStorageCompaction observe the duration of the offline compaction of a
+// shard, the result is "succeed" or "failed".
+func ObserveStorageCompaction(storeID uint64, result string, start time.Time) {
+	storageCompactionDurationHistogram.WithLabelValues(storeLabel(storeID),
+		result).Observe(time.Since(start).Seconds())
+}
+
+// ObserveSplitCheckDuration observe the duration of a finished or canceled
+// split check, the shard is labeled by the scope of the cfg.
+func ObserveSplitCheckDuration(cfg Cfg, storeID, shardID, group uint64, result string,
+	start time.Time) {
+	now := time.Now()
+	splitCheckDurationHistogram.WithLabelValues(storeLabel(storeID),
+		shardScope(cfg, shardID, group, now), result).Observe(now.Sub(start).Seconds())
+}
+
+// shardScope returns the scope of a single event of the shard. The events are
+// not ranked, so they are aggregated into the store with ShardMetricTopN.
+func shardScope(cfg Cfg, shardID, group uint64, now time.Time) string {
+	switch getShardMetricLevel(cfg, now) {
+	case ShardMetricShard:
+		return fmt.Sprintf("shard-%d", shardID)
+	case ShardMetricGroup:
+		return fmt.Sprintf("group-%d", group)
+	default:
+		return "store"
+	}
+}
+
+func storeLabel(storeID uint64) string {
+	return fmt.Sprintf("%d", storeID)
+}
+
+// StorageCollector exports the stats.Stats of the data storages of a store, the
+// stats are read when the metrics are collected.
+type StorageCollector struct {
+	ops       *prometheus.Desc
+	gcPending *prometheus.Desc
+	statsFunc func() map[uint64]stats.Stats
+}
+
+var _ prometheus.Collector = (*StorageCollector)(nil)
+
+// NewStorageCollector returns a StorageCollector of the store, the statsFunc
+// returns the stats of the data storages by shard group.
+func NewStorageCollector(storeID uint64, statsFunc func() map[uint64]stats.Stats) *StorageCollector {
+	labels := prometheus.Labels{"store": storeLabel(storeID)}
+	return &StorageCollector{
+		ops: prometheus.NewDesc("matrixcube_storage_ops_total",
+			"Total operations of the data storages.",
+			[]string{"group", "type"}, labels),
+		gcPending: prometheus.NewDesc("matrixcube_storage_gc_pending_ranges",
+			"Number of the data ranges of the destroyed shards waiting to be removed.",
+			[]string{"group"}, labels),
+		statsFunc: statsFunc,
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *StorageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.ops
+	ch <- c.gcPending
+}
+
+// Collect implements prometheus.Collector
+func (c *StorageCollector) Collect(ch chan<- prometheus.Metric) {
+	for group, st := range c.statsFunc() {
+		g := fmt.Sprintf("%d", group)
+		for _, v := range []struct {
+			name  string
+			value uint64
+		}{
+			{"written-keys", st.WrittenKeys},
+			{"written-bytes", st.WrittenBytes},
+			{"read-keys", st.ReadKeys},
+			{"read-bytes", st.ReadBytes},
+			{"sync", st.SyncCount},
+			{"gc-removed-ranges", st.GCRemovedRanges},
+			{"gc-compacted-ranges", st.GCCompactedRanges},
+			{"block-cache-hits", st.BlockCacheHits},
+			{"block-cache-misses", st.BlockCacheMisses},
+		} {
+			ch <- prometheus.MustNewConstMetric(c.ops, prometheus.CounterValue,
+				float64(v.value), g, v.name)
+		}
+		ch <- prometheus.MustNewConstMetric(c.gcPending, prometheus.GaugeValue,
+			float64(st.GCPendingRanges), g)
+	}
+}
+
+// RegisterStorageCollector registers the collector, an error is returned if
+// the collector of the same store has been registered.
+func RegisterStorageCollector(c *StorageCollector) error {
+	return registry.Register(c)
+}
+
+// UnregisterStorageCollector unregisters the collector
+func UnregisterStorageCollector(c *StorageCollector) {
+	registry.Unregister(c)
+}
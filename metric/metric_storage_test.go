@@ -0,0 +1,81 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metric
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/storage/stats"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardScope(t *testing.T) {
+	defer DisableShardDetail()
+
+	now := time.Now()
+	assert.Equal(t, "store", shardScope(Cfg{}, 1, 2, now))
+	assert.Equal(t, "store", shardScope(Cfg{ShardLevel: ShardMetricTopN}, 1, 2, now))
+	assert.Equal(t, "group-2", shardScope(Cfg{ShardLevel: ShardMetricGroup}, 1, 2, now))
+	assert.Equal(t, "shard-1", shardScope(Cfg{ShardLevel: ShardMetricShard}, 1, 2, now))
+
+	EnableShardDetail(time.Minute)
+	assert.Equal(t, "shard-1", shardScope(Cfg{}, 1, 2, now))
+}
+
+func TestObserveStorageSnapshot(t *testing.T) {
+	storageSnapshotDurationHistogram.Reset()
+	storageSnapshotSizeHistogram.Reset()
+	defer storageSnapshotDurationHistogram.Reset()
+	defer storageSnapshotSizeHistogram.Reset()
+
+	cfg := Cfg{ShardLevel: ShardMetricGroup}
+	ObserveStorageSnapshot(cfg, 1, 10, 0, SnapshotCreate, time.Now(), 1024)
+	ObserveStorageSnapshot(cfg, 1, 11, 0, SnapshotCreate, time.Now(), 1024)
+	ObserveStorageSnapshot(cfg, 1, 12, 1, SnapshotApply, time.Now(), 1024)
+	assert.Equal(t, 2, testutil.CollectAndCount(storageSnapshotDurationHistogram))
+	assert.Equal(t, 2, testutil.CollectAndCount(storageSnapshotSizeHistogram))
+}
+
+func TestStorageCollector(t *testing.T) {
+	values := map[uint64]stats.Stats{
+		0: {WrittenKeys: 1, WrittenBytes: 2, ReadKeys: 3, ReadBytes: 4, GCPendingRanges: 5},
+		1: {WrittenKeys: 10},
+	}
+	c := NewStorageCollector(1, func() map[uint64]stats.Stats { return values })
+	// 9 counters and 1 gauge per group
+	assert.Equal(t, 20, testutil.CollectAndCount(c))
+
+	require.NoError(t, RegisterStorageCollector(c))
+	defer UnregisterStorageCollector(c)
+	assert.Error(t, RegisterStorageCollector(NewStorageCollector(1, func() map[uint64]stats.Stats { return nil })))
+
+	c2 := NewStorageCollector(2, func() map[uint64]stats.Stats { return values })
+	require.NoError(t, RegisterStorageCollector(c2))
+	defer UnregisterStorageCollector(c2)
+
+	expected := `
+# HELP matrixcube_storage_gc_pending_ranges Number of the data ranges of the destroyed shards waiting to be removed.
+# TYPE matrixcube_storage_gc_pending_ranges gauge
+matrixcube_storage_gc_pending_ranges{group="0",store="1"} 5
+matrixcube_storage_gc_pending_ranges{group="0",store="2"} 5
+matrixcube_storage_gc_pending_ranges{group="1",store="1"} 0
+matrixcube_storage_gc_pending_ranges{group="1",store="2"} 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"matrixcube_storage_gc_pending_ranges"))
+}
@@ -14,23 +14,50 @@
 package metric
 
 import (
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/client_golang/prometheus/push"
 	"go.uber.org/zap"
 )
 
-// StartPush start push metric
+// StartPush starts pushing the metrics to the prometheus pushgateway
 func StartPush(cfg Cfg, logger *zap.Logger) {
-	logger = log.Adjust(logger)
-	logger.Info("start push job metric",
+	_ = newPrometheusSink(cfg, logger).Start()
+}
+
+// Handler returns the http handler of the metrics for the prometheus scraping
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+type prometheusSink struct {
+	cfg      Cfg
+	logger   *zap.Logger
+	stopOnce sync.Once
+	stopC    chan struct{}
+}
+
+func newPrometheusSink(cfg Cfg, logger *zap.Logger) *prometheusSink {
+	return &prometheusSink{
+		cfg:    cfg,
+		logger: log.Adjust(logger),
+		stopC:  make(chan struct{}),
+	}
+}
+
+func (s *prometheusSink) Start() error {
+	cfg := s.cfg
+	s.logger.Info("start push job metric",
 		zap.String("job", cfg.Job),
 		zap.String("pushgateway", cfg.Addr),
 		zap.Int("interval", cfg.Interval))
 
 	if cfg.Interval == 0 || cfg.Addr == "" || cfg.Job == "" {
-		return
+		return nil
 	}
 
 	pusher := push.New(cfg.Addr, cfg.Job).
@@ -40,12 +67,24 @@ func StartPush(cfg Cfg, logger *zap.Logger) {
 		timer := time.NewTicker(time.Second * time.Duration(cfg.Interval))
 		defer timer.Stop()
 
-		for range timer.C {
-			if err := pusher.Push(); err != nil {
-				logger.Error("fail to push metric",
-					zap.String("pushgateway", cfg.Addr),
-					zap.Error(err))
+		for {
+			select {
+			case <-s.stopC:
+				return
+			case <-timer.C:
+				if err := pusher.Push(); err != nil {
+					s.logger.Error("fail to push metric",
+						zap.String("pushgateway", cfg.Addr),
+						zap.Error(err))
+				}
 			}
 		}
 	}()
+	return nil
+}
+
+func (s *prometheusSink) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopC)
+	})
 }
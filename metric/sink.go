@@ -0,0 +1,126 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metric
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+)
+
+// SinkType the type of the sink which the metrics are exported to
+type SinkType string
+
+const (
+	// PrometheusSink the metrics are pushed to the prometheus pushgateway, and
+	// can be scraped by the Handler
+	PrometheusSink = SinkType("prometheus")
+	// StatsdSink the metrics are sent to the statsd server periodically
+	StatsdSink = SinkType("statsd")
+	// ExpvarSink the metrics are published as an expvar variable, which is
+	// served by the `/debug/vars` of the http.DefaultServeMux
+	ExpvarSink = SinkType("expvar")
+)
+
+// Sink exports all the registered metrics to a monitoring system
+type Sink interface {
+	// Start starts exporting the metrics
+	Start() error
+	// Stop stops exporting the metrics
+	Stop()
+}
+
+// NewSink creates the sink of the metrics by the Cfg.Sink
+func NewSink(cfg Cfg, logger *zap.Logger) (Sink, error) {
+	switch cfg.sink() {
+	case PrometheusSink:
+		return newPrometheusSink(cfg, logger), nil
+	case StatsdSink:
+		return newStatsdSink(cfg, logger), nil
+	case ExpvarSink:
+		return newExpvarSink(), nil
+	default:
+		return nil, fmt.Errorf("unknown metric sink %s", cfg.Sink)
+	}
+}
+
+// sample a sample of the gathered metrics, the histograms and summaries are
+// sampled as the count and the sum.
+type sample struct {
+	name    string
+	labels  []*dto.LabelPair
+	counter bool
+	value   float64
+}
+
+// gather gathers the samples of all the registered metrics
+func gather() ([]sample, error) {
+	families, err := registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []sample
+	for _, f := range families {
+		name := f.GetName()
+		for _, m := range f.GetMetric() {
+			labels := m.GetLabel()
+			sort.Slice(labels, func(i, j int) bool {
+				return labels[i].GetName() < labels[j].GetName()
+			})
+			switch f.GetType() {
+			case dto.MetricType_COUNTER:
+				samples = append(samples, sample{name, labels, true, m.GetCounter().GetValue()})
+			case dto.MetricType_GAUGE:
+				samples = append(samples, sample{name, labels, false, m.GetGauge().GetValue()})
+			case dto.MetricType_UNTYPED:
+				samples = append(samples, sample{name, labels, false, m.GetUntyped().GetValue()})
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				samples = append(samples,
+					sample{name + "_count", labels, true, float64(h.GetSampleCount())},
+					sample{name + "_sum", labels, true, h.GetSampleSum()})
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				samples = append(samples,
+					sample{name + "_count", labels, true, float64(s.GetSampleCount())},
+					sample{name + "_sum", labels, true, s.GetSampleSum()})
+			}
+		}
+	}
+	return samples, nil
+}
+
+// key returns the key of the sample in the prometheus text format, e.g.
+// name{label1="value1",label2="value2"}
+func (s sample) key() string {
+	if len(s.labels) == 0 {
+		return s.name
+	}
+
+	var b strings.Builder
+	b.WriteString(s.name)
+	b.WriteByte('{')
+	for i, l := range s.labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%q", l.GetName(), l.GetValue())
+	}
+	b.WriteByte('}')
+	return b.String()
+}
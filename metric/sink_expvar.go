@@ -0,0 +1,60 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metric
+
+import (
+	"expvar"
+	"sync"
+)
+
+const (
+	// ExpvarName the name of the expvar variable of the metrics
+	ExpvarName = "matrixcube"
+)
+
+var (
+	publishExpvarOnce sync.Once
+)
+
+// expvarSink publishes the metrics as the ExpvarName expvar variable, the value
+// is a map from the metric key in the prometheus text format to the value,
+// gathered when the variable is read.
+type expvarSink struct{}
+
+func newExpvarSink() *expvarSink {
+	return &expvarSink{}
+}
+
+func (s *expvarSink) Start() error {
+	// the expvar variables can't be unpublished
+	publishExpvarOnce.Do(func() {
+		expvar.Publish(ExpvarName, expvar.Func(expvarValue))
+	})
+	return nil
+}
+
+func (s *expvarSink) Stop() {}
+
+func expvarValue() interface{} {
+	samples, err := gather()
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	values := make(map[string]float64, len(samples))
+	for _, v := range samples {
+		values[v.key()] = v.value
+	}
+	return values
+}
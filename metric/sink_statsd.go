@@ -0,0 +1,169 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metric
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultStatsdInterval = 10
+	// maxStatsdPacketSize keeps the udp packets unfragmented in the common
+	// ethernet networks
+	maxStatsdPacketSize = 1432
+)
+
+var (
+	statsdReplacer = strings.NewReplacer(".", "_", ":", "_", "|", "_", "@", "_", " ", "_")
+)
+
+// statsdSink sends the metrics to the statsd server over udp. The gauges are
+// sent as the statsd gauges, and the counters are sent as the statsd counters
+// with the increments since the last flush. The labels are appended to the
+// metric name as `.label.value`, since the tags are not supported by the plain
+// statsd protocol.
+type statsdSink struct {
+	cfg      Cfg
+	logger   *zap.Logger
+	conn     net.Conn
+	last     map[string]float64
+	stopOnce sync.Once
+	stopC    chan struct{}
+	doneC    chan struct{}
+}
+
+func newStatsdSink(cfg Cfg, logger *zap.Logger) *statsdSink {
+	return &statsdSink{
+		cfg:    cfg,
+		logger: log.Adjust(logger),
+		last:   make(map[string]float64),
+		stopC:  make(chan struct{}),
+		doneC:  make(chan struct{}),
+	}
+}
+
+func (s *statsdSink) Start() error {
+	interval := s.cfg.Interval
+	if interval == 0 {
+		interval = defaultStatsdInterval
+	}
+	s.logger.Info("start sending metric to statsd",
+		zap.String("statsd", s.cfg.Addr),
+		zap.Int("interval", interval))
+
+	conn, err := net.Dial("udp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+
+	go func() {
+		defer close(s.doneC)
+		timer := time.NewTicker(time.Second * time.Duration(interval))
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-s.stopC:
+				return
+			case <-timer.C:
+				if err := s.flush(); err != nil {
+					s.logger.Error("fail to send metric",
+						zap.String("statsd", s.cfg.Addr),
+						zap.Error(err))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *statsdSink) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopC)
+		if s.conn != nil {
+			<-s.doneC
+			_ = s.conn.Close()
+		}
+	})
+}
+
+// flush sends all the metrics to the statsd server
+func (s *statsdSink) flush() error {
+	samples, err := gather()
+	if err != nil {
+		return err
+	}
+
+	var packet bytes.Buffer
+	for _, v := range samples {
+		line := s.format(v)
+		if line == "" {
+			continue
+		}
+		if packet.Len() > 0 && packet.Len()+len(line)+1 > maxStatsdPacketSize {
+			if _, err := s.conn.Write(packet.Bytes()); err != nil {
+				return err
+			}
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if packet.Len() > 0 {
+		if _, err := s.conn.Write(packet.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// format returns the statsd line of the sample, empty if the counter is not
+// increased since the last flush.
+func (s *statsdSink) format(v sample) string {
+	var b strings.Builder
+	if s.cfg.Job != "" {
+		b.WriteString(s.cfg.Job)
+		b.WriteByte('.')
+	}
+	b.WriteString(v.name)
+	for _, l := range v.labels {
+		b.WriteByte('.')
+		b.WriteString(statsdReplacer.Replace(l.GetName()))
+		b.WriteByte('.')
+		b.WriteString(statsdReplacer.Replace(l.GetValue()))
+	}
+	name := b.String()
+
+	if !v.counter {
+		return name + ":" + strconv.FormatFloat(v.value, 'f', -1, 64) + "|g"
+	}
+
+	delta := v.value - s.last[name]
+	s.last[name] = v.value
+	if delta <= 0 {
+		return ""
+	}
+	return name + ":" + strconv.FormatFloat(delta, 'f', -1, 64) + "|c"
+}
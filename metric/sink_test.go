@@ -0,0 +1,121 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metric
+
+import (
+	"encoding/json"
+	"expvar"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testSinkCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "matrixcube",
+			Subsystem: "test",
+			Name:      "sink_total",
+			Help:      "Counter for the sink tests.",
+		}, []string{"type"})
+
+	testSinkGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "matrixcube",
+			Subsystem: "test",
+			Name:      "sink_value",
+			Help:      "Gauge for the sink tests.",
+		})
+)
+
+func init() {
+	MustRegister(testSinkCounter, testSinkGauge)
+}
+
+func TestNewSink(t *testing.T) {
+	_, err := NewSink(Cfg{Sink: "unknown"}, nil)
+	assert.Error(t, err)
+
+	s, err := NewSink(Cfg{}, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &prometheusSink{}, s)
+	require.NoError(t, s.Start())
+	s.Stop()
+}
+
+func TestStatsdSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	receive := func() []string {
+		buf := make([]byte, 64*1024)
+		var lines []string
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return lines
+			}
+			lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+		}
+	}
+
+	s, err := NewSink(Cfg{Sink: StatsdSink, Addr: conn.LocalAddr().String(), Job: "cube.test"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, s.Start())
+	defer s.Stop()
+	sink := s.(*statsdSink)
+
+	testSinkCounter.WithLabelValues("a.b").Add(3)
+	testSinkGauge.Set(1.5)
+	require.NoError(t, sink.flush())
+	lines := receive()
+	assert.Contains(t, lines, "cube.test.matrixcube_test_sink_total.type.a_b:3|c")
+	assert.Contains(t, lines, "cube.test.matrixcube_test_sink_value:1.5|g")
+
+	// the counters are sent with the increments
+	testSinkCounter.WithLabelValues("a.b").Add(2)
+	require.NoError(t, sink.flush())
+	lines = receive()
+	assert.Contains(t, lines, "cube.test.matrixcube_test_sink_total.type.a_b:2|c")
+	assert.Contains(t, lines, "cube.test.matrixcube_test_sink_value:1.5|g")
+
+	require.NoError(t, sink.flush())
+	for _, line := range receive() {
+		assert.False(t, strings.HasPrefix(line, "cube.test.matrixcube_test_sink_total"))
+	}
+}
+
+func TestExpvarSink(t *testing.T) {
+	s, err := NewSink(Cfg{Sink: ExpvarSink}, nil)
+	require.NoError(t, err)
+	require.NoError(t, s.Start())
+	// started again by another store in the same process
+	require.NoError(t, s.Start())
+	defer s.Stop()
+
+	testSinkCounter.WithLabelValues("expvar").Add(1)
+	testSinkGauge.Set(2)
+
+	values := make(map[string]float64)
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get(ExpvarName).String()), &values))
+	assert.Equal(t, float64(1), values[`matrixcube_test_sink_total{type="expvar"}`])
+	assert.Equal(t, float64(2), values["matrixcube_test_sink_value"])
+}
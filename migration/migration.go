@@ -0,0 +1,278 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fagongzi/util/protoc"
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/keys"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+)
+
+var (
+	// ErrDuplicateMigration the version or the name of the migration is already
+	// registered
+	ErrDuplicateMigration = errors.New("duplicate migration")
+	// ErrInvalidMigration the migration is malformed
+	ErrInvalidMigration = errors.New("invalid migration")
+	// ErrUnknownSchema the on-disk data is migrated by a newer version of the
+	// store, it can not be read by the current version, i.e. the store can not
+	// be rolled back once a migration is started.
+	ErrUnknownSchema = errors.New("unknown store schema")
+)
+
+// Migration migrates the on-disk data of the store, e.g. the key layout or the
+// format of the metadata, from the previous version to the Version.
+type Migration struct {
+	// Version the schema version after migrated, the migrations are run in the
+	// order of the versions
+	Version uint64
+	// Name the name of the migration shown by the logs
+	Name string
+	// Run migrates the data. It's resumed from the `Context.Checkpoint` if the
+	// store is restarted in the middle, so the data migrated after the last
+	// saved checkpoint must be migrated idempotently.
+	Run func(ctx *Context) error
+}
+
+// Registry the registered migrations of the store
+type Registry struct {
+	mu         sync.RWMutex
+	migrations map[uint64]Migration
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{migrations: make(map[uint64]Migration)}
+}
+
+// Register registers the migrations, ErrDuplicateMigration is returned if the
+// version or the name is already registered, and none of the migrations are
+// registered if any of them is rejected.
+func (r *Registry) Register(migrations ...Migration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions := make(map[uint64]struct{}, len(migrations))
+	names := make(map[string]struct{}, len(r.migrations)+len(migrations))
+	for _, m := range r.migrations {
+		names[m.Name] = struct{}{}
+	}
+	for _, m := range migrations {
+		if m.Version == 0 || m.Name == "" || m.Run == nil {
+			return fmt.Errorf("%w: %d %q", ErrInvalidMigration, m.Version, m.Name)
+		}
+		_, ok := r.migrations[m.Version]
+		if _, dup := versions[m.Version]; ok || dup {
+			return fmt.Errorf("%w: version %d", ErrDuplicateMigration, m.Version)
+		}
+		if _, dup := names[m.Name]; dup {
+			return fmt.Errorf("%w: name %q", ErrDuplicateMigration, m.Name)
+		}
+		versions[m.Version] = struct{}{}
+		names[m.Name] = struct{}{}
+	}
+
+	for _, m := range migrations {
+		r.migrations[m.Version] = m
+	}
+	return nil
+}
+
+// MustRegister is similar to Register, but panics if failed
+func (r *Registry) MustRegister(migrations ...Migration) {
+	if err := r.Register(migrations...); err != nil {
+		panic(err)
+	}
+}
+
+// Migrations returns all the registered migrations ordered by the versions
+func (r *Registry) Migrations() []Migration {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	migrations := make([]Migration, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations
+}
+
+// Latest returns the schema version after all the migrations are run, 0 if no
+// migration is registered.
+func (r *Registry) Latest() uint64 {
+	migrations := r.Migrations()
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// Context the context of the running migration
+type Context struct {
+	// KVStorage the storage of the store metadata, e.g. the raft logs and the
+	// shard metadata
+	KVStorage storage.KVStorage
+	// DataStorage returns the data storage of the shard group
+	DataStorage func(group uint64) storage.DataStorage
+	// Logger the logger of the migration
+	Logger *zap.Logger
+
+	schema metapb.StoreSchema
+}
+
+// Checkpoint returns the checkpoint saved by the migration before the store is
+// restarted, nil if the migration is not resumed.
+func (c *Context) Checkpoint() []byte {
+	return c.schema.Checkpoint
+}
+
+// SaveCheckpoint saves the progress of the migration, the migration is resumed
+// from the checkpoint if the store is restarted. The data migrated must be
+// durable before the checkpoint is saved.
+func (c *Context) SaveCheckpoint(checkpoint []byte) error {
+	schema := c.schema
+	schema.Checkpoint = checkpoint
+	if err := saveSchema(c.KVStorage, schema); err != nil {
+		return err
+	}
+	c.schema = schema
+	c.Logger.Info("migration checkpoint saved",
+		log.HexField("checkpoint", checkpoint))
+	return nil
+}
+
+// Options the options of running the migrations
+type Options struct {
+	// KVStorage the storage of the store metadata, the schema version is saved
+	// in it
+	KVStorage storage.KVStorage
+	// DataStorage returns the data storage of the shard group
+	DataStorage func(group uint64) storage.DataStorage
+	// Logger the logger
+	Logger *zap.Logger
+}
+
+// Run runs the pending migrations of the registry in the order of the versions
+// at the startup of the store, before any replica is started. The schema
+// version of a new store is set to the latest version without running any
+// migration. ErrUnknownSchema is returned if the data is migrated by a newer
+// version of the store, so the data is never read by the version not aware of
+// the layout.
+func Run(registry *Registry, opts Options) error {
+	logger := log.Adjust(opts.Logger).Named("migration")
+	latest := registry.Latest()
+	schema, ok, err := GetSchema(opts.KVStorage)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fresh, err := isNewStore(opts.KVStorage)
+		if err != nil {
+			return err
+		}
+		if fresh {
+			logger.Info("store schema created",
+				zap.Uint64("version", latest))
+			return saveSchema(opts.KVStorage, metapb.StoreSchema{Version: latest})
+		}
+	}
+
+	migrations := registry.Migrations()
+	idx := sort.Search(len(migrations), func(i int) bool {
+		return migrations[i].Version > schema.Version
+	})
+	pending := migrations[idx:]
+	if schema.Version > latest ||
+		(schema.Migrating > 0 && (len(pending) == 0 || pending[0].Version != schema.Migrating)) {
+		return fmt.Errorf("%w: version %d, migrating %d, latest known %d",
+			ErrUnknownSchema, schema.Version, schema.Migrating, latest)
+	}
+
+	for _, m := range pending {
+		ctx := &Context{
+			KVStorage:   opts.KVStorage,
+			DataStorage: opts.DataStorage,
+			Logger: logger.With(zap.Uint64("version", m.Version),
+				zap.String("name", m.Name)),
+		}
+		if schema.Migrating == m.Version {
+			ctx.Logger.Info("migration resumed",
+				log.HexField("checkpoint", schema.Checkpoint))
+		} else {
+			schema.Migrating = m.Version
+			schema.Checkpoint = nil
+			if err := saveSchema(opts.KVStorage, schema); err != nil {
+				return err
+			}
+			ctx.Logger.Info("migration started",
+				zap.Uint64("from", schema.Version))
+		}
+		ctx.schema = schema
+
+		start := time.Now()
+		if err := m.Run(ctx); err != nil {
+			return fmt.Errorf("migration %d %q failed: %w", m.Version, m.Name, err)
+		}
+		schema = metapb.StoreSchema{Version: m.Version}
+		if err := saveSchema(opts.KVStorage, schema); err != nil {
+			return err
+		}
+		ctx.Logger.Info("migration completed",
+			zap.Duration("cost", time.Since(start)))
+	}
+	return nil
+}
+
+// GetSchema returns the schema of the store, false if it's not saved.
+func GetSchema(kv storage.KVStorage) (metapb.StoreSchema, bool, error) {
+	schema := metapb.StoreSchema{}
+	data, err := kv.Get(keys.GetStoreSchemaKey())
+	if err != nil {
+		return schema, false, err
+	}
+	if len(data) == 0 {
+		return schema, false, nil
+	}
+	protoc.MustUnmarshal(&schema, data)
+	return schema, true, nil
+}
+
+func saveSchema(kv storage.KVStorage, schema metapb.StoreSchema) error {
+	return kv.Set(keys.GetStoreSchemaKey(), protoc.MustMarshal(&schema), true)
+}
+
+// isNewStore returns true if the store is never bootstrapped on the data
+// directory
+func isNewStore(kv storage.KVStorage) (bool, error) {
+	data, err := kv.Get(keys.GetStoreIdentKey())
+	if err != nil {
+		return false, err
+	}
+	return len(data) == 0, nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/matrixorigin/matrixcube/keys"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+)
+
+func noop(*Context) error { return nil }
+
+func newBootstrappedStorage(t *testing.T) storage.KVStorage {
+	kv := mem.NewStorage()
+	require.NoError(t, kv.Set(keys.GetStoreIdentKey(), []byte("ident"), true))
+	return kv
+}
+
+func mustGetSchema(t *testing.T, kv storage.KVStorage) metapb.StoreSchema {
+	schema, ok, err := GetSchema(kv)
+	require.NoError(t, err)
+	require.True(t, ok)
+	return schema
+}
+
+func TestRegister(t *testing.T) {
+	r := NewRegistry()
+	assert.Equal(t, uint64(0), r.Latest())
+	require.NoError(t, r.Register(Migration{Version: 2, Name: "m2", Run: noop},
+		Migration{Version: 1, Name: "m1", Run: noop}))
+	assert.Equal(t, uint64(2), r.Latest())
+
+	assert.True(t, errors.Is(r.Register(Migration{Version: 3, Name: "m3"}), ErrInvalidMigration))
+	assert.True(t, errors.Is(r.Register(Migration{Version: 2, Name: "m3", Run: noop}), ErrDuplicateMigration))
+	assert.True(t, errors.Is(r.Register(Migration{Version: 3, Name: "m1", Run: noop}), ErrDuplicateMigration))
+	// none is registered if any is rejected
+	assert.True(t, errors.Is(r.Register(Migration{Version: 3, Name: "m3", Run: noop},
+		Migration{Version: 3, Name: "m4", Run: noop}), ErrDuplicateMigration))
+	migrations := r.Migrations()
+	require.Equal(t, 2, len(migrations))
+	assert.Equal(t, "m1", migrations[0].Name)
+	assert.Equal(t, "m2", migrations[1].Name)
+}
+
+func TestRunOnNewStore(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(Migration{Version: 1, Name: "m1", Run: func(*Context) error {
+		return errors.New("should not run")
+	}})
+	kv := mem.NewStorage()
+	require.NoError(t, Run(r, Options{KVStorage: kv}))
+	assert.Equal(t, metapb.StoreSchema{Version: 1}, mustGetSchema(t, kv))
+}
+
+func TestRunMigrations(t *testing.T) {
+	var versions []uint64
+	newMigration := func(version uint64, name string) Migration {
+		return Migration{Version: version, Name: name, Run: func(ctx *Context) error {
+			assert.Nil(t, ctx.Checkpoint())
+			versions = append(versions, version)
+			return nil
+		}}
+	}
+	r := NewRegistry()
+	r.MustRegister(newMigration(3, "m3"), newMigration(1, "m1"))
+	kv := newBootstrappedStorage(t)
+	require.NoError(t, Run(r, Options{KVStorage: kv}))
+	assert.Equal(t, []uint64{1, 3}, versions)
+	assert.Equal(t, metapb.StoreSchema{Version: 3}, mustGetSchema(t, kv))
+
+	// only the new migrations are run
+	r.MustRegister(newMigration(4, "m4"))
+	require.NoError(t, Run(r, Options{KVStorage: kv}))
+	assert.Equal(t, []uint64{1, 3, 4}, versions)
+	require.NoError(t, Run(r, Options{KVStorage: kv}))
+	assert.Equal(t, []uint64{1, 3, 4}, versions)
+}
+
+func TestResumeMigration(t *testing.T) {
+	failed := errors.New("crashed")
+	var checkpoints [][]byte
+	r := NewRegistry()
+	r.MustRegister(Migration{Version: 1, Name: "m1", Run: func(ctx *Context) error {
+		checkpoints = append(checkpoints, ctx.Checkpoint())
+		if ctx.Checkpoint() == nil {
+			require.NoError(t, ctx.SaveCheckpoint([]byte("k1")))
+			return failed
+		}
+		return nil
+	}})
+	kv := newBootstrappedStorage(t)
+	assert.True(t, errors.Is(Run(r, Options{KVStorage: kv}), failed))
+	assert.Equal(t, metapb.StoreSchema{Migrating: 1, Checkpoint: []byte("k1")}, mustGetSchema(t, kv))
+
+	require.NoError(t, Run(r, Options{KVStorage: kv}))
+	assert.Equal(t, [][]byte{nil, []byte("k1")}, checkpoints)
+	assert.Equal(t, metapb.StoreSchema{Version: 1}, mustGetSchema(t, kv))
+}
+
+func TestRunRejectsUnknownSchema(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(Migration{Version: 1, Name: "m1", Run: noop})
+
+	kv := newBootstrappedStorage(t)
+	require.NoError(t, saveSchema(kv, metapb.StoreSchema{Version: 2}))
+	assert.True(t, errors.Is(Run(r, Options{KVStorage: kv}), ErrUnknownSchema))
+	assert.True(t, errors.Is(Run(nil, Options{KVStorage: kv}), ErrUnknownSchema))
+
+	// the migration in progress is unknown
+	require.NoError(t, saveSchema(kv, metapb.StoreSchema{Version: 1, Migrating: 2}))
+	assert.True(t, errors.Is(Run(r, Options{KVStorage: kv}), ErrUnknownSchema))
+	assert.Equal(t, metapb.StoreSchema{Version: 1, Migrating: 2}, mustGetSchema(t, kv))
+}
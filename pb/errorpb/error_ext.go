@@ -23,5 +23,6 @@ func Retryable(err Error) bool {
 	return HasError(err) &&
 		err.RaftEntryTooLarge == nil && // can not retry
 		err.ShardUnavailable == nil &&
-		err.LeaseMismatch == nil
+		err.LeaseMismatch == nil &&
+		err.EpochNotMatch == nil
 }
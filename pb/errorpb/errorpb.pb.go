@@ -336,8 +336,14 @@ func (m *StaleEpoch) GetNewShards() []metapb.Shard {
 	return nil
 }
 
-// ServerIsBusy the server is busy
+// ServerIsBusy the server is busy, the load hints help the client to decide
+// when and where to retry
 type ServerIsBusy struct {
+	// queueDepth the number of requests waiting in the queue of the replica
+	QueueDepth uint64 `protobuf:"varint,1,opt,name=queueDepth,proto3" json:"queueDepth,omitempty"`
+	// estimatedWaitMS the estimated time in milliseconds that a request waits
+	// in the queue before it is handled
+	EstimatedWaitMS      uint64   `protobuf:"varint,2,opt,name=estimatedWaitMS,proto3" json:"estimatedWaitMS,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -376,6 +382,20 @@ func (m *ServerIsBusy) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_ServerIsBusy proto.InternalMessageInfo
 
+func (m *ServerIsBusy) GetQueueDepth() uint64 {
+	if m != nil {
+		return m.QueueDepth
+	}
+	return 0
+}
+
+func (m *ServerIsBusy) GetEstimatedWaitMS() uint64 {
+	if m != nil {
+		return m.EstimatedWaitMS
+	}
+	return 0
+}
+
 // StaleCommand the command is stale, need to retry
 type StaleCommand struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -647,6 +667,8 @@ type Error struct {
 	LeaseMissing         *LeaseMissing      `protobuf:"bytes,11,opt,name=leaseMissing,proto3" json:"leaseMissing,omitempty"`
 	LeaseMismatch        *LeaseMismatch     `protobuf:"bytes,12,opt,name=leaseMismatch,proto3" json:"leaseMismatch,omitempty"`
 	LeaseReadNotReady    *LeaseReadNotReady `protobuf:"bytes,13,opt,name=leaseReadNotReady,proto3" json:"leaseReadNotReady,omitempty"`
+	ShardBusy            *ShardBusy         `protobuf:"bytes,14,opt,name=shardBusy,proto3" json:"shardBusy,omitempty"`
+	EpochNotMatch        *EpochNotMatch     `protobuf:"bytes,15,opt,name=epochNotMatch,proto3" json:"epochNotMatch,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
 	XXX_unrecognized     []byte             `json:"-"`
 	XXX_sizecache        int32              `json:"-"`
@@ -776,6 +798,159 @@ func (m *Error) GetLeaseReadNotReady() *LeaseReadNotReady {
 	return nil
 }
 
+func (m *Error) GetShardBusy() *ShardBusy {
+	if m != nil {
+		return m.ShardBusy
+	}
+	return nil
+}
+
+func (m *Error) GetEpochNotMatch() *EpochNotMatch {
+	if m != nil {
+		return m.EpochNotMatch
+	}
+	return nil
+}
+
+// ShardBusy the leader rejects the writes because its proposals are not
+// applied in time, the client should backoff before retry
+type ShardBusy struct {
+	ShardID              uint64   `protobuf:"varint,1,opt,name=shardID,proto3" json:"shardID,omitempty"`
+	InflightBytes        uint64   `protobuf:"varint,2,opt,name=inflightBytes,proto3" json:"inflightBytes,omitempty"`
+	ApplyLag             uint64   `protobuf:"varint,3,opt,name=applyLag,proto3" json:"applyLag,omitempty"`
+	RetryAfterMS         uint64   `protobuf:"varint,4,opt,name=retryAfterMS,proto3" json:"retryAfterMS,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ShardBusy) Reset()         { *m = ShardBusy{} }
+func (m *ShardBusy) String() string { return proto.CompactTextString(m) }
+func (*ShardBusy) ProtoMessage()    {}
+func (*ShardBusy) Descriptor() ([]byte, []int) {
+	return fileDescriptor_390aa86757fd1154, []int{13}
+}
+func (m *ShardBusy) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ShardBusy) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ShardBusy.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ShardBusy) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ShardBusy.Merge(m, src)
+}
+func (m *ShardBusy) XXX_Size() int {
+	return m.Size()
+}
+func (m *ShardBusy) XXX_DiscardUnknown() {
+	xxx_messageInfo_ShardBusy.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ShardBusy proto.InternalMessageInfo
+
+func (m *ShardBusy) GetShardID() uint64 {
+	if m != nil {
+		return m.ShardID
+	}
+	return 0
+}
+
+func (m *ShardBusy) GetInflightBytes() uint64 {
+	if m != nil {
+		return m.InflightBytes
+	}
+	return 0
+}
+
+func (m *ShardBusy) GetApplyLag() uint64 {
+	if m != nil {
+		return m.ApplyLag
+	}
+	return 0
+}
+
+func (m *ShardBusy) GetRetryAfterMS() uint64 {
+	if m != nil {
+		return m.RetryAfterMS
+	}
+	return 0
+}
+
+// EpochNotMatch the write is rejected because the epoch of the shard is not
+// the epoch expected by the request, i.e. the shard is split, merged or its
+// replicas are changed since the request was routed by the client
+type EpochNotMatch struct {
+	ShardID              uint64            `protobuf:"varint,1,opt,name=shardID,proto3" json:"shardID,omitempty"`
+	Expected             metapb.ShardEpoch `protobuf:"bytes,2,opt,name=expected,proto3" json:"expected"`
+	Current              metapb.ShardEpoch `protobuf:"bytes,3,opt,name=current,proto3" json:"current"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *EpochNotMatch) Reset()         { *m = EpochNotMatch{} }
+func (m *EpochNotMatch) String() string { return proto.CompactTextString(m) }
+func (*EpochNotMatch) ProtoMessage()    {}
+func (*EpochNotMatch) Descriptor() ([]byte, []int) {
+	return fileDescriptor_390aa86757fd1154, []int{14}
+}
+func (m *EpochNotMatch) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *EpochNotMatch) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_EpochNotMatch.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *EpochNotMatch) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EpochNotMatch.Merge(m, src)
+}
+func (m *EpochNotMatch) XXX_Size() int {
+	return m.Size()
+}
+func (m *EpochNotMatch) XXX_DiscardUnknown() {
+	xxx_messageInfo_EpochNotMatch.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EpochNotMatch proto.InternalMessageInfo
+
+func (m *EpochNotMatch) GetShardID() uint64 {
+	if m != nil {
+		return m.ShardID
+	}
+	return 0
+}
+
+func (m *EpochNotMatch) GetExpected() metapb.ShardEpoch {
+	if m != nil {
+		return m.Expected
+	}
+	return metapb.ShardEpoch{}
+}
+
+func (m *EpochNotMatch) GetCurrent() metapb.ShardEpoch {
+	if m != nil {
+		return m.Current
+	}
+	return metapb.ShardEpoch{}
+}
+
 func init() {
 	proto.RegisterType((*NotLeader)(nil), "errorpb.NotLeader")
 	proto.RegisterType((*StoreMismatch)(nil), "errorpb.StoreMismatch")
@@ -790,55 +965,67 @@ func init() {
 	proto.RegisterType((*LeaseMismatch)(nil), "errorpb.LeaseMismatch")
 	proto.RegisterType((*LeaseReadNotReady)(nil), "errorpb.LeaseReadNotReady")
 	proto.RegisterType((*Error)(nil), "errorpb.Error")
+	proto.RegisterType((*ShardBusy)(nil), "errorpb.ShardBusy")
+	proto.RegisterType((*EpochNotMatch)(nil), "errorpb.EpochNotMatch")
 }
 
 func init() { proto.RegisterFile("errorpb.proto", fileDescriptor_390aa86757fd1154) }
 
 var fileDescriptor_390aa86757fd1154 = []byte{
-	// 673 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x54, 0xdf, 0x4e, 0xdb, 0x3e,
-	0x18, 0x25, 0x14, 0xca, 0xaf, 0x1f, 0xcd, 0x8f, 0x12, 0xb6, 0xc9, 0x43, 0x53, 0x87, 0x72, 0xc5,
-	0xa4, 0x41, 0x37, 0x90, 0x26, 0x21, 0x4d, 0x9b, 0xc4, 0x56, 0x04, 0x82, 0x71, 0xe1, 0xb2, 0x07,
-	0x70, 0x1a, 0x93, 0x46, 0x4b, 0xe2, 0xce, 0x76, 0xd9, 0xba, 0x67, 0xd8, 0x4b, 0xec, 0x6d, 0xb8,
-	0xe4, 0x09, 0xa6, 0x8d, 0x27, 0x99, 0xec, 0xfc, 0xa9, 0x9d, 0x8a, 0x5e, 0x25, 0x9f, 0xbf, 0x73,
-	0x8e, 0x9d, 0xe3, 0xf3, 0x05, 0x5c, 0xca, 0x39, 0xe3, 0xe3, 0x60, 0x7f, 0xcc, 0x99, 0x64, 0xde,
-	0x5a, 0x51, 0x6e, 0x1f, 0x45, 0xb1, 0x1c, 0x4d, 0x82, 0xfd, 0x21, 0x4b, 0x7b, 0x29, 0x91, 0x3c,
-	0xfe, 0xce, 0x78, 0x1c, 0xc5, 0x59, 0x51, 0x0c, 0x27, 0x01, 0xed, 0x8d, 0x83, 0x5e, 0x4a, 0x25,
-	0xa9, 0x1e, 0xb9, 0xc6, 0xf6, 0x9e, 0x41, 0x8d, 0x58, 0xc4, 0x7a, 0x7a, 0x39, 0x98, 0x5c, 0xeb,
-	0x4a, 0x17, 0xfa, 0x2d, 0x87, 0xfb, 0x57, 0xd0, 0xba, 0x64, 0xf2, 0x82, 0x92, 0x90, 0x72, 0x0f,
-	0xc1, 0x9a, 0x18, 0x11, 0x1e, 0x9e, 0x7d, 0x44, 0xce, 0x8e, 0xb3, 0xbb, 0x82, 0xcb, 0xd2, 0xdb,
-	0x83, 0x66, 0xa2, 0x31, 0x68, 0x79, 0xc7, 0xd9, 0x5d, 0x3f, 0xd8, 0xd8, 0x2f, 0x36, 0xc5, 0x74,
-	0x9c, 0xc4, 0x43, 0x72, 0xbc, 0x72, 0xfb, 0xfb, 0xf9, 0x12, 0x2e, 0x40, 0xfe, 0x06, 0xb8, 0x03,
-	0xc9, 0x38, 0xfd, 0x14, 0x8b, 0x94, 0xc8, 0xe1, 0xc8, 0x7f, 0x09, 0x9d, 0x81, 0x92, 0xfa, 0x9c,
-	0x91, 0x1b, 0x12, 0x27, 0x24, 0x48, 0xe8, 0xc3, 0xbb, 0xf9, 0x2f, 0xc0, 0xd5, 0xe8, 0x4b, 0x26,
-	0x4f, 0xd8, 0x24, 0x0b, 0x17, 0x40, 0x87, 0xe0, 0x9e, 0xd3, 0xe9, 0x25, 0x93, 0x67, 0x99, 0xa6,
-	0x78, 0x1d, 0x68, 0x7c, 0xa1, 0x53, 0x0d, 0x6b, 0x63, 0xf5, 0x6a, 0x92, 0x97, 0xed, 0xaf, 0x7a,
-	0x04, 0xab, 0x42, 0x12, 0x2e, 0x51, 0x43, 0xa3, 0xf3, 0x42, 0x29, 0xd0, 0x2c, 0x44, 0x2b, 0xb9,
-	0x02, 0xcd, 0x42, 0xff, 0x3d, 0xc0, 0x40, 0x92, 0x84, 0xf6, 0xc7, 0x6c, 0x38, 0xf2, 0x5e, 0x43,
-	0x2b, 0xa3, 0xdf, 0xf4, 0x6e, 0x02, 0x39, 0x3b, 0x8d, 0xdd, 0xf5, 0x03, 0xb7, 0xb4, 0x43, 0xaf,
-	0x16, 0x66, 0xcc, 0x50, 0xfe, 0xff, 0xd0, 0x1e, 0x50, 0x7e, 0x43, 0xf9, 0x99, 0x38, 0x9e, 0x88,
-	0xa9, 0xae, 0x95, 0xe0, 0x07, 0x96, 0xa6, 0x24, 0x0b, 0xfd, 0x73, 0xd8, 0xc4, 0xe4, 0x5a, 0xf6,
-	0x33, 0xc9, 0xa7, 0x57, 0x8c, 0x5d, 0x10, 0x1e, 0x2d, 0xf0, 0xc7, 0x7b, 0x06, 0x2d, 0xaa, 0xa0,
-	0x83, 0xf8, 0x07, 0x2d, 0xbe, 0x69, 0xb6, 0xe0, 0x9f, 0x40, 0xfb, 0x82, 0x12, 0xa1, 0xcc, 0x17,
-	0x71, 0x16, 0x2d, 0xd6, 0xe1, 0xf9, 0xfd, 0x55, 0xde, 0xcc, 0x16, 0xfc, 0x5f, 0x0e, 0xb8, 0xa5,
-	0x90, 0xbe, 0xc5, 0x05, 0x4a, 0x6f, 0xa0, 0xcd, 0xe9, 0xd7, 0x09, 0x15, 0x52, 0x33, 0x8a, 0x94,
-	0x78, 0xa5, 0x2d, 0xda, 0x38, 0xdd, 0xc1, 0x16, 0xce, 0x7b, 0x07, 0x9d, 0x62, 0xc3, 0x53, 0x9a,
-	0x84, 0x39, 0xb7, 0xf1, 0x20, 0x77, 0x0e, 0xeb, 0x6f, 0xc1, 0x66, 0xde, 0xa2, 0x44, 0xa5, 0x45,
-	0x3d, 0xa6, 0xfe, 0xcf, 0x26, 0xac, 0xf6, 0xd5, 0x24, 0xa9, 0x03, 0xa7, 0x54, 0x08, 0x12, 0x51,
-	0x7d, 0xe0, 0x16, 0x2e, 0x4b, 0xef, 0x15, 0xb4, 0xb2, 0x32, 0xf7, 0xd5, 0x69, 0xcb, 0x69, 0xac,
-	0x26, 0x02, 0xcf, 0x40, 0xde, 0x5b, 0x70, 0x85, 0x19, 0xca, 0xe2, 0x9c, 0x4f, 0x2a, 0x96, 0x15,
-	0x59, 0x6c, 0x83, 0x15, 0xdb, 0xca, 0xa9, 0x8e, 0x97, 0xc9, 0xb6, 0xba, 0xb8, 0x16, 0xea, 0x43,
-	0x00, 0x51, 0x05, 0x10, 0xad, 0x6a, 0xea, 0xd6, 0x6c, 0xe3, 0xaa, 0x85, 0x0d, 0x98, 0x77, 0x04,
-	0x6d, 0x61, 0x84, 0x0e, 0x35, 0x35, 0xed, 0xf1, 0x8c, 0x66, 0x34, 0xb1, 0x05, 0xd5, 0x54, 0x23,
-	0x9f, 0x68, 0xad, 0x4e, 0x35, 0x9a, 0xd8, 0x82, 0x6a, 0x9b, 0xcc, 0xd1, 0x47, 0xff, 0xd5, 0x6d,
-	0x32, 0xbb, 0xd8, 0x06, 0x7b, 0xa7, 0xb0, 0xc9, 0xeb, 0x83, 0x80, 0x5a, 0x5a, 0x61, 0xbb, 0x52,
-	0x98, 0x1b, 0x15, 0x3c, 0x4f, 0xf2, 0xfa, 0xd0, 0x11, 0xb5, 0x3f, 0x0e, 0x02, 0x2d, 0xf4, 0xd4,
-	0xbe, 0x31, 0x03, 0x80, 0xe7, 0x28, 0xca, 0x89, 0xc4, 0x18, 0x26, 0xb4, 0x5e, 0x73, 0xc2, 0x9c,
-	0x34, 0x6c, 0x41, 0x95, 0x13, 0x89, 0x39, 0x3e, 0xa8, 0x5d, 0x73, 0xc2, 0x1a, 0x2e, 0x6c, 0x83,
-	0x95, 0x13, 0x49, 0x3d, 0xd9, 0xc8, 0xad, 0x39, 0x31, 0x97, 0x7d, 0x3c, 0x4f, 0x3a, 0xee, 0xdc,
-	0xfd, 0xed, 0x2e, 0xdd, 0xde, 0x77, 0x9d, 0xbb, 0xfb, 0xae, 0xf3, 0xe7, 0xbe, 0xeb, 0x04, 0x4d,
-	0xfd, 0xef, 0x3f, 0xfc, 0x17, 0x00, 0x00, 0xff, 0xff, 0x76, 0xa2, 0x28, 0x9c, 0x7f, 0x06, 0x00,
-	0x00,
+	// 839 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x55, 0xdd, 0x8e, 0xdb, 0x44,
+	0x14, 0xae, 0x9b, 0xec, 0x4f, 0xce, 0xc6, 0x4d, 0x76, 0xda, 0xa2, 0x61, 0x85, 0xc2, 0xca, 0x12,
+	0xd2, 0x22, 0xd1, 0x5d, 0xd8, 0x22, 0xa4, 0x4a, 0x15, 0x88, 0xa5, 0xa9, 0xba, 0xea, 0xee, 0x5e,
+	0x4c, 0x8a, 0x40, 0xe2, 0x6a, 0x12, 0x9f, 0x38, 0x16, 0x8e, 0xc7, 0x9d, 0x19, 0x97, 0x86, 0xd7,
+	0xe0, 0x09, 0x78, 0x9b, 0x5e, 0xf6, 0x09, 0x10, 0xec, 0x1b, 0xf0, 0x06, 0xc8, 0xe3, 0x9f, 0x8c,
+	0x27, 0x6a, 0xb8, 0x8a, 0x67, 0xce, 0xf7, 0x9d, 0x73, 0x7c, 0xfc, 0x7d, 0x27, 0xe0, 0xa3, 0x94,
+	0x42, 0x66, 0xd3, 0xd3, 0x4c, 0x0a, 0x2d, 0xc8, 0x5e, 0x75, 0x3c, 0x7a, 0x12, 0xc5, 0x7a, 0x91,
+	0x4f, 0x4f, 0x67, 0x62, 0x79, 0xb6, 0xe4, 0x5a, 0xc6, 0x6f, 0x85, 0x8c, 0xa3, 0x38, 0xad, 0x0e,
+	0xb3, 0x7c, 0x8a, 0x67, 0xd9, 0xf4, 0x6c, 0x89, 0x9a, 0x37, 0x3f, 0x65, 0x8e, 0xa3, 0x47, 0x16,
+	0x35, 0x12, 0x91, 0x38, 0x33, 0xd7, 0xd3, 0x7c, 0x6e, 0x4e, 0xe6, 0x60, 0x9e, 0x4a, 0x78, 0xf0,
+	0x0a, 0x7a, 0x37, 0x42, 0x5f, 0x21, 0x0f, 0x51, 0x12, 0x0a, 0x7b, 0x6a, 0xc1, 0x65, 0x78, 0xf9,
+	0x8c, 0x7a, 0xc7, 0xde, 0x49, 0x97, 0xd5, 0x47, 0xf2, 0x08, 0x76, 0x13, 0x83, 0xa1, 0x77, 0x8f,
+	0xbd, 0x93, 0x83, 0xf3, 0xc1, 0x69, 0x55, 0x94, 0x61, 0x96, 0xc4, 0x33, 0x7e, 0xd1, 0x7d, 0xf7,
+	0xd7, 0xa7, 0x77, 0x58, 0x05, 0x0a, 0x06, 0xe0, 0x4f, 0xb4, 0x90, 0x78, 0x1d, 0xab, 0x25, 0xd7,
+	0xb3, 0x45, 0xf0, 0x05, 0x0c, 0x27, 0x45, 0xaa, 0x1f, 0x53, 0xfe, 0x86, 0xc7, 0x09, 0x9f, 0x26,
+	0xf8, 0xe1, 0x6a, 0xc1, 0xe7, 0xe0, 0x1b, 0xf4, 0x8d, 0xd0, 0xcf, 0x45, 0x9e, 0x86, 0x5b, 0xa0,
+	0x33, 0xf0, 0x5f, 0xe2, 0xea, 0x46, 0xe8, 0xcb, 0xd4, 0x50, 0xc8, 0x10, 0x3a, 0xbf, 0xe2, 0xca,
+	0xc0, 0xfa, 0xac, 0x78, 0xb4, 0xc9, 0x77, 0xdb, 0x6f, 0xf5, 0x00, 0x76, 0x94, 0xe6, 0x52, 0xd3,
+	0x8e, 0x41, 0x97, 0x87, 0x22, 0x03, 0xa6, 0x21, 0xed, 0x96, 0x19, 0x30, 0x0d, 0x83, 0xef, 0x00,
+	0x26, 0x9a, 0x27, 0x38, 0xce, 0xc4, 0x6c, 0x41, 0xbe, 0x82, 0x5e, 0x8a, 0xbf, 0x99, 0x6a, 0x8a,
+	0x7a, 0xc7, 0x9d, 0x93, 0x83, 0x73, 0xbf, 0x1e, 0x87, 0xb9, 0xad, 0x86, 0xb1, 0x46, 0x05, 0x3f,
+	0x43, 0x7f, 0x82, 0xf2, 0x0d, 0xca, 0x4b, 0x75, 0x91, 0xab, 0x15, 0x19, 0x01, 0xbc, 0xce, 0x31,
+	0xc7, 0x67, 0x98, 0xe9, 0x45, 0xf5, 0x4a, 0xd6, 0x0d, 0x39, 0x81, 0x01, 0x2a, 0x1d, 0x2f, 0xb9,
+	0xc6, 0xf0, 0x27, 0x1e, 0xeb, 0xeb, 0x49, 0xd5, 0xba, 0x7b, 0x1d, 0xdc, 0x83, 0xbe, 0x69, 0xed,
+	0x07, 0xb1, 0x5c, 0xf2, 0x34, 0x0c, 0x5e, 0xc2, 0x21, 0xe3, 0x73, 0x3d, 0x4e, 0xb5, 0x5c, 0xbd,
+	0x12, 0xe2, 0x8a, 0xcb, 0x68, 0xcb, 0xa4, 0xc9, 0x27, 0xd0, 0xc3, 0x02, 0x3a, 0x89, 0x7f, 0xc7,
+	0xaa, 0xc4, 0xfa, 0x22, 0x78, 0x0e, 0xfd, 0x2b, 0xe4, 0xaa, 0xf8, 0x8c, 0x2a, 0x4e, 0xa3, 0xed,
+	0x79, 0x64, 0xa9, 0x84, 0x66, 0xca, 0xeb, 0x8b, 0xe0, 0x4f, 0x0f, 0xfc, 0x3a, 0x91, 0xd1, 0xc3,
+	0x96, 0x4c, 0xdf, 0x40, 0x5f, 0xe2, 0xeb, 0x1c, 0x95, 0x36, 0x8c, 0x4a, 0x6f, 0xa4, 0x1e, 0xb0,
+	0xf9, 0x04, 0x26, 0xc2, 0x5a, 0x38, 0xf2, 0x2d, 0x0c, 0xab, 0x82, 0x2f, 0x30, 0x09, 0x4b, 0x6e,
+	0xe7, 0x83, 0xdc, 0x0d, 0x6c, 0x70, 0x1f, 0x0e, 0xcb, 0x10, 0xf2, 0x42, 0x77, 0xc5, 0xcf, 0x2a,
+	0xf8, 0x77, 0x17, 0x76, 0xc6, 0x85, 0x27, 0x8b, 0x86, 0x97, 0xa8, 0x14, 0x8f, 0xd0, 0x34, 0xdc,
+	0x63, 0xf5, 0x91, 0x7c, 0x09, 0xbd, 0xb4, 0x76, 0x50, 0xd3, 0x6d, 0xed, 0xeb, 0xc6, 0x5b, 0x6c,
+	0x0d, 0x22, 0x4f, 0xc1, 0x57, 0xb6, 0xbc, 0xab, 0x3e, 0x3f, 0x6a, 0x58, 0x2d, 0xf1, 0xb3, 0x36,
+	0x98, 0x3c, 0x75, 0x14, 0x4f, 0xbb, 0x0e, 0xbb, 0x15, 0x65, 0x8e, 0x3d, 0x1e, 0x03, 0xa8, 0x46,
+	0xca, 0x74, 0xc7, 0x50, 0xef, 0xaf, 0x0b, 0x37, 0x21, 0x66, 0xc1, 0xc8, 0x13, 0xe8, 0x2b, 0x4b,
+	0xbe, 0x74, 0xd7, 0xd0, 0x1e, 0xae, 0x69, 0x56, 0x90, 0xb5, 0xa0, 0x86, 0x6a, 0xe9, 0x93, 0xee,
+	0xb9, 0x54, 0x2b, 0xc8, 0x5a, 0x50, 0x33, 0x26, 0x7b, 0x89, 0xd0, 0x7d, 0x77, 0x4c, 0x76, 0x94,
+	0xb5, 0xc1, 0xe4, 0x05, 0x1c, 0x4a, 0xd7, 0x08, 0xb4, 0x67, 0x32, 0x1c, 0x35, 0x19, 0x36, 0xac,
+	0xc2, 0x36, 0x49, 0x64, 0x0c, 0x43, 0xe5, 0xec, 0x2e, 0x0a, 0x26, 0xd1, 0xc7, 0xed, 0x2f, 0x66,
+	0x01, 0xd8, 0x06, 0xa5, 0x98, 0x44, 0x62, 0x99, 0x89, 0x1e, 0x38, 0x93, 0xb0, 0x9d, 0xc6, 0x5a,
+	0xd0, 0x62, 0x12, 0x89, 0x6d, 0x1f, 0xda, 0x77, 0x26, 0xd1, 0x32, 0x17, 0x6b, 0x83, 0x8b, 0x49,
+	0x24, 0xae, 0xb2, 0xa9, 0xef, 0x4c, 0x62, 0x43, 0xfb, 0x6c, 0x93, 0x44, 0x3e, 0x83, 0x9e, 0x79,
+	0x2d, 0x23, 0x82, 0x7b, 0x8e, 0xd4, 0x27, 0x75, 0xa4, 0x68, 0x17, 0x0b, 0xdd, 0xdc, 0x08, 0x7d,
+	0x6d, 0xda, 0x1d, 0x38, 0xed, 0x8e, 0xed, 0x28, 0x6b, 0x83, 0x83, 0x5f, 0xa0, 0xb7, 0x4e, 0x35,
+	0x70, 0xf6, 0x04, 0x79, 0x08, 0x7e, 0x9c, 0xce, 0x93, 0x38, 0x5a, 0xe8, 0x8b, 0x95, 0x46, 0x55,
+	0x2e, 0x1b, 0x32, 0x84, 0x7d, 0x9e, 0x65, 0xc9, 0xea, 0x8a, 0x47, 0xc6, 0x4d, 0x5d, 0xf2, 0xa0,
+	0xd8, 0x23, 0x5a, 0xae, 0xbe, 0x9f, 0x6b, 0x94, 0xd7, 0x13, 0xe3, 0x92, 0x6e, 0xf0, 0x87, 0x07,
+	0x7e, 0xab, 0xfa, 0x96, 0x4d, 0xf4, 0x35, 0xec, 0xe3, 0xdb, 0x0c, 0x67, 0x1a, 0x43, 0x77, 0x0b,
+	0x99, 0x06, 0x4d, 0x9e, 0x6a, 0xd7, 0x37, 0x48, 0x72, 0x0e, 0x7b, 0xb3, 0x5c, 0x4a, 0x4c, 0x35,
+	0xed, 0xfc, 0x0f, 0xa9, 0x06, 0x5e, 0x0c, 0xdf, 0xff, 0x33, 0xba, 0xf3, 0xee, 0x76, 0xe4, 0xbd,
+	0xbf, 0x1d, 0x79, 0x7f, 0xdf, 0x8e, 0xbc, 0xe9, 0xae, 0xf9, 0x77, 0x7e, 0xfc, 0xdf, 0x00, 0x93,
+	0x19, 0xcb, 0x42, 0x21, 0x08, 0x00, 0x00,
 }
 
 func (m *NotLeader) Marshal() (dAtA []byte, err error) {
@@ -1040,6 +1227,16 @@ func (m *ServerIsBusy) MarshalTo(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.QueueDepth != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintErrorpb(dAtA, i, uint64(m.QueueDepth))
+	}
+	if m.EstimatedWaitMS != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintErrorpb(dAtA, i, uint64(m.EstimatedWaitMS))
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
@@ -1337,6 +1534,109 @@ func (m *Error) MarshalTo(dAtA []byte) (int, error) {
 		}
 		i += n15
 	}
+	if m.ShardBusy != nil {
+		dAtA[i] = 0x72
+		i++
+		i = encodeVarintErrorpb(dAtA, i, uint64(m.ShardBusy.Size()))
+		n16, err := m.ShardBusy.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n16
+	}
+	if m.EpochNotMatch != nil {
+		dAtA[i] = 0x7a
+		i++
+		i = encodeVarintErrorpb(dAtA, i, uint64(m.EpochNotMatch.Size()))
+		n17, err := m.EpochNotMatch.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n17
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func (m *ShardBusy) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ShardBusy) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.ShardID != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintErrorpb(dAtA, i, uint64(m.ShardID))
+	}
+	if m.InflightBytes != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintErrorpb(dAtA, i, uint64(m.InflightBytes))
+	}
+	if m.ApplyLag != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintErrorpb(dAtA, i, uint64(m.ApplyLag))
+	}
+	if m.RetryAfterMS != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintErrorpb(dAtA, i, uint64(m.RetryAfterMS))
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func (m *EpochNotMatch) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *EpochNotMatch) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.ShardID != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintErrorpb(dAtA, i, uint64(m.ShardID))
+	}
+	dAtA[i] = 0x12
+	i++
+	i = encodeVarintErrorpb(dAtA, i, uint64(m.Expected.Size()))
+	n1, err := m.Expected.MarshalTo(dAtA[i:])
+	if err != nil {
+		return 0, err
+	}
+	i += n1
+	dAtA[i] = 0x1a
+	i++
+	i = encodeVarintErrorpb(dAtA, i, uint64(m.Current.Size()))
+	n2, err := m.Current.MarshalTo(dAtA[i:])
+	if err != nil {
+		return 0, err
+	}
+	i += n2
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
@@ -1462,6 +1762,12 @@ func (m *ServerIsBusy) Size() (n int) {
 	}
 	var l int
 	_ = l
+	if m.QueueDepth != 0 {
+		n += 1 + sovErrorpb(uint64(m.QueueDepth))
+	}
+	if m.EstimatedWaitMS != 0 {
+		n += 1 + sovErrorpb(uint64(m.EstimatedWaitMS))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1609,6 +1915,57 @@ func (m *Error) Size() (n int) {
 		l = m.LeaseReadNotReady.Size()
 		n += 1 + l + sovErrorpb(uint64(l))
 	}
+	if m.ShardBusy != nil {
+		l = m.ShardBusy.Size()
+		n += 1 + l + sovErrorpb(uint64(l))
+	}
+	if m.EpochNotMatch != nil {
+		l = m.EpochNotMatch.Size()
+		n += 1 + l + sovErrorpb(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ShardBusy) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ShardID != 0 {
+		n += 1 + sovErrorpb(uint64(m.ShardID))
+	}
+	if m.InflightBytes != 0 {
+		n += 1 + sovErrorpb(uint64(m.InflightBytes))
+	}
+	if m.ApplyLag != 0 {
+		n += 1 + sovErrorpb(uint64(m.ApplyLag))
+	}
+	if m.RetryAfterMS != 0 {
+		n += 1 + sovErrorpb(uint64(m.RetryAfterMS))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *EpochNotMatch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ShardID != 0 {
+		n += 1 + sovErrorpb(uint64(m.ShardID))
+	}
+	l = m.Expected.Size()
+	n += 1 + l + sovErrorpb(uint64(l))
+	l = m.Current.Size()
+	n += 1 + l + sovErrorpb(uint64(l))
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -2226,6 +2583,44 @@ func (m *ServerIsBusy) Unmarshal(dAtA []byte) error {
 			return fmt.Errorf("proto: ServerIsBusy: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueueDepth", wireType)
+			}
+			m.QueueDepth = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowErrorpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.QueueDepth |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EstimatedWaitMS", wireType)
+			}
+			m.EstimatedWaitMS = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowErrorpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EstimatedWaitMS |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipErrorpb(dAtA[iNdEx:])
@@ -3181,9 +3576,81 @@ func (m *Error) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipErrorpb(dAtA[iNdEx:])
+		case 14:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardBusy", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowErrorpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthErrorpb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthErrorpb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.ShardBusy == nil {
+				m.ShardBusy = &ShardBusy{}
+			}
+			if err := m.ShardBusy.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 15:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EpochNotMatch", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowErrorpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthErrorpb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthErrorpb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.EpochNotMatch == nil {
+				m.EpochNotMatch = &EpochNotMatch{}
+			}
+			if err := m.EpochNotMatch.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipErrorpb(dAtA[iNdEx:])
 			if err != nil {
 				return err
 			}
@@ -3206,6 +3673,277 @@ func (m *Error) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+
+func (m *ShardBusy) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowErrorpb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ShardBusy: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ShardBusy: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardID", wireType)
+			}
+			m.ShardID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowErrorpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ShardID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field InflightBytes", wireType)
+			}
+			m.InflightBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowErrorpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.InflightBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ApplyLag", wireType)
+			}
+			m.ApplyLag = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowErrorpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ApplyLag |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RetryAfterMS", wireType)
+			}
+			m.RetryAfterMS = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowErrorpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RetryAfterMS |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipErrorpb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthErrorpb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthErrorpb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *EpochNotMatch) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowErrorpb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: EpochNotMatch: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: EpochNotMatch: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardID", wireType)
+			}
+			m.ShardID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowErrorpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ShardID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Expected", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowErrorpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthErrorpb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthErrorpb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Expected.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Current", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowErrorpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthErrorpb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthErrorpb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Current.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipErrorpb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthErrorpb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthErrorpb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
 func skipErrorpb(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -131,6 +131,14 @@ func (m *Store) SetLastHeartbeat(value int64) {
 	m.LastHeartbeatTime = value
 }
 
+func (m *Store) SetEpoch(value uint64) {
+	m.Epoch = value
+}
+
+func (m *Store) SetSnapshotFormat(value uint64) {
+	m.SnapshotFormat = value
+}
+
 // ContainsKey returns true if the shard contains the key
 func (m *Shard) ContainsKey(key []byte) bool {
 	return (len(m.Start) == 0 || bytes.Compare(key, m.Start) >= 0) &&
@@ -100,6 +100,10 @@ const (
 	// this state is normally not available for external read and write services,
 	// the shards have been destroyed in the whole cluster and cannot be used again.
 	ShardState_Destroyed ShardState = 3
+	// Merging is waiting to be merged into the adjacent target shard state,
+	// this state does not provide read and write services to the public, and
+	// the shard is destroyed after the target shard committed the merge.
+	ShardState_Merging ShardState = 4
 )
 
 var ShardState_name = map[int32]string{
@@ -107,6 +111,7 @@ var ShardState_name = map[int32]string{
 	1: "Creating",
 	2: "Destroying",
 	3: "Destroyed",
+	4: "Merging",
 }
 
 var ShardState_value = map[string]int32{
@@ -114,6 +119,7 @@ var ShardState_value = map[string]int32{
 	"Creating":   1,
 	"Destroying": 2,
 	"Destroyed":  3,
+	"Merging":    4,
 }
 
 func (x ShardState) String() string {
@@ -371,6 +377,83 @@ func (ShardsPoolCmdType) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_77b4d575d5a68dda, []int{10}
 }
 
+// SnapshotPhase the phase of the snapshot sent to the replica observed by the
+// shard leader
+type SnapshotPhase int32
+
+const (
+	// NoSnapshot the replica is catching up from the raft logs or up to date
+	SnapshotPhase_NoSnapshot SnapshotPhase = 0
+	// PendingSnapshot the replica needs a snapshot, the snapshot is being
+	// created or waiting to be sent
+	SnapshotPhase_PendingSnapshot SnapshotPhase = 1
+	// SendingSnapshot the snapshot is being sent to the replica
+	SnapshotPhase_SendingSnapshot SnapshotPhase = 2
+	// ApplyingSnapshot the snapshot has been sent, and the replica has not
+	// applied it yet
+	SnapshotPhase_ApplyingSnapshot SnapshotPhase = 3
+)
+
+var SnapshotPhase_name = map[int32]string{
+	0: "NoSnapshot",
+	1: "PendingSnapshot",
+	2: "SendingSnapshot",
+	3: "ApplyingSnapshot",
+}
+
+var SnapshotPhase_value = map[string]int32{
+	"NoSnapshot":       0,
+	"PendingSnapshot":  1,
+	"SendingSnapshot":  2,
+	"ApplyingSnapshot": 3,
+}
+
+func (x SnapshotPhase) String() string {
+	return proto.EnumName(SnapshotPhase_name, int32(x))
+}
+
+func (SnapshotPhase) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_77b4d575d5a68dda, []int{11}
+}
+
+// SnapshotFailureReason the reason of the snapshot failed to send to the replica
+type SnapshotFailureReason int32
+
+const (
+	SnapshotFailureReason_NoFailure         SnapshotFailureReason = 0
+	SnapshotFailureReason_NetworkFailure    SnapshotFailureReason = 1
+	SnapshotFailureReason_DiskFull          SnapshotFailureReason = 2
+	SnapshotFailureReason_ChecksumMismatch  SnapshotFailureReason = 3
+	SnapshotFailureReason_UnsupportedFormat SnapshotFailureReason = 4
+	SnapshotFailureReason_InsufficientSpace SnapshotFailureReason = 5
+)
+
+var SnapshotFailureReason_name = map[int32]string{
+	0: "NoFailure",
+	1: "NetworkFailure",
+	2: "DiskFull",
+	3: "ChecksumMismatch",
+	4: "UnsupportedFormat",
+	5: "InsufficientSpace",
+}
+
+var SnapshotFailureReason_value = map[string]int32{
+	"NoFailure":         0,
+	"NetworkFailure":    1,
+	"DiskFull":          2,
+	"ChecksumMismatch":  3,
+	"UnsupportedFormat": 4,
+	"InsufficientSpace": 5,
+}
+
+func (x SnapshotFailureReason) String() string {
+	return proto.EnumName(SnapshotFailureReason_name, int32(x))
+}
+
+func (SnapshotFailureReason) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_77b4d575d5a68dda, []int{12}
+}
+
 // ShardEpoch shard epoch
 type ShardEpoch struct {
 	// Conf change version, auto increment when add or remove replica
@@ -725,6 +808,109 @@ func (m *ShardStats) GetInterval() *TimeInterval {
 	return nil
 }
 
+// ShardBucket the read and write stats of a key range [start, end) of the shard
+// during the reported interval, the buckets of a shard are ordered by the start
+// key and cover the whole range of the shard.
+type ShardBucket struct {
+	// shard ID
+	ShardID uint64 `protobuf:"varint,1,opt,name=shardID,proto3" json:"shardID,omitempty"`
+	Start   []byte `protobuf:"bytes,2,opt,name=start,proto3" json:"start,omitempty"`
+	End     []byte `protobuf:"bytes,3,opt,name=end,proto3" json:"end,omitempty"`
+	// bytes written during this period
+	WrittenBytes uint64 `protobuf:"varint,4,opt,name=writtenBytes,proto3" json:"writtenBytes,omitempty"`
+	// keys written during this period
+	WrittenKeys uint64 `protobuf:"varint,5,opt,name=writtenKeys,proto3" json:"writtenKeys,omitempty"`
+	// bytes read during this period
+	ReadBytes uint64 `protobuf:"varint,6,opt,name=readBytes,proto3" json:"readBytes,omitempty"`
+	// keys read during this period
+	ReadKeys             uint64   `protobuf:"varint,7,opt,name=readKeys,proto3" json:"readKeys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ShardBucket) Reset()         { *m = ShardBucket{} }
+func (m *ShardBucket) String() string { return proto.CompactTextString(m) }
+func (*ShardBucket) ProtoMessage()    {}
+func (*ShardBucket) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77b4d575d5a68dda, []int{38}
+}
+func (m *ShardBucket) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ShardBucket) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ShardBucket.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ShardBucket) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ShardBucket.Merge(m, src)
+}
+func (m *ShardBucket) XXX_Size() int {
+	return m.Size()
+}
+func (m *ShardBucket) XXX_DiscardUnknown() {
+	xxx_messageInfo_ShardBucket.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ShardBucket proto.InternalMessageInfo
+
+func (m *ShardBucket) GetShardID() uint64 {
+	if m != nil {
+		return m.ShardID
+	}
+	return 0
+}
+
+func (m *ShardBucket) GetStart() []byte {
+	if m != nil {
+		return m.Start
+	}
+	return nil
+}
+
+func (m *ShardBucket) GetEnd() []byte {
+	if m != nil {
+		return m.End
+	}
+	return nil
+}
+
+func (m *ShardBucket) GetWrittenBytes() uint64 {
+	if m != nil {
+		return m.WrittenBytes
+	}
+	return 0
+}
+
+func (m *ShardBucket) GetWrittenKeys() uint64 {
+	if m != nil {
+		return m.WrittenKeys
+	}
+	return 0
+}
+
+func (m *ShardBucket) GetReadBytes() uint64 {
+	if m != nil {
+		return m.ReadBytes
+	}
+	return 0
+}
+
+func (m *ShardBucket) GetReadKeys() uint64 {
+	if m != nil {
+		return m.ReadKeys
+	}
+	return 0
+}
+
 // StoreStats store stats
 type StoreStats struct {
 	// Store id
@@ -762,10 +948,23 @@ type StoreStats struct {
 	// Threads' read disk I/O rates in the store
 	ReadIORates []RecordPair `protobuf:"bytes,17,rep,name=readIORates,proto3" json:"readIORates"`
 	// Threads' write disk I/O rates in the store
-	WriteIORates         []RecordPair `protobuf:"bytes,18,rep,name=writeIORates,proto3" json:"writeIORates"`
-	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
-	XXX_unrecognized     []byte       `json:"-"`
-	XXX_sizecache        int32        `json:"-"`
+	WriteIORates []RecordPair `protobuf:"bytes,18,rep,name=writeIORates,proto3" json:"writeIORates"`
+	// Offset of the store's clock to the prophet leader's clock in nanoseconds
+	ClockOffset int64 `protobuf:"varint,19,opt,name=clockOffset,proto3" json:"clockOffset,omitempty"`
+	// If the clock offset exceeds the max tolerated offset
+	ClockSkewed bool `protobuf:"varint,20,opt,name=clockSkewed,proto3" json:"clockSkewed,omitempty"`
+	// The max replica count the store accepts, 0 means no limit
+	MaxReplicaCount uint64 `protobuf:"varint,21,opt,name=maxReplicaCount,proto3" json:"maxReplicaCount,omitempty"`
+	// The max leader count the store accepts, 0 means no limit
+	MaxLeaderCount uint64 `protobuf:"varint,22,opt,name=maxLeaderCount,proto3" json:"maxLeaderCount,omitempty"`
+	// The read load of the replicas in the store during this period
+	ReplicaReadStats []ReplicaReadStats `protobuf:"bytes,23,rep,name=replicaReadStats,proto3" json:"replicaReadStats"`
+	// The max snapshot count the store sends or receives at the same time, 0
+	// means no limit
+	MaxSnapshotCount     uint64   `protobuf:"varint,24,opt,name=maxSnapshotCount,proto3" json:"maxSnapshotCount,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *StoreStats) Reset()         { *m = StoreStats{} }
@@ -927,6 +1126,48 @@ func (m *StoreStats) GetWriteIORates() []RecordPair {
 	return nil
 }
 
+func (m *StoreStats) GetClockOffset() int64 {
+	if m != nil {
+		return m.ClockOffset
+	}
+	return 0
+}
+
+func (m *StoreStats) GetClockSkewed() bool {
+	if m != nil {
+		return m.ClockSkewed
+	}
+	return false
+}
+
+func (m *StoreStats) GetMaxReplicaCount() uint64 {
+	if m != nil {
+		return m.MaxReplicaCount
+	}
+	return 0
+}
+
+func (m *StoreStats) GetMaxLeaderCount() uint64 {
+	if m != nil {
+		return m.MaxLeaderCount
+	}
+	return 0
+}
+
+func (m *StoreStats) GetReplicaReadStats() []ReplicaReadStats {
+	if m != nil {
+		return m.ReplicaReadStats
+	}
+	return nil
+}
+
+func (m *StoreStats) GetMaxSnapshotCount() uint64 {
+	if m != nil {
+		return m.MaxSnapshotCount
+	}
+	return 0
+}
+
 // RecordPair record pair
 type RecordPair struct {
 	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
@@ -1225,6 +1466,72 @@ func (m *Job) GetState() JobState {
 	return JobState_Created
 }
 
+// JobCheckpoint the intermediate state of the job saved by the job processor,
+// the job is resumed from the latest checkpoint after the prophet leader
+// changed. The seq is increased by each checkpoint of the job.
+type JobCheckpoint struct {
+	Type                 JobType  `protobuf:"varint,1,opt,name=type,proto3,enum=metapb.JobType" json:"type,omitempty"`
+	Seq                  uint64   `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	Data                 []byte   `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *JobCheckpoint) Reset()         { *m = JobCheckpoint{} }
+func (m *JobCheckpoint) String() string { return proto.CompactTextString(m) }
+func (*JobCheckpoint) ProtoMessage()    {}
+func (*JobCheckpoint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77b4d575d5a68dda, []int{39}
+}
+func (m *JobCheckpoint) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *JobCheckpoint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_JobCheckpoint.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *JobCheckpoint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JobCheckpoint.Merge(m, src)
+}
+func (m *JobCheckpoint) XXX_Size() int {
+	return m.Size()
+}
+func (m *JobCheckpoint) XXX_DiscardUnknown() {
+	xxx_messageInfo_JobCheckpoint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JobCheckpoint proto.InternalMessageInfo
+
+func (m *JobCheckpoint) GetType() JobType {
+	if m != nil {
+		return m.Type
+	}
+	return JobType_RemoveShard
+}
+
+func (m *JobCheckpoint) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *JobCheckpoint) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
 // RemoveShardJob remove shards job
 type RemoveShardJob struct {
 	ID                   uint64    `protobuf:"varint,1,opt,name=shardID,proto3" json:"shardID,omitempty"`
@@ -1643,22 +1950,28 @@ func (m *RaftMessageBatch) GetMessages() []RaftMessage {
 
 // RaftMessage the message wrapped raft msg with shard info
 type RaftMessage struct {
-	ShardID              uint64         `protobuf:"varint,1,opt,name=shardID,proto3" json:"shardID,omitempty"`
-	Group                uint64         `protobuf:"varint,2,opt,name=group,proto3" json:"group,omitempty"`
-	From                 Replica        `protobuf:"bytes,3,opt,name=from,proto3" json:"from"`
-	To                   Replica        `protobuf:"bytes,4,opt,name=to,proto3" json:"to"`
-	Message              raftpb.Message `protobuf:"bytes,5,opt,name=message,proto3" json:"message"`
-	ShardEpoch           ShardEpoch     `protobuf:"bytes,6,opt,name=shardEpoch,proto3" json:"shardEpoch"`
-	IsTombstone          bool           `protobuf:"varint,7,opt,name=isTombstone,proto3" json:"isTombstone,omitempty"`
-	Start                []byte         `protobuf:"bytes,8,opt,name=start,proto3" json:"start,omitempty"`
-	End                  []byte         `protobuf:"bytes,9,opt,name=end,proto3" json:"end,omitempty"`
-	Unique               string         `protobuf:"bytes,10,opt,name=unique,proto3" json:"unique,omitempty"`
-	RuleGroups           []string       `protobuf:"bytes,11,rep,name=ruleGroups,proto3" json:"ruleGroups,omitempty"`
-	CommitIndex          uint64         `protobuf:"varint,12,opt,name=commitIndex,proto3" json:"commitIndex,omitempty"`
-	SendTime             uint64         `protobuf:"varint,13,opt,name=sendTime,proto3" json:"sendTime,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
-	XXX_unrecognized     []byte         `json:"-"`
-	XXX_sizecache        int32          `json:"-"`
+	ShardID     uint64         `protobuf:"varint,1,opt,name=shardID,proto3" json:"shardID,omitempty"`
+	Group       uint64         `protobuf:"varint,2,opt,name=group,proto3" json:"group,omitempty"`
+	From        Replica        `protobuf:"bytes,3,opt,name=from,proto3" json:"from"`
+	To          Replica        `protobuf:"bytes,4,opt,name=to,proto3" json:"to"`
+	Message     raftpb.Message `protobuf:"bytes,5,opt,name=message,proto3" json:"message"`
+	ShardEpoch  ShardEpoch     `protobuf:"bytes,6,opt,name=shardEpoch,proto3" json:"shardEpoch"`
+	IsTombstone bool           `protobuf:"varint,7,opt,name=isTombstone,proto3" json:"isTombstone,omitempty"`
+	Start       []byte         `protobuf:"bytes,8,opt,name=start,proto3" json:"start,omitempty"`
+	End         []byte         `protobuf:"bytes,9,opt,name=end,proto3" json:"end,omitempty"`
+	Unique      string         `protobuf:"bytes,10,opt,name=unique,proto3" json:"unique,omitempty"`
+	RuleGroups  []string       `protobuf:"bytes,11,rep,name=ruleGroups,proto3" json:"ruleGroups,omitempty"`
+	CommitIndex uint64         `protobuf:"varint,12,opt,name=commitIndex,proto3" json:"commitIndex,omitempty"`
+	SendTime    uint64         `protobuf:"varint,13,opt,name=sendTime,proto3" json:"sendTime,omitempty"`
+	// AppliedIndex the applied index of the sender replica
+	AppliedIndex uint64 `protobuf:"varint,14,opt,name=appliedIndex,proto3" json:"appliedIndex,omitempty"`
+	// SnapshotFailure the reason of the snapshot rejected by the receiver, it is
+	// set in the snapshot message by the transport failed to save the snapshot,
+	// and reported to the sender of the snapshot with MsgSnapStatus
+	SnapshotFailure      SnapshotFailureReason `protobuf:"varint,15,opt,name=snapshotFailure,proto3,enum=metapb.SnapshotFailureReason" json:"snapshotFailure,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
 }
 
 func (m *RaftMessage) Reset()         { *m = RaftMessage{} }
@@ -1785,6 +2098,20 @@ func (m *RaftMessage) GetSendTime() uint64 {
 	return 0
 }
 
+func (m *RaftMessage) GetAppliedIndex() uint64 {
+	if m != nil {
+		return m.AppliedIndex
+	}
+	return 0
+}
+
+func (m *RaftMessage) GetSnapshotFailure() SnapshotFailureReason {
+	if m != nil {
+		return m.SnapshotFailure
+	}
+	return SnapshotFailureReason_NoFailure
+}
+
 type SnapshotChunk struct {
 	StoreID              uint64           `protobuf:"varint,1,opt,name=storeID,proto3" json:"storeID,omitempty"`
 	ShardID              uint64           `protobuf:"varint,2,opt,name=shardID,proto3" json:"shardID,omitempty"`
@@ -1802,6 +2129,9 @@ type SnapshotChunk struct {
 	Data                 []byte           `protobuf:"bytes,14,opt,name=data,proto3" json:"data,omitempty"`
 	Extra                []byte           `protobuf:"bytes,15,opt,name=extra,proto3" json:"extra,omitempty"`
 	ConfState            raftpb.ConfState `protobuf:"bytes,16,opt,name=confState,proto3" json:"confState"`
+	FromStoreID          uint64           `protobuf:"varint,17,opt,name=fromStoreID,proto3" json:"fromStoreID,omitempty"`
+	Format               uint64           `protobuf:"varint,18,opt,name=format,proto3" json:"format,omitempty"`
+	SnapshotSize         uint64           `protobuf:"varint,19,opt,name=snapshotSize,proto3" json:"snapshotSize,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
 	XXX_unrecognized     []byte           `json:"-"`
 	XXX_sizecache        int32            `json:"-"`
@@ -1952,10 +2282,32 @@ func (m *SnapshotChunk) GetConfState() raftpb.ConfState {
 	return raftpb.ConfState{}
 }
 
+func (m *SnapshotChunk) GetFromStoreID() uint64 {
+	if m != nil {
+		return m.FromStoreID
+	}
+	return 0
+}
+
+func (m *SnapshotChunk) GetFormat() uint64 {
+	if m != nil {
+		return m.Format
+	}
+	return 0
+}
+
+func (m *SnapshotChunk) GetSnapshotSize() uint64 {
+	if m != nil {
+		return m.SnapshotSize
+	}
+	return 0
+}
+
 // StoreIdent store ident
 type StoreIdent struct {
 	ClusterID            uint64   `protobuf:"varint,1,opt,name=clusterID,proto3" json:"clusterID,omitempty"`
 	StoreID              uint64   `protobuf:"varint,2,opt,name=storeID,proto3" json:"storeID,omitempty"`
+	Epoch                uint64   `protobuf:"varint,3,opt,name=epoch,proto3" json:"epoch,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -2008,6 +2360,13 @@ func (m *StoreIdent) GetStoreID() uint64 {
 	return 0
 }
 
+func (m *StoreIdent) GetEpoch() uint64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
 // Shard a shard [start,end) of the data
 type Shard struct {
 	ID                   uint64     `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -2336,6 +2695,8 @@ type Store struct {
 	CommitID             string     `protobuf:"bytes,9,opt,name=commitID,proto3" json:"commitID,omitempty"`
 	DeployPath           string     `protobuf:"bytes,10,opt,name=deployPath,proto3" json:"deployPath,omitempty"`
 	Destroyed            bool       `protobuf:"varint,11,opt,name=destroyed,proto3" json:"destroyed,omitempty"`
+	Epoch                uint64     `protobuf:"varint,12,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	SnapshotFormat       uint64     `protobuf:"varint,13,opt,name=snapshotFormat,proto3" json:"snapshotFormat,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
 	XXX_unrecognized     []byte     `json:"-"`
 	XXX_sizecache        int32      `json:"-"`
@@ -2451,6 +2812,20 @@ func (m *Store) GetDestroyed() bool {
 	return false
 }
 
+func (m *Store) GetEpoch() uint64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *Store) GetSnapshotFormat() uint64 {
+	if m != nil {
+		return m.SnapshotFormat
+	}
+	return 0
+}
+
 // ShardsPool shards pool
 type ShardsPool struct {
 	Pools                map[uint64]*ShardPool `protobuf:"bytes,1,rep,name=pools,proto3" json:"pools,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
@@ -2922,574 +3297,882 @@ func (m *EpochLease) GetReplicaID() uint64 {
 	return 0
 }
 
-func init() {
-	proto.RegisterEnum("metapb.ShardType", ShardType_name, ShardType_value)
-	proto.RegisterEnum("metapb.StoreState", StoreState_name, StoreState_value)
-	proto.RegisterEnum("metapb.ShardState", ShardState_name, ShardState_value)
-	proto.RegisterEnum("metapb.ConfigChangeType", ConfigChangeType_name, ConfigChangeType_value)
-	proto.RegisterEnum("metapb.ReplicaRole", ReplicaRole_name, ReplicaRole_value)
-	proto.RegisterEnum("metapb.CheckPolicy", CheckPolicy_name, CheckPolicy_value)
-	proto.RegisterEnum("metapb.OperatorStatus", OperatorStatus_name, OperatorStatus_value)
-	proto.RegisterEnum("metapb.JobType", JobType_name, JobType_value)
-	proto.RegisterEnum("metapb.JobState", JobState_name, JobState_value)
-	proto.RegisterEnum("metapb.ReplicaState", ReplicaState_name, ReplicaState_value)
-	proto.RegisterEnum("metapb.ShardsPoolCmdType", ShardsPoolCmdType_name, ShardsPoolCmdType_value)
-	proto.RegisterType((*ShardEpoch)(nil), "metapb.ShardEpoch")
-	proto.RegisterType((*Replica)(nil), "metapb.Replica")
-	proto.RegisterType((*ReplicaStats)(nil), "metapb.ReplicaStats")
-	proto.RegisterType((*Label)(nil), "metapb.Label")
-	proto.RegisterType((*ShardStats)(nil), "metapb.ShardStats")
-	proto.RegisterType((*StoreStats)(nil), "metapb.StoreStats")
-	proto.RegisterType((*RecordPair)(nil), "metapb.RecordPair")
-	proto.RegisterType((*Member)(nil), "metapb.Member")
-	proto.RegisterType((*ProphetCluster)(nil), "metapb.ProphetCluster")
-	proto.RegisterType((*TimeInterval)(nil), "metapb.TimeInterval")
-	proto.RegisterType((*Job)(nil), "metapb.Job")
-	proto.RegisterType((*RemoveShardJob)(nil), "metapb.RemoveShardJob")
-	proto.RegisterType((*ShardPoolJob)(nil), "metapb.ShardPoolJob")
-	proto.RegisterType((*ShardPoolJobMeta)(nil), "metapb.ShardPoolJobMeta")
-	proto.RegisterType((*DestroyingStatus)(nil), "metapb.DestroyingStatus")
-	proto.RegisterMapType((map[uint64]bool)(nil), "metapb.DestroyingStatus.ReplicasEntry")
-	proto.RegisterType((*ShardExtra)(nil), "metapb.ShardExtra")
-	proto.RegisterMapType((map[string]string)(nil), "metapb.ShardExtra.LabelsEntry")
-	proto.RegisterType((*ScheduleGroupRule)(nil), "metapb.ScheduleGroupRule")
-	proto.RegisterType((*RaftMessageBatch)(nil), "metapb.RaftMessageBatch")
-	proto.RegisterType((*RaftMessage)(nil), "metapb.RaftMessage")
-	proto.RegisterType((*SnapshotChunk)(nil), "metapb.SnapshotChunk")
-	proto.RegisterType((*StoreIdent)(nil), "metapb.StoreIdent")
-	proto.RegisterType((*Shard)(nil), "metapb.Shard")
-	proto.RegisterType((*LogIndex)(nil), "metapb.LogIndex")
-	proto.RegisterType((*ShardMetadata)(nil), "metapb.ShardMetadata")
-	proto.RegisterType((*ShardLocalState)(nil), "metapb.ShardLocalState")
-	proto.RegisterType((*Store)(nil), "metapb.Store")
-	proto.RegisterType((*ShardsPool)(nil), "metapb.ShardsPool")
-	proto.RegisterMapType((map[uint64]*ShardPool)(nil), "metapb.ShardsPool.PoolsEntry")
-	proto.RegisterType((*ShardPool)(nil), "metapb.ShardPool")
-	proto.RegisterType((*AllocatedShard)(nil), "metapb.AllocatedShard")
-	proto.RegisterType((*ShardsPoolCmd)(nil), "metapb.ShardsPoolCmd")
-	proto.RegisterType((*ShardsPoolCreateCmd)(nil), "metapb.ShardsPoolCreateCmd")
-	proto.RegisterType((*ShardsPoolAllocCmd)(nil), "metapb.ShardsPoolAllocCmd")
-	proto.RegisterType((*SnapshotInfo)(nil), "metapb.SnapshotInfo")
-	proto.RegisterType((*EpochLease)(nil), "metapb.EpochLease")
+// ReplicaReadStats the read load of a replica
+type ReplicaReadStats struct {
+	ShardID              uint64   `protobuf:"varint,1,opt,name=shardID,proto3" json:"shardID,omitempty"`
+	ReplicaID            uint64   `protobuf:"varint,2,opt,name=replicaID,proto3" json:"replicaID,omitempty"`
+	ReadBytes            uint64   `protobuf:"varint,3,opt,name=readBytes,proto3" json:"readBytes,omitempty"`
+	ReadKeys             uint64   `protobuf:"varint,4,opt,name=readKeys,proto3" json:"readKeys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func init() { proto.RegisterFile("metapb.proto", fileDescriptor_77b4d575d5a68dda) }
-
-var fileDescriptor_77b4d575d5a68dda = []byte{
-	// 2431 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x59, 0x4f, 0x73, 0x23, 0x47,
-	0x15, 0xf7, 0x8c, 0x64, 0x5b, 0x7a, 0xb2, 0xec, 0x71, 0x67, 0x09, 0xc2, 0x84, 0x8d, 0x6b, 0x08,
-	0x89, 0x23, 0x12, 0x3b, 0xec, 0x6e, 0x52, 0x49, 0xa0, 0xa8, 0xc8, 0x92, 0x49, 0x94, 0xf5, 0x7a,
-	0x5d, 0xa3, 0x75, 0x80, 0x63, 0x4b, 0xd3, 0x92, 0xa7, 0x76, 0x66, 0x7a, 0x32, 0xd3, 0x72, 0x56,
-	0x54, 0x51, 0xc5, 0x99, 0x03, 0xdf, 0x82, 0x1b, 0x27, 0x8e, 0x9c, 0xb8, 0x50, 0xe4, 0x46, 0xce,
-	0x1c, 0x52, 0xb0, 0x5f, 0x81, 0x3b, 0x45, 0xf5, 0xeb, 0x9e, 0x99, 0x1e, 0xc9, 0xf6, 0x2e, 0x17,
-	0x6b, 0xde, 0xeb, 0xf7, 0xfa, 0xcf, 0xfb, 0xfb, 0xeb, 0x36, 0x6c, 0x45, 0x4c, 0xd0, 0x64, 0x7c,
-	0x98, 0xa4, 0x5c, 0x70, 0xb2, 0xa1, 0xa8, 0xbd, 0x77, 0x67, 0x81, 0xb8, 0x9c, 0x8f, 0x0f, 0x27,
-	0x3c, 0x3a, 0x9a, 0xf1, 0x19, 0x3f, 0xc2, 0xe1, 0xf1, 0x7c, 0x8a, 0x14, 0x12, 0xf8, 0xa5, 0xd4,
-	0xf6, 0xde, 0x9e, 0xf1, 0x43, 0x26, 0x26, 0xfe, 0x61, 0xc0, 0x8f, 0xe4, 0xef, 0x51, 0x4a, 0xa7,
-	0xe2, 0xe8, 0xea, 0x3e, 0xfe, 0x26, 0x63, 0xfc, 0x51, 0xa2, 0xee, 0xe7, 0x00, 0xa3, 0x4b, 0x9a,
-	0xfa, 0x27, 0x09, 0x9f, 0x5c, 0x92, 0xd7, 0xa0, 0x39, 0xe1, 0xf1, 0x34, 0x98, 0x7d, 0xc1, 0xd2,
-	0x8e, 0xb5, 0x6f, 0x1d, 0xd4, 0xbd, 0x92, 0x41, 0xee, 0x02, 0xcc, 0x58, 0xcc, 0x52, 0x2a, 0x02,
-	0x1e, 0x77, 0x6c, 0x1c, 0x36, 0x38, 0xee, 0xef, 0x2d, 0xd8, 0xf4, 0x58, 0x12, 0x06, 0x13, 0x4a,
-	0x5e, 0x05, 0x3b, 0xf0, 0xd5, 0x14, 0xc7, 0x1b, 0xcf, 0xbf, 0x7d, 0xdd, 0x1e, 0x0e, 0x3c, 0x3b,
-	0xf0, 0x49, 0x07, 0x36, 0x33, 0xc1, 0x53, 0x36, 0x1c, 0xe8, 0x09, 0x72, 0x92, 0xbc, 0x05, 0xf5,
-	0x94, 0x87, 0xac, 0x53, 0xdb, 0xb7, 0x0e, 0xb6, 0xef, 0xbd, 0x72, 0xa8, 0x0d, 0xa1, 0x27, 0xf4,
-	0x78, 0xc8, 0x3c, 0x14, 0x20, 0x6f, 0x40, 0x3b, 0x88, 0x03, 0x11, 0xd0, 0xf0, 0x11, 0x8b, 0xc6,
-	0x2c, 0xed, 0xd4, 0xf7, 0xad, 0x83, 0x86, 0x57, 0x65, 0xba, 0x14, 0xb6, 0xb4, 0xea, 0x48, 0x50,
-	0x91, 0x91, 0x23, 0xd8, 0x4c, 0x15, 0x8d, 0xbb, 0x6a, 0xdd, 0xdb, 0x59, 0x5a, 0xe1, 0xb8, 0xfe,
-	0xf5, 0xb7, 0xaf, 0xaf, 0x79, 0xb9, 0x14, 0xd9, 0x87, 0x96, 0xcf, 0xbf, 0x8a, 0x47, 0x6c, 0xc2,
-	0x63, 0x3f, 0xd3, 0xbb, 0x35, 0x59, 0xee, 0x11, 0xac, 0x9f, 0xd2, 0x31, 0x0b, 0x89, 0x03, 0xb5,
-	0xa7, 0x6c, 0x81, 0xf3, 0x36, 0x3d, 0xf9, 0x49, 0xee, 0xc0, 0xfa, 0x15, 0x0d, 0xe7, 0x0c, 0xd5,
-	0x9a, 0x9e, 0x22, 0xdc, 0x3f, 0xd9, 0xda, 0xda, 0x6a, 0x4b, 0xd2, 0x16, 0x92, 0x1a, 0x0e, 0xb4,
-	0xad, 0x73, 0x92, 0xb8, 0xb0, 0xf5, 0x55, 0x1a, 0x08, 0xc1, 0xe2, 0xe3, 0x85, 0x60, 0xf9, 0xe2,
-	0x15, 0x9e, 0xdc, 0x9f, 0xa6, 0x1f, 0xb2, 0x45, 0x86, 0x66, 0xab, 0x7b, 0x26, 0x4b, 0x7a, 0x33,
-	0x65, 0xd4, 0x57, 0x53, 0xd4, 0x95, 0x37, 0x0b, 0x06, 0xd9, 0x83, 0x86, 0x24, 0x50, 0x79, 0x1d,
-	0x07, 0x0b, 0x9a, 0x1c, 0xc0, 0x0e, 0x4d, 0x92, 0x94, 0x3f, 0x0b, 0x22, 0x2a, 0xd8, 0x28, 0xf8,
-	0x0d, 0xeb, 0x6c, 0xa0, 0xc8, 0x32, 0x7b, 0x49, 0x12, 0x27, 0xdb, 0x5c, 0x91, 0xc4, 0x39, 0xdf,
-	0x83, 0x46, 0x10, 0x0b, 0x96, 0x5e, 0xd1, 0xb0, 0xd3, 0x40, 0x0f, 0xdc, 0xc9, 0x3d, 0xf0, 0x24,
-	0x88, 0xd8, 0x50, 0x8f, 0x79, 0x85, 0x94, 0xfb, 0xd7, 0x75, 0x80, 0x91, 0x8c, 0x8e, 0xd2, 0x5c,
-	0x3a, 0x74, 0xac, 0x6a, 0xe8, 0xbc, 0x06, 0xcd, 0x4c, 0xd0, 0x54, 0xc8, 0x79, 0xb4, 0xad, 0x4a,
-	0x46, 0x65, 0xe1, 0xda, 0xcb, 0x2c, 0x2c, 0x4d, 0x33, 0xa1, 0x09, 0x9d, 0x04, 0x62, 0xa1, 0xed,
-	0x56, 0xd0, 0x72, 0x2d, 0x7a, 0x45, 0x83, 0x90, 0x8e, 0x43, 0xa6, 0xed, 0x56, 0x32, 0xa4, 0xe6,
-	0x3c, 0x63, 0xbe, 0x61, 0xb1, 0x82, 0x26, 0xaf, 0xc2, 0x46, 0x90, 0x1d, 0xcf, 0xb3, 0x05, 0x5a,
-	0xa8, 0xe1, 0x69, 0x4a, 0xa6, 0x15, 0xfa, 0xbd, 0xcf, 0xe7, 0xb1, 0x40, 0xd3, 0xd4, 0x3d, 0x83,
-	0x43, 0xba, 0xe0, 0x64, 0x2c, 0xf6, 0x83, 0x78, 0x36, 0x8a, 0x69, 0xa2, 0xa4, 0x9a, 0x28, 0xb5,
-	0xc2, 0x27, 0x87, 0x40, 0x52, 0x36, 0x61, 0xc1, 0x55, 0x45, 0x1a, 0x50, 0xfa, 0x9a, 0x11, 0xf2,
-	0x0e, 0xec, 0xd2, 0x24, 0x09, 0x17, 0x15, 0xf1, 0x16, 0x8a, 0xaf, 0x0e, 0xac, 0x84, 0xe5, 0xd6,
-	0x35, 0x61, 0x59, 0x09, 0xba, 0xf6, 0x72, 0xd0, 0x2d, 0x05, 0xed, 0xf6, 0x6a, 0xd0, 0x9a, 0x61,
-	0xb9, 0xb3, 0x14, 0x96, 0x1f, 0x40, 0x73, 0x92, 0xcc, 0x2f, 0x32, 0x3a, 0x63, 0x59, 0xc7, 0xd9,
-	0xaf, 0x1d, 0xb4, 0xee, 0x91, 0x32, 0x8b, 0x27, 0x3c, 0xf5, 0xcf, 0x69, 0x90, 0xea, 0x44, 0x2e,
-	0x45, 0xc9, 0xc7, 0xd0, 0x92, 0x73, 0x0c, 0x1f, 0x7b, 0x54, 0xee, 0x6a, 0xf7, 0x05, 0x9a, 0xa6,
-	0x30, 0xf9, 0x99, 0x3a, 0x33, 0xcb, 0x95, 0xc9, 0x0b, 0x94, 0x2b, 0xd2, 0xee, 0x03, 0x80, 0x52,
-	0xe2, 0x45, 0x75, 0xa2, 0x9e, 0xd7, 0x89, 0xcf, 0x60, 0x43, 0x55, 0xb1, 0x1b, 0xcb, 0x28, 0x81,
-	0x7a, 0x4c, 0xa3, 0xbc, 0xbc, 0xe0, 0xb7, 0xe4, 0x51, 0xdf, 0x4f, 0x31, 0xc6, 0x9b, 0x1e, 0x7e,
-	0xbb, 0x1e, 0x6c, 0x9f, 0xa7, 0x3c, 0xb9, 0x64, 0xa2, 0x1f, 0xce, 0x33, 0x71, 0xcb, 0x8c, 0x07,
-	0xb0, 0x13, 0xd1, 0x67, 0xba, 0x16, 0xaa, 0x38, 0x90, 0x93, 0xb7, 0xbd, 0x65, 0xb6, 0xfb, 0x01,
-	0x6c, 0x99, 0x79, 0x23, 0xcf, 0x80, 0xc9, 0xa6, 0xb3, 0x52, 0x11, 0xf2, 0xac, 0x2c, 0xf6, 0xf5,
-	0xb9, 0xe4, 0xa7, 0x1b, 0x42, 0xed, 0x73, 0x3e, 0x26, 0x3f, 0x84, 0xba, 0x58, 0x24, 0x0c, 0xa5,
-	0xb7, 0xcb, 0x2a, 0xfc, 0x39, 0x1f, 0x3f, 0x59, 0x24, 0xcc, 0xc3, 0x41, 0x99, 0xeb, 0x13, 0x1e,
-	0x0b, 0xa6, 0x77, 0xb1, 0xe5, 0xe5, 0x24, 0x79, 0x13, 0x57, 0x13, 0x79, 0x9f, 0x70, 0x0c, 0x7d,
-	0x59, 0x26, 0x98, 0xa7, 0x86, 0x5d, 0x06, 0xdb, 0x1e, 0x8b, 0xf8, 0x15, 0xc3, 0x82, 0x2b, 0x17,
-	0xde, 0x5f, 0x2a, 0xb7, 0xc5, 0xf1, 0x8b, 0xb2, 0xfb, 0x13, 0x19, 0x7b, 0x78, 0x52, 0x59, 0x72,
-	0x6b, 0x37, 0x37, 0x89, 0x42, 0xcc, 0x1d, 0xc0, 0x16, 0x2e, 0x70, 0xce, 0x79, 0x28, 0x17, 0x79,
-	0x00, 0xeb, 0x09, 0xe7, 0x61, 0xd6, 0xb1, 0x50, 0xbf, 0x93, 0xeb, 0x9b, 0x42, 0x8f, 0x98, 0xc8,
-	0x27, 0x52, 0xc2, 0xee, 0x14, 0x9c, 0x65, 0x01, 0x69, 0xd6, 0x59, 0xca, 0xe7, 0x49, 0x6e, 0x56,
-	0x24, 0x2a, 0xa5, 0xc9, 0x5e, 0x2a, 0x4d, 0xfb, 0xd0, 0x4a, 0x69, 0x3c, 0x63, 0xe7, 0x29, 0x9b,
-	0x06, 0xcf, 0xd0, 0x40, 0x5b, 0x9e, 0xc9, 0x72, 0xff, 0x63, 0x81, 0x33, 0x60, 0x99, 0x48, 0x39,
-	0x26, 0xb6, 0xa0, 0x62, 0x9e, 0xc9, 0x85, 0x82, 0xd8, 0x67, 0xcf, 0xf2, 0x85, 0x90, 0x20, 0xc7,
-	0x2b, 0xb6, 0x78, 0x33, 0x3f, 0xcb, 0xf2, 0x0c, 0xb9, 0x71, 0xb2, 0x93, 0x58, 0xa4, 0x8b, 0xd2,
-	0x38, 0xe4, 0xa0, 0xea, 0x2b, 0x52, 0x31, 0x86, 0xe9, 0x2d, 0x59, 0x03, 0x53, 0xf4, 0xd6, 0x80,
-	0x0a, 0xaa, 0x1b, 0xba, 0xc1, 0xd9, 0xfb, 0x29, 0xb4, 0x2b, 0x8b, 0x98, 0xa9, 0x54, 0xbf, 0x26,
-	0x95, 0x1a, 0x3a, 0x95, 0x3e, 0xb6, 0x3f, 0xb4, 0xdc, 0xbf, 0x59, 0x39, 0xc8, 0x79, 0x26, 0x52,
-	0x4a, 0x3e, 0x80, 0x8d, 0x50, 0xb6, 0xed, 0xdc, 0x47, 0x77, 0x2b, 0xdb, 0x42, 0x99, 0x43, 0xec,
-	0xeb, 0xfa, 0x3c, 0x5a, 0x9a, 0x0c, 0xc0, 0xf1, 0x97, 0x4e, 0x8e, 0x6b, 0x19, 0x5e, 0x5e, 0xb6,
-	0x8c, 0xb7, 0xa2, 0xb1, 0xf7, 0x11, 0xb4, 0x8c, 0xc9, 0x5f, 0x16, 0x3a, 0xe0, 0x39, 0x7e, 0x0b,
-	0xbb, 0xa3, 0xc9, 0x25, 0xf3, 0xe7, 0x21, 0xfb, 0x54, 0x06, 0x83, 0x37, 0x0f, 0xd9, 0x6d, 0x40,
-	0x0b, 0x23, 0xa6, 0x04, 0x5a, 0x9a, 0x2c, 0x6a, 0x47, 0xcd, 0xa8, 0x1d, 0x2e, 0x6c, 0xe1, 0xf0,
-	0xf1, 0x02, 0x37, 0x87, 0x1e, 0x68, 0x7a, 0x15, 0x9e, 0x3b, 0x04, 0xc7, 0xa3, 0x53, 0xf1, 0x88,
-	0x65, 0xb2, 0xaa, 0x1e, 0x53, 0x31, 0xb9, 0x24, 0xef, 0x43, 0x23, 0x52, 0x74, 0x6e, 0xcd, 0x12,
-	0xb8, 0x19, 0xb2, 0x3a, 0x6b, 0x72, 0x51, 0xf7, 0x2f, 0x35, 0x68, 0x19, 0xe3, 0xb7, 0x20, 0xa1,
-	0x22, 0x0b, 0x6c, 0x33, 0x0b, 0xde, 0x86, 0xfa, 0x34, 0xe5, 0x91, 0x6e, 0xe7, 0x37, 0x24, 0x29,
-	0x8a, 0x90, 0x1f, 0x81, 0x2d, 0x38, 0x9e, 0xe7, 0x46, 0x41, 0x5b, 0x70, 0x09, 0x0f, 0xf5, 0xee,
-	0xb0, 0xa9, 0x4b, 0x59, 0x05, 0x96, 0x0f, 0xab, 0x67, 0xc8, 0xa5, 0xc8, 0x87, 0xba, 0x6b, 0x23,
-	0x70, 0xc6, 0x5e, 0xdf, 0x5a, 0x0a, 0x70, 0x1c, 0xd1, 0x6a, 0x86, 0xac, 0x4c, 0xd3, 0x20, 0x7b,
-	0xc2, 0xa3, 0x71, 0x26, 0x78, 0xcc, 0x34, 0x18, 0x30, 0x59, 0x65, 0x45, 0x6d, 0x60, 0x0a, 0x57,
-	0x2b, 0x6a, 0x13, 0x79, 0xf2, 0x53, 0x22, 0x8a, 0x79, 0x1c, 0x7c, 0x39, 0x67, 0xd8, 0xe1, 0x9b,
-	0x9e, 0xa6, 0x30, 0x9b, 0xf2, 0x20, 0xc9, 0x3a, 0xad, 0xfd, 0xda, 0x41, 0xd3, 0x33, 0x38, 0x72,
-	0x07, 0x13, 0x1e, 0x45, 0x81, 0x18, 0x62, 0xde, 0xab, 0x36, 0x6e, 0xb2, 0x64, 0x99, 0x91, 0xd8,
-	0x02, 0x01, 0x95, 0x6a, 0xe2, 0x05, 0xed, 0xfe, 0xb3, 0x06, 0x6d, 0x89, 0x09, 0xb2, 0x4b, 0x2e,
-	0xfa, 0x97, 0xf3, 0xf8, 0xe9, 0x2d, 0xc8, 0xcc, 0x70, 0xac, 0x5d, 0x75, 0x2c, 0xe2, 0x04, 0xf4,
-	0xc2, 0x70, 0xa0, 0xc1, 0x6b, 0xc9, 0x90, 0x31, 0x8a, 0x0e, 0x56, 0xe8, 0x4b, 0x79, 0x52, 0xf6,
-	0x04, 0xb9, 0xdc, 0x70, 0xa0, 0x71, 0x57, 0x4e, 0xe2, 0xb5, 0x45, 0x7e, 0x1a, 0xb0, 0xab, 0x64,
-	0x48, 0x6b, 0x20, 0xa1, 0x9a, 0x9a, 0x42, 0xa7, 0x06, 0xa7, 0xac, 0x7f, 0x0d, 0xb3, 0xfe, 0x11,
-	0xa8, 0x0b, 0x96, 0x46, 0x1a, 0x69, 0xe1, 0xb7, 0xb4, 0xca, 0x34, 0x08, 0xd9, 0x39, 0x15, 0x97,
-	0xda, 0xe2, 0x05, 0x9d, 0x8f, 0xe1, 0x16, 0x14, 0x80, 0x2a, 0x68, 0x69, 0x6f, 0xf9, 0xdd, 0xd7,
-	0xbb, 0xd7, 0xf6, 0x36, 0x58, 0xe4, 0x4d, 0xd8, 0x2e, 0x48, 0xb5, 0x4f, 0x65, 0xf5, 0x25, 0xae,
-	0xdc, 0x95, 0x2f, 0x2b, 0xe4, 0x36, 0x06, 0x01, 0x7e, 0xcb, 0xfd, 0x33, 0x59, 0xb4, 0x10, 0x2e,
-	0x6d, 0x79, 0x8a, 0x20, 0xef, 0xab, 0xab, 0x1c, 0x56, 0xd9, 0x8e, 0x83, 0xe1, 0xb9, 0x9b, 0x87,
-	0x74, 0x3f, 0x1f, 0x28, 0xa0, 0x52, 0xce, 0x70, 0x07, 0x1a, 0x72, 0x0f, 0x7d, 0xd9, 0x6c, 0xa5,
-	0x61, 0x15, 0x6e, 0x28, 0x5c, 0x5b, 0x32, 0x6e, 0xbe, 0xcb, 0xb9, 0xff, 0xb0, 0x61, 0x1d, 0x73,
-	0xe0, 0xc6, 0xf2, 0x54, 0x84, 0xb8, 0x7d, 0x4d, 0x88, 0xd7, 0xca, 0x10, 0x3f, 0x84, 0x75, 0x86,
-	0x19, 0x56, 0x7f, 0x41, 0x86, 0x29, 0xb1, 0xb2, 0xe5, 0xac, 0xbf, 0xa8, 0xe5, 0x98, 0xcd, 0x7e,
-	0xe3, 0xa5, 0x9a, 0x7d, 0x59, 0x8c, 0x36, 0xcd, 0x62, 0x54, 0x66, 0x61, 0xe3, 0x96, 0x2c, 0x6c,
-	0xae, 0x64, 0xe1, 0x8f, 0x8b, 0x3e, 0x04, 0xb8, 0x7c, 0x3b, 0x5f, 0x1e, 0xcb, 0xad, 0x5e, 0x5c,
-	0x8b, 0xb8, 0x0f, 0xa0, 0x71, 0xca, 0x67, 0x2a, 0x39, 0xaf, 0x6f, 0xd8, 0x79, 0xc0, 0xda, 0x65,
-	0xc0, 0xba, 0xbf, 0xb3, 0xa0, 0x8d, 0x27, 0x97, 0x88, 0x02, 0x83, 0xe5, 0xe6, 0x4a, 0xbb, 0x07,
-	0x8d, 0x50, 0xaf, 0x90, 0x23, 0x8b, 0x9c, 0x26, 0x1f, 0xc9, 0x32, 0xaf, 0x66, 0xd0, 0x35, 0xf7,
-	0xbb, 0x15, 0xc3, 0x9e, 0xf2, 0x09, 0x0d, 0xcd, 0x88, 0x2a, 0xc4, 0xdd, 0x3f, 0x5b, 0xb0, 0xb3,
-	0x24, 0x43, 0xde, 0x86, 0x75, 0x5c, 0x55, 0xdf, 0xc4, 0xdb, 0x95, 0xb9, 0x72, 0x7f, 0xa2, 0x84,
-	0xf4, 0x67, 0xc8, 0x68, 0xc6, 0x74, 0xa7, 0x2d, 0xfc, 0x89, 0xae, 0x3f, 0x95, 0x23, 0x9e, 0x12,
-	0x20, 0xdd, 0x2a, 0xd8, 0xb8, 0xb3, 0xe4, 0xcc, 0xff, 0x07, 0x6e, 0xb8, 0xff, 0x95, 0xf1, 0x2b,
-	0x63, 0xf9, 0xc6, 0xf8, 0x45, 0xac, 0x35, 0x15, 0x3d, 0xdf, 0x4f, 0x59, 0x96, 0xe9, 0x5e, 0x6d,
-	0xb2, 0xc8, 0x1b, 0xd0, 0x9e, 0x84, 0x01, 0x8b, 0x0b, 0x19, 0xd5, 0x6f, 0xab, 0x4c, 0x23, 0x08,
-	0xea, 0x2f, 0x0c, 0x82, 0x9b, 0x83, 0x3b, 0xbf, 0x24, 0x17, 0x07, 0xac, 0xdc, 0x88, 0x65, 0x45,
-	0xac, 0x99, 0x37, 0xe2, 0x77, 0x60, 0x37, 0xa4, 0x99, 0xf8, 0x8c, 0xd1, 0x54, 0x8c, 0x19, 0x55,
-	0x52, 0x9b, 0x28, 0xb5, 0x3a, 0x20, 0x43, 0xe6, 0x8a, 0xa5, 0x59, 0xc0, 0x63, 0x1d, 0xe0, 0x39,
-	0x89, 0x60, 0x54, 0x35, 0x8d, 0x01, 0xd6, 0xc9, 0xa6, 0x57, 0xd0, 0xd2, 0xc4, 0x3e, 0x4b, 0x42,
-	0xbe, 0x30, 0xaa, 0xa5, 0xc1, 0x91, 0x3b, 0xd4, 0xd8, 0x88, 0xf9, 0x58, 0x30, 0x1b, 0x5e, 0xc9,
-	0x70, 0xff, 0x90, 0x43, 0xb6, 0x4c, 0x42, 0x62, 0x72, 0xbf, 0x8a, 0xaa, 0x7f, 0x50, 0x09, 0x18,
-	0x14, 0x39, 0x94, 0x7f, 0x34, 0x60, 0x53, 0xb2, 0x7b, 0x0f, 0x01, 0x4a, 0xe6, 0x35, 0x80, 0xf1,
-	0x2d, 0x13, 0x68, 0xc9, 0xea, 0xb8, 0x0c, 0xd5, 0x4d, 0xec, 0xf5, 0x77, 0x0b, 0x9a, 0xc5, 0x40,
-	0x05, 0x85, 0x5b, 0xb7, 0xa3, 0x70, 0x7b, 0x05, 0x85, 0x93, 0x4f, 0x60, 0x87, 0x86, 0x21, 0x9f,
-	0x50, 0xc1, 0x7c, 0x75, 0x82, 0x4e, 0x0d, 0xcf, 0xf5, 0x6a, 0xbe, 0x85, 0x5e, 0x65, 0xd8, 0x5b,
-	0x16, 0x97, 0x87, 0xc9, 0xd8, 0x97, 0xba, 0x3b, 0xca, 0x4f, 0x7c, 0x87, 0xc9, 0x85, 0x1e, 0x4f,
-	0xa7, 0x19, 0x13, 0xba, 0x49, 0x2e, 0xb3, 0xdd, 0x29, 0x6c, 0x57, 0xa7, 0xbf, 0xa5, 0x26, 0xec,
-	0x43, 0xab, 0x50, 0xef, 0x89, 0xfc, 0x0d, 0xcc, 0x60, 0x49, 0xdd, 0x64, 0x9e, 0x26, 0x3c, 0x63,
-	0xba, 0x6a, 0xe7, 0xa4, 0xfb, 0xc7, 0xbc, 0xf6, 0xa0, 0x7f, 0xfa, 0x91, 0x4f, 0xde, 0xad, 0xdc,
-	0xfc, 0xbe, 0xb7, 0xea, 0xc4, 0x7e, 0xe4, 0x1b, 0x77, 0xc0, 0xfb, 0xb0, 0x31, 0x49, 0x99, 0x0c,
-	0x77, 0xe5, 0xa0, 0xef, 0x5f, 0xa3, 0x80, 0xe3, 0xfd, 0xc8, 0xf7, 0xb4, 0x28, 0x79, 0x0f, 0xd6,
-	0x71, 0x7b, 0xba, 0x4c, 0xed, 0xad, 0xea, 0xe0, 0xe1, 0xa5, 0x8a, 0x12, 0x74, 0xbf, 0x03, 0xaf,
-	0x5c, 0x33, 0xa1, 0x3b, 0x00, 0xb2, 0xaa, 0x73, 0xc3, 0xa5, 0xcc, 0x30, 0x82, 0x5d, 0x35, 0xc2,
-	0xc7, 0xb0, 0x95, 0x43, 0xa5, 0x61, 0x3c, 0xe5, 0x65, 0xaf, 0xd6, 0xfa, 0xaa, 0x57, 0xdf, 0x81,
-	0x75, 0x7f, 0x1e, 0x45, 0x8b, 0xfc, 0xea, 0x82, 0x84, 0xfb, 0x09, 0x40, 0x59, 0xe5, 0x50, 0x13,
-	0x1b, 0x61, 0xae, 0x99, 0x3f, 0xd8, 0x96, 0x28, 0xca, 0x5e, 0x42, 0x51, 0xdd, 0xae, 0x8e, 0x59,
-	0x69, 0x54, 0xb2, 0x0d, 0x70, 0xca, 0xa8, 0xcf, 0xd2, 0xc7, 0x71, 0xb8, 0x70, 0xd6, 0x48, 0x1b,
-	0x9a, 0xbd, 0x30, 0x54, 0x67, 0x74, 0xac, 0xee, 0x3d, 0xe3, 0xad, 0x8d, 0x91, 0x0d, 0xb0, 0x2f,
-	0x12, 0x67, 0x8d, 0x34, 0xa0, 0x3e, 0xe0, 0x5f, 0xc5, 0x8e, 0x45, 0x08, 0x6c, 0xe3, 0x78, 0x81,
-	0x52, 0x1d, 0xbb, 0xfb, 0x0b, 0xe3, 0x39, 0x93, 0x91, 0x16, 0x6c, 0x7a, 0xf3, 0x38, 0x0e, 0xe2,
-	0x99, 0xb3, 0x46, 0xb6, 0xa0, 0x81, 0xb6, 0x94, 0x94, 0x25, 0xd7, 0x2e, 0xaf, 0x46, 0x8e, 0x2d,
-	0xd7, 0x1e, 0xe4, 0xb9, 0xee, 0xd4, 0xba, 0x23, 0x70, 0xfa, 0xf8, 0xca, 0xdc, 0xbf, 0x94, 0x69,
-	0x82, 0xdb, 0x6d, 0xc1, 0x66, 0xcf, 0xf7, 0xcf, 0xb8, 0xcf, 0x9c, 0x35, 0xa9, 0xaf, 0x2e, 0xf3,
-	0x48, 0xe3, 0x7c, 0x17, 0x89, 0x4f, 0x85, 0xa2, 0x6d, 0xb9, 0xb9, 0x9e, 0xef, 0x9f, 0x32, 0x9a,
-	0xc6, 0x2c, 0x45, 0x5e, 0xad, 0xfb, 0x10, 0x5a, 0xc6, 0xdb, 0x31, 0x69, 0xc2, 0xfa, 0x17, 0x5c,
-	0xb0, 0xd4, 0x59, 0x93, 0x53, 0x6b, 0x51, 0xc7, 0x22, 0xbb, 0xd0, 0x1e, 0xc6, 0x13, 0x1e, 0x05,
-	0xf1, 0x4c, 0x8d, 0xdb, 0x92, 0x35, 0x60, 0x11, 0x17, 0x05, 0xab, 0xd6, 0x7d, 0x00, 0xad, 0xfe,
-	0x25, 0x9b, 0x3c, 0x3d, 0xe7, 0x61, 0x30, 0x59, 0x48, 0xb3, 0x8c, 0xfa, 0xbd, 0x33, 0x67, 0x8d,
-	0xec, 0x40, 0xab, 0x77, 0x7e, 0xee, 0x3d, 0xfe, 0xd5, 0xf0, 0x51, 0xef, 0xc9, 0x89, 0x63, 0x11,
-	0x80, 0x8d, 0x8b, 0xd1, 0xc9, 0xc3, 0x93, 0x5f, 0x3b, 0x76, 0xf7, 0x1c, 0xb6, 0x1f, 0x27, 0x2c,
-	0xa5, 0x82, 0xa7, 0xfa, 0xae, 0xdd, 0x82, 0xcd, 0xd1, 0x45, 0xbf, 0x7f, 0x32, 0x1a, 0xa9, 0x7d,
-	0x3c, 0x19, 0x3e, 0x3a, 0x79, 0x7c, 0xf1, 0x44, 0xe9, 0xf5, 0x7b, 0x67, 0xfd, 0x93, 0x53, 0xc7,
-	0x46, 0x4b, 0x9e, 0x9c, 0x9f, 0xf6, 0xfa, 0x27, 0x4e, 0x0d, 0x89, 0x8b, 0xb3, 0xb3, 0xe1, 0xd9,
-	0xa7, 0x4e, 0xbd, 0x7b, 0x0c, 0x9b, 0xfa, 0xa1, 0x44, 0xae, 0x6c, 0x3c, 0x70, 0x38, 0x6b, 0xe4,
-	0x15, 0xd8, 0x51, 0xe1, 0x5b, 0xd4, 0x29, 0x75, 0xbc, 0xfe, 0x3c, 0x13, 0x3c, 0x1a, 0xc9, 0xea,
-	0xdf, 0x13, 0x8e, 0xdf, 0xbd, 0x0f, 0x8d, 0xfc, 0xb1, 0x44, 0x4e, 0xae, 0x74, 0x7c, 0xb5, 0x9f,
-	0x5f, 0xf2, 0xf4, 0xa9, 0x72, 0x59, 0x1b, 0x9a, 0x7d, 0x1e, 0x25, 0x21, 0x93, 0x63, 0x76, 0xf7,
-	0xe7, 0x95, 0xe7, 0x74, 0x26, 0xb7, 0x7b, 0xc6, 0xd3, 0x88, 0x86, 0xca, 0xd7, 0x3d, 0xfd, 0x56,
-	0xe8, 0x58, 0xe4, 0x0e, 0x38, 0x5a, 0xd2, 0x0c, 0x95, 0x07, 0xb0, 0xbb, 0x92, 0xe7, 0xf2, 0x08,
-	0xc6, 0x8e, 0x95, 0x9f, 0x31, 0xd5, 0x14, 0x6d, 0x1d, 0x3b, 0xdf, 0xfc, 0xfb, 0xae, 0xf5, 0xf5,
-	0xf3, 0xbb, 0xd6, 0x37, 0xcf, 0xef, 0x5a, 0xff, 0x7a, 0x7e, 0xd7, 0x1a, 0x6f, 0xe0, 0xbf, 0x2d,
-	0xee, 0xff, 0x2f, 0x00, 0x00, 0xff, 0xff, 0x83, 0x65, 0x37, 0x77, 0x28, 0x19, 0x00, 0x00,
+func (m *ReplicaReadStats) Reset()         { *m = ReplicaReadStats{} }
+func (m *ReplicaReadStats) String() string { return proto.CompactTextString(m) }
+func (*ReplicaReadStats) ProtoMessage()    {}
+func (*ReplicaReadStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77b4d575d5a68dda, []int{34}
 }
-
-func (m *ShardEpoch) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+func (m *ReplicaReadStats) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ReplicaReadStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ReplicaReadStats.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return dAtA[:n], nil
+}
+func (m *ReplicaReadStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicaReadStats.Merge(m, src)
+}
+func (m *ReplicaReadStats) XXX_Size() int {
+	return m.Size()
+}
+func (m *ReplicaReadStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicaReadStats.DiscardUnknown(m)
 }
 
-func (m *ShardEpoch) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if m.ConfigVer != 0 {
-		dAtA[i] = 0x8
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ConfigVer))
+var xxx_messageInfo_ReplicaReadStats proto.InternalMessageInfo
+
+func (m *ReplicaReadStats) GetShardID() uint64 {
+	if m != nil {
+		return m.ShardID
 	}
-	if m.Generation != 0 {
-		dAtA[i] = 0x10
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Generation))
+	return 0
+}
+
+func (m *ReplicaReadStats) GetReplicaID() uint64 {
+	if m != nil {
+		return m.ReplicaID
 	}
-	if m.XXX_unrecognized != nil {
-		i += copy(dAtA[i:], m.XXX_unrecognized)
+	return 0
+}
+
+func (m *ReplicaReadStats) GetReadBytes() uint64 {
+	if m != nil {
+		return m.ReadBytes
 	}
-	return i, nil
+	return 0
 }
 
-func (m *Replica) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+func (m *ReplicaReadStats) GetReadKeys() uint64 {
+	if m != nil {
+		return m.ReadKeys
 	}
-	return dAtA[:n], nil
+	return 0
 }
 
-func (m *Replica) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if m.ID != 0 {
-		dAtA[i] = 0x8
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ID))
+// ShardReadHint the replicas that the follower reads of the shard should be
+// routed to, all replicas are used if it's empty
+type ShardReadHint struct {
+	ShardID              uint64   `protobuf:"varint,1,opt,name=shardID,proto3" json:"shardID,omitempty"`
+	Replicas             []uint64 `protobuf:"varint,2,rep,packed,name=replicas,proto3" json:"replicas,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ShardReadHint) Reset()         { *m = ShardReadHint{} }
+func (m *ShardReadHint) String() string { return proto.CompactTextString(m) }
+func (*ShardReadHint) ProtoMessage()    {}
+func (*ShardReadHint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77b4d575d5a68dda, []int{35}
+}
+func (m *ShardReadHint) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ShardReadHint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ShardReadHint.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	if m.StoreID != 0 {
-		dAtA[i] = 0x10
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.StoreID))
+}
+func (m *ShardReadHint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ShardReadHint.Merge(m, src)
+}
+func (m *ShardReadHint) XXX_Size() int {
+	return m.Size()
+}
+func (m *ShardReadHint) XXX_DiscardUnknown() {
+	xxx_messageInfo_ShardReadHint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ShardReadHint proto.InternalMessageInfo
+
+func (m *ShardReadHint) GetShardID() uint64 {
+	if m != nil {
+		return m.ShardID
 	}
-	if m.Role != 0 {
-		dAtA[i] = 0x18
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Role))
+	return 0
+}
+
+func (m *ShardReadHint) GetReplicas() []uint64 {
+	if m != nil {
+		return m.Replicas
 	}
-	if m.InitialMember {
-		dAtA[i] = 0x20
-		i++
-		if m.InitialMember {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
+	return nil
+}
+
+// ReplicaProgress the replication progress of a replica observed by the shard
+// leader
+type ReplicaProgress struct {
+	Replica Replica `protobuf:"bytes,1,opt,name=replica,proto3" json:"replica"`
+	// AppliedIndex the applied index last reported by the replica
+	AppliedIndex uint64 `protobuf:"varint,2,opt,name=appliedIndex,proto3" json:"appliedIndex,omitempty"`
+	// MatchIndex the largest log index known to be replicated to the replica
+	MatchIndex uint64 `protobuf:"varint,3,opt,name=matchIndex,proto3" json:"matchIndex,omitempty"`
+	// LastContactTime the unix milliseconds of the last message received from
+	// the replica, 0 if the leader has not heard from the replica yet
+	LastContactTime uint64 `protobuf:"varint,4,opt,name=lastContactTime,proto3" json:"lastContactTime,omitempty"`
+	// NeedSnapshot the replica can not catch up from the raft logs and needs a
+	// snapshot from the leader
+	NeedSnapshot bool `protobuf:"varint,5,opt,name=needSnapshot,proto3" json:"needSnapshot,omitempty"`
+	// SnapshotPhase the phase of the snapshot sent to the replica
+	SnapshotPhase SnapshotPhase `protobuf:"varint,6,opt,name=snapshotPhase,proto3,enum=metapb.SnapshotPhase" json:"snapshotPhase,omitempty"`
+	// SnapshotSentBytes and SnapshotTotalBytes the bytes of the snapshot sent
+	// to the replica, only set in the SendingSnapshot phase
+	SnapshotSentBytes  uint64 `protobuf:"varint,7,opt,name=snapshotSentBytes,proto3" json:"snapshotSentBytes,omitempty"`
+	SnapshotTotalBytes uint64 `protobuf:"varint,8,opt,name=snapshotTotalBytes,proto3" json:"snapshotTotalBytes,omitempty"`
+	// SnapshotFailure the reason of the last snapshot failed to send to the
+	// replica, SnapshotFailures the count of the consecutive failures
+	SnapshotFailure  SnapshotFailureReason `protobuf:"varint,9,opt,name=snapshotFailure,proto3,enum=metapb.SnapshotFailureReason" json:"snapshotFailure,omitempty"`
+	SnapshotFailures uint64                `protobuf:"varint,10,opt,name=snapshotFailures,proto3" json:"snapshotFailures,omitempty"`
+	// SnapshotQuarantined the leader stops sending the snapshots to the replica
+	// after too many consecutive failures, and probes the replica periodically
+	SnapshotQuarantined  bool     `protobuf:"varint,11,opt,name=snapshotQuarantined,proto3" json:"snapshotQuarantined,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReplicaProgress) Reset()         { *m = ReplicaProgress{} }
+func (m *ReplicaProgress) String() string { return proto.CompactTextString(m) }
+func (*ReplicaProgress) ProtoMessage()    {}
+func (*ReplicaProgress) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77b4d575d5a68dda, []int{36}
+}
+func (m *ReplicaProgress) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ReplicaProgress) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ReplicaProgress.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
 		}
-		i++
+		return b[:n], nil
 	}
-	if m.XXX_unrecognized != nil {
-		i += copy(dAtA[i:], m.XXX_unrecognized)
+}
+func (m *ReplicaProgress) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicaProgress.Merge(m, src)
+}
+func (m *ReplicaProgress) XXX_Size() int {
+	return m.Size()
+}
+func (m *ReplicaProgress) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicaProgress.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicaProgress proto.InternalMessageInfo
+
+func (m *ReplicaProgress) GetReplica() Replica {
+	if m != nil {
+		return m.Replica
 	}
-	return i, nil
+	return Replica{}
 }
 
-func (m *ReplicaStats) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+func (m *ReplicaProgress) GetAppliedIndex() uint64 {
+	if m != nil {
+		return m.AppliedIndex
 	}
-	return dAtA[:n], nil
+	return 0
 }
 
-func (m *ReplicaStats) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	dAtA[i] = 0xa
-	i++
-	i = encodeVarintMetapb(dAtA, i, uint64(m.Replica.Size()))
-	n1, err := m.Replica.MarshalTo(dAtA[i:])
-	if err != nil {
-		return 0, err
+func (m *ReplicaProgress) GetMatchIndex() uint64 {
+	if m != nil {
+		return m.MatchIndex
 	}
-	i += n1
-	if m.DownSeconds != 0 {
-		dAtA[i] = 0x10
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.DownSeconds))
+	return 0
+}
+
+func (m *ReplicaProgress) GetLastContactTime() uint64 {
+	if m != nil {
+		return m.LastContactTime
 	}
-	if m.XXX_unrecognized != nil {
-		i += copy(dAtA[i:], m.XXX_unrecognized)
+	return 0
+}
+
+func (m *ReplicaProgress) GetNeedSnapshot() bool {
+	if m != nil {
+		return m.NeedSnapshot
 	}
-	return i, nil
+	return false
 }
 
-func (m *Label) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+func (m *ReplicaProgress) GetSnapshotPhase() SnapshotPhase {
+	if m != nil {
+		return m.SnapshotPhase
 	}
-	return dAtA[:n], nil
+	return SnapshotPhase_NoSnapshot
 }
 
-func (m *Label) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if len(m.Key) > 0 {
-		dAtA[i] = 0xa
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Key)))
-		i += copy(dAtA[i:], m.Key)
+func (m *ReplicaProgress) GetSnapshotSentBytes() uint64 {
+	if m != nil {
+		return m.SnapshotSentBytes
 	}
-	if len(m.Value) > 0 {
-		dAtA[i] = 0x12
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Value)))
-		i += copy(dAtA[i:], m.Value)
+	return 0
+}
+
+func (m *ReplicaProgress) GetSnapshotTotalBytes() uint64 {
+	if m != nil {
+		return m.SnapshotTotalBytes
 	}
-	if m.XXX_unrecognized != nil {
-		i += copy(dAtA[i:], m.XXX_unrecognized)
+	return 0
+}
+
+func (m *ReplicaProgress) GetSnapshotFailure() SnapshotFailureReason {
+	if m != nil {
+		return m.SnapshotFailure
 	}
-	return i, nil
+	return SnapshotFailureReason_NoFailure
 }
 
-func (m *ShardStats) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+func (m *ReplicaProgress) GetSnapshotFailures() uint64 {
+	if m != nil {
+		return m.SnapshotFailures
 	}
-	return dAtA[:n], nil
+	return 0
 }
 
-func (m *ShardStats) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if m.ShardID != 0 {
-		dAtA[i] = 0x8
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ShardID))
+func (m *ReplicaProgress) GetSnapshotQuarantined() bool {
+	if m != nil {
+		return m.SnapshotQuarantined
 	}
-	if m.WrittenBytes != 0 {
-		dAtA[i] = 0x10
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.WrittenBytes))
+	return false
+}
+
+// SnapshotManifest the header of the snapshot, the fields added later are
+// ignored by the nodes with the older format, the changes can't be ignored
+// must be marked in the features
+type SnapshotManifest struct {
+	// FormatVersion the version of the manifest format
+	FormatVersion uint32 `protobuf:"varint,1,opt,name=formatVersion,proto3" json:"formatVersion,omitempty"`
+	// Shard the shard descriptor of the snapshot
+	Shard Shard `protobuf:"bytes,2,opt,name=shard,proto3" json:"shard"`
+	// Start and End the encoded range of the key-value pairs
+	Start []byte `protobuf:"bytes,3,opt,name=start,proto3" json:"start,omitempty"`
+	End   []byte `protobuf:"bytes,4,opt,name=end,proto3" json:"end,omitempty"`
+	// AppliedIndexKey and AppliedIndexValue the applied index of the shard
+	AppliedIndexKey   []byte `protobuf:"bytes,5,opt,name=appliedIndexKey,proto3" json:"appliedIndexKey,omitempty"`
+	AppliedIndexValue []byte `protobuf:"bytes,6,opt,name=appliedIndexValue,proto3" json:"appliedIndexValue,omitempty"`
+	// MetadataKey and MetadataValue the metadata of the shard
+	MetadataKey   []byte `protobuf:"bytes,7,opt,name=metadataKey,proto3" json:"metadataKey,omitempty"`
+	MetadataValue []byte `protobuf:"bytes,8,opt,name=metadataValue,proto3" json:"metadataValue,omitempty"`
+	// KeyCount the number of the key-value pairs
+	KeyCount uint64 `protobuf:"varint,9,opt,name=keyCount,proto3" json:"keyCount,omitempty"`
+	// ByteCount the total bytes of the keys and values
+	ByteCount uint64 `protobuf:"varint,10,opt,name=byteCount,proto3" json:"byteCount,omitempty"`
+	// DataChecksum the crc32 checksum of the key-value pairs in order
+	DataChecksum uint32 `protobuf:"varint,11,opt,name=dataChecksum,proto3" json:"dataChecksum,omitempty"`
+	// SstSize and SstChecksum the size and the crc32 checksum of the SST file
+	// if the key-value pairs are in the SST file
+	SstSize     uint64 `protobuf:"varint,12,opt,name=sstSize,proto3" json:"sstSize,omitempty"`
+	SstChecksum uint32 `protobuf:"varint,13,opt,name=sstChecksum,proto3" json:"sstChecksum,omitempty"`
+	// Features the feature flags required to apply the snapshot, the nodes
+	// not aware of any of the flags must reject the snapshot
+	Features             uint64   `protobuf:"varint,14,opt,name=features,proto3" json:"features,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SnapshotManifest) Reset()         { *m = SnapshotManifest{} }
+func (m *SnapshotManifest) String() string { return proto.CompactTextString(m) }
+func (*SnapshotManifest) ProtoMessage()    {}
+func (*SnapshotManifest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77b4d575d5a68dda, []int{37}
+}
+func (m *SnapshotManifest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SnapshotManifest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SnapshotManifest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	if m.WrittenKeys != 0 {
-		dAtA[i] = 0x18
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.WrittenKeys))
+}
+func (m *SnapshotManifest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SnapshotManifest.Merge(m, src)
+}
+func (m *SnapshotManifest) XXX_Size() int {
+	return m.Size()
+}
+func (m *SnapshotManifest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SnapshotManifest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SnapshotManifest proto.InternalMessageInfo
+
+func (m *SnapshotManifest) GetFormatVersion() uint32 {
+	if m != nil {
+		return m.FormatVersion
 	}
-	if m.ReadBytes != 0 {
-		dAtA[i] = 0x20
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ReadBytes))
+	return 0
+}
+
+func (m *SnapshotManifest) GetShard() Shard {
+	if m != nil {
+		return m.Shard
 	}
-	if m.ReadKeys != 0 {
-		dAtA[i] = 0x28
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ReadKeys))
+	return Shard{}
+}
+
+func (m *SnapshotManifest) GetStart() []byte {
+	if m != nil {
+		return m.Start
 	}
-	if m.ApproximateSize != 0 {
-		dAtA[i] = 0x30
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ApproximateSize))
+	return nil
+}
+
+func (m *SnapshotManifest) GetEnd() []byte {
+	if m != nil {
+		return m.End
 	}
-	if m.ApproximateKeys != 0 {
-		dAtA[i] = 0x38
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ApproximateKeys))
+	return nil
+}
+
+func (m *SnapshotManifest) GetAppliedIndexKey() []byte {
+	if m != nil {
+		return m.AppliedIndexKey
 	}
-	if m.Interval != nil {
-		dAtA[i] = 0x42
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Interval.Size()))
-		n2, err := m.Interval.MarshalTo(dAtA[i:])
-		if err != nil {
-			return 0, err
-		}
-		i += n2
+	return nil
+}
+
+func (m *SnapshotManifest) GetAppliedIndexValue() []byte {
+	if m != nil {
+		return m.AppliedIndexValue
 	}
-	if m.XXX_unrecognized != nil {
-		i += copy(dAtA[i:], m.XXX_unrecognized)
+	return nil
+}
+
+func (m *SnapshotManifest) GetMetadataKey() []byte {
+	if m != nil {
+		return m.MetadataKey
 	}
-	return i, nil
+	return nil
 }
 
-func (m *StoreStats) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+func (m *SnapshotManifest) GetMetadataValue() []byte {
+	if m != nil {
+		return m.MetadataValue
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *StoreStats) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if m.StoreID != 0 {
-		dAtA[i] = 0x8
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.StoreID))
+func (m *SnapshotManifest) GetKeyCount() uint64 {
+	if m != nil {
+		return m.KeyCount
 	}
-	if m.StartTime != 0 {
-		dAtA[i] = 0x10
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.StartTime))
+	return 0
+}
+
+func (m *SnapshotManifest) GetByteCount() uint64 {
+	if m != nil {
+		return m.ByteCount
 	}
-	if m.Interval != nil {
-		dAtA[i] = 0x1a
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Interval.Size()))
-		n3, err := m.Interval.MarshalTo(dAtA[i:])
-		if err != nil {
-			return 0, err
-		}
-		i += n3
+	return 0
+}
+
+func (m *SnapshotManifest) GetDataChecksum() uint32 {
+	if m != nil {
+		return m.DataChecksum
 	}
-	if m.Capacity != 0 {
-		dAtA[i] = 0x20
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Capacity))
+	return 0
+}
+
+func (m *SnapshotManifest) GetSstSize() uint64 {
+	if m != nil {
+		return m.SstSize
 	}
-	if m.Available != 0 {
-		dAtA[i] = 0x28
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Available))
+	return 0
+}
+
+func (m *SnapshotManifest) GetSstChecksum() uint32 {
+	if m != nil {
+		return m.SstChecksum
 	}
-	if m.UsedSize != 0 {
-		dAtA[i] = 0x30
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.UsedSize))
+	return 0
+}
+
+func (m *SnapshotManifest) GetFeatures() uint64 {
+	if m != nil {
+		return m.Features
 	}
-	if m.IsBusy {
-		dAtA[i] = 0x38
-		i++
-		if m.IsBusy {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
+	return 0
+}
+
+// Keyspace the keyspace of a tenant, all the keys of the keyspace are prefixed
+// by the prefix in the shard group, the shards of the keyspace are scheduled by
+// the scheduling config of the keyspace rather than the shard group
+type Keyspace struct {
+	ID    uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name  string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Group uint64 `protobuf:"varint,3,opt,name=group,proto3" json:"group,omitempty"`
+	// Prefix the key prefix of the keyspace, generated by the name
+	Prefix []byte `protobuf:"bytes,4,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// MaxReplicas the number of the replicas of the shards, the replicas of the
+	// shard group are used if it's 0
+	MaxReplicas uint64 `protobuf:"varint,5,opt,name=maxReplicas,proto3" json:"maxReplicas,omitempty"`
+	// Labels the replicas of the shards are only placed on the stores with all
+	// the labels
+	Labels []Label `protobuf:"bytes,6,rep,name=labels,proto3" json:"labels"`
+	// ShardCapacityBytes the shards are split once the size exceeds it, the
+	// capacity of the shard group is used if it's 0
+	ShardCapacityBytes   uint64   `protobuf:"varint,7,opt,name=shardCapacityBytes,proto3" json:"shardCapacityBytes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Keyspace) Reset()         { *m = Keyspace{} }
+func (m *Keyspace) String() string { return proto.CompactTextString(m) }
+func (*Keyspace) ProtoMessage()    {}
+func (*Keyspace) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77b4d575d5a68dda, []int{40}
+}
+func (m *Keyspace) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Keyspace) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Keyspace.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
 		}
-		i++
-	}
-	if m.ShardCount != 0 {
-		dAtA[i] = 0x40
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ShardCount))
+		return b[:n], nil
 	}
-	if m.SendingSnapCount != 0 {
-		dAtA[i] = 0x48
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.SendingSnapCount))
+}
+func (m *Keyspace) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Keyspace.Merge(m, src)
+}
+func (m *Keyspace) XXX_Size() int {
+	return m.Size()
+}
+func (m *Keyspace) XXX_DiscardUnknown() {
+	xxx_messageInfo_Keyspace.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Keyspace proto.InternalMessageInfo
+
+func (m *Keyspace) GetID() uint64 {
+	if m != nil {
+		return m.ID
 	}
-	if m.ReceivingSnapCount != 0 {
-		dAtA[i] = 0x50
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ReceivingSnapCount))
+	return 0
+}
+
+func (m *Keyspace) GetName() string {
+	if m != nil {
+		return m.Name
 	}
-	if m.ApplyingSnapCount != 0 {
-		dAtA[i] = 0x58
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ApplyingSnapCount))
+	return ""
+}
+
+func (m *Keyspace) GetGroup() uint64 {
+	if m != nil {
+		return m.Group
 	}
-	if m.WrittenBytes != 0 {
-		dAtA[i] = 0x60
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.WrittenBytes))
+	return 0
+}
+
+func (m *Keyspace) GetPrefix() []byte {
+	if m != nil {
+		return m.Prefix
 	}
-	if m.ReadBytes != 0 {
-		dAtA[i] = 0x68
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ReadBytes))
+	return nil
+}
+
+func (m *Keyspace) GetMaxReplicas() uint64 {
+	if m != nil {
+		return m.MaxReplicas
 	}
-	if m.WrittenKeys != 0 {
-		dAtA[i] = 0x70
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.WrittenKeys))
+	return 0
+}
+
+func (m *Keyspace) GetLabels() []Label {
+	if m != nil {
+		return m.Labels
 	}
-	if m.ReadKeys != 0 {
-		dAtA[i] = 0x78
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ReadKeys))
+	return nil
+}
+
+func (m *Keyspace) GetShardCapacityBytes() uint64 {
+	if m != nil {
+		return m.ShardCapacityBytes
 	}
-	if len(m.CpuUsages) > 0 {
-		for _, msg := range m.CpuUsages {
-			dAtA[i] = 0x82
-			i++
-			dAtA[i] = 0x1
-			i++
-			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
-			n, err := msg.MarshalTo(dAtA[i:])
-			if err != nil {
-				return 0, err
-			}
-			i += n
+	return 0
+}
+
+// StoreSchema the version of the on-disk data layout of the store
+type StoreSchema struct {
+	// Version the version of the last completed migration
+	Version uint64 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	// Migrating the version of the migration in progress, 0 if none
+	Migrating uint64 `protobuf:"varint,2,opt,name=migrating,proto3" json:"migrating,omitempty"`
+	// Checkpoint the progress saved by the migration in progress, it's
+	// resumed from the checkpoint after restarted
+	Checkpoint           []byte   `protobuf:"bytes,3,opt,name=checkpoint,proto3" json:"checkpoint,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StoreSchema) Reset()         { *m = StoreSchema{} }
+func (m *StoreSchema) String() string { return proto.CompactTextString(m) }
+func (*StoreSchema) ProtoMessage()    {}
+func (*StoreSchema) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77b4d575d5a68dda, []int{41}
+}
+func (m *StoreSchema) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *StoreSchema) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_StoreSchema.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if len(m.ReadIORates) > 0 {
-		for _, msg := range m.ReadIORates {
-			dAtA[i] = 0x8a
-			i++
-			dAtA[i] = 0x1
-			i++
-			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
-			n, err := msg.MarshalTo(dAtA[i:])
-			if err != nil {
-				return 0, err
-			}
-			i += n
-		}
+}
+func (m *StoreSchema) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StoreSchema.Merge(m, src)
+}
+func (m *StoreSchema) XXX_Size() int {
+	return m.Size()
+}
+func (m *StoreSchema) XXX_DiscardUnknown() {
+	xxx_messageInfo_StoreSchema.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StoreSchema proto.InternalMessageInfo
+
+func (m *StoreSchema) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
 	}
-	if len(m.WriteIORates) > 0 {
-		for _, msg := range m.WriteIORates {
-			dAtA[i] = 0x92
-			i++
-			dAtA[i] = 0x1
-			i++
-			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
-			n, err := msg.MarshalTo(dAtA[i:])
-			if err != nil {
-				return 0, err
-			}
-			i += n
-		}
+	return 0
+}
+
+func (m *StoreSchema) GetMigrating() uint64 {
+	if m != nil {
+		return m.Migrating
 	}
-	if m.XXX_unrecognized != nil {
-		i += copy(dAtA[i:], m.XXX_unrecognized)
+	return 0
+}
+
+func (m *StoreSchema) GetCheckpoint() []byte {
+	if m != nil {
+		return m.Checkpoint
 	}
-	return i, nil
+	return nil
 }
 
-func (m *RecordPair) Marshal() (dAtA []byte, err error) {
+func init() {
+	proto.RegisterEnum("metapb.ShardType", ShardType_name, ShardType_value)
+	proto.RegisterEnum("metapb.StoreState", StoreState_name, StoreState_value)
+	proto.RegisterEnum("metapb.ShardState", ShardState_name, ShardState_value)
+	proto.RegisterEnum("metapb.ConfigChangeType", ConfigChangeType_name, ConfigChangeType_value)
+	proto.RegisterEnum("metapb.ReplicaRole", ReplicaRole_name, ReplicaRole_value)
+	proto.RegisterEnum("metapb.CheckPolicy", CheckPolicy_name, CheckPolicy_value)
+	proto.RegisterEnum("metapb.OperatorStatus", OperatorStatus_name, OperatorStatus_value)
+	proto.RegisterEnum("metapb.JobType", JobType_name, JobType_value)
+	proto.RegisterEnum("metapb.JobState", JobState_name, JobState_value)
+	proto.RegisterEnum("metapb.ReplicaState", ReplicaState_name, ReplicaState_value)
+	proto.RegisterEnum("metapb.ShardsPoolCmdType", ShardsPoolCmdType_name, ShardsPoolCmdType_value)
+	proto.RegisterEnum("metapb.SnapshotPhase", SnapshotPhase_name, SnapshotPhase_value)
+	proto.RegisterEnum("metapb.SnapshotFailureReason", SnapshotFailureReason_name, SnapshotFailureReason_value)
+	proto.RegisterType((*ShardEpoch)(nil), "metapb.ShardEpoch")
+	proto.RegisterType((*Replica)(nil), "metapb.Replica")
+	proto.RegisterType((*ReplicaStats)(nil), "metapb.ReplicaStats")
+	proto.RegisterType((*Label)(nil), "metapb.Label")
+	proto.RegisterType((*ShardStats)(nil), "metapb.ShardStats")
+	proto.RegisterType((*ShardBucket)(nil), "metapb.ShardBucket")
+	proto.RegisterType((*StoreStats)(nil), "metapb.StoreStats")
+	proto.RegisterType((*RecordPair)(nil), "metapb.RecordPair")
+	proto.RegisterType((*Member)(nil), "metapb.Member")
+	proto.RegisterType((*ProphetCluster)(nil), "metapb.ProphetCluster")
+	proto.RegisterType((*TimeInterval)(nil), "metapb.TimeInterval")
+	proto.RegisterType((*Job)(nil), "metapb.Job")
+	proto.RegisterType((*JobCheckpoint)(nil), "metapb.JobCheckpoint")
+	proto.RegisterType((*RemoveShardJob)(nil), "metapb.RemoveShardJob")
+	proto.RegisterType((*ShardPoolJob)(nil), "metapb.ShardPoolJob")
+	proto.RegisterType((*ShardPoolJobMeta)(nil), "metapb.ShardPoolJobMeta")
+	proto.RegisterType((*DestroyingStatus)(nil), "metapb.DestroyingStatus")
+	proto.RegisterMapType((map[uint64]bool)(nil), "metapb.DestroyingStatus.ReplicasEntry")
+	proto.RegisterType((*ShardExtra)(nil), "metapb.ShardExtra")
+	proto.RegisterMapType((map[string]string)(nil), "metapb.ShardExtra.LabelsEntry")
+	proto.RegisterType((*ScheduleGroupRule)(nil), "metapb.ScheduleGroupRule")
+	proto.RegisterType((*RaftMessageBatch)(nil), "metapb.RaftMessageBatch")
+	proto.RegisterType((*RaftMessage)(nil), "metapb.RaftMessage")
+	proto.RegisterType((*SnapshotChunk)(nil), "metapb.SnapshotChunk")
+	proto.RegisterType((*StoreIdent)(nil), "metapb.StoreIdent")
+	proto.RegisterType((*Shard)(nil), "metapb.Shard")
+	proto.RegisterType((*LogIndex)(nil), "metapb.LogIndex")
+	proto.RegisterType((*ShardMetadata)(nil), "metapb.ShardMetadata")
+	proto.RegisterType((*ShardLocalState)(nil), "metapb.ShardLocalState")
+	proto.RegisterType((*Store)(nil), "metapb.Store")
+	proto.RegisterType((*ShardsPool)(nil), "metapb.ShardsPool")
+	proto.RegisterMapType((map[uint64]*ShardPool)(nil), "metapb.ShardsPool.PoolsEntry")
+	proto.RegisterType((*ShardPool)(nil), "metapb.ShardPool")
+	proto.RegisterType((*AllocatedShard)(nil), "metapb.AllocatedShard")
+	proto.RegisterType((*ShardsPoolCmd)(nil), "metapb.ShardsPoolCmd")
+	proto.RegisterType((*ShardsPoolCreateCmd)(nil), "metapb.ShardsPoolCreateCmd")
+	proto.RegisterType((*ShardsPoolAllocCmd)(nil), "metapb.ShardsPoolAllocCmd")
+	proto.RegisterType((*SnapshotInfo)(nil), "metapb.SnapshotInfo")
+	proto.RegisterType((*EpochLease)(nil), "metapb.EpochLease")
+	proto.RegisterType((*ReplicaReadStats)(nil), "metapb.ReplicaReadStats")
+	proto.RegisterType((*ShardReadHint)(nil), "metapb.ShardReadHint")
+	proto.RegisterType((*ReplicaProgress)(nil), "metapb.ReplicaProgress")
+	proto.RegisterType((*SnapshotManifest)(nil), "metapb.SnapshotManifest")
+	proto.RegisterType((*Keyspace)(nil), "metapb.Keyspace")
+	proto.RegisterType((*StoreSchema)(nil), "metapb.StoreSchema")
+}
+
+func init() { proto.RegisterFile("metapb.proto", fileDescriptor_77b4d575d5a68dda) }
+
+var fileDescriptor_77b4d575d5a68dda = []byte{
+	// 3168 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x5a, 0x5f, 0x6f, 0x1b, 0xc7,
+	0xb5, 0x17, 0xff, 0x48, 0x22, 0x0f, 0xff, 0x68, 0x35, 0xf2, 0x1f, 0x46, 0x49, 0x1c, 0x61, 0x73,
+	0xaf, 0xa3, 0xe8, 0x26, 0x72, 0xae, 0xed, 0x18, 0x49, 0xee, 0x45, 0x11, 0x89, 0x52, 0x12, 0xda,
+	0x92, 0xac, 0x2e, 0x2d, 0xb7, 0x45, 0x1f, 0x8a, 0x11, 0x77, 0x48, 0x2d, 0xb4, 0xdc, 0x61, 0x76,
+	0x87, 0xb6, 0x59, 0xa0, 0x40, 0xfb, 0x56, 0x14, 0x45, 0xbf, 0x43, 0x51, 0xe4, 0xad, 0x4f, 0x7d,
+	0xee, 0x6b, 0xd1, 0xbc, 0x35, 0x9f, 0x20, 0x68, 0xfd, 0xd0, 0x2f, 0xd0, 0x2f, 0x50, 0x9c, 0x33,
+	0x33, 0xcb, 0x5d, 0x52, 0x92, 0xdd, 0x17, 0x6b, 0xcf, 0x99, 0x33, 0x33, 0x67, 0xce, 0xdf, 0xdf,
+	0x0c, 0x0d, 0xf5, 0xa1, 0x50, 0x7c, 0x74, 0xba, 0x3d, 0x8a, 0xa5, 0x92, 0x6c, 0x49, 0x53, 0xeb,
+	0x1f, 0x0e, 0x02, 0x75, 0x36, 0x3e, 0xdd, 0xee, 0xc9, 0xe1, 0x9d, 0x81, 0x1c, 0xc8, 0x3b, 0x34,
+	0x7c, 0x3a, 0xee, 0x13, 0x45, 0x04, 0x7d, 0xe9, 0x69, 0xeb, 0xef, 0x0f, 0xe4, 0xb6, 0x50, 0x3d,
+	0x7f, 0x3b, 0x90, 0x77, 0xf0, 0xef, 0x9d, 0x98, 0xf7, 0xd5, 0x9d, 0x67, 0xf7, 0xe8, 0xef, 0xe8,
+	0x94, 0xfe, 0x68, 0x51, 0xf7, 0x21, 0x40, 0xf7, 0x8c, 0xc7, 0xfe, 0xfe, 0x48, 0xf6, 0xce, 0xd8,
+	0x5b, 0x50, 0xed, 0xc9, 0xa8, 0x1f, 0x0c, 0x9e, 0x8a, 0xb8, 0x55, 0xd8, 0x28, 0x6c, 0x96, 0xbd,
+	0x29, 0x83, 0xdd, 0x02, 0x18, 0x88, 0x48, 0xc4, 0x5c, 0x05, 0x32, 0x6a, 0x15, 0x69, 0x38, 0xc3,
+	0x71, 0x7f, 0x53, 0x80, 0x65, 0x4f, 0x8c, 0xc2, 0xa0, 0xc7, 0xd9, 0x0d, 0x28, 0x06, 0xbe, 0x5e,
+	0x62, 0x77, 0xe9, 0xe5, 0xf7, 0xef, 0x14, 0x3b, 0x7b, 0x5e, 0x31, 0xf0, 0x59, 0x0b, 0x96, 0x13,
+	0x25, 0x63, 0xd1, 0xd9, 0x33, 0x0b, 0x58, 0x92, 0xbd, 0x07, 0xe5, 0x58, 0x86, 0xa2, 0x55, 0xda,
+	0x28, 0x6c, 0x36, 0xef, 0xae, 0x6d, 0x1b, 0x43, 0x98, 0x05, 0x3d, 0x19, 0x0a, 0x8f, 0x04, 0xd8,
+	0x7f, 0x41, 0x23, 0x88, 0x02, 0x15, 0xf0, 0xf0, 0x50, 0x0c, 0x4f, 0x45, 0xdc, 0x2a, 0x6f, 0x14,
+	0x36, 0x2b, 0x5e, 0x9e, 0xe9, 0x72, 0xa8, 0x9b, 0xa9, 0x5d, 0xc5, 0x55, 0xc2, 0xee, 0xc0, 0x72,
+	0xac, 0x69, 0xd2, 0xaa, 0x76, 0x77, 0x65, 0x66, 0x87, 0xdd, 0xf2, 0xb7, 0xdf, 0xbf, 0xb3, 0xe0,
+	0x59, 0x29, 0xb6, 0x01, 0x35, 0x5f, 0x3e, 0x8f, 0xba, 0xa2, 0x27, 0x23, 0x3f, 0x31, 0xda, 0x66,
+	0x59, 0xee, 0x1d, 0x58, 0x3c, 0xe0, 0xa7, 0x22, 0x64, 0x0e, 0x94, 0xce, 0xc5, 0x84, 0xd6, 0xad,
+	0x7a, 0xf8, 0xc9, 0xae, 0xc1, 0xe2, 0x33, 0x1e, 0x8e, 0x05, 0x4d, 0xab, 0x7a, 0x9a, 0x70, 0xff,
+	0x58, 0x34, 0xd6, 0xd6, 0x2a, 0xa1, 0x2d, 0x90, 0xea, 0xec, 0x19, 0x5b, 0x5b, 0x92, 0xb9, 0x50,
+	0x7f, 0x1e, 0x07, 0x4a, 0x89, 0x68, 0x77, 0xa2, 0x84, 0xdd, 0x3c, 0xc7, 0x43, 0xfd, 0x0c, 0xfd,
+	0x48, 0x4c, 0x12, 0x32, 0x5b, 0xd9, 0xcb, 0xb2, 0xd0, 0x9b, 0xb1, 0xe0, 0xbe, 0x5e, 0xa2, 0xac,
+	0xbd, 0x99, 0x32, 0xd8, 0x3a, 0x54, 0x90, 0xa0, 0xc9, 0x8b, 0x34, 0x98, 0xd2, 0x6c, 0x13, 0x56,
+	0xf8, 0x68, 0x14, 0xcb, 0x17, 0xc1, 0x90, 0x2b, 0xd1, 0x0d, 0x7e, 0x2e, 0x5a, 0x4b, 0x24, 0x32,
+	0xcb, 0x9e, 0x91, 0xa4, 0xc5, 0x96, 0xe7, 0x24, 0x69, 0xcd, 0x8f, 0xa0, 0x12, 0x44, 0x4a, 0xc4,
+	0xcf, 0x78, 0xd8, 0xaa, 0x90, 0x07, 0xae, 0x59, 0x0f, 0x3c, 0x09, 0x86, 0xa2, 0x63, 0xc6, 0xbc,
+	0x54, 0xca, 0xfd, 0xd5, 0x32, 0x40, 0x17, 0xa3, 0x63, 0x6a, 0x2e, 0x13, 0x3a, 0x85, 0x7c, 0xe8,
+	0xbc, 0x05, 0xd5, 0x44, 0xf1, 0x58, 0xe1, 0x3a, 0xc6, 0x56, 0x53, 0x46, 0x6e, 0xe3, 0xd2, 0xeb,
+	0x6c, 0x8c, 0xa6, 0xe9, 0xf1, 0x11, 0xef, 0x05, 0x6a, 0x62, 0xec, 0x96, 0xd2, 0xb8, 0x17, 0x7f,
+	0xc6, 0x83, 0x90, 0x9f, 0x86, 0xc2, 0xd8, 0x6d, 0xca, 0xc0, 0x99, 0xe3, 0x44, 0xf8, 0x19, 0x8b,
+	0xa5, 0x34, 0xbb, 0x01, 0x4b, 0x41, 0xb2, 0x3b, 0x4e, 0x26, 0x64, 0xa1, 0x8a, 0x67, 0x28, 0x4c,
+	0x2b, 0xf2, 0x7b, 0x5b, 0x8e, 0x23, 0x45, 0xa6, 0x29, 0x7b, 0x19, 0x0e, 0xdb, 0x02, 0x27, 0x11,
+	0x91, 0x1f, 0x44, 0x83, 0x6e, 0xc4, 0x47, 0x5a, 0xaa, 0x4a, 0x52, 0x73, 0x7c, 0xb6, 0x0d, 0x2c,
+	0x16, 0x3d, 0x11, 0x3c, 0xcb, 0x49, 0x03, 0x49, 0x5f, 0x30, 0xc2, 0x3e, 0x80, 0x55, 0x3e, 0x1a,
+	0x85, 0x93, 0x9c, 0x78, 0x8d, 0xc4, 0xe7, 0x07, 0xe6, 0xc2, 0xb2, 0x7e, 0x41, 0x58, 0xe6, 0x82,
+	0xae, 0x31, 0x1b, 0x74, 0x33, 0x41, 0xdb, 0x9c, 0x0f, 0xda, 0x6c, 0x58, 0xae, 0xcc, 0x84, 0xe5,
+	0x03, 0xa8, 0xf6, 0x46, 0xe3, 0x93, 0x84, 0x0f, 0x44, 0xd2, 0x72, 0x36, 0x4a, 0x9b, 0xb5, 0xbb,
+	0x6c, 0x9a, 0xc5, 0x3d, 0x19, 0xfb, 0xc7, 0x3c, 0x88, 0x4d, 0x22, 0x4f, 0x45, 0xd9, 0x67, 0x50,
+	0xc3, 0x35, 0x3a, 0x8f, 0x3d, 0x8e, 0x5a, 0xad, 0xbe, 0x62, 0x66, 0x56, 0x98, 0xfd, 0xbf, 0x3e,
+	0xb3, 0xb0, 0x93, 0xd9, 0x2b, 0x26, 0xe7, 0xa4, 0xd9, 0x1a, 0xd4, 0x7a, 0xa1, 0xec, 0x9d, 0x3f,
+	0xee, 0xf7, 0x13, 0xa1, 0x5a, 0x6b, 0x1b, 0x85, 0xcd, 0x52, 0xca, 0xec, 0x9e, 0x8b, 0xe7, 0xc2,
+	0x6f, 0x5d, 0xc3, 0x68, 0x60, 0x37, 0x61, 0x65, 0xc8, 0x5f, 0x98, 0x5a, 0xa4, 0xfd, 0x70, 0x1d,
+	0x8f, 0xcf, 0x6e, 0x40, 0x73, 0xc8, 0x5f, 0x1c, 0x08, 0xee, 0x8b, 0x58, 0xf3, 0x6f, 0x10, 0xff,
+	0x13, 0x70, 0x4c, 0xa9, 0xf2, 0x04, 0xd7, 0x15, 0xa5, 0x75, 0x93, 0x94, 0x6b, 0xcd, 0xd6, 0x4e,
+	0x3b, 0xae, 0x55, 0xc4, 0x80, 0x1a, 0xf2, 0x17, 0xe8, 0xd6, 0xe4, 0x4c, 0x2a, 0xbd, 0x66, 0x4b,
+	0x07, 0xd4, 0x2c, 0xdf, 0xbd, 0x0f, 0x30, 0x3d, 0xe2, 0xab, 0x0a, 0x5d, 0xd9, 0x16, 0xba, 0xaf,
+	0x60, 0x49, 0x97, 0xe1, 0x4b, 0xfb, 0x00, 0x83, 0x72, 0xc4, 0x87, 0xb6, 0x3e, 0xd2, 0x37, 0xf2,
+	0xb8, 0xef, 0xc7, 0x94, 0xa4, 0x55, 0x8f, 0xbe, 0x5d, 0x0f, 0x9a, 0xc7, 0xb1, 0x1c, 0x9d, 0x09,
+	0xd5, 0x0e, 0xc7, 0x89, 0xba, 0x62, 0xc5, 0xcd, 0x79, 0x03, 0xe2, 0xe2, 0x0d, 0x6f, 0x96, 0xed,
+	0x3e, 0x80, 0x7a, 0x36, 0xf1, 0xf1, 0x0c, 0x54, 0x2d, 0x4c, 0x59, 0xd1, 0x04, 0x9e, 0x55, 0x44,
+	0xbe, 0x39, 0x17, 0x7e, 0xba, 0x21, 0x94, 0x1e, 0xca, 0x53, 0xf6, 0x2e, 0x94, 0xd5, 0x64, 0x24,
+	0x48, 0xba, 0x39, 0x6d, 0x23, 0x0f, 0xe5, 0xe9, 0x93, 0xc9, 0x48, 0x78, 0x34, 0x88, 0xc5, 0xaa,
+	0x27, 0x23, 0x25, 0x8c, 0x16, 0x75, 0xcf, 0x92, 0xec, 0x36, 0xed, 0xa6, 0x6c, 0xa3, 0x73, 0x32,
+	0xf3, 0xd1, 0x49, 0xc2, 0xd3, 0xc3, 0xae, 0x80, 0xa6, 0x27, 0x86, 0xf2, 0x99, 0xa0, 0x8e, 0x81,
+	0x1b, 0x6f, 0xcc, 0xf4, 0x8b, 0xf4, 0xf8, 0x96, 0xcd, 0xfe, 0x17, 0x93, 0x87, 0x4e, 0x8a, 0x3d,
+	0xa3, 0x74, 0x79, 0x97, 0x4b, 0xc5, 0xdc, 0x3d, 0xa8, 0xd3, 0x06, 0xc7, 0x52, 0x86, 0xb8, 0xc9,
+	0x7d, 0x58, 0x1c, 0x49, 0x19, 0x26, 0xad, 0x42, 0x3e, 0x96, 0xb2, 0x42, 0x87, 0x42, 0xd9, 0x85,
+	0xb4, 0xb0, 0xdb, 0x07, 0x67, 0x56, 0x00, 0xcd, 0x3a, 0x88, 0xe5, 0x78, 0x64, 0xcd, 0x4a, 0x44,
+	0xae, 0xb6, 0x16, 0x67, 0x6a, 0xeb, 0x06, 0xd4, 0x62, 0x1e, 0x0d, 0xc4, 0x71, 0x2c, 0xfa, 0xc1,
+	0x0b, 0x32, 0x50, 0xdd, 0xcb, 0xb2, 0xdc, 0x7f, 0x15, 0xc0, 0xd9, 0x13, 0x89, 0x8a, 0x25, 0x55,
+	0x26, 0xc5, 0xd5, 0x38, 0xc1, 0x8d, 0x82, 0xc8, 0x17, 0x2f, 0xec, 0x46, 0x44, 0xb0, 0xdd, 0x39,
+	0x5b, 0xdc, 0xb6, 0x67, 0x99, 0x5d, 0xc1, 0x1a, 0x27, 0xd9, 0x8f, 0x54, 0x3c, 0x99, 0x1a, 0x87,
+	0x6d, 0xe6, 0x7d, 0xc5, 0x72, 0xc6, 0xc8, 0x7a, 0x0b, 0x8b, 0x78, 0x4c, 0xde, 0xda, 0xe3, 0x8a,
+	0x1b, 0x44, 0x92, 0xe1, 0xac, 0xff, 0x1f, 0x34, 0x72, 0x9b, 0x64, 0x53, 0xa9, 0x7c, 0x41, 0x2a,
+	0x55, 0x4c, 0x2a, 0x7d, 0x56, 0xfc, 0xa4, 0xe0, 0xfe, 0xa5, 0x60, 0x51, 0xda, 0x0b, 0x15, 0x73,
+	0xf6, 0x00, 0x96, 0x42, 0xc4, 0x1d, 0xd6, 0x47, 0xb7, 0x72, 0x6a, 0x91, 0xcc, 0x36, 0x01, 0x13,
+	0x73, 0x1e, 0x23, 0xcd, 0xf6, 0xc0, 0xf1, 0x67, 0x4e, 0x4e, 0x7b, 0x65, 0xbc, 0x3c, 0x6b, 0x19,
+	0x6f, 0x6e, 0xc6, 0xfa, 0xa7, 0x50, 0xcb, 0x2c, 0xfe, 0xba, 0xd8, 0x87, 0xce, 0xf1, 0x0b, 0x58,
+	0xed, 0xf6, 0xce, 0x84, 0x3f, 0x0e, 0xc5, 0x97, 0x18, 0x0c, 0xde, 0x38, 0x14, 0x57, 0x21, 0x45,
+	0x8a, 0x98, 0x29, 0x52, 0x34, 0x64, 0x5a, 0x3b, 0x4a, 0x99, 0xda, 0xe1, 0x42, 0x9d, 0x86, 0x77,
+	0x27, 0xa4, 0x1c, 0x79, 0xa0, 0xea, 0xe5, 0x78, 0x6e, 0x07, 0x1c, 0x8f, 0xf7, 0xd5, 0xa1, 0x48,
+	0xb0, 0x2d, 0xec, 0x72, 0xd5, 0x3b, 0x63, 0x1f, 0x43, 0x65, 0xa8, 0x69, 0x6b, 0xcd, 0x29, 0xf2,
+	0xcc, 0xc8, 0x9a, 0xac, 0xb1, 0xa2, 0xee, 0xaf, 0xcb, 0x50, 0xcb, 0x8c, 0x5f, 0x01, 0xe5, 0xd2,
+	0x2c, 0x28, 0x66, 0xb3, 0xe0, 0x7d, 0x28, 0xf7, 0x63, 0x39, 0x34, 0x78, 0xe4, 0x92, 0x24, 0x25,
+	0x11, 0xf6, 0xdf, 0x50, 0x54, 0xb2, 0x55, 0xbe, 0x4a, 0xb0, 0xa8, 0x24, 0xe2, 0x5b, 0xa3, 0x5d,
+	0x6b, 0xd1, 0xc8, 0x6a, 0xb4, 0xbf, 0x9d, 0x3f, 0x83, 0x95, 0x62, 0x9f, 0x18, 0xd8, 0x41, 0xc8,
+	0x9f, 0xc0, 0x4a, 0x6d, 0x26, 0xc0, 0x69, 0xc4, 0x4c, 0xcb, 0xc8, 0x62, 0x9a, 0x06, 0xc9, 0x13,
+	0x39, 0x3c, 0x4d, 0x94, 0x8c, 0x84, 0x41, 0x33, 0x59, 0xd6, 0xb4, 0xa2, 0x56, 0x28, 0x85, 0xf3,
+	0x15, 0xb5, 0x4a, 0x3c, 0xfc, 0x44, 0x48, 0x34, 0x8e, 0x82, 0xaf, 0xc7, 0x82, 0x20, 0x4a, 0xd5,
+	0x33, 0x14, 0x65, 0x93, 0x0d, 0x92, 0xa4, 0x55, 0xdb, 0x28, 0x6d, 0x56, 0xbd, 0x0c, 0x07, 0x35,
+	0xe8, 0xc9, 0xe1, 0x30, 0x50, 0x1d, 0xca, 0x7b, 0x8d, 0x43, 0xb2, 0x2c, 0x2c, 0x33, 0x08, 0x8e,
+	0x08, 0x11, 0x6a, 0x14, 0x92, 0xd2, 0xec, 0x1a, 0xd4, 0x11, 0xdb, 0x04, 0xc2, 0xd7, 0xd3, 0x09,
+	0x85, 0xb0, 0x07, 0xb0, 0x92, 0x98, 0xd6, 0xf7, 0x05, 0x0f, 0xc2, 0x71, 0x2c, 0x08, 0x7f, 0x34,
+	0xef, 0xbe, 0x9d, 0x1a, 0x25, 0x3f, 0xec, 0x09, 0x9e, 0xc8, 0xc8, 0xfd, 0x43, 0x19, 0x1a, 0x69,
+	0xcf, 0x3c, 0x1b, 0x47, 0xe7, 0x57, 0x00, 0xd5, 0x4c, 0x98, 0x14, 0xf3, 0x61, 0x42, 0xb0, 0x89,
+	0x7c, 0xda, 0xd9, 0x33, 0x58, 0x7e, 0xca, 0xc0, 0x88, 0xa7, 0x70, 0xd1, 0x60, 0x94, 0xbe, 0xa9,
+	0xc3, 0xe0, 0x76, 0x9d, 0x3d, 0x03, 0x43, 0x2d, 0x49, 0xb7, 0x38, 0xfc, 0xcc, 0xa0, 0xd0, 0x29,
+	0x03, 0x6d, 0x4b, 0x84, 0x6e, 0x91, 0x1a, 0xac, 0x67, 0x38, 0xd3, 0x6a, 0x5a, 0xc9, 0x56, 0x53,
+	0x06, 0x65, 0x25, 0xe2, 0xa1, 0x01, 0x9e, 0xf4, 0x8d, 0x36, 0xee, 0x07, 0xa1, 0x38, 0xe6, 0xea,
+	0xcc, 0xf8, 0x2f, 0xa5, 0xed, 0x18, 0xa9, 0xa0, 0xf1, 0x64, 0x4a, 0xa3, 0xf7, 0xf0, 0xbb, 0x6d,
+	0xb4, 0x37, 0xde, 0xcb, 0xb0, 0xd8, 0x6d, 0x68, 0xa6, 0xa4, 0xd6, 0x53, 0xfb, 0x70, 0x86, 0x8b,
+	0x5a, 0xf9, 0x58, 0x6f, 0x9b, 0x14, 0x52, 0xf4, 0x8d, 0xfa, 0x0b, 0x2c, 0x81, 0xe4, 0xbd, 0xba,
+	0xa7, 0x09, 0xf6, 0xb1, 0xbe, 0xd9, 0x52, 0xcd, 0x6e, 0x39, 0x14, 0xec, 0xab, 0x36, 0x41, 0xda,
+	0x76, 0x20, 0x45, 0x8e, 0x96, 0x81, 0x50, 0x0d, 0x8d, 0xdd, 0x35, 0xee, 0x5c, 0xa5, 0x48, 0x69,
+	0xc2, 0x52, 0x5f, 0xc6, 0x43, 0xae, 0x5a, 0x8c, 0x68, 0x17, 0xea, 0x36, 0x72, 0xe8, 0xbc, 0x6b,
+	0x1a, 0x16, 0x67, 0x79, 0x6e, 0xd7, 0x5c, 0x65, 0x3a, 0x3e, 0x62, 0x00, 0xf4, 0x90, 0x86, 0x33,
+	0x69, 0x8c, 0x4c, 0x19, 0x57, 0xdc, 0x91, 0x1b, 0xb0, 0x28, 0x28, 0x5d, 0x29, 0x42, 0xdc, 0xbf,
+	0x15, 0x61, 0x91, 0x32, 0xf5, 0xd2, 0x22, 0x9a, 0x26, 0x62, 0xf1, 0x82, 0x44, 0x2c, 0x4d, 0x13,
+	0x71, 0xdb, 0x2e, 0x5c, 0x7e, 0x45, 0x1d, 0xd0, 0x62, 0xd3, 0xc6, 0xb8, 0xf8, 0xaa, 0xc6, 0x98,
+	0x85, 0x24, 0x4b, 0xaf, 0x05, 0x49, 0xa6, 0x25, 0x73, 0x39, 0x5b, 0x32, 0xa7, 0xb5, 0xa2, 0x72,
+	0x45, 0xad, 0xa8, 0xce, 0xd5, 0x8a, 0xff, 0x49, 0xbb, 0x25, 0xd0, 0xf6, 0x0d, 0xbb, 0x3d, 0x35,
+	0x05, 0xb3, 0xb9, 0x11, 0x71, 0xef, 0x43, 0xe5, 0x40, 0x0e, 0x74, 0x09, 0xb9, 0x18, 0x56, 0xd8,
+	0x44, 0x28, 0x4e, 0x13, 0xc1, 0xfd, 0x65, 0x01, 0x1a, 0x74, 0x72, 0xc4, 0x3d, 0x14, 0x84, 0x97,
+	0xf7, 0x83, 0x75, 0xa8, 0x84, 0x66, 0x07, 0x8b, 0x7f, 0x2c, 0xcd, 0x3e, 0xc5, 0x66, 0xa4, 0x57,
+	0x30, 0x9d, 0xe1, 0x66, 0xce, 0xb0, 0x07, 0xb2, 0xc7, 0xc3, 0x6c, 0xa4, 0xa6, 0xe2, 0xee, 0x9f,
+	0x0a, 0xb0, 0x32, 0x23, 0xc3, 0xde, 0x87, 0x45, 0xda, 0xd5, 0x3c, 0x78, 0x34, 0x72, 0x6b, 0x59,
+	0x7f, 0x92, 0x04, 0xfa, 0x33, 0x14, 0x3c, 0x11, 0x06, 0x0f, 0xa4, 0xfe, 0x24, 0xd7, 0x1f, 0xe0,
+	0x88, 0xa7, 0x05, 0xd8, 0x56, 0x1e, 0x12, 0x5d, 0x9b, 0x71, 0xe6, 0x7f, 0x02, 0x8a, 0xdc, 0x6f,
+	0x4a, 0xb0, 0x48, 0x59, 0x71, 0x69, 0xfc, 0x12, 0x22, 0xec, 0xab, 0x1d, 0xdf, 0x8f, 0x45, 0x92,
+	0x18, 0x44, 0x91, 0x65, 0xe1, 0x6b, 0x50, 0x2f, 0x0c, 0x44, 0x94, 0xca, 0x68, 0x54, 0x90, 0x67,
+	0x66, 0x82, 0xa0, 0xfc, 0xca, 0x20, 0xb8, 0x3c, 0xb8, 0xed, 0x5b, 0x44, 0x7a, 0xc0, 0xdc, 0xc3,
+	0x03, 0x56, 0xda, 0x52, 0xf6, 0xe1, 0xe1, 0x03, 0x58, 0x0d, 0x79, 0xa2, 0xbe, 0x12, 0x3c, 0x56,
+	0xa7, 0x82, 0x6b, 0xa9, 0x65, 0x92, 0x9a, 0x1f, 0xc0, 0x90, 0x79, 0x26, 0xe2, 0x04, 0x9f, 0xd6,
+	0x74, 0x80, 0x5b, 0x92, 0x20, 0xb3, 0x6e, 0x6d, 0x7b, 0x54, 0x7f, 0xab, 0x5e, 0x4a, 0xa3, 0x89,
+	0x7d, 0x31, 0x0a, 0xe5, 0x24, 0x53, 0x85, 0x33, 0x1c, 0xd4, 0xd0, 0x20, 0x38, 0xe1, 0x53, 0x21,
+	0xae, 0x78, 0x53, 0xc6, 0xb4, 0x9e, 0xd4, 0xed, 0x55, 0x33, 0x6d, 0x81, 0xba, 0xc0, 0x51, 0xd9,
+	0x75, 0x7f, 0x67, 0xf1, 0x67, 0x82, 0xf8, 0x9e, 0xdd, 0xcb, 0x5f, 0x11, 0xde, 0xce, 0xc5, 0x15,
+	0x89, 0x6c, 0xe3, 0x3f, 0x06, 0x7d, 0x6a, 0xd9, 0xf5, 0x47, 0x00, 0x53, 0xe6, 0x05, 0xe8, 0xf7,
+	0xbd, 0x2c, 0x6a, 0xc4, 0xe2, 0x3c, 0x7b, 0xef, 0xc8, 0x02, 0xc9, 0xbf, 0x16, 0xa0, 0x9a, 0x0e,
+	0xe4, 0xae, 0x14, 0x85, 0xab, 0xaf, 0x14, 0xc5, 0xb9, 0x2b, 0x05, 0xfb, 0x1c, 0x56, 0x78, 0x18,
+	0xca, 0x1e, 0x57, 0xc2, 0xd7, 0x27, 0x68, 0x95, 0xe8, 0x5c, 0x37, 0xac, 0x0a, 0x3b, 0xb9, 0x61,
+	0x6f, 0x56, 0x1c, 0x0f, 0x93, 0x88, 0xaf, 0x4d, 0x73, 0xc6, 0x4f, 0x7a, 0x15, 0xb3, 0x42, 0xe6,
+	0xea, 0xbf, 0x68, 0x5e, 0xc5, 0xf2, 0x6c, 0xb7, 0x0f, 0xcd, 0xfc, 0xf2, 0x57, 0x94, 0x8e, 0x0d,
+	0xa8, 0xa5, 0xd3, 0x77, 0x94, 0x7d, 0x91, 0xcc, 0xb0, 0x70, 0xee, 0x68, 0x1c, 0x8f, 0x64, 0x22,
+	0x4c, 0x71, 0xb7, 0xa4, 0xfb, 0x8d, 0x2d, 0x51, 0xe4, 0x9f, 0xf6, 0xd0, 0x67, 0x1f, 0xe6, 0xae,
+	0xb1, 0x6f, 0xcc, 0x3b, 0xb1, 0x3d, 0xf4, 0x33, 0x17, 0xda, 0x7b, 0xb0, 0xd4, 0x8b, 0x05, 0x66,
+	0x85, 0x76, 0xd0, 0x9b, 0x17, 0x4c, 0xa0, 0xf1, 0xf6, 0xd0, 0xf7, 0x8c, 0x28, 0xfb, 0x08, 0x16,
+	0x49, 0x3d, 0x53, 0xcd, 0xd6, 0xe7, 0xe7, 0xd0, 0xe1, 0x71, 0x8a, 0x16, 0x74, 0xaf, 0xc3, 0xda,
+	0x05, 0x0b, 0xba, 0x7b, 0xc0, 0xe6, 0xe7, 0x5c, 0x72, 0xc3, 0xcc, 0x18, 0xa1, 0x98, 0x37, 0xc2,
+	0x67, 0x50, 0xb7, 0x48, 0xad, 0x13, 0xf5, 0xe5, 0x14, 0x2a, 0x98, 0xf9, 0x44, 0x20, 0xd7, 0x1f,
+	0x0f, 0x87, 0x13, 0x7b, 0x0f, 0x23, 0xc2, 0xfd, 0x1c, 0x60, 0x5a, 0x0c, 0x69, 0x26, 0x52, 0xe9,
+	0x4c, 0xfb, 0x7c, 0x3e, 0x05, 0x71, 0xc5, 0x19, 0x10, 0xe7, 0xfe, 0x14, 0x9c, 0xd9, 0x07, 0x19,
+	0xb6, 0x32, 0xe3, 0x6c, 0xb6, 0x3a, 0xb7, 0x84, 0x66, 0xd9, 0x17, 0x35, 0x6a, 0xfc, 0xcc, 0xc9,
+	0x3c, 0x92, 0x51, 0xd8, 0xb9, 0x77, 0x8d, 0x7b, 0x71, 0xe9, 0xaf, 0x82, 0x48, 0xcd, 0xaf, 0xec,
+	0xcc, 0xdc, 0x87, 0xcb, 0xee, 0x3f, 0x8b, 0xb0, 0x62, 0x34, 0x3a, 0x8e, 0xe5, 0x80, 0x0a, 0xe5,
+	0xed, 0xd7, 0x7b, 0x26, 0x9f, 0xc3, 0xd0, 0x5a, 0x55, 0x06, 0x30, 0xc4, 0x6b, 0x95, 0xe6, 0x69,
+	0x5d, 0x6f, 0xc2, 0x0a, 0x16, 0xbb, 0xb6, 0x8c, 0x14, 0xef, 0xe9, 0x1a, 0x48, 0x2a, 0xe3, 0x12,
+	0x91, 0x10, 0xbe, 0xf5, 0x08, 0x65, 0x48, 0x85, 0x7d, 0x00, 0x0d, 0x5b, 0x83, 0x8e, 0xcf, 0x78,
+	0xa2, 0xcb, 0x6a, 0xf3, 0xee, 0xf5, 0x59, 0x10, 0x4e, 0x83, 0xec, 0x0d, 0x58, 0xb5, 0xd2, 0x5d,
+	0x11, 0x29, 0x6d, 0x23, 0x82, 0x0d, 0x6c, 0x1d, 0x98, 0x1d, 0x7a, 0x22, 0x15, 0x0f, 0xf5, 0x58,
+	0xe5, 0x32, 0xac, 0x5f, 0x7d, 0x0d, 0xac, 0xcf, 0x5a, 0xe0, 0xcc, 0xcc, 0x4b, 0xf4, 0xe3, 0x2a,
+	0x7b, 0x13, 0xd6, 0xec, 0xc8, 0x0f, 0xc7, 0x3c, 0xe6, 0x91, 0x0a, 0x22, 0x5b, 0x71, 0xdd, 0x3f,
+	0x17, 0xc1, 0xb1, 0x0b, 0x1e, 0xf2, 0x28, 0xe8, 0x8b, 0x44, 0xb1, 0xeb, 0xd0, 0xd0, 0x28, 0xf2,
+	0xa9, 0xa9, 0xfa, 0x68, 0xef, 0x06, 0x73, 0x6d, 0xd3, 0x2e, 0x5e, 0xda, 0xb4, 0xb1, 0x6c, 0x6b,
+	0x54, 0x47, 0x49, 0xce, 0x6a, 0x1a, 0xce, 0x95, 0x89, 0xb8, 0x09, 0x2b, 0x59, 0xc7, 0x3c, 0x12,
+	0x13, 0x32, 0x6c, 0x1d, 0x4d, 0x95, 0x1d, 0x78, 0x4a, 0xc5, 0x76, 0x89, 0x86, 0xd6, 0xa0, 0x66,
+	0x81, 0x04, 0xca, 0x2f, 0x13, 0xf3, 0x3a, 0x34, 0x2c, 0x53, 0xcb, 0xd2, 0x5d, 0x0e, 0xc3, 0xe8,
+	0x5c, 0x4c, 0x32, 0xaf, 0xd0, 0x18, 0x9f, 0xa7, 0x13, 0x25, 0x32, 0x4f, 0xcd, 0xe8, 0x5a, 0x9c,
+	0xd7, 0x3e, 0x13, 0xbd, 0xf3, 0x64, 0x3c, 0x24, 0x33, 0x34, 0x28, 0x24, 0x13, 0x0d, 0x91, 0x75,
+	0xbf, 0x59, 0x83, 0x5a, 0x92, 0xa8, 0x54, 0xaa, 0x41, 0x52, 0x0e, 0x54, 0xfa, 0x82, 0x2b, 0xb2,
+	0x2d, 0xdd, 0xcc, 0xf0, 0x77, 0xa5, 0x9a, 0x3e, 0xfe, 0xb8, 0x77, 0x2e, 0x2e, 0x08, 0xed, 0x46,
+	0x0e, 0xe5, 0x5a, 0x7b, 0x68, 0xe3, 0x5c, 0x9b, 0x79, 0xb3, 0x2e, 0xdb, 0x9d, 0xb3, 0xef, 0xd0,
+	0x8b, 0xf3, 0x89, 0xb6, 0x34, 0x97, 0x68, 0x14, 0x56, 0x6e, 0x07, 0x1a, 0x0f, 0xe5, 0x29, 0xe9,
+	0x3c, 0x92, 0x98, 0x68, 0x6f, 0x5f, 0xf9, 0x1c, 0xc8, 0x6a, 0xba, 0x39, 0xe8, 0xfc, 0xa8, 0x9b,
+	0xfb, 0x0a, 0xa9, 0xe6, 0xfe, 0xbe, 0x00, 0x15, 0x5c, 0x79, 0xc4, 0x7b, 0xf8, 0xf8, 0x39, 0x87,
+	0x80, 0x50, 0x7c, 0xfa, 0x48, 0x8a, 0xa7, 0xd4, 0xd5, 0xae, 0x64, 0x6f, 0x21, 0x23, 0xdd, 0xd4,
+	0xca, 0xa9, 0x13, 0xd3, 0x87, 0x4e, 0x7b, 0xa4, 0x77, 0x53, 0xdc, 0xb3, 0x74, 0x29, 0xee, 0xa1,
+	0x4c, 0xa1, 0x9f, 0x1b, 0x4c, 0xd3, 0xcc, 0x64, 0x91, 0x2b, 0xa0, 0xa6, 0x61, 0x4f, 0xef, 0x4c,
+	0x0c, 0x79, 0x16, 0xa4, 0x98, 0xe6, 0x64, 0x48, 0xac, 0x7d, 0xc3, 0x60, 0x10, 0x73, 0x15, 0x44,
+	0x03, 0x5b, 0xfb, 0x52, 0x86, 0xbe, 0x74, 0x5a, 0x93, 0x99, 0xde, 0x94, 0xe1, 0x6c, 0x6d, 0x99,
+	0x7e, 0x4e, 0x26, 0x6b, 0x02, 0xe8, 0xe7, 0xee, 0xc7, 0x51, 0x38, 0x71, 0x30, 0xdc, 0xab, 0x3b,
+	0x61, 0x48, 0xe3, 0x89, 0x53, 0xd8, 0xba, 0x9b, 0xf9, 0x55, 0x48, 0xb0, 0x25, 0x28, 0x9e, 0x8c,
+	0x9c, 0x05, 0x56, 0x81, 0xf2, 0x9e, 0x7c, 0x1e, 0x39, 0x05, 0xc6, 0xa0, 0x49, 0xe3, 0xe9, 0x73,
+	0x84, 0x53, 0xdc, 0xea, 0x66, 0x7e, 0x78, 0x43, 0x9f, 0x2c, 0x7b, 0xe3, 0x28, 0x0a, 0xa2, 0x81,
+	0xb3, 0xc0, 0xea, 0x50, 0xa1, 0x3e, 0x83, 0x54, 0x01, 0xf7, 0x9e, 0xbe, 0x81, 0x39, 0x45, 0xdc,
+	0x7b, 0xcf, 0xc2, 0x25, 0xa7, 0x84, 0x33, 0x0f, 0x45, 0x3c, 0xc0, 0xb1, 0xf2, 0x56, 0x17, 0x9c,
+	0x36, 0xfd, 0x38, 0xda, 0x3e, 0x43, 0x3c, 0x61, 0xdc, 0xbd, 0xbc, 0xe3, 0xfb, 0x47, 0xd2, 0x17,
+	0xce, 0x02, 0x2e, 0xa6, 0x9f, 0x70, 0x89, 0xa6, 0xc5, 0x4f, 0x46, 0x3e, 0x57, 0x9a, 0x2e, 0xa2,
+	0xa6, 0x3b, 0xbe, 0x7f, 0x20, 0x78, 0x1c, 0x89, 0x98, 0x78, 0xa5, 0xad, 0x47, 0x50, 0xcb, 0xfc,
+	0xe4, 0xc9, 0xaa, 0xb0, 0xf8, 0x54, 0x2a, 0x11, 0x3b, 0x0b, 0xb8, 0xb4, 0x11, 0x75, 0x0a, 0x6c,
+	0x15, 0x1a, 0x9d, 0xa8, 0x27, 0x87, 0x41, 0x34, 0xd0, 0xe3, 0x45, 0x64, 0xed, 0x89, 0xa1, 0x54,
+	0x29, 0xab, 0xb4, 0x75, 0x1f, 0x6a, 0x14, 0xa9, 0xc7, 0x32, 0x0c, 0x7a, 0x13, 0xb4, 0x51, 0xb7,
+	0xbd, 0x73, 0xe4, 0x2c, 0xb0, 0x15, 0xa8, 0xed, 0x1c, 0x1f, 0x7b, 0x8f, 0x7f, 0xdc, 0x39, 0xdc,
+	0x79, 0xb2, 0xef, 0x14, 0x18, 0xc0, 0xd2, 0x49, 0x77, 0xff, 0xd1, 0xfe, 0x4f, 0x9c, 0xe2, 0xd6,
+	0x31, 0x34, 0x1f, 0x8f, 0x44, 0xcc, 0x95, 0x8c, 0xcd, 0x0b, 0x6b, 0x0d, 0x96, 0xbb, 0x27, 0xed,
+	0xf6, 0x7e, 0xb7, 0xab, 0xf5, 0x78, 0xd2, 0x39, 0xdc, 0x7f, 0x7c, 0xf2, 0x44, 0xcf, 0x6b, 0xef,
+	0x1c, 0xb5, 0xf7, 0x0f, 0x9c, 0x22, 0x99, 0x75, 0xff, 0xf8, 0x60, 0xa7, 0xbd, 0xaf, 0x2d, 0xe5,
+	0x9d, 0x1c, 0x1d, 0x75, 0x8e, 0xbe, 0x74, 0xca, 0x5b, 0xbb, 0xb0, 0x6c, 0xf3, 0x61, 0x05, 0x6a,
+	0xda, 0x26, 0xe4, 0x0f, 0x67, 0x81, 0xad, 0xc1, 0x8a, 0xee, 0xf3, 0x29, 0xa0, 0xd3, 0xc7, 0x6b,
+	0x8f, 0x13, 0x85, 0x37, 0x6f, 0x1e, 0xab, 0x1d, 0xe5, 0xf8, 0x5b, 0xf7, 0xa0, 0x62, 0x9f, 0xc8,
+	0x71, 0x71, 0x3d, 0xc7, 0xd7, 0xfa, 0xfc, 0x48, 0xc6, 0xe7, 0xda, 0x7f, 0x0d, 0xa8, 0xb6, 0xe5,
+	0x70, 0x14, 0x0a, 0x1c, 0x2b, 0x6e, 0xfd, 0x20, 0xf7, 0x2b, 0xb0, 0x40, 0x75, 0x8f, 0xb0, 0xe8,
+	0x86, 0xda, 0xf1, 0x3b, 0xe6, 0x27, 0x2e, 0xa7, 0xc0, 0xae, 0xa5, 0xdd, 0x39, 0x1b, 0x37, 0xf7,
+	0x61, 0x75, 0x0e, 0x10, 0xe1, 0x11, 0x32, 0x1a, 0x6b, 0x3f, 0x13, 0x26, 0xd1, 0x74, 0x61, 0xeb,
+	0x67, 0xd0, 0xc8, 0xb7, 0xa9, 0x26, 0xc0, 0x91, 0xb4, 0x2c, 0x7d, 0xe6, 0xe3, 0xe9, 0x4f, 0x77,
+	0xc4, 0x2c, 0x20, 0xb3, 0x3b, 0xc3, 0x2c, 0xa2, 0x5a, 0x3b, 0x99, 0xdf, 0xe1, 0x88, 0x5b, 0xda,
+	0xfa, 0x6d, 0x01, 0xae, 0x5f, 0xdc, 0xa1, 0x1a, 0x50, 0x3d, 0x92, 0x86, 0xe5, 0x2c, 0x60, 0x84,
+	0x1d, 0x09, 0xf5, 0x5c, 0xc6, 0xe7, 0x96, 0x57, 0xc0, 0x73, 0xef, 0x05, 0xc9, 0xf9, 0x17, 0xe3,
+	0x30, 0xd4, 0x1b, 0xd8, 0x02, 0x7c, 0x18, 0x24, 0xd4, 0xbd, 0x9d, 0x12, 0xbb, 0x0e, 0xab, 0x27,
+	0x51, 0x32, 0x1e, 0x8d, 0x64, 0xac, 0x84, 0xaf, 0x2f, 0x03, 0x4e, 0x19, 0xd9, 0x9d, 0x28, 0x19,
+	0xf7, 0xfb, 0x41, 0x0f, 0x6f, 0x57, 0x5d, 0xac, 0x5c, 0xce, 0xe2, 0xae, 0xf3, 0xdd, 0x3f, 0x6e,
+	0x15, 0xbe, 0x7d, 0x79, 0xab, 0xf0, 0xdd, 0xcb, 0x5b, 0x85, 0xbf, 0xbf, 0xbc, 0x55, 0x38, 0x5d,
+	0xa2, 0xff, 0x5d, 0x70, 0xef, 0xdf, 0x03, 0x00, 0x97, 0x0d, 0x72, 0x9c, 0xcf, 0x20, 0x00, 0x00,
+}
+
+func (m *ShardEpoch) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -3499,21 +4182,20 @@ func (m *RecordPair) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *RecordPair) MarshalTo(dAtA []byte) (int, error) {
+func (m *ShardEpoch) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if len(m.Key) > 0 {
-		dAtA[i] = 0xa
+	if m.ConfigVer != 0 {
+		dAtA[i] = 0x8
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Key)))
-		i += copy(dAtA[i:], m.Key)
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ConfigVer))
 	}
-	if m.Value != 0 {
+	if m.Generation != 0 {
 		dAtA[i] = 0x10
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Value))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Generation))
 	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
@@ -3521,7 +4203,7 @@ func (m *RecordPair) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *Member) Marshal() (dAtA []byte, err error) {
+func (m *Replica) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -3531,7 +4213,7 @@ func (m *Member) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *Member) MarshalTo(dAtA []byte) (int, error) {
+func (m *Replica) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
@@ -3541,17 +4223,25 @@ func (m *Member) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintMetapb(dAtA, i, uint64(m.ID))
 	}
-	if len(m.Name) > 0 {
-		dAtA[i] = 0x12
+	if m.StoreID != 0 {
+		dAtA[i] = 0x10
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Name)))
-		i += copy(dAtA[i:], m.Name)
+		i = encodeVarintMetapb(dAtA, i, uint64(m.StoreID))
 	}
-	if len(m.Addr) > 0 {
-		dAtA[i] = 0x1a
+	if m.Role != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Role))
+	}
+	if m.InitialMember {
+		dAtA[i] = 0x20
+		i++
+		if m.InitialMember {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Addr)))
-		i += copy(dAtA[i:], m.Addr)
 	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
@@ -3559,7 +4249,7 @@ func (m *Member) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *ProphetCluster) Marshal() (dAtA []byte, err error) {
+func (m *ReplicaStats) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -3569,20 +4259,23 @@ func (m *ProphetCluster) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *ProphetCluster) MarshalTo(dAtA []byte) (int, error) {
+func (m *ReplicaStats) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.ID != 0 {
-		dAtA[i] = 0x8
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ID))
+	dAtA[i] = 0xa
+	i++
+	i = encodeVarintMetapb(dAtA, i, uint64(m.Replica.Size()))
+	n1, err := m.Replica.MarshalTo(dAtA[i:])
+	if err != nil {
+		return 0, err
 	}
-	if m.MaxReplicaCount != 0 {
+	i += n1
+	if m.DownSeconds != 0 {
 		dAtA[i] = 0x10
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.MaxReplicaCount))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.DownSeconds))
 	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
@@ -3590,7 +4283,7 @@ func (m *ProphetCluster) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *TimeInterval) Marshal() (dAtA []byte, err error) {
+func (m *Label) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -3600,20 +4293,22 @@ func (m *TimeInterval) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *TimeInterval) MarshalTo(dAtA []byte) (int, error) {
+func (m *Label) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.Start != 0 {
-		dAtA[i] = 0x8
+	if len(m.Key) > 0 {
+		dAtA[i] = 0xa
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Start))
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Key)))
+		i += copy(dAtA[i:], m.Key)
 	}
-	if m.End != 0 {
-		dAtA[i] = 0x10
+	if len(m.Value) > 0 {
+		dAtA[i] = 0x12
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.End))
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Value)))
+		i += copy(dAtA[i:], m.Value)
 	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
@@ -3621,7 +4316,7 @@ func (m *TimeInterval) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *Job) Marshal() (dAtA []byte, err error) {
+func (m *ShardStats) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -3631,97 +4326,55 @@ func (m *Job) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *Job) MarshalTo(dAtA []byte) (int, error) {
+func (m *ShardStats) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.Type != 0 {
+	if m.ShardID != 0 {
 		dAtA[i] = 0x8
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Type))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ShardID))
 	}
-	if len(m.Content) > 0 {
-		dAtA[i] = 0x12
+	if m.WrittenBytes != 0 {
+		dAtA[i] = 0x10
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Content)))
-		i += copy(dAtA[i:], m.Content)
+		i = encodeVarintMetapb(dAtA, i, uint64(m.WrittenBytes))
 	}
-	if m.State != 0 {
+	if m.WrittenKeys != 0 {
 		dAtA[i] = 0x18
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.State))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.WrittenKeys))
 	}
-	if m.XXX_unrecognized != nil {
-		i += copy(dAtA[i:], m.XXX_unrecognized)
+	if m.ReadBytes != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ReadBytes))
 	}
-	return i, nil
-}
-
-func (m *RemoveShardJob) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+	if m.ReadKeys != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ReadKeys))
 	}
-	return dAtA[:n], nil
-}
-
-func (m *RemoveShardJob) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if m.ID != 0 {
-		dAtA[i] = 0x8
+	if m.ApproximateSize != 0 {
+		dAtA[i] = 0x30
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ID))
-	}
-	if len(m.Replicas) > 0 {
-		for _, msg := range m.Replicas {
-			dAtA[i] = 0x12
-			i++
-			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
-			n, err := msg.MarshalTo(dAtA[i:])
-			if err != nil {
-				return 0, err
-			}
-			i += n
-		}
-	}
-	if m.XXX_unrecognized != nil {
-		i += copy(dAtA[i:], m.XXX_unrecognized)
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ApproximateSize))
 	}
-	return i, nil
-}
-
-func (m *ShardPoolJob) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+	if m.ApproximateKeys != 0 {
+		dAtA[i] = 0x38
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ApproximateKeys))
 	}
-	return dAtA[:n], nil
-}
-
-func (m *ShardPoolJob) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if len(m.Pools) > 0 {
-		for _, msg := range m.Pools {
-			dAtA[i] = 0xa
-			i++
-			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
-			n, err := msg.MarshalTo(dAtA[i:])
-			if err != nil {
-				return 0, err
-			}
-			i += n
+	if m.Interval != nil {
+		dAtA[i] = 0x42
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Interval.Size()))
+		n2, err := m.Interval.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
 		}
+		i += n2
 	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
@@ -3729,7 +4382,7 @@ func (m *ShardPoolJob) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *ShardPoolJobMeta) Marshal() (dAtA []byte, err error) {
+func (m *ShardBucket) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -3739,87 +4392,47 @@ func (m *ShardPoolJobMeta) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *ShardPoolJobMeta) MarshalTo(dAtA []byte) (int, error) {
+func (m *ShardBucket) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.Group != 0 {
+	if m.ShardID != 0 {
 		dAtA[i] = 0x8
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Group))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ShardID))
 	}
-	if m.Capacity != 0 {
-		dAtA[i] = 0x10
+	if len(m.Start) > 0 {
+		dAtA[i] = 0x12
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Capacity))
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Start)))
+		i += copy(dAtA[i:], m.Start)
 	}
-	if len(m.RangePrefix) > 0 {
+	if len(m.End) > 0 {
 		dAtA[i] = 0x1a
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.RangePrefix)))
-		i += copy(dAtA[i:], m.RangePrefix)
-	}
-	if m.XXX_unrecognized != nil {
-		i += copy(dAtA[i:], m.XXX_unrecognized)
-	}
-	return i, nil
-}
-
-func (m *DestroyingStatus) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.End)))
+		i += copy(dAtA[i:], m.End)
 	}
-	return dAtA[:n], nil
-}
-
-func (m *DestroyingStatus) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if m.Index != 0 {
-		dAtA[i] = 0x8
+	if m.WrittenBytes != 0 {
+		dAtA[i] = 0x20
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Index))
-	}
-	if len(m.Replicas) > 0 {
-		for k, _ := range m.Replicas {
-			dAtA[i] = 0x12
-			i++
-			v := m.Replicas[k]
-			mapSize := 1 + sovMetapb(uint64(k)) + 1 + 1
-			i = encodeVarintMetapb(dAtA, i, uint64(mapSize))
-			dAtA[i] = 0x8
-			i++
-			i = encodeVarintMetapb(dAtA, i, uint64(k))
-			dAtA[i] = 0x10
-			i++
-			if v {
-				dAtA[i] = 1
-			} else {
-				dAtA[i] = 0
-			}
-			i++
-		}
+		i = encodeVarintMetapb(dAtA, i, uint64(m.WrittenBytes))
 	}
-	if m.State != 0 {
-		dAtA[i] = 0x18
+	if m.WrittenKeys != 0 {
+		dAtA[i] = 0x28
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.State))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.WrittenKeys))
 	}
-	if m.RemoveData {
-		dAtA[i] = 0x20
+	if m.ReadBytes != 0 {
+		dAtA[i] = 0x30
 		i++
-		if m.RemoveData {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ReadBytes))
+	}
+	if m.ReadKeys != 0 {
+		dAtA[i] = 0x38
 		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ReadKeys))
 	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
@@ -3827,7 +4440,7 @@ func (m *DestroyingStatus) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *ShardExtra) Marshal() (dAtA []byte, err error) {
+func (m *StoreStats) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -3837,105 +4450,176 @@ func (m *ShardExtra) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *ShardExtra) MarshalTo(dAtA []byte) (int, error) {
+func (m *StoreStats) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if len(m.Labels) > 0 {
-		for k, _ := range m.Labels {
-			dAtA[i] = 0xa
-			i++
-			v := m.Labels[k]
-			mapSize := 1 + len(k) + sovMetapb(uint64(len(k))) + 1 + len(v) + sovMetapb(uint64(len(v)))
-			i = encodeVarintMetapb(dAtA, i, uint64(mapSize))
-			dAtA[i] = 0xa
-			i++
-			i = encodeVarintMetapb(dAtA, i, uint64(len(k)))
-			i += copy(dAtA[i:], k)
-			dAtA[i] = 0x12
-			i++
-			i = encodeVarintMetapb(dAtA, i, uint64(len(v)))
-			i += copy(dAtA[i:], v)
-		}
+	if m.StoreID != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.StoreID))
 	}
-	if m.DestroyingStatus != nil {
-		dAtA[i] = 0x12
+	if m.StartTime != 0 {
+		dAtA[i] = 0x10
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.DestroyingStatus.Size()))
-		n4, err := m.DestroyingStatus.MarshalTo(dAtA[i:])
+		i = encodeVarintMetapb(dAtA, i, uint64(m.StartTime))
+	}
+	if m.Interval != nil {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Interval.Size()))
+		n3, err := m.Interval.MarshalTo(dAtA[i:])
 		if err != nil {
 			return 0, err
 		}
-		i += n4
+		i += n3
 	}
-	if m.XXX_unrecognized != nil {
-		i += copy(dAtA[i:], m.XXX_unrecognized)
+	if m.Capacity != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Capacity))
 	}
-	return i, nil
-}
-
-func (m *ScheduleGroupRule) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+	if m.Available != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Available))
 	}
-	return dAtA[:n], nil
-}
-
-func (m *ScheduleGroupRule) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if m.ID != 0 {
-		dAtA[i] = 0x8
+	if m.UsedSize != 0 {
+		dAtA[i] = 0x30
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ID))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.UsedSize))
 	}
-	if m.GroupID != 0 {
-		dAtA[i] = 0x10
+	if m.IsBusy {
+		dAtA[i] = 0x38
+		i++
+		if m.IsBusy {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.GroupID))
 	}
-	if len(m.Name) > 0 {
-		dAtA[i] = 0x1a
+	if m.ShardCount != 0 {
+		dAtA[i] = 0x40
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Name)))
-		i += copy(dAtA[i:], m.Name)
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ShardCount))
 	}
-	if len(m.GroupByLabel) > 0 {
-		dAtA[i] = 0x22
+	if m.SendingSnapCount != 0 {
+		dAtA[i] = 0x48
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.GroupByLabel)))
-		i += copy(dAtA[i:], m.GroupByLabel)
+		i = encodeVarintMetapb(dAtA, i, uint64(m.SendingSnapCount))
 	}
-	if m.XXX_unrecognized != nil {
-		i += copy(dAtA[i:], m.XXX_unrecognized)
+	if m.ReceivingSnapCount != 0 {
+		dAtA[i] = 0x50
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ReceivingSnapCount))
 	}
-	return i, nil
-}
-
-func (m *RaftMessageBatch) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+	if m.ApplyingSnapCount != 0 {
+		dAtA[i] = 0x58
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ApplyingSnapCount))
 	}
-	return dAtA[:n], nil
-}
-
-func (m *RaftMessageBatch) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if len(m.Messages) > 0 {
-		for _, msg := range m.Messages {
-			dAtA[i] = 0xa
+	if m.WrittenBytes != 0 {
+		dAtA[i] = 0x60
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.WrittenBytes))
+	}
+	if m.ReadBytes != 0 {
+		dAtA[i] = 0x68
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ReadBytes))
+	}
+	if m.WrittenKeys != 0 {
+		dAtA[i] = 0x70
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.WrittenKeys))
+	}
+	if m.ReadKeys != 0 {
+		dAtA[i] = 0x78
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ReadKeys))
+	}
+	if len(m.CpuUsages) > 0 {
+		for _, msg := range m.CpuUsages {
+			dAtA[i] = 0x82
+			i++
+			dAtA[i] = 0x1
+			i++
+			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if len(m.ReadIORates) > 0 {
+		for _, msg := range m.ReadIORates {
+			dAtA[i] = 0x8a
+			i++
+			dAtA[i] = 0x1
+			i++
+			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if len(m.WriteIORates) > 0 {
+		for _, msg := range m.WriteIORates {
+			dAtA[i] = 0x92
+			i++
+			dAtA[i] = 0x1
+			i++
+			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if m.ClockOffset != 0 {
+		dAtA[i] = 0x98
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ClockOffset))
+	}
+	if m.ClockSkewed {
+		dAtA[i] = 0xa0
+		i++
+		dAtA[i] = 0x1
+		i++
+		if m.ClockSkewed {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.MaxReplicaCount != 0 {
+		dAtA[i] = 0xa8
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.MaxReplicaCount))
+	}
+	if m.MaxLeaderCount != 0 {
+		dAtA[i] = 0xb0
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.MaxLeaderCount))
+	}
+	if len(m.ReplicaReadStats) > 0 {
+		for _, msg := range m.ReplicaReadStats {
+			dAtA[i] = 0xba
+			i++
+			dAtA[i] = 0x1
 			i++
 			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
 			n, err := msg.MarshalTo(dAtA[i:])
@@ -3945,13 +4629,20 @@ func (m *RaftMessageBatch) MarshalTo(dAtA []byte) (int, error) {
 			i += n
 		}
 	}
+	if m.MaxSnapshotCount != 0 {
+		dAtA[i] = 0xc0
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.MaxSnapshotCount))
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
 	return i, nil
 }
 
-func (m *RaftMessage) Marshal() (dAtA []byte, err error) {
+func (m *RecordPair) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -3961,105 +4652,90 @@ func (m *RaftMessage) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *RaftMessage) MarshalTo(dAtA []byte) (int, error) {
+func (m *RecordPair) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.ShardID != 0 {
-		dAtA[i] = 0x8
+	if len(m.Key) > 0 {
+		dAtA[i] = 0xa
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ShardID))
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Key)))
+		i += copy(dAtA[i:], m.Key)
 	}
-	if m.Group != 0 {
+	if m.Value != 0 {
 		dAtA[i] = 0x10
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Group))
-	}
-	dAtA[i] = 0x1a
-	i++
-	i = encodeVarintMetapb(dAtA, i, uint64(m.From.Size()))
-	n5, err := m.From.MarshalTo(dAtA[i:])
-	if err != nil {
-		return 0, err
-	}
-	i += n5
-	dAtA[i] = 0x22
-	i++
-	i = encodeVarintMetapb(dAtA, i, uint64(m.To.Size()))
-	n6, err := m.To.MarshalTo(dAtA[i:])
-	if err != nil {
-		return 0, err
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Value))
 	}
-	i += n6
-	dAtA[i] = 0x2a
-	i++
-	i = encodeVarintMetapb(dAtA, i, uint64(m.Message.Size()))
-	n7, err := m.Message.MarshalTo(dAtA[i:])
-	if err != nil {
-		return 0, err
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	i += n7
-	dAtA[i] = 0x32
-	i++
-	i = encodeVarintMetapb(dAtA, i, uint64(m.ShardEpoch.Size()))
-	n8, err := m.ShardEpoch.MarshalTo(dAtA[i:])
+	return i, nil
+}
+
+func (m *Member) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	i += n8
-	if m.IsTombstone {
-		dAtA[i] = 0x38
-		i++
-		if m.IsTombstone {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
+	return dAtA[:n], nil
+}
+
+func (m *Member) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.ID != 0 {
+		dAtA[i] = 0x8
 		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ID))
 	}
-	if len(m.Start) > 0 {
-		dAtA[i] = 0x42
+	if len(m.Name) > 0 {
+		dAtA[i] = 0x12
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Start)))
-		i += copy(dAtA[i:], m.Start)
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Name)))
+		i += copy(dAtA[i:], m.Name)
 	}
-	if len(m.End) > 0 {
-		dAtA[i] = 0x4a
+	if len(m.Addr) > 0 {
+		dAtA[i] = 0x1a
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.End)))
-		i += copy(dAtA[i:], m.End)
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Addr)))
+		i += copy(dAtA[i:], m.Addr)
 	}
-	if len(m.Unique) > 0 {
-		dAtA[i] = 0x52
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Unique)))
-		i += copy(dAtA[i:], m.Unique)
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	if len(m.RuleGroups) > 0 {
-		for _, s := range m.RuleGroups {
-			dAtA[i] = 0x5a
-			i++
-			l = len(s)
-			for l >= 1<<7 {
-				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
-				l >>= 7
-				i++
-			}
-			dAtA[i] = uint8(l)
-			i++
-			i += copy(dAtA[i:], s)
-		}
+	return i, nil
+}
+
+func (m *ProphetCluster) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	if m.CommitIndex != 0 {
-		dAtA[i] = 0x60
+	return dAtA[:n], nil
+}
+
+func (m *ProphetCluster) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.ID != 0 {
+		dAtA[i] = 0x8
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.CommitIndex))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ID))
 	}
-	if m.SendTime != 0 {
-		dAtA[i] = 0x68
+	if m.MaxReplicaCount != 0 {
+		dAtA[i] = 0x10
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.SendTime))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.MaxReplicaCount))
 	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
@@ -4067,7 +4743,7 @@ func (m *RaftMessage) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *SnapshotChunk) Marshal() (dAtA []byte, err error) {
+func (m *TimeInterval) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -4077,106 +4753,65 @@ func (m *SnapshotChunk) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *SnapshotChunk) MarshalTo(dAtA []byte) (int, error) {
+func (m *TimeInterval) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.StoreID != 0 {
+	if m.Start != 0 {
 		dAtA[i] = 0x8
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.StoreID))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Start))
 	}
-	if m.ShardID != 0 {
+	if m.End != 0 {
 		dAtA[i] = 0x10
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ShardID))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.End))
 	}
-	if m.ReplicaID != 0 {
-		dAtA[i] = 0x18
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ReplicaID))
-	}
-	if m.From != 0 {
-		dAtA[i] = 0x20
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.From))
-	}
-	if m.ChunkID != 0 {
-		dAtA[i] = 0x28
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ChunkID))
-	}
-	if m.ChunkSize != 0 {
-		dAtA[i] = 0x30
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ChunkSize))
-	}
-	if m.ChunkCount != 0 {
-		dAtA[i] = 0x38
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ChunkCount))
-	}
-	if m.Index != 0 {
-		dAtA[i] = 0x40
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Index))
-	}
-	if m.Term != 0 {
-		dAtA[i] = 0x48
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Term))
-	}
-	if len(m.FilePath) > 0 {
-		dAtA[i] = 0x52
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.FilePath)))
-		i += copy(dAtA[i:], m.FilePath)
-	}
-	if m.FileSize != 0 {
-		dAtA[i] = 0x58
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.FileSize))
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	if m.FileChunkID != 0 {
-		dAtA[i] = 0x60
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.FileChunkID))
+	return i, nil
+}
+
+func (m *Job) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	if m.FileChunkCount != 0 {
-		dAtA[i] = 0x68
+	return dAtA[:n], nil
+}
+
+func (m *Job) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Type != 0 {
+		dAtA[i] = 0x8
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.FileChunkCount))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Type))
 	}
-	if len(m.Data) > 0 {
-		dAtA[i] = 0x72
+	if len(m.Content) > 0 {
+		dAtA[i] = 0x12
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Data)))
-		i += copy(dAtA[i:], m.Data)
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Content)))
+		i += copy(dAtA[i:], m.Content)
 	}
-	if len(m.Extra) > 0 {
-		dAtA[i] = 0x7a
+	if m.State != 0 {
+		dAtA[i] = 0x18
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Extra)))
-		i += copy(dAtA[i:], m.Extra)
-	}
-	dAtA[i] = 0x82
-	i++
-	dAtA[i] = 0x1
-	i++
-	i = encodeVarintMetapb(dAtA, i, uint64(m.ConfState.Size()))
-	n9, err := m.ConfState.MarshalTo(dAtA[i:])
-	if err != nil {
-		return 0, err
+		i = encodeVarintMetapb(dAtA, i, uint64(m.State))
 	}
-	i += n9
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
 	return i, nil
 }
 
-func (m *StoreIdent) Marshal() (dAtA []byte, err error) {
+func (m *JobCheckpoint) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -4186,20 +4821,26 @@ func (m *StoreIdent) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *StoreIdent) MarshalTo(dAtA []byte) (int, error) {
+func (m *JobCheckpoint) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.ClusterID != 0 {
+	if m.Type != 0 {
 		dAtA[i] = 0x8
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ClusterID))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Type))
 	}
-	if m.StoreID != 0 {
+	if m.Seq != 0 {
 		dAtA[i] = 0x10
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.StoreID))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Seq))
+	}
+	if len(m.Data) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Data)))
+		i += copy(dAtA[i:], m.Data)
 	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
@@ -4207,7 +4848,7 @@ func (m *StoreIdent) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *Shard) Marshal() (dAtA []byte, err error) {
+func (m *RemoveShardJob) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -4217,7 +4858,7 @@ func (m *Shard) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *Shard) MarshalTo(dAtA []byte) (int, error) {
+func (m *RemoveShardJob) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
@@ -4227,72 +4868,9 @@ func (m *Shard) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintMetapb(dAtA, i, uint64(m.ID))
 	}
-	if len(m.Start) > 0 {
-		dAtA[i] = 0x12
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Start)))
-		i += copy(dAtA[i:], m.Start)
-	}
-	if len(m.End) > 0 {
-		dAtA[i] = 0x1a
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.End)))
-		i += copy(dAtA[i:], m.End)
-	}
-	dAtA[i] = 0x22
-	i++
-	i = encodeVarintMetapb(dAtA, i, uint64(m.Epoch.Size()))
-	n10, err := m.Epoch.MarshalTo(dAtA[i:])
-	if err != nil {
-		return 0, err
-	}
-	i += n10
-	if m.State != 0 {
-		dAtA[i] = 0x28
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.State))
-	}
 	if len(m.Replicas) > 0 {
 		for _, msg := range m.Replicas {
-			dAtA[i] = 0x32
-			i++
-			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
-			n, err := msg.MarshalTo(dAtA[i:])
-			if err != nil {
-				return 0, err
-			}
-			i += n
-		}
-	}
-	if m.Group != 0 {
-		dAtA[i] = 0x38
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Group))
-	}
-	if len(m.Unique) > 0 {
-		dAtA[i] = 0x42
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Unique)))
-		i += copy(dAtA[i:], m.Unique)
-	}
-	if len(m.RuleGroups) > 0 {
-		for _, s := range m.RuleGroups {
-			dAtA[i] = 0x4a
-			i++
-			l = len(s)
-			for l >= 1<<7 {
-				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
-				l >>= 7
-				i++
-			}
-			dAtA[i] = uint8(l)
-			i++
-			i += copy(dAtA[i:], s)
-		}
-	}
-	if len(m.Labels) > 0 {
-		for _, msg := range m.Labels {
-			dAtA[i] = 0x52
+			dAtA[i] = 0x12
 			i++
 			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
 			n, err := msg.MarshalTo(dAtA[i:])
@@ -4308,7 +4886,7 @@ func (m *Shard) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *LogIndex) Marshal() (dAtA []byte, err error) {
+func (m *ShardPoolJob) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -4318,20 +4896,22 @@ func (m *LogIndex) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *LogIndex) MarshalTo(dAtA []byte) (int, error) {
+func (m *ShardPoolJob) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.Index != 0 {
-		dAtA[i] = 0x8
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Index))
-	}
-	if m.Term != 0 {
-		dAtA[i] = 0x10
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Term))
+	if len(m.Pools) > 0 {
+		for _, msg := range m.Pools {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
 	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
@@ -4339,7 +4919,7 @@ func (m *LogIndex) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *ShardMetadata) Marshal() (dAtA []byte, err error) {
+func (m *ShardPoolJobMeta) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -4349,36 +4929,34 @@ func (m *ShardMetadata) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *ShardMetadata) MarshalTo(dAtA []byte) (int, error) {
+func (m *ShardPoolJobMeta) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.ShardID != 0 {
+	if m.Group != 0 {
 		dAtA[i] = 0x8
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ShardID))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Group))
 	}
-	if m.LogIndex != 0 {
+	if m.Capacity != 0 {
 		dAtA[i] = 0x10
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.LogIndex))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Capacity))
 	}
-	dAtA[i] = 0x1a
-	i++
-	i = encodeVarintMetapb(dAtA, i, uint64(m.Metadata.Size()))
-	n11, err := m.Metadata.MarshalTo(dAtA[i:])
-	if err != nil {
-		return 0, err
+	if len(m.RangePrefix) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.RangePrefix)))
+		i += copy(dAtA[i:], m.RangePrefix)
 	}
-	i += n11
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
 	return i, nil
 }
 
-func (m *ShardLocalState) Marshal() (dAtA []byte, err error) {
+func (m *DestroyingStatus) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -4388,30 +4966,37 @@ func (m *ShardLocalState) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *ShardLocalState) MarshalTo(dAtA []byte) (int, error) {
+func (m *DestroyingStatus) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	dAtA[i] = 0xa
-	i++
-	i = encodeVarintMetapb(dAtA, i, uint64(m.Shard.Size()))
-	n12, err := m.Shard.MarshalTo(dAtA[i:])
-	if err != nil {
-		return 0, err
-	}
-	i += n12
-	if m.Lease != nil {
-		dAtA[i] = 0x12
+	if m.Index != 0 {
+		dAtA[i] = 0x8
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Lease.Size()))
-		n13, err := m.Lease.MarshalTo(dAtA[i:])
-		if err != nil {
-			return 0, err
-		}
-		i += n13
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Index))
 	}
-	if m.State != 0 {
+	if len(m.Replicas) > 0 {
+		for k, _ := range m.Replicas {
+			dAtA[i] = 0x12
+			i++
+			v := m.Replicas[k]
+			mapSize := 1 + sovMetapb(uint64(k)) + 1 + 1
+			i = encodeVarintMetapb(dAtA, i, uint64(mapSize))
+			dAtA[i] = 0x8
+			i++
+			i = encodeVarintMetapb(dAtA, i, uint64(k))
+			dAtA[i] = 0x10
+			i++
+			if v {
+				dAtA[i] = 1
+			} else {
+				dAtA[i] = 0
+			}
+			i++
+		}
+	}
+	if m.State != 0 {
 		dAtA[i] = 0x18
 		i++
 		i = encodeVarintMetapb(dAtA, i, uint64(m.State))
@@ -4432,7 +5017,7 @@ func (m *ShardLocalState) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *Store) Marshal() (dAtA []byte, err error) {
+func (m *ShardExtra) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -4442,82 +5027,37 @@ func (m *Store) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *Store) MarshalTo(dAtA []byte) (int, error) {
+func (m *ShardExtra) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.ID != 0 {
-		dAtA[i] = 0x8
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ID))
-	}
-	if len(m.RaftAddress) > 0 {
-		dAtA[i] = 0x12
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.RaftAddress)))
-		i += copy(dAtA[i:], m.RaftAddress)
-	}
-	if len(m.ClientAddress) > 0 {
-		dAtA[i] = 0x1a
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.ClientAddress)))
-		i += copy(dAtA[i:], m.ClientAddress)
-	}
 	if len(m.Labels) > 0 {
-		for _, msg := range m.Labels {
-			dAtA[i] = 0x22
+		for k, _ := range m.Labels {
+			dAtA[i] = 0xa
 			i++
-			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
-			n, err := msg.MarshalTo(dAtA[i:])
-			if err != nil {
-				return 0, err
-			}
-			i += n
+			v := m.Labels[k]
+			mapSize := 1 + len(k) + sovMetapb(uint64(len(k))) + 1 + len(v) + sovMetapb(uint64(len(v)))
+			i = encodeVarintMetapb(dAtA, i, uint64(mapSize))
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintMetapb(dAtA, i, uint64(len(k)))
+			i += copy(dAtA[i:], k)
+			dAtA[i] = 0x12
+			i++
+			i = encodeVarintMetapb(dAtA, i, uint64(len(v)))
+			i += copy(dAtA[i:], v)
 		}
 	}
-	if m.State != 0 {
-		dAtA[i] = 0x28
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.State))
-	}
-	if m.StartTime != 0 {
-		dAtA[i] = 0x30
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.StartTime))
-	}
-	if m.LastHeartbeatTime != 0 {
-		dAtA[i] = 0x38
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.LastHeartbeatTime))
-	}
-	if len(m.Version) > 0 {
-		dAtA[i] = 0x42
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Version)))
-		i += copy(dAtA[i:], m.Version)
-	}
-	if len(m.CommitID) > 0 {
-		dAtA[i] = 0x4a
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.CommitID)))
-		i += copy(dAtA[i:], m.CommitID)
-	}
-	if len(m.DeployPath) > 0 {
-		dAtA[i] = 0x52
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.DeployPath)))
-		i += copy(dAtA[i:], m.DeployPath)
-	}
-	if m.Destroyed {
-		dAtA[i] = 0x58
+	if m.DestroyingStatus != nil {
+		dAtA[i] = 0x12
 		i++
-		if m.Destroyed {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
+		i = encodeVarintMetapb(dAtA, i, uint64(m.DestroyingStatus.Size()))
+		n4, err := m.DestroyingStatus.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
 		}
-		i++
+		i += n4
 	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
@@ -4525,7 +5065,7 @@ func (m *Store) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *ShardsPool) Marshal() (dAtA []byte, err error) {
+func (m *ScheduleGroupRule) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -4535,37 +5075,32 @@ func (m *ShardsPool) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *ShardsPool) MarshalTo(dAtA []byte) (int, error) {
+func (m *ScheduleGroupRule) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if len(m.Pools) > 0 {
-		for k, _ := range m.Pools {
-			dAtA[i] = 0xa
-			i++
-			v := m.Pools[k]
-			msgSize := 0
-			if v != nil {
-				msgSize = v.Size()
-				msgSize += 1 + sovMetapb(uint64(msgSize))
-			}
-			mapSize := 1 + sovMetapb(uint64(k)) + msgSize
-			i = encodeVarintMetapb(dAtA, i, uint64(mapSize))
-			dAtA[i] = 0x8
-			i++
-			i = encodeVarintMetapb(dAtA, i, uint64(k))
-			if v != nil {
-				dAtA[i] = 0x12
-				i++
-				i = encodeVarintMetapb(dAtA, i, uint64(v.Size()))
-				n14, err := v.MarshalTo(dAtA[i:])
-				if err != nil {
-					return 0, err
-				}
-				i += n14
-			}
-		}
+	if m.ID != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ID))
+	}
+	if m.GroupID != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.GroupID))
+	}
+	if len(m.Name) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Name)))
+		i += copy(dAtA[i:], m.Name)
+	}
+	if len(m.GroupByLabel) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.GroupByLabel)))
+		i += copy(dAtA[i:], m.GroupByLabel)
 	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
@@ -4573,7 +5108,7 @@ func (m *ShardsPool) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *ShardPool) Marshal() (dAtA []byte, err error) {
+func (m *RaftMessageBatch) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -4583,25 +5118,14 @@ func (m *ShardPool) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *ShardPool) MarshalTo(dAtA []byte) (int, error) {
+func (m *RaftMessageBatch) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.Capacity != 0 {
-		dAtA[i] = 0x8
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Capacity))
-	}
-	if len(m.RangePrefix) > 0 {
-		dAtA[i] = 0x12
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.RangePrefix)))
-		i += copy(dAtA[i:], m.RangePrefix)
-	}
-	if len(m.AllocatedShards) > 0 {
-		for _, msg := range m.AllocatedShards {
-			dAtA[i] = 0x1a
+	if len(m.Messages) > 0 {
+		for _, msg := range m.Messages {
+			dAtA[i] = 0xa
 			i++
 			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
 			n, err := msg.MarshalTo(dAtA[i:])
@@ -4611,23 +5135,13 @@ func (m *ShardPool) MarshalTo(dAtA []byte) (int, error) {
 			i += n
 		}
 	}
-	if m.Seq != 0 {
-		dAtA[i] = 0x20
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Seq))
-	}
-	if m.AllocatedOffset != 0 {
-		dAtA[i] = 0x28
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.AllocatedOffset))
-	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
 	return i, nil
 }
 
-func (m *AllocatedShard) Marshal() (dAtA []byte, err error) {
+func (m *RaftMessage) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -4637,7 +5151,7 @@ func (m *AllocatedShard) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *AllocatedShard) MarshalTo(dAtA []byte) (int, error) {
+func (m *RaftMessage) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
@@ -4647,91 +5161,113 @@ func (m *AllocatedShard) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintMetapb(dAtA, i, uint64(m.ShardID))
 	}
-	if m.AllocatedAt != 0 {
+	if m.Group != 0 {
 		dAtA[i] = 0x10
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.AllocatedAt))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Group))
 	}
-	if len(m.Purpose) > 0 {
-		dAtA[i] = 0x1a
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Purpose)))
-		i += copy(dAtA[i:], m.Purpose)
+	dAtA[i] = 0x1a
+	i++
+	i = encodeVarintMetapb(dAtA, i, uint64(m.From.Size()))
+	n5, err := m.From.MarshalTo(dAtA[i:])
+	if err != nil {
+		return 0, err
 	}
-	if m.XXX_unrecognized != nil {
-		i += copy(dAtA[i:], m.XXX_unrecognized)
+	i += n5
+	dAtA[i] = 0x22
+	i++
+	i = encodeVarintMetapb(dAtA, i, uint64(m.To.Size()))
+	n6, err := m.To.MarshalTo(dAtA[i:])
+	if err != nil {
+		return 0, err
 	}
-	return i, nil
-}
-
-func (m *ShardsPoolCmd) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
+	i += n6
+	dAtA[i] = 0x2a
+	i++
+	i = encodeVarintMetapb(dAtA, i, uint64(m.Message.Size()))
+	n7, err := m.Message.MarshalTo(dAtA[i:])
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	return dAtA[:n], nil
-}
-
-func (m *ShardsPoolCmd) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if m.Type != 0 {
-		dAtA[i] = 0x8
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Type))
+	i += n7
+	dAtA[i] = 0x32
+	i++
+	i = encodeVarintMetapb(dAtA, i, uint64(m.ShardEpoch.Size()))
+	n8, err := m.ShardEpoch.MarshalTo(dAtA[i:])
+	if err != nil {
+		return 0, err
 	}
-	if m.Create != nil {
-		dAtA[i] = 0x12
-		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Create.Size()))
-		n15, err := m.Create.MarshalTo(dAtA[i:])
-		if err != nil {
-			return 0, err
+	i += n8
+	if m.IsTombstone {
+		dAtA[i] = 0x38
+		i++
+		if m.IsTombstone {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
 		}
-		i += n15
+		i++
 	}
-	if m.Alloc != nil {
-		dAtA[i] = 0x1a
+	if len(m.Start) > 0 {
+		dAtA[i] = 0x42
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Alloc.Size()))
-		n16, err := m.Alloc.MarshalTo(dAtA[i:])
-		if err != nil {
-			return 0, err
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Start)))
+		i += copy(dAtA[i:], m.Start)
+	}
+	if len(m.End) > 0 {
+		dAtA[i] = 0x4a
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.End)))
+		i += copy(dAtA[i:], m.End)
+	}
+	if len(m.Unique) > 0 {
+		dAtA[i] = 0x52
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Unique)))
+		i += copy(dAtA[i:], m.Unique)
+	}
+	if len(m.RuleGroups) > 0 {
+		for _, s := range m.RuleGroups {
+			dAtA[i] = 0x5a
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
 		}
-		i += n16
 	}
-	if m.XXX_unrecognized != nil {
-		i += copy(dAtA[i:], m.XXX_unrecognized)
+	if m.CommitIndex != 0 {
+		dAtA[i] = 0x60
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.CommitIndex))
 	}
-	return i, nil
-}
-
-func (m *ShardsPoolCreateCmd) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+	if m.SendTime != 0 {
+		dAtA[i] = 0x68
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.SendTime))
+	}
+	if m.AppliedIndex != 0 {
+		dAtA[i] = 0x70
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.AppliedIndex))
+	}
+	if m.SnapshotFailure != 0 {
+		dAtA[i] = 0x78
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.SnapshotFailure))
 	}
-	return dAtA[:n], nil
-}
-
-func (m *ShardsPoolCreateCmd) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
 	return i, nil
 }
 
-func (m *ShardsPoolAllocCmd) Marshal() (dAtA []byte, err error) {
+func (m *SnapshotChunk) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -4741,21 +5277,119 @@ func (m *ShardsPoolAllocCmd) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *ShardsPoolAllocCmd) MarshalTo(dAtA []byte) (int, error) {
+func (m *SnapshotChunk) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.Group != 0 {
+	if m.StoreID != 0 {
 		dAtA[i] = 0x8
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Group))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.StoreID))
 	}
-	if len(m.Purpose) > 0 {
-		dAtA[i] = 0x12
+	if m.ShardID != 0 {
+		dAtA[i] = 0x10
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Purpose)))
-		i += copy(dAtA[i:], m.Purpose)
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ShardID))
+	}
+	if m.ReplicaID != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ReplicaID))
+	}
+	if m.From != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.From))
+	}
+	if m.ChunkID != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ChunkID))
+	}
+	if m.ChunkSize != 0 {
+		dAtA[i] = 0x30
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ChunkSize))
+	}
+	if m.ChunkCount != 0 {
+		dAtA[i] = 0x38
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ChunkCount))
+	}
+	if m.Index != 0 {
+		dAtA[i] = 0x40
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Index))
+	}
+	if m.Term != 0 {
+		dAtA[i] = 0x48
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Term))
+	}
+	if len(m.FilePath) > 0 {
+		dAtA[i] = 0x52
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.FilePath)))
+		i += copy(dAtA[i:], m.FilePath)
+	}
+	if m.FileSize != 0 {
+		dAtA[i] = 0x58
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.FileSize))
+	}
+	if m.FileChunkID != 0 {
+		dAtA[i] = 0x60
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.FileChunkID))
+	}
+	if m.FileChunkCount != 0 {
+		dAtA[i] = 0x68
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.FileChunkCount))
+	}
+	if len(m.Data) > 0 {
+		dAtA[i] = 0x72
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Data)))
+		i += copy(dAtA[i:], m.Data)
+	}
+	if len(m.Extra) > 0 {
+		dAtA[i] = 0x7a
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Extra)))
+		i += copy(dAtA[i:], m.Extra)
+	}
+	dAtA[i] = 0x82
+	i++
+	dAtA[i] = 0x1
+	i++
+	i = encodeVarintMetapb(dAtA, i, uint64(m.ConfState.Size()))
+	n9, err := m.ConfState.MarshalTo(dAtA[i:])
+	if err != nil {
+		return 0, err
+	}
+	i += n9
+	if m.FromStoreID != 0 {
+		dAtA[i] = 0x88
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.FromStoreID))
+	}
+	if m.Format != 0 {
+		dAtA[i] = 0x90
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Format))
+	}
+	if m.SnapshotSize != 0 {
+		dAtA[i] = 0x98
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.SnapshotSize))
 	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
@@ -4763,7 +5397,7 @@ func (m *ShardsPoolAllocCmd) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *SnapshotInfo) Marshal() (dAtA []byte, err error) {
+func (m *StoreIdent) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -4773,25 +5407,25 @@ func (m *SnapshotInfo) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *SnapshotInfo) MarshalTo(dAtA []byte) (int, error) {
+func (m *StoreIdent) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.Extra != 0 {
+	if m.ClusterID != 0 {
 		dAtA[i] = 0x8
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Extra))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ClusterID))
 	}
-	if m.Dummy {
+	if m.StoreID != 0 {
 		dAtA[i] = 0x10
 		i++
-		if m.Dummy {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
+		i = encodeVarintMetapb(dAtA, i, uint64(m.StoreID))
+	}
+	if m.Epoch != 0 {
+		dAtA[i] = 0x18
 		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Epoch))
 	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
@@ -4799,7 +5433,7 @@ func (m *SnapshotInfo) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *EpochLease) Marshal() (dAtA []byte, err error) {
+func (m *Shard) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -4809,727 +5443,1024 @@ func (m *EpochLease) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *EpochLease) MarshalTo(dAtA []byte) (int, error) {
+func (m *Shard) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.Epoch != 0 {
+	if m.ID != 0 {
 		dAtA[i] = 0x8
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.Epoch))
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ID))
 	}
-	if m.ReplicaID != 0 {
-		dAtA[i] = 0x10
+	if len(m.Start) > 0 {
+		dAtA[i] = 0x12
 		i++
-		i = encodeVarintMetapb(dAtA, i, uint64(m.ReplicaID))
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Start)))
+		i += copy(dAtA[i:], m.Start)
 	}
-	if m.XXX_unrecognized != nil {
-		i += copy(dAtA[i:], m.XXX_unrecognized)
+	if len(m.End) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.End)))
+		i += copy(dAtA[i:], m.End)
 	}
-	return i, nil
-}
-
-func encodeVarintMetapb(dAtA []byte, offset int, v uint64) int {
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+	dAtA[i] = 0x22
+	i++
+	i = encodeVarintMetapb(dAtA, i, uint64(m.Epoch.Size()))
+	n10, err := m.Epoch.MarshalTo(dAtA[i:])
+	if err != nil {
+		return 0, err
 	}
-	dAtA[offset] = uint8(v)
-	return offset + 1
-}
-func (m *ShardEpoch) Size() (n int) {
-	if m == nil {
-		return 0
+	i += n10
+	if m.State != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.State))
 	}
-	var l int
-	_ = l
-	if m.ConfigVer != 0 {
-		n += 1 + sovMetapb(uint64(m.ConfigVer))
-	}
-	if m.Generation != 0 {
-		n += 1 + sovMetapb(uint64(m.Generation))
-	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
-	}
-	return n
-}
-
-func (m *Replica) Size() (n int) {
-	if m == nil {
-		return 0
+	if len(m.Replicas) > 0 {
+		for _, msg := range m.Replicas {
+			dAtA[i] = 0x32
+			i++
+			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
 	}
-	var l int
-	_ = l
-	if m.ID != 0 {
-		n += 1 + sovMetapb(uint64(m.ID))
+	if m.Group != 0 {
+		dAtA[i] = 0x38
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Group))
 	}
-	if m.StoreID != 0 {
-		n += 1 + sovMetapb(uint64(m.StoreID))
+	if len(m.Unique) > 0 {
+		dAtA[i] = 0x42
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Unique)))
+		i += copy(dAtA[i:], m.Unique)
 	}
-	if m.Role != 0 {
-		n += 1 + sovMetapb(uint64(m.Role))
+	if len(m.RuleGroups) > 0 {
+		for _, s := range m.RuleGroups {
+			dAtA[i] = 0x4a
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
 	}
-	if m.InitialMember {
-		n += 2
+	if len(m.Labels) > 0 {
+		for _, msg := range m.Labels {
+			dAtA[i] = 0x52
+			i++
+			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *ReplicaStats) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *LogIndex) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *LogIndex) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = m.Replica.Size()
-	n += 1 + l + sovMetapb(uint64(l))
-	if m.DownSeconds != 0 {
-		n += 1 + sovMetapb(uint64(m.DownSeconds))
+	if m.Index != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Index))
+	}
+	if m.Term != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Term))
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *Label) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *ShardMetadata) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ShardMetadata) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Key)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
+	if m.ShardID != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ShardID))
 	}
-	l = len(m.Value)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
+	if m.LogIndex != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.LogIndex))
+	}
+	dAtA[i] = 0x1a
+	i++
+	i = encodeVarintMetapb(dAtA, i, uint64(m.Metadata.Size()))
+	n11, err := m.Metadata.MarshalTo(dAtA[i:])
+	if err != nil {
+		return 0, err
 	}
+	i += n11
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *ShardStats) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *ShardLocalState) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ShardLocalState) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.ShardID != 0 {
-		n += 1 + sovMetapb(uint64(m.ShardID))
-	}
-	if m.WrittenBytes != 0 {
-		n += 1 + sovMetapb(uint64(m.WrittenBytes))
-	}
-	if m.WrittenKeys != 0 {
-		n += 1 + sovMetapb(uint64(m.WrittenKeys))
-	}
-	if m.ReadBytes != 0 {
-		n += 1 + sovMetapb(uint64(m.ReadBytes))
-	}
-	if m.ReadKeys != 0 {
-		n += 1 + sovMetapb(uint64(m.ReadKeys))
+	dAtA[i] = 0xa
+	i++
+	i = encodeVarintMetapb(dAtA, i, uint64(m.Shard.Size()))
+	n12, err := m.Shard.MarshalTo(dAtA[i:])
+	if err != nil {
+		return 0, err
 	}
-	if m.ApproximateSize != 0 {
-		n += 1 + sovMetapb(uint64(m.ApproximateSize))
+	i += n12
+	if m.Lease != nil {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Lease.Size()))
+		n13, err := m.Lease.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n13
 	}
-	if m.ApproximateKeys != 0 {
-		n += 1 + sovMetapb(uint64(m.ApproximateKeys))
+	if m.State != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.State))
 	}
-	if m.Interval != nil {
-		l = m.Interval.Size()
-		n += 1 + l + sovMetapb(uint64(l))
+	if m.RemoveData {
+		dAtA[i] = 0x20
+		i++
+		if m.RemoveData {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *StoreStats) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *Store) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *Store) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.StoreID != 0 {
-		n += 1 + sovMetapb(uint64(m.StoreID))
-	}
-	if m.StartTime != 0 {
-		n += 1 + sovMetapb(uint64(m.StartTime))
+	if m.ID != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ID))
 	}
-	if m.Interval != nil {
-		l = m.Interval.Size()
-		n += 1 + l + sovMetapb(uint64(l))
+	if len(m.RaftAddress) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.RaftAddress)))
+		i += copy(dAtA[i:], m.RaftAddress)
 	}
-	if m.Capacity != 0 {
-		n += 1 + sovMetapb(uint64(m.Capacity))
+	if len(m.ClientAddress) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.ClientAddress)))
+		i += copy(dAtA[i:], m.ClientAddress)
 	}
-	if m.Available != 0 {
-		n += 1 + sovMetapb(uint64(m.Available))
-	}
-	if m.UsedSize != 0 {
-		n += 1 + sovMetapb(uint64(m.UsedSize))
-	}
-	if m.IsBusy {
-		n += 2
-	}
-	if m.ShardCount != 0 {
-		n += 1 + sovMetapb(uint64(m.ShardCount))
-	}
-	if m.SendingSnapCount != 0 {
-		n += 1 + sovMetapb(uint64(m.SendingSnapCount))
+	if len(m.Labels) > 0 {
+		for _, msg := range m.Labels {
+			dAtA[i] = 0x22
+			i++
+			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
 	}
-	if m.ReceivingSnapCount != 0 {
-		n += 1 + sovMetapb(uint64(m.ReceivingSnapCount))
+	if m.State != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.State))
 	}
-	if m.ApplyingSnapCount != 0 {
-		n += 1 + sovMetapb(uint64(m.ApplyingSnapCount))
+	if m.StartTime != 0 {
+		dAtA[i] = 0x30
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.StartTime))
 	}
-	if m.WrittenBytes != 0 {
-		n += 1 + sovMetapb(uint64(m.WrittenBytes))
+	if m.LastHeartbeatTime != 0 {
+		dAtA[i] = 0x38
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.LastHeartbeatTime))
 	}
-	if m.ReadBytes != 0 {
-		n += 1 + sovMetapb(uint64(m.ReadBytes))
+	if len(m.Version) > 0 {
+		dAtA[i] = 0x42
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Version)))
+		i += copy(dAtA[i:], m.Version)
 	}
-	if m.WrittenKeys != 0 {
-		n += 1 + sovMetapb(uint64(m.WrittenKeys))
+	if len(m.CommitID) > 0 {
+		dAtA[i] = 0x4a
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.CommitID)))
+		i += copy(dAtA[i:], m.CommitID)
 	}
-	if m.ReadKeys != 0 {
-		n += 1 + sovMetapb(uint64(m.ReadKeys))
+	if len(m.DeployPath) > 0 {
+		dAtA[i] = 0x52
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.DeployPath)))
+		i += copy(dAtA[i:], m.DeployPath)
 	}
-	if len(m.CpuUsages) > 0 {
-		for _, e := range m.CpuUsages {
-			l = e.Size()
-			n += 2 + l + sovMetapb(uint64(l))
+	if m.Destroyed {
+		dAtA[i] = 0x58
+		i++
+		if m.Destroyed {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
 		}
+		i++
 	}
-	if len(m.ReadIORates) > 0 {
-		for _, e := range m.ReadIORates {
-			l = e.Size()
-			n += 2 + l + sovMetapb(uint64(l))
-		}
+	if m.Epoch != 0 {
+		dAtA[i] = 0x60
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Epoch))
 	}
-	if len(m.WriteIORates) > 0 {
-		for _, e := range m.WriteIORates {
-			l = e.Size()
-			n += 2 + l + sovMetapb(uint64(l))
-		}
+	if m.SnapshotFormat != 0 {
+		dAtA[i] = 0x68
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.SnapshotFormat))
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *RecordPair) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *ShardsPool) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ShardsPool) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Key)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
-	}
-	if m.Value != 0 {
-		n += 1 + sovMetapb(uint64(m.Value))
+	if len(m.Pools) > 0 {
+		for k, _ := range m.Pools {
+			dAtA[i] = 0xa
+			i++
+			v := m.Pools[k]
+			msgSize := 0
+			if v != nil {
+				msgSize = v.Size()
+				msgSize += 1 + sovMetapb(uint64(msgSize))
+			}
+			mapSize := 1 + sovMetapb(uint64(k)) + msgSize
+			i = encodeVarintMetapb(dAtA, i, uint64(mapSize))
+			dAtA[i] = 0x8
+			i++
+			i = encodeVarintMetapb(dAtA, i, uint64(k))
+			if v != nil {
+				dAtA[i] = 0x12
+				i++
+				i = encodeVarintMetapb(dAtA, i, uint64(v.Size()))
+				n14, err := v.MarshalTo(dAtA[i:])
+				if err != nil {
+					return 0, err
+				}
+				i += n14
+			}
+		}
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *Member) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *ShardPool) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ShardPool) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.ID != 0 {
-		n += 1 + sovMetapb(uint64(m.ID))
+	if m.Capacity != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Capacity))
 	}
-	l = len(m.Name)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
+	if len(m.RangePrefix) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.RangePrefix)))
+		i += copy(dAtA[i:], m.RangePrefix)
 	}
-	l = len(m.Addr)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
+	if len(m.AllocatedShards) > 0 {
+		for _, msg := range m.AllocatedShards {
+			dAtA[i] = 0x1a
+			i++
+			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if m.Seq != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Seq))
+	}
+	if m.AllocatedOffset != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.AllocatedOffset))
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *ProphetCluster) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *AllocatedShard) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *AllocatedShard) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.ID != 0 {
-		n += 1 + sovMetapb(uint64(m.ID))
+	if m.ShardID != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ShardID))
 	}
-	if m.MaxReplicaCount != 0 {
-		n += 1 + sovMetapb(uint64(m.MaxReplicaCount))
+	if m.AllocatedAt != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.AllocatedAt))
+	}
+	if len(m.Purpose) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Purpose)))
+		i += copy(dAtA[i:], m.Purpose)
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *TimeInterval) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *ShardsPoolCmd) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ShardsPoolCmd) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.Start != 0 {
-		n += 1 + sovMetapb(uint64(m.Start))
+	if m.Type != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Type))
 	}
-	if m.End != 0 {
-		n += 1 + sovMetapb(uint64(m.End))
+	if m.Create != nil {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Create.Size()))
+		n15, err := m.Create.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n15
+	}
+	if m.Alloc != nil {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Alloc.Size()))
+		n16, err := m.Alloc.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n16
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *Job) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *ShardsPoolCreateCmd) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ShardsPoolCreateCmd) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.Type != 0 {
-		n += 1 + sovMetapb(uint64(m.Type))
-	}
-	l = len(m.Content)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
-	}
-	if m.State != 0 {
-		n += 1 + sovMetapb(uint64(m.State))
-	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *RemoveShardJob) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *ShardsPoolAllocCmd) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ShardsPoolAllocCmd) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.ID != 0 {
-		n += 1 + sovMetapb(uint64(m.ID))
+	if m.Group != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Group))
 	}
-	if len(m.Replicas) > 0 {
-		for _, e := range m.Replicas {
-			l = e.Size()
-			n += 1 + l + sovMetapb(uint64(l))
-		}
+	if len(m.Purpose) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Purpose)))
+		i += copy(dAtA[i:], m.Purpose)
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *ShardPoolJob) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *SnapshotInfo) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *SnapshotInfo) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if len(m.Pools) > 0 {
-		for _, e := range m.Pools {
-			l = e.Size()
-			n += 1 + l + sovMetapb(uint64(l))
+	if m.Extra != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Extra))
+	}
+	if m.Dummy {
+		dAtA[i] = 0x10
+		i++
+		if m.Dummy {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
 		}
+		i++
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *ShardPoolJobMeta) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *EpochLease) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *EpochLease) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.Group != 0 {
-		n += 1 + sovMetapb(uint64(m.Group))
-	}
-	if m.Capacity != 0 {
-		n += 1 + sovMetapb(uint64(m.Capacity))
+	if m.Epoch != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Epoch))
 	}
-	l = len(m.RangePrefix)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
+	if m.ReplicaID != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ReplicaID))
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *DestroyingStatus) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *ReplicaReadStats) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ReplicaReadStats) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.Index != 0 {
-		n += 1 + sovMetapb(uint64(m.Index))
+	if m.ShardID != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ShardID))
 	}
-	if len(m.Replicas) > 0 {
-		for k, v := range m.Replicas {
-			_ = k
-			_ = v
-			mapEntrySize := 1 + sovMetapb(uint64(k)) + 1 + 1
-			n += mapEntrySize + 1 + sovMetapb(uint64(mapEntrySize))
-		}
+	if m.ReplicaID != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ReplicaID))
 	}
-	if m.State != 0 {
-		n += 1 + sovMetapb(uint64(m.State))
+	if m.ReadBytes != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ReadBytes))
 	}
-	if m.RemoveData {
-		n += 2
+	if m.ReadKeys != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ReadKeys))
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *ShardExtra) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *ShardReadHint) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ShardReadHint) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if len(m.Labels) > 0 {
-		for k, v := range m.Labels {
-			_ = k
-			_ = v
-			mapEntrySize := 1 + len(k) + sovMetapb(uint64(len(k))) + 1 + len(v) + sovMetapb(uint64(len(v)))
-			n += mapEntrySize + 1 + sovMetapb(uint64(mapEntrySize))
-		}
+	if m.ShardID != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ShardID))
 	}
-	if m.DestroyingStatus != nil {
-		l = m.DestroyingStatus.Size()
-		n += 1 + l + sovMetapb(uint64(l))
+	if len(m.Replicas) > 0 {
+		dAtA2 := make([]byte, len(m.Replicas)*10)
+		var j1 int
+		for _, num := range m.Replicas {
+			for num >= 1<<7 {
+				dAtA2[j1] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j1++
+			}
+			dAtA2[j1] = uint8(num)
+			j1++
+		}
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(j1))
+		i += copy(dAtA[i:], dAtA2[:j1])
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *ScheduleGroupRule) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *ReplicaProgress) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.ID != 0 {
-		n += 1 + sovMetapb(uint64(m.ID))
-	}
-	if m.GroupID != 0 {
-		n += 1 + sovMetapb(uint64(m.GroupID))
-	}
-	l = len(m.Name)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
-	}
-	l = len(m.GroupByLabel)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
-	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
-	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *RaftMessageBatch) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+func (m *ReplicaProgress) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if len(m.Messages) > 0 {
-		for _, e := range m.Messages {
-			l = e.Size()
-			n += 1 + l + sovMetapb(uint64(l))
-		}
-	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+	dAtA[i] = 0xa
+	i++
+	i = encodeVarintMetapb(dAtA, i, uint64(m.Replica.Size()))
+	n1, err := m.Replica.MarshalTo(dAtA[i:])
+	if err != nil {
+		return 0, err
 	}
-	return n
-}
-
-func (m *RaftMessage) Size() (n int) {
-	if m == nil {
-		return 0
+	i += n1
+	if m.AppliedIndex != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.AppliedIndex))
 	}
-	var l int
-	_ = l
-	if m.ShardID != 0 {
-		n += 1 + sovMetapb(uint64(m.ShardID))
+	if m.MatchIndex != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.MatchIndex))
 	}
-	if m.Group != 0 {
-		n += 1 + sovMetapb(uint64(m.Group))
+	if m.LastContactTime != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.LastContactTime))
 	}
-	l = m.From.Size()
-	n += 1 + l + sovMetapb(uint64(l))
-	l = m.To.Size()
-	n += 1 + l + sovMetapb(uint64(l))
-	l = m.Message.Size()
-	n += 1 + l + sovMetapb(uint64(l))
-	l = m.ShardEpoch.Size()
-	n += 1 + l + sovMetapb(uint64(l))
-	if m.IsTombstone {
-		n += 2
+	if m.NeedSnapshot {
+		dAtA[i] = 0x28
+		i++
+		if m.NeedSnapshot {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
 	}
-	l = len(m.Start)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
+	if m.SnapshotPhase != 0 {
+		dAtA[i] = 0x30
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.SnapshotPhase))
 	}
-	l = len(m.End)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
+	if m.SnapshotSentBytes != 0 {
+		dAtA[i] = 0x38
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.SnapshotSentBytes))
 	}
-	l = len(m.Unique)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
+	if m.SnapshotTotalBytes != 0 {
+		dAtA[i] = 0x40
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.SnapshotTotalBytes))
 	}
-	if len(m.RuleGroups) > 0 {
-		for _, s := range m.RuleGroups {
-			l = len(s)
-			n += 1 + l + sovMetapb(uint64(l))
-		}
+	if m.SnapshotFailure != 0 {
+		dAtA[i] = 0x48
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.SnapshotFailure))
 	}
-	if m.CommitIndex != 0 {
-		n += 1 + sovMetapb(uint64(m.CommitIndex))
+	if m.SnapshotFailures != 0 {
+		dAtA[i] = 0x50
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.SnapshotFailures))
 	}
-	if m.SendTime != 0 {
-		n += 1 + sovMetapb(uint64(m.SendTime))
+	if m.SnapshotQuarantined {
+		dAtA[i] = 0x58
+		i++
+		if m.SnapshotQuarantined {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *SnapshotChunk) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *SnapshotManifest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *SnapshotManifest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.StoreID != 0 {
-		n += 1 + sovMetapb(uint64(m.StoreID))
-	}
-	if m.ShardID != 0 {
-		n += 1 + sovMetapb(uint64(m.ShardID))
+	if m.FormatVersion != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.FormatVersion))
 	}
-	if m.ReplicaID != 0 {
-		n += 1 + sovMetapb(uint64(m.ReplicaID))
+	dAtA[i] = 0x12
+	i++
+	i = encodeVarintMetapb(dAtA, i, uint64(m.Shard.Size()))
+	n1, err := m.Shard.MarshalTo(dAtA[i:])
+	if err != nil {
+		return 0, err
 	}
-	if m.From != 0 {
-		n += 1 + sovMetapb(uint64(m.From))
+	i += n1
+	if len(m.Start) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Start)))
+		i += copy(dAtA[i:], m.Start)
 	}
-	if m.ChunkID != 0 {
-		n += 1 + sovMetapb(uint64(m.ChunkID))
+	if len(m.End) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.End)))
+		i += copy(dAtA[i:], m.End)
 	}
-	if m.ChunkSize != 0 {
-		n += 1 + sovMetapb(uint64(m.ChunkSize))
+	if len(m.AppliedIndexKey) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.AppliedIndexKey)))
+		i += copy(dAtA[i:], m.AppliedIndexKey)
 	}
-	if m.ChunkCount != 0 {
-		n += 1 + sovMetapb(uint64(m.ChunkCount))
+	if len(m.AppliedIndexValue) > 0 {
+		dAtA[i] = 0x32
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.AppliedIndexValue)))
+		i += copy(dAtA[i:], m.AppliedIndexValue)
 	}
-	if m.Index != 0 {
-		n += 1 + sovMetapb(uint64(m.Index))
+	if len(m.MetadataKey) > 0 {
+		dAtA[i] = 0x3a
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.MetadataKey)))
+		i += copy(dAtA[i:], m.MetadataKey)
 	}
-	if m.Term != 0 {
-		n += 1 + sovMetapb(uint64(m.Term))
+	if len(m.MetadataValue) > 0 {
+		dAtA[i] = 0x42
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.MetadataValue)))
+		i += copy(dAtA[i:], m.MetadataValue)
 	}
-	l = len(m.FilePath)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
+	if m.KeyCount != 0 {
+		dAtA[i] = 0x48
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.KeyCount))
 	}
-	if m.FileSize != 0 {
-		n += 1 + sovMetapb(uint64(m.FileSize))
+	if m.ByteCount != 0 {
+		dAtA[i] = 0x50
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ByteCount))
 	}
-	if m.FileChunkID != 0 {
-		n += 1 + sovMetapb(uint64(m.FileChunkID))
+	if m.DataChecksum != 0 {
+		dAtA[i] = 0x58
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.DataChecksum))
 	}
-	if m.FileChunkCount != 0 {
-		n += 1 + sovMetapb(uint64(m.FileChunkCount))
+	if m.SstSize != 0 {
+		dAtA[i] = 0x60
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.SstSize))
 	}
-	l = len(m.Data)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
+	if m.SstChecksum != 0 {
+		dAtA[i] = 0x68
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.SstChecksum))
 	}
-	l = len(m.Extra)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
+	if m.Features != 0 {
+		dAtA[i] = 0x70
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Features))
 	}
-	l = m.ConfState.Size()
-	n += 2 + l + sovMetapb(uint64(l))
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *StoreIdent) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.ClusterID != 0 {
-		n += 1 + sovMetapb(uint64(m.ClusterID))
-	}
-	if m.StoreID != 0 {
-		n += 1 + sovMetapb(uint64(m.StoreID))
-	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+func (m *Keyspace) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *Shard) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+func (m *Keyspace) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
 	if m.ID != 0 {
-		n += 1 + sovMetapb(uint64(m.ID))
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ID))
 	}
-	l = len(m.Start)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
+	if len(m.Name) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Name)))
+		i += copy(dAtA[i:], m.Name)
 	}
-	l = len(m.End)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
+	if m.Group != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Group))
 	}
-	l = m.Epoch.Size()
-	n += 1 + l + sovMetapb(uint64(l))
-	if m.State != 0 {
-		n += 1 + sovMetapb(uint64(m.State))
+	if len(m.Prefix) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Prefix)))
+		i += copy(dAtA[i:], m.Prefix)
 	}
-	if len(m.Replicas) > 0 {
-		for _, e := range m.Replicas {
-			l = e.Size()
-			n += 1 + l + sovMetapb(uint64(l))
-		}
-	}
-	if m.Group != 0 {
-		n += 1 + sovMetapb(uint64(m.Group))
-	}
-	l = len(m.Unique)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
-	}
-	if len(m.RuleGroups) > 0 {
-		for _, s := range m.RuleGroups {
-			l = len(s)
-			n += 1 + l + sovMetapb(uint64(l))
-		}
+	if m.MaxReplicas != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.MaxReplicas))
 	}
 	if len(m.Labels) > 0 {
-		for _, e := range m.Labels {
-			l = e.Size()
-			n += 1 + l + sovMetapb(uint64(l))
+		for _, msg := range m.Labels {
+			dAtA[i] = 0x32
+			i++
+			i = encodeVarintMetapb(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
 		}
 	}
+	if m.ShardCapacityBytes != 0 {
+		dAtA[i] = 0x38
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.ShardCapacityBytes))
+	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *LogIndex) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.Index != 0 {
-		n += 1 + sovMetapb(uint64(m.Index))
-	}
-	if m.Term != 0 {
-		n += 1 + sovMetapb(uint64(m.Term))
-	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+func (m *StoreSchema) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *ShardMetadata) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+func (m *StoreSchema) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.ShardID != 0 {
-		n += 1 + sovMetapb(uint64(m.ShardID))
+	if m.Version != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Version))
 	}
-	if m.LogIndex != 0 {
-		n += 1 + sovMetapb(uint64(m.LogIndex))
+	if m.Migrating != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(m.Migrating))
+	}
+	if len(m.Checkpoint) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintMetapb(dAtA, i, uint64(len(m.Checkpoint)))
+		i += copy(dAtA[i:], m.Checkpoint)
 	}
-	l = m.Metadata.Size()
-	n += 1 + l + sovMetapb(uint64(l))
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *ShardLocalState) Size() (n int) {
+func encodeVarintMetapb(dAtA []byte, offset int, v uint64) int {
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return offset + 1
+}
+func (m *ShardEpoch) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	l = m.Shard.Size()
-	n += 1 + l + sovMetapb(uint64(l))
-	if m.Lease != nil {
-		l = m.Lease.Size()
-		n += 1 + l + sovMetapb(uint64(l))
-	}
-	if m.State != 0 {
-		n += 1 + sovMetapb(uint64(m.State))
+	if m.ConfigVer != 0 {
+		n += 1 + sovMetapb(uint64(m.ConfigVer))
 	}
-	if m.RemoveData {
-		n += 2
+	if m.Generation != 0 {
+		n += 1 + sovMetapb(uint64(m.Generation))
 	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
@@ -5537,7 +6468,7 @@ func (m *ShardLocalState) Size() (n int) {
 	return n
 }
 
-func (m *Store) Size() (n int) {
+func (m *Replica) Size() (n int) {
 	if m == nil {
 		return 0
 	}
@@ -5546,42 +6477,13 @@ func (m *Store) Size() (n int) {
 	if m.ID != 0 {
 		n += 1 + sovMetapb(uint64(m.ID))
 	}
-	l = len(m.RaftAddress)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
-	}
-	l = len(m.ClientAddress)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
-	}
-	if len(m.Labels) > 0 {
-		for _, e := range m.Labels {
-			l = e.Size()
-			n += 1 + l + sovMetapb(uint64(l))
-		}
-	}
-	if m.State != 0 {
-		n += 1 + sovMetapb(uint64(m.State))
-	}
-	if m.StartTime != 0 {
-		n += 1 + sovMetapb(uint64(m.StartTime))
-	}
-	if m.LastHeartbeatTime != 0 {
-		n += 1 + sovMetapb(uint64(m.LastHeartbeatTime))
-	}
-	l = len(m.Version)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
-	}
-	l = len(m.CommitID)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
+	if m.StoreID != 0 {
+		n += 1 + sovMetapb(uint64(m.StoreID))
 	}
-	l = len(m.DeployPath)
-	if l > 0 {
-		n += 1 + l + sovMetapb(uint64(l))
+	if m.Role != 0 {
+		n += 1 + sovMetapb(uint64(m.Role))
 	}
-	if m.Destroyed {
+	if m.InitialMember {
 		n += 2
 	}
 	if m.XXX_unrecognized != nil {
@@ -5590,24 +6492,16 @@ func (m *Store) Size() (n int) {
 	return n
 }
 
-func (m *ShardsPool) Size() (n int) {
+func (m *ReplicaStats) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	if len(m.Pools) > 0 {
-		for k, v := range m.Pools {
-			_ = k
-			_ = v
-			l = 0
-			if v != nil {
-				l = v.Size()
-				l += 1 + sovMetapb(uint64(l))
-			}
-			mapEntrySize := 1 + sovMetapb(uint64(k)) + l
-			n += mapEntrySize + 1 + sovMetapb(uint64(mapEntrySize))
-		}
+	l = m.Replica.Size()
+	n += 1 + l + sovMetapb(uint64(l))
+	if m.DownSeconds != 0 {
+		n += 1 + sovMetapb(uint64(m.DownSeconds))
 	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
@@ -5615,30 +6509,19 @@ func (m *ShardsPool) Size() (n int) {
 	return n
 }
 
-func (m *ShardPool) Size() (n int) {
+func (m *Label) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	if m.Capacity != 0 {
-		n += 1 + sovMetapb(uint64(m.Capacity))
-	}
-	l = len(m.RangePrefix)
+	l = len(m.Key)
 	if l > 0 {
 		n += 1 + l + sovMetapb(uint64(l))
 	}
-	if len(m.AllocatedShards) > 0 {
-		for _, e := range m.AllocatedShards {
-			l = e.Size()
-			n += 1 + l + sovMetapb(uint64(l))
-		}
-	}
-	if m.Seq != 0 {
-		n += 1 + sovMetapb(uint64(m.Seq))
-	}
-	if m.AllocatedOffset != 0 {
-		n += 1 + sovMetapb(uint64(m.AllocatedOffset))
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
 	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
@@ -5646,7 +6529,7 @@ func (m *ShardPool) Size() (n int) {
 	return n
 }
 
-func (m *AllocatedShard) Size() (n int) {
+func (m *ShardStats) Size() (n int) {
 	if m == nil {
 		return 0
 	}
@@ -5655,11 +6538,26 @@ func (m *AllocatedShard) Size() (n int) {
 	if m.ShardID != 0 {
 		n += 1 + sovMetapb(uint64(m.ShardID))
 	}
-	if m.AllocatedAt != 0 {
-		n += 1 + sovMetapb(uint64(m.AllocatedAt))
+	if m.WrittenBytes != 0 {
+		n += 1 + sovMetapb(uint64(m.WrittenBytes))
 	}
-	l = len(m.Purpose)
-	if l > 0 {
+	if m.WrittenKeys != 0 {
+		n += 1 + sovMetapb(uint64(m.WrittenKeys))
+	}
+	if m.ReadBytes != 0 {
+		n += 1 + sovMetapb(uint64(m.ReadBytes))
+	}
+	if m.ReadKeys != 0 {
+		n += 1 + sovMetapb(uint64(m.ReadKeys))
+	}
+	if m.ApproximateSize != 0 {
+		n += 1 + sovMetapb(uint64(m.ApproximateSize))
+	}
+	if m.ApproximateKeys != 0 {
+		n += 1 + sovMetapb(uint64(m.ApproximateKeys))
+	}
+	if m.Interval != nil {
+		l = m.Interval.Size()
 		n += 1 + l + sovMetapb(uint64(l))
 	}
 	if m.XXX_unrecognized != nil {
@@ -5668,71 +6566,150 @@ func (m *AllocatedShard) Size() (n int) {
 	return n
 }
 
-func (m *ShardsPoolCmd) Size() (n int) {
+func (m *ShardBucket) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	if m.Type != 0 {
-		n += 1 + sovMetapb(uint64(m.Type))
+	if m.ShardID != 0 {
+		n += 1 + sovMetapb(uint64(m.ShardID))
 	}
-	if m.Create != nil {
-		l = m.Create.Size()
+	l = len(m.Start)
+	if l > 0 {
 		n += 1 + l + sovMetapb(uint64(l))
 	}
-	if m.Alloc != nil {
-		l = m.Alloc.Size()
+	l = len(m.End)
+	if l > 0 {
 		n += 1 + l + sovMetapb(uint64(l))
 	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+	if m.WrittenBytes != 0 {
+		n += 1 + sovMetapb(uint64(m.WrittenBytes))
 	}
-	return n
-}
-
-func (m *ShardsPoolCreateCmd) Size() (n int) {
-	if m == nil {
-		return 0
+	if m.WrittenKeys != 0 {
+		n += 1 + sovMetapb(uint64(m.WrittenKeys))
+	}
+	if m.ReadBytes != 0 {
+		n += 1 + sovMetapb(uint64(m.ReadBytes))
+	}
+	if m.ReadKeys != 0 {
+		n += 1 + sovMetapb(uint64(m.ReadKeys))
 	}
-	var l int
-	_ = l
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
 	return n
 }
 
-func (m *ShardsPoolAllocCmd) Size() (n int) {
+func (m *StoreStats) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	if m.Group != 0 {
-		n += 1 + sovMetapb(uint64(m.Group))
+	if m.StoreID != 0 {
+		n += 1 + sovMetapb(uint64(m.StoreID))
 	}
-	l = len(m.Purpose)
-	if l > 0 {
+	if m.StartTime != 0 {
+		n += 1 + sovMetapb(uint64(m.StartTime))
+	}
+	if m.Interval != nil {
+		l = m.Interval.Size()
 		n += 1 + l + sovMetapb(uint64(l))
 	}
+	if m.Capacity != 0 {
+		n += 1 + sovMetapb(uint64(m.Capacity))
+	}
+	if m.Available != 0 {
+		n += 1 + sovMetapb(uint64(m.Available))
+	}
+	if m.UsedSize != 0 {
+		n += 1 + sovMetapb(uint64(m.UsedSize))
+	}
+	if m.IsBusy {
+		n += 2
+	}
+	if m.ShardCount != 0 {
+		n += 1 + sovMetapb(uint64(m.ShardCount))
+	}
+	if m.SendingSnapCount != 0 {
+		n += 1 + sovMetapb(uint64(m.SendingSnapCount))
+	}
+	if m.ReceivingSnapCount != 0 {
+		n += 1 + sovMetapb(uint64(m.ReceivingSnapCount))
+	}
+	if m.ApplyingSnapCount != 0 {
+		n += 1 + sovMetapb(uint64(m.ApplyingSnapCount))
+	}
+	if m.WrittenBytes != 0 {
+		n += 1 + sovMetapb(uint64(m.WrittenBytes))
+	}
+	if m.ReadBytes != 0 {
+		n += 1 + sovMetapb(uint64(m.ReadBytes))
+	}
+	if m.WrittenKeys != 0 {
+		n += 1 + sovMetapb(uint64(m.WrittenKeys))
+	}
+	if m.ReadKeys != 0 {
+		n += 1 + sovMetapb(uint64(m.ReadKeys))
+	}
+	if len(m.CpuUsages) > 0 {
+		for _, e := range m.CpuUsages {
+			l = e.Size()
+			n += 2 + l + sovMetapb(uint64(l))
+		}
+	}
+	if len(m.ReadIORates) > 0 {
+		for _, e := range m.ReadIORates {
+			l = e.Size()
+			n += 2 + l + sovMetapb(uint64(l))
+		}
+	}
+	if len(m.WriteIORates) > 0 {
+		for _, e := range m.WriteIORates {
+			l = e.Size()
+			n += 2 + l + sovMetapb(uint64(l))
+		}
+	}
+	if m.ClockOffset != 0 {
+		n += 2 + sovMetapb(uint64(m.ClockOffset))
+	}
+	if m.ClockSkewed {
+		n += 3
+	}
+	if m.MaxReplicaCount != 0 {
+		n += 2 + sovMetapb(uint64(m.MaxReplicaCount))
+	}
+	if m.MaxLeaderCount != 0 {
+		n += 2 + sovMetapb(uint64(m.MaxLeaderCount))
+	}
+	if len(m.ReplicaReadStats) > 0 {
+		for _, e := range m.ReplicaReadStats {
+			l = e.Size()
+			n += 2 + l + sovMetapb(uint64(l))
+		}
+	}
+	if m.MaxSnapshotCount != 0 {
+		n += 2 + sovMetapb(uint64(m.MaxSnapshotCount))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
 	return n
 }
 
-func (m *SnapshotInfo) Size() (n int) {
+func (m *RecordPair) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	if m.Extra != 0 {
-		n += 1 + sovMetapb(uint64(m.Extra))
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
 	}
-	if m.Dummy {
-		n += 2
+	if m.Value != 0 {
+		n += 1 + sovMetapb(uint64(m.Value))
 	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
@@ -5740,17 +6717,22 @@ func (m *SnapshotInfo) Size() (n int) {
 	return n
 }
 
-func (m *EpochLease) Size() (n int) {
+func (m *Member) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	if m.Epoch != 0 {
-		n += 1 + sovMetapb(uint64(m.Epoch))
+	if m.ID != 0 {
+		n += 1 + sovMetapb(uint64(m.ID))
 	}
-	if m.ReplicaID != 0 {
-		n += 1 + sovMetapb(uint64(m.ReplicaID))
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = len(m.Addr)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
 	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
@@ -5758,20 +6740,2771 @@ func (m *EpochLease) Size() (n int) {
 	return n
 }
 
-func sovMetapb(x uint64) (n int) {
-	for {
-		n++
-		x >>= 7
-		if x == 0 {
-			break
+func (m *ProphetCluster) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ID != 0 {
+		n += 1 + sovMetapb(uint64(m.ID))
+	}
+	if m.MaxReplicaCount != 0 {
+		n += 1 + sovMetapb(uint64(m.MaxReplicaCount))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *TimeInterval) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Start != 0 {
+		n += 1 + sovMetapb(uint64(m.Start))
+	}
+	if m.End != 0 {
+		n += 1 + sovMetapb(uint64(m.End))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Job) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Type != 0 {
+		n += 1 + sovMetapb(uint64(m.Type))
+	}
+	l = len(m.Content)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.State != 0 {
+		n += 1 + sovMetapb(uint64(m.State))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *JobCheckpoint) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Type != 0 {
+		n += 1 + sovMetapb(uint64(m.Type))
+	}
+	if m.Seq != 0 {
+		n += 1 + sovMetapb(uint64(m.Seq))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *RemoveShardJob) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ID != 0 {
+		n += 1 + sovMetapb(uint64(m.ID))
+	}
+	if len(m.Replicas) > 0 {
+		for _, e := range m.Replicas {
+			l = e.Size()
+			n += 1 + l + sovMetapb(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ShardPoolJob) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Pools) > 0 {
+		for _, e := range m.Pools {
+			l = e.Size()
+			n += 1 + l + sovMetapb(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ShardPoolJobMeta) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Group != 0 {
+		n += 1 + sovMetapb(uint64(m.Group))
+	}
+	if m.Capacity != 0 {
+		n += 1 + sovMetapb(uint64(m.Capacity))
+	}
+	l = len(m.RangePrefix)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *DestroyingStatus) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Index != 0 {
+		n += 1 + sovMetapb(uint64(m.Index))
+	}
+	if len(m.Replicas) > 0 {
+		for k, v := range m.Replicas {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + sovMetapb(uint64(k)) + 1 + 1
+			n += mapEntrySize + 1 + sovMetapb(uint64(mapEntrySize))
 		}
 	}
-	return n
-}
-func sozMetapb(x uint64) (n int) {
-	return sovMetapb(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	if m.State != 0 {
+		n += 1 + sovMetapb(uint64(m.State))
+	}
+	if m.RemoveData {
+		n += 2
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ShardExtra) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Labels) > 0 {
+		for k, v := range m.Labels {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovMetapb(uint64(len(k))) + 1 + len(v) + sovMetapb(uint64(len(v)))
+			n += mapEntrySize + 1 + sovMetapb(uint64(mapEntrySize))
+		}
+	}
+	if m.DestroyingStatus != nil {
+		l = m.DestroyingStatus.Size()
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ScheduleGroupRule) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ID != 0 {
+		n += 1 + sovMetapb(uint64(m.ID))
+	}
+	if m.GroupID != 0 {
+		n += 1 + sovMetapb(uint64(m.GroupID))
+	}
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = len(m.GroupByLabel)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *RaftMessageBatch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Messages) > 0 {
+		for _, e := range m.Messages {
+			l = e.Size()
+			n += 1 + l + sovMetapb(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *RaftMessage) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ShardID != 0 {
+		n += 1 + sovMetapb(uint64(m.ShardID))
+	}
+	if m.Group != 0 {
+		n += 1 + sovMetapb(uint64(m.Group))
+	}
+	l = m.From.Size()
+	n += 1 + l + sovMetapb(uint64(l))
+	l = m.To.Size()
+	n += 1 + l + sovMetapb(uint64(l))
+	l = m.Message.Size()
+	n += 1 + l + sovMetapb(uint64(l))
+	l = m.ShardEpoch.Size()
+	n += 1 + l + sovMetapb(uint64(l))
+	if m.IsTombstone {
+		n += 2
+	}
+	l = len(m.Start)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = len(m.End)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = len(m.Unique)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if len(m.RuleGroups) > 0 {
+		for _, s := range m.RuleGroups {
+			l = len(s)
+			n += 1 + l + sovMetapb(uint64(l))
+		}
+	}
+	if m.CommitIndex != 0 {
+		n += 1 + sovMetapb(uint64(m.CommitIndex))
+	}
+	if m.SendTime != 0 {
+		n += 1 + sovMetapb(uint64(m.SendTime))
+	}
+	if m.AppliedIndex != 0 {
+		n += 1 + sovMetapb(uint64(m.AppliedIndex))
+	}
+	if m.SnapshotFailure != 0 {
+		n += 1 + sovMetapb(uint64(m.SnapshotFailure))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *SnapshotChunk) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StoreID != 0 {
+		n += 1 + sovMetapb(uint64(m.StoreID))
+	}
+	if m.ShardID != 0 {
+		n += 1 + sovMetapb(uint64(m.ShardID))
+	}
+	if m.ReplicaID != 0 {
+		n += 1 + sovMetapb(uint64(m.ReplicaID))
+	}
+	if m.From != 0 {
+		n += 1 + sovMetapb(uint64(m.From))
+	}
+	if m.ChunkID != 0 {
+		n += 1 + sovMetapb(uint64(m.ChunkID))
+	}
+	if m.ChunkSize != 0 {
+		n += 1 + sovMetapb(uint64(m.ChunkSize))
+	}
+	if m.ChunkCount != 0 {
+		n += 1 + sovMetapb(uint64(m.ChunkCount))
+	}
+	if m.Index != 0 {
+		n += 1 + sovMetapb(uint64(m.Index))
+	}
+	if m.Term != 0 {
+		n += 1 + sovMetapb(uint64(m.Term))
+	}
+	l = len(m.FilePath)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.FileSize != 0 {
+		n += 1 + sovMetapb(uint64(m.FileSize))
+	}
+	if m.FileChunkID != 0 {
+		n += 1 + sovMetapb(uint64(m.FileChunkID))
+	}
+	if m.FileChunkCount != 0 {
+		n += 1 + sovMetapb(uint64(m.FileChunkCount))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = len(m.Extra)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = m.ConfState.Size()
+	n += 2 + l + sovMetapb(uint64(l))
+	if m.FromStoreID != 0 {
+		n += 2 + sovMetapb(uint64(m.FromStoreID))
+	}
+	if m.Format != 0 {
+		n += 2 + sovMetapb(uint64(m.Format))
+	}
+	if m.SnapshotSize != 0 {
+		n += 2 + sovMetapb(uint64(m.SnapshotSize))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *StoreIdent) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ClusterID != 0 {
+		n += 1 + sovMetapb(uint64(m.ClusterID))
+	}
+	if m.StoreID != 0 {
+		n += 1 + sovMetapb(uint64(m.StoreID))
+	}
+	if m.Epoch != 0 {
+		n += 1 + sovMetapb(uint64(m.Epoch))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Shard) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ID != 0 {
+		n += 1 + sovMetapb(uint64(m.ID))
+	}
+	l = len(m.Start)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = len(m.End)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = m.Epoch.Size()
+	n += 1 + l + sovMetapb(uint64(l))
+	if m.State != 0 {
+		n += 1 + sovMetapb(uint64(m.State))
+	}
+	if len(m.Replicas) > 0 {
+		for _, e := range m.Replicas {
+			l = e.Size()
+			n += 1 + l + sovMetapb(uint64(l))
+		}
+	}
+	if m.Group != 0 {
+		n += 1 + sovMetapb(uint64(m.Group))
+	}
+	l = len(m.Unique)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if len(m.RuleGroups) > 0 {
+		for _, s := range m.RuleGroups {
+			l = len(s)
+			n += 1 + l + sovMetapb(uint64(l))
+		}
+	}
+	if len(m.Labels) > 0 {
+		for _, e := range m.Labels {
+			l = e.Size()
+			n += 1 + l + sovMetapb(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *LogIndex) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Index != 0 {
+		n += 1 + sovMetapb(uint64(m.Index))
+	}
+	if m.Term != 0 {
+		n += 1 + sovMetapb(uint64(m.Term))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ShardMetadata) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ShardID != 0 {
+		n += 1 + sovMetapb(uint64(m.ShardID))
+	}
+	if m.LogIndex != 0 {
+		n += 1 + sovMetapb(uint64(m.LogIndex))
+	}
+	l = m.Metadata.Size()
+	n += 1 + l + sovMetapb(uint64(l))
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ShardLocalState) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = m.Shard.Size()
+	n += 1 + l + sovMetapb(uint64(l))
+	if m.Lease != nil {
+		l = m.Lease.Size()
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.State != 0 {
+		n += 1 + sovMetapb(uint64(m.State))
+	}
+	if m.RemoveData {
+		n += 2
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Store) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ID != 0 {
+		n += 1 + sovMetapb(uint64(m.ID))
+	}
+	l = len(m.RaftAddress)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = len(m.ClientAddress)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if len(m.Labels) > 0 {
+		for _, e := range m.Labels {
+			l = e.Size()
+			n += 1 + l + sovMetapb(uint64(l))
+		}
+	}
+	if m.State != 0 {
+		n += 1 + sovMetapb(uint64(m.State))
+	}
+	if m.StartTime != 0 {
+		n += 1 + sovMetapb(uint64(m.StartTime))
+	}
+	if m.LastHeartbeatTime != 0 {
+		n += 1 + sovMetapb(uint64(m.LastHeartbeatTime))
+	}
+	l = len(m.Version)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = len(m.CommitID)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = len(m.DeployPath)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.Destroyed {
+		n += 2
+	}
+	if m.Epoch != 0 {
+		n += 1 + sovMetapb(uint64(m.Epoch))
+	}
+	if m.SnapshotFormat != 0 {
+		n += 1 + sovMetapb(uint64(m.SnapshotFormat))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ShardsPool) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Pools) > 0 {
+		for k, v := range m.Pools {
+			_ = k
+			_ = v
+			l = 0
+			if v != nil {
+				l = v.Size()
+				l += 1 + sovMetapb(uint64(l))
+			}
+			mapEntrySize := 1 + sovMetapb(uint64(k)) + l
+			n += mapEntrySize + 1 + sovMetapb(uint64(mapEntrySize))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ShardPool) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Capacity != 0 {
+		n += 1 + sovMetapb(uint64(m.Capacity))
+	}
+	l = len(m.RangePrefix)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if len(m.AllocatedShards) > 0 {
+		for _, e := range m.AllocatedShards {
+			l = e.Size()
+			n += 1 + l + sovMetapb(uint64(l))
+		}
+	}
+	if m.Seq != 0 {
+		n += 1 + sovMetapb(uint64(m.Seq))
+	}
+	if m.AllocatedOffset != 0 {
+		n += 1 + sovMetapb(uint64(m.AllocatedOffset))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *AllocatedShard) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ShardID != 0 {
+		n += 1 + sovMetapb(uint64(m.ShardID))
+	}
+	if m.AllocatedAt != 0 {
+		n += 1 + sovMetapb(uint64(m.AllocatedAt))
+	}
+	l = len(m.Purpose)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ShardsPoolCmd) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Type != 0 {
+		n += 1 + sovMetapb(uint64(m.Type))
+	}
+	if m.Create != nil {
+		l = m.Create.Size()
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.Alloc != nil {
+		l = m.Alloc.Size()
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ShardsPoolCreateCmd) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ShardsPoolAllocCmd) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Group != 0 {
+		n += 1 + sovMetapb(uint64(m.Group))
+	}
+	l = len(m.Purpose)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *SnapshotInfo) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Extra != 0 {
+		n += 1 + sovMetapb(uint64(m.Extra))
+	}
+	if m.Dummy {
+		n += 2
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *EpochLease) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Epoch != 0 {
+		n += 1 + sovMetapb(uint64(m.Epoch))
+	}
+	if m.ReplicaID != 0 {
+		n += 1 + sovMetapb(uint64(m.ReplicaID))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ReplicaReadStats) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ShardID != 0 {
+		n += 1 + sovMetapb(uint64(m.ShardID))
+	}
+	if m.ReplicaID != 0 {
+		n += 1 + sovMetapb(uint64(m.ReplicaID))
+	}
+	if m.ReadBytes != 0 {
+		n += 1 + sovMetapb(uint64(m.ReadBytes))
+	}
+	if m.ReadKeys != 0 {
+		n += 1 + sovMetapb(uint64(m.ReadKeys))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ShardReadHint) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ShardID != 0 {
+		n += 1 + sovMetapb(uint64(m.ShardID))
+	}
+	if len(m.Replicas) > 0 {
+		l = 0
+		for _, e := range m.Replicas {
+			l += sovMetapb(uint64(e))
+		}
+		n += 1 + sovMetapb(uint64(l)) + l
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ReplicaProgress) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = m.Replica.Size()
+	n += 1 + l + sovMetapb(uint64(l))
+	if m.AppliedIndex != 0 {
+		n += 1 + sovMetapb(uint64(m.AppliedIndex))
+	}
+	if m.MatchIndex != 0 {
+		n += 1 + sovMetapb(uint64(m.MatchIndex))
+	}
+	if m.LastContactTime != 0 {
+		n += 1 + sovMetapb(uint64(m.LastContactTime))
+	}
+	if m.NeedSnapshot {
+		n += 2
+	}
+	if m.SnapshotPhase != 0 {
+		n += 1 + sovMetapb(uint64(m.SnapshotPhase))
+	}
+	if m.SnapshotSentBytes != 0 {
+		n += 1 + sovMetapb(uint64(m.SnapshotSentBytes))
+	}
+	if m.SnapshotTotalBytes != 0 {
+		n += 1 + sovMetapb(uint64(m.SnapshotTotalBytes))
+	}
+	if m.SnapshotFailure != 0 {
+		n += 1 + sovMetapb(uint64(m.SnapshotFailure))
+	}
+	if m.SnapshotFailures != 0 {
+		n += 1 + sovMetapb(uint64(m.SnapshotFailures))
+	}
+	if m.SnapshotQuarantined {
+		n += 2
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *SnapshotManifest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.FormatVersion != 0 {
+		n += 1 + sovMetapb(uint64(m.FormatVersion))
+	}
+	l = m.Shard.Size()
+	n += 1 + l + sovMetapb(uint64(l))
+	l = len(m.Start)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = len(m.End)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = len(m.AppliedIndexKey)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = len(m.AppliedIndexValue)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = len(m.MetadataKey)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	l = len(m.MetadataValue)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.KeyCount != 0 {
+		n += 1 + sovMetapb(uint64(m.KeyCount))
+	}
+	if m.ByteCount != 0 {
+		n += 1 + sovMetapb(uint64(m.ByteCount))
+	}
+	if m.DataChecksum != 0 {
+		n += 1 + sovMetapb(uint64(m.DataChecksum))
+	}
+	if m.SstSize != 0 {
+		n += 1 + sovMetapb(uint64(m.SstSize))
+	}
+	if m.SstChecksum != 0 {
+		n += 1 + sovMetapb(uint64(m.SstChecksum))
+	}
+	if m.Features != 0 {
+		n += 1 + sovMetapb(uint64(m.Features))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Keyspace) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ID != 0 {
+		n += 1 + sovMetapb(uint64(m.ID))
+	}
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.Group != 0 {
+		n += 1 + sovMetapb(uint64(m.Group))
+	}
+	l = len(m.Prefix)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.MaxReplicas != 0 {
+		n += 1 + sovMetapb(uint64(m.MaxReplicas))
+	}
+	if len(m.Labels) > 0 {
+		for _, e := range m.Labels {
+			l = e.Size()
+			n += 1 + l + sovMetapb(uint64(l))
+		}
+	}
+	if m.ShardCapacityBytes != 0 {
+		n += 1 + sovMetapb(uint64(m.ShardCapacityBytes))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *StoreSchema) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Version != 0 {
+		n += 1 + sovMetapb(uint64(m.Version))
+	}
+	if m.Migrating != 0 {
+		n += 1 + sovMetapb(uint64(m.Migrating))
+	}
+	l = len(m.Checkpoint)
+	if l > 0 {
+		n += 1 + l + sovMetapb(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func sovMetapb(x uint64) (n int) {
+	for {
+		n++
+		x >>= 7
+		if x == 0 {
+			break
+		}
+	}
+	return n
+}
+func sozMetapb(x uint64) (n int) {
+	return sovMetapb(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *ShardEpoch) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ShardEpoch: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ShardEpoch: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ConfigVer", wireType)
+			}
+			m.ConfigVer = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ConfigVer |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Generation", wireType)
+			}
+			m.Generation = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Generation |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Replica) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Replica: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Replica: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+			}
+			m.ID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StoreID", wireType)
+			}
+			m.StoreID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StoreID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Role", wireType)
+			}
+			m.Role = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Role |= ReplicaRole(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field InitialMember", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.InitialMember = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ReplicaStats) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ReplicaStats: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ReplicaStats: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Replica", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Replica.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DownSeconds", wireType)
+			}
+			m.DownSeconds = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DownSeconds |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Label) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Label: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Label: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ShardStats) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ShardStats: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ShardStats: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardID", wireType)
+			}
+			m.ShardID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ShardID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WrittenBytes", wireType)
+			}
+			m.WrittenBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.WrittenBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WrittenKeys", wireType)
+			}
+			m.WrittenKeys = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.WrittenKeys |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReadBytes", wireType)
+			}
+			m.ReadBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ReadBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReadKeys", wireType)
+			}
+			m.ReadKeys = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ReadKeys |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ApproximateSize", wireType)
+			}
+			m.ApproximateSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ApproximateSize |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ApproximateKeys", wireType)
+			}
+			m.ApproximateKeys = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ApproximateKeys |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Interval", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Interval == nil {
+				m.Interval = &TimeInterval{}
+			}
+			if err := m.Interval.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (m *ShardBucket) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ShardBucket: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ShardBucket: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardID", wireType)
+			}
+			m.ShardID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ShardID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Start = append(m.Start[:0], dAtA[iNdEx:postIndex]...)
+			if m.Start == nil {
+				m.Start = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.End = append(m.End[:0], dAtA[iNdEx:postIndex]...)
+			if m.End == nil {
+				m.End = []byte{}
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WrittenBytes", wireType)
+			}
+			m.WrittenBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.WrittenBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WrittenKeys", wireType)
+			}
+			m.WrittenKeys = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.WrittenKeys |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReadBytes", wireType)
+			}
+			m.ReadBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ReadBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReadKeys", wireType)
+			}
+			m.ReadKeys = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ReadKeys |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *StoreStats) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: StoreStats: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: StoreStats: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StoreID", wireType)
+			}
+			m.StoreID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StoreID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartTime", wireType)
+			}
+			m.StartTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartTime |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Interval", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Interval == nil {
+				m.Interval = &TimeInterval{}
+			}
+			if err := m.Interval.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Capacity", wireType)
+			}
+			m.Capacity = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Capacity |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Available", wireType)
+			}
+			m.Available = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Available |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UsedSize", wireType)
+			}
+			m.UsedSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.UsedSize |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsBusy", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsBusy = bool(v != 0)
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardCount", wireType)
+			}
+			m.ShardCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ShardCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SendingSnapCount", wireType)
+			}
+			m.SendingSnapCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SendingSnapCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReceivingSnapCount", wireType)
+			}
+			m.ReceivingSnapCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ReceivingSnapCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ApplyingSnapCount", wireType)
+			}
+			m.ApplyingSnapCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ApplyingSnapCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WrittenBytes", wireType)
+			}
+			m.WrittenBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.WrittenBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 13:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReadBytes", wireType)
+			}
+			m.ReadBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ReadBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 14:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WrittenKeys", wireType)
+			}
+			m.WrittenKeys = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.WrittenKeys |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 15:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReadKeys", wireType)
+			}
+			m.ReadKeys = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ReadKeys |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 16:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CpuUsages", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.CpuUsages = append(m.CpuUsages, RecordPair{})
+			if err := m.CpuUsages[len(m.CpuUsages)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 17:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReadIORates", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ReadIORates = append(m.ReadIORates, RecordPair{})
+			if err := m.ReadIORates[len(m.ReadIORates)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 18:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WriteIORates", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.WriteIORates = append(m.WriteIORates, RecordPair{})
+			if err := m.WriteIORates[len(m.WriteIORates)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 19:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ClockOffset", wireType)
+			}
+			m.ClockOffset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ClockOffset |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 20:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ClockSkewed", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.ClockSkewed = bool(v != 0)
+		case 21:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxReplicaCount", wireType)
+			}
+			m.MaxReplicaCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxReplicaCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 22:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxLeaderCount", wireType)
+			}
+			m.MaxLeaderCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxLeaderCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 23:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReplicaReadStats", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ReplicaReadStats = append(m.ReplicaReadStats, ReplicaReadStats{})
+			if err := m.ReplicaReadStats[len(m.ReplicaReadStats)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 24:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxSnapshotCount", wireType)
+			}
+			m.MaxSnapshotCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxSnapshotCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RecordPair) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RecordPair: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RecordPair: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			m.Value = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Value |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Member) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Member: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Member: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+			}
+			m.ID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Addr", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Addr = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ProphetCluster) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ProphetCluster: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ProphetCluster: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+			}
+			m.ID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxReplicaCount", wireType)
+			}
+			m.MaxReplicaCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxReplicaCount |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
 }
-func (m *ShardEpoch) Unmarshal(dAtA []byte) error {
+func (m *TimeInterval) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5794,17 +9527,17 @@ func (m *ShardEpoch) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ShardEpoch: wiretype end group for non-group")
+			return fmt.Errorf("proto: TimeInterval: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ShardEpoch: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: TimeInterval: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ConfigVer", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
 			}
-			m.ConfigVer = 0
+			m.Start = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -5814,16 +9547,16 @@ func (m *ShardEpoch) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ConfigVer |= uint64(b&0x7F) << shift
+				m.Start |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Generation", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
 			}
-			m.Generation = 0
+			m.End = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -5833,7 +9566,7 @@ func (m *ShardEpoch) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Generation |= uint64(b&0x7F) << shift
+				m.End |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -5863,7 +9596,7 @@ func (m *ShardEpoch) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Replica) Unmarshal(dAtA []byte) error {
+func (m *Job) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5886,17 +9619,17 @@ func (m *Replica) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Replica: wiretype end group for non-group")
+			return fmt.Errorf("proto: Job: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Replica: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Job: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
 			}
-			m.ID = 0
+			m.Type = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -5906,16 +9639,16 @@ func (m *Replica) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ID |= uint64(b&0x7F) << shift
+				m.Type |= JobType(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StoreID", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Content", wireType)
 			}
-			m.StoreID = 0
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -5925,35 +9658,31 @@ func (m *Replica) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.StoreID |= uint64(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Role", wireType)
+			if byteLen < 0 {
+				return ErrInvalidLengthMetapb
 			}
-			m.Role = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Role |= ReplicaRole(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
 			}
-		case 4:
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Content = append(m.Content[:0], dAtA[iNdEx:postIndex]...)
+			if m.Content == nil {
+				m.Content = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field InitialMember", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
 			}
-			var v int
+			m.State = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -5963,12 +9692,11 @@ func (m *Replica) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				m.State |= JobState(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.InitialMember = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -5994,7 +9722,8 @@ func (m *Replica) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ReplicaStats) Unmarshal(dAtA []byte) error {
+
+func (m *JobCheckpoint) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6017,17 +9746,17 @@ func (m *ReplicaStats) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ReplicaStats: wiretype end group for non-group")
+			return fmt.Errorf("proto: JobCheckpoint: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ReplicaStats: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: JobCheckpoint: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Replica", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
 			}
-			var msglen int
+			m.Type = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6037,30 +9766,16 @@ func (m *ReplicaStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.Type |= JobType(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if err := m.Replica.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field DownSeconds", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
 			}
-			m.DownSeconds = 0
+			m.Seq = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6070,11 +9785,45 @@ func (m *ReplicaStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.DownSeconds |= uint64(b&0x7F) << shift
+				m.Seq |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -6100,7 +9849,7 @@ func (m *ReplicaStats) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Label) Unmarshal(dAtA []byte) error {
+func (m *RemoveShardJob) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6123,17 +9872,36 @@ func (m *Label) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Label: wiretype end group for non-group")
+			return fmt.Errorf("proto: RemoveShardJob: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Label: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: RemoveShardJob: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+			}
+			m.ID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Replicas", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6143,29 +9911,85 @@ func (m *Label) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Key = string(dAtA[iNdEx:postIndex])
+			m.Replicas = append(m.Replicas, Replica{})
+			if err := m.Replicas[len(m.Replicas)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
-		case 2:
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ShardPoolJob) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ShardPoolJob: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ShardPoolJob: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Pools", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6175,23 +9999,25 @@ func (m *Label) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Value = string(dAtA[iNdEx:postIndex])
+			m.Pools = append(m.Pools, ShardPoolJobMeta{})
+			if err := m.Pools[len(m.Pools)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -6218,7 +10044,7 @@ func (m *Label) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ShardStats) Unmarshal(dAtA []byte) error {
+func (m *ShardPoolJobMeta) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6241,17 +10067,17 @@ func (m *ShardStats) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ShardStats: wiretype end group for non-group")
+			return fmt.Errorf("proto: ShardPoolJobMeta: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ShardStats: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ShardPoolJobMeta: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ShardID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Group", wireType)
 			}
-			m.ShardID = 0
+			m.Group = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6261,16 +10087,16 @@ func (m *ShardStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ShardID |= uint64(b&0x7F) << shift
+				m.Group |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field WrittenBytes", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Capacity", wireType)
 			}
-			m.WrittenBytes = 0
+			m.Capacity = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6280,16 +10106,16 @@ func (m *ShardStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.WrittenBytes |= uint64(b&0x7F) << shift
+				m.Capacity |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field WrittenKeys", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RangePrefix", wireType)
 			}
-			m.WrittenKeys = 0
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6299,35 +10125,85 @@ func (m *ShardStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.WrittenKeys |= uint64(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 4:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ReadBytes", wireType)
+			if byteLen < 0 {
+				return ErrInvalidLengthMetapb
 			}
-			m.ReadBytes = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.ReadBytes |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
 			}
-		case 5:
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RangePrefix = append(m.RangePrefix[:0], dAtA[iNdEx:postIndex]...)
+			if m.RangePrefix == nil {
+				m.RangePrefix = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *DestroyingStatus) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DestroyingStatus: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DestroyingStatus: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ReadKeys", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Index", wireType)
 			}
-			m.ReadKeys = 0
+			m.Index = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6337,16 +10213,16 @@ func (m *ShardStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ReadKeys |= uint64(b&0x7F) << shift
+				m.Index |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 6:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ApproximateSize", wireType)
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Replicas", wireType)
 			}
-			m.ApproximateSize = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6356,35 +10232,98 @@ func (m *ShardStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ApproximateSize |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 7:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ApproximateKeys", wireType)
+			if msglen < 0 {
+				return ErrInvalidLengthMetapb
 			}
-			m.ApproximateKeys = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Replicas == nil {
+				m.Replicas = make(map[uint64]bool)
+			}
+			var mapkey uint64
+			var mapvalue bool
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowMetapb
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
 				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.ApproximateKeys |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMetapb
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else if fieldNum == 2 {
+					var mapvaluetemp int
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMetapb
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapvaluetemp |= int(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					mapvalue = bool(mapvaluetemp != 0)
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipMetapb(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthMetapb
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
 				}
 			}
-		case 8:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Interval", wireType)
+			m.Replicas[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
 			}
-			var msglen int
+			m.State = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6394,28 +10333,31 @@ func (m *ShardStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.State |= ShardState(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Interval == nil {
-				m.Interval = &TimeInterval{}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RemoveData", wireType)
 			}
-			if err := m.Interval.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx = postIndex
+			m.RemoveData = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -6441,7 +10383,7 @@ func (m *ShardStats) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *StoreStats) Unmarshal(dAtA []byte) error {
+func (m *ShardExtra) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6464,17 +10406,17 @@ func (m *StoreStats) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: StoreStats: wiretype end group for non-group")
+			return fmt.Errorf("proto: ShardExtra: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: StoreStats: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ShardExtra: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StoreID", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
 			}
-			m.StoreID = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6484,33 +10426,122 @@ func (m *StoreStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.StoreID |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StartTime", wireType)
+			if msglen < 0 {
+				return ErrInvalidLengthMetapb
 			}
-			m.StartTime = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Labels == nil {
+				m.Labels = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowMetapb
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
 				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.StartTime |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMetapb
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthMetapb
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthMetapb
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMetapb
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthMetapb
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthMetapb
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipMetapb(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthMetapb
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
 				}
 			}
-		case 3:
+			m.Labels[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Interval", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field DestroyingStatus", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -6537,75 +10568,72 @@ func (m *StoreStats) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Interval == nil {
-				m.Interval = &TimeInterval{}
+			if m.DestroyingStatus == nil {
+				m.DestroyingStatus = &DestroyingStatus{}
 			}
-			if err := m.Interval.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.DestroyingStatus.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 4:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Capacity", wireType)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			m.Capacity = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Capacity |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
 			}
-		case 5:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Available", wireType)
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
 			}
-			m.Available = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Available |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
 			}
-		case 6:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field UsedSize", wireType)
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ScheduleGroupRule) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
 			}
-			m.UsedSize = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.UsedSize |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
 			}
-		case 7:
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ScheduleGroupRule: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ScheduleGroupRule: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field IsBusy", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
 			}
-			var v int
+			m.ID = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6615,17 +10643,16 @@ func (m *StoreStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				m.ID |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.IsBusy = bool(v != 0)
-		case 8:
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ShardCount", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field GroupID", wireType)
 			}
-			m.ShardCount = 0
+			m.GroupID = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6635,16 +10662,16 @@ func (m *StoreStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ShardCount |= uint64(b&0x7F) << shift
+				m.GroupID |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 9:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SendingSnapCount", wireType)
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
 			}
-			m.SendingSnapCount = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6654,35 +10681,29 @@ func (m *StoreStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.SendingSnapCount |= uint64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 10:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ReceivingSnapCount", wireType)
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetapb
 			}
-			m.ReceivingSnapCount = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.ReceivingSnapCount |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
 			}
-		case 11:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ApplyingSnapCount", wireType)
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
 			}
-			m.ApplyingSnapCount = 0
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field GroupByLabel", wireType)
+			}
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6692,16 +10713,83 @@ func (m *StoreStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ApplyingSnapCount |= uint64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 12:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field WrittenBytes", wireType)
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetapb
 			}
-			m.WrittenBytes = 0
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.GroupByLabel = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RaftMessageBatch) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RaftMessageBatch: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RaftMessageBatch: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Messages", wireType)
+			}
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6711,16 +10799,85 @@ func (m *StoreStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.WrittenBytes |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 13:
+			if msglen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Messages = append(m.Messages, RaftMessage{})
+			if err := m.Messages[len(m.Messages)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RaftMessage) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RaftMessage: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RaftMessage: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ReadBytes", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardID", wireType)
 			}
-			m.ReadBytes = 0
+			m.ShardID = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6730,16 +10887,16 @@ func (m *StoreStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ReadBytes |= uint64(b&0x7F) << shift
+				m.ShardID |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 14:
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field WrittenKeys", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Group", wireType)
 			}
-			m.WrittenKeys = 0
+			m.Group = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6749,16 +10906,16 @@ func (m *StoreStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.WrittenKeys |= uint64(b&0x7F) << shift
+				m.Group |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 15:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ReadKeys", wireType)
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field From", wireType)
 			}
-			m.ReadKeys = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6768,14 +10925,28 @@ func (m *StoreStats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ReadKeys |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 16:
+			if msglen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.From.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field CpuUsages", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field To", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -6802,14 +10973,13 @@ func (m *StoreStats) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.CpuUsages = append(m.CpuUsages, RecordPair{})
-			if err := m.CpuUsages[len(m.CpuUsages)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.To.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 17:
+		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ReadIORates", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Message", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -6836,14 +11006,13 @@ func (m *StoreStats) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.ReadIORates = append(m.ReadIORates, RecordPair{})
-			if err := m.ReadIORates[len(m.ReadIORates)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Message.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 18:
+		case 6:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field WriteIORates", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardEpoch", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -6870,68 +11039,101 @@ func (m *StoreStats) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.WriteIORates = append(m.WriteIORates, RecordPair{})
-			if err := m.WriteIORates[len(m.WriteIORates)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.ShardEpoch.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMetapb(dAtA[iNdEx:])
-			if err != nil {
-				return err
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsTombstone", wireType)
 			}
-			if skippy < 0 {
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsTombstone = bool(v != 0)
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			if (iNdEx + skippy) < 0 {
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			if (iNdEx + skippy) > l {
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *RecordPair) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMetapb
+			m.Start = append(m.Start[:0], dAtA[iNdEx:postIndex]...)
+			if m.Start == nil {
+				m.Start = []byte{}
 			}
-			if iNdEx >= l {
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
+			m.End = append(m.End[:0], dAtA[iNdEx:postIndex]...)
+			if m.End == nil {
+				m.End = []byte{}
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: RecordPair: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: RecordPair: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+			iNdEx = postIndex
+		case 10:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Unique", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -6959,13 +11161,13 @@ func (m *RecordPair) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Key = string(dAtA[iNdEx:postIndex])
+			m.Unique = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RuleGroups", wireType)
 			}
-			m.Value = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -6975,70 +11177,29 @@ func (m *RecordPair) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Value |= uint64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMetapb(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			if (iNdEx + skippy) < 0 {
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *Member) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMetapb
-			}
-			if iNdEx >= l {
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: Member: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Member: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+			m.RuleGroups = append(m.RuleGroups, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 12:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field CommitIndex", wireType)
 			}
-			m.ID = 0
+			m.CommitIndex = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7048,16 +11209,16 @@ func (m *Member) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ID |= uint64(b&0x7F) << shift
+				m.CommitIndex |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+		case 13:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SendTime", wireType)
 			}
-			var stringLen uint64
+			m.SendTime = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7067,29 +11228,35 @@ func (m *Member) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.SendTime |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
+		case 14:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AppliedIndex", wireType)
 			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+			m.AppliedIndex = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.AppliedIndex |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			m.Name = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Addr", wireType)
+		case 15:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotFailure", wireType)
 			}
-			var stringLen uint64
+			m.SnapshotFailure = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7099,24 +11266,11 @@ func (m *Member) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.SnapshotFailure |= SnapshotFailureReason(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Addr = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -7142,7 +11296,7 @@ func (m *Member) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ProphetCluster) Unmarshal(dAtA []byte) error {
+func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7165,17 +11319,17 @@ func (m *ProphetCluster) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ProphetCluster: wiretype end group for non-group")
+			return fmt.Errorf("proto: SnapshotChunk: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ProphetCluster: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SnapshotChunk: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field StoreID", wireType)
 			}
-			m.ID = 0
+			m.StoreID = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7185,16 +11339,16 @@ func (m *ProphetCluster) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ID |= uint64(b&0x7F) << shift
+				m.StoreID |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field MaxReplicaCount", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardID", wireType)
 			}
-			m.MaxReplicaCount = 0
+			m.ShardID = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7204,70 +11358,16 @@ func (m *ProphetCluster) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.MaxReplicaCount |= uint32(b&0x7F) << shift
+				m.ShardID |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMetapb(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *TimeInterval) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMetapb
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: TimeInterval: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: TimeInterval: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ReplicaID", wireType)
 			}
-			m.Start = 0
+			m.ReplicaID = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7277,16 +11377,16 @@ func (m *TimeInterval) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Start |= uint64(b&0x7F) << shift
+				m.ReplicaID |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
+		case 4:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field From", wireType)
 			}
-			m.End = 0
+			m.From = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7296,70 +11396,16 @@ func (m *TimeInterval) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.End |= uint64(b&0x7F) << shift
+				m.From |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMetapb(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *Job) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMetapb
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: Job: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Job: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+		case 5:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunkID", wireType)
 			}
-			m.Type = 0
+			m.ChunkID = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7369,16 +11415,16 @@ func (m *Job) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Type |= JobType(b&0x7F) << shift
+				m.ChunkID |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Content", wireType)
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunkSize", wireType)
 			}
-			var byteLen int
+			m.ChunkSize = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7388,31 +11434,16 @@ func (m *Job) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				m.ChunkSize |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + byteLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Content = append(m.Content[:0], dAtA[iNdEx:postIndex]...)
-			if m.Content == nil {
-				m.Content = []byte{}
-			}
-			iNdEx = postIndex
-		case 3:
+		case 7:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunkCount", wireType)
 			}
-			m.State = 0
+			m.ChunkCount = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7422,70 +11453,16 @@ func (m *Job) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.State |= JobState(b&0x7F) << shift
+				m.ChunkCount |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMetapb(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *RemoveShardJob) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMetapb
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: RemoveShardJob: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: RemoveShardJob: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+		case 8:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Index", wireType)
 			}
-			m.ID = 0
+			m.Index = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7495,16 +11472,16 @@ func (m *RemoveShardJob) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ID |= uint64(b&0x7F) << shift
+				m.Index |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Replicas", wireType)
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Term", wireType)
 			}
-			var msglen int
+			m.Term = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7514,85 +11491,16 @@ func (m *RemoveShardJob) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.Term |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Replicas = append(m.Replicas, Replica{})
-			if err := m.Replicas[len(m.Replicas)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMetapb(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *ShardPoolJob) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMetapb
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: ShardPoolJob: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ShardPoolJob: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+		case 10:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Pools", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field FilePath", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7602,85 +11510,48 @@ func (m *ShardPoolJob) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Pools = append(m.Pools, ShardPoolJobMeta{})
-			if err := m.Pools[len(m.Pools)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.FilePath = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMetapb(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *ShardPoolJobMeta) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMetapb
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FileSize", wireType)
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
+			m.FileSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FileSize |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: ShardPoolJobMeta: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ShardPoolJobMeta: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+		case 12:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Group", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field FileChunkID", wireType)
 			}
-			m.Group = 0
+			m.FileChunkID = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7690,16 +11561,16 @@ func (m *ShardPoolJobMeta) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Group |= uint64(b&0x7F) << shift
+				m.FileChunkID |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
+		case 13:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Capacity", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field FileChunkCount", wireType)
 			}
-			m.Capacity = 0
+			m.FileChunkCount = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7709,14 +11580,14 @@ func (m *ShardPoolJobMeta) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Capacity |= uint64(b&0x7F) << shift
+				m.FileChunkCount |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 3:
+		case 14:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RangePrefix", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -7740,73 +11611,19 @@ func (m *ShardPoolJobMeta) Unmarshal(dAtA []byte) error {
 			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.RangePrefix = append(m.RangePrefix[:0], dAtA[iNdEx:postIndex]...)
-			if m.RangePrefix == nil {
-				m.RangePrefix = []byte{}
-			}
-			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMetapb(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *DestroyingStatus) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMetapb
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: DestroyingStatus: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: DestroyingStatus: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Index", wireType)
-			}
-			m.Index = 0
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		case 15:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Extra", wireType)
+			}
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7816,14 +11633,29 @@ func (m *DestroyingStatus) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Index |= uint64(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
+			if byteLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Extra = append(m.Extra[:0], dAtA[iNdEx:postIndex]...)
+			if m.Extra == nil {
+				m.Extra = []byte{}
+			}
+			iNdEx = postIndex
+		case 16:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Replicas", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ConfState", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -7850,83 +11682,34 @@ func (m *DestroyingStatus) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Replicas == nil {
-				m.Replicas = make(map[uint64]bool)
+			if err := m.ConfState.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-			var mapkey uint64
-			var mapvalue bool
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowMetapb
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
+			iNdEx = postIndex
+		case 17:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FromStoreID", wireType)
+			}
+			m.FromStoreID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
 				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowMetapb
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapkey |= uint64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-				} else if fieldNum == 2 {
-					var mapvaluetemp int
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowMetapb
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapvaluetemp |= int(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					mapvalue = bool(mapvaluetemp != 0)
-				} else {
-					iNdEx = entryPreIndex
-					skippy, err := skipMetapb(dAtA[iNdEx:])
-					if err != nil {
-						return err
-					}
-					if skippy < 0 {
-						return ErrInvalidLengthMetapb
-					}
-					if (iNdEx + skippy) > postIndex {
-						return io.ErrUnexpectedEOF
-					}
-					iNdEx += skippy
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FromStoreID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
 				}
 			}
-			m.Replicas[mapkey] = mapvalue
-			iNdEx = postIndex
-		case 3:
+		case 18:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Format", wireType)
 			}
-			m.State = 0
+			m.Format = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7936,16 +11719,16 @@ func (m *DestroyingStatus) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.State |= ShardState(b&0x7F) << shift
+				m.Format |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 4:
+		case 19:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RemoveData", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotSize", wireType)
 			}
-			var v int
+			m.SnapshotSize = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -7955,12 +11738,11 @@ func (m *DestroyingStatus) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				m.SnapshotSize |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.RemoveData = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -7986,7 +11768,7 @@ func (m *DestroyingStatus) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ShardExtra) Unmarshal(dAtA []byte) error {
+func (m *StoreIdent) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -8009,17 +11791,17 @@ func (m *ShardExtra) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ShardExtra: wiretype end group for non-group")
+			return fmt.Errorf("proto: StoreIdent: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ShardExtra: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: StoreIdent: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ClusterID", wireType)
 			}
-			var msglen int
+			m.ClusterID = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8029,124 +11811,35 @@ func (m *ShardExtra) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.ClusterID |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Labels == nil {
-				m.Labels = make(map[string]string)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StoreID", wireType)
 			}
-			var mapkey string
-			var mapvalue string
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowMetapb
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
+			m.StoreID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
 				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					var stringLenmapkey uint64
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowMetapb
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						stringLenmapkey |= uint64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					intStringLenmapkey := int(stringLenmapkey)
-					if intStringLenmapkey < 0 {
-						return ErrInvalidLengthMetapb
-					}
-					postStringIndexmapkey := iNdEx + intStringLenmapkey
-					if postStringIndexmapkey < 0 {
-						return ErrInvalidLengthMetapb
-					}
-					if postStringIndexmapkey > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
-					iNdEx = postStringIndexmapkey
-				} else if fieldNum == 2 {
-					var stringLenmapvalue uint64
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowMetapb
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						stringLenmapvalue |= uint64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					intStringLenmapvalue := int(stringLenmapvalue)
-					if intStringLenmapvalue < 0 {
-						return ErrInvalidLengthMetapb
-					}
-					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
-					if postStringIndexmapvalue < 0 {
-						return ErrInvalidLengthMetapb
-					}
-					if postStringIndexmapvalue > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
-					iNdEx = postStringIndexmapvalue
-				} else {
-					iNdEx = entryPreIndex
-					skippy, err := skipMetapb(dAtA[iNdEx:])
-					if err != nil {
-						return err
-					}
-					if skippy < 0 {
-						return ErrInvalidLengthMetapb
-					}
-					if (iNdEx + skippy) > postIndex {
-						return io.ErrUnexpectedEOF
-					}
-					iNdEx += skippy
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StoreID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
 				}
 			}
-			m.Labels[mapkey] = mapvalue
-			iNdEx = postIndex
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field DestroyingStatus", wireType)
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Epoch", wireType)
 			}
-			var msglen int
+			m.Epoch = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8156,28 +11849,11 @@ func (m *ShardExtra) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.Epoch |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.DestroyingStatus == nil {
-				m.DestroyingStatus = &DestroyingStatus{}
-			}
-			if err := m.DestroyingStatus.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -8203,7 +11879,7 @@ func (m *ShardExtra) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ScheduleGroupRule) Unmarshal(dAtA []byte) error {
+func (m *Shard) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -8226,10 +11902,10 @@ func (m *ScheduleGroupRule) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ScheduleGroupRule: wiretype end group for non-group")
+			return fmt.Errorf("proto: Shard: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ScheduleGroupRule: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Shard: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -8252,10 +11928,10 @@ func (m *ScheduleGroupRule) Unmarshal(dAtA []byte) error {
 				}
 			}
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field GroupID", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
 			}
-			m.GroupID = 0
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8265,16 +11941,31 @@ func (m *ScheduleGroupRule) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.GroupID |= uint64(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Start = append(m.Start[:0], dAtA[iNdEx:postIndex]...)
+			if m.Start == nil {
+				m.Start = []byte{}
+			}
+			iNdEx = postIndex
 		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
 			}
-			var stringLen uint64
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8284,29 +11975,31 @@ func (m *ScheduleGroupRule) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + byteLen
 			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Name = string(dAtA[iNdEx:postIndex])
+			m.End = append(m.End[:0], dAtA[iNdEx:postIndex]...)
+			if m.End == nil {
+				m.End = []byte{}
+			}
 			iNdEx = postIndex
 		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field GroupByLabel", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Epoch", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8316,81 +12009,164 @@ func (m *ScheduleGroupRule) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.GroupByLabel = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMetapb(dAtA[iNdEx:])
-			if err != nil {
+			if err := m.Epoch.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			if skippy < 0 {
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
+			}
+			m.State = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.State |= ShardState(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Replicas", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			if (iNdEx + skippy) < 0 {
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			if (iNdEx + skippy) > l {
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *RaftMessageBatch) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMetapb
+			m.Replicas = append(m.Replicas, Replica{})
+			if err := m.Replicas[len(m.Replicas)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-			if iNdEx >= l {
+			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Group", wireType)
+			}
+			m.Group = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Group |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Unique", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
+			m.Unique = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RuleGroups", wireType)
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: RaftMessageBatch: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: RaftMessageBatch: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RuleGroups = append(m.RuleGroups, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 10:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Messages", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -8417,8 +12193,8 @@ func (m *RaftMessageBatch) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Messages = append(m.Messages, RaftMessage{})
-			if err := m.Messages[len(m.Messages)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Labels = append(m.Labels, Label{})
+			if err := m.Labels[len(m.Labels)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -8447,7 +12223,7 @@ func (m *RaftMessageBatch) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *RaftMessage) Unmarshal(dAtA []byte) error {
+func (m *LogIndex) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -8470,17 +12246,17 @@ func (m *RaftMessage) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: RaftMessage: wiretype end group for non-group")
+			return fmt.Errorf("proto: LogIndex: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: RaftMessage: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LogIndex: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ShardID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Index", wireType)
 			}
-			m.ShardID = 0
+			m.Index = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8490,16 +12266,16 @@ func (m *RaftMessage) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ShardID |= uint64(b&0x7F) << shift
+				m.Index |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Group", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Term", wireType)
 			}
-			m.Group = 0
+			m.Term = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8509,49 +12285,70 @@ func (m *RaftMessage) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Group |= uint64(b&0x7F) << shift
+				m.Term |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field From", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			if msglen < 0 {
+			if skippy < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
+			if (iNdEx + skippy) < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			if err := m.From.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ShardMetadata) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
 			}
-			iNdEx = postIndex
-		case 4:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field To", wireType)
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
 			}
-			var msglen int
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ShardMetadata: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ShardMetadata: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardID", wireType)
+			}
+			m.ShardID = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8561,30 +12358,16 @@ func (m *RaftMessage) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.ShardID |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if err := m.To.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		case 5:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Message", wireType)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LogIndex", wireType)
 			}
-			var msglen int
+			m.LogIndex = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8594,28 +12377,14 @@ func (m *RaftMessage) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.LogIndex |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if err := m.Message.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		case 6:
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ShardEpoch", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -8642,103 +12411,69 @@ func (m *RaftMessage) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if err := m.ShardEpoch.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Metadata.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 7:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field IsTombstone", wireType)
-			}
-			var v int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				v |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			m.IsTombstone = bool(v != 0)
-		case 8:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
-			}
-			var byteLen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				byteLen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			if byteLen < 0 {
+			if skippy < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + byteLen
-			if postIndex < 0 {
+			if (iNdEx + skippy) < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Start = append(m.Start[:0], dAtA[iNdEx:postIndex]...)
-			if m.Start == nil {
-				m.Start = []byte{}
-			}
-			iNdEx = postIndex
-		case 9:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
-			}
-			var byteLen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				byteLen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if byteLen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + byteLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ShardLocalState) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
 			}
-			if postIndex > l {
+			if iNdEx >= l {
 				return io.ErrUnexpectedEOF
 			}
-			m.End = append(m.End[:0], dAtA[iNdEx:postIndex]...)
-			if m.End == nil {
-				m.End = []byte{}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
 			}
-			iNdEx = postIndex
-		case 10:
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ShardLocalState: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ShardLocalState: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Unique", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Shard", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8748,29 +12483,30 @@ func (m *RaftMessage) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Unique = string(dAtA[iNdEx:postIndex])
+			if err := m.Shard.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
-		case 11:
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RuleGroups", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Lease", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8780,29 +12516,33 @@ func (m *RaftMessage) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.RuleGroups = append(m.RuleGroups, string(dAtA[iNdEx:postIndex]))
+			if m.Lease == nil {
+				m.Lease = &EpochLease{}
+			}
+			if err := m.Lease.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
-		case 12:
+		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field CommitIndex", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
 			}
-			m.CommitIndex = 0
+			m.State = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8812,16 +12552,16 @@ func (m *RaftMessage) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.CommitIndex |= uint64(b&0x7F) << shift
+				m.State |= ReplicaState(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 13:
+		case 4:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SendTime", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field RemoveData", wireType)
 			}
-			m.SendTime = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8831,11 +12571,12 @@ func (m *RaftMessage) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.SendTime |= uint64(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			m.RemoveData = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -8861,7 +12602,7 @@ func (m *RaftMessage) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
+func (m *Store) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -8884,17 +12625,17 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SnapshotChunk: wiretype end group for non-group")
+			return fmt.Errorf("proto: Store: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SnapshotChunk: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Store: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StoreID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
 			}
-			m.StoreID = 0
+			m.ID = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8904,16 +12645,16 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.StoreID |= uint64(b&0x7F) << shift
+				m.ID |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ShardID", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RaftAddress", wireType)
 			}
-			m.ShardID = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8923,16 +12664,29 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ShardID |= uint64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RaftAddress = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ReplicaID", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ClientAddress", wireType)
 			}
-			m.ReplicaID = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8942,16 +12696,29 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ReplicaID |= uint64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ClientAddress = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 4:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field From", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
 			}
-			m.From = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8961,16 +12728,31 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.From |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Labels = append(m.Labels, Label{})
+			if err := m.Labels[len(m.Labels)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 5:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ChunkID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
 			}
-			m.ChunkID = 0
+			m.State = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8980,16 +12762,118 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ChunkID |= uint64(b&0x7F) << shift
+				m.State |= StoreState(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartTime", wireType)
+			}
+			m.StartTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LastHeartbeatTime", wireType)
+			}
+			m.LastHeartbeatTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LastHeartbeatTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Version = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CommitID", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 6:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ChunkSize", wireType)
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.CommitID = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DeployPath", wireType)
 			}
-			m.ChunkSize = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -8999,16 +12883,29 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ChunkSize |= uint64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 7:
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DeployPath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 11:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ChunkCount", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Destroyed", wireType)
 			}
-			m.ChunkCount = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9018,16 +12915,17 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ChunkCount |= uint64(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 8:
+			m.Destroyed = bool(v != 0)
+		case 12:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Index", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Epoch", wireType)
 			}
-			m.Index = 0
+			m.Epoch = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9037,16 +12935,16 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Index |= uint64(b&0x7F) << shift
+				m.Epoch |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 9:
+		case 13:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Term", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotFormat", wireType)
 			}
-			m.Term = 0
+			m.SnapshotFormat = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9056,16 +12954,70 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Term |= uint64(b&0x7F) << shift
+				m.SnapshotFormat |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 10:
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ShardsPool) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ShardsPool: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ShardsPool: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FilePath", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Pools", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9075,67 +13027,166 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.FilePath = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 11:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FileSize", wireType)
+			if m.Pools == nil {
+				m.Pools = make(map[uint64]*ShardPool)
 			}
-			m.FileSize = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
+			var mapkey uint64
+			var mapvalue *ShardPool
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowMetapb
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
 				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.FileSize |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMetapb
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else if fieldNum == 2 {
+					var mapmsglen int
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMetapb
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapmsglen |= int(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					if mapmsglen < 0 {
+						return ErrInvalidLengthMetapb
+					}
+					postmsgIndex := iNdEx + mapmsglen
+					if postmsgIndex < 0 {
+						return ErrInvalidLengthMetapb
+					}
+					if postmsgIndex > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = &ShardPool{}
+					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
+						return err
+					}
+					iNdEx = postmsgIndex
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipMetapb(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthMetapb
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
 				}
 			}
-		case 12:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FileChunkID", wireType)
+			m.Pools[mapkey] = mapvalue
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			m.FileChunkID = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.FileChunkID |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ShardPool) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
 			}
-		case 13:
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ShardPool: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ShardPool: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FileChunkCount", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Capacity", wireType)
 			}
-			m.FileChunkCount = 0
+			m.Capacity = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9145,14 +13196,14 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.FileChunkCount |= uint64(b&0x7F) << shift
+				m.Capacity |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 14:
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field RangePrefix", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -9179,16 +13230,16 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
-			if m.Data == nil {
-				m.Data = []byte{}
+			m.RangePrefix = append(m.RangePrefix[:0], dAtA[iNdEx:postIndex]...)
+			if m.RangePrefix == nil {
+				m.RangePrefix = []byte{}
 			}
 			iNdEx = postIndex
-		case 15:
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Extra", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field AllocatedShards", wireType)
 			}
-			var byteLen int
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9198,31 +13249,31 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Extra = append(m.Extra[:0], dAtA[iNdEx:postIndex]...)
-			if m.Extra == nil {
-				m.Extra = []byte{}
+			m.AllocatedShards = append(m.AllocatedShards, &AllocatedShard{})
+			if err := m.AllocatedShards[len(m.AllocatedShards)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
 			iNdEx = postIndex
-		case 16:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ConfState", wireType)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
 			}
-			var msglen int
+			m.Seq = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9232,25 +13283,30 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.Seq |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AllocatedOffset", wireType)
 			}
-			if err := m.ConfState.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.AllocatedOffset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.AllocatedOffset |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -9276,7 +13332,7 @@ func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *StoreIdent) Unmarshal(dAtA []byte) error {
+func (m *AllocatedShard) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -9299,17 +13355,17 @@ func (m *StoreIdent) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: StoreIdent: wiretype end group for non-group")
+			return fmt.Errorf("proto: AllocatedShard: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: StoreIdent: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: AllocatedShard: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ClusterID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardID", wireType)
 			}
-			m.ClusterID = 0
+			m.ShardID = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9319,16 +13375,16 @@ func (m *StoreIdent) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ClusterID |= uint64(b&0x7F) << shift
+				m.ShardID |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StoreID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field AllocatedAt", wireType)
 			}
-			m.StoreID = 0
+			m.AllocatedAt = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9338,11 +13394,45 @@ func (m *StoreIdent) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.StoreID |= uint64(b&0x7F) << shift
+				m.AllocatedAt |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Purpose", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Purpose = append(m.Purpose[:0], dAtA[iNdEx:postIndex]...)
+			if m.Purpose == nil {
+				m.Purpose = []byte{}
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -9368,7 +13458,7 @@ func (m *StoreIdent) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Shard) Unmarshal(dAtA []byte) error {
+func (m *ShardsPoolCmd) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -9391,17 +13481,17 @@ func (m *Shard) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Shard: wiretype end group for non-group")
+			return fmt.Errorf("proto: ShardsPoolCmd: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Shard: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ShardsPoolCmd: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
 			}
-			m.ID = 0
+			m.Type = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9411,16 +13501,16 @@ func (m *Shard) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ID |= uint64(b&0x7F) << shift
+				m.Type |= ShardsPoolCmdType(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Create", wireType)
 			}
-			var byteLen int
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9430,31 +13520,33 @@ func (m *Shard) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Start = append(m.Start[:0], dAtA[iNdEx:postIndex]...)
-			if m.Start == nil {
-				m.Start = []byte{}
+			if m.Create == nil {
+				m.Create = &ShardsPoolCreateCmd{}
+			}
+			if err := m.Create.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
 			iNdEx = postIndex
 		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Alloc", wireType)
 			}
-			var byteLen int
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9464,64 +13556,141 @@ func (m *Shard) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.End = append(m.End[:0], dAtA[iNdEx:postIndex]...)
-			if m.End == nil {
-				m.End = []byte{}
+			if m.Alloc == nil {
+				m.Alloc = &ShardsPoolAllocCmd{}
+			}
+			if err := m.Alloc.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
 			iNdEx = postIndex
-		case 4:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Epoch", wireType)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			if skippy < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ShardsPoolCreateCmd) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
 			}
-			if msglen < 0 {
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ShardsPoolCreateCmd: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ShardsPoolCreateCmd: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
+			if (iNdEx + skippy) < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			if err := m.Epoch.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ShardsPoolAllocCmd) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
 			}
-			iNdEx = postIndex
-		case 5:
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ShardsPoolAllocCmd: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ShardsPoolAllocCmd: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Group", wireType)
 			}
-			m.State = 0
+			m.Group = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9531,16 +13700,16 @@ func (m *Shard) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.State |= ShardState(b&0x7F) << shift
+				m.Group |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 6:
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Replicas", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Purpose", wireType)
 			}
-			var msglen int
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9550,82 +13719,85 @@ func (m *Shard) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + byteLen
 			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Replicas = append(m.Replicas, Replica{})
-			if err := m.Replicas[len(m.Replicas)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.Purpose = append(m.Purpose[:0], dAtA[iNdEx:postIndex]...)
+			if m.Purpose == nil {
+				m.Purpose = []byte{}
 			}
 			iNdEx = postIndex
-		case 7:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Group", wireType)
-			}
-			m.Group = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Group |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 8:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Unique", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetapb(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if skippy < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
+			if (iNdEx + skippy) < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Unique = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 9:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RuleGroups", wireType)
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SnapshotInfo) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetapb
 			}
-			var stringLen uint64
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SnapshotInfo: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SnapshotInfo: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Extra", wireType)
+			}
+			m.Extra = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9635,29 +13807,16 @@ func (m *Shard) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.Extra |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.RuleGroups = append(m.RuleGroups, string(dAtA[iNdEx:postIndex]))
-			iNdEx = postIndex
-		case 10:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Dummy", wireType)
 			}
-			var msglen int
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9667,26 +13826,12 @@ func (m *Shard) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Labels = append(m.Labels, Label{})
-			if err := m.Labels[len(m.Labels)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
+			m.Dummy = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -9712,7 +13857,7 @@ func (m *Shard) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LogIndex) Unmarshal(dAtA []byte) error {
+func (m *EpochLease) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -9735,17 +13880,17 @@ func (m *LogIndex) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LogIndex: wiretype end group for non-group")
+			return fmt.Errorf("proto: EpochLease: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LogIndex: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: EpochLease: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Index", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Epoch", wireType)
 			}
-			m.Index = 0
+			m.Epoch = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9755,16 +13900,16 @@ func (m *LogIndex) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Index |= uint64(b&0x7F) << shift
+				m.Epoch |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Term", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ReplicaID", wireType)
 			}
-			m.Term = 0
+			m.ReplicaID = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9774,7 +13919,7 @@ func (m *LogIndex) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Term |= uint64(b&0x7F) << shift
+				m.ReplicaID |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -9804,7 +13949,8 @@ func (m *LogIndex) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ShardMetadata) Unmarshal(dAtA []byte) error {
+
+func (m *ReplicaReadStats) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -9827,10 +13973,10 @@ func (m *ShardMetadata) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ShardMetadata: wiretype end group for non-group")
+			return fmt.Errorf("proto: ReplicaReadStats: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ShardMetadata: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ReplicaReadStats: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -9854,9 +14000,9 @@ func (m *ShardMetadata) Unmarshal(dAtA []byte) error {
 			}
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LogIndex", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ReplicaID", wireType)
 			}
-			m.LogIndex = 0
+			m.ReplicaID = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9866,16 +14012,16 @@ func (m *ShardMetadata) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.LogIndex |= uint64(b&0x7F) << shift
+				m.ReplicaID |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReadBytes", wireType)
 			}
-			var msglen int
+			m.ReadBytes = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9885,25 +14031,30 @@ func (m *ShardMetadata) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.ReadBytes |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReadKeys", wireType)
 			}
-			if err := m.Metadata.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.ReadKeys = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ReadKeys |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -9929,7 +14080,8 @@ func (m *ShardMetadata) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ShardLocalState) Unmarshal(dAtA []byte) error {
+
+func (m *ShardReadHint) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -9952,17 +14104,17 @@ func (m *ShardLocalState) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ShardLocalState: wiretype end group for non-group")
+			return fmt.Errorf("proto: ShardReadHint: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ShardLocalState: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ShardReadHint: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Shard", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardID", wireType)
 			}
-			var msglen int
+			m.ShardID = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -9972,100 +14124,87 @@ func (m *ShardLocalState) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.ShardID |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if err := m.Shard.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Lease", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
+			if wireType == 0 {
+				var v uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowMetapb
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
 				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
+				m.Replicas = append(m.Replicas, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowMetapb
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
 				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
+				if packedLen < 0 {
+					return ErrInvalidLengthMetapb
 				}
-			}
-			if msglen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Lease == nil {
-				m.Lease = &EpochLease{}
-			}
-			if err := m.Lease.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
-			}
-			m.State = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthMetapb
 				}
-				if iNdEx >= l {
+				if postIndex > l {
 					return io.ErrUnexpectedEOF
 				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.State |= ReplicaState(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 4:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RemoveData", wireType)
-			}
-			var v int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMetapb
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
 				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
+				elementCount = count
+				if elementCount != 0 && len(m.Replicas) == 0 {
+					m.Replicas = make([]uint64, 0, elementCount)
 				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				v |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
+				for iNdEx < postIndex {
+					var v uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMetapb
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.Replicas = append(m.Replicas, v)
 				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field Replicas", wireType)
 			}
-			m.RemoveData = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -10091,7 +14230,8 @@ func (m *ShardLocalState) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Store) Unmarshal(dAtA []byte) error {
+
+func (m *ReplicaProgress) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -10114,17 +14254,17 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Store: wiretype end group for non-group")
+			return fmt.Errorf("proto: ReplicaProgress: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Store: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ReplicaProgress: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Replica", wireType)
 			}
-			m.ID = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10134,16 +14274,30 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ID |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Replica.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RaftAddress", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AppliedIndex", wireType)
 			}
-			var stringLen uint64
+			m.AppliedIndex = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10153,29 +14307,16 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.AppliedIndex |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.RaftAddress = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ClientAddress", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MatchIndex", wireType)
 			}
-			var stringLen uint64
+			m.MatchIndex = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10185,29 +14326,16 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.MatchIndex |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.ClientAddress = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		case 4:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LastContactTime", wireType)
 			}
-			var msglen int
+			m.LastContactTime = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10217,31 +14345,16 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.LastContactTime |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Labels = append(m.Labels, Label{})
-			if err := m.Labels[len(m.Labels)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		case 5:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field NeedSnapshot", wireType)
 			}
-			m.State = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10251,16 +14364,17 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.State |= StoreState(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			m.NeedSnapshot = bool(v != 0)
 		case 6:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StartTime", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotPhase", wireType)
 			}
-			m.StartTime = 0
+			m.SnapshotPhase = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10270,16 +14384,16 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.StartTime |= int64(b&0x7F) << shift
+				m.SnapshotPhase |= SnapshotPhase(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 7:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LastHeartbeatTime", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotSentBytes", wireType)
 			}
-			m.LastHeartbeatTime = 0
+			m.SnapshotSentBytes = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10289,16 +14403,16 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.LastHeartbeatTime |= int64(b&0x7F) << shift
+				m.SnapshotSentBytes |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 8:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotTotalBytes", wireType)
 			}
-			var stringLen uint64
+			m.SnapshotTotalBytes = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10308,29 +14422,16 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.SnapshotTotalBytes |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Version = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		case 9:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field CommitID", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotFailure", wireType)
 			}
-			var stringLen uint64
+			m.SnapshotFailure = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10340,29 +14441,16 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.SnapshotFailure |= SnapshotFailureReason(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.CommitID = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		case 10:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field DeployPath", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotFailures", wireType)
 			}
-			var stringLen uint64
+			m.SnapshotFailures = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10372,27 +14460,14 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.SnapshotFailures |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.DeployPath = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		case 11:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Destroyed", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotQuarantined", wireType)
 			}
 			var v int
 			for shift := uint(0); ; shift += 7 {
@@ -10409,7 +14484,7 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-			m.Destroyed = bool(v != 0)
+			m.SnapshotQuarantined = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -10435,7 +14510,8 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ShardsPool) Unmarshal(dAtA []byte) error {
+
+func (m *SnapshotManifest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -10458,15 +14534,34 @@ func (m *ShardsPool) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ShardsPool: wiretype end group for non-group")
+			return fmt.Errorf("proto: SnapshotManifest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ShardsPool: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SnapshotManifest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FormatVersion", wireType)
+			}
+			m.FormatVersion = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FormatVersion |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Pools", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Shard", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -10493,151 +14588,15 @@ func (m *ShardsPool) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Pools == nil {
-				m.Pools = make(map[uint64]*ShardPool)
-			}
-			var mapkey uint64
-			var mapvalue *ShardPool
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowMetapb
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowMetapb
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapkey |= uint64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-				} else if fieldNum == 2 {
-					var mapmsglen int
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowMetapb
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapmsglen |= int(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					if mapmsglen < 0 {
-						return ErrInvalidLengthMetapb
-					}
-					postmsgIndex := iNdEx + mapmsglen
-					if postmsgIndex < 0 {
-						return ErrInvalidLengthMetapb
-					}
-					if postmsgIndex > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapvalue = &ShardPool{}
-					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
-						return err
-					}
-					iNdEx = postmsgIndex
-				} else {
-					iNdEx = entryPreIndex
-					skippy, err := skipMetapb(dAtA[iNdEx:])
-					if err != nil {
-						return err
-					}
-					if skippy < 0 {
-						return ErrInvalidLengthMetapb
-					}
-					if (iNdEx + skippy) > postIndex {
-						return io.ErrUnexpectedEOF
-					}
-					iNdEx += skippy
-				}
-			}
-			m.Pools[mapkey] = mapvalue
-			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMetapb(dAtA[iNdEx:])
-			if err != nil {
+			if err := m.Shard.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *ShardPool) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMetapb
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: ShardPool: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ShardPool: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Capacity", wireType)
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
 			}
-			m.Capacity = 0
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10647,14 +14606,29 @@ func (m *ShardPool) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Capacity |= uint64(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
+			if byteLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Start = append(m.Start[:0], dAtA[iNdEx:postIndex]...)
+			if m.Start == nil {
+				m.Start = []byte{}
+			}
+			iNdEx = postIndex
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RangePrefix", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -10681,16 +14655,16 @@ func (m *ShardPool) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.RangePrefix = append(m.RangePrefix[:0], dAtA[iNdEx:postIndex]...)
-			if m.RangePrefix == nil {
-				m.RangePrefix = []byte{}
+			m.End = append(m.End[:0], dAtA[iNdEx:postIndex]...)
+			if m.End == nil {
+				m.End = []byte{}
 			}
 			iNdEx = postIndex
-		case 3:
+		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field AllocatedShards", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field AppliedIndexKey", wireType)
 			}
-			var msglen int
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10700,31 +14674,31 @@ func (m *ShardPool) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + byteLen
 			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.AllocatedShards = append(m.AllocatedShards, &AllocatedShard{})
-			if err := m.AllocatedShards[len(m.AllocatedShards)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.AppliedIndexKey = append(m.AppliedIndexKey[:0], dAtA[iNdEx:postIndex]...)
+			if m.AppliedIndexKey == nil {
+				m.AppliedIndexKey = []byte{}
 			}
 			iNdEx = postIndex
-		case 4:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AppliedIndexValue", wireType)
 			}
-			m.Seq = 0
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10734,16 +14708,31 @@ func (m *ShardPool) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Seq |= uint64(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 5:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field AllocatedOffset", wireType)
+			if byteLen < 0 {
+				return ErrInvalidLengthMetapb
 			}
-			m.AllocatedOffset = 0
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.AppliedIndexValue = append(m.AppliedIndexValue[:0], dAtA[iNdEx:postIndex]...)
+			if m.AppliedIndexValue == nil {
+				m.AppliedIndexValue = []byte{}
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MetadataKey", wireType)
+			}
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10753,70 +14742,31 @@ func (m *ShardPool) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.AllocatedOffset |= uint64(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMetapb(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			if (iNdEx + skippy) < 0 {
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
 				return ErrInvalidLengthMetapb
 			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *AllocatedShard) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMetapb
-			}
-			if iNdEx >= l {
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
+			m.MetadataKey = append(m.MetadataKey[:0], dAtA[iNdEx:postIndex]...)
+			if m.MetadataKey == nil {
+				m.MetadataKey = []byte{}
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: AllocatedShard: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: AllocatedShard: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ShardID", wireType)
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MetadataValue", wireType)
 			}
-			m.ShardID = 0
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10826,16 +14776,31 @@ func (m *AllocatedShard) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ShardID |= uint64(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
+			if byteLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.MetadataValue = append(m.MetadataValue[:0], dAtA[iNdEx:postIndex]...)
+			if m.MetadataValue == nil {
+				m.MetadataValue = []byte{}
+			}
+			iNdEx = postIndex
+		case 9:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field AllocatedAt", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field KeyCount", wireType)
 			}
-			m.AllocatedAt = 0
+			m.KeyCount = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10845,16 +14810,16 @@ func (m *AllocatedShard) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.AllocatedAt |= uint64(b&0x7F) << shift
+				m.KeyCount |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Purpose", wireType)
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ByteCount", wireType)
 			}
-			var byteLen int
+			m.ByteCount = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10864,85 +14829,16 @@ func (m *AllocatedShard) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				m.ByteCount |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + byteLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Purpose = append(m.Purpose[:0], dAtA[iNdEx:postIndex]...)
-			if m.Purpose == nil {
-				m.Purpose = []byte{}
-			}
-			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMetapb(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *ShardsPoolCmd) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMetapb
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: ShardsPoolCmd: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ShardsPoolCmd: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+		case 11:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field DataChecksum", wireType)
 			}
-			m.Type = 0
+			m.DataChecksum = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10952,16 +14848,16 @@ func (m *ShardsPoolCmd) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Type |= ShardsPoolCmdType(b&0x7F) << shift
+				m.DataChecksum |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Create", wireType)
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SstSize", wireType)
 			}
-			var msglen int
+			m.SstSize = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -10971,33 +14867,16 @@ func (m *ShardsPoolCmd) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.SstSize |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Create == nil {
-				m.Create = &ShardsPoolCreateCmd{}
-			}
-			if err := m.Create.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Alloc", wireType)
+		case 13:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SstChecksum", wireType)
 			}
-			var msglen int
+			m.SstChecksum = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -11007,28 +14886,30 @@ func (m *ShardsPoolCmd) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.SstChecksum |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Alloc == nil {
-				m.Alloc = &ShardsPoolAllocCmd{}
+		case 14:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Features", wireType)
 			}
-			if err := m.Alloc.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.Features = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Features |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -11054,7 +14935,8 @@ func (m *ShardsPoolCmd) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ShardsPoolCreateCmd) Unmarshal(dAtA []byte) error {
+
+func (m *Keyspace) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -11077,67 +14959,64 @@ func (m *ShardsPoolCreateCmd) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ShardsPoolCreateCmd: wiretype end group for non-group")
+			return fmt.Errorf("proto: Keyspace: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ShardsPoolCreateCmd: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Keyspace: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMetapb(dAtA[iNdEx:])
-			if err != nil {
-				return err
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthMetapb
+			m.ID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthMetapb
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
 			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *ShardsPoolAllocCmd) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMetapb
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetapb
 			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: ShardsPoolAllocCmd: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ShardsPoolAllocCmd: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
 			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Group", wireType)
 			}
@@ -11156,9 +15035,9 @@ func (m *ShardsPoolAllocCmd) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-		case 2:
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Purpose", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Prefix", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -11185,70 +15064,35 @@ func (m *ShardsPoolAllocCmd) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Purpose = append(m.Purpose[:0], dAtA[iNdEx:postIndex]...)
-			if m.Purpose == nil {
-				m.Purpose = []byte{}
+			m.Prefix = append(m.Prefix[:0], dAtA[iNdEx:postIndex]...)
+			if m.Prefix == nil {
+				m.Prefix = []byte{}
 			}
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMetapb(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthMetapb
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *SnapshotInfo) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMetapb
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxReplicas", wireType)
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
+			m.MaxReplicas = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxReplicas |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: SnapshotInfo: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SnapshotInfo: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Extra", wireType)
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
 			}
-			m.Extra = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -11258,16 +15102,31 @@ func (m *SnapshotInfo) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Extra |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
+			if msglen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Labels = append(m.Labels, Label{})
+			if err := m.Labels[len(m.Labels)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Dummy", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardCapacityBytes", wireType)
 			}
-			var v int
+			m.ShardCapacityBytes = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -11277,12 +15136,11 @@ func (m *SnapshotInfo) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				m.ShardCapacityBytes |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.Dummy = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -11308,7 +15166,7 @@ func (m *SnapshotInfo) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *EpochLease) Unmarshal(dAtA []byte) error {
+func (m *StoreSchema) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -11331,17 +15189,17 @@ func (m *EpochLease) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: EpochLease: wiretype end group for non-group")
+			return fmt.Errorf("proto: StoreSchema: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: EpochLease: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: StoreSchema: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Epoch", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
 			}
-			m.Epoch = 0
+			m.Version = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -11351,16 +15209,16 @@ func (m *EpochLease) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Epoch |= uint64(b&0x7F) << shift
+				m.Version |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ReplicaID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Migrating", wireType)
 			}
-			m.ReplicaID = 0
+			m.Migrating = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMetapb
@@ -11370,11 +15228,45 @@ func (m *EpochLease) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ReplicaID |= uint64(b&0x7F) << shift
+				m.Migrating |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Checkpoint", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetapb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetapb
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Checkpoint = append(m.Checkpoint[:0], dAtA[iNdEx:postIndex]...)
+			if m.Checkpoint == nil {
+				m.Checkpoint = []byte{}
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetapb(dAtA[iNdEx:])
@@ -11400,6 +15292,7 @@ func (m *EpochLease) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+
 func skipMetapb(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -68,6 +68,34 @@ func (m *RequestBatch) GetUpdateEpochLeaseRequest() UpdateEpochLeaseRequest {
 	return req
 }
 
+// GetPrepareMergeRequest return PrepareMergeRequest request
+func (m *RequestBatch) GetPrepareMergeRequest() PrepareMergeRequest {
+	var req PrepareMergeRequest
+	protoc.MustUnmarshal(&req, m.GetAdminRequest().Cmd)
+	return req
+}
+
+// GetCommitMergeRequest return CommitMergeRequest request
+func (m *RequestBatch) GetCommitMergeRequest() CommitMergeRequest {
+	var req CommitMergeRequest
+	protoc.MustUnmarshal(&req, m.GetAdminRequest().Cmd)
+	return req
+}
+
+// GetRollbackMergeRequest return RollbackMergeRequest request
+func (m *RequestBatch) GetRollbackMergeRequest() RollbackMergeRequest {
+	var req RollbackMergeRequest
+	protoc.MustUnmarshal(&req, m.GetAdminRequest().Cmd)
+	return req
+}
+
+// GetVerifyHashRequest return VerifyHashRequest request
+func (m *RequestBatch) GetVerifyHashRequest() VerifyHashRequest {
+	var req VerifyHashRequest
+	protoc.MustUnmarshal(&req, m.GetAdminRequest().Cmd)
+	return req
+}
+
 // IsEmpty returns true if is a empty batch
 func (m *RequestBatch) IsEmpty() bool {
 	return len(m.Header.ID) == 0
@@ -71,13 +71,24 @@ func (c *batch) isFull(n, max int) bool {
 
 func (c *batch) canBatches(req rpcpb.Request) bool {
 	return c.canBatchesWithEpoch(req) &&
-		c.canBatchesWithLease(req)
+		c.canBatchesWithLease(req) &&
+		c.canBatchesWithExpectedEpoch(req)
 }
 
 func (c *batch) canBatchesWithLease(req rpcpb.Request) bool {
 	return c.requestBatch.Header.Lease.Match(req.GetLease())
 }
 
+// canBatchesWithExpectedEpoch only the requests expecting the same epoch are
+// batched, as the expected epoch is checked by the first request of the batch
+func (c *batch) canBatchesWithExpectedEpoch(req rpcpb.Request) bool {
+	expected := c.requestBatch.Requests[0].ExpectedEpoch
+	if expected == nil || req.ExpectedEpoch == nil {
+		return expected == nil && req.ExpectedEpoch == nil
+	}
+	return epochMatch(*expected, *req.ExpectedEpoch)
+}
+
 func (c *batch) canBatchesWithEpoch(req rpcpb.Request) bool {
 	return (c.requestBatch.Requests[0].IgnoreEpochCheck && req.IgnoreEpochCheck) || // batch IgnoreEpochCheck requests
 		(epochMatch(c.requestBatch.Requests[0].Epoch, req.Epoch) && // batch epoch match requests
@@ -170,6 +181,14 @@ func (c *batch) respNotLeader(shardID uint64, leader Replica) {
 	c.resp(rsp)
 }
 
+func (c *batch) respLeaseReadNotReady() {
+	rsp := errorPbResp(c.getRequestID(), errorpb.Error{
+		Message:           "lease read not ready",
+		LeaseReadNotReady: &errorpb.LeaseReadNotReady{},
+	})
+	c.resp(rsp)
+}
+
 func (c *batch) getRequestID() []byte {
 	return c.requestBatch.Header.ID
 }
@@ -238,6 +257,32 @@ func respLeaseReadNotReady(req rpcpb.Request, cb func(rpcpb.ResponseBatch)) {
 	cb(rsp)
 }
 
+func respServerIsBusy(busy *errorpb.ServerIsBusy, req rpcpb.Request, cb func(rpcpb.ResponseBatch)) {
+	rsp := errorPbResp(uuid.NewV4().Bytes(), errorpb.Error{
+		Message:      errServerIsBusy.Error(),
+		ServerIsBusy: busy,
+	})
+	resp := rpcpb.Response{
+		ID:  req.ID,
+		PID: req.PID,
+	}
+	rsp.Responses = append(rsp.Responses, resp)
+	cb(rsp)
+}
+
+func respShardBusy(busy *errorpb.ShardBusy, req rpcpb.Request, cb func(rpcpb.ResponseBatch)) {
+	rsp := errorPbResp(uuid.NewV4().Bytes(), errorpb.Error{
+		Message:   errShardBusy.Error(),
+		ShardBusy: busy,
+	})
+	resp := rpcpb.Response{
+		ID:  req.ID,
+		PID: req.PID,
+	}
+	rsp.Responses = append(rsp.Responses, resp)
+	cb(rsp)
+}
+
 func respShardUnavailable(id uint64, req rpcpb.Request, cb func(responseBatch rpcpb.ResponseBatch)) {
 	rsp := errorPbResp(uuid.NewV4().Bytes(), errorpb.Error{
 		Message:          fmt.Sprintf("shard %d is unavailable", id),
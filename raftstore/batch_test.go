@@ -100,6 +100,31 @@ func TestCanAppendCmd(t *testing.T) {
 	}
 }
 
+func TestCanBatchesWithExpectedEpoch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	tests := []struct {
+		expected1 *metapb.ShardEpoch
+		expected2 *metapb.ShardEpoch
+		canAppend bool
+	}{
+		{nil, nil, true},
+		{nil, &metapb.ShardEpoch{}, false},
+		{&metapb.ShardEpoch{}, nil, false},
+		{&metapb.ShardEpoch{Generation: 1, ConfigVer: 1}, &metapb.ShardEpoch{Generation: 1, ConfigVer: 1}, true},
+		{&metapb.ShardEpoch{Generation: 1, ConfigVer: 1}, &metapb.ShardEpoch{Generation: 1, ConfigVer: 2}, false},
+	}
+
+	for _, tt := range tests {
+		cmd := &batch{
+			requestBatch: rpcpb.RequestBatch{
+				Requests: []rpcpb.Request{{ExpectedEpoch: tt.expected1}},
+			},
+		}
+		assert.Equal(t, tt.canAppend, cmd.canBatches(rpcpb.Request{ExpectedEpoch: tt.expected2}))
+	}
+}
+
 func TestBatchResp(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -33,6 +33,9 @@ var (
 	errLargeRaftEntrySize = errors.New("raft entry is too large")
 	errKeyNotInShard      = errors.New("key not in shard")
 	errStoreNotMatch      = errors.New("store not match")
+	errServerIsBusy       = errors.New("server is busy")
+	errShardBusy          = errors.New("shard is busy")
+	errEpochNotMatch      = errors.New("epoch not match")
 
 	infoStaleCMD  = new(errorpb.StaleCommand)
 	storeMismatch = new(errorpb.StoreMismatch)
@@ -41,6 +44,12 @@ var (
 	ErrTimeout = errors.New("exec timeout")
 	// ErrKeysNotInShard keys not in shard, request data needs to be split
 	ErrKeysNotInShard = errors.New("keys not in shard, request data needs to be split")
+	// ErrStoreNotStarted the store is not started yet
+	ErrStoreNotStarted = errors.New("store not started")
+	// ErrStoreStopped the store is stopped
+	ErrStoreStopped = errors.New("store stopped")
+	// ErrNoProphetLeader the prophet leader is unknown to the store
+	ErrNoProphetLeader = errors.New("no prophet leader")
 )
 
 type ShardLeaseMismatchErr struct {
@@ -87,6 +96,62 @@ func IsShardUnavailableErr(err error) bool {
 	return ok
 }
 
+// ShardBusyErr is an error indicates the writes are rejected by the flow control
+// of the shard, the client should backoff RetryAfter before retry
+type ShardBusyErr struct {
+	ShardID    uint64
+	RetryAfter time.Duration
+}
+
+// NewShardBusyErr returns a wrapped error that the shard is busy
+func NewShardBusyErr(busy *errorpb.ShardBusy) error {
+	return ShardBusyErr{
+		ShardID:    busy.ShardID,
+		RetryAfter: time.Duration(busy.RetryAfterMS) * time.Millisecond,
+	}
+}
+
+// String implements error interface
+func (err ShardBusyErr) Error() string {
+	return fmt.Sprintf("shard %d is busy, retry after %v", err.ShardID, err.RetryAfter)
+}
+
+// IsShardBusyErr checks if an error is ShardBusyErr
+func IsShardBusyErr(err error) bool {
+	_, ok := err.(ShardBusyErr)
+	return ok
+}
+
+// EpochNotMatchErr is an error indicates the write is rejected because the epoch
+// of the shard is not the epoch expected by the request, see
+// `client.WithExpectedEpoch`. The write is not retried.
+type EpochNotMatchErr struct {
+	ShardID  uint64
+	Expected metapb.ShardEpoch
+	Current  metapb.ShardEpoch
+}
+
+// NewEpochNotMatchErr returns a wrapped error that the epoch is not match
+func NewEpochNotMatchErr(err *errorpb.EpochNotMatch) error {
+	return EpochNotMatchErr{
+		ShardID:  err.ShardID,
+		Expected: err.Expected,
+		Current:  err.Current,
+	}
+}
+
+// String implements error interface
+func (err EpochNotMatchErr) Error() string {
+	return fmt.Sprintf("shard %d epoch not match, expected %+v, current %+v",
+		err.ShardID, err.Expected, err.Current)
+}
+
+// IsEpochNotMatchErr checks if an error is EpochNotMatchErr
+func IsEpochNotMatchErr(err error) bool {
+	_, ok := err.(EpochNotMatchErr)
+	return ok
+}
+
 func buildID(id []byte, resp *rpcpb.ResponseBatch) {
 	if resp.Header.IsEmpty() {
 		return
@@ -126,6 +191,21 @@ func errorStaleEpochResp(id []byte,
 	return resp
 }
 
+func newEpochNotMatchError(shard Shard, expected metapb.ShardEpoch) errorpb.Error {
+	return errorpb.Error{
+		Message: errEpochNotMatch.Error(),
+		EpochNotMatch: &errorpb.EpochNotMatch{
+			ShardID:  shard.ID,
+			Expected: expected,
+			Current:  shard.Epoch,
+		},
+	}
+}
+
+func errorEpochNotMatchResp(id []byte, shard Shard, expected metapb.ShardEpoch) rpcpb.ResponseBatch {
+	return errorPbResp(id, newEpochNotMatchError(shard, expected))
+}
+
 func errorLeaseMismatchResp(id []byte, shardID uint64, requestLease, heldLease *metapb.EpochLease) rpcpb.ResponseBatch {
 	resp := errorBaseResp(id)
 	resp.Header.Error.Message = "lease mismatch"
@@ -16,6 +16,7 @@ package raftstore
 import (
 	"sync"
 
+	"github.com/matrixorigin/matrixcube/pb/hlcpb"
 	"github.com/matrixorigin/matrixcube/util/buf"
 
 	"github.com/matrixorigin/matrixcube/storage"
@@ -35,7 +36,10 @@ type writeContext struct {
 	batch        storage.Batch
 	responses    [][]byte
 	writtenBytes uint64
+	writtenKeys  uint64
 	diffBytes    int64
+	// writtenKeysSet the written keys are reported by the data storage
+	writtenKeysSet bool
 }
 
 var _ storage.WriteContext = (*writeContext)(nil)
@@ -79,16 +83,23 @@ func (ctx *writeContext) SetWrittenBytes(value uint64) {
 	ctx.writtenBytes = value
 }
 
+func (ctx *writeContext) SetWrittenKeys(value uint64) {
+	ctx.writtenKeys = value
+	ctx.writtenKeysSet = true
+}
+
 func (ctx *writeContext) SetDiffBytes(value int64) {
 	ctx.diffBytes = value
 }
 
-func (ctx *writeContext) initialize(shard Shard, index uint64) {
+func (ctx *writeContext) initialize(shard Shard, index uint64, ts hlcpb.Timestamp) {
 	ctx.buf.Clear()
 	ctx.shard = shard
-	ctx.batch = storage.Batch{Index: index}
+	ctx.batch = storage.Batch{Index: index, Timestamp: ts}
 	ctx.responses = ctx.responses[:0]
 	ctx.writtenBytes = 0
+	ctx.writtenKeys = 0
+	ctx.writtenKeysSet = false
 	ctx.diffBytes = 0
 }
 
@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/matrixorigin/matrixcube/pb/hlcpb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/matrixorigin/matrixcube/storage/kv"
 	"github.com/matrixorigin/matrixcube/storage/kv/mem"
@@ -36,7 +37,7 @@ func TestWriteContextCanBeInitialized(t *testing.T) {
 	ctx := newWriteContext(base)
 	assert.False(t, ctx.hasRequest())
 
-	ctx.initialize(shard, 0)
+	ctx.initialize(shard, 0, hlcpb.Timestamp{})
 	assert.Empty(t, ctx.responses)
 	assert.Equal(t, shard, ctx.shard)
 }
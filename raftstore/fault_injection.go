@@ -0,0 +1,267 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxInjectedApplyDelay the max delay injected before applying the committed
+	// entries of a shard, the worker driving the replica is blocked by the delay.
+	maxInjectedApplyDelay = 10 * time.Second
+)
+
+var (
+	// ErrFaultInjectionDisabled the fault injection is not enabled by
+	// `Config.EnableFaultInjection`
+	ErrFaultInjectionDisabled = errors.New("fault injection disabled")
+	// ErrInvalidApplyDelay the injected apply delay is negative or exceeds the
+	// max delay
+	ErrInvalidApplyDelay = errors.New("invalid apply delay")
+
+	errDroppedByFaultInjection = errors.New("dropped by fault injection")
+)
+
+// FaultInjector injects failures into a live store for the resilience drills,
+// all the methods return ErrFaultInjectionDisabled unless the fault injection
+// is enabled by `Config.EnableFaultInjection`. The injected faults are kept in
+// memory only and are cleared after the store restarted.
+type FaultInjector interface {
+	// DropRaftMessages drops or stops dropping all the raft messages sent to the
+	// replicas on the specified store.
+	DropRaftMessages(storeID uint64, drop bool) error
+	// DelayApply delays applying the committed entries of the specified shard,
+	// zero delay removes the fault.
+	DelayApply(shardID uint64, delay time.Duration) error
+	// FailNextSnapshot drops the next snapshot received by the replica of the
+	// specified shard as if it's corrupted, the leader sends the snapshot again.
+	FailNextSnapshot(shardID uint64) error
+	// RejectShardGroup rejects or stops rejecting the requests of the shards in
+	// the specified shard group with the server is busy error.
+	RejectShardGroup(group uint64, reject bool) error
+	// Faults returns all the injected faults.
+	Faults() (Faults, error)
+	// Reset removes all the injected faults.
+	Reset() error
+}
+
+// Faults the injected faults of a store
+type Faults struct {
+	DroppedStores     []uint64                 `json:"dropped-stores"`
+	ApplyDelays       map[uint64]time.Duration `json:"apply-delays"`
+	FailNextSnapshots []uint64                 `json:"fail-next-snapshots"`
+	RejectedGroups    []uint64                 `json:"rejected-groups"`
+}
+
+type faultInjector struct {
+	enabled bool
+	logger  *zap.Logger
+	// active whether any fault is injected, checked before acquiring the lock
+	active uint32
+
+	mu struct {
+		sync.RWMutex
+		droppedStores     map[uint64]struct{}
+		applyDelays       map[uint64]time.Duration
+		failNextSnapshots map[uint64]struct{}
+		rejectedGroups    map[uint64]struct{}
+	}
+}
+
+var _ FaultInjector = (*faultInjector)(nil)
+
+func newFaultInjector(enabled bool, logger *zap.Logger) *faultInjector {
+	fi := &faultInjector{enabled: enabled, logger: logger}
+	fi.resetLocked()
+	return fi
+}
+
+func (fi *faultInjector) DropRaftMessages(storeID uint64, drop bool) error {
+	return fi.update(func() {
+		if drop {
+			fi.mu.droppedStores[storeID] = struct{}{}
+		} else {
+			delete(fi.mu.droppedStores, storeID)
+		}
+	}, zap.String("fault", "drop-raft-messages"),
+		zap.Uint64("to-store", storeID),
+		zap.Bool("enable", drop))
+}
+
+func (fi *faultInjector) DelayApply(shardID uint64, delay time.Duration) error {
+	if delay < 0 || delay > maxInjectedApplyDelay {
+		return ErrInvalidApplyDelay
+	}
+	return fi.update(func() {
+		if delay > 0 {
+			fi.mu.applyDelays[shardID] = delay
+		} else {
+			delete(fi.mu.applyDelays, shardID)
+		}
+	}, zap.String("fault", "delay-apply"),
+		zap.Uint64("shard", shardID),
+		zap.Duration("delay", delay))
+}
+
+func (fi *faultInjector) FailNextSnapshot(shardID uint64) error {
+	return fi.update(func() {
+		fi.mu.failNextSnapshots[shardID] = struct{}{}
+	}, zap.String("fault", "fail-next-snapshot"),
+		zap.Uint64("shard", shardID))
+}
+
+func (fi *faultInjector) RejectShardGroup(group uint64, reject bool) error {
+	return fi.update(func() {
+		if reject {
+			fi.mu.rejectedGroups[group] = struct{}{}
+		} else {
+			delete(fi.mu.rejectedGroups, group)
+		}
+	}, zap.String("fault", "reject-shard-group"),
+		zap.Uint64("group", group),
+		zap.Bool("enable", reject))
+}
+
+func (fi *faultInjector) Faults() (Faults, error) {
+	if !fi.enabled {
+		return Faults{}, ErrFaultInjectionDisabled
+	}
+
+	fi.mu.RLock()
+	defer fi.mu.RUnlock()
+	faults := Faults{
+		DroppedStores:     sortedIDs(fi.mu.droppedStores),
+		ApplyDelays:       make(map[uint64]time.Duration, len(fi.mu.applyDelays)),
+		FailNextSnapshots: sortedIDs(fi.mu.failNextSnapshots),
+		RejectedGroups:    sortedIDs(fi.mu.rejectedGroups),
+	}
+	for id, delay := range fi.mu.applyDelays {
+		faults.ApplyDelays[id] = delay
+	}
+	return faults, nil
+}
+
+func (fi *faultInjector) Reset() error {
+	return fi.update(fi.resetLocked, zap.String("fault", "reset"))
+}
+
+func (fi *faultInjector) resetLocked() {
+	fi.mu.droppedStores = make(map[uint64]struct{})
+	fi.mu.applyDelays = make(map[uint64]time.Duration)
+	fi.mu.failNextSnapshots = make(map[uint64]struct{})
+	fi.mu.rejectedGroups = make(map[uint64]struct{})
+}
+
+func (fi *faultInjector) update(fn func(), fields ...zap.Field) error {
+	if !fi.enabled {
+		return ErrFaultInjectionDisabled
+	}
+
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	fn()
+	fi.updateActiveLocked()
+	fi.logger.Warn("fault injected", fields...)
+	return nil
+}
+
+func (fi *faultInjector) updateActiveLocked() {
+	active := uint32(0)
+	if len(fi.mu.droppedStores) > 0 ||
+		len(fi.mu.applyDelays) > 0 ||
+		len(fi.mu.failNextSnapshots) > 0 ||
+		len(fi.mu.rejectedGroups) > 0 {
+		active = 1
+	}
+	atomic.StoreUint32(&fi.active, active)
+}
+
+// isActive returns true if any fault is injected, the nil faultInjector of the
+// stores created in the tests is never active.
+func (fi *faultInjector) isActive() bool {
+	return fi != nil && atomic.LoadUint32(&fi.active) == 1
+}
+
+// shouldDropMessage returns true if the raft messages to the store should be
+// dropped
+func (fi *faultInjector) shouldDropMessage(storeID uint64) bool {
+	if !fi.isActive() {
+		return false
+	}
+
+	fi.mu.RLock()
+	defer fi.mu.RUnlock()
+	_, ok := fi.mu.droppedStores[storeID]
+	return ok
+}
+
+// getApplyDelay returns the delay before applying the committed entries of
+// the shard
+func (fi *faultInjector) getApplyDelay(shardID uint64) time.Duration {
+	if !fi.isActive() {
+		return 0
+	}
+
+	fi.mu.RLock()
+	defer fi.mu.RUnlock()
+	return fi.mu.applyDelays[shardID]
+}
+
+// shouldFailSnapshot returns true if the received snapshot of the shard should
+// be dropped, the fault is removed once returned true.
+func (fi *faultInjector) shouldFailSnapshot(shardID uint64) bool {
+	if !fi.isActive() {
+		return false
+	}
+
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	if _, ok := fi.mu.failNextSnapshots[shardID]; !ok {
+		return false
+	}
+	delete(fi.mu.failNextSnapshots, shardID)
+	fi.updateActiveLocked()
+	return true
+}
+
+// shouldRejectGroup returns true if the requests of the shards in the group
+// should be rejected
+func (fi *faultInjector) shouldRejectGroup(group uint64) bool {
+	if !fi.isActive() {
+		return false
+	}
+
+	fi.mu.RLock()
+	defer fi.mu.RUnlock()
+	_, ok := fi.mu.rejectedGroups[group]
+	return ok
+}
+
+func sortedIDs(ids map[uint64]struct{}) []uint64 {
+	values := make([]uint64, 0, len(ids))
+	for id := range ids {
+		values = append(values, id)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i] < values[j]
+	})
+	return values
+}
@@ -0,0 +1,129 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// FaultInjectionPath the http path of the fault injection admin API
+	FaultInjectionPath = "/debug/fault-injection"
+
+	// FaultDropRaftMessages drops the raft messages to the `store`
+	FaultDropRaftMessages = "drop-raft-messages"
+	// FaultDelayApply delays the applies of the `shard` by the `delay`
+	FaultDelayApply = "delay-apply"
+	// FaultFailNextSnapshot fails the next received snapshot of the `shard`
+	FaultFailNextSnapshot = "fail-next-snapshot"
+	// FaultRejectShardGroup rejects the requests of the shard `group` as busy
+	FaultRejectShardGroup = "reject-shard-group"
+)
+
+// NewFaultInjectionHandler returns the http handler of the fault injection
+// admin API. GET returns the injected faults in JSON, POST injects the fault
+// specified by the query, e.g. `?fault=delay-apply&shard=1&delay=500ms`, and
+// DELETE removes the fault specified by the query or all the faults if the
+// query is empty. 403 is responded if the fault injection is disabled.
+func NewFaultInjectionHandler(fi FaultInjector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		switch r.Method {
+		case http.MethodGet:
+			var faults Faults
+			if faults, err = fi.Faults(); err == nil {
+				w.Header().Set("Content-Type", "application/json")
+				err = json.NewEncoder(w).Encode(faults)
+			}
+		case http.MethodPost:
+			err = injectFault(fi, r, true)
+		case http.MethodDelete:
+			if len(r.URL.Query()) == 0 {
+				err = fi.Reset()
+			} else {
+				err = injectFault(fi, r, false)
+			}
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if errors.Is(err, ErrFaultInjectionDisabled) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("ok"))
+		}
+	})
+}
+
+func injectFault(fi FaultInjector, r *http.Request, enable bool) error {
+	query := r.URL.Query()
+	id := func(name string) (uint64, error) {
+		v, err := strconv.ParseUint(query.Get(name), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		return v, nil
+	}
+
+	switch fault := query.Get("fault"); fault {
+	case FaultDropRaftMessages:
+		storeID, err := id("store")
+		if err != nil {
+			return err
+		}
+		return fi.DropRaftMessages(storeID, enable)
+	case FaultDelayApply:
+		shardID, err := id("shard")
+		if err != nil {
+			return err
+		}
+		delay := time.Duration(0)
+		if enable {
+			if delay, err = time.ParseDuration(query.Get("delay")); err != nil {
+				return fmt.Errorf("invalid delay: %w", err)
+			}
+		}
+		return fi.DelayApply(shardID, delay)
+	case FaultFailNextSnapshot:
+		if !enable {
+			return fmt.Errorf("fault %s can not be removed", fault)
+		}
+		shardID, err := id("shard")
+		if err != nil {
+			return err
+		}
+		return fi.FailNextSnapshot(shardID)
+	case FaultRejectShardGroup:
+		group, err := id("group")
+		if err != nil {
+			return err
+		}
+		return fi.RejectShardGroup(group, enable)
+	default:
+		return fmt.Errorf("unknown fault %q", fault)
+	}
+}
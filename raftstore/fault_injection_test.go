@@ -0,0 +1,171 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFaultInjectionDisabled(t *testing.T) {
+	fi := newFaultInjector(false, zap.L())
+	assert.ErrorIs(t, fi.DropRaftMessages(1, true), ErrFaultInjectionDisabled)
+	assert.ErrorIs(t, fi.DelayApply(1, time.Second), ErrFaultInjectionDisabled)
+	assert.ErrorIs(t, fi.FailNextSnapshot(1), ErrFaultInjectionDisabled)
+	assert.ErrorIs(t, fi.RejectShardGroup(1, true), ErrFaultInjectionDisabled)
+	assert.ErrorIs(t, fi.Reset(), ErrFaultInjectionDisabled)
+	_, err := fi.Faults()
+	assert.ErrorIs(t, err, ErrFaultInjectionDisabled)
+	assert.False(t, fi.isActive())
+
+	var nilFI *faultInjector
+	assert.False(t, nilFI.shouldDropMessage(1))
+	assert.Equal(t, time.Duration(0), nilFI.getApplyDelay(1))
+	assert.False(t, nilFI.shouldFailSnapshot(1))
+	assert.False(t, nilFI.shouldRejectGroup(1))
+}
+
+func TestFaultInjection(t *testing.T) {
+	fi := newFaultInjector(true, zap.L())
+	assert.False(t, fi.isActive())
+
+	assert.NoError(t, fi.DropRaftMessages(2, true))
+	assert.True(t, fi.isActive())
+	assert.True(t, fi.shouldDropMessage(2))
+	assert.False(t, fi.shouldDropMessage(3))
+	assert.NoError(t, fi.DropRaftMessages(2, false))
+	assert.False(t, fi.shouldDropMessage(2))
+	assert.False(t, fi.isActive())
+
+	assert.ErrorIs(t, fi.DelayApply(1, -time.Second), ErrInvalidApplyDelay)
+	assert.ErrorIs(t, fi.DelayApply(1, maxInjectedApplyDelay+1), ErrInvalidApplyDelay)
+	assert.NoError(t, fi.DelayApply(1, time.Second))
+	assert.Equal(t, time.Second, fi.getApplyDelay(1))
+	assert.Equal(t, time.Duration(0), fi.getApplyDelay(2))
+	assert.NoError(t, fi.DelayApply(1, 0))
+	assert.Equal(t, time.Duration(0), fi.getApplyDelay(1))
+
+	// only the next snapshot is failed
+	assert.NoError(t, fi.FailNextSnapshot(1))
+	assert.False(t, fi.shouldFailSnapshot(2))
+	assert.True(t, fi.shouldFailSnapshot(1))
+	assert.False(t, fi.shouldFailSnapshot(1))
+	assert.False(t, fi.isActive())
+
+	assert.NoError(t, fi.RejectShardGroup(1, true))
+	assert.True(t, fi.shouldRejectGroup(1))
+	assert.False(t, fi.shouldRejectGroup(0))
+
+	assert.NoError(t, fi.DropRaftMessages(3, true))
+	assert.NoError(t, fi.DelayApply(2, time.Millisecond))
+	assert.NoError(t, fi.FailNextSnapshot(4))
+	faults, err := fi.Faults()
+	assert.NoError(t, err)
+	assert.Equal(t, Faults{
+		DroppedStores:     []uint64{3},
+		ApplyDelays:       map[uint64]time.Duration{2: time.Millisecond},
+		FailNextSnapshots: []uint64{4},
+		RejectedGroups:    []uint64{1},
+	}, faults)
+
+	assert.NoError(t, fi.Reset())
+	assert.False(t, fi.isActive())
+	assert.False(t, fi.shouldDropMessage(3))
+	assert.False(t, fi.shouldRejectGroup(1))
+}
+
+func TestFaultInjectionHandler(t *testing.T) {
+	do := func(h http.Handler, method, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, FaultInjectionPath+query, nil))
+		return w
+	}
+
+	h := NewFaultInjectionHandler(newFaultInjector(false, zap.L()))
+	assert.Equal(t, http.StatusForbidden, do(h, http.MethodGet, "").Code)
+	assert.Equal(t, http.StatusForbidden, do(h, http.MethodPost, "?fault=fail-next-snapshot&shard=1").Code)
+
+	fi := newFaultInjector(true, zap.L())
+	h = NewFaultInjectionHandler(fi)
+	assert.Equal(t, http.StatusOK, do(h, http.MethodPost, "?fault=drop-raft-messages&store=2").Code)
+	assert.Equal(t, http.StatusOK, do(h, http.MethodPost, "?fault=delay-apply&shard=1&delay=100ms").Code)
+	assert.Equal(t, http.StatusOK, do(h, http.MethodPost, "?fault=fail-next-snapshot&shard=3").Code)
+	assert.Equal(t, http.StatusOK, do(h, http.MethodPost, "?fault=reject-shard-group&group=4").Code)
+	assert.Equal(t, http.StatusBadRequest, do(h, http.MethodPost, "?fault=unknown").Code)
+	assert.Equal(t, http.StatusBadRequest, do(h, http.MethodPost, "?fault=delay-apply&shard=1").Code)
+	assert.Equal(t, http.StatusBadRequest, do(h, http.MethodPost, "?fault=drop-raft-messages").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, do(h, http.MethodPut, "").Code)
+
+	w := do(h, http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var faults Faults
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &faults))
+	assert.Equal(t, Faults{
+		DroppedStores:     []uint64{2},
+		ApplyDelays:       map[uint64]time.Duration{1: 100 * time.Millisecond},
+		FailNextSnapshots: []uint64{3},
+		RejectedGroups:    []uint64{4},
+	}, faults)
+
+	assert.Equal(t, http.StatusOK, do(h, http.MethodDelete, "?fault=drop-raft-messages&store=2").Code)
+	assert.Equal(t, http.StatusOK, do(h, http.MethodDelete, "?fault=delay-apply&shard=1").Code)
+	assert.False(t, fi.shouldDropMessage(2))
+	assert.Equal(t, time.Duration(0), fi.getApplyDelay(1))
+
+	assert.Equal(t, http.StatusOK, do(h, http.MethodDelete, "").Code)
+	assert.False(t, fi.isActive())
+}
+
+func TestFaultInjectionOnStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode.")
+		return
+	}
+
+	defer leaktest.AfterTest(t)()
+	c := NewSingleTestClusterStore(t,
+		WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+			cfg.EnableFaultInjection = true
+		}))
+	c.Start()
+	defer c.Stop()
+
+	c.WaitLeadersByCount(1, testWaitTimeout)
+	kv := c.CreateTestKVClient(0)
+	defer kv.Close()
+	fi := c.GetStore(0).FaultInjector()
+
+	// the requests are rejected as busy and retried until timeout
+	shard := c.GetShardByIndex(0, 0)
+	require.NoError(t, fi.RejectShardGroup(shard.Group, true))
+	assert.Error(t, kv.Set("k1", "v1", time.Second))
+	require.NoError(t, fi.RejectShardGroup(shard.Group, false))
+	assert.NoError(t, kv.Set("k1", "v1", testWaitTimeout))
+
+	delay := 200 * time.Millisecond
+	require.NoError(t, fi.DelayApply(shard.ID, delay))
+	start := time.Now()
+	assert.NoError(t, kv.Set("k2", "v2", testWaitTimeout))
+	assert.True(t, time.Since(start) >= delay)
+	require.NoError(t, fi.Reset())
+}
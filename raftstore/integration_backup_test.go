@@ -0,0 +1,79 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/matrixorigin/matrixcube/backup"
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+func TestBackupAndRestoreToFreshCluster(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode.")
+		return
+	}
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	timeout := 10 * time.Second
+	m := backup.NewManager(backup.Options{FS: vfs.NewMemFS(), Dir: "/backups"})
+
+	c := NewSingleTestClusterStore(t, WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+		cfg.Customize.CustomInitShardsFactory = func() []metapb.Shard {
+			return []metapb.Shard{{End: []byte("k5")}, {Start: []byte("k5")}}
+		}
+	}))
+	c.Start()
+	c.WaitLeadersByCount(2, timeout)
+	kv := c.CreateTestKVClient(0)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, kv.Set(fmt.Sprintf("k%d", i), fmt.Sprintf("v%d", i), timeout))
+	}
+	kv.Close()
+	manifest, err := m.Backup(ctx, "full", backup.BackupOptions{}, c.GetStore(0))
+	c.Stop()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(manifest.Shards))
+
+	c = NewSingleTestClusterStore(t,
+		WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+			cfg.Customize.CustomInitShardsFactory = manifest.InitShards
+		}),
+		WithTestClusterStoreFactory(func(node int, cfg *config.Config) Store {
+			_, err := m.Restore(ctx, "full", cfg.Storage.DataStorageFactory)
+			require.NoError(t, err)
+			return NewStore(cfg)
+		}))
+	c.Start()
+	defer c.Stop()
+	c.WaitLeadersByCount(2, timeout)
+	kv = c.CreateTestKVClient(0)
+	defer kv.Close()
+	for i := 0; i < 10; i++ {
+		v, err := kv.Get(fmt.Sprintf("k%d", i), timeout)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("v%d", i), v)
+	}
+}
@@ -14,16 +14,24 @@
 package raftstore
 
 import (
+	"bytes"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/fagongzi/util/protoc"
+	"github.com/matrixorigin/matrixcube/aware"
 	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/pb/hlcpb"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
 	"github.com/matrixorigin/matrixcube/util/leaktest"
 	"github.com/matrixorigin/matrixcube/util/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/raft/v3/raftpb"
 )
 
 func TestSingleClusterReadAndWrite(t *testing.T) {
@@ -43,6 +51,213 @@ func TestSingleClusterReadAndWrite(t *testing.T) {
 	assert.Equal(t, "v1", v)
 }
 
+func TestSingleClusterReadWithReadIndexBatching(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := NewSingleTestClusterStore(t,
+		WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+			cfg.Raft.ReadIndexBatchTicks = 2
+		}))
+	c.Start()
+	defer c.Stop()
+
+	c.WaitShardByCountPerNode(1, testWaitTimeout)
+	c.CheckShardCount(1)
+
+	kv := c.CreateTestKVClient(0)
+	defer kv.Close()
+	assert.NoError(t, kv.Set("k1", "v1", testWaitTimeout))
+	v, err := kv.Get("k1", testWaitTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", v)
+}
+
+func TestSingleClusterWriteWithTimestamp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var mu sync.Mutex
+	var timestamps []hlcpb.Timestamp
+	c := NewSingleTestClusterStore(t,
+		WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+			cfg.Customize.CustomApplyResultHandler = func(result aware.ApplyResult) {
+				mu.Lock()
+				defer mu.Unlock()
+				timestamps = append(timestamps, result.Timestamp)
+			}
+		}))
+	c.Start()
+	defer c.Stop()
+
+	c.WaitShardByCountPerNode(1, testWaitTimeout)
+
+	kv := c.CreateTestKVClient(0)
+	defer kv.Close()
+	assert.NoError(t, kv.Set("k1", "v1", testWaitTimeout))
+	assert.NoError(t, kv.Set("k2", "v2", testWaitTimeout))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, len(timestamps))
+	assert.False(t, timestamps[0].IsEmpty())
+	assert.True(t, timestamps[0].Less(timestamps[1]))
+}
+
+func TestSingleClusterWALHook(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var mu sync.Mutex
+	var writes int
+	c := NewSingleTestClusterStore(t,
+		WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+			cfg.Customize.CustomWALHookFactory = func(group uint64) *aware.WALHook {
+				return &aware.WALHook{Async: true, Ship: func(entry aware.WALEntry) error {
+					if entry.Type != raftpb.EntryNormal {
+						return nil
+					}
+					var rb rpcpb.RequestBatch
+					protoc.MustUnmarshal(&rb, entry.Data)
+					mu.Lock()
+					defer mu.Unlock()
+					if !rb.IsAdmin() {
+						writes += len(rb.Requests)
+					}
+					return nil
+				}}
+			}
+		}))
+	c.Start()
+	defer c.Stop()
+
+	c.WaitShardByCountPerNode(1, testWaitTimeout)
+
+	kv := c.CreateTestKVClient(0)
+	defer kv.Close()
+	assert.NoError(t, kv.Set("k1", "v1", testWaitTimeout))
+	assert.NoError(t, kv.Set("k2", "v2", testWaitTimeout))
+
+	for i := 0; i < 100; i++ {
+		mu.Lock()
+		n := writes
+		mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, writes)
+}
+
+func TestSingleClusterShardBuckets(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := NewSingleTestClusterStore(t,
+		WithTestClusterShardBuckets(4, time.Millisecond*100))
+	c.Start()
+	defer c.Stop()
+
+	c.WaitShardByCountPerNode(1, testWaitTimeout)
+
+	kv := c.CreateTestKVClient(0)
+	defer kv.Close()
+	for i := 0; i < 16; i++ {
+		assert.NoError(t, kv.Set(fmt.Sprintf("k%02d", i), "v", testWaitTimeout))
+	}
+
+	// k15 is the hottest key, it's in the last bucket once the boundaries
+	// are sampled
+	timeout := time.After(testWaitTimeout)
+	for {
+		assert.NoError(t, kv.Set("k15", "v", testWaitTimeout))
+		buckets, err := c.GetProphet().GetClient().GetHotBuckets(0, 1)
+		assert.NoError(t, err)
+		if len(buckets) == 1 && len(buckets[0].Start) > 0 {
+			assert.True(t, bytes.Compare(buckets[0].Start, []byte("k15")) <= 0)
+			assert.Empty(t, buckets[0].End)
+			assert.True(t, buckets[0].WrittenKeys > 0)
+			return
+		}
+
+		select {
+		case <-timeout:
+			assert.FailNow(t, "timeout")
+		case <-time.After(time.Millisecond * 100):
+		}
+	}
+}
+
+func TestSingleClusterReadCache(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := NewSingleTestClusterStore(t,
+		WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+			cfg.ReadCache.Enable = true
+			cfg.Customize.CustomReadCacheKeyRangeFunc = func(req storage.Request) ([]byte, []byte, bool) {
+				switch rpcpb.InternalCmd(req.CmdType) {
+				case rpcpb.CmdKVGet, rpcpb.CmdKVSet:
+					return req.Key, nil, true
+				}
+				return nil, nil, false
+			}
+		}))
+	c.Start()
+	defer c.Stop()
+
+	c.WaitShardByCountPerNode(1, testWaitTimeout)
+	c.WaitLeadersByCount(1, testWaitTimeout)
+	pr := c.GetStore(0).(*store).getReplica(c.GetShardByIndex(0, 0).ID, true)
+	require.NotNil(t, pr)
+	require.NotNil(t, pr.sm.readCache)
+
+	kv := c.CreateTestKVClient(0)
+	defer kv.Close()
+	assert.NoError(t, kv.Set("k1", "v1", testWaitTimeout))
+	for i := 0; i < 2; i++ {
+		v, err := kv.Get("k1", testWaitTimeout)
+		assert.NoError(t, err)
+		assert.Equal(t, "v1", v)
+	}
+	assert.Equal(t, 1, pr.sm.readCache.len())
+
+	// the cached response is invalidated by the write
+	assert.NoError(t, kv.Set("k1", "v2", testWaitTimeout))
+	assert.Equal(t, 0, pr.sm.readCache.len())
+	v, err := kv.Get("k1", testWaitTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", v)
+}
+
+func TestClusterReadWithReadIndexBatching(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode.")
+		return
+	}
+
+	defer leaktest.AfterTest(t)()
+
+	// the reads are confirmed by the heartbeats of the ticks
+	c := NewTestClusterStore(t,
+		WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+			cfg.Raft.ReadIndexBatchTicks = 2
+		}))
+	c.Start()
+	defer c.Stop()
+
+	c.WaitShardByCountPerNode(1, testWaitTimeout)
+	c.WaitLeadersByCount(1, testWaitTimeout)
+
+	kv := c.CreateTestKVClient(0)
+	defer kv.Close()
+	for i := 0; i < 3; i++ {
+		key := fmt.Sprintf("k-%d", i)
+		assert.NoError(t, kv.Set(key, key, testWaitTimeout))
+		v, err := kv.Get(key, testWaitTimeout)
+		assert.NoError(t, err)
+		assert.Equal(t, key, v)
+	}
+}
+
 func TestAdvertiseAddr(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping in short mode.")
@@ -201,6 +416,143 @@ func TestReadAndWriteAndRestart(t *testing.T) {
 	fn(3)
 }
 
+func TestReadAndWriteAndRestartWithDataWALDisabled(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode.")
+		return
+	}
+
+	defer leaktest.AfterTest(t)()
+
+	c := NewTestClusterStore(t,
+		DiskTestCluster,
+		WithDataWALDisabledGroups(0))
+	c.Start()
+	defer c.Stop()
+
+	c.WaitShardByCountPerNode(1, testWaitTimeout)
+	c.WaitLeadersByCount(1, testWaitTimeout)
+
+	kv := c.CreateTestKVClient(0)
+	defer kv.Close()
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, kv.Set(fmt.Sprintf("k-%d", i), fmt.Sprintf("v-%d", i), testWaitTimeout))
+	}
+
+	// the writes which are not flushed are lost, they are applied again from
+	// the raft log
+	c.Restart()
+	c.WaitShardByCountPerNode(1, testWaitTimeout)
+	c.WaitLeadersByCount(1, testWaitTimeout)
+
+	kv2 := c.CreateTestKVClient(0)
+	defer kv2.Close()
+
+	for i := 0; i < 10; i++ {
+		v, err := kv2.Get(fmt.Sprintf("k-%d", i), testWaitTimeout)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("v-%d", i), v)
+	}
+}
+
+func TestRestartWithLazyOpenReplica(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode.")
+		return
+	}
+
+	defer leaktest.AfterTest(t)()
+
+	c := NewTestClusterStore(t,
+		WithTestClusterNodeCount(3),
+		DiskTestCluster,
+		WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+			cfg.Replication.LazyOpenReplica = true
+		}))
+
+	c.Start()
+	defer c.Stop()
+
+	c.WaitShardByCountPerNode(1, testWaitTimeout)
+	c.WaitLeadersByCount(1, testWaitTimeout)
+	shard := c.GetShardByIndex(0, 0)
+	leaderReplicaID := findReplica(shard, c.GetShardLeaderStore(shard.ID).Meta().ID).ID
+	c.WaitShardLeaseChangedTo(shard.ID, &metapb.EpochLease{Epoch: 1, ReplicaID: leaderReplicaID}, testWaitTimeout)
+
+	kv := c.CreateTestKVClient(0)
+	defer kv.Close()
+	assert.NoError(t, kv.Set("k", "v", testWaitTimeout))
+
+	c.Restart()
+	// followers are opened by the raft messages from the leader
+	c.WaitShardByCountPerNode(1, testWaitTimeout)
+	c.WaitLeadersByCount(1, testWaitTimeout)
+
+	kv2 := c.CreateTestKVClient(0)
+	defer kv2.Close()
+	v, err := kv2.Get("k", testWaitTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, "v", v)
+}
+
+func TestRestartWithLazyOpenReplicaAndLeaderStoreDown(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode.")
+		return
+	}
+
+	defer leaktest.AfterTest(t)()
+
+	down := -1
+	c := NewTestClusterStore(t,
+		WithTestClusterNodeCount(3),
+		DiskTestCluster,
+		WithTestClusterNodeStartFunc(func(node int, store Store) {
+			if node != down {
+				store.Start()
+			}
+		}),
+		WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+			cfg.Replication.LazyOpenReplica = true
+		}))
+
+	c.Start()
+	defer c.Stop()
+
+	c.WaitShardByCountPerNode(1, testWaitTimeout)
+	c.WaitLeadersByCount(1, testWaitTimeout)
+	shard := c.GetShardByIndex(0, 0)
+	leaderStoreID := c.GetShardLeaderStore(shard.ID).Meta().ID
+	leaderReplicaID := findReplica(shard, leaderStoreID).ID
+	c.WaitShardLeaseChangedTo(shard.ID, &metapb.EpochLease{Epoch: 1, ReplicaID: leaderReplicaID}, testWaitTimeout)
+	for i := 0; i < 3; i++ {
+		if c.GetStore(i).Meta().ID == leaderStoreID {
+			down = i
+		}
+	}
+	follower := (down + 1) % 3
+
+	kv := c.CreateTestKVClient(follower)
+	defer kv.Close()
+	assert.NoError(t, kv.Set("k", "v", testWaitTimeout))
+
+	// the store of the lease holder stays down after restart, the lazy replicas
+	// are opened since prophet reports the shard has no leader
+	c.Restart()
+	c.WaitLeadersByCount(1, testWaitTimeout)
+
+	kv2 := c.CreateTestKVClient(follower)
+	defer kv2.Close()
+	v, err := kv2.Get("k", testWaitTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, "v", v)
+
+	node := down
+	down = -1
+	c.StartNode(node)
+}
+
 func TestAddShardLabel(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping in short mode.")
@@ -14,13 +14,16 @@
 package raftstore
 
 import (
+	"fmt"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"testing"
+	"time"
 
 	"github.com/matrixorigin/matrixcube/config"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/matrixorigin/matrixcube/util/leaktest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestScheduleReplicasWithRules(t *testing.T) {
@@ -58,3 +61,173 @@ func TestScheduleReplicasWithRules(t *testing.T) {
 	assert.NoError(t, err)
 	c.WaitShardByCounts([]int{2, 2, 1}, testWaitTimeout)
 }
+
+func TestSystemGroupReplicas(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode.")
+		return
+	}
+
+	defer leaktest.AfterTest(t)()
+	c := NewTestClusterStore(t,
+		WithAppendTestClusterAdjustConfigFunc(func(i int, cfg *config.Config) {
+			cfg.SystemGroup.Enable = true
+			cfg.SystemGroup.MaxReplicas = 3
+		}))
+
+	c.Start()
+	defer c.Stop()
+	c.WaitVoterReplicaByCountsAndShardGroup([]int{1, 1, 1}, config.SystemGroup, testWaitTimeout)
+	c.WaitVoterReplicaByCountsAndShardGroup([]int{1, 1, 1}, 0, testWaitTimeout)
+
+	_, err := c.GetStore(0).ReserveSystemKeyspace(JobKeyspace)
+	assert.ErrorIs(t, err, ErrKeyspaceReserved)
+	ks, err := c.GetStore(0).ReserveSystemKeyspace("test")
+	assert.NoError(t, err)
+
+	kv := c.CreateTestKVClientWithAdjust(0, func(req *rpcpb.Request) {
+		req.Group = config.SystemGroup
+	})
+	defer kv.Close()
+	key := string(ks.Key([]byte("k1")))
+	assert.NoError(t, kv.Set(key, "v1", testWaitTimeout))
+	v, err := kv.Get(key, testWaitTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", v)
+}
+
+func TestFollowerReadWithLeaseRead(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode.")
+		return
+	}
+
+	defer leaktest.AfterTest(t)()
+	c := NewTestClusterStore(t,
+		WithAppendTestClusterAdjustConfigFunc(func(i int, cfg *config.Config) {
+			cfg.Raft.EnableLeaseRead = true
+		}))
+
+	c.Start()
+	defer c.Stop()
+	c.WaitVoterReplicaByCountsAndShardGroup([]int{1, 1, 1}, 0, testWaitTimeout)
+
+	kv := c.CreateTestKVClientWithAdjust(0, func(req *rpcpb.Request) {
+		if req.Type == rpcpb.Read {
+			req.ReplicaSelectPolicy = rpcpb.SelectRandom
+		}
+	})
+	defer kv.Close()
+	for i := 0; i < 10; i++ {
+		value := fmt.Sprintf("v%d", i)
+		assert.NoError(t, kv.Set("k1", value, testWaitTimeout))
+		// the follower waits for the write applied before the read
+		v, err := kv.Get("k1", testWaitTimeout)
+		assert.NoError(t, err)
+		assert.Equal(t, value, v)
+	}
+}
+
+func TestLeaseReadWithSkewedClock(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode.")
+		return
+	}
+
+	defer leaktest.AfterTest(t)()
+	c := NewTestClusterStore(t,
+		WithAppendTestClusterAdjustConfigFunc(func(i int, cfg *config.Config) {
+			cfg.Raft.EnableLeaseRead = true
+		}))
+	for i := 0; i < 3; i++ {
+		// any measured offset makes the clock skewed
+		c.GetStore(i).(*store).clock = newClockMonitor(-time.Hour)
+	}
+
+	c.Start()
+	defer c.Stop()
+	c.WaitVoterReplicaByCountsAndShardGroup([]int{1, 1, 1}, 0, testWaitTimeout)
+	for i := 0; i < 3; i++ {
+		s := c.GetStore(i).(*store)
+		require.Eventually(t, s.clock.isSkewed, testWaitTimeout, time.Millisecond*10)
+	}
+
+	// the reads fall back to the read index confirmed by the quorum
+	kv := c.CreateTestKVClient(0)
+	defer kv.Close()
+	for i := 0; i < 10; i++ {
+		value := fmt.Sprintf("v%d", i)
+		assert.NoError(t, kv.Set("k1", value, testWaitTimeout))
+		v, err := kv.Get("k1", testWaitTimeout)
+		assert.NoError(t, err)
+		assert.Equal(t, value, v)
+	}
+}
+
+func TestLearnerRead(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode.")
+		return
+	}
+
+	defer leaktest.AfterTest(t)()
+	c := NewTestClusterStore(t,
+		WithAppendTestClusterAdjustConfigFunc(func(i int, cfg *config.Config) {
+			cfg.Customize.CustomInitShardsFactory = func() []Shard {
+				return []Shard{{Start: []byte("a"), End: []byte("b")}}
+			}
+		}))
+
+	c.Start()
+	defer c.Stop()
+	c.WaitShardByCountPerNode(1, testWaitTimeout)
+
+	// 2 voters and 1 learner
+	client := c.GetProphet().GetClient()
+	assert.NoError(t, client.PutPlacementRule(rpcpb.PlacementRule{
+		GroupID: "learner-read",
+		ID:      "voters",
+		Role:    rpcpb.Voter,
+		Count:   2,
+	}))
+	assert.NoError(t, client.PutPlacementRule(rpcpb.PlacementRule{
+		GroupID: "learner-read",
+		ID:      "learners",
+		Role:    rpcpb.Learner,
+		Count:   1,
+	}))
+	res := metapb.Shard{Start: []byte("b"), End: []byte("c"), Unique: "learner-read",
+		RuleGroups: []string{"learner-read"}}
+	assert.NoError(t, client.AsyncAddShards(res))
+	c.WaitShardByCountPerNode(2, testWaitTimeout)
+
+	var shardID uint64
+	learnerNode := -1
+	require.Eventually(t, func() bool {
+		for node := 0; node < 3; node++ {
+			c.GetStore(node).(*store).forEachReplica(func(pr *replica) bool {
+				shard := pr.getShard()
+				if shard.Unique == res.Unique {
+					shardID = pr.shardID
+					if r := findReplica(shard, pr.replica.StoreID); r != nil &&
+						r.Role == metapb.ReplicaRole_Learner {
+						learnerNode = node
+					}
+				}
+				return true
+			})
+		}
+		return learnerNode >= 0
+	}, testWaitTimeout, time.Millisecond*100)
+
+	kv := c.CreateTestKVClient(0)
+	defer kv.Close()
+	for i := 0; i < 10; i++ {
+		value := fmt.Sprintf("v%d", i)
+		assert.NoError(t, kv.SetWithShard("b1", value, shardID, testWaitTimeout))
+		// the learner waits for the write applied before the read
+		v, err := kv.GetWithShardAndPolicy("b1", shardID, rpcpb.SelectLearner, testWaitTimeout)
+		assert.NoError(t, err)
+		assert.Equal(t, value, v)
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pconfig "github.com/matrixorigin/matrixcube/components/prophet/config"
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/migration"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestUpgradeVersions returns the previous and the current versions of the
+// store, the current version migrates the on-disk data of the previous version.
+func newTestUpgradeVersions(migrated *uint64) (TestStoreVersion, TestStoreVersion) {
+	previous := TestStoreVersion{Version: "0.1.0"}
+	current := TestStoreVersion{
+		Version: "0.2.0",
+		Migrations: []migration.Migration{
+			{
+				Version: 1,
+				Name:    "test-upgrade",
+				Run: func(ctx *migration.Context) error {
+					atomic.AddUint64(migrated, 1)
+					return nil
+				},
+			},
+		},
+	}
+	return previous, current
+}
+
+// TestRollingRestartWithMigrations restarts the stores one by one with a new
+// migration and version, and checks the migration is run once by each store,
+// the reported version is changed, and no data is lost by the splits, the
+// snapshots and the conf changes around the restarts. All stores run the
+// current tree, so the wire compatibility with a previous release is not
+// covered.
+func TestRollingRestartWithMigrations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode.")
+		return
+	}
+
+	defer leaktest.AfterTest(t)()
+
+	migrated := uint64(0)
+	previous, current := newTestUpgradeVersions(&migrated)
+	c := NewTestClusterStore(t,
+		DiskTestCluster,
+		WithTestClusterNodeCount(4),
+		WithTestClusterSplitPolicy(64, 32),
+		WithTestClusterStoreVersions(previous, previous, previous, previous),
+		WithAppendTestClusterAdjustConfigFunc(func(node int, cfg *config.Config) {
+			// the replicas are only moved by the conf changes of the test
+			cfg.Prophet.Schedule.Schedulers = pconfig.SchedulerConfigs{
+				{Type: "balance-shard", Disable: true},
+				{Type: "balance-leader"},
+			}
+		}))
+	c.Start()
+	defer c.Stop()
+
+	// the shard is split after all the replicas are added
+	c.WaitLeadersByCount(1, testWaitTimeout)
+	c.WaitAllReplicasChangeToVoter(waitTestUpgradeShard(t, c, []byte("k")), testWaitTimeout)
+	expected := make(map[string]string)
+	writeTestUpgradeKeys(t, c, 0, "k", 20, expected)
+
+	// splits
+	c.WaitShardByCount(3, testWaitTimeout)
+	checkTestUpgradeCluster(t, c, expected)
+
+	// conf change, the new replica is created by the snapshot
+	moveTestUpgradeReplica(t, c, []byte("k-0"))
+	checkTestUpgradeCluster(t, c, expected)
+
+	// rolling upgrade, the writes are continued when the node is stopped
+	for node := 0; node < 4; node++ {
+		c.StopNode(node)
+		writeTestUpgradeKeys(t, c, (node+1)%4, fmt.Sprintf("u%d", node), 5, expected)
+		c.UpgradeNode(node, current)
+
+		assert.Equal(t, uint64(node+1), atomic.LoadUint64(&migrated))
+		schema, ok, err := migration.GetSchema(c.GetStore(node).(*store).kvStorage)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, metapb.StoreSchema{Version: 1}, schema)
+		assert.Equal(t, current.Version, c.GetStore(node).Meta().Version)
+		checkTestUpgradeCluster(t, c, expected)
+	}
+
+	// the versions are kept by the restart, no migration is run again
+	c.Restart()
+	assert.Equal(t, uint64(4), atomic.LoadUint64(&migrated))
+	checkTestUpgradeCluster(t, c, expected)
+}
+
+func waitTestUpgradeShard(t *testing.T, c TestRaftCluster, key []byte) uint64 {
+	timeoutC := time.After(testWaitTimeout)
+	for {
+		select {
+		case <-timeoutC:
+			assert.FailNowf(t, "", "wait shard of key %s timeout", key)
+		default:
+			if id := c.GetStore(0).GetRouter().SelectShardIDByKey(0, key); id > 0 {
+				return id
+			}
+			time.Sleep(time.Millisecond * 100)
+		}
+	}
+}
+
+func writeTestUpgradeKeys(t *testing.T, c TestRaftCluster, node int, prefix string, n int, expected map[string]string) {
+	kv := c.CreateTestKVClient(node)
+	defer kv.Close()
+
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("%s-%d", prefix, i)
+		value := fmt.Sprintf("v-%s", key)
+		// the leaders on the stopped node are moved by the elections, retry
+		// until the router is updated
+		deadline := time.Now().Add(testWaitTimeout)
+		for {
+			err := kv.Set(key, value, time.Second*5)
+			if err == nil {
+				break
+			}
+			require.True(t, time.Now().Before(deadline), "key %s, %+v", key, err)
+			time.Sleep(time.Millisecond * 100)
+		}
+		expected[key] = value
+	}
+}
+
+// waitTestUpgradeLeader returns the leader replica of the shard of the key
+// which is split from the first shard, the stale shards in the routers are
+// skipped
+func waitTestUpgradeLeader(t *testing.T, c TestRaftCluster, key []byte) *replica {
+	timeoutC := time.After(testWaitTimeout)
+	for {
+		select {
+		case <-timeoutC:
+			assert.FailNowf(t, "", "wait leader of key %s timeout", key)
+		default:
+			for node := 0; node < 4; node++ {
+				s := c.GetStore(node).(*store)
+				id := s.GetRouter().SelectShardIDByKey(0, key)
+				if pr := s.getReplica(id, true); pr != nil {
+					if shard := pr.getShard(); shard.State == metapb.ShardState_Running &&
+						(len(shard.Start) > 0 || len(shard.End) > 0) {
+						return pr
+					}
+				}
+			}
+			time.Sleep(time.Millisecond * 100)
+		}
+	}
+}
+
+// compactTestUpgradeShard compacts the raft log of the shard, so the new
+// replicas are created by the snapshots rather than the raft logs. The log of
+// the split shard starts with its metadata, and the data written before the
+// split is only in the snapshot.
+func compactTestUpgradeShard(t *testing.T, c TestRaftCluster, leader *replica) {
+	// sync the data storage to move the persistent log index, the compaction
+	// never removes the logs after the persistent log index
+	for node := 0; node < 4; node++ {
+		if pr := c.GetStore(node).(*store).getReplica(leader.shardID, false); pr != nil {
+			require.NoError(t, pr.sm.dataStorage.Sync([]uint64{leader.shardID}))
+		}
+	}
+
+	index, _ := leader.sm.getAppliedIndexTerm()
+	leader.addAdminRequest(rpcpb.CmdCompactLog, &rpcpb.CompactLogRequest{
+		CompactIndex: index,
+	})
+	timeoutC := time.After(testWaitTimeout)
+	for leader.getFirstIndex() <= 1 {
+		select {
+		case <-timeoutC:
+			assert.FailNowf(t, "", "wait shard %d log compacted timeout", leader.shardID)
+		default:
+			time.Sleep(time.Millisecond * 100)
+		}
+	}
+}
+
+// moveTestUpgradeReplica moves a follower replica of the shard of the key to
+// the store without the replica of the shard
+func moveTestUpgradeReplica(t *testing.T, c TestRaftCluster, key []byte) {
+	var shard Shard
+	to := 0
+	// the shard may be not reported to the prophet yet, retry until the
+	// operator is created
+	deadline := time.Now().Add(testWaitTimeout)
+	for {
+		leader := waitTestUpgradeLeader(t, c, key)
+		compactTestUpgradeShard(t, c, leader)
+
+		shard = leader.getShard()
+		stores := make(map[uint64]struct{}, len(shard.Replicas))
+		for _, r := range shard.Replicas {
+			stores[r.StoreID] = struct{}{}
+		}
+
+		from := uint64(0)
+		for _, r := range shard.Replicas {
+			if r.StoreID != leader.storeID {
+				from = r.StoreID
+				break
+			}
+		}
+		for node := 0; node < 4; node++ {
+			if _, ok := stores[c.GetStore(node).Meta().ID]; !ok {
+				to = node
+				break
+			}
+		}
+		require.NotEqual(t, uint64(0), from)
+
+		err := c.GetStore(0).Prophet().GetClient().CreateOperator(rpcpb.ManualMoveReplica,
+			shard.ID, from, c.GetStore(to).Meta().ID)
+		if err == nil {
+			break
+		}
+		require.True(t, time.Now().Before(deadline), "shard %d, %+v", shard.ID, err)
+		time.Sleep(time.Millisecond * 100)
+	}
+
+	timeoutC := time.After(testWaitTimeout)
+	for {
+		select {
+		case <-timeoutC:
+			assert.FailNowf(t, "", "wait shard %d moved to node %d timeout", shard.ID, to)
+		default:
+			// the first index is moved by the snapshot
+			if pr := c.GetStore(to).(*store).getReplica(shard.ID, false); pr != nil &&
+				pr.getShard().Epoch.ConfigVer > shard.Epoch.ConfigVer &&
+				pr.getFirstIndex() > 1 {
+				return
+			}
+			time.Sleep(time.Millisecond * 100)
+		}
+	}
+}
+
+// checkTestUpgradeCluster checks no data is lost and all the shards have the
+// leaders, the destroyed shards are skipped
+func checkTestUpgradeCluster(t *testing.T, c TestRaftCluster, expected map[string]string) {
+	tc := c.(*testRaftCluster)
+	stuck := uint64(0)
+	timeoutC := time.After(testWaitTimeout)
+	for {
+		select {
+		case <-timeoutC:
+			assert.FailNowf(t, "", "wait all shards have leaders timeout, shard %d", stuck)
+		default:
+		}
+
+		var shards []uint64
+		c.GetStore(0).GetRouter().ForeachShards(0, func(shard Shard) bool {
+			// the parents of the splits are destroyed
+			if shard.State == metapb.ShardState_Running {
+				shards = append(shards, shard.ID)
+			}
+			return true
+		})
+		stuck = 0
+		for _, id := range shards {
+			hasReplica, hasLeader := false, false
+			for node := range tc.stores {
+				if !tc.status[node] {
+					continue
+				}
+				hasReplica = hasReplica || tc.stores[node].getReplica(id, false) != nil
+				hasLeader = hasLeader || tc.awares[node].isLeader(id)
+			}
+			// the router may be not updated after the shard is destroyed
+			if hasReplica && !hasLeader {
+				stuck = id
+				break
+			}
+		}
+		if len(shards) > 0 && stuck == 0 {
+			break
+		}
+		time.Sleep(time.Millisecond * 100)
+	}
+
+	kv := c.CreateTestKVClient(0)
+	defer kv.Close()
+	for key, value := range expected {
+		v, err := kv.Get(key, testWaitTimeout)
+		require.NoError(t, err, "key %s", key)
+		assert.Equal(t, value, v, "key %s", key)
+	}
+}
@@ -22,8 +22,14 @@ type applyMetrics struct {
 	approximateDiffHint uint64
 	// delete keys' count since last reset.
 	deleteKeysHint uint64
-	writtenBytes   uint64
-	writtenKeys    uint64
+	// the bytes removed from the shard since last reset, e.g. by the range
+	// deletions.
+	deletedBytesHint uint64
+	writtenBytes     uint64
+	writtenKeys      uint64
+	// sizeStale the approximate size is stale after the range deletions or the
+	// gc of the mvcc data, it's refreshed by the storage estimate later.
+	sizeStale bool
 
 	admin raftAdminMetrics
 }
@@ -127,6 +133,7 @@ func (m *raftMessageMetrics) flush() {
 type raftProposeMetrics struct {
 	readLocal      uint64
 	readIndex      uint64
+	staleRead      uint64
 	normal         uint64
 	transferLeader uint64
 	confChange     uint64
@@ -143,6 +150,11 @@ func (m *raftProposeMetrics) flush() {
 		m.readIndex = 0
 	}
 
+	if m.staleRead > 0 {
+		metric.AddRaftProposalStaleReadCount(m.staleRead)
+		m.staleRead = 0
+	}
+
 	if m.normal > 0 {
 		metric.AddRaftProposalNormalCount(m.normal)
 		m.normal = 0
@@ -15,6 +15,7 @@ package raftstore
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/matrixorigin/matrixcube/components/log"
 	"github.com/matrixorigin/matrixcube/metric"
@@ -41,6 +42,8 @@ type reqCtx struct {
 	reqType int
 	req     rpcpb.Request
 	cb      func(rpcpb.ResponseBatch)
+	// enqueueAt the time the request added to the request queue of the replica
+	enqueueAt time.Time
 }
 
 func newReqCtx(req rpcpb.Request, cb func(rpcpb.ResponseBatch)) reqCtx {
@@ -16,6 +16,7 @@ package raftstore
 import (
 	"bytes"
 	"errors"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -33,6 +34,9 @@ var (
 
 var (
 	defaultRetryInterval = time.Second
+	// followerReadRetryInterval the retry interval of the read which can be
+	// served by any replica and rejected by a busy replica
+	followerReadRetryInterval = time.Millisecond * 10
 )
 
 // SuccessCallback request success callback
@@ -47,6 +51,46 @@ type RetryController interface {
 	Retry(requestID []byte) (rpcpb.Request, bool)
 }
 
+// RetryBackoff is implemented by the RetryController which decides the retry
+// interval of the requests. The interval of the ShardsProxy is used if the
+// returned interval is 0, or the request is rejected by a busy replica.
+type RetryBackoff interface {
+	// RetryInterval returns the interval before the next retry of the request
+	RetryInterval(requestID []byte) time.Duration
+}
+
+// RouteResult the result of the route lookup of a request
+type RouteResult int
+
+const (
+	// RouteHit the replica of the request is found by the router
+	RouteHit RouteResult = iota
+	// RouteMiss the shard or its leader is unknown to the router
+	RouteMiss
+	// RouteStale the replica found by the router rejected the request, e.g. it's
+	// not the leader or the epoch of the shard is changed
+	RouteStale
+)
+
+func (r RouteResult) String() string {
+	switch r {
+	case RouteHit:
+		return "hit"
+	case RouteMiss:
+		return "miss"
+	default:
+		return "stale"
+	}
+}
+
+// RouteObserver is implemented by the RetryController which observes the route
+// lookups of the requests, e.g. to compute the hit rate of the routing table.
+type RouteObserver interface {
+	// ObserveRoute is called for each dispatch of the request, and for each
+	// response rejected by the replica of a stale route.
+	ObserveRoute(requestID []byte, result RouteResult)
+}
+
 // ShardsProxy Shards proxy, distribute the appropriate request to the corresponding backend,
 // retry the request for the error
 type ShardsProxy interface {
@@ -221,7 +265,8 @@ func (p *shardsProxy) DispatchTo(req rpcpb.Request, shard Shard, store metapb.St
 
 	// No leader, retry after a leader tick
 	if to == "" {
-		p.retryDispatch(req.ID, "dispatch to nil store")
+		p.observeRoute(req.ID, RouteMiss)
+		p.retryDispatch(req.ID, errors.New("dispatch to nil store"), nil)
 		return nil
 	}
 
@@ -234,21 +279,22 @@ func (p *shardsProxy) DispatchTo(req rpcpb.Request, shard Shard, store metapb.St
 		return ErrKeysNotInShard
 	}
 
+	p.observeRoute(req.ID, RouteHit)
 	req.Epoch = shard.Epoch
 	// Only SelectLeaseHolder use the newest lease.
 	if req.ReplicaSelectPolicy == rpcpb.SelectLeaseHolder {
 		req.Lease = lease
 	}
-	return p.forwardToBackend(req, to)
+	return p.forwardToBackend(req, store)
 }
 
 func (p *shardsProxy) Router() Router {
 	return p.cfg.router
 }
 
-func (p *shardsProxy) forwardToBackend(req rpcpb.Request, leader string) error {
+func (p *shardsProxy) forwardToBackend(req rpcpb.Request, store metapb.Store) error {
 	var err error
-	bc := p.getBackend(leader)
+	bc := p.getBackend(store.ClientAddress)
 	if bc == nil {
 		p.Lock()
 		defer p.Unlock()
@@ -257,13 +303,20 @@ func (p *shardsProxy) forwardToBackend(req rpcpb.Request, leader string) error {
 			return errStopped
 		}
 
-		bc, err = p.createBackendLocked(leader)
+		bc, err = p.createBackendLocked(store)
 		if err != nil {
 			return err
 		}
 	}
 
-	return bc.dispatch(req)
+	err = bc.dispatch(req)
+	if err != nil && isFollowerRead(req) {
+		// fails over to the replicas on other stores
+		p.cfg.router.MarkStoreUnreachable(store.ID)
+		p.retryDispatch(req.ID, err, nil)
+		return nil
+	}
+	return err
 }
 
 func (p *shardsProxy) OnResponse(resp rpcpb.ResponseBatch) {
@@ -283,13 +336,17 @@ func (p *shardsProxy) getBackend(addr string) backend {
 	return p.backends[addr]
 }
 
-func (p *shardsProxy) createBackendLocked(addr string) (backend, error) {
-	bc, err := p.cfg.backendFactory.create(addr, p.done, p.doneWithError)
+func (p *shardsProxy) createBackendLocked(store metapb.Store) (backend, error) {
+	bc, err := p.cfg.backendFactory.create(store.ClientAddress, p.done,
+		func(requestID []byte, err error) {
+			p.cfg.router.MarkStoreUnreachable(store.ID)
+			p.doneWithError(requestID, err)
+		})
 	if err != nil {
 		return nil, err
 	}
 
-	p.addBackendLocked(addr, bc)
+	p.addBackendLocked(store.ClientAddress, bc)
 	return bc, nil
 }
 
@@ -303,7 +360,7 @@ func (p *shardsProxy) onLocalResp(header rpcpb.ResponseBatchHeader, rsp rpcpb.Re
 }
 
 func (p *shardsProxy) doneWithError(requestID []byte, err error) {
-	p.retryDispatch(requestID, err.Error())
+	p.retryDispatch(requestID, err, nil)
 }
 
 func (p *shardsProxy) done(rsp rpcpb.Response) {
@@ -320,6 +377,9 @@ func (p *shardsProxy) done(rsp rpcpb.Response) {
 		if rsp.Error.ShardUnavailable != nil {
 			p.cfg.failureCallback(rsp.ID, NewShardUnavailableErr(rsp.Error.ShardUnavailable.ShardID))
 			return
+		} else if rsp.Error.EpochNotMatch != nil {
+			p.cfg.failureCallback(rsp.ID, NewEpochNotMatchErr(rsp.Error.EpochNotMatch))
+			return
 		} else if rsp.Error.LeaseMismatch != nil {
 			p.cfg.failureCallback(rsp.ID, NewShardLeaseMismatchErr(rsp.Error.LeaseMismatch.ShardID,
 				rsp.Error.LeaseMismatch.RequestLease,
@@ -331,7 +391,17 @@ func (p *shardsProxy) done(rsp rpcpb.Response) {
 	}
 
 	p.adjustRoute(rsp.Error)
-	p.retryDispatch(rsp.ID, rsp.Error.String())
+	if isStaleRouteError(rsp.Error) {
+		p.observeRoute(rsp.ID, RouteStale)
+	}
+	if rsp.Error.ShardBusy != nil {
+		// backoff as the busy replica, and the typed error is returned if the
+		// request is not retried
+		p.retryDispatch(rsp.ID, NewShardBusyErr(rsp.Error.ShardBusy),
+			&errorpb.ServerIsBusy{EstimatedWaitMS: rsp.Error.ShardBusy.RetryAfterMS})
+		return
+	}
+	p.retryDispatch(rsp.ID, errors.New(rsp.Error.String()), rsp.Error.ServerIsBusy)
 }
 
 func (p *shardsProxy) adjustRoute(err errorpb.Error) {
@@ -342,14 +412,31 @@ func (p *shardsProxy) adjustRoute(err errorpb.Error) {
 	}
 }
 
-func (p *shardsProxy) retryDispatch(requestID []byte, err string) {
+func (p *shardsProxy) observeRoute(requestID []byte, result RouteResult) {
+	if o, ok := p.cfg.retryController.(RouteObserver); ok {
+		o.ObserveRoute(requestID, result)
+	}
+}
+
+func isStaleRouteError(err errorpb.Error) bool {
+	return err.NotLeader != nil ||
+		err.ShardNotFound != nil ||
+		err.KeyNotInShard != nil ||
+		err.StaleEpoch != nil ||
+		err.StoreMismatch != nil
+}
+
+// retryDispatch retries the request after the retry interval, or after the
+// interval adjusted by the load hints if the request is rejected by a busy
+// replica.
+func (p *shardsProxy) retryDispatch(requestID []byte, cause error, busy *errorpb.ServerIsBusy) {
 	if p.cfg.retryController == nil {
 		if ce := p.logger.Check(zap.DebugLevel, "dispatch request failed with no retry"); ce != nil {
 			ce.Write(log.HexField("id", requestID),
 				log.ReasonField("retry controller not set"),
-				zap.String("cause", err))
+				zap.String("cause", cause.Error()))
 		}
-		p.cfg.failureCallback(requestID, errors.New(err))
+		p.cfg.failureCallback(requestID, cause)
 		return
 	}
 
@@ -358,24 +445,49 @@ func (p *shardsProxy) retryDispatch(requestID []byte, err string) {
 		if ce := p.logger.Check(zap.DebugLevel, "dispatch request failed with no retry"); ce != nil {
 			ce.Write(log.HexField("id", requestID),
 				log.ReasonField("retry controller return false"),
-				zap.String("cause", err))
+				zap.String("cause", cause.Error()))
 		}
-		p.cfg.failureCallback(requestID, errors.New(err))
+		p.cfg.failureCallback(requestID, cause)
 		return
 	}
 
 	// FIXME: more efficient retry mechanism
 	if ce := p.logger.Check(zap.DebugLevel, "dispatch request failed, retry later"); ce != nil {
 		ce.Write(log.HexField("id", req.ID),
-			zap.String("cause", err))
+			zap.String("cause", cause.Error()))
+	}
+	interval := p.getRetryInterval(req, busy)
+	if b, ok := p.cfg.retryController.(RetryBackoff); ok && busy == nil {
+		if value := b.RetryInterval(requestID); value > 0 {
+			interval = value
+		}
 	}
-	if _, err := util.DefaultTimeoutWheel().Schedule(p.cfg.retryInterval, p.doRetry, req); err != nil {
+	if _, err := util.DefaultTimeoutWheel().Schedule(interval, p.doRetry, req); err != nil {
 		p.logger.Error("fail to retry request",
 			log.HexField("id", req.ID))
 	}
 
 }
 
+// getRetryInterval returns the retry interval of the request. The read which can
+// be served by any replica is retried soon on a random replica to spread the
+// load, other requests rejected by a busy replica are retried after the wait
+// estimated by the replica. A random jitter is added to the busy retries, so the
+// rejected requests do not arrive at the replica at the same time.
+func (p *shardsProxy) getRetryInterval(req rpcpb.Request, busy *errorpb.ServerIsBusy) time.Duration {
+	if busy == nil {
+		return p.cfg.retryInterval
+	}
+
+	interval := p.cfg.retryInterval
+	if isFollowerRead(req) {
+		interval = followerReadRetryInterval
+	} else if busy.EstimatedWaitMS > 0 {
+		interval = time.Duration(busy.EstimatedWaitMS) * time.Millisecond
+	}
+	return interval + time.Duration(rand.Int63n(int64(interval)/2+1))
+}
+
 func (p *shardsProxy) doRetry(arg interface{}) {
 	req := arg.(rpcpb.Request)
 	if req.ToShard == 0 {
@@ -391,6 +503,14 @@ func (p *shardsProxy) doRetry(arg interface{}) {
 	}
 }
 
+// isFollowerRead returns true if the request can be served by any replica,
+// including the learners
+func isFollowerRead(req rpcpb.Request) bool {
+	return req.Type == rpcpb.Read &&
+		(req.ReplicaSelectPolicy == rpcpb.SelectRandom ||
+			req.ReplicaSelectPolicy == rpcpb.SelectLearner)
+}
+
 func keysRangeInShard(keys *rpcpb.Range, shard Shard) bool {
 	return (len(shard.Start) == 0 || bytes.Compare(shard.Start, keys.From) <= 0) &&
 		(len(shard.End) == 0 || bytes.Compare(shard.End, keys.To) >= 0)
@@ -22,6 +22,7 @@ import (
 	"github.com/fagongzi/goetty"
 	"github.com/fagongzi/goetty/codec/length"
 	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/pb/errorpb"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/matrixorigin/matrixcube/util/leaktest"
@@ -218,3 +219,237 @@ func TestRPCDispatch(t *testing.T) {
 		assert.Fail(t, "need succ")
 	}
 }
+
+func TestGetRetryIntervalWithBusyHints(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	p := &shardsProxy{cfg: shardsProxyConfig{retryInterval: time.Minute}}
+
+	write := rpcpb.Request{Type: rpcpb.Write}
+	assert.Equal(t, time.Minute, p.getRetryInterval(write, nil))
+
+	// retry after the estimated wait with jitter
+	interval := p.getRetryInterval(write, &errorpb.ServerIsBusy{EstimatedWaitMS: 100})
+	assert.True(t, interval >= time.Millisecond*100 && interval <= time.Millisecond*150)
+
+	// no estimated wait
+	interval = p.getRetryInterval(write, &errorpb.ServerIsBusy{})
+	assert.True(t, interval >= time.Minute)
+
+	// spread the read on other replicas
+	read := rpcpb.Request{Type: rpcpb.Read, ReplicaSelectPolicy: rpcpb.SelectRandom}
+	interval = p.getRetryInterval(read, &errorpb.ServerIsBusy{EstimatedWaitMS: 100000})
+	assert.True(t, interval <= followerReadRetryInterval*3/2)
+}
+
+func TestRetryWithBusyHints(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	sc := make(chan rpcpb.Response, 1)
+	fc := make(chan []byte, 1)
+	success := func(r rpcpb.Response) { sc <- r }
+	failure := func(id []byte, e error) {
+		select {
+		case fc <- id:
+		default:
+		}
+	}
+	factory := newTestBackendFactory()
+	rr, err := newRouterBuilder().build(make(chan rpcpb.EventNotify))
+	assert.NoError(t, err)
+	rr.UpdateStore(metapb.Store{ID: 1, ClientAddress: "b1"})
+	rr.UpdateShard(Shard{ID: 1, Replicas: []Replica{{ID: 1, StoreID: 1}}})
+	rr.UpdateLeader(1, 1)
+
+	// the default retry interval is too long to wait
+	sp, err := newShardsProxyBuilder().
+		withRetryInterval(time.Minute).
+		withBackendFactory(factory).
+		withRequestCallback(success, failure).
+		build(rr)
+	assert.NoError(t, err)
+
+	rc := newMockRetryController()
+	sp.SetRetryController(rc)
+
+	req := rpcpb.Request{ID: []byte("k1"), Key: []byte("k1"), Type: rpcpb.Write}
+	rc.setRequest(req, time.Minute)
+
+	var mu sync.Mutex
+	times := 0
+	factory.backends["b1"] = newLocalBackend(func(r rpcpb.Request) error {
+		mu.Lock()
+		times++
+		busy := times == 1
+		mu.Unlock()
+
+		resp := rpcpb.ResponseBatch{Responses: []rpcpb.Response{{ID: r.ID}}}
+		if busy {
+			resp.Header.Error = errorpb.Error{
+				Message:      errServerIsBusy.Error(),
+				ServerIsBusy: &errorpb.ServerIsBusy{QueueDepth: 10, EstimatedWaitMS: 10},
+			}
+		}
+		sp.OnResponse(resp)
+		return nil
+	})
+	assert.NoError(t, sp.Dispatch(req))
+	select {
+	case rsp := <-sc:
+		assert.Equal(t, req.ID, rsp.ID)
+	case <-fc:
+		assert.Fail(t, "need succ")
+	case <-time.After(time.Second * 5):
+		assert.Fail(t, "need retry after the estimated wait")
+	}
+	mu.Lock()
+	assert.Equal(t, 2, times)
+	mu.Unlock()
+}
+
+func TestShardBusyWithoutRetry(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	fc := make(chan error, 1)
+	success := func(r rpcpb.Response) {}
+	failure := func(id []byte, e error) { fc <- e }
+	factory := newTestBackendFactory()
+	rr, err := newRouterBuilder().build(make(chan rpcpb.EventNotify))
+	assert.NoError(t, err)
+	rr.UpdateStore(metapb.Store{ID: 1, ClientAddress: "b1"})
+	rr.UpdateShard(Shard{ID: 1, Replicas: []Replica{{ID: 1, StoreID: 1}}})
+	rr.UpdateLeader(1, 1)
+
+	sp, err := newShardsProxyBuilder().
+		withBackendFactory(factory).
+		withRequestCallback(success, failure).
+		build(rr)
+	assert.NoError(t, err)
+
+	factory.backends["b1"] = newLocalBackend(func(r rpcpb.Request) error {
+		resp := rpcpb.ResponseBatch{Responses: []rpcpb.Response{{ID: r.ID}}}
+		resp.Header.Error = errorpb.Error{
+			Message:   errShardBusy.Error(),
+			ShardBusy: &errorpb.ShardBusy{ShardID: 1, RetryAfterMS: 200},
+		}
+		sp.OnResponse(resp)
+		return nil
+	})
+	assert.NoError(t, sp.Dispatch(rpcpb.Request{ID: []byte("k1"), Key: []byte("k1"), Type: rpcpb.Write}))
+	select {
+	case err := <-fc:
+		assert.True(t, IsShardBusyErr(err))
+		assert.Equal(t, ShardBusyErr{ShardID: 1, RetryAfter: time.Millisecond * 200}, err)
+	case <-time.After(time.Second * 5):
+		assert.Fail(t, "need the shard busy error")
+	}
+}
+
+func TestEpochNotMatchWithoutRetry(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	fc := make(chan error, 1)
+	success := func(r rpcpb.Response) {}
+	failure := func(id []byte, e error) { fc <- e }
+	factory := newTestBackendFactory()
+	rr, err := newRouterBuilder().build(make(chan rpcpb.EventNotify))
+	assert.NoError(t, err)
+	rr.UpdateStore(metapb.Store{ID: 1, ClientAddress: "b1"})
+	rr.UpdateShard(Shard{ID: 1, Replicas: []Replica{{ID: 1, StoreID: 1}}})
+	rr.UpdateLeader(1, 1)
+
+	sp, err := newShardsProxyBuilder().
+		withBackendFactory(factory).
+		withRequestCallback(success, failure).
+		build(rr)
+	assert.NoError(t, err)
+	// the request is never retried
+	rc := newMockRetryController()
+	sp.SetRetryController(rc)
+	req := rpcpb.Request{ID: []byte("k1"), Key: []byte("k1"), Type: rpcpb.Write,
+		ExpectedEpoch: &metapb.ShardEpoch{Generation: 1}}
+	rc.setRequest(req, time.Minute)
+
+	times := 0
+	factory.backends["b1"] = newLocalBackend(func(r rpcpb.Request) error {
+		times++
+		resp := rpcpb.ResponseBatch{Responses: []rpcpb.Response{{ID: r.ID}}}
+		resp.Header.Error = newEpochNotMatchError(Shard{ID: 1, Epoch: Epoch{Generation: 2}}, *r.ExpectedEpoch)
+		sp.OnResponse(resp)
+		return nil
+	})
+	assert.NoError(t, sp.Dispatch(req))
+	select {
+	case err := <-fc:
+		assert.True(t, IsEpochNotMatchErr(err))
+		assert.Equal(t, EpochNotMatchErr{ShardID: 1, Expected: Epoch{Generation: 1}, Current: Epoch{Generation: 2}}, err)
+		assert.Equal(t, 1, times)
+	case <-time.After(time.Second * 5):
+		assert.Fail(t, "need the epoch not match error")
+	}
+}
+
+func TestFollowerReadFailover(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	sc := make(chan rpcpb.Response, 1)
+	fc := make(chan []byte, 1)
+	success := func(r rpcpb.Response) { sc <- r }
+	failure := func(id []byte, e error) {
+		select {
+		case fc <- id:
+		default:
+		}
+	}
+	factory := newTestBackendFactory()
+	rr, err := newRouterBuilder().
+		withLocality("zone", "z1").
+		withUnreachableStoreTimeout(time.Minute).
+		build(make(chan rpcpb.EventNotify))
+	assert.NoError(t, err)
+	rr.UpdateStore(metapb.Store{ID: 1, ClientAddress: "b1", Labels: []metapb.Label{{Key: "zone", Value: "z1"}}})
+	rr.UpdateStore(metapb.Store{ID: 2, ClientAddress: "b2", Labels: []metapb.Label{{Key: "zone", Value: "z2"}}})
+	rr.UpdateShard(Shard{ID: 1, Replicas: []Replica{{ID: 1, StoreID: 1}, {ID: 2, StoreID: 2}}})
+	rr.UpdateLeader(1, 2)
+
+	sp, err := newShardsProxyBuilder().
+		withRetryInterval(time.Millisecond*10).
+		withBackendFactory(factory).
+		withRequestCallback(success, failure).
+		build(rr)
+	assert.NoError(t, err)
+
+	rc := newMockRetryController()
+	sp.SetRetryController(rc)
+
+	req := rpcpb.Request{ID: []byte("k1"), Key: []byte("k1"), Type: rpcpb.Read, ReplicaSelectPolicy: rpcpb.SelectRandom}
+	rc.setRequest(req, time.Minute)
+
+	var mu sync.Mutex
+	var stores []string
+	factory.backends["b1"] = newLocalBackend(func(r rpcpb.Request) error {
+		mu.Lock()
+		defer mu.Unlock()
+		stores = append(stores, "b1")
+		return errConnect
+	})
+	factory.backends["b2"] = newLocalBackend(func(r rpcpb.Request) error {
+		mu.Lock()
+		stores = append(stores, "b2")
+		mu.Unlock()
+		sp.OnResponse(rpcpb.ResponseBatch{Responses: []rpcpb.Response{{ID: r.ID}}})
+		return nil
+	})
+	assert.NoError(t, sp.Dispatch(req))
+	select {
+	case rsp := <-sc:
+		assert.Equal(t, req.ID, rsp.ID)
+	case <-fc:
+		assert.Fail(t, "need succ")
+	case <-time.After(time.Second * 5):
+		assert.Fail(t, "need failover to other zones")
+	}
+	mu.Lock()
+	assert.Equal(t, []string{"b1", "b2"}, stores)
+	mu.Unlock()
+}
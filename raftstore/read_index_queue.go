@@ -19,6 +19,7 @@ import (
 	"github.com/matrixorigin/matrixcube/components/log"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/raftpb"
 	"go.uber.org/zap"
 )
 
@@ -27,6 +28,9 @@ type requestExecutor func(req rpcpb.Request)
 type readyRead struct {
 	batch batch
 	index uint64
+	// ctx is the read index context used to confirm the batch, multiple batches
+	// may share the same ctx if they are confirmed in one read index round.
+	ctx []byte
 }
 
 type readIndexQueue struct {
@@ -35,6 +39,14 @@ type readIndexQueue struct {
 	reads        []readyRead
 	readyCount   int
 	lastReadyIdx int
+	// batching read batches which waiting for the next read index round
+	batching      []batch
+	batchingTicks int
+	// piggybackCtx the read index ctx of the batching reads, the heartbeats
+	// broadcast by its read index round to the replicas in piggybackTo are not
+	// sent, the ctx is carried by the heartbeats of the next ticks instead.
+	piggybackCtx []byte
+	piggybackTo  map[uint64]struct{}
 }
 
 func newReadIndexQueue(shardID uint64, logger *zap.Logger) *readIndexQueue {
@@ -48,12 +60,19 @@ func (q *readIndexQueue) reset() {
 	q.reads = q.reads[:0]
 	q.readyCount = 0
 	q.lastReadyIdx = 0
+	q.batching = q.batching[:0]
+	q.batchingTicks = 0
+	q.piggybackCtx = nil
+	q.piggybackTo = nil
 }
 
 func (q *readIndexQueue) close() {
 	for _, rr := range q.reads {
 		rr.batch.respShardNotFound(q.shardID)
 	}
+	for _, c := range q.batching {
+		c.respShardNotFound(q.shardID)
+	}
 	q.reset()
 }
 
@@ -61,13 +80,82 @@ func (q *readIndexQueue) leaderChanged(newLeader Replica) {
 	for _, rr := range q.reads {
 		rr.batch.respNotLeader(q.shardID, newLeader)
 	}
+	for _, c := range q.batching {
+		c.respNotLeader(q.shardID, newLeader)
+	}
 	q.reset()
 }
 
 func (q *readIndexQueue) append(c batch) {
-	q.reads = append(q.reads, readyRead{
-		batch: c,
-	})
+	q.appendWithCtx(c.getRequestID(), c)
+}
+
+// appendWithCtx adds the batches which confirmed by the same read index ctx
+func (q *readIndexQueue) appendWithCtx(ctx []byte, batches ...batch) {
+	for _, c := range batches {
+		q.reads = append(q.reads, readyRead{
+			batch: c,
+			ctx:   ctx,
+		})
+	}
+}
+
+// addBatching adds the read batch to the batching window, all batches in the
+// window will be confirmed by a single read index round when the window closed.
+func (q *readIndexQueue) addBatching(c batch) {
+	q.batching = append(q.batching, c)
+}
+
+// tick advances the batching window by n ticks, returns true if the window is
+// closed and the batching reads need to be confirmed.
+func (q *readIndexQueue) tick(n int, windowTicks int) bool {
+	if len(q.batching) == 0 {
+		q.batchingTicks = 0
+		return false
+	}
+	q.batchingTicks += n
+	return q.batchingTicks >= windowTicks
+}
+
+// takeBatching returns and clears all batching reads
+func (q *readIndexQueue) takeBatching() []batch {
+	batches := q.batching
+	q.batching = nil
+	q.batchingTicks = 0
+	return batches
+}
+
+// piggyback makes the read index round of the ctx confirmed by the scheduled
+// heartbeats, the heartbeats broadcast to the replicas by the round are dropped.
+func (q *readIndexQueue) piggyback(ctx []byte, to []uint64) {
+	q.piggybackCtx = ctx
+	q.piggybackTo = make(map[uint64]struct{}, len(to))
+	for _, id := range to {
+		q.piggybackTo[id] = struct{}{}
+	}
+}
+
+// dropPiggybacked returns true if the msg is the heartbeat broadcast by the read
+// index round of the piggyback ctx. The leader always carries the ctx of the
+// last pending read index request in its heartbeats, so the ctx is confirmed by
+// the next heartbeats, or by the later requests which confirm all the earlier
+// ones.
+func (q *readIndexQueue) dropPiggybacked(msg raftpb.Message) bool {
+	if msg.Type != raftpb.MsgHeartbeat || len(q.piggybackCtx) == 0 ||
+		!bytes.Equal(msg.Context, q.piggybackCtx) {
+		return false
+	}
+	// the first heartbeat of the ctx to a replica is the one of the read index
+	// round, the followings are scheduled by the ticks
+	if _, ok := q.piggybackTo[msg.To]; !ok {
+		return false
+	}
+	delete(q.piggybackTo, msg.To)
+	if len(q.piggybackTo) == 0 {
+		q.piggybackCtx = nil
+		q.piggybackTo = nil
+	}
+	return true
 }
 
 func (q *readIndexQueue) ready(state raft.ReadState) {
@@ -77,15 +165,22 @@ func (q *readIndexQueue) ready(state raft.ReadState) {
 	}
 
 	for idx := range q.reads {
-		if bytes.Equal(q.reads[idx].batch.requestBatch.Header.ID, state.RequestCtx) {
+		if q.reads[idx].index == 0 &&
+			bytes.Equal(q.reads[idx].ctx, state.RequestCtx) {
 			q.reads[idx].index = state.Index
 			q.readyCount++
 			q.lastReadyIdx = idx
-			return
 		}
 	}
 }
 
+// hasUnready returns true if any read is waiting for the read index, the reads
+// confirmed later must not be ready before them, otherwise they are removed as
+// lost.
+func (q *readIndexQueue) hasUnready() bool {
+	return len(q.reads) > q.readyCount
+}
+
 func (q *readIndexQueue) process(appliedIndex uint64, exector requestExecutor) bool {
 	if len(q.reads) == 0 || q.readyCount == 0 {
 		return false
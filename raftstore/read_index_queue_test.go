@@ -19,6 +19,7 @@ import (
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/stretchr/testify/assert"
 	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/raftpb"
 )
 
 func TestReadIndexQueueReset(t *testing.T) {
@@ -201,3 +202,59 @@ func TestReadIndexQueueProcessWithReadyApplied(t *testing.T) {
 	assert.Equal(t, 1, q.readyCount)
 	assert.Equal(t, 0, q.lastReadyIdx)
 }
+
+func TestReadIndexQueueBatching(t *testing.T) {
+	q := newReadIndexQueue(1, nil)
+	assert.False(t, q.tick(1, 2))
+
+	q.addBatching(newTestBatch("1", "k1", 1, rpcpb.Write, 0, nil))
+	q.addBatching(newTestBatch("2", "k2", 1, rpcpb.Write, 0, nil))
+	assert.False(t, q.tick(1, 2))
+	assert.True(t, q.tick(1, 2))
+
+	batches := q.takeBatching()
+	assert.Equal(t, 2, len(batches))
+	assert.Empty(t, q.batching)
+	assert.Equal(t, 0, q.batchingTicks)
+
+	q.appendWithCtx([]byte("ctx"), batches...)
+	q.append(newTestBatch("3", "k3", 1, rpcpb.Write, 0, nil))
+	assert.Equal(t, 3, len(q.reads))
+
+	q.ready(raft.ReadState{
+		Index:      2,
+		RequestCtx: []byte("ctx"),
+	})
+	assert.Equal(t, 2, q.readyCount)
+	assert.Equal(t, 1, q.lastReadyIdx)
+	assert.False(t, q.removeLost())
+
+	n := 0
+	assert.True(t, q.process(2, func(req rpcpb.Request) { n++ }))
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 1, len(q.reads))
+	assert.Equal(t, 0, q.readyCount)
+}
+
+func TestReadIndexQueuePiggyback(t *testing.T) {
+	q := newReadIndexQueue(1, nil)
+	hb := func(to uint64, ctx string) raftpb.Message {
+		return raftpb.Message{Type: raftpb.MsgHeartbeat, To: to, Context: []byte(ctx)}
+	}
+	assert.False(t, q.dropPiggybacked(hb(2, "")))
+
+	q.piggyback([]byte("ctx"), []uint64{2, 3})
+	assert.False(t, q.dropPiggybacked(raftpb.Message{Type: raftpb.MsgApp, To: 2, Context: []byte("ctx")}))
+	assert.False(t, q.dropPiggybacked(hb(2, "other")))
+	// the heartbeats of the read index round are dropped
+	assert.True(t, q.dropPiggybacked(hb(2, "ctx")))
+	// the heartbeats of the ticks carry the ctx
+	assert.False(t, q.dropPiggybacked(hb(2, "ctx")))
+	assert.True(t, q.dropPiggybacked(hb(3, "ctx")))
+	assert.Empty(t, q.piggybackCtx)
+	assert.False(t, q.dropPiggybacked(hb(3, "ctx")))
+
+	q.piggyback([]byte("ctx"), []uint64{2})
+	q.reset()
+	assert.False(t, q.dropPiggybacked(hb(2, "ctx")))
+}
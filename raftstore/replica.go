@@ -37,6 +37,7 @@ import (
 	"github.com/matrixorigin/matrixcube/util/task"
 	"go.etcd.io/etcd/raft/v3"
 	"go.etcd.io/etcd/raft/v3/raftpb"
+	trackerPkg "go.etcd.io/etcd/raft/v3/tracker"
 	"go.uber.org/zap"
 )
 
@@ -48,6 +49,7 @@ type replicaGetter interface {
 
 type snapshotStatus struct {
 	to       uint64
+	index    uint64
 	rejected bool
 }
 
@@ -101,10 +103,42 @@ type replica struct {
 	// pushedIndex is the log index that has been passed to the state machine to
 	// be applied
 	pushedIndex uint64
-	stats       *replicaStats
+	// pendingApplyEntries the committed entries pushed but not applied by the
+	// state machine, the apply is paused until the merge source is prepared, the
+	// entries are shipped by the WAL hook, or the disk space is reclaimed
+	pendingApplyEntries []raftpb.Entry
+	// mergePausedSince the time when the apply is paused by the commit merge log
+	mergePausedSince time.Time
+	// walShippedIndex the index of the last committed entry shipped by the WAL
+	// hook of the shard group
+	walShippedIndex uint64
+	// walUnshipped the number of the committed entries not shipped, the writes
+	// are rejected until they are shipped
+	walUnshipped uint64
+	stats        *replicaStats
+	// persistedStats the stats persisted last time
+	persistedStats replicaStats
+	readLoad       replicaReadLoad
+	// buckets the read and write stats of the key ranges of the shard, nil if
+	// the buckets are disabled
+	buckets *shardBuckets
+	// loadSplitter tracks the load of the shard to split it by the load, nil if
+	// the load based split is disabled
+	loadSplitter *loadSplitter
+	loadSplit    pendingLoadSplit
+	// compaction tracks the writes of the shard for the offline compaction
+	compaction replicaCompaction
+	// consistency the state of the latest consistency check of the replicas
+	consistency consistencyCheck
 	metrics     localMetrics
 
 	limiter *ratelimit.Bucket
+	// queueWait the moving average of the nanoseconds that requests wait in the
+	// request queue
+	queueWait int64
+	// flowControl tracks the proposals not applied to reject the writes when
+	// the shard is busy
+	flowControl replicaFlowControl
 
 	initialized bool
 	closedC     chan struct{}
@@ -116,6 +150,18 @@ type replica struct {
 	// necessarily up-to-date.
 	// this map must access in event worker
 	committedIndexes map[uint64]uint64 // replica-id -> committed index(saved into logdb)
+	// appliedIndexes the applied index last reported by all replicas, used to
+	// report the replica progresses to prophet.
+	// this map must access in event worker
+	appliedIndexes map[uint64]uint64 // replica-id -> applied index
+	// sentSnapshots the index of the snapshot last sent to the replicas, used
+	// to report the snapshot phase of the replicas to prophet.
+	// this map must access in event worker
+	sentSnapshots map[uint64]uint64 // replica-id -> snapshot index
+	// snapshotFailures the snapshot failures of the replicas, used to delay the
+	// snapshots to the failed replicas.
+	// this map must access in event worker
+	snapshotFailures *snapshotFailureTracker
 	// lastCommittedIndex last committed log
 	lastCommittedIndex uint64
 
@@ -181,6 +227,9 @@ func newReplica(store *store, shard Shard, r Replica, reason string) (*replica,
 		unloadedC:         make(chan struct{}),
 		destroyedC:        make(chan struct{}),
 		committedIndexes:  make(map[uint64]uint64),
+		appliedIndexes:    make(map[uint64]uint64),
+		sentSnapshots:     make(map[uint64]uint64),
+		snapshotFailures:  newSnapshotFailureTracker(store.cfg.Snapshot),
 		limiter: ratelimit.NewBucketWithRate(float64(store.cfg.Raft.LimitRequestBytesPerShard),
 			int64(store.cfg.Raft.LimitRequestBytesPerShard)),
 	}
@@ -196,9 +245,23 @@ func newReplica(store *store, shard Shard, r Replica, reason string) (*replica,
 			return newReplicaCreator(store)
 		},
 		pr.store.aware)
+	pr.sm.applyResultHandler = store.cfg.Customize.CustomApplyResultHandler
+	pr.sm.clock = store.hlcClock
+	pr.sm.mergeSourceGetter = store.getMergeSource
+	if store.cfg.ReadCache.Enable {
+		pr.sm.readCache = newReadCache(store.cfg.ReadCache.MaxEntries,
+			int(store.cfg.ReadCache.MaxValueBytes), store.cfg.Customize.CustomReadCacheKeyRangeFunc)
+	}
 	pr.destroyTaskFactory = newDefaultDestroyReplicaTaskFactory(pr.addAction,
 		pr.prophetClient, defaultCheckInterval)
-	pr.feature = storage.Feature()
+	pr.feature = store.getShardFeature(shard.Group)
+	if pr.feature.ShardBuckets > 0 {
+		pr.buckets = newShardBuckets()
+		pr.sm.buckets = pr.buckets
+	}
+	pr.loadSplitter = newLoadSplitter(pr.feature)
+	pr.sm.loadSplitter = pr.loadSplitter
+	pr.compaction = newReplicaCompaction(time.Now())
 	return pr, nil
 }
 
@@ -223,6 +286,10 @@ func (pr *replica) start(campaign bool) {
 		pr.logger.Fatal("failed to initialize confState",
 			zap.Error(err))
 	}
+	if err := pr.restoreStats(); err != nil {
+		pr.logger.Fatal("failed to restore shard stats",
+			zap.Error(err))
+	}
 	if _, err := pr.initLogState(); err != nil {
 		pr.logger.Fatal("failed to initialize log state",
 			zap.Error(err))
@@ -505,6 +572,34 @@ func (pr *replica) maybeExecRead() {
 }
 
 func (pr *replica) execReadRequest(req rpcpb.Request) {
+	pr.execRead(req, requestDone)
+}
+
+func (pr *replica) execStaleReadRequest(req rpcpb.Request) {
+	pr.execRead(req, staleReadDone)
+}
+
+func (pr *replica) execRead(req rpcpb.Request,
+	done func(rpcpb.Request, func(rpcpb.ResponseBatch), []byte)) {
+	sreq := storage.Request{
+		CmdType: req.CustomType,
+		Key:     req.Key,
+		Cmd:     req.Cmd,
+	}
+	if v, ok := pr.sm.readCache.get(sreq); ok {
+		pr.addAction(action{
+			actionType: updateReadMetrics,
+			readMetrics: readMetrics{
+				key:       req.Key,
+				readBytes: uint64(len(v)),
+				readKeys:  1,
+			},
+		})
+		done(req, pr.store.shardsProxy.OnResponse, v)
+		return
+	}
+
+	index := pr.appliedIndex
 	// FIXME: use an externally passed context instead of `context.Background()` for future tracking.
 	err := pr.readStopper.RunTask(context.Background(), func(ctx context.Context) {
 		select {
@@ -520,11 +615,7 @@ func (pr *replica) execReadRequest(req rpcpb.Request) {
 			defer releaseReadCtx(ctx)
 
 			// FIXME: pr.getShard() has a lock, it's a hot path.
-			ctx.reset(pr.getShard(), storage.Request{
-				CmdType: req.CustomType,
-				Key:     req.Key,
-				Cmd:     req.Cmd,
-			})
+			ctx.reset(pr.getShard(), sreq)
 
 			v, err := pr.sm.dataStorage.Read(ctx)
 			if err != nil {
@@ -532,16 +623,18 @@ func (pr *replica) execReadRequest(req rpcpb.Request) {
 				pr.logger.Fatal("fail to exec read batch",
 					zap.Error(err))
 			}
+			pr.sm.readCache.put(sreq, v, index)
 
 			pr.addAction(action{
 				actionType: updateReadMetrics,
 				readMetrics: readMetrics{
+					key:       req.Key,
 					readBytes: ctx.readBytes,
 					readKeys:  1,
 				},
 			})
 
-			requestDone(req, pr.store.shardsProxy.OnResponse, v)
+			done(req, pr.store.shardsProxy.OnResponse, v)
 		}
 	})
 	if err == stop.ErrUnavailable {
@@ -591,6 +684,61 @@ func (pr *replica) collectDownReplicas() []metapb.ReplicaStats {
 	return downReplicas
 }
 
+// collectReplicaProgresses returns the replication progress of all replicas
+// observed by the leader, including the leader itself.
+func (pr *replica) collectReplicaProgresses() []metapb.ReplicaProgress {
+	now := time.Now()
+	shard := pr.getShard()
+	status := pr.rn.Status()
+	firstIndex := pr.getFirstIndex()
+	progresses := make([]metapb.ReplicaProgress, 0, len(shard.Replicas))
+	for _, p := range shard.Replicas {
+		progress := metapb.ReplicaProgress{
+			Replica:      Replica{ID: p.ID, StoreID: p.StoreID},
+			AppliedIndex: pr.appliedIndexes[p.ID],
+		}
+		if p.ID == pr.replicaID {
+			progress.AppliedIndex = pr.appliedIndex
+			progress.LastContactTime = uint64(now.UnixMilli())
+		} else if value, ok := pr.replicaHeartbeatsMap.Load(p.ID); ok {
+			progress.LastContactTime = uint64(value.(time.Time).UnixMilli())
+		}
+		if rp, ok := status.Progress[p.ID]; ok {
+			progress.MatchIndex = rp.Match
+			// the logs required by the replica have been compacted
+			progress.NeedSnapshot = rp.State == trackerPkg.StateSnapshot ||
+				rp.Next < firstIndex
+			pr.updateSnapshotPhase(&progress)
+			pr.snapshotFailures.update(&progress)
+		}
+		progresses = append(progresses, progress)
+	}
+	return progresses
+}
+
+// updateSnapshotPhase sets the phase of the snapshot sent to the replica. The
+// snapshot is being applied if it was sent and the replica has not matched the
+// index of the snapshot yet.
+func (pr *replica) updateSnapshotPhase(progress *metapb.ReplicaProgress) {
+	id := progress.Replica.ID
+	if sent, total, ok := pr.transport.SnapshotProgress(pr.shardID, id); ok {
+		progress.SnapshotPhase = metapb.SnapshotPhase_SendingSnapshot
+		progress.SnapshotSentBytes = sent
+		progress.SnapshotTotalBytes = total
+		return
+	}
+	if index, ok := pr.sentSnapshots[id]; ok {
+		if progress.MatchIndex < index {
+			progress.SnapshotPhase = metapb.SnapshotPhase_ApplyingSnapshot
+			return
+		}
+		delete(pr.sentSnapshots, id)
+	}
+	if progress.NeedSnapshot {
+		progress.SnapshotPhase = metapb.SnapshotPhase_PendingSnapshot
+	}
+}
+
 // collectPendingReplicas returns a list of replicas that are potentially waiting for
 // snapshots from the leader.
 func (pr *replica) collectPendingReplicas() []Replica {
@@ -611,6 +759,8 @@ func (pr *replica) getTickHandledCount() uint64 {
 }
 
 func getRaftConfig(id, appliedIndex uint64, lr *LogReader, cfg *config.Config, logger *zap.Logger) *raft.Config {
+	// the lease reads are confirmed by the replica, so that the reads can fall
+	// back to the heartbeat round to the quorum while the clock is skewed
 	return &raft.Config{
 		ID:                        id,
 		Applied:                   appliedIndex,
@@ -622,6 +772,7 @@ func getRaftConfig(id, appliedIndex uint64, lr *LogReader, cfg *config.Config, l
 		CheckQuorum:               true,
 		PreVote:                   true,
 		DisableProposalForwarding: true,
+		ReadOnlyOption:            raft.ReadOnlySafe,
 		Logger:                    &etcdRaftLoggerAdapter{logger: logger.Sugar()},
 	}
 }
@@ -44,6 +44,13 @@ type adminResult struct {
 	compactionResult     compactionResult
 	updateMetadataResult updateMetadataResult
 	updateLabelsResult   updateLabelsResult
+	mergeResult          mergeResult
+	computeHashResult    computeHashResult
+	verifyHashResult     verifyHashResult
+}
+
+type mergeResult struct {
+	source Shard
 }
 
 type updateLabelsResult struct {
@@ -88,6 +95,7 @@ func (pr *replica) updateAppliedIndex(result applyResult) {
 	pr.appliedIndex = result.index
 	pr.maybeSetLeaseReadReady()
 	pr.maybeExecRead()
+	pr.updateFlowControl()
 }
 
 func (pr *replica) updateMetricsHints(result applyResult) {
@@ -95,6 +103,12 @@ func (pr *replica) updateMetricsHints(result applyResult) {
 
 	pr.stats.writtenBytes += result.metrics.writtenBytes
 	pr.stats.writtenKeys += result.metrics.writtenKeys
+	if result.metrics.writtenKeys > 0 {
+		pr.compaction.recordWrite(time.Now(), result.metrics.deletedBytesHint)
+	}
+	if result.metrics.sizeStale {
+		pr.compaction.markSizeStale()
+	}
 	if result.hasSplitResult() {
 		pr.stats.deleteKeysHint = result.metrics.deleteKeysHint
 		pr.stats.approximateSize = result.metrics.approximateDiffHint
@@ -116,7 +130,52 @@ func (pr *replica) handleAdminResult(result applyResult) {
 		pr.applyUpdateMetadataResult(result.adminResult.updateMetadataResult)
 	case rpcpb.CmdUpdateLabels:
 		pr.applyUpdateLabels(result.adminResult.updateLabelsResult)
+	case rpcpb.CmdPrepareMerge:
+		pr.applyPrepareMerge()
+	case rpcpb.CmdCommitMerge:
+		pr.applyCommitMerge(result.adminResult.mergeResult)
+	case rpcpb.CmdRollbackMerge:
+		pr.applyRollbackMerge()
+	case rpcpb.CmdComputeHash:
+		pr.applyComputeHash(result.index, result.adminResult.computeHashResult)
+	case rpcpb.CmdVerifyHash:
+		pr.applyVerifyHash(result.adminResult.verifyHashResult)
+	}
+}
+
+func (pr *replica) applyPrepareMerge() {
+	if pr.aware != nil {
+		pr.aware.Updated(pr.getShard())
+	}
+	// notify prophet immediately, prophet sends the commit merge to the target
+	// shard once all the replicas prepared.
+	pr.prophetHeartbeat()
+}
+
+func (pr *replica) applyRollbackMerge() {
+	if pr.aware != nil {
+		pr.aware.Updated(pr.getShard())
+	}
+	// notify prophet immediately, prophet sends the rollback merge to the source
+	// shard once the target shard rejected the merge.
+	pr.prophetHeartbeat()
+}
+
+func (pr *replica) applyCommitMerge(result mergeResult) {
+	shard := pr.getShard()
+	pr.logger.Info("shard merge applied, source shard will destroy",
+		log.ShardField("source", result.source),
+		log.ShardField("shard", shard))
+
+	pr.store.updateShardKeyRange(shard.Group, shard)
+	if pr.aware != nil {
+		pr.aware.Updated(shard)
 	}
+	pr.prophetHeartbeat()
+	// the data of the source shard belongs to the current shard now, and the
+	// size is refreshed later
+	pr.compaction.markSizeStale()
+	pr.store.destroyReplica(result.source.ID, true, false, "merged")
 }
 
 func (pr *replica) applyUpdateMetadataResult(cp updateMetadataResult) {
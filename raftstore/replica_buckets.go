@@ -0,0 +1,164 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+)
+
+const (
+	defaultShardBucketRefreshDuration = time.Minute * 10
+	// bucketSampleFactor the number of the keys sampled for each bucket, the
+	// boundaries of the buckets are picked from the sampled keys evenly
+	bucketSampleFactor = 8
+)
+
+// shardBuckets the read and write stats of the key ranges of the replica, the
+// bucket i is [keys[i-1], keys[i]), the first and the last bucket are bounded by
+// the range of the shard. It's only accessed by the event worker of the replica.
+type shardBuckets struct {
+	keys  [][]byte
+	stats []metapb.ShardBucket
+}
+
+func newShardBuckets() *shardBuckets {
+	b := &shardBuckets{}
+	b.reset(nil)
+	return b
+}
+
+// reset updates the boundaries of the buckets and clears the stats
+func (b *shardBuckets) reset(keys [][]byte) {
+	b.keys = keys
+	b.stats = make([]metapb.ShardBucket, len(keys)+1)
+}
+
+func (b *shardBuckets) bucket(key []byte) *metapb.ShardBucket {
+	i := sort.Search(len(b.keys), func(i int) bool {
+		return bytes.Compare(b.keys[i], key) > 0
+	})
+	return &b.stats[i]
+}
+
+func (b *shardBuckets) addRead(key []byte, readBytes, readKeys uint64) {
+	if b == nil {
+		return
+	}
+	s := b.bucket(key)
+	s.ReadBytes += readBytes
+	s.ReadKeys += readKeys
+}
+
+func (b *shardBuckets) addWrite(key []byte, writtenBytes, writtenKeys uint64) {
+	if b == nil {
+		return
+	}
+	s := b.bucket(key)
+	s.WrittenBytes += writtenBytes
+	s.WrittenKeys += writtenKeys
+}
+
+// collect returns the buckets within the range of the shard with the stats
+// since the last collect and clears the stats. The boundaries out of the range
+// are skipped since the shard may be split after they sampled, the stats of the
+// skipped buckets are merged into the adjacent bucket.
+func (b *shardBuckets) collect(shard Shard) []metapb.ShardBucket {
+	if b == nil {
+		return nil
+	}
+
+	buckets := make([]metapb.ShardBucket, 0, len(b.stats))
+	current := metapb.ShardBucket{ShardID: shard.ID, Start: shard.Start}
+	for i, s := range b.stats {
+		current.WrittenBytes += s.WrittenBytes
+		current.WrittenKeys += s.WrittenKeys
+		current.ReadBytes += s.ReadBytes
+		current.ReadKeys += s.ReadKeys
+		if i == len(b.keys) {
+			break
+		}
+		key := b.keys[i]
+		if bytes.Compare(key, shard.Start) <= 0 ||
+			(len(shard.End) > 0 && bytes.Compare(key, shard.End) >= 0) {
+			continue
+		}
+		current.End = key
+		buckets = append(buckets, current)
+		current = metapb.ShardBucket{ShardID: shard.ID, Start: key}
+	}
+	current.End = shard.End
+	buckets = append(buckets, current)
+
+	for i := range b.stats {
+		b.stats[i] = metapb.ShardBucket{}
+	}
+	return buckets
+}
+
+// bucketBoundaries picks the boundaries of the buckets from the sorted sampled
+// keys, every sampled key starts a bucket if there are not enough samples.
+func bucketBoundaries(samples [][]byte, buckets int) [][]byte {
+	if len(samples) < buckets {
+		return samples
+	}
+	keys := make([][]byte, 0, buckets-1)
+	for i := 1; i < buckets; i++ {
+		keys = append(keys, samples[i*len(samples)/buckets])
+	}
+	return keys
+}
+
+func (pr *replica) doUpdateBuckets(act action) {
+	if pr.buckets == nil ||
+		pr.getShard().Epoch.Generation != act.epoch.Generation {
+		return
+	}
+	pr.buckets.reset(act.bucketKeys)
+}
+
+// handleBucketRefreshTask samples the boundaries of the buckets of the leader
+// replicas of the group.
+func (s *store) handleBucketRefreshTask(group uint64) {
+	sampler, ok := s.DataStorageByGroup(group).(storage.KeySampler)
+	if !ok {
+		return
+	}
+	n := s.getShardFeature(group).ShardBuckets
+	s.forEachReplica(func(pr *replica) bool {
+		if pr.group != group || !pr.isLeader() {
+			return true
+		}
+
+		shard := pr.getShard()
+		samples, err := sampler.SampleKeys(shard.ID, n*bucketSampleFactor)
+		if err != nil {
+			pr.logger.Error("fail to sample bucket keys, retry later",
+				zap.Error(err))
+			return true
+		}
+		pr.addAction(action{
+			actionType: updateBucketsAction,
+			epoch:      shard.Epoch,
+			bucketKeys: bucketBoundaries(samples, n),
+		})
+		return true
+	})
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+func TestShardBuckets(t *testing.T) {
+	var disabled *shardBuckets
+	disabled.addRead([]byte("a"), 1, 1)
+	disabled.addWrite([]byte("a"), 1, 1)
+	assert.Nil(t, disabled.collect(Shard{ID: 1}))
+
+	b := newShardBuckets()
+	b.addRead([]byte("a"), 10, 1)
+	assert.Equal(t, []metapb.ShardBucket{{ShardID: 1, ReadBytes: 10, ReadKeys: 1}},
+		b.collect(Shard{ID: 1}))
+
+	b.reset([][]byte{[]byte("b"), []byte("d")})
+	b.addRead([]byte("a"), 10, 1)
+	b.addWrite([]byte("b"), 20, 1)
+	b.addWrite([]byte("c"), 20, 1)
+	b.addRead([]byte("e"), 30, 1)
+	buckets := b.collect(Shard{ID: 1})
+	assert.Equal(t, []metapb.ShardBucket{
+		{ShardID: 1, End: []byte("b"), ReadBytes: 10, ReadKeys: 1},
+		{ShardID: 1, Start: []byte("b"), End: []byte("d"), WrittenBytes: 40, WrittenKeys: 2},
+		{ShardID: 1, Start: []byte("d"), ReadBytes: 30, ReadKeys: 1},
+	}, buckets)
+	for _, bucket := range b.collect(Shard{ID: 1}) {
+		assert.Equal(t, uint64(0), bucket.ReadBytes+bucket.WrittenBytes, "cleared after collected")
+	}
+
+	// the shard is split at c after the boundaries sampled
+	b.addWrite([]byte("c"), 20, 1)
+	b.addRead([]byte("e"), 30, 1)
+	buckets = b.collect(Shard{ID: 1, Start: []byte("c")})
+	assert.Equal(t, []metapb.ShardBucket{
+		{ShardID: 1, Start: []byte("c"), End: []byte("d"), WrittenBytes: 20, WrittenKeys: 1},
+		{ShardID: 1, Start: []byte("d"), ReadBytes: 30, ReadKeys: 1},
+	}, buckets)
+	b.addWrite([]byte("a"), 20, 1)
+	buckets = b.collect(Shard{ID: 1, End: []byte("b")})
+	assert.Equal(t, []metapb.ShardBucket{
+		{ShardID: 1, End: []byte("b"), WrittenBytes: 20, WrittenKeys: 1},
+	}, buckets)
+}
+
+func TestBucketBoundaries(t *testing.T) {
+	var samples [][]byte
+	for i := 0; i < 8; i++ {
+		samples = append(samples, []byte{byte(i)})
+	}
+	assert.Equal(t, [][]byte{{2}, {4}, {6}}, bucketBoundaries(samples, 4))
+	assert.Equal(t, samples[:3], bucketBoundaries(samples[:3], 4))
+	assert.Empty(t, bucketBoundaries(nil, 4))
+}
+
+func TestUpdateBuckets(t *testing.T) {
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	pr := newTestReplica(Shard{ID: 1, Epoch: Epoch{Generation: 2}}, Replica{ID: 1}, s)
+	pr.doUpdateBuckets(action{bucketKeys: [][]byte{[]byte("b")}})
+	assert.Nil(t, pr.buckets, "disabled")
+
+	pr.buckets = newShardBuckets()
+	pr.doUpdateBuckets(action{epoch: Epoch{Generation: 1}, bucketKeys: [][]byte{[]byte("b")}})
+	assert.Empty(t, pr.buckets.keys, "stale epoch")
+	pr.doUpdateBuckets(action{epoch: Epoch{Generation: 2}, bucketKeys: [][]byte{[]byte("b")}})
+	assert.Equal(t, [][]byte{[]byte("b")}, pr.buckets.keys)
+	assert.Equal(t, 2, len(pr.buckets.stats))
+}
@@ -0,0 +1,55 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/pb/errorpb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+const (
+	// queueWaitSmoothing the weight of the latest sample is 1/queueWaitSmoothing
+	queueWaitSmoothing = 8
+)
+
+// observeQueueWait updates the moving average of the queue wait, it's called in
+// the event worker when the request is taken from the request queue.
+func (pr *replica) observeQueueWait(wait time.Duration) {
+	old := atomic.LoadInt64(&pr.queueWait)
+	atomic.StoreInt64(&pr.queueWait, old+(int64(wait)-old)/queueWaitSmoothing)
+}
+
+// checkBusy returns the load hints if the request should be rejected because
+// too many requests are waiting in the request queue. The admin requests are
+// never rejected, they are used to bring the cluster back to normal.
+func (pr *replica) checkBusy(req rpcpb.Request) (*errorpb.ServerIsBusy, bool) {
+	limit := pr.cfg.Raft.MaxPendingRequestsPerShard
+	if limit <= 0 || req.Type == rpcpb.Admin {
+		return nil, false
+	}
+
+	depth := pr.requests.Len()
+	if depth < int64(limit) {
+		return nil, false
+	}
+
+	wait := time.Duration(atomic.LoadInt64(&pr.queueWait))
+	return &errorpb.ServerIsBusy{
+		QueueDepth:      uint64(depth),
+		EstimatedWaitMS: uint64(wait.Milliseconds()),
+	}, true
+}
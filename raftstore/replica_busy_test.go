@@ -0,0 +1,56 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+)
+
+func TestReplicaCheckBusy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	pr := newTestReplica(Shard{ID: 1}, Replica{ID: 1}, s)
+	req := rpcpb.Request{ID: []byte("k1"), Type: rpcpb.Write}
+
+	// no limit
+	_, ok := pr.checkBusy(req)
+	assert.False(t, ok)
+
+	pr.cfg.Raft.MaxPendingRequestsPerShard = 2
+	assert.NoError(t, pr.requests.Put(newReqCtx(req, nil)))
+	_, ok = pr.checkBusy(req)
+	assert.False(t, ok)
+
+	assert.NoError(t, pr.requests.Put(newReqCtx(req, nil)))
+	for i := 0; i < 100; i++ {
+		pr.observeQueueWait(time.Second)
+	}
+	busy, ok := pr.checkBusy(req)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), busy.QueueDepth)
+	assert.True(t, busy.EstimatedWaitMS > 900 && busy.EstimatedWaitMS <= 1000)
+
+	// admin requests are never rejected
+	_, ok = pr.checkBusy(rpcpb.Request{Type: rpcpb.Admin})
+	assert.False(t, ok)
+}
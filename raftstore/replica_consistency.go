@@ -0,0 +1,187 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"context"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/metric"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+	"github.com/matrixorigin/matrixcube/util/buf"
+	"go.uber.org/zap"
+)
+
+// consistencyCheck the state of the latest consistency check of the replica.
+// All the replicas compute the hash of the shard data in the view taken at the
+// index of the applied CmdComputeHash, then the leader proposes its hash with
+// CmdVerifyHash, and all the replicas compare the hash with their own.
+type consistencyCheck struct {
+	// index the index of the CmdComputeHash, 0 if there is no check
+	index uint64
+	// computed the hash of the local replica is computed
+	computed bool
+	hash     uint64
+	// verifying the CmdVerifyHash is applied before the local hash is computed,
+	// expected is compared once the local hash is computed
+	verifying bool
+	expected  uint64
+}
+
+type computeHashResult struct {
+	// view the view of the shard data at the index, nil if the data storage
+	// does not support the consistency check
+	view  storage.View
+	kv    storage.KVStorage
+	shard Shard
+}
+
+type verifyHashResult struct {
+	index uint64
+	hash  uint64
+}
+
+type consistencyHash struct {
+	index uint64
+	hash  uint64
+	err   error
+}
+
+func (d *stateMachine) doExecComputeHash(ctx *applyContext) (rpcpb.ResponseBatch, error) {
+	result := computeHashResult{shard: d.getShard()}
+	if wrapper, ok := d.dataStorage.(storage.KVStorageWrapper); ok {
+		result.kv = wrapper.GetKVStorage()
+		// the keys expired by the local clock are hashed until they're purged
+		if ttlStore, ok := result.kv.(storage.TTLStore); ok {
+			result.kv = ttlStore.WithoutTTL()
+		}
+		// the data of the shard in the view is the data at the index, since the
+		// writes are applied to the data storage one by one
+		result.view = result.kv.GetView()
+	}
+
+	resp := newAdminResponseBatch(rpcpb.CmdComputeHash, &rpcpb.ComputeHashResponse{})
+	ctx.adminResult = &adminResult{
+		adminType:         rpcpb.CmdComputeHash,
+		computeHashResult: result,
+	}
+	return resp, nil
+}
+
+func (d *stateMachine) doExecVerifyHash(ctx *applyContext) (rpcpb.ResponseBatch, error) {
+	req := ctx.req.GetVerifyHashRequest()
+	resp := newAdminResponseBatch(rpcpb.CmdVerifyHash, &rpcpb.VerifyHashResponse{})
+	ctx.adminResult = &adminResult{
+		adminType: rpcpb.CmdVerifyHash,
+		verifyHashResult: verifyHashResult{
+			index: req.Index,
+			hash:  req.Hash,
+		},
+	}
+	return resp, nil
+}
+
+// applyComputeHash computes the hash of the shard data in the view
+// asynchronously, the view is closed once the hash is computed.
+func (pr *replica) applyComputeHash(index uint64, result computeHashResult) {
+	if result.view == nil {
+		pr.logger.Warn("skip consistency check",
+			log.IndexField(index),
+			log.ReasonField("data storage not supported"))
+		return
+	}
+
+	pr.consistency = consistencyCheck{index: index}
+	err := pr.readStopper.RunTask(context.Background(), func(ctx context.Context) {
+		defer result.view.Close()
+		buffer := buf.NewByteBuf(128)
+		defer buffer.Release()
+
+		checksum, err := executor.ChecksumInView(result.kv, result.view,
+			result.shard.Start, result.shard.End, buffer)
+		pr.addAction(action{
+			actionType: consistencyHashAction,
+			consistencyHash: consistencyHash{
+				index: index,
+				hash:  checksum.Checksum,
+				err:   err,
+			},
+		})
+	})
+	if err != nil {
+		result.view.Close()
+	}
+}
+
+func (pr *replica) doConsistencyHashComputed(act action) {
+	computed := act.consistencyHash
+	if computed.index != pr.consistency.index {
+		return
+	}
+	if computed.err != nil {
+		pr.logger.Error("failed to compute the hash of the shard data",
+			log.IndexField(computed.index),
+			zap.Error(computed.err))
+		pr.consistency = consistencyCheck{}
+		return
+	}
+
+	pr.consistency.computed = true
+	pr.consistency.hash = computed.hash
+	if pr.consistency.verifying {
+		pr.verifyHash(pr.consistency.expected)
+		return
+	}
+	if pr.isLeader() {
+		pr.addAdminRequest(rpcpb.CmdVerifyHash, &rpcpb.VerifyHashRequest{
+			Index: computed.index,
+			Hash:  computed.hash,
+		})
+	}
+}
+
+func (pr *replica) applyVerifyHash(result verifyHashResult) {
+	if result.index != pr.consistency.index {
+		// the replica restarted or started a newer check after the hash of the
+		// leader computed
+		pr.logger.Info("skip consistency check",
+			log.IndexField(result.index),
+			log.ReasonField("local hash not found"))
+		return
+	}
+	if !pr.consistency.computed {
+		pr.consistency.verifying = true
+		pr.consistency.expected = result.hash
+		return
+	}
+	pr.verifyHash(result.hash)
+}
+
+func (pr *replica) verifyHash(expected uint64) {
+	if pr.consistency.hash != expected {
+		metric.IncConsistencyCheckCount("mismatch")
+		pr.logger.Error("shard data is inconsistent with the leader",
+			log.IndexField(pr.consistency.index),
+			zap.Uint64("hash", pr.consistency.hash),
+			zap.Uint64("leader-hash", expected))
+	} else {
+		metric.IncConsistencyCheckCount("match")
+		pr.logger.Info("shard data is consistent with the leader",
+			log.IndexField(pr.consistency.index),
+			zap.Uint64("hash", expected))
+	}
+	pr.consistency = consistencyCheck{}
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+	"github.com/matrixorigin/matrixcube/util/buf"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsistencyCheck(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	pr := newTestReplica(Shard{ID: 1, Start: []byte{1}, End: []byte{10}}, Replica{ID: 100}, s)
+	defer pr.readStopper.Stop()
+	kv := pr.sm.dataStorage.(storage.KVStorageWrapper).GetKVStorage()
+	assert.NoError(t, kv.Set(keysutil.EncodeDataKey([]byte{1}, nil), []byte("v1"), false))
+	assert.NoError(t, kv.Set(keysutil.EncodeDataKey([]byte{10}, nil), []byte("v10"), false))
+
+	view := kv.GetView()
+	expected, err := executor.ChecksumInView(kv, view, []byte{1}, []byte{10}, buf.NewByteBuf(32))
+	require.NoError(t, err)
+	view.Close()
+	assert.Equal(t, uint64(1), expected.Keys)
+
+	computeHash := func(index uint64) action {
+		ctx := newApplyContext()
+		_, err := pr.sm.doExecComputeHash(ctx)
+		require.NoError(t, err)
+		pr.handleAdminResult(applyResult{index: index, adminResult: ctx.adminResult})
+
+		items := make([]interface{}, 1)
+		for pr.actions.Len() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		_, err = pr.actions.Get(1, items)
+		require.NoError(t, err)
+		act := items[0].(action)
+		assert.Equal(t, consistencyHashAction, act.actionType)
+		return act
+	}
+
+	// the hash of the leader is verified after the local hash computed
+	act := computeHash(10)
+	assert.Equal(t, consistencyHash{index: 10, hash: expected.Checksum}, act.consistencyHash)
+	pr.doConsistencyHashComputed(act)
+	assert.Equal(t, consistencyCheck{index: 10, computed: true, hash: expected.Checksum}, pr.consistency)
+	assert.Equal(t, int64(0), pr.requests.Len(), "only the leader proposes the hash")
+	pr.applyVerifyHash(verifyHashResult{index: 9, hash: expected.Checksum})
+	assert.Equal(t, uint64(10), pr.consistency.index, "stale verify requests are skipped")
+	pr.applyVerifyHash(verifyHashResult{index: 10, hash: expected.Checksum})
+	assert.Equal(t, consistencyCheck{}, pr.consistency)
+
+	// the hash of the leader is verified before the local hash computed
+	act = computeHash(20)
+	pr.applyVerifyHash(verifyHashResult{index: 20, hash: expected.Checksum + 1})
+	assert.Equal(t, consistencyCheck{index: 20, verifying: true, expected: expected.Checksum + 1}, pr.consistency)
+	pr.doConsistencyHashComputed(act)
+	assert.Equal(t, consistencyCheck{}, pr.consistency)
+
+	// the leader proposes its hash
+	pr.leaderID = pr.replicaID
+	pr.doConsistencyHashComputed(computeHash(30))
+	require.Equal(t, int64(1), pr.requests.Len())
+	items := make([]interface{}, 1)
+	_, err = pr.requests.Get(1, items)
+	require.NoError(t, err)
+	req := items[0].(reqCtx).req
+	assert.Equal(t, rpcpb.Admin, req.Type)
+	assert.Equal(t, uint64(rpcpb.CmdVerifyHash), req.CustomType)
+	assert.Equal(t, uint64(30), pr.consistency.index)
+}
@@ -14,6 +14,8 @@
 package raftstore
 
 import (
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/fagongzi/util/protoc"
@@ -44,6 +46,8 @@ type replicaCreator struct {
 	campaign                          bool
 	afterStartedFunc, beforeStartFunc func(*replica)
 	replicaRecordGetter               func(Shard) Replica
+	startWorkers                      int
+	startPriority                     func(Shard) bool
 	wc                                *logdb.WorkerContext
 	logger                            *zap.Logger
 	shardsMetadata                    []metapb.ShardMetadata
@@ -77,6 +81,14 @@ func (rc *replicaCreator) withStartReplica(campaign bool,
 	return rc
 }
 
+// withParallelStart starts the replicas by the workers concurrently, and the
+// replicas which priority func returns true are started first.
+func (rc *replicaCreator) withParallelStart(workers int, priority func(Shard) bool) *replicaCreator {
+	rc.startWorkers = workers
+	rc.startPriority = priority
+	return rc
+}
+
 func (rc *replicaCreator) withSaveMetadata(sync bool) *replicaCreator {
 	rc.sync = sync
 	rc.saveMetadata = true
@@ -220,7 +232,8 @@ func (rc *replicaCreator) maybeStartReplicas(shards []Shard, replicas []*replica
 		time.Sleep(rc.store.cfg.Test.SaveDynamicallyShardInitStateWait)
 	}
 
-	for idx, pr := range replicas {
+	startReplica := func(idx int) {
+		pr := replicas[idx]
 		if rc.beforeStartFunc != nil {
 			rc.beforeStartFunc(pr)
 		}
@@ -234,6 +247,39 @@ func (rc *replicaCreator) maybeStartReplicas(shards []Shard, replicas []*replica
 		}
 	}
 
+	order := make([]int, 0, len(replicas))
+	for idx := range replicas {
+		order = append(order, idx)
+	}
+	if rc.startPriority != nil {
+		sort.SliceStable(order, func(i, j int) bool {
+			return rc.startPriority(shards[order[i]]) && !rc.startPriority(shards[order[j]])
+		})
+	}
+
+	if rc.startWorkers <= 1 {
+		for _, idx := range order {
+			startReplica(idx)
+		}
+	} else {
+		var wg sync.WaitGroup
+		idxC := make(chan int)
+		for i := 0; i < rc.startWorkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for idx := range idxC {
+					startReplica(idx)
+				}
+			}()
+		}
+		for _, idx := range order {
+			idxC <- idx
+		}
+		close(idxC)
+		wg.Wait()
+	}
+
 	groupBy := groupShardByGroupID(shards)
 	for g, shards := range groupBy {
 		rc.store.updateShardKeyRange(g, shards...)
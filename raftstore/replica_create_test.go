@@ -1,6 +1,7 @@
 package raftstore
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/matrixorigin/matrixcube/logdb"
@@ -39,6 +40,47 @@ func TestShardCreateWithStart(t *testing.T) {
 	assert.Equal(t, logdb.ErrNoSavedLog, err)
 }
 
+func TestShardCreateWithParallelStart(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, closeFunc := newTestStore(t)
+	defer closeFunc()
+
+	db := NewTestDataBuilder()
+	var started []uint64
+	newReplicaCreator(s).
+		withReason("TestShardCreateWithParallelStart").
+		withParallelStart(1, func(shard Shard) bool { return shard.ID == 3 }).
+		withStartReplica(false, nil, func(r *replica) {
+			started = append(started, r.shardID)
+		}).
+		create([]Shard{
+			db.CreateShard(1, "1/0"),
+			db.CreateShard(2, "2/0"),
+			db.CreateShard(3, "3/0"),
+		})
+	assert.Equal(t, []uint64{3, 1, 2}, started)
+
+	var mu sync.Mutex
+	started = started[:0]
+	newReplicaCreator(s).
+		withReason("TestShardCreateWithParallelStart").
+		withParallelStart(4, nil).
+		withStartReplica(false, nil, func(r *replica) {
+			mu.Lock()
+			defer mu.Unlock()
+			started = append(started, r.shardID)
+		}).
+		create([]Shard{
+			db.CreateShard(4, "4/0"),
+			db.CreateShard(5, "5/0"),
+			db.CreateShard(6, "6/0"),
+		})
+	assert.ElementsMatch(t, []uint64{4, 5, 6}, started)
+	for id := uint64(1); id <= 6; id++ {
+		assert.NotNil(t, s.getReplica(id, false))
+	}
+}
+
 func testShardCreateWithSaveMetadataWithSync(t *testing.T, sync bool) {
 	defer leaktest.AfterTest(t)()
 	s, closeFunc := newTestStore(t)
@@ -170,6 +170,7 @@ func (pr *replica) destroy(shardRemoved bool, reason string) error {
 	if shardRemoved {
 		pr.sm.setShardState(metapb.ShardState_Destroyed)
 	}
+	pr.forgetProphetHeartbeat()
 
 	// Use last applied index as Tombstone metadata's log index. And any logs are
 	// not executed by the state machine anymore. So the index+1's metedata never
@@ -15,6 +15,7 @@ package raftstore
 
 import (
 	"testing"
+	"time"
 
 	"github.com/matrixorigin/matrixcube/components/log"
 	"github.com/matrixorigin/matrixcube/config"
@@ -96,7 +97,8 @@ func TestDestroyReplica(t *testing.T) {
 	assert.NoError(t, err)
 	pr.waitDestroyed()
 	assert.Nil(t, s.getReplica(1, false))
-	assert.Equal(t, 0, scan())
+	// the data is removed in background
+	assert.Eventually(t, func() bool { return scan() == 0 }, testWaitTimeout, time.Millisecond*10)
 	smd, err := pr.sm.dataStorage.GetInitialStates()
 	require.NoError(t, err)
 	require.Empty(t, smd)
@@ -183,7 +185,12 @@ func testDestroyReplicaWithRemoveShardDataAfterRestart(t *testing.T, removeData
 	if !removeData {
 		assert.Equal(t, "v1", string(v))
 	} else {
-		assert.Empty(t, v)
+		// the data is removed in background
+		assert.Eventually(t, func() bool {
+			v, err := kvs.Get(keysutil.EncodeDataKey([]byte("k1"), nil))
+			assert.NoError(t, err)
+			return len(v) == 0
+		}, testWaitTimeout, time.Millisecond*10)
 	}
 
 	v, err = kvs.Get(keysutil.EncodeDataKey([]byte("k2"), nil))
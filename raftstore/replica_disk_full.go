@@ -0,0 +1,146 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/pb/errorpb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+const (
+	// PausedShardsPath the http path of the paused shards admin API
+	PausedShardsPath = "/debug/paused-shards"
+
+	// pauseReasonDiskFull the apply is paused because the disk is full
+	pauseReasonDiskFull = "disk full"
+)
+
+// PausedShard the shard whose apply is paused on the store
+type PausedShard struct {
+	ShardID uint64    `json:"shard"`
+	Reason  string    `json:"reason"`
+	Since   time.Time `json:"since"`
+	// AppliedIndex the index of the last applied log, the apply is resumed from
+	// the next log
+	AppliedIndex uint64 `json:"applied-index"`
+}
+
+// pauseByDiskFull pauses the apply at the write log failed to be applied
+// because the disk is full, it's retried by the replica tick until the space
+// is reclaimed.
+func (d *stateMachine) pauseByDiskFull(index uint64) {
+	if atomic.CompareAndSwapInt64(&d.diskFullSince, 0, time.Now().UnixNano()) {
+		d.logger.Error("apply paused",
+			log.IndexField(index),
+			log.ReasonField(pauseReasonDiskFull))
+	}
+}
+
+// resumeFromDiskFull is called once a log is applied
+func (d *stateMachine) resumeFromDiskFull(index uint64) {
+	if since := atomic.SwapInt64(&d.diskFullSince, 0); since > 0 {
+		d.logger.Info("apply resumed",
+			log.IndexField(index),
+			zap.Duration("paused", time.Since(time.Unix(0, since))))
+	}
+}
+
+// getDiskFullSince returns the time when the apply is paused by the disk full,
+// false if the apply is not paused.
+func (d *stateMachine) getDiskFullSince() (time.Time, bool) {
+	since := atomic.LoadInt64(&d.diskFullSince)
+	if since == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, since), true
+}
+
+// checkDiskFull returns the load hints if the request should be rejected
+// because the apply of the replica is paused by the disk full. The admin
+// requests are never rejected.
+func (pr *replica) checkDiskFull(req rpcpb.Request) (*errorpb.ServerIsBusy, bool) {
+	if req.Type == rpcpb.Admin || pr.sm == nil {
+		return nil, false
+	}
+	if _, ok := pr.sm.getDiskFullSince(); !ok {
+		return nil, false
+	}
+	return &errorpb.ServerIsBusy{}, true
+}
+
+// PausedShards returns the shards whose apply is paused on the store, ordered
+// by the shard id.
+func (s *store) PausedShards() []PausedShard {
+	var shards []PausedShard
+	s.forEachReplica(func(pr *replica) bool {
+		if pr.sm == nil {
+			return true
+		}
+		if since, ok := pr.sm.getDiskFullSince(); ok {
+			index, _ := pr.sm.getAppliedIndexTerm()
+			shards = append(shards, PausedShard{
+				ShardID:      pr.getShardID(),
+				Reason:       pauseReasonDiskFull,
+				Since:        since,
+				AppliedIndex: index,
+			})
+		}
+		return true
+	})
+	sort.Slice(shards, func(i, j int) bool {
+		return shards[i].ShardID < shards[j].ShardID
+	})
+	return shards
+}
+
+// hasDiskFullShards returns true if the apply of any shard on the store is
+// paused by the disk full
+func (s *store) hasDiskFullShards() bool {
+	found := false
+	s.forEachReplica(func(pr *replica) bool {
+		if pr.sm != nil {
+			_, found = pr.sm.getDiskFullSince()
+		}
+		return !found
+	})
+	return found
+}
+
+// NewPausedShardsHandler returns the http handler of the paused shards admin
+// API, GET returns the result of `Store.PausedShards` in JSON.
+func NewPausedShardsHandler(s Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		shards := s.PausedShards()
+		if shards == nil {
+			shards = []PausedShard{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(shards); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
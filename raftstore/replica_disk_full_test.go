@@ -0,0 +1,101 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/fagongzi/util/protoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+)
+
+// diskFullDataStorage fails the writes with ENOSPC if the disk is full
+type diskFullDataStorage struct {
+	storage.DataStorage
+	full bool
+}
+
+func (s *diskFullDataStorage) Write(ctx storage.WriteContext) error {
+	if s.full {
+		return fmt.Errorf("write batch: %w", syscall.ENOSPC)
+	}
+	return s.DataStorage.Write(ctx)
+}
+
+func TestStateMachinePausedByDiskFull(t *testing.T) {
+	h := &testReplicaResultHandler{}
+	f := func(sm *stateMachine) {
+		ds := &diskFullDataStorage{DataStorage: sm.dataStorage, full: true}
+		sm.dataStorage = ds
+		pr := &replica{sm: sm}
+
+		var entries []raftpb.Entry
+		for i := uint64(1); i <= 2; i++ {
+			key := []byte(fmt.Sprintf("k%d", i))
+			batch := rpcpb.RequestBatch{
+				Header: rpcpb.RequestBatchHeader{ID: []byte{byte(i)}, ShardID: 100},
+				Requests: []rpcpb.Request{{
+					Type:       rpcpb.Write,
+					Key:        key,
+					CustomType: uint64(rpcpb.CmdKVSet),
+					Cmd:        protoc.MustMarshal(&rpcpb.KVSetRequest{Key: key, Value: key}),
+				}},
+			}
+			entries = append(entries, raftpb.Entry{
+				Index: i,
+				Term:  1,
+				Type:  raftpb.EntryNormal,
+				Data:  protoc.MustMarshal(&batch),
+			})
+		}
+
+		// paused at the first write, the proposal is kept pending
+		remain := sm.applyCommittedEntries(entries)
+		assert.Equal(t, entries, remain)
+		index, _ := sm.getAppliedIndexTerm()
+		assert.Equal(t, uint64(0), index)
+		assert.Equal(t, uint64(0), h.notified)
+		_, paused := sm.getDiskFullSince()
+		assert.True(t, paused)
+		_, busy := pr.checkDiskFull(rpcpb.Request{Type: rpcpb.Write})
+		assert.True(t, busy)
+		_, busy = pr.checkDiskFull(rpcpb.Request{Type: rpcpb.Read})
+		assert.True(t, busy)
+		_, busy = pr.checkDiskFull(rpcpb.Request{Type: rpcpb.Admin})
+		assert.False(t, busy)
+
+		// still paused on retry
+		assert.Equal(t, entries, sm.applyCommittedEntries(remain))
+
+		// resumed once the space is reclaimed
+		ds.full = false
+		assert.Empty(t, sm.applyCommittedEntries(remain))
+		index, _ = sm.getAppliedIndexTerm()
+		assert.Equal(t, uint64(2), index)
+		assert.Equal(t, uint64(2), h.notified)
+		require.Equal(t, 1, len(h.resp.Responses))
+		_, paused = sm.getDiskFullSince()
+		assert.False(t, paused)
+		_, busy = pr.checkDiskFull(rpcpb.Request{Type: rpcpb.Write})
+		assert.False(t, busy)
+	}
+	runSimpleStateMachineTest(t, f, h)
+}
@@ -41,11 +41,14 @@ type action struct {
 	splitCheckData     splitCheckData
 	targetIndex        uint64
 	readMetrics        readMetrics
+	bucketKeys         [][]byte
+	consistencyHash    consistencyHash
 	epoch              Epoch
 	actionCallback     func(interface{})
 }
 
 type readMetrics struct {
+	key       []byte
 	readBytes uint64
 	readKeys  uint64
 }
@@ -77,11 +80,23 @@ const (
 	logCompactionAction
 	snapshotCompactionAction
 	checkPendingReadsAction
+	updateBucketsAction
+	persistStatsAction
+	consistencyHashAction
+	refreshSizeAction
 )
 
 func (pr *replica) addAdminRequest(adminType rpcpb.InternalCmd, request protoc.PB) {
+	if err := pr.tryAddAdminRequest(adminType, request); err != nil {
+		panic(err)
+	}
+}
+
+// tryAddAdminRequest same as addAdminRequest, but returns the error if the
+// replica is stopped.
+func (pr *replica) tryAddAdminRequest(adminType rpcpb.InternalCmd, request protoc.PB) error {
 	shard := pr.getShard()
-	if err := pr.addRequest(newReqCtx(rpcpb.Request{
+	return pr.addRequest(newReqCtx(rpcpb.Request{
 		ID:         uuid.NewV4().Bytes(),
 		Group:      shard.Group,
 		ToShard:    shard.ID,
@@ -89,13 +104,12 @@ func (pr *replica) addAdminRequest(adminType rpcpb.InternalCmd, request protoc.P
 		CustomType: uint64(adminType),
 		Epoch:      shard.Epoch,
 		Cmd:        protoc.MustMarshal(request),
-	}, nil)); err != nil {
-		panic(err)
-	}
+	}, nil))
 }
 
 func (pr *replica) addRequest(req reqCtx) error {
 	pr.limiter.Wait(int64(req.req.Size()))
+	req.enqueueAt = time.Now()
 	if err := pr.requests.Put(req); err != nil {
 		return err
 	}
@@ -318,6 +332,10 @@ func (pr *replica) handleAction(items []interface{}) (bool, error) {
 			pr.prophetHeartbeat()
 		case updateReadMetrics:
 			pr.doUpdateReadMetrics(act)
+		case updateBucketsAction:
+			pr.doUpdateBuckets(act)
+		case persistStatsAction:
+			pr.doPersistStats()
 		case checkLogCommittedAction:
 			pr.doCheckLogCommitted(act)
 		case checkLogAppliedAction:
@@ -335,6 +353,10 @@ func (pr *replica) handleAction(items []interface{}) (bool, error) {
 			}
 		case checkPendingReadsAction:
 			pr.pendingReads.removeLost()
+		case consistencyHashAction:
+			pr.doConsistencyHashComputed(act)
+		case refreshSizeAction:
+			pr.doRefreshSize(act)
 		}
 	}
 
@@ -347,6 +369,9 @@ func (pr *replica) handleAction(items []interface{}) (bool, error) {
 func (pr *replica) doUpdateReadMetrics(act action) {
 	pr.stats.readBytes += act.readMetrics.readBytes
 	pr.stats.readKeys += act.readMetrics.readKeys
+	pr.readLoad.add(act.readMetrics.readBytes, act.readMetrics.readKeys)
+	pr.buckets.addRead(act.readMetrics.key, act.readMetrics.readBytes, act.readMetrics.readKeys)
+	pr.loadSplitter.record(act.readMetrics.key, act.readMetrics.readBytes)
 }
 
 func (pr *replica) handleMessage(items []interface{}) bool {
@@ -361,12 +386,29 @@ func (pr *replica) handleMessage(items []interface{}) bool {
 	for i := int64(0); i < n; i++ {
 		raftMsg := items[i].(metapb.RaftMessage)
 		msg := raftMsg.Message
-		pr.updateReplicasCommittedIndex(raftMsg)
+		pr.updateReplicasIndexes(raftMsg)
 
 		if pr.isLeader() && msg.From != 0 {
 			pr.replicaHeartbeatsMap.Store(msg.From, time.Now())
 		}
 
+		if raftMsg.SnapshotFailure != metapb.SnapshotFailureReason_NoFailure {
+			pr.handleSnapshotFailure(raftMsg)
+			continue
+		}
+		if msg.Type == raftpb.MsgSnap {
+			if pr.store.faults.shouldFailSnapshot(pr.shardID) {
+				continue
+			}
+			if !pr.verifySnapshot(msg.Snapshot) {
+				pr.reportSnapshotFailure(raftMsg,
+					metapb.SnapshotFailureReason_ChecksumMismatch)
+				continue
+			}
+		}
+		if pr.maybeLeaseReadIndexResp(msg) {
+			continue
+		}
 		if err := pr.rn.Step(msg); err != nil {
 			pr.logger.Error("fail to step raft",
 				zap.Error(err))
@@ -381,8 +423,9 @@ func (pr *replica) handleMessage(items []interface{}) bool {
 	return true
 }
 
-func (pr *replica) updateReplicasCommittedIndex(msg metapb.RaftMessage) {
+func (pr *replica) updateReplicasIndexes(msg metapb.RaftMessage) {
 	pr.committedIndexes[msg.From.ID] = msg.CommitIndex
+	pr.appliedIndexes[msg.From.ID] = msg.AppliedIndex
 }
 
 func (pr *replica) handleTick(items []interface{}) bool {
@@ -400,6 +443,21 @@ func (pr *replica) handleTick(items []interface{}) bool {
 		pr.rn.Tick()
 		atomic.AddUint64(&pr.tickHandledCount, 1)
 	}
+	if window := pr.cfg.Raft.ReadIndexBatchTicks; window > 0 &&
+		pr.pendingReads.tick(int(n), window) {
+		pr.flushBatchingReads()
+	}
+	pr.retryDelayedSnapshots()
+	pr.updateFlowControl()
+	// retry the paused apply of the commit merge log, the unshipped entries or
+	// the write log failed by the disk full
+	if len(pr.pendingApplyEntries) > 0 &&
+		!pr.checkMergeSourceWait(time.Now()) {
+		if err := pr.doApplyCommittedEntries(nil); err != nil {
+			pr.logger.Error("fail to apply pending entries",
+				zap.Error(err))
+		}
+	}
 
 	return true
 }
@@ -442,6 +500,8 @@ func (pr *replica) handleSnapshotStatus(items []interface{}) bool {
 			rss := raft.SnapshotFinish
 			if ss.rejected {
 				rss = raft.SnapshotFailure
+				pr.snapshotFailures.failed(ss.to, ss.index,
+					metapb.SnapshotFailureReason_NetworkFailure, time.Now())
 			}
 			pr.rn.ReportSnapshot(ss.to, rss)
 		}
@@ -462,14 +522,33 @@ func (pr *replica) prophetHeartbeat() {
 	}
 	shard := pr.getShard()
 	req := rpcpb.ShardHeartbeatReq{
-		Term:            pr.rn.BasicStatus().Term,
-		Leader:          &pr.replica,
-		StoreID:         pr.storeID,
-		DownReplicas:    pr.collectDownReplicas(),
-		PendingReplicas: pr.collectPendingReplicas(),
-		Stats:           pr.stats.heartbeatState(),
-		GroupKey:        pr.groupController.getShardGroupKey(shard),
-		Lease:           pr.getLease(),
+		Term:              pr.rn.BasicStatus().Term,
+		Leader:            &pr.replica,
+		StoreID:           pr.storeID,
+		DownReplicas:      pr.collectDownReplicas(),
+		PendingReplicas:   pr.collectPendingReplicas(),
+		Stats:             pr.stats.heartbeatState(),
+		GroupKey:          pr.groupController.getShardGroupKey(shard),
+		Lease:             pr.getLease(),
+		ReplicaProgresses: pr.collectReplicaProgresses(),
+		Buckets:           pr.buckets.collect(shard),
+	}
+	req.MergePrepared = pr.isMergePrepared(req.ReplicaProgresses)
+	if pr.store != nil && pr.store.shardMetrics != nil {
+		inflightBytes, applyLag, rejected := pr.flowControl.collect()
+		pr.store.shardMetrics.update(metric.ShardStats{
+			ShardID:               shard.ID,
+			Group:                 shard.Group,
+			WrittenBytes:          req.Stats.WrittenBytes,
+			WrittenKeys:           req.Stats.WrittenKeys,
+			ReadBytes:             req.Stats.ReadBytes,
+			ReadKeys:              req.Stats.ReadKeys,
+			ApproximateSize:       req.Stats.ApproximateSize,
+			ApproximateKeys:       req.Stats.ApproximateKeys,
+			InflightProposalBytes: inflightBytes,
+			ApplyLag:              applyLag,
+			BusyRejectedWrites:    rejected,
+		}, time.Now())
 	}
 	pr.logger.Debug("start send shard heartbeat")
 	if err := pr.prophetClient.ShardHeartbeat(shard, req); err != nil {
@@ -479,6 +558,15 @@ func (pr *replica) prophetHeartbeat() {
 	pr.logger.Debug("end send shard heartbeat")
 }
 
+// forgetProphetHeartbeat makes the prophet client forget the shard descriptor
+// sent by the last full heartbeat, the replica stops sending the heartbeats
+// after its leadership moved away or it's destroyed.
+func (pr *replica) forgetProphetHeartbeat() {
+	if pr.prophetClient != nil {
+		pr.prophetClient.ForgetShardHeartbeat(pr.shardID)
+	}
+}
+
 func (pr *replica) doCheckLogCompact(progresses map[uint64]trackerPkg.Progress, lastIndex uint64) {
 	if !pr.isLeader() {
 		return
@@ -14,12 +14,16 @@
 package raftstore
 
 import (
+	"time"
+
 	"github.com/cockroachdb/errors"
 	"github.com/fagongzi/util/protoc"
 	"github.com/matrixorigin/matrixcube/components/log"
 	"github.com/matrixorigin/matrixcube/metric"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/util/uuid"
+	"go.etcd.io/etcd/raft/v3"
 	"go.etcd.io/etcd/raft/v3/raftpb"
 	trackerPkg "go.etcd.io/etcd/raft/v3/tracker"
 	"go.uber.org/zap"
@@ -73,6 +77,7 @@ func (pr *replica) handleRequest(items []interface{}) bool {
 		}
 		for i := int64(0); i < n; i++ {
 			req := items[i].(reqCtx)
+			pr.observeQueueWait(time.Since(req.enqueueAt))
 			if ce := pr.logger.Check(zap.DebugLevel, "push to proposal batch"); ce != nil {
 				ce.Write(log.HexField("id", req.req.ID))
 			}
@@ -151,30 +156,154 @@ func (pr *replica) execReadIndex(c batch) {
 	if c.tp != read {
 		panic("not a read index request")
 	}
+	// the replica of the shard which lost the quorum serves the reads with its
+	// applied state if the degraded read is enabled by the operator
+	if pr.canStaleRead(c.requestBatch) {
+		pr.execStaleRead(c)
+		return
+	}
 	if !pr.isLeader() {
+		if pr.canFollowerRead(c.requestBatch) {
+			pr.readIndex(c.getRequestID(), c)
+			return
+		}
 		pr.respNotLeader(c)
 		return
 	}
 
+	// the reads in the batching window are confirmed together on the tick which
+	// closes the window, so the leader only broadcasts once for all of them. The
+	// lease reads are confirmed without broadcasting, so they are not batched.
+	if pr.cfg.Raft.ReadIndexBatchTicks > 0 && !pr.canLeaseRead() {
+		pr.pendingReads.addBatching(c)
+		return
+	}
+	pr.readIndex(c.getRequestID(), c)
+}
+
+// flushBatchingReads issues a single read index round for all reads in the
+// batching window. The round is piggybacked on the heartbeats scheduled by the
+// ticks, so no extra heartbeat is broadcast for the reads.
+func (pr *replica) flushBatchingReads() {
+	batches := pr.pendingReads.takeBatching()
+	if len(batches) == 0 {
+		return
+	}
+	if !pr.isLeader() {
+		for _, c := range batches {
+			pr.respNotLeader(c)
+		}
+		return
+	}
+	ctx := uuid.NewV4().Bytes()
+	var to []uint64
+	for id := range pr.rn.Status().Progress {
+		if id != pr.replica.ID {
+			to = append(to, id)
+		}
+	}
+	pr.pendingReads.piggyback(ctx, to)
+	pr.readIndex(ctx, batches...)
+}
+
+func (pr *replica) readIndex(ctx []byte, batches ...batch) {
+	if index, _, ok := pr.leaseReadIndex(); ok && !pr.pendingReads.hasUnready() {
+		pr.metrics.propose.readIndex++
+		pr.pendingReads.appendWithCtx(ctx, batches...)
+		pr.pendingReads.ready(raft.ReadState{Index: index, RequestCtx: ctx})
+		pr.maybeExecRead()
+		return
+	}
+
 	prevPendingReadCount := pr.pendingReadCount()
 	prevReadyReadCount := pr.readyReadCount()
 
-	pr.rn.ReadIndex(c.getRequestID())
+	pr.rn.ReadIndex(ctx)
 
 	pendingReadCount := pr.pendingReadCount()
 	readyReadCount := pr.readyReadCount()
 
+	// the follower forwards the read index to the leader, so the read index queue
+	// of the follower does not change until the leader responded.
 	if pendingReadCount == prevPendingReadCount &&
-		readyReadCount == prevReadyReadCount {
-		pr.respNotLeader(c)
+		readyReadCount == prevReadyReadCount &&
+		(pr.isLeader() || pr.getLeaderReplicaID() == 0) {
+		for _, c := range batches {
+			pr.respNotLeader(c)
+		}
 		return
 	}
 	pr.metrics.propose.readIndex++
 	if ce := pr.logger.Check(zap.DebugLevel, "call read index"); ce != nil {
-		ce.Write(log.HexField("id", c.getRequestID()))
+		ce.Write(log.HexField("id", ctx),
+			zap.Int("batches", len(batches)))
 	}
 
-	pr.pendingReads.append(c)
+	pr.pendingReads.appendWithCtx(ctx, batches...)
+}
+
+// canLeaseRead returns true if the reads can be confirmed by the lease of the
+// leader. The lease relies on the bounded clock drift between the stores, so
+// the reads fall back to the heartbeat round to the quorum while the clock of
+// the store is skewed.
+func (pr *replica) canLeaseRead() bool {
+	return pr.cfg.Raft.EnableLeaseRead && !pr.store.clock.isSkewed()
+}
+
+// leaseReadIndex returns the read index and the term confirmed by the lease of
+// the leader, i.e. the committed index if the leader has committed an entry in
+// its term. The leader steps down if it can't reach the quorum in an election
+// timeout, so the committed index is the latest one while it's the leader.
+func (pr *replica) leaseReadIndex() (uint64, uint64, bool) {
+	if !pr.canLeaseRead() {
+		return 0, 0, false
+	}
+	status := pr.rn.BasicStatus()
+	if status.RaftState != raft.StateLeader {
+		return 0, 0, false
+	}
+	term, err := pr.lr.Term(status.Commit)
+	if err != nil || term != status.Term {
+		return 0, 0, false
+	}
+	return status.Commit, status.Term, true
+}
+
+// maybeLeaseReadIndexResp responds the read index request forwarded by the
+// follower with the lease of the leader, returns false if the request needs to
+// be confirmed by raft.
+func (pr *replica) maybeLeaseReadIndexResp(msg raftpb.Message) bool {
+	if msg.Type != raftpb.MsgReadIndex || msg.From == 0 || len(msg.Entries) != 1 {
+		return false
+	}
+	index, term, ok := pr.leaseReadIndex()
+	if !ok {
+		return false
+	}
+	pr.sendMessage(raftpb.Message{
+		Type:    raftpb.MsgReadIndexResp,
+		From:    pr.replicaID,
+		To:      msg.From,
+		Term:    term,
+		Index:   index,
+		Entries: msg.Entries,
+	})
+	return true
+}
+
+// canFollowerRead returns true if all requests of the read batch allow to be
+// served by any replica, the follower confirms the read index with the leader
+// and serves them after applied to the read index.
+func (pr *replica) canFollowerRead(rb rpcpb.RequestBatch) bool {
+	if pr.getLeaderReplicaID() == 0 || rb.IsAdmin() {
+		return false
+	}
+	for _, req := range rb.Requests {
+		if !isFollowerRead(req) {
+			return false
+		}
+	}
+	return len(rb.Requests) > 0
 }
 
 func (pr *replica) proposeNormal(c batch) bool {
@@ -183,6 +312,9 @@ func (pr *replica) proposeNormal(c batch) bool {
 		return false
 	}
 
+	if pr.store != nil && pr.store.hlcClock != nil {
+		c.requestBatch.Header.Timestamp, _ = pr.store.hlcClock.Now()
+	}
 	data := protoc.MustMarshal(&c.requestBatch)
 	size := len(data)
 	metric.ObserveProposalBytes(int64(size))
@@ -201,6 +333,7 @@ func (pr *replica) proposeNormal(c batch) bool {
 		pr.respNotLeader(c)
 		return false
 	}
+	pr.flowControl.propose(idx, uint64(size))
 	if ce := pr.logger.Check(zap.DebugLevel, "made a proposal"); ce != nil {
 		ce.Write(
 			log.ShardIDField(pr.shardID),
@@ -16,6 +16,7 @@ package raftstore
 import (
 	"math"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -381,3 +382,53 @@ func TestInvalidConfigChangeRequestIsRejected(t *testing.T) {
 		assert.Equal(t, tt.err, result, "idx: %d", idx)
 	}
 }
+
+func TestCanFollowerRead(t *testing.T) {
+	pr := &replica{}
+	read := rpcpb.Request{Type: rpcpb.Read, ReplicaSelectPolicy: rpcpb.SelectRandom}
+	rb := rpcpb.RequestBatch{Requests: []rpcpb.Request{read}}
+	assert.False(t, pr.canFollowerRead(rb), "no leader")
+
+	pr.setLeaderReplicaID(1)
+	assert.True(t, pr.canFollowerRead(rb))
+	rb.Requests = append(rb.Requests, rpcpb.Request{Type: rpcpb.Read})
+	assert.False(t, pr.canFollowerRead(rb), "leader read mixed")
+	assert.False(t, pr.canFollowerRead(rpcpb.RequestBatch{}))
+
+	// any custom read is allowed by the follower read policies
+	for _, policy := range []rpcpb.ReplicaSelectPolicy{rpcpb.SelectRandom, rpcpb.SelectLearner} {
+		rb.Requests = []rpcpb.Request{{Type: rpcpb.Read, ReplicaSelectPolicy: policy,
+			CustomType: uint64(rpcpb.CmdKVGet)}}
+		assert.True(t, pr.canFollowerRead(rb), policy.String())
+	}
+}
+
+func TestLeaseReadFallbackWithSkewedClock(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	pr := newTestReplica(Shard{ID: 1}, Replica{ID: 1}, s)
+	assert.False(t, pr.canLeaseRead())
+	pr.cfg.Raft.EnableLeaseRead = true
+	assert.True(t, pr.canLeaseRead())
+
+	now := time.Now()
+	s.clock = newClockMonitor(time.Millisecond)
+	s.clock.update(now, now, now.Add(-time.Second).UnixNano())
+	require.True(t, s.clock.isSkewed())
+	assert.False(t, pr.canLeaseRead())
+	_, _, ok := pr.leaseReadIndex()
+	assert.False(t, ok)
+	assert.False(t, pr.maybeLeaseReadIndexResp(raftpb.Message{Type: raftpb.MsgReadIndex,
+		From: 2, Entries: []raftpb.Entry{{Data: []byte("r1")}}}))
+
+	// the read is not rejected, but handled as the read without the lease
+	var rsp rpcpb.ResponseBatch
+	rb := rpcpb.RequestBatch{Requests: []rpcpb.Request{{ID: []byte("r1"), Type: rpcpb.Read}}}
+	pr.execReadIndex(newBatch(s.logger, rb, func(r rpcpb.ResponseBatch) { rsp = r }, read, 0))
+	require.Equal(t, 1, len(rsp.Responses))
+	assert.Nil(t, rsp.Responses[0].Error.LeaseReadNotReady)
+	assert.NotNil(t, rsp.Responses[0].Error.NotLeader)
+}
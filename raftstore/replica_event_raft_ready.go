@@ -51,17 +51,25 @@ func (pr *replica) commitRaftReady(rd raft.Ready) {
 
 func (pr *replica) processReady(rd raft.Ready, wc *logdb.WorkerContext) error {
 	pr.handleRaftState(rd)
+	start := time.Now()
 	pr.sendRaftAppendLogMessages(rd)
+	sent := time.Since(start)
+	start = time.Now()
 	if err := pr.saveRaftState(rd, wc); err != nil {
 		return err
 	}
 	if err := pr.appendEntries(rd); err != nil {
 		return err
 	}
+	pr.observeReadyStage(readyStageAppend, time.Since(start), rd)
+	start = time.Now()
 	pr.sendRaftMessages(rd)
+	pr.observeReadyStage(readyStageSend, sent+time.Since(start), rd)
+	start = time.Now()
 	if err := pr.applyCommittedEntries(rd); err != nil {
 		return err
 	}
+	pr.observeReadyStage(readyStageApply, time.Since(start), rd)
 	pr.handleReadyToRead(rd)
 	if err := pr.handleRaftCreateSnapshotRequest(); err != nil {
 		return err
@@ -97,6 +105,8 @@ func (pr *replica) handleRaftState(rd raft.Ready) {
 				pr.aware.BecomeFollower(shard)
 			}
 			pr.pendingReads.leaderChanged(pr.getLeaderReplica())
+			pr.flowControl.reset()
+			pr.forgetProphetHeartbeat()
 		}
 	}
 }
@@ -193,6 +203,7 @@ func (pr *replica) applyCommittedEntries(rd raft.Ready) error {
 			return err
 		}
 		pr.pushedIndex = rd.Snapshot.Metadata.Index
+		pr.pendingApplyEntries = nil
 		pr.logger.Info("snapshot applied into the replica")
 	}
 	for _, entry := range rd.CommittedEntries {
@@ -250,6 +261,9 @@ func (pr *replica) send(msgs []raftpb.Message, msgAppOnly bool) {
 		if isMsgApp(msg) && msgAppOnly {
 			pr.sendMessage(msg)
 		} else if !isMsgApp(msg) && !msgAppOnly {
+			if pr.pendingReads.dropPiggybacked(msg) {
+				continue
+			}
 			pr.sendMessage(msg)
 		}
 	}
@@ -273,19 +287,23 @@ func (pr *replica) sendRaftMessage(msg raftpb.Message) error {
 		return errors.Wrapf(ErrUnknownReplica,
 			"shardID %d, replicaID: %d", pr.shardID, msg.To)
 	}
+	if pr.store.faults.shouldDropMessage(to.StoreID) {
+		return errDroppedByFaultInjection
+	}
 
 	m := metapb.RaftMessage{
-		ShardID:     pr.shardID,
-		From:        pr.replica,
-		To:          to,
-		Start:       shard.Start,
-		End:         shard.End,
-		ShardEpoch:  shard.Epoch,
-		Group:       shard.Group,
-		Unique:      shard.Unique,
-		RuleGroups:  shard.RuleGroups,
-		Message:     msg,
-		CommitIndex: pr.lastCommittedIndex,
+		ShardID:      pr.shardID,
+		From:         pr.replica,
+		To:           to,
+		Start:        shard.Start,
+		End:          shard.End,
+		ShardEpoch:   shard.Epoch,
+		Group:        shard.Group,
+		Unique:       shard.Unique,
+		RuleGroups:   shard.RuleGroups,
+		Message:      msg,
+		CommitIndex:  pr.lastCommittedIndex,
+		AppliedIndex: pr.appliedIndex,
 		// FIXME: remove this hack
 		SendTime: uint64(time.Now().UnixMilli()),
 	}
@@ -308,8 +326,15 @@ func (pr *replica) sendRaftMessage(msg raftpb.Message) error {
 	}
 
 	if msg.Type == raftpb.MsgSnap {
+		if !pr.snapshotFailures.canSend(to.ID, time.Now()) {
+			pr.delaySnapshot(to, msg.Snapshot)
+			return nil
+		}
 		pr.logger.Info("sending a snapshot message")
-		pr.transport.SendSnapshot(m)
+		if pr.transport.SendSnapshot(m) {
+			pr.sentSnapshots[to.ID] = msg.Snapshot.Metadata.Index
+			pr.snapshotFailures.sent(to.ID)
+		}
 	} else {
 		pr.transport.Send(m)
 	}
@@ -342,7 +367,26 @@ func (pr *replica) doApplyCommittedEntries(entries []raftpb.Entry) error {
 	entries = pr.entriesToApply(entries)
 	if len(entries) > 0 {
 		pr.pushedIndex = entries[len(entries)-1].Index
-		pr.sm.applyCommittedEntries(entries)
+	}
+	if len(pr.pendingApplyEntries) > 0 {
+		entries = append(append([]raftpb.Entry(nil), pr.pendingApplyEntries...), entries...)
+		pr.pendingApplyEntries = nil
+	}
+	if len(entries) > 0 {
+		if delay := pr.store.faults.getApplyDelay(pr.shardID); delay > 0 {
+			time.Sleep(delay)
+		}
+		entries, unshipped := pr.shipWAL(entries)
+		pr.pendingApplyEntries = pr.sm.applyCommittedEntries(entries)
+		if !pr.sm.mergePaused {
+			pr.mergePausedSince = time.Time{}
+		} else if pr.mergePausedSince.IsZero() {
+			pr.mergePausedSince = time.Now()
+		}
+		if len(unshipped) > 0 {
+			pr.pendingApplyEntries = append(append([]raftpb.Entry(nil),
+				pr.pendingApplyEntries...), unshipped...)
+		}
 		if pr.sm.isRemoved() {
 			// local replica is removed, keep the shard
 			pr.store.destroyReplica(pr.shardID, false, true, "removed by config change")
@@ -121,6 +121,14 @@ func (t *replicaTestTransport) SendingSnapshotCount() uint64 {
 	return 0
 }
 
+func (t *replicaTestTransport) ReceivingSnapshotCount() uint64 {
+	return 0
+}
+
+func (t *replicaTestTransport) SnapshotProgress(shardID, replicaID uint64) (uint64, uint64, bool) {
+	return 0, 0, false
+}
+
 func TestSendRaftMessageAttachsExpectedShardDetails(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	trans := &replicaTestTransport{}
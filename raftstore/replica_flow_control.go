@@ -0,0 +1,157 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"github.com/matrixorigin/matrixcube/metric"
+	"github.com/matrixorigin/matrixcube/pb/errorpb"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+const (
+	shardBusyInflightBytes = "inflight-bytes"
+	shardBusyApplyLag      = "apply-lag"
+)
+
+// replicaFlowControl tracks the proposals of the leader until they are applied.
+// The proposals are tracked by the event worker, and the counters are checked
+// by the store when the requests are received, so the counters must be
+// accessed atomically.
+type replicaFlowControl struct {
+	// proposals the proposals not applied in the order of the indexes, only
+	// accessed by the event worker
+	proposals     []inflightProposal
+	inflightBytes uint64
+	applyLag      uint64
+	// rejected the number of the writes rejected since the last collect
+	rejected uint64
+}
+
+type inflightProposal struct {
+	index uint64
+	size  uint64
+}
+
+func (fc *replicaFlowControl) propose(index, size uint64) {
+	fc.proposals = append(fc.proposals, inflightProposal{index: index, size: size})
+	atomic.AddUint64(&fc.inflightBytes, size)
+}
+
+// applied removes the proposals applied
+func (fc *replicaFlowControl) applied(index uint64) {
+	n := 0
+	var size uint64
+	for ; n < len(fc.proposals) && fc.proposals[n].index <= index; n++ {
+		size += fc.proposals[n].size
+	}
+	if n == 0 {
+		return
+	}
+	fc.proposals = append(fc.proposals[:0], fc.proposals[n:]...)
+	atomic.AddUint64(&fc.inflightBytes, ^(size - 1))
+}
+
+// reset drops all the proposals, it's called when the leadership is lost, the
+// proposals are applied or dropped by the new leader.
+func (fc *replicaFlowControl) reset() {
+	fc.proposals = fc.proposals[:0]
+	atomic.StoreUint64(&fc.inflightBytes, 0)
+	atomic.StoreUint64(&fc.applyLag, 0)
+}
+
+// collect returns the counters of the flow control, the rejected writes are
+// reset.
+func (fc *replicaFlowControl) collect() (inflightBytes, applyLag, rejected uint64) {
+	return atomic.LoadUint64(&fc.inflightBytes),
+		atomic.LoadUint64(&fc.applyLag),
+		atomic.SwapUint64(&fc.rejected, 0)
+}
+
+// updateFlowControl updates the flow control after the entries applied, and on
+// the ticks since the applied indexes of the followers are reported by the
+// raft messages.
+func (pr *replica) updateFlowControl() {
+	if !pr.isLeader() {
+		return
+	}
+	pr.flowControl.applied(pr.appliedIndex)
+	if pr.cfg.Raft.MaxApplyLagPerShard == 0 {
+		return
+	}
+
+	var lag uint64
+	lastIndex := pr.nextProposalIndex() - 1
+	if applied := pr.getQuorumAppliedIndex(); lastIndex > applied {
+		lag = lastIndex - applied
+	}
+	atomic.StoreUint64(&pr.flowControl.applyLag, lag)
+}
+
+// getQuorumAppliedIndex returns the max index applied by the quorum of the
+// voters
+func (pr *replica) getQuorumAppliedIndex() uint64 {
+	var indexes []uint64
+	for _, r := range pr.getShard().Replicas {
+		if r.Role != metapb.ReplicaRole_Voter {
+			continue
+		}
+		if r.ID == pr.replicaID {
+			indexes = append(indexes, pr.appliedIndex)
+		} else {
+			indexes = append(indexes, pr.appliedIndexes[r.ID])
+		}
+	}
+	if len(indexes) == 0 {
+		return pr.appliedIndex
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] > indexes[j] })
+	return indexes[len(indexes)/2]
+}
+
+// checkShardBusy returns the backoff hint if the write should be rejected
+// because the proposals of the leader are not applied in time. Only the writes
+// are rejected, the reads and the admin requests do not grow the raft log.
+func (pr *replica) checkShardBusy(req rpcpb.Request) (*errorpb.ShardBusy, bool) {
+	if req.Type != rpcpb.Write {
+		return nil, false
+	}
+
+	reason := ""
+	inflightBytes := atomic.LoadUint64(&pr.flowControl.inflightBytes)
+	applyLag := atomic.LoadUint64(&pr.flowControl.applyLag)
+	if limit := uint64(pr.cfg.Raft.MaxInflightProposalBytesPerShard); limit > 0 &&
+		inflightBytes >= limit {
+		reason = shardBusyInflightBytes
+	} else if limit := pr.cfg.Raft.MaxApplyLagPerShard; limit > 0 && applyLag >= limit {
+		reason = shardBusyApplyLag
+	}
+	if reason == "" {
+		return nil, false
+	}
+
+	atomic.AddUint64(&pr.flowControl.rejected, 1)
+	metric.IncShardBusyCount(reason)
+	// the followers report the applied indexes by the heartbeats at least, the
+	// lag can't be seen to drop sooner
+	return &errorpb.ShardBusy{
+		ShardID:       pr.shardID,
+		InflightBytes: inflightBytes,
+		ApplyLag:      applyLag,
+		RetryAfterMS:  uint64(pr.cfg.Raft.GetHeartbeatDuration().Milliseconds()),
+	}, true
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+)
+
+func TestReplicaFlowControl(t *testing.T) {
+	fc := replicaFlowControl{}
+	fc.propose(1, 10)
+	fc.propose(2, 20)
+	fc.propose(3, 30)
+	fc.applied(2)
+	inflightBytes, _, _ := fc.collect()
+	assert.Equal(t, uint64(30), inflightBytes)
+	assert.Equal(t, []inflightProposal{{index: 3, size: 30}}, fc.proposals)
+
+	fc.applied(2)
+	inflightBytes, _, _ = fc.collect()
+	assert.Equal(t, uint64(30), inflightBytes)
+
+	atomic.StoreUint64(&fc.applyLag, 5)
+	fc.reset()
+	inflightBytes, applyLag, _ := fc.collect()
+	assert.Equal(t, uint64(0), inflightBytes)
+	assert.Equal(t, uint64(0), applyLag)
+	assert.Empty(t, fc.proposals)
+}
+
+func TestReplicaGetQuorumAppliedIndex(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	pr := newTestReplica(Shard{ID: 1, Replicas: []Replica{
+		{ID: 1, Role: metapb.ReplicaRole_Voter},
+		{ID: 2, Role: metapb.ReplicaRole_Voter},
+		{ID: 3, Role: metapb.ReplicaRole_Voter},
+		{ID: 4, Role: metapb.ReplicaRole_Learner},
+	}}, Replica{ID: 1}, s)
+	pr.appliedIndex = 10
+	pr.appliedIndexes[2] = 8
+	pr.appliedIndexes[3] = 2
+	pr.appliedIndexes[4] = 1
+	assert.Equal(t, uint64(8), pr.getQuorumAppliedIndex())
+
+	pr.appliedIndexes[2] = 1
+	assert.Equal(t, uint64(2), pr.getQuorumAppliedIndex(), "the learners are not counted")
+}
+
+func TestReplicaCheckShardBusy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	pr := newTestReplica(Shard{ID: 1}, Replica{ID: 1}, s)
+	pr.cfg.Raft.TickInterval.Duration = time.Millisecond * 100
+	pr.cfg.Raft.HeartbeatTicks = 2
+	write := rpcpb.Request{ID: []byte("k1"), Type: rpcpb.Write}
+
+	// no limit
+	pr.flowControl.propose(1, 1024)
+	atomic.StoreUint64(&pr.flowControl.applyLag, 100)
+	_, ok := pr.checkShardBusy(write)
+	assert.False(t, ok)
+
+	pr.cfg.Raft.MaxInflightProposalBytesPerShard = 1024
+	busy, ok := pr.checkShardBusy(write)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), busy.ShardID)
+	assert.Equal(t, uint64(1024), busy.InflightBytes)
+	assert.Equal(t, uint64(100), busy.ApplyLag)
+	assert.Equal(t, uint64(200), busy.RetryAfterMS)
+
+	// only the writes are rejected
+	_, ok = pr.checkShardBusy(rpcpb.Request{Type: rpcpb.Read})
+	assert.False(t, ok)
+	_, ok = pr.checkShardBusy(rpcpb.Request{Type: rpcpb.Admin})
+	assert.False(t, ok)
+
+	pr.flowControl.applied(1)
+	_, ok = pr.checkShardBusy(write)
+	assert.False(t, ok)
+
+	pr.cfg.Raft.MaxApplyLagPerShard = 100
+	_, ok = pr.checkShardBusy(write)
+	assert.True(t, ok)
+
+	_, _, rejected := pr.flowControl.collect()
+	assert.Equal(t, uint64(2), rejected)
+	_, _, rejected = pr.flowControl.collect()
+	assert.Equal(t, uint64(0), rejected)
+}
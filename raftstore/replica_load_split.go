@@ -0,0 +1,201 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/storage"
+)
+
+const (
+	defaultShardSplitLoadWindows = 3
+	// loadSplitSamples the max number of the accessed keys sampled in each window
+	loadSplitSamples = 32
+)
+
+// loadSplitter tracks the load of the replica in the windows between the split
+// checks, and samples the accessed keys to pick the split key once the load
+// stays above the threshold. It's only accessed by the event worker of the
+// replica.
+type loadSplitter struct {
+	qps     uint64
+	bytes   uint64
+	windows int
+	rand    *rand.Rand
+
+	// hotWindows the number of the consecutive windows above the threshold
+	hotWindows int
+	start      time.Time
+	requests   uint64
+	readWrite  uint64
+	seen       uint64
+	samples    [][]byte
+}
+
+// newLoadSplitter returns nil if the load based split is disabled
+func newLoadSplitter(feature storage.Feature) *loadSplitter {
+	if feature.DisableShardSplit ||
+		(feature.ShardSplitLoadQPS == 0 && feature.ShardSplitLoadBytes == 0) {
+		return nil
+	}
+	windows := feature.ShardSplitLoadWindows
+	if windows <= 0 {
+		windows = defaultShardSplitLoadWindows
+	}
+	return &loadSplitter{
+		qps:     feature.ShardSplitLoadQPS,
+		bytes:   feature.ShardSplitLoadBytes,
+		windows: windows,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		start:   time.Now(),
+	}
+}
+
+// record records a request accessed the key, the key is sampled by the
+// reservoir sampling and copied since the request may be released.
+func (l *loadSplitter) record(key []byte, n uint64) {
+	if l == nil {
+		return
+	}
+	l.requests++
+	l.readWrite += n
+	l.seen++
+	if len(l.samples) < loadSplitSamples {
+		l.samples = append(l.samples, append([]byte(nil), key...))
+	} else if i := l.rand.Int63n(int64(l.seen)); i < loadSplitSamples {
+		l.samples[i] = append([]byte(nil), key...)
+	}
+}
+
+// reset clears the load, e.g. the replica is not the leader any more
+func (l *loadSplitter) reset(now time.Time) {
+	if l == nil {
+		return
+	}
+	l.hotWindows = 0
+	l.resetWindow(now)
+}
+
+func (l *loadSplitter) resetWindow(now time.Time) {
+	l.start = now
+	l.requests = 0
+	l.readWrite = 0
+	l.seen = 0
+	l.samples = nil
+}
+
+// rotate ends the current window, returns the split key picked from the keys
+// sampled in the window if the load stays above the threshold in enough
+// windows, nil if the shard needn't or can't be split by the load.
+func (l *loadSplitter) rotate(now time.Time, shard Shard,
+	adjust func(Shard, []byte) []byte) []byte {
+	if l == nil {
+		return nil
+	}
+	defer l.resetWindow(now)
+
+	seconds := now.Sub(l.start).Seconds()
+	if seconds <= 0 {
+		return nil
+	}
+	if (l.qps == 0 || float64(l.requests)/seconds < float64(l.qps)) &&
+		(l.bytes == 0 || float64(l.readWrite)/seconds < float64(l.bytes)) {
+		l.hotWindows = 0
+		return nil
+	}
+	l.hotWindows++
+	if l.hotWindows < l.windows {
+		return nil
+	}
+
+	key := loadSplitKey(l.samples, shard, adjust)
+	if key != nil {
+		l.hotWindows = 0
+	}
+	return key
+}
+
+// loadSplitKey picks the median of the sampled keys as the split key, nil if
+// the key can't split the load, e.g. all the requests access the same key.
+func loadSplitKey(samples [][]byte, shard Shard, adjust func(Shard, []byte) []byte) []byte {
+	if len(samples) == 0 {
+		return nil
+	}
+	sorted := make([][]byte, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+
+	key := sorted[len(sorted)/2]
+	if adjust != nil {
+		key = adjust(shard, key)
+	}
+	if bytes.Compare(key, sorted[0]) <= 0 ||
+		bytes.Compare(key, shard.Start) <= 0 ||
+		checkKeyInShard(key, shard) != nil {
+		return nil
+	}
+	return key
+}
+
+// pendingLoadSplit the split key picked by the load of the shard, set by the
+// event worker and taken by the split checker.
+type pendingLoadSplit struct {
+	sync.Mutex
+	epoch Epoch
+	key   []byte
+}
+
+func (p *pendingLoadSplit) set(epoch Epoch, key []byte) {
+	p.Lock()
+	defer p.Unlock()
+	p.epoch = epoch
+	p.key = key
+}
+
+// take returns and clears the split key, nil if it's picked in another epoch
+func (p *pendingLoadSplit) take(epoch Epoch) []byte {
+	p.Lock()
+	defer p.Unlock()
+	key := p.key
+	p.key = nil
+	if p.epoch.Generation != epoch.Generation {
+		return nil
+	}
+	return key
+}
+
+// checkLoadSplit returns true if the shard should be split by the load
+func (pr *replica) checkLoadSplit() bool {
+	shard := pr.getShard()
+	key := pr.loadSplitter.rotate(time.Now(), shard, pr.feature.AdjustSplitKey)
+	if key == nil {
+		return false
+	}
+	pr.logger.Info("shard is hot, try to split by load",
+		log.HexField("split-key", key),
+		zap.Uint64("qps-threshold", pr.loadSplitter.qps),
+		zap.Uint64("bytes-threshold", pr.loadSplitter.bytes))
+	pr.loadSplit.set(shard.Epoch, key)
+	return true
+}
@@ -0,0 +1,119 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/matrixorigin/matrixcube/storage"
+)
+
+func TestNewLoadSplitter(t *testing.T) {
+	assert.Nil(t, newLoadSplitter(storage.Feature{}))
+	assert.Nil(t, newLoadSplitter(storage.Feature{ShardSplitLoadQPS: 1, DisableShardSplit: true}))
+	l := newLoadSplitter(storage.Feature{ShardSplitLoadBytes: 1})
+	assert.NotNil(t, l)
+	assert.Equal(t, defaultShardSplitLoadWindows, l.windows)
+
+	var disabled *loadSplitter
+	disabled.record([]byte("a"), 1)
+	disabled.reset(time.Now())
+	assert.Nil(t, disabled.rotate(time.Now(), Shard{}, nil))
+}
+
+func TestLoadSplitterRotate(t *testing.T) {
+	l := newLoadSplitter(storage.Feature{ShardSplitLoadQPS: 10, ShardSplitLoadWindows: 2})
+	start := time.Now()
+	hot := func(now time.Time) {
+		l.start = now.Add(-time.Second)
+		for i := 0; i < 10; i++ {
+			l.record([]byte{byte(i)}, 1)
+		}
+	}
+
+	hot(start)
+	assert.Nil(t, l.rotate(start, Shard{}, nil), "not enough windows")
+	assert.Equal(t, 1, l.hotWindows)
+	assert.Empty(t, l.samples, "window reset")
+
+	// the load drops
+	l.record([]byte{1}, 1)
+	assert.Nil(t, l.rotate(start.Add(time.Second), Shard{}, nil))
+	assert.Equal(t, 0, l.hotWindows)
+
+	hot(start)
+	assert.Nil(t, l.rotate(start, Shard{}, nil))
+	hot(start)
+	assert.Equal(t, []byte{5}, l.rotate(start, Shard{}, nil))
+	assert.Equal(t, 0, l.hotWindows)
+
+	// the bytes threshold
+	l = newLoadSplitter(storage.Feature{ShardSplitLoadBytes: 100, ShardSplitLoadWindows: 1})
+	l.start = start.Add(-time.Second)
+	l.record([]byte{1}, 60)
+	l.record([]byte{2}, 60)
+	assert.Equal(t, []byte{2}, l.rotate(start, Shard{}, nil))
+
+	// not enough samples
+	l.reset(start)
+	l.start = start.Add(-time.Second)
+	l.record([]byte{1}, 200)
+	assert.Nil(t, l.rotate(start, Shard{}, nil))
+	assert.Equal(t, 1, l.hotWindows, "retry at next window")
+}
+
+func TestLoadSplitterSamples(t *testing.T) {
+	l := newLoadSplitter(storage.Feature{ShardSplitLoadQPS: 1})
+	key := []byte{1}
+	l.record(key, 1)
+	key[0] = 2
+	assert.Equal(t, []byte{1}, l.samples[0], "copied")
+
+	for i := 0; i < loadSplitSamples*4; i++ {
+		l.record([]byte{byte(i)}, 1)
+	}
+	assert.Equal(t, loadSplitSamples, len(l.samples))
+	assert.Equal(t, uint64(loadSplitSamples*4+1), l.requests)
+}
+
+func TestLoadSplitKey(t *testing.T) {
+	samples := [][]byte{{3}, {1}, {2}, {4}}
+	assert.Equal(t, []byte{3}, loadSplitKey(samples, Shard{}, nil))
+	assert.Nil(t, loadSplitKey(nil, Shard{}, nil))
+	assert.Nil(t, loadSplitKey([][]byte{{1}, {1}, {1}}, Shard{}, nil), "single hot key")
+	assert.Nil(t, loadSplitKey(samples, Shard{Start: []byte{3}}, nil), "start key")
+	assert.Nil(t, loadSplitKey(samples, Shard{End: []byte{3}}, nil), "out of range")
+	assert.Equal(t, []byte{2}, loadSplitKey(samples, Shard{}, func(_ Shard, key []byte) []byte {
+		return []byte{key[0] - 1}
+	}))
+	assert.Nil(t, loadSplitKey(samples, Shard{}, func(_ Shard, key []byte) []byte {
+		return []byte{1}
+	}), "adjusted to the first key")
+}
+
+func TestPendingLoadSplit(t *testing.T) {
+	var p pendingLoadSplit
+	assert.Nil(t, p.take(Epoch{}))
+
+	p.set(Epoch{Generation: 1}, []byte{1})
+	assert.Nil(t, p.take(Epoch{Generation: 2}), "stale")
+	assert.Nil(t, p.take(Epoch{Generation: 1}), "cleared")
+
+	p.set(Epoch{Generation: 1}, []byte{1})
+	assert.Equal(t, []byte{1}, p.take(Epoch{Generation: 1}))
+	assert.Nil(t, p.take(Epoch{Generation: 1}))
+}
@@ -0,0 +1,241 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+var (
+	errMergeShardsNotAdjacent  = errors.New("merge shards not adjacent")
+	errMergeReplicasNotAligned = errors.New("merge shards replicas not aligned")
+	errMergeTargetEpochChanged = errors.New("merge target epoch changed")
+)
+
+// maxMergeSourceWait the max duration the target replica waits for the local
+// replica of the source shard to apply the prepare merge log. The target
+// replica is destroyed after that, and rebuilt by prophet.
+var maxMergeSourceWait = time.Minute * 10
+
+// checkMergeShards checks the source shard can be merged into the target
+// shard. The shards must be adjacent shards of the same group, and the replicas
+// of the shards must be placed on the same stores, so that every replica of the
+// target shard has the data of the source shard locally.
+func checkMergeShards(source, target Shard) error {
+	if source.ID == target.ID || source.Group != target.Group {
+		return errMergeShardsNotAdjacent
+	}
+	if !(len(source.End) > 0 && bytes.Equal(source.End, target.Start)) &&
+		!(len(target.End) > 0 && bytes.Equal(target.End, source.Start)) {
+		return errMergeShardsNotAdjacent
+	}
+
+	if len(source.Replicas) != len(target.Replicas) {
+		return errMergeReplicasNotAligned
+	}
+	for _, r := range source.Replicas {
+		if findReplica(target, r.StoreID) == nil {
+			return errMergeReplicasNotAligned
+		}
+	}
+	return nil
+}
+
+// checkCommitMergeShards checks the source shard can be merged into the target
+// shard, and the epoch of the target shard is not changed since the merge
+// prepared.
+func checkCommitMergeShards(source, prepared, target Shard) error {
+	if !epochMatch(prepared.Epoch, target.Epoch) {
+		return errMergeTargetEpochChanged
+	}
+	return checkMergeShards(source, target)
+}
+
+// mergedShard returns the target shard with the key range extended by the
+// source shard.
+func mergedShard(source, target Shard) Shard {
+	if bytes.Equal(source.End, target.Start) {
+		target.Start = source.Start
+	} else {
+		target.End = source.End
+	}
+	if source.Epoch.Generation > target.Epoch.Generation {
+		target.Epoch.Generation = source.Epoch.Generation
+	}
+	target.Epoch.Generation++
+	return target
+}
+
+// doPrepareMerge proposes the prepare merge log of the source shard, the source
+// shard stops serving once the log applied and waits for the target shard to
+// commit the merge.
+func (pr *replica) doPrepareMerge(target Shard) {
+	current := pr.getShard()
+	if current.State == metapb.ShardState_Merging {
+		return
+	}
+	if err := checkMergeShards(current, target); err != nil {
+		pr.logger.Info("skip prepare merge",
+			log.ShardField("target", target),
+			zap.Error(err))
+		return
+	}
+
+	pr.logger.Info("send prepare merge request",
+		log.ShardField("target", target))
+	pr.addAdminRequest(rpcpb.CmdPrepareMerge, &rpcpb.PrepareMergeRequest{
+		Target: target,
+	})
+}
+
+// doCommitMerge proposes the commit merge log of the target shard, the source
+// shard must have applied the prepare merge log on all the replicas. The
+// prepared is the target shard when the merge prepared, the merge is skipped if
+// the epoch of the target shard changed since then.
+func (pr *replica) doCommitMerge(source, prepared Shard) {
+	current := pr.getShard()
+	if source.State != metapb.ShardState_Merging {
+		return
+	}
+	if err := checkCommitMergeShards(source, prepared, current); err != nil {
+		pr.logger.Info("skip commit merge",
+			log.ShardField("source", source),
+			zap.Error(err))
+		return
+	}
+
+	pr.logger.Info("send commit merge request",
+		log.ShardField("source", source))
+	pr.addAdminRequest(rpcpb.CmdCommitMerge, &rpcpb.CommitMergeRequest{
+		Source: source,
+		Target: prepared,
+	})
+}
+
+// doRollbackMerge proposes the rollback merge log. The target shard rejects
+// the merge first, and then the source shard is unfrozen.
+func (pr *replica) doRollbackMerge(source Shard) {
+	current := pr.getShard()
+	if current.ID == source.ID &&
+		current.State != metapb.ShardState_Merging {
+		return
+	}
+
+	pr.logger.Info("send rollback merge request",
+		log.ShardField("source", source))
+	pr.addAdminRequest(rpcpb.CmdRollbackMerge, &rpcpb.RollbackMergeRequest{
+		Source: source,
+	})
+}
+
+// getMergeSource returns the local replica's shard and the applied index of the
+// source shard, false if the local replica has not applied the prepare merge
+// log yet.
+func (s *store) getMergeSource(id uint64) (Shard, uint64, bool) {
+	pr := s.getReplica(id, false)
+	if pr == nil {
+		return Shard{}, 0, false
+	}
+	shard := pr.getShard()
+	if shard.State != metapb.ShardState_Merging {
+		return Shard{}, 0, false
+	}
+	index, _ := pr.sm.getAppliedIndexTerm()
+	return shard, index, true
+}
+
+// isMergePrepared returns true if all the replicas of the merging shard have
+// applied the logs applied by the leader, no more logs are applied after the
+// prepare merge log.
+func (pr *replica) isMergePrepared(progresses []metapb.ReplicaProgress) bool {
+	if pr.getShard().State != metapb.ShardState_Merging {
+		return false
+	}
+	for _, p := range progresses {
+		if p.AppliedIndex < pr.appliedIndex {
+			return false
+		}
+	}
+	return true
+}
+
+// isMergeTarget returns true if the adjacent shard is merging into the shard
+// locally, the epoch of the shard is pinned until the merge committed or
+// rollback.
+func (s *store) isMergeTarget(shard Shard) bool {
+	target := false
+	s.forEachReplica(func(r *replica) bool {
+		source := r.getShard()
+		if source.State == metapb.ShardState_Merging &&
+			checkMergeShards(source, shard) == nil {
+			target = true
+			return false
+		}
+		return true
+	})
+	return target
+}
+
+// checkMergeSourceWait destroys the replica if the apply is paused by the commit
+// merge log for too long, the local replica of the source shard may be removed.
+func (pr *replica) checkMergeSourceWait(now time.Time) bool {
+	if pr.mergePausedSince.IsZero() ||
+		now.Sub(pr.mergePausedSince) < maxMergeSourceWait {
+		return false
+	}
+
+	pr.logger.Error("merge source not prepared in time, destroy the replica",
+		zap.Duration("wait", now.Sub(pr.mergePausedSince)))
+	pr.pendingApplyEntries = nil
+	pr.mergePausedSince = time.Time{}
+	pr.store.destroyReplica(pr.shardID, false, true, "merge source not prepared")
+	return true
+}
+
+// destroyMergedSources destroys the local replicas of the merging shards
+// covered by the shard. The merge was committed before the snapshot of the
+// shard created, and the data of the merged shards is included in the snapshot.
+func (pr *replica) destroyMergedSources(shard Shard) {
+	pr.store.forEachReplica(func(r *replica) bool {
+		source := r.getShard()
+		if source.ID != shard.ID &&
+			source.Group == shard.Group &&
+			source.State == metapb.ShardState_Merging &&
+			source.Epoch.Generation < shard.Epoch.Generation &&
+			shardContains(shard, source) {
+			pr.logger.Info("destroy merged source shard",
+				log.ShardField("source", source))
+			pr.store.destroyReplica(source.ID, true, false, "merged")
+		}
+		return true
+	})
+}
+
+// shardContains returns true if the key range of the shard contains the key
+// range of the other shard.
+func shardContains(shard, other Shard) bool {
+	if bytes.Compare(other.Start, shard.Start) < 0 {
+		return false
+	}
+	return len(shard.End) == 0 ||
+		(len(other.End) > 0 && bytes.Compare(other.End, shard.End) <= 0)
+}
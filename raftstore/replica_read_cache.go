@@ -0,0 +1,202 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"bytes"
+	"container/list"
+	"sync"
+
+	"github.com/matrixorigin/matrixcube/metric"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+)
+
+type readCacheKeyRangeFunc func(req storage.Request) (start, end []byte, ok bool)
+
+type readCacheKey struct {
+	cmdType uint64
+	key     string
+	cmd     string
+}
+
+type readCacheEntry struct {
+	key        readCacheKey
+	start, end []byte
+	value      []byte
+}
+
+// readCache the LRU cache of the read responses of a shard. The responses are
+// invalidated once the writes overlapping the key ranges of the reads are
+// applied. The reads are executed concurrently with the apply, so the response
+// is only cached if no write was applied since the read started.
+type readCache struct {
+	sync.Mutex
+	maxEntries    int
+	maxValueBytes int
+	keyRangeFunc  readCacheKeyRangeFunc
+	// writeIndex the index of the last applied write
+	writeIndex uint64
+	ll         *list.List
+	entries    map[readCacheKey]*list.Element
+}
+
+// newReadCache returns nil if the read cache is disabled
+func newReadCache(maxEntries, maxValueBytes int, keyRangeFunc readCacheKeyRangeFunc) *readCache {
+	if maxEntries <= 0 || keyRangeFunc == nil {
+		return nil
+	}
+	return &readCache{
+		maxEntries:    maxEntries,
+		maxValueBytes: maxValueBytes,
+		keyRangeFunc:  keyRangeFunc,
+		ll:            list.New(),
+		entries:       make(map[readCacheKey]*list.Element),
+	}
+}
+
+func newReadCacheKey(req storage.Request) readCacheKey {
+	return readCacheKey{cmdType: req.CmdType, key: string(req.Key), cmd: string(req.Cmd)}
+}
+
+// get returns the cached response of the read request
+func (c *readCache) get(req storage.Request) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	if _, _, ok := c.keyRangeFunc(req); !ok {
+		return nil, false
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if ele, ok := c.entries[newReadCacheKey(req)]; ok {
+		c.ll.MoveToFront(ele)
+		metric.IncReadCacheCount("hit")
+		return ele.Value.(*readCacheEntry).value, true
+	}
+	metric.IncReadCacheCount("miss")
+	return nil, false
+}
+
+// put caches the response of the read request started after the index applied
+func (c *readCache) put(req storage.Request, value []byte, index uint64) {
+	if c == nil || len(value) > c.maxValueBytes {
+		return
+	}
+	start, end, ok := c.keyRangeFunc(req)
+	if !ok {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if c.writeIndex > index {
+		return
+	}
+	key := newReadCacheKey(req)
+	if ele, ok := c.entries[key]; ok {
+		c.removeElement(ele)
+	}
+	c.entries[key] = c.ll.PushFront(&readCacheEntry{
+		key:   key,
+		start: start,
+		end:   end,
+		value: append([]byte(nil), value...),
+	})
+	if c.ll.Len() > c.maxEntries {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// invalidate removes the cached responses overlapping the write requests
+// applied at the index. The requests are passed before encoded by the data
+// storage, and the transaction requests invalidate all the cached responses.
+func (c *readCache) invalidate(index uint64, requests []rpcpb.Request) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	c.writeIndex = index
+	for _, req := range requests {
+		if c.ll.Len() == 0 {
+			return
+		}
+		if req.IsTransaction() {
+			c.clearLocked()
+			return
+		}
+		start, end, ok := c.keyRangeFunc(storage.Request{
+			CmdType: req.CustomType,
+			Key:     req.Key,
+			Cmd:     req.Cmd,
+		})
+		if !ok {
+			c.clearLocked()
+			return
+		}
+		for ele := c.ll.Front(); ele != nil; {
+			next := ele.Next()
+			if entry := ele.Value.(*readCacheEntry); overlapKeyRange(entry.start, entry.end, start, end) {
+				c.removeElement(ele)
+			}
+			ele = next
+		}
+	}
+}
+
+// clear removes all the cached responses, e.g. the shard is changed by the
+// admin request or the snapshot at the index.
+func (c *readCache) clear(index uint64) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	c.writeIndex = index
+	c.clearLocked()
+}
+
+func (c *readCache) clearLocked() {
+	c.ll.Init()
+	c.entries = make(map[readCacheKey]*list.Element)
+}
+
+func (c *readCache) len() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.ll.Len()
+}
+
+func (c *readCache) removeElement(ele *list.Element) {
+	c.ll.Remove(ele)
+	delete(c.entries, ele.Value.(*readCacheEntry).key)
+}
+
+// overlapKeyRange returns true if the key ranges [start, end) are overlapped,
+// the end is nil if only the start key is in the range.
+func overlapKeyRange(start1, end1, start2, end2 []byte) bool {
+	return keyRangeBefore(start1, start2, end2) && keyRangeBefore(start2, start1, end1)
+}
+
+// keyRangeBefore returns true if the key is before the end of the key range
+func keyRangeBefore(key, start, end []byte) bool {
+	if len(end) == 0 {
+		return bytes.Compare(key, start) <= 0
+	}
+	return bytes.Compare(key, end) < 0
+}
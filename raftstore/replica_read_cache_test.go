@@ -0,0 +1,176 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+)
+
+const (
+	testCachedPointRead = 1
+	testCachedRangeRead = 2
+	testUncachedRead    = 3
+	testUnknownWrite    = 4
+)
+
+// testReadCacheKeyRange the range reads scan [Key, Cmd), and the point reads
+// and writes touch the Key only
+func testReadCacheKeyRange(req storage.Request) ([]byte, []byte, bool) {
+	switch req.CmdType {
+	case testCachedRangeRead:
+		return req.Key, req.Cmd, true
+	case testUncachedRead, testUnknownWrite:
+		return nil, nil, false
+	}
+	return req.Key, nil, true
+}
+
+func TestReadCacheDisabled(t *testing.T) {
+	assert.Nil(t, newReadCache(0, 1024, testReadCacheKeyRange))
+	assert.Nil(t, newReadCache(16, 1024, nil))
+
+	var c *readCache
+	c.put(storage.Request{Key: []byte("k1")}, []byte("v1"), 1)
+	_, ok := c.get(storage.Request{Key: []byte("k1")})
+	assert.False(t, ok)
+	c.invalidate(2, []rpcpb.Request{{Key: []byte("k1")}})
+	c.clear(3)
+}
+
+func TestReadCacheGetAndPut(t *testing.T) {
+	c := newReadCache(2, 4, testReadCacheKeyRange)
+	r1 := storage.Request{CmdType: testCachedPointRead, Key: []byte("k1")}
+	r2 := storage.Request{CmdType: testCachedPointRead, Key: []byte("k2")}
+	r3 := storage.Request{CmdType: testCachedPointRead, Key: []byte("k3")}
+
+	_, ok := c.get(r1)
+	assert.False(t, ok)
+	value := []byte("v1")
+	c.put(r1, value, 1)
+	value[0] = 'x'
+	v, ok := c.get(r1)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("v1"), v)
+
+	// the same key with the different commands are cached separately
+	_, ok = c.get(storage.Request{CmdType: testCachedPointRead, Key: []byte("k1"), Cmd: []byte("c")})
+	assert.False(t, ok)
+
+	// the uncacheable reads and the large responses are not cached
+	c.put(storage.Request{CmdType: testUncachedRead, Key: []byte("k1")}, []byte("v1"), 1)
+	c.put(r2, []byte("large"), 1)
+	assert.Equal(t, 1, c.len())
+
+	// the least recently used response is evicted
+	c.put(r2, []byte("v2"), 1)
+	c.get(r1)
+	c.put(r3, []byte("v3"), 1)
+	assert.Equal(t, 2, c.len())
+	_, ok = c.get(r2)
+	assert.False(t, ok)
+	_, ok = c.get(r1)
+	assert.True(t, ok)
+
+	// the reads started before the last applied write are not cached
+	c.invalidate(5, []rpcpb.Request{{Key: []byte("k0")}})
+	c.put(r2, []byte("v2"), 4)
+	_, ok = c.get(r2)
+	assert.False(t, ok)
+	c.put(r2, []byte("v2"), 5)
+	_, ok = c.get(r2)
+	assert.True(t, ok)
+}
+
+func TestReadCacheInvalidate(t *testing.T) {
+	c := newReadCache(16, 1024, testReadCacheKeyRange)
+	point := storage.Request{CmdType: testCachedPointRead, Key: []byte("b")}
+	scan := storage.Request{CmdType: testCachedRangeRead, Key: []byte("c"), Cmd: []byte("e")}
+	reset := func() {
+		c.clear(0)
+		c.put(point, []byte("v"), 0)
+		c.put(scan, []byte("v"), 0)
+	}
+	cached := func(req storage.Request) bool {
+		_, ok := c.get(req)
+		return ok
+	}
+
+	reset()
+	c.invalidate(1, []rpcpb.Request{{Key: []byte("a")}, {Key: []byte("e")}})
+	assert.True(t, cached(point))
+	assert.True(t, cached(scan))
+
+	reset()
+	c.invalidate(1, []rpcpb.Request{{Key: []byte("b")}})
+	assert.False(t, cached(point))
+	assert.True(t, cached(scan))
+
+	reset()
+	c.invalidate(1, []rpcpb.Request{{Key: []byte("d")}})
+	assert.True(t, cached(point))
+	assert.False(t, cached(scan))
+
+	reset()
+	c.invalidate(1, []rpcpb.Request{{CustomType: testCachedRangeRead, Key: []byte("a"), Cmd: []byte("c")}})
+	assert.False(t, cached(point))
+	assert.True(t, cached(scan))
+
+	reset()
+	c.invalidate(1, []rpcpb.Request{{CustomType: testCachedRangeRead, Key: []byte("a"), Cmd: []byte("d")}})
+	assert.False(t, cached(point))
+	assert.False(t, cached(scan))
+
+	reset()
+	c.invalidate(1, []rpcpb.Request{{CustomType: testUnknownWrite, Key: []byte("z")}})
+	assert.Equal(t, 0, c.len())
+
+	reset()
+	c.invalidate(1, []rpcpb.Request{{CustomType: uint64(rpcpb.CmdUpdateTxnRecord), Key: []byte("z")}})
+	assert.Equal(t, 0, c.len())
+
+	reset()
+	c.clear(1)
+	assert.Equal(t, 0, c.len())
+}
+
+func TestOverlapKeyRange(t *testing.T) {
+	k := func(v string) []byte {
+		if v == "" {
+			return nil
+		}
+		return []byte(v)
+	}
+	cases := []struct {
+		start1, end1, start2, end2 string
+		overlap                    bool
+	}{
+		{"a", "", "a", "", true},
+		{"a", "", "b", "", false},
+		{"a", "c", "b", "", true},
+		{"a", "c", "c", "", false},
+		{"a", "c", "a", "", true},
+		{"a", "c", "c", "d", false},
+		{"a", "c", "b", "d", true},
+		{"b", "c", "a", "d", true},
+	}
+	for i, c := range cases {
+		assert.Equal(t, c.overlap, overlapKeyRange(k(c.start1), k(c.end1), k(c.start2), k(c.end2)), "case %d", i)
+		assert.Equal(t, c.overlap, overlapKeyRange(k(c.start2), k(c.end2), k(c.start1), k(c.end1)), "case %d", i)
+	}
+}
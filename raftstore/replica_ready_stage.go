@@ -0,0 +1,64 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"time"
+
+	"go.etcd.io/etcd/raft/v3"
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/metric"
+)
+
+const (
+	// readyStageAppend saves the raft state and appends the entries to the logdb
+	readyStageAppend = "append"
+	// readyStageSend sends the raft messages
+	readyStageSend = "send"
+	// readyStageApply dispatches the committed entries to apply
+	readyStageApply = "apply"
+)
+
+// observeReadyStage records the duration of the raft ready handling stage, the
+// stage exceeding the ReadyStageBudget is logged with the shard detail, as it
+// stalls the other replicas handled by the same event worker.
+func (pr *replica) observeReadyStage(stage string, elapsed time.Duration, rd raft.Ready) {
+	metric.ObserveRaftReadyStageDuration(stage, elapsed)
+	budget := pr.cfg.Raft.ReadyStageBudget.Duration
+	if budget <= 0 || elapsed <= budget {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("stage", stage),
+		zap.Duration("elapsed", elapsed),
+		zap.Duration("budget", budget),
+		log.ShardIDField(pr.shardID),
+		log.ReplicaIDField(pr.replicaID),
+		zap.Uint64("group", pr.getShard().Group),
+		zap.Int("entries", len(rd.Entries)),
+		zap.Int("estimated-append-size", getEstimatedAppendSize(rd)),
+		zap.Int("committed-entries", len(rd.CommittedEntries)),
+		zap.Int("messages", len(rd.Messages)),
+		zap.Bool("snapshot", !raft.IsEmptySnap(rd.Snapshot)),
+	}
+	if n := len(rd.CommittedEntries); n > 0 {
+		fields = append(fields,
+			zap.Uint64("first-committed-index", rd.CommittedEntries[0].Index),
+			zap.Uint64("last-committed-index", rd.CommittedEntries[n-1].Index))
+	}
+	pr.logger.Warn("raft ready stage exceeds the budget", fields...)
+}
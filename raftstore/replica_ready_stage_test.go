@@ -0,0 +1,63 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+)
+
+func TestObserveReadyStage(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	pr := newTestReplica(Shard{ID: 1, Group: 2}, Replica{ID: 3}, s)
+	pr.logger = zap.New(core)
+	pr.cfg.Raft.ReadyStageBudget.Duration = time.Millisecond * 100
+
+	rd := raft.Ready{
+		Entries:          []raftpb.Entry{{Index: 5, Data: []byte("data")}},
+		CommittedEntries: []raftpb.Entry{{Index: 3}, {Index: 4}},
+		Messages:         []raftpb.Message{{}},
+	}
+	pr.observeReadyStage(readyStageAppend, time.Millisecond*100, rd)
+	assert.Equal(t, 0, logs.Len(), "within the budget")
+
+	pr.observeReadyStage(readyStageApply, time.Second, rd)
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, readyStageApply, fields["stage"])
+	assert.Equal(t, time.Second, fields["elapsed"])
+	assert.Equal(t, uint64(1), fields["shard-id"])
+	assert.Equal(t, uint64(2), fields["group"])
+	assert.Equal(t, int64(1), fields["entries"])
+	assert.Equal(t, int64(2), fields["committed-entries"])
+	assert.Equal(t, uint64(3), fields["first-committed-index"])
+	assert.Equal(t, uint64(4), fields["last-committed-index"])
+	assert.Equal(t, int64(1), fields["messages"])
+	assert.Equal(t, false, fields["snapshot"])
+}
@@ -14,6 +14,8 @@
 package raftstore
 
 import (
+	"time"
+
 	"github.com/cockroachdb/errors"
 	"github.com/fagongzi/util/protoc"
 	"go.etcd.io/etcd/raft/v3"
@@ -21,6 +23,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/metric"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/storage"
 )
@@ -55,6 +58,17 @@ func (pr *replica) createSnapshot() (raftpb.Snapshot, bool, error) {
 		log.ReplicaIDsField("voters", cs.Voters),
 		log.ReplicaIDsField("learners", cs.Learners))
 
+	// the raft requests the snapshot again if not created now
+	if !pr.store.snapshots.acquire(pr.snapshotter.rootDir) {
+		logger.Info("snapshot postponed")
+		return raftpb.Snapshot{}, false, nil
+	}
+	var size int64
+	defer func() {
+		pr.store.snapshots.release(pr.snapshotter.rootDir, size)
+	}()
+
+	start := time.Now()
 	ss, ssenv, err := pr.snapshotter.save(pr.sm.dataStorage, cs, index, term)
 	if err != nil {
 		if errors.Is(err, storage.ErrAborted) {
@@ -80,6 +94,10 @@ func (pr *replica) createSnapshot() (raftpb.Snapshot, bool, error) {
 		return raftpb.Snapshot{}, false, err
 	}
 	logger.Info("snapshot committed")
+	shard := pr.getShard()
+	size = pr.snapshotter.getSnapshotSize(ssenv.GetFinalDir())
+	metric.ObserveStorageSnapshot(pr.cfg.Metric, pr.storeID, shard.ID, shard.Group,
+		metric.SnapshotCreate, start, size)
 	if err := pr.lr.CreateSnapshot(ss); err != nil {
 		if errors.Is(err, raft.ErrSnapOutOfDate) {
 			// lr already has a more recent snapshot
@@ -94,6 +112,26 @@ func (pr *replica) createSnapshot() (raftpb.Snapshot, bool, error) {
 	return ss, true, nil
 }
 
+// verifySnapshot returns false if the received snapshot is corrupted. The
+// corrupted snapshot is dropped before stepped into the raft, then the leader
+// sends the snapshot again.
+func (pr *replica) verifySnapshot(ss raftpb.Snapshot) bool {
+	v, ok := pr.sm.dataStorage.(storage.SnapshotVerifier)
+	if !ok {
+		return true
+	}
+	if err := pr.snapshotter.verify(v, ss); err != nil {
+		if errors.Is(err, storage.ErrSnapshotCorrupted) {
+			return false
+		}
+		// other errors are returned again when applying the snapshot
+		pr.logger.Error("failed to verify the snapshot",
+			log.SnapshotField(ss),
+			zap.Error(err))
+	}
+	return true
+}
+
 func (pr *replica) applySnapshot(ss raftpb.Snapshot) error {
 	logger := pr.logger.With(log.SnapshotField(ss))
 	// double check whether we are trying to recover from a dummy snapshot
@@ -104,13 +142,18 @@ func (pr *replica) applySnapshot(ss raftpb.Snapshot) error {
 			logger.Fatal("trying to recover from a dummy snapshot")
 		}
 	}
+	start := time.Now()
 	md, err := pr.snapshotter.recover(pr.sm.dataStorage, ss)
 	if err != nil {
 		logger.Error("failed to recover from the snapshot",
 			zap.Error(err))
 		return err
 	}
+	env := pr.snapshotter.getRecoverSnapshotEnv(ss)
+	metric.ObserveStorageSnapshot(pr.cfg.Metric, pr.storeID, pr.shardID, md.Metadata.Shard.Group,
+		metric.SnapshotApply, start, pr.snapshotter.getSnapshotSize(env.GetFinalDir()))
 	pr.appliedIndex = ss.Metadata.Index
+	pr.sm.readCache.clear(ss.Metadata.Index)
 	// when applying initial snapshot, we've already applied the ss record into
 	// the LogReader beforehand, applying the ss record again here would void
 	// the lr.SetRange change.
@@ -124,6 +167,7 @@ func (pr *replica) applySnapshot(ss raftpb.Snapshot) error {
 	// after snapshot applied, the shard range may changed, so we
 	// need update key ranges
 	pr.store.updateShardKeyRange(pr.group, md.Metadata.Shard)
+	pr.destroyMergedSources(md.Metadata.Shard)
 	// r.replica is more like a local cached copy of the replica record.
 	pr.replica = *findReplica(pr.getShard(), pr.storeID)
 	pr.sm.updateAppliedIndexTerm(ss.Metadata.Index, ss.Metadata.Term)
@@ -0,0 +1,238 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"time"
+
+	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+// snapshotFailure the consecutive snapshot failures of a replica
+type snapshotFailure struct {
+	failures uint64
+	reason   metapb.SnapshotFailureReason
+	// index the index of the last failed snapshot, the replica is healthy once
+	// it matches the index
+	index   uint64
+	retryAt time.Time
+	// sending a snapshot was sent and no failure reported yet, both the sender
+	// and the receiver may report the failure of the same snapshot
+	sending bool
+	// delayed a snapshot was generated before the retry time and dropped, the
+	// raft is notified at the retry time to generate the snapshot again
+	delayed bool
+}
+
+// snapshotFailureTracker tracks the snapshot failures of the replicas on the
+// leader. The snapshot is resent to the failed replica with exponential
+// backoff delays, and the replica is quarantined after too many consecutive
+// failures. The tracker must be accessed in the event worker.
+type snapshotFailureTracker struct {
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	maxRetries uint64
+	quarantine time.Duration
+	replicas   map[uint64]*snapshotFailure // replica-id -> failure
+}
+
+func newSnapshotFailureTracker(cfg config.SnapshotConfig) *snapshotFailureTracker {
+	return &snapshotFailureTracker{
+		baseDelay:  cfg.RetryBaseDelay.Duration,
+		maxDelay:   cfg.RetryMaxDelay.Duration,
+		maxRetries: cfg.MaxRetries,
+		quarantine: cfg.QuarantineDuration.Duration,
+		replicas:   make(map[uint64]*snapshotFailure),
+	}
+}
+
+// canSend returns false if the snapshot to the replica should be delayed
+func (t *snapshotFailureTracker) canSend(id uint64, now time.Time) bool {
+	if f, ok := t.replicas[id]; ok {
+		return !now.Before(f.retryAt)
+	}
+	return true
+}
+
+// delay records the snapshot to the replica dropped before the retry time
+func (t *snapshotFailureTracker) delay(id uint64) {
+	if f, ok := t.replicas[id]; ok {
+		f.delayed = true
+	}
+}
+
+// retryable returns the replicas whose delayed snapshots can be retried
+func (t *snapshotFailureTracker) retryable(now time.Time) []uint64 {
+	var ids []uint64
+	for id, f := range t.replicas {
+		if f.delayed && !now.Before(f.retryAt) {
+			f.delayed = false
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// sent records the snapshot sent to the replica
+func (t *snapshotFailureTracker) sent(id uint64) {
+	if f, ok := t.replicas[id]; ok {
+		f.sending = true
+	}
+}
+
+// failed records the snapshot failure of the replica. Only the first failure of
+// a sent snapshot is counted, the later reports of the same snapshot only
+// replace the network failure by the more specific reason.
+func (t *snapshotFailureTracker) failed(id, index uint64,
+	reason metapb.SnapshotFailureReason, now time.Time) {
+	f, ok := t.replicas[id]
+	if !ok {
+		f = &snapshotFailure{sending: true}
+		t.replicas[id] = f
+	}
+	if !f.sending {
+		if index == f.index && reason != metapb.SnapshotFailureReason_NetworkFailure {
+			f.reason = reason
+		}
+		return
+	}
+
+	f.sending = false
+	f.failures++
+	f.reason = reason
+	f.index = index
+	f.retryAt = now.Add(t.retryDelay(f.failures))
+}
+
+func (t *snapshotFailureTracker) retryDelay(failures uint64) time.Duration {
+	if t.isQuarantined(failures) {
+		return t.quarantine
+	}
+	delay := t.baseDelay
+	for i := uint64(1); i < failures && delay < t.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > t.maxDelay {
+		delay = t.maxDelay
+	}
+	return delay
+}
+
+func (t *snapshotFailureTracker) isQuarantined(failures uint64) bool {
+	return t.maxRetries > 0 && failures >= t.maxRetries
+}
+
+// update resets the failures of the replica if the replica has matched the
+// index of the failed snapshot, and fills the failures into the progress.
+func (t *snapshotFailureTracker) update(progress *metapb.ReplicaProgress) {
+	id := progress.Replica.ID
+	f, ok := t.replicas[id]
+	if !ok {
+		return
+	}
+	if progress.MatchIndex >= f.index {
+		delete(t.replicas, id)
+		return
+	}
+	progress.SnapshotFailure = f.reason
+	progress.SnapshotFailures = f.failures
+	progress.SnapshotQuarantined = t.isQuarantined(f.failures)
+}
+
+// handleSnapshotFailure handles the snapshot failure reported by the transport
+// or the replica receiving the snapshot. The transport of the receiver marks
+// the snapshot message with the failure if the snapshot can not be saved, and
+// the receiver reports the failure to the leader with MsgSnapStatus.
+func (pr *replica) handleSnapshotFailure(msg metapb.RaftMessage) {
+	switch msg.Message.Type {
+	case raftpb.MsgSnap:
+		pr.reportSnapshotFailure(msg, msg.SnapshotFailure)
+	case raftpb.MsgSnapStatus:
+		if !pr.isLeader() {
+			return
+		}
+		pr.logger.Warn("snapshot rejected by replica",
+			log.ReplicaField("replica", msg.From),
+			zap.Uint64("index", msg.Message.Index),
+			zap.String("reason", msg.SnapshotFailure.String()))
+		pr.snapshotFailures.failed(msg.From.ID, msg.Message.Index,
+			msg.SnapshotFailure, time.Now())
+		pr.rn.ReportSnapshot(msg.From.ID, raft.SnapshotFailure)
+	}
+}
+
+// reportSnapshotFailure reports the failure of the received snapshot to the
+// sender of the snapshot.
+func (pr *replica) reportSnapshotFailure(msg metapb.RaftMessage,
+	reason metapb.SnapshotFailureReason) {
+	index := msg.Message.Snapshot.Metadata.Index
+	pr.logger.Error("failed to receive snapshot",
+		log.ReplicaField("from", msg.From),
+		zap.Uint64("index", index),
+		zap.String("reason", reason.String()))
+	if msg.From.StoreID == 0 {
+		// the sender is unknown, the leader resends the snapshot after the timeout
+		return
+	}
+
+	shard := pr.getShard()
+	pr.transport.Send(metapb.RaftMessage{
+		ShardID:    pr.shardID,
+		Group:      shard.Group,
+		From:       pr.replica,
+		To:         msg.From,
+		ShardEpoch: shard.Epoch,
+		Message: raftpb.Message{
+			Type:   raftpb.MsgSnapStatus,
+			From:   pr.replicaID,
+			To:     msg.From.ID,
+			Index:  index,
+			Reject: true,
+		},
+		CommitIndex:     pr.lastCommittedIndex,
+		AppliedIndex:    pr.appliedIndex,
+		SnapshotFailure: reason,
+		SendTime:        uint64(time.Now().UnixMilli()),
+	})
+}
+
+// delaySnapshot drops the snapshot generated before the retry time of the
+// replica, the raft is notified to generate the snapshot again once the replica
+// is retryable.
+func (pr *replica) delaySnapshot(to Replica, ss raftpb.Snapshot) {
+	pr.logger.Debug("snapshot delayed by previous failures",
+		log.ReplicaField("to", to),
+		log.SnapshotField(ss))
+	pr.snapshotFailures.delay(to.ID)
+	if err := pr.removeSnapshot(ss, false); err != nil {
+		pr.logger.Error("remove snapshot failed",
+			zap.Error(err))
+	}
+}
+
+// retryDelayedSnapshots notifies the raft the delayed snapshots are failed, so
+// that the snapshots are generated and sent again.
+func (pr *replica) retryDelayedSnapshots() {
+	for _, id := range pr.snapshotFailures.retryable(time.Now()) {
+		if pr.isLeader() {
+			pr.rn.ReportSnapshot(id, raft.SnapshotFailure)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/util/typeutil"
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+func newTestSnapshotFailureTracker() *snapshotFailureTracker {
+	return newSnapshotFailureTracker(config.SnapshotConfig{
+		RetryBaseDelay:     typeutil.NewDuration(time.Second),
+		RetryMaxDelay:      typeutil.NewDuration(time.Second * 3),
+		MaxRetries:         4,
+		QuarantineDuration: typeutil.NewDuration(time.Minute),
+	})
+}
+
+func TestSnapshotFailureBackoff(t *testing.T) {
+	tr := newTestSnapshotFailureTracker()
+	now := time.Now()
+	assert.True(t, tr.canSend(1, now))
+
+	delays := []time.Duration{time.Second, time.Second * 2, time.Second * 3, time.Minute}
+	for i, delay := range delays {
+		tr.sent(1)
+		tr.failed(1, 10, metapb.SnapshotFailureReason_NetworkFailure, now)
+		assert.False(t, tr.canSend(1, now.Add(delay-time.Millisecond)), "failure %d", i+1)
+		assert.True(t, tr.canSend(1, now.Add(delay)), "failure %d", i+1)
+	}
+	assert.True(t, tr.canSend(2, now))
+
+	progress := metapb.ReplicaProgress{Replica: Replica{ID: 1}, MatchIndex: 9}
+	tr.update(&progress)
+	assert.Equal(t, metapb.SnapshotFailureReason_NetworkFailure, progress.SnapshotFailure)
+	assert.Equal(t, uint64(4), progress.SnapshotFailures)
+	assert.True(t, progress.SnapshotQuarantined)
+
+	// healthy once the replica matches the failed snapshot
+	progress = metapb.ReplicaProgress{Replica: Replica{ID: 1}, MatchIndex: 10}
+	tr.update(&progress)
+	assert.Equal(t, uint64(0), progress.SnapshotFailures)
+	assert.True(t, tr.canSend(1, now))
+}
+
+func TestSnapshotFailureReportedTwice(t *testing.T) {
+	tr := newTestSnapshotFailureTracker()
+	now := time.Now()
+	tr.failed(1, 10, metapb.SnapshotFailureReason_DiskFull, now)
+	// the sender reports the same snapshot
+	tr.failed(1, 10, metapb.SnapshotFailureReason_NetworkFailure, now)
+	progress := metapb.ReplicaProgress{Replica: Replica{ID: 1}}
+	tr.update(&progress)
+	assert.Equal(t, metapb.SnapshotFailureReason_DiskFull, progress.SnapshotFailure)
+	assert.Equal(t, uint64(1), progress.SnapshotFailures)
+
+	tr.sent(1)
+	tr.failed(1, 12, metapb.SnapshotFailureReason_NetworkFailure, now)
+	tr.failed(1, 12, metapb.SnapshotFailureReason_ChecksumMismatch, now)
+	tr.update(&progress)
+	assert.Equal(t, metapb.SnapshotFailureReason_ChecksumMismatch, progress.SnapshotFailure)
+	assert.Equal(t, uint64(2), progress.SnapshotFailures)
+}
+
+func TestSnapshotFailureDelayed(t *testing.T) {
+	tr := newTestSnapshotFailureTracker()
+	now := time.Now()
+	tr.delay(1)
+	assert.Empty(t, tr.retryable(now), "no failure")
+
+	tr.failed(1, 10, metapb.SnapshotFailureReason_NetworkFailure, now)
+	tr.delay(1)
+	assert.Empty(t, tr.retryable(now))
+	assert.Equal(t, []uint64{1}, tr.retryable(now.Add(time.Second)))
+	assert.Empty(t, tr.retryable(now.Add(time.Second)), "retried once")
+}
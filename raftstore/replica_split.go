@@ -14,6 +14,8 @@
 package raftstore
 
 import (
+	"time"
+
 	trackerPkg "go.etcd.io/etcd/raft/v3/tracker"
 	"go.uber.org/zap"
 
@@ -23,10 +25,13 @@ import (
 
 func (pr *replica) tryCheckSplit(act action) bool {
 	if !pr.isLeader() {
+		pr.loadSplitter.reset(time.Now())
 		return false
 	}
 
-	if !pr.needDoCheckSplit() {
+	// the shard is checked if it's hot even if it's small, the split key picked
+	// by the load is used if the shard needn't be split by the size
+	if !pr.checkLoadSplit() && !pr.needDoCheckSplit() {
 		return false
 	}
 
@@ -61,7 +66,37 @@ func (pr *replica) hasReplicaInSnapshotState() (bool, uint64) {
 }
 
 func (pr *replica) needDoCheckSplit() bool {
-	return pr.stats.approximateSize >= pr.feature.ShardSplitCheckBytes
+	return pr.stats.approximateSize >= pr.getSplitCheckBytes()
+}
+
+// getSplitCheckBytes returns the size to start the split check, the capacity
+// of the keyspace of the shard overrides the feature of the shard group.
+func (pr *replica) getSplitCheckBytes() uint64 {
+	if pr.store != nil {
+		if keyspace, ok := pr.store.getKeyspace(pr.getShard()); ok && keyspace.ShardCapacityBytes > 0 {
+			return keyspace.ShardCapacityBytes * 80 / 100
+		}
+	}
+	return pr.feature.ShardSplitCheckBytes
+}
+
+// doRefreshSize updates the approximate size estimated by the storage, and the
+// leader reports it to prophet at once, so the merges are not blocked by the
+// stale size. The estimate is dropped if the shard is split or merged since.
+func (pr *replica) doRefreshSize(act action) {
+	current := pr.getShard()
+	if current.Epoch.Generation != act.epoch.Generation {
+		pr.compaction.markSizeStale()
+		return
+	}
+
+	pr.logger.Info("approximate size refreshed",
+		zap.Uint64("from", pr.stats.approximateSize),
+		zap.Uint64("to", act.splitCheckData.size),
+		zap.Uint64("keys", act.splitCheckData.keys))
+	pr.stats.approximateSize = act.splitCheckData.size
+	pr.stats.approximateKeys = act.splitCheckData.keys
+	pr.prophetHeartbeat()
 }
 
 func (pr *replica) doSplit(act action) {
@@ -87,6 +122,11 @@ func (pr *replica) doSplit(act action) {
 	if len(act.splitCheckData.splitKeys) == 0 {
 		return
 	}
+	if pr.store.isMergeTarget(current) {
+		pr.logger.Info("skip split",
+			log.ReasonField("merge in flight"))
+		return
+	}
 
 	if len(act.splitCheckData.splitIDs) == 0 {
 		pr.logger.Fatal("missing splitIDs")
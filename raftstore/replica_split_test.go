@@ -15,10 +15,12 @@ package raftstore
 
 import (
 	"testing"
+	"time"
 
 	"github.com/fagongzi/util/protoc"
 	"github.com/matrixorigin/matrixcube/components/log"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
 	"github.com/matrixorigin/matrixcube/util/leaktest"
 	"github.com/stretchr/testify/assert"
 	"go.etcd.io/etcd/raft/v3"
@@ -48,6 +50,16 @@ func TestTryCheckSplit(t *testing.T) {
 	assert.True(t, pr.tryCheckSplit(action{actionType: checkSplitAction, actionCallback: func(v interface{}) {
 		assert.Equal(t, pr.getShard(), v)
 	}}))
+
+	// check the small but hot shard
+	pr.feature.ShardSplitCheckBytes = 200
+	pr.loadSplitter = newLoadSplitter(storage.Feature{ShardSplitLoadQPS: 1, ShardSplitLoadWindows: 1})
+	pr.loadSplitter.start = time.Now().Add(-time.Second)
+	for _, key := range []string{"a", "b", "c"} {
+		pr.loadSplitter.record([]byte(key), 1)
+	}
+	assert.True(t, pr.tryCheckSplit(action{actionType: checkSplitAction, actionCallback: func(v interface{}) {}}))
+	assert.Equal(t, []byte("b"), pr.loadSplit.take(pr.getShard().Epoch))
 }
 
 func TestDoSplit(t *testing.T) {
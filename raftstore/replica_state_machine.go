@@ -29,6 +29,19 @@ import (
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util/hlc"
+)
+
+var (
+	// requestsPool reuses the decoded requests of the raft logs among all state
+	// machines, rpcpb.Request is a large struct and a raft log may contain
+	// hundreds of requests.
+	requestsPool = sync.Pool{
+		New: func() interface{} {
+			v := make([]rpcpb.Request, 0, 16)
+			return &v
+		},
+	}
 )
 
 type applyContext struct {
@@ -38,6 +51,12 @@ type applyContext struct {
 	v2cc        raftpb.ConfChangeV2
 	adminResult *adminResult
 	metrics     applyMetrics
+	// requests is the decode buffer of req.Requests acquired from requestsPool,
+	// used is the max number of requests decoded into it since acquired.
+	requests *[]rpcpb.Request
+	used     int
+	// diskFull the write requests are not applied because the disk is full
+	diskFull bool
 }
 
 func newApplyContext() *applyContext {
@@ -47,12 +66,16 @@ func newApplyContext() *applyContext {
 }
 
 func (ctx *applyContext) initialize(entry raftpb.Entry) {
+	if ctx.requests == nil {
+		ctx.requests = requestsPool.Get().(*[]rpcpb.Request)
+	}
 	ctx.index = entry.Index
 	ctx.term = entry.Term
-	ctx.req = rpcpb.RequestBatch{}
+	ctx.req = rpcpb.RequestBatch{Requests: (*ctx.requests)[:0]}
 	ctx.adminResult = nil
 	ctx.metrics = applyMetrics{}
 	ctx.v2cc = raftpb.ConfChangeV2{}
+	ctx.diskFull = false
 
 	switch entry.Type {
 	case raftpb.EntryNormal:
@@ -72,6 +95,29 @@ func (ctx *applyContext) initialize(entry raftpb.Entry) {
 	default:
 		panic("unknown entry type")
 	}
+
+	*ctx.requests = ctx.req.Requests[:0]
+	if n := len(ctx.req.Requests); n > ctx.used {
+		ctx.used = n
+	} else if n == 0 {
+		ctx.req.Requests = nil
+	}
+}
+
+// release returns the decode buffer to the pool, the requests must not be used
+// after released.
+func (ctx *applyContext) release() {
+	if ctx.requests == nil {
+		return
+	}
+	requests := (*ctx.requests)[:ctx.used]
+	for i := range requests {
+		requests[i] = rpcpb.Request{}
+	}
+	requestsPool.Put(ctx.requests)
+	ctx.req = rpcpb.RequestBatch{}
+	ctx.requests = nil
+	ctx.used = 0
 }
 
 type replicaResultHandler interface {
@@ -94,6 +140,28 @@ type stateMachine struct {
 	replicaCreatorFactory    replicaCreatorFactory
 	resultHandler            replicaResultHandler
 	aware                    aware.ShardStateAware
+	applyResultHandler       aware.ApplyResultHandler
+	applyCommands            []aware.ApplyCommand
+	// readCache the cached read responses invalidated by the applied writes, nil
+	// if the read cache is disabled
+	readCache *readCache
+	// clock is updated by the timestamps of the applied raft logs, so the
+	// timestamps assigned by this store follow the timestamps it has applied
+	clock hlc.Clock
+	// mergeSourceGetter returns the local replica's shard and the applied index
+	// of the merge source shard, false if the merge is not prepared locally
+	mergeSourceGetter func(id uint64) (Shard, uint64, bool)
+	// mergePaused the apply is paused by the commit merge log
+	mergePaused bool
+	// buckets the write stats of the key ranges are updated by the applied
+	// writes, nil if the buckets are disabled
+	buckets *shardBuckets
+	// loadSplitter the load of the applied writes is recorded to split the shard
+	// by the load, nil if the load based split is disabled
+	loadSplitter *loadSplitter
+	// diskFullSince the unix nano when the apply is paused by the disk full, 0
+	// if the apply is not paused
+	diskFullSince int64
 
 	metadataMu struct {
 		sync.Mutex
@@ -182,9 +250,13 @@ func (d *stateMachine) getConfState() raftpb.ConfState {
 	return cs
 }
 
-func (d *stateMachine) applyCommittedEntries(entries []raftpb.Entry) {
+// applyCommittedEntries returns the entries not applied, the apply is paused at
+// the commit merge log until the local replica of the source shard prepared, or
+// at the write log failed to be applied until the disk space is reclaimed.
+func (d *stateMachine) applyCommittedEntries(entries []raftpb.Entry) []raftpb.Entry {
+	d.mergePaused = false
 	if len(entries) <= 0 {
-		return
+		return nil
 	}
 
 	d.logger.Debug("apply committed logs",
@@ -193,7 +265,7 @@ func (d *stateMachine) applyCommittedEntries(entries []raftpb.Entry) {
 	// FIXME: the initial idea is to batch multiple entries into the same
 	// executeContext so they can be applied into the stateMachine together.
 	// in the loop below, we are still applying entries one by one.
-	for _, entry := range entries {
+	for i, entry := range entries {
 		d.applyCtx.initialize(entry)
 		d.checkEntryIndexTerm(entry)
 		// notify all clients that current shard has been removed or splitted
@@ -217,8 +289,22 @@ func (d *stateMachine) applyCommittedEntries(entries []raftpb.Entry) {
 			})
 			continue
 		}
+		if !d.checkCommitMerge(d.applyCtx.req) {
+			d.logger.Info("apply paused",
+				log.IndexField(entry.Index),
+				log.ReasonField("merge source not prepared"))
+			d.mergePaused = true
+			d.applyCtx.release()
+			return entries[i:]
+		}
 
 		ignoreMetrics := d.applyRequestBatch(d.applyCtx)
+		if d.applyCtx.diskFull {
+			d.pauseByDiskFull(entry.Index)
+			d.applyCtx.release()
+			return entries[i:]
+		}
+		d.resumeFromDiskFull(entry.Index)
 		result := applyResult{
 			shardID:       d.shardID,
 			adminResult:   d.applyCtx.adminResult,
@@ -239,7 +325,9 @@ func (d *stateMachine) applyCommittedEntries(entries []raftpb.Entry) {
 		d.updateAppliedIndexTerm(entry.Index, entry.Term)
 		d.resultHandler.handleApplyResult(result)
 	}
+	d.applyCtx.release()
 	metric.ObserveRaftLogApplyDuration(start)
+	return nil
 }
 
 func (d *stateMachine) checkEntryIndexTerm(entry raftpb.Entry) {
@@ -288,6 +376,14 @@ func (d *stateMachine) applyRequestBatch(ctx *applyContext) bool {
 				log.IndexField(ctx.index))
 		}
 		resp = errorStaleEpochResp(ctx.req.Header.ID, d.getShard())
+	} else if !checkExpectedEpoch(d.getShard(), ctx.req) {
+		if ce := d.logger.Check(zap.DebugLevel, "apply committed log skipped"); ce != nil {
+			ce.Write(log.IndexField(ctx.index),
+				log.ReasonField("expected epoch check failed"),
+				log.EpochField("current-epoch", d.getShard().Epoch),
+				log.EpochField("expected-epoch", *ctx.req.Requests[0].ExpectedEpoch))
+		}
+		resp = errorEpochNotMatchResp(ctx.req.Header.ID, d.getShard(), *ctx.req.Requests[0].ExpectedEpoch)
 	} else if !d.checkLease(ctx.req) {
 		if ce := d.logger.Check(zap.DebugLevel, "apply committed log skipped"); ce != nil {
 			ce.Write(log.IndexField(ctx.index),
@@ -308,6 +404,7 @@ func (d *stateMachine) applyRequestBatch(ctx *applyContext) bool {
 				ce.Write(log.IndexField(ctx.index),
 					zap.String("type", ctx.req.GetAdminCmdType().String()))
 			}
+			d.readCache.clear(ctx.index)
 			resp, err = d.execAdminRequest(ctx)
 			if err != nil {
 				resp = errorStaleEpochResp(ctx.req.Header.ID, d.getShard())
@@ -318,6 +415,11 @@ func (d *stateMachine) applyRequestBatch(ctx *applyContext) bool {
 			}
 			ignoreMetrics = false
 			resp = d.execWriteRequest(ctx)
+			if ctx.diskFull {
+				// the log is applied again once the space is reclaimed, the
+				// proposal is kept pending
+				return ignoreMetrics
+			}
 		}
 
 		if ce := d.logger.Check(zap.DebugLevel, "apply committed log completed"); ce != nil {
@@ -367,14 +469,24 @@ func (d *stateMachine) canApply(entry raftpb.Entry) bool {
 	// so we need check shard state.
 	if !d.metadataMu.removed &&
 		!d.metadataMu.splited &&
-		d.metadataMu.shard.State != metapb.ShardState_Destroying {
+		d.metadataMu.shard.State != metapb.ShardState_Destroying &&
+		d.metadataMu.shard.State != metapb.ShardState_Merging {
 		return true
 	}
 
 	// In some scenarios, we need to remove the replica that is not online,
-	// so that the deletion task can be completed.
+	// so that the deletion task can be completed. The merging shard is frozen
+	// except the config changes too, the replicas of the merging shard can be
+	// aligned with the target shard again. The rollback merge log unfreezes
+	// the merging shard.
+	if d.metadataMu.shard.State == metapb.ShardState_Merging &&
+		!d.metadataMu.removed && !d.metadataMu.splited &&
+		isRollbackMergeRequestBatch(d.applyCtx.req) {
+		return true
+	}
 	return isConfigChangeEntry(entry) &&
-		d.metadataMu.shard.State == metapb.ShardState_Destroying
+		(d.metadataMu.shard.State == metapb.ShardState_Destroying ||
+			d.metadataMu.shard.State == metapb.ShardState_Merging)
 }
 
 func (d *stateMachine) setShardState(st metapb.ShardState) {
@@ -430,3 +542,8 @@ func isConfigChangeRequestBatch(req rpcpb.RequestBatch) bool {
 	return req.IsAdmin() &&
 		req.GetAdminCmdType() == rpcpb.CmdConfigChange
 }
+
+func isRollbackMergeRequestBatch(req rpcpb.RequestBatch) bool {
+	return req.IsAdmin() &&
+		req.GetAdminCmdType() == rpcpb.CmdRollbackMerge
+}
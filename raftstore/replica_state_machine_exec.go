@@ -21,7 +21,9 @@ import (
 
 	"github.com/cockroachdb/errors"
 	"github.com/fagongzi/util/protoc"
+	"github.com/matrixorigin/matrixcube/aware"
 	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/metric"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/matrixorigin/matrixcube/storage"
@@ -49,6 +51,16 @@ func (d *stateMachine) execAdminRequest(ctx *applyContext) (rpcpb.ResponseBatch,
 		return d.doUpdateLabels(ctx)
 	case rpcpb.CmdUpdateEpochLease:
 		return d.doUpdateEpochLease(ctx)
+	case rpcpb.CmdPrepareMerge:
+		return d.doExecPrepareMerge(ctx)
+	case rpcpb.CmdCommitMerge:
+		return d.doExecCommitMerge(ctx)
+	case rpcpb.CmdRollbackMerge:
+		return d.doExecRollbackMerge(ctx)
+	case rpcpb.CmdComputeHash:
+		return d.doExecComputeHash(ctx)
+	case rpcpb.CmdVerifyHash:
+		return d.doExecVerifyHash(ctx)
 	}
 
 	return rpcpb.ResponseBatch{}, nil
@@ -399,6 +411,162 @@ func (d *stateMachine) doUpdateEpochLease(ctx *applyContext) (rpcpb.ResponseBatc
 	return resp, nil
 }
 
+func (d *stateMachine) doExecPrepareMerge(ctx *applyContext) (rpcpb.ResponseBatch, error) {
+	req := ctx.req.GetPrepareMergeRequest()
+	current := d.getShard()
+	if err := checkMergeShards(current, req.Target); err != nil {
+		d.logger.Info("skip prepare merge",
+			log.ShardField("target", req.Target),
+			zap.Error(err))
+		return rpcpb.ResponseBatch{}, err
+	}
+
+	// The merging shard does not apply any logs except the config changes
+	// anymore, and waits for the target shard to commit the merge.
+	current.State = metapb.ShardState_Merging
+	current.Epoch.Generation++
+	if err := d.saveShardMetedata(ctx.index, current, metapb.ReplicaState_Normal, d.getLease()); err != nil {
+		d.logger.Fatal("failed to prepare merge",
+			zap.Error(err))
+	}
+	d.updateShard(current)
+
+	d.logger.Info("shard merge prepared",
+		log.ShardField("target", req.Target))
+
+	resp := newAdminResponseBatch(rpcpb.CmdPrepareMerge, &rpcpb.PrepareMergeResponse{})
+	ctx.adminResult = &adminResult{
+		adminType: rpcpb.CmdPrepareMerge,
+	}
+	return resp, nil
+}
+
+// checkCommitMerge returns false if the local replica of the source shard has
+// not applied the prepare merge log, the commit merge log can not be applied
+// until then.
+func (d *stateMachine) checkCommitMerge(req rpcpb.RequestBatch) bool {
+	if !req.IsAdmin() ||
+		req.GetAdminCmdType() != rpcpb.CmdCommitMerge ||
+		!d.checkEpoch(req) {
+		return true
+	}
+
+	commit := req.GetCommitMergeRequest()
+	source := commit.Source
+	if checkCommitMergeShards(source, commit.Target, d.getShard()) != nil {
+		return true
+	}
+	if d.mergeSourceGetter == nil {
+		return true
+	}
+	_, _, ok := d.mergeSourceGetter(source.ID)
+	return ok
+}
+
+func (d *stateMachine) doExecCommitMerge(ctx *applyContext) (rpcpb.ResponseBatch, error) {
+	req := ctx.req.GetCommitMergeRequest()
+	current := d.getShard()
+	if err := checkCommitMergeShards(req.Source, req.Target, current); err != nil {
+		d.logger.Info("skip commit merge",
+			log.ShardField("source", req.Source),
+			zap.Error(err))
+		return rpcpb.ResponseBatch{}, err
+	}
+
+	var source Shard
+	var sourceIndex uint64
+	if d.mergeSourceGetter != nil {
+		var ok bool
+		source, sourceIndex, ok = d.mergeSourceGetter(req.Source.ID)
+		if !ok {
+			d.logger.Fatal("missing local merge source",
+				log.ShardField("source", req.Source))
+		}
+	} else {
+		source = req.Source
+	}
+
+	// The target shard and the tombstone of the source shard are saved
+	// atomically, the data of the source shard is kept for the target shard.
+	current = mergedShard(source, current)
+	source.State = metapb.ShardState_Destroyed
+	if err := d.dataStorage.SaveShardMetadata([]metapb.ShardMetadata{
+		{
+			ShardID:  current.ID,
+			LogIndex: ctx.index,
+			Metadata: metapb.ShardLocalState{
+				State: metapb.ReplicaState_Normal,
+				Shard: current,
+				Lease: d.getLease(),
+			},
+		},
+		{
+			ShardID:  source.ID,
+			LogIndex: sourceIndex + 1,
+			Metadata: metapb.ShardLocalState{
+				State:      metapb.ReplicaState_ReplicaTombstone,
+				Shard:      source,
+				RemoveData: false,
+			},
+		},
+	}); err != nil {
+		d.logger.Fatal("failed to commit merge",
+			zap.Error(err))
+	}
+	d.updateShard(current)
+
+	d.logger.Info("shard merge committed",
+		log.ShardField("source", source),
+		log.ShardField("new-shard", current))
+
+	resp := newAdminResponseBatch(rpcpb.CmdCommitMerge, &rpcpb.CommitMergeResponse{})
+	ctx.adminResult = &adminResult{
+		adminType: rpcpb.CmdCommitMerge,
+		mergeResult: mergeResult{
+			source: source,
+		},
+	}
+	return resp, nil
+}
+
+// doExecRollbackMerge rollbacks the merge of the source shard. The target shard
+// increases its generation to reject the merge, the commit merge log after it
+// can't match the target epoch when the merge prepared anymore. Then the source
+// shard is unfrozen.
+func (d *stateMachine) doExecRollbackMerge(ctx *applyContext) (rpcpb.ResponseBatch, error) {
+	req := ctx.req.GetRollbackMergeRequest()
+	current := d.getShard()
+	if current.ID == req.Source.ID {
+		if current.State != metapb.ShardState_Merging {
+			return rpcpb.ResponseBatch{}, nil
+		}
+		current.State = metapb.ShardState_Running
+	} else if shardContains(current, req.Source) {
+		// the merge is committed already
+		d.logger.Info("skip rollback merge",
+			log.ShardField("source", req.Source),
+			log.ReasonField("merge committed"))
+		return rpcpb.ResponseBatch{}, nil
+	}
+
+	current.Epoch.Generation++
+	if err := d.saveShardMetedata(ctx.index, current, metapb.ReplicaState_Normal, d.getLease()); err != nil {
+		d.logger.Fatal("failed to rollback merge",
+			zap.Error(err))
+	}
+	d.updateShard(current)
+
+	d.logger.Info("shard merge rollback",
+		log.ShardField("source", req.Source),
+		log.ShardField("new-shard", current))
+
+	resp := newAdminResponseBatch(rpcpb.CmdRollbackMerge, &rpcpb.RollbackMergeResponse{})
+	ctx.adminResult = &adminResult{
+		adminType: rpcpb.CmdRollbackMerge,
+	}
+	return resp, nil
+}
+
 func (d *stateMachine) doUpdateMetadata(ctx *applyContext) (rpcpb.ResponseBatch, error) {
 	ctx.metrics.admin.updateMetadata++
 	updateReq := ctx.req.GetUpdateMetadataRequest()
@@ -450,7 +618,11 @@ func (d *stateMachine) doUpdateMetadata(ctx *applyContext) (rpcpb.ResponseBatch,
 }
 
 func (d *stateMachine) execWriteRequest(ctx *applyContext) rpcpb.ResponseBatch {
-	d.writeCtx.initialize(d.getShard(), ctx.index)
+	ts := ctx.req.Header.Timestamp
+	if !ts.IsEmpty() && d.clock != nil {
+		d.clock.Update(ts)
+	}
+	d.writeCtx.initialize(d.getShard(), ctx.index, ts)
 	requests := ctx.req.Requests
 	for idx := range requests {
 		if ce := d.logger.Check(zap.DebugLevel, "begin to execute write"); ce != nil {
@@ -459,6 +631,9 @@ func (d *stateMachine) execWriteRequest(ctx *applyContext) rpcpb.ResponseBatch {
 				log.ReplicaIDField(d.replica.ID),
 				log.IndexField(ctx.index))
 		}
+		if isRangeDeletion(requests[idx]) {
+			ctx.metrics.sizeStale = true
+		}
 		if !requests[idx].IsTransaction() {
 			d.writeCtx.batch.Requests = append(d.writeCtx.batch.Requests, storage.Request{
 				CmdType: requests[idx].CustomType,
@@ -472,9 +647,34 @@ func (d *stateMachine) execWriteRequest(ctx *applyContext) rpcpb.ResponseBatch {
 	}
 
 	if err := d.dataStorage.Write(d.writeCtx); err != nil {
+		if storage.IsDiskFull(err) {
+			ctx.diskFull = true
+			return rpcpb.ResponseBatch{}
+		}
 		d.logger.Fatal("failed to exec write cmd",
 			zap.Error(err))
 	}
+	// the data storage not reporting the written keys writes a key per request
+	if !d.writeCtx.writtenKeysSet {
+		d.writeCtx.writtenKeys = uint64(len(requests))
+	}
+	d.readCache.invalidate(ctx.index, requests)
+	metric.ObserveStorageWriteBatch(d.replica.StoreID, uint64(len(requests)),
+		d.writeCtx.writtenBytes)
+	if (d.buckets != nil || d.loadSplitter != nil) && len(requests) > 0 {
+		// the written bytes and keys of each request are unknown, they are
+		// evenly distributed to the keys of the batch
+		n := uint64(len(requests))
+		writtenBytes := d.writeCtx.writtenBytes / n
+		for idx := range requests {
+			writtenKeys := d.writeCtx.writtenKeys / n
+			if uint64(idx) < d.writeCtx.writtenKeys%n {
+				writtenKeys++
+			}
+			d.buckets.addWrite(requests[idx].Key, writtenBytes, writtenKeys)
+			d.loadSplitter.record(requests[idx].Key, writtenBytes)
+		}
+	}
 
 	resp := rpcpb.ResponseBatch{}
 	customResponseIdx := 0
@@ -485,7 +685,6 @@ func (d *stateMachine) execWriteRequest(ctx *applyContext) rpcpb.ResponseBatch {
 				log.ReplicaIDField(d.replica.ID),
 				log.IndexField(ctx.index))
 		}
-		ctx.metrics.writtenKeys++
 		r := rpcpb.Response{}
 		if !requests[idx].IsTransaction() {
 			r.Value = d.writeCtx.responses[customResponseIdx]
@@ -495,9 +694,43 @@ func (d *stateMachine) execWriteRequest(ctx *applyContext) rpcpb.ResponseBatch {
 	}
 
 	d.updateWriteMetrics()
+	d.notifyApplyResult(ctx)
 	return resp
 }
 
+// isRangeDeletion returns true if the request removes a range of the data, the
+// approximate size of the shard is stale after it's applied.
+func isRangeDeletion(req rpcpb.Request) bool {
+	switch rpcpb.InternalCmd(req.CustomType) {
+	case rpcpb.CmdKVRangeDelete, rpcpb.CmdCleanTxnMVCCData:
+		return true
+	}
+	return false
+}
+
+func (d *stateMachine) notifyApplyResult(ctx *applyContext) {
+	if d.applyResultHandler == nil {
+		return
+	}
+
+	d.applyCommands = d.applyCommands[:0]
+	for _, req := range ctx.req.Requests {
+		d.applyCommands = append(d.applyCommands, aware.ApplyCommand{
+			CmdType: req.CustomType,
+			Key:     req.Key,
+		})
+	}
+	d.applyResultHandler(aware.ApplyResult{
+		Shard:        d.writeCtx.shard,
+		Index:        ctx.index,
+		Timestamp:    d.writeCtx.batch.Timestamp,
+		Commands:     d.applyCommands,
+		WrittenKeys:  d.writeCtx.writtenKeys,
+		WrittenBytes: d.writeCtx.writtenBytes,
+		DiffBytes:    d.writeCtx.diffBytes,
+	})
+}
+
 func (d *stateMachine) execTransactionWrite(req rpcpb.Request, ctx storage.WriteContext) {
 	if d.transactionalDataStorage == nil {
 		d.logger.Fatal("can not handle transaction request.",
@@ -538,8 +771,10 @@ func (d *stateMachine) execTransactionWrite(req rpcpb.Request, ctx storage.Write
 
 func (d *stateMachine) updateWriteMetrics() {
 	d.applyCtx.metrics.writtenBytes += d.writeCtx.writtenBytes
+	d.applyCtx.metrics.writtenKeys += d.writeCtx.writtenKeys
 	if d.writeCtx.diffBytes < 0 {
 		v := uint64(math.Abs(float64(d.writeCtx.diffBytes)))
+		d.applyCtx.metrics.deletedBytesHint += v
 		if v >= d.applyCtx.metrics.approximateDiffHint {
 			d.applyCtx.metrics.approximateDiffHint = 0
 		} else {
@@ -15,8 +15,10 @@ package raftstore
 
 import (
 	"testing"
+	"time"
 
 	"github.com/fagongzi/util/protoc"
+	"github.com/matrixorigin/matrixcube/aware"
 	"github.com/matrixorigin/matrixcube/pb/hlcpb"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
@@ -320,10 +322,230 @@ func TestDoExecUpdateLease(t *testing.T) {
 	assert.Equal(t, &metapb.EpochLease{Epoch: 2, ReplicaID: 3}, pr.getLease())
 }
 
+func TestDoExecPrepareMerge(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	pr := newTestReplica(Shard{ID: 1,
+		Start:    []byte("a"),
+		End:      []byte("b"),
+		Epoch:    Epoch{Generation: 2},
+		Replicas: []Replica{{ID: 2, StoreID: 1}}},
+		Replica{ID: 2, StoreID: 1}, s)
+	ctx := newApplyContext()
+
+	ctx.req = newTestAdminRequestBatch("", 0, rpcpb.CmdPrepareMerge, protoc.MustMarshal(&rpcpb.PrepareMergeRequest{
+		Target: Shard{ID: 3, Start: []byte("c"), Replicas: []Replica{{ID: 4, StoreID: 1}}},
+	}))
+	_, err := pr.sm.execAdminRequest(ctx)
+	assert.Equal(t, errMergeShardsNotAdjacent, err)
+	assert.Equal(t, metapb.ShardState_Running, pr.getShard().State)
+
+	ctx.req = newTestAdminRequestBatch("", 0, rpcpb.CmdPrepareMerge, protoc.MustMarshal(&rpcpb.PrepareMergeRequest{
+		Target: Shard{ID: 3, Start: []byte("b"), Replicas: []Replica{{ID: 4, StoreID: 2}}},
+	}))
+	_, err = pr.sm.execAdminRequest(ctx)
+	assert.Equal(t, errMergeReplicasNotAligned, err)
+	assert.Equal(t, metapb.ShardState_Running, pr.getShard().State)
+
+	ctx.req = newTestAdminRequestBatch("", 0, rpcpb.CmdPrepareMerge, protoc.MustMarshal(&rpcpb.PrepareMergeRequest{
+		Target: Shard{ID: 3, Start: []byte("b"), Replicas: []Replica{{ID: 4, StoreID: 1}}},
+	}))
+	resp, err := pr.sm.execAdminRequest(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(resp.Responses))
+	assert.Equal(t, metapb.ShardState_Merging, pr.getShard().State)
+	assert.Equal(t, uint64(3), pr.getShard().Epoch.Generation)
+	assert.Equal(t, rpcpb.CmdPrepareMerge, ctx.adminResult.adminType)
+}
+
+func TestDoExecCommitMerge(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	source := Shard{ID: 1,
+		Start:    []byte("a"),
+		End:      []byte("b"),
+		State:    metapb.ShardState_Merging,
+		Epoch:    Epoch{Generation: 5},
+		Replicas: []Replica{{ID: 2, StoreID: 1}}}
+	s.addReplica(newTestReplica(source, Replica{ID: 2, StoreID: 1}, s))
+	target := Shard{ID: 3,
+		Start:    []byte("b"),
+		End:      []byte("c"),
+		Epoch:    Epoch{Generation: 2},
+		Replicas: []Replica{{ID: 4, StoreID: 1}}}
+	pr := newTestReplica(target, Replica{ID: 4, StoreID: 1}, s)
+	ctx := newApplyContext()
+
+	// the epoch of the target shard changed since the merge prepared
+	prepared := target
+	prepared.Epoch.Generation = 1
+	ctx.req = newTestAdminRequestBatch("", 0, rpcpb.CmdCommitMerge, protoc.MustMarshal(&rpcpb.CommitMergeRequest{
+		Source: source,
+		Target: prepared,
+	}))
+	ctx.req.Header.ShardID = 3
+	assert.True(t, pr.sm.checkCommitMerge(ctx.req))
+	_, err := pr.sm.execAdminRequest(ctx)
+	assert.Equal(t, errMergeTargetEpochChanged, err)
+	assert.Equal(t, []byte("b"), pr.getShard().Start)
+
+	ctx.req = newTestAdminRequestBatch("", 0, rpcpb.CmdCommitMerge, protoc.MustMarshal(&rpcpb.CommitMergeRequest{
+		Source: source,
+		Target: target,
+	}))
+	ctx.req.Header.ShardID = 3
+	assert.True(t, pr.sm.checkCommitMerge(ctx.req))
+	resp, err := pr.sm.execAdminRequest(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(resp.Responses))
+	shard := pr.getShard()
+	assert.Equal(t, []byte("a"), shard.Start)
+	assert.Equal(t, []byte("c"), shard.End)
+	assert.Equal(t, uint64(6), shard.Epoch.Generation)
+	assert.Equal(t, rpcpb.CmdCommitMerge, ctx.adminResult.adminType)
+	assert.Equal(t, uint64(1), ctx.adminResult.mergeResult.source.ID)
+	assert.Equal(t, metapb.ShardState_Destroyed, ctx.adminResult.mergeResult.source.State)
+}
+
+func TestCommitMergeWaitsForMergeSource(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	source := Shard{ID: 1,
+		Start:    []byte("a"),
+		End:      []byte("b"),
+		Replicas: []Replica{{ID: 2, StoreID: 1}}}
+	sourcePR := newTestReplica(source, Replica{ID: 2, StoreID: 1}, s)
+	s.addReplica(sourcePR)
+	target := Shard{ID: 3,
+		Start:    []byte("b"),
+		Replicas: []Replica{{ID: 4, StoreID: 1}}}
+	pr := newTestReplica(target, Replica{ID: 4, StoreID: 1}, s)
+
+	source.State = metapb.ShardState_Merging
+	req := newTestAdminRequestBatch("", 0, rpcpb.CmdCommitMerge, protoc.MustMarshal(&rpcpb.CommitMergeRequest{
+		Source: source,
+		Target: target,
+	}))
+	req.Header.ShardID = 3
+	assert.False(t, pr.sm.checkCommitMerge(req))
+
+	sourcePR.sm.setShardState(metapb.ShardState_Merging)
+	assert.True(t, pr.sm.checkCommitMerge(req))
+}
+
+func TestDoExecRollbackMerge(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	source := Shard{ID: 1,
+		Start:    []byte("a"),
+		End:      []byte("b"),
+		State:    metapb.ShardState_Merging,
+		Epoch:    Epoch{Generation: 5},
+		Replicas: []Replica{{ID: 2, StoreID: 1}}}
+	sourcePR := newTestReplica(source, Replica{ID: 2, StoreID: 1}, s)
+	target := Shard{ID: 3,
+		Start:    []byte("b"),
+		End:      []byte("c"),
+		Epoch:    Epoch{Generation: 2},
+		Replicas: []Replica{{ID: 4, StoreID: 1}}}
+	pr := newTestReplica(target, Replica{ID: 4, StoreID: 1}, s)
+	ctx := newApplyContext()
+
+	// the target shard rejects the merge
+	ctx.req = newTestAdminRequestBatch("", 0, rpcpb.CmdRollbackMerge, protoc.MustMarshal(&rpcpb.RollbackMergeRequest{
+		Source: source,
+	}))
+	resp, err := pr.sm.execAdminRequest(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(resp.Responses))
+	assert.Equal(t, uint64(3), pr.getShard().Epoch.Generation)
+	assert.Equal(t, []byte("b"), pr.getShard().Start)
+	assert.Equal(t, rpcpb.CmdRollbackMerge, ctx.adminResult.adminType)
+
+	// the merging source shard only applies the rollback merge log
+	assert.False(t, sourcePR.sm.canApply(raftpb.Entry{}))
+	sourcePR.sm.applyCtx.req = ctx.req
+	assert.True(t, sourcePR.sm.canApply(raftpb.Entry{}))
+	ctx = newApplyContext()
+	ctx.req = sourcePR.sm.applyCtx.req
+	_, err = sourcePR.sm.execAdminRequest(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, metapb.ShardState_Running, sourcePR.getShard().State)
+	assert.Equal(t, uint64(6), sourcePR.getShard().Epoch.Generation)
+	assert.Equal(t, rpcpb.CmdRollbackMerge, ctx.adminResult.adminType)
+
+	// the merge is committed already
+	pr.sm.updateShard(mergedShard(source, pr.getShard()))
+	ctx = newApplyContext()
+	ctx.req = newTestAdminRequestBatch("", 0, rpcpb.CmdRollbackMerge, protoc.MustMarshal(&rpcpb.RollbackMergeRequest{
+		Source: source,
+	}))
+	generation := pr.getShard().Epoch.Generation
+	_, err = pr.sm.execAdminRequest(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, generation, pr.getShard().Epoch.Generation)
+	assert.Nil(t, ctx.adminResult)
+}
+
+func TestCheckMergeSourceWait(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	pr := newTestReplica(Shard{ID: 3, Replicas: []Replica{{ID: 4, StoreID: 1}}},
+		Replica{ID: 4, StoreID: 1}, s)
+	s.addReplica(pr)
+	now := time.Now()
+	assert.False(t, pr.checkMergeSourceWait(now))
+
+	pr.pendingApplyEntries = []raftpb.Entry{{Index: 1}}
+	pr.mergePausedSince = now
+	assert.False(t, pr.checkMergeSourceWait(now.Add(maxMergeSourceWait-time.Second)))
+	assert.True(t, pr.checkMergeSourceWait(now.Add(maxMergeSourceWait)))
+	assert.Empty(t, pr.pendingApplyEntries)
+}
+
+func TestIsMergeTarget(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	source := Shard{ID: 1,
+		Start:    []byte("a"),
+		End:      []byte("b"),
+		Replicas: []Replica{{ID: 2, StoreID: 1}}}
+	sourcePR := newTestReplica(source, Replica{ID: 2, StoreID: 1}, s)
+	s.addReplica(sourcePR)
+	target := Shard{ID: 3,
+		Start:    []byte("b"),
+		Replicas: []Replica{{ID: 4, StoreID: 1}}}
+	assert.False(t, s.isMergeTarget(target))
+
+	sourcePR.sm.setShardState(metapb.ShardState_Merging)
+	assert.True(t, s.isMergeTarget(target))
+	assert.False(t, s.isMergeTarget(Shard{ID: 5, Start: []byte("c"), Replicas: []Replica{{ID: 6, StoreID: 1}}}))
+}
+
 type testDataStorage struct {
 	persistentLogIndex uint64
 	feature            storage.Feature
 	counts             map[int]int
+	// noWrittenKeys the written keys are not reported
+	noWrittenKeys bool
 }
 
 func (t *testDataStorage) Close() error                                     { panic("not implemented") }
@@ -335,6 +557,11 @@ func (t *testDataStorage) Write(ctx storage.WriteContext) error {
 	for range ctx.Batch().Requests {
 		ctx.AppendResponse([]byte("OK"))
 	}
+	if t.noWrittenKeys {
+		return nil
+	}
+	// each request writes two keys
+	ctx.SetWrittenKeys(uint64(2 * len(ctx.Batch().Requests)))
 	return nil
 }
 func (t *testDataStorage) Read(storage.ReadContext) ([]byte, error) { panic("not implemented") }
@@ -565,6 +792,77 @@ func TestExecWriteRequest(t *testing.T) {
 	}
 }
 
+func TestExecWriteRequestWithApplyResultHandler(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, cancel := newTestStore(t)
+	defer cancel()
+	pr := newTestReplica(Shard{ID: 1, Replicas: []Replica{{ID: 2}}}, Replica{ID: 2}, s)
+	ds := &testDataStorage{}
+	_, err := ds.GetInitialStates()
+	assert.NoError(t, err)
+	pr.sm.dataStorage = ds
+	pr.sm.transactionalDataStorage = ds
+
+	var results []aware.ApplyResult
+	pr.sm.applyResultHandler = func(result aware.ApplyResult) {
+		result.Commands = append([]aware.ApplyCommand(nil), result.Commands...)
+		results = append(results, result)
+	}
+
+	ctx := newApplyContext()
+	ctx.index = 10
+	ctx.req = newTestRequestBatch(2, func(r *rpcpb.Request, i int) { r.CustomType = uint64(rpcpb.CmdReserved) + uint64(i) })
+	pr.sm.execWriteRequest(ctx)
+	assert.Equal(t, 1, len(results))
+	assert.Equal(t, uint64(1), results[0].Shard.ID)
+	assert.Equal(t, uint64(10), results[0].Index)
+	assert.Equal(t, uint64(4), results[0].WrittenKeys)
+	assert.Equal(t, []aware.ApplyCommand{
+		{CmdType: uint64(rpcpb.CmdReserved), Key: buf.Int2Bytes(0)},
+		{CmdType: uint64(rpcpb.CmdReserved) + 1, Key: buf.Int2Bytes(1)},
+	}, results[0].Commands)
+
+	// one key per request if the written keys are not reported
+	ds.noWrittenKeys = true
+	ctx = newApplyContext()
+	ctx.index = 11
+	ctx.req = newTestRequestBatch(2, func(r *rpcpb.Request, i int) { r.CustomType = uint64(rpcpb.CmdReserved) + uint64(i) })
+	pr.sm.execWriteRequest(ctx)
+	assert.Equal(t, 2, len(results))
+	assert.Equal(t, uint64(2), results[1].WrittenKeys)
+}
+
+func TestExecWriteRequestWithTimestamp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, cancel := newTestStore(t)
+	defer cancel()
+	pr := newTestReplica(Shard{ID: 1, Replicas: []Replica{{ID: 2}}}, Replica{ID: 2}, s)
+	ds := &testDataStorage{}
+	_, err := ds.GetInitialStates()
+	assert.NoError(t, err)
+	pr.sm.dataStorage = ds
+	pr.sm.clock = hlc.NewHLCClock(func() int64 { return 1 }, time.Second)
+
+	var results []aware.ApplyResult
+	pr.sm.applyResultHandler = func(result aware.ApplyResult) {
+		results = append(results, result)
+	}
+
+	ts := hlcpb.Timestamp{PhysicalTime: 100, LogicalTime: 1}
+	ctx := newApplyContext()
+	ctx.index = 10
+	ctx.req = newTestRequestBatch(1, func(r *rpcpb.Request, i int) {})
+	ctx.req.Header.Timestamp = ts
+	pr.sm.execWriteRequest(ctx)
+	assert.Equal(t, ts, pr.sm.writeCtx.Batch().Timestamp)
+	assert.Equal(t, 1, len(results))
+	assert.Equal(t, ts, results[0].Timestamp)
+
+	// the clock of the replica is updated by the applied timestamp
+	now, _ := pr.sm.clock.Now()
+	assert.True(t, ts.Less(now))
+}
+
 func newTestRequestBatch(n int, builder func(*rpcpb.Request, int)) rpcpb.RequestBatch {
 	rb := rpcpb.RequestBatch{
 		Header: rpcpb.RequestBatchHeader{ID: uuid.NewV4().Bytes()}}
@@ -94,6 +94,46 @@ func TestStateMachineApplyContextCanBeInitializedForConfigChange(t *testing.T) {
 	assert.Equal(t, cc.AsV2(), ctx.v2cc)
 }
 
+func TestStateMachineApplyContextReusesRequests(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	newEntry := func(index uint64, n int) raftpb.Entry {
+		req := newTestRequestBatch(n, func(r *rpcpb.Request, i int) {})
+		return raftpb.Entry{Index: index, Data: protoc.MustMarshal(&req)}
+	}
+
+	ctx := newApplyContext()
+	ctx.initialize(newEntry(1, 3))
+	assert.Equal(t, 3, len(ctx.req.Requests))
+	ctx.initialize(newEntry(2, 1))
+	assert.Equal(t, 1, len(ctx.req.Requests))
+	assert.Equal(t, 3, ctx.used)
+	ctx.initialize(newEntry(3, 0))
+	assert.Nil(t, ctx.req.Requests)
+
+	requests := ctx.requests
+	ctx.release()
+	assert.Nil(t, ctx.requests)
+	assert.Empty(t, ctx.req)
+	for _, req := range (*requests)[:3] {
+		assert.Empty(t, req)
+	}
+}
+
+func BenchmarkStateMachineApplyContextInitialize(b *testing.B) {
+	req := newTestRequestBatch(32, func(r *rpcpb.Request, i int) {
+		r.Cmd = make([]byte, 64)
+	})
+	entry := raftpb.Entry{Index: 1, Data: protoc.MustMarshal(&req)}
+	ctx := newApplyContext()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx.initialize(entry)
+		ctx.release()
+	}
+}
+
 func runSimpleStateMachineTest(t *testing.T,
 	f func(sm *stateMachine), h replicaResultHandler) {
 	l := log.GetDefaultZapLogger(zap.OnFatal(zapcore.WriteThenPanic))
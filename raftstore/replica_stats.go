@@ -14,8 +14,14 @@
 package raftstore
 
 import (
+	"math"
+	"sync/atomic"
 	"time"
 
+	"github.com/fagongzi/util/protoc"
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/keys"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 )
 
@@ -52,3 +58,109 @@ func (rs *replicaStats) heartbeatState() metapb.ShardStats {
 	rs.prophetHeartbeatTime = now
 	return stats
 }
+
+// persistedState returns the stats to be persisted, the End of the Interval is
+// the time when the stats persisted.
+func (rs *replicaStats) persistedState(now time.Time) metapb.ShardStats {
+	return metapb.ShardStats{
+		WrittenBytes:    rs.writtenBytes,
+		WrittenKeys:     rs.writtenKeys,
+		ReadBytes:       rs.readBytes,
+		ReadKeys:        rs.readKeys,
+		ApproximateKeys: rs.approximateKeys,
+		ApproximateSize: rs.approximateSize,
+		Interval:        &metapb.TimeInterval{End: uint64(now.Unix())},
+	}
+}
+
+// persistedFields returns the copy of the stats only with the persisted fields,
+// used to skip the persistence if nothing changed.
+func (rs *replicaStats) persistedFields() replicaStats {
+	return replicaStats{
+		writtenKeys:     rs.writtenKeys,
+		writtenBytes:    rs.writtenBytes,
+		readKeys:        rs.readKeys,
+		readBytes:       rs.readBytes,
+		approximateSize: rs.approximateSize,
+		approximateKeys: rs.approximateKeys,
+	}
+}
+
+// restore restores the stats persisted before the store restarted. The read and
+// write stats are halved every halfLife since they persisted, the approximate
+// size and keys are restored as is, they are corrected by the next split check.
+func (rs *replicaStats) restore(stats metapb.ShardStats, now time.Time, halfLife time.Duration) {
+	var persisted uint64
+	if stats.Interval != nil {
+		persisted = stats.Interval.End
+	}
+	factor := 1.0
+	if elapsed := now.Unix() - int64(persisted); elapsed > 0 && halfLife > 0 {
+		factor = math.Pow(0.5, float64(elapsed)/halfLife.Seconds())
+	}
+	decay := func(v uint64) uint64 {
+		return uint64(float64(v) * factor)
+	}
+
+	rs.writtenBytes = decay(stats.WrittenBytes)
+	rs.writtenKeys = decay(stats.WrittenKeys)
+	rs.readBytes = decay(stats.ReadBytes)
+	rs.readKeys = decay(stats.ReadKeys)
+	rs.approximateSize = stats.ApproximateSize
+	rs.approximateKeys = stats.ApproximateKeys
+	rs.prophetHeartbeatTime = persisted
+}
+
+// doPersistStats persists the stats of the replica, so that prophet doesn't
+// start the balancing and the hot shards detection from zero after restart.
+func (pr *replica) doPersistStats() {
+	current := pr.stats.persistedFields()
+	if current == pr.persistedStats {
+		return
+	}
+
+	stats := pr.stats.persistedState(time.Now())
+	key := keys.GetShardStatsKey(pr.shardID, nil)
+	if err := pr.store.kvStorage.Set(key, protoc.MustMarshal(&stats), false); err != nil {
+		pr.logger.Error("fail to persist shard stats, retry later",
+			zap.Error(err))
+		return
+	}
+	pr.persistedStats = current
+}
+
+// restoreStats restores the stats persisted before the store restarted
+func (pr *replica) restoreStats() error {
+	data, err := pr.store.kvStorage.Get(keys.GetShardStatsKey(pr.shardID, nil))
+	if err != nil || len(data) == 0 {
+		return err
+	}
+
+	stats := metapb.ShardStats{}
+	protoc.MustUnmarshal(&stats, data)
+	pr.stats.restore(stats, time.Now(), pr.cfg.Replication.ShardStatsHalfLife.Duration)
+	pr.persistedStats = pr.stats.persistedFields()
+	pr.logger.Info("shard stats restored",
+		zap.Uint64("written-bytes", pr.stats.writtenBytes),
+		zap.Uint64("read-bytes", pr.stats.readBytes),
+		zap.Uint64("approximate-size", pr.stats.approximateSize))
+	return nil
+}
+
+// replicaReadLoad the read load of the replica since the last store heartbeat,
+// including the follower reads. It's updated by the event loop and collected by
+// the store heartbeat, so the fields must be accessed atomically.
+type replicaReadLoad struct {
+	readBytes uint64
+	readKeys  uint64
+}
+
+func (l *replicaReadLoad) add(readBytes, readKeys uint64) {
+	atomic.AddUint64(&l.readBytes, readBytes)
+	atomic.AddUint64(&l.readKeys, readKeys)
+}
+
+// collect returns the read load since the last collect and resets it
+func (l *replicaReadLoad) collect() (readBytes, readKeys uint64) {
+	return atomic.SwapUint64(&l.readBytes, 0), atomic.SwapUint64(&l.readKeys, 0)
+}
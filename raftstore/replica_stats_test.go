@@ -0,0 +1,80 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/matrixorigin/matrixcube/keys"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+func TestReplicaStatsRestore(t *testing.T) {
+	now := time.Now()
+	stats := metapb.ShardStats{
+		WrittenBytes:    400,
+		WrittenKeys:     40,
+		ReadBytes:       800,
+		ReadKeys:        80,
+		ApproximateSize: 1000,
+		ApproximateKeys: 100,
+		Interval:        &metapb.TimeInterval{End: uint64(now.Add(-time.Minute * 20).Unix())},
+	}
+
+	rs := newReplicaStats()
+	rs.restore(stats, now, time.Minute*10)
+	assert.Equal(t, uint64(100), rs.writtenBytes)
+	assert.Equal(t, uint64(10), rs.writtenKeys)
+	assert.Equal(t, uint64(200), rs.readBytes)
+	assert.Equal(t, uint64(20), rs.readKeys)
+	assert.Equal(t, uint64(1000), rs.approximateSize, "size is not decayed")
+	assert.Equal(t, uint64(100), rs.approximateKeys, "keys is not decayed")
+	assert.Equal(t, stats.Interval.End, rs.prophetHeartbeatTime)
+
+	rs = newReplicaStats()
+	rs.restore(stats, now, 0)
+	assert.Equal(t, uint64(400), rs.writtenBytes, "no decay")
+}
+
+func TestPersistStats(t *testing.T) {
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	key := keys.GetShardStatsKey(1, nil)
+	pr := newTestReplica(Shard{ID: 1}, Replica{ID: 1}, s)
+	pr.doPersistStats()
+	v, err := s.kvStorage.Get(key)
+	assert.NoError(t, err)
+	assert.Empty(t, v, "nothing to persist")
+
+	pr.stats.writtenBytes = 100
+	pr.stats.approximateSize = 1000
+	pr.doPersistStats()
+	v, err = s.kvStorage.Get(key)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, v)
+
+	restored := newTestReplica(Shard{ID: 1}, Replica{ID: 1}, s)
+	assert.NoError(t, restored.restoreStats())
+	assert.Equal(t, uint64(100), restored.stats.writtenBytes)
+	assert.Equal(t, uint64(1000), restored.stats.approximateSize)
+	assert.Equal(t, pr.persistedStats, restored.persistedStats)
+
+	other := newTestReplica(Shard{ID: 2}, Replica{ID: 2}, s)
+	assert.NoError(t, other.restoreStats())
+	assert.Equal(t, uint64(0), other.stats.writtenBytes)
+}
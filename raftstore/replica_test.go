@@ -22,6 +22,8 @@ import (
 	"go.etcd.io/etcd/raft/v3"
 	"go.etcd.io/etcd/raft/v3/raftpb"
 
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/config"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/util/leaktest"
 	"github.com/matrixorigin/matrixcube/util/stop"
@@ -95,3 +97,14 @@ func newTestReplica(shard Shard, peer Replica, s *store) *replica {
 	pr.setStarted()
 	return pr
 }
+
+func TestGetRaftConfigWithLeaseRead(t *testing.T) {
+	cfg := &config.Config{}
+	assert.Equal(t, raft.ReadOnlySafe, getRaftConfig(1, 0, nil, cfg, log.Adjust(nil)).ReadOnlyOption)
+	// the lease reads are confirmed by the replica to fall back to the
+	// heartbeat round while the clock is skewed
+	cfg.Raft.EnableLeaseRead = true
+	rc := getRaftConfig(1, 0, nil, cfg, log.Adjust(nil))
+	assert.Equal(t, raft.ReadOnlySafe, rc.ReadOnlyOption)
+	assert.True(t, rc.CheckQuorum, "lease read requires check quorum")
+}
@@ -13,6 +13,14 @@ func requestDone(req rpcpb.Request, cb func(rpcpb.ResponseBatch), data []byte) {
 	cb(rpcpb.ResponseBatch{Responses: []rpcpb.Response{r}})
 }
 
+// staleReadDone responds the read served in the degraded read mode
+func staleReadDone(req rpcpb.Request, cb func(rpcpb.ResponseBatch), data []byte) {
+	r := getResponse(req)
+	r.Value = data
+	r.Stale = true
+	cb(rpcpb.ResponseBatch{Responses: []rpcpb.Response{r}})
+}
+
 func requestDoneWithReplicaRemoved(req rpcpb.Request, cb func(rpcpb.ResponseBatch), id uint64) {
 	r := getResponse(req)
 	cb(rpcpb.ResponseBatch{Responses: []rpcpb.Response{r}, Header: rpcpb.ResponseBatchHeader{Error: errorpb.Error{
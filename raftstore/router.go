@@ -17,6 +17,7 @@ import (
 	"context"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/fagongzi/util/protoc"
 	"github.com/matrixorigin/matrixcube/components/log"
@@ -61,6 +62,8 @@ type Router interface {
 	ForeachShards(group uint64, fn func(shard Shard) bool)
 	// GetShard returns the shard by shard id
 	GetShard(id uint64) Shard
+	// GetStore returns the store by store id
+	GetStore(id uint64) metapb.Store
 
 	// UpdateLeader update shard leader
 	UpdateLeader(shardID uint64, leaderReplciaID uint64)
@@ -70,6 +73,9 @@ type Router interface {
 	UpdateShard(shard Shard)
 	// UpdateStore update store metadata
 	UpdateStore(store metapb.Store)
+	// MarkStoreUnreachable marks the store unreachable for a while, the follower
+	// reads are routed to the replicas on other stores during this period.
+	MarkStoreUnreachable(storeID uint64)
 
 	// Deprecated: LeaderReplicaStore return leader replica store. Use `SelectReplicaStoreWithPolicy` instead.
 	LeaderReplicaStore(shardID uint64) metapb.Store
@@ -100,6 +106,11 @@ type routerOptions struct {
 	fields             []zap.Field
 	removeShardHandler func(id uint64)
 	createShardHandler func(shard Shard)
+	// zoneLabel and zone are used to prefer the replicas in the same zone for
+	// the follower reads, the locality routing is disabled if zone is empty.
+	zoneLabel          string
+	zone               string
+	unreachableTimeout time.Duration
 }
 
 func (opts *routerOptions) adjust() {
@@ -137,6 +148,17 @@ func (rb *routerBuilder) withCreatShardHandle(handle func(shard Shard)) *routerB
 	return rb
 }
 
+func (rb *routerBuilder) withLocality(zoneLabel, zone string) *routerBuilder {
+	rb.options.zoneLabel = zoneLabel
+	rb.options.zone = zone
+	return rb
+}
+
+func (rb *routerBuilder) withUnreachableStoreTimeout(timeout time.Duration) *routerBuilder {
+	rb.options.unreachableTimeout = timeout
+	return rb
+}
+
 func (rb *routerBuilder) build(eventC chan rpcpb.EventNotify) (Router, error) {
 	return newRouter(eventC, rb.options)
 }
@@ -160,6 +182,8 @@ type defaultRouter struct {
 		opts                     map[uint64]op                // shard id -> op
 		shardStats               map[uint64]metapb.ShardStats // shard id -> metapb.ShardStats
 		storeStats               map[uint64]metapb.StoreStats // store id -> metapb.StoreStats
+		readHints                map[uint64][]uint64          // shard id -> replica ids for follower reads
+		unreachableStores        map[uint64]time.Time         // store id -> unreachable deadline
 	}
 }
 
@@ -181,6 +205,8 @@ func newRouter(eventC chan rpcpb.EventNotify, options *routerOptions) (Router, e
 	r.mu.opts = make(map[uint64]op)
 	r.mu.shardStats = make(map[uint64]metapb.ShardStats)
 	r.mu.storeStats = make(map[uint64]metapb.StoreStats)
+	r.mu.readHints = make(map[uint64][]uint64)
+	r.mu.unreachableStores = make(map[uint64]time.Time)
 	return r, nil
 }
 
@@ -266,6 +292,8 @@ func (r *defaultRouter) selectReplicaStoreByPolicyLocked(shard Shard, policy rpc
 	case rpcpb.SelectLeaseHolder:
 		info := r.getLeaseReplicaStoreLocked(shard.ID)
 		return info.store, info.lease
+	case rpcpb.SelectLearner:
+		return r.mustGetStoreLocked(r.selectLearnerStoreLocked(shard)), nil
 	default:
 		panic("not yet implemented")
 	}
@@ -278,6 +306,13 @@ func (r *defaultRouter) GetShard(id uint64) Shard {
 	return r.mu.shards[id]
 }
 
+func (r *defaultRouter) GetStore(id uint64) metapb.Store {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.mu.stores[id]
+}
+
 func (r *defaultRouter) Every(group uint64, mustLeader bool, doFunc func(Shard, metapb.Store) bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -343,6 +378,23 @@ func (r *defaultRouter) GetStoreStats(id uint64) metapb.StoreStats {
 	return r.mu.storeStats[id]
 }
 
+func (r *defaultRouter) MarkStoreUnreachable(storeID uint64) {
+	if r.options.unreachableTimeout == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, deadline := range r.mu.unreachableStores {
+		if !now.Before(deadline) {
+			delete(r.mu.unreachableStores, id)
+		}
+	}
+	r.mu.unreachableStores[storeID] = now.Add(r.options.unreachableTimeout)
+}
+
 func (r *defaultRouter) UpdateLeader(shardID uint64, leaderReplciaID uint64) {
 	if leaderReplciaID == 0 {
 		return
@@ -351,6 +403,11 @@ func (r *defaultRouter) UpdateLeader(shardID uint64, leaderReplciaID uint64) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	// the shard may be removed before the response of the stale route is
+	// received
+	if _, ok := r.mu.shards[shardID]; !ok {
+		return
+	}
 	r.updateLeaderLocked(shardID, leaderReplciaID)
 }
 
@@ -361,6 +418,9 @@ func (r *defaultRouter) UpdateLease(shardID uint64, lease *metapb.EpochLease) {
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if _, ok := r.mu.shards[shardID]; !ok {
+		return
+	}
 	r.updateLeaseLocked(shardID, lease)
 }
 
@@ -428,7 +488,17 @@ func (r *defaultRouter) handleEvent(evt rpcpb.EventNotify) {
 		r.mu.shardStats[evt.ShardStatsEvent.ShardID] = *evt.ShardStatsEvent
 	case event.StoreStatsEvent:
 		r.mu.storeStats[evt.StoreStatsEvent.StoreID] = *evt.StoreStatsEvent
+	case event.ReadHintEvent:
+		r.updateReadHintLocked(*evt.ReadHintEvent)
+	}
+}
+
+func (r *defaultRouter) updateReadHintLocked(hint metapb.ShardReadHint) {
+	if len(hint.Replicas) == 0 {
+		delete(r.mu.readHints, hint.ShardID)
+		return
 	}
+	r.mu.readHints[hint.ShardID] = hint.Replicas
 }
 
 func (r *defaultRouter) updateShardLocked(
@@ -455,6 +525,9 @@ func (r *defaultRouter) updateShardLocked(
 		delete(r.mu.shards, res.GetID())
 		delete(r.mu.missingLeaderStoreShards, res.GetID())
 		delete(r.mu.leaders, res.GetID())
+		delete(r.mu.missingLeaseStoreShards, res.GetID())
+		delete(r.mu.leases, res.GetID())
+		delete(r.mu.readHints, res.GetID())
 		return
 	}
 
@@ -621,12 +694,98 @@ func (r *defaultRouter) getLeaseReplicaStoreLocked(shardID uint64) leaseInfo {
 }
 
 func (r *defaultRouter) selectStoreLocked(shard Shard) uint64 {
-	ops := r.mu.opts[shard.ID]
-	storeID := shard.Replicas[int(ops.next())%len(shard.Replicas)].StoreID
-	r.mu.opts[shard.ID] = ops
+	return r.nextStoreLocked(shard.ID,
+		r.getLocalityReplicasLocked(r.getReadHintReplicasLocked(shard)))
+}
+
+// selectLearnerStoreLocked returns the store of a learner of the shard, the
+// reads are routed to any replica if the shard has no learner.
+func (r *defaultRouter) selectLearnerStoreLocked(shard Shard) uint64 {
+	var learners []Replica
+	for _, p := range shard.Replicas {
+		if p.Role == metapb.ReplicaRole_Learner {
+			learners = append(learners, p)
+		}
+	}
+	if len(learners) == 0 {
+		return r.selectStoreLocked(shard)
+	}
+	return r.nextStoreLocked(shard.ID, r.getLocalityReplicasLocked(learners))
+}
+
+func (r *defaultRouter) nextStoreLocked(shardID uint64, replicas []Replica) uint64 {
+	ops := r.mu.opts[shardID]
+	storeID := replicas[int(ops.next())%len(replicas)].StoreID
+	r.mu.opts[shardID] = ops
 	return storeID
 }
 
+// getReadHintReplicasLocked returns the replicas that the follower reads of
+// the shard should be routed to by the read hint, all replicas are returned if
+// no hinted replica in the shard.
+func (r *defaultRouter) getReadHintReplicasLocked(shard Shard) []Replica {
+	hint, ok := r.mu.readHints[shard.ID]
+	if !ok {
+		return shard.Replicas
+	}
+
+	var replicas []Replica
+	for _, p := range shard.Replicas {
+		for _, id := range hint {
+			if p.ID == id {
+				replicas = append(replicas, p)
+				break
+			}
+		}
+	}
+	if len(replicas) == 0 {
+		return shard.Replicas
+	}
+	return replicas
+}
+
+// getLocalityReplicasLocked returns the reachable replicas in the same zone as
+// the client, and fails over to the reachable replicas in other zones if no
+// such replica. All the replicas are returned if no replica is reachable.
+func (r *defaultRouter) getLocalityReplicasLocked(replicas []Replica) []Replica {
+	if r.options.zone == "" && len(r.mu.unreachableStores) == 0 {
+		return replicas
+	}
+
+	now := time.Now()
+	var reachable, local []Replica
+	for _, p := range replicas {
+		if deadline, ok := r.mu.unreachableStores[p.StoreID]; ok && now.Before(deadline) {
+			continue
+		}
+		reachable = append(reachable, p)
+		if r.options.zone != "" && r.inLocalZoneLocked(p.StoreID) {
+			local = append(local, p)
+		}
+	}
+
+	if len(local) > 0 {
+		return local
+	}
+	if len(reachable) > 0 {
+		return reachable
+	}
+	return replicas
+}
+
+func (r *defaultRouter) inLocalZoneLocked(storeID uint64) bool {
+	store, ok := r.mu.stores[storeID]
+	if !ok {
+		return false
+	}
+	for _, l := range store.Labels {
+		if l.Key == r.options.zoneLabel {
+			return l.Value == r.options.zone
+		}
+	}
+	return false
+}
+
 func (r *defaultRouter) searchShardLocked(group uint64, key []byte) Shard {
 	if tree, ok := r.mu.keyRanges[group]; ok {
 		return tree.Search(key)
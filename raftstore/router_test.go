@@ -15,6 +15,7 @@ package raftstore
 
 import (
 	"testing"
+	"time"
 
 	"github.com/fagongzi/util/format"
 	"github.com/fagongzi/util/protoc"
@@ -23,6 +24,7 @@ import (
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/matrixorigin/matrixcube/util/leaktest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHandleInitEvent(t *testing.T) {
@@ -116,6 +118,58 @@ func TestHandleShardEventWithLeader(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestUpdateLeaderAndLeaseOfRemovedShard(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	b := NewTestDataBuilder()
+	rr, err := newRouterBuilder().build(make(chan rpcpb.EventNotify))
+	assert.NoError(t, err)
+	r := rr.(*defaultRouter)
+
+	shard := b.CreateShard(1, "100/101,200/201,300/301")
+	store := metapb.Store{ID: 101}
+	e := rpcpb.EventNotify{}
+	e.Type = event.StoreEvent
+	e.StoreEvent = &rpcpb.StoreEventData{
+		Data: protoc.MustMarshal(&store),
+	}
+	r.handleEvent(e)
+	e.Type = event.ShardEvent
+	e.ShardEvent = &rpcpb.ShardEventData{
+		Data: protoc.MustMarshal(&shard),
+	}
+	r.handleEvent(e)
+
+	r.UpdateLeader(shard.ID, 100)
+	r.UpdateLease(shard.ID, &metapb.EpochLease{Epoch: 1, ReplicaID: 100})
+	assert.Equal(t, store, r.mu.leaders[shard.ID])
+	assert.Equal(t, store, r.mu.leases[shard.ID].store)
+
+	e.ShardEvent = &rpcpb.ShardEventData{
+		Data:    protoc.MustMarshal(&shard),
+		Removed: true,
+	}
+	r.handleEvent(e)
+	_, ok := r.mu.shards[shard.ID]
+	require.False(t, ok)
+
+	// the response of the stale route is received after the shard is removed
+	r.UpdateLeader(shard.ID, 100)
+	r.UpdateLease(shard.ID, &metapb.EpochLease{Epoch: 2, ReplicaID: 100})
+	_, ok = r.mu.leaders[shard.ID]
+	assert.False(t, ok)
+	_, ok = r.mu.leases[shard.ID]
+	assert.False(t, ok)
+
+	// the shard is never known by the router
+	r.UpdateLeader(2, 100)
+	r.UpdateLease(2, &metapb.EpochLease{Epoch: 1, ReplicaID: 100})
+	_, ok = r.mu.leaders[2]
+	assert.False(t, ok)
+	_, ok = r.mu.leases[2]
+	assert.False(t, ok)
+}
+
 func TestHandleShardEventWithLease(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -239,6 +293,108 @@ func TestHandleStoreEvent(t *testing.T) {
 	assert.Equal(t, store, r.mu.stores[store.ID])
 }
 
+func TestHandleReadHintEvent(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	rr, err := newRouterBuilder().build(make(chan rpcpb.EventNotify))
+	assert.NoError(t, err)
+	r := rr.(*defaultRouter)
+
+	b := NewTestDataBuilder()
+	shard := b.CreateShard(1, "100/101,200/201,300/301")
+	r.updateShardLocked(protoc.MustMarshal(&shard), 100, nil, false, false)
+	for _, id := range []uint64{101, 201, 301} {
+		r.updateStoreLocked(protoc.MustMarshal(&metapb.Store{ID: id}))
+	}
+
+	r.handleEvent(event.NewReadHintEvent(&metapb.ShardReadHint{ShardID: 1, Replicas: []uint64{200}}))
+	for i := 0; i < 3; i++ {
+		store, _ := r.SelectReplicaStoreWithPolicy(1, rpcpb.SelectRandom)
+		assert.Equal(t, uint64(201), store.ID)
+	}
+	store, _ := r.SelectReplicaStoreWithPolicy(1, rpcpb.SelectLeader)
+	assert.Equal(t, uint64(101), store.ID)
+
+	// hinted replica not in the shard
+	r.handleEvent(event.NewReadHintEvent(&metapb.ShardReadHint{ShardID: 1, Replicas: []uint64{400}}))
+	assert.Equal(t, shard.Replicas, r.getReadHintReplicasLocked(shard))
+
+	r.handleEvent(event.NewReadHintEvent(&metapb.ShardReadHint{ShardID: 1}))
+	assert.Empty(t, r.mu.readHints)
+}
+
+func TestLocalityRouting(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	rr, err := newRouterBuilder().
+		withLocality("zone", "z1").
+		withUnreachableStoreTimeout(time.Minute).
+		build(make(chan rpcpb.EventNotify))
+	assert.NoError(t, err)
+	r := rr.(*defaultRouter)
+
+	b := NewTestDataBuilder()
+	shard := b.CreateShard(1, "100/101,200/201,300/301")
+	r.updateShardLocked(protoc.MustMarshal(&shard), 300, nil, false, false)
+	r.updateStoreLocked(protoc.MustMarshal(&metapb.Store{ID: 101, Labels: []metapb.Label{{Key: "zone", Value: "z1"}}}))
+	r.updateStoreLocked(protoc.MustMarshal(&metapb.Store{ID: 201, Labels: []metapb.Label{{Key: "zone", Value: "z2"}}}))
+	r.updateStoreLocked(protoc.MustMarshal(&metapb.Store{ID: 301}))
+
+	for i := 0; i < 3; i++ {
+		store, _ := r.SelectReplicaStoreWithPolicy(1, rpcpb.SelectRandom)
+		assert.Equal(t, uint64(101), store.ID)
+	}
+	store, _ := r.SelectReplicaStoreWithPolicy(1, rpcpb.SelectLeader)
+	assert.Equal(t, uint64(301), store.ID)
+
+	// fails over to other zones
+	r.MarkStoreUnreachable(101)
+	for i := 0; i < 3; i++ {
+		store, _ := r.SelectReplicaStoreWithPolicy(1, rpcpb.SelectRandom)
+		assert.NotEqual(t, uint64(101), store.ID)
+	}
+
+	// all replicas are unreachable
+	r.MarkStoreUnreachable(201)
+	r.MarkStoreUnreachable(301)
+	assert.Equal(t, shard.Replicas, r.getLocalityReplicasLocked(shard.Replicas))
+
+	// back to the local zone after the unreachable timeout
+	r.mu.unreachableStores[101] = time.Now().Add(-time.Second)
+	store, _ = r.SelectReplicaStoreWithPolicy(1, rpcpb.SelectRandom)
+	assert.Equal(t, uint64(101), store.ID)
+}
+
+func TestLearnerRouting(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	rr, err := newRouterBuilder().build(make(chan rpcpb.EventNotify))
+	assert.NoError(t, err)
+	r := rr.(*defaultRouter)
+
+	b := NewTestDataBuilder()
+	shard := b.CreateShard(1, "100/101,200/201,300/301")
+	r.updateShardLocked(protoc.MustMarshal(&shard), 100, nil, false, false)
+	for _, id := range []uint64{101, 201, 301} {
+		r.updateStoreLocked(protoc.MustMarshal(&metapb.Store{ID: id}))
+	}
+
+	// no learner, any replica is selected
+	stores := make(map[uint64]struct{})
+	for i := 0; i < 3; i++ {
+		store, _ := r.SelectReplicaStoreWithPolicy(1, rpcpb.SelectLearner)
+		stores[store.ID] = struct{}{}
+	}
+	assert.Equal(t, 3, len(stores))
+
+	shard.Replicas[2].Role = metapb.ReplicaRole_Learner
+	r.updateShardLocked(protoc.MustMarshal(&shard), 100, nil, false, false)
+	for i := 0; i < 3; i++ {
+		store, _ := r.SelectReplicaStoreWithPolicy(1, rpcpb.SelectLearner)
+		assert.Equal(t, uint64(301), store.ID)
+	}
+}
+
 func TestSelectShard(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
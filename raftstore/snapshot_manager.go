@@ -0,0 +1,253 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/snapshot"
+	"github.com/matrixorigin/matrixcube/util/fileutil"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+var (
+	replicaSnapshotDirNameRe = regexp.MustCompile(`^shard-([0-9]+)-replica-([0-9]+)$`)
+)
+
+// snapshotManager manages the snapshot dirs of all the replicas of the store.
+// It limits the snapshots created at the same time and the disk used by the
+// snapshots, and removes the snapshot dirs no longer used, e.g. the dirs of the
+// destroyed replicas and the temp dirs left by the aborted snapshots.
+type snapshotManager struct {
+	logger  *zap.Logger
+	cfg     config.SnapshotConfig
+	fs      vfs.FS
+	rootDir string
+	// hasReplica returns true if the replica is on the store
+	hasReplica func(shardID, replicaID uint64) bool
+
+	mu struct {
+		sync.Mutex
+		// creating the replica snapshot dirs in which the snapshots are being
+		// created
+		creating map[string]int
+		count    int
+		// diskUsage the disk used by the snapshot dirs, computed by the last gc
+		// and increased by the snapshots created after that
+		diskUsage uint64
+	}
+}
+
+func newSnapshotManager(logger *zap.Logger, cfg config.SnapshotConfig, fs vfs.FS,
+	rootDir string, hasReplica func(shardID, replicaID uint64) bool) *snapshotManager {
+	m := &snapshotManager{
+		logger:     logger,
+		cfg:        cfg,
+		fs:         fs,
+		rootDir:    rootDir,
+		hasReplica: hasReplica,
+	}
+	m.mu.creating = make(map[string]int)
+	return m
+}
+
+// acquire registers the replica snapshot dir before creating a snapshot in it,
+// and returns false if the snapshot should not be created now.
+func (m *snapshotManager) acquire(dir string) bool {
+	if m == nil {
+		return true
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if max := m.cfg.MaxConcurrentCreates; max > 0 && uint64(m.mu.count) >= max {
+		m.logger.Info("too many snapshots being created",
+			zap.Int("creating", m.mu.count))
+		return false
+	}
+	if max := uint64(m.cfg.MaxDiskUsage); max > 0 && m.mu.diskUsage >= max {
+		m.logger.Info("too much disk used by snapshots",
+			zap.Uint64("disk-usage", m.mu.diskUsage))
+		return false
+	}
+	m.mu.creating[dir]++
+	m.mu.count++
+	return true
+}
+
+// release unregisters the replica snapshot dir after the snapshot created or
+// aborted, size is the size of the created snapshot.
+func (m *snapshotManager) release(dir string, size int64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.mu.creating[dir] <= 1 {
+		delete(m.mu.creating, dir)
+	} else {
+		m.mu.creating[dir]--
+	}
+	m.mu.count--
+	if size > 0 {
+		m.mu.diskUsage += uint64(size)
+	}
+}
+
+func (m *snapshotManager) isCreating(dir string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.mu.creating[dir]
+	return ok
+}
+
+func (m *snapshotManager) getDiskUsage() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mu.diskUsage
+}
+
+// gc removes the snapshot dirs of the replicas not on the store, and the temp
+// dirs of the replicas which are not creating the snapshots. The dirs modified
+// in the last gracePeriod are kept, since the snapshot may be received before
+// the replica is created. The disk usage is computed by the kept dirs.
+func (m *snapshotManager) gc(now time.Time, gracePeriod time.Duration) error {
+	exist, err := fileutil.Exist(m.rootDir, m.fs)
+	if err != nil || !exist {
+		return err
+	}
+	names, err := m.fs.List(m.rootDir)
+	if err != nil {
+		return err
+	}
+
+	var usage uint64
+	removed := false
+	for _, name := range names {
+		dir := m.fs.PathJoin(m.rootDir, name)
+		parts := replicaSnapshotDirNameRe.FindStringSubmatch(name)
+		if len(parts) != 3 {
+			continue
+		}
+		shardID, _ := strconv.ParseUint(parts[1], 10, 64)
+		replicaID, _ := strconv.ParseUint(parts[2], 10, 64)
+		size, modTime, err := m.getDirStats(dir)
+		if err != nil {
+			return err
+		}
+		if !m.hasReplica(shardID, replicaID) {
+			if now.Sub(modTime) >= gracePeriod {
+				m.logger.Info("removing orphan replica snapshot dir",
+					zap.String("dir", dir))
+				if err := m.fs.RemoveAll(dir); err != nil {
+					return err
+				}
+				removed = true
+				continue
+			}
+		} else if !m.isCreating(dir) {
+			removedSize, err := m.removeTempDirs(now, dir, gracePeriod)
+			if err != nil {
+				return err
+			}
+			size -= removedSize
+		}
+		usage += size
+	}
+	if removed {
+		if err := fileutil.SyncDir(m.rootDir, m.fs); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	m.mu.diskUsage = usage
+	m.mu.Unlock()
+	return nil
+}
+
+// removeTempDirs removes the temp dirs in the replica snapshot dir, and returns
+// the size of the removed dirs.
+func (m *snapshotManager) removeTempDirs(now time.Time, dir string, gracePeriod time.Duration) (uint64, error) {
+	names, err := m.fs.List(dir)
+	if err != nil {
+		return 0, err
+	}
+	var removedSize uint64
+	removed := false
+	for _, name := range names {
+		if !snapshot.GenSnapshotDirNameRe.MatchString(name) &&
+			!snapshot.RecvSnapshotDirNameRe.MatchString(name) {
+			continue
+		}
+		tmpDir := m.fs.PathJoin(dir, name)
+		size, modTime, err := m.getDirStats(tmpDir)
+		if err != nil {
+			return 0, err
+		}
+		if now.Sub(modTime) < gracePeriod {
+			continue
+		}
+		m.logger.Info("removing snapshot temp dir",
+			zap.String("dir", tmpDir))
+		if err := m.fs.RemoveAll(tmpDir); err != nil {
+			return 0, err
+		}
+		removedSize += size
+		removed = true
+	}
+	if removed {
+		if err := fileutil.SyncDir(dir, m.fs); err != nil {
+			return 0, err
+		}
+	}
+	return removedSize, nil
+}
+
+// getDirStats returns the total size of the files in the dir and the last
+// modification time of the dir and its children.
+func (m *snapshotManager) getDirStats(dir string) (uint64, time.Time, error) {
+	fi, err := m.fs.Stat(dir)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	modTime := fi.ModTime()
+	if !fi.IsDir() {
+		return uint64(fi.Size()), modTime, nil
+	}
+
+	names, err := m.fs.List(dir)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	var size uint64
+	for _, name := range names {
+		childSize, childModTime, err := m.getDirStats(m.fs.PathJoin(dir, name))
+		if err != nil {
+			return 0, time.Time{}, err
+		}
+		size += childSize
+		if childModTime.After(modTime) {
+			modTime = childModTime
+		}
+	}
+	return size, modTime, nil
+}
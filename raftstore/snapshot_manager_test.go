@@ -0,0 +1,104 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/components/prophet/util/typeutil"
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+func newTestSnapshotManager(t *testing.T, cfg config.SnapshotConfig,
+	hasReplica func(shardID, replicaID uint64) bool) (*snapshotManager, vfs.FS) {
+	fs := vfs.NewMemFS()
+	require.NoError(t, fs.MkdirAll("snapshots", 0755))
+	return newSnapshotManager(log.GetDefaultZapLogger(), cfg, fs, "snapshots", hasReplica), fs
+}
+
+func writeTestSnapshotFile(t *testing.T, fs vfs.FS, dir string, size int) {
+	require.NoError(t, fs.MkdirAll(dir, 0755))
+	f, err := fs.Create(fs.PathJoin(dir, "data"))
+	require.NoError(t, err)
+	_, err = f.Write(make([]byte, size))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestSnapshotManagerAcquire(t *testing.T) {
+	m, _ := newTestSnapshotManager(t, config.SnapshotConfig{
+		MaxConcurrentCreates: 2,
+		MaxDiskUsage:         typeutil.ByteSize(100),
+	}, nil)
+
+	assert.True(t, m.acquire("d1"))
+	assert.True(t, m.acquire("d2"))
+	assert.False(t, m.acquire("d3"), "too many snapshots being created")
+	assert.True(t, m.isCreating("d1"))
+
+	m.release("d1", 60)
+	assert.False(t, m.isCreating("d1"))
+	assert.True(t, m.acquire("d3"))
+	m.release("d3", 60)
+	assert.Equal(t, uint64(120), m.getDiskUsage())
+	assert.False(t, m.acquire("d4"), "too much disk used by snapshots")
+
+	var nilManager *snapshotManager
+	assert.True(t, nilManager.acquire("d1"))
+	nilManager.release("d1", 10)
+}
+
+func TestSnapshotManagerGC(t *testing.T) {
+	m, fs := newTestSnapshotManager(t, config.SnapshotConfig{}, func(shardID, replicaID uint64) bool {
+		return shardID == 1 && replicaID == 1
+	})
+
+	writeTestSnapshotFile(t, fs, "snapshots/shard-1-replica-1/snapshot-0000000000000064-0000000000000001", 10)
+	writeTestSnapshotFile(t, fs, "snapshots/shard-1-replica-1/snapshot-0000000000000003.generating", 20)
+	writeTestSnapshotFile(t, fs, "snapshots/shard-1-replica-1/snapshot-0000000000000064-0000000000000002.receiving", 30)
+	writeTestSnapshotFile(t, fs, "snapshots/shard-2-replica-2/snapshot-0000000000000064-0000000000000001", 40)
+	writeTestSnapshotFile(t, fs, "snapshots/unknown", 50)
+
+	exists := func(dir string) bool {
+		_, err := fs.Stat(dir)
+		return err == nil
+	}
+
+	// all the dirs are modified in the grace period
+	require.NoError(t, m.gc(time.Now(), time.Hour))
+	assert.True(t, exists("snapshots/shard-2-replica-2"))
+	assert.True(t, exists("snapshots/shard-1-replica-1/snapshot-0000000000000003.generating"))
+	assert.Equal(t, uint64(100), m.getDiskUsage())
+
+	// the temp dirs of the replica creating snapshots are kept
+	assert.True(t, m.acquire("snapshots/shard-1-replica-1"))
+	require.NoError(t, m.gc(time.Now().Add(time.Hour), time.Hour))
+	assert.False(t, exists("snapshots/shard-2-replica-2"))
+	assert.True(t, exists("snapshots/shard-1-replica-1/snapshot-0000000000000003.generating"))
+	assert.Equal(t, uint64(60), m.getDiskUsage())
+
+	m.release("snapshots/shard-1-replica-1", 0)
+	require.NoError(t, m.gc(time.Now(), 0))
+	assert.True(t, exists("snapshots/shard-1-replica-1/snapshot-0000000000000064-0000000000000001"))
+	assert.False(t, exists("snapshots/shard-1-replica-1/snapshot-0000000000000003.generating"))
+	assert.False(t, exists("snapshots/shard-1-replica-1/snapshot-0000000000000064-0000000000000002.receiving"))
+	assert.True(t, exists("snapshots/unknown"), "the dirs not created by the replicas are skipped")
+	assert.Equal(t, uint64(10), m.getDiskUsage())
+}
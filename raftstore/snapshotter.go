@@ -216,6 +216,22 @@ func (s *snapshotter) recover(rc recoverable,
 	panic("missing shard metadata after recovering from snapshot")
 }
 
+// verify verifies the received snapshot, the corrupted snapshot is removed so
+// it can be received again.
+func (s *snapshotter) verify(v storage.SnapshotVerifier, ss raftpb.Snapshot) error {
+	env := s.getRecoverSnapshotEnv(ss)
+	err := v.VerifySnapshot(s.shardID, env.GetFinalDir())
+	if errors.Is(err, storage.ErrSnapshotCorrupted) {
+		s.logger.Error("received corrupted snapshot",
+			zap.String("dir", env.GetFinalDir()),
+			zap.Error(err))
+		if err := env.RemoveFinalDir(); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
 func (s *snapshotter) commit(ss raftpb.Snapshot, env snapshot.SSEnv) error {
 	env.FinalizeIndex(ss.Metadata.Index)
 	if err := env.FinalizeSnapshot(); err != nil {
@@ -229,6 +245,22 @@ func (s *snapshotter) commit(ss raftpb.Snapshot, env snapshot.SSEnv) error {
 	return nil
 }
 
+// getSnapshotSize returns the total size of the files in the snapshot dir, the
+// size is only used by the metrics, so the errors are ignored.
+func (s *snapshotter) getSnapshotSize(dir string) int64 {
+	files, err := s.fs.List(dir)
+	if err != nil {
+		return 0
+	}
+	size := int64(0)
+	for _, file := range files {
+		if fi, err := s.fs.Stat(s.fs.PathJoin(dir, file)); err == nil && !fi.IsDir() {
+			size += fi.Size()
+		}
+	}
+	return size
+}
+
 func (s *snapshotter) getRecoverSnapshotEnv(ss raftpb.Snapshot) snapshot.SSEnv {
 	var si metapb.SnapshotInfo
 	protoc.MustUnmarshal(&si, ss.Data)
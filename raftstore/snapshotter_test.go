@@ -33,12 +33,16 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/cockroachdb/errors"
+	"github.com/fagongzi/util/protoc"
 	"github.com/stretchr/testify/assert"
 	"go.etcd.io/etcd/raft/v3/raftpb"
 	"go.uber.org/zap"
 
 	"github.com/matrixorigin/matrixcube/components/log"
 	"github.com/matrixorigin/matrixcube/logdb"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
 	"github.com/matrixorigin/matrixcube/storage/kv/mem"
 	"github.com/matrixorigin/matrixcube/util/leaktest"
 	"github.com/matrixorigin/matrixcube/vfs"
@@ -537,3 +541,55 @@ func TestSnapshotCanBeFinalized(t *testing.T) {
 	}
 	runSnapshotterTest(t, fn, fs)
 }
+
+type testSnapshotVerifier func(shardID uint64, path string) error
+
+func (f testSnapshotVerifier) VerifySnapshot(shardID uint64, path string) error {
+	return f(shardID, path)
+}
+
+func TestCorruptedSnapshotIsRemoved(t *testing.T) {
+	fs := vfs.GetTestFS()
+	fn := func(t *testing.T, ldb logdb.LogDB, s *snapshotter) {
+		ss := raftpb.Snapshot{
+			Data:     protoc.MustMarshal(&metapb.SnapshotInfo{Extra: 1}),
+			Metadata: raftpb.SnapshotMetadata{Index: 100, Term: 200},
+		}
+		env := s.getRecoverSnapshotEnv(ss)
+		assert.NoError(t, env.CreateTempDir())
+		assert.NoError(t, env.FinalizeSnapshot())
+		var dir string
+		verified := testSnapshotVerifier(func(shardID uint64, path string) error {
+			dir = path
+			return nil
+		})
+		assert.NoError(t, s.verify(verified, ss))
+		assert.Equal(t, env.GetFinalDir(), dir)
+		assert.True(t, env.FinalDirExists())
+
+		corrupted := testSnapshotVerifier(func(shardID uint64, path string) error {
+			return errors.Wrap(storage.ErrSnapshotCorrupted, "checksum mismatch")
+		})
+		assert.True(t, errors.Is(s.verify(corrupted, ss), storage.ErrSnapshotCorrupted))
+		assert.False(t, env.FinalDirExists())
+	}
+	runSnapshotterTest(t, fn, fs)
+}
+
+func TestGetSnapshotSize(t *testing.T) {
+	fn := func(t *testing.T, ldb logdb.LogDB, s *snapshotter) {
+		dir := s.fs.PathJoin(s.rootDir, "size")
+		assert.Equal(t, int64(0), s.getSnapshotSize(dir))
+		assert.NoError(t, s.fs.MkdirAll(s.fs.PathJoin(dir, "sub"), 0755))
+		for _, name := range []string{"a", "b"} {
+			f, err := s.fs.Create(s.fs.PathJoin(dir, name))
+			assert.NoError(t, err)
+			_, err = f.Write(make([]byte, 10))
+			assert.NoError(t, err)
+			assert.NoError(t, f.Close())
+		}
+		assert.Equal(t, int64(20), s.getSnapshotSize(dir))
+	}
+	fs := vfs.GetTestFS()
+	runSnapshotterTest(t, fn, fs)
+}
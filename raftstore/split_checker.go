@@ -15,44 +15,75 @@ package raftstore
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/lni/goutils/syncutil"
 	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/metric"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/storage"
 	"go.uber.org/zap"
 )
 
-type splitCheckFunc func(shard Shard, size uint64) (currentApproximateSize uint64,
-	currentApproximateKeys uint64, splitKeys [][]byte, ctx []byte, err error)
-type featureGetter func(uint64) storage.Feature
+const (
+	// defaultSplitCheckWatchInterval the interval to check whether the running
+	// split check should be canceled
+	defaultSplitCheckWatchInterval = 100 * time.Millisecond
+)
+
+type splitCheckFunc func(ctx context.Context, shard Shard, size uint64) (currentApproximateSize uint64,
+	currentApproximateKeys uint64, splitKeys [][]byte, splitCtx []byte, err error)
+type featureGetter func(Shard) storage.Feature
 
+// splitChecker runs the split checks in a bounded worker pool. The running
+// check is canceled once the epoch of the shard changes or the leadership
+// moves, since the result is discarded in that case.
 type splitChecker struct {
+	workers           int
+	watchInterval     time.Duration
 	replicaGetter     replicaGetter
 	featureGetterFunc featureGetter
 	checkFuncFactory  func(group uint64) splitCheckFunc
 	stopper           *syncutil.Stopper
 	shardsC           chan Shard
+	ctx               context.Context
+	cancel            context.CancelFunc
+	running           int64
 
 	mu struct {
 		sync.Mutex
 		running bool
+		// pending the shards waiting or being checked, a shard is checked by
+		// only one worker at the same time
+		pending map[uint64]struct{}
 	}
 }
 
-func newSplitChecker(maxWaitToCheck int,
+func newSplitChecker(maxWaitToCheck int, workers int,
 	replicaGetter replicaGetter,
 	featureGetter featureGetter,
 	checkFuncFactory func(group uint64) splitCheckFunc) *splitChecker {
-	return &splitChecker{
+	if workers <= 0 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	sc := &splitChecker{
+		workers:           workers,
+		watchInterval:     defaultSplitCheckWatchInterval,
 		stopper:           syncutil.NewStopper(),
 		replicaGetter:     replicaGetter,
 		checkFuncFactory:  checkFuncFactory,
 		featureGetterFunc: featureGetter,
 		shardsC:           make(chan Shard, maxWaitToCheck),
+		ctx:               ctx,
+		cancel:            cancel,
 	}
+	sc.mu.pending = make(map[uint64]struct{})
+	return sc
 }
 
 func (sc *splitChecker) start() {
@@ -64,17 +95,22 @@ func (sc *splitChecker) start() {
 	}
 
 	sc.mu.running = true
-	go func() {
-		for {
-			select {
-			case <-sc.stopper.ShouldStop():
-				close(sc.shardsC)
-				return
-			case shard := <-sc.shardsC:
-				sc.doChecker(shard)
+	for i := 0; i < sc.workers; i++ {
+		sc.stopper.RunWorker(func() {
+			for {
+				select {
+				case <-sc.stopper.ShouldStop():
+					return
+				case shard := <-sc.shardsC:
+					atomic.AddInt64(&sc.running, 1)
+					sc.updateMetrics()
+					sc.doChecker(shard)
+					atomic.AddInt64(&sc.running, -1)
+					sc.done(shard.ID)
+				}
 			}
-		}
-	}()
+		})
+	}
 }
 
 func (sc *splitChecker) doChecker(shard Shard) bool {
@@ -87,20 +123,49 @@ func (sc *splitChecker) doChecker(shard Shard) bool {
 
 	epoch := shard.Epoch
 	current := pr.getShard()
-	if current.Epoch.Generation != epoch.Generation {
-		pr.logger.Info("epoch changed, need re-check later",
+	if reason := sc.cancelReason(pr, epoch); reason != "" {
+		pr.logger.Info("split check skipped, need re-check later",
+			zap.String("reason", reason),
 			log.EpochField("current-epoch", current.Epoch),
 			log.EpochField("check-epoch", epoch))
 		return false
 	}
 
-	policy := sc.featureGetterFunc(shard.Group)
+	checkCtx, cancel := context.WithCancel(sc.ctx)
+	defer cancel()
+	go sc.watch(checkCtx, cancel, pr, epoch)
+
+	policy := sc.featureGetterFunc(shard)
 	fn := sc.checkFuncFactory(shard.Group)
-	size, keys, splitKeys, ctx, err := fn(shard, policy.ShardCapacityBytes)
-	if err != nil {
+	start := time.Now()
+	size, keys, splitKeys, ctx, err := fn(checkCtx, shard, policy.ShardCapacityBytes)
+	if err != nil && checkCtx.Err() == nil {
 		pr.logger.Fatal("fail to scan split key",
 			zap.Error(err))
 	}
+	// the result is discarded if the check is finished after the shard changed
+	if reason := sc.cancelReason(pr, epoch); err != nil || reason != "" {
+		if reason == "" {
+			reason = checkCtx.Err().Error()
+		}
+		pr.logger.Info("split check canceled, need re-check later",
+			zap.String("reason", reason))
+		metric.IncSplitCheckCount("canceled")
+		metric.ObserveSplitCheckDuration(pr.cfg.Metric, pr.storeID, shard.ID, shard.Group,
+			"canceled", start)
+		return false
+	}
+	metric.IncSplitCheckCount("finished")
+	metric.ObserveSplitCheckDuration(pr.cfg.Metric, pr.storeID, shard.ID, shard.Group,
+		"finished", start)
+
+	if len(splitKeys) == 0 {
+		if key := pr.loadSplit.take(epoch); key != nil && checkKeyInShard(key, shard) == nil {
+			pr.logger.Info("split by load",
+				log.HexField("split-key", key))
+			splitKeys = [][]byte{key}
+		}
+	}
 
 	pr.logger.Debug("split check result",
 		log.ShardField("metadata", shard),
@@ -158,16 +223,61 @@ func (sc *splitChecker) doChecker(shard Shard) bool {
 	return true
 }
 
-func (sc *splitChecker) close() {
+// cancelReason returns why the split check of the shard in the epoch should be
+// canceled, empty if it needn't.
+func (sc *splitChecker) cancelReason(pr *replica, epoch Epoch) string {
+	if pr.getShard().Epoch.Generation != epoch.Generation {
+		return "epoch changed"
+	}
+	if !pr.isLeader() {
+		return "not leader"
+	}
+	return ""
+}
+
+// watch cancels the running split check once it should be canceled, returns
+// after the split check is finished.
+func (sc *splitChecker) watch(ctx context.Context, cancel context.CancelFunc,
+	pr *replica, epoch Epoch) {
+	ticker := time.NewTicker(sc.watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if sc.cancelReason(pr, epoch) != "" {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (sc *splitChecker) done(shardID uint64) {
 	sc.mu.Lock()
-	defer sc.mu.Unlock()
+	delete(sc.mu.pending, shardID)
+	sc.mu.Unlock()
+	sc.updateMetrics()
+}
 
+func (sc *splitChecker) updateMetrics() {
+	running := atomic.LoadInt64(&sc.running)
+	metric.SetSplitCheckQueueMetric(int64(len(sc.shardsC)), running)
+}
+
+func (sc *splitChecker) close() {
+	sc.mu.Lock()
 	if !sc.mu.running {
+		sc.mu.Unlock()
 		return
 	}
+	sc.mu.running = false
+	sc.mu.Unlock()
 
+	// aborts the running checks before waiting for the workers
+	sc.cancel()
 	sc.stopper.Stop()
-	sc.mu.running = false
 }
 
 func (sc *splitChecker) add(shard Shard) {
@@ -183,8 +293,14 @@ func (sc *splitChecker) add(shard Shard) {
 		return
 	}
 
+	if _, ok := sc.mu.pending[shard.ID]; ok {
+		return
+	}
+
 	select {
 	case sc.shardsC <- shard:
+		sc.mu.pending[shard.ID] = struct{}{}
+		sc.updateMetrics()
 	default:
 	}
 }
@@ -14,7 +14,11 @@
 package raftstore
 
 import (
+	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/matrixorigin/matrixcube/components/prophet/mock/mockclient"
@@ -28,7 +32,7 @@ import (
 func TestSplitCheckerAdd(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
-	sc := newSplitChecker(1, nil, func(u uint64) storage.Feature {
+	sc := newSplitChecker(1, 1, nil, func(shard Shard) storage.Feature {
 		return storage.Feature{
 			ShardCapacityBytes: 100,
 		}
@@ -47,7 +51,7 @@ func TestSplitCheckerAdd(t *testing.T) {
 func TestSplitCheckerAddWithInvalidShardState(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
-	sc := newSplitChecker(1, nil, func(u uint64) storage.Feature {
+	sc := newSplitChecker(1, 1, nil, func(shard Shard) storage.Feature {
 		return storage.Feature{
 			ShardCapacityBytes: 100,
 		}
@@ -64,7 +68,7 @@ func TestSplitCheckerAddWithInvalidShardState(t *testing.T) {
 func TestSplitCheckerStartAndClose(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
-	sc := newSplitChecker(1, nil, func(u uint64) storage.Feature {
+	sc := newSplitChecker(1, 1, nil, func(shard Shard) storage.Feature {
 		return storage.Feature{
 			ShardCapacityBytes: 100,
 		}
@@ -86,12 +90,12 @@ func TestSplitCheckerDoCheck(t *testing.T) {
 	var splitKeys [][]byte
 	var err error
 	trg := newTestReplicaGetter()
-	sc := newSplitChecker(1, trg, func(u uint64) storage.Feature {
+	sc := newSplitChecker(1, 1, trg, func(shard Shard) storage.Feature {
 		return storage.Feature{
 			ShardCapacityBytes: 100,
 		}
 	}, func(group uint64) splitCheckFunc {
-		return func(shard Shard, size uint64) (uint64, uint64, [][]byte, []byte, error) {
+		return func(ctx context.Context, shard Shard, size uint64) (uint64, uint64, [][]byte, []byte, error) {
 			return currentSize, currentKeys, splitKeys, nil, err
 		}
 	})
@@ -108,6 +112,10 @@ func TestSplitCheckerDoCheck(t *testing.T) {
 	// epoch not match
 	assert.False(t, sc.doChecker(Shard{}))
 
+	// not leader
+	assert.False(t, sc.doChecker(pr.getShard()))
+	pr.setLeaderReplicaID(1)
+
 	// ok with no split
 	assert.True(t, sc.doChecker(pr.getShard()))
 	assert.Equal(t, int64(1), pr.actions.Len())
@@ -128,5 +136,110 @@ func TestSplitCheckerDoCheck(t *testing.T) {
 	assert.Equal(t, int64(1), pr.actions.Len())
 	act, _ = pr.actions.Peek()
 	assert.Equal(t, action{actionType: splitAction, epoch: pr.getShard().Epoch, splitCheckData: splitCheckData{keys: currentKeys, size: currentSize, splitKeys: splitKeys, splitIDs: splitIDs}}, act)
+	_, err = pr.actions.Get(1, make([]interface{}, 1))
+	assert.NoError(t, err)
+
+	// split by load
+	splitKeys = nil
+	splitIDs = splitIDs[:2]
+	pr.loadSplit.set(pr.getShard().Epoch, []byte{1})
+	client.EXPECT().AskBatchSplit(gomock.Any(), gomock.Any()).Return(splitIDs, nil)
+	assert.True(t, sc.doChecker(pr.getShard()))
+	act, _ = pr.actions.Peek()
+	assert.Equal(t, action{actionType: splitAction, epoch: pr.getShard().Epoch, splitCheckData: splitCheckData{keys: currentKeys, size: currentSize, splitKeys: [][]byte{{1}}, splitIDs: splitIDs}}, act)
+}
+
+func TestSplitCheckerCancel(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	tests := []struct {
+		name   string
+		change func(pr *replica)
+	}{
+		{"epoch changed", func(pr *replica) {
+			pr.sm.updateShard(Shard{ID: 1, Epoch: Epoch{Generation: 2}})
+		}},
+		{"leadership moved", func(pr *replica) { pr.setLeaderReplicaID(2) }},
+	}
+	for _, tt := range tests {
+		trg := newTestReplicaGetter()
+		startedC := make(chan struct{})
+		sc := newSplitChecker(1, 1, trg, func(shard Shard) storage.Feature {
+			return storage.Feature{ShardCapacityBytes: 100}
+		}, func(group uint64) splitCheckFunc {
+			return func(ctx context.Context, shard Shard, size uint64) (uint64, uint64, [][]byte, []byte, error) {
+				close(startedC)
+				<-ctx.Done()
+				return 0, 0, nil, nil, ctx.Err()
+			}
+		})
+		sc.watchInterval = time.Millisecond
+
+		pr := newTestReplica(Shard{ID: 1, Epoch: Epoch{Generation: 1}}, Replica{ID: 1}, s)
+		pr.setLeaderReplicaID(1)
+		trg.replicas[1] = pr
+
+		go func() {
+			<-startedC
+			tt.change(pr)
+		}()
+		assert.False(t, sc.doChecker(pr.getShard()), tt.name)
+		assert.Equal(t, int64(0), pr.actions.Len(), tt.name)
+	}
+}
+
+func TestSplitCheckerWorkers(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	var mu sync.Mutex
+	checked := make(map[uint64]int)
+	startedC := make(chan uint64, 4)
+	trg := newTestReplicaGetter()
+	sc := newSplitChecker(4, 2, trg, func(shard Shard) storage.Feature {
+		return storage.Feature{ShardCapacityBytes: 100}
+	}, func(group uint64) splitCheckFunc {
+		return func(ctx context.Context, shard Shard, size uint64) (uint64, uint64, [][]byte, []byte, error) {
+			mu.Lock()
+			checked[shard.ID]++
+			mu.Unlock()
+			startedC <- shard.ID
+			// blocked until the checker is closed
+			<-ctx.Done()
+			return 0, 0, nil, nil, ctx.Err()
+		}
+	})
+	for id := uint64(1); id <= 3; id++ {
+		pr := newTestReplica(Shard{ID: id, Epoch: Epoch{Generation: 1}}, Replica{ID: id}, s)
+		pr.setLeaderReplicaID(id)
+		trg.replicas[id] = pr
+	}
 
+	sc.start()
+	sc.add(trg.replicas[1].getShard())
+	sc.add(trg.replicas[2].getShard())
+	// both workers are busy
+	started := map[uint64]bool{<-startedC: true, <-startedC: true}
+	assert.Equal(t, map[uint64]bool{1: true, 2: true}, started)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&sc.running))
+
+	// the shard being checked is not added again
+	sc.add(trg.replicas[1].getShard())
+	sc.add(trg.replicas[3].getShard())
+	assert.Equal(t, 1, len(sc.shardsC))
+
+	sc.close()
+	assert.Equal(t, int64(0), atomic.LoadInt64(&sc.running))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, checked[1])
+	assert.Equal(t, 1, checked[2])
+	for id := uint64(1); id <= 2; id++ {
+		assert.Equal(t, int64(0), trg.replicas[id].actions.Len())
+	}
 }
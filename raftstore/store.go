@@ -14,6 +14,7 @@
 package raftstore
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -22,20 +23,29 @@ import (
 	"github.com/RoaringBitmap/roaring/roaring64"
 	"github.com/fagongzi/util/protoc"
 	"github.com/lni/goutils/syncutil"
+	"github.com/matrixorigin/matrixcube/advisor"
 	"github.com/matrixorigin/matrixcube/aware"
+	"github.com/matrixorigin/matrixcube/backup"
 	"github.com/matrixorigin/matrixcube/components/log"
 	"github.com/matrixorigin/matrixcube/components/prophet"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
 	"github.com/matrixorigin/matrixcube/components/prophet/event"
 	putil "github.com/matrixorigin/matrixcube/components/prophet/util"
 	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/keys"
 	"github.com/matrixorigin/matrixcube/logdb"
+	"github.com/matrixorigin/matrixcube/metric"
+	"github.com/matrixorigin/matrixcube/migration"
 	"github.com/matrixorigin/matrixcube/pb/errorpb"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/matrixorigin/matrixcube/storage"
 	"github.com/matrixorigin/matrixcube/storage/kv/pebble"
+	"github.com/matrixorigin/matrixcube/storage/stats"
 	"github.com/matrixorigin/matrixcube/transport"
 	"github.com/matrixorigin/matrixcube/util"
+	"github.com/matrixorigin/matrixcube/util/hlc"
+	"github.com/matrixorigin/matrixcube/util/watchdog"
 	"go.etcd.io/etcd/raft/v3/raftpb"
 	"go.uber.org/zap"
 )
@@ -67,6 +77,8 @@ type Store interface {
 	DataStorageByGroup(uint64) storage.DataStorage
 	// MaybeLeader returns the shard replica maybe leader
 	MaybeLeader(uint64) bool
+	// GetLeaderShards returns the shards whose leader replica is on the store
+	GetLeaderShards() []Shard
 	// MustAllocID returns an uint64 id, panic if it has an error
 	MustAllocID() uint64
 	// Prophet return current prophet instance
@@ -78,6 +90,58 @@ type Store interface {
 	CreateShardPool(...metapb.ShardPoolJobMeta) (ShardsPool, error)
 	// GetShardPool returns `ShardsPool`, nil if `CreateShardPool` not completed
 	GetShardPool() ShardsPool
+
+	// ReserveSystemKeyspace reserves the keyspace of the name in the system shard
+	// group, the requests to the keyspace use `config.SystemGroup` as the shard
+	// group and the keys in `SystemKeyspace.Range()` as the route keys. Returns
+	// ErrKeyspaceReserved if the name is already reserved on the store.
+	ReserveSystemKeyspace(name string) (SystemKeyspace, error)
+
+	// Ready returns nil if the store is started and the prophet leader is known,
+	// otherwise returns the reason why the store is not ready to serve.
+	Ready() error
+	// DrainLeaders transfers the leaders of the shards on the store to the other
+	// voters until no leader left or the context is done. The shards without any
+	// other voter are ignored. It's used to stop the store gracefully.
+	DrainLeaders(ctx context.Context) error
+	// FaultInjector returns the FaultInjector of the store, which is disabled
+	// unless `Config.EnableFaultInjection` is set.
+	FaultInjector() FaultInjector
+	// ArchiveShard writes the data of the shard whose leader is on the store
+	// into its archive, and then removes the shard from the cluster, only the
+	// metadata of the shard is kept by prophet. The writes to the shard are
+	// rejected during the archiving.
+	ArchiveShard(ctx context.Context, shardID uint64, m *backup.Manager) error
+	// UnarchiveShard recreates the archived shard with the same range, and
+	// returns the new shard once it has a leader. The data of the archive is
+	// loaded into the new shard by `client.LoadArchive`.
+	UnarchiveShard(ctx context.Context, shardID uint64, m *backup.Manager) (Shard, error)
+	// EnableDegradedRead allows the replica of the shard on the store to serve
+	// the reads with its applied state while the shard has no leader, e.g. the
+	// quorum of the shard is lost. The responses are flagged by
+	// `rpcpb.Response.Stale`, as the writes committed by the other replicas may
+	// be missing. It's enabled explicitly by the operator to keep the read-mostly
+	// workloads available during the repair, and the reads are sent to the
+	// replica by `rpcpb.SelectRandom`. The mode is not persisted, and kept until
+	// DisableDegradedRead is called, the reads are served by the leader as usual
+	// once the shard has a leader again.
+	EnableDegradedRead(shardID uint64) error
+	// DisableDegradedRead disables the degraded read mode of the shard
+	DisableDegradedRead(shardID uint64)
+	// PausedShards returns the shards whose apply is paused on the store. The
+	// apply of a shard is paused instead of crashing the store if the disk is
+	// full, the requests to the shard are rejected as busy, and the apply is
+	// resumed automatically once the space is reclaimed.
+	PausedShards() []PausedShard
+	// TuningAdvices returns the configuration changes recommended by the tuning
+	// advisor of the write path, e.g. raising the memtable size or moving the WAL
+	// to another device. The stats of the storages are sampled every minute, and
+	// the advices of the last sample are returned, which are also logged once.
+	TuningAdvices() []advisor.Advice
+	// StalledTasks returns the critical loops of the store found stalled by the
+	// watchdog, e.g. a raft worker blocked on an apply, empty if the watchdog is
+	// disabled.
+	StalledTasks() []string
 }
 
 type store struct {
@@ -90,21 +154,33 @@ type store struct {
 	bootOnce   sync.Once
 	pdStartedC chan struct{}
 
-	kvStorage             storage.KVStorage
-	logdb                 logdb.LogDB
-	trans                 transport.Trans
-	shardsProxy           ShardsProxy
-	router                Router
-	splitChecker          *splitChecker
-	watcher               prophet.EventWatcher
-	vacuumCleaner         *vacuumCleaner
+	kvStorage     storage.KVStorage
+	logdb         logdb.LogDB
+	trans         transport.Trans
+	shardsProxy   ShardsProxy
+	router        Router
+	splitChecker  *splitChecker
+	watcher       prophet.EventWatcher
+	vacuumCleaner *vacuumCleaner
+	snapshots     *snapshotManager
+	// keyspaces the keyspaces refreshed from prophet
+	keyspaces        *core.KeyspaceCache
+	metricSink       metric.Sink
+	storageCollector *metric.StorageCollector
+	// advisor the tuning advisor of the write path
+	advisor *advisor.Advisor
+	// watchdog monitors the critical loops, nil if the watchdog is disabled
+	watchdog              *watchdog.Watchdog
 	createShardsProtector *createShardsProtector
 	keyRanges             sync.Map // group id -> *util.ShardTree
 	replicaRecords        sync.Map // replica id -> metapb.Replica
 	replicas              sync.Map // shard id -> *replica
+	lazyReplicas          sync.Map // shard id -> *lazyReplica
 	droppedVoteMsgs       sync.Map // shard id -> raftpb.Message
+	walShippers           sync.Map // group id -> *walShipper
 
 	state    uint32
+	started  uint32
 	stopOnce sync.Once
 
 	aware   aware.ShardStateAware
@@ -116,38 +192,86 @@ type store struct {
 	groupController *replicaGroupController
 
 	storageStatsReader storageStatsReader
+	shardMetrics       *shardMetricsCollector
+	clock              *clockMonitor
+	faults             *faultInjector
+	requestLogger      *requestLogger
+	hlcClock           hlc.Clock
+	systemKeyspaces    *systemKeyspaces
 
 	mu struct {
 		sync.RWMutex
 		unavailableShards *roaring64.Bitmap
+		// archivingShards the shards being archived by the store, the writes
+		// to them are rejected
+		archivingShards *roaring64.Bitmap
+		// degradedReadShards the shards served by the replicas on the store
+		// without the leader
+		degradedReadShards *roaring64.Bitmap
+		// trashedGroups the dropped shard groups refreshed from prophet, the
+		// requests to them are rejected
+		trashedGroups map[uint64]struct{}
 	}
 }
 
 // NewStore returns a raft store
 func NewStore(cfg *config.Config) Store {
 	cfg.Adjust()
-	kv := pebble.CreateLogDBStorage(cfg.DataPath, cfg.FS, cfg.Logger)
 	logger := cfg.Logger.Named("store").With(zap.String("store", cfg.Prophet.Name))
+	checkStorageDevices(cfg, logger)
+	kv := pebble.CreateLogDBStorageWithOptions(cfg.DataPath, cfg.FS, cfg.Logger,
+		pebble.LogDBOptions{
+			WALDir:            cfg.Storage.WALDir,
+			DisableWALRecycle: cfg.Storage.DisableWALRecycle,
+		})
+	if err := migration.Run(cfg.Customize.CustomMigrationRegistry, migration.Options{
+		KVStorage:   kv,
+		DataStorage: cfg.Storage.DataStorageFactory,
+		Logger:      logger,
+	}); err != nil {
+		logger.Fatal("failed to migrate the store data",
+			zap.Error(err))
+	}
+	ldb := logdb.NewKVLogDB(kv, logger.Named("logdb"),
+		logdb.WithGroupCommit(cfg.Raft.RaftLog.GroupCommitWindow.Duration,
+			cfg.Raft.RaftLog.GroupCommitMaxBatch))
 	s := &store{
 		kvStorage:             kv,
 		meta:                  metapb.Store{},
 		cfg:                   cfg,
 		logger:                logger,
-		logdb:                 logdb.NewKVLogDB(kv, logger.Named("logdb")),
+		logdb:                 ldb,
 		stopper:               syncutil.NewStopper(),
 		createShardsProtector: newCreateShardsProtector(),
 		groupController:       newReplicaGroupController(),
+		shardMetrics:          newShardMetricsCollector(),
+		clock:                 newClockMonitor(cfg.Replication.MaxClockOffset.Duration),
+		faults:                newFaultInjector(cfg.EnableFaultInjection, logger.Named("fault-injection")),
+		systemKeyspaces:       newSystemKeyspaces(reservedKeyspaces...),
+		advisor:               advisor.NewAdvisor(advisor.Options{}),
+		watchdog:              newStoreWatchdog(cfg.Watchdog, logger),
 	}
 
+	s.hlcClock = cfg.Customize.CustomClock
+	s.requestLogger = newRequestLogger(cfg.RequestLog, cfg.Customize.CustomRequestLogRedactFunc,
+		cfg.Customize.CustomCommandRegistry, logger.Named("request-log"))
 	s.vacuumCleaner = newVacuumCleaner(s.vacuum)
+	s.keyspaces = core.NewKeyspaceCache()
+	s.snapshots = newSnapshotManager(logger.Named("snapshot-manager"), cfg.Snapshot, cfg.FS,
+		cfg.FS.PathJoin(cfg.DataPath, snapshotDirName), s.hasReplica)
 	// TODO: make maxWaitToChecker configurable
-	s.splitChecker = newSplitChecker(4, &storeReplicaGetter{s},
-		func(group uint64) storage.Feature {
-			return s.cfg.Storage.DataStorageFactory(group).Feature()
-		}, func(group uint64) splitCheckFunc {
-			return s.cfg.Storage.DataStorageFactory(group).SplitCheck
+	s.splitChecker = newSplitChecker(4, int(s.cfg.Worker.SplitCheckWorkers), &storeReplicaGetter{s},
+		s.getSplitFeature, func(group uint64) splitCheckFunc {
+			ds := s.cfg.Storage.DataStorageFactory(group)
+			if checker, ok := ds.(storage.ContextSplitChecker); ok {
+				return checker.SplitCheckWithContext
+			}
+			return func(_ context.Context, shard Shard, size uint64) (uint64, uint64, [][]byte, []byte, error) {
+				return ds.SplitCheck(shard, size)
+			}
 		})
 	s.workerPool = newWorkerPool(s.logger, s.logdb, &storeReplicaLoader{s}, s.cfg.Worker.RaftEventWorkers)
+	s.workerPool.watchdog = s.watchdog
 	s.shardPool = newDynamicShardsPool(cfg, s.logger)
 
 	if s.cfg.Customize.CustomShardStateAwareFactory != nil {
@@ -161,6 +285,8 @@ func NewStore(cfg *config.Config) Store {
 	}
 
 	s.mu.unavailableShards = roaring64.New()
+	s.mu.archivingShards = roaring64.New()
+	s.mu.degradedReadShards = roaring64.New()
 	return s
 }
 
@@ -168,8 +294,17 @@ func (s *store) GetConfig() *config.Config {
 	return s.cfg
 }
 
+func (s *store) FaultInjector() FaultInjector {
+	return s.faults
+}
+
 func (s *store) Start() {
 	s.logger.Info("begin to start raftstore")
+	s.startClock()
+	s.startMetricSink()
+	if s.watchdog != nil {
+		s.watchdog.Start()
+	}
 	s.workerPool.start()
 	s.logger.Info("worker pool started",
 		s.storeField())
@@ -194,6 +329,10 @@ func (s *store) Start() {
 	s.logger.Info("shards started",
 		s.storeField())
 
+	// no snapshot is received before the transport started, all the snapshot
+	// dirs not belongs to the started replicas can be removed
+	s.handleSnapshotGCTask(0)
+
 	s.startTransport()
 	s.logger.Info("raft internal transport started",
 		s.storeField(),
@@ -203,6 +342,8 @@ func (s *store) Start() {
 	s.logger.Info("shard timer based tasks started",
 		s.storeField())
 
+	s.registerStorageCollector()
+
 	s.startRouter()
 	s.logger.Info("router started",
 		s.storeField())
@@ -213,6 +354,34 @@ func (s *store) Start() {
 		log.ListenAddressField(s.cfg.ClientAddr))
 
 	s.handleStoreHeartbeatTask(time.Now())
+	atomic.StoreUint32(&s.started, 1)
+}
+
+func (s *store) Ready() error {
+	if atomic.LoadUint32(&s.state) == 1 {
+		return ErrStoreStopped
+	}
+	if atomic.LoadUint32(&s.started) == 0 {
+		return ErrStoreNotStarted
+	}
+	if s.pd.GetLeader() == nil {
+		return ErrNoProphetLeader
+	}
+	return nil
+}
+
+// startClock starts the HLC clock based on the local wall clock if no custom
+// clock specified, the clock is used to assign timestamps to the proposals.
+func (s *store) startClock() {
+	if s.hlcClock != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.hlcClock = hlc.NewUnixNanoHLCClock(ctx, s.cfg.Replication.MaxClockOffset.Duration)
+	s.stopper.RunWorker(func() {
+		<-s.stopper.ShouldStop()
+		cancel()
+	})
 }
 
 func (s *store) Stop() {
@@ -242,6 +411,10 @@ func (s *store) Stop() {
 		// stop the router first to prevent any new replica to be created when
 		// closing replicas.
 		s.router.Stop()
+		// the events are not consumed after the router stopped
+		if s.watcher != nil {
+			s.watcher.Close()
+		}
 		s.logger.Info("store router stopped",
 			s.storeField())
 
@@ -271,6 +444,12 @@ func (s *store) Stop() {
 		s.logger.Info("stopper stopped",
 			s.storeField())
 
+		if s.watchdog != nil {
+			s.watchdog.Stop()
+		}
+		s.logger.Info("watchdog stopped",
+			s.storeField())
+
 		if err := s.shardsProxy.Stop(); err != nil {
 			s.logger.Fatal("stop shards proxt failed",
 				s.storeField(),
@@ -279,16 +458,72 @@ func (s *store) Stop() {
 		s.logger.Info("proxy stopped",
 			s.storeField())
 
+		if s.storageCollector != nil {
+			metric.UnregisterStorageCollector(s.storageCollector)
+		}
 		s.kvStorage.Close()
 		s.logger.Info("kvStorage closed")
+
+		if s.metricSink != nil {
+			s.metricSink.Stop()
+			s.logger.Info("metric sink stopped",
+				s.storeField())
+		}
 	})
 }
 
+func (s *store) startMetricSink() {
+	sink, err := metric.NewSink(s.cfg.Metric, s.logger)
+	if err == nil {
+		err = sink.Start()
+	}
+	if err != nil {
+		s.logger.Fatal("fail to start metric sink",
+			s.storeField(),
+			zap.Error(err))
+	}
+	s.metricSink = sink
+}
+
+// registerStorageCollector exports the stats of the data storages, the store ID
+// is only known after the prophet started.
+func (s *store) registerStorageCollector() {
+	c := metric.NewStorageCollector(s.Meta().ID, func() map[uint64]stats.Stats {
+		values := make(map[uint64]stats.Stats)
+		s.cfg.Storage.ForeachDataStorageFunc(func(group uint64, db storage.DataStorage) {
+			values[group] = db.Stats()
+		})
+		return values
+	})
+	// the collector of the same store is left by another store in the same
+	// process, e.g. the tests, the metrics are not exported in that case
+	if err := metric.RegisterStorageCollector(c); err != nil {
+		s.logger.Warn("fail to register storage collector",
+			s.storeField(),
+			zap.Error(err))
+		return
+	}
+	s.storageCollector = c
+}
+
 func (s *store) GetReplicaSnapshotDir(shardID uint64, replicaID uint64) string {
 	dir := fmt.Sprintf("shard-%d-replica-%d", shardID, replicaID)
 	return s.cfg.FS.PathJoin(s.cfg.DataPath, snapshotDirName, dir)
 }
 
+// hasReplica returns true if the replica is on the store, the lazy replicas are
+// not opened.
+func (s *store) hasReplica(shardID uint64, replicaID uint64) bool {
+	if value, ok := s.replicas.Load(shardID); ok {
+		return value.(*replica).replicaID == replicaID
+	}
+	if value, ok := s.lazyReplicas.Load(shardID); ok {
+		r := findReplica(value.(*lazyReplica).shard, s.Meta().ID)
+		return r != nil && r.ID == replicaID
+	}
+	return false
+}
+
 func (s *store) GetShardsProxy() ShardsProxy {
 	return s.shardsProxy
 }
@@ -304,8 +539,13 @@ func (s *store) startRouter() {
 			s.storeField(),
 			zap.Error(err))
 	}
-	r, err := newRouterBuilder().
+	rb := newRouterBuilder().
 		withLogger(s.logger).
+		withUnreachableStoreTimeout(s.cfg.Locality.UnreachableStoreTimeout.Duration)
+	if s.cfg.Locality.Enable {
+		rb = rb.withLocality(s.cfg.Locality.ZoneLabel, s.cfg.Locality.Zone)
+	}
+	r, err := rb.
 		withCreatShardHandle(func(shard Shard) {
 			s.doDynamicallyCreate(shard)
 		}).
@@ -338,11 +578,21 @@ func (s *store) OnRequest(req rpcpb.Request) error {
 }
 
 func (s *store) OnRequestWithCB(req rpcpb.Request, cb func(resp rpcpb.ResponseBatch)) error {
+	cb = s.requestLogger.wrap(req, cb)
 	if ce := s.logger.Check(zap.DebugLevel, "receive request"); ce != nil {
 		ce.Write(log.RequestIDField(req.ID),
 			s.storeField())
 	}
 
+	if err := validateUserKeys(req); err != nil {
+		respOtherError(err, req, cb)
+		return nil
+	}
+	if err := s.validateCommand(req); err != nil {
+		respOtherError(err, req, cb)
+		return nil
+	}
+
 	var pr *replica
 	var err error
 	if req.ToShard > 0 {
@@ -382,6 +632,20 @@ func (s *store) OnRequestWithCB(req rpcpb.Request, cb func(resp rpcpb.ResponseBa
 		}
 	}
 
+	// the merging shard is frozen until merged into the target shard
+	if req.Type != rpcpb.Admin &&
+		pr.getShard().State == metapb.ShardState_Merging {
+		respShardUnavailable(pr.shardID, req, cb)
+		return nil
+	}
+
+	// the shards of the dropped group are kept until purged by prophet
+	if req.Type != rpcpb.Admin &&
+		s.isShardGroupTrashed(pr.getShard().Group) {
+		respShardUnavailable(pr.shardID, req, cb)
+		return nil
+	}
+
 	if req.ReplicaSelectPolicy == rpcpb.SelectLeaseHolder {
 		if req.Lease == nil {
 			s.logger.Fatal("missing lease when use SelectLeaseHolder")
@@ -403,6 +667,11 @@ func (s *store) OnRequestWithCB(req rpcpb.Request, cb func(resp rpcpb.ResponseBa
 			respLeaseReadNotReady(req, cb)
 			return nil
 		}
+		// the lease may be expired on the other stores
+		if s.clock.isSkewed() {
+			respLeaseReadNotReady(req, cb)
+			return nil
+		}
 
 		if err := s.cfg.Customize.CustomLeaseHolderRequestHandler(pr.getShard(),
 			*req.Lease,
@@ -420,6 +689,36 @@ func (s *store) OnRequestWithCB(req rpcpb.Request, cb func(resp rpcpb.ResponseBa
 		return nil
 	}
 
+	if (req.Type == rpcpb.Write || req.Type == rpcpb.Txn) && s.isShardArchiving(pr.getShardID()) {
+		respShardUnavailable(pr.getShardID(), req, cb)
+		return nil
+	}
+
+	if busy, ok := pr.checkBusy(req); ok {
+		respServerIsBusy(busy, req, cb)
+		return nil
+	}
+
+	if busy, ok := pr.checkShardBusy(req); ok {
+		respShardBusy(busy, req, cb)
+		return nil
+	}
+
+	if busy, ok := pr.checkWALBusy(req); ok {
+		respServerIsBusy(busy, req, cb)
+		return nil
+	}
+
+	if busy, ok := pr.checkDiskFull(req); ok {
+		respServerIsBusy(busy, req, cb)
+		return nil
+	}
+
+	if req.Type != rpcpb.Admin && s.faults.shouldRejectGroup(pr.getShard().Group) {
+		respServerIsBusy(&errorpb.ServerIsBusy{}, req, cb)
+		return nil
+	}
+
 	if err := pr.onReq(req, cb); err != nil {
 		if s.isShardUnavailable(pr.getShardID()) {
 			respShardUnavailable(pr.getShardID(), req, cb)
@@ -434,10 +733,59 @@ func (s *store) DataStorageByGroup(group uint64) storage.DataStorage {
 	return s.cfg.Storage.DataStorageFactory(group)
 }
 
+// getShardFeature returns the feature of the shard group, the split thresholds of
+// the data storage are overridden by the shard size class of the group.
+func (s *store) getShardFeature(group uint64) storage.Feature {
+	feature := s.DataStorageByGroup(group).Feature()
+	class, ok := s.cfg.Prophet.Replication.GetShardSizeClass(group)
+	if !ok {
+		return feature
+	}
+
+	if class.ShardCapacityBytes > 0 {
+		feature.ShardCapacityBytes = uint64(class.ShardCapacityBytes)
+		feature.ShardSplitCheckBytes = feature.ShardCapacityBytes * 80 / 100
+	}
+	if class.ShardSplitCheckBytes > 0 {
+		feature.ShardSplitCheckBytes = uint64(class.ShardSplitCheckBytes)
+	}
+	return feature
+}
+
+// getSplitFeature returns the feature used to split the shard, the capacity of
+// the keyspace of the shard is used if it's set.
+func (s *store) getSplitFeature(shard Shard) storage.Feature {
+	feature := s.getShardFeature(shard.Group)
+	if keyspace, ok := s.getKeyspace(shard); ok && keyspace.ShardCapacityBytes > 0 {
+		feature.ShardCapacityBytes = keyspace.ShardCapacityBytes
+		feature.ShardSplitCheckBytes = feature.ShardCapacityBytes * 80 / 100
+	}
+	return feature
+}
+
+// getKeyspace returns the keyspace which contains the shard
+func (s *store) getKeyspace(shard Shard) (metapb.Keyspace, bool) {
+	if s.keyspaces == nil {
+		return metapb.Keyspace{}, false
+	}
+	return s.keyspaces.GetByRange(shard.Group, shard.Start, shard.End)
+}
+
 func (s *store) MaybeLeader(shard uint64) bool {
 	return nil != s.getReplica(shard, true)
 }
 
+func (s *store) GetLeaderShards() []Shard {
+	var shards []Shard
+	s.forEachReplica(func(pr *replica) bool {
+		if pr.isLeader() {
+			shards = append(shards, pr.getShard())
+		}
+		return true
+	})
+	return shards
+}
+
 func (s *store) MustAllocID() uint64 {
 	for {
 		id, err := s.pd.GetClient().AllocID()
@@ -466,9 +814,17 @@ func (s *store) startProphet() {
 }
 
 func (s *store) createTransport() {
+	opts := []transport.Option{transport.WithMessageBatch(s.cfg.Raft.MessageBatchWindow.Duration,
+		uint64(s.cfg.Raft.MaxMessageBatchSize))}
+	if s.cfg.Raft.EnableUDPHeartbeat {
+		opts = append(opts, transport.WithUDPHeartbeat())
+	}
+	opts = append(opts, transport.WithSnapshotFormatResolver(s.snapshotFormatResolver))
+	opts = append(opts, transport.WithSnapshotSpaceChecker(s.snapshotAvailableSpace))
+	opts = append(opts, transport.WithMaxConcurrentSnapshots(s.cfg.Snapshot.MaxConcurrentTransfers))
 	s.trans = transport.NewTransport(s.logger,
 		s.cfg.RaftAddr, s.Meta().ID, s.handle, s.unreachable, s.snapshotStatus,
-		s.GetReplicaSnapshotDir, s.containerResolver, s.cfg.FS)
+		s.GetReplicaSnapshotDir, s.containerResolver, s.cfg.FS, opts...)
 	if s.cfg.Customize.CustomWrapNewTransport != nil {
 		s.trans = s.cfg.Customize.CustomWrapNewTransport(s.trans)
 	}
@@ -557,14 +913,30 @@ func (s *store) startShards() {
 	}
 
 	var readyBootstrapShards []Shard
+	var lazyShards []Shard
 	leases := make(map[uint64]*metapb.EpochLease)
 	for _, sls := range shards {
-		readyBootstrapShards = append(readyBootstrapShards, sls.Shard)
 		leases[sls.Shard.ID] = sls.Lease
+		if _, ok := localDestroyings[sls.Shard.ID]; !ok &&
+			s.cfg.Replication.LazyOpenReplica &&
+			s.isLazyOpenCandidate(sls.Shard, sls.Lease) {
+			lazyShards = append(lazyShards, sls.Shard)
+			continue
+		}
+		readyBootstrapShards = append(readyBootstrapShards, sls.Shard)
 	}
 
+	started := int64(0)
+	total := len(readyBootstrapShards)
+	metric.SetShardsStartProgress(0, total)
 	newReplicaCreator(s).
 		withReason("restart").
+		withParallelStart(int(s.cfg.Worker.ReplicaStartWorkers), func(shard Shard) bool {
+			// the replica held the lease was the leader before restart
+			lease := leases[shard.ID]
+			r := findReplica(shard, s.Meta().ID)
+			return lease != nil && r != nil && lease.ReplicaID == r.ID
+		}).
 		withStartReplica(true,
 			func(r *replica) {
 				r.sm.updateLease(leases[r.shardID])
@@ -573,8 +945,17 @@ func (s *store) startShards() {
 				if metadata, ok := localDestroyings[r.shardID]; ok {
 					r.startDestroyReplicaTask(metadata.LogIndex, metadata.Metadata.RemoveData, "restart")
 				}
+				n := atomic.AddInt64(&started, 1)
+				metric.SetShardsStartProgress(int(n), total)
+				if n%1000 == 0 {
+					s.logger.Info("shards starting",
+						s.storeField(),
+						zap.Int64("started", n),
+						zap.Int("total", total))
+				}
 			}).
 		create(readyBootstrapShards)
+	s.addLazyReplicas(lazyShards, leases)
 
 	s.cleanupTombstones(tombstones)
 
@@ -582,6 +963,7 @@ func (s *store) startShards() {
 		s.storeField(),
 		zap.Int("total", totalCount),
 		zap.Int("bootstrap", len(readyBootstrapShards)),
+		zap.Int("lazy", len(lazyShards)),
 		zap.Int("tombstone", tombstoneCount))
 }
 
@@ -641,16 +1023,21 @@ func (s *store) forEachReplica(consumerFunc func(*replica) bool) {
 }
 
 func (s *store) getReplica(id uint64, mustLeader bool) *replica {
-	if value, ok := s.replicas.Load(id); ok {
-		pr := value.(*replica)
-		if mustLeader && !pr.isLeader() {
-			return nil
+	for {
+		if value, ok := s.replicas.Load(id); ok {
+			pr := value.(*replica)
+			if mustLeader && !pr.isLeader() {
+				return nil
+			}
+
+			return pr
 		}
 
-		return pr
+		// the lazy replica is opened on the first access
+		if !s.openLazyReplica(id) {
+			return nil
+		}
 	}
-
-	return nil
 }
 
 // In some case, the vote raft msg maybe dropped, so follower node can't respond the vote msg
@@ -686,6 +1073,29 @@ func (s *store) validateStoreID(req rpcpb.RequestBatch) error {
 	return nil
 }
 
+// validateUserKeys rejects the writes to the keys reserved by cube
+func validateUserKeys(req rpcpb.Request) error {
+	if req.Type != rpcpb.Write && req.Type != rpcpb.Txn {
+		return nil
+	}
+	if err := keys.ValidateUserKey(req.Key); err != nil {
+		return err
+	}
+	if req.KeysRange != nil {
+		return keys.ValidateUserKeyRange(req.KeysRange.From, req.KeysRange.To)
+	}
+	return nil
+}
+
+// validateCommand rejects the requests with the unknown custom types if the
+// command registry is set
+func (s *store) validateCommand(req rpcpb.Request) error {
+	if s.cfg.Customize.CustomCommandRegistry == nil {
+		return nil
+	}
+	return s.cfg.Customize.CustomCommandRegistry.Validate(req)
+}
+
 func (s *store) validateShard(req rpcpb.RequestBatch) (errorpb.Error, bool) {
 	shardID := req.Header.ShardID
 	replicaID := req.Header.Replica.ID
@@ -700,7 +1110,7 @@ func (s *store) validateShard(req rpcpb.RequestBatch) (errorpb.Error, bool) {
 		}, true
 	}
 
-	if !pr.isLeader() {
+	if !pr.isLeader() && !pr.canFollowerRead(req) {
 		err := new(errorpb.NotLeader)
 		err.ShardID = shardID
 		err.Leader, _ = s.getReplicaRecord(pr.getLeaderReplicaID())
@@ -735,6 +1145,10 @@ func (s *store) validateShard(req rpcpb.RequestBatch) (errorpb.Error, bool) {
 		}, true
 	}
 
+	if !checkExpectedEpoch(shard, req) {
+		return newEpochNotMatchError(shard, *req.Requests[0].ExpectedEpoch), true
+	}
+
 	return errorpb.Error{}, false
 }
 
@@ -748,7 +1162,8 @@ func checkEpoch(shard Shard, req rpcpb.RequestBatch) bool {
 			checkVer = true
 		case rpcpb.CmdConfigChange:
 			checkConfVer = true
-		case rpcpb.CmdTransferLeader:
+		case rpcpb.CmdTransferLeader, rpcpb.CmdPrepareMerge, rpcpb.CmdCommitMerge,
+			rpcpb.CmdRollbackMerge:
 			checkVer = true
 			checkConfVer = true
 		}
@@ -776,6 +1191,23 @@ func checkEpoch(shard Shard, req rpcpb.RequestBatch) bool {
 		!isStale(req.Requests[0].Epoch)
 }
 
+// checkExpectedEpoch returns false if the writes expect an epoch other than the
+// current epoch of the shard. Unlike checkEpoch, both the generation and the
+// conf version must be equal, so the writes routed before the shard is split,
+// merged or its replicas are changed are rejected instead of being applied to
+// the new range.
+func checkExpectedEpoch(shard Shard, req rpcpb.RequestBatch) bool {
+	if len(req.Requests) == 0 || req.IsAdmin() ||
+		req.Requests[0].Type == rpcpb.Read {
+		return true
+	}
+
+	// only check first request, the requests expecting the different epochs are
+	// not batched
+	expected := req.Requests[0].ExpectedEpoch
+	return expected == nil || epochMatch(*expected, shard.Epoch)
+}
+
 func newAdminResponseBatch(adminType rpcpb.InternalCmd, rsp protoc.PB) rpcpb.ResponseBatch {
 	return rpcpb.ResponseBatch{
 		Responses: []rpcpb.Response{
@@ -815,12 +1247,12 @@ func (s *store) selectShard(group uint64, key []byte) (*replica, error) {
 		return nil, errStoreNotMatch
 	}
 
-	pr, ok := s.replicas.Load(shard.ID)
-	if !ok {
+	pr := s.getReplica(shard.ID, false)
+	if pr == nil {
 		return nil, errStoreNotMatch
 	}
 
-	return pr.(*replica), nil
+	return pr, nil
 }
 
 func (s *store) searchShard(group uint64, key []byte) Shard {
@@ -857,6 +1289,19 @@ func (s *store) containerResolver(storeID uint64) (string, error) {
 	return container.GetRaftAddress(), nil
 }
 
+// snapshotFormatResolver returns the snapshot format advertised by the store,
+// the stores before the format negotiation advertise nothing.
+func (s *store) snapshotFormatResolver(storeID uint64) (uint64, error) {
+	container, err := s.pd.GetStorage().GetStore(storeID)
+	if err != nil {
+		return 0, err
+	}
+	if container == nil {
+		return transport.SnapshotFormatRaw, nil
+	}
+	return container.GetSnapshotFormat(), nil
+}
+
 func (s *store) unreachable(shardID uint64, replicaID uint64) {
 	if pr := s.getReplica(shardID, true); pr != nil {
 		pr.addFeedback(replicaID)
@@ -878,7 +1323,8 @@ func (s *store) snapshotStatus(shardID uint64,
 		select {
 		case <-timer.C:
 			if pr := s.getReplica(shardID, true); pr != nil {
-				pr.addSnapshotStatus(snapshotStatus{to: replicaID, rejected: rejected})
+				pr.addSnapshotStatus(snapshotStatus{to: replicaID,
+					index: ss.Metadata.Index, rejected: rejected})
 				if err := pr.removeSnapshot(ss, false); err != nil {
 					s.logger.Error("remove snapshot failed",
 						s.storeField(),
@@ -897,7 +1343,7 @@ func (s *store) getReplicaCount() uint64 {
 		n++
 		return true
 	})
-	return n
+	return n + s.getLazyReplicaCount()
 }
 
 func (s *store) isShardUnavailable(id uint64) bool {
@@ -907,6 +1353,24 @@ func (s *store) isShardUnavailable(id uint64) bool {
 	return s.mu.unavailableShards.Contains(id)
 }
 
+func (s *store) isShardGroupTrashed(group uint64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.mu.trashedGroups[group]
+	return ok
+}
+
+func (s *store) setTrashedShardGroups(groups []rpcpb.TrashedShardGroup) {
+	trashed := make(map[uint64]struct{}, len(groups))
+	for _, g := range groups {
+		trashed[g.Group] = struct{}{}
+	}
+	s.mu.Lock()
+	s.mu.trashedGroups = trashed
+	s.mu.Unlock()
+}
+
 func (s *store) addUnavailableShard(id uint64) {
 	s.mu.Lock()
 	s.mu.unavailableShards.Add(id)
@@ -954,6 +1418,11 @@ func (s *store) getStoreHeartbeat(last time.Time) (rpcpb.StoreHeartbeatReq, erro
 		// If `Capacity` set, calculate `Available` using `Capacity`
 		stats.Available = stats.Capacity - stats.UsedSize
 	}
+	// report the low space to prophet once the apply of any shard is paused by
+	// the disk full, so no more replica is scheduled to the store
+	if s.hasDiskFullShards() {
+		stats.Available = 0
+	}
 
 	// cpu usages
 	usages, err := util.CPUUsages()
@@ -996,12 +1465,25 @@ func (s *store) getStoreHeartbeat(last time.Time) (rpcpb.StoreHeartbeatReq, erro
 		//}
 
 		stats.ShardCount++
+		if readBytes, readKeys := pr.readLoad.collect(); readKeys > 0 {
+			stats.ReplicaReadStats = append(stats.ReplicaReadStats, metapb.ReplicaReadStats{
+				ShardID:   pr.shardID,
+				ReplicaID: pr.replicaID,
+				ReadBytes: readBytes,
+				ReadKeys:  readKeys,
+			})
+		}
 		return true
 	})
-	// FIXME: provide this count from the new implementation
-	// stats.ReceivingSnapCount = s.snapshotManager.ReceiveSnapCount()
+	stats.ShardCount += s.getLazyReplicaCount()
+	stats.ReceivingSnapCount = s.trans.ReceivingSnapshotCount()
 	stats.SendingSnapCount = s.trans.SendingSnapshotCount()
+	stats.MaxSnapshotCount = s.cfg.Snapshot.MaxConcurrentTransfers
 	stats.StartTime = uint64(s.Meta().StartTime)
+	stats.ClockOffset = int64(s.clock.getOffset())
+	stats.ClockSkewed = s.clock.isSkewed()
+	stats.MaxReplicaCount = s.cfg.Replication.MaxReplicaCount
+	stats.MaxLeaderCount = s.cfg.Replication.MaxLeaderCount
 
 	s.cfg.Storage.ForeachDataStorageFunc(func(_ uint64, db storage.DataStorage) {
 		st := db.Stats()
@@ -1121,7 +1603,36 @@ func (s *store) doShardHeartbeatRsp(rsp rpcpb.ShardHeartbeatRsp) {
 				},
 			})
 		}
+	} else if rsp.Merge != nil {
+		if rsp.Merge.Rollback {
+			var source Shard
+			protoc.MustUnmarshal(&source, rsp.Merge.Source)
+			pr.doRollbackMerge(source)
+		} else if len(rsp.Merge.Source) > 0 {
+			var source, target Shard
+			protoc.MustUnmarshal(&source, rsp.Merge.Source)
+			protoc.MustUnmarshal(&target, rsp.Merge.Target)
+			pr.doCommitMerge(source, target)
+		} else {
+			var target Shard
+			protoc.MustUnmarshal(&target, rsp.Merge.Target)
+			pr.doPrepareMerge(target)
+		}
+	} else if rsp.CheckConsistency {
+		s.logger.Info("send compute hash request",
+			s.storeField(),
+			log.ShardIDField(rsp.ShardID))
+		pr.addAdminRequest(rpcpb.CmdComputeHash, &rpcpb.ComputeHashRequest{})
+	}
+}
+
+// snapshotAvailableSpace returns the available space for the received snapshots
+func (s *store) snapshotAvailableSpace() (uint64, error) {
+	v, err := s.storageStatsReader.stats()
+	if err != nil {
+		return 0, err
 	}
+	return v.available, nil
 }
 
 type storageStatsReader interface {
@@ -0,0 +1,73 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/advisor"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/stats"
+)
+
+// tuningAdviceSampleInterval the interval of sampling the stats of the storages
+// for the tuning advisor, the rates are computed over the interval
+const tuningAdviceSampleInterval = time.Minute
+
+// TuningAdvices returns the tuning advices of the write path of the store given
+// by the last sample
+func (s *store) TuningAdvices() []advisor.Advice {
+	return s.advisor.Advices()
+}
+
+// handleTuningAdviceTask samples the stats of the LogDB and the data storages,
+// and logs the advices not given by the previous sample, so an advice is only
+// logged once until the problem is gone.
+func (s *store) handleTuningAdviceTask(now time.Time) {
+	sample := advisor.Sample{
+		Time:  now,
+		LogDB: s.kvStorage.Stats(),
+		Data:  make(map[uint64]stats.Stats),
+	}
+	s.cfg.Storage.ForeachDataStorageFunc(func(group uint64, db storage.DataStorage) {
+		sample.Data[group] = db.Stats()
+	})
+
+	type adviceKey struct {
+		rule  string
+		group uint64
+	}
+	given := make(map[adviceKey]struct{})
+	for _, a := range s.advisor.Advices() {
+		given[adviceKey{a.Rule, a.Group}] = struct{}{}
+	}
+	for _, a := range s.advisor.Observe(sample, advisor.Settings{
+		WALDir:            s.cfg.Storage.WALDir,
+		GroupCommitWindow: s.cfg.Raft.RaftLog.GroupCommitWindow.Duration,
+	}) {
+		if _, ok := given[adviceKey{a.Rule, a.Group}]; ok {
+			continue
+		}
+		s.logger.Warn("tuning advice",
+			s.storeField(),
+			zap.String("rule", a.Rule),
+			zap.Uint64("group", a.Group),
+			zap.String("key", a.Key),
+			zap.String("current", a.Current),
+			zap.String("recommended", a.Recommended),
+			zap.String("reason", a.Reason))
+	}
+}
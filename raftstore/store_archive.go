@@ -0,0 +1,179 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/backup"
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/storage"
+)
+
+const (
+	archiveCheckInterval = 100 * time.Millisecond
+)
+
+var (
+	// ErrArchiveConflict the shard is written or its leader is changed during
+	// the archiving, the archive is dropped and the shard keeps serving.
+	ErrArchiveConflict = errors.New("shard changed during archiving")
+)
+
+func (s *store) ArchiveShard(ctx context.Context, shardID uint64, m *backup.Manager) error {
+	pr := s.getReplica(shardID, true)
+	if pr == nil {
+		return errNotLeader
+	}
+	shard := pr.getShard()
+	ds, ok := s.DataStorageByGroup(shard.Group).(storage.ShardBackuper)
+	if !ok {
+		return storage.ErrBackupNotSupported
+	}
+
+	s.setShardArchiving(shardID, true)
+	defer s.setShardArchiving(shardID, false)
+	// the writes accepted before the archiving must be applied, so they are in
+	// the archive
+	if err := s.waitShardQuiesced(ctx, pr); err != nil {
+		return err
+	}
+	archived, err := m.Archive(ctx, pr.getShard(), ds)
+	if err != nil {
+		return err
+	}
+	// a new leader appends an entry of its term, so the unchanged last index
+	// means no write is accepted by any replica since the archive is created
+	if last, err := pr.lr.LastIndex(); err != nil || !pr.isLeader() ||
+		last != archived.AppliedIndex {
+		s.dropArchive(m, shardID)
+		return ErrArchiveConflict
+	}
+	if err := s.pd.GetClient().AsyncRemoveShards(shardID); err != nil {
+		s.dropArchive(m, shardID)
+		return err
+	}
+	s.logger.Info("shard archived",
+		s.storeField(),
+		log.ShardField("shard", archived.Shard),
+		zap.Uint64("applied-index", archived.AppliedIndex))
+	return s.waitReplicaRemoved(ctx, shardID)
+}
+
+func (s *store) UnarchiveShard(ctx context.Context, shardID uint64, m *backup.Manager) (Shard, error) {
+	archived, err := m.GetArchive(shardID)
+	if err != nil {
+		return Shard{}, err
+	}
+	shard := Shard{
+		Start:      archived.Shard.Start,
+		End:        archived.Shard.End,
+		Group:      archived.Shard.Group,
+		Unique:     archived.Shard.Unique,
+		RuleGroups: archived.Shard.RuleGroups,
+		Labels:     archived.Shard.Labels,
+	}
+	// prophet creates the shard once by the unique
+	if shard.Unique == "" {
+		shard.Unique = backup.ArchiveName(shardID)
+	}
+	if err := s.pd.GetClient().AsyncAddShards(shard); err != nil {
+		return Shard{}, err
+	}
+
+	ticker := time.NewTicker(archiveCheckInterval)
+	defer ticker.Stop()
+	for {
+		created, leader := s.router.SelectShard(shard.Group, shard.Start)
+		if created.ID != shardID && created.Unique == shard.Unique && leader != "" {
+			s.logger.Info("shard unarchived",
+				s.storeField(),
+				log.ShardIDField(shardID),
+				log.ShardField("new-shard", created))
+			return created, nil
+		}
+		select {
+		case <-ctx.Done():
+			return Shard{}, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitShardQuiesced waits until all the logs of the shard are applied
+func (s *store) waitShardQuiesced(ctx context.Context, pr *replica) error {
+	ticker := time.NewTicker(archiveCheckInterval)
+	defer ticker.Stop()
+	for {
+		if !pr.isLeader() {
+			return errNotLeader
+		}
+		last, err := pr.lr.LastIndex()
+		if err != nil {
+			return err
+		}
+		if applied, _ := pr.sm.getAppliedIndexTerm(); applied == last {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *store) waitReplicaRemoved(ctx context.Context, shardID uint64) error {
+	ticker := time.NewTicker(archiveCheckInterval)
+	defer ticker.Stop()
+	for {
+		if _, ok := s.replicas.Load(shardID); !ok {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *store) dropArchive(m *backup.Manager, shardID uint64) {
+	if err := m.RemoveArchive(shardID); err != nil {
+		s.logger.Error("fail to remove the dropped archive",
+			s.storeField(),
+			log.ShardIDField(shardID),
+			zap.Error(err))
+	}
+}
+
+func (s *store) isShardArchiving(id uint64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mu.archivingShards.Contains(id)
+}
+
+func (s *store) setShardArchiving(id uint64, archiving bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if archiving {
+		s.mu.archivingShards.Add(id)
+	} else {
+		s.mu.archivingShards.Remove(id)
+	}
+}
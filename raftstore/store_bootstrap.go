@@ -14,13 +14,16 @@
 package raftstore
 
 import (
+	"errors"
 	"math"
 	"time"
 
 	"github.com/fagongzi/util/protoc"
+	"github.com/matrixorigin/matrixcube/components/prophet/util"
 	"github.com/matrixorigin/matrixcube/keys"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/transport"
 	"go.uber.org/zap"
 )
 
@@ -50,6 +53,7 @@ func (s *store) initMeta() {
 	s.meta.SetDeployPath(s.cfg.DeployPath)
 	s.meta.SetVersionAndCommitID(s.cfg.Version, s.cfg.GitHash)
 	s.meta.SetAddrs(s.cfg.AdvertiseClientAddr, s.cfg.AdvertiseRaftAddr)
+	s.meta.SetSnapshotFormat(transport.SnapshotFormatLatest)
 
 	s.logger.Info("store metadata init",
 		s.storeField(),
@@ -66,6 +70,7 @@ func (s *store) doBootstrapCluster(bootstrap bool) {
 	s.initMeta()
 
 	if s.mustLoadStoreMetadata() {
+		s.mustFenceStoreIdentity()
 		return
 	}
 
@@ -75,6 +80,11 @@ func (s *store) doBootstrapCluster(bootstrap bool) {
 	s.meta.SetID(s.MustAllocID())
 	s.Unlock()
 	s.mustSaveStoreMetadata()
+	s.mustSaveStoreIdentity(metapb.StoreIdent{
+		ClusterID: s.pd.GetClusterID(),
+		StoreID:   s.meta.GetID(),
+		Epoch:     1,
+	})
 	s.logger.Info("create local store",
 		s.storeField())
 
@@ -107,6 +117,12 @@ func (s *store) doBootstrapCluster(bootstrap bool) {
 				initShards = append(initShards, *shard)
 				resources = append(resources, shard)
 			}
+			if s.cfg.SystemGroup.Enable {
+				shard := newSystemGroupShard()
+				s.doCreateInitShard(&shard)
+				initShards = append(initShards, shard)
+				resources = append(resources, shard.Clone())
+			}
 
 			newReplicaCreator(s).
 				withReason("bootstrap init").
@@ -131,6 +147,7 @@ func (s *store) doBootstrapCluster(bootstrap bool) {
 
 func (s *store) postBootstrapped() {
 	s.mustPutStore()
+	s.mustPutSystemGroupRule()
 	s.startHandleShardHeartbeat()
 	close(s.pdStartedC)
 }
@@ -138,6 +155,11 @@ func (s *store) postBootstrapped() {
 func (s *store) mustPutStore() {
 	for {
 		if err := s.pd.GetClient().PutStore(s.meta); err != nil {
+			if errors.Is(err, util.ErrStaleStoreEpoch) {
+				s.logger.Fatal("store identity fenced by prophet, another incarnation of the store started",
+					s.storeField(),
+					zap.Error(err))
+			}
 			s.logger.Info("failed to put container to prophet",
 				s.storeField(),
 				zap.Error(err),
@@ -0,0 +1,84 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/metric"
+)
+
+func (s *store) handleClockOffset(sent, received time.Time, remote int64) {
+	changed := s.clock.update(sent, received, remote)
+	offset := s.clock.getOffset()
+	metric.SetClockOffsetOnStore(offset)
+	if !changed {
+		return
+	}
+	if s.clock.isSkewed() {
+		s.logger.Error("clock skewed, lease based reads disabled",
+			s.storeField(),
+			zap.Duration("offset", offset),
+			zap.Duration("max-offset", s.clock.maxOffset))
+	} else {
+		s.logger.Info("clock offset recovered, lease based reads enabled",
+			s.storeField(),
+			zap.Duration("offset", offset))
+	}
+}
+
+// clockMonitor measures the offset of the store's clock to the prophet leader's
+// clock by the store heartbeats. The prophet leader's clock is read at some
+// point between the heartbeat sent and the response received, so the offset is
+// measured against the middle of the round trip, and the half of the round trip
+// is the uncertainty of the measurement. The store is skewed only if the offset
+// exceeds the max offset regardless of the uncertainty, so that a slow round
+// trip doesn't disable the lease reads.
+type clockMonitor struct {
+	maxOffset time.Duration
+	offset    int64  // nanoseconds
+	skewed    uint32 // 1: skewed
+}
+
+func newClockMonitor(maxOffset time.Duration) *clockMonitor {
+	return &clockMonitor{maxOffset: maxOffset}
+}
+
+// update updates the offset by the heartbeat round trip and the prophet leader's
+// unix time in nanoseconds, returns true if the skewed state changed.
+func (m *clockMonitor) update(sent, received time.Time, remote int64) bool {
+	rtt := received.Sub(sent)
+	offset := time.Duration(sent.Add(rtt/2).UnixNano() - remote)
+	atomic.StoreInt64(&m.offset, int64(offset))
+
+	if offset < 0 {
+		offset = -offset
+	}
+	skewed := uint32(0)
+	if offset-rtt/2 > m.maxOffset {
+		skewed = 1
+	}
+	return atomic.SwapUint32(&m.skewed, skewed) != skewed
+}
+
+func (m *clockMonitor) getOffset() time.Duration {
+	return time.Duration(atomic.LoadInt64(&m.offset))
+}
+
+func (m *clockMonitor) isSkewed() bool {
+	return atomic.LoadUint32(&m.skewed) == 1
+}
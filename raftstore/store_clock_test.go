@@ -0,0 +1,54 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClockMonitor(t *testing.T) {
+	m := newClockMonitor(time.Millisecond * 500)
+	sent := time.Now()
+	received := sent.Add(time.Millisecond * 100)
+	middle := sent.Add(time.Millisecond * 50)
+
+	assert.False(t, m.update(sent, received, middle.Add(-time.Millisecond*100).UnixNano()))
+	assert.Equal(t, time.Millisecond*100, m.getOffset())
+	assert.False(t, m.isSkewed())
+
+	// ahead of the prophet leader
+	assert.True(t, m.update(sent, received, middle.Add(-time.Second).UnixNano()))
+	assert.Equal(t, time.Second, m.getOffset())
+	assert.True(t, m.isSkewed())
+	assert.False(t, m.update(sent, received, middle.Add(-time.Second).UnixNano()))
+
+	// recovered
+	assert.True(t, m.update(sent, received, middle.UnixNano()))
+	assert.False(t, m.isSkewed())
+
+	// behind the prophet leader
+	assert.True(t, m.update(sent, received, middle.Add(time.Second).UnixNano()))
+	assert.Equal(t, -time.Second, m.getOffset())
+	assert.True(t, m.isSkewed())
+
+	// the uncertainty of a slow round trip covers the offset
+	m = newClockMonitor(time.Millisecond * 500)
+	received = sent.Add(time.Second * 2)
+	middle = sent.Add(time.Second)
+	assert.False(t, m.update(sent, received, middle.Add(-time.Millisecond*1200).UnixNano()))
+	assert.False(t, m.isSkewed())
+}
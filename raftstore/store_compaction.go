@@ -0,0 +1,229 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/metric"
+	"github.com/matrixorigin/matrixcube/storage"
+)
+
+// replicaCompaction tracks the writes of the replica for the offline
+// compaction, it's updated by the event worker of the replica and read by the
+// compaction task of the store.
+type replicaCompaction struct {
+	// lastWrite the unix nanoseconds of the last applied write
+	lastWrite int64
+	// deletedBytes the bytes removed from the shard since the last compaction
+	deletedBytes uint64
+	// lastCompacted the unix nanoseconds of the last compaction, 0 if the shard
+	// is not compacted since the replica created
+	lastCompacted int64
+	// sizeStale 1 if the approximate size of the shard is stale after the range
+	// deletions, the merges or the gc of the mvcc data
+	sizeStale int32
+}
+
+func newReplicaCompaction(now time.Time) replicaCompaction {
+	return replicaCompaction{lastWrite: now.UnixNano()}
+}
+
+func (c *replicaCompaction) recordWrite(now time.Time, deletedBytes uint64) {
+	atomic.StoreInt64(&c.lastWrite, now.UnixNano())
+	if deletedBytes > 0 {
+		atomic.AddUint64(&c.deletedBytes, deletedBytes)
+	}
+}
+
+// compacted records the compaction, the deletedBytes are the bytes removed
+// before the compaction started.
+func (c *replicaCompaction) compacted(now time.Time, deletedBytes uint64) {
+	atomic.StoreInt64(&c.lastCompacted, now.UnixNano())
+	// the bytes deleted during the compaction are kept
+	atomic.AddUint64(&c.deletedBytes, ^(deletedBytes - 1))
+}
+
+func (c *replicaCompaction) markSizeStale() {
+	atomic.StoreInt32(&c.sizeStale, 1)
+}
+
+// takeSizeStale returns true if the size is stale, and clears the flag
+func (c *replicaCompaction) takeSizeStale() bool {
+	return atomic.CompareAndSwapInt32(&c.sizeStale, 1, 0)
+}
+
+type compactionCandidate struct {
+	pr            *replica
+	deletedBytes  uint64
+	lastCompacted int64
+}
+
+// pickCompactionCandidates returns the cold replicas to compact, the replicas
+// deleted most are the first, and then the replicas compacted earliest.
+func (s *store) pickCompactionCandidates(now time.Time) []compactionCandidate {
+	cfg := s.cfg.Compaction
+	var candidates []compactionCandidate
+	s.forEachReplica(func(pr *replica) bool {
+		if _, ok := s.DataStorageByGroup(pr.group).(storage.ShardCompactor); !ok {
+			return true
+		}
+		lastWrite := atomic.LoadInt64(&pr.compaction.lastWrite)
+		if now.Sub(time.Unix(0, lastWrite)) < cfg.ColdDuration.Duration {
+			return true
+		}
+		lastCompacted := atomic.LoadInt64(&pr.compaction.lastCompacted)
+		if lastCompacted > 0 &&
+			now.Sub(time.Unix(0, lastCompacted)) < cfg.MinInterval.Duration {
+			return true
+		}
+		candidates = append(candidates, compactionCandidate{
+			pr:            pr,
+			deletedBytes:  atomic.LoadUint64(&pr.compaction.deletedBytes),
+			lastCompacted: lastCompacted,
+		})
+		return true
+	})
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].deletedBytes != candidates[j].deletedBytes {
+			return candidates[i].deletedBytes > candidates[j].deletedBytes
+		}
+		return candidates[i].lastCompacted < candidates[j].lastCompacted
+	})
+	if len(candidates) > cfg.MaxShardsPerRound {
+		candidates = candidates[:cfg.MaxShardsPerRound]
+	}
+	return candidates
+}
+
+// handleCompactionTask compacts the cold replicas one by one, paused by the
+// pace between two compactions. It stops once the window ends or the store
+// stopped, the rest replicas are picked again in the next check.
+func (s *store) handleCompactionTask(now time.Time) {
+	cfg := s.cfg.Compaction
+	for idx, c := range s.pickCompactionCandidates(now) {
+		if idx > 0 {
+			select {
+			case <-s.stopper.ShouldStop():
+				return
+			case <-time.After(cfg.Pace.Duration):
+			}
+		}
+		if !cfg.InWindow(time.Now()) {
+			s.logger.Info("compaction window ended",
+				s.storeField())
+			return
+		}
+		s.compactReplica(c)
+	}
+}
+
+func (s *store) compactReplica(c compactionCandidate) {
+	compactor, ok := s.DataStorageByGroup(c.pr.group).(storage.ShardCompactor)
+	if !ok {
+		return
+	}
+
+	shard := c.pr.getShard()
+	deletedBytes := atomic.LoadUint64(&c.pr.compaction.deletedBytes)
+	start := time.Now()
+	if err := compactor.CompactShard(shard); err != nil {
+		metric.ObserveStorageCompaction(c.pr.storeID, "failed", start)
+		s.logger.Error("fail to compact shard, retry later",
+			s.storeField(),
+			log.ShardIDField(shard.ID),
+			zap.Error(err))
+		return
+	}
+	c.pr.compaction.compacted(time.Now(), deletedBytes)
+	metric.ObserveStorageCompaction(c.pr.storeID, "succeed", start)
+	s.logger.Info("shard compacted",
+		s.storeField(),
+		log.ShardIDField(shard.ID),
+		zap.Uint64("deleted-bytes", deletedBytes),
+		zap.Duration("cost", time.Since(start)))
+}
+
+// handleSizeRefreshTask refreshes the approximate sizes of the replicas marked
+// stale. The range deletions are not reflected in the estimated size until the
+// range tombstones are compacted, so the shard is compacted before estimating
+// its size if the storage is a ShardCompactor.
+func (s *store) handleSizeRefreshTask() {
+	cfg := s.cfg.Compaction
+	var stale []*replica
+	s.forEachReplica(func(pr *replica) bool {
+		if len(stale) >= cfg.MaxShardsPerRound {
+			return false
+		}
+		if _, ok := s.DataStorageByGroup(pr.group).(storage.ShardSizeEstimator); ok &&
+			pr.compaction.takeSizeStale() {
+			stale = append(stale, pr)
+		}
+		return true
+	})
+
+	for idx, pr := range stale {
+		if idx > 0 {
+			select {
+			case <-s.stopper.ShouldStop():
+				return
+			case <-time.After(cfg.Pace.Duration):
+			}
+		}
+		s.refreshReplicaSize(pr)
+	}
+}
+
+func (s *store) refreshReplicaSize(pr *replica) {
+	ds := s.DataStorageByGroup(pr.group)
+	shard := pr.getShard()
+	if compactor, ok := ds.(storage.ShardCompactor); ok {
+		deletedBytes := atomic.LoadUint64(&pr.compaction.deletedBytes)
+		start := time.Now()
+		if err := compactor.CompactShard(shard); err != nil {
+			metric.ObserveStorageCompaction(pr.storeID, "failed", start)
+			s.logger.Error("fail to compact shard before refreshing size, retry later",
+				s.storeField(),
+				log.ShardIDField(shard.ID),
+				zap.Error(err))
+			pr.compaction.markSizeStale()
+			return
+		}
+		pr.compaction.compacted(time.Now(), deletedBytes)
+		metric.ObserveStorageCompaction(pr.storeID, "succeed", start)
+	}
+
+	size, keys, err := ds.(storage.ShardSizeEstimator).EstimateShardSize(shard)
+	if err != nil {
+		if err != storage.ErrEstimateNotSupported {
+			s.logger.Error("fail to estimate shard size, retry later",
+				s.storeField(),
+				log.ShardIDField(shard.ID),
+				zap.Error(err))
+			pr.compaction.markSizeStale()
+		}
+		return
+	}
+	pr.addAction(action{
+		actionType:     refreshSizeAction,
+		epoch:          shard.Epoch,
+		splitCheckData: splitCheckData{size: size, keys: keys},
+	})
+}
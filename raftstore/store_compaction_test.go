@@ -0,0 +1,191 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	pconfig "github.com/matrixorigin/matrixcube/components/prophet/config"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+)
+
+type testShardCompactor struct {
+	storage.DataStorage
+
+	sync.Mutex
+	err       error
+	compacted []uint64
+}
+
+func (c *testShardCompactor) CompactShard(shard metapb.Shard) error {
+	c.Lock()
+	defer c.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.compacted = append(c.compacted, shard.ID)
+	return nil
+}
+
+func (c *testShardCompactor) getCompacted() []uint64 {
+	c.Lock()
+	defer c.Unlock()
+	return append([]uint64(nil), c.compacted...)
+}
+
+func TestReplicaCompaction(t *testing.T) {
+	now := time.Now()
+	c := newReplicaCompaction(now)
+	assert.Equal(t, now.UnixNano(), c.lastWrite)
+
+	c.recordWrite(now.Add(time.Second), 10)
+	c.recordWrite(now.Add(time.Second*2), 0)
+	assert.Equal(t, now.Add(time.Second*2).UnixNano(), c.lastWrite)
+	assert.Equal(t, uint64(10), c.deletedBytes)
+
+	c.recordWrite(now.Add(time.Second*3), 5)
+	c.compacted(now.Add(time.Second*4), 10)
+	assert.Equal(t, uint64(5), c.deletedBytes, "deleted during the compaction")
+	assert.Equal(t, now.Add(time.Second*4).UnixNano(), c.lastCompacted)
+	c.compacted(now.Add(time.Second*5), 0)
+	assert.Equal(t, uint64(5), c.deletedBytes)
+}
+
+func TestHandleCompactionTask(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	compactor := &testShardCompactor{DataStorage: s.DataStorageByGroup(0)}
+	s.cfg.Storage.DataStorageFactory = func(group uint64) storage.DataStorage {
+		return compactor
+	}
+	s.cfg.Compaction.Windows = []pconfig.MaintenanceWindow{{Start: "00:00", End: "00:00"}}
+	s.cfg.Compaction.ColdDuration.Duration = time.Minute
+	s.cfg.Compaction.MinInterval.Duration = time.Hour
+	s.cfg.Compaction.Pace.Duration = time.Millisecond
+	s.cfg.Compaction.MaxShardsPerRound = 2
+
+	now := time.Now()
+	for id := uint64(1); id <= 4; id++ {
+		pr := newTestReplica(Shard{ID: id}, Replica{ID: id}, s)
+		pr.compaction = newReplicaCompaction(now.Add(-time.Hour))
+		s.addReplica(pr)
+	}
+	s.getReplica(1, false).compaction.recordWrite(now, 100)
+	s.getReplica(2, false).compaction.recordWrite(now.Add(-time.Hour), 10)
+	s.getReplica(3, false).compaction.recordWrite(now.Add(-time.Hour), 20)
+
+	// 1 is hot, 3 is deleted most
+	s.handleCompactionTask(now)
+	assert.Equal(t, []uint64{3, 2}, compactor.getCompacted())
+	assert.Equal(t, uint64(0), s.getReplica(3, false).compaction.deletedBytes)
+
+	// 2 and 3 are compacted recently
+	s.handleCompactionTask(now)
+	assert.Equal(t, []uint64{3, 2, 4}, compactor.getCompacted())
+
+	s.handleCompactionTask(now.Add(time.Hour * 2))
+	assert.Equal(t, []uint64{3, 2, 4, 1, 3}, compactor.getCompacted())
+
+	// the failed compaction is retried later
+	compactor.err = errors.New("compact failed")
+	s.getReplica(2, false).compaction.recordWrite(now.Add(-time.Hour), 10)
+	s.handleCompactionTask(now.Add(time.Hour * 4))
+	assert.Equal(t, uint64(10), s.getReplica(2, false).compaction.deletedBytes)
+	compactor.err = nil
+	s.handleCompactionTask(now.Add(time.Hour * 4))
+	assert.Equal(t, []uint64{3, 2, 4, 1, 3, 2, 4}, compactor.getCompacted())
+
+	// out of the windows
+	s.cfg.Compaction.Windows = nil
+	s.handleCompactionTask(now.Add(time.Hour * 8))
+	assert.Equal(t, 7, len(compactor.getCompacted()))
+}
+
+type testShardSizeEstimator struct {
+	testShardCompactor
+
+	err error
+}
+
+func (e *testShardSizeEstimator) EstimateShardSize(shard metapb.Shard) (uint64, uint64, error) {
+	if e.err != nil {
+		return 0, 0, e.err
+	}
+	return shard.ID * 100, shard.ID, nil
+}
+
+func TestHandleSizeRefreshTask(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	estimator := &testShardSizeEstimator{testShardCompactor: testShardCompactor{DataStorage: s.DataStorageByGroup(0)}}
+	s.cfg.Storage.DataStorageFactory = func(group uint64) storage.DataStorage {
+		return estimator
+	}
+	s.cfg.Compaction.Pace.Duration = time.Millisecond
+	s.cfg.Compaction.MaxShardsPerRound = 1
+
+	for id := uint64(1); id <= 2; id++ {
+		s.addReplica(newTestReplica(Shard{ID: id, Epoch: Epoch{Generation: 1}}, Replica{ID: id}, s))
+	}
+	pr := s.getReplica(2, false)
+
+	// nothing is stale
+	s.handleSizeRefreshTask()
+	assert.Empty(t, estimator.getCompacted())
+	assert.Equal(t, int64(0), pr.actions.Len())
+
+	// the failed estimate is retried later
+	estimator.err = errors.New("estimate failed")
+	pr.compaction.markSizeStale()
+	s.handleSizeRefreshTask()
+	assert.Equal(t, []uint64{2}, estimator.getCompacted())
+	assert.Equal(t, int64(0), pr.actions.Len())
+
+	estimator.err = nil
+	s.handleSizeRefreshTask()
+	assert.Equal(t, []uint64{2, 2}, estimator.getCompacted())
+	assert.Equal(t, int64(1), pr.actions.Len())
+	act, _ := pr.actions.Peek()
+	assert.Equal(t, action{actionType: refreshSizeAction, epoch: Epoch{Generation: 1}, splitCheckData: splitCheckData{size: 200, keys: 2}}, act)
+	assert.False(t, pr.compaction.takeSizeStale())
+
+	// the estimate of the stale generation is dropped
+	pr.doRefreshSize(action{epoch: Epoch{Generation: 0}, splitCheckData: splitCheckData{size: 200, keys: 2}})
+	assert.Equal(t, uint64(0), pr.stats.approximateSize)
+	assert.True(t, pr.compaction.takeSizeStale())
+	pr.doRefreshSize(act.(action))
+	assert.Equal(t, uint64(200), pr.stats.approximateSize)
+	assert.Equal(t, uint64(2), pr.stats.approximateKeys)
+}
+
+func TestIsRangeDeletion(t *testing.T) {
+	assert.True(t, isRangeDeletion(rpcpb.Request{CustomType: uint64(rpcpb.CmdKVRangeDelete)}))
+	assert.False(t, isRangeDeletion(rpcpb.Request{CustomType: uint64(rpcpb.CmdKVDelete)}))
+	assert.True(t, isRangeDeletion(rpcpb.Request{CustomType: uint64(rpcpb.CmdCleanTxnMVCCData)}))
+	assert.False(t, isRangeDeletion(rpcpb.Request{CustomType: uint64(rpcpb.CmdUpdateTxnRecord)}))
+}
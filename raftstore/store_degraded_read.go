@@ -0,0 +1,70 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+func (s *store) EnableDegradedRead(shardID uint64) error {
+	if pr := s.getReplica(shardID, false); pr == nil {
+		return errShardNotFound
+	}
+
+	s.mu.Lock()
+	s.mu.degradedReadShards.Add(shardID)
+	s.mu.Unlock()
+	s.logger.Warn("degraded read enabled, the stale reads are served without leader",
+		s.storeField(),
+		log.ShardIDField(shardID))
+	return nil
+}
+
+func (s *store) DisableDegradedRead(shardID uint64) {
+	s.mu.Lock()
+	s.mu.degradedReadShards.Remove(shardID)
+	s.mu.Unlock()
+	s.logger.Info("degraded read disabled",
+		s.storeField(),
+		log.ShardIDField(shardID))
+}
+
+func (s *store) isDegradedRead(shardID uint64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mu.degradedReadShards.Contains(shardID)
+}
+
+// canStaleRead returns true if the read batch is served by the replica with its
+// applied state, i.e. the shard has no leader and the degraded read is enabled.
+func (pr *replica) canStaleRead(rb rpcpb.RequestBatch) bool {
+	if pr.getLeaderReplicaID() != 0 || rb.IsAdmin() ||
+		!pr.store.isDegradedRead(pr.shardID) {
+		return false
+	}
+	for _, req := range rb.Requests {
+		if req.Type != rpcpb.Read {
+			return false
+		}
+	}
+	return len(rb.Requests) > 0
+}
+
+func (pr *replica) execStaleRead(c batch) {
+	for _, req := range c.requestBatch.Requests {
+		pr.execStaleReadRequest(req)
+	}
+	pr.metrics.propose.staleRead++
+}
@@ -0,0 +1,51 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+)
+
+func TestCanStaleRead(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	assert.Equal(t, errShardNotFound, s.EnableDegradedRead(1))
+	pr := newTestReplica(Shard{ID: 1}, Replica{ID: 1}, s)
+	s.replicas.Store(uint64(1), pr)
+
+	rb := rpcpb.RequestBatch{Requests: []rpcpb.Request{{Type: rpcpb.Read}}}
+	assert.False(t, pr.canStaleRead(rb), "degraded read disabled")
+
+	require.NoError(t, s.EnableDegradedRead(1))
+	assert.True(t, pr.canStaleRead(rb))
+	assert.False(t, pr.canStaleRead(rpcpb.RequestBatch{}))
+	assert.False(t, pr.canStaleRead(rpcpb.RequestBatch{Requests: []rpcpb.Request{{Type: rpcpb.Read}, {Type: rpcpb.Write}}}),
+		"write mixed")
+
+	pr.setLeaderReplicaID(2)
+	assert.False(t, pr.canStaleRead(rb), "has leader")
+	pr.setLeaderReplicaID(0)
+
+	s.DisableDegradedRead(1)
+	assert.False(t, pr.canStaleRead(rb))
+}
@@ -0,0 +1,82 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+// checkStorageDevices warns if the WAL of the LogDB shares the device with the
+// data, the storage classes or the snapshots, the fsync of the WAL is slowed
+// down by the flushes, the compactions and the snapshot transfers on the same
+// device.
+func checkStorageDevices(cfg *config.Config, logger *zap.Logger) {
+	wal := cfg.Storage.WALDir
+	if wal == "" {
+		logger.Info("WAL is stored with the data, set storage.wal-dir to put it on a separate device",
+			zap.String("data-dir", cfg.DataPath))
+		return
+	}
+
+	type storageDir struct {
+		name string
+		key  string
+		path string
+	}
+	dirs := []storageDir{
+		{name: "data", key: "data-dir", path: cfg.DataPath},
+		{name: "snapshot", key: "snapshot-dir", path: cfg.SnapshotDir()},
+	}
+	for _, class := range cfg.Storage.Classes {
+		dirs = append(dirs, storageDir{name: "storage class " + class.Name,
+			key: class.Name + "-dir", path: class.Path})
+	}
+	for _, dir := range dirs {
+		if sameDevice(cfg.FS, wal, dir.path) {
+			logger.Warn("WAL shares the device with the "+dir.name+", the write latency suffers",
+				zap.String("wal-dir", wal),
+				zap.String(dir.key, dir.path))
+		}
+	}
+}
+
+// sameDevice returns true if the both dirs are on the same device, false if it's
+// unknown, e.g. the dirs are in the memory FS.
+func sameDevice(fs vfs.FS, a, b string) bool {
+	deviceA, ok := getDeviceID(fs, a)
+	if !ok {
+		return false
+	}
+	deviceB, ok := getDeviceID(fs, b)
+	return ok && deviceA == deviceB
+}
+
+// getDeviceID returns the ID of the device of the dir, the nearest existing
+// parent is used if the dir is not created yet.
+func getDeviceID(fs vfs.FS, dir string) (uint64, bool) {
+	for {
+		fi, err := fs.Stat(dir)
+		if err == nil {
+			return fileDeviceID(fi)
+		}
+		parent := fs.PathDir(dir)
+		if parent == dir {
+			return 0, false
+		}
+		dir = parent
+	}
+}
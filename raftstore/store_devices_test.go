@@ -0,0 +1,32 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+func TestSameDevice(t *testing.T) {
+	dir := t.TempDir()
+	assert.True(t, sameDevice(vfs.Default, dir, filepath.Join(dir, "not-created", "wal")))
+
+	fs := vfs.NewMemFS()
+	assert.NoError(t, fs.MkdirAll("data", 0755))
+	assert.False(t, sameDevice(fs, "data", "data"), "unknown in the memory FS")
+}
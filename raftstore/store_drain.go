@@ -0,0 +1,92 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+const (
+	drainLeadersInterval = time.Second
+)
+
+func (s *store) DrainLeaders(ctx context.Context) error {
+	s.logger.Info("begin to drain leaders",
+		s.storeField())
+
+	ticker := time.NewTicker(drainLeadersInterval)
+	defer ticker.Stop()
+	for round := 0; ; round++ {
+		n := s.transferLeaders(round)
+		if n == 0 {
+			s.logger.Info("leaders drained",
+				s.storeField())
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("fail to drain leaders",
+				s.storeField(),
+				zap.Int("leaders", n),
+				zap.Error(ctx.Err()))
+			return fmt.Errorf("%d leaders left: %w", n, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// transferLeaders requests to transfer the leaders to the other voters, and
+// returns the number of leaders that can be transferred. The target voter is
+// changed in each round, in case of a voter can't catch up the leader.
+func (s *store) transferLeaders(round int) int {
+	n := 0
+	s.forEachReplica(func(pr *replica) bool {
+		if !pr.isLeader() {
+			return true
+		}
+
+		targets := getTransferLeaderTargets(pr.getShard(), pr.replicaID)
+		if len(targets) == 0 {
+			return true
+		}
+
+		n++
+		target := targets[round%len(targets)]
+		if err := pr.tryAddAdminRequest(rpcpb.CmdTransferLeader,
+			&rpcpb.TransferLeaderRequest{Replica: target}); err != nil {
+			pr.logger.Info("fail to transfer leader for draining",
+				zap.Error(err))
+		}
+		return true
+	})
+	return n
+}
+
+func getTransferLeaderTargets(shard Shard, replicaID uint64) []Replica {
+	var targets []Replica
+	for _, r := range shard.Replicas {
+		if r.ID != replicaID && r.Role == metapb.ReplicaRole_Voter {
+			targets = append(targets, r)
+		}
+	}
+	return targets
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTransferLeaderTargets(t *testing.T) {
+	shard := Shard{
+		Replicas: []Replica{
+			{ID: 1, Role: metapb.ReplicaRole_Voter},
+			{ID: 2, Role: metapb.ReplicaRole_Learner},
+			{ID: 3, Role: metapb.ReplicaRole_Voter},
+		},
+	}
+	assert.Equal(t, []Replica{shard.Replicas[2]}, getTransferLeaderTargets(shard, 1))
+	assert.Equal(t, []Replica{shard.Replicas[0]}, getTransferLeaderTargets(shard, 3))
+	assert.Empty(t, getTransferLeaderTargets(Shard{Replicas: shard.Replicas[:1]}, 1))
+}
+
+func TestDrainLeaders(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode.")
+		return
+	}
+
+	defer leaktest.AfterTest(t)()
+	c := NewTestClusterStore(t)
+	c.Start()
+	defer c.Stop()
+
+	c.WaitVoterReplicaByCountPerNode(1, testWaitTimeout)
+	c.WaitLeadersByCount(1, testWaitTimeout)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, c.GetStore(i).Ready())
+	}
+
+	shard := c.GetShardByIndex(0, 0)
+	node := c.GetShardLeaderNode(shard.ID)
+	ctx, cancel := context.WithTimeout(context.Background(), testWaitTimeout)
+	defer cancel()
+	assert.NoError(t, c.GetStore(node).DrainLeaders(ctx))
+	c.WaitShardOldLeaderChanged([]int{0, 1, 2}, shard.ID,
+		c.GetStore(node).Meta().ID, testWaitTimeout)
+}
+
+func TestReadyAfterStop(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	c := NewSingleTestClusterStore(t)
+	c.Start()
+	s := c.GetStore(0)
+	assert.NoError(t, s.Ready())
+	c.Stop()
+	assert.ErrorIs(t, s.Ready(), ErrStoreStopped)
+}
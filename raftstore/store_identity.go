@@ -0,0 +1,129 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/util/fileutil"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+const (
+	storeIdentityFilename    = "STORE_IDENTITY"
+	storeIdentityTmpFilename = "STORE_IDENTITY.tmp"
+)
+
+// mustFenceStoreIdentity makes sure the data directory belongs to the latest
+// incarnation of the store, and starts a new incarnation by increasing the epoch
+// in the store identity file. Otherwise a data directory copied from another
+// node or restored from an older backup brings up a second store with the same
+// ID and the outdated raft states.
+func (s *store) mustFenceStoreIdentity() {
+	ident := metapb.StoreIdent{
+		ClusterID: s.pd.GetClusterID(),
+		StoreID:   s.meta.GetID(),
+	}
+	local, ok, err := loadStoreIdentity(s.cfg.DataPath, s.cfg.FS)
+	if err != nil {
+		s.logger.Fatal("failed to load store identity",
+			s.storeField(),
+			zap.Error(err))
+	}
+	if ok {
+		if local.ClusterID != ident.ClusterID || local.StoreID != ident.StoreID {
+			s.logger.Fatal("store identity mismatch, the data directory belongs to another store",
+				s.storeField(),
+				zap.Uint64("identity-cluster", local.ClusterID),
+				zap.Uint64("identity-store", local.StoreID))
+		}
+		ident.Epoch = local.Epoch
+	}
+
+	// the store is not found if it crashed before putting to prophet
+	if current, err := s.pd.GetClient().GetStore(ident.StoreID); err == nil {
+		aliveTimeout := 3 * s.cfg.Replication.StoreHeartbeatDuration.Duration
+		if err := checkStoreIdentity(ident, *current, s.Meta().RaftAddress, aliveTimeout, time.Now()); err != nil {
+			if !s.cfg.ForceStartWithStaleIdentity {
+				s.logger.Fatal("store identity fenced, set force-start-with-stale-identity to override",
+					s.storeField(),
+					zap.Error(err))
+			}
+			s.logger.Warn("force to start the store with stale identity",
+				s.storeField(),
+				zap.Error(err))
+		}
+		if current.GetEpoch() > ident.Epoch {
+			ident.Epoch = current.GetEpoch()
+		}
+	}
+
+	ident.Epoch++
+	s.mustSaveStoreIdentity(ident)
+}
+
+func (s *store) mustSaveStoreIdentity(ident metapb.StoreIdent) {
+	if err := saveStoreIdentity(s.cfg.DataPath, ident, s.cfg.FS); err != nil {
+		s.logger.Fatal("failed to save store identity",
+			s.storeField(),
+			zap.Error(err))
+	}
+	s.meta.SetEpoch(ident.Epoch)
+	s.logger.Info("store identity saved",
+		s.storeField(),
+		zap.Uint64("epoch", ident.Epoch))
+}
+
+// checkStoreIdentity returns an error if the store identity is older than the
+// one known by prophet, or the store is still alive on another node.
+func checkStoreIdentity(ident metapb.StoreIdent, current metapb.Store,
+	raftAddr string, aliveTimeout time.Duration, now time.Time) error {
+	if current.GetEpoch() > ident.Epoch {
+		return fmt.Errorf("the data directory is from an older incarnation of store %d, epoch %d, current %d",
+			ident.StoreID, ident.Epoch, current.GetEpoch())
+	}
+	if current.GetRaftAddress() != raftAddr &&
+		now.Sub(time.Unix(0, current.GetLastHeartbeatTime())) < aliveTimeout {
+		return fmt.Errorf("store %d is alive on %s, the data directory may be copied from it",
+			ident.StoreID, current.GetRaftAddress())
+	}
+	return nil
+}
+
+func loadStoreIdentity(dir string, fs vfs.FS) (metapb.StoreIdent, bool, error) {
+	ident := metapb.StoreIdent{}
+	if !fileutil.HasFlagFile(dir, storeIdentityFilename, fs) {
+		return ident, false, nil
+	}
+	if err := fileutil.GetFlagFileContent(dir, storeIdentityFilename, &ident, fs); err != nil {
+		return ident, false, err
+	}
+	return ident, true, nil
+}
+
+// saveStoreIdentity replaces the store identity file atomically.
+func saveStoreIdentity(dir string, ident metapb.StoreIdent, fs vfs.FS) error {
+	if err := fileutil.CreateFlagFile(dir, storeIdentityTmpFilename, &ident, fs); err != nil {
+		return err
+	}
+	if err := fs.Rename(fs.PathJoin(dir, storeIdentityTmpFilename),
+		fs.PathJoin(dir, storeIdentityFilename)); err != nil {
+		return err
+	}
+	return fileutil.SyncDir(dir, fs)
+}
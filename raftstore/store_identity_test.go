@@ -0,0 +1,69 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+func TestSaveAndLoadStoreIdentity(t *testing.T) {
+	fs := vfs.GetTestFS()
+	dir := "store-identity-test"
+	require.NoError(t, fs.MkdirAll(dir, 0755))
+	defer func() {
+		require.NoError(t, fs.RemoveAll(dir))
+	}()
+
+	_, ok, err := loadStoreIdentity(dir, fs)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ident := metapb.StoreIdent{ClusterID: 1, StoreID: 2, Epoch: 3}
+	assert.NoError(t, saveStoreIdentity(dir, ident, fs))
+	ident.Epoch++
+	assert.NoError(t, saveStoreIdentity(dir, ident, fs))
+
+	v, ok, err := loadStoreIdentity(dir, fs)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, ident, v)
+}
+
+func TestCheckStoreIdentity(t *testing.T) {
+	now := time.Now()
+	timeout := time.Second * 30
+	ident := metapb.StoreIdent{ClusterID: 1, StoreID: 2, Epoch: 3}
+
+	current := metapb.Store{ID: 2, RaftAddress: "n1", Epoch: 3, LastHeartbeatTime: now.UnixNano()}
+	assert.NoError(t, checkStoreIdentity(ident, current, "n1", timeout, now))
+
+	// restored from an older backup
+	current.Epoch = 4
+	assert.Error(t, checkStoreIdentity(ident, current, "n1", timeout, now))
+
+	// copied from a running node
+	current.Epoch = 3
+	assert.Error(t, checkStoreIdentity(ident, current, "n2", timeout, now))
+
+	// the store moved to another node
+	current.LastHeartbeatTime = now.Add(-timeout).UnixNano()
+	assert.NoError(t, checkStoreIdentity(ident, current, "n2", timeout, now))
+}
@@ -0,0 +1,149 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+// lazyReplica is the lightweight descriptor of a replica which is not opened
+// after restart. The replica's state machine is opened on the first access.
+type lazyReplica struct {
+	shard    Shard
+	lease    *metapb.EpochLease
+	deadline time.Time
+	once     sync.Once
+	// leaderlessSince is the time since the shard has no leader reported by
+	// prophet, only accessed by the timer task.
+	leaderlessSince time.Time
+}
+
+// isLazyOpenCandidate returns true if the replica of the shard can be opened
+// lazily. The replica held the lease was the leader before restart, it must be
+// opened to campaign, otherwise the shard can not elect a leader until some
+// other replica is opened. The replica without lease has no idea about who is
+// the leader, so it is opened too.
+func (s *store) isLazyOpenCandidate(shard Shard, lease *metapb.EpochLease) bool {
+	if lease == nil {
+		return false
+	}
+	r := findReplica(shard, s.Meta().ID)
+	return r != nil && lease.ReplicaID != r.ID
+}
+
+// addLazyReplicas registers the shards as lazy replicas. The key ranges are
+// updated so that requests can be routed to these shards and open them.
+func (s *store) addLazyReplicas(shards []Shard, leases map[uint64]*metapb.EpochLease) {
+	deadline := time.Now().Add(s.cfg.Replication.LazyOpenTimeout.Duration)
+	for _, shard := range shards {
+		s.lazyReplicas.Store(shard.ID, &lazyReplica{
+			shard:    shard,
+			lease:    leases[shard.ID],
+			deadline: deadline,
+		})
+	}
+	for g, shards := range groupShardByGroupID(shards) {
+		s.updateShardKeyRange(g, shards...)
+	}
+}
+
+// handleLazyReplicaCheckTask opens the lazy replicas which no longer wait for
+// the first access. The leader replica on other store may never come back after
+// restart, and no raft message opens the lazy replicas, so the lazy replica is
+// opened if prophet reports the local store holds the lease of the shard, or
+// the shard has no leader for a while. All lazy replicas are opened after the
+// LazyOpenTimeout.
+func (s *store) handleLazyReplicaCheckTask(now time.Time) {
+	// the leader reported after restart needs an election and a shard heartbeat
+	grace := s.cfg.Raft.GetElectionTimeoutDuration() +
+		s.cfg.Replication.ShardHeartbeatDuration.Duration*2
+	routerStarted := atomic.LoadUint32(&s.started) == 1
+	n := 0
+	s.lazyReplicas.Range(func(key, value interface{}) bool {
+		id := key.(uint64)
+		lr := value.(*lazyReplica)
+		reason := ""
+		if !now.Before(lr.deadline) {
+			reason = "timeout"
+		} else if routerStarted {
+			leaseStore, lease := s.router.SelectReplicaStoreWithPolicy(id, rpcpb.SelectLeaseHolder)
+			if lease != nil && leaseStore.ID == s.Meta().ID {
+				reason = "lease holder"
+			} else if s.router.LeaderReplicaStore(id).ID != 0 {
+				lr.leaderlessSince = time.Time{}
+			} else if lr.leaderlessSince.IsZero() {
+				lr.leaderlessSince = now
+			} else if now.Sub(lr.leaderlessSince) >= grace {
+				reason = "no leader"
+			}
+		}
+
+		if reason != "" {
+			s.logger.Info("lazy replica need to be opened",
+				s.storeField(),
+				log.ShardIDField(id),
+				log.ReasonField(reason))
+			if s.openLazyReplica(id) {
+				n++
+			}
+		}
+		return true
+	})
+	if n > 0 {
+		s.logger.Info("lazy replicas opened",
+			s.storeField(),
+			zap.Int("count", n))
+	}
+}
+
+// openLazyReplica opens the lazy replica of the shard, returns false if the
+// shard has no lazy replica. Concurrent callers wait until the replica opened.
+func (s *store) openLazyReplica(id uint64) bool {
+	value, ok := s.lazyReplicas.Load(id)
+	if !ok {
+		return false
+	}
+
+	lr := value.(*lazyReplica)
+	lr.once.Do(func() {
+		s.logger.Info("begin to open lazy replica",
+			s.storeField(),
+			log.ShardIDField(id))
+		newReplicaCreator(s).
+			withReason("lazy open").
+			withStartReplica(false, func(r *replica) {
+				r.sm.updateLease(lr.lease)
+			}, nil).
+			create([]Shard{lr.shard})
+		s.lazyReplicas.Delete(id)
+	})
+	return true
+}
+
+func (s *store) getLazyReplicaCount() uint64 {
+	n := uint64(0)
+	s.lazyReplicas.Range(func(key, value interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
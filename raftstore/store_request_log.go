@@ -0,0 +1,100 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/command"
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+// requestLogger logs 1 in the sampleRate requests received by the store with
+// the latencies. The values of the requests and the responses are never logged,
+// and the keys are redacted by the redactFunc and truncated to the prefixes.
+// The names of the commands are logged if the command registry is set.
+type requestLogger struct {
+	logger          *zap.Logger
+	sampleRate      uint64
+	keyPrefixLength int
+	redactFunc      func(req rpcpb.Request) []byte
+	commands        *command.Registry
+	seq             uint64
+}
+
+// newRequestLogger returns nil if the request log is disabled
+func newRequestLogger(cfg config.RequestLogConfig, redactFunc func(req rpcpb.Request) []byte,
+	commands *command.Registry, logger *zap.Logger) *requestLogger {
+	if !cfg.Enable {
+		return nil
+	}
+	if redactFunc == nil {
+		redactFunc = func(req rpcpb.Request) []byte { return req.Key }
+	}
+	return &requestLogger{
+		logger:          logger,
+		sampleRate:      cfg.SampleRate,
+		keyPrefixLength: cfg.KeyPrefixLength,
+		redactFunc:      redactFunc,
+		commands:        commands,
+	}
+}
+
+// wrap returns the callback logging the request once responded if the request
+// is sampled, otherwise the callback is returned as is.
+func (l *requestLogger) wrap(req rpcpb.Request,
+	cb func(resp rpcpb.ResponseBatch)) func(resp rpcpb.ResponseBatch) {
+	if l == nil || atomic.AddUint64(&l.seq, 1)%l.sampleRate != 0 {
+		return cb
+	}
+
+	// the request may be reused once responded, so the fields are built first
+	fields := []zap.Field{
+		log.RequestIDField(req.ID),
+		zap.Uint64("group", req.Group),
+		zap.String("type", req.Type.String()),
+		zap.Uint64("custom-type", req.CustomType),
+		log.HexField("key-prefix", l.keyPrefix(req)),
+		zap.Int("size", req.Size()),
+	}
+	if l.commands != nil {
+		if cmd, ok := l.commands.Get(req.CustomType); ok {
+			fields = append(fields, zap.String("command", cmd.Name))
+		}
+	}
+	start := time.Now()
+	return func(resp rpcpb.ResponseBatch) {
+		fields = append(fields,
+			zap.Duration("latency", time.Since(start)),
+			zap.Int("response-size", resp.Size()))
+		if !resp.Header.IsEmpty() {
+			fields = append(fields, zap.String("error", resp.Header.Error.Message))
+		}
+		l.logger.Info("sampled request", fields...)
+		cb(resp)
+	}
+}
+
+func (l *requestLogger) keyPrefix(req rpcpb.Request) []byte {
+	key := l.redactFunc(req)
+	if len(key) > l.keyPrefixLength {
+		key = key[:l.keyPrefixLength]
+	}
+	return key
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/matrixorigin/matrixcube/command"
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/pb/errorpb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+func TestRequestLoggerDisabled(t *testing.T) {
+	assert.Nil(t, newRequestLogger(config.RequestLogConfig{}, nil, nil, zap.NewNop()))
+
+	var l *requestLogger
+	called := false
+	l.wrap(rpcpb.Request{}, func(resp rpcpb.ResponseBatch) { called = true })(rpcpb.ResponseBatch{})
+	assert.True(t, called)
+}
+
+func TestRequestLoggerSampling(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := newRequestLogger(config.RequestLogConfig{Enable: true, SampleRate: 2, KeyPrefixLength: 4},
+		nil, nil, zap.New(core))
+
+	responded := 0
+	cb := func(resp rpcpb.ResponseBatch) { responded++ }
+	for i := 0; i < 4; i++ {
+		req := rpcpb.Request{ID: []byte{byte(i)}, Type: rpcpb.Write, CustomType: 1,
+			Key: []byte("key-secret"), Cmd: []byte("value-secret")}
+		l.wrap(req, cb)(rpcpb.ResponseBatch{})
+	}
+	assert.Equal(t, 4, responded)
+	require.Equal(t, 2, logs.Len())
+
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "Write", fields["type"])
+	assert.Equal(t, uint64(1), fields["custom-type"])
+	assert.Equal(t, "6b65792d", fields["key-prefix"], "the key is truncated to the prefix")
+	assert.Contains(t, fields, "latency")
+	assert.Contains(t, fields, "size")
+	assert.NotContains(t, fields, "error")
+}
+
+func TestRequestLoggerRedact(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := newRequestLogger(config.RequestLogConfig{Enable: true, SampleRate: 1, KeyPrefixLength: 8},
+		func(req rpcpb.Request) []byte { return []byte("***") }, nil, zap.New(core))
+
+	resp := rpcpb.ResponseBatch{}
+	resp.Header.Error = errorpb.Error{Message: "shard unavailable"}
+	l.wrap(rpcpb.Request{Key: []byte("key-secret")}, func(resp rpcpb.ResponseBatch) {})(resp)
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "2a2a2a", fields["key-prefix"])
+	assert.Equal(t, "shard unavailable", fields["error"])
+}
+
+func TestRequestLoggerCommandName(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	commands := command.NewRegistry()
+	commands.MustRegister(command.Command{Type: 1, Name: "set", RequestType: rpcpb.Write})
+	l := newRequestLogger(config.RequestLogConfig{Enable: true, SampleRate: 1},
+		nil, commands, zap.New(core))
+
+	l.wrap(rpcpb.Request{Type: rpcpb.Write, CustomType: 1}, func(resp rpcpb.ResponseBatch) {})(rpcpb.ResponseBatch{})
+	l.wrap(rpcpb.Request{Type: rpcpb.Write, CustomType: 2}, func(resp rpcpb.ResponseBatch) {})(rpcpb.ResponseBatch{})
+	require.Equal(t, 2, logs.Len())
+	assert.Equal(t, "set", logs.All()[0].ContextMap()["command"])
+	assert.NotContains(t, logs.All()[1].ContextMap(), "command")
+}
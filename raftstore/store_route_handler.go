@@ -25,7 +25,7 @@ func (s *store) doDynamicallyCreate(shard Shard) bool {
 		return false
 	}
 
-	if _, ok := s.replicas.Load(shard.ID); ok {
+	if s.getReplica(shard.ID, false) != nil {
 		return false
 	}
 
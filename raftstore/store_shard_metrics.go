@@ -0,0 +1,67 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/metric"
+)
+
+type shardMetricsEntry struct {
+	stats     metric.ShardStats
+	updatedAt time.Time
+}
+
+// shardMetricsCollector collects the stats of the shards whose leader is on the
+// current store. The stats are updated by the leader replicas while sending the
+// shard heartbeat, so that the stats of a shard are only reported by one store.
+type shardMetricsCollector struct {
+	sync.Mutex
+	shards map[uint64]shardMetricsEntry
+}
+
+func newShardMetricsCollector() *shardMetricsCollector {
+	return &shardMetricsCollector{shards: make(map[uint64]shardMetricsEntry)}
+}
+
+func (c *shardMetricsCollector) update(stats metric.ShardStats, now time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	c.shards[stats.ShardID] = shardMetricsEntry{stats: stats, updatedAt: now}
+}
+
+// collect returns the stats of the shards, the shards not updated in the
+// expired duration are removed, e.g. the leader is transferred or the replica
+// is destroyed.
+func (c *shardMetricsCollector) collect(expired time.Duration, now time.Time) []metric.ShardStats {
+	c.Lock()
+	defer c.Unlock()
+	values := make([]metric.ShardStats, 0, len(c.shards))
+	for id, v := range c.shards {
+		if now.Sub(v.updatedAt) > expired {
+			delete(c.shards, id)
+			continue
+		}
+		values = append(values, v.stats)
+	}
+	return values
+}
+
+func (s *store) handleShardMetricsTask() {
+	// the stats are updated on every shard heartbeat
+	expired := s.cfg.Replication.ShardHeartbeatDuration.Duration * 2
+	metric.SetShardStats(s.cfg.Metric, s.shardMetrics.collect(expired, time.Now()))
+}
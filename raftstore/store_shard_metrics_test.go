@@ -0,0 +1,36 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/metric"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardMetricsCollector(t *testing.T) {
+	c := newShardMetricsCollector()
+	now := time.Now()
+	c.update(metric.ShardStats{ShardID: 1, WrittenBytes: 1}, now)
+	c.update(metric.ShardStats{ShardID: 2, WrittenBytes: 2}, now.Add(time.Second))
+	c.update(metric.ShardStats{ShardID: 1, WrittenBytes: 3}, now.Add(time.Second))
+	assert.Equal(t, 2, len(c.collect(time.Second, now.Add(time.Second))))
+
+	c.update(metric.ShardStats{ShardID: 3}, now.Add(time.Second*3))
+	values := c.collect(time.Second, now.Add(time.Second*3))
+	assert.Equal(t, []metric.ShardStats{{ShardID: 3}}, values)
+	assert.Equal(t, 1, len(c.shards))
+}
@@ -0,0 +1,170 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/prophet/schedule/placement"
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+const (
+	systemGroupUnique = "matrixcube-system"
+	systemGroupRuleID = "default"
+	keyspaceSeparator = "/"
+)
+
+// The keyspaces in the system shard group reserved by matrixcube's own subsystems
+const (
+	// JobKeyspace the keyspace of the job states
+	JobKeyspace = "job"
+	// GCSafePointKeyspace the keyspace of the GC safepoints
+	GCSafePointKeyspace = "gc-safepoint"
+	// DedupKeyspace the keyspace of the dedup caches
+	DedupKeyspace = "dedup"
+	// ConfigKeyspace the keyspace of the cluster-wide config store
+	ConfigKeyspace = "config"
+)
+
+var reservedKeyspaces = []string{JobKeyspace, GCSafePointKeyspace, DedupKeyspace, ConfigKeyspace}
+
+var (
+	// ErrSystemGroupDisabled the system shard group is not enabled
+	ErrSystemGroupDisabled = errors.New("system shard group disabled")
+	// ErrKeyspaceReserved the keyspace is already reserved in the system shard group
+	ErrKeyspaceReserved = errors.New("keyspace already reserved")
+	// ErrInvalidKeyspaceName the keyspace name is empty or contains the separator
+	ErrInvalidKeyspaceName = errors.New("invalid keyspace name")
+)
+
+// SystemKeyspace is a keyspace reserved in the system shard group, all keys of
+// the keyspace are prefixed by the keyspace prefix. The prefix is derived from
+// the name, so the same name always maps to the same keyspace across restarts.
+type SystemKeyspace struct {
+	Name   string
+	Prefix []byte
+}
+
+func newSystemKeyspace(name string) SystemKeyspace {
+	return SystemKeyspace{Name: name, Prefix: []byte(name + keyspaceSeparator)}
+}
+
+// GetReservedKeyspace returns the keyspace reserved by matrixcube's own
+// subsystems, e.g. ConfigKeyspace. The clients of the subsystems use it to
+// build the keys without the store.
+func GetReservedKeyspace(name string) (SystemKeyspace, bool) {
+	for _, v := range reservedKeyspaces {
+		if v == name {
+			return newSystemKeyspace(name), true
+		}
+	}
+	return SystemKeyspace{}, false
+}
+
+// Key returns the key in the keyspace
+func (k SystemKeyspace) Key(key []byte) []byte {
+	v := make([]byte, 0, len(k.Prefix)+len(key))
+	v = append(v, k.Prefix...)
+	return append(v, key...)
+}
+
+// Range returns the key range [start, end) of the keyspace, the route key of the
+// requests to the keyspace must be in the range.
+func (k SystemKeyspace) Range() ([]byte, []byte) {
+	end := append([]byte(nil), k.Prefix...)
+	end[len(end)-1]++
+	return k.Prefix, end
+}
+
+// systemKeyspaces records the keyspaces reserved in the system shard group on
+// the store, to prevent the different subsystems from sharing the keyspace.
+type systemKeyspaces struct {
+	sync.Mutex
+	reserved map[string]SystemKeyspace
+}
+
+func newSystemKeyspaces(names ...string) *systemKeyspaces {
+	ks := &systemKeyspaces{reserved: make(map[string]SystemKeyspace)}
+	for _, name := range names {
+		ks.reserved[name] = newSystemKeyspace(name)
+	}
+	return ks
+}
+
+func (ks *systemKeyspaces) reserve(name string) (SystemKeyspace, error) {
+	if name == "" || strings.Contains(name, keyspaceSeparator) {
+		return SystemKeyspace{}, fmt.Errorf("%w: %q", ErrInvalidKeyspaceName, name)
+	}
+
+	ks.Lock()
+	defer ks.Unlock()
+	if _, ok := ks.reserved[name]; ok {
+		return SystemKeyspace{}, fmt.Errorf("%w: %s", ErrKeyspaceReserved, name)
+	}
+	k := newSystemKeyspace(name)
+	ks.reserved[name] = k
+	return k, nil
+}
+
+func (s *store) ReserveSystemKeyspace(name string) (SystemKeyspace, error) {
+	if !s.cfg.SystemGroup.Enable {
+		return SystemKeyspace{}, ErrSystemGroupDisabled
+	}
+	return s.systemKeyspaces.reserve(name)
+}
+
+// newSystemGroupShard returns the init shard of the system group, the shard is
+// placed by the system rule group only.
+func newSystemGroupShard() Shard {
+	shard := metapb.NewShard()
+	shard.Group = config.SystemGroup
+	shard.Unique = systemGroupUnique
+	shard.SetRuleGroups(placement.SystemRuleGroupID)
+	return *shard
+}
+
+// mustPutSystemGroupRule puts the placement rule of the system group to prophet
+// on every start, so that the changes of the replicas config are applied.
+func (s *store) mustPutSystemGroupRule() {
+	if !s.cfg.SystemGroup.Enable {
+		return
+	}
+
+	rule := rpcpb.PlacementRule{
+		GroupID:        placement.SystemRuleGroupID,
+		ID:             systemGroupRuleID,
+		Role:           rpcpb.Voter,
+		Count:          uint32(s.cfg.SystemGroup.MaxReplicas),
+		LocationLabels: s.cfg.Prophet.Replication.LocationLabels,
+	}
+	for {
+		if err := s.pd.GetClient().PutPlacementRule(rule); err != nil {
+			s.logger.Info("failed to put system group placement rule to prophet",
+				s.storeField(),
+				zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+		break
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReserveSystemKeyspace(t *testing.T) {
+	ks := newSystemKeyspaces(JobKeyspace)
+
+	_, err := ks.reserve(JobKeyspace)
+	assert.ErrorIs(t, err, ErrKeyspaceReserved)
+	_, err = ks.reserve("")
+	assert.ErrorIs(t, err, ErrInvalidKeyspaceName)
+	_, err = ks.reserve("a/b")
+	assert.ErrorIs(t, err, ErrInvalidKeyspaceName)
+
+	k, err := ks.reserve("test")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("test/"), k.Prefix)
+	assert.Equal(t, []byte("test/key"), k.Key([]byte("key")))
+	start, end := k.Range()
+	assert.Equal(t, []byte("test/"), start)
+	assert.Equal(t, []byte("test0"), end)
+
+	_, err = ks.reserve("test")
+	assert.ErrorIs(t, err, ErrKeyspaceReserved)
+}
@@ -14,11 +14,18 @@
 package raftstore
 
 import (
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/fagongzi/util/protoc"
 	"github.com/juju/ratelimit"
+	"github.com/matrixorigin/matrixcube/command"
+	pconfig "github.com/matrixorigin/matrixcube/components/prophet/config"
+	"github.com/matrixorigin/matrixcube/components/prophet/core"
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/keys"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/matrixorigin/matrixcube/storage"
@@ -28,6 +35,7 @@ import (
 	"github.com/matrixorigin/matrixcube/util/leaktest"
 	"github.com/matrixorigin/matrixcube/util/task"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.etcd.io/etcd/raft/v3/raftpb"
 )
 
@@ -39,6 +47,37 @@ func TestStartAndStop(t *testing.T) {
 	defer c.Stop()
 }
 
+func TestStopClosesWatcher(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode.")
+		return
+	}
+
+	defer leaktest.AfterTest(t)()
+
+	c := NewTestClusterStore(t)
+	c.Start()
+	defer c.Stop()
+	c.WaitLeadersByCount(1, testWaitTimeout)
+
+	// the events are not consumed after the router stopped, the watcher is
+	// closed by the store instead of blocking on the notify channel
+	s := c.GetStore(2).(*store)
+	notify := s.watcher.GetNotify()
+	s.Stop()
+	timeout := time.After(testWaitTimeout)
+	for {
+		select {
+		case _, ok := <-notify:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			assert.FailNow(t, "watcher not closed")
+		}
+	}
+}
+
 func TestSearchShard(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -123,6 +162,88 @@ func TestStoreSelectShard(t *testing.T) {
 	assert.Equal(t, uint64(1), v.shardID)
 }
 
+func TestStoreOpenLazyReplica(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	db := NewTestDataBuilder()
+	shard := db.CreateShard(1, "1/0,2/1")
+	local := findReplica(shard, s.Meta().ID)
+	assert.NotNil(t, local)
+	assert.False(t, s.isLazyOpenCandidate(shard, nil))
+	assert.False(t, s.isLazyOpenCandidate(shard, &metapb.EpochLease{ReplicaID: local.ID}))
+	lease := &metapb.EpochLease{Epoch: 1, ReplicaID: local.ID + 1}
+	assert.True(t, s.isLazyOpenCandidate(shard, lease))
+
+	s.addLazyReplicas([]Shard{shard}, map[uint64]*metapb.EpochLease{shard.ID: lease})
+	_, ok := s.replicas.Load(shard.ID)
+	assert.False(t, ok)
+	assert.Equal(t, uint64(1), s.getReplicaCount())
+	assert.Equal(t, shard.ID, s.searchShard(shard.Group, shard.Start).ID)
+
+	pr, err := s.selectShard(shard.Group, shard.Start)
+	assert.NoError(t, err)
+	assert.Equal(t, shard.ID, pr.shardID)
+	assert.Equal(t, lease.ReplicaID, pr.getLease().ReplicaID)
+	assert.Equal(t, uint64(1), s.getReplicaCount())
+	assert.False(t, s.openLazyReplica(shard.ID))
+}
+
+func TestStoreLazyReplicaCheckTask(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	r, err := newRouterBuilder().build(make(chan rpcpb.EventNotify))
+	assert.NoError(t, err)
+	s.router = r
+	atomic.StoreUint32(&s.started, 1)
+
+	db := NewTestDataBuilder()
+	// the local store holds the lease reported by prophet
+	leaseShard := db.CreateShard(1, "1/0,2/1")
+	// the leader is reported by prophet
+	leaderShard := db.CreateShard(2, "3/0,4/1")
+	// no leader is reported by prophet
+	leaderlessShard := db.CreateShard(3, "5/0,6/1")
+	shards := []Shard{leaseShard, leaderShard, leaderlessShard}
+	leases := make(map[uint64]*metapb.EpochLease)
+	r.UpdateStore(s.Meta())
+	r.UpdateStore(metapb.Store{ID: 1})
+	for _, shard := range shards {
+		leases[shard.ID] = &metapb.EpochLease{Epoch: 1, ReplicaID: shard.Replicas[1].ID}
+		r.UpdateShard(shard)
+	}
+	r.UpdateLease(leaseShard.ID, &metapb.EpochLease{Epoch: 2, ReplicaID: leaseShard.Replicas[0].ID})
+	r.UpdateLeader(leaderShard.ID, leaderShard.Replicas[1].ID)
+	s.addLazyReplicas(shards, leases)
+	assert.Equal(t, uint64(3), s.getLazyReplicaCount())
+
+	isOpened := func(id uint64) bool {
+		_, ok := s.replicas.Load(id)
+		return ok
+	}
+
+	now := time.Now()
+	s.handleLazyReplicaCheckTask(now)
+	assert.True(t, isOpened(leaseShard.ID))
+	assert.False(t, isOpened(leaderShard.ID))
+	assert.False(t, isOpened(leaderlessShard.ID))
+
+	grace := s.cfg.Raft.GetElectionTimeoutDuration() +
+		s.cfg.Replication.ShardHeartbeatDuration.Duration*2
+	s.handleLazyReplicaCheckTask(now.Add(grace))
+	assert.False(t, isOpened(leaderShard.ID))
+	assert.True(t, isOpened(leaderlessShard.ID))
+
+	s.handleLazyReplicaCheckTask(now.Add(s.cfg.Replication.LazyOpenTimeout.Duration))
+	assert.True(t, isOpened(leaderShard.ID))
+	assert.Equal(t, uint64(0), s.getLazyReplicaCount())
+}
+
 func TestStoreRemoveReplica(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -215,12 +336,33 @@ func TestVacuum(t *testing.T) {
 		removeData: true,
 	}))
 
-	c := 0
-	assert.NoError(t, kv.Scan(keysutil.EncodeShardStart(shard.Start, nil), keysutil.EncodeShardEnd(shard.End, nil), func(key, value []byte) (bool, error) {
-		c++
-		return true, nil
-	}, false))
-	assert.Equal(t, 0, c)
+	// the data is removed in background
+	assert.Eventually(t, func() bool {
+		c := 0
+		assert.NoError(t, kv.Scan(keysutil.EncodeShardStart(shard.Start, nil), keysutil.EncodeShardEnd(shard.End, nil), func(key, value []byte) (bool, error) {
+			c++
+			return true, nil
+		}, false))
+		return c == 0
+	}, testWaitTimeout, time.Millisecond*10)
+}
+
+func TestCheckExpectedEpoch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	newBatch := func(cmdType rpcpb.CmdType, expected *Epoch) rpcpb.RequestBatch {
+		return rpcpb.RequestBatch{Requests: []rpcpb.Request{{Type: cmdType, ExpectedEpoch: expected}}}
+	}
+	shard := Shard{Epoch: Epoch{Generation: 2, ConfigVer: 3}}
+
+	assert.True(t, checkExpectedEpoch(shard, newBatch(rpcpb.Write, nil)))
+	assert.True(t, checkExpectedEpoch(shard, newBatch(rpcpb.Write, &Epoch{Generation: 2, ConfigVer: 3})))
+	assert.False(t, checkExpectedEpoch(shard, newBatch(rpcpb.Write, &Epoch{Generation: 1, ConfigVer: 3})))
+	assert.False(t, checkExpectedEpoch(shard, newBatch(rpcpb.Write, &Epoch{Generation: 2, ConfigVer: 2})))
+	// newer than the current epoch, e.g. the replica is behind
+	assert.False(t, checkExpectedEpoch(shard, newBatch(rpcpb.Txn, &Epoch{Generation: 3, ConfigVer: 3})))
+	// the reads are not checked
+	assert.True(t, checkExpectedEpoch(shard, newBatch(rpcpb.Read, &Epoch{Generation: 1})))
 }
 
 func TestCheckEpoch(t *testing.T) {
@@ -322,6 +464,40 @@ func TestValidateStoreID(t *testing.T) {
 	assert.NotNil(t, s.validateStoreID(rpcpb.RequestBatch{Header: rpcpb.RequestBatchHeader{Replica: Replica{StoreID: 1}}}))
 }
 
+func TestValidateUserKeys(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	reserved := keys.GetSystemKey([]byte("k"))
+	assert.NoError(t, validateUserKeys(rpcpb.Request{Type: rpcpb.Write, Key: []byte("k")}))
+	assert.NoError(t, validateUserKeys(rpcpb.Request{Type: rpcpb.Read, Key: reserved}))
+	assert.Error(t, validateUserKeys(rpcpb.Request{Type: rpcpb.Write, Key: reserved}))
+	assert.Error(t, validateUserKeys(rpcpb.Request{Type: rpcpb.Txn, Key: reserved}))
+	assert.Error(t, validateUserKeys(rpcpb.Request{Type: rpcpb.Write, Key: []byte("k"),
+		KeysRange: &rpcpb.Range{From: []byte("k"), To: reserved}}))
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+	var resp rpcpb.ResponseBatch
+	assert.NoError(t, s.OnRequestWithCB(rpcpb.Request{ID: []byte{1}, Type: rpcpb.Write, Key: reserved},
+		func(r rpcpb.ResponseBatch) {
+			resp = r
+		}))
+	assert.Contains(t, resp.Header.Error.Message, keys.ErrReservedKey.Error())
+}
+
+func TestValidateCommand(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s := &store{cfg: &config.Config{}}
+	assert.NoError(t, s.validateCommand(rpcpb.Request{Type: rpcpb.Write, CustomType: 10000}))
+
+	s.cfg.Customize.CustomCommandRegistry = command.NewRegistry()
+	s.cfg.Customize.CustomCommandRegistry.MustRegister(command.Command{Type: 10000, Name: "set", RequestType: rpcpb.Write})
+	assert.NoError(t, s.validateCommand(rpcpb.Request{Type: rpcpb.Write, CustomType: 10000}))
+	assert.True(t, errors.Is(s.validateCommand(rpcpb.Request{Type: rpcpb.Write, CustomType: 10001}), command.ErrUnknownCommand))
+	assert.True(t, errors.Is(s.validateCommand(rpcpb.Request{Type: rpcpb.Read, CustomType: 10000}), command.ErrInvalidCommand))
+}
+
 func TestCacheAndRemoveDroppedVoteMsg(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -348,13 +524,22 @@ func TestGetStoreHeartbeat(t *testing.T) {
 	s, cancel := newTestStore(t)
 	defer cancel()
 
-	s.addReplica(&replica{shardID: 1})
+	pr := &replica{shardID: 1, replicaID: 10}
+	pr.readLoad.add(100, 2)
+	s.addReplica(pr)
 	s.addReplica(&replica{shardID: 2})
 	s.trans = transport.NewTransport(nil, "", 0, nil, nil, nil, nil, nil, s.cfg.FS)
 	defer s.trans.Close()
 	req, err := s.getStoreHeartbeat(time.Now())
 	assert.NoError(t, err)
 	assert.Equal(t, uint64(2), req.Stats.ShardCount)
+	assert.Equal(t, []metapb.ReplicaReadStats{{ShardID: 1, ReplicaID: 10, ReadBytes: 100, ReadKeys: 2}},
+		req.Stats.ReplicaReadStats)
+
+	// the read load is reset after reported
+	req, err = s.getStoreHeartbeat(time.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, req.Stats.ReplicaReadStats)
 }
 
 func TestDoShardHeartbeatRsp(t *testing.T) {
@@ -489,3 +674,125 @@ func TestOnRequestWithLease(t *testing.T) {
 		}()
 	}
 }
+
+func TestOnRequestToTrashedShardGroup(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+	pr := &replica{shardID: 1, replica: Replica{ID: 1}, store: s}
+	pr.sm = &stateMachine{}
+	pr.sm.metadataMu.shard = Shard{ID: 1, Group: 1}
+	s.addReplica(pr)
+
+	s.setTrashedShardGroups([]rpcpb.TrashedShardGroup{{Group: 1}})
+	assert.True(t, s.isShardGroupTrashed(1))
+	assert.False(t, s.isShardGroupTrashed(0))
+
+	var resp rpcpb.ResponseBatch
+	assert.NoError(t, s.OnRequestWithCB(rpcpb.Request{ToShard: 1, Type: rpcpb.Read}, func(r rpcpb.ResponseBatch) {
+		resp = r
+	}))
+	require.NotNil(t, resp.Header.Error.ShardUnavailable)
+	assert.Equal(t, uint64(1), resp.Header.Error.ShardUnavailable.ShardID)
+
+	s.setTrashedShardGroups(nil)
+	assert.False(t, s.isShardGroupTrashed(1))
+}
+
+func TestTuningAdvices(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+	s.cfg.Raft.RaftLog.GroupCommitWindow.Duration = 0
+
+	now := time.Now()
+	s.handleTuningAdviceTask(now)
+	assert.Empty(t, s.TuningAdvices())
+
+	// the WAL is synced so frequently without the group commit
+	for i := 0; i < 10; i++ {
+		require.NoError(t, s.kvStorage.Sync())
+	}
+	s.handleTuningAdviceTask(now.Add(time.Millisecond))
+	advices := s.TuningAdvices()
+	require.Equal(t, 1, len(advices))
+	assert.Equal(t, "group-commit", advices[0].Rule)
+}
+
+type testFeatureDataStorage struct {
+	storage.DataStorage
+	feature storage.Feature
+}
+
+func (s testFeatureDataStorage) Feature() storage.Feature {
+	return s.feature
+}
+
+func TestGetShardFeature(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	cfg := &config.Config{}
+	cfg.Storage.DataStorageFactory = func(group uint64) storage.DataStorage {
+		return testFeatureDataStorage{feature: storage.Feature{
+			ShardCapacityBytes:   100,
+			ShardSplitCheckBytes: 80,
+			ForceCompactCount:    10,
+		}}
+	}
+	cfg.Prophet.Replication.ShardSizeClasses = []pconfig.ShardSizeClass{
+		{Group: 1, ShardCapacityBytes: 10},
+		{Group: 2, ShardCapacityBytes: 1000, ShardSplitCheckBytes: 500},
+		{Group: 3, MaxMergeShardSize: 1},
+	}
+	s := &store{cfg: cfg}
+
+	cases := []struct {
+		group           uint64
+		capacity, check uint64
+	}{
+		{group: 0, capacity: 100, check: 80},
+		{group: 1, capacity: 10, check: 8},
+		{group: 2, capacity: 1000, check: 500},
+		{group: 3, capacity: 100, check: 80},
+	}
+	for idx, c := range cases {
+		feature := s.getShardFeature(c.group)
+		assert.Equal(t, c.capacity, feature.ShardCapacityBytes, "index %d", idx)
+		assert.Equal(t, c.check, feature.ShardSplitCheckBytes, "index %d", idx)
+		assert.Equal(t, uint64(10), feature.ForceCompactCount, "index %d", idx)
+	}
+}
+
+func TestGetSplitFeature(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	cfg := &config.Config{}
+	cfg.Storage.DataStorageFactory = func(group uint64) storage.DataStorage {
+		return testFeatureDataStorage{feature: storage.Feature{
+			ShardCapacityBytes:   100,
+			ShardSplitCheckBytes: 80,
+		}}
+	}
+	s := &store{cfg: cfg, keyspaces: core.NewKeyspaceCache()}
+	s.keyspaces.Reset([]metapb.Keyspace{
+		{Name: "a", Group: 1, Prefix: core.GetKeyspacePrefix("a"), ShardCapacityBytes: 1000},
+		{Name: "b", Group: 1, Prefix: core.GetKeyspacePrefix("b")},
+	})
+
+	cases := []struct {
+		shard           Shard
+		capacity, check uint64
+	}{
+		{shard: Shard{Group: 1, Start: []byte("a/"), End: []byte("a/1")}, capacity: 1000, check: 800},
+		{shard: Shard{Group: 1, Start: []byte("b/"), End: []byte("b0")}, capacity: 100, check: 80},
+		{shard: Shard{Group: 1, Start: []byte("a/"), End: []byte("b0")}, capacity: 100, check: 80},
+		{shard: Shard{Group: 2, Start: []byte("a/"), End: []byte("a/1")}, capacity: 100, check: 80},
+	}
+	for idx, c := range cases {
+		feature := s.getSplitFeature(c.shard)
+		assert.Equal(t, c.capacity, feature.ShardCapacityBytes, "index %d", idx)
+		assert.Equal(t, c.check, feature.ShardSplitCheckBytes, "index %d", idx)
+	}
+}
@@ -38,12 +38,32 @@ func (s *store) startTimerTasks() {
 		compactLogCheckTicker := time.NewTicker(s.cfg.Replication.CompactLogCheckDuration.Duration)
 		defer compactLogCheckTicker.Stop()
 
+		statsPersistTicker := time.NewTicker(s.cfg.Replication.ShardStatsPersistDuration.Duration)
+		defer statsPersistTicker.Stop()
+
 		refreshScheduleGroupRuleTicker := time.NewTicker(time.Second * 30)
 		defer refreshScheduleGroupRuleTicker.Stop()
 
 		debugTicker := time.NewTicker(time.Second * 10)
 		defer debugTicker.Stop()
 
+		snapshotGCTicker := time.NewTicker(s.cfg.Snapshot.GCInterval.Duration)
+		defer snapshotGCTicker.Stop()
+
+		tuningAdviceTicker := time.NewTicker(tuningAdviceSampleInterval)
+		defer tuningAdviceTicker.Stop()
+
+		ttlPurgeTicker := time.NewTicker(ttlPurgeInterval)
+		defer ttlPurgeTicker.Stop()
+
+		task := s.watchdog.Register(storeTimerComponent, "timer-tasks")
+		defer s.watchdog.Unregister(task)
+		run := func(fn func()) {
+			task.Begin()
+			defer task.End()
+			fn()
+		}
+
 		for {
 			select {
 			case <-s.stopper.ShouldStop():
@@ -51,25 +71,105 @@ func (s *store) startTimerTasks() {
 					s.storeField())
 				return
 			case <-compactLogCheckTicker.C:
-				s.handleCompactLogTask()
+				run(s.handleCompactLogTask)
 			case <-stateCheckTicker.C:
-				s.handleShardStateCheckTask()
-			case <-shardLeaderheartbeatTicker.C:
-				s.handleShardHeartbeatTask()
+				run(s.handleShardStateCheckTask)
+			case now := <-shardLeaderheartbeatTicker.C:
+				run(func() {
+					s.handleShardHeartbeatTask()
+					s.handleLazyReplicaCheckTask(now)
+				})
 			case <-storeheartbeatTicker.C:
-				s.handleStoreHeartbeatTask(last)
-				last = time.Now()
+				run(func() {
+					s.handleStoreHeartbeatTask(last)
+					s.handleShardMetricsTask()
+					last = time.Now()
+				})
+			case <-statsPersistTicker.C:
+				run(s.handleShardStatsPersistTask)
 			case <-refreshScheduleGroupRuleTicker.C:
-				s.handleRefreshScheduleGroupRule()
+				run(func() {
+					s.handleRefreshScheduleGroupRule()
+					s.handleRefreshKeyspaces()
+					s.handleRefreshTrashedShardGroups()
+				})
 			case <-debugTicker.C:
-				s.doLogDebugInfo()
+				run(s.doLogDebugInfo)
+			case <-snapshotGCTicker.C:
+				run(func() {
+					s.handleSnapshotGCTask(s.cfg.Snapshot.GCInterval.Duration)
+				})
+			case now := <-tuningAdviceTicker.C:
+				run(func() {
+					s.handleTuningAdviceTask(now)
+				})
+			case <-ttlPurgeTicker.C:
+				run(s.handleTTLPurgeTask)
+			}
+		}
+	})
+
+	if s.cfg.Compaction.Enable && len(s.cfg.Compaction.Windows) > 0 {
+		s.stopper.RunWorker(func() {
+			compactionTicker := time.NewTicker(s.cfg.Compaction.CheckDuration.Duration)
+			defer compactionTicker.Stop()
+
+			for {
+				select {
+				case <-s.stopper.ShouldStop():
+					s.logger.Info("timer based tasks stopped",
+						s.storeField())
+					return
+				case now := <-compactionTicker.C:
+					if s.cfg.Compaction.InWindow(now) {
+						s.handleCompactionTask(now)
+					}
+				}
+			}
+		})
+	}
+
+	s.stopper.RunWorker(func() {
+		sizeRefreshTicker := time.NewTicker(s.cfg.Compaction.SizeRefreshDuration.Duration)
+		defer sizeRefreshTicker.Stop()
+
+		for {
+			select {
+			case <-s.stopper.ShouldStop():
+				s.logger.Info("timer based tasks stopped",
+					s.storeField())
+				return
+			case <-sizeRefreshTicker.C:
+				s.handleSizeRefreshTask()
 			}
 		}
 	})
 
 	s.cfg.Storage.ForeachDataStorageFunc(func(group uint64, ds storage.DataStorage) {
+		if _, ok := ds.(storage.KeySampler); ok && s.getShardFeature(group).ShardBuckets > 0 {
+			s.stopper.RunWorker(func() {
+				interval := s.getShardFeature(group).ShardBucketRefreshDuration
+				if interval <= 0 {
+					interval = defaultShardBucketRefreshDuration
+				}
+				bucketRefreshTicker := time.NewTicker(interval)
+				defer bucketRefreshTicker.Stop()
+
+				for {
+					select {
+					case <-s.stopper.ShouldStop():
+						s.logger.Info("timer based tasks stopped",
+							s.storeField())
+						return
+					case <-bucketRefreshTicker.C:
+						s.handleBucketRefreshTask(group)
+					}
+				}
+			})
+		}
+
 		s.stopper.RunWorker(func() {
-			policy := ds.Feature()
+			policy := s.getShardFeature(group)
 			if policy.DisableShardSplit {
 				return
 			}
@@ -134,6 +234,16 @@ func (s *store) handleShardStateCheckTask() {
 	}
 }
 
+// handleSnapshotGCTask removes the snapshot dirs not used, the dirs modified in
+// the last gracePeriod are kept.
+func (s *store) handleSnapshotGCTask(gracePeriod time.Duration) {
+	if err := s.snapshots.gc(time.Now(), gracePeriod); err != nil {
+		s.logger.Error("fail to remove the unused snapshot dirs",
+			s.storeField(),
+			zap.Error(err))
+	}
+}
+
 func (s *store) handleSplitCheckTask(group uint64) {
 	s.forEachReplica(func(pr *replica) bool {
 		if pr.group == group &&
@@ -156,6 +266,15 @@ func (s *store) handleShardHeartbeatTask() {
 	})
 }
 
+// handleShardStatsPersistTask persists the stats of all the replicas, since any
+// of them may become the leader after the store restarted.
+func (s *store) handleShardStatsPersistTask() {
+	s.forEachReplica(func(pr *replica) bool {
+		pr.addAction(action{actionType: persistStatsAction})
+		return true
+	})
+}
+
 func (s *store) handleCompactLogTask() {
 	s.forEachReplica(func(pr *replica) bool {
 		if pr.isLeader() {
@@ -171,6 +290,7 @@ func (s *store) handleStoreHeartbeatTask(last time.Time) {
 		return
 	}
 
+	sent := time.Now()
 	rsp, err := s.pd.GetClient().StoreHeartbeat(req)
 	if err != nil {
 		s.logger.Error("fail to send store heartbeat",
@@ -178,6 +298,9 @@ func (s *store) handleStoreHeartbeatTask(last time.Time) {
 			zap.Error(err))
 		return
 	}
+	if rsp.Timestamp > 0 {
+		s.handleClockOffset(sent, time.Now(), rsp.Timestamp)
+	}
 	if s.cfg.Customize.CustomStoreHeartbeatDataProcessor != nil {
 		err := s.cfg.Customize.CustomStoreHeartbeatDataProcessor.HandleHeartbeatRsp(rsp.Data)
 		if err != nil {
@@ -201,3 +324,31 @@ func (s *store) handleRefreshScheduleGroupRule() bool {
 	s.groupController.setRules(rules)
 	return true
 }
+
+func (s *store) handleRefreshKeyspaces() bool {
+	keyspaces, err := s.pd.GetClient().GetKeyspaces()
+	if err != nil {
+		s.logger.Error("failed to load keyspaces from prophet",
+			zap.Error(err))
+		return false
+	}
+
+	s.logger.Debug("keyspaces loaded",
+		zap.Int("count", len(keyspaces)))
+	s.keyspaces.Reset(keyspaces)
+	return true
+}
+
+func (s *store) handleRefreshTrashedShardGroups() bool {
+	groups, err := s.pd.GetClient().GetTrashedShardGroups()
+	if err != nil {
+		s.logger.Error("failed to load trashed shard groups from prophet",
+			zap.Error(err))
+		return false
+	}
+
+	s.logger.Debug("trashed shard groups loaded",
+		zap.Int("count", len(groups)))
+	s.setTrashedShardGroups(groups)
+	return true
+}
@@ -0,0 +1,103 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"time"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/util/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	// ttlPurgeInterval the interval of purging the expired keys of the shards
+	// led by the store
+	ttlPurgeInterval = time.Minute
+)
+
+const (
+	// maxTTLPurgeKeys the max number of the keys purged by a proposal
+	maxTTLPurgeKeys = 256
+)
+
+// handleTTLPurgeTask proposes the removal of the expired keys found by the
+// leaders, the keys are removed when the proposals applied, so all replicas
+// remove the same keys.
+func (s *store) handleTTLPurgeTask() {
+	s.forEachReplica(func(pr *replica) bool {
+		if pr.isLeader() {
+			pr.maybePurgeExpired()
+		}
+		return true
+	})
+}
+
+func (pr *replica) maybePurgeExpired() {
+	req, ok, err := pr.newPurgeExpiredRequest()
+	if err != nil {
+		pr.logger.Error("failed to find expired keys",
+			zap.Error(err))
+		return
+	}
+	if !ok {
+		return
+	}
+	if err := pr.addRequest(newReqCtx(req, nil)); err != nil {
+		pr.logger.Debug("failed to purge expired keys",
+			zap.Error(err))
+	}
+}
+
+// newPurgeExpiredRequest returns the request to purge the expired keys of the
+// shard, false is returned if the data storage has no TTL or no key expired.
+func (pr *replica) newPurgeExpiredRequest() (rpcpb.Request, bool, error) {
+	wrapper, ok := pr.sm.dataStorage.(storage.KVStorageWrapper)
+	if !ok {
+		return rpcpb.Request{}, false, nil
+	}
+	ttlStore, ok := wrapper.GetKVStorage().(storage.TTLStore)
+	if !ok {
+		return rpcpb.Request{}, false, nil
+	}
+
+	shard := pr.getShard()
+	expired, err := ttlStore.ExpiredKeys(keysutil.EncodeShardStart(shard.Start, nil),
+		keysutil.EncodeShardEnd(shard.End, nil), maxTTLPurgeKeys)
+	if err != nil || len(expired) == 0 {
+		return rpcpb.Request{}, false, err
+	}
+	for i := range expired {
+		expired[i].Key = keysutil.DecodeDataKey(expired[i].Key)
+	}
+
+	pr.logger.Debug("purge expired keys",
+		zap.Int("keys", len(expired)),
+		log.HexField("first", expired[0].Key))
+	purge := executor.NewPurgeExpiredRequest(expired)
+	return rpcpb.Request{
+		ID:         uuid.NewV4().Bytes(),
+		Group:      shard.Group,
+		ToShard:    shard.ID,
+		Type:       rpcpb.Write,
+		CustomType: purge.CmdType,
+		Key:        purge.Key,
+		Epoch:      shard.Epoch,
+		Cmd:        purge.Cmd,
+	}, true, nil
+}
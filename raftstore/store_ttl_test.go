@@ -0,0 +1,77 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+	"github.com/matrixorigin/matrixcube/storage/kv"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	"github.com/matrixorigin/matrixcube/util"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPurgeExpiredRequest(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, cancel := newTestStore(t)
+	defer cancel()
+	shard := Shard{ID: 1, Group: 2, Start: []byte("a"), End: []byte("c"),
+		Epoch: metapb.ShardEpoch{Generation: 3}, Replicas: []Replica{{ID: 2}}}
+	pr := newTestReplica(shard, Replica{ID: 2}, s)
+
+	// no TTL supported
+	pr.sm.dataStorage = &testDataStorage{}
+	_, ok, err := pr.newPurgeExpiredRequest()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	base := kv.NewBaseStorage(mem.NewStorage(), vfs.NewMemFS())
+	ds := kv.NewKVDataStorage(base, executor.NewKVExecutor(base))
+	defer ds.Close()
+	pr.sm.dataStorage = ds
+	_, ok, err = pr.newPurgeExpiredRequest()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	expired := time.Now().Add(-time.Second).UnixNano()
+	wb := base.NewWriteBatch().(util.WriteBatch)
+	defer wb.Close()
+	base.SetTTL(wb, keysutil.EncodeDataKey([]byte("b"), nil), []byte("b"), expired)
+	base.SetTTL(wb, keysutil.EncodeDataKey([]byte("a1"), nil), []byte("a1"), time.Now().Add(time.Hour).UnixNano())
+	// out of the shard
+	base.SetTTL(wb, keysutil.EncodeDataKey([]byte("d"), nil), []byte("d"), expired)
+	require.NoError(t, base.Write(wb, false))
+
+	req, ok, err := pr.newPurgeExpiredRequest()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, rpcpb.Write, req.Type)
+	assert.Equal(t, executor.CmdKVPurgeExpired, req.CustomType)
+	assert.Equal(t, uint64(2), req.Group)
+	assert.Equal(t, uint64(1), req.ToShard)
+	assert.Equal(t, shard.Epoch, req.Epoch)
+	assert.Equal(t, []byte("b"), req.Key)
+	var purge executor.PurgeExpiredRequest
+	require.NoError(t, purge.Unmarshal(req.Cmd))
+	assert.Equal(t, []storage.ExpiredKey{{Key: []byte("b"), Expiration: expired}}, purge.Keys)
+}
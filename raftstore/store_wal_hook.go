@@ -0,0 +1,181 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/aware"
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/pb/errorpb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+const (
+	defaultWALMaxPendingEntries = 1024
+	walShipRetryInterval        = time.Millisecond * 100
+)
+
+// walShipper ships the committed raft logs of a shard group by the WAL hook. In
+// the async mode, the raft logs are queued and shipped by a background worker.
+type walShipper struct {
+	hook    aware.WALHook
+	logger  *zap.Logger
+	entries chan aware.WALEntry
+}
+
+func newWALShipper(hook aware.WALHook, logger *zap.Logger) *walShipper {
+	ws := &walShipper{
+		hook:   hook,
+		logger: logger,
+	}
+	if hook.Async {
+		size := hook.MaxPendingEntries
+		if size <= 0 {
+			size = defaultWALMaxPendingEntries
+		}
+		ws.entries = make(chan aware.WALEntry, size)
+	}
+	return ws
+}
+
+// ship returns false if the raft log is neither shipped nor queued, the apply of
+// the raft log is paused and retried later.
+func (ws *walShipper) ship(entry aware.WALEntry) bool {
+	if !ws.hook.Async {
+		if err := ws.hook.Ship(entry); err != nil {
+			ws.logger.Error("fail to ship raft log, retry later",
+				log.ShardIDField(entry.Shard.ID),
+				log.IndexField(entry.Index),
+				zap.Error(err))
+			return false
+		}
+		return true
+	}
+
+	// the raft log is released once applied
+	entry.Data = append([]byte(nil), entry.Data...)
+	select {
+	case ws.entries <- entry:
+		return true
+	default:
+		return false
+	}
+}
+
+// busy returns true if too many raft logs are waiting to be shipped
+func (ws *walShipper) busy() bool {
+	return ws.hook.Async && len(ws.entries) >= cap(ws.entries)
+}
+
+func (ws *walShipper) run(stopC chan struct{}) {
+	for {
+		select {
+		case <-stopC:
+			return
+		case entry := <-ws.entries:
+			for {
+				err := ws.hook.Ship(entry)
+				if err == nil {
+					break
+				}
+				ws.logger.Error("fail to ship raft log, retry later",
+					log.ShardIDField(entry.Shard.ID),
+					log.IndexField(entry.Index),
+					zap.Error(err))
+				select {
+				case <-stopC:
+					return
+				case <-time.After(walShipRetryInterval):
+				}
+			}
+		}
+	}
+}
+
+// getWALShipper returns nil if the raft logs of the group are not shipped
+func (s *store) getWALShipper(group uint64) *walShipper {
+	if v, ok := s.walShippers.Load(group); ok {
+		return v.(*walShipper)
+	}
+
+	var ws *walShipper
+	if factory := s.cfg.Customize.CustomWALHookFactory; factory != nil {
+		if hook := factory(group); hook != nil && hook.Ship != nil {
+			ws = newWALShipper(*hook, s.logger.Named("wal-hook").With(zap.Uint64("group", group)))
+		}
+	}
+	if v, loaded := s.walShippers.LoadOrStore(group, ws); loaded {
+		return v.(*walShipper)
+	}
+	if ws != nil && ws.hook.Async {
+		s.stopper.RunWorker(func() {
+			ws.run(s.stopper.ShouldStop())
+		})
+	}
+	return ws
+}
+
+// checkWALBusy returns the load hints if the write request should be rejected
+// because the committed entries of the replica are not shipped, or too many raft
+// logs of the shard group are waiting to be shipped.
+func (pr *replica) checkWALBusy(req rpcpb.Request) (*errorpb.ServerIsBusy, bool) {
+	if req.Type != rpcpb.Write || pr.store == nil {
+		return nil, false
+	}
+	ws := pr.store.getWALShipper(pr.getShard().Group)
+	if ws == nil {
+		return nil, false
+	}
+	if n := atomic.LoadUint64(&pr.walUnshipped); n > 0 {
+		return &errorpb.ServerIsBusy{QueueDepth: n}, true
+	}
+	if ws.busy() {
+		return &errorpb.ServerIsBusy{QueueDepth: uint64(len(ws.entries))}, true
+	}
+	return nil, false
+}
+
+// shipWAL ships the committed entries not shipped yet by the WAL hook of the
+// shard group, returns the entries can be applied and the entries not shipped.
+func (pr *replica) shipWAL(entries []raftpb.Entry) ([]raftpb.Entry, []raftpb.Entry) {
+	shard := pr.getShard()
+	ws := pr.store.getWALShipper(shard.Group)
+	if ws == nil {
+		return entries, nil
+	}
+
+	for i, entry := range entries {
+		if entry.Index <= pr.walShippedIndex || len(entry.Data) == 0 {
+			continue
+		}
+		if !ws.ship(aware.WALEntry{
+			Shard: shard,
+			Index: entry.Index,
+			Term:  entry.Term,
+			Type:  entry.Type,
+			Data:  entry.Data,
+		}) {
+			atomic.StoreUint64(&pr.walUnshipped, uint64(len(entries)-i))
+			return entries[:i], entries[i:]
+		}
+		pr.walShippedIndex = entry.Index
+	}
+	atomic.StoreUint64(&pr.walUnshipped, 0)
+	return entries, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/aware"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+)
+
+func newTestWALEntries(indexes ...uint64) []raftpb.Entry {
+	var entries []raftpb.Entry
+	for _, index := range indexes {
+		entries = append(entries, raftpb.Entry{Index: index, Term: 1, Data: []byte{byte(index)}})
+	}
+	return entries
+}
+
+func TestShipWALSync(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	var shipped []uint64
+	fail := true
+	s.cfg.Customize.CustomWALHookFactory = func(group uint64) *aware.WALHook {
+		if group != 1 {
+			return nil
+		}
+		return &aware.WALHook{Ship: func(entry aware.WALEntry) error {
+			if fail && entry.Index == 3 {
+				return errors.New("unavailable")
+			}
+			shipped = append(shipped, entry.Index)
+			return nil
+		}}
+	}
+	pr := newTestReplica(Shard{ID: 1, Group: 1}, Replica{ID: 1}, s)
+	write := rpcpb.Request{Type: rpcpb.Write}
+
+	entries := newTestWALEntries(1, 2, 3, 4)
+	entries[1].Data = nil // noop entry
+	applied, unshipped := pr.shipWAL(entries)
+	assert.Equal(t, entries[:2], applied)
+	assert.Equal(t, entries[2:], unshipped)
+	assert.Equal(t, []uint64{1}, shipped)
+	busy, ok := pr.checkWALBusy(write)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), busy.QueueDepth)
+	_, ok = pr.checkWALBusy(rpcpb.Request{Type: rpcpb.Read})
+	assert.False(t, ok, "reads are not rejected")
+
+	fail = false
+	applied, unshipped = pr.shipWAL(append(entries[:1:1], entries[2:]...))
+	assert.Equal(t, 3, len(applied))
+	assert.Empty(t, unshipped)
+	assert.Equal(t, []uint64{1, 3, 4}, shipped, "shipped once")
+	_, ok = pr.checkWALBusy(write)
+	assert.False(t, ok)
+
+	// no hook of the group
+	other := newTestReplica(Shard{ID: 2, Group: 2}, Replica{ID: 2}, s)
+	applied, unshipped = other.shipWAL(entries)
+	assert.Equal(t, entries, applied)
+	assert.Empty(t, unshipped)
+}
+
+func TestShipWALAsync(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, cancel := newTestStore(t)
+	defer cancel()
+
+	// not started, the entries are kept in the queue
+	ws := newWALShipper(aware.WALHook{
+		Async:             true,
+		MaxPendingEntries: 2,
+		Ship:              func(entry aware.WALEntry) error { return nil },
+	}, zap.NewNop())
+	s.walShippers.Store(uint64(1), ws)
+	pr := newTestReplica(Shard{ID: 1, Group: 1}, Replica{ID: 1}, s)
+
+	entries := newTestWALEntries(1, 2, 3)
+	applied, unshipped := pr.shipWAL(entries)
+	assert.Equal(t, entries[:2], applied)
+	assert.Equal(t, entries[2:], unshipped)
+	assert.True(t, ws.busy())
+	_, ok := pr.checkWALBusy(rpcpb.Request{Type: rpcpb.Write})
+	assert.True(t, ok)
+
+	// the data is copied before queued
+	entries[0].Data[0] = 0
+	entry := <-ws.entries
+	assert.Equal(t, uint64(1), entry.Index)
+	assert.Equal(t, []byte{1}, entry.Data)
+	assert.False(t, ws.busy())
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/metric"
+	"github.com/matrixorigin/matrixcube/util/watchdog"
+)
+
+const (
+	// raftWorkerComponent the workers of the worker pool
+	raftWorkerComponent = "raft-worker"
+	// storeTimerComponent the loop of the timer based tasks of the store
+	storeTimerComponent = "store-timer"
+)
+
+// newStoreWatchdog returns the watchdog of the store, nil if the watchdog is
+// disabled
+func newStoreWatchdog(cfg config.WatchdogConfig, logger *zap.Logger) *watchdog.Watchdog {
+	if cfg.Disable {
+		return nil
+	}
+	return watchdog.New(logger,
+		watchdog.WithCheckInterval(cfg.CheckDuration.Duration),
+		watchdog.WithDeadline(cfg.Deadline.Duration),
+		watchdog.WithStalledFunc(metric.SetWatchdogStalledTasks))
+}
+
+// StalledTasks returns the tasks of the store stalled for longer than the
+// deadline of the watchdog
+func (s *store) StalledTasks() []string {
+	if s.watchdog == nil {
+		return nil
+	}
+	return s.watchdog.Stalled()
+}
@@ -32,6 +32,7 @@ import (
 	_ "github.com/matrixorigin/matrixcube/components/prophet/schedulers"
 	"github.com/matrixorigin/matrixcube/components/prophet/util/typeutil"
 	"github.com/matrixorigin/matrixcube/config"
+	"github.com/matrixorigin/matrixcube/migration"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/matrixorigin/matrixcube/storage"
@@ -76,11 +77,17 @@ type testClusterOptions struct {
 	useDisk               bool
 	enableAdvertiseAddr   bool
 	dataOpts              *cpebble.Options
+	walDisabledGroups     []uint64
 	shardCapacityBytes    uint64
 	shardSplitCheckBytes  uint64
+	shardBuckets          int
+	bucketRefreshDuration time.Duration
 	disableSchedule       bool
 	enableParallelTest    bool
 	useProphetInitCluster bool
+	// storeVersions node -> the version of the store, the nodes without the
+	// version use the default version and migrations
+	storeVersions map[int]TestStoreVersion
 
 	storageStatsReaderFunc func(*store) storageStatsReader
 }
@@ -202,6 +209,15 @@ func WithDataStorageOption(dataOpts *cpebble.Options) TestClusterOption {
 	}
 }
 
+// WithDataWALDisabledGroups the data of the groups are written to the
+// dedicated pebble storages opened with the WAL disabled, the other groups
+// share the data storage with the WAL.
+func WithDataWALDisabledGroups(groups ...uint64) TestClusterOption {
+	return func(opts *testClusterOptions) {
+		opts.walDisabledGroups = groups
+	}
+}
+
 // WithAppendTestClusterAdjustConfigFunc adjust config
 func WithAppendTestClusterAdjustConfigFunc(value func(node int, cfg *config.Config)) TestClusterOption {
 	return func(opts *testClusterOptions) {
@@ -217,6 +233,53 @@ func WithTestClusterSplitPolicy(shardCapacityBytes, shardSplitCheckBytes uint64)
 	}
 }
 
+// WithTestClusterShardBuckets enables the bucket stats of the shards
+func WithTestClusterShardBuckets(buckets int, refreshDuration time.Duration) TestClusterOption {
+	return func(opts *testClusterOptions) {
+		opts.shardBuckets = buckets
+		opts.bucketRefreshDuration = refreshDuration
+	}
+}
+
+// WithTestClusterStoreVersions set the versions of the stores, the versions[i]
+// is the version of the node i, the nodes after the versions use the default
+// version and migrations. See `TestStoreVersion` and `UpgradeNode`.
+func WithTestClusterStoreVersions(versions ...TestStoreVersion) TestClusterOption {
+	return func(opts *testClusterOptions) {
+		opts.storeVersions = make(map[int]TestStoreVersion, len(versions))
+		for node, v := range versions {
+			opts.storeVersions[node] = v
+		}
+	}
+}
+
+// TestStoreVersion the version string, the migrations and the config of a
+// store run by the test cluster. It does not run a previous release: the stores
+// of all the versions run the current tree, and differ only in the reported
+// version, the pending migrations of the on-disk data and the adjusted config.
+// So it covers the migrations and the config gated features, but not the wire
+// or on-disk compatibility with the code of a previous release.
+type TestStoreVersion struct {
+	// Version the version of the store reported to the prophet
+	Version string
+	// Migrations the migrations of the on-disk data known by the release, the
+	// pending migrations are run when the store is started with the release.
+	Migrations []migration.Migration
+	// AdjustConfig adjusts the config of the store of the release, e.g. disables
+	// the features added after the release.
+	AdjustConfig func(cfg *config.Config)
+}
+
+func (v TestStoreVersion) adjust(cfg *config.Config) {
+	cfg.Version = v.Version
+	registry := migration.NewRegistry()
+	registry.MustRegister(v.Migrations...)
+	cfg.Customize.CustomMigrationRegistry = registry
+	if v.AdjustConfig != nil {
+		v.AdjustConfig(cfg)
+	}
+}
+
 func recreateTestTempDir(fs vfs.FS, tmpDir string) {
 	if err := fs.RemoveAll(tmpDir); err != nil {
 		panic(err)
@@ -586,6 +649,10 @@ type TestRaftCluster interface {
 	StopNode(node int)
 	// RestartNode restart the node
 	RestartNode(node int)
+	// UpgradeNode stops the node, and starts the node with the version and the
+	// existing data, so the pending migrations of the version are run. The
+	// version is kept by the later restarts.
+	UpgradeNode(node int, version TestStoreVersion)
 	// StartNetworkPartition node will in network partition, must call after node started
 	StartNetworkPartition(partitions [][]int)
 	// StopNetworkPartition stop network partition
@@ -980,7 +1047,9 @@ type testRaftCluster struct {
 	stores       []*store
 	awares       []*testShardAware
 	dataStorages []storage.DataStorage
-	status       []bool
+	// walDisabledStorages node -> the data storages of the WAL disabled groups
+	walDisabledStorages []map[uint64]storage.DataStorage
+	status              []bool
 }
 
 // NewSingleTestClusterStore create test cluster with 1 node
@@ -1072,6 +1141,7 @@ func (c *testRaftCluster) reset(init bool, opts ...TestClusterOption) {
 	c.stores = make([]*store, c.opts.nodes)
 	c.awares = make([]*testShardAware, c.opts.nodes)
 	c.dataStorages = make([]storage.DataStorage, c.opts.nodes)
+	c.walDisabledStorages = make([]map[uint64]storage.DataStorage, c.opts.nodes)
 	for i := 0; i < c.opts.nodes; i++ {
 		c.resetNode(i, init)
 	}
@@ -1081,6 +1151,7 @@ func (c *testRaftCluster) resetNode(node int, init bool) {
 	c.stores[node] = nil
 	c.awares[node] = nil
 	c.dataStorages[node] = nil
+	c.walDisabledStorages[node] = nil
 
 	cfg := &config.Config{}
 	cfg.Logger = log.GetDefaultZapLoggerWithLevel(c.opts.logLevel).WithOptions(zap.OnFatal(zapcore.WriteThenPanic)).With(zap.String("case", c.t.Name()))
@@ -1146,6 +1217,9 @@ func (c *testRaftCluster) resetNode(node int, init bool) {
 	for _, fn := range c.opts.adjustConfigFuncs {
 		fn(node, cfg)
 	}
+	if v, ok := c.opts.storeVersions[node]; ok {
+		v.adjust(cfg)
+	}
 
 	// check whether the raft tickinterval is set properly.
 	// If the time that the raft log persists to disk is longer
@@ -1173,18 +1247,36 @@ func (c *testRaftCluster) resetNode(node int, init bool) {
 		base := kv.NewBaseStorage(kvs, cfg.FS)
 		dataStorage = kv.NewKVDataStorage(base, executor.NewKVExecutor(kvs),
 			kv.WithLogger(cfg.Logger), kv.WithFeature(storage.Feature{
-				ShardSplitCheckDuration: time.Millisecond * 100,
-				ShardCapacityBytes:      c.opts.shardCapacityBytes,
-				ShardSplitCheckBytes:    c.opts.shardSplitCheckBytes,
+				ShardSplitCheckDuration:    time.Millisecond * 100,
+				ShardCapacityBytes:         c.opts.shardCapacityBytes,
+				ShardSplitCheckBytes:       c.opts.shardSplitCheckBytes,
+				ShardBuckets:               c.opts.shardBuckets,
+				ShardBucketRefreshDuration: c.opts.bucketRefreshDuration,
 			}))
 
+		walDisabledStorages := make(map[uint64]storage.DataStorage)
+		for _, group := range c.opts.walDisabledGroups {
+			s, err := pebble.NewStorage(cfg.FS.PathJoin(cfg.DataPath, fmt.Sprintf("data-%d", group)),
+				cfg.Logger, &cpebble.Options{FS: vfs.NewPebbleFS(cfg.FS), DisableWAL: true})
+			assert.NoError(c.t, err)
+			walDisabledStorages[group] = kv.NewKVDataStorage(kv.NewBaseStorage(s, cfg.FS),
+				executor.NewKVExecutor(s), kv.WithLogger(cfg.Logger), kv.WithWALDisabled(group))
+		}
+
 		cfg.Storage.DataStorageFactory = func(group uint64) storage.DataStorage {
+			if s, ok := walDisabledStorages[group]; ok {
+				return s
+			}
 			return dataStorage
 		}
 		cfg.Storage.ForeachDataStorageFunc = func(cb func(uint64, storage.DataStorage)) {
 			cb(0, dataStorage)
+			for group, s := range walDisabledStorages {
+				cb(group, s)
+			}
 		}
 		c.dataStorages[node] = dataStorage
+		c.walDisabledStorages[node] = walDisabledStorages
 	}
 
 	ts := newTestShardAware(node)
@@ -1293,6 +1385,9 @@ func (c *testRaftCluster) StopNode(node int) {
 	if s != nil {
 		s.Close()
 	}
+	for _, s := range c.walDisabledStorages[node] {
+		s.Close()
+	}
 	c.status[node] = false
 }
 
@@ -1304,6 +1399,18 @@ func (c *testRaftCluster) RestartNode(node int) {
 	c.StartNode(node)
 }
 
+func (c *testRaftCluster) UpgradeNode(node int, version TestStoreVersion) {
+	if c.status[node] {
+		c.StopNode(node)
+	}
+	if c.opts.storeVersions == nil {
+		c.opts.storeVersions = make(map[int]TestStoreVersion)
+	}
+	c.opts.storeVersions[node] = version
+	c.resetNode(node, false)
+	c.StartNode(node)
+}
+
 func (c *testRaftCluster) Stop() {
 	c.stop(true)
 }
@@ -1348,6 +1455,11 @@ func (c *testRaftCluster) closeStorage() {
 			s.Close()
 		}
 	}
+	for _, storages := range c.walDisabledStorages {
+		for _, s := range storages {
+			s.Close()
+		}
+	}
 }
 
 func (c *testRaftCluster) GetPRCount(node int) int {
@@ -14,6 +14,7 @@
 package raftstore
 
 import (
+	"fmt"
 	"reflect"
 	"sync"
 
@@ -22,6 +23,7 @@ import (
 
 	"github.com/matrixorigin/matrixcube/components/log"
 	"github.com/matrixorigin/matrixcube/logdb"
+	"github.com/matrixorigin/matrixcube/util/watchdog"
 )
 
 type replicaLoader interface {
@@ -56,10 +58,13 @@ type replicaWorker struct {
 	requestC   chan replicaEventHandler
 	completedC chan struct{}
 	workerID   uint64
+	// task the watchdog task of the worker, the ticks, the proposals, the
+	// applies and the snapshots of the replicas are all handled by the workers
+	task *watchdog.Task
 }
 
 func newReplicaWorker(logger *zap.Logger, workerID uint64,
-	stopper *syncutil.Stopper, wc *logdb.WorkerContext) *replicaWorker {
+	stopper *syncutil.Stopper, wc *logdb.WorkerContext, task *watchdog.Task) *replicaWorker {
 	w := &replicaWorker{
 		logger:     logger,
 		workerID:   workerID,
@@ -67,6 +72,7 @@ func newReplicaWorker(logger *zap.Logger, workerID uint64,
 		requestC:   make(chan replicaEventHandler, 1),
 		completedC: make(chan struct{}, 1),
 		wc:         wc,
+		task:       task,
 	}
 	stopper.RunWorker(func() {
 		w.workerMain()
@@ -88,9 +94,11 @@ func (w *replicaWorker) workerMain() {
 				zap.Uint64("worker-id", w.workerID))
 			return
 		case h := <-w.requestC:
+			w.task.Begin()
 			if err := w.handleEvent(h); err != nil {
 				panic(err)
 			}
+			w.task.End()
 			w.completed()
 		}
 	}
@@ -146,6 +154,8 @@ type workerPool struct {
 
 	ldb         logdb.LogDB
 	workerCount uint64
+	// watchdog monitors the workers, nil if the watchdog is disabled
+	watchdog *watchdog.Watchdog
 }
 
 func newWorkerPool(logger *zap.Logger, ldb logdb.LogDB, loader replicaLoader, workerCount uint64) *workerPool {
@@ -167,7 +177,8 @@ func newWorkerPool(logger *zap.Logger, ldb logdb.LogDB, loader replicaLoader, wo
 func (p *workerPool) start() {
 	for workerID := uint64(0); workerID < p.workerCount; workerID++ {
 		workerContext := p.ldb.NewWorkerContext()
-		w := newReplicaWorker(p.logger, workerID, p.workerStopper, workerContext)
+		task := p.watchdog.Register(raftWorkerComponent, fmt.Sprintf("worker-%d", workerID))
+		w := newReplicaWorker(p.logger, workerID, p.workerStopper, workerContext, task)
 		p.workers = append(p.workers, w)
 	}
 
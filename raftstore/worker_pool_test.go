@@ -21,6 +21,7 @@ import (
 	"github.com/matrixorigin/matrixcube/logdb"
 	"github.com/matrixorigin/matrixcube/storage/kv/mem"
 	"github.com/matrixorigin/matrixcube/util/leaktest"
+	"github.com/matrixorigin/matrixcube/util/watchdog"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -262,3 +263,35 @@ func TestWorkerPoolCanConcurrentlyProcessMultipleJobs(t *testing.T) {
 func TestWorkerPoolWillNotBlockCallToNotify(t *testing.T) {
 	testWorkerPoolConcurrentJobs(t, true)
 }
+
+func TestWorkerPoolStalledWorkerFoundByWatchdog(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	l := newTestReplicaLoader()
+	h, _ := l.getReplica(10)
+	h.(*testReplicaEventHandler).enableWait()
+	mem := mem.NewStorage()
+	defer mem.Close()
+	ldb := logdb.NewKVLogDB(mem, nil)
+	defer ldb.Close()
+	w := watchdog.New(nil, watchdog.WithCheckInterval(time.Millisecond*10),
+		watchdog.WithDeadline(time.Millisecond*50))
+	w.Start()
+	defer w.Stop()
+	p := newWorkerPool(nil, ldb, l, 1)
+	p.watchdog = w
+	p.start()
+	defer p.close()
+
+	p.notify(h.getShardID())
+	<-h.(*testReplicaEventHandler).invoked
+	for i := 0; i < 100 && len(w.Stalled()) == 0; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Equal(t, []string{"raft-worker/worker-0"}, w.Stalled())
+
+	close(h.(*testReplicaEventHandler).waitC)
+	for i := 0; i < 100 && len(w.Stalled()) > 0; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Empty(t, w.Stalled())
+}
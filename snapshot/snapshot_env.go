@@ -51,7 +51,7 @@ var (
 	SnapshotDirNamePartsRe = regexp.MustCompile(`^snapshot-([0-9A-F]+)-[0-9A-F]+$`)
 	// GenSnapshotDirNameRe is the regex of temp snapshot directory name used when
 	// generating snapshots.
-	GenSnapshotDirNameRe = regexp.MustCompile(`^snapshot-[0-9A-F]+-[0-9A-F]+\.generating$`)
+	GenSnapshotDirNameRe = regexp.MustCompile(`^snapshot-[0-9A-F]+(-[0-9A-F]+)?\.generating$`)
 	// RecvSnapshotDirNameRe is the regex of temp snapshot directory name used when
 	// receiving snapshots from remote NodeHosts.
 	RecvSnapshotDirNameRe = regexp.MustCompile(`^snapshot-[0-9A-F]+-[0-9A-F]+\.receiving$`)
@@ -30,6 +30,7 @@
 package snapshot
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -135,11 +136,17 @@ func TestTempSuffix(t *testing.T) {
 	if !strings.Contains(dir, ".generating") {
 		t.Errorf("unexpected suffix")
 	}
+	if !GenSnapshotDirNameRe.MatchString(filepath.Base(dir)) {
+		t.Errorf("unexpected temp dir name: %s", dir)
+	}
 	env = NewSSEnv(f, 1, 1, 1, 2, ReceivingMode, fs)
 	dir = env.GetTempDir()
 	if !strings.Contains(dir, ".receiving") {
 		t.Errorf("unexpected suffix: %s", dir)
 	}
+	if !RecvSnapshotDirNameRe.MatchString(filepath.Base(dir)) {
+		t.Errorf("unexpected temp dir name: %s", dir)
+	}
 }
 
 func TestFinalSnapshotDirDoesNotContainTempSuffix(t *testing.T) {
@@ -0,0 +1,136 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util"
+)
+
+var (
+	// ErrGroupNotRegistered no executor registered for the shard group
+	ErrGroupNotRegistered = errors.New("shard group not registered")
+	// ErrStaleExecutorVersion the version of the executor is not greater than the
+	// version of the registered executor
+	ErrStaleExecutorVersion = errors.New("stale executor version")
+)
+
+// GroupExecutor is a storage.Executor which routes the requests to the executor
+// registered for the shard group of the shard. It allows a single DataStorage to
+// host multiple different state machines (KV, queue, index, ...) in different
+// shard groups.
+type GroupExecutor interface {
+	storage.Executor
+
+	// Register registers the executor of the shard group with the version. The
+	// executor of the group can only be replaced by a higher version, otherwise
+	// ErrStaleExecutorVersion is returned.
+	Register(group uint64, version uint64, executor storage.Executor) error
+	// RegisterKV registers a KV executor of the shard group with the version, and
+	// returns the RegisterExecutor to register group-specific custom commands.
+	RegisterKV(group uint64, version uint64) (RegisterExecutor, error)
+	// Version returns the version of the executor registered for the group, false
+	// if no executor registered.
+	Version(group uint64) (uint64, bool)
+}
+
+type groupRoute struct {
+	version  uint64
+	executor storage.Executor
+}
+
+type groupExecutor struct {
+	kv storage.KVStorage
+
+	mu struct {
+		sync.RWMutex
+		routes map[uint64]groupRoute
+	}
+}
+
+var _ storage.Executor = (*groupExecutor)(nil)
+
+// NewGroupExecutor returns a GroupExecutor, all registered executors must share
+// the same kv storage.
+func NewGroupExecutor(kv storage.KVStorage) GroupExecutor {
+	ge := &groupExecutor{kv: kv}
+	ge.mu.routes = make(map[uint64]groupRoute)
+	return ge
+}
+
+func (ge *groupExecutor) Register(group uint64, version uint64, executor storage.Executor) error {
+	ge.mu.Lock()
+	defer ge.mu.Unlock()
+
+	if route, ok := ge.mu.routes[group]; ok && route.version >= version {
+		return fmt.Errorf("%w: group %d, registered %d, register %d",
+			ErrStaleExecutorVersion, group, route.version, version)
+	}
+	ge.mu.routes[group] = groupRoute{version: version, executor: executor}
+	return nil
+}
+
+func (ge *groupExecutor) RegisterKV(group uint64, version uint64) (RegisterExecutor, error) {
+	executor := NewKVExecutor(ge.kv)
+	if err := ge.Register(group, version, executor); err != nil {
+		return nil, err
+	}
+	return executor, nil
+}
+
+func (ge *groupExecutor) Version(group uint64) (uint64, bool) {
+	ge.mu.RLock()
+	defer ge.mu.RUnlock()
+
+	route, ok := ge.mu.routes[group]
+	return route.version, ok
+}
+
+func (ge *groupExecutor) UpdateWriteBatch(ctx storage.WriteContext) error {
+	executor, err := ge.getExecutor(ctx.Shard().Group)
+	if err != nil {
+		return err
+	}
+	return executor.UpdateWriteBatch(ctx)
+}
+
+func (ge *groupExecutor) ApplyWriteBatch(r storage.Resetable) error {
+	// all executors share the same kv storage, and the write batch is created by
+	// the kv storage, so we can apply it directly.
+	wb := r.(util.WriteBatch)
+	return ge.kv.Write(wb, false)
+}
+
+func (ge *groupExecutor) Read(ctx storage.ReadContext) ([]byte, error) {
+	executor, err := ge.getExecutor(ctx.Shard().Group)
+	if err != nil {
+		return nil, err
+	}
+	return executor.Read(ctx)
+}
+
+func (ge *groupExecutor) getExecutor(group uint64) (storage.Executor, error) {
+	ge.mu.RLock()
+	defer ge.mu.RUnlock()
+
+	route, ok := ge.mu.routes[group]
+	if !ok {
+		return nil, fmt.Errorf("%w: group %d", ErrGroupNotRegistered, group)
+	}
+	return route.executor, nil
+}
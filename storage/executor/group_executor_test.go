@@ -0,0 +1,120 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	"github.com/matrixorigin/matrixcube/util"
+	"github.com/matrixorigin/matrixcube/util/buf"
+	"github.com/stretchr/testify/assert"
+)
+
+type testGroupWriteContext struct {
+	*storage.SimpleWriteContext
+	group uint64
+}
+
+func (ctx testGroupWriteContext) Shard() metapb.Shard {
+	shard := ctx.SimpleWriteContext.Shard()
+	shard.Group = ctx.group
+	return shard
+}
+
+type testGroupReadContext struct {
+	*storage.SimpleReadContext
+	group uint64
+}
+
+func (ctx testGroupReadContext) Shard() metapb.Shard {
+	shard := ctx.SimpleReadContext.Shard()
+	shard.Group = ctx.group
+	return shard
+}
+
+func TestGroupExecutorRegister(t *testing.T) {
+	kvStore := mem.NewStorage()
+	defer kvStore.Close()
+
+	ge := NewGroupExecutor(kvStore)
+	_, ok := ge.Version(1)
+	assert.False(t, ok)
+
+	assert.NoError(t, ge.Register(1, 1, NewKVExecutor(kvStore)))
+	v, ok := ge.Version(1)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), v)
+
+	assert.True(t, errors.Is(ge.Register(1, 1, NewKVExecutor(kvStore)), ErrStaleExecutorVersion))
+	assert.NoError(t, ge.Register(1, 2, NewKVExecutor(kvStore)))
+	v, _ = ge.Version(1)
+	assert.Equal(t, uint64(2), v)
+}
+
+func TestGroupExecutorRouteByGroup(t *testing.T) {
+	kvStore := mem.NewStorage()
+	defer kvStore.Close()
+
+	cmdType := uint64(rpcpb.CmdReserved) + 1
+	ge := NewGroupExecutor(kvStore)
+	handled := make(map[uint64]int)
+	for _, g := range []uint64{1, 2} {
+		group := g
+		e, err := ge.RegisterKV(group, 1)
+		assert.NoError(t, err)
+		e.RegisterWrite(cmdType, func(shard metapb.Shard, cmd []byte, wb util.WriteBatch, buffer *buf.ByteBuf, kvStore storage.KVStorage) (KVWriteCommandResult, error) {
+			handled[group]++
+			return KVWriteCommandResult{}, nil
+		})
+		e.RegisterRead(cmdType, func(shard metapb.Shard, cmd []byte, buffer *buf.ByteBuf, kvStore storage.KVStorage) (KVReadCommandResult, error) {
+			handled[group] += 10
+			return KVReadCommandResult{}, nil
+		})
+	}
+
+	batch := storage.Batch{Index: 1, Requests: []storage.Request{{CmdType: cmdType}}}
+	assert.NoError(t, ge.UpdateWriteBatch(testGroupWriteContext{storage.NewSimpleWriteContext(1, kvStore, batch), 2}))
+	assert.Equal(t, 0, handled[1])
+	assert.Equal(t, 1, handled[2])
+
+	_, err := ge.Read(testGroupReadContext{storage.NewSimpleReadContext(1, storage.Request{CmdType: cmdType}), 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, handled[1])
+	assert.Equal(t, 1, handled[2])
+
+	err = ge.UpdateWriteBatch(testGroupWriteContext{storage.NewSimpleWriteContext(1, kvStore, batch), 3})
+	assert.True(t, errors.Is(err, ErrGroupNotRegistered))
+}
+
+func TestGroupExecutorBuiltinKVCommands(t *testing.T) {
+	kvStore := mem.NewStorage()
+	defer kvStore.Close()
+
+	ge := NewGroupExecutor(kvStore)
+	_, err := ge.RegisterKV(1, 1)
+	assert.NoError(t, err)
+
+	ctx := testGroupWriteContext{storage.NewSimpleWriteContext(1, kvStore, storage.Batch{
+		Index:    1,
+		Requests: []storage.Request{NewWriteRequest([]byte("k1"), []byte("v1"))},
+	}), 1}
+	assert.NoError(t, ge.UpdateWriteBatch(ctx))
+	assert.NoError(t, ge.ApplyWriteBatch(ctx.WriteBatch()))
+	assert.Equal(t, 1, len(ctx.Responses()))
+}
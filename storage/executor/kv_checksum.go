@@ -0,0 +1,206 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc64"
+
+	"github.com/fagongzi/util/protoc"
+	"github.com/matrixorigin/matrixcube/keys"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util/buf"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+)
+
+// CmdKVScanChecksum computes the checksum of the key-value pairs in a sub-range
+// of the shard. The request and response are ScanChecksumRequest and
+// ScanChecksumResponse.
+const CmdKVScanChecksum = uint64(rpcpb.CmdKVBatchMixedWrite) + 100
+
+var (
+	// ErrInvalidScanChecksum the scan checksum payload is malformed
+	ErrInvalidScanChecksum = errors.New("invalid scan checksum payload")
+
+	checksumTable = crc64.MakeTable(crc64.ECMA)
+)
+
+// ScanChecksumRequest computes the checksum in [Start, End), the range is
+// limited by the range of the shard which executes the request.
+type ScanChecksumRequest struct {
+	Start []byte
+	End   []byte
+}
+
+// ScanChecksumResponse the checksum result. AppliedIndex is the applied index
+// of the shard in the same storage view as the checksum computed, so the results
+// of different replicas with the same AppliedIndex must be the same.
+type ScanChecksumResponse struct {
+	AppliedIndex uint64
+	Keys         uint64
+	Bytes        uint64
+	Checksum     uint64
+}
+
+// Marshal marshal the request
+func (req ScanChecksumRequest) Marshal() []byte {
+	data := make([]byte, 8+len(req.Start)+len(req.End))
+	binary.BigEndian.PutUint32(data, uint32(len(req.Start)))
+	copy(data[4:], req.Start)
+	binary.BigEndian.PutUint32(data[4+len(req.Start):], uint32(len(req.End)))
+	copy(data[8+len(req.Start):], req.End)
+	return data
+}
+
+// Unmarshal unmarshal the request
+func (req *ScanChecksumRequest) Unmarshal(data []byte) error {
+	var ok bool
+	if req.Start, data, ok = readChecksumBytes(data); !ok {
+		return ErrInvalidScanChecksum
+	}
+	if req.End, data, ok = readChecksumBytes(data); !ok || len(data) > 0 {
+		return ErrInvalidScanChecksum
+	}
+	return nil
+}
+
+func readChecksumBytes(data []byte) ([]byte, []byte, bool) {
+	if len(data) < 4 {
+		return nil, nil, false
+	}
+	n := int(binary.BigEndian.Uint32(data))
+	if len(data) < 4+n {
+		return nil, nil, false
+	}
+	return data[4 : 4+n], data[4+n:], true
+}
+
+// Marshal marshal the response
+func (resp ScanChecksumResponse) Marshal() []byte {
+	data := make([]byte, 32)
+	binary.BigEndian.PutUint64(data, resp.AppliedIndex)
+	binary.BigEndian.PutUint64(data[8:], resp.Keys)
+	binary.BigEndian.PutUint64(data[16:], resp.Bytes)
+	binary.BigEndian.PutUint64(data[24:], resp.Checksum)
+	return data
+}
+
+// Unmarshal unmarshal the response
+func (resp *ScanChecksumResponse) Unmarshal(data []byte) error {
+	if len(data) != 32 {
+		return ErrInvalidScanChecksum
+	}
+	resp.AppliedIndex = binary.BigEndian.Uint64(data)
+	resp.Keys = binary.BigEndian.Uint64(data[8:])
+	resp.Bytes = binary.BigEndian.Uint64(data[16:])
+	resp.Checksum = binary.BigEndian.Uint64(data[24:])
+	return nil
+}
+
+func handleScanChecksum(shard metapb.Shard, cmd []byte, buffer *buf.ByteBuf, kvStore storage.KVStorage) (KVReadCommandResult, error) {
+	var req ScanChecksumRequest
+	if err := req.Unmarshal(cmd); err != nil {
+		panic(err)
+	}
+
+	req.Start, req.End = limitShardRange(shard, req.Start, req.End)
+
+	view, err := storage.GetBackgroundView(kvStore)
+	if err != nil {
+		return KVReadCommandResult{}, err
+	}
+	defer view.Close()
+
+	var resp ScanChecksumResponse
+	resp.AppliedIndex, err = appliedIndexInView(kvStore, view, shard.ID, buffer)
+	if err != nil {
+		return KVReadCommandResult{}, err
+	}
+
+	checksum, err := ChecksumInView(kvStore, view, req.Start, req.End, buffer)
+	if err != nil {
+		return KVReadCommandResult{}, err
+	}
+
+	resp.Keys = checksum.Keys
+	resp.Bytes = checksum.Bytes
+	resp.Checksum = checksum.Checksum
+	return KVReadCommandResult{
+		ReadBytes: resp.Bytes,
+		Response:  resp.Marshal(),
+	}, nil
+}
+
+// limitShardRange limits [start, end) by the range of the shard, the empty
+// start and end mean the start and the end of the shard.
+func limitShardRange(shard metapb.Shard, start, end []byte) ([]byte, []byte) {
+	if len(start) == 0 ||
+		bytes.Compare(start, shard.Start) < 0 {
+		start = shard.Start
+	}
+	if len(end) == 0 ||
+		(len(shard.End) > 0 && bytes.Compare(end, shard.End) > 0) {
+		end = shard.End
+	}
+	return start, end
+}
+
+// appliedIndexInView returns the applied index of the shard in the view
+func appliedIndexInView(kvStore storage.KVStorage, view storage.View, shardID uint64,
+	buffer *buf.ByteBuf) (uint64, error) {
+	var appliedIndex uint64
+	appliedIndexKey := keysutil.EncodeShardMetadataKey(keys.GetAppliedIndexKey(shardID, nil), nil)
+	err := kvStore.ScanInView(view, appliedIndexKey, keysutil.NextKey(appliedIndexKey, buffer),
+		func(key, value []byte) (bool, error) {
+			var index metapb.LogIndex
+			protoc.MustUnmarshal(&index, value)
+			appliedIndex = index.Index
+			return false, nil
+		}, false)
+	return appliedIndex, err
+}
+
+// ChecksumInView computes the checksum of the key-value pairs in [start, end)
+// in the view, the AppliedIndex of the result is not set. The checksums of the
+// same data in different replicas are the same.
+func ChecksumInView(kvStore storage.KVStorage, view storage.View, start, end []byte,
+	buffer *buf.ByteBuf) (ScanChecksumResponse, error) {
+	var resp ScanChecksumResponse
+	h := crc64.New(checksumTable)
+	size := make([]byte, 4)
+	err := kvStore.ScanInView(view, keysutil.EncodeShardStart(start, buffer),
+		keysutil.EncodeShardEnd(end, buffer), func(key, value []byte) (bool, error) {
+			originKey := keysutil.DecodeDataKey(key)
+			// length prefixed to avoid the ambiguity of key and value boundaries
+			binary.BigEndian.PutUint32(size, uint32(len(originKey)))
+			h.Write(size)
+			h.Write(originKey)
+			binary.BigEndian.PutUint32(size, uint32(len(value)))
+			h.Write(size)
+			h.Write(value)
+			resp.Keys++
+			resp.Bytes += uint64(len(originKey) + len(value))
+			return true, nil
+		}, false)
+	if err != nil {
+		return ScanChecksumResponse{}, err
+	}
+
+	resp.Checksum = h.Sum64()
+	return resp, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"testing"
+
+	"github.com/fagongzi/util/protoc"
+	"github.com/matrixorigin/matrixcube/keys"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	"github.com/matrixorigin/matrixcube/util/buf"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanChecksumCodec(t *testing.T) {
+	req := ScanChecksumRequest{Start: []byte("a"), End: []byte("bc")}
+	var decoded ScanChecksumRequest
+	assert.NoError(t, decoded.Unmarshal(req.Marshal()))
+	assert.Equal(t, req, decoded)
+	assert.Error(t, decoded.Unmarshal([]byte{0xff}))
+
+	resp := ScanChecksumResponse{AppliedIndex: 1, Keys: 2, Bytes: 3, Checksum: 4}
+	var decodedResp ScanChecksumResponse
+	assert.NoError(t, decodedResp.Unmarshal(resp.Marshal()))
+	assert.Equal(t, resp, decodedResp)
+}
+
+func TestHandleScanChecksum(t *testing.T) {
+	kvStore := mem.NewStorage()
+	defer kvStore.Close()
+
+	buffer := buf.NewByteBuf(32)
+	defer buffer.Release()
+
+	shard := metapb.Shard{ID: 1}
+	for _, k := range []string{"a", "b", "c", "d"} {
+		assert.NoError(t, kvStore.Set(keysutil.EncodeDataKey([]byte(k), nil), []byte(k), false))
+	}
+	assert.NoError(t, kvStore.Set(keysutil.EncodeShardMetadataKey(keys.GetAppliedIndexKey(shard.ID, nil), nil),
+		protoc.MustMarshal(&metapb.LogIndex{Index: 10}), false))
+
+	checksum := func(shard metapb.Shard, start, end string) ScanChecksumResponse {
+		req := ScanChecksumRequest{Start: []byte(start), End: []byte(end)}
+		result, err := handleScanChecksum(shard, req.Marshal(), buffer, kvStore)
+		assert.NoError(t, err)
+		var resp ScanChecksumResponse
+		assert.NoError(t, resp.Unmarshal(result.Response))
+		assert.Equal(t, resp.Bytes, result.ReadBytes)
+		return resp
+	}
+
+	all := checksum(shard, "", "")
+	assert.Equal(t, uint64(10), all.AppliedIndex)
+	assert.Equal(t, uint64(4), all.Keys)
+	assert.Equal(t, uint64(8), all.Bytes)
+
+	sub := checksum(shard, "b", "d")
+	assert.Equal(t, uint64(2), sub.Keys)
+	assert.NotEqual(t, all.Checksum, sub.Checksum)
+	// range limited by the shard
+	assert.Equal(t, sub, checksum(metapb.Shard{ID: 1, Start: []byte("b"), End: []byte("d")}, "", ""))
+
+	assert.NoError(t, kvStore.Set(keysutil.EncodeDataKey([]byte("c"), nil), []byte("x"), false))
+	assert.NotEqual(t, sub.Checksum, checksum(shard, "b", "d").Checksum)
+}
@@ -178,18 +178,21 @@ func handleBatchGet(shard metapb.Shard, cmd []byte, buffer *buf.ByteBuf, kvStore
 
 	var resp rpcpb.KVBatchGetResponse
 	resp.Indexes = req.Indexes
-	resp.Values = make([][]byte, 0, len(req.Keys))
 
-	readed := 0
+	keys := make([][]byte, 0, len(req.Keys))
 	for _, key := range req.Keys {
-		v, err := kvStore.Get(keysutil.EncodeDataKey(key, buffer))
-		buffer.ResetWrite()
-		if err != nil {
-			return KVReadCommandResult{}, err
-		}
+		keys = append(keys, keysutil.EncodeDataKey(key, nil))
+	}
+	values, err := kvStore.MultiGet(keys)
+	if err != nil {
+		return KVReadCommandResult{}, err
+	}
+
+	readed := 0
+	for _, v := range values {
 		readed += len(v)
-		resp.Values = append(resp.Values, v)
 	}
+	resp.Values = values
 
 	return KVReadCommandResult{
 		ReadBytes: uint64(readed),
@@ -203,6 +206,15 @@ func handleScan(shard metapb.Shard, cmd []byte, buffer *buf.ByteBuf, kvStore sto
 		panic(err)
 	}
 
+	view := kvStore.GetView()
+	defer view.Close()
+	return scanInView(shard, req, buffer, kvStore, view)
+}
+
+// scanInView scans the range in the request in the view, the range is limited
+// by the range of the shard.
+func scanInView(shard metapb.Shard, req rpcpb.KVScanRequest, buffer *buf.ByteBuf,
+	kvStore storage.KVStorage, view storage.View) (KVReadCommandResult, error) {
 	// req.Start < shard.Start, only scan the data in current shard
 	if len(req.Start) == 0 ||
 		bytes.Compare(req.Start, shard.Start) < 0 {
@@ -221,9 +233,6 @@ func handleScan(shard metapb.Shard, cmd []byte, buffer *buf.ByteBuf, kvStore sto
 	}
 
 	var resp rpcpb.KVScanResponse
-	view := kvStore.GetView()
-	defer view.Close()
-
 	start := keysutil.EncodeShardStart(req.Start, buffer)
 	end := keysutil.EncodeShardEnd(req.End, buffer)
 	n := uint64(0)
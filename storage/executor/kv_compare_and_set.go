@@ -0,0 +1,260 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util"
+	"github.com/matrixorigin/matrixcube/util/buf"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+)
+
+// CmdKVCompareAndSet sets the key if its current value is the expected one, the
+// empty expected value means the key does not exist. The request and response
+// are CompareAndSetRequest and CompareAndSetResponse.
+//
+// The condition is evaluated when the request is applied, against the data
+// written by the requests before it in the same batch, so all the replicas get
+// the same result. The failed condition only skips the request itself.
+const CmdKVCompareAndSet = CmdKVScanChecksum + 5
+
+var (
+	// ErrInvalidCompareAndSet the compare and set payload is malformed
+	ErrInvalidCompareAndSet = errors.New("invalid compare and set payload")
+)
+
+// CompareAndSetRequest sets the Key to the Value if the current value of the
+// Key is the Expected one.
+type CompareAndSetRequest struct {
+	Key      []byte
+	Expected []byte
+	Value    []byte
+}
+
+// CompareAndSetResponse the result of the compare and set, Value is the current
+// value of the key if the condition failed.
+type CompareAndSetResponse struct {
+	Succeeded bool
+	Value     []byte
+}
+
+// NewCompareAndSetRequest returns the CmdKVCompareAndSet request
+func NewCompareAndSetRequest(key, expected, value []byte) storage.Request {
+	return storage.Request{
+		CmdType: CmdKVCompareAndSet,
+		Key:     key,
+		Cmd:     CompareAndSetRequest{Key: key, Expected: expected, Value: value}.Marshal(),
+	}
+}
+
+// NewSetIfAbsentRequest returns the CmdKVCompareAndSet request which sets the
+// key only if it does not exist.
+func NewSetIfAbsentRequest(key, value []byte) storage.Request {
+	return NewCompareAndSetRequest(key, nil, value)
+}
+
+// Marshal marshal the request
+func (req CompareAndSetRequest) Marshal() []byte {
+	data := make([]byte, 0, 12+len(req.Key)+len(req.Expected)+len(req.Value))
+	data = appendChecksumBytes(data, req.Key)
+	data = appendChecksumBytes(data, req.Expected)
+	return appendChecksumBytes(data, req.Value)
+}
+
+// Unmarshal unmarshal the request
+func (req *CompareAndSetRequest) Unmarshal(data []byte) error {
+	var ok bool
+	if req.Key, data, ok = readChecksumBytes(data); !ok {
+		return ErrInvalidCompareAndSet
+	}
+	if req.Expected, data, ok = readChecksumBytes(data); !ok {
+		return ErrInvalidCompareAndSet
+	}
+	if req.Value, data, ok = readChecksumBytes(data); !ok || len(data) > 0 {
+		return ErrInvalidCompareAndSet
+	}
+	return nil
+}
+
+// Marshal marshal the response
+func (resp CompareAndSetResponse) Marshal() []byte {
+	data := make([]byte, 1+len(resp.Value))
+	if resp.Succeeded {
+		data[0] = 1
+	}
+	copy(data[1:], resp.Value)
+	return data
+}
+
+// Unmarshal unmarshal the response
+func (resp *CompareAndSetResponse) Unmarshal(data []byte) error {
+	if len(data) == 0 || data[0] > 1 {
+		return ErrInvalidCompareAndSet
+	}
+	resp.Succeeded = data[0] == 1
+	resp.Value = data[1:]
+	return nil
+}
+
+func appendChecksumBytes(data, v []byte) []byte {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(v)))
+	return append(append(data, size[:]...), v...)
+}
+
+func handleCompareAndSet(shard metapb.Shard, cmd []byte, wb util.WriteBatch, buffer *buf.ByteBuf, kvStore storage.KVStorage) (KVWriteCommandResult, error) {
+	var req CompareAndSetRequest
+	if err := req.Unmarshal(cmd); err != nil {
+		panic(err)
+	}
+
+	key := keysutil.EncodeDataKey(req.Key, nil)
+	current, err := getInBatch(wb, kvStore, key)
+	if err != nil {
+		return KVWriteCommandResult{}, err
+	}
+	if !bytes.Equal(current, req.Expected) {
+		return KVWriteCommandResult{
+			Response: CompareAndSetResponse{Value: current}.Marshal(),
+		}, nil
+	}
+
+	wb.Set(key, req.Value)
+	changed := len(key) + len(req.Value)
+	return KVWriteCommandResult{
+		DiffBytes:    int64(changed),
+		WrittenBytes: uint64(changed),
+		Response:     CompareAndSetResponse{Succeeded: true}.Marshal(),
+	}, nil
+}
+
+// getInBatch returns the value of the key written by the batch if the batch is
+// a batchOverlay, otherwise the value in the storage.
+func getInBatch(wb util.WriteBatch, kvStore storage.KVStorage, key []byte) ([]byte, error) {
+	if o, ok := wb.(*batchOverlay); ok {
+		if value, found := o.get(key); found {
+			return value, nil
+		}
+	}
+	return kvStore.Get(key)
+}
+
+type overlayOpKind int
+
+const (
+	overlaySet overlayOpKind = iota
+	overlayDelete
+	// overlayDeleteRange the value is the end of the range
+	overlayDeleteRange
+)
+
+type overlayOp struct {
+	kind  overlayOpKind
+	key   []byte
+	value []byte
+}
+
+// batchOverlay records the writes of the requests in the write batch, so the
+// requests checked by readsInBatch read the writes of the requests before them.
+// It's only used by the batches with these requests.
+type batchOverlay struct {
+	util.WriteBatch
+	ops []overlayOp
+}
+
+func newBatchOverlay(wb util.WriteBatch) *batchOverlay {
+	return &batchOverlay{WriteBatch: wb}
+}
+
+// get returns the value of the key written by the batch, found is false if the
+// key is not written by the batch.
+func (o *batchOverlay) get(key []byte) (value []byte, found bool) {
+	for i := len(o.ops) - 1; i >= 0; i-- {
+		op := o.ops[i]
+		switch op.kind {
+		case overlaySet:
+			if bytes.Equal(op.key, key) {
+				return op.value, true
+			}
+		case overlayDelete:
+			if bytes.Equal(op.key, key) {
+				return nil, true
+			}
+		case overlayDeleteRange:
+			if bytes.Compare(key, op.key) >= 0 && bytes.Compare(key, op.value) < 0 {
+				return nil, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (o *batchOverlay) add(kind overlayOpKind, key, value []byte) {
+	o.ops = append(o.ops, overlayOp{
+		kind:  kind,
+		key:   keysutil.Clone(key),
+		value: keysutil.Clone(value),
+	})
+}
+
+func (o *batchOverlay) Set(key, value []byte) {
+	o.WriteBatch.Set(key, value)
+	o.add(overlaySet, key, value)
+}
+
+func (o *batchOverlay) SetDeferred(keyLen, valueLen int, setter func(key, value []byte)) {
+	o.WriteBatch.SetDeferred(keyLen, valueLen, func(key, value []byte) {
+		setter(key, value)
+		o.add(overlaySet, key, value)
+	})
+}
+
+func (o *batchOverlay) Delete(key []byte) {
+	o.WriteBatch.Delete(key)
+	o.add(overlayDelete, key, nil)
+}
+
+func (o *batchOverlay) DeleteDeferred(keyLen int, setter func(key []byte)) {
+	o.WriteBatch.DeleteDeferred(keyLen, func(key []byte) {
+		setter(key)
+		o.add(overlayDelete, key, nil)
+	})
+}
+
+func (o *batchOverlay) DeleteRange(start, end []byte) {
+	o.WriteBatch.DeleteRange(start, end)
+	o.add(overlayDeleteRange, start, end)
+}
+
+func (o *batchOverlay) DeleteRangeDeferred(startLen, endLen int, setter func(start, end []byte)) {
+	o.WriteBatch.DeleteRangeDeferred(startLen, endLen, func(start, end []byte) {
+		setter(start, end)
+		o.add(overlayDeleteRange, start, end)
+	})
+}
+
+func (o *batchOverlay) SetIfAbsent(key, value []byte) {
+	o.WriteBatch.SetIfAbsent(key, value)
+	o.add(overlaySet, key, value)
+}
+
+func (o *batchOverlay) CompareAndSet(key, expected, value []byte) {
+	o.WriteBatch.CompareAndSet(key, expected, value)
+	o.add(overlaySet, key, value)
+}
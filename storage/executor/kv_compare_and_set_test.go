@@ -0,0 +1,136 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	"github.com/matrixorigin/matrixcube/util"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareAndSetCodec(t *testing.T) {
+	req := CompareAndSetRequest{Key: []byte("k"), Value: []byte("v")}
+	var decoded CompareAndSetRequest
+	require.NoError(t, decoded.Unmarshal(req.Marshal()))
+	assert.Equal(t, "k", string(decoded.Key))
+	assert.Empty(t, decoded.Expected)
+	assert.Equal(t, "v", string(decoded.Value))
+
+	data := req.Marshal()
+	assert.Error(t, decoded.Unmarshal(data[:len(data)-1]))
+	assert.Error(t, decoded.Unmarshal(append(data, 0)))
+
+	var resp CompareAndSetResponse
+	require.NoError(t, resp.Unmarshal(CompareAndSetResponse{Value: []byte("v")}.Marshal()))
+	assert.False(t, resp.Succeeded)
+	assert.Equal(t, "v", string(resp.Value))
+	require.NoError(t, resp.Unmarshal(CompareAndSetResponse{Succeeded: true}.Marshal()))
+	assert.True(t, resp.Succeeded)
+	assert.Error(t, resp.Unmarshal(nil))
+	assert.Error(t, resp.Unmarshal([]byte{2}))
+}
+
+func TestBatchOverlay(t *testing.T) {
+	kvStore := mem.NewStorage()
+	defer kvStore.Close()
+
+	o := newBatchOverlay(kvStore.NewWriteBatch().(util.WriteBatch))
+	_, found := o.get([]byte("k1"))
+	assert.False(t, found)
+
+	o.Set([]byte("k1"), []byte("v1"))
+	o.SetDeferred(2, 2, func(key, value []byte) {
+		copy(key, "k2")
+		copy(value, "v2")
+	})
+	value, found := o.get([]byte("k1"))
+	assert.True(t, found)
+	assert.Equal(t, "v1", string(value))
+	value, found = o.get([]byte("k2"))
+	assert.True(t, found)
+	assert.Equal(t, "v2", string(value))
+
+	o.DeleteRange([]byte("k1"), []byte("k2"))
+	value, found = o.get([]byte("k1"))
+	assert.True(t, found)
+	assert.Empty(t, value)
+	value, found = o.get([]byte("k2"))
+	assert.True(t, found)
+	assert.Equal(t, "v2", string(value))
+
+	o.Delete([]byte("k2"))
+	_, found = o.get([]byte("k2"))
+	assert.True(t, found)
+	o.CompareAndSet([]byte("k2"), nil, []byte("v3"))
+	value, _ = o.get([]byte("k2"))
+	assert.Equal(t, "v3", string(value))
+}
+
+func TestCompareAndSetInBatch(t *testing.T) {
+	fs := vfs.GetTestFS()
+	defer vfs.ReportLeakedFD(fs, t)
+	kvStore := mem.NewStorage()
+	defer kvStore.Close()
+	require.NoError(t, kvStore.Set(keysutil.EncodeDataKey([]byte("k1"), nil), []byte("v1"), false))
+
+	exec := NewKVExecutor(kvStore)
+	ctx := storage.NewSimpleWriteContext(1, kvStore, storage.Batch{
+		Index: 1,
+		Requests: []storage.Request{
+			NewCompareAndSetRequest([]byte("k1"), []byte("v0"), []byte("v2")),
+			NewCompareAndSetRequest([]byte("k1"), []byte("v1"), []byte("v2")),
+			// written by the previous request
+			NewCompareAndSetRequest([]byte("k1"), []byte("v2"), []byte("v3")),
+			NewSetIfAbsentRequest([]byte("k2"), []byte("v1")),
+			NewSetIfAbsentRequest([]byte("k2"), []byte("v2")),
+			{CmdType: uint64(rpcpb.CmdKVSet), Cmd: newTestSetRequest("k3", "v1")},
+			NewSetIfAbsentRequest([]byte("k3"), []byte("v2")),
+		},
+	})
+	require.NoError(t, exec.UpdateWriteBatch(ctx))
+	require.NoError(t, exec.ApplyWriteBatch(ctx.WriteBatch()))
+
+	expected := []CompareAndSetResponse{
+		{Value: []byte("v1")},
+		{Succeeded: true},
+		{Succeeded: true},
+		{Succeeded: true},
+		{Value: []byte("v1")},
+		{},
+		{Value: []byte("v1")},
+	}
+	require.Equal(t, len(expected), len(ctx.Responses()))
+	for i, e := range expected {
+		if i == 5 {
+			continue
+		}
+		var resp CompareAndSetResponse
+		require.NoError(t, resp.Unmarshal(ctx.Responses()[i]))
+		assert.Equal(t, e.Succeeded, resp.Succeeded, "request %d", i)
+		assert.Equal(t, string(e.Value), string(resp.Value), "request %d", i)
+	}
+
+	for k, v := range map[string]string{"k1": "v3", "k2": "v1", "k3": "v1"} {
+		value, err := kvStore.Get(keysutil.EncodeDataKey([]byte(k), nil))
+		require.NoError(t, err)
+		assert.Equal(t, v, string(value))
+	}
+}
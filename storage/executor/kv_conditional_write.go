@@ -0,0 +1,164 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util"
+	"github.com/matrixorigin/matrixcube/util/buf"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+)
+
+// CmdKVConditionalWrite sets or deletes the key if the condition holds. The
+// request and response are ConditionalWriteRequest and CompareAndSetResponse.
+// Same as CmdKVCompareAndSet, the condition is evaluated when the request is
+// applied, against the data written by the requests before it in the same
+// batch.
+const CmdKVConditionalWrite = CmdKVScanChecksum + 6
+
+var (
+	// ErrInvalidConditionalWrite the conditional write payload is malformed
+	ErrInvalidConditionalWrite = errors.New("invalid conditional write payload")
+)
+
+// Condition the condition of the conditional write
+type Condition byte
+
+const (
+	// ConditionValueEqual the current value of the key is the expected one, the
+	// empty expected value means the key does not exist.
+	ConditionValueEqual Condition = iota
+	// ConditionExists the key exists
+	ConditionExists
+	// ConditionNotExists the key does not exist
+	ConditionNotExists
+	// ConditionShardGeneration the epoch generation of the shard is the expected
+	// one, i.e. the shard is not split or merged since the client read it.
+	ConditionShardGeneration
+)
+
+// ConditionalWriteOp the write performed if the condition holds
+type ConditionalWriteOp byte
+
+const (
+	// ConditionalSet sets the key to the value
+	ConditionalSet ConditionalWriteOp = iota
+	// ConditionalDelete deletes the key
+	ConditionalDelete
+)
+
+// ConditionalWriteRequest performs the Op on the Key if the Condition holds.
+type ConditionalWriteRequest struct {
+	Key       []byte
+	Condition Condition
+	// Expected the expected value of ConditionValueEqual
+	Expected []byte
+	// ShardGeneration the expected generation of ConditionShardGeneration
+	ShardGeneration uint64
+	Op              ConditionalWriteOp
+	// Value the value of ConditionalSet
+	Value []byte
+}
+
+// NewConditionalWriteRequest returns the CmdKVConditionalWrite request
+func NewConditionalWriteRequest(req ConditionalWriteRequest) storage.Request {
+	return storage.Request{
+		CmdType: CmdKVConditionalWrite,
+		Key:     req.Key,
+		Cmd:     req.Marshal(),
+	}
+}
+
+// Marshal marshal the request
+func (req ConditionalWriteRequest) Marshal() []byte {
+	data := make([]byte, 0, 22+len(req.Key)+len(req.Expected)+len(req.Value))
+	data = appendChecksumBytes(data, req.Key)
+	data = appendChecksumBytes(data, req.Expected)
+	data = appendChecksumBytes(data, req.Value)
+	data = append(data, byte(req.Condition), byte(req.Op))
+	var generation [8]byte
+	binary.BigEndian.PutUint64(generation[:], req.ShardGeneration)
+	return append(data, generation[:]...)
+}
+
+// Unmarshal unmarshal the request
+func (req *ConditionalWriteRequest) Unmarshal(data []byte) error {
+	var ok bool
+	if req.Key, data, ok = readChecksumBytes(data); !ok {
+		return ErrInvalidConditionalWrite
+	}
+	if req.Expected, data, ok = readChecksumBytes(data); !ok {
+		return ErrInvalidConditionalWrite
+	}
+	if req.Value, data, ok = readChecksumBytes(data); !ok || len(data) != 10 {
+		return ErrInvalidConditionalWrite
+	}
+	req.Condition = Condition(data[0])
+	req.Op = ConditionalWriteOp(data[1])
+	if req.Condition > ConditionShardGeneration || req.Op > ConditionalDelete {
+		return ErrInvalidConditionalWrite
+	}
+	req.ShardGeneration = binary.BigEndian.Uint64(data[2:])
+	return nil
+}
+
+func handleConditionalWrite(shard metapb.Shard, cmd []byte, wb util.WriteBatch, buffer *buf.ByteBuf, kvStore storage.KVStorage) (KVWriteCommandResult, error) {
+	var req ConditionalWriteRequest
+	if err := req.Unmarshal(cmd); err != nil {
+		panic(err)
+	}
+
+	key := keysutil.EncodeDataKey(req.Key, nil)
+	current, err := getInBatch(wb, kvStore, key)
+	if err != nil {
+		return KVWriteCommandResult{}, err
+	}
+	var ok bool
+	switch req.Condition {
+	case ConditionValueEqual:
+		ok = bytes.Equal(current, req.Expected)
+	case ConditionExists:
+		ok = len(current) > 0
+	case ConditionNotExists:
+		ok = len(current) == 0
+	case ConditionShardGeneration:
+		ok = shard.Epoch.Generation == req.ShardGeneration
+	}
+	if !ok {
+		return KVWriteCommandResult{
+			Response: CompareAndSetResponse{Value: current}.Marshal(),
+		}, nil
+	}
+
+	changed := len(key)
+	diff := -int64(changed)
+	switch req.Op {
+	case ConditionalSet:
+		wb.Set(key, req.Value)
+		changed += len(req.Value)
+		diff = int64(changed)
+	case ConditionalDelete:
+		wb.Delete(key)
+	}
+	return KVWriteCommandResult{
+		DiffBytes:    diff,
+		WrittenBytes: uint64(changed),
+		Response:     CompareAndSetResponse{Succeeded: true}.Marshal(),
+	}, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	"github.com/matrixorigin/matrixcube/util"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionalWriteCodec(t *testing.T) {
+	req := ConditionalWriteRequest{
+		Key:             []byte("k"),
+		Condition:       ConditionShardGeneration,
+		ShardGeneration: 3,
+		Op:              ConditionalDelete,
+	}
+	var decoded ConditionalWriteRequest
+	require.NoError(t, decoded.Unmarshal(req.Marshal()))
+	assert.Equal(t, "k", string(decoded.Key))
+	assert.Equal(t, ConditionShardGeneration, decoded.Condition)
+	assert.Equal(t, uint64(3), decoded.ShardGeneration)
+	assert.Equal(t, ConditionalDelete, decoded.Op)
+
+	data := req.Marshal()
+	assert.Error(t, decoded.Unmarshal(data[:len(data)-1]))
+	assert.Error(t, decoded.Unmarshal(append(data, 0)))
+	data[len(data)-10] = byte(ConditionShardGeneration + 1)
+	assert.Error(t, decoded.Unmarshal(data))
+}
+
+func TestConditionalWriteInBatch(t *testing.T) {
+	kvStore := mem.NewStorage()
+	defer kvStore.Close()
+	require.NoError(t, kvStore.Set(keysutil.EncodeDataKey([]byte("k1"), nil), []byte("v1"), false))
+
+	exec := NewKVExecutor(kvStore)
+	ctx := storage.NewSimpleWriteContext(1, kvStore, storage.Batch{
+		Index: 1,
+		Requests: []storage.Request{
+			NewConditionalWriteRequest(ConditionalWriteRequest{Key: []byte("k1"),
+				Condition: ConditionNotExists, Value: []byte("v2")}),
+			NewConditionalWriteRequest(ConditionalWriteRequest{Key: []byte("k1"),
+				Condition: ConditionExists, Op: ConditionalDelete}),
+			// deleted by the previous request
+			NewConditionalWriteRequest(ConditionalWriteRequest{Key: []byte("k1"),
+				Condition: ConditionValueEqual, Value: []byte("v3")}),
+			NewConditionalWriteRequest(ConditionalWriteRequest{Key: []byte("k2"),
+				Condition: ConditionShardGeneration, ShardGeneration: 1, Value: []byte("v1")}),
+			NewConditionalWriteRequest(ConditionalWriteRequest{Key: []byte("k2"),
+				Condition: ConditionShardGeneration, Value: []byte("v2")}),
+			NewCompareAndSetRequest([]byte("k2"), []byte("v2"), []byte("v3")),
+		},
+	})
+	require.NoError(t, exec.UpdateWriteBatch(ctx))
+	require.NoError(t, exec.ApplyWriteBatch(ctx.WriteBatch()))
+
+	expected := []CompareAndSetResponse{
+		{Value: []byte("v1")},
+		{Succeeded: true},
+		{Succeeded: true},
+		{},
+		{Succeeded: true},
+		{Succeeded: true},
+	}
+	require.Equal(t, len(expected), len(ctx.Responses()))
+	for i, e := range expected {
+		var resp CompareAndSetResponse
+		require.NoError(t, resp.Unmarshal(ctx.Responses()[i]))
+		assert.Equal(t, e.Succeeded, resp.Succeeded, "request %d", i)
+		assert.Equal(t, string(e.Value), string(resp.Value), "request %d", i)
+	}
+
+	for k, v := range map[string]string{"k1": "v3", "k2": "v3"} {
+		value, err := kvStore.Get(keysutil.EncodeDataKey([]byte(k), nil))
+		require.NoError(t, err)
+		assert.Equal(t, v, string(value))
+	}
+}
+
+func TestConditionalWriteShardGeneration(t *testing.T) {
+	kvStore := mem.NewStorage()
+	defer kvStore.Close()
+
+	shard := metapb.Shard{Epoch: metapb.ShardEpoch{Generation: 2}}
+	req := ConditionalWriteRequest{Key: []byte("k"), Condition: ConditionShardGeneration,
+		ShardGeneration: 2, Value: []byte("v")}
+	wb := kvStore.NewWriteBatch().(util.WriteBatch)
+	result, err := handleConditionalWrite(shard, req.Marshal(), wb, nil, kvStore)
+	require.NoError(t, err)
+	var resp CompareAndSetResponse
+	require.NoError(t, resp.Unmarshal(result.Response))
+	assert.True(t, resp.Succeeded)
+	assert.Equal(t, int64(len(keysutil.EncodeDataKey([]byte("k"), nil))+1), result.DiffBytes)
+
+	shard.Epoch.Generation++
+	result, err = handleConditionalWrite(shard, req.Marshal(), wb, nil, kvStore)
+	require.NoError(t, err)
+	require.NoError(t, resp.Unmarshal(result.Response))
+	assert.False(t, resp.Succeeded, "the shard is split or merged")
+}
@@ -16,6 +16,7 @@ package executor
 import (
 	"fmt"
 
+	"github.com/matrixorigin/matrixcube/command"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/matrixorigin/matrixcube/storage"
@@ -60,9 +61,15 @@ type KVReadCommandHandler func(shard metapb.Shard, cmd []byte, buffer *buf.ByteB
 // kvExecutor is a kv executor.
 type kvExecutor struct {
 	kv storage.KVStorage
-
-	writeHandlers map[uint64]KVWriteCommandHandler
-	readHandlers  map[uint64]KVReadCommandHandler
+	// applyKV the storage read by the write commands, the TTL is ignored, so
+	// the replicas read the same data regardless of their local clocks
+	applyKV storage.KVStorage
+	// readSnapshots the views pinned by CmdKVCreateReadSnapshot
+	readSnapshots *readSnapshots
+
+	writeHandlers      map[uint64]KVWriteCommandHandler
+	timedWriteHandlers map[uint64]timedWriteCommandHandler
+	readHandlers       map[uint64]KVReadCommandHandler
 }
 
 var _ storage.Executor = (*kvExecutor)(nil)
@@ -70,9 +77,15 @@ var _ storage.Executor = (*kvExecutor)(nil)
 // NewKVExecutor returns a kv executor.
 func NewKVExecutor(kv storage.KVStorage) RegisterExecutor {
 	ke := &kvExecutor{
-		kv:            kv,
-		writeHandlers: map[uint64]KVWriteCommandHandler{},
-		readHandlers:  map[uint64]KVReadCommandHandler{},
+		kv:                 kv,
+		applyKV:            kv,
+		readSnapshots:      newReadSnapshots(),
+		writeHandlers:      map[uint64]KVWriteCommandHandler{},
+		timedWriteHandlers: map[uint64]timedWriteCommandHandler{},
+		readHandlers:       map[uint64]KVReadCommandHandler{},
+	}
+	if ttlStore, ok := kv.(storage.TTLStore); ok {
+		ke.applyKV = ttlStore.WithoutTTL()
 	}
 
 	ke.writeHandlers[uint64(rpcpb.CmdKVSet)] = handleSet
@@ -81,10 +94,21 @@ func NewKVExecutor(kv storage.KVStorage) RegisterExecutor {
 	ke.writeHandlers[uint64(rpcpb.CmdKVBatchDelete)] = handleBatchDelete
 	ke.writeHandlers[uint64(rpcpb.CmdKVRangeDelete)] = handleRangeDelete
 	ke.writeHandlers[uint64(rpcpb.CmdKVBatchMixedWrite)] = handleBatchMixedWrite
+	ke.writeHandlers[CmdKVCompareAndSet] = handleCompareAndSet
+	ke.writeHandlers[CmdKVConditionalWrite] = handleConditionalWrite
+	ke.writeHandlers[CmdKVIncrement] = handleIncrement
+	ke.timedWriteHandlers[CmdKVSetWithTTL] = handleSetWithTTL
+	ke.timedWriteHandlers[CmdKVPurgeExpired] = handlePurgeExpired
 
 	ke.readHandlers[uint64(rpcpb.CmdKVGet)] = handleGet
 	ke.readHandlers[uint64(rpcpb.CmdKVBatchGet)] = handleBatchGet
 	ke.readHandlers[uint64(rpcpb.CmdKVScan)] = handleScan
+	ke.readHandlers[CmdKVScanChecksum] = handleScanChecksum
+	ke.readHandlers[CmdKVScanStats] = handleScanStats
+	ke.readHandlers[CmdKVCreateReadSnapshot] = ke.readSnapshots.handleCreate
+	ke.readHandlers[CmdKVReadSnapshotGet] = ke.readSnapshots.handleGet
+	ke.readHandlers[CmdKVReadSnapshotScan] = ke.readSnapshots.handleScan
+	ke.readHandlers[CmdKVReleaseReadSnapshot] = ke.readSnapshots.handleRelease
 	return ke
 }
 
@@ -92,6 +116,9 @@ func (ke *kvExecutor) RegisterWrite(cmdType uint64, handler KVWriteCommandHandle
 	if _, ok := ke.writeHandlers[cmdType]; ok {
 		panic(fmt.Sprintf("%d already register", cmdType))
 	}
+	if _, ok := ke.timedWriteHandlers[cmdType]; ok {
+		panic(fmt.Sprintf("%d already register", cmdType))
+	}
 	ke.writeHandlers[cmdType] = handler
 }
 
@@ -106,18 +133,30 @@ func (ke *kvExecutor) UpdateWriteBatch(ctx storage.WriteContext) error {
 	changedBytes := int64(0)
 	writtenBytes := uint64(0)
 	r := ctx.WriteBatch()
-	wb := r.(util.WriteBatch)
+	counter := util.NewCountingWriteBatch(r.(util.WriteBatch))
+	var wb util.WriteBatch = counter
 	batch := ctx.Batch()
 	requests := batch.Requests
 	buffer := ctx.(storage.InternalContext).ByteBuf()
+	// the conditions and the counters are evaluated with the writes of the
+	// previous requests
+	for idx := range requests {
+		if readsInBatch(requests[idx].CmdType) {
+			wb = newBatchOverlay(wb)
+			break
+		}
+	}
 
 	for idx := range requests {
-		handlerFunc, ok := ke.writeHandlers[requests[idx].CmdType]
-		if !ok {
+		var result KVWriteCommandResult
+		var err error
+		if handlerFunc, ok := ke.writeHandlers[requests[idx].CmdType]; ok {
+			result, err = handlerFunc(ctx.Shard(), requests[idx].Cmd, wb, buffer, ke.applyKV)
+		} else if handlerFunc, ok := ke.timedWriteHandlers[requests[idx].CmdType]; ok {
+			result, err = handlerFunc(ctx.Shard(), requests[idx].Cmd, wb, buffer, ke.applyKV, batch.Timestamp)
+		} else {
 			panic(fmt.Errorf("not support write cmd %d", requests[idx].CmdType))
 		}
-
-		result, err := handlerFunc(ctx.Shard(), requests[idx].Cmd, wb, buffer, ke.kv)
 		if err != nil {
 			return err
 		}
@@ -128,12 +167,22 @@ func (ke *kvExecutor) UpdateWriteBatch(ctx storage.WriteContext) error {
 
 	ctx.SetDiffBytes(changedBytes)
 	ctx.SetWrittenBytes(writtenBytes)
+	ctx.SetWrittenKeys(counter.Keys())
 	return nil
 }
 
+// readsInBatch returns true if the command reads the keys written by the
+// previous requests in the same batch.
+func readsInBatch(cmdType uint64) bool {
+	return cmdType == CmdKVCompareAndSet ||
+		cmdType == CmdKVConditionalWrite ||
+		cmdType == CmdKVIncrement ||
+		cmdType == CmdKVPurgeExpired
+}
+
 func (ke *kvExecutor) ApplyWriteBatch(r storage.Resetable) error {
 	wb := r.(util.WriteBatch)
-	return ke.kv.Write(wb, false)
+	return ke.applyKV.Write(wb, false)
 }
 
 func (ke *kvExecutor) Read(ctx storage.ReadContext) ([]byte, error) {
@@ -145,7 +194,13 @@ func (ke *kvExecutor) Read(ctx storage.ReadContext) ([]byte, error) {
 		panic(fmt.Errorf("not support read cmd %d", request.CmdType))
 	}
 
-	result, err := handlerFunc(ctx.Shard(), request.Cmd, buffer, ke.kv)
+	kv := ke.kv
+	if c, ok := ctx.(storage.MirrorContext); ok {
+		if mirror := c.MirrorKVStorage(); mirror != nil {
+			kv = mirror
+		}
+	}
+	result, err := handlerFunc(ctx.Shard(), request.Cmd, buffer, kv)
 	if err != nil {
 		return nil, err
 	}
@@ -153,3 +208,39 @@ func (ke *kvExecutor) Read(ctx storage.ReadContext) ([]byte, error) {
 	ctx.SetReadBytes(result.ReadBytes)
 	return result.Response, nil
 }
+
+// KVCommands returns the commands handled by the kv executor, which are
+// registered to the command registry of the store using the kv executor.
+func KVCommands() []command.Command {
+	return []command.Command{
+		{Type: uint64(rpcpb.CmdKVSet), Name: "kv-set", RequestType: rpcpb.Write,
+			Request: &rpcpb.KVSetRequest{}, Response: &rpcpb.KVSetResponse{}},
+		{Type: uint64(rpcpb.CmdKVBatchSet), Name: "kv-batch-set", RequestType: rpcpb.Write,
+			Request: &rpcpb.KVBatchSetRequest{}, Response: &rpcpb.KVBatchSetResponse{}},
+		{Type: uint64(rpcpb.CmdKVDelete), Name: "kv-delete", RequestType: rpcpb.Write,
+			Request: &rpcpb.KVDeleteRequest{}, Response: &rpcpb.KVDeleteResponse{}},
+		{Type: uint64(rpcpb.CmdKVBatchDelete), Name: "kv-batch-delete", RequestType: rpcpb.Write,
+			Request: &rpcpb.KVBatchDeleteRequest{}, Response: &rpcpb.KVBatchDeleteResponse{}},
+		{Type: uint64(rpcpb.CmdKVRangeDelete), Name: "kv-range-delete", RequestType: rpcpb.Write,
+			Request: &rpcpb.KVRangeDeleteRequest{}, Response: &rpcpb.KVRangeDeleteResponse{}},
+		{Type: uint64(rpcpb.CmdKVBatchMixedWrite), Name: "kv-batch-mixed-write", RequestType: rpcpb.Write,
+			Request: &rpcpb.KVBatchMixedWriteRequest{}, Response: &rpcpb.KVBatchMixedWriteResponse{}},
+		{Type: CmdKVCompareAndSet, Name: "kv-compare-and-set", RequestType: rpcpb.Write},
+		{Type: CmdKVConditionalWrite, Name: "kv-conditional-write", RequestType: rpcpb.Write},
+		{Type: CmdKVIncrement, Name: "kv-increment", RequestType: rpcpb.Write},
+		{Type: CmdKVSetWithTTL, Name: "kv-set-with-ttl", RequestType: rpcpb.Write},
+		{Type: CmdKVPurgeExpired, Name: "kv-purge-expired", RequestType: rpcpb.Write},
+		{Type: uint64(rpcpb.CmdKVGet), Name: "kv-get", RequestType: rpcpb.Read,
+			Request: &rpcpb.KVGetRequest{}, Response: &rpcpb.KVGetResponse{}},
+		{Type: uint64(rpcpb.CmdKVBatchGet), Name: "kv-batch-get", RequestType: rpcpb.Read,
+			Request: &rpcpb.KVBatchGetRequest{}, Response: &rpcpb.KVBatchGetResponse{}},
+		{Type: uint64(rpcpb.CmdKVScan), Name: "kv-scan", RequestType: rpcpb.Read,
+			Request: &rpcpb.KVScanRequest{}, Response: &rpcpb.KVScanResponse{}},
+		{Type: CmdKVScanChecksum, Name: "kv-scan-checksum", RequestType: rpcpb.Read},
+		{Type: CmdKVScanStats, Name: "kv-scan-stats", RequestType: rpcpb.Read},
+		{Type: CmdKVCreateReadSnapshot, Name: "kv-create-read-snapshot", RequestType: rpcpb.Read},
+		{Type: CmdKVReadSnapshotGet, Name: "kv-read-snapshot-get", RequestType: rpcpb.Read},
+		{Type: CmdKVReadSnapshotScan, Name: "kv-read-snapshot-scan", RequestType: rpcpb.Read},
+		{Type: CmdKVReleaseReadSnapshot, Name: "kv-release-read-snapshot", RequestType: rpcpb.Read},
+	}
+}
@@ -16,6 +16,7 @@ package executor
 import (
 	"testing"
 
+	"github.com/matrixorigin/matrixcube/command"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/pb/rpcpb"
 	"github.com/matrixorigin/matrixcube/storage"
@@ -65,3 +66,43 @@ func TestRegisterReadHandler(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, handled)
 }
+
+func TestKVCommands(t *testing.T) {
+	kvStore := mem.NewStorage()
+	defer kvStore.Close()
+	ke := NewKVExecutor(kvStore).(*kvExecutor)
+
+	r := command.NewRegistry()
+	assert.NoError(t, r.Register(KVCommands()...))
+	cmds := r.Commands()
+	assert.Equal(t, len(ke.writeHandlers)+len(ke.timedWriteHandlers)+len(ke.readHandlers), len(cmds))
+	for _, cmd := range cmds {
+		if cmd.RequestType == rpcpb.Write {
+			_, ok := ke.writeHandlers[cmd.Type]
+			if !ok {
+				_, ok = ke.timedWriteHandlers[cmd.Type]
+			}
+			assert.True(t, ok, cmd.Name)
+		} else {
+			assert.Contains(t, ke.readHandlers, cmd.Type, cmd.Name)
+		}
+	}
+}
+
+func TestUpdateWriteBatchCountsWrittenKeys(t *testing.T) {
+	fs := vfs.GetTestFS()
+	defer vfs.ReportLeakedFD(fs, t)
+	kvStore := mem.NewStorage()
+	defer kvStore.Close()
+
+	exec := NewKVExecutor(kvStore)
+	ctx := storage.NewSimpleWriteContext(1, kvStore, storage.Batch{
+		Index: 1,
+		Requests: []storage.Request{
+			{CmdType: uint64(rpcpb.CmdKVBatchSet), Cmd: newTestBatchSetRequest("k1", "v1", "k2", "v2", "k3", "v3")},
+			{CmdType: uint64(rpcpb.CmdKVBatchSet), Cmd: newTestBatchSetRequest("k4", "v4")},
+		},
+	})
+	assert.NoError(t, exec.UpdateWriteBatch(ctx))
+	assert.Equal(t, uint64(4), ctx.GetWrittenKeys())
+}
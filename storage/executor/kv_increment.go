@@ -0,0 +1,161 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util"
+	"github.com/matrixorigin/matrixcube/util/buf"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+)
+
+// CmdKVIncrement adds the delta to the counter stored in the key, the counter
+// is a varint encoded int64 and the missing key is a counter of 0. The request
+// and response are IncrementRequest and IncrementResponse.
+//
+// The counter is read when the request is applied, with the writes of the
+// requests before it in the same batch, so all the replicas get the same value.
+// The increment fails without any write if the key holds a value which is not
+// a counter, or the counter overflows.
+const CmdKVIncrement = CmdKVScanChecksum + 7
+
+var (
+	// ErrInvalidIncrement the increment payload is malformed
+	ErrInvalidIncrement = errors.New("invalid increment payload")
+)
+
+// IncrementRequest adds the Delta to the counter of the Key, the negative delta
+// decrements the counter.
+type IncrementRequest struct {
+	Key   []byte
+	Delta int64
+}
+
+// IncrementResponse the result of the increment, Value is the new value of the
+// counter if succeeded.
+type IncrementResponse struct {
+	Succeeded bool
+	Value     int64
+}
+
+// NewIncrementRequest returns the CmdKVIncrement request
+func NewIncrementRequest(key []byte, delta int64) storage.Request {
+	return storage.Request{
+		CmdType: CmdKVIncrement,
+		Key:     key,
+		Cmd:     IncrementRequest{Key: key, Delta: delta}.Marshal(),
+	}
+}
+
+// EncodeCounter encodes the counter as the value stored in the key
+func EncodeCounter(value int64) []byte {
+	data := make([]byte, binary.MaxVarintLen64)
+	return data[:binary.PutVarint(data, value)]
+}
+
+// DecodeCounter decodes the value of the counter stored in the key, the empty
+// value is 0, false is returned if the value is not a counter.
+func DecodeCounter(data []byte) (int64, bool) {
+	if len(data) == 0 {
+		return 0, true
+	}
+	value, n := binary.Varint(data)
+	if n != len(data) {
+		return 0, false
+	}
+	return value, true
+}
+
+// Marshal marshal the request
+func (req IncrementRequest) Marshal() []byte {
+	data := make([]byte, 0, 4+len(req.Key)+binary.MaxVarintLen64)
+	data = appendChecksumBytes(data, req.Key)
+	return append(data, EncodeCounter(req.Delta)...)
+}
+
+// Unmarshal unmarshal the request
+func (req *IncrementRequest) Unmarshal(data []byte) error {
+	var ok bool
+	if req.Key, data, ok = readChecksumBytes(data); !ok || len(data) == 0 {
+		return ErrInvalidIncrement
+	}
+	if req.Delta, ok = DecodeCounter(data); !ok {
+		return ErrInvalidIncrement
+	}
+	return nil
+}
+
+// Marshal marshal the response
+func (resp IncrementResponse) Marshal() []byte {
+	if !resp.Succeeded {
+		return []byte{0}
+	}
+	return append([]byte{1}, EncodeCounter(resp.Value)...)
+}
+
+// Unmarshal unmarshal the response
+func (resp *IncrementResponse) Unmarshal(data []byte) error {
+	if len(data) == 0 || data[0] > 1 {
+		return ErrInvalidIncrement
+	}
+	resp.Succeeded = data[0] == 1
+	resp.Value = 0
+	if !resp.Succeeded {
+		return nil
+	}
+	var ok bool
+	if resp.Value, ok = DecodeCounter(data[1:]); !ok || len(data) == 1 {
+		return ErrInvalidIncrement
+	}
+	return nil
+}
+
+func handleIncrement(shard metapb.Shard, cmd []byte, wb util.WriteBatch, buffer *buf.ByteBuf, kvStore storage.KVStorage) (KVWriteCommandResult, error) {
+	var req IncrementRequest
+	if err := req.Unmarshal(cmd); err != nil {
+		panic(err)
+	}
+
+	key := keysutil.EncodeDataKey(req.Key, nil)
+	current, err := getInBatch(wb, kvStore, key)
+	if err != nil {
+		return KVWriteCommandResult{}, err
+	}
+	counter, ok := DecodeCounter(current)
+	if !ok ||
+		(req.Delta > 0 && counter > math.MaxInt64-req.Delta) ||
+		(req.Delta < 0 && counter < math.MinInt64-req.Delta) {
+		return KVWriteCommandResult{
+			Response: IncrementResponse{}.Marshal(),
+		}, nil
+	}
+
+	counter += req.Delta
+	value := EncodeCounter(counter)
+	wb.Set(key, value)
+	diff := int64(len(value) - len(current))
+	if len(current) == 0 {
+		diff += int64(len(key))
+	}
+	return KVWriteCommandResult{
+		DiffBytes:    diff,
+		WrittenBytes: uint64(len(key) + len(value)),
+		Response:     IncrementResponse{Succeeded: true, Value: counter}.Marshal(),
+	}, nil
+}
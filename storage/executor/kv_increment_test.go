@@ -0,0 +1,105 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncrementCodec(t *testing.T) {
+	req := IncrementRequest{Key: []byte("k"), Delta: -3}
+	var decoded IncrementRequest
+	require.NoError(t, decoded.Unmarshal(req.Marshal()))
+	assert.Equal(t, "k", string(decoded.Key))
+	assert.Equal(t, int64(-3), decoded.Delta)
+
+	data := req.Marshal()
+	assert.Error(t, decoded.Unmarshal(data[:len(data)-1]))
+	assert.Error(t, decoded.Unmarshal(append(data, 0)))
+
+	var resp IncrementResponse
+	require.NoError(t, resp.Unmarshal(IncrementResponse{Succeeded: true, Value: 10}.Marshal()))
+	assert.True(t, resp.Succeeded)
+	assert.Equal(t, int64(10), resp.Value)
+	require.NoError(t, resp.Unmarshal(IncrementResponse{}.Marshal()))
+	assert.False(t, resp.Succeeded)
+	assert.Error(t, resp.Unmarshal(nil))
+	assert.Error(t, resp.Unmarshal([]byte{1}))
+
+	value, ok := DecodeCounter(nil)
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), value)
+	_, ok = DecodeCounter([]byte("not a counter"))
+	assert.False(t, ok)
+}
+
+func TestIncrementInBatch(t *testing.T) {
+	kvStore := mem.NewStorage()
+	defer kvStore.Close()
+	require.NoError(t, kvStore.Set(keysutil.EncodeDataKey([]byte("k2"), nil), []byte("not a counter"), false))
+	require.NoError(t, kvStore.Set(keysutil.EncodeDataKey([]byte("k3"), nil), EncodeCounter(math.MaxInt64), false))
+
+	exec := NewKVExecutor(kvStore)
+	ctx := storage.NewSimpleWriteContext(1, kvStore, storage.Batch{
+		Index: 1,
+		Requests: []storage.Request{
+			NewIncrementRequest([]byte("k1"), 1),
+			// read the counter written by the previous request
+			NewIncrementRequest([]byte("k1"), 5),
+			NewIncrementRequest([]byte("k1"), -10),
+			NewIncrementRequest([]byte("k2"), 1),
+			NewIncrementRequest([]byte("k3"), 1),
+			{CmdType: uint64(rpcpb.CmdKVSet), Cmd: newTestSetRequest("k4", "v1")},
+			NewIncrementRequest([]byte("k4"), 1),
+		},
+	})
+	require.NoError(t, exec.UpdateWriteBatch(ctx))
+	require.NoError(t, exec.ApplyWriteBatch(ctx.WriteBatch()))
+
+	expected := []IncrementResponse{
+		{Succeeded: true, Value: 1},
+		{Succeeded: true, Value: 6},
+		{Succeeded: true, Value: -4},
+		{},
+		{},
+		{},
+		{},
+	}
+	require.Equal(t, len(expected), len(ctx.Responses()))
+	for i, e := range expected {
+		if i == 5 {
+			continue
+		}
+		var resp IncrementResponse
+		require.NoError(t, resp.Unmarshal(ctx.Responses()[i]))
+		assert.Equal(t, e, resp, "request %d", i)
+	}
+
+	value, err := kvStore.Get(keysutil.EncodeDataKey([]byte("k1"), nil))
+	require.NoError(t, err)
+	counter, ok := DecodeCounter(value)
+	assert.True(t, ok)
+	assert.Equal(t, int64(-4), counter)
+	value, err = kvStore.Get(keysutil.EncodeDataKey([]byte("k3"), nil))
+	require.NoError(t, err)
+	assert.Equal(t, EncodeCounter(math.MaxInt64), value, "not changed on overflow")
+}
@@ -0,0 +1,307 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/fagongzi/util/protoc"
+	"github.com/matrixorigin/matrixcube/keys"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util/buf"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+)
+
+const (
+	// CmdKVCreateReadSnapshot pins a point in time view of the shard on the
+	// replica which executes the request. The request is ReadSnapshotRequest,
+	// and the Data of the ReadSnapshotResponse is the applied index of the view
+	// in big endian.
+	CmdKVCreateReadSnapshot = CmdKVScanChecksum + 1
+	// CmdKVReadSnapshotGet is similar to CmdKVGet, but reads the pinned view.
+	// The Cmd of the ReadSnapshotRequest is rpcpb.KVGetRequest, and the Data of
+	// the ReadSnapshotResponse is rpcpb.KVGetResponse.
+	CmdKVReadSnapshotGet = CmdKVScanChecksum + 2
+	// CmdKVReadSnapshotScan is similar to CmdKVScan, but scans the pinned view.
+	// The Cmd of the ReadSnapshotRequest is rpcpb.KVScanRequest, and the Data
+	// of the ReadSnapshotResponse is rpcpb.KVScanResponse.
+	CmdKVReadSnapshotScan = CmdKVScanChecksum + 3
+	// CmdKVReleaseReadSnapshot releases the pinned view.
+	CmdKVReleaseReadSnapshot = CmdKVScanChecksum + 4
+
+	// DefaultReadSnapshotTTL the default TTL of the read snapshot
+	DefaultReadSnapshotTTL = time.Minute
+)
+
+var (
+	// ErrInvalidReadSnapshot the read snapshot payload is malformed
+	ErrInvalidReadSnapshot = errors.New("invalid read snapshot payload")
+)
+
+// ReadSnapshotRequest the request of the read snapshot commands. The read
+// snapshot is identified by the ID and the shard, the TTL is the time the view
+// is kept after the last access, the view is released after the TTL even if
+// the client never releases it.
+type ReadSnapshotRequest struct {
+	ID  []byte
+	TTL time.Duration
+	Cmd []byte
+}
+
+// ReadSnapshotResponse the response of the read snapshot commands, NotFound is
+// true if the read snapshot is released or expired.
+type ReadSnapshotResponse struct {
+	NotFound bool
+	Data     []byte
+}
+
+// Marshal marshal the request
+func (req ReadSnapshotRequest) Marshal() []byte {
+	data := make([]byte, 12+len(req.ID)+len(req.Cmd))
+	binary.BigEndian.PutUint32(data, uint32(len(req.ID)))
+	copy(data[4:], req.ID)
+	binary.BigEndian.PutUint64(data[4+len(req.ID):], uint64(req.TTL))
+	copy(data[12+len(req.ID):], req.Cmd)
+	return data
+}
+
+// Unmarshal unmarshal the request
+func (req *ReadSnapshotRequest) Unmarshal(data []byte) error {
+	var ok bool
+	if req.ID, data, ok = readChecksumBytes(data); !ok || len(data) < 8 {
+		return ErrInvalidReadSnapshot
+	}
+	req.TTL = time.Duration(binary.BigEndian.Uint64(data))
+	req.Cmd = data[8:]
+	return nil
+}
+
+// Marshal marshal the response
+func (resp ReadSnapshotResponse) Marshal() []byte {
+	data := make([]byte, 1+len(resp.Data))
+	if resp.NotFound {
+		data[0] = 1
+	}
+	copy(data[1:], resp.Data)
+	return data
+}
+
+// Unmarshal unmarshal the response
+func (resp *ReadSnapshotResponse) Unmarshal(data []byte) error {
+	if len(data) == 0 || data[0] > 1 {
+		return ErrInvalidReadSnapshot
+	}
+	resp.NotFound = data[0] == 1
+	resp.Data = data[1:]
+	return nil
+}
+
+type readSnapshotKey struct {
+	id    string
+	shard uint64
+}
+
+// readSnapshot the pinned view, the view is closed after it's removed and no
+// read is using it.
+type readSnapshot struct {
+	view     storage.View
+	shard    metapb.Shard
+	ttl      time.Duration
+	expireAt time.Time
+	refs     int
+	removed  bool
+}
+
+// readSnapshots the read snapshots pinned on the store, the expired ones are
+// removed on access.
+type readSnapshots struct {
+	sync.Mutex
+	snapshots map[readSnapshotKey]*readSnapshot
+	now       func() time.Time
+}
+
+func newReadSnapshots() *readSnapshots {
+	return &readSnapshots{
+		snapshots: make(map[readSnapshotKey]*readSnapshot),
+		now:       time.Now,
+	}
+}
+
+func (rs *readSnapshots) len() int {
+	rs.Lock()
+	defer rs.Unlock()
+	return len(rs.snapshots)
+}
+
+func (rs *readSnapshots) add(key readSnapshotKey, s *readSnapshot) {
+	rs.Lock()
+	defer rs.Unlock()
+	now := rs.now()
+	rs.gcLocked(now)
+	if old, ok := rs.snapshots[key]; ok {
+		rs.removeLocked(key, old)
+	}
+	s.expireAt = now.Add(s.ttl)
+	rs.snapshots[key] = s
+}
+
+// acquire returns the read snapshot and refreshes its expire time, the caller
+// must call done after used.
+func (rs *readSnapshots) acquire(key readSnapshotKey) *readSnapshot {
+	rs.Lock()
+	defer rs.Unlock()
+	now := rs.now()
+	rs.gcLocked(now)
+	s, ok := rs.snapshots[key]
+	if !ok {
+		return nil
+	}
+	s.refs++
+	s.expireAt = now.Add(s.ttl)
+	return s
+}
+
+func (rs *readSnapshots) done(s *readSnapshot) {
+	rs.Lock()
+	defer rs.Unlock()
+	s.refs--
+	if s.removed && s.refs == 0 {
+		_ = s.view.Close()
+	}
+}
+
+func (rs *readSnapshots) remove(key readSnapshotKey) bool {
+	rs.Lock()
+	defer rs.Unlock()
+	rs.gcLocked(rs.now())
+	s, ok := rs.snapshots[key]
+	if ok {
+		rs.removeLocked(key, s)
+	}
+	return ok
+}
+
+func (rs *readSnapshots) gcLocked(now time.Time) {
+	for key, s := range rs.snapshots {
+		if now.After(s.expireAt) {
+			rs.removeLocked(key, s)
+		}
+	}
+}
+
+func (rs *readSnapshots) removeLocked(key readSnapshotKey, s *readSnapshot) {
+	delete(rs.snapshots, key)
+	s.removed = true
+	if s.refs == 0 {
+		_ = s.view.Close()
+	}
+}
+
+func unmarshalReadSnapshotRequest(shard metapb.Shard, cmd []byte) (ReadSnapshotRequest, readSnapshotKey) {
+	var req ReadSnapshotRequest
+	if err := req.Unmarshal(cmd); err != nil {
+		panic(err)
+	}
+	return req, readSnapshotKey{id: string(req.ID), shard: shard.ID}
+}
+
+func (rs *readSnapshots) handleCreate(shard metapb.Shard, cmd []byte, buffer *buf.ByteBuf, kvStore storage.KVStorage) (KVReadCommandResult, error) {
+	req, key := unmarshalReadSnapshotRequest(shard, cmd)
+	if req.TTL <= 0 {
+		req.TTL = DefaultReadSnapshotTTL
+	}
+
+	view := kvStore.GetView()
+	var appliedIndex uint64
+	appliedIndexKey := keysutil.EncodeShardMetadataKey(keys.GetAppliedIndexKey(shard.ID, nil), nil)
+	err := kvStore.ScanInView(view, appliedIndexKey, keysutil.NextKey(appliedIndexKey, buffer),
+		func(key, value []byte) (bool, error) {
+			var index metapb.LogIndex
+			protoc.MustUnmarshal(&index, value)
+			appliedIndex = index.Index
+			return false, nil
+		}, false)
+	if err != nil {
+		_ = view.Close()
+		return KVReadCommandResult{}, err
+	}
+
+	rs.add(key, &readSnapshot{view: view, shard: shard, ttl: req.TTL})
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, appliedIndex)
+	return KVReadCommandResult{
+		Response: ReadSnapshotResponse{Data: data}.Marshal(),
+	}, nil
+}
+
+func (rs *readSnapshots) handleGet(shard metapb.Shard, cmd []byte, buffer *buf.ByteBuf, kvStore storage.KVStorage) (KVReadCommandResult, error) {
+	req, key := unmarshalReadSnapshotRequest(shard, cmd)
+	s := rs.acquire(key)
+	if s == nil {
+		return KVReadCommandResult{Response: ReadSnapshotResponse{NotFound: true}.Marshal()}, nil
+	}
+	defer rs.done(s)
+
+	var getReq rpcpb.KVGetRequest
+	if err := getReq.FastUnmarshal(req.Cmd); err != nil {
+		panic(err)
+	}
+	var resp rpcpb.KVGetResponse
+	dataKey := keysutil.EncodeDataKey(getReq.Key, nil)
+	err := kvStore.ScanInView(s.view, dataKey, keysutil.NextKey(dataKey, nil),
+		func(key, value []byte) (bool, error) {
+			resp.Value = keysutil.Clone(value)
+			return false, nil
+		}, false)
+	if err != nil {
+		return KVReadCommandResult{}, err
+	}
+	return KVReadCommandResult{
+		ReadBytes: uint64(len(resp.Value)),
+		Response:  ReadSnapshotResponse{Data: protoc.MustMarshal(&resp)}.Marshal(),
+	}, nil
+}
+
+func (rs *readSnapshots) handleScan(shard metapb.Shard, cmd []byte, buffer *buf.ByteBuf, kvStore storage.KVStorage) (KVReadCommandResult, error) {
+	req, key := unmarshalReadSnapshotRequest(shard, cmd)
+	s := rs.acquire(key)
+	if s == nil {
+		return KVReadCommandResult{Response: ReadSnapshotResponse{NotFound: true}.Marshal()}, nil
+	}
+	defer rs.done(s)
+
+	var scanReq rpcpb.KVScanRequest
+	if err := scanReq.FastUnmarshal(req.Cmd); err != nil {
+		panic(err)
+	}
+	// the range of the shard may be changed after pinned
+	result, err := scanInView(s.shard, scanReq, buffer, kvStore, s.view)
+	if err != nil {
+		return KVReadCommandResult{}, err
+	}
+	result.Response = ReadSnapshotResponse{Data: result.Response}.Marshal()
+	return result, nil
+}
+
+func (rs *readSnapshots) handleRelease(shard metapb.Shard, cmd []byte, buffer *buf.ByteBuf, kvStore storage.KVStorage) (KVReadCommandResult, error) {
+	_, key := unmarshalReadSnapshotRequest(shard, cmd)
+	return KVReadCommandResult{
+		Response: ReadSnapshotResponse{NotFound: !rs.remove(key)}.Marshal(),
+	}, nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/fagongzi/util/protoc"
+	"github.com/matrixorigin/matrixcube/keys"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	"github.com/matrixorigin/matrixcube/util/buf"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSnapshotCodec(t *testing.T) {
+	req := ReadSnapshotRequest{ID: []byte("id"), TTL: time.Second, Cmd: []byte("cmd")}
+	var decoded ReadSnapshotRequest
+	assert.NoError(t, decoded.Unmarshal(req.Marshal()))
+	assert.Equal(t, req, decoded)
+	assert.Error(t, decoded.Unmarshal([]byte{0, 0, 0, 1, 1}))
+
+	for _, resp := range []ReadSnapshotResponse{{NotFound: true, Data: []byte{}}, {Data: []byte("data")}} {
+		var decodedResp ReadSnapshotResponse
+		assert.NoError(t, decodedResp.Unmarshal(resp.Marshal()))
+		assert.Equal(t, resp, decodedResp)
+	}
+	var decodedResp ReadSnapshotResponse
+	assert.Error(t, decodedResp.Unmarshal(nil))
+	assert.Error(t, decodedResp.Unmarshal([]byte{2}))
+}
+
+func TestReadSnapshot(t *testing.T) {
+	kvStore := mem.NewStorage()
+	defer kvStore.Close()
+	buffer := buf.NewByteBuf(32)
+	defer buffer.Release()
+
+	shard := metapb.Shard{ID: 1, Start: []byte("a"), End: []byte("d")}
+	for _, k := range []string{"a", "b", "c", "d"} {
+		assert.NoError(t, kvStore.Set(keysutil.EncodeDataKey([]byte(k), nil), []byte(k), false))
+	}
+	assert.NoError(t, kvStore.Set(keysutil.EncodeShardMetadataKey(keys.GetAppliedIndexKey(shard.ID, nil), nil),
+		protoc.MustMarshal(&metapb.LogIndex{Index: 10}), false))
+
+	rs := newReadSnapshots()
+	now := time.Now()
+	rs.now = func() time.Time { return now }
+	exec := func(handler KVReadCommandHandler, shard metapb.Shard, id string, cmd []byte) ReadSnapshotResponse {
+		req := ReadSnapshotRequest{ID: []byte(id), TTL: time.Second, Cmd: cmd}
+		result, err := handler(shard, req.Marshal(), buffer, kvStore)
+		require.NoError(t, err)
+		var resp ReadSnapshotResponse
+		require.NoError(t, resp.Unmarshal(result.Response))
+		return resp
+	}
+	get := func(id, key string) (rpcpb.KVGetResponse, bool) {
+		resp := exec(rs.handleGet, shard, id, protoc.MustMarshal(&rpcpb.KVGetRequest{Key: []byte(key)}))
+		var getResp rpcpb.KVGetResponse
+		if !resp.NotFound {
+			protoc.MustUnmarshal(&getResp, resp.Data)
+		}
+		return getResp, !resp.NotFound
+	}
+	scan := func(shard metapb.Shard, id string) ([][]byte, bool) {
+		resp := exec(rs.handleScan, shard, id, protoc.MustMarshal(&rpcpb.KVScanRequest{WithValue: true}))
+		var scanResp rpcpb.KVScanResponse
+		if !resp.NotFound {
+			protoc.MustUnmarshal(&scanResp, resp.Data)
+		}
+		return scanResp.Values, !resp.NotFound
+	}
+
+	resp := exec(rs.handleCreate, shard, "s1", nil)
+	assert.False(t, resp.NotFound)
+	assert.Equal(t, uint64(10), binary.BigEndian.Uint64(resp.Data))
+	assert.Equal(t, 1, rs.len())
+
+	// the later writes are invisible
+	assert.NoError(t, kvStore.Set(keysutil.EncodeDataKey([]byte("b"), nil), []byte("x"), false))
+	assert.NoError(t, kvStore.Delete(keysutil.EncodeDataKey([]byte("c"), nil), false))
+	v, ok := get("s1", "b")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("b"), v.Value)
+	v, ok = get("s1", "c")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("c"), v.Value)
+	values, ok := scan(shard, "s1")
+	assert.True(t, ok)
+	assert.Equal(t, [][]byte{[]byte("a"), []byte("b"), []byte("c")}, values)
+	// the range of the pinned shard is used
+	values, ok = scan(metapb.Shard{ID: 1, Start: []byte("a"), End: []byte("b")}, "s1")
+	assert.True(t, ok)
+	assert.Equal(t, [][]byte{[]byte("a"), []byte("b"), []byte("c")}, values)
+
+	// unknown read snapshot
+	_, ok = get("s2", "b")
+	assert.False(t, ok)
+	_, ok = scan(metapb.Shard{ID: 2}, "s1")
+	assert.False(t, ok)
+
+	// the ttl is refreshed by the reads
+	now = now.Add(time.Millisecond * 800)
+	_, ok = get("s1", "b")
+	assert.True(t, ok)
+	now = now.Add(time.Millisecond * 800)
+	_, ok = get("s1", "b")
+	assert.True(t, ok)
+	now = now.Add(time.Second * 2)
+	_, ok = get("s1", "b")
+	assert.False(t, ok)
+	assert.Equal(t, 0, rs.len())
+
+	// release
+	exec(rs.handleCreate, shard, "s1", nil)
+	assert.False(t, exec(rs.handleRelease, shard, "s1", nil).NotFound)
+	assert.True(t, exec(rs.handleRelease, shard, "s1", nil).NotFound)
+	_, ok = get("s1", "b")
+	assert.False(t, ok)
+}
+
+func TestReadSnapshotClosedAfterDone(t *testing.T) {
+	kvStore := mem.NewStorage()
+	defer kvStore.Close()
+
+	rs := newReadSnapshots()
+	view := &testView{View: kvStore.GetView()}
+	key := readSnapshotKey{id: "s1", shard: 1}
+	rs.add(key, &readSnapshot{view: view, ttl: time.Second})
+	s := rs.acquire(key)
+	require.NotNil(t, s)
+	assert.True(t, rs.remove(key))
+	assert.False(t, view.closed)
+	rs.done(s)
+	assert.True(t, view.closed)
+}
+
+type testView struct {
+	storage.View
+	closed bool
+}
+
+func (v *testView) Close() error {
+	v.closed = true
+	return v.View.Close()
+}
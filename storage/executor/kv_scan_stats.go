@@ -0,0 +1,106 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util/buf"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+)
+
+// CmdKVScanStats counts the keys and sums the bytes of the values in a sub-range
+// of the shard. The request is ScanChecksumRequest, and the response is
+// ScanStatsResponse.
+const CmdKVScanStats = CmdKVScanChecksum + 8
+
+var (
+	// ErrInvalidScanStats the scan stats payload is malformed
+	ErrInvalidScanStats = errors.New("invalid scan stats payload")
+)
+
+// ScanStatsResponse the stats of the range limited by the shard. End is the end
+// of the range, an empty End means the range has no upper bound. AppliedIndex
+// is the applied index of the shard in the same storage view as the stats.
+type ScanStatsResponse struct {
+	AppliedIndex uint64
+	Keys         uint64
+	ValueBytes   uint64
+	End          []byte
+}
+
+// Marshal marshal the response
+func (resp ScanStatsResponse) Marshal() []byte {
+	data := make([]byte, 24+len(resp.End))
+	binary.BigEndian.PutUint64(data, resp.AppliedIndex)
+	binary.BigEndian.PutUint64(data[8:], resp.Keys)
+	binary.BigEndian.PutUint64(data[16:], resp.ValueBytes)
+	copy(data[24:], resp.End)
+	return data
+}
+
+// Unmarshal unmarshal the response
+func (resp *ScanStatsResponse) Unmarshal(data []byte) error {
+	if len(data) < 24 {
+		return ErrInvalidScanStats
+	}
+	resp.AppliedIndex = binary.BigEndian.Uint64(data)
+	resp.Keys = binary.BigEndian.Uint64(data[8:])
+	resp.ValueBytes = binary.BigEndian.Uint64(data[16:])
+	resp.End = nil
+	if len(data) > 24 {
+		resp.End = data[24:]
+	}
+	return nil
+}
+
+func handleScanStats(shard metapb.Shard, cmd []byte, buffer *buf.ByteBuf, kvStore storage.KVStorage) (KVReadCommandResult, error) {
+	var req ScanChecksumRequest
+	if err := req.Unmarshal(cmd); err != nil {
+		panic(err)
+	}
+	req.Start, req.End = limitShardRange(shard, req.Start, req.End)
+
+	view, err := storage.GetBackgroundView(kvStore)
+	if err != nil {
+		return KVReadCommandResult{}, err
+	}
+	defer view.Close()
+
+	resp := ScanStatsResponse{End: req.End}
+	resp.AppliedIndex, err = appliedIndexInView(kvStore, view, shard.ID, buffer)
+	if err != nil {
+		return KVReadCommandResult{}, err
+	}
+
+	readBytes := uint64(0)
+	err = kvStore.ScanInView(view, keysutil.EncodeShardStart(req.Start, buffer),
+		keysutil.EncodeShardEnd(req.End, buffer), func(key, value []byte) (bool, error) {
+			resp.Keys++
+			resp.ValueBytes += uint64(len(value))
+			readBytes += uint64(len(key) + len(value))
+			return true, nil
+		}, false)
+	if err != nil {
+		return KVReadCommandResult{}, err
+	}
+
+	return KVReadCommandResult{
+		ReadBytes: readBytes,
+		Response:  resp.Marshal(),
+	}, nil
+}
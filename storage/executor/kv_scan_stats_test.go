@@ -0,0 +1,72 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"testing"
+
+	"github.com/fagongzi/util/protoc"
+	"github.com/matrixorigin/matrixcube/keys"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	"github.com/matrixorigin/matrixcube/util/buf"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanStatsCodec(t *testing.T) {
+	for _, resp := range []ScanStatsResponse{
+		{AppliedIndex: 1, Keys: 2, ValueBytes: 3, End: []byte("a")},
+		{AppliedIndex: 1, Keys: 2, ValueBytes: 3},
+	} {
+		var decoded ScanStatsResponse
+		assert.NoError(t, decoded.Unmarshal(resp.Marshal()))
+		assert.Equal(t, resp, decoded)
+	}
+	var decoded ScanStatsResponse
+	assert.Error(t, decoded.Unmarshal([]byte{0xff}))
+}
+
+func TestHandleScanStats(t *testing.T) {
+	kvStore := mem.NewStorage()
+	defer kvStore.Close()
+	// the stats scans are served by the checkpoints of the storage
+	kvStore.SetBackgroundScanCacheSize(1024 * 1024)
+
+	buffer := buf.NewByteBuf(32)
+	defer buffer.Release()
+
+	shard := metapb.Shard{ID: 1}
+	for _, k := range []string{"a", "b", "c", "d"} {
+		assert.NoError(t, kvStore.Set(keysutil.EncodeDataKey([]byte(k), nil), []byte(k+k), false))
+	}
+	assert.NoError(t, kvStore.Set(keysutil.EncodeShardMetadataKey(keys.GetAppliedIndexKey(shard.ID, nil), nil),
+		protoc.MustMarshal(&metapb.LogIndex{Index: 10}), false))
+
+	stats := func(shard metapb.Shard, start, end string) ScanStatsResponse {
+		req := ScanChecksumRequest{Start: []byte(start), End: []byte(end)}
+		result, err := handleScanStats(shard, req.Marshal(), buffer, kvStore)
+		assert.NoError(t, err)
+		var resp ScanStatsResponse
+		assert.NoError(t, resp.Unmarshal(result.Response))
+		assert.True(t, result.ReadBytes > 0)
+		return resp
+	}
+
+	assert.Equal(t, ScanStatsResponse{AppliedIndex: 10, Keys: 4, ValueBytes: 8}, stats(shard, "", ""))
+	assert.Equal(t, ScanStatsResponse{AppliedIndex: 10, Keys: 2, ValueBytes: 4, End: []byte("d")}, stats(shard, "b", "d"))
+	// range limited by the shard
+	assert.Equal(t, ScanStatsResponse{AppliedIndex: 10, Keys: 1, ValueBytes: 2, End: []byte("c")},
+		stats(metapb.Shard{ID: 1, Start: []byte("b"), End: []byte("c")}, "a", "d"))
+}
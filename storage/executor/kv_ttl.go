@@ -0,0 +1,228 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/pb/hlcpb"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util"
+	"github.com/matrixorigin/matrixcube/util/buf"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+)
+
+// CmdKVSetWithTTL sets the key which expires after the TTL. The request is
+// SetWithTTLRequest, and the response is the same as the CmdKVSet.
+//
+// The expiration is the timestamp of the batch assigned by the leader plus the
+// TTL, so all the replicas store the same expiration. The storage of the
+// executor must be a storage.TTLStore.
+const CmdKVSetWithTTL = CmdKVScanChecksum + 9
+
+// CmdKVPurgeExpired removes the expired keys found by the leader. The request
+// and response are PurgeExpiredRequest and PurgeExpiredResponse.
+//
+// A key is removed only if its TTL and value are not changed since it's found
+// expired, so the keys overwritten by the requests proposed meanwhile are kept.
+const CmdKVPurgeExpired = CmdKVScanChecksum + 10
+
+var (
+	// ErrInvalidTTL the ttl payload is malformed or the TTL is not positive
+	ErrInvalidTTL = errors.New("invalid ttl payload")
+	// ErrTTLNotSupported the storage of the executor is not a storage.TTLStore
+	ErrTTLNotSupported = errors.New("ttl not supported by the storage")
+)
+
+// SetWithTTLRequest sets the Key to the Value which expires after the TTL
+type SetWithTTLRequest struct {
+	Key   []byte
+	Value []byte
+	TTL   time.Duration
+}
+
+// PurgeExpiredRequest removes the expired keys, the keys are the origin keys.
+type PurgeExpiredRequest struct {
+	Keys []storage.ExpiredKey
+}
+
+// PurgeExpiredResponse the number of the removed keys
+type PurgeExpiredResponse struct {
+	Removed uint64
+}
+
+// NewSetWithTTLRequest returns the CmdKVSetWithTTL request
+func NewSetWithTTLRequest(key, value []byte, ttl time.Duration) (storage.Request, error) {
+	if ttl <= 0 {
+		return storage.Request{}, ErrInvalidTTL
+	}
+	return storage.Request{
+		CmdType: CmdKVSetWithTTL,
+		Key:     key,
+		Cmd:     SetWithTTLRequest{Key: key, Value: value, TTL: ttl}.Marshal(),
+	}, nil
+}
+
+// NewPurgeExpiredRequest returns the CmdKVPurgeExpired request, the keys are
+// the origin keys.
+func NewPurgeExpiredRequest(keys []storage.ExpiredKey) storage.Request {
+	req := storage.Request{
+		CmdType: CmdKVPurgeExpired,
+		Cmd:     PurgeExpiredRequest{Keys: keys}.Marshal(),
+	}
+	if len(keys) > 0 {
+		req.Key = keys[0].Key
+	}
+	return req
+}
+
+// Marshal marshal the request
+func (req SetWithTTLRequest) Marshal() []byte {
+	data := make([]byte, 0, 16+len(req.Key)+len(req.Value))
+	data = appendChecksumBytes(data, req.Key)
+	data = appendChecksumBytes(data, req.Value)
+	var ttl [8]byte
+	binary.BigEndian.PutUint64(ttl[:], uint64(req.TTL))
+	return append(data, ttl[:]...)
+}
+
+// Unmarshal unmarshal the request
+func (req *SetWithTTLRequest) Unmarshal(data []byte) error {
+	var ok bool
+	if req.Key, data, ok = readChecksumBytes(data); !ok {
+		return ErrInvalidTTL
+	}
+	if req.Value, data, ok = readChecksumBytes(data); !ok || len(data) != 8 {
+		return ErrInvalidTTL
+	}
+	req.TTL = time.Duration(binary.BigEndian.Uint64(data))
+	if req.TTL <= 0 {
+		return ErrInvalidTTL
+	}
+	return nil
+}
+
+// Marshal marshal the request
+func (req PurgeExpiredRequest) Marshal() []byte {
+	size := 0
+	for _, e := range req.Keys {
+		size += 12 + len(e.Key)
+	}
+	data := make([]byte, 0, size)
+	for _, e := range req.Keys {
+		data = appendChecksumBytes(data, e.Key)
+		var expiration [8]byte
+		binary.BigEndian.PutUint64(expiration[:], uint64(e.Expiration))
+		data = append(data, expiration[:]...)
+	}
+	return data
+}
+
+// Unmarshal unmarshal the request
+func (req *PurgeExpiredRequest) Unmarshal(data []byte) error {
+	req.Keys = req.Keys[:0]
+	for len(data) > 0 {
+		var key []byte
+		var ok bool
+		if key, data, ok = readChecksumBytes(data); !ok || len(data) < 8 {
+			return ErrInvalidTTL
+		}
+		req.Keys = append(req.Keys, storage.ExpiredKey{
+			Key:        key,
+			Expiration: int64(binary.BigEndian.Uint64(data)),
+		})
+		data = data[8:]
+	}
+	return nil
+}
+
+// Marshal marshal the response
+func (resp PurgeExpiredResponse) Marshal() []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, resp.Removed)
+	return data
+}
+
+// Unmarshal unmarshal the response
+func (resp *PurgeExpiredResponse) Unmarshal(data []byte) error {
+	if len(data) != 8 {
+		return ErrInvalidTTL
+	}
+	resp.Removed = binary.BigEndian.Uint64(data)
+	return nil
+}
+
+// timedWriteCommandHandler the write command handler depends on the timestamp
+// of the batch.
+type timedWriteCommandHandler func(shard metapb.Shard, cmd []byte, wb util.WriteBatch, buffer *buf.ByteBuf,
+	kvStore storage.KVStorage, ts hlcpb.Timestamp) (KVWriteCommandResult, error)
+
+func handleSetWithTTL(shard metapb.Shard, cmd []byte, wb util.WriteBatch, buffer *buf.ByteBuf,
+	kvStore storage.KVStorage, ts hlcpb.Timestamp) (KVWriteCommandResult, error) {
+	var req SetWithTTLRequest
+	if err := req.Unmarshal(cmd); err != nil {
+		panic(err)
+	}
+	ttlStore, ok := kvStore.(storage.TTLStore)
+	if !ok {
+		return KVWriteCommandResult{}, ErrTTLNotSupported
+	}
+
+	key := keysutil.EncodeDataKey(req.Key, nil)
+	ttlStore.SetTTL(wb, key, req.Value, ts.PhysicalTime+int64(req.TTL))
+	changed := len(key) + len(req.Value)
+	return KVWriteCommandResult{
+		DiffBytes:    int64(changed),
+		WrittenBytes: uint64(changed),
+		Response:     setResponse,
+	}, nil
+}
+
+func handlePurgeExpired(shard metapb.Shard, cmd []byte, wb util.WriteBatch, buffer *buf.ByteBuf,
+	kvStore storage.KVStorage, ts hlcpb.Timestamp) (KVWriteCommandResult, error) {
+	var req PurgeExpiredRequest
+	if err := req.Unmarshal(cmd); err != nil {
+		panic(err)
+	}
+	ttlStore, ok := kvStore.(storage.TTLStore)
+	if !ok {
+		return KVWriteCommandResult{}, ErrTTLNotSupported
+	}
+
+	expired := make([]storage.ExpiredKey, 0, len(req.Keys))
+	for _, e := range req.Keys {
+		// the keys moved to other shards by split are purged by them
+		if !shard.ContainsKey(e.Key) {
+			continue
+		}
+		expired = append(expired, storage.ExpiredKey{
+			Key:        keysutil.EncodeDataKey(e.Key, nil),
+			Expiration: e.Expiration,
+		})
+	}
+	removed, removedBytes, err := ttlStore.PurgeExpired(wb, func(key []byte) ([]byte, error) {
+		return getInBatch(wb, kvStore, key)
+	}, expired)
+	if err != nil {
+		return KVWriteCommandResult{}, err
+	}
+	return KVWriteCommandResult{
+		DiffBytes:    -int64(removedBytes),
+		WrittenBytes: removedBytes,
+		Response:     PurgeExpiredResponse{Removed: uint64(removed)}.Marshal(),
+	}, nil
+}
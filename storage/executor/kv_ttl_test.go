@@ -0,0 +1,153 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/pb/hlcpb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/kv"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTLCodec(t *testing.T) {
+	_, err := NewSetWithTTLRequest([]byte("k"), []byte("v"), 0)
+	assert.ErrorIs(t, err, ErrInvalidTTL)
+
+	req := SetWithTTLRequest{Key: []byte("k"), Value: []byte("v"), TTL: time.Second}
+	var decoded SetWithTTLRequest
+	require.NoError(t, decoded.Unmarshal(req.Marshal()))
+	assert.Equal(t, req, decoded)
+	data := req.Marshal()
+	assert.Error(t, decoded.Unmarshal(data[:len(data)-1]))
+
+	purge := PurgeExpiredRequest{Keys: []storage.ExpiredKey{
+		{Key: []byte("k1"), Expiration: 1},
+		{Key: []byte("k2"), Expiration: 2},
+	}}
+	var decodedPurge PurgeExpiredRequest
+	require.NoError(t, decodedPurge.Unmarshal(purge.Marshal()))
+	assert.Equal(t, purge, decodedPurge)
+	data = purge.Marshal()
+	assert.Error(t, decodedPurge.Unmarshal(data[:len(data)-1]))
+
+	var resp PurgeExpiredResponse
+	require.NoError(t, resp.Unmarshal(PurgeExpiredResponse{Removed: 3}.Marshal()))
+	assert.Equal(t, uint64(3), resp.Removed)
+	assert.Error(t, resp.Unmarshal(nil))
+}
+
+func newTestTTLRequest(t *testing.T, key, value string, ttl time.Duration) storage.Request {
+	req, err := NewSetWithTTLRequest([]byte(key), []byte(value), ttl)
+	require.NoError(t, err)
+	return req
+}
+
+func TestSetWithTTLUsesBatchTimestamp(t *testing.T) {
+	base := kv.NewBaseStorage(mem.NewStorage(), vfs.NewMemFS())
+	defer base.Close()
+
+	ts := hlcpb.Timestamp{PhysicalTime: time.Now().Add(-time.Hour).UnixNano()}
+	exec := NewKVExecutor(base)
+	ctx := storage.NewSimpleWriteContext(1, base, storage.Batch{
+		Index:     1,
+		Timestamp: ts,
+		Requests: []storage.Request{
+			newTestTTLRequest(t, "k1", "v1", time.Second),
+			newTestTTLRequest(t, "k2", "v2", time.Hour*2),
+		},
+	})
+	require.NoError(t, exec.UpdateWriteBatch(ctx))
+	require.NoError(t, exec.ApplyWriteBatch(ctx.WriteBatch()))
+	assert.Equal(t, int64(10), ctx.GetDiffBytes())
+
+	// expired by the timestamp of the batch, not the time of the apply
+	value, err := base.Get(keysutil.EncodeDataKey([]byte("k1"), nil))
+	require.NoError(t, err)
+	assert.Nil(t, value)
+	value, err = base.Get(keysutil.EncodeDataKey([]byte("k2"), nil))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), value)
+
+	keys, err := base.ExpiredKeys(nil, nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.ExpiredKey{{
+		Key:        keysutil.EncodeDataKey([]byte("k1"), nil),
+		Expiration: ts.PhysicalTime + int64(time.Second),
+	}}, keys)
+
+	ctx = storage.NewSimpleWriteContext(1, mem.NewStorage(), storage.Batch{
+		Index:    1,
+		Requests: []storage.Request{newTestTTLRequest(t, "k1", "v1", time.Second)},
+	})
+	assert.ErrorIs(t, NewKVExecutor(mem.NewStorage()).UpdateWriteBatch(ctx), ErrTTLNotSupported)
+}
+
+func TestPurgeExpired(t *testing.T) {
+	base := kv.NewBaseStorage(mem.NewStorage(), vfs.NewMemFS())
+	defer base.Close()
+
+	exec := NewKVExecutor(base)
+	ts := hlcpb.Timestamp{PhysicalTime: time.Now().Add(-time.Hour).UnixNano()}
+	ctx := storage.NewSimpleWriteContext(1, base, storage.Batch{
+		Index:     1,
+		Timestamp: ts,
+		Requests: []storage.Request{
+			newTestTTLRequest(t, "k1", "v1", time.Second),
+			newTestTTLRequest(t, "k2", "v2", time.Second),
+			newTestTTLRequest(t, "k3", "v3", time.Second),
+		},
+	})
+	require.NoError(t, exec.UpdateWriteBatch(ctx))
+	require.NoError(t, exec.ApplyWriteBatch(ctx.WriteBatch()))
+
+	expired, err := base.ExpiredKeys(nil, nil, 10)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(expired))
+	for i := range expired {
+		expired[i].Key = keysutil.DecodeDataKey(expired[i].Key)
+	}
+
+	ctx = storage.NewSimpleWriteContext(1, base, storage.Batch{
+		Index:     2,
+		Timestamp: ts,
+		Requests: []storage.Request{
+			// overwritten before the purge in the same batch
+			{CmdType: uint64(rpcpb.CmdKVSet), Cmd: newTestSetRequest("k2", "v4")},
+			NewPurgeExpiredRequest(expired),
+		},
+	})
+	require.NoError(t, exec.UpdateWriteBatch(ctx))
+	require.NoError(t, exec.ApplyWriteBatch(ctx.WriteBatch()))
+	var resp PurgeExpiredResponse
+	require.NoError(t, resp.Unmarshal(ctx.Responses()[1]))
+	assert.Equal(t, uint64(2), resp.Removed)
+
+	raw := base.WithoutTTL()
+	for key, expected := range map[string][]byte{"k1": nil, "k2": []byte("v4"), "k3": nil} {
+		value, err := raw.Get(keysutil.EncodeDataKey([]byte(key), nil))
+		require.NoError(t, err)
+		assert.Equal(t, expected, value, key)
+	}
+	keys, err := base.ExpiredKeys(nil, nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
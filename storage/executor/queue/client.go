@@ -0,0 +1,91 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"context"
+
+	"github.com/matrixorigin/matrixcube/client"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+// Client queue client
+type Client interface {
+	// Append append values to the queue, returns the offset of the first value
+	Append(ctx context.Context, queue []byte, values ...[]byte) (AppendResponse, error)
+	// Read read the entries from the offset in the shard which contains the offset.
+	// Use ReadResponse.NextOffset to continue reading if ReadResponse.Completed is
+	// false.
+	Read(ctx context.Context, req ReadRequest) (ReadResponse, error)
+	// Trim delete entries before the offset in the shard which contains the entry
+	// before the offset.
+	Trim(ctx context.Context, queue []byte, before uint64) error
+}
+
+type queueClient struct {
+	group  uint64
+	policy rpcpb.ReplicaSelectPolicy
+	cli    client.Client
+}
+
+// NewClient returns a queue client of the shard group. The read policy is used
+// to select the replica to serve the read requests, `rpcpb.SelectRandom` can be
+// used to read from followers.
+func NewClient(cli client.Client, group uint64, readPolicy rpcpb.ReplicaSelectPolicy) Client {
+	return &queueClient{group: group, policy: readPolicy, cli: cli}
+}
+
+func (c *queueClient) Append(ctx context.Context, queue []byte, values ...[]byte) (AppendResponse, error) {
+	f := c.cli.Write(ctx, CmdAppend,
+		AppendRequest{Queue: queue, Values: values}.Marshal(),
+		client.WithShardGroup(c.group),
+		client.WithRouteKey(TailKey(queue)))
+	defer f.Close()
+
+	var resp AppendResponse
+	v, err := f.Get()
+	if err != nil {
+		return resp, err
+	}
+	err = resp.Unmarshal(v)
+	return resp, err
+}
+
+func (c *queueClient) Read(ctx context.Context, req ReadRequest) (ReadResponse, error) {
+	f := c.cli.Read(ctx, CmdRead, req.Marshal(),
+		client.WithShardGroup(c.group),
+		client.WithRouteKey(EntryKey(req.Queue, req.Offset)),
+		client.WithReplicaSelectPolicy(c.policy))
+	defer f.Close()
+
+	var resp ReadResponse
+	v, err := f.Get()
+	if err != nil {
+		return resp, err
+	}
+	err = resp.Unmarshal(v)
+	return resp, err
+}
+
+func (c *queueClient) Trim(ctx context.Context, queue []byte, before uint64) error {
+	if before == 0 {
+		return nil
+	}
+	f := c.cli.Write(ctx, CmdTrim,
+		TrimRequest{Queue: queue, Before: before}.Marshal(),
+		client.WithShardGroup(c.group),
+		client.WithRouteKey(EntryKey(queue, before-1)))
+	defer f.Close()
+	return f.GetError()
+}
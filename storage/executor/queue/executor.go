@@ -0,0 +1,179 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+)
+
+type executor struct {
+	kv storage.KVStorage
+}
+
+var _ storage.Executor = (*executor)(nil)
+
+// NewExecutor returns a queue state machine executor. It can be registered to a
+// shard group by executor.GroupExecutor.
+func NewExecutor(kv storage.KVStorage) storage.Executor {
+	return &executor{kv: kv}
+}
+
+func (e *executor) UpdateWriteBatch(ctx storage.WriteContext) error {
+	wb := util.NewCountingWriteBatch(ctx.WriteBatch().(util.WriteBatch))
+	shard := ctx.Shard()
+	// the tails changed by the previous requests in the same batch are not
+	// visible in the kv storage until the batch applied.
+	tails := make(map[string]uint64)
+	writtenBytes := uint64(0)
+	diffBytes := int64(0)
+	for _, req := range ctx.Batch().Requests {
+		switch req.CmdType {
+		case CmdAppend:
+			var r AppendRequest
+			if err := r.Unmarshal(req.Cmd); err != nil {
+				return err
+			}
+			resp, n, err := e.append(r, wb, tails)
+			if err != nil {
+				return err
+			}
+			writtenBytes += n
+			diffBytes += int64(n)
+			ctx.AppendResponse(resp.Marshal())
+		case CmdTrim:
+			var r TrimRequest
+			if err := r.Unmarshal(req.Cmd); err != nil {
+				return err
+			}
+			start, end, ok := clamp(shard, EntryKey(r.Queue, 0), EntryKey(r.Queue, r.Before))
+			if ok {
+				wb.DeleteRange(keysutil.EncodeDataKey(start, nil), keysutil.EncodeDataKey(end, nil))
+				writtenBytes += uint64(len(start) + len(end))
+			}
+			ctx.AppendResponse(nil)
+		default:
+			return fmt.Errorf("not support queue write cmd %d", req.CmdType)
+		}
+	}
+	ctx.SetWrittenBytes(writtenBytes)
+	ctx.SetWrittenKeys(wb.Keys())
+	ctx.SetDiffBytes(diffBytes)
+	return nil
+}
+
+func (e *executor) append(req AppendRequest, wb util.WriteBatch,
+	tails map[string]uint64) (AppendResponse, uint64, error) {
+	tailKey := TailKey(req.Queue)
+	next, ok := tails[string(tailKey)]
+	if !ok {
+		v, err := e.kv.Get(keysutil.EncodeDataKey(tailKey, nil))
+		if err != nil {
+			return AppendResponse{}, 0, err
+		}
+		if len(v) == offsetLen {
+			next = binary.BigEndian.Uint64(v)
+		}
+	}
+
+	resp := AppendResponse{FirstOffset: next}
+	written := uint64(0)
+	for _, value := range req.Values {
+		key := keysutil.EncodeDataKey(EntryKey(req.Queue, next), nil)
+		wb.Set(key, value)
+		written += uint64(len(key) + len(value))
+		next++
+	}
+	tail := make([]byte, offsetLen)
+	binary.BigEndian.PutUint64(tail, next)
+	wb.Set(keysutil.EncodeDataKey(tailKey, nil), tail)
+	tails[string(tailKey)] = next
+	resp.NextOffset = next
+	return resp, written, nil
+}
+
+func (e *executor) ApplyWriteBatch(r storage.Resetable) error {
+	return e.kv.Write(r.(util.WriteBatch), false)
+}
+
+func (e *executor) Read(ctx storage.ReadContext) ([]byte, error) {
+	req := ctx.Request()
+	if req.CmdType != CmdRead {
+		return nil, fmt.Errorf("not support queue read cmd %d", req.CmdType)
+	}
+	var r ReadRequest
+	if err := r.Unmarshal(req.Cmd); err != nil {
+		return nil, err
+	}
+
+	shard := ctx.Shard()
+	resp := ReadResponse{NextOffset: r.Offset}
+	start, end, ok := clamp(shard, EntryKey(r.Queue, r.Offset), TailKey(r.Queue))
+	if !ok {
+		resp.Completed = true
+		return resp.Marshal(), nil
+	}
+
+	view := e.kv.GetView()
+	defer view.Close()
+	readBytes := uint64(0)
+	stopped := false
+	err := e.kv.ScanInView(view, keysutil.EncodeDataKey(start, nil), keysutil.EncodeDataKey(end, nil),
+		func(key, value []byte) (bool, error) {
+			_, offset, ok := ParseEntryKey(keysutil.DecodeDataKey(key))
+			if !ok {
+				return true, nil
+			}
+			resp.Entries = append(resp.Entries, Entry{Offset: offset, Value: value})
+			resp.NextOffset = offset + 1
+			readBytes += uint64(len(key) + len(value))
+			if (r.Limit > 0 && uint64(len(resp.Entries)) >= r.Limit) ||
+				(r.MaxBytes > 0 && readBytes >= r.MaxBytes) {
+				stopped = true
+				return false, nil
+			}
+			return true, nil
+		}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// the rest entries are located in the next shard if the shard end is before
+	// the tail of the queue.
+	resp.Completed = !stopped && bytes.Equal(end, TailKey(r.Queue))
+	if !resp.Completed && !stopped && len(resp.Entries) == 0 {
+		if _, offset, ok := ParseEntryKey(shard.End); ok {
+			resp.NextOffset = offset
+		}
+	}
+	ctx.SetReadBytes(readBytes)
+	return resp.Marshal(), nil
+}
+
+// clamp returns the intersection of [start, end) and the shard range
+func clamp(shard metapb.Shard, start, end []byte) ([]byte, []byte, bool) {
+	if bytes.Compare(start, shard.Start) < 0 {
+		start = shard.Start
+	}
+	if len(shard.End) > 0 && bytes.Compare(end, shard.End) > 0 {
+		end = shard.End
+	}
+	return start, end, bytes.Compare(start, end) < 0
+}
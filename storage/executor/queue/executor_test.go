@@ -0,0 +1,132 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	"github.com/stretchr/testify/assert"
+)
+
+type testReadContext struct {
+	*storage.SimpleReadContext
+	shard metapb.Shard
+}
+
+func (ctx testReadContext) Shard() metapb.Shard {
+	return ctx.shard
+}
+
+func TestKeyLayout(t *testing.T) {
+	q := []byte("q")
+	assert.True(t, string(EntryKey(q, 0)) < string(EntryKey(q, 1)))
+	assert.True(t, string(EntryKey(q, 1<<40)) < string(TailKey(q)))
+	assert.True(t, string(TailKey(q)) < string(EntryKey([]byte("q1"), 0)))
+
+	name, offset, ok := ParseEntryKey(EntryKey(q, 10))
+	assert.True(t, ok)
+	assert.Equal(t, q, name)
+	assert.Equal(t, uint64(10), offset)
+	_, _, ok = ParseEntryKey(TailKey(q))
+	assert.False(t, ok)
+
+	fn := SplitKeyAdjustFunc(100)
+	assert.Equal(t, EntryKey(q, 200), fn(metapb.Shard{}, EntryKey(q, 234)))
+	assert.Equal(t, TailKey(q), fn(metapb.Shard{}, TailKey(q)))
+	// rounded down to the start key of the shard
+	assert.Nil(t, fn(metapb.Shard{Start: EntryKey(q, 200)}, EntryKey(q, 234)))
+	assert.Nil(t, fn(metapb.Shard{Start: EntryKey(q, 210)}, EntryKey(q, 234)))
+	assert.Equal(t, EntryKey(q, 200), fn(metapb.Shard{Start: EntryKey(q, 100)}, EntryKey(q, 234)))
+}
+
+func TestCodec(t *testing.T) {
+	req := AppendRequest{Queue: []byte("q"), Values: [][]byte{[]byte("a"), []byte("bc")}}
+	var decoded AppendRequest
+	assert.NoError(t, decoded.Unmarshal(req.Marshal()))
+	assert.Equal(t, req, decoded)
+
+	resp := ReadResponse{NextOffset: 3, Completed: true, Entries: []Entry{{Offset: 2, Value: []byte("v")}}}
+	var decodedResp ReadResponse
+	assert.NoError(t, decodedResp.Unmarshal(resp.Marshal()))
+	assert.Equal(t, resp, decodedResp)
+
+	assert.Error(t, decoded.Unmarshal([]byte{0xff}))
+}
+
+func TestAppendReadAndTrim(t *testing.T) {
+	kv := mem.NewStorage()
+	defer kv.Close()
+	e := NewExecutor(kv)
+
+	q := []byte("q")
+	ctx := storage.NewSimpleWriteContext(1, kv, storage.Batch{Index: 1, Requests: []storage.Request{
+		{CmdType: CmdAppend, Cmd: AppendRequest{Queue: q, Values: [][]byte{[]byte("v0"), []byte("v1")}}.Marshal()},
+		{CmdType: CmdAppend, Cmd: AppendRequest{Queue: q, Values: [][]byte{[]byte("v2")}}.Marshal()},
+	}})
+	assert.NoError(t, e.UpdateWriteBatch(ctx))
+	assert.NoError(t, e.ApplyWriteBatch(ctx.WriteBatch()))
+
+	var resp AppendResponse
+	assert.NoError(t, resp.Unmarshal(ctx.Responses()[0]))
+	assert.Equal(t, AppendResponse{FirstOffset: 0, NextOffset: 2}, resp)
+	assert.NoError(t, resp.Unmarshal(ctx.Responses()[1]))
+	assert.Equal(t, AppendResponse{FirstOffset: 2, NextOffset: 3}, resp)
+
+	read := func(req ReadRequest, shard metapb.Shard) ReadResponse {
+		v, err := e.Read(testReadContext{storage.NewSimpleReadContext(1,
+			storage.Request{CmdType: CmdRead, Cmd: req.Marshal()}), shard})
+		assert.NoError(t, err)
+		var resp ReadResponse
+		assert.NoError(t, resp.Unmarshal(v))
+		return resp
+	}
+
+	rr := read(ReadRequest{Queue: q, Offset: 1}, metapb.Shard{})
+	assert.True(t, rr.Completed)
+	assert.Equal(t, uint64(3), rr.NextOffset)
+	assert.Equal(t, []Entry{{Offset: 1, Value: []byte("v1")}, {Offset: 2, Value: []byte("v2")}}, rr.Entries)
+
+	rr = read(ReadRequest{Queue: q, Offset: 0, Limit: 1}, metapb.Shard{})
+	assert.False(t, rr.Completed)
+	assert.Equal(t, uint64(1), rr.NextOffset)
+
+	// queue split at offset 2
+	rr = read(ReadRequest{Queue: q, Offset: 0}, metapb.Shard{End: EntryKey(q, 2)})
+	assert.False(t, rr.Completed)
+	assert.Equal(t, 2, len(rr.Entries))
+	assert.Equal(t, uint64(2), rr.NextOffset)
+	rr = read(ReadRequest{Queue: q, Offset: 2}, metapb.Shard{Start: EntryKey(q, 2)})
+	assert.True(t, rr.Completed)
+	assert.Equal(t, 1, len(rr.Entries))
+
+	ctx = storage.NewSimpleWriteContext(1, kv, storage.Batch{Index: 2, Requests: []storage.Request{
+		{CmdType: CmdTrim, Cmd: TrimRequest{Queue: q, Before: 2}.Marshal()},
+	}})
+	assert.NoError(t, e.UpdateWriteBatch(ctx))
+	assert.NoError(t, e.ApplyWriteBatch(ctx.WriteBatch()))
+	rr = read(ReadRequest{Queue: q, Offset: 0}, metapb.Shard{})
+	assert.True(t, rr.Completed)
+	assert.Equal(t, []Entry{{Offset: 2, Value: []byte("v2")}}, rr.Entries)
+
+	// tail is kept after trim
+	ctx = storage.NewSimpleWriteContext(1, kv, storage.Batch{Index: 3, Requests: []storage.Request{
+		{CmdType: CmdAppend, Cmd: AppendRequest{Queue: q, Values: [][]byte{[]byte("v3")}}.Marshal()},
+	}})
+	assert.NoError(t, e.UpdateWriteBatch(ctx))
+	assert.NoError(t, resp.Unmarshal(ctx.Responses()[0]))
+	assert.Equal(t, uint64(3), resp.FirstOffset)
+}
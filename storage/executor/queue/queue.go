@@ -0,0 +1,334 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package queue is a reference implementation of an append-only log/queue state
+// machine on top of matrixcube. It shows how to run a non-KV state machine in a
+// dedicated shard group using custom commands.
+//
+// Key layout (inside the user key space of the shard group):
+//
+//	entry: queue-key | 0x00 | offset (8 bytes, big-endian)
+//	tail:  queue-key | 0x01
+//
+// where queue-key is the 2 bytes big-endian length of the queue name followed by
+// the name. All entries of a queue are ordered by offset, and the tail record,
+// which holds the next offset to be allocated, is always sorted after all
+// entries. So a queue can be split by offset, the appends always routed to the
+// last shard of the queue by the tail key, and the reads routed by the offset.
+package queue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+)
+
+const (
+	// CmdAppend append values to the queue
+	CmdAppend = uint64(rpcpb.CmdReserved) + 100
+	// CmdTrim delete all entries of the queue before the offset
+	CmdTrim = uint64(rpcpb.CmdReserved) + 101
+	// CmdRead read entries from the offset
+	CmdRead = uint64(rpcpb.CmdReserved) + 102
+
+	entryFlag byte = 0x00
+	tailFlag  byte = 0x01
+
+	offsetLen = 8
+)
+
+var (
+	// ErrInvalidCommand the command payload is malformed
+	ErrInvalidCommand = errors.New("invalid queue command")
+)
+
+// EntryKey returns the key of the entry at the offset
+func EntryKey(queue []byte, offset uint64) []byte {
+	key := make([]byte, keyLen(queue)+1+offsetLen)
+	n := encodeQueueKey(queue, key)
+	key[n] = entryFlag
+	binary.BigEndian.PutUint64(key[n+1:], offset)
+	return key
+}
+
+// TailKey returns the key of the tail record of the queue. The append requests
+// should use it as the route key.
+func TailKey(queue []byte) []byte {
+	key := make([]byte, keyLen(queue)+1)
+	n := encodeQueueKey(queue, key)
+	key[n] = tailFlag
+	return key
+}
+
+// ParseEntryKey returns the queue name and the offset of the entry key
+func ParseEntryKey(key []byte) ([]byte, uint64, bool) {
+	queue, n, ok := decodeQueueKey(key)
+	if !ok || len(key) != n+1+offsetLen || key[n] != entryFlag {
+		return nil, 0, false
+	}
+	return queue, binary.BigEndian.Uint64(key[n+1:]), true
+}
+
+// SplitKeyAdjustFunc returns a func used as storage.Feature.ShardSplitKeyAdjustFunc,
+// which aligns the split key to the first entry of an offset segment, so each
+// shard manages whole segments of the queue. Nil is returned if the aligned key
+// is not greater than the start key of the shard, which is not split then.
+func SplitKeyAdjustFunc(segment uint64) func(metapb.Shard, []byte) []byte {
+	return func(shard metapb.Shard, key []byte) []byte {
+		queue, offset, ok := ParseEntryKey(key)
+		if !ok || segment == 0 {
+			return key
+		}
+		key = EntryKey(queue, offset-offset%segment)
+		if bytes.Compare(key, shard.Start) <= 0 {
+			return nil
+		}
+		return key
+	}
+}
+
+func keyLen(queue []byte) int {
+	return 2 + len(queue)
+}
+
+func encodeQueueKey(queue []byte, dst []byte) int {
+	if len(queue) > math.MaxUint16 {
+		panic("queue name too long")
+	}
+	binary.BigEndian.PutUint16(dst, uint16(len(queue)))
+	copy(dst[2:], queue)
+	return keyLen(queue)
+}
+
+func decodeQueueKey(key []byte) ([]byte, int, bool) {
+	if len(key) < 2 {
+		return nil, 0, false
+	}
+	n := int(binary.BigEndian.Uint16(key)) + 2
+	if len(key) < n {
+		return nil, 0, false
+	}
+	return key[2:n], n, true
+}
+
+// AppendRequest append values to the queue
+type AppendRequest struct {
+	Queue  []byte
+	Values [][]byte
+}
+
+// AppendResponse the offset of the first appended value and the next offset
+type AppendResponse struct {
+	FirstOffset uint64
+	NextOffset  uint64
+}
+
+// TrimRequest delete all entries before the offset. Only the entries in the
+// shard which executes the request are deleted.
+type TrimRequest struct {
+	Queue  []byte
+	Before uint64
+}
+
+// ReadRequest read at most Limit entries and at most MaxBytes bytes from the
+// offset. Zero means no limit.
+type ReadRequest struct {
+	Queue    []byte
+	Offset   uint64
+	Limit    uint64
+	MaxBytes uint64
+}
+
+// Entry queue entry
+type Entry struct {
+	Offset uint64
+	Value  []byte
+}
+
+// ReadResponse read response. NextOffset is the offset to read from in the next
+// read, if Completed is false, the rest entries are located in the next shard.
+type ReadResponse struct {
+	Entries    []Entry
+	NextOffset uint64
+	Completed  bool
+}
+
+// Marshal marshal the request
+func (req AppendRequest) Marshal() []byte {
+	e := encoder{}
+	e.bytes(req.Queue)
+	e.uint64(uint64(len(req.Values)))
+	for _, v := range req.Values {
+		e.bytes(v)
+	}
+	return e.data
+}
+
+// Unmarshal unmarshal the request
+func (req *AppendRequest) Unmarshal(data []byte) error {
+	d := decoder{data: data}
+	req.Queue = d.bytes()
+	n := d.uint64()
+	if d.err == nil && n > uint64(len(data)) {
+		return ErrInvalidCommand
+	}
+	req.Values = make([][]byte, 0, n)
+	for i := uint64(0); i < n && d.err == nil; i++ {
+		req.Values = append(req.Values, d.bytes())
+	}
+	return d.done()
+}
+
+// Marshal marshal the response
+func (resp AppendResponse) Marshal() []byte {
+	e := encoder{}
+	e.uint64(resp.FirstOffset)
+	e.uint64(resp.NextOffset)
+	return e.data
+}
+
+// Unmarshal unmarshal the response
+func (resp *AppendResponse) Unmarshal(data []byte) error {
+	d := decoder{data: data}
+	resp.FirstOffset = d.uint64()
+	resp.NextOffset = d.uint64()
+	return d.done()
+}
+
+// Marshal marshal the request
+func (req TrimRequest) Marshal() []byte {
+	e := encoder{}
+	e.bytes(req.Queue)
+	e.uint64(req.Before)
+	return e.data
+}
+
+// Unmarshal unmarshal the request
+func (req *TrimRequest) Unmarshal(data []byte) error {
+	d := decoder{data: data}
+	req.Queue = d.bytes()
+	req.Before = d.uint64()
+	return d.done()
+}
+
+// Marshal marshal the request
+func (req ReadRequest) Marshal() []byte {
+	e := encoder{}
+	e.bytes(req.Queue)
+	e.uint64(req.Offset)
+	e.uint64(req.Limit)
+	e.uint64(req.MaxBytes)
+	return e.data
+}
+
+// Unmarshal unmarshal the request
+func (req *ReadRequest) Unmarshal(data []byte) error {
+	d := decoder{data: data}
+	req.Queue = d.bytes()
+	req.Offset = d.uint64()
+	req.Limit = d.uint64()
+	req.MaxBytes = d.uint64()
+	return d.done()
+}
+
+// Marshal marshal the response
+func (resp ReadResponse) Marshal() []byte {
+	e := encoder{}
+	e.uint64(resp.NextOffset)
+	if resp.Completed {
+		e.uint64(1)
+	} else {
+		e.uint64(0)
+	}
+	e.uint64(uint64(len(resp.Entries)))
+	for _, entry := range resp.Entries {
+		e.uint64(entry.Offset)
+		e.bytes(entry.Value)
+	}
+	return e.data
+}
+
+// Unmarshal unmarshal the response
+func (resp *ReadResponse) Unmarshal(data []byte) error {
+	d := decoder{data: data}
+	resp.NextOffset = d.uint64()
+	resp.Completed = d.uint64() == 1
+	n := d.uint64()
+	if d.err == nil && n > uint64(len(data)) {
+		return ErrInvalidCommand
+	}
+	resp.Entries = make([]Entry, 0, n)
+	for i := uint64(0); i < n && d.err == nil; i++ {
+		offset := d.uint64()
+		resp.Entries = append(resp.Entries, Entry{Offset: offset, Value: d.bytes()})
+	}
+	return d.done()
+}
+
+type encoder struct {
+	data []byte
+}
+
+func (e *encoder) uint64(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	e.data = append(e.data, tmp[:n]...)
+}
+
+func (e *encoder) bytes(v []byte) {
+	e.uint64(uint64(len(v)))
+	e.data = append(e.data, v...)
+}
+
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) uint64() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = ErrInvalidCommand
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.uint64()
+	if d.err != nil {
+		return nil
+	}
+	if n > uint64(len(d.data)) {
+		d.err = ErrInvalidCommand
+		return nil
+	}
+	v := d.data[:n]
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *decoder) done() error {
+	if d.err == nil && len(d.data) > 0 {
+		return ErrInvalidCommand
+	}
+	return d.err
+}
@@ -0,0 +1,544 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"bytes"
+	"sync/atomic"
+
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/stats"
+	"github.com/matrixorigin/matrixcube/util"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// defaultInitialMmapSize the file is mapped with the size initially, the
+	// writers are not blocked by the views until the file exceeds the size.
+	defaultInitialMmapSize = 1 << 30
+)
+
+var (
+	// bucket all the key-value pairs are stored in the bucket
+	bucket = []byte("data")
+)
+
+type view struct {
+	tx *bolt.Tx
+}
+
+func (v *view) Close() error {
+	return v.tx.Rollback()
+}
+
+func (v *view) Raw() interface{} {
+	return v.tx
+}
+
+// Storage is a kv storage based on bbolt, it has a much smaller memory
+// footprint than the pebble based storage, all the key-value pairs are stored
+// in a single B+tree file mapped into memory.
+//
+// The view is a read-only transaction of bbolt, the long running views prevent
+// the file from being remapped and block the writers growing the file beyond
+// the mapped size until they are closed, so the views should be closed as soon
+// as possible. The file is mapped with 1GB initially unless the InitialMmapSize
+// is specified. All the writes are synced to disk
+// unless the storage is opened with NoSync.
+type Storage struct {
+	db    *bolt.DB
+	stats stats.Stats
+}
+
+var _ storage.KVStorage = (*Storage)(nil)
+
+// NewStorage returns a bbolt backed kv store, the key-value pairs are stored in
+// the specified file.
+func NewStorage(file string, opts *bolt.Options) (*Storage, error) {
+	o := *bolt.DefaultOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.InitialMmapSize == 0 {
+		o.InitialMmapSize = defaultInitialMmapSize
+	}
+	db, err := bolt.Open(file, 0600, &o)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &Storage{db: db}, nil
+}
+
+func (s *Storage) GetView() storage.View {
+	tx, err := s.db.Begin(false)
+	if err != nil {
+		panic(err)
+	}
+	return &view{tx: tx}
+}
+
+// Close close the storage
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// Write write the data in batch
+func (s *Storage) Write(uwb util.WriteBatch, sync bool) error {
+	wb := uwb.(*writeBatch)
+	if len(wb.ops) == 0 {
+		return nil
+	}
+	return s.update(func(b *bolt.Bucket) error {
+		for _, op := range wb.ops {
+			var err error
+			switch op.kind {
+			case opSet:
+				err = b.Put(op.key, op.value)
+			case opDelete:
+				err = b.Delete(op.key)
+			case opDeleteRange:
+				err = deleteRange(b, op.key, op.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Set put the key, value pair to the storage
+func (s *Storage) Set(key, value []byte, sync bool) error {
+	atomic.AddUint64(&s.stats.WrittenKeys, 1)
+	atomic.AddUint64(&s.stats.WrittenBytes, uint64(len(value)+len(key)))
+	return s.update(func(b *bolt.Bucket) error {
+		return b.Put(key, value)
+	})
+}
+
+// Get returns the value of the key
+func (s *Storage) Get(key []byte) ([]byte, error) {
+	var v []byte
+	err := s.GetWithFunc(key, func(value []byte) error {
+		if len(value) > 0 {
+			v = keysutil.Clone(value)
+		}
+		return nil
+	})
+	return v, err
+}
+
+// MultiGet reads all the keys in a single read-only transaction
+func (s *Storage) MultiGet(keys [][]byte) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		for i, key := range keys {
+			value := b.Get(key)
+			if len(value) == 0 {
+				continue
+			}
+			values[i] = keysutil.Clone(value)
+			atomic.AddUint64(&s.stats.ReadKeys, 1)
+			atomic.AddUint64(&s.stats.ReadBytes, uint64(len(key)+len(value)))
+		}
+		return nil
+	})
+	return values, err
+}
+
+// GetWithFunc is similer to Get, but avoid clone the value
+func (s *Storage) GetWithFunc(key []byte, fn func([]byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(bucket).Get(key)
+		if value == nil {
+			return nil
+		}
+		atomic.AddUint64(&s.stats.ReadKeys, 1)
+		atomic.AddUint64(&s.stats.ReadBytes, uint64(len(key)+len(value)))
+		return fn(value)
+	})
+}
+
+// Delete remove the key from the storage
+func (s *Storage) Delete(key []byte, sync bool) error {
+	atomic.AddUint64(&s.stats.WrittenKeys, 1)
+	atomic.AddUint64(&s.stats.WrittenBytes, uint64(len(key)))
+	return s.update(func(b *bolt.Bucket) error {
+		return b.Delete(key)
+	})
+}
+
+// RangeDelete remove data in [start,end), the empty start or end means no
+// bound.
+func (s *Storage) RangeDelete(start, end []byte, sync bool) error {
+	atomic.AddUint64(&s.stats.WrittenKeys, 2)
+	atomic.AddUint64(&s.stats.WrittenBytes, uint64(len(start)+len(end)))
+	return s.update(func(b *bolt.Bucket) error {
+		return deleteRange(b, start, end)
+	})
+}
+
+// Scan scans the key-value pairs in [start, end), and perform with a handler function, if the function
+// returns false, the scan will be terminated.
+// The Handler func will received a cloned the key and value, if the `cloneResult` is true.
+func (s *Storage) Scan(start, end []byte, handler func(key, value []byte) (bool, error), cloneResult bool) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return s.scan(tx, start, end, handler, cloneResult)
+	})
+}
+
+func (s *Storage) ScanInView(view storage.View,
+	start, end []byte, handler func(key, value []byte) (bool, error), cloneResult bool) error {
+	return s.scan(view.Raw().(*bolt.Tx), start, end, handler, cloneResult)
+}
+
+func (s *Storage) scan(tx *bolt.Tx, start, end []byte,
+	handler func(key, value []byte) (bool, error), cloneResult bool) error {
+	c := tx.Bucket(bucket).Cursor()
+	for k, v := seekGE(c, start); inRange(k, start, end); k, v = c.Next() {
+		var ok bool
+		var err error
+		if cloneResult {
+			ok, err = handler(keysutil.Clone(k), keysutil.Clone(v))
+		} else {
+			ok, err = handler(k, v)
+		}
+		if err != nil {
+			return err
+		}
+		atomic.AddUint64(&s.stats.ReadKeys, 1)
+		atomic.AddUint64(&s.stats.ReadBytes, uint64(len(k)+len(v)))
+		if !ok {
+			break
+		}
+	}
+	return nil
+}
+
+// ScanReverse scans the key-value pairs in [start, end) in descending key
+// order, and perform with a handler function, if the function returns false,
+// the scan will be terminated.
+// The Handler func will received a cloned the key and value, if the `cloneResult` is true.
+func (s *Storage) ScanReverse(start, end []byte, handler func(key, value []byte) (bool, error), cloneResult bool) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return s.scanReverse(tx, start, end, handler, cloneResult)
+	})
+}
+
+func (s *Storage) ScanReverseInView(view storage.View,
+	start, end []byte, handler func(key, value []byte) (bool, error), cloneResult bool) error {
+	return s.scanReverse(view.Raw().(*bolt.Tx), start, end, handler, cloneResult)
+}
+
+func (s *Storage) scanReverse(tx *bolt.Tx, start, end []byte,
+	handler func(key, value []byte) (bool, error), cloneResult bool) error {
+	c := tx.Bucket(bucket).Cursor()
+	for k, v := seekLT(c, end); inRange(k, start, end); k, v = c.Prev() {
+		var ok bool
+		var err error
+		if cloneResult {
+			ok, err = handler(keysutil.Clone(k), keysutil.Clone(v))
+		} else {
+			ok, err = handler(k, v)
+		}
+		if err != nil {
+			return err
+		}
+		atomic.AddUint64(&s.stats.ReadKeys, 1)
+		atomic.AddUint64(&s.stats.ReadBytes, uint64(len(k)+len(v)))
+		if !ok {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *Storage) ScanInViewWithOptions(view storage.View, start, end []byte, handler func(key, value []byte) (storage.NextIterOptions, error)) error {
+	c := view.Raw().(*bolt.Tx).Bucket(bucket).Cursor()
+	k, v := seekGE(c, start)
+	return s.scanWithOptions(c, k, v, start, end, handler, c.Next)
+}
+
+func (s *Storage) ReverseScanInViewWithOptions(view storage.View, start, end []byte, handler func(key, value []byte) (storage.NextIterOptions, error)) error {
+	c := view.Raw().(*bolt.Tx).Bucket(bucket).Cursor()
+	k, v := seekLT(c, end)
+	return s.scanWithOptions(c, k, v, start, end, handler, c.Prev)
+}
+
+func (s *Storage) scanWithOptions(c *bolt.Cursor, k, v []byte, start, end []byte,
+	handler func(key, value []byte) (storage.NextIterOptions, error),
+	next func() ([]byte, []byte)) error {
+	for inRange(k, start, end) {
+		opts, err := handler(k, v)
+		if err != nil {
+			return err
+		}
+		atomic.AddUint64(&s.stats.ReadKeys, 1)
+		atomic.AddUint64(&s.stats.ReadBytes, uint64(len(k)+len(v)))
+		if opts.Stop {
+			break
+		}
+
+		if len(opts.SeekGE) > 0 {
+			k, v = c.Seek(opts.SeekGE)
+		} else if len(opts.SeekLT) > 0 {
+			k, v = seekLT(c, opts.SeekLT)
+		} else {
+			k, v = next()
+		}
+	}
+	return nil
+}
+
+// PrefixScan scans the key-value pairs starts from prefix but only keys for the same prefix,
+// while perform with a handler function, if the function returns false, the scan will be terminated.
+// The Handler func will received a cloned the key and value, if the `clone` is true.
+func (s *Storage) PrefixScan(prefix []byte, handler func(key, value []byte) (bool, error), cloneResult bool) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return s.scan(tx, prefix, nil, func(key, value []byte) (bool, error) {
+			if !bytes.HasPrefix(key, prefix) {
+				return false, nil
+			}
+			return handler(key, value)
+		}, cloneResult)
+	})
+}
+
+// Seek returns min[lowerBound, +inf)
+func (s *Storage) Seek(lowerBound []byte) ([]byte, []byte, error) {
+	return s.SeekAndLT(lowerBound, nil)
+}
+
+// SeekAndLT returns min[lowerBound, upperBound)
+func (s *Storage) SeekAndLT(lowerBound, upperBound []byte) ([]byte, []byte, error) {
+	return s.seek(func(c *bolt.Cursor) ([]byte, []byte) {
+		k, v := seekGE(c, lowerBound)
+		if !inRange(k, lowerBound, upperBound) {
+			return nil, nil
+		}
+		return k, v
+	})
+}
+
+// SeekLT returns max(-inf, upperBound)
+func (s *Storage) SeekLT(upperBound []byte) ([]byte, []byte, error) {
+	return s.SeekLTAndGE(upperBound, nil)
+}
+
+// SeekLTInView returns max(-inf, upperBound) in the view
+func (s *Storage) SeekLTInView(view storage.View, upperBound []byte) ([]byte, []byte, error) {
+	k, v := seekLT(view.Raw().(*bolt.Tx).Bucket(bucket).Cursor(), upperBound)
+	if !inRange(k, nil, upperBound) {
+		return nil, nil, nil
+	}
+	atomic.AddUint64(&s.stats.ReadKeys, 1)
+	atomic.AddUint64(&s.stats.ReadBytes, uint64(len(k)+len(v)))
+	return keysutil.Clone(k), keysutil.Clone(v), nil
+}
+
+// ScanPage scans a page of [start, end), see storage.PageScan.
+func (s *Storage) ScanPage(start, end []byte, limit uint64,
+	opts storage.ScanPageOptions) ([]storage.KeyValue, []byte, error) {
+	return storage.PageScan(s, start, end, limit, opts)
+}
+
+// NewIterator returns the Iterator over [start, end) read by ScanPage.
+func (s *Storage) NewIterator(start, end []byte, opts storage.ScanPageOptions) storage.Iterator {
+	return storage.NewPageIterator(s, start, end, opts)
+}
+
+// SeekLTAndGE returns max[lowerBound, upperBound)
+func (s *Storage) SeekLTAndGE(upperBound, lowerBound []byte) ([]byte, []byte, error) {
+	return s.seek(func(c *bolt.Cursor) ([]byte, []byte) {
+		k, v := seekLT(c, upperBound)
+		if !inRange(k, lowerBound, upperBound) {
+			return nil, nil
+		}
+		return k, v
+	})
+}
+
+func (s *Storage) seek(fn func(c *bolt.Cursor) ([]byte, []byte)) ([]byte, []byte, error) {
+	var key, value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		k, v := fn(tx.Bucket(bucket).Cursor())
+		if k != nil {
+			key = keysutil.Clone(k)
+			value = keysutil.Clone(v)
+			atomic.AddUint64(&s.stats.ReadKeys, 1)
+			atomic.AddUint64(&s.stats.ReadBytes, uint64(len(k)+len(v)))
+		}
+		return nil
+	})
+	return key, value, err
+}
+
+// Sync persist data to disk, it's required only if the storage is opened with
+// NoSync.
+func (s *Storage) Sync() error {
+	atomic.AddUint64(&s.stats.SyncCount, 1)
+	return s.db.Sync()
+}
+
+func (s *Storage) Stats() stats.Stats {
+	return stats.Stats{
+		WrittenKeys:  atomic.LoadUint64(&s.stats.WrittenKeys),
+		WrittenBytes: atomic.LoadUint64(&s.stats.WrittenBytes),
+		ReadKeys:     atomic.LoadUint64(&s.stats.ReadKeys),
+		ReadBytes:    atomic.LoadUint64(&s.stats.ReadBytes),
+		SyncCount:    atomic.LoadUint64(&s.stats.SyncCount),
+	}
+}
+
+// NewWriteBatch create and returns write batch
+func (s *Storage) NewWriteBatch() storage.Resetable {
+	return &writeBatch{stats: &s.stats}
+}
+
+// update runs the fn in a read-write transaction, the transaction is synced to
+// disk on commit unless the storage is opened with NoSync.
+func (s *Storage) update(fn func(b *bolt.Bucket) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return fn(tx.Bucket(bucket))
+	})
+}
+
+// seekGE moves the cursor to the first key in [key, +inf), the empty key means
+// the first key.
+func seekGE(c *bolt.Cursor, key []byte) ([]byte, []byte) {
+	if len(key) == 0 {
+		return c.First()
+	}
+	return c.Seek(key)
+}
+
+// seekLT moves the cursor to the last key in (-inf, key), the empty key means
+// the last key.
+func seekLT(c *bolt.Cursor, key []byte) ([]byte, []byte) {
+	if len(key) == 0 {
+		return c.Last()
+	}
+	if k, _ := c.Seek(key); k == nil {
+		return c.Last()
+	}
+	return c.Prev()
+}
+
+// inRange returns true if the key is in [start, end), the empty start or end
+// means no bound.
+func inRange(key, start, end []byte) bool {
+	return key != nil &&
+		(len(start) == 0 || bytes.Compare(key, start) >= 0) &&
+		(len(end) == 0 || bytes.Compare(key, end) < 0)
+}
+
+// deleteRange removes the keys in [start, end), the keys are collected before
+// deleting since deleting while iterating by the cursor skips keys.
+func deleteRange(b *bolt.Bucket, start, end []byte) error {
+	var keys [][]byte
+	c := b.Cursor()
+	for k, _ := seekGE(c, start); inRange(k, start, end); k, _ = c.Next() {
+		keys = append(keys, keysutil.Clone(k))
+	}
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type opKind int
+
+const (
+	opSet opKind = iota
+	opDelete
+	// opDeleteRange the value is the end of the range
+	opDeleteRange
+)
+
+type op struct {
+	kind  opKind
+	key   []byte
+	value []byte
+}
+
+// writeBatch records the operations in memory, they are applied in a bbolt
+// transaction atomically.
+type writeBatch struct {
+	util.WriteConditions
+	ops   []op
+	stats *stats.Stats
+}
+
+func (wb *writeBatch) Delete(key []byte) {
+	wb.ops = append(wb.ops, op{kind: opDelete, key: keysutil.Clone(key)})
+	atomic.AddUint64(&wb.stats.WrittenBytes, uint64(len(key)))
+}
+
+func (wb *writeBatch) DeleteDeferred(keyLen int, setter func(key []byte)) {
+	key := make([]byte, keyLen)
+	setter(key)
+	wb.ops = append(wb.ops, op{kind: opDelete, key: key})
+}
+
+func (wb *writeBatch) DeleteRange(fk []byte, lk []byte) {
+	wb.ops = append(wb.ops, op{kind: opDeleteRange, key: keysutil.Clone(fk), value: keysutil.Clone(lk)})
+}
+
+func (wb *writeBatch) DeleteRangeDeferred(startLen, endLen int, setter func(start, end []byte)) {
+	start := make([]byte, startLen)
+	end := make([]byte, endLen)
+	setter(start, end)
+	wb.ops = append(wb.ops, op{kind: opDeleteRange, key: start, value: end})
+}
+
+func (wb *writeBatch) Set(key []byte, value []byte) {
+	wb.ops = append(wb.ops, op{kind: opSet, key: keysutil.Clone(key), value: keysutil.Clone(value)})
+	atomic.AddUint64(&wb.stats.WrittenBytes, uint64(len(key)+len(value)))
+}
+
+func (wb *writeBatch) SetDeferred(keyLen, valueLen int, setter func(key, value []byte)) {
+	key := make([]byte, keyLen)
+	value := make([]byte, valueLen)
+	setter(key, value)
+	wb.ops = append(wb.ops, op{kind: opSet, key: key, value: value})
+}
+
+func (wb *writeBatch) SetIfAbsent(key []byte, value []byte) {
+	wb.CompareAndSet(key, nil, value)
+}
+
+func (wb *writeBatch) CompareAndSet(key, expected, value []byte) {
+	wb.AddCondition(key, expected)
+	wb.Set(key, value)
+}
+
+func (wb *writeBatch) Reset() {
+	wb.ops = wb.ops[:0]
+	wb.ResetConditions()
+}
+
+func (wb *writeBatch) Close() {
+	wb.ops = nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+	"github.com/matrixorigin/matrixcube/storage/kv"
+	"github.com/matrixorigin/matrixcube/storage/kv/kvtest"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStorage(t *testing.T) *Storage {
+	s, err := NewStorage(filepath.Join(t.TempDir(), "data.db"), nil)
+	require.NoError(t, err)
+	return s
+}
+
+func TestStorageConformance(t *testing.T) {
+	kvtest.RunKVStorageTests(t, func(t *testing.T) storage.KVStorage {
+		return newTestStorage(t)
+	})
+}
+
+func TestReopenStorage(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "data.db")
+	s, err := NewStorage(file, nil)
+	require.NoError(t, err)
+	require.NoError(t, s.Set([]byte("k1"), []byte("v1"), true))
+	require.NoError(t, s.Close())
+
+	s, err = NewStorage(file, nil)
+	require.NoError(t, err)
+	defer s.Close()
+	v, err := s.Get([]byte("k1"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), v)
+}
+
+func TestCreateAndApplySnapshot(t *testing.T) {
+	fs := vfs.NewMemFS()
+	dir := "snapshot-dir"
+	shardID := uint64(100)
+	func() {
+		s := newTestStorage(t)
+		base := kv.NewBaseStorage(s, fs)
+		ds := kv.NewKVDataStorage(base, executor.NewKVExecutor(s))
+		defer ds.Close()
+		require.NoError(t, base.Set(keysutil.EncodeDataKey([]byte("bb"), nil), []byte("v"), false))
+		require.NoError(t, base.Set(keysutil.EncodeDataKey([]byte("yy"), nil), []byte("v"), false))
+		sm := metapb.ShardMetadata{
+			ShardID:  shardID,
+			LogIndex: 110,
+			Metadata: metapb.ShardLocalState{
+				Shard: metapb.Shard{ID: shardID, Start: []byte("aa"), End: []byte("xx")},
+			},
+		}
+		require.NoError(t, ds.SaveShardMetadata([]metapb.ShardMetadata{sm}))
+		require.NoError(t, base.CreateSnapshot(shardID, dir))
+	}()
+
+	s := newTestStorage(t)
+	base := kv.NewBaseStorage(s, fs)
+	defer base.Close()
+	require.NoError(t, base.Set(keysutil.EncodeDataKey([]byte("cc"), nil), []byte("v"), false))
+	require.NoError(t, base.ApplySnapshot(shardID, dir))
+	for k, expected := range map[string][]byte{"bb": []byte("v"), "cc": nil, "yy": nil} {
+		v, err := base.Get(keysutil.EncodeDataKey([]byte(k), nil))
+		require.NoError(t, err)
+		assert.Equal(t, expected, v, k)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build gofuzz
+// +build gofuzz
+
+// The fuzzing harnesses for the snapshot file, run them by go-fuzz:
+//
+//   go-fuzz-build -func FuzzReadSnapshot github.com/matrixorigin/matrixcube/storage/kv
+//   go-fuzz -bin kv-fuzz.zip -workdir fuzz
+//
+// or build with -libfuzzer to run by libFuzzer. Any panic or allocation larger
+// than the input is a bug, since the snapshots are received from the network.
+
+package kv
+
+import (
+	"bytes"
+
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+const (
+	fuzzShardID = uint64(1)
+)
+
+// FuzzReadSnapshot fuzzes the snapshot parsing and the metadata decoding
+func FuzzReadSnapshot(data []byte) int {
+	limit := int64(len(data))
+	sr, err := newSnapshotReader(bytes.NewReader(data), limit)
+	if err != nil {
+		return 0
+	}
+	manifest, err := readSnapshotManifest(sr, fuzzShardID)
+	if err != nil {
+		return 0
+	}
+	if err := readSnapshotData(newSnapshotRecordIterator(fuzzShardID, sr, manifest, limit),
+		func(key, value []byte) {}); err != nil {
+		return 0
+	}
+	return 1
+}
+
+// FuzzApplySnapshot fuzzes applying the snapshot file to the storage
+func FuzzApplySnapshot(data []byte) int {
+	fs := vfs.NewMemFS()
+	if err := fs.MkdirAll("snapshot", 0755); err != nil {
+		panic(err)
+	}
+	f, err := fs.Create(fs.PathJoin("snapshot", "db.data"))
+	if err != nil {
+		panic(err)
+	}
+	if _, err := f.Write(data); err != nil {
+		panic(err)
+	}
+	if err := f.Close(); err != nil {
+		panic(err)
+	}
+
+	kv := mem.NewStorage()
+	defer kv.Close()
+	if err := NewBaseStorage(kv, fs).ApplySnapshot(fuzzShardID, "snapshot"); err != nil {
+		return 0
+	}
+	return 1
+}
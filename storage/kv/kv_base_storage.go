@@ -14,42 +14,90 @@
 package kv
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
 	"io"
 	"math"
+	"sync"
 
 	"github.com/cockroachdb/errors"
-	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/sstable"
 	"github.com/fagongzi/util/protoc"
 	"github.com/matrixorigin/matrixcube/keys"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
 	"github.com/matrixorigin/matrixcube/storage"
 	"github.com/matrixorigin/matrixcube/storage/stats"
 	"github.com/matrixorigin/matrixcube/util"
+	"github.com/matrixorigin/matrixcube/util/buf"
 	keysutil "github.com/matrixorigin/matrixcube/util/keys"
 	"github.com/matrixorigin/matrixcube/vfs"
 )
 
 var (
 	ErrNoMetadata = errors.New("no metadata")
+	// ErrNoAppliedIndex the applied index of the shard is not found
+	ErrNoAppliedIndex = errors.New("no applied index")
 )
 
+var _ storage.SnapshotVerifier = (*BaseStorage)(nil)
+var _ storage.SizeEstimator = (*BaseStorage)(nil)
+var _ storage.WALDisabledStore = (*BaseStorage)(nil)
+var _ storage.BackgroundScanStore = (*BaseStorage)(nil)
+
 type BaseStorage struct {
-	kv storage.KVStorage
-	fs vfs.FS
+	kv  storage.KVStorage
+	fs  vfs.FS
+	ttl *ttlStore
+	// ignoreTTL the expired keys are not filtered, see WithoutTTL
+	ignoreTTL bool
+	// condMu is held in write mode by the write batches with conditions and in
+	// read mode by the other writes, so the conditions are not changed before
+	// the batch written
+	condMu *sync.RWMutex
+	// snapshotWriteBufferSize the buffer size of writing the snapshot files
+	snapshotWriteBufferSize int
+	// snapshotMaxRecordSize the max size of a record read from the snapshots
+	snapshotMaxRecordSize int64
+	// snapshotProgress the handler of the progress of applying the snapshots
+	snapshotProgress func(SnapshotProgress)
 }
 
 func NewBaseStorage(kv storage.KVStorage, fs vfs.FS) storage.KVBaseStorage {
 	return &BaseStorage{
-		kv: kv,
-		fs: fs,
+		kv:                      kv,
+		fs:                      fs,
+		ttl:                     newTTLStore(kv),
+		condMu:                  &sync.RWMutex{},
+		snapshotWriteBufferSize: defaultSnapshotWriteBufferSize,
+		snapshotMaxRecordSize:   defaultSnapshotMaxRecordSize,
+	}
+}
+
+// SetSnapshotWriteBufferSize set the buffer size of writing the snapshot files
+// and the snapshot streams, each key-value pair is written as a few small
+// fields, the buffer turns them into one write per buffer. 0 means the default
+// 1MB. It's not safe to call it while creating the snapshots.
+func (s *BaseStorage) SetSnapshotWriteBufferSize(size int) {
+	if size <= 0 {
+		size = defaultSnapshotWriteBufferSize
 	}
+	s.snapshotWriteBufferSize = size
 }
 
 func (s *BaseStorage) GetView() storage.View {
 	return s.kv.GetView()
 }
 
+// GetBackgroundView returns the view of the background scans of the underlying
+// storage, see storage.BackgroundScanStore.
+func (s *BaseStorage) GetBackgroundView() (storage.View, error) {
+	return storage.GetBackgroundView(s.kv)
+}
+
 func (s *BaseStorage) Close() error {
 	return s.kv.Close()
 }
@@ -62,115 +110,363 @@ func (s *BaseStorage) Stats() stats.Stats {
 	return s.kv.Stats()
 }
 
+// Write writes the batch, the conditions of the batch are checked against the
+// data before the batch atomically. A *storage.ConditionFailedError is returned
+// and nothing is written if any of the conditions failed.
 func (s *BaseStorage) Write(wb util.WriteBatch, sync bool) error {
+	if len(wb.Conditions()) == 0 {
+		defer s.lockWrite()()
+		return s.kv.Write(wb, sync)
+	}
+
+	s.condMu.Lock()
+	defer s.condMu.Unlock()
+	var failed []int
+	for i, cond := range wb.Conditions() {
+		value, err := s.kv.Get(cond.Key)
+		if err != nil {
+			return err
+		}
+		if expired, err := s.isExpired(cond.Key, value); err != nil {
+			return err
+		} else if expired {
+			value = nil
+		}
+		if !cond.Check(value) {
+			failed = append(failed, i)
+		}
+	}
+	if len(failed) > 0 {
+		return &storage.ConditionFailedError{Failed: failed}
+	}
 	return s.kv.Write(wb, sync)
 }
 
+// lockWrite is called before the writes without conditions, the returned func
+// must be called after the write.
+func (s *BaseStorage) lockWrite() func() {
+	s.condMu.RLock()
+	return s.condMu.RUnlock
+}
+
 func (s *BaseStorage) Set(key []byte, value []byte, sync bool) error {
+	defer s.lockWrite()()
 	return s.kv.Set(key, value, sync)
 }
 
+func (s *BaseStorage) SetTTL(wb util.WriteBatch, key, value []byte, expiration int64) {
+	s.ttl.set(wb, key, value, expiration)
+}
+
+func (s *BaseStorage) PurgeExpired(wb util.WriteBatch, get func(key []byte) ([]byte, error),
+	expired []storage.ExpiredKey) (int, uint64, error) {
+	return s.ttl.purge(wb, get, expired)
+}
+
+func (s *BaseStorage) ExpiredKeys(start, end []byte, limit int) ([]storage.ExpiredKey, error) {
+	return s.ttl.expiredKeys(start, end, limit)
+}
+
+// WithoutTTL returns the BaseStorage sharing the same data and the locks, but
+// the expired keys are not filtered.
+func (s *BaseStorage) WithoutTTL() storage.KVStorage {
+	return &BaseStorage{
+		kv:                      s.kv,
+		fs:                      s.fs,
+		ttl:                     s.ttl,
+		ignoreTTL:               true,
+		condMu:                  s.condMu,
+		snapshotWriteBufferSize: s.snapshotWriteBufferSize,
+		snapshotMaxRecordSize:   s.snapshotMaxRecordSize,
+		snapshotProgress:        s.snapshotProgress,
+	}
+}
+
 func (s *BaseStorage) Get(key []byte) ([]byte, error) {
-	return s.kv.Get(key)
+	value, err := s.kv.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if expired, err := s.isExpired(key, value); err != nil || expired {
+		return nil, err
+	}
+	return value, nil
 }
 
 func (s *BaseStorage) GetWithFunc(key []byte, fn func([]byte) error) error {
-	return s.kv.GetWithFunc(key, fn)
+	return s.kv.GetWithFunc(key, func(value []byte) error {
+		if expired, err := s.isExpired(key, value); err != nil || expired {
+			return err
+		}
+		return fn(value)
+	})
+}
+
+func (s *BaseStorage) MultiGet(keys [][]byte) ([][]byte, error) {
+	values, err := s.kv.MultiGet(keys)
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		if expired, err := s.isExpired(keys[i], value); err != nil {
+			return nil, err
+		} else if expired {
+			values[i] = nil
+		}
+	}
+	return values, nil
 }
 
 func (s *BaseStorage) Delete(key []byte, sync bool) error {
+	defer s.lockWrite()()
 	return s.kv.Delete(key, sync)
 }
 
 func (s *BaseStorage) Scan(start, end []byte,
 	handler func(key, value []byte) (bool, error), clone bool) error {
-	return s.kv.Scan(start, end, handler, clone)
+	expired, err := s.expired(nil, start, end)
+	if err != nil {
+		return err
+	}
+	return s.kv.Scan(start, end, skipExpired(expired, handler), clone)
 }
 
 func (s *BaseStorage) ScanInView(view storage.View,
 	start, end []byte, handler func(key, value []byte) (bool, error), clone bool) error {
-	return s.kv.ScanInView(view, start, end, handler, clone)
+	expired, err := s.expired(view, start, end)
+	if err != nil {
+		return err
+	}
+	return s.kv.ScanInView(view, start, end, skipExpired(expired, handler), clone)
+}
+
+func (s *BaseStorage) ScanReverse(start, end []byte,
+	handler func(key, value []byte) (bool, error), clone bool) error {
+	expired, err := s.expired(nil, start, end)
+	if err != nil {
+		return err
+	}
+	return s.kv.ScanReverse(start, end, skipExpired(expired, handler), clone)
+}
+
+func (s *BaseStorage) ScanReverseInView(view storage.View,
+	start, end []byte, handler func(key, value []byte) (bool, error), clone bool) error {
+	expired, err := s.expired(view, start, end)
+	if err != nil {
+		return err
+	}
+	return s.kv.ScanReverseInView(view, start, end, skipExpired(expired, handler), clone)
 }
 
 func (s *BaseStorage) ScanInViewWithOptions(view storage.View, start, end []byte, handler func(key, value []byte) (storage.NextIterOptions, error)) error {
-	return s.kv.ScanInViewWithOptions(view, start, end, handler)
+	expired, err := s.expired(view, start, end)
+	if err != nil {
+		return err
+	}
+	return s.kv.ScanInViewWithOptions(view, start, end, skipExpiredWithOptions(expired, handler))
 }
 
 func (s *BaseStorage) ReverseScanInViewWithOptions(view storage.View, start, end []byte, handler func(key, value []byte) (storage.NextIterOptions, error)) error {
-	return s.kv.ReverseScanInViewWithOptions(view, start, end, handler)
+	expired, err := s.expired(view, start, end)
+	if err != nil {
+		return err
+	}
+	return s.kv.ReverseScanInViewWithOptions(view, start, end, skipExpiredWithOptions(expired, handler))
 }
 
 // Deprecated: implement interface
 func (s *BaseStorage) PrefixScan(prefix []byte,
 	handler func(key, value []byte) (bool, error), clone bool) error {
-	return s.kv.PrefixScan(prefix, handler, clone)
+	expired, err := s.expired(nil, prefix, nil)
+	if err != nil {
+		return err
+	}
+	return s.kv.PrefixScan(prefix, skipExpired(expired, handler), clone)
 }
 
 func (s *BaseStorage) RangeDelete(start, end []byte, sync bool) error {
+	defer s.lockWrite()()
 	return s.kv.RangeDelete(start, end, sync)
 }
 
 func (s *BaseStorage) Seek(lowerBound []byte) ([]byte, []byte, error) {
-	return s.kv.Seek(lowerBound)
+	return s.seekNotExpired(lowerBound, s.kv.Seek, nextSeekGE)
 }
 
 func (s *BaseStorage) SeekAndLT(lowerBound, upperBound []byte) ([]byte, []byte, error) {
-	return s.kv.SeekAndLT(lowerBound, upperBound)
+	return s.seekNotExpired(lowerBound, func(bound []byte) ([]byte, []byte, error) {
+		return s.kv.SeekAndLT(bound, upperBound)
+	}, nextSeekGE)
 }
 
 func (s *BaseStorage) SeekLT(upperBound []byte) ([]byte, []byte, error) {
-	return s.kv.SeekLT(upperBound)
+	return s.seekNotExpired(upperBound, s.kv.SeekLT, nextSeekLT)
+}
+
+func (s *BaseStorage) SeekLTInView(view storage.View, upperBound []byte) ([]byte, []byte, error) {
+	return s.seekNotExpired(upperBound, func(bound []byte) ([]byte, []byte, error) {
+		return s.kv.SeekLTInView(view, bound)
+	}, nextSeekLT)
+}
+
+// ScanPage scans a page of [start, end), see storage.PageScan.
+func (s *BaseStorage) ScanPage(start, end []byte, limit uint64,
+	opts storage.ScanPageOptions) ([]storage.KeyValue, []byte, error) {
+	return storage.PageScan(s, start, end, limit, opts)
+}
+
+// NewIterator returns the Iterator over [start, end) read by ScanPage.
+func (s *BaseStorage) NewIterator(start, end []byte, opts storage.ScanPageOptions) storage.Iterator {
+	return storage.NewPageIterator(s, start, end, opts)
 }
 
 func (s *BaseStorage) SeekLTAndGE(upperBound, lowerBound []byte) ([]byte, []byte, error) {
-	return s.kv.SeekLTAndGE(upperBound, lowerBound)
+	return s.seekNotExpired(upperBound, func(bound []byte) ([]byte, []byte, error) {
+		return s.kv.SeekLTAndGE(bound, lowerBound)
+	}, nextSeekLT)
+}
+
+// isExpired returns true if the value of the key is expired
+func (s *BaseStorage) isExpired(key, value []byte) (bool, error) {
+	if s.ignoreTTL {
+		return false, nil
+	}
+	return s.ttl.isExpired(key, value)
+}
+
+// expired returns the checksums of the expired values in [start, end), see
+// ttlStore.expired.
+func (s *BaseStorage) expired(view storage.View, start, end []byte) (map[string]uint32, error) {
+	if s.ignoreTTL {
+		return nil, nil
+	}
+	return s.ttl.expired(view, start, end)
+}
+
+// seekNotExpired seeks from the bound until the found key is not expired, the
+// next returns the bound of the next seek after the expired key.
+func (s *BaseStorage) seekNotExpired(bound []byte,
+	seek func(bound []byte) ([]byte, []byte, error),
+	next func(key []byte) []byte) ([]byte, []byte, error) {
+	for {
+		key, value, err := seek(bound)
+		if err != nil || len(key) == 0 {
+			return key, value, err
+		}
+		if expired, err := s.isExpired(key, value); err != nil || !expired {
+			return key, value, err
+		}
+		bound = next(key)
+	}
+}
+
+func nextSeekGE(key []byte) []byte {
+	return keysutil.NextKey(key, nil)
+}
+
+func nextSeekLT(key []byte) []byte {
+	return key
+}
+
+func skipExpired(expired map[string]uint32,
+	handler func(key, value []byte) (bool, error)) func(key, value []byte) (bool, error) {
+	if len(expired) == 0 {
+		return handler
+	}
+	return func(key, value []byte) (bool, error) {
+		if checksum, ok := expired[string(key)]; ok && checksum == crc32.ChecksumIEEE(value) {
+			return true, nil
+		}
+		return handler(key, value)
+	}
+}
+
+func skipExpiredWithOptions(expired map[string]uint32,
+	handler func(key, value []byte) (storage.NextIterOptions, error)) func(key, value []byte) (storage.NextIterOptions, error) {
+	if len(expired) == 0 {
+		return handler
+	}
+	return func(key, value []byte) (storage.NextIterOptions, error) {
+		if checksum, ok := expired[string(key)]; ok && checksum == crc32.ChecksumIEEE(value) {
+			return storage.NextIterOptions{}, nil
+		}
+		return handler(key, value)
+	}
 }
 
 func (s *BaseStorage) Sync() error {
 	return s.kv.Sync()
 }
 
-func (s *BaseStorage) getAppliedIndex(ss *pebble.Snapshot,
+// EstimateSize estimates the size of [start, end) by the underlying storage,
+// storage.ErrEstimateNotSupported is returned if it's not a SizeEstimator.
+func (s *BaseStorage) EstimateSize(start, end []byte) (uint64, uint64, error) {
+	if e, ok := s.kv.(storage.SizeEstimator); ok {
+		return e.EstimateSize(start, end)
+	}
+	return 0, 0, storage.ErrEstimateNotSupported
+}
+
+// CompactRange compacts [start, end) by the underlying storage,
+// storage.ErrCompactNotSupported is returned if it's not a RangeCompactor.
+func (s *BaseStorage) CompactRange(start, end []byte) error {
+	if c, ok := s.kv.(storage.RangeCompactor); ok {
+		return c.CompactRange(start, end)
+	}
+	return storage.ErrCompactNotSupported
+}
+
+// WALDisabled returns true if the WAL of the underlying storage is disabled.
+func (s *BaseStorage) WALDisabled() bool {
+	if w, ok := s.kv.(storage.WALDisabledStore); ok {
+		return w.WALDisabled()
+	}
+	return false
+}
+
+// EstimateSplitKeys estimates the split keys of [start, end) by the underlying
+// storage, storage.ErrEstimateNotSupported is returned if it's not a
+// SizeEstimator.
+func (s *BaseStorage) EstimateSplitKeys(start, end []byte, size uint64) ([][]byte, error) {
+	if e, ok := s.kv.(storage.SizeEstimator); ok {
+		return e.EstimateSplitKeys(start, end, size)
+	}
+	return nil, storage.ErrEstimateNotSupported
+}
+
+func (s *BaseStorage) getAppliedIndex(view storage.View,
 	shardID uint64) ([]byte, []byte, error) {
 	key := keysutil.EncodeShardMetadataKey(keys.GetAppliedIndexKey(shardID, nil), nil)
-	v, closer, err := ss.Get(key)
-	if err != nil {
+	var value []byte
+	if err := s.kv.ScanInView(view, key, keysutil.NextKey(key, nil),
+		func(_, v []byte) (bool, error) {
+			value = v
+			return false, nil
+		}, true); err != nil {
 		return nil, nil, err
 	}
-	defer closer.Close()
-	return key, v, nil
+	if value == nil {
+		return nil, nil, ErrNoAppliedIndex
+	}
+	return key, value, nil
 }
 
-func (s *BaseStorage) getShardMetadata(ss *pebble.Snapshot,
+func (s *BaseStorage) getShardMetadata(view storage.View,
 	shardID uint64) ([]byte, []byte, error) {
-	ios := &pebble.IterOptions{
-		LowerBound: keysutil.EncodeShardMetadataKey(keys.GetMetadataKey(shardID, 0, nil), nil),
-		UpperBound: keysutil.EncodeShardMetadataKey(keys.GetMetadataKey(shardID, math.MaxUint64, nil), nil),
-	}
-	iter := ss.NewIter(ios)
-	defer iter.Close()
-
-	clone := func(value []byte) []byte {
-		v := make([]byte, len(value))
-		copy(v, value)
-		return v
-	}
+	start := keysutil.EncodeShardMetadataKey(keys.GetMetadataKey(shardID, 0, nil), nil)
+	end := keysutil.EncodeShardMetadataKey(keys.GetMetadataKey(shardID, math.MaxUint64, nil), nil)
 
 	var value []byte
 	var key []byte
-	iter.First()
-	for iter.Valid() {
-		if err := iter.Error(); err != nil {
-			return nil, nil, err
+	if err := s.kv.ScanInView(view, start, end, func(k, v []byte) (bool, error) {
+		keyShardID, err := keys.GetShardIDFromMetadataKey(k[1:])
+		if err != nil || keyShardID != shardID {
+			return false, nil
 		}
-		keyShardID, err := keys.GetShardIDFromMetadataKey(iter.Key()[1:])
-		if err == nil && keyShardID == shardID {
-			value = clone(iter.Value())
-			key = clone(iter.Key())
-		} else {
-			break
-		}
-		iter.Next()
+		key, value = k, v
+		return true, nil
+	}, true); err != nil {
+		return nil, nil, err
 	}
 
 	if len(value) == 0 || len(key) == 0 {
@@ -179,180 +475,862 @@ func (s *BaseStorage) getShardMetadata(ss *pebble.Snapshot,
 	return key, value, nil
 }
 
-// TODO: change the snapshot ops below to sst ingestion based with
-// special attention paid to its sync state.
+const (
+	// snapshotDataFile the file of the snapshot header, followed by the
+	// key-value pairs of the shard if the snapshot is not SST based.
+	snapshotDataFile = "db.data"
+	// snapshotSSTFile the SST file of the key-value pairs of the shard.
+	snapshotSSTFile = "db.sst"
+	// snapshotMetadataSSTFile the SST file of the shard metadata, written while
+	// ingesting the snapshot.
+	snapshotMetadataSSTFile = "metadata.sst"
+	// defaultSnapshotWriteBufferSize the default buffer size of writing the
+	// snapshot files
+	defaultSnapshotWriteBufferSize = buf.MB
+)
 
-// CreateSnapshot create a snapshot file under the giving path
+const (
+	// snapshotMagic the magic number at the beginning of the versioned snapshot
+	// file, the snapshot file without it is in the legacy format.
+	snapshotMagic uint32 = 0x4d435353
+	// snapshotVersionLegacy the fields are not checksummed, and the snapshot
+	// file ends at EOF.
+	snapshotVersionLegacy uint32 = 0
+	// snapshotVersionChecksum each field is followed by the crc32 checksum of it,
+	// the SST file is checksummed as a whole, and the snapshot file ends with an
+	// empty field, so the truncated snapshot file is detected.
+	snapshotVersionChecksum uint32 = 1
+	// snapshotVersionManifest the header is a metapb.SnapshotManifest instead of
+	// the positional fields, the format of the manifest evolves by itself.
+	snapshotVersionManifest uint32 = 2
+	// snapshotVersion the version of the created snapshot
+	snapshotVersion = snapshotVersionManifest
+)
+
+const (
+	// snapshotManifestFormatVersion the format version of the created manifest,
+	// the manifest converted from the positional header is in version 0.
+	snapshotManifestFormatVersion uint32 = 1
+
+	// snapshotFeatureSST the key-value pairs are in the SST file
+	snapshotFeatureSST uint64 = 1 << 0
+	// snapshotFeatureTTL the expiry metadata of the keys in the shard follows
+	// the key-value pairs, it's only set if any key has the metadata, so the
+	// snapshot without TTL can be applied by the older versions
+	snapshotFeatureTTL uint64 = 1 << 1
+	// supportedSnapshotFeatures all the features known by this version, the
+	// snapshot with any other feature is rejected
+	supportedSnapshotFeatures = snapshotFeatureSST | snapshotFeatureTTL
+)
+
+// CreateSnapshot create a snapshot file under the giving path. The key-value
+// pairs of the shard are written into a SST file if the KVStorage is able to
+// ingest it.
 func (s *BaseStorage) CreateSnapshot(shardID uint64, path string) error {
 	if err := s.fs.MkdirAll(path, 0755); err != nil {
 		return err
 	}
-	file := s.fs.PathJoin(path, "db.data")
-	f, err := s.fs.Create(file)
+	view := s.kv.GetView()
+	defer view.Close()
+
+	manifest, err := s.getSnapshotManifest(view, shardID)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	view := s.kv.GetView()
-	defer view.Close()
+	_, hasSST := s.kv.(storage.SSTIngester)
+	if hasSST {
+		// the SST file is written first, its checksum is in the manifest
+		sstFile := s.fs.PathJoin(path, snapshotSSTFile)
+		if err := s.createSnapshotSST(view, sstFile, &manifest); err != nil {
+			return err
+		}
+		if manifest.SstSize, manifest.SstChecksum, err = getSnapshotSSTChecksum(s.fs, sstFile); err != nil {
+			return err
+		}
+		manifest.Features |= snapshotFeatureSST
+	} else if err := s.fillSnapshotDataStats(view, &manifest); err != nil {
+		return err
+	}
 
-	snap := view.Raw().(*pebble.Snapshot)
-	appliedIndexKey, appliedIndexValue, err := s.getAppliedIndex(snap, shardID)
+	f, err := s.fs.Create(s.fs.PathJoin(path, snapshotDataFile))
 	if err != nil {
-		return errors.Wrapf(err, "failed to get applied index in CreateSnapshot")
+		return err
+	}
+	w := bufio.NewWriterSize(f, s.snapshotWriteBufferSize)
+	if err := s.writeSnapshotFile(w, view, manifest, hasSST); err != nil {
+		_ = f.Close()
+		return err
 	}
-	metadataKey, metadataValue, err := s.getShardMetadata(snap, shardID)
+	// the buffered fields must be written before the file is closed, the file
+	// written to the object storage is uploaded when it's closed
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeSnapshotFile writes the manifest and the key-value pairs if they're not
+// in the SST file.
+func (s *BaseStorage) writeSnapshotFile(w io.Writer, view storage.View,
+	manifest metapb.SnapshotManifest, hasSST bool) error {
+	if err := writeSnapshotManifest(w, manifest); err != nil {
+		return err
+	}
+	if hasSST {
+		return writeSnapshotEnd(w)
+	}
+	return s.writeSnapshotData(w, view, manifest)
+}
+
+// getSnapshotManifest returns the manifest with the range and the metadata of
+// the shard in the view, the stats of the key-value pairs are not filled.
+func (s *BaseStorage) getSnapshotManifest(view storage.View, shardID uint64) (metapb.SnapshotManifest, error) {
+	appliedIndexKey, appliedIndexValue, err := s.getAppliedIndex(view, shardID)
 	if err != nil {
-		return errors.Wrapf(err, "failed to get shard in CreateSnapshot")
+		return metapb.SnapshotManifest{}, errors.Wrapf(err, "failed to get applied index in CreateSnapshot")
+	}
+	metadataKey, metadataValue, err := s.getShardMetadata(view, shardID)
+	if err != nil {
+		return metapb.SnapshotManifest{}, errors.Wrapf(err, "failed to get shard in CreateSnapshot")
 	}
 
 	var sls metapb.ShardMetadata
-	var logIndex metapb.LogIndex
 	protoc.MustUnmarshal(&sls, metadataValue)
-	protoc.MustUnmarshal(&logIndex, appliedIndexValue)
 	shard := sls.Metadata.Shard
+	manifest := metapb.SnapshotManifest{
+		FormatVersion:     snapshotManifestFormatVersion,
+		Shard:             shard,
+		Start:             keysutil.EncodeShardStart(shard.Start, nil),
+		End:               keysutil.EncodeShardEnd(shard.End, nil),
+		AppliedIndexKey:   appliedIndexKey,
+		AppliedIndexValue: appliedIndexValue,
+		MetadataKey:       metadataKey,
+		MetadataValue:     metadataValue,
+	}
+	hasTTL, err := s.ttl.hasMeta(view, manifest.Start, manifest.End)
+	if err != nil {
+		return metapb.SnapshotManifest{}, err
+	}
+	if hasTTL {
+		manifest.Features |= snapshotFeatureTTL
+	}
+	return manifest, nil
+}
+
+// scanSnapshotData scans the key-value pairs in the range of the manifest, and
+// then the expiry metadata of them if the snapshot has TTL.
+func (s *BaseStorage) scanSnapshotData(view storage.View, manifest metapb.SnapshotManifest,
+	handler func(key, value []byte) (bool, error)) error {
+	if err := s.kv.ScanInView(view, manifest.Start, manifest.End, handler, false); err != nil {
+		return err
+	}
+	if manifest.Features&snapshotFeatureTTL == 0 {
+		return nil
+	}
+	start, end := ttlMetaRange(manifest.Start, manifest.End)
+	return s.kv.ScanInView(view, start, end, handler, false)
+}
+
+// removeSnapshotTTL puts the removal of the expiry metadata in the range of the
+// manifest into the batch, the stale metadata is removed even if the snapshot
+// has no TTL.
+func (s *BaseStorage) removeSnapshotTTL(batch util.WriteBatch, manifest metapb.SnapshotManifest) {
+	if manifest.Features&snapshotFeatureTTL != 0 {
+		s.ttl.activate()
+	}
+	batch.DeleteRange(ttlMetaRange(manifest.Start, manifest.End))
+}
 
-	if err := writeBytes(f, keysutil.EncodeShardStart(shard.Start, nil)); err != nil {
+// fillSnapshotDataStats fills the stats of the key-value pairs in the range
+// of the manifest, they're written after the manifest later.
+func (s *BaseStorage) fillSnapshotDataStats(view storage.View,
+	manifest *metapb.SnapshotManifest) error {
+	stats := newSnapshotDataStats()
+	if err := s.scanSnapshotData(view, *manifest,
+		func(key, value []byte) (bool, error) {
+			stats.add(key, value)
+			return true, nil
+		}); err != nil {
 		return err
 	}
-	if err := writeBytes(f, keysutil.EncodeShardEnd(shard.End, nil)); err != nil {
+	stats.fill(manifest)
+	return nil
+}
+
+// writeSnapshotData writes the key-value pairs in the range after the manifest,
+// and the end of the snapshot.
+func (s *BaseStorage) writeSnapshotData(w io.Writer, view storage.View,
+	manifest metapb.SnapshotManifest) error {
+	if err := s.scanSnapshotData(view, manifest,
+		func(key, value []byte) (bool, error) {
+			if err := writeBytes(w, key); err != nil {
+				return false, err
+			}
+			if err := writeBytes(w, value); err != nil {
+				return false, err
+			}
+			return true, nil
+		}); err != nil {
 		return err
 	}
-	if err := writeBytes(f, appliedIndexKey); err != nil {
+	return writeSnapshotEnd(w)
+}
+
+// createSnapshotSST writes the key-value pairs of the shard into the SST file
+// with a range deletion tombstone of the shard range, so the stale key-value
+// pairs are removed atomically when the SST file is ingested. The stats of the
+// key-value pairs are filled into the manifest.
+func (s *BaseStorage) createSnapshotSST(view storage.View, file string,
+	manifest *metapb.SnapshotManifest) error {
+	f, err := s.fs.Create(file)
+	if err != nil {
 		return err
 	}
-	if err := writeBytes(f, appliedIndexValue); err != nil {
+	// the file is synced and closed by the writer
+	w := sstable.NewWriter(f, sstable.WriterOptions{})
+	if err := w.DeleteRange(manifest.Start, manifest.End); err != nil {
+		_ = w.Close()
 		return err
 	}
-	if err := writeBytes(f, metadataKey); err != nil {
+
+	stats := newSnapshotDataStats()
+	if err := s.scanSnapshotData(view, *manifest,
+		func(key, value []byte) (bool, error) {
+			stats.add(key, value)
+			return true, w.Set(key, value)
+		}); err != nil {
+		_ = w.Close()
 		return err
 	}
-	if err := writeBytes(f, metadataValue); err != nil {
+	stats.fill(manifest)
+	return w.Close()
+}
+
+// ApplySnapshot apply a snapshort file from giving path. The SST file of the
+// snapshot is ingested if the KVStorage is able to, otherwise the key-value
+// pairs are written in a batch.
+func (s *BaseStorage) ApplySnapshot(shardID uint64, path string) error {
+	snap, err := s.openSnapshot(shardID, path)
+	if err != nil {
 		return err
 	}
+	defer snap.close()
+	manifest := snap.manifest
+	hasSST := snap.hasSST
+	sstFile := s.fs.PathJoin(path, snapshotSSTFile)
+	if ingester, ok := s.kv.(storage.SSTIngester); ok && hasSST {
+		return s.ingestSnapshot(ingester, path, manifest)
+	}
 
-	ios := &pebble.IterOptions{
-		LowerBound: keysutil.EncodeShardStart(shard.Start, nil),
-		UpperBound: keysutil.EncodeShardEnd(shard.End, nil),
+	batch := s.kv.NewWriteBatch().(util.WriteBatch)
+	defer batch.Close()
+	batch.DeleteRange(manifest.Start, manifest.End)
+	s.removeSnapshotTTL(batch, manifest)
+	batch.Set(manifest.AppliedIndexKey, manifest.AppliedIndexValue)
+	batch.Set(manifest.MetadataKey, manifest.MetadataValue)
+	fn := func(key, value []byte) {
+		batch.Set(key, value)
+	}
+	if hasSST {
+		err = readSnapshotSST(s.fs, sstFile, manifest, fn)
+	} else {
+		err = readSnapshotData(s.newSnapshotRecordIterator(shardID, snap.sr, manifest), fn)
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.kv.Write(batch, true); err != nil {
+		return err
 	}
 
-	iter := snap.NewIter(ios)
-	defer iter.Close()
-	iter.First()
-	for iter.Valid() {
-		if err := iter.Error(); err != nil {
+	return s.kv.Sync()
+}
+
+// VerifySnapshot checks the snapshot under the giving path without applying it,
+// storage.ErrSnapshotCorrupted is returned if the snapshot is corrupted.
+func (s *BaseStorage) VerifySnapshot(shardID uint64, path string) error {
+	snap, err := s.openSnapshot(shardID, path)
+	if err != nil {
+		return err
+	}
+	defer snap.close()
+	if snap.hasSST {
+		return readSnapshotSST(s.fs, s.fs.PathJoin(path, snapshotSSTFile), snap.manifest,
+			func(key, value []byte) {})
+	}
+	return readSnapshotData(newSnapshotRecordIterator(shardID, snap.sr, snap.manifest,
+		s.snapshotMaxRecordSize), func(key, value []byte) {})
+}
+
+// openedSnapshot is the snapshot with the manifest read, the key-value pairs
+// are read from the sr if the snapshot is not SST based.
+type openedSnapshot struct {
+	f        vfs.File
+	sr       *snapshotReader
+	manifest metapb.SnapshotManifest
+	hasSST   bool
+}
+
+func (o *openedSnapshot) close() {
+	_ = o.f.Close()
+}
+
+// openSnapshot opens the snapshot under the giving path and reads the manifest,
+// the checksum of the SST file is verified if the snapshot is SST based.
+func (s *BaseStorage) openSnapshot(shardID uint64, path string) (*openedSnapshot, error) {
+	f, err := s.fs.Open(s.fs.PathJoin(path, snapshotDataFile))
+	if err != nil {
+		return nil, err
+	}
+	snap := &openedSnapshot{f: f}
+	if err := s.doOpenSnapshot(snap, shardID, path); err != nil {
+		snap.close()
+		return nil, err
+	}
+	return snap, nil
+}
+
+func (s *BaseStorage) doOpenSnapshot(snap *openedSnapshot, shardID uint64, path string) error {
+	stat, err := snap.f.Stat()
+	if err != nil {
+		return err
+	}
+	if snap.sr, err = newSnapshotReader(snap.f, stat.Size()); err != nil {
+		return err
+	}
+	if snap.manifest, err = readSnapshotManifest(snap.sr, shardID); err != nil {
+		return err
+	}
+
+	sstFile := s.fs.PathJoin(path, snapshotSSTFile)
+	if snap.manifest.FormatVersion > 0 {
+		snap.hasSST = snap.manifest.Features&snapshotFeatureSST != 0
+		if _, err := s.fs.Stat(sstFile); snap.hasSST && vfs.IsNotExist(err) {
+			return errors.Wrap(storage.ErrSnapshotCorrupted, "missing sst file")
+		} else if snap.hasSST && err != nil {
 			return err
 		}
-		if err := writeBytes(f, iter.Key()); err != nil {
+	} else {
+		// the positional header, the snapshot is SST based if the file exists
+		snap.hasSST = true
+		if _, err := s.fs.Stat(sstFile); vfs.IsNotExist(err) {
+			snap.hasSST = false
+		} else if err != nil {
 			return err
 		}
-		if err = writeBytes(f, iter.Value()); err != nil {
+	}
+	if snap.hasSST {
+		if err := snap.sr.checkSSTChecksum(s.fs, sstFile, snap.manifest); err != nil {
 			return err
 		}
-		iter.Next()
+		// all key-value pairs are in the SST file
+		if v, err := snap.sr.readBytes(); err != nil {
+			return err
+		} else if v != nil {
+			return errors.Wrap(storage.ErrSnapshotCorrupted, "unexpected data after the manifest")
+		}
 	}
-
 	return nil
 }
 
-// ApplySnapshot apply a snapshort file from giving path
-func (s *BaseStorage) ApplySnapshot(shardID uint64, path string) error {
-	f, err := s.fs.Open(s.fs.PathJoin(path, "db.data"))
-	if err != nil {
+// ingestSnapshot ingests the SST file of the snapshot together with a SST file
+// of the shard metadata in the manifest. They're ingested atomically, so the
+// data and the applied index of the shard are always consistent after crash.
+func (s *BaseStorage) ingestSnapshot(ingester storage.SSTIngester,
+	path string, manifest metapb.SnapshotManifest) error {
+	sstFile := s.fs.PathJoin(path, snapshotSSTFile)
+	if err := checkSnapshotSST(s.fs, sstFile, manifest); err != nil {
 		return err
 	}
-	defer f.Close()
+
+	// the SST file has no range deletion of the expiry metadata, the stale one
+	// is removed before ingested, the snapshot is applied again after restart
+	// if the ingestion is not finished
 	batch := s.kv.NewWriteBatch().(util.WriteBatch)
 	defer batch.Close()
-
-	start, err := readBytes(f)
-	if err != nil {
+	s.removeSnapshotTTL(batch, manifest)
+	if err := s.kv.Write(batch, false); err != nil {
 		return err
 	}
-	if len(start) == 0 {
-		panic("range start not specified in snapshot")
+
+	metadataFile := s.fs.PathJoin(path, snapshotMetadataSSTFile)
+	if err := s.createMetadataSST(metadataFile, manifest); err != nil {
+		return err
 	}
-	end, err := readBytes(f)
+	defer func() {
+		_ = s.fs.Remove(metadataFile)
+	}()
+	return ingester.IngestExternalFiles(s.fs, []string{metadataFile, sstFile})
+}
+
+func (s *BaseStorage) createMetadataSST(file string, manifest metapb.SnapshotManifest) error {
+	f, err := s.fs.Create(file)
 	if err != nil {
 		return err
 	}
-	if len(end) == 0 {
-		panic("range end not specified in snapshot")
+	w := sstable.NewWriter(f, sstable.WriterOptions{})
+	kvs := [][2][]byte{
+		{manifest.AppliedIndexKey, manifest.AppliedIndexValue},
+		{manifest.MetadataKey, manifest.MetadataValue},
+	}
+	if bytes.Compare(kvs[0][0], kvs[1][0]) > 0 {
+		kvs[0], kvs[1] = kvs[1], kvs[0]
+	}
+	for _, kv := range kvs {
+		if err := w.Set(kv[0], kv[1]); err != nil {
+			_ = w.Close()
+			return err
+		}
 	}
-	appliedIndexKey, err := readBytes(f)
+	return w.Close()
+}
+
+// readSnapshotManifest reads and validates the snapshot manifest, the snapshot
+// may be received from the network, so all fields are checked before applied.
+// The positional header of the older versions is converted to the manifest in
+// format version 0.
+func readSnapshotManifest(sr *snapshotReader, shardID uint64) (metapb.SnapshotManifest, error) {
+	var manifest metapb.SnapshotManifest
+	if sr.version < snapshotVersionManifest {
+		if err := readSnapshotHeader(sr, &manifest); err != nil {
+			return manifest, err
+		}
+	} else {
+		v, err := sr.readBytes()
+		if err != nil {
+			return manifest, err
+		}
+		if len(v) == 0 {
+			return manifest, errors.Wrap(storage.ErrSnapshotCorrupted, "missing snapshot manifest")
+		}
+		if err := manifest.Unmarshal(v); err != nil {
+			return manifest, errors.Wrapf(storage.ErrSnapshotCorrupted, "invalid manifest, %v", err)
+		}
+		if manifest.FormatVersion == 0 || manifest.FormatVersion > snapshotManifestFormatVersion {
+			return manifest, errors.Wrapf(storage.ErrSnapshotCorrupted,
+				"unsupported manifest format version %d", manifest.FormatVersion)
+		}
+		if unknown := manifest.Features &^ supportedSnapshotFeatures; unknown != 0 {
+			return manifest, errors.Wrapf(storage.ErrSnapshotCorrupted,
+				"unsupported snapshot features %#x", unknown)
+		}
+		for _, field := range [][]byte{manifest.Start, manifest.End,
+			manifest.AppliedIndexKey, manifest.AppliedIndexValue,
+			manifest.MetadataKey, manifest.MetadataValue} {
+			if len(field) == 0 {
+				return manifest, errors.Wrap(storage.ErrSnapshotCorrupted, "missing snapshot manifest field")
+			}
+		}
+	}
+
+	if bytes.Compare(manifest.Start, manifest.End) >= 0 {
+		return manifest, errors.Wrapf(storage.ErrSnapshotCorrupted,
+			"invalid range [%+v, %+v)", manifest.Start, manifest.End)
+	}
+	if !bytes.Equal(manifest.AppliedIndexKey,
+		keysutil.EncodeShardMetadataKey(keys.GetAppliedIndexKey(shardID, nil), nil)) {
+		return manifest, errors.Wrapf(storage.ErrSnapshotCorrupted,
+			"invalid applied index key %+v", manifest.AppliedIndexKey)
+	}
+	var logIndex metapb.LogIndex
+	if err := logIndex.Unmarshal(manifest.AppliedIndexValue); err != nil {
+		return manifest, errors.Wrapf(storage.ErrSnapshotCorrupted, "invalid applied index, %v", err)
+	}
+	if metadataShardID, err := keys.GetShardIDFromMetadataKey(manifest.MetadataKey[1:]); err != nil ||
+		metadataShardID != shardID {
+		return manifest, errors.Wrapf(storage.ErrSnapshotCorrupted,
+			"invalid metadata key %+v", manifest.MetadataKey)
+	}
+	var sm metapb.ShardMetadata
+	if err := sm.Unmarshal(manifest.MetadataValue); err != nil {
+		return manifest, errors.Wrapf(storage.ErrSnapshotCorrupted, "invalid metadata, %v", err)
+	}
+	if sm.ShardID != shardID {
+		return manifest, errors.Wrapf(storage.ErrSnapshotCorrupted,
+			"metadata of shard %d, expect %d", sm.ShardID, shardID)
+	}
+	if manifest.FormatVersion == 0 {
+		manifest.Shard = sm.Metadata.Shard
+	}
+	if manifest.Shard.ID != shardID {
+		return manifest, errors.Wrapf(storage.ErrSnapshotCorrupted,
+			"snapshot of shard %d, expect %d", manifest.Shard.ID, shardID)
+	}
+	if !bytes.Equal(manifest.Start, keysutil.EncodeShardStart(manifest.Shard.Start, nil)) ||
+		!bytes.Equal(manifest.End, keysutil.EncodeShardEnd(manifest.Shard.End, nil)) {
+		return manifest, errors.Wrapf(storage.ErrSnapshotCorrupted,
+			"range [%+v, %+v) not match the shard", manifest.Start, manifest.End)
+	}
+	return manifest, nil
+}
+
+// readSnapshotHeader reads the positional header of the older versions into
+// the manifest.
+func readSnapshotHeader(sr *snapshotReader, manifest *metapb.SnapshotManifest) error {
+	fields := []*[]byte{&manifest.Start, &manifest.End,
+		&manifest.AppliedIndexKey, &manifest.AppliedIndexValue,
+		&manifest.MetadataKey, &manifest.MetadataValue}
+	for _, field := range fields {
+		v, err := sr.readBytes()
+		if err != nil {
+			return err
+		}
+		if len(v) == 0 {
+			return errors.Wrap(storage.ErrSnapshotCorrupted, "missing snapshot header field")
+		}
+		*field = v
+	}
+	return nil
+}
+
+// checkSnapshotSST checks the SST file of the snapshot before ingested, the
+// point keys must be in the range of the manifest, and the only range deletion
+// tombstone must be the range of the manifest.
+func checkSnapshotSST(fs vfs.FS, file string, manifest metapb.SnapshotManifest) error {
+	r, err := openSnapshotSST(fs, file)
 	if err != nil {
 		return err
 	}
-	appliedIndexValue, err := readBytes(f)
+	defer r.Close()
+
+	iter, err := r.NewIter(nil, nil)
 	if err != nil {
 		return err
 	}
-	metadataKey, err := readBytes(f)
-	if err != nil {
+	defer iter.Close()
+	// the point keys are sorted, checking the first and the last is enough
+	if key, _ := iter.First(); key != nil && !inSnapshotRange(key.UserKey, manifest) {
+		return errors.Wrapf(storage.ErrSnapshotCorrupted, "key %+v out of range", key.UserKey)
+	}
+	if key, _ := iter.Last(); key != nil && !inSnapshotRange(key.UserKey, manifest) {
+		return errors.Wrapf(storage.ErrSnapshotCorrupted, "key %+v out of range", key.UserKey)
+	}
+	if err := iter.Error(); err != nil {
 		return err
 	}
-	metadataValue, err := readBytes(f)
+	return checkSnapshotSSTRangeDel(r, manifest)
+}
+
+// readSnapshotSST reads the key-value pairs in the SST file of the snapshot,
+// all keys must be in the range of the manifest, and the stats must match the
+// manifest.
+func readSnapshotSST(fs vfs.FS, file string, manifest metapb.SnapshotManifest,
+	fn func(key, value []byte)) error {
+	r, err := openSnapshotSST(fs, file)
 	if err != nil {
 		return err
 	}
-	batch.DeleteRange(start, end)
-	batch.Set(appliedIndexKey, appliedIndexValue)
-	batch.Set(metadataKey, metadataValue)
+	defer r.Close()
+	if err := checkSnapshotSSTRangeDel(r, manifest); err != nil {
+		return err
+	}
 
-	for {
-		key, err := readBytes(f)
-		if err != nil {
-			return err
-		}
-		if len(key) == 0 {
-			break
-		}
-		value, err := readBytes(f)
-		if err != nil {
-			return err
+	iter, err := r.NewIter(nil, nil)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	stats := newSnapshotDataStats()
+	for key, value := iter.First(); key != nil; key, value = iter.Next() {
+		if key.Kind() != sstable.InternalKeyKindSet {
+			return errors.Wrapf(storage.ErrSnapshotCorrupted, "key %+v with kind %s",
+				key.UserKey, key.Kind())
 		}
-		if len(value) == 0 {
-			panic("key specified without value")
+		if !inSnapshotRange(key.UserKey, manifest) {
+			return errors.Wrapf(storage.ErrSnapshotCorrupted, "key %+v out of range", key.UserKey)
 		}
-		batch.Set(key, value)
+		stats.add(key.UserKey, value)
+		fn(key.UserKey, value)
 	}
-	if err := s.kv.Write(batch, true); err != nil {
+	if err := iter.Error(); err != nil {
 		return err
 	}
+	return stats.check(manifest)
+}
 
-	return s.kv.Sync()
+func openSnapshotSST(fs vfs.FS, file string) (*sstable.Reader, error) {
+	f, err := fs.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	// the file is closed by the reader
+	r, err := sstable.NewReader(f, sstable.ReaderOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(storage.ErrSnapshotCorrupted, "invalid sst, %v", err)
+	}
+	return r, nil
+}
+
+func checkSnapshotSSTRangeDel(r *sstable.Reader, manifest metapb.SnapshotManifest) error {
+	iter, err := r.NewRawRangeDelIter()
+	if err != nil {
+		return err
+	}
+	if iter == nil {
+		return errors.Wrap(storage.ErrSnapshotCorrupted, "missing range deletion")
+	}
+	defer iter.Close()
+	key, end := iter.First()
+	if key == nil || !bytes.Equal(key.UserKey, manifest.Start) || !bytes.Equal(end, manifest.End) {
+		return errors.Wrap(storage.ErrSnapshotCorrupted, "invalid range deletion")
+	}
+	if key, _ := iter.Next(); key != nil {
+		return errors.Wrap(storage.ErrSnapshotCorrupted, "unexpected range deletion")
+	}
+	return iter.Error()
+}
+
+func inSnapshotRange(key []byte, manifest metapb.SnapshotManifest) bool {
+	if bytes.Compare(key, manifest.Start) >= 0 && bytes.Compare(key, manifest.End) < 0 {
+		return true
+	}
+	if manifest.Features&snapshotFeatureTTL == 0 {
+		return false
+	}
+	start, end := ttlMetaRange(manifest.Start, manifest.End)
+	return bytes.Compare(key, start) >= 0 && bytes.Compare(key, end) < 0
+}
+
+// snapshotDataStats the stats of the key-value pairs in the snapshot manifest
+type snapshotDataStats struct {
+	keys     uint64
+	bytes    uint64
+	checksum hash.Hash32
+}
+
+func newSnapshotDataStats() *snapshotDataStats {
+	return &snapshotDataStats{checksum: crc32.NewIEEE()}
+}
+
+func (st *snapshotDataStats) add(key, value []byte) {
+	st.keys++
+	st.bytes += uint64(len(key) + len(value))
+	_, _ = st.checksum.Write(key)
+	_, _ = st.checksum.Write(value)
 }
 
-func writeBytes(f vfs.File, data []byte) error {
+func (st *snapshotDataStats) fill(manifest *metapb.SnapshotManifest) {
+	manifest.KeyCount = st.keys
+	manifest.ByteCount = st.bytes
+	manifest.DataChecksum = st.checksum.Sum32()
+}
+
+// check compares the stats with the manifest, the manifest converted from the
+// positional header has no stats.
+func (st *snapshotDataStats) check(manifest metapb.SnapshotManifest) error {
+	if manifest.FormatVersion == 0 {
+		return nil
+	}
+	if st.keys != manifest.KeyCount || st.bytes != manifest.ByteCount {
+		return errors.Wrapf(storage.ErrSnapshotCorrupted,
+			"%d keys with %d bytes, expect %d keys with %d bytes",
+			st.keys, st.bytes, manifest.KeyCount, manifest.ByteCount)
+	}
+	if st.checksum.Sum32() != manifest.DataChecksum {
+		return errors.Wrap(storage.ErrSnapshotCorrupted, "data checksum mismatch")
+	}
+	return nil
+}
+
+// writeSnapshotManifest writes the version and the manifest of the snapshot
+func writeSnapshotManifest(w io.Writer, manifest metapb.SnapshotManifest) error {
+	version := make([]byte, 8)
+	binary.BigEndian.PutUint32(version, snapshotMagic)
+	binary.BigEndian.PutUint32(version[4:], snapshotVersion)
+	if _, err := w.Write(version); err != nil {
+		return err
+	}
+	return writeBytes(w, protoc.MustMarshal(&manifest))
+}
+
+// writeSnapshotEnd writes the empty field at the end of the snapshot
+func writeSnapshotEnd(w io.Writer) error {
+	return writeBytes(w, nil)
+}
+
+// writeBytes writes a length prefixed field followed by the crc32 checksum of
+// the length prefix and the field.
+func writeBytes(w io.Writer, data []byte) error {
 	size := make([]byte, 4)
 	binary.BigEndian.PutUint32(size, uint32(len(data)))
-	if _, err := f.Write(size); err != nil {
+	if _, err := w.Write(size); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
 		return err
 	}
-	if _, err := f.Write(data); err != nil {
+	checksum := make([]byte, 4)
+	binary.BigEndian.PutUint32(checksum, fieldChecksum(size, data))
+	if _, err := w.Write(checksum); err != nil {
 		return err
 	}
 	return nil
 }
 
-func readBytes(f vfs.File) ([]byte, error) {
-	size := make([]byte, 4)
-	n, err := f.Read(size)
-	if n == 0 && err == io.EOF {
-		return nil, nil
+func fieldChecksum(size, data []byte) uint32 {
+	return crc32.Update(crc32.ChecksumIEEE(size), crc32.IEEETable, data)
+}
+
+// getSnapshotSSTChecksum returns the size and the crc32 checksum of the SST file
+func getSnapshotSSTChecksum(fs vfs.FS, file string) (uint64, uint32, error) {
+	f, err := fs.Open(file)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	h := crc32.NewIEEE()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, 0, err
 	}
+	return uint64(size), h.Sum32(), nil
+}
 
-	total := int(binary.BigEndian.Uint32(size))
-	written := 0
-	data := make([]byte, total)
-	for {
-		n, err = f.Read(data[written:])
-		if err != nil && err != io.EOF {
-			return nil, err
+// snapshotReader reads the fields of the snapshot file in the version of the
+// file, the fields are validated against the checksums if there are.
+type snapshotReader struct {
+	r       io.Reader
+	limit   int64
+	version uint32
+	end     bool
+	// size and checksum are the buffers of the length prefix and the checksum
+	// of the field
+	size     [4]byte
+	checksum [4]byte
+}
+
+// newSnapshotReader reads the version of the snapshot file, the legacy snapshot
+// file starts with the first field of the header instead of the magic number.
+func newSnapshotReader(r io.Reader, limit int64) (*snapshotReader, error) {
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, truncatedError(err, "truncated magic number")
+	}
+	if binary.BigEndian.Uint32(magic) != snapshotMagic {
+		return &snapshotReader{
+			r:       io.MultiReader(bytes.NewReader(magic), r),
+			limit:   limit,
+			version: snapshotVersionLegacy,
+		}, nil
+	}
+
+	version := make([]byte, 4)
+	if _, err := io.ReadFull(r, version); err != nil {
+		return nil, truncatedError(err, "truncated version")
+	}
+	v := binary.BigEndian.Uint32(version)
+	if v < snapshotVersionChecksum || v > snapshotVersion {
+		return nil, errors.Wrapf(storage.ErrSnapshotCorrupted,
+			"unsupported snapshot version %d", v)
+	}
+	return &snapshotReader{r: r, limit: limit, version: v}, nil
+}
+
+// readBytes reads a field written by writeBytes, nil is returned at the end of
+// the snapshot. The length prefix can't be trusted on the corrupted input, it
+// is validated against the limit, e.g. the size of the snapshot file, before
+// allocating the buffer.
+func (sr *snapshotReader) readBytes() ([]byte, error) {
+	return sr.readBytesInto(nil, sr.limit)
+}
+
+// readBytesInto is readBytes reading the field into the buf if it's large
+// enough, the field is rejected if it's larger than the limit.
+func (sr *snapshotReader) readBytesInto(buf []byte, limit int64) ([]byte, error) {
+	if sr.end {
+		return nil, nil
+	}
+	if _, err := io.ReadFull(sr.r, sr.size[:]); err != nil {
+		if err == io.EOF && sr.version == snapshotVersionLegacy {
+			sr.end = true
+			return nil, nil
 		}
-		written += n
-		if written == total {
-			return data, nil
+		return nil, truncatedError(err, "truncated length prefix")
+	}
+
+	if limit > sr.limit {
+		limit = sr.limit
+	}
+	total := int64(binary.BigEndian.Uint32(sr.size[:]))
+	if total > limit {
+		return nil, errors.Wrapf(storage.ErrSnapshotCorrupted,
+			"length prefix %d exceeds limit %d", total, limit)
+	}
+	var data []byte
+	if int64(cap(buf)) >= total {
+		data = buf[:total]
+	} else {
+		data = make([]byte, total)
+	}
+	if _, err := io.ReadFull(sr.r, data); err != nil {
+		return nil, truncatedError(err,
+			fmt.Sprintf("truncated field, expect %d bytes", total))
+	}
+	if sr.version == snapshotVersionLegacy {
+		return data, nil
+	}
+
+	if _, err := io.ReadFull(sr.r, sr.checksum[:]); err != nil {
+		return nil, truncatedError(err, "truncated field checksum")
+	}
+	if fieldChecksum(sr.size[:], data) != binary.BigEndian.Uint32(sr.checksum[:]) {
+		return nil, errors.Wrap(storage.ErrSnapshotCorrupted, "field checksum mismatch")
+	}
+	if total == 0 {
+		return nil, sr.readEnd()
+	}
+	return data, nil
+}
+
+// readEnd makes sure nothing is after the end of the snapshot
+func (sr *snapshotReader) readEnd() error {
+	sr.end = true
+	if _, err := io.ReadFull(sr.r, make([]byte, 1)); err == nil {
+		return errors.Wrap(storage.ErrSnapshotCorrupted, "unexpected data after the end")
+	} else if err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// checkSSTChecksum compares the checksum of the SST file with the one in the
+// manifest, or the one read after the positional header. The legacy snapshot
+// has no such checksum.
+func (sr *snapshotReader) checkSSTChecksum(fs vfs.FS, file string,
+	manifest metapb.SnapshotManifest) error {
+	if sr.version == snapshotVersionLegacy {
+		return nil
+	}
+	size, checksum, err := getSnapshotSSTChecksum(fs, file)
+	if err != nil {
+		return err
+	}
+	if sr.version >= snapshotVersionManifest {
+		if size != manifest.SstSize || checksum != manifest.SstChecksum {
+			return errors.Wrap(storage.ErrSnapshotCorrupted, "sst checksum mismatch")
 		}
+		return nil
+	}
+
+	expect, err := sr.readBytes()
+	if err != nil {
+		return err
+	}
+	if len(expect) == 0 {
+		return errors.Wrap(storage.ErrSnapshotCorrupted, "missing sst checksum")
+	}
+	if len(expect) != 12 ||
+		binary.BigEndian.Uint64(expect) != size ||
+		binary.BigEndian.Uint32(expect[8:]) != checksum {
+		return errors.Wrap(storage.ErrSnapshotCorrupted, "sst checksum mismatch")
+	}
+	return nil
+}
+
+func truncatedError(err error, msg string) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errors.Wrap(storage.ErrSnapshotCorrupted, msg)
 	}
+	return err
 }
@@ -0,0 +1,82 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	"github.com/matrixorigin/matrixcube/util"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteWithConditions(t *testing.T) {
+	base := NewBaseStorage(mem.NewStorage(), vfs.NewMemFS())
+	defer base.Close()
+	require.NoError(t, base.Set([]byte("k1"), []byte("v1"), false))
+
+	wb := base.NewWriteBatch().(util.WriteBatch)
+	wb.SetIfAbsent([]byte("k2"), []byte("v2"))
+	wb.CompareAndSet([]byte("k1"), []byte("v1"), []byte("v11"))
+	require.NoError(t, base.Write(wb, false))
+	assertTestValue(t, base, "k1", "v11")
+	assertTestValue(t, base, "k2", "v2")
+
+	// all or nothing
+	wb.Reset()
+	assert.Empty(t, wb.Conditions())
+	wb.Set([]byte("k3"), []byte("v3"))
+	wb.SetIfAbsent([]byte("k2"), []byte("v22"))
+	wb.CompareAndSet([]byte("k1"), []byte("v11"), []byte("v111"))
+	wb.CompareAndSet([]byte("k3"), []byte("v3"), []byte("v33"))
+	err := base.Write(wb, false)
+	assert.True(t, errors.Is(err, storage.ErrConditionFailed))
+	var condErr *storage.ConditionFailedError
+	require.True(t, errors.As(err, &condErr))
+	// the conditions are evaluated before the batch
+	assert.Equal(t, []int{0, 2}, condErr.Failed)
+	assertTestValue(t, base, "k1", "v11")
+	assertTestValue(t, base, "k2", "v2")
+	assertTestValue(t, base, "k3", "")
+}
+
+func TestWriteWithConditionsOnExpiredKey(t *testing.T) {
+	base := NewBaseStorage(mem.NewStorage(), vfs.NewMemFS()).(*BaseStorage)
+	defer base.Close()
+
+	now := time.Now()
+	base.ttl.now = func() time.Time { return now }
+	setTestTTL(t, base, []byte("k1"), []byte("v1"), now.Add(time.Second))
+
+	wb := base.NewWriteBatch().(util.WriteBatch)
+	wb.SetIfAbsent([]byte("k1"), []byte("v2"))
+	assert.Error(t, base.Write(wb, false))
+
+	base.ttl.now = func() time.Time { return now.Add(time.Minute) }
+	// the expired key is checked until purged if the TTL is ignored
+	assert.Error(t, base.WithoutTTL().Write(wb, false))
+	require.NoError(t, base.Write(wb, false))
+	assertTestValue(t, base, "k1", "v2")
+}
+
+func assertTestValue(t *testing.T, s storage.KVStorage, key, expected string) {
+	value, err := s.Get([]byte(key))
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(value))
+}
@@ -0,0 +1,165 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"github.com/cockroachdb/errors"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util/buf"
+)
+
+const (
+	// defaultSnapshotMaxRecordSize the default max size of the key and the value
+	// of a record in the snapshot
+	defaultSnapshotMaxRecordSize = maxSnapshotStreamFieldSize
+	// snapshotProgressInterval the progress is reported every time so many bytes
+	// of the records are processed
+	snapshotProgressInterval = 64 * buf.MB
+)
+
+// SnapshotProgress the progress of applying the key-value records of a
+// snapshot, Bytes is the size of the keys and the values.
+type SnapshotProgress struct {
+	ShardID uint64
+	Records uint64
+	Bytes   uint64
+	// Done the last progress of the snapshot, reported after all the records
+	// are read and verified.
+	Done bool
+}
+
+// SetSnapshotMaxRecordSize set the max size of the key and the value of a
+// record read from the snapshots, the snapshot with a larger record is treated
+// as corrupted. 0 means the default 256MB. It's not safe to call it while
+// applying the snapshots.
+func (s *BaseStorage) SetSnapshotMaxRecordSize(size int64) {
+	if size <= 0 {
+		size = defaultSnapshotMaxRecordSize
+	}
+	s.snapshotMaxRecordSize = size
+}
+
+// SetSnapshotProgressHandler set the handler called with the progress of
+// applying the snapshots which are not SST based, it's called every 64MB of the
+// records and once all records are applied. It's not safe to call it while
+// applying the snapshots.
+func (s *BaseStorage) SetSnapshotProgressHandler(handler func(SnapshotProgress)) {
+	s.snapshotProgress = handler
+}
+
+// snapshotRecordIterator iterates the key-value records after the snapshot
+// manifest. The records are read into the buffers reused by all records, so a
+// snapshot of many large values needs no more memory than its largest record,
+// the key and the value are only valid until the next call of next.
+type snapshotRecordIterator struct {
+	sr            *snapshotReader
+	manifest      metapb.SnapshotManifest
+	maxRecordSize int64
+	stats         *snapshotDataStats
+	key           []byte
+	value         []byte
+	err           error
+	done          bool
+
+	progress   SnapshotProgress
+	reported   uint64
+	onProgress func(SnapshotProgress)
+}
+
+func newSnapshotRecordIterator(shardID uint64, sr *snapshotReader,
+	manifest metapb.SnapshotManifest, maxRecordSize int64) *snapshotRecordIterator {
+	return &snapshotRecordIterator{
+		sr:            sr,
+		manifest:      manifest,
+		maxRecordSize: maxRecordSize,
+		stats:         newSnapshotDataStats(),
+		progress:      SnapshotProgress{ShardID: shardID},
+	}
+}
+
+// newSnapshotRecordIterator returns the iterator with the max record size and
+// the progress handler of the storage.
+func (s *BaseStorage) newSnapshotRecordIterator(shardID uint64, sr *snapshotReader,
+	manifest metapb.SnapshotManifest) *snapshotRecordIterator {
+	it := newSnapshotRecordIterator(shardID, sr, manifest, s.snapshotMaxRecordSize)
+	it.onProgress = s.snapshotProgress
+	return it
+}
+
+// next reads the next record, false is returned at the end of the snapshot or
+// on error. All keys must be in the range of the manifest, and the stats must
+// match the manifest at the end.
+func (it *snapshotRecordIterator) next() bool {
+	if it.done {
+		return false
+	}
+	if it.err = it.readRecord(); it.err != nil || it.done {
+		it.done = true
+		return false
+	}
+	it.stats.add(it.key, it.value)
+	it.progress.Records++
+	it.progress.Bytes += uint64(len(it.key) + len(it.value))
+	if it.progress.Bytes-it.reported >= snapshotProgressInterval {
+		it.report()
+	}
+	return true
+}
+
+func (it *snapshotRecordIterator) readRecord() error {
+	key, err := it.sr.readBytesInto(it.key, it.maxRecordSize)
+	if err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		it.done = true
+		if err := it.stats.check(it.manifest); err != nil {
+			return err
+		}
+		it.progress.Done = true
+		it.report()
+		return nil
+	}
+	it.key = key
+	if !inSnapshotRange(key, it.manifest) {
+		return errors.Wrapf(storage.ErrSnapshotCorrupted, "key %+v out of range", key)
+	}
+	value, err := it.sr.readBytesInto(it.value, it.maxRecordSize-int64(len(key)))
+	if err != nil {
+		return err
+	}
+	if len(value) == 0 {
+		return errors.Wrapf(storage.ErrSnapshotCorrupted, "key %+v specified without value", key)
+	}
+	it.value = value
+	return nil
+}
+
+func (it *snapshotRecordIterator) report() {
+	it.reported = it.progress.Bytes
+	if it.onProgress != nil {
+		it.onProgress(it.progress)
+	}
+}
+
+// readSnapshotData reads the key-value pairs after the snapshot manifest until
+// the end of the snapshot, the key and the value passed to the fn are reused by
+// the next pair.
+func readSnapshotData(it *snapshotRecordIterator, fn func(key, value []byte)) error {
+	for it.next() {
+		fn(it.key, it.value)
+	}
+	return it.err
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotRecordIteratorReusesBuffers(t *testing.T) {
+	fs := vfs.NewMemFS()
+	shardID := uint64(100)
+	base := newTestSnapshotDataStorage(t, fs, shardID, 100)
+	defer base.Close()
+	require.NoError(t, base.CreateSnapshot(shardID, "snap"))
+
+	snap, err := base.openSnapshot(shardID, "snap")
+	require.NoError(t, err)
+	defer snap.close()
+	it := newSnapshotRecordIterator(shardID, snap.sr, snap.manifest, defaultSnapshotMaxRecordSize)
+	var key, value *byte
+	for it.next() {
+		if key == nil {
+			key, value = &it.key[0], &it.value[0]
+		}
+		assert.True(t, key == &it.key[0])
+		assert.True(t, value == &it.value[0])
+	}
+	require.NoError(t, it.err)
+	assert.Equal(t, uint64(100), it.progress.Records)
+	assert.False(t, it.next())
+}
+
+func TestSnapshotRecordIteratorMaxRecordSize(t *testing.T) {
+	fs := vfs.NewMemFS()
+	shardID := uint64(100)
+	base := newTestSnapshotDataStorage(t, fs, shardID, 10)
+	defer base.Close()
+	require.NoError(t, base.CreateSnapshot(shardID, "snap"))
+
+	applied := NewBaseStorage(noSSTStorage{mem.NewStorage()}, fs).(*BaseStorage)
+	defer applied.Close()
+	recordSize := int64(len(keysutil.EncodeDataKey([]byte("k00000000"), nil)) + 100)
+	applied.SetSnapshotMaxRecordSize(recordSize - 1)
+	err := applied.ApplySnapshot(shardID, "snap")
+	assert.True(t, errors.Is(err, storage.ErrSnapshotCorrupted), "%+v", err)
+
+	var progress []SnapshotProgress
+	applied.SetSnapshotProgressHandler(func(p SnapshotProgress) {
+		progress = append(progress, p)
+	})
+	applied.SetSnapshotMaxRecordSize(recordSize)
+	require.NoError(t, applied.ApplySnapshot(shardID, "snap"))
+	assert.Equal(t, []SnapshotProgress{
+		{ShardID: shardID, Records: 10, Bytes: uint64(10 * recordSize), Done: true},
+	}, progress)
+}
@@ -0,0 +1,65 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/fagongzi/util/protoc"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+)
+
+var _ storage.KeySampler = (*BaseStorage)(nil)
+
+// SampleKeys returns at most n keys uniformly sampled from the shard by the
+// reservoir sampling, the keys are read from a view in one pass, only the
+// sampled keys are kept in memory.
+func (s *BaseStorage) SampleKeys(shardID uint64, n int) ([][]byte, error) {
+	return s.sampleKeys(shardID, n, rand.New(rand.NewSource(time.Now().UnixNano())))
+}
+
+func (s *BaseStorage) sampleKeys(shardID uint64, n int, rnd *rand.Rand) ([][]byte, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	view := s.kv.GetView()
+	defer view.Close()
+	_, value, err := s.getShardMetadata(view, shardID)
+	if err != nil {
+		return nil, err
+	}
+	var sm metapb.ShardMetadata
+	protoc.MustUnmarshal(&sm, value)
+	start, end := shardDataRange(sm.Metadata.Shard)
+
+	var samples [][]byte
+	seen := 0
+	if err := s.ScanInView(view, start, end, func(key, _ []byte) (bool, error) {
+		seen++
+		if len(samples) < n {
+			samples = append(samples, keysutil.Clone(keysutil.DecodeDataKey(key)))
+		} else if i := rnd.Intn(seen); i < n {
+			samples[i] = keysutil.Clone(keysutil.DecodeDataKey(key))
+		}
+		return true, nil
+	}, false); err != nil {
+		return nil, err
+	}
+	keysutil.Sort(samples)
+	return samples, nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestSampleStorage(t *testing.T, shardID uint64, count int) (*BaseStorage, storage.DataStorage) {
+	kv := mem.NewStorage()
+	base := NewBaseStorage(kv, vfs.NewMemFS())
+	ds := NewKVDataStorage(base, executor.NewKVExecutor(kv))
+	require.NoError(t, base.Set(keysutil.EncodeDataKey([]byte("a"), nil), []byte("v"), false))
+	for i := 0; i < count; i++ {
+		key := keysutil.EncodeDataKey([]byte(fmt.Sprintf("b%03d", i)), nil)
+		require.NoError(t, base.Set(key, []byte("value"), false))
+	}
+	require.NoError(t, base.Set(keysutil.EncodeDataKey([]byte("c"), nil), []byte("v"), false))
+	require.NoError(t, ds.SaveShardMetadata([]metapb.ShardMetadata{{
+		ShardID:  shardID,
+		LogIndex: 1,
+		Metadata: metapb.ShardLocalState{
+			Shard: metapb.Shard{ID: shardID, Start: []byte("b"), End: []byte("c")},
+		},
+	}}))
+	return base.(*BaseStorage), ds
+}
+
+func TestSampleKeys(t *testing.T) {
+	base, ds := createTestSampleStorage(t, 1, 100)
+	defer ds.Close()
+
+	keys, err := ds.(storage.KeySampler).SampleKeys(1, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+	_, err = ds.(storage.KeySampler).SampleKeys(2, 10)
+	assert.ErrorIs(t, err, ErrNoMetadata)
+
+	// all the keys are returned
+	keys, err = ds.(storage.KeySampler).SampleKeys(1, 200)
+	require.NoError(t, err)
+	require.Equal(t, 100, len(keys))
+	for i, key := range keys {
+		assert.Equal(t, fmt.Sprintf("b%03d", i), string(key))
+	}
+
+	keys, err = base.SampleKeys(1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 10, len(keys))
+	assert.True(t, sort.SliceIsSorted(keys, func(i, j int) bool {
+		return string(keys[i]) < string(keys[j])
+	}))
+	for i, key := range keys {
+		assert.True(t, string(key) >= "b" && string(key) < "c")
+		if i > 0 {
+			assert.NotEqual(t, keys[i-1], key)
+		}
+	}
+}
+
+func TestSampleKeysUniformly(t *testing.T) {
+	base, ds := createTestSampleStorage(t, 1, 100)
+	defer ds.Close()
+
+	rnd := rand.New(rand.NewSource(1))
+	counts := make(map[string]int)
+	trials := 2000
+	for i := 0; i < trials; i++ {
+		keys, err := base.sampleKeys(1, 10, rnd)
+		require.NoError(t, err)
+		require.Equal(t, 10, len(keys))
+		for _, key := range keys {
+			counts[string(key)]++
+		}
+	}
+	// each key is sampled 200 times expected
+	assert.Equal(t, 100, len(counts))
+	for key, n := range counts {
+		assert.True(t, n > 100 && n < 300, "key %s sampled %d times", key, n)
+	}
+}
+
+func TestSampleKeysWithExpiredKeys(t *testing.T) {
+	base, ds := createTestSampleStorage(t, 1, 10)
+	defer ds.Close()
+
+	now := time.Now()
+	base.ttl.now = func() time.Time { return now }
+	setTestTTL(t, base, keysutil.EncodeDataKey([]byte("b100"), nil),
+		[]byte("v"), now.Add(time.Second))
+	base.ttl.now = func() time.Time { return now.Add(time.Minute) }
+
+	keys, err := base.SampleKeys(1, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 10, len(keys))
+}
@@ -0,0 +1,288 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/cockroachdb/errors"
+	"github.com/golang/snappy"
+	"github.com/juju/ratelimit"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util"
+	"github.com/matrixorigin/matrixcube/util/buf"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+)
+
+const (
+	// maxSnapshotStreamFieldSize the max size of a field in the snapshot stream,
+	// the length prefix is validated against it since the size of the stream is
+	// unknown.
+	maxSnapshotStreamFieldSize = 256 * buf.MB
+)
+
+// CreateSnapshotTo writes the snapshot of the shard into the writer. The stream
+// starts with the compression, followed by the compressed snapshot in the same
+// format of the snapshot file without the SST file.
+func (s *BaseStorage) CreateSnapshotTo(shardID uint64, w io.Writer,
+	opts storage.SnapshotStreamOptions) error {
+	_, err := s.BackupShard(shardID, w, opts)
+	return err
+}
+
+// BackupShard is the same as CreateSnapshotTo, but returns the manifest of the
+// written snapshot.
+func (s *BaseStorage) BackupShard(shardID uint64, w io.Writer,
+	opts storage.SnapshotStreamOptions) (metapb.SnapshotManifest, error) {
+	w = limitWriter(w, opts.BytesPerSecond)
+	if _, err := w.Write([]byte{byte(opts.Compression)}); err != nil {
+		return metapb.SnapshotManifest{}, err
+	}
+	cw, err := newCompressWriter(w, opts.Compression)
+	if err != nil {
+		return metapb.SnapshotManifest{}, err
+	}
+
+	view := s.kv.GetView()
+	defer view.Close()
+	manifest, err := s.getSnapshotManifest(view, shardID)
+	if err != nil {
+		_ = cw.Close()
+		return manifest, err
+	}
+	if err := s.fillSnapshotDataStats(view, &manifest); err != nil {
+		_ = cw.Close()
+		return manifest, err
+	}
+	bw := bufio.NewWriterSize(cw, s.snapshotWriteBufferSize)
+	if err := writeSnapshotManifest(bw, manifest); err != nil {
+		_ = cw.Close()
+		return manifest, err
+	}
+	if err := s.writeSnapshotData(bw, view, manifest); err != nil {
+		_ = cw.Close()
+		return manifest, err
+	}
+	// flushes the buffered fields into the compressor, and then the compressed
+	// data
+	if err := bw.Flush(); err != nil {
+		_ = cw.Close()
+		return manifest, err
+	}
+	return manifest, cw.Close()
+}
+
+// ApplySnapshotFrom applies the snapshot stream written by CreateSnapshotTo,
+// the key-value pairs are written in a batch, so the snapshot is applied
+// atomically.
+func (s *BaseStorage) ApplySnapshotFrom(shardID uint64, r io.Reader,
+	opts storage.SnapshotStreamOptions) error {
+	return s.applySnapshotStream(shardID, r, opts, func(batch util.WriteBatch,
+		manifest metapb.SnapshotManifest) error {
+		batch.DeleteRange(manifest.Start, manifest.End)
+		s.removeSnapshotTTL(batch, manifest)
+		batch.Set(manifest.AppliedIndexKey, manifest.AppliedIndexValue)
+		batch.Set(manifest.MetadataKey, manifest.MetadataValue)
+		return nil
+	})
+}
+
+// RestoreShardData writes the key-value pairs of the snapshot stream of the
+// backupShardID into the range of the shard, the applied index and the metadata
+// in the stream are dropped.
+func (s *BaseStorage) RestoreShardData(backupShardID uint64, shard metapb.Shard,
+	r io.Reader, opts storage.SnapshotStreamOptions) error {
+	start := keysutil.EncodeShardStart(shard.Start, nil)
+	end := keysutil.EncodeShardEnd(shard.End, nil)
+	return s.applySnapshotStream(backupShardID, r, opts, func(batch util.WriteBatch,
+		manifest metapb.SnapshotManifest) error {
+		if !bytes.Equal(manifest.Start, start) || !bytes.Equal(manifest.End, end) {
+			return errors.Newf("range of the backup shard %d not match the shard %d",
+				backupShardID, shard.ID)
+		}
+		batch.DeleteRange(manifest.Start, manifest.End)
+		s.removeSnapshotTTL(batch, manifest)
+		return nil
+	})
+}
+
+// ReadShardData reads the key-value pairs of the snapshot stream of the
+// backupShardID written by BackupShard, the keys passed to the fn are the
+// origin keys without the data prefix. The key and the value are reused by the
+// next pair, and the reading stops at the first error returned by the fn. The
+// expiry metadata in the stream is skipped.
+func (s *BaseStorage) ReadShardData(backupShardID uint64, r io.Reader,
+	opts storage.SnapshotStreamOptions, fn func(key, value []byte) error) error {
+	return s.readSnapshotStream(backupShardID, r, opts, func(manifest metapb.SnapshotManifest,
+		it *snapshotRecordIterator) error {
+		for it.next() {
+			if bytes.Compare(it.key, manifest.End) >= 0 {
+				continue
+			}
+			if err := fn(keysutil.DecodeDataKey(it.key), it.value); err != nil {
+				return err
+			}
+		}
+		return it.err
+	})
+}
+
+// applySnapshotStream reads the manifest of the snapshot stream, the batch is
+// initialized by the init func and then the key-value pairs are written in it.
+func (s *BaseStorage) applySnapshotStream(shardID uint64, r io.Reader,
+	opts storage.SnapshotStreamOptions,
+	init func(util.WriteBatch, metapb.SnapshotManifest) error) error {
+	return s.readSnapshotStream(shardID, r, opts, func(manifest metapb.SnapshotManifest,
+		it *snapshotRecordIterator) error {
+		batch := s.kv.NewWriteBatch().(util.WriteBatch)
+		defer batch.Close()
+		if err := init(batch, manifest); err != nil {
+			return err
+		}
+		if err := readSnapshotData(it, func(key, value []byte) {
+			batch.Set(key, value)
+		}); err != nil {
+			return err
+		}
+		if err := s.kv.Write(batch, true); err != nil {
+			return err
+		}
+		return s.kv.Sync()
+	})
+}
+
+// readSnapshotStream reads the manifest of the snapshot stream, and then the
+// key-value pairs are read by the fn with the iterator.
+func (s *BaseStorage) readSnapshotStream(shardID uint64, r io.Reader,
+	opts storage.SnapshotStreamOptions,
+	fn func(metapb.SnapshotManifest, *snapshotRecordIterator) error) error {
+	r = limitReader(r, opts.BytesPerSecond)
+	compression := make([]byte, 1)
+	if _, err := io.ReadFull(r, compression); err != nil {
+		return truncatedError(err, "truncated compression")
+	}
+	cr, err := newDecompressReader(r, storage.SnapshotCompression(compression[0]))
+	if err != nil {
+		return err
+	}
+	defer cr.Close()
+
+	sr, err := newSnapshotReader(cr, maxSnapshotStreamFieldSize)
+	if err != nil {
+		return err
+	}
+	if sr.version == snapshotVersionLegacy {
+		return errors.Wrap(storage.ErrSnapshotCorrupted, "missing snapshot version")
+	}
+	manifest, err := readSnapshotManifest(sr, shardID)
+	if err != nil {
+		return err
+	}
+	if manifest.Features&snapshotFeatureSST != 0 {
+		return errors.Wrap(storage.ErrSnapshotCorrupted, "unexpected sst in the stream")
+	}
+	return fn(manifest, s.newSnapshotRecordIterator(shardID, sr, manifest))
+}
+
+func limitWriter(w io.Writer, bytesPerSecond int64) io.Writer {
+	if bytesPerSecond <= 0 {
+		return w
+	}
+	return ratelimit.Writer(w, ratelimit.NewBucketWithRate(float64(bytesPerSecond), bytesPerSecond))
+}
+
+func limitReader(r io.Reader, bytesPerSecond int64) io.Reader {
+	if bytesPerSecond <= 0 {
+		return r
+	}
+	return ratelimit.Reader(r, ratelimit.NewBucketWithRate(float64(bytesPerSecond), bytesPerSecond))
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func newCompressWriter(w io.Writer, compression storage.SnapshotCompression) (io.WriteCloser, error) {
+	switch compression {
+	case storage.NoCompression:
+		return nopWriteCloser{w}, nil
+	case storage.SnappyCompression:
+		return snappy.NewBufferedWriter(w), nil
+	case storage.ZstdCompression:
+		return newZstdWriter(w)
+	}
+	return nil, errors.Newf("unknown snapshot compression %d", compression)
+}
+
+// decompressReader marks the errors of the decompressor as
+// storage.ErrSnapshotCorrupted, unless the error is caused by the underlying
+// reader.
+type decompressReader struct {
+	src  *sourceReader
+	r    io.Reader
+	done func() error
+}
+
+func newDecompressReaderWith(src *sourceReader, r io.Reader, done func() error) *decompressReader {
+	return &decompressReader{src: src, r: r, done: done}
+}
+
+func (r *decompressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		if r.src.err != nil && r.src.err != io.EOF {
+			return n, r.src.err
+		}
+		err = errors.Wrapf(storage.ErrSnapshotCorrupted, "fail to decompress, %v", err)
+	}
+	return n, err
+}
+
+func (r *decompressReader) Close() error {
+	return r.done()
+}
+
+// sourceReader records the last error of the underlying reader
+type sourceReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *sourceReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.err = err
+	return n, err
+}
+
+func newDecompressReader(r io.Reader, compression storage.SnapshotCompression) (io.ReadCloser, error) {
+	switch compression {
+	case storage.NoCompression:
+		return io.NopCloser(r), nil
+	case storage.SnappyCompression:
+		src := &sourceReader{r: r}
+		return newDecompressReaderWith(src, snappy.NewReader(src),
+			func() error { return nil }), nil
+	case storage.ZstdCompression:
+		return newZstdReader(r)
+	}
+	return nil, errors.Wrapf(storage.ErrSnapshotCorrupted,
+		"unknown snapshot compression %d", compression)
+}
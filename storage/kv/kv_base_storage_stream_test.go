@@ -0,0 +1,213 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/matrixorigin/matrixcube/keys"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestSnapshotStream(t *testing.T, shardID uint64, opts storage.SnapshotStreamOptions) []byte {
+	kv := mem.NewStorage()
+	base := NewBaseStorage(kv, vfs.NewMemFS())
+	ds := NewKVDataStorage(base, executor.NewKVExecutor(kv))
+	defer ds.Close()
+	for i := 0; i < 100; i++ {
+		key := keysutil.EncodeDataKey([]byte(fmt.Sprintf("b%03d", i)), nil)
+		require.NoError(t, base.Set(key, []byte("value"), false))
+	}
+	require.NoError(t, base.Set(keysutil.EncodeDataKey([]byte("yy"), nil), []byte("v"), false))
+	sm := metapb.ShardMetadata{
+		ShardID:  shardID,
+		LogIndex: 110,
+		Metadata: metapb.ShardLocalState{
+			Shard: metapb.Shard{ID: shardID, Start: []byte("aa"), End: []byte("xx")},
+		},
+	}
+	require.NoError(t, ds.SaveShardMetadata([]metapb.ShardMetadata{sm}))
+
+	var buf bytes.Buffer
+	require.NoError(t, base.CreateSnapshotTo(shardID, &buf, opts))
+	return buf.Bytes()
+}
+
+func applyTestSnapshotStream(data []byte, shardID uint64, opts storage.SnapshotStreamOptions) (storage.KVBaseStorage, error) {
+	kv := mem.NewStorage()
+	base := NewBaseStorage(kv, vfs.NewMemFS())
+	if err := base.Set(keysutil.EncodeDataKey([]byte("cc"), nil), []byte("v"), false); err != nil {
+		return nil, err
+	}
+	return base, base.ApplySnapshotFrom(shardID, bytes.NewReader(data), opts)
+}
+
+func TestCreateAndApplySnapshotStream(t *testing.T) {
+	shardID := uint64(100)
+	tests := []storage.SnapshotStreamOptions{
+		{Compression: storage.NoCompression},
+		{Compression: storage.SnappyCompression},
+		{Compression: storage.ZstdCompression},
+		{Compression: storage.SnappyCompression, BytesPerSecond: 1024 * 1024},
+	}
+	for i, opts := range tests {
+		data := createTestSnapshotStream(t, shardID, opts)
+		if i > 0 {
+			assert.Equal(t, byte(opts.Compression), data[0], "index %d", i)
+		}
+		base, err := applyTestSnapshotStream(data, shardID, opts)
+		require.NoError(t, err, "index %d", i)
+
+		v, err := base.Get(keysutil.EncodeDataKey([]byte("cc"), nil))
+		assert.NoError(t, err)
+		assert.Empty(t, v, "index %d", i)
+		v, err = base.Get(keysutil.EncodeDataKey([]byte("b050"), nil))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("value"), v, "index %d", i)
+		v, err = base.Get(keysutil.EncodeDataKey([]byte("yy"), nil))
+		assert.NoError(t, err)
+		assert.Empty(t, v, "index %d", i)
+		assert.NoError(t, base.Close())
+	}
+}
+
+func TestRestoreShardData(t *testing.T) {
+	shardID := uint64(100)
+	opts := storage.SnapshotStreamOptions{Compression: storage.SnappyCompression}
+	data := createTestSnapshotStream(t, shardID, opts)
+
+	kv := mem.NewStorage()
+	base := NewBaseStorage(kv, vfs.NewMemFS())
+	defer base.Close()
+	require.NoError(t, base.Set(keysutil.EncodeDataKey([]byte("cc"), nil), []byte("v"), false))
+
+	r := base.(*BaseStorage)
+	shard := metapb.Shard{ID: 1, Start: []byte("aa"), End: []byte("xx")}
+	require.NoError(t, r.RestoreShardData(shardID, shard, bytes.NewReader(data), opts))
+	v, err := base.Get(keysutil.EncodeDataKey([]byte("cc"), nil))
+	assert.NoError(t, err)
+	assert.Empty(t, v)
+	v, err = base.Get(keysutil.EncodeDataKey([]byte("b050"), nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), v)
+	// the applied index and the metadata are dropped
+	v, err = base.Get(keysutil.EncodeShardMetadataKey(keys.GetAppliedIndexKey(shardID, nil), nil))
+	assert.NoError(t, err)
+	assert.Empty(t, v)
+
+	shard.End = []byte("yy")
+	assert.Error(t, r.RestoreShardData(shardID, shard, bytes.NewReader(data), opts))
+	err = r.RestoreShardData(shardID+1, shard, bytes.NewReader(data), opts)
+	assert.True(t, errors.Is(err, storage.ErrSnapshotCorrupted))
+}
+
+func TestReadShardData(t *testing.T) {
+	shardID := uint64(100)
+	opts := storage.SnapshotStreamOptions{Compression: storage.SnappyCompression}
+	data := createTestSnapshotStream(t, shardID, opts)
+
+	base := NewBaseStorage(mem.NewStorage(), vfs.NewMemFS())
+	defer base.Close()
+	r := base.(*BaseStorage)
+	var keys []string
+	require.NoError(t, r.ReadShardData(shardID, bytes.NewReader(data), opts, func(key, value []byte) error {
+		keys = append(keys, string(key))
+		assert.Equal(t, []byte("value"), value)
+		return nil
+	}))
+	require.Equal(t, 100, len(keys))
+	assert.Equal(t, "b000", keys[0])
+	assert.Equal(t, "b099", keys[99])
+
+	stop := errors.New("stop")
+	n := 0
+	err := r.ReadShardData(shardID, bytes.NewReader(data), opts, func(key, value []byte) error {
+		n++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, n)
+	err = r.ReadShardData(shardID+1, bytes.NewReader(data), opts, func(key, value []byte) error {
+		return nil
+	})
+	assert.True(t, errors.Is(err, storage.ErrSnapshotCorrupted))
+}
+
+func TestApplyCorruptedSnapshotStream(t *testing.T) {
+	shardID := uint64(100)
+	for _, compression := range []storage.SnapshotCompression{
+		storage.NoCompression,
+		storage.SnappyCompression,
+		storage.ZstdCompression,
+	} {
+		opts := storage.SnapshotStreamOptions{Compression: compression}
+		data := createTestSnapshotStream(t, shardID, opts)
+		check := func(data []byte, shardID uint64, msg string, args ...interface{}) {
+			base, err := applyTestSnapshotStream(data, shardID, opts)
+			if err == nil && compression != storage.NoCompression {
+				// some bits of the zstd frame header or the snappy copy offsets
+				// in the repeated data do not change the content, the content
+				// is still protected by the checksums of the fields
+				v, err := base.Get(keysutil.EncodeDataKey([]byte("b050"), nil))
+				assert.NoError(t, err)
+				assert.Equal(t, []byte("value"), v)
+			} else {
+				assert.True(t, errors.Is(err, storage.ErrSnapshotCorrupted),
+					"compression %d, %s, %v", compression, fmt.Sprintf(msg, args...), err)
+			}
+			if base != nil {
+				assert.NoError(t, base.Close())
+			}
+		}
+
+		check(data, shardID+1, "another shard")
+		for i := 0; i < len(data); i++ {
+			check(data[:i], shardID, "truncated at %d", i)
+		}
+		for i := 0; i < len(data); i++ {
+			corrupted := append([]byte(nil), data...)
+			corrupted[i] ^= 0x10
+			check(corrupted, shardID, "flipped at %d", i)
+		}
+	}
+
+	// unknown compression
+	_, err := applyTestSnapshotStream([]byte{0xff}, shardID, storage.SnapshotStreamOptions{})
+	assert.True(t, errors.Is(err, storage.ErrSnapshotCorrupted))
+}
+
+func TestLimitSnapshotStream(t *testing.T) {
+	data := make([]byte, 200)
+	w := limitWriter(ioutil.Discard, 100)
+	start := time.Now()
+	_, err := w.Write(data)
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 900*time.Millisecond)
+
+	assert.Equal(t, ioutil.Discard, limitWriter(ioutil.Discard, 0))
+	r := bytes.NewReader(data)
+	assert.Equal(t, r, limitReader(r, 0))
+}
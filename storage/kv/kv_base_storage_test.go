@@ -14,10 +14,15 @@
 package kv
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"math"
 	"testing"
 
-	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble/sstable"
 	"github.com/fagongzi/util/protoc"
 	"github.com/matrixorigin/matrixcube/keys"
 	"github.com/matrixorigin/matrixcube/pb/metapb"
@@ -59,16 +64,79 @@ func TestReadWriteBytes(t *testing.T) {
 				require.NoError(t, fs.RemoveAll(fn))
 			}()
 			defer f.Close()
-			result, err := readBytes(f)
+			sr := &snapshotReader{r: f, limit: 1024, version: snapshotVersion}
+			result, err := sr.readBytes()
 			assert.NoError(t, err)
-			if tt.data == nil && len(result) == 0 {
-				result = nil
+			// the empty field is the end of the snapshot
+			if len(tt.data) == 0 && result == nil {
+				result = tt.data
 			}
 			assert.Equal(t, tt.data, result)
 		}()
 	}
 }
 
+func TestReadBytesWithCorruptedInput(t *testing.T) {
+	var buf bytes.Buffer
+	size := make([]byte, 4)
+	binary.BigEndian.PutUint32(size, math.MaxUint32)
+	buf.Write(size)
+	buf.Write([]byte("data"))
+	var field bytes.Buffer
+	require.NoError(t, writeBytes(&field, []byte("data")))
+	flipped := append([]byte(nil), field.Bytes()...)
+	flipped[5] ^= 1
+	tests := []struct {
+		data    []byte
+		limit   int64
+		version uint32
+	}{
+		{[]byte{0, 0}, 1024, snapshotVersionLegacy},             // truncated length prefix
+		{[]byte{0, 0, 0, 8, 1, 2}, 1024, snapshotVersionLegacy}, // truncated data
+		{[]byte{0, 0, 0, 8, 1, 2}, 6, snapshotVersionLegacy},    // length prefix exceeds limit
+		{buf.Bytes(), 1024, snapshotVersionLegacy},
+		{nil, 1024, snapshotVersion},                                     // missing end
+		{field.Bytes()[:9], 1024, snapshotVersion},                       // truncated checksum
+		{flipped, 1024, snapshotVersion},                                 // checksum mismatch
+		{append(field.Bytes()[:4:4], 0, 0, 0, 0), 1024, snapshotVersion}, // checksum of another field
+	}
+
+	for i, tt := range tests {
+		sr := &snapshotReader{r: bytes.NewReader(tt.data), limit: tt.limit, version: tt.version}
+		_, err := sr.readBytes()
+		assert.True(t, errors.Is(err, storage.ErrSnapshotCorrupted), "index %d", i)
+	}
+
+	sr := &snapshotReader{r: bytes.NewReader(nil), limit: 1024, version: snapshotVersionLegacy}
+	v, err := sr.readBytes()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+}
+
+func TestReadSnapshotVersion(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeSnapshotManifest(&buf, metapb.SnapshotManifest{}))
+	sr, err := newSnapshotReader(bytes.NewReader(buf.Bytes()), 1024)
+	require.NoError(t, err)
+	assert.Equal(t, snapshotVersion, sr.version)
+
+	// the legacy snapshot starts with the first field
+	legacy := []byte{0, 0, 0, 4, 1, 2, 3, 4}
+	sr, err = newSnapshotReader(bytes.NewReader(legacy), 1024)
+	require.NoError(t, err)
+	assert.Equal(t, snapshotVersionLegacy, sr.version)
+	v, err := sr.readBytes()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3, 4}, v)
+
+	unsupported := append([]byte(nil), buf.Bytes()...)
+	binary.BigEndian.PutUint32(unsupported[4:], snapshotVersion+1)
+	for _, data := range [][]byte{nil, buf.Bytes()[:2], buf.Bytes()[:6], unsupported} {
+		_, err := newSnapshotReader(bytes.NewReader(data), 1024)
+		assert.True(t, errors.Is(err, storage.ErrSnapshotCorrupted), "%+v", data)
+	}
+}
+
 func TestGetAppliedIndexReturnsErrorOnEmptyDB(t *testing.T) {
 	fs := vfs.GetTestFS()
 	defer vfs.ReportLeakedFD(fs, t)
@@ -77,10 +145,10 @@ func TestGetAppliedIndexReturnsErrorOnEmptyDB(t *testing.T) {
 	defer base.Close()
 	view := base.GetView()
 	defer view.Close()
-	key, val, err := base.(*BaseStorage).getAppliedIndex(view.Raw().(*pebble.Snapshot), 100)
+	key, val, err := base.(*BaseStorage).getAppliedIndex(view, 100)
 	assert.Empty(t, key)
 	assert.Empty(t, val)
-	assert.Equal(t, pebble.ErrNotFound, err)
+	assert.Equal(t, ErrNoAppliedIndex, err)
 }
 
 func TestGetAppliedIndex(t *testing.T) {
@@ -94,7 +162,7 @@ func TestGetAppliedIndex(t *testing.T) {
 	assert.NoError(t, ds.Write(ctx))
 	view := base.GetView()
 	defer view.Close()
-	key, val, err := base.(*BaseStorage).getAppliedIndex(view.Raw().(*pebble.Snapshot), 100)
+	key, val, err := base.(*BaseStorage).getAppliedIndex(view, 100)
 	assert.NoError(t, err)
 	var logIndex metapb.LogIndex
 	protoc.MustUnmarshal(&logIndex, val)
@@ -110,7 +178,7 @@ func TestGetShardMetadataReturnsErrorOnEmptyDB(t *testing.T) {
 	defer base.Close()
 	view := base.GetView()
 	defer view.Close()
-	key, val, err := base.(*BaseStorage).getShardMetadata(view.Raw().(*pebble.Snapshot), 100)
+	key, val, err := base.(*BaseStorage).getShardMetadata(view, 100)
 	assert.Empty(t, key)
 	assert.Empty(t, val)
 	assert.Equal(t, ErrNoMetadata, err)
@@ -137,7 +205,7 @@ func TestGetShardMetadata(t *testing.T) {
 	assert.NoError(t, ds.SaveShardMetadata([]metapb.ShardMetadata{sm2}))
 	view := base.GetView()
 	defer view.Close()
-	key, val, err := base.(*BaseStorage).getShardMetadata(view.Raw().(*pebble.Snapshot), 100)
+	key, val, err := base.(*BaseStorage).getShardMetadata(view, 100)
 	assert.NoError(t, err)
 	assert.Equal(t, keys.GetMetadataKey(uint64(100), uint64(120), nil), key[1:])
 	assert.Equal(t, protoc.MustMarshal(&sm2), val)
@@ -202,14 +270,14 @@ func TestCreateAndApplySnapshot(t *testing.T) {
 		assert.Equal(t, []byte("vv"), v)
 		view := base.GetView()
 		defer view.Close()
-		key, val, err := base.(*BaseStorage).getAppliedIndex(view.Raw().(*pebble.Snapshot), shardID)
+		key, val, err := base.(*BaseStorage).getAppliedIndex(view, shardID)
 		assert.NoError(t, err)
 		var logIndex metapb.LogIndex
 		protoc.MustUnmarshal(&logIndex, val)
 		assert.Equal(t, keys.GetAppliedIndexKey(shardID, nil), key[1:])
 		assert.Equal(t, uint64(110), logIndex.Index)
 
-		key, val, err = base.(*BaseStorage).getShardMetadata(view.Raw().(*pebble.Snapshot), shardID)
+		key, val, err = base.(*BaseStorage).getShardMetadata(view, shardID)
 		assert.NoError(t, err)
 		assert.Equal(t, keys.GetMetadataKey(shardID, uint64(110), nil), key[1:])
 		assert.Equal(t, metadata, val)
@@ -379,3 +447,570 @@ func TestReverseScanInViewWithOptions(t *testing.T) {
 		assert.Equal(t, c.expectKeys, keys, "idx %d", idx)
 	}
 }
+
+// noSSTStorage hides the SSTIngester of the KVStorage
+type noSSTStorage struct {
+	storage.KVStorage
+}
+
+func TestApplyCorruptedSnapshot(t *testing.T) {
+	fs := vfs.NewMemFS()
+	dir := "snapshot-dir"
+	shardID := uint64(100)
+	func() {
+		kv := mem.NewStorage()
+		base := NewBaseStorage(noSSTStorage{kv}, fs)
+		ds := NewKVDataStorage(base, executor.NewKVExecutor(kv))
+		defer ds.Close()
+		assert.NoError(t, base.Set(keysutil.EncodeDataKey([]byte("bb"), nil), []byte("v"), false))
+		sm := metapb.ShardMetadata{
+			ShardID:  shardID,
+			LogIndex: 110,
+			Metadata: metapb.ShardLocalState{
+				Shard: metapb.Shard{ID: shardID, Start: []byte("aa"), End: []byte("xx")},
+			},
+		}
+		assert.NoError(t, ds.SaveShardMetadata([]metapb.ShardMetadata{sm}))
+		assert.NoError(t, base.CreateSnapshot(sm.ShardID, dir))
+	}()
+
+	file := fs.PathJoin(dir, "db.data")
+	f, err := fs.Open(file)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	data := buf.Bytes()
+
+	apply := func(data []byte, shardID uint64) error {
+		f, err := fs.Create(file)
+		require.NoError(t, err)
+		_, err = f.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		kv := mem.NewStorage()
+		defer kv.Close()
+		return NewBaseStorage(noSSTStorage{kv}, fs).ApplySnapshot(shardID, dir)
+	}
+
+	_, err = fs.Stat(fs.PathJoin(dir, snapshotSSTFile))
+	assert.True(t, vfs.IsNotExist(err))
+	assert.NoError(t, apply(data, shardID))
+	// the snapshot of another shard
+	assert.True(t, errors.Is(apply(data, shardID+1), storage.ErrSnapshotCorrupted))
+	// truncated at any position
+	for i := 0; i < len(data); i++ {
+		err := apply(data[:i], shardID)
+		assert.True(t, errors.Is(err, storage.ErrSnapshotCorrupted), "truncated at %d, %v", i, err)
+	}
+	// flipped at any position
+	for i := 0; i < len(data); i++ {
+		corrupted := append([]byte(nil), data...)
+		corrupted[i] ^= 0x10
+		err := apply(corrupted, shardID)
+		assert.True(t, errors.Is(err, storage.ErrSnapshotCorrupted), "flipped at %d, %v", i, err)
+	}
+	// data after the end
+	assert.True(t, errors.Is(apply(append(data, 0), shardID), storage.ErrSnapshotCorrupted))
+	// key out of range
+	var corrupted bytes.Buffer
+	corrupted.Write(data[:len(data)-8])
+	for _, v := range [][]byte{keysutil.EncodeDataKey([]byte("zz"), nil), []byte("v")} {
+		require.NoError(t, writeBytes(&corrupted, v))
+	}
+	require.NoError(t, writeSnapshotEnd(&corrupted))
+	assert.True(t, errors.Is(apply(corrupted.Bytes(), shardID), storage.ErrSnapshotCorrupted))
+}
+
+func TestApplyLegacySnapshot(t *testing.T) {
+	fs := vfs.NewMemFS()
+	dir := "snapshot-dir"
+	shardID := uint64(100)
+	require.NoError(t, fs.MkdirAll(dir, 0755))
+
+	shard := metapb.Shard{ID: shardID, Start: []byte("aa"), End: []byte("xx")}
+	sm := metapb.ShardMetadata{
+		ShardID:  shardID,
+		LogIndex: 110,
+		Metadata: metapb.ShardLocalState{Shard: shard},
+	}
+	var buf bytes.Buffer
+	size := make([]byte, 4)
+	for _, v := range [][]byte{
+		keysutil.EncodeShardStart(shard.Start, nil),
+		keysutil.EncodeShardEnd(shard.End, nil),
+		keysutil.EncodeShardMetadataKey(keys.GetAppliedIndexKey(shardID, nil), nil),
+		protoc.MustMarshal(&metapb.LogIndex{Index: 110}),
+		keysutil.EncodeShardMetadataKey(keys.GetMetadataKey(shardID, 110, nil), nil),
+		protoc.MustMarshal(&sm),
+		keysutil.EncodeDataKey([]byte("bb"), nil),
+		[]byte("v"),
+	} {
+		binary.BigEndian.PutUint32(size, uint32(len(v)))
+		buf.Write(size)
+		buf.Write(v)
+	}
+	f, err := fs.Create(fs.PathJoin(dir, snapshotDataFile))
+	require.NoError(t, err)
+	_, err = f.Write(buf.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	kv := mem.NewStorage()
+	base := NewBaseStorage(kv, fs)
+	defer base.Close()
+	assert.NoError(t, base.(storage.SnapshotVerifier).VerifySnapshot(shardID, dir))
+	assert.NoError(t, base.ApplySnapshot(shardID, dir))
+	value, err := base.Get(keysutil.EncodeDataKey([]byte("bb"), nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v"), value)
+}
+
+func TestApplyChecksumVersionSnapshot(t *testing.T) {
+	fs := vfs.NewMemFS()
+	dir := "snapshot-dir"
+	shardID := uint64(100)
+	require.NoError(t, fs.MkdirAll(dir, 0755))
+
+	shard := metapb.Shard{ID: shardID, Start: []byte("aa"), End: []byte("xx")}
+	sm := metapb.ShardMetadata{
+		ShardID:  shardID,
+		LogIndex: 110,
+		Metadata: metapb.ShardLocalState{Shard: shard},
+	}
+	var buf bytes.Buffer
+	version := make([]byte, 8)
+	binary.BigEndian.PutUint32(version, snapshotMagic)
+	binary.BigEndian.PutUint32(version[4:], snapshotVersionChecksum)
+	buf.Write(version)
+	for _, v := range [][]byte{
+		keysutil.EncodeShardStart(shard.Start, nil),
+		keysutil.EncodeShardEnd(shard.End, nil),
+		keysutil.EncodeShardMetadataKey(keys.GetAppliedIndexKey(shardID, nil), nil),
+		protoc.MustMarshal(&metapb.LogIndex{Index: 110}),
+		keysutil.EncodeShardMetadataKey(keys.GetMetadataKey(shardID, 110, nil), nil),
+		protoc.MustMarshal(&sm),
+		keysutil.EncodeDataKey([]byte("bb"), nil),
+		[]byte("v"),
+		nil,
+	} {
+		require.NoError(t, writeBytes(&buf, v))
+	}
+	f, err := fs.Create(fs.PathJoin(dir, snapshotDataFile))
+	require.NoError(t, err)
+	_, err = f.Write(buf.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	kv := mem.NewStorage()
+	base := NewBaseStorage(kv, fs)
+	defer base.Close()
+	assert.NoError(t, base.(storage.SnapshotVerifier).VerifySnapshot(shardID, dir))
+	assert.NoError(t, base.ApplySnapshot(shardID, dir))
+	value, err := base.Get(keysutil.EncodeDataKey([]byte("bb"), nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v"), value)
+}
+
+func TestSnapshotManifest(t *testing.T) {
+	fs := vfs.NewMemFS()
+	dir := "snapshot-dir"
+	shardID := uint64(100)
+	createTestSnapshot(t, fs, dir, shardID)
+
+	manifest := readTestSnapshotManifest(t, fs, dir, shardID)
+	assert.Equal(t, snapshotManifestFormatVersion, manifest.FormatVersion)
+	assert.Equal(t, shardID, manifest.Shard.ID)
+	assert.Equal(t, snapshotFeatureSST, manifest.Features)
+	// "bb" and "mmm" in the range, the keys are encoded with the data prefix
+	assert.Equal(t, uint64(2), manifest.KeyCount)
+	assert.Equal(t, uint64(12), manifest.ByteCount)
+	assert.NotZero(t, manifest.SstSize)
+
+	apply := func(manifest metapb.SnapshotManifest) error {
+		writeTestSnapshotManifest(t, fs, dir, manifest)
+		kv := mem.NewStorage()
+		defer kv.Close()
+		return NewBaseStorage(noSSTStorage{kv}, fs).ApplySnapshot(shardID, dir)
+	}
+	assert.NoError(t, apply(manifest))
+
+	tests := []struct {
+		name   string
+		update func(m *metapb.SnapshotManifest)
+	}{
+		{"unknown format version", func(m *metapb.SnapshotManifest) { m.FormatVersion++ }},
+		{"missing format version", func(m *metapb.SnapshotManifest) { m.FormatVersion = 0 }},
+		{"unknown feature", func(m *metapb.SnapshotManifest) { m.Features |= 1 << 63 }},
+		{"missing sst feature", func(m *metapb.SnapshotManifest) { m.Features = 0 }},
+		{"another shard", func(m *metapb.SnapshotManifest) { m.Shard.ID++ }},
+		{"range not match", func(m *metapb.SnapshotManifest) { m.Shard.End = []byte("yy") }},
+		{"key count mismatch", func(m *metapb.SnapshotManifest) { m.KeyCount++ }},
+		{"byte count mismatch", func(m *metapb.SnapshotManifest) { m.ByteCount++ }},
+		{"data checksum mismatch", func(m *metapb.SnapshotManifest) { m.DataChecksum++ }},
+		{"sst checksum mismatch", func(m *metapb.SnapshotManifest) { m.SstChecksum++ }},
+	}
+	for _, tt := range tests {
+		m := manifest
+		tt.update(&m)
+		err := apply(m)
+		assert.True(t, errors.Is(err, storage.ErrSnapshotCorrupted), "%s, %v", tt.name, err)
+	}
+}
+
+func createTestSnapshot(t *testing.T, fs vfs.FS, dir string, shardID uint64) {
+	kv := mem.NewStorage()
+	base := NewBaseStorage(kv, fs)
+	ds := NewKVDataStorage(base, executor.NewKVExecutor(kv))
+	defer ds.Close()
+	for _, k := range []string{"bb", "mmm", "yy"} {
+		assert.NoError(t, base.Set(keysutil.EncodeDataKey([]byte(k), nil), []byte(k), false))
+	}
+	sm := metapb.ShardMetadata{
+		ShardID:  shardID,
+		LogIndex: 110,
+		Metadata: metapb.ShardLocalState{
+			Shard: metapb.Shard{ID: shardID, Start: []byte("aa"), End: []byte("xx")},
+		},
+	}
+	assert.NoError(t, ds.SaveShardMetadata([]metapb.ShardMetadata{sm}))
+	assert.NoError(t, base.CreateSnapshot(sm.ShardID, dir))
+}
+
+func TestApplySnapshotSST(t *testing.T) {
+	fs := vfs.NewMemFS()
+	dir := "snapshot-dir"
+	shardID := uint64(100)
+	createTestSnapshot(t, fs, dir, shardID)
+	_, err := fs.Stat(fs.PathJoin(dir, snapshotSSTFile))
+	require.NoError(t, err)
+
+	for _, ingest := range []bool{true, false} {
+		func() {
+			var kv storage.KVStorage = mem.NewStorage()
+			defer kv.Close()
+			if !ingest {
+				kv = noSSTStorage{kv}
+			}
+			base := NewBaseStorage(kv, fs)
+			assert.NoError(t, base.Set(keysutil.EncodeDataKey([]byte("cc"), nil), []byte("cc"), false))
+			assert.NoError(t, base.Set(keysutil.EncodeDataKey([]byte("zz"), nil), []byte("zz"), false))
+			assert.NoError(t, base.ApplySnapshot(shardID, dir))
+
+			expect := map[string]string{"bb": "bb", "cc": "", "mmm": "mmm", "yy": "", "zz": "zz"}
+			for k, v := range expect {
+				value, err := base.Get(keysutil.EncodeDataKey([]byte(k), nil))
+				assert.NoError(t, err)
+				assert.Equal(t, v, string(value), "ingest %v, key %s", ingest, k)
+			}
+
+			view := base.GetView()
+			defer view.Close()
+			_, val, err := base.(*BaseStorage).getAppliedIndex(view, shardID)
+			assert.NoError(t, err)
+			var logIndex metapb.LogIndex
+			protoc.MustUnmarshal(&logIndex, val)
+			assert.Equal(t, uint64(110), logIndex.Index)
+			_, _, err = base.(*BaseStorage).getShardMetadata(view, shardID)
+			assert.NoError(t, err)
+			_, err = fs.Stat(fs.PathJoin(dir, snapshotMetadataSSTFile))
+			assert.True(t, vfs.IsNotExist(err))
+		}()
+	}
+}
+
+func TestApplyCorruptedSnapshotSST(t *testing.T) {
+	fs := vfs.NewMemFS()
+	dir := "snapshot-dir"
+	shardID := uint64(100)
+	createTestSnapshot(t, fs, dir, shardID)
+
+	start := keysutil.EncodeShardStart([]byte("aa"), nil)
+	end := keysutil.EncodeShardEnd([]byte("xx"), nil)
+	manifest := readTestSnapshotManifest(t, fs, dir, shardID)
+	key := func(k string) []byte {
+		return keysutil.EncodeDataKey([]byte(k), nil)
+	}
+	writeSST := func(rangeDels [][2][]byte, keys ...[]byte) {
+		f, err := fs.Create(fs.PathJoin(dir, snapshotSSTFile))
+		require.NoError(t, err)
+		w := sstable.NewWriter(f, sstable.WriterOptions{})
+		for _, r := range rangeDels {
+			require.NoError(t, w.DeleteRange(r[0], r[1]))
+		}
+		for _, k := range keys {
+			require.NoError(t, w.Set(k, k))
+		}
+		require.NoError(t, w.Close())
+		writeTestSnapshotManifest(t, fs, dir, updateTestSnapshotSSTChecksum(t, fs, dir, manifest))
+	}
+	apply := func(ingest bool) error {
+		var kv storage.KVStorage = mem.NewStorage()
+		defer kv.Close()
+		if !ingest {
+			kv = noSSTStorage{kv}
+		}
+		return NewBaseStorage(kv, fs).ApplySnapshot(shardID, dir)
+	}
+
+	tests := []struct {
+		name  string
+		write func()
+	}{
+		{"key out of range", func() { writeSST([][2][]byte{{start, end}}, key("bb"), key("zz")) }},
+		{"key before range", func() { writeSST([][2][]byte{{start, end}}, key("a"), key("bb")) }},
+		{"missing range deletion", func() { writeSST(nil, key("bb")) }},
+		{"wider range deletion", func() { writeSST([][2][]byte{{start, key("zz")}}, key("bb")) }},
+		{"extra range deletion", func() {
+			writeSST([][2][]byte{{start, end}, {key("yy"), key("zz")}}, key("bb"))
+		}},
+		{"not a sst", func() {
+			f, err := fs.Create(fs.PathJoin(dir, snapshotSSTFile))
+			require.NoError(t, err)
+			_, err = f.Write([]byte("not a sst"))
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+		}},
+	}
+	for _, tt := range tests {
+		tt.write()
+		for _, ingest := range []bool{true, false} {
+			err := apply(ingest)
+			assert.True(t, errors.Is(err, storage.ErrSnapshotCorrupted), "%s, ingest %v, %v", tt.name, ingest, err)
+		}
+	}
+
+	// the key-value pairs after the header
+	writeSST([][2][]byte{{start, end}}, key("bb"))
+	assert.NoError(t, apply(true))
+	f, err := fs.Create(fs.PathJoin(dir, snapshotDataFile))
+	require.NoError(t, err)
+	require.NoError(t, writeSnapshotManifest(f, updateTestSnapshotSSTChecksum(t, fs, dir, manifest)))
+	require.NoError(t, writeBytes(f, key("bb")))
+	require.NoError(t, writeBytes(f, key("bb")))
+	require.NoError(t, writeSnapshotEnd(f))
+	require.NoError(t, f.Close())
+	assert.True(t, errors.Is(apply(true), storage.ErrSnapshotCorrupted))
+}
+
+func readTestSnapshotManifest(t *testing.T, fs vfs.FS, dir string, shardID uint64) metapb.SnapshotManifest {
+	f, err := fs.Open(fs.PathJoin(dir, snapshotDataFile))
+	require.NoError(t, err)
+	defer f.Close()
+	sr, err := newSnapshotReader(f, 1024)
+	require.NoError(t, err)
+	manifest, err := readSnapshotManifest(sr, shardID)
+	require.NoError(t, err)
+	return manifest
+}
+
+func updateTestSnapshotSSTChecksum(t *testing.T, fs vfs.FS, dir string,
+	manifest metapb.SnapshotManifest) metapb.SnapshotManifest {
+	var err error
+	manifest.SstSize, manifest.SstChecksum, err = getSnapshotSSTChecksum(fs, fs.PathJoin(dir, snapshotSSTFile))
+	require.NoError(t, err)
+	return manifest
+}
+
+func writeTestSnapshotManifest(t *testing.T, fs vfs.FS, dir string, manifest metapb.SnapshotManifest) {
+	f, err := fs.Create(fs.PathJoin(dir, snapshotDataFile))
+	require.NoError(t, err)
+	require.NoError(t, writeSnapshotManifest(f, manifest))
+	require.NoError(t, writeSnapshotEnd(f))
+	require.NoError(t, f.Close())
+}
+
+func TestVerifySnapshotSST(t *testing.T) {
+	fs := vfs.NewMemFS()
+	dir := "snapshot-dir"
+	shardID := uint64(100)
+	createTestSnapshot(t, fs, dir, shardID)
+	kv := mem.NewStorage()
+	base := NewBaseStorage(kv, fs)
+	defer base.Close()
+	verifier := base.(storage.SnapshotVerifier)
+	assert.NoError(t, verifier.VerifySnapshot(shardID, dir))
+
+	file := fs.PathJoin(dir, snapshotSSTFile)
+	f, err := fs.Open(file)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	data := buf.Bytes()
+
+	write := func(data []byte) {
+		f, err := fs.Create(file)
+		require.NoError(t, err)
+		_, err = f.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+	// truncated or flipped SST file
+	for _, i := range []int{0, len(data) / 2, len(data) - 1} {
+		write(data[:i])
+		err := verifier.VerifySnapshot(shardID, dir)
+		assert.True(t, errors.Is(err, storage.ErrSnapshotCorrupted), "truncated at %d, %v", i, err)
+
+		corrupted := append([]byte(nil), data...)
+		corrupted[i] ^= 0x10
+		write(corrupted)
+		err = verifier.VerifySnapshot(shardID, dir)
+		assert.True(t, errors.Is(err, storage.ErrSnapshotCorrupted), "flipped at %d, %v", i, err)
+		assert.True(t, errors.Is(base.ApplySnapshot(shardID, dir), storage.ErrSnapshotCorrupted))
+	}
+	write(data)
+	assert.NoError(t, verifier.VerifySnapshot(shardID, dir))
+}
+
+func TestEncryptedSnapshot(t *testing.T) {
+	value := bytes.Repeat([]byte("plaintext-value"), 10)
+	for _, ingest := range []bool{true, false} {
+		fs := vfs.NewMemFS()
+		efs, err := vfs.NewEncryptedFS(fs, vfs.EncryptionOptions{
+			Keys:        map[uint32][]byte{1: bytes.Repeat([]byte{1}, 32)},
+			ActiveKeyID: 1,
+		})
+		require.NoError(t, err)
+		dir := "snapshot-dir"
+		shardID := uint64(100)
+		newKV := func() storage.KVStorage {
+			if ingest {
+				return mem.NewStorage()
+			}
+			return noSSTStorage{mem.NewStorage()}
+		}
+
+		kv := newKV()
+		base := NewBaseStorage(kv, efs)
+		ds := NewKVDataStorage(base, executor.NewKVExecutor(kv))
+		for _, k := range []string{"bb", "mmm"} {
+			require.NoError(t, base.Set(keysutil.EncodeDataKey([]byte(k), nil), value, false))
+		}
+		sm := metapb.ShardMetadata{
+			ShardID:  shardID,
+			LogIndex: 110,
+			Metadata: metapb.ShardLocalState{
+				Shard: metapb.Shard{ID: shardID, Start: []byte("aa"), End: []byte("xx")},
+			},
+		}
+		require.NoError(t, ds.SaveShardMetadata([]metapb.ShardMetadata{sm}))
+		require.NoError(t, base.CreateSnapshot(shardID, dir))
+		require.NoError(t, ds.Close())
+
+		names, err := fs.List(dir)
+		require.NoError(t, err)
+		assert.NotEmpty(t, names)
+		for _, name := range names {
+			f, err := fs.Open(fs.PathJoin(dir, name))
+			require.NoError(t, err)
+			var buf bytes.Buffer
+			_, err = buf.ReadFrom(f)
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+			assert.False(t, bytes.Contains(buf.Bytes(), value[:32]), "ingest %v, %s", ingest, name)
+		}
+
+		// the snapshot can only be applied by the storage with the key
+		plain := NewBaseStorage(newKV(), fs)
+		assert.Error(t, plain.ApplySnapshot(shardID, dir))
+		require.NoError(t, plain.Close())
+
+		base = NewBaseStorage(newKV(), efs)
+		require.NoError(t, base.ApplySnapshot(shardID, dir))
+		for _, k := range []string{"bb", "mmm"} {
+			v, err := base.Get(keysutil.EncodeDataKey([]byte(k), nil))
+			require.NoError(t, err)
+			assert.Equal(t, value, v, "ingest %v, key %s", ingest, k)
+		}
+		require.NoError(t, base.Close())
+	}
+}
+
+// newTestSnapshotDataStorage returns the storage of the shard with n keys, the
+// key-value pairs are written in the snapshot file instead of the SST file.
+func newTestSnapshotDataStorage(t testing.TB, fs vfs.FS, shardID uint64, n int) *BaseStorage {
+	kv := noSSTStorage{mem.NewStorage()}
+	base := NewBaseStorage(kv, fs)
+	ds := NewKVDataStorage(base, executor.NewKVExecutor(kv))
+	value := make([]byte, 100)
+	for i := 0; i < n; i++ {
+		key := keysutil.EncodeDataKey([]byte(fmt.Sprintf("k%08d", i)), nil)
+		require.NoError(t, base.Set(key, value, false))
+	}
+	require.NoError(t, ds.SaveShardMetadata([]metapb.ShardMetadata{{
+		ShardID:  shardID,
+		LogIndex: 110,
+		Metadata: metapb.ShardLocalState{Shard: metapb.Shard{ID: shardID}},
+	}}))
+	return base.(*BaseStorage)
+}
+
+func TestCreateSnapshotWriteBuffer(t *testing.T) {
+	fs := vfs.NewMemFS()
+	shardID := uint64(100)
+	base := newTestSnapshotDataStorage(t, fs, shardID, 100)
+	defer base.Close()
+
+	require.NoError(t, base.CreateSnapshot(shardID, "default"))
+	// the fields span the buffers
+	base.SetSnapshotWriteBufferSize(64)
+	require.NoError(t, base.CreateSnapshot(shardID, "small"))
+	read := func(dir string) []byte {
+		f, err := fs.Open(fs.PathJoin(dir, snapshotDataFile))
+		require.NoError(t, err)
+		defer f.Close()
+		var buf bytes.Buffer
+		_, err = buf.ReadFrom(f)
+		require.NoError(t, err)
+		return buf.Bytes()
+	}
+	assert.Equal(t, read("default"), read("small"))
+
+	kv := noSSTStorage{mem.NewStorage()}
+	applied := NewBaseStorage(kv, fs)
+	defer applied.Close()
+	require.NoError(t, applied.ApplySnapshot(shardID, "small"))
+	value, err := applied.Get(keysutil.EncodeDataKey([]byte("k00000099"), nil))
+	require.NoError(t, err)
+	assert.Equal(t, 100, len(value))
+}
+
+// countingWriter counts the writes, each write to a file is a syscall
+type countingWriter struct {
+	writes int
+}
+
+func (w *countingWriter) Write(data []byte) (int, error) {
+	w.writes++
+	return len(data), nil
+}
+
+func BenchmarkWriteSnapshotData(b *testing.B) {
+	shardID := uint64(100)
+	base := newTestSnapshotDataStorage(b, vfs.NewMemFS(), shardID, 10000)
+	defer base.Close()
+	view := base.GetView()
+	defer view.Close()
+	manifest, err := base.getSnapshotManifest(view, shardID)
+	require.NoError(b, err)
+
+	for _, size := range []int{0, 4 * 1024, 1024 * 1024} {
+		b.Run(fmt.Sprintf("buffer-%d", size), func(b *testing.B) {
+			w := &countingWriter{}
+			for i := 0; i < b.N; i++ {
+				if size == 0 {
+					require.NoError(b, base.writeSnapshotData(w, view, manifest))
+					continue
+				}
+				bw := bufio.NewWriterSize(w, size)
+				require.NoError(b, base.writeSnapshotData(bw, view, manifest))
+				require.NoError(b, bw.Flush())
+			}
+			b.ReportMetric(float64(w.writes)/float64(b.N), "writes/op")
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+// +build cgo
+
+package kv
+
+import (
+	"io"
+
+	"github.com/DataDog/zstd"
+)
+
+func newZstdWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w), nil
+}
+
+func newZstdReader(r io.Reader) (io.ReadCloser, error) {
+	src := &sourceReader{r: r}
+	zr := zstd.NewReader(src)
+	return newDecompressReaderWith(src, zr, zr.Close), nil
+}
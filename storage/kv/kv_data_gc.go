@@ -0,0 +1,258 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fagongzi/util/protoc"
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/keys"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/stats"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultGCInterval = time.Second
+)
+
+// shardGC removes the data ranges of the destroyed shards in background, one
+// range every interval, so the removal of the large shards does not stall the
+// writes. The removed range is compacted afterwards if the base storage is able
+// to, to drop the range tombstones slowing down the scans.
+//
+// The pending ranges are persisted with the keys.GetShardGCKey keys before the
+// shard metadata is removed, and loaded again by GetInitialStates after
+// restart, so the cleanup will not be lost.
+type shardGC struct {
+	base     storage.KVBaseStorage
+	logger   *zap.Logger
+	interval time.Duration
+
+	removed   uint64
+	compacted uint64
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	started   bool
+	stopC     chan struct{}
+	doneC     chan struct{}
+
+	mu struct {
+		sync.Mutex
+		// pending the destroyed shards in the order of the removal
+		pending []metapb.Shard
+	}
+}
+
+func newShardGC(base storage.KVBaseStorage, logger *zap.Logger, interval time.Duration) *shardGC {
+	return &shardGC{
+		base:     base,
+		logger:   logger,
+		interval: interval,
+		stopC:    make(chan struct{}),
+		doneC:    make(chan struct{}),
+	}
+}
+
+// add persists the data range of the destroyed shard and queues it.
+func (gc *shardGC) add(shard metapb.Shard) error {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	if gc.pendingLocked(shard.ID) >= 0 {
+		return nil
+	}
+	if err := gc.base.Set(shardGCKey(shard.ID),
+		protoc.MustMarshal(&metapb.Shard{ID: shard.ID, Start: shard.Start, End: shard.End}),
+		false); err != nil {
+		return err
+	}
+	gc.addLocked(shard)
+	return nil
+}
+
+// load queues the pending data ranges persisted before restart.
+func (gc *shardGC) load() error {
+	var shards []metapb.Shard
+	if err := gc.base.Scan(shardGCKey(0), shardGCKey(math.MaxUint64),
+		func(key, value []byte) (bool, error) {
+			var shard metapb.Shard
+			protoc.MustUnmarshal(&shard, value)
+			shards = append(shards, shard)
+			return true, nil
+		}, true); err != nil {
+		return err
+	}
+
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	for _, shard := range shards {
+		if gc.pendingLocked(shard.ID) < 0 {
+			gc.addLocked(shard)
+		}
+	}
+	return nil
+}
+
+func (gc *shardGC) addLocked(shard metapb.Shard) {
+	gc.mu.pending = append(gc.mu.pending, shard)
+	gc.logger.Debug("shard data range queued for removal",
+		log.ShardField("shard", shard),
+		zap.Int("pending", len(gc.mu.pending)))
+	gc.startOnce.Do(func() {
+		gc.started = true
+		go gc.loop()
+	})
+}
+
+func (gc *shardGC) pendingLocked(shardID uint64) int {
+	for i, shard := range gc.mu.pending {
+		if shard.ID == shardID {
+			return i
+		}
+	}
+	return -1
+}
+
+// flush removes all the pending data ranges synchronously without compaction,
+// it must be called before any data is written into the ranges of the destroyed
+// shards again, e.g. applying a snapshot.
+func (gc *shardGC) flush() error {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	for len(gc.mu.pending) > 0 {
+		shard := gc.mu.pending[0]
+		if err := gc.deleteLocked(shard); err != nil {
+			return err
+		}
+		if err := gc.finishLocked(shard); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (gc *shardGC) loop() {
+	defer close(gc.doneC)
+	ticker := time.NewTicker(gc.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-gc.stopC:
+			return
+		case <-ticker.C:
+			// the failed range is removed again in the next round
+			if err := gc.removeNext(); err != nil {
+				gc.logger.Error("failed to remove shard data range",
+					zap.Error(err))
+			}
+		}
+	}
+}
+
+// removeNext deletes the first pending data range and compacts it.
+func (gc *shardGC) removeNext() error {
+	gc.mu.Lock()
+	if len(gc.mu.pending) == 0 {
+		gc.mu.Unlock()
+		return nil
+	}
+	shard := gc.mu.pending[0]
+	err := gc.deleteLocked(shard)
+	gc.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// the compaction is slow and not required by the correctness, the range may
+	// be flushed and reused meanwhile
+	err = gc.base.CompactRange(shardDataRange(shard))
+	if err == nil {
+		atomic.AddUint64(&gc.compacted, 1)
+	} else if !errors.Is(err, storage.ErrCompactNotSupported) {
+		gc.logger.Warn("failed to compact removed shard data range",
+			log.ShardField("shard", shard),
+			zap.Error(err))
+	}
+
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	if gc.pendingLocked(shard.ID) < 0 {
+		return nil
+	}
+	return gc.finishLocked(shard)
+}
+
+func (gc *shardGC) deleteLocked(shard metapb.Shard) error {
+	min, max := shardDataRange(shard)
+	gc.logger.Debug("remove shard data",
+		log.ShardField("shard", shard),
+		log.HexField("from", min),
+		log.HexField("to", max))
+	if err := gc.base.RangeDelete(min, max, false); err != nil {
+		return err
+	}
+	// the expiry metadata of the removed keys
+	min, max = shardTTLRange(shard)
+	return gc.base.RangeDelete(min, max, false)
+}
+
+// finishLocked removes the deleted range from the pending ranges.
+func (gc *shardGC) finishLocked(shard metapb.Shard) error {
+	if err := gc.base.Delete(shardGCKey(shard.ID), false); err != nil {
+		return err
+	}
+	idx := gc.pendingLocked(shard.ID)
+	gc.mu.pending = append(gc.mu.pending[:idx], gc.mu.pending[idx+1:]...)
+	atomic.AddUint64(&gc.removed, 1)
+	return nil
+}
+
+func (gc *shardGC) fillStats(st *stats.Stats) {
+	gc.mu.Lock()
+	st.GCPendingRanges = uint64(len(gc.mu.pending))
+	gc.mu.Unlock()
+	st.GCRemovedRanges = atomic.LoadUint64(&gc.removed)
+	st.GCCompactedRanges = atomic.LoadUint64(&gc.compacted)
+}
+
+func (gc *shardGC) close() {
+	gc.stopOnce.Do(func() {
+		close(gc.stopC)
+		gc.startOnce.Do(func() {})
+		if gc.started {
+			<-gc.doneC
+		}
+	})
+}
+
+func shardGCKey(shardID uint64) []byte {
+	return keysutil.EncodeShardMetadataKey(keys.GetShardGCKey(shardID), nil)
+}
+
+func shardDataRange(shard metapb.Shard) ([]byte, []byte) {
+	return keysutil.EncodeShardStart(shard.Start, nil),
+		keysutil.EncodeShardEnd(shard.End, nil)
+}
+
+func shardTTLRange(shard metapb.Shard) ([]byte, []byte) {
+	return ttlMetaRange(shardDataRange(shard))
+}
@@ -0,0 +1,135 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"testing"
+	"time"
+
+	cpebble "github.com/cockroachdb/pebble"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	"github.com/matrixorigin/matrixcube/storage/kv/pebble"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func countTestShardData(t *testing.T, kv storage.KVStorage, shard metapb.Shard) int {
+	c := 0
+	min, max := shardDataRange(shard)
+	require.NoError(t, kv.Scan(min, max, func(key, value []byte) (bool, error) {
+		c++
+		return true, nil
+	}, false))
+	return c
+}
+
+func TestShardGCAfterRestart(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.NewMemFS()
+	open := func() *pebble.Storage {
+		kv, err := pebble.NewStorage(testDir, nil, &cpebble.Options{FS: vfs.NewPebbleFS(fs)})
+		require.NoError(t, err)
+		return kv
+	}
+	shard := metapb.Shard{ID: 1, Start: []byte("a"), End: []byte("c")}
+
+	kv := open()
+	ds := NewKVDataStorage(NewBaseStorage(kv, fs), nil, WithGCInterval(time.Hour))
+	for _, k := range []string{"a", "b", "c"} {
+		require.NoError(t, kv.Set(keysutil.EncodeDataKey([]byte(k), nil), []byte(k), false))
+	}
+	require.NoError(t, ds.RemoveShard(shard, true))
+	require.NoError(t, ds.RemoveShard(shard, true))
+	st := ds.Stats()
+	assert.Equal(t, uint64(1), st.GCPendingRanges)
+	assert.Equal(t, uint64(0), st.GCRemovedRanges)
+	assert.Equal(t, 2, countTestShardData(t, kv, shard))
+	require.NoError(t, kv.Sync())
+	require.NoError(t, ds.Close())
+
+	// the pending range is loaded after restart
+	kv = open()
+	ds = NewKVDataStorage(NewBaseStorage(kv, fs), nil, WithGCInterval(time.Millisecond))
+	defer ds.Close()
+	_, err := ds.GetInitialStates()
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		st := ds.Stats()
+		return st.GCPendingRanges == 0 && st.GCRemovedRanges == 1
+	}, time.Second*10, time.Millisecond*10)
+	assert.Equal(t, uint64(1), ds.Stats().GCCompactedRanges)
+	assert.Equal(t, 0, countTestShardData(t, kv, shard))
+	v, err := kv.Get(keysutil.EncodeDataKey([]byte("c"), nil))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("c"), v)
+	v, err = kv.Get(shardGCKey(shard.ID))
+	require.NoError(t, err)
+	assert.Empty(t, v)
+}
+
+func TestShardGCFlushedBeforeApplySnapshot(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.NewMemFS()
+	dir := "snapshot-dir"
+	shardID := uint64(100)
+	createTestSnapshot(t, fs, dir, shardID)
+
+	kv := mem.NewStorage()
+	base := NewBaseStorage(kv, fs)
+	ds := NewKVDataStorage(base, executor.NewKVExecutor(kv), WithGCInterval(time.Hour))
+	defer ds.Close()
+	// the destroyed shard in the range of the snapshot
+	shard := metapb.Shard{ID: 1, Start: []byte("aa"), End: []byte("xx")}
+	require.NoError(t, base.Set(keysutil.EncodeDataKey([]byte("cc"), nil), []byte("cc"), false))
+	require.NoError(t, ds.RemoveShard(shard, true))
+	assert.Equal(t, uint64(1), ds.Stats().GCPendingRanges)
+
+	require.NoError(t, ds.ApplySnapshot(shardID, dir))
+	st := ds.Stats()
+	assert.Equal(t, uint64(0), st.GCPendingRanges)
+	assert.Equal(t, uint64(1), st.GCRemovedRanges)
+	assert.Equal(t, uint64(0), st.GCCompactedRanges)
+	v, err := base.Get(keysutil.EncodeDataKey([]byte("cc"), nil))
+	require.NoError(t, err)
+	assert.Empty(t, v)
+	v, err = base.Get(keysutil.EncodeDataKey([]byte("mmm"), nil))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("mmm"), v)
+}
+
+func TestShardGCRemovesTTL(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	base := NewBaseStorage(mem.NewStorage(), vfs.NewMemFS()).(*BaseStorage)
+	defer base.Close()
+
+	now := time.Now()
+	setTestTTL(t, base, keysutil.EncodeDataKey([]byte("bb"), nil), []byte("bb"), now)
+	setTestTTL(t, base, keysutil.EncodeDataKey([]byte("zz"), nil), []byte("zz"), now)
+	gc := newShardGC(base, zap.NewNop(), time.Hour)
+	require.NoError(t, gc.deleteLocked(metapb.Shard{Start: []byte("aa"), End: []byte("xx")}))
+
+	keys, err := base.ExpiredKeys(nil, nil, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.ExpiredKey{{
+		Key:        keysutil.EncodeDataKey([]byte("zz"), nil),
+		Expiration: now.UnixNano(),
+	}}, keys)
+}
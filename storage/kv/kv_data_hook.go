@@ -0,0 +1,168 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+)
+
+// commitHooks the storage.CommitHooks of the shards. The hook of a shard is
+// created and rebuilt on restart, or lazily before the first write of the
+// shard, so it never misses any committed write.
+type commitHooks struct {
+	factory storage.CommitHookFactory
+	base    storage.KVBaseStorage
+	logger  *zap.Logger
+
+	mu    sync.Mutex
+	hooks map[uint64]storage.CommitHook
+}
+
+func newCommitHooks(base storage.KVBaseStorage, opts *options) *commitHooks {
+	if opts.commitHookFactory == nil {
+		return nil
+	}
+	return &commitHooks{
+		factory: opts.commitHookFactory,
+		base:    base,
+		logger:  opts.logger,
+		hooks:   make(map[uint64]storage.CommitHook),
+	}
+}
+
+func (h *commitHooks) get(shardID uint64) storage.CommitHook {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.hooks[shardID]
+}
+
+// rebuild rebuilds the hook of the shard from the persisted data, the hook is
+// created if it's not created yet. The hook is removed if it's failed to be
+// rebuilt, and it's rebuilt again before the next write.
+func (h *commitHooks) rebuild(shard metapb.Shard) error {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	hook, ok := h.hooks[shard.ID]
+	if !ok {
+		hook = h.factory(shard)
+		h.hooks[shard.ID] = hook
+	}
+	h.mu.Unlock()
+
+	start, end := shardDataRange(shard)
+	if err := hook.Rebuild(shard, func(handler func(key, value []byte) (bool, error)) error {
+		return h.base.Scan(start, end, func(key, value []byte) (bool, error) {
+			return handler(keysutil.DecodeDataKey(key), value)
+		}, false)
+	}); err != nil {
+		h.logger.Error("fail to rebuild commit hook",
+			log.ShardField("shard", shard),
+			zap.Error(err))
+		h.remove(shard.ID)
+		return err
+	}
+	return nil
+}
+
+// remove closes and removes the hook of the shard
+func (h *commitHooks) remove(shardID uint64) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	hook, ok := h.hooks[shardID]
+	delete(h.hooks, shardID)
+	h.mu.Unlock()
+	if ok {
+		hook.Close()
+	}
+}
+
+// beginWrite is called before the write batch of the shard is built, the
+// returned hookWrite must be ended after the write batch is applied.
+func (h *commitHooks) beginWrite(ctx storage.WriteContext) (*hookWrite, error) {
+	if h == nil {
+		return nil, nil
+	}
+
+	shard := ctx.Shard()
+	hook := h.get(shard.ID)
+	if hook == nil {
+		if err := h.rebuild(shard); err != nil {
+			return nil, err
+		}
+		hook = h.get(shard.ID)
+	}
+	return &hookWrite{
+		WriteContext: ctx,
+		hook:         hook,
+		wb:           &mirrorWriteBatch{WriteBatch: ctx.WriteBatch().(util.WriteBatch)},
+	}, nil
+}
+
+// hookWrite the write context recording the writes for the commit hook, the
+// writes are recorded in the same way as the mirrors.
+type hookWrite struct {
+	storage.WriteContext
+	hook storage.CommitHook
+	wb   *mirrorWriteBatch
+}
+
+// context returns the write context passed to the executor
+func (w *hookWrite) context(ctx storage.WriteContext) storage.WriteContext {
+	if w == nil {
+		return ctx
+	}
+	return w
+}
+
+func (w *hookWrite) WriteBatch() storage.Resetable {
+	return w.wb
+}
+
+// end passes the recorded writes to the hook if the write batch is applied
+func (w *hookWrite) end(index uint64, applied bool) {
+	if w == nil || !applied {
+		return
+	}
+
+	mutations := make([]storage.Mutation, 0, len(w.wb.ops))
+	for _, op := range w.wb.ops {
+		m := storage.Mutation{Key: keysutil.DecodeDataKey(op.key)}
+		switch op.opType {
+		case mirrorSet:
+			m.Type = storage.MutationSet
+			m.Value = op.value
+		case mirrorDelete:
+			m.Type = storage.MutationDelete
+		case mirrorDeleteRange:
+			m.Type = storage.MutationDeleteRange
+			m.End = keysutil.DecodeDataKey(op.value)
+		}
+		mutations = append(mutations, m)
+	}
+	w.hook.OnCommit(index, mutations)
+}
@@ -0,0 +1,160 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fagongzi/util/protoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+// testCommitHook keeps an in-memory copy of the shard
+type testCommitHook struct {
+	data      map[string]string
+	lastIndex uint64
+	rebuilds  int
+	closed    bool
+}
+
+func (h *testCommitHook) OnCommit(index uint64, mutations []storage.Mutation) {
+	h.lastIndex = index
+	for _, m := range mutations {
+		switch m.Type {
+		case storage.MutationSet:
+			h.data[string(m.Key)] = string(m.Value)
+		case storage.MutationDelete:
+			delete(h.data, string(m.Key))
+		case storage.MutationDeleteRange:
+			for k := range h.data {
+				if bytes.Compare([]byte(k), m.Key) >= 0 &&
+					(len(m.End) == 0 || bytes.Compare([]byte(k), m.End) < 0) {
+					delete(h.data, k)
+				}
+			}
+		}
+	}
+}
+
+func (h *testCommitHook) Rebuild(shard metapb.Shard, scan func(handler func(key, value []byte) (bool, error)) error) error {
+	h.rebuilds++
+	h.data = make(map[string]string)
+	return scan(func(key, value []byte) (bool, error) {
+		h.data[string(key)] = string(value)
+		return true, nil
+	})
+}
+
+func (h *testCommitHook) Close() {
+	h.closed = true
+}
+
+func TestCommitHooks(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.GetTestFS()
+	defer vfs.ReportLeakedFD(fs, t)
+	base := NewBaseStorage(getTestPebbleStorage(t, fs), fs)
+	defer func() {
+		require.NoError(t, fs.RemoveAll(testDir))
+	}()
+
+	hooks := make(map[uint64]*testCommitHook)
+	factory := func(shard metapb.Shard) storage.CommitHook {
+		h := &testCommitHook{}
+		hooks[shard.ID] = h
+		return h
+	}
+	s := NewKVDataStorage(base, executor.NewKVExecutor(base), WithCommitHooks(factory))
+	defer s.Close()
+
+	index := uint64(1)
+	write := func(reqs ...storage.Request) {
+		index++
+		assert.NoError(t, s.Write(storage.NewSimpleWriteContext(1, base,
+			storage.Batch{Index: index, Requests: reqs})))
+	}
+	_, err := s.GetInitialStates()
+	require.NoError(t, err)
+	require.NoError(t, s.SaveShardMetadata([]metapb.ShardMetadata{{
+		ShardID:  1,
+		LogIndex: 1,
+		Metadata: metapb.ShardLocalState{Shard: metapb.Shard{ID: 1}},
+	}}))
+	// written before the hook is created
+	require.NoError(t, base.Set([]byte{1, 'k', '0'}, []byte("v0"), false))
+
+	// the hook is rebuilt before the first write
+	write(executor.NewWriteRequest([]byte("k1"), []byte("v1")),
+		executor.NewWriteRequest([]byte("k2"), []byte("v2")))
+	h := hooks[1]
+	require.NotNil(t, h)
+	assert.Equal(t, 1, h.rebuilds)
+	assert.Equal(t, uint64(2), h.lastIndex)
+	assert.Equal(t, map[string]string{"k0": "v0", "k1": "v1", "k2": "v2"}, h.data)
+
+	write(storage.Request{
+		CmdType: uint64(rpcpb.CmdKVDelete),
+		Key:     []byte("k2"),
+		Cmd:     protoc.MustMarshal(&rpcpb.KVDeleteRequest{Key: []byte("k2")}),
+	}, executor.NewWriteRequest([]byte("k3"), []byte("v3")))
+	assert.Equal(t, map[string]string{"k0": "v0", "k1": "v1", "k3": "v3"}, h.data)
+
+	write(storage.Request{
+		CmdType: uint64(rpcpb.CmdKVRangeDelete),
+		Cmd:     protoc.MustMarshal(&rpcpb.KVRangeDeleteRequest{Start: []byte("k1")}),
+	})
+	assert.Equal(t, map[string]string{"k0": "v0"}, h.data)
+	assert.Equal(t, uint64(4), h.lastIndex)
+
+	// the hooks are rebuilt on restart
+	write(executor.NewWriteRequest([]byte("k4"), []byte("v4")))
+	require.NoError(t, s.SaveShardMetadata([]metapb.ShardMetadata{{
+		ShardID:  1,
+		LogIndex: index,
+		Metadata: metapb.ShardLocalState{Shard: metapb.Shard{ID: 1}},
+	}}))
+	restarted := NewKVDataStorage(base, executor.NewKVExecutor(base), WithCommitHooks(factory))
+	_, err = restarted.GetInitialStates()
+	require.NoError(t, err)
+	assert.NotSame(t, h, hooks[1])
+	h = hooks[1]
+	assert.Equal(t, 1, h.rebuilds)
+	assert.Equal(t, map[string]string{"k0": "v0", "k4": "v4"}, h.data)
+
+	// split
+	news := []metapb.ShardMetadata{
+		{ShardID: 2, LogIndex: index, Metadata: metapb.ShardLocalState{Shard: metapb.Shard{ID: 2, End: []byte("k1")}}},
+		{ShardID: 3, LogIndex: index, Metadata: metapb.ShardLocalState{Shard: metapb.Shard{ID: 3, Start: []byte("k1")}}},
+	}
+	old := metapb.ShardMetadata{ShardID: 1, LogIndex: index,
+		Metadata: metapb.ShardLocalState{Shard: metapb.Shard{ID: 1}, State: metapb.ReplicaState_ReplicaTombstone}}
+	require.NoError(t, restarted.Split(old, news, nil))
+	assert.True(t, h.closed)
+	assert.Equal(t, map[string]string{"k0": "v0"}, hooks[2].data)
+	assert.Equal(t, map[string]string{"k4": "v4"}, hooks[3].data)
+
+	// remove
+	require.NoError(t, restarted.RemoveShard(news[0].Metadata.Shard, false))
+	assert.True(t, hooks[2].closed)
+	assert.False(t, hooks[3].closed)
+}
@@ -0,0 +1,688 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/btree"
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util"
+)
+
+const (
+	mirrorBTreeDegree = 32
+	// mirrorReadWindow the window of counting the reads of the shards
+	mirrorReadWindow = time.Second
+	// mirrorRetryInterval the interval of retrying to mirror the hot shard which
+	// was too large or out of the memory budget
+	mirrorRetryInterval = time.Minute
+)
+
+type mirrorItem struct {
+	key   []byte
+	value []byte
+}
+
+func (i *mirrorItem) Less(other btree.Item) bool {
+	return bytes.Compare(i.key, other.(*mirrorItem).key) < 0
+}
+
+func (i *mirrorItem) size() uint64 {
+	return uint64(len(i.key) + len(i.value))
+}
+
+type mirrorOpType int
+
+const (
+	mirrorSet mirrorOpType = iota
+	mirrorDelete
+	mirrorDeleteRange
+)
+
+// mirrorOp the write recorded for the mirror, the value is the end key of the
+// mirrorDeleteRange.
+type mirrorOp struct {
+	opType mirrorOpType
+	key    []byte
+	value  []byte
+}
+
+// shardMirror the in-memory copy of the data range of a shard. It's immutable,
+// a new mirror is created with the copy-on-write clone of the tree for each
+// applied write batch, so the reads use the mirror without locking and always
+// see the whole write batches.
+type shardMirror struct {
+	// shardStart and shardEnd the range of the shard when the mirror loaded
+	shardStart, shardEnd []byte
+	// start and end the encoded data range of the shard
+	start, end []byte
+	tree       *btree.BTree
+	bytes      uint64
+}
+
+// containsKey returns true if the key is in the range of the mirror
+func (m *shardMirror) containsKey(key []byte) bool {
+	return bytes.Compare(key, m.start) >= 0 && bytes.Compare(key, m.end) < 0
+}
+
+// containsRange returns true if [start, end) is in the range of the mirror, an
+// empty end means no upper bound.
+func (m *shardMirror) containsRange(start, end []byte) bool {
+	return len(end) > 0 && bytes.Compare(start, m.start) >= 0 &&
+		bytes.Compare(end, m.end) <= 0
+}
+
+// mirrorState the state of the mirror of a shard
+type mirrorState struct {
+	// retryAt the unix nanoseconds before which the shard is not mirrored again
+	retryAt     int64
+	windowStart int64
+	reads       uint64
+	lastReads   uint64
+	loading     uint32
+
+	// mu serializes the loading, the writes and the removal of the mirror
+	mu sync.Mutex
+	// mirror the *shardMirror, nil if the shard is not mirrored
+	mirror atomic.Value
+}
+
+func (st *mirrorState) getMirror() *shardMirror {
+	m, _ := st.mirror.Load().(*shardMirror)
+	return m
+}
+
+// recordRead returns the number of reads in the current window
+func (st *mirrorState) recordRead(now time.Time) uint64 {
+	start := atomic.LoadInt64(&st.windowStart)
+	if now.UnixNano()-start >= int64(mirrorReadWindow) &&
+		atomic.CompareAndSwapInt64(&st.windowStart, start, now.UnixNano()) {
+		atomic.StoreUint64(&st.lastReads, atomic.SwapUint64(&st.reads, 0))
+	}
+	return atomic.AddUint64(&st.reads, 1)
+}
+
+// isCold returns true if the shard was read less than the hotReads in both the
+// last window and the current window.
+func (st *mirrorState) isCold(now time.Time, hotReads uint64) bool {
+	elapsed := now.UnixNano() - atomic.LoadInt64(&st.windowStart)
+	switch {
+	case elapsed >= 2*int64(mirrorReadWindow):
+		return true
+	case elapsed >= int64(mirrorReadWindow):
+		return atomic.LoadUint64(&st.reads) < hotReads
+	default:
+		return atomic.LoadUint64(&st.lastReads) < hotReads &&
+			atomic.LoadUint64(&st.reads) < hotReads
+	}
+}
+
+// shardMirrors the in-memory mirrors of the small hot shards. A shard is
+// mirrored once it's read more than the hotReads in a second, and the writes of
+// the mirrored shards are applied to the mirrors after they are applied to the
+// base storage. The mirrors larger than the maxShardBytes are removed, and the
+// cold mirrors are evicted to mirror the hot shards once the total bytes would
+// exceed the maxBytes.
+type shardMirrors struct {
+	// bytes the total bytes of the mirrors
+	bytes         uint64
+	maxShardBytes uint64
+	maxBytes      uint64
+	hotReads      uint64
+	base          storage.KVBaseStorage
+	logger        *zap.Logger
+
+	mu     sync.RWMutex
+	states map[uint64]*mirrorState
+}
+
+// newShardMirrors returns nil if the mirror is disabled
+func newShardMirrors(base storage.KVBaseStorage, opts *options) *shardMirrors {
+	if opts.mirrorMaxBytes == 0 || opts.mirrorMaxShardBytes == 0 {
+		return nil
+	}
+	return &shardMirrors{
+		base:          base,
+		logger:        opts.logger,
+		maxShardBytes: opts.mirrorMaxShardBytes,
+		maxBytes:      opts.mirrorMaxBytes,
+		hotReads:      opts.mirrorHotReads,
+		states:        make(map[uint64]*mirrorState),
+	}
+}
+
+func (m *shardMirrors) getState(shardID uint64) *mirrorState {
+	m.mu.RLock()
+	st, ok := m.states[shardID]
+	m.mu.RUnlock()
+	if ok {
+		return st
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if st, ok := m.states[shardID]; ok {
+		return st
+	}
+	st = &mirrorState{}
+	m.states[shardID] = st
+	return st
+}
+
+// ttlActive returns true if any key with TTL is written, the mirrors are not
+// used since the expired keys are only filtered by the base storage.
+func (m *shardMirrors) ttlActive() bool {
+	b, ok := m.base.(*BaseStorage)
+	return ok && b.ttl.isActive()
+}
+
+// get returns the mirror serving the reads of the shard, nil if the shard is
+// not mirrored. The hot shard is mirrored before return if possible.
+func (m *shardMirrors) get(shard metapb.Shard, now time.Time) storage.KVStorage {
+	if m == nil {
+		return nil
+	}
+
+	st := m.getState(shard.ID)
+	reads := st.recordRead(now)
+	if mirror := st.getMirror(); mirror != nil {
+		if m.ttlActive() ||
+			!bytes.Equal(mirror.shardStart, shard.Start) ||
+			!bytes.Equal(mirror.shardEnd, shard.End) {
+			m.drop(shard.ID, true)
+			return nil
+		}
+		return &mirrorKVStorage{KVStorage: m.base, mirror: mirror}
+	}
+
+	if reads < m.hotReads ||
+		now.UnixNano() < atomic.LoadInt64(&st.retryAt) ||
+		m.ttlActive() ||
+		!atomic.CompareAndSwapUint32(&st.loading, 0, 1) {
+		return nil
+	}
+	defer atomic.StoreUint32(&st.loading, 0)
+
+	if atomic.LoadUint64(&m.bytes)+m.maxShardBytes > m.maxBytes {
+		m.evictCold(shard.ID, now)
+	}
+	if mirror := m.load(st, shard, now); mirror != nil {
+		return &mirrorKVStorage{KVStorage: m.base, mirror: mirror}
+	}
+	return nil
+}
+
+// load loads the data of the shard into the mirror, the writes of the shard are
+// blocked during the loading.
+func (m *shardMirrors) load(st *mirrorState, shard metapb.Shard, now time.Time) *shardMirror {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if mirror := st.getMirror(); mirror != nil {
+		return mirror
+	}
+
+	limit := m.maxShardBytes
+	if used := atomic.LoadUint64(&m.bytes); used >= m.maxBytes {
+		limit = 0
+	} else if left := m.maxBytes - used; left < limit {
+		limit = left
+	}
+	start, end := shardDataRange(shard)
+	tree := btree.New(mirrorBTreeDegree)
+	size := uint64(0)
+	tooLarge := false
+	err := m.base.Scan(start, end, func(key, value []byte) (bool, error) {
+		item := &mirrorItem{key: key, value: value}
+		size += item.size()
+		if size > limit {
+			tooLarge = true
+			return false, nil
+		}
+		tree.ReplaceOrInsert(item)
+		return true, nil
+	}, true)
+	if err != nil || tooLarge || !m.resize(0, size) {
+		atomic.StoreInt64(&st.retryAt, now.Add(mirrorRetryInterval).UnixNano())
+		m.logger.Debug("failed to mirror shard",
+			log.ShardIDField(shard.ID),
+			zap.Bool("too-large", tooLarge),
+			zap.Error(err))
+		return nil
+	}
+
+	mirror := &shardMirror{
+		shardStart: shard.Start,
+		shardEnd:   shard.End,
+		start:      start,
+		end:        end,
+		tree:       tree,
+		bytes:      size,
+	}
+	st.mirror.Store(mirror)
+	m.logger.Debug("shard mirrored",
+		log.ShardIDField(shard.ID),
+		zap.Uint64("bytes", size))
+	return mirror
+}
+
+// resize changes the bytes of a mirror from old to new, false is returned if it
+// exceeds the memory budget.
+func (m *shardMirrors) resize(old, new uint64) bool {
+	for {
+		used := atomic.LoadUint64(&m.bytes)
+		updated := used - old + new
+		if new > old && updated > m.maxBytes {
+			return false
+		}
+		if atomic.CompareAndSwapUint64(&m.bytes, used, updated) {
+			return true
+		}
+	}
+}
+
+// evictCold removes the mirrors of the cold shards except the specified one
+func (m *shardMirrors) evictCold(except uint64, now time.Time) {
+	var cold []uint64
+	m.mu.RLock()
+	for id, st := range m.states {
+		if id != except && st.getMirror() != nil && st.isCold(now, m.hotReads) {
+			cold = append(cold, id)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, id := range cold {
+		m.drop(id, false)
+	}
+}
+
+// drop removes the mirror of the shard, the shard is not mirrored again in the
+// mirrorRetryInterval if retry is false.
+func (m *shardMirrors) drop(shardID uint64, retry bool) {
+	if m == nil {
+		return
+	}
+
+	m.mu.RLock()
+	st, ok := m.states[shardID]
+	m.mu.RUnlock()
+	if !ok {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	m.dropLocked(st, retry, time.Now())
+}
+
+func (m *shardMirrors) dropLocked(st *mirrorState, retry bool, now time.Time) {
+	mirror := st.getMirror()
+	if mirror == nil {
+		return
+	}
+	st.mirror.Store((*shardMirror)(nil))
+	m.resize(mirror.bytes, 0)
+	if !retry {
+		atomic.StoreInt64(&st.retryAt, now.Add(mirrorRetryInterval).UnixNano())
+	}
+}
+
+// remove removes the mirror and the state of the removed shard
+func (m *shardMirrors) remove(shardID uint64) {
+	if m == nil {
+		return
+	}
+
+	m.drop(shardID, true)
+	m.mu.Lock()
+	delete(m.states, shardID)
+	m.mu.Unlock()
+}
+
+// beginWrite is called before the write batch of the shard is built, the
+// returned mirrorWrite must be ended after the write batch is applied.
+func (m *shardMirrors) beginWrite(ctx storage.WriteContext) *mirrorWrite {
+	if m == nil {
+		return nil
+	}
+
+	st := m.getState(ctx.Shard().ID)
+	st.mu.Lock()
+	w := &mirrorWrite{WriteContext: ctx, mirrors: m, st: st}
+	if st.getMirror() != nil {
+		w.wb = &mirrorWriteBatch{WriteBatch: ctx.WriteBatch().(util.WriteBatch)}
+	}
+	return w
+}
+
+// applyLocked applies the recorded writes to the mirror, the mirror is removed
+// if it's too large or out of the memory budget.
+func (m *shardMirrors) applyLocked(st *mirrorState, ops []mirrorOp) {
+	mirror := st.getMirror()
+	if mirror == nil || len(ops) == 0 {
+		return
+	}
+
+	tree := mirror.tree.Clone()
+	size := mirror.bytes
+	for _, op := range ops {
+		switch op.opType {
+		case mirrorSet:
+			if !mirror.containsKey(op.key) {
+				continue
+			}
+			item := &mirrorItem{key: op.key, value: op.value}
+			if old := tree.ReplaceOrInsert(item); old != nil {
+				size -= old.(*mirrorItem).size()
+			}
+			size += item.size()
+		case mirrorDelete:
+			if old := tree.Delete(&mirrorItem{key: op.key}); old != nil {
+				size -= old.(*mirrorItem).size()
+			}
+		case mirrorDeleteRange:
+			var deleted []btree.Item
+			tree.AscendRange(&mirrorItem{key: op.key}, &mirrorItem{key: op.value},
+				func(i btree.Item) bool {
+					deleted = append(deleted, i)
+					return true
+				})
+			for _, i := range deleted {
+				tree.Delete(i)
+				size -= i.(*mirrorItem).size()
+			}
+		}
+	}
+
+	if size > m.maxShardBytes || !m.resize(mirror.bytes, size) {
+		m.dropLocked(st, false, time.Now())
+		return
+	}
+	st.mirror.Store(&shardMirror{
+		shardStart: mirror.shardStart,
+		shardEnd:   mirror.shardEnd,
+		start:      mirror.start,
+		end:        mirror.end,
+		tree:       tree,
+		bytes:      size,
+	})
+}
+
+// mirrorWrite the write context recording the writes of the mirrored shard
+type mirrorWrite struct {
+	storage.WriteContext
+	mirrors *shardMirrors
+	st      *mirrorState
+	// wb is nil if the shard is not mirrored
+	wb *mirrorWriteBatch
+}
+
+// context returns the write context passed to the executor
+func (w *mirrorWrite) context(ctx storage.WriteContext) storage.WriteContext {
+	if w == nil || w.wb == nil {
+		return ctx
+	}
+	return w
+}
+
+func (w *mirrorWrite) WriteBatch() storage.Resetable {
+	return w.wb
+}
+
+// end applies the recorded writes to the mirror if the write batch is applied,
+// the mirror is removed otherwise.
+func (w *mirrorWrite) end(applied bool) {
+	if w == nil {
+		return
+	}
+
+	defer w.st.mu.Unlock()
+	if w.wb == nil {
+		return
+	}
+	if !applied {
+		w.mirrors.dropLocked(w.st, true, time.Now())
+		return
+	}
+	w.mirrors.applyLocked(w.st, w.wb.ops)
+}
+
+// mirrorWriteBatch records the writes added to the write batch
+type mirrorWriteBatch struct {
+	util.WriteBatch
+	ops []mirrorOp
+}
+
+func (wb *mirrorWriteBatch) add(opType mirrorOpType, key, value []byte) {
+	op := mirrorOp{opType: opType, key: append([]byte(nil), key...)}
+	if value != nil {
+		op.value = append([]byte{}, value...)
+	}
+	wb.ops = append(wb.ops, op)
+}
+
+func (wb *mirrorWriteBatch) Set(key, value []byte) {
+	wb.WriteBatch.Set(key, value)
+	wb.add(mirrorSet, key, value)
+}
+
+func (wb *mirrorWriteBatch) SetDeferred(keyLen, valueLen int, setter func(key, value []byte)) {
+	wb.WriteBatch.SetDeferred(keyLen, valueLen, func(key, value []byte) {
+		setter(key, value)
+		wb.add(mirrorSet, key, value)
+	})
+}
+
+func (wb *mirrorWriteBatch) Delete(key []byte) {
+	wb.WriteBatch.Delete(key)
+	wb.add(mirrorDelete, key, nil)
+}
+
+func (wb *mirrorWriteBatch) DeleteDeferred(keyLen int, setter func(key []byte)) {
+	wb.WriteBatch.DeleteDeferred(keyLen, func(key []byte) {
+		setter(key)
+		wb.add(mirrorDelete, key, nil)
+	})
+}
+
+func (wb *mirrorWriteBatch) DeleteRange(start, end []byte) {
+	wb.WriteBatch.DeleteRange(start, end)
+	wb.add(mirrorDeleteRange, start, end)
+}
+
+func (wb *mirrorWriteBatch) DeleteRangeDeferred(startLen, endLen int, setter func(start, end []byte)) {
+	wb.WriteBatch.DeleteRangeDeferred(startLen, endLen, func(start, end []byte) {
+		setter(start, end)
+		wb.add(mirrorDeleteRange, start, end)
+	})
+}
+
+func (wb *mirrorWriteBatch) SetIfAbsent(key, value []byte) {
+	wb.WriteBatch.SetIfAbsent(key, value)
+	wb.add(mirrorSet, key, value)
+}
+
+func (wb *mirrorWriteBatch) CompareAndSet(key, expected, value []byte) {
+	wb.WriteBatch.CompareAndSet(key, expected, value)
+	wb.add(mirrorSet, key, value)
+}
+
+func (wb *mirrorWriteBatch) Reset() {
+	wb.WriteBatch.Reset()
+	wb.ops = wb.ops[:0]
+}
+
+// mirrorView the view of the mirror, the view of the base storage is only
+// created if it's used by the base storage.
+type mirrorView struct {
+	kv   storage.KVStorage
+	tree *btree.BTree
+	view storage.View
+}
+
+func (v *mirrorView) Raw() interface{} {
+	if v.view == nil {
+		v.view = v.kv.GetView()
+	}
+	return v.view.Raw()
+}
+
+func (v *mirrorView) Close() error {
+	if v.view == nil {
+		return nil
+	}
+	return v.view.Close()
+}
+
+// mirrorKVStorage serves the reads in the range of the mirror by the mirror,
+// the other reads and the writes are served by the base storage.
+type mirrorKVStorage struct {
+	storage.KVStorage
+	mirror *shardMirror
+}
+
+var _ storage.KVStorage = (*mirrorKVStorage)(nil)
+
+func (s *mirrorKVStorage) GetView() storage.View {
+	return &mirrorView{kv: s.KVStorage, tree: s.mirror.tree}
+}
+
+func (s *mirrorKVStorage) Get(key []byte) ([]byte, error) {
+	if !s.mirror.containsKey(key) {
+		return s.KVStorage.Get(key)
+	}
+	if v := getInTree(s.mirror.tree, key); len(v) > 0 {
+		return append([]byte(nil), v...), nil
+	}
+	return nil, nil
+}
+
+func (s *mirrorKVStorage) GetWithFunc(key []byte, fn func(value []byte) error) error {
+	if !s.mirror.containsKey(key) {
+		return s.KVStorage.GetWithFunc(key, fn)
+	}
+	if item := s.mirror.tree.Get(&mirrorItem{key: key}); item != nil {
+		return fn(item.(*mirrorItem).value)
+	}
+	return nil
+}
+
+func (s *mirrorKVStorage) MultiGet(keys [][]byte) ([][]byte, error) {
+	for _, key := range keys {
+		if !s.mirror.containsKey(key) {
+			return s.KVStorage.MultiGet(keys)
+		}
+	}
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		if v := getInTree(s.mirror.tree, key); len(v) > 0 {
+			values[i] = append([]byte(nil), v...)
+		}
+	}
+	return values, nil
+}
+
+func (s *mirrorKVStorage) Scan(start, end []byte,
+	handler func(key, value []byte) (bool, error), clone bool) error {
+	if !s.mirror.containsRange(start, end) {
+		return s.KVStorage.Scan(start, end, handler, clone)
+	}
+	return scanTree(s.mirror.tree, start, end, false, handler, clone)
+}
+
+func (s *mirrorKVStorage) ScanInView(view storage.View, start, end []byte,
+	handler func(key, value []byte) (bool, error), clone bool) error {
+	v, ok := view.(*mirrorView)
+	if !ok || !s.mirror.containsRange(start, end) {
+		return s.KVStorage.ScanInView(view, start, end, handler, clone)
+	}
+	return scanTree(v.tree, start, end, false, handler, clone)
+}
+
+func (s *mirrorKVStorage) ScanReverse(start, end []byte,
+	handler func(key, value []byte) (bool, error), clone bool) error {
+	if !s.mirror.containsRange(start, end) {
+		return s.KVStorage.ScanReverse(start, end, handler, clone)
+	}
+	return scanTree(s.mirror.tree, start, end, true, handler, clone)
+}
+
+func (s *mirrorKVStorage) ScanReverseInView(view storage.View, start, end []byte,
+	handler func(key, value []byte) (bool, error), clone bool) error {
+	v, ok := view.(*mirrorView)
+	if !ok || !s.mirror.containsRange(start, end) {
+		return s.KVStorage.ScanReverseInView(view, start, end, handler, clone)
+	}
+	return scanTree(v.tree, start, end, true, handler, clone)
+}
+
+// ScanPage scans a page of [start, end), see storage.PageScan.
+func (s *mirrorKVStorage) ScanPage(start, end []byte, limit uint64,
+	opts storage.ScanPageOptions) ([]storage.KeyValue, []byte, error) {
+	return storage.PageScan(s, start, end, limit, opts)
+}
+
+// NewIterator returns the Iterator over [start, end) read by ScanPage.
+func (s *mirrorKVStorage) NewIterator(start, end []byte, opts storage.ScanPageOptions) storage.Iterator {
+	return storage.NewPageIterator(s, start, end, opts)
+}
+
+func getInTree(tree *btree.BTree, key []byte) []byte {
+	if item := tree.Get(&mirrorItem{key: key}); item != nil {
+		return item.(*mirrorItem).value
+	}
+	return nil
+}
+
+// scanTree scans the items in [start, end) of the tree, the items are never
+// modified so they are only copied if clone is true.
+func scanTree(tree *btree.BTree, start, end []byte, reverse bool,
+	handler func(key, value []byte) (bool, error), clone bool) error {
+	var err error
+	fn := func(i btree.Item) bool {
+		item := i.(*mirrorItem)
+		key, value := item.key, item.value
+		if clone {
+			key = append([]byte(nil), key...)
+			value = append([]byte(nil), value...)
+		}
+		var next bool
+		next, err = handler(key, value)
+		return err == nil && next
+	}
+	if !reverse {
+		tree.AscendRange(&mirrorItem{key: start}, &mirrorItem{key: end}, fn)
+		return err
+	}
+	// DescendRange is (greaterThan, lessOrEqual], the end is excluded and the
+	// start is included here.
+	tree.DescendLessOrEqual(&mirrorItem{key: end}, func(i btree.Item) bool {
+		item := i.(*mirrorItem)
+		if bytes.Compare(item.key, end) >= 0 {
+			return true
+		}
+		if bytes.Compare(item.key, start) < 0 {
+			return false
+		}
+		return fn(i)
+	})
+	return err
+}
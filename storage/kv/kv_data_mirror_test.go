@@ -0,0 +1,164 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fagongzi/util/protoc"
+	"github.com/google/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/pb/rpcpb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/executor"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+func TestShardMirror(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.GetTestFS()
+	defer vfs.ReportLeakedFD(fs, t)
+	base := NewBaseStorage(getTestPebbleStorage(t, fs), fs)
+	s := NewKVDataStorage(base, executor.NewKVExecutor(base), WithShardMirror(64, 1024, 2))
+	defer func() {
+		require.NoError(t, fs.RemoveAll(testDir))
+	}()
+	defer s.Close()
+	kvd := s.(*kvDataStorage)
+
+	index := uint64(0)
+	write := func(reqs ...storage.Request) {
+		index++
+		assert.NoError(t, s.Write(storage.NewSimpleWriteContext(1, base,
+			storage.Batch{Index: index, Requests: reqs})))
+	}
+	get := func(key string) string {
+		v, err := s.Read(storage.NewSimpleReadContext(1, executor.NewReadRequest([]byte(key))))
+		assert.NoError(t, err)
+		var resp rpcpb.KVGetResponse
+		protoc.MustUnmarshal(&resp, v)
+		return string(resp.Value)
+	}
+	mirrored := func() *shardMirror {
+		return kvd.mirrors.getState(1).getMirror()
+	}
+
+	write(executor.NewWriteRequest([]byte("k1"), []byte("v1")),
+		executor.NewWriteRequest([]byte("k2"), []byte("v2")))
+	assert.Equal(t, "v1", get("k1"))
+	assert.Nil(t, mirrored())
+	assert.Equal(t, "v1", get("k1"))
+	require.NotNil(t, mirrored(), "the hot shard is mirrored")
+	assert.Equal(t, uint64(10), kvd.mirrors.bytes)
+
+	// the reads are served by the mirror, which is changed by the applied writes
+	// only
+	assert.NoError(t, base.Set(keysutil.EncodeDataKey([]byte("k1"), nil), []byte("x"), false))
+	assert.Equal(t, "v1", get("k1"))
+	write(executor.NewWriteRequest([]byte("k1"), []byte("v3")),
+		storage.Request{
+			CmdType: uint64(rpcpb.CmdKVDelete),
+			Key:     []byte("k2"),
+			Cmd:     protoc.MustMarshal(&rpcpb.KVDeleteRequest{Key: []byte("k2")}),
+		})
+	assert.Equal(t, "v3", get("k1"))
+	assert.Equal(t, "", get("k2"))
+	assert.Equal(t, uint64(5), kvd.mirrors.bytes)
+
+	// too large
+	write(executor.NewWriteRequest([]byte("k3"), make([]byte, 64)))
+	assert.Nil(t, mirrored())
+	assert.Equal(t, uint64(0), kvd.mirrors.bytes)
+	assert.Equal(t, 64, len(get("k3")))
+
+	require.NoError(t, s.RemoveShard(metapb.Shard{ID: 1}, false))
+	assert.Empty(t, kvd.mirrors.states)
+}
+
+func TestShardMirrorEvictCold(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.GetTestFS()
+	defer vfs.ReportLeakedFD(fs, t)
+	base := NewBaseStorage(getTestPebbleStorage(t, fs), fs)
+	s := NewKVDataStorage(base, executor.NewKVExecutor(base), WithShardMirror(16, 24, 1))
+	defer func() {
+		require.NoError(t, fs.RemoveAll(testDir))
+	}()
+	defer s.Close()
+	kvd := s.(*kvDataStorage)
+
+	shard1 := metapb.Shard{ID: 1, End: []byte("b")}
+	shard2 := metapb.Shard{ID: 2, Start: []byte("b")}
+	for _, key := range []string{"a1", "a2", "b1", "b2"} {
+		require.NoError(t, base.Set(keysutil.EncodeDataKey([]byte(key), nil), []byte("value"), false))
+	}
+
+	now := time.Now()
+	assert.NotNil(t, kvd.mirrors.get(shard1, now))
+	assert.Equal(t, uint64(16), kvd.mirrors.bytes)
+	// out of the memory budget, the shard 1 is still hot
+	assert.Nil(t, kvd.mirrors.get(shard2, now))
+	assert.NotNil(t, kvd.mirrors.get(shard1, now))
+
+	// the shard 1 is cold and evicted
+	now = now.Add(mirrorRetryInterval * 2)
+	assert.NotNil(t, kvd.mirrors.get(shard2, now))
+	assert.Nil(t, kvd.mirrors.getState(1).getMirror())
+	assert.Equal(t, uint64(16), kvd.mirrors.bytes)
+
+	// the mirror is removed once the range of the shard changed
+	shard2.Start = []byte("b2")
+	assert.Nil(t, kvd.mirrors.get(shard2, now))
+	assert.NotNil(t, kvd.mirrors.get(shard2, now))
+	assert.Equal(t, uint64(8), kvd.mirrors.bytes)
+}
+
+func TestMirrorKVStorageScan(t *testing.T) {
+	tree := btree.New(mirrorBTreeDegree)
+	for _, key := range []string{"a", "b", "c", "d"} {
+		tree.ReplaceOrInsert(&mirrorItem{key: []byte(key), value: []byte(key)})
+	}
+	s := &mirrorKVStorage{mirror: &shardMirror{start: []byte("a"), end: []byte("e"), tree: tree}}
+
+	scan := func(reverse bool, start, end string) string {
+		var keys []byte
+		handler := func(key, value []byte) (bool, error) {
+			keys = append(keys, key...)
+			return len(keys) < 3, nil
+		}
+		if reverse {
+			assert.NoError(t, s.ScanReverse([]byte(start), []byte(end), handler, false))
+		} else {
+			assert.NoError(t, s.Scan([]byte(start), []byte(end), handler, true))
+		}
+		return string(keys)
+	}
+	assert.Equal(t, "bc", scan(false, "b", "d"))
+	assert.Equal(t, "abc", scan(false, "a", "e"))
+	assert.Equal(t, "cb", scan(true, "b", "d"))
+	assert.Equal(t, "dcb", scan(true, "a", "e"))
+
+	v, err := s.Get([]byte("c"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("c"), v)
+	values, err := s.MultiGet([][]byte{[]byte("a"), []byte("b1")})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("a"), nil}, values)
+}
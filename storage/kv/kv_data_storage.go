@@ -15,12 +15,15 @@ package kv
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"math"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/cockroachdb/errors"
 	"github.com/fagongzi/util/protoc"
 	"github.com/matrixorigin/matrixcube/components/log"
 	"github.com/matrixorigin/matrixcube/keys"
@@ -35,15 +38,36 @@ import (
 
 var (
 	mb = uint64(1024 * 1024)
+
+	defaultSampleSync            = uint64(100)
+	defaultWALDisabledSampleSync = uint64(10000)
+	// splitCheckCancelKeys the number of keys scanned between checking whether
+	// the split check is canceled
+	splitCheckCancelKeys = uint64(256)
+	// defaultMirrorHotReads the reads per second of the hot shards to mirror
+	defaultMirrorHotReads = uint64(1000)
+
+	// ErrWALDisabledGroup the batch of the shard is written to the kv data
+	// storage whose WAL is disabled for another group
+	ErrWALDisabledGroup = errors.New("wal disabled for another group")
 )
 
 // Option option func
 type Option func(*options)
 
 type options struct {
-	sampleSync uint64
-	logger     *zap.Logger
-	feature    storage.Feature
+	sampleSync       uint64
+	walDisabled      bool
+	walDisabledGroup uint64
+	gcInterval       time.Duration
+	logger           *zap.Logger
+	feature          storage.Feature
+
+	mirrorMaxShardBytes uint64
+	mirrorMaxBytes      uint64
+	mirrorHotReads      uint64
+
+	commitHookFactory storage.CommitHookFactory
 }
 
 // WithSampleSync set sync sample interval. `Cube` will call the `GetPersistentLogIndex` method of `DataStorage` to obtain
@@ -56,6 +80,33 @@ func WithSampleSync(value uint64) Option {
 	}
 }
 
+// WithWALDisabled indicates the kv data storage is dedicated to the shards of
+// the group, and the raft log is used as the WAL of their writes. The pebble
+// WAL can not be skipped per write batch, so the base storage must be dedicated
+// to the group too and opened with the WAL disabled, e.g. the pebble storage
+// with `DisableWAL`, the other groups keep their WAL in their own base storage.
+// The batches of the other groups are rejected by ErrWALDisabledGroup.
+//
+// The written data is persistent only after the base storage synced, so the
+// raft log entries since the last sync are not compacted and they are applied
+// again after restart. The sync is expensive without the WAL, the default
+// sample sync interval is increased to trade recovery time for write
+// amplification.
+func WithWALDisabled(group uint64) Option {
+	return func(opts *options) {
+		opts.walDisabled = true
+		opts.walDisabledGroup = group
+	}
+}
+
+// WithGCInterval set the interval of removing the data ranges of the destroyed
+// shards in background, one range is removed and compacted every interval.
+func WithGCInterval(value time.Duration) Option {
+	return func(opts *options) {
+		opts.gcInterval = value
+	}
+}
+
 // WithLogger set logger
 func WithLogger(logger *zap.Logger) Option {
 	return func(opts *options) {
@@ -70,13 +121,49 @@ func WithFeature(feature storage.Feature) Option {
 	}
 }
 
+// WithShardMirror enables the in-memory mirrors of the small hot shards, the
+// shards read more than hotReads times per second are mirrored if they are not
+// larger than maxShardBytes. The mirrors are maintained by the applied writes
+// and the reads of the mirrored shards are served without touching the base
+// storage. The mirrors of the cold shards are evicted to keep the total bytes of
+// the mirrors under maxBytes. The mirrors are not used once any key is written
+// with TTL, since the direct writes of the base storage bypass the mirrors.
+func WithShardMirror(maxShardBytes, maxBytes, hotReads uint64) Option {
+	return func(opts *options) {
+		opts.mirrorMaxShardBytes = maxShardBytes
+		opts.mirrorMaxBytes = maxBytes
+		opts.mirrorHotReads = hotReads
+	}
+}
+
+// WithCommitHooks set the factory of the CommitHooks of the shards, the hooks
+// see the writes made by the executor in the Write. The writes of the
+// TransactionalDataStorage methods and the expiration of the keys written with
+// TTL are not seen by the hooks.
+func WithCommitHooks(factory storage.CommitHookFactory) Option {
+	return func(opts *options) {
+		opts.commitHookFactory = factory
+	}
+}
+
 func newOptions() *options {
 	return &options{}
 }
 
 func (opts *options) adjust() {
 	if opts.sampleSync == 0 {
-		opts.sampleSync = 100
+		opts.sampleSync = defaultSampleSync
+		if opts.walDisabled {
+			opts.sampleSync = defaultWALDisabledSampleSync
+		}
+	}
+
+	if opts.gcInterval == 0 {
+		opts.gcInterval = defaultGCInterval
+	}
+
+	if opts.mirrorHotReads == 0 {
+		opts.mirrorHotReads = defaultMirrorHotReads
 	}
 
 	if opts.feature.ShardSplitCheckDuration == 0 {
@@ -91,6 +178,10 @@ func (opts *options) adjust() {
 		opts.feature.ShardSplitCheckBytes = opts.feature.ShardCapacityBytes * 80 / 100
 	}
 
+	if opts.feature.ShardSplitCheckApproximateBytes == 0 {
+		opts.feature.ShardSplitCheckApproximateBytes = opts.feature.ShardCapacityBytes
+	}
+
 	if opts.feature.ForceCompactCount == 0 {
 		opts.feature.ForceCompactCount = opts.feature.ShardCapacityBytes * mb * 3 / 4 / 1024
 	}
@@ -106,7 +197,12 @@ type kvDataStorage struct {
 	opts       *options
 	base       storage.KVBaseStorage
 	executor   storage.Executor
+	gc         *shardGC
 	writeCount uint64
+	// mirrors the mirrors of the hot shards, nil if disabled
+	mirrors *shardMirrors
+	// hooks the commit hooks of the shards, nil if disabled
+	hooks *commitHooks
 
 	mu struct {
 		sync.RWMutex
@@ -118,6 +214,11 @@ type kvDataStorage struct {
 
 var _ storage.DataStorage = (*kvDataStorage)(nil)
 var _ storage.KVStorageWrapper = (*kvDataStorage)(nil)
+var _ storage.ContextSplitChecker = (*kvDataStorage)(nil)
+var _ storage.KeySampler = (*kvDataStorage)(nil)
+var _ storage.ShardCompactor = (*kvDataStorage)(nil)
+var _ storage.ShardSizeEstimator = (*kvDataStorage)(nil)
+var _ storage.ShardBackuper = (*kvDataStorage)(nil)
 
 // NewKVDataStorage returns data storage based on a kv base storage.
 func NewKVDataStorage(base storage.KVBaseStorage,
@@ -131,6 +232,12 @@ func NewKVDataStorage(base storage.KVBaseStorage,
 		opt(s.opts)
 	}
 	s.opts.adjust()
+	if isWALDisabled(base) && !s.opts.walDisabled {
+		panic("the WAL of the base storage is disabled, WithWALDisabled is required")
+	}
+	s.gc = newShardGC(base, s.opts.logger, s.opts.gcInterval)
+	s.mirrors = newShardMirrors(base, s.opts)
+	s.hooks = newCommitHooks(base, s.opts)
 
 	s.mu.lastAppliedIndexes = make(map[uint64]uint64)
 	s.mu.persistentAppliedIndexes = make(map[uint64]uint64)
@@ -150,27 +257,49 @@ func (kv *kvDataStorage) Write(ctx storage.WriteContext) error {
 	if batch.Index == 0 {
 		panic("empty batch?")
 	}
+	if err := kv.checkWALDisabledGroup(ctx.Shard().Group); err != nil {
+		return err
+	}
 
 	// append data key
 	for idx := range batch.Requests {
 		batch.Requests[idx].Key = keysutil.EncodeDataKey(batch.Requests[idx].Key, ctx.(storage.InternalContext).ByteBuf())
 	}
-	if err := kv.executor.UpdateWriteBatch(ctx); err != nil {
+	// the writes of the mirrored shard are recorded and applied to the mirror
+	// once they are applied to the base storage
+	mw := kv.mirrors.beginWrite(ctx)
+	wctx := mw.context(ctx)
+	// so are the writes of the shards with the commit hooks
+	hw, err := kv.hooks.beginWrite(wctx)
+	if err != nil {
+		mw.end(false)
+		return err
+	}
+	if err := kv.executor.UpdateWriteBatch(hw.context(wctx)); err != nil {
+		mw.end(false)
 		return err
 	}
 	r := ctx.WriteBatch()
 	defer r.Reset()
 
 	kv.setAppliedIndexToWriteBatch(ctx, batch.Index)
-	kv.updateAppliedIndex(ctx.Shard().ID, batch.Index)
-	if err := kv.executor.ApplyWriteBatch(r); err != nil {
+	err = kv.executor.ApplyWriteBatch(r)
+	mw.end(err == nil)
+	hw.end(batch.Index, err == nil)
+	if err != nil {
+		// the batch is not applied, e.g. the disk is full, the applied index is
+		// kept so the batch can be applied again
 		return err
 	}
+	kv.updateAppliedIndex(ctx.Shard().ID, batch.Index)
 	return kv.trySync()
 }
 
 func (kv *kvDataStorage) Read(ctx storage.ReadContext) ([]byte, error) {
-	return kv.executor.Read(readContext{base: ctx})
+	return kv.executor.Read(readContext{
+		base:   ctx,
+		mirror: kv.mirrors.get(ctx.Shard(), time.Now()),
+	})
 }
 
 func (kv *kvDataStorage) SaveShardMetadata(metadatas []metapb.ShardMetadata) error {
@@ -178,6 +307,12 @@ func (kv *kvDataStorage) SaveShardMetadata(metadatas []metapb.ShardMetadata) err
 	wb := r.(util.WriteBatch)
 	defer wb.Close()
 
+	for _, m := range metadatas {
+		if err := kv.checkWALDisabledGroup(m.Metadata.Shard.Group); err != nil {
+			return err
+		}
+	}
+
 	seen := make(map[uint64]struct{})
 	kv.mu.Lock()
 	for _, m := range metadatas {
@@ -209,6 +344,10 @@ func (kv *kvDataStorage) SaveShardMetadata(metadatas []metapb.ShardMetadata) err
 func (kv *kvDataStorage) GetInitialStates() ([]metapb.ShardMetadata, error) {
 	// TODO: this assumes that all shards have applied index records saved.
 	// double check to make sure this is actually true.
+	// the data ranges of the shards destroyed before restart are removed again
+	if err := kv.gc.load(); err != nil {
+		return nil, err
+	}
 	min := keysutil.EncodeShardMetadataKey(keys.GetAppliedIndexKey(0, nil), nil)
 	max := keysutil.EncodeShardMetadataKey(keys.GetAppliedIndexKey(math.MaxUint64, nil), nil)
 	var shards []uint64
@@ -240,40 +379,55 @@ func (kv *kvDataStorage) GetInitialStates() ([]metapb.ShardMetadata, error) {
 	// for each shard,
 	var values []metapb.ShardMetadata
 	for _, shard := range shards {
-		min := keysutil.EncodeShardMetadataKey(keys.GetMetadataKey(shard, 0, nil), nil)
-		max := keysutil.EncodeShardMetadataKey(keys.GetMetadataKey(shard, math.MaxUint64, nil), nil)
-		var v []byte
-		var logIndex uint64
-		var err error
-		if err := kv.base.Scan(min, max, func(key, value []byte) (bool, error) {
-			key = key[1:]
-			if keys.IsMetadataKey(key) {
-				v = value
-				logIndex, err = keys.GetMetadataIndex(key)
-				if err != nil {
-					panic(err)
-				}
-			} else {
-				panic("unexpected key/value")
-			}
-			return true, nil
-		}, true); err != nil {
+		sm, err := kv.getShardMetadata(shard)
+		if err != nil {
 			return nil, err
 		}
-
-		if v == nil && logIndex == 0 {
-			panic("failed to get shard metadata")
+		// the commit hooks are rebuilt from the persisted data, the raft logs
+		// replayed later are seen by the hooks as usual
+		if sm.Metadata.State != metapb.ReplicaState_ReplicaTombstone {
+			if err := kv.hooks.rebuild(sm.Metadata.Shard); err != nil {
+				return nil, err
+			}
 		}
+		values = append(values, sm)
+	}
+	return values, nil
+}
 
-		sm := metapb.ShardMetadata{}
-		protoc.MustUnmarshal(&sm, v)
-		if sm.LogIndex != logIndex {
-			panic(fmt.Sprintf("LogIndex not match, expect %d, but %d", logIndex, sm.LogIndex))
+// getShardMetadata returns the latest metadata of the shard
+func (kv *kvDataStorage) getShardMetadata(shard uint64) (metapb.ShardMetadata, error) {
+	min := keysutil.EncodeShardMetadataKey(keys.GetMetadataKey(shard, 0, nil), nil)
+	max := keysutil.EncodeShardMetadataKey(keys.GetMetadataKey(shard, math.MaxUint64, nil), nil)
+	var v []byte
+	var logIndex uint64
+	var err error
+	if err := kv.base.Scan(min, max, func(key, value []byte) (bool, error) {
+		key = key[1:]
+		if keys.IsMetadataKey(key) {
+			v = value
+			logIndex, err = keys.GetMetadataIndex(key)
+			if err != nil {
+				panic(err)
+			}
+		} else {
+			panic("unexpected key/value")
 		}
+		return true, nil
+	}, true); err != nil {
+		return metapb.ShardMetadata{}, err
+	}
 
-		values = append(values, sm)
+	if v == nil && logIndex == 0 {
+		panic("failed to get shard metadata")
 	}
-	return values, nil
+
+	sm := metapb.ShardMetadata{}
+	protoc.MustUnmarshal(&sm, v)
+	if sm.LogIndex != logIndex {
+		panic(fmt.Sprintf("LogIndex not match, expect %d, but %d", logIndex, sm.LogIndex))
+	}
+	return sm, nil
 }
 
 // TODO: handle shardID not found error, maybe define ShardNotFound?
@@ -288,25 +442,16 @@ func (kv *kvDataStorage) GetPersistentLogIndex(shardID uint64) (uint64, error) {
 }
 
 func (kv *kvDataStorage) Sync(_ []uint64) error {
-	if err := kv.base.Sync(); err != nil {
-		return err
-	}
-
-	kv.updatePersistentAppliedIndexes()
-	return nil
+	return kv.sync()
 }
 
+// RemoveShard removes the metadata of the shard, the data of the shard is
+// removed in background, see shardGC.
 func (kv *kvDataStorage) RemoveShard(shard metapb.Shard, removeData bool) error {
 	// This is not an atomic operation, but it is idempotent, and the metadata is
-	// deleted afterwards, so the cleanup will not be lost.
+	// deleted after the data range is queued, so the cleanup will not be lost.
 	if removeData {
-		min := keysutil.EncodeShardStart(shard.Start, nil)
-		max := keysutil.EncodeShardEnd(shard.End, nil)
-		kv.opts.logger.Debug("remove shard data",
-			log.ShardField("shard", shard),
-			log.HexField("from", min),
-			log.HexField("to", max))
-		if err := kv.base.RangeDelete(min, max, false); err != nil {
+		if err := kv.gc.add(shard); err != nil {
 			return err
 		}
 	}
@@ -317,13 +462,84 @@ func (kv *kvDataStorage) RemoveShard(shard metapb.Shard, removeData bool) error
 	delete(kv.mu.lastAppliedIndexes, shard.ID)
 	delete(kv.mu.persistentAppliedIndexes, shard.ID)
 	kv.mu.Unlock()
+	kv.mirrors.remove(shard.ID)
+	kv.hooks.remove(shard.ID)
 	return kv.base.RangeDelete(min, max, false)
 }
 
 // SplitCheck find keys from [start, end), so that the sum of bytes of the
 // value of [start, key) <=size, returns the current bytes in [start,end),
-// and the founded keys.
+// and the founded keys. The split keys of the large shards are estimated by
+// the metadata of the base storage if it's a storage.SizeEstimator, to avoid
+// reading all the data of the shard.
 func (kv *kvDataStorage) SplitCheck(shard metapb.Shard,
+	size uint64) (uint64, uint64, [][]byte, []byte, error) {
+	return kv.SplitCheckWithContext(context.Background(), shard, size)
+}
+
+// SplitCheckWithContext is SplitCheck aborted once the ctx is done, the ctx is
+// checked every splitCheckCancelKeys keys while scanning the shard.
+func (kv *kvDataStorage) SplitCheckWithContext(ctx context.Context, shard metapb.Shard,
+	size uint64) (uint64, uint64, [][]byte, []byte, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, nil, nil, err
+	}
+	start := keysutil.EncodeShardStart(shard.Start, nil)
+	end := keysutil.EncodeShardEnd(shard.End, nil)
+	total, keys, splitKeys, ok, err := kv.approximateSplitCheck(shard, start, end, size)
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+	if ok {
+		return total, keys, splitKeys, nil, nil
+	}
+	return kv.scanSplitCheck(ctx, shard, start, end, size)
+}
+
+// approximateSplitCheck estimates the split keys of [start, end), ok is false
+// if the estimated size is less than ShardSplitCheckApproximateBytes or no split
+// key is estimated for a shard which should be split, the shard needs to be
+// scanned then.
+func (kv *kvDataStorage) approximateSplitCheck(shard metapb.Shard, start, end []byte,
+	size uint64) (uint64, uint64, [][]byte, bool, error) {
+	total, keys, err := kv.base.EstimateSize(start, end)
+	if err == storage.ErrEstimateNotSupported {
+		return 0, 0, nil, false, nil
+	}
+	if err != nil {
+		return 0, 0, nil, false, err
+	}
+	if total < kv.opts.feature.ShardSplitCheckApproximateBytes {
+		return 0, 0, nil, false, nil
+	}
+
+	estimated, err := kv.base.EstimateSplitKeys(start, end, size)
+	if err != nil {
+		return 0, 0, nil, false, err
+	}
+	var splitKeys [][]byte
+	last := start
+	for _, key := range estimated {
+		splitKey := kv.opts.feature.AdjustSplitKey(shard, key[1:])
+		if splitKey == nil {
+			continue
+		}
+		// the adjusted split keys must be still in order and within the shard
+		encoded := keysutil.EncodeDataKey(splitKey, nil)
+		if bytes.Compare(encoded, last) <= 0 || bytes.Compare(encoded, end) >= 0 {
+			continue
+		}
+		splitKeys = append(splitKeys, keysutil.Clone(splitKey))
+		last = encoded
+	}
+	if len(splitKeys) == 0 && total >= size {
+		return 0, 0, nil, false, nil
+	}
+	return total, keys, splitKeys, true, nil
+}
+
+// scanSplitCheck finds the split keys of [start, end) by scanning all the data.
+func (kv *kvDataStorage) scanSplitCheck(ctx context.Context, shard metapb.Shard, start, end []byte,
 	size uint64) (uint64, uint64, [][]byte, []byte, error) {
 	total := uint64(0)
 	keys := uint64(0)
@@ -331,25 +547,30 @@ func (kv *kvDataStorage) SplitCheck(shard metapb.Shard,
 	appendSplitKey := false
 	var splitKeys [][]byte
 
-	view := kv.base.GetView()
-	start := keysutil.EncodeShardStart(shard.Start, nil)
-	end := keysutil.EncodeShardEnd(shard.End, nil)
+	view, err := storage.GetBackgroundView(kv.base)
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+	defer view.Close()
 	if err := kv.base.ScanInViewWithOptions(view, start, end, func(key, val []byte) (storage.NextIterOptions, error) {
 		opts := storage.NextIterOptions{}
+		if keys%splitCheckCancelKeys == 0 {
+			if err := ctx.Err(); err != nil {
+				return opts, err
+			}
+		}
 		if appendSplitKey {
-			var realSplitKey []byte
-			if kv.opts.feature.SplitKeyAdjustFunc == nil {
-				realSplitKey = keysutil.Clone(key[1:])
-			} else {
-				realSplitKey = keysutil.Clone(kv.opts.feature.SplitKeyAdjustFunc(key[1:]))
+			// the shard can't be split around the key, try the next key
+			if realSplitKey := kv.opts.feature.AdjustSplitKey(shard, key[1:]); realSplitKey != nil {
+				realSplitKey = keysutil.Clone(realSplitKey)
 				// split key changed
 				if !bytes.Equal(realSplitKey, key[1:]) {
 					opts.SeekGE = keysutil.NextKey(keysutil.EncodeDataKey(realSplitKey, nil), nil)
 				}
+				splitKeys = append(splitKeys, realSplitKey)
+				appendSplitKey = false
+				sum = 0
 			}
-			splitKeys = append(splitKeys, realSplitKey)
-			appendSplitKey = false
-			sum = 0
 		}
 		n := uint64(len(key[1:]) + len(val))
 		sum += n
@@ -368,7 +589,17 @@ func (kv *kvDataStorage) SplitCheck(shard metapb.Shard,
 
 func (kv *kvDataStorage) Split(old metapb.ShardMetadata,
 	news []metapb.ShardMetadata, ctx []byte) error {
-	return kv.SaveShardMetadata(append(news, old))
+	kv.mirrors.drop(old.ShardID, true)
+	if err := kv.SaveShardMetadata(append(news, old)); err != nil {
+		return err
+	}
+	kv.hooks.remove(old.ShardID)
+	for _, sm := range news {
+		if err := kv.hooks.rebuild(sm.Metadata.Shard); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (kv *kvDataStorage) Feature() storage.Feature {
@@ -391,6 +622,25 @@ func (kv *kvDataStorage) updateAppliedIndex(shardID uint64, index uint64) {
 	kv.mu.lastAppliedIndexes[shardID] = index
 }
 
+// checkWALDisabledGroup makes sure the shards of the other groups never write
+// to the storage whose WAL is disabled for the group, they would silently lose
+// the WAL durability.
+func (kv *kvDataStorage) checkWALDisabledGroup(group uint64) error {
+	if kv.opts.walDisabled && group != kv.opts.walDisabledGroup {
+		return ErrWALDisabledGroup
+	}
+	return nil
+}
+
+// isWALDisabled returns true if the base storage is opened with the WAL
+// disabled.
+func isWALDisabled(base storage.KVBaseStorage) bool {
+	if w, ok := base.(storage.WALDisabledStore); ok {
+		return w.WALDisabled()
+	}
+	return false
+}
+
 // trySync syncs the data to disk every interval and then mark the appliedIndex
 // values of the raft log as persistented.
 func (kv *kvDataStorage) trySync() error {
@@ -398,16 +648,25 @@ func (kv *kvDataStorage) trySync() error {
 	if n%kv.opts.sampleSync != 0 {
 		return nil
 	}
+	return kv.sync()
+}
+
+// sync syncs the base storage, the applied indexes are taken before the sync,
+// the writes after that may be not persistent, especially if the WAL is
+// disabled.
+func (kv *kvDataStorage) sync() error {
+	appliedIndexes := kv.getLastAppliedIndexes()
 	if err := kv.base.Sync(); err != nil {
 		return err
 	}
 
-	kv.updatePersistentAppliedIndexes()
+	kv.updatePersistentAppliedIndexes(appliedIndexes)
 	return nil
 }
 
 // delegate method
 func (kv *kvDataStorage) Close() error {
+	kv.gc.close()
 	return kv.base.Close()
 }
 
@@ -415,7 +674,91 @@ func (kv *kvDataStorage) CreateSnapshot(shardID uint64, path string) error {
 	return kv.base.CreateSnapshot(shardID, path)
 }
 
+func (kv *kvDataStorage) VerifySnapshot(shardID uint64, path string) error {
+	if v, ok := kv.base.(storage.SnapshotVerifier); ok {
+		return v.VerifySnapshot(shardID, path)
+	}
+	return nil
+}
+
+// SampleKeys samples the keys of the shard by the base storage,
+// storage.ErrSampleNotSupported is returned if it's not a KeySampler.
+func (kv *kvDataStorage) SampleKeys(shardID uint64, n int) ([][]byte, error) {
+	if s, ok := kv.base.(storage.KeySampler); ok {
+		return s.SampleKeys(shardID, n)
+	}
+	return nil, storage.ErrSampleNotSupported
+}
+
+// CompactShard compacts the data range of the shard by the base storage
+func (kv *kvDataStorage) CompactShard(shard metapb.Shard) error {
+	return kv.base.CompactRange(shardDataRange(shard))
+}
+
+// EstimateShardSize estimates the size of the data range of the shard by the
+// base storage
+func (kv *kvDataStorage) EstimateShardSize(shard metapb.Shard) (uint64, uint64, error) {
+	return kv.base.EstimateSize(shardDataRange(shard))
+}
+
+// shardBackuper is implemented by the BaseStorage
+type shardBackuper interface {
+	BackupShard(shardID uint64, w io.Writer, opts storage.SnapshotStreamOptions) (metapb.SnapshotManifest, error)
+	RestoreShardData(backupShardID uint64, shard metapb.Shard, r io.Reader, opts storage.SnapshotStreamOptions) error
+	ReadShardData(backupShardID uint64, r io.Reader, opts storage.SnapshotStreamOptions, fn func(key, value []byte) error) error
+}
+
+func (kv *kvDataStorage) GetAppliedIndex(shardID uint64) (uint64, error) {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+	index, ok := kv.mu.lastAppliedIndexes[shardID]
+	if !ok {
+		return 0, storage.ErrShardNotFound
+	}
+	return index, nil
+}
+
+// BackupShard backs up the shard by the base storage,
+// storage.ErrBackupNotSupported is returned if it's not able to.
+func (kv *kvDataStorage) BackupShard(shardID uint64, w io.Writer,
+	opts storage.SnapshotStreamOptions) (metapb.SnapshotManifest, error) {
+	if b, ok := kv.base.(shardBackuper); ok {
+		return b.BackupShard(shardID, w, opts)
+	}
+	return metapb.SnapshotManifest{}, storage.ErrBackupNotSupported
+}
+
+func (kv *kvDataStorage) RestoreShardData(backupShardID uint64, shard metapb.Shard,
+	r io.Reader, opts storage.SnapshotStreamOptions) error {
+	b, ok := kv.base.(shardBackuper)
+	if !ok {
+		return storage.ErrBackupNotSupported
+	}
+	// the range may be in the range of a destroyed shard not removed yet
+	if err := kv.gc.flush(); err != nil {
+		return err
+	}
+	defer kv.mirrors.drop(shard.ID, true)
+	if err := b.RestoreShardData(backupShardID, shard, r, opts); err != nil {
+		return err
+	}
+	return kv.hooks.rebuild(shard)
+}
+
+func (kv *kvDataStorage) ReadShardData(backupShardID uint64, r io.Reader,
+	opts storage.SnapshotStreamOptions, fn func(key, value []byte) error) error {
+	if b, ok := kv.base.(shardBackuper); ok {
+		return b.ReadShardData(backupShardID, r, opts, fn)
+	}
+	return storage.ErrBackupNotSupported
+}
+
 func (kv *kvDataStorage) ApplySnapshot(shardID uint64, path string) error {
+	// the snapshot may be in the range of a destroyed shard not removed yet
+	if err := kv.gc.flush(); err != nil {
+		return err
+	}
+	defer kv.mirrors.drop(shardID, true)
 	// FIXME: kv.base.ApplySnapshot is not atomic
 	// kvDataStorage.ApplySnapshot suffers from the same issue
 	if err := kv.base.ApplySnapshot(shardID, path); err != nil {
@@ -432,28 +775,58 @@ func (kv *kvDataStorage) ApplySnapshot(shardID uint64, path string) error {
 	var idx metapb.LogIndex
 	protoc.MustUnmarshal(&idx, v)
 	kv.updateAppliedIndex(shardID, idx.Index)
+	if kv.hooks != nil {
+		sm, err := kv.getShardMetadata(shardID)
+		if err != nil {
+			return err
+		}
+		if err := kv.hooks.rebuild(sm.Metadata.Shard); err != nil {
+			return err
+		}
+	}
 	return kv.Sync(nil)
 }
 
 func (kv *kvDataStorage) Stats() stats.Stats {
-	return kv.base.Stats()
+	st := kv.base.Stats()
+	kv.gc.fillStats(&st)
+	return st
 }
 
-func (kv *kvDataStorage) updatePersistentAppliedIndexes() {
-	kv.mu.Lock()
+func (kv *kvDataStorage) getLastAppliedIndexes() map[uint64]uint64 {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+	appliedIndexes := make(map[uint64]uint64, len(kv.mu.lastAppliedIndexes))
 	for k, v := range kv.mu.lastAppliedIndexes {
-		kv.mu.persistentAppliedIndexes[k] = v
+		appliedIndexes[k] = v
+	}
+	return appliedIndexes
+}
+
+func (kv *kvDataStorage) updatePersistentAppliedIndexes(appliedIndexes map[uint64]uint64) {
+	kv.mu.Lock()
+	for k, v := range appliedIndexes {
+		// the shard maybe removed during the sync
+		if _, ok := kv.mu.lastAppliedIndexes[k]; ok {
+			kv.mu.persistentAppliedIndexes[k] = v
+		}
 	}
 	kv.mu.Unlock()
 }
 
 type readContext struct {
-	base storage.ReadContext
+	base   storage.ReadContext
+	mirror storage.KVStorage
 }
 
+var _ storage.MirrorContext = readContext{}
+
 func (c readContext) ByteBuf() *buf.ByteBuf { return c.base.(storage.InternalContext).ByteBuf() }
 func (c readContext) Shard() metapb.Shard   { return c.base.Shard() }
 func (c readContext) SetReadBytes(v uint64) { c.base.SetReadBytes(v) }
+func (c readContext) MirrorKVStorage() storage.KVStorage {
+	return c.mirror
+}
 func (c readContext) Request() storage.Request {
 	req := c.base.Request()
 	req.Key = keysutil.EncodeDataKey(req.Key, c.base.(storage.InternalContext).ByteBuf())
@@ -14,9 +14,12 @@
 package kv
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	cpebble "github.com/cockroachdb/pebble"
 	"github.com/fagongzi/util/format"
@@ -351,13 +354,132 @@ func TestKVDataStorageRestartWithNotSyncedDataLost(t *testing.T) {
 	}
 }
 
+func TestKVDataStorageRestartWithWALDisabled(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	memfs := vfs.NewMemFS()
+	defer vfs.ReportLeakedFD(memfs, t)
+	opts := &cpebble.Options{
+		FS:         vfs.NewPebbleFS(memfs),
+		DisableWAL: true,
+	}
+	require.NoError(t, memfs.MkdirAll("/test-data", 0755))
+	dir, err := memfs.OpenDir("/")
+	assert.NoError(t, err)
+	require.NoError(t, dir.Sync())
+
+	shardID := uint64(1)
+	key := func(index uint64) []byte {
+		return keysutil.EncodeDataKey([]byte(fmt.Sprintf("%d", index)), nil)
+	}
+	func() {
+		kv, err := pebble.NewStorage("test-data", nil, opts)
+		assert.NoError(t, err)
+		base := NewBaseStorage(kv, memfs)
+		s := NewKVDataStorage(base, executor.NewKVExecutor(base), WithWALDisabled(0), WithSampleSync(10))
+		defer func() {
+			// to emulate a crash
+			memfs.(*pvfs.MemFS).SetIgnoreSyncs(true)
+			s.Close()
+		}()
+		_, err = s.GetInitialStates()
+		assert.NoError(t, err)
+		assert.NoError(t, s.SaveShardMetadata([]metapb.ShardMetadata{{
+			ShardID:  shardID,
+			LogIndex: 1,
+			Metadata: metapb.ShardLocalState{Shard: metapb.Shard{ID: shardID}},
+		}}))
+
+		for index := uint64(2); index <= 25; index++ {
+			var batch storage.Batch
+			batch.Index = index
+			k := []byte(fmt.Sprintf("%d", index))
+			batch.Requests = append(batch.Requests, executor.NewWriteRequest(k, k))
+			ctx := storage.NewSimpleWriteContext(shardID, base, batch)
+			assert.NoError(t, s.Write(ctx))
+		}
+		v, err := s.GetPersistentLogIndex(shardID)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(20), v)
+	}()
+
+	memfs.(*pvfs.MemFS).ResetToSyncedState()
+	memfs.(*pvfs.MemFS).SetIgnoreSyncs(false)
+	kv, err := pebble.NewStorage("test-data", nil, opts)
+	assert.NoError(t, err)
+	base := NewBaseStorage(kv, memfs)
+	s := NewKVDataStorage(base, executor.NewKVExecutor(base), WithWALDisabled(0), WithSampleSync(10))
+	defer s.Close()
+	md, err := s.GetInitialStates()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(md))
+	index, err := s.GetPersistentLogIndex(shardID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20), index)
+
+	// the data is consistent with the persistent applied index, the lost writes
+	// are applied again from the raft log
+	v, err := base.Get(key(20))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, v)
+	v, err = base.Get(key(21))
+	assert.NoError(t, err)
+	assert.Empty(t, v)
+}
+
+func TestKVDataStorageWALDisabledForGroup(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	memfs := vfs.NewMemFS()
+	defer vfs.ReportLeakedFD(memfs, t)
+	kv, err := pebble.NewStorage("test-data", nil, &cpebble.Options{
+		FS:         vfs.NewPebbleFS(memfs),
+		DisableWAL: true,
+	})
+	require.NoError(t, err)
+	base := NewBaseStorage(kv, memfs)
+	defer base.Close()
+	assert.True(t, kv.WALDisabled())
+
+	// the base storage without the WAL must be bound to a group
+	assert.Panics(t, func() {
+		NewKVDataStorage(base, executor.NewKVExecutor(base))
+	})
+
+	s := NewKVDataStorage(base, executor.NewKVExecutor(base), WithWALDisabled(1))
+	_, err = s.GetInitialStates()
+	assert.NoError(t, err)
+	assert.Equal(t, ErrWALDisabledGroup, s.SaveShardMetadata([]metapb.ShardMetadata{{
+		ShardID:  1,
+		LogIndex: 1,
+		Metadata: metapb.ShardLocalState{Shard: metapb.Shard{ID: 1, Group: 2}},
+	}}))
+	assert.NoError(t, s.SaveShardMetadata([]metapb.ShardMetadata{{
+		ShardID:  2,
+		LogIndex: 1,
+		Metadata: metapb.ShardLocalState{Shard: metapb.Shard{ID: 2, Group: 1}},
+	}}))
+
+	// the test write context writes the shards of the group 0
+	write := func(s storage.DataStorage) error {
+		var batch storage.Batch
+		batch.Index = 2
+		batch.Requests = append(batch.Requests, executor.NewWriteRequest([]byte("k"), []byte("v")))
+		return s.Write(storage.NewSimpleWriteContext(2, base, batch))
+	}
+	assert.Equal(t, ErrWALDisabledGroup, write(s))
+
+	s = NewKVDataStorage(base, executor.NewKVExecutor(base), WithWALDisabled(0))
+	_, err = s.GetInitialStates()
+	assert.NoError(t, err)
+	assert.NoError(t, write(s))
+}
+
 func TestRemoveShard(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	fs := vfs.GetTestFS()
 	defer vfs.ReportLeakedFD(fs, t)
 	kv := getTestPebbleStorage(t, fs)
 	base := NewBaseStorage(kv, fs)
-	ds := NewKVDataStorage(base, nil)
+	ds := NewKVDataStorage(base, nil, WithGCInterval(time.Millisecond))
 	defer func() {
 		require.NoError(t, fs.RemoveAll(testDir))
 	}()
@@ -409,6 +531,10 @@ func TestRemoveShard(t *testing.T) {
 		return true, nil
 	}, false))
 	assert.Equal(t, 0, c)
+	// the data is removed in background
+	require.Eventually(t, func() bool {
+		return ds.Stats().GCRemovedRanges == 1
+	}, time.Second*10, time.Millisecond*10)
 	c = 0
 	require.NoError(t, kv.Scan(keysutil.EncodeShardStart([]byte{2}, nil), keysutil.EncodeShardEnd(nil, nil), func(key, value []byte) (bool, error) {
 		c++
@@ -417,6 +543,55 @@ func TestRemoveShard(t *testing.T) {
 	assert.Equal(t, 0, c)
 }
 
+func TestCompactShard(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.GetTestFS()
+	defer vfs.ReportLeakedFD(fs, t)
+	kv := getTestPebbleStorage(t, fs)
+	ds := NewKVDataStorage(NewBaseStorage(kv, fs), nil)
+	defer func() {
+		require.NoError(t, fs.RemoveAll(testDir))
+	}()
+	defer ds.Close()
+
+	require.NoError(t, kv.Set(keysutil.EncodeDataKey([]byte{1}, nil), []byte{1}, false))
+	assert.NoError(t, ds.(storage.ShardCompactor).CompactShard(metapb.Shard{ID: 1, End: []byte{2}}))
+	v, err := kv.Get(keysutil.EncodeDataKey([]byte{1}, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1}, v)
+}
+
+func TestEstimateShardSize(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.GetTestFS()
+	defer vfs.ReportLeakedFD(fs, t)
+	kv := getTestPebbleStorage(t, fs)
+	ds := NewKVDataStorage(NewBaseStorage(kv, fs), nil)
+	defer func() {
+		require.NoError(t, fs.RemoveAll(testDir))
+	}()
+	defer ds.Close()
+
+	shard := metapb.Shard{ID: 1, End: []byte{2}}
+	for i := 0; i < 100; i++ {
+		require.NoError(t, kv.Set(keysutil.EncodeDataKey([]byte{1, byte(i)}, nil), make([]byte, 100), false))
+	}
+	require.NoError(t, ds.(storage.ShardCompactor).CompactShard(shard))
+	bytes, keys, err := ds.(storage.ShardSizeEstimator).EstimateShardSize(shard)
+	require.NoError(t, err)
+	assert.True(t, bytes > 0)
+	assert.True(t, keys > 0)
+
+	// the range deletion is reflected after the compaction
+	start, end := shardDataRange(shard)
+	require.NoError(t, kv.RangeDelete(start, end, false))
+	require.NoError(t, ds.(storage.ShardCompactor).CompactShard(shard))
+	bytes, keys, err = ds.(storage.ShardSizeEstimator).EstimateShardSize(shard)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), bytes)
+	assert.Equal(t, uint64(0), keys)
+}
+
 func TestSplitCheck(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	fs := vfs.GetTestFS()
@@ -455,6 +630,64 @@ func TestSplitCheck(t *testing.T) {
 	assert.Empty(t, ctx)
 }
 
+func TestSplitCheckInBackgroundView(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.GetTestFS()
+	defer vfs.ReportLeakedFD(fs, t)
+	kv := getTestPebbleStorage(t, fs)
+	kv.SetBackgroundScanCacheSize(1024 * 1024)
+	base := NewBaseStorage(kv, fs)
+	ds := NewKVDataStorage(base, nil)
+	defer func() {
+		require.NoError(t, fs.RemoveAll(testDir))
+	}()
+	defer ds.Close()
+
+	view, err := storage.GetBackgroundView(base)
+	require.NoError(t, err)
+	_, ok := view.Raw().(*cpebble.DB)
+	assert.True(t, ok, "checkpoint of the storage")
+	require.NoError(t, view.Close())
+
+	require.NoError(t, kv.Set(keysutil.EncodeDataKey([]byte{1}, nil), []byte{1}, false))
+	require.NoError(t, kv.Set(keysutil.EncodeDataKey([]byte{2}, nil), []byte{2}, false))
+	require.NoError(t, kv.Set(keysutil.EncodeDataKey([]byte{3}, nil), []byte{3}, false))
+
+	size, keys, splitKeys, _, err := ds.SplitCheck(metapb.Shard{}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), size)
+	assert.Equal(t, uint64(3), keys)
+	assert.Equal(t, [][]byte{{2}, {3}}, splitKeys)
+}
+
+func TestSplitCheckWithContext(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.GetTestFS()
+	defer vfs.ReportLeakedFD(fs, t)
+	kv := getTestPebbleStorage(t, fs)
+	base := NewBaseStorage(kv, fs)
+	ds := NewKVDataStorage(base, nil)
+	defer func() {
+		require.NoError(t, fs.RemoveAll(testDir))
+	}()
+	defer ds.Close()
+
+	for i := 0; i < int(splitCheckCancelKeys)*2; i++ {
+		key := keysutil.EncodeDataKey([]byte(fmt.Sprintf("%04d", i)), nil)
+		require.NoError(t, kv.Set(key, []byte{1}, false))
+	}
+
+	checker := ds.(storage.ContextSplitChecker)
+	ctx, cancel := context.WithCancel(context.Background())
+	_, keys, _, _, err := checker.SplitCheckWithContext(ctx, metapb.Shard{}, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, splitCheckCancelKeys*2, keys)
+
+	cancel()
+	_, _, _, _, err = checker.SplitCheckWithContext(ctx, metapb.Shard{}, 100)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
 func TestSplitCheckWithSplitKeyFunc(t *testing.T) {
 	// mvcc encode: key+uint64, fix key length 4
 	decode := func(k []byte) []byte {
@@ -523,3 +756,76 @@ func newTestShardMetadata(n uint64) []metapb.ShardMetadata {
 	}
 	return values
 }
+
+func TestApproximateSplitCheck(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.GetTestFS()
+	defer vfs.ReportLeakedFD(fs, t)
+	require.NoError(t, fs.RemoveAll(testDir))
+	kv, err := pebble.NewStorage(testDir, nil, &cpebble.Options{
+		FS:         vfs.NewPebbleFS(fs),
+		DisableWAL: true,
+		// keep a sstable per flush
+		L0CompactionThreshold: 100,
+		L0StopWritesThreshold: 100,
+	})
+	require.NoError(t, err)
+	base := NewBaseStorage(kv, fs)
+	defer func() {
+		require.NoError(t, fs.RemoveAll(testDir))
+	}()
+	defer base.Close()
+
+	value := make([]byte, 100)
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 100; j++ {
+			key := keysutil.EncodeDataKey([]byte(fmt.Sprintf("k%04d", i*100+j)), nil)
+			require.NoError(t, base.Set(key, value, false))
+		}
+		// flush a sstable
+		require.NoError(t, base.Sync())
+	}
+
+	// estimated by the sstables, 113 bytes per key with the internal trailer
+	ds := NewKVDataStorage(base, nil, WithWALDisabled(0),
+		WithFeature(storage.Feature{ShardSplitCheckApproximateBytes: 1}))
+	size, keys, splitKeys, _, err := ds.SplitCheck(metapb.Shard{}, 3*11300)
+	assert.NoError(t, err)
+	assert.InDelta(t, uint64(113000), size, 113000*0.2)
+	assert.InDelta(t, uint64(1000), keys, 1000*0.2)
+	assert.Equal(t, [][]byte{[]byte("k0299"), []byte("k0599"), []byte("k0899")}, splitKeys)
+
+	// the split keys are adjusted and deduplicated
+	ds = NewKVDataStorage(base, nil, WithWALDisabled(0), WithFeature(storage.Feature{
+		ShardSplitCheckApproximateBytes: 1,
+		SplitKeyAdjustFunc: func(splitKey []byte) []byte {
+			return splitKey[:2]
+		},
+	}))
+	_, _, splitKeys, _, err = ds.SplitCheck(metapb.Shard{}, 3*11300)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("k0")}, splitKeys)
+
+	// scanned if the estimated size is less than the threshold
+	ds = NewKVDataStorage(base, nil, WithWALDisabled(0),
+		WithFeature(storage.Feature{ShardSplitCheckApproximateBytes: 1000000}))
+	size, keys, splitKeys, _, err = ds.SplitCheck(metapb.Shard{}, 3*10500)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(105000), size)
+	assert.Equal(t, uint64(1000), keys)
+	assert.Equal(t, [][]byte{[]byte("k0300"), []byte("k0600"), []byte("k0900")}, splitKeys)
+
+	// the keys the shard can't be split around are skipped
+	ds = NewKVDataStorage(base, nil, WithWALDisabled(0), WithFeature(storage.Feature{
+		ShardSplitCheckApproximateBytes: 1000000,
+		ShardSplitKeyAdjustFunc: func(shard metapb.Shard, splitKey []byte) []byte {
+			if bytes.Compare(splitKey, []byte("k0500")) < 0 {
+				return nil
+			}
+			return splitKey
+		},
+	}))
+	_, _, splitKeys, _, err = ds.SplitCheck(metapb.Shard{}, 3*10500)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("k0500"), []byte("k0800")}, splitKeys)
+}
@@ -0,0 +1,233 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util"
+)
+
+const (
+	ttlMetaSize = 12
+)
+
+var (
+	// The expiry metadata is stored after the data keys, the 0x02 prefix is
+	// reserved by the TTL. The metadata of the keys in [start, end) are in
+	// [ttlMetaPrefix + start, ttlMetaPrefix + end), so the metadata range of a
+	// shard mirrors its data range.
+	//
+	// ttlMetaPrefix + key -> expiration + crc32 of the value
+	ttlMetaPrefix = []byte{0x02, 0x01}
+	ttlEndKey     = []byte{0x02, 0x02}
+)
+
+// ttlStore maintains the expiry metadata of the keys written by SetTTL. The
+// checksum of the value is recorded with the expiration, so the TTL of a key
+// overwritten by other writes is ignored.
+type ttlStore struct {
+	kv  storage.KVStorage
+	now func() time.Time
+	// active whether any key with TTL has been written, the other operations
+	// skip the expiry checks before that
+	active uint32
+}
+
+func newTTLStore(kv storage.KVStorage) *ttlStore {
+	t := &ttlStore{
+		kv:  kv,
+		now: time.Now,
+	}
+	// keys with TTL written before restart
+	if key, _, err := kv.SeekAndLT(ttlMetaPrefix, ttlEndKey); err != nil || len(key) > 0 {
+		t.activate()
+	}
+	return t
+}
+
+func (t *ttlStore) isActive() bool {
+	return atomic.LoadUint32(&t.active) == 1
+}
+
+func (t *ttlStore) activate() {
+	atomic.StoreUint32(&t.active, 1)
+}
+
+func (t *ttlStore) set(wb util.WriteBatch, key, value []byte, expiration int64) {
+	t.activate()
+	wb.Set(key, value)
+	wb.Set(ttlMetaKey(key), encodeTTLMeta(uint64(expiration), value))
+}
+
+// isExpired returns true if the value of the key is expired
+func (t *ttlStore) isExpired(key, value []byte) (bool, error) {
+	if !t.isActive() || value == nil {
+		return false, nil
+	}
+	meta, err := t.kv.Get(ttlMetaKey(key))
+	if err != nil || len(meta) != ttlMetaSize {
+		return false, err
+	}
+	expiration, checksum := decodeTTLMeta(meta)
+	return expiration <= uint64(t.now().UnixNano()) &&
+		checksum == crc32.ChecksumIEEE(value), nil
+}
+
+// expired returns the checksums of the expired values in [start, end) read
+// from the metadata, the view is used if it's not nil. An empty end means no
+// upper bound.
+func (t *ttlStore) expired(view storage.View, start, end []byte) (map[string]uint32, error) {
+	if !t.isActive() {
+		return nil, nil
+	}
+
+	var values map[string]uint32
+	now := uint64(t.now().UnixNano())
+	err := t.scanMeta(view, start, end, func(key []byte, expiration uint64, checksum uint32) bool {
+		if expiration <= now {
+			if values == nil {
+				values = make(map[string]uint32)
+			}
+			values[string(key)] = checksum
+		}
+		return true
+	})
+	return values, err
+}
+
+// expiredKeys returns at most limit keys in [start, end) whose TTL expired,
+// including the keys overwritten after the TTL set.
+func (t *ttlStore) expiredKeys(start, end []byte, limit int) ([]storage.ExpiredKey, error) {
+	if !t.isActive() {
+		return nil, nil
+	}
+
+	var keys []storage.ExpiredKey
+	now := uint64(t.now().UnixNano())
+	err := t.scanMeta(nil, start, end, func(key []byte, expiration uint64, _ uint32) bool {
+		if expiration <= now {
+			keys = append(keys, storage.ExpiredKey{
+				Key:        append([]byte(nil), key...),
+				Expiration: int64(expiration),
+			})
+		}
+		return len(keys) < limit
+	})
+	return keys, err
+}
+
+// scanMeta scans the metadata of the keys in [start, end), the view is used if
+// it's not nil. An empty end means no upper bound.
+func (t *ttlStore) scanMeta(view storage.View, start, end []byte,
+	fn func(key []byte, expiration uint64, checksum uint32) bool) error {
+	min, max := ttlMetaRange(start, end)
+	scan := func(key, value []byte) (bool, error) {
+		if len(value) != ttlMetaSize {
+			return true, nil
+		}
+		expiration, checksum := decodeTTLMeta(value)
+		return fn(key[len(ttlMetaPrefix):], expiration, checksum), nil
+	}
+	if view != nil {
+		return t.kv.ScanInView(view, min, max, scan, false)
+	}
+	return t.kv.Scan(min, max, scan, false)
+}
+
+// purge puts the removal of the expired keys into the write batch, the key is
+// removed if both of its TTL and value are not changed, and the metadata is
+// removed if only the value is changed.
+func (t *ttlStore) purge(wb util.WriteBatch, get func(key []byte) ([]byte, error),
+	expired []storage.ExpiredKey) (int, uint64, error) {
+	removed := 0
+	removedBytes := uint64(0)
+	for _, e := range expired {
+		metaKey := ttlMetaKey(e.Key)
+		meta, err := get(metaKey)
+		if err != nil {
+			return 0, 0, err
+		}
+		if len(meta) != ttlMetaSize {
+			continue
+		}
+		// the key has been set with another TTL
+		expiration, checksum := decodeTTLMeta(meta)
+		if expiration != uint64(e.Expiration) {
+			continue
+		}
+		wb.Delete(metaKey)
+		value, err := get(e.Key)
+		if err != nil {
+			return 0, 0, err
+		}
+		// the key has been overwritten or deleted after the TTL was set
+		if value == nil || checksum != crc32.ChecksumIEEE(value) {
+			continue
+		}
+		wb.Delete(e.Key)
+		removed++
+		removedBytes += uint64(len(e.Key) + len(value))
+	}
+	return removed, removedBytes, nil
+}
+
+// hasMeta returns true if any key in [start, end) has the expiry metadata in
+// the view.
+func (t *ttlStore) hasMeta(view storage.View, start, end []byte) (bool, error) {
+	if !t.isActive() {
+		return false, nil
+	}
+	found := false
+	err := t.scanMeta(view, start, end, func([]byte, uint64, uint32) bool {
+		found = true
+		return false
+	})
+	return found, err
+}
+
+func ttlMetaKey(key []byte) []byte {
+	v := make([]byte, len(ttlMetaPrefix)+len(key))
+	copy(v, ttlMetaPrefix)
+	copy(v[len(ttlMetaPrefix):], key)
+	return v
+}
+
+// ttlMetaRange returns the range of the metadata of the keys in [start, end),
+// an empty end means no upper bound.
+func ttlMetaRange(start, end []byte) ([]byte, []byte) {
+	if len(end) == 0 {
+		return ttlMetaKey(start), ttlEndKey
+	}
+	return ttlMetaKey(start), ttlMetaKey(end)
+}
+
+func encodeTTLMeta(expiration uint64, value []byte) []byte {
+	v := make([]byte, ttlMetaSize)
+	binary.BigEndian.PutUint64(v, expiration)
+	binary.BigEndian.PutUint32(v[8:], crc32.ChecksumIEEE(value))
+	return v
+}
+
+func decodeTTLMeta(v []byte) (uint64, uint32) {
+	if len(v) != ttlMetaSize {
+		return math.MaxUint64, 0
+	}
+	return binary.BigEndian.Uint64(v), binary.BigEndian.Uint32(v[8:])
+}
@@ -0,0 +1,326 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/kv/mem"
+	"github.com/matrixorigin/matrixcube/util"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/util/leaktest"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTTLStorage(t *testing.T, fs vfs.FS) (storage.KVBaseStorage, *time.Time) {
+	now := time.Now()
+	base := NewBaseStorage(getTestPebbleStorage(t, fs), fs)
+	base.(*BaseStorage).ttl.now = func() time.Time { return now }
+	return base, &now
+}
+
+func setTestTTL(t *testing.T, base storage.KVBaseStorage, key, value []byte, expiration time.Time) {
+	wb := base.NewWriteBatch().(util.WriteBatch)
+	defer wb.Close()
+	base.SetTTL(wb, key, value, expiration.UnixNano())
+	require.NoError(t, base.Write(wb, false))
+}
+
+func purgeTestExpired(t *testing.T, base storage.KVBaseStorage, expired []storage.ExpiredKey) int {
+	wb := base.NewWriteBatch().(util.WriteBatch)
+	defer wb.Close()
+	n, _, err := base.PurgeExpired(wb, base.WithoutTTL().Get, expired)
+	require.NoError(t, err)
+	require.NoError(t, base.Write(wb, false))
+	return n
+}
+
+func TestSetTTL(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.GetTestFS()
+	defer vfs.ReportLeakedFD(fs, t)
+	base, now := newTestTTLStorage(t, fs)
+	defer func() {
+		require.NoError(t, fs.RemoveAll(testDir))
+	}()
+	defer base.Close()
+
+	setTestTTL(t, base, []byte("k1"), []byte("v1"), now.Add(time.Second))
+	setTestTTL(t, base, []byte("k2"), []byte("v2"), now.Add(time.Hour))
+	require.NoError(t, base.Set([]byte("k3"), []byte("v3"), false))
+
+	value, err := base.Get([]byte("k1"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v1"), value)
+
+	*now = now.Add(time.Second)
+	value, err = base.Get([]byte("k1"))
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+	// kept until purged
+	value, err = base.WithoutTTL().Get([]byte("k1"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v1"), value)
+
+	values, err := base.MultiGet([][]byte{[]byte("k1"), []byte("k2"), []byte("k3")})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{nil, []byte("v2"), []byte("v3")}, values)
+
+	// overwritten by the plain write
+	setTestTTL(t, base, []byte("k4"), []byte("v4"), now.Add(time.Second))
+	require.NoError(t, base.Set([]byte("k4"), []byte("v5"), false))
+	*now = now.Add(time.Second)
+	value, err = base.Get([]byte("k4"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v5"), value)
+
+	// the later TTL is used
+	setTestTTL(t, base, []byte("k3"), []byte("v3"), now.Add(time.Second))
+	setTestTTL(t, base, []byte("k3"), []byte("v3"), now.Add(time.Hour))
+	*now = now.Add(time.Second)
+	value, err = base.Get([]byte("k3"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v3"), value)
+}
+
+func TestScanAndSeekSkipExpired(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.GetTestFS()
+	defer vfs.ReportLeakedFD(fs, t)
+	base, now := newTestTTLStorage(t, fs)
+	defer func() {
+		require.NoError(t, fs.RemoveAll(testDir))
+	}()
+	defer base.Close()
+
+	require.NoError(t, base.Set([]byte("k1"), []byte("v1"), false))
+	setTestTTL(t, base, []byte("k2"), []byte("v2"), now.Add(time.Second))
+	setTestTTL(t, base, []byte("k3"), []byte("v3"), now.Add(time.Second))
+	require.NoError(t, base.Set([]byte("k4"), []byte("v4"), false))
+	*now = now.Add(time.Second)
+
+	var keys []string
+	collect := func(key, value []byte) (bool, error) {
+		keys = append(keys, string(key))
+		return true, nil
+	}
+	require.NoError(t, base.Scan([]byte("k"), []byte("l"), collect, false))
+	assert.Equal(t, []string{"k1", "k4"}, keys)
+	keys = keys[:0]
+	require.NoError(t, base.ScanReverse([]byte("k"), []byte("l"), collect, false))
+	assert.Equal(t, []string{"k4", "k1"}, keys)
+	keys = keys[:0]
+	require.NoError(t, base.PrefixScan([]byte("k"), collect, false))
+	assert.Equal(t, []string{"k1", "k4"}, keys)
+	keys = keys[:0]
+	require.NoError(t, base.WithoutTTL().Scan([]byte("k"), []byte("l"), collect, false))
+	assert.Equal(t, []string{"k1", "k2", "k3", "k4"}, keys)
+
+	view := base.GetView()
+	keys = keys[:0]
+	require.NoError(t, base.ScanInViewWithOptions(view, []byte("k"), []byte("l"), func(key, value []byte) (storage.NextIterOptions, error) {
+		keys = append(keys, string(key))
+		return storage.NextIterOptions{}, nil
+	}))
+	assert.Equal(t, []string{"k1", "k4"}, keys)
+	require.NoError(t, view.Close())
+
+	pairs, next, err := base.ScanPage([]byte("k"), []byte("l"), 1, storage.ScanPageOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.KeyValue{{Key: []byte("k1"), Value: []byte("v1")}}, pairs)
+	assert.Equal(t, []byte("k4"), next)
+	keys = keys[:0]
+	it := base.NewIterator([]byte("k"), []byte("l"), storage.ScanPageOptions{PageSize: 1})
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	assert.NoError(t, it.Close())
+	assert.Equal(t, []string{"k1", "k4"}, keys)
+
+	key, _, err := base.Seek([]byte("k2"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("k4"), key)
+	key, _, err = base.SeekAndLT([]byte("k2"), []byte("k4"))
+	assert.NoError(t, err)
+	assert.Empty(t, key)
+	key, _, err = base.SeekLT([]byte("k4"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("k1"), key)
+	key, _, err = base.SeekLTAndGE([]byte("k4"), []byte("k2"))
+	assert.NoError(t, err)
+	assert.Empty(t, key)
+}
+
+func TestExpiredKeys(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.GetTestFS()
+	defer vfs.ReportLeakedFD(fs, t)
+	base, now := newTestTTLStorage(t, fs)
+	defer func() {
+		require.NoError(t, fs.RemoveAll(testDir))
+	}()
+	defer base.Close()
+
+	keys, err := base.ExpiredKeys(nil, nil, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	expiration := now.Add(time.Second)
+	setTestTTL(t, base, []byte("k1"), []byte("v1"), expiration)
+	setTestTTL(t, base, []byte("k2"), []byte("v2"), expiration)
+	setTestTTL(t, base, []byte("k3"), []byte("v3"), now.Add(time.Hour))
+	setTestTTL(t, base, []byte("m1"), []byte("v1"), expiration)
+	*now = now.Add(time.Second)
+
+	keys, err = base.ExpiredKeys([]byte("k"), []byte("l"), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.ExpiredKey{
+		{Key: []byte("k1"), Expiration: expiration.UnixNano()},
+		{Key: []byte("k2"), Expiration: expiration.UnixNano()},
+	}, keys)
+	keys, err = base.ExpiredKeys([]byte("k"), nil, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.ExpiredKey{{Key: []byte("k1"), Expiration: expiration.UnixNano()}}, keys)
+	keys, err = base.ExpiredKeys([]byte("k2"), nil, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(keys))
+}
+
+func TestPurgeExpired(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.GetTestFS()
+	defer vfs.ReportLeakedFD(fs, t)
+	base, now := newTestTTLStorage(t, fs)
+	defer func() {
+		require.NoError(t, fs.RemoveAll(testDir))
+	}()
+	defer base.Close()
+
+	setTestTTL(t, base, []byte("k1"), []byte("v1"), now.Add(time.Second))
+	setTestTTL(t, base, []byte("k2"), []byte("v2"), now.Add(time.Second))
+	setTestTTL(t, base, []byte("k3"), []byte("v3"), now.Add(time.Second))
+	setTestTTL(t, base, []byte("k4"), []byte("v4"), now.Add(time.Hour))
+	*now = now.Add(time.Second)
+	expired, err := base.ExpiredKeys(nil, nil, 10)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(expired))
+
+	// overwritten after found expired
+	require.NoError(t, base.Set([]byte("k2"), []byte("v5"), false))
+	// set with another TTL after found expired
+	setTestTTL(t, base, []byte("k3"), []byte("v3"), now.Add(time.Hour))
+	assert.Equal(t, 1, purgeTestExpired(t, base, expired))
+
+	kv := base.(*BaseStorage).kv
+	value, err := kv.Get([]byte("k1"))
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+	value, err = kv.Get([]byte("k2"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v5"), value)
+	value, err = base.Get([]byte("k3"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v3"), value)
+	// only the metadata of k3 and k4 are left
+	c := 0
+	require.NoError(t, kv.Scan(ttlMetaPrefix, ttlEndKey, func(key, value []byte) (bool, error) {
+		c++
+		return true, nil
+	}, false))
+	assert.Equal(t, 2, c)
+
+	// purged again
+	assert.Equal(t, 0, purgeTestExpired(t, base, expired))
+}
+
+func TestSplitCheckSkipExpired(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.GetTestFS()
+	defer vfs.ReportLeakedFD(fs, t)
+	base, now := newTestTTLStorage(t, fs)
+	ds := NewKVDataStorage(base, nil)
+	defer func() {
+		require.NoError(t, fs.RemoveAll(testDir))
+	}()
+	defer ds.Close()
+
+	require.NoError(t, base.Set(keysutil.EncodeDataKey([]byte{1}, nil), []byte{1}, false))
+	setTestTTL(t, base, keysutil.EncodeDataKey([]byte{2}, nil), []byte{2}, now.Add(time.Second))
+	require.NoError(t, base.Set(keysutil.EncodeDataKey([]byte{3}, nil), []byte{3}, false))
+	*now = now.Add(time.Second)
+
+	size, keys, splitKeys, _, err := ds.SplitCheck(metapb.Shard{}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), size)
+	assert.Equal(t, uint64(2), keys)
+	assert.Equal(t, [][]byte{{3}}, splitKeys)
+}
+
+func TestSnapshotWithTTL(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fs := vfs.NewMemFS()
+	dir := "snapshot-dir"
+	shardID := uint64(100)
+	now := time.Now()
+	expiration := now.Add(time.Second)
+
+	kv := mem.NewStorage()
+	base := NewBaseStorage(kv, fs).(*BaseStorage)
+	ds := NewKVDataStorage(base, nil)
+	defer ds.Close()
+	setTestTTL(t, base, keysutil.EncodeDataKey([]byte("bb"), nil), []byte("bb"), expiration)
+	// out of the shard
+	setTestTTL(t, base, keysutil.EncodeDataKey([]byte("zz"), nil), []byte("zz"), expiration)
+	sm := metapb.ShardMetadata{
+		ShardID:  shardID,
+		LogIndex: 110,
+		Metadata: metapb.ShardLocalState{
+			Shard: metapb.Shard{ID: shardID, Start: []byte("aa"), End: []byte("xx")},
+		},
+	}
+	require.NoError(t, ds.SaveShardMetadata([]metapb.ShardMetadata{sm}))
+	require.NoError(t, base.CreateSnapshot(shardID, dir))
+	manifest := readTestSnapshotManifest(t, fs, dir, shardID)
+	assert.Equal(t, snapshotFeatureSST|snapshotFeatureTTL, manifest.Features)
+
+	for _, ingest := range []bool{true, false} {
+		func() {
+			var kv storage.KVStorage = mem.NewStorage()
+			defer kv.Close()
+			if !ingest {
+				kv = noSSTStorage{kv}
+			}
+			base := NewBaseStorage(kv, fs).(*BaseStorage)
+			base.ttl.now = func() time.Time { return expiration }
+			// the stale metadata of the shard is replaced
+			setTestTTL(t, base, keysutil.EncodeDataKey([]byte("cc"), nil), []byte("cc"), now)
+			require.NoError(t, base.ApplySnapshot(shardID, dir))
+
+			value, err := base.Get(keysutil.EncodeDataKey([]byte("bb"), nil))
+			assert.NoError(t, err)
+			assert.Nil(t, value, "ingest %v", ingest)
+			keys, err := base.ExpiredKeys(nil, nil, 10)
+			assert.NoError(t, err)
+			assert.Equal(t, []storage.ExpiredKey{{
+				Key:        keysutil.EncodeDataKey([]byte("bb"), nil),
+				Expiration: expiration.UnixNano(),
+			}}, keys, "ingest %v", ingest)
+		}()
+	}
+}
@@ -0,0 +1,686 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kvtest is the conformance test suite of the storage.KVStorage, the
+// third-party KVStorage implementations are able to validate themselves by
+// calling RunKVStorageTests in their tests.
+package kvtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory creates an empty KVStorage for a test, the storage is closed by the
+// test.
+type Factory func(t *testing.T) storage.KVStorage
+
+// RunKVStorageTests runs all the conformance tests against the KVStorage
+// created by the factory.
+func RunKVStorageTests(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, kv storage.KVStorage)
+	}{
+		{"SetGetDelete", testSetGetDelete},
+		{"GetWithFunc", testGetWithFunc},
+		{"MultiGet", testMultiGet},
+		{"WriteBatch", testWriteBatch},
+		{"WriteBatchDeferred", testWriteBatchDeferred},
+		{"WriteBatchReset", testWriteBatchReset},
+		{"View", testView},
+		{"Scan", testScan},
+		{"ScanInView", testScanInView},
+		{"ScanReverse", testScanReverse},
+		{"ScanReverseInView", testScanReverseInView},
+		{"ScanInViewWithOptions", testScanInViewWithOptions},
+		{"ReverseScanInViewWithOptions", testReverseScanInViewWithOptions},
+		{"PrefixScan", testPrefixScan},
+		{"ScanPage", testScanPage},
+		{"Iterator", testIterator},
+		{"RangeDelete", testRangeDelete},
+		{"Seek", testSeek},
+		{"SeekLTInView", testSeekLTInView},
+		{"Sync", testSync},
+		{"Stats", testStats},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kv := factory(t)
+			defer func() {
+				assert.NoError(t, kv.Close())
+			}()
+			tt.fn(t, kv)
+		})
+	}
+}
+
+func key(i int) []byte {
+	return []byte(fmt.Sprintf("k%03d", i))
+}
+
+func value(i int) []byte {
+	return []byte(fmt.Sprintf("v%03d", i))
+}
+
+// setKeys sets the keys k000, k001 ... k(n-1)
+func setKeys(t *testing.T, kv storage.KVStorage, n int) {
+	for i := 0; i < n; i++ {
+		require.NoError(t, kv.Set(key(i), value(i), false))
+	}
+}
+
+// requireKeys checks the keys in the storage, the scan range is limited since
+// the storage may write internal keys, e.g. on Sync.
+func requireKeys(t *testing.T, kv storage.KVStorage, expected ...int) {
+	var keys [][]byte
+	require.NoError(t, kv.Scan([]byte("k"), []byte("l"), func(k, v []byte) (bool, error) {
+		keys = append(keys, k)
+		return true, nil
+	}, true))
+	require.Equal(t, len(expected), len(keys), "keys %q", keys)
+	for i, k := range expected {
+		assert.Equal(t, key(k), keys[i])
+	}
+}
+
+func newWriteBatch(kv storage.KVStorage) util.WriteBatch {
+	return kv.NewWriteBatch().(util.WriteBatch)
+}
+
+func testSetGetDelete(t *testing.T, kv storage.KVStorage) {
+	v, err := kv.Get(key(1))
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	require.NoError(t, kv.Set(key(1), value(1), false))
+	require.NoError(t, kv.Set(key(2), value(2), true))
+	v, err = kv.Get(key(1))
+	require.NoError(t, err)
+	assert.Equal(t, value(1), v)
+	v, err = kv.Get(key(2))
+	require.NoError(t, err)
+	assert.Equal(t, value(2), v)
+
+	// overwrite
+	require.NoError(t, kv.Set(key(1), value(3), false))
+	v, err = kv.Get(key(1))
+	require.NoError(t, err)
+	assert.Equal(t, value(3), v)
+
+	require.NoError(t, kv.Delete(key(1), false))
+	require.NoError(t, kv.Delete(key(3), true))
+	v, err = kv.Get(key(1))
+	require.NoError(t, err)
+	assert.Nil(t, v)
+	requireKeys(t, kv, 2)
+}
+
+func testGetWithFunc(t *testing.T, kv storage.KVStorage) {
+	called := false
+	require.NoError(t, kv.GetWithFunc(key(1), func(v []byte) error {
+		called = true
+		return nil
+	}))
+	assert.False(t, called)
+
+	setKeys(t, kv, 2)
+	require.NoError(t, kv.GetWithFunc(key(1), func(v []byte) error {
+		called = true
+		assert.Equal(t, value(1), v)
+		return nil
+	}))
+	assert.True(t, called)
+
+	expected := fmt.Errorf("error")
+	assert.Equal(t, expected, kv.GetWithFunc(key(1), func(v []byte) error {
+		return expected
+	}))
+}
+
+func testMultiGet(t *testing.T, kv storage.KVStorage) {
+	values, err := kv.MultiGet(nil)
+	require.NoError(t, err)
+	assert.Empty(t, values)
+
+	setKeys(t, kv, 5)
+	// unsorted, duplicated and missing keys
+	values, err = kv.MultiGet([][]byte{key(3), key(1), key(7), key(3), key(0)})
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{value(3), value(1), nil, value(3), value(0)}, values)
+
+	// the returned values are not changed by the later writes
+	require.NoError(t, kv.Set(key(1), value(9), false))
+	assert.Equal(t, value(1), values[1])
+}
+
+func testWriteBatch(t *testing.T, kv storage.KVStorage) {
+	setKeys(t, kv, 10)
+	wb := newWriteBatch(kv)
+	defer wb.Close()
+
+	k := key(20)
+	wb.Set(k, value(20))
+	// the batch owns the data after the call
+	k[0] = 'x'
+	wb.Delete(key(0))
+	wb.DeleteRange(key(3), key(6))
+	// not visible before written
+	v, err := kv.Get(key(20))
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	require.NoError(t, kv.Write(wb, true))
+	requireKeys(t, kv, 1, 2, 6, 7, 8, 9, 20)
+
+	// the operations are applied in order
+	wb.Reset()
+	wb.Set(key(1), value(100))
+	wb.DeleteRange(key(0), key(2))
+	wb.Set(key(0), value(0))
+	wb.Delete(key(2))
+	wb.Set(key(2), value(200))
+	require.NoError(t, kv.Write(wb, false))
+	requireKeys(t, kv, 0, 2, 6, 7, 8, 9, 20)
+	v, err = kv.Get(key(2))
+	require.NoError(t, err)
+	assert.Equal(t, value(200), v)
+
+	// empty batch
+	wb.Reset()
+	require.NoError(t, kv.Write(wb, true))
+	requireKeys(t, kv, 0, 2, 6, 7, 8, 9, 20)
+}
+
+func testWriteBatchDeferred(t *testing.T, kv storage.KVStorage) {
+	setKeys(t, kv, 10)
+	wb := newWriteBatch(kv)
+	defer wb.Close()
+
+	wb.SetDeferred(len(key(20)), len(value(20)), func(k, v []byte) {
+		copy(k, key(20))
+		copy(v, value(20))
+	})
+	wb.DeleteDeferred(len(key(0)), func(k []byte) {
+		copy(k, key(0))
+	})
+	wb.DeleteRangeDeferred(len(key(3)), len(key(6)), func(start, end []byte) {
+		copy(start, key(3))
+		copy(end, key(6))
+	})
+	require.NoError(t, kv.Write(wb, true))
+	requireKeys(t, kv, 1, 2, 6, 7, 8, 9, 20)
+	v, err := kv.Get(key(20))
+	require.NoError(t, err)
+	assert.Equal(t, value(20), v)
+}
+
+func testWriteBatchReset(t *testing.T, kv storage.KVStorage) {
+	wb := newWriteBatch(kv)
+	defer wb.Close()
+	wb.Set(key(1), value(1))
+	wb.Reset()
+	wb.Set(key(2), value(2))
+	require.NoError(t, kv.Write(wb, true))
+	requireKeys(t, kv, 2)
+}
+
+func testView(t *testing.T, kv storage.KVStorage) {
+	setKeys(t, kv, 3)
+	view := kv.GetView()
+	assert.NotNil(t, view.Raw())
+	require.NoError(t, view.Close())
+
+	// the view is a point in time view, the later writes are invisible
+	view = kv.GetView()
+	// the writes are done in another goroutine, the storage may block the
+	// writes until the view closed
+	done := make(chan error, 1)
+	go func() {
+		if err := kv.Delete(key(0), false); err != nil {
+			done <- err
+			return
+		}
+		done <- kv.Set(key(3), value(3), false)
+	}()
+	var keys [][]byte
+	require.NoError(t, kv.ScanInView(view, nil, nil, func(k, v []byte) (bool, error) {
+		keys = append(keys, k)
+		return true, nil
+	}, true))
+	assert.Equal(t, [][]byte{key(0), key(1), key(2)}, keys)
+	require.NoError(t, view.Close())
+	require.NoError(t, <-done)
+	requireKeys(t, kv, 1, 2, 3)
+}
+
+func testScan(t *testing.T, kv storage.KVStorage) {
+	setKeys(t, kv, 10)
+	tests := []struct {
+		start, end []byte
+		limit      int
+		expected   []int
+	}{
+		{nil, nil, 0, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{key(3), nil, 0, []int{3, 4, 5, 6, 7, 8, 9}},
+		{nil, key(3), 0, []int{0, 1, 2}},
+		{key(3), key(5), 0, []int{3, 4}},
+		{[]byte("k0025"), []byte("k0055"), 0, []int{3, 4, 5}},
+		{key(5), key(5), 0, nil},
+		{key(20), nil, 0, nil},
+		{nil, nil, 3, []int{0, 1, 2}},
+	}
+	for i, tt := range tests {
+		for _, clone := range []bool{true, false} {
+			var keys, values [][]byte
+			require.NoError(t, kv.Scan(tt.start, tt.end, func(k, v []byte) (bool, error) {
+				if clone {
+					keys = append(keys, k)
+					values = append(values, v)
+				} else {
+					keys = append(keys, append([]byte(nil), k...))
+					values = append(values, append([]byte(nil), v...))
+				}
+				return tt.limit == 0 || len(keys) < tt.limit, nil
+			}, clone))
+			require.Equal(t, len(tt.expected), len(keys), "index %d", i)
+			for j, k := range tt.expected {
+				assert.Equal(t, key(k), keys[j], "index %d", i)
+				assert.Equal(t, value(k), values[j], "index %d", i)
+			}
+		}
+	}
+
+	expected := fmt.Errorf("error")
+	assert.Equal(t, expected, kv.Scan(nil, nil, func(k, v []byte) (bool, error) {
+		return true, expected
+	}, false))
+}
+
+func testScanInView(t *testing.T, kv storage.KVStorage) {
+	setKeys(t, kv, 10)
+	view := kv.GetView()
+	defer func() {
+		require.NoError(t, view.Close())
+	}()
+	var keys [][]byte
+	require.NoError(t, kv.ScanInView(view, key(3), key(6), func(k, v []byte) (bool, error) {
+		keys = append(keys, k)
+		assert.Equal(t, value(len(keys)+2), v)
+		return true, nil
+	}, true))
+	assert.Equal(t, [][]byte{key(3), key(4), key(5)}, keys)
+
+	keys = keys[:0]
+	require.NoError(t, kv.ScanInView(view, nil, nil, func(k, v []byte) (bool, error) {
+		keys = append(keys, append([]byte(nil), k...))
+		return len(keys) < 2, nil
+	}, false))
+	assert.Equal(t, [][]byte{key(0), key(1)}, keys)
+}
+
+func testScanReverse(t *testing.T, kv storage.KVStorage) {
+	setKeys(t, kv, 10)
+	tests := []struct {
+		start, end []byte
+		limit      int
+		expected   []int
+	}{
+		{nil, nil, 0, []int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0}},
+		{key(3), nil, 0, []int{9, 8, 7, 6, 5, 4, 3}},
+		{nil, key(3), 0, []int{2, 1, 0}},
+		{key(3), key(5), 0, []int{4, 3}},
+		{[]byte("k0025"), []byte("k0055"), 0, []int{5, 4, 3}},
+		{key(5), key(5), 0, nil},
+		{key(20), nil, 0, nil},
+		{nil, nil, 3, []int{9, 8, 7}},
+	}
+	for i, tt := range tests {
+		for _, clone := range []bool{true, false} {
+			var keys, values [][]byte
+			require.NoError(t, kv.ScanReverse(tt.start, tt.end, func(k, v []byte) (bool, error) {
+				if clone {
+					keys = append(keys, k)
+					values = append(values, v)
+				} else {
+					keys = append(keys, append([]byte(nil), k...))
+					values = append(values, append([]byte(nil), v...))
+				}
+				return tt.limit == 0 || len(keys) < tt.limit, nil
+			}, clone))
+			require.Equal(t, len(tt.expected), len(keys), "index %d", i)
+			for j, k := range tt.expected {
+				assert.Equal(t, key(k), keys[j], "index %d", i)
+				assert.Equal(t, value(k), values[j], "index %d", i)
+			}
+		}
+	}
+
+	expected := fmt.Errorf("error")
+	assert.Equal(t, expected, kv.ScanReverse(nil, nil, func(k, v []byte) (bool, error) {
+		return true, expected
+	}, false))
+}
+
+func testScanReverseInView(t *testing.T, kv storage.KVStorage) {
+	setKeys(t, kv, 10)
+	view := kv.GetView()
+	defer func() {
+		require.NoError(t, view.Close())
+	}()
+	// not visible in the view
+	require.NoError(t, kv.Set(key(5), []byte("v"), false))
+
+	var keys [][]byte
+	require.NoError(t, kv.ScanReverseInView(view, key(3), key(6), func(k, v []byte) (bool, error) {
+		keys = append(keys, k)
+		assert.Equal(t, value(6-len(keys)), v)
+		return true, nil
+	}, true))
+	assert.Equal(t, [][]byte{key(5), key(4), key(3)}, keys)
+
+	keys = keys[:0]
+	require.NoError(t, kv.ScanReverseInView(view, nil, nil, func(k, v []byte) (bool, error) {
+		keys = append(keys, append([]byte(nil), k...))
+		return len(keys) < 2, nil
+	}, false))
+	assert.Equal(t, [][]byte{key(9), key(8)}, keys)
+}
+
+func scanWithOptions(t *testing.T, kv storage.KVStorage, reverse bool, start, end []byte,
+	fn func(k []byte) storage.NextIterOptions) []int {
+	view := kv.GetView()
+	defer func() {
+		require.NoError(t, view.Close())
+	}()
+	var keys []int
+	handler := func(k, v []byte) (storage.NextIterOptions, error) {
+		var i int
+		if _, err := fmt.Sscanf(string(k), "k%03d", &i); err != nil {
+			return storage.NextIterOptions{}, err
+		}
+		assert.Equal(t, value(i), v)
+		keys = append(keys, i)
+		return fn(k), nil
+	}
+	if reverse {
+		require.NoError(t, kv.ReverseScanInViewWithOptions(view, start, end, handler))
+	} else {
+		require.NoError(t, kv.ScanInViewWithOptions(view, start, end, handler))
+	}
+	return keys
+}
+
+func testScanInViewWithOptions(t *testing.T, kv storage.KVStorage) {
+	setKeys(t, kv, 10)
+	next := func(k []byte) storage.NextIterOptions { return storage.NextIterOptions{} }
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, scanWithOptions(t, kv, false, nil, nil, next))
+	assert.Equal(t, []int{2, 3, 4}, scanWithOptions(t, kv, false, key(2), key(5), next))
+
+	stop := 0
+	assert.Equal(t, []int{0, 1, 2}, scanWithOptions(t, kv, false, nil, nil, func(k []byte) storage.NextIterOptions {
+		stop++
+		return storage.NextIterOptions{Stop: stop == 3}
+	}))
+
+	// skip to k006 from k002
+	assert.Equal(t, []int{1, 2, 6, 7}, scanWithOptions(t, kv, false, key(1), key(8), func(k []byte) storage.NextIterOptions {
+		if string(k) == string(key(2)) {
+			return storage.NextIterOptions{SeekGE: key(6)}
+		}
+		return storage.NextIterOptions{}
+	}))
+
+	// seek back to k004 once from k006
+	seeked := false
+	assert.Equal(t, []int{3, 4, 5, 6, 4, 5, 6, 7}, scanWithOptions(t, kv, false, key(3), key(8), func(k []byte) storage.NextIterOptions {
+		if string(k) == string(key(6)) && !seeked {
+			seeked = true
+			return storage.NextIterOptions{SeekLT: key(5)}
+		}
+		return storage.NextIterOptions{}
+	}))
+
+	// seek out of the range
+	assert.Equal(t, []int{3}, scanWithOptions(t, kv, false, key(3), key(8), func(k []byte) storage.NextIterOptions {
+		return storage.NextIterOptions{SeekLT: key(3)}
+	}))
+	assert.Equal(t, []int{3}, scanWithOptions(t, kv, false, key(3), key(8), func(k []byte) storage.NextIterOptions {
+		return storage.NextIterOptions{SeekGE: key(8)}
+	}))
+}
+
+func testReverseScanInViewWithOptions(t *testing.T, kv storage.KVStorage) {
+	setKeys(t, kv, 10)
+	next := func(k []byte) storage.NextIterOptions { return storage.NextIterOptions{} }
+	assert.Equal(t, []int{4, 3, 2}, scanWithOptions(t, kv, true, key(2), key(5), next))
+	assert.Equal(t, []int{9, 8, 7}, scanWithOptions(t, kv, true, key(7), []byte("k999"), next))
+	assert.Equal(t, []int{2, 1, 0}, scanWithOptions(t, kv, true, []byte("k"), key(3), next))
+
+	stop := 0
+	assert.Equal(t, []int{8, 7}, scanWithOptions(t, kv, true, key(0), key(9), func(k []byte) storage.NextIterOptions {
+		stop++
+		return storage.NextIterOptions{Stop: stop == 2}
+	}))
+
+	// skip to k003 from k007
+	assert.Equal(t, []int{8, 7, 2, 1}, scanWithOptions(t, kv, true, key(1), key(9), func(k []byte) storage.NextIterOptions {
+		if string(k) == string(key(7)) {
+			return storage.NextIterOptions{SeekLT: key(3)}
+		}
+		return storage.NextIterOptions{}
+	}))
+
+	// seek forward to k006
+	seeked := false
+	assert.Equal(t, []int{7, 6, 5, 6, 5, 4}, scanWithOptions(t, kv, true, key(4), key(8), func(k []byte) storage.NextIterOptions {
+		if string(k) == string(key(5)) && !seeked {
+			seeked = true
+			return storage.NextIterOptions{SeekGE: key(6)}
+		}
+		return storage.NextIterOptions{}
+	}))
+}
+
+func testPrefixScan(t *testing.T, kv storage.KVStorage) {
+	require.NoError(t, kv.Set([]byte("a1"), []byte("1"), false))
+	require.NoError(t, kv.Set([]byte("b1"), []byte("2"), false))
+	require.NoError(t, kv.Set([]byte("b2"), []byte("3"), false))
+	require.NoError(t, kv.Set([]byte("c1"), []byte("4"), false))
+
+	for _, clone := range []bool{true, false} {
+		var keys []string
+		require.NoError(t, kv.PrefixScan([]byte("b"), func(k, v []byte) (bool, error) {
+			keys = append(keys, string(k))
+			return true, nil
+		}, clone))
+		assert.Equal(t, []string{"b1", "b2"}, keys)
+	}
+
+	var keys []string
+	require.NoError(t, kv.PrefixScan([]byte("b"), func(k, v []byte) (bool, error) {
+		keys = append(keys, string(k))
+		return false, nil
+	}, true))
+	assert.Equal(t, []string{"b1"}, keys)
+}
+
+func testScanPage(t *testing.T, kv storage.KVStorage) {
+	setKeys(t, kv, 10)
+	pairs, next, err := kv.ScanPage(key(2), key(8), 4, storage.ScanPageOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 4, len(pairs))
+	for i, p := range pairs {
+		assert.Equal(t, key(i+2), p.Key)
+		assert.Equal(t, value(i+2), p.Value)
+	}
+	assert.Equal(t, key(6), next)
+
+	pairs, next, err = kv.ScanPage(next, key(8), 4, storage.ScanPageOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(pairs))
+	assert.Empty(t, next, "range exhausted")
+
+	// each pair is 8 bytes
+	pairs, next, err = kv.ScanPage(key(0), key(8), 0, storage.ScanPageOptions{MaxBytes: 20})
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(pairs))
+	assert.Equal(t, key(3), next)
+	pairs, _, err = kv.ScanPage(key(0), key(8), 0, storage.ScanPageOptions{MaxBytes: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(pairs), "at least one pair")
+
+	view := kv.GetView()
+	defer func() {
+		require.NoError(t, view.Close())
+	}()
+	require.NoError(t, kv.Delete(key(0), false))
+	pairs, _, err = kv.ScanPage(key(0), key(1), 0, storage.ScanPageOptions{View: view})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(pairs))
+	assert.Equal(t, key(0), pairs[0].Key)
+}
+
+func testIterator(t *testing.T, kv storage.KVStorage) {
+	setKeys(t, kv, 10)
+	it := kv.NewIterator(key(1), key(9), storage.ScanPageOptions{PageSize: 3})
+	var keys [][]byte
+	for it.Next() {
+		assert.Equal(t, value(len(keys)+1), it.Value())
+		keys = append(keys, it.Key())
+	}
+	require.NoError(t, it.Error())
+	require.NoError(t, it.Close())
+	assert.False(t, it.Next())
+	require.Equal(t, 8, len(keys))
+	for i, k := range keys {
+		assert.Equal(t, key(i+1), k)
+	}
+
+	it = kv.NewIterator(key(20), nil, storage.ScanPageOptions{})
+	assert.False(t, it.Next())
+	require.NoError(t, it.Error())
+	require.NoError(t, it.Close())
+}
+
+func testRangeDelete(t *testing.T, kv storage.KVStorage) {
+	setKeys(t, kv, 10)
+	require.NoError(t, kv.RangeDelete(key(2), key(4), false))
+	requireKeys(t, kv, 0, 1, 4, 5, 6, 7, 8, 9)
+	require.NoError(t, kv.RangeDelete(key(8), nil, true))
+	requireKeys(t, kv, 0, 1, 4, 5, 6, 7)
+	require.NoError(t, kv.RangeDelete(nil, key(5), true))
+	requireKeys(t, kv, 5, 6, 7)
+	require.NoError(t, kv.RangeDelete(nil, nil, true))
+	requireKeys(t, kv)
+	// empty storage
+	require.NoError(t, kv.RangeDelete(nil, nil, true))
+}
+
+func testSeek(t *testing.T, kv storage.KVStorage) {
+	k, v, err := kv.Seek(nil)
+	require.NoError(t, err)
+	assert.Nil(t, k)
+	assert.Nil(t, v)
+
+	for _, i := range []int{2, 4, 6} {
+		require.NoError(t, kv.Set(key(i), value(i), false))
+	}
+	seekTests := []struct {
+		fn       func() ([]byte, []byte, error)
+		expected int
+	}{
+		{func() ([]byte, []byte, error) { return kv.Seek(key(0)) }, 2},
+		{func() ([]byte, []byte, error) { return kv.Seek(key(3)) }, 4},
+		{func() ([]byte, []byte, error) { return kv.Seek(key(4)) }, 4},
+		{func() ([]byte, []byte, error) { return kv.Seek(key(7)) }, -1},
+		{func() ([]byte, []byte, error) { return kv.SeekAndLT(key(3), key(6)) }, 4},
+		{func() ([]byte, []byte, error) { return kv.SeekAndLT(key(5), key(6)) }, -1},
+		{func() ([]byte, []byte, error) { return kv.SeekLT(key(5)) }, 4},
+		{func() ([]byte, []byte, error) { return kv.SeekLT(key(4)) }, 2},
+		{func() ([]byte, []byte, error) { return kv.SeekLT(key(9)) }, 6},
+		{func() ([]byte, []byte, error) { return kv.SeekLT(key(2)) }, -1},
+		{func() ([]byte, []byte, error) { return kv.SeekLTAndGE(key(6), key(3)) }, 4},
+		{func() ([]byte, []byte, error) { return kv.SeekLTAndGE(key(4), key(3)) }, -1},
+	}
+	for i, tt := range seekTests {
+		k, v, err := tt.fn()
+		require.NoError(t, err, "index %d", i)
+		if tt.expected < 0 {
+			assert.Nil(t, k, "index %d", i)
+			assert.Nil(t, v, "index %d", i)
+		} else {
+			assert.Equal(t, key(tt.expected), k, "index %d", i)
+			assert.Equal(t, value(tt.expected), v, "index %d", i)
+		}
+	}
+}
+
+func testSeekLTInView(t *testing.T, kv storage.KVStorage) {
+	for _, i := range []int{2, 4, 6} {
+		require.NoError(t, kv.Set(key(i), value(i), false))
+	}
+	view := kv.GetView()
+	defer func() {
+		require.NoError(t, view.Close())
+	}()
+	// not visible in the view
+	require.NoError(t, kv.Set(key(5), value(5), false))
+	require.NoError(t, kv.Set(key(8), value(8), false))
+
+	tests := []struct {
+		upperBound []byte
+		expected   int
+	}{
+		{key(5), 4},
+		{key(4), 2},
+		{key(9), 6},
+		{nil, 6},
+		{key(2), -1},
+	}
+	for i, tt := range tests {
+		k, v, err := kv.SeekLTInView(view, tt.upperBound)
+		require.NoError(t, err, "index %d", i)
+		if tt.expected < 0 {
+			assert.Nil(t, k, "index %d", i)
+			assert.Nil(t, v, "index %d", i)
+		} else {
+			assert.Equal(t, key(tt.expected), k, "index %d", i)
+			assert.Equal(t, value(tt.expected), v, "index %d", i)
+		}
+	}
+}
+
+func testSync(t *testing.T, kv storage.KVStorage) {
+	setKeys(t, kv, 3)
+	require.NoError(t, kv.Sync())
+	requireKeys(t, kv, 0, 1, 2)
+}
+
+func testStats(t *testing.T, kv storage.KVStorage) {
+	setKeys(t, kv, 3)
+	_, err := kv.Get(key(1))
+	require.NoError(t, err)
+	require.NoError(t, kv.Sync())
+	s := kv.Stats()
+	assert.True(t, s.WrittenKeys > 0)
+	assert.True(t, s.WrittenBytes > 0)
+	assert.True(t, s.ReadKeys > 0)
+	assert.True(t, s.ReadBytes > 0)
+	assert.True(t, s.SyncCount > 0)
+}
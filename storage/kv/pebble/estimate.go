@@ -0,0 +1,126 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pebble
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/matrixorigin/matrixcube/storage"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+)
+
+var _ storage.SizeEstimator = (*Storage)(nil)
+
+// tableEstimate the estimated data of a sstable within the estimated range
+type tableEstimate struct {
+	largest []byte
+	bytes   uint64
+}
+
+// EstimateSize estimates the size of [start, end) by the disk usage of the
+// range and the table properties of the overlapped sstables. The disk usage is
+// compressed, so it's scaled by the ratio between the raw key-value size and
+// the file size of the overlapped sstables. The data in the memtables is not
+// included.
+func (s *Storage) EstimateSize(start, end []byte) (uint64, uint64, error) {
+	usage, err := s.db.EstimateDiskUsage(start, end)
+	if err != nil {
+		return 0, 0, err
+	}
+	if usage == 0 {
+		return 0, 0, nil
+	}
+
+	var size, raw, entries uint64
+	if err := s.overlappedTables(start, end, func(t pebble.SSTableInfo, _ bool) {
+		size += t.Size
+		raw += t.Properties.RawKeySize + t.Properties.RawValueSize
+		entries += t.Properties.NumEntries
+	}); err != nil {
+		return 0, 0, err
+	}
+	if size == 0 {
+		return 0, 0, nil
+	}
+	return scale(usage, raw, size), scale(usage, entries, size), nil
+}
+
+// EstimateSplitKeys estimates the split keys of [start, end) by the largest
+// keys of the overlapped sstables. The sstables are ordered by their largest
+// keys, and the largest key of a sstable becomes a split key once the raw size
+// of the sstables since the last split key reaches the size. The sstables
+// partially overlapped with the range are counted as half of their raw size.
+func (s *Storage) EstimateSplitKeys(start, end []byte, size uint64) ([][]byte, error) {
+	var tables []tableEstimate
+	total := uint64(0)
+	if err := s.overlappedTables(start, end, func(t pebble.SSTableInfo, contained bool) {
+		n := t.Properties.RawKeySize + t.Properties.RawValueSize
+		if !contained {
+			n /= 2
+		}
+		tables = append(tables, tableEstimate{largest: t.Largest.UserKey, bytes: n})
+		total += n
+	}); err != nil {
+		return nil, err
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return bytes.Compare(tables[i].largest, tables[j].largest) < 0
+	})
+
+	var splitKeys [][]byte
+	sum := uint64(0)
+	for _, t := range tables {
+		sum += t.bytes
+		total -= t.bytes
+		if sum < size || total == 0 {
+			continue
+		}
+		if bytes.Compare(t.largest, start) <= 0 || bytes.Compare(t.largest, end) >= 0 {
+			continue
+		}
+		if n := len(splitKeys); n > 0 && bytes.Compare(t.largest, splitKeys[n-1]) <= 0 {
+			continue
+		}
+		splitKeys = append(splitKeys, keysutil.Clone(t.largest))
+		sum = 0
+	}
+	return splitKeys, nil
+}
+
+// overlappedTables calls the fn with each sstable overlapped with [start, end)
+// in all levels, contained is true if the sstable is in the range entirely.
+func (s *Storage) overlappedTables(start, end []byte,
+	fn func(t pebble.SSTableInfo, contained bool)) error {
+	levels, err := s.db.SSTables(pebble.WithProperties())
+	if err != nil {
+		return err
+	}
+	for _, tables := range levels {
+		for _, t := range tables {
+			smallest, largest := t.Smallest.UserKey, t.Largest.UserKey
+			if bytes.Compare(largest, start) < 0 || bytes.Compare(smallest, end) >= 0 {
+				continue
+			}
+			fn(t, bytes.Compare(smallest, start) >= 0 && bytes.Compare(largest, end) < 0)
+		}
+	}
+	return nil
+}
+
+// scale returns v * numerator / denominator without overflow
+func scale(v, numerator, denominator uint64) uint64 {
+	return uint64(float64(v) * (float64(numerator) / float64(denominator)))
+}
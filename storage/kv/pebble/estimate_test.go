@@ -0,0 +1,76 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pebble
+
+import (
+	"fmt"
+	"testing"
+
+	cpebble "github.com/cockroachdb/pebble"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateSize(t *testing.T) {
+	opts := &cpebble.Options{
+		FS: vfs.NewPebbleFS(vfs.NewMemFS()),
+		// keep a sstable per flush
+		L0CompactionThreshold: 100,
+		L0StopWritesThreshold: 100,
+	}
+	s, err := NewStorage("test-data", nil, opts)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+
+	bytes, keys, err := s.EstimateSize([]byte("k"), []byte("l"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), bytes)
+	assert.Equal(t, uint64(0), keys)
+
+	value := make([]byte, 100)
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 100; j++ {
+			key := []byte(fmt.Sprintf("k%04d", i*100+j))
+			require.NoError(t, s.Set(key, value, false))
+		}
+		require.NoError(t, s.db.Flush())
+	}
+
+	// 1000 keys, each key-value pair is 105 bytes and 8 bytes internal trailer
+	bytes, keys, err = s.EstimateSize([]byte("k"), []byte("l"))
+	require.NoError(t, err)
+	assert.InDelta(t, uint64(113000), bytes, 113000*0.2)
+	assert.InDelta(t, uint64(1000), keys, 1000*0.2)
+
+	bytes, keys, err = s.EstimateSize([]byte("m"), []byte("n"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), bytes)
+	assert.Equal(t, uint64(0), keys)
+
+	splitKeys, err := s.EstimateSplitKeys([]byte("k"), []byte("l"), 3*11300)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("k0299"), []byte("k0599"), []byte("k0899")}, splitKeys)
+
+	splitKeys, err = s.EstimateSplitKeys([]byte("k"), []byte("l"), 200000)
+	require.NoError(t, err)
+	assert.Empty(t, splitKeys)
+
+	// the largest key of the last sstable is the end of the range
+	splitKeys, err = s.EstimateSplitKeys([]byte("k"), []byte("k0999"), 3*11300)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("k0299"), []byte("k0599"), []byte("k0899")}, splitKeys)
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pebble
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/vfs"
+)
+
+var _ storage.SSTIngester = (*Storage)(nil)
+
+// IngestExternalFiles atomically ingests the SST files into the storage. The
+// files are hard linked into the data dir if they're in the same fs as the
+// storage, otherwise they're copied into the data dir first, so the external
+// files are kept. The ingestion is recorded in the MANIFEST, the ingested
+// key-value pairs are persistent on return even if the WAL is disabled.
+func (s *Storage) IngestExternalFiles(fs vfs.FS, files []string) error {
+	paths := make([]string, 0, len(files))
+	defer func() {
+		// the staged files are removed by pebble once ingested
+		for _, path := range paths {
+			_ = s.fs.Remove(path)
+		}
+	}()
+	for i, file := range files {
+		path := s.fs.PathJoin(s.dir, fmt.Sprintf("ingest-%d.tmp", i))
+		if err := s.stageFile(fs, file, path); err != nil {
+			return err
+		}
+		paths = append(paths, path)
+	}
+	return s.db.Ingest(paths)
+}
+
+func (s *Storage) stageFile(fs vfs.FS, from, to string) error {
+	// the staged file may be left by the last failed ingestion
+	_ = s.fs.Remove(to)
+	if s.isFS(fs) {
+		if err := s.fs.Link(from, to); err == nil {
+			return nil
+		}
+	}
+	return s.copyFile(fs, from, to)
+}
+
+func (s *Storage) isFS(fs vfs.FS) bool {
+	p, ok := s.fs.(*vfs.PebbleFS)
+	return ok && p.GetVFS() == fs
+}
+
+func (s *Storage) copyFile(fs vfs.FS, from, to string) (err error) {
+	src, err := fs.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := s.fs.Create(to)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := dst.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	if _, err := io.Copy(dst, src); err != nil {
+		return err
+	}
+	return dst.Sync()
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pebble
+
+import (
+	"testing"
+
+	cpebble "github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/sstable"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestIngestSST(t *testing.T, fs vfs.FS, file string, keys ...string) {
+	f, err := fs.Create(file)
+	require.NoError(t, err)
+	w := sstable.NewWriter(f, sstable.WriterOptions{})
+	for _, k := range keys {
+		require.NoError(t, w.Set([]byte(k), []byte(k)))
+	}
+	require.NoError(t, w.Close())
+}
+
+func TestIngestExternalFiles(t *testing.T) {
+	for _, sameFS := range []bool{true, false} {
+		fs := vfs.NewMemFS()
+		dbFS := fs
+		if !sameFS {
+			dbFS = vfs.NewMemFS()
+		}
+		s, err := NewStorage("data", nil, &cpebble.Options{FS: vfs.NewPebbleFS(dbFS)})
+		require.NoError(t, err)
+		assert.Equal(t, sameFS, s.isFS(fs))
+
+		require.NoError(t, s.Set([]byte("a"), []byte("old"), false))
+		require.NoError(t, fs.MkdirAll("snapshot", 0755))
+		writeTestIngestSST(t, fs, "snapshot/1.sst", "a", "b")
+		writeTestIngestSST(t, fs, "snapshot/2.sst", "c")
+		require.NoError(t, s.IngestExternalFiles(fs, []string{"snapshot/1.sst", "snapshot/2.sst"}))
+
+		for _, k := range []string{"a", "b", "c"} {
+			v, err := s.Get([]byte(k))
+			assert.NoError(t, err)
+			assert.Equal(t, k, string(v))
+		}
+		// the external files are kept, and the staged files are removed
+		_, err = fs.Stat("snapshot/1.sst")
+		assert.NoError(t, err)
+		names, err := dbFS.List("data")
+		require.NoError(t, err)
+		for _, name := range names {
+			assert.NotContains(t, name, "ingest-")
+		}
+		require.NoError(t, s.Close())
+	}
+}
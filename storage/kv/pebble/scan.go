@@ -0,0 +1,109 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pebble
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/cockroachdb/pebble"
+	pbvfs "github.com/cockroachdb/pebble/vfs"
+	"github.com/matrixorigin/matrixcube/storage"
+)
+
+var _ storage.BackgroundScanStore = (*Storage)(nil)
+
+// reader is the source of the iterators of a view, i.e. the snapshot of the db
+// or the db of a checkpoint.
+type reader interface {
+	NewIter(o *pebble.IterOptions) *pebble.Iterator
+}
+
+func viewReader(view storage.View) reader {
+	return view.Raw().(reader)
+}
+
+// backgroundView is the view of a checkpoint of the storage opened in the read
+// only mode, the blocks read by the view are cached by the small block cache
+// of the background scans, see SetBackgroundScanCacheSize.
+type backgroundView struct {
+	db  *pebble.DB
+	dir string
+	fs  pbvfs.FS
+}
+
+func (v *backgroundView) Close() error {
+	err := v.db.Close()
+	if rerr := v.fs.RemoveAll(v.dir); err == nil {
+		err = rerr
+	}
+	return err
+}
+
+func (v *backgroundView) Raw() interface{} {
+	return v.db
+}
+
+// SetBackgroundScanCacheSize enables the views of the background scans, e.g.
+// the split check, the stats and the checksum scans, with a block cache of the
+// size. The pebble in use can not skip filling the block cache per iterator,
+// so these scans read a checkpoint of the storage instead, the checkpoint is
+// opened with its own block cache and the blocks of the large scans never
+// evict the hot blocks of the foreground reads. A checkpoint flushes the WAL,
+// links the SSTs and copies the WALs of the unflushed memtables, so it's only
+// worth for the large scans. 0 disables it, the background scans use GetView. It's not safe to call
+// it while the background views are in use.
+func (s *Storage) SetBackgroundScanCacheSize(size int64) {
+	if s.scanCache != nil {
+		s.scanCache.Unref()
+		s.scanCache = nil
+	}
+	// the checkpoints left by the crash
+	_ = s.fs.RemoveAll(s.scanDir())
+	if size > 0 {
+		s.scanCache = pebble.NewCache(size)
+	}
+}
+
+// GetBackgroundView returns a point in time view backed by a checkpoint of the
+// storage. The view of the snapshot is returned if the background scans are
+// not enabled, or the storage can not create the complete checkpoints, i.e. the
+// WAL is disabled or the SSTs are shared.
+func (s *Storage) GetBackgroundView() (storage.View, error) {
+	if s.scanCache == nil || s.walDisabled || s.shared != nil {
+		return s.GetView(), nil
+	}
+
+	dir := s.fs.PathJoin(s.scanDir(),
+		fmt.Sprintf("%d", atomic.AddUint64(&s.scanSeq, 1)))
+	if err := s.db.Checkpoint(dir, pebble.WithFlushedWAL()); err != nil {
+		return nil, err
+	}
+	opts := s.opts.Clone()
+	opts.ReadOnly = true
+	opts.Cache = s.scanCache
+	opts.WALDir = ""
+	opts.EventListener = pebble.EventListener{}
+	db, err := pebble.Open(dir, opts)
+	if err != nil {
+		_ = s.fs.RemoveAll(dir)
+		return nil, err
+	}
+	return &backgroundView{db: db, dir: dir, fs: s.fs}, nil
+}
+
+// scanDir the dir of the checkpoints of the background views
+func (s *Storage) scanDir() string {
+	return s.dir + ".scan"
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pebble
+
+import (
+	"fmt"
+	"testing"
+
+	cpebble "github.com/cockroachdb/pebble"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackgroundView(t *testing.T) {
+	fs := vfs.NewMemFS()
+	opts := &cpebble.Options{FS: vfs.NewPebbleFS(fs)}
+	kv, err := NewStorage("test-data", nil, opts)
+	require.NoError(t, err)
+	defer kv.Close()
+
+	for i := 0; i < 10; i++ {
+		k := []byte(fmt.Sprintf("k%d", i))
+		require.NoError(t, kv.Set(k, k, false))
+	}
+	require.NoError(t, kv.db.Flush())
+	// the unflushed writes are seen by the checkpoint too
+	require.NoError(t, kv.Set([]byte("k10"), []byte("k10"), false))
+
+	scan := func(view storage.View) int {
+		n := 0
+		require.NoError(t, kv.ScanInView(view, nil, nil, func(key, value []byte) (bool, error) {
+			assert.Equal(t, key, value)
+			n++
+			return true, nil
+		}, false))
+		return n
+	}
+	blocks := func() int64 {
+		m := kv.db.Metrics()
+		return m.BlockCache.Hits + m.BlockCache.Misses
+	}
+
+	view, err := kv.GetBackgroundView()
+	require.NoError(t, err)
+	_, ok := view.(*backgroundView)
+	assert.False(t, ok, "disabled by default")
+	assert.Equal(t, 11, scan(view))
+	assert.NoError(t, view.Close())
+
+	kv.SetBackgroundScanCacheSize(1024 * 1024)
+	view, err = storage.GetBackgroundView(kv)
+	require.NoError(t, err)
+	bv, ok := view.(*backgroundView)
+	require.True(t, ok)
+	require.NoError(t, kv.Set([]byte("k11"), []byte("k11"), false))
+
+	before := blocks()
+	assert.Equal(t, 11, scan(view), "point in time view")
+	n := 0
+	require.NoError(t, kv.ScanInViewWithOptions(view, []byte("k5"), nil, func(key, value []byte) (storage.NextIterOptions, error) {
+		n++
+		return storage.NextIterOptions{}, nil
+	}))
+	assert.Equal(t, 5, n)
+	assert.Equal(t, before, blocks(), "block cache of the storage not touched")
+
+	assert.NoError(t, view.Close())
+	_, err = fs.Stat(bv.dir)
+	assert.Error(t, err, "checkpoint removed")
+
+	kv.SetBackgroundScanCacheSize(0)
+	view, err = kv.GetBackgroundView()
+	require.NoError(t, err)
+	_, ok = view.(*backgroundView)
+	assert.False(t, ok)
+	assert.Equal(t, 12, scan(view))
+	assert.True(t, blocks() > before)
+	assert.NoError(t, view.Close())
+}
+
+func TestBackgroundViewWithWALDisabled(t *testing.T) {
+	opts := &cpebble.Options{
+		FS:         vfs.NewPebbleFS(vfs.NewMemFS()),
+		DisableWAL: true,
+	}
+	kv, err := NewStorage("test-data", nil, opts)
+	require.NoError(t, err)
+	defer kv.Close()
+
+	kv.SetBackgroundScanCacheSize(1024 * 1024)
+	view, err := kv.GetBackgroundView()
+	require.NoError(t, err)
+	defer view.Close()
+	_, ok := view.(*backgroundView)
+	assert.False(t, ok, "the memtables are not in the checkpoint")
+}
@@ -0,0 +1,483 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pebble
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/pebble"
+	pbvfs "github.com/cockroachdb/pebble/vfs"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"go.uber.org/zap"
+)
+
+const (
+	sstSuffix = ".sst"
+)
+
+// ObjectStorage is the shared object storage used to store the immutable SSTs,
+// e.g. S3 or a NFS mount. The object names are flat, and the not exist error
+// must be recognized by vfs.IsNotExist.
+type ObjectStorage interface {
+	// Put writes the object
+	Put(name string, r io.Reader) error
+	// Get returns the reader of the object
+	Get(name string) (io.ReadCloser, error)
+	// Size returns the size of the object
+	Size(name string) (int64, error)
+	// Delete deletes the object
+	Delete(name string) error
+	// List returns the names of all objects
+	List() ([]string, error)
+}
+
+// SharedSSTOptions the options of the shared SST tier
+type SharedSSTOptions struct {
+	// Objects the shared object storage
+	Objects ObjectStorage
+	// Prefix the prefix of the object names, the stores sharing the same object
+	// storage must use different prefixes.
+	Prefix string
+	// CacheBytes the max bytes of the SSTs cached on the local disk, the SSTs in
+	// use are never evicted. 0 means no limit.
+	CacheBytes uint64
+}
+
+// NewSharedSSTStorage is an experimental pebble backed kv store, the immutable
+// SSTs are stored in the shared object storage, only the WAL and the small
+// metadata files, e.g. MANIFEST, are local. The SSTs are cached on the local
+// disk, and the SSTs of the hot shards set by SetHotShards are evicted last.
+// A compute node can be replaced by copying its local files except the SSTs to
+// the new node.
+func NewSharedSSTStorage(dir string, logger *zap.Logger, opts *pebble.Options,
+	shared SharedSSTOptions) (*Storage, error) {
+	if opts.FS == nil {
+		opts.FS = vfs.DefaultPebbleFS
+	}
+	fs := newSharedSSTFS(opts.FS, dir, shared)
+	opts.FS = fs
+	s, err := NewStorage(dir, logger, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.shared = fs
+	return s, nil
+}
+
+// SetHotShards sets the shards whose SSTs are evicted last from the local cache,
+// e.g. the shards which have the lease holder replicas on the store. It's no-op
+// if the storage is not created by NewSharedSSTStorage.
+func (s *Storage) SetHotShards(shards []metapb.Shard) error {
+	if s.shared == nil {
+		return nil
+	}
+
+	levels, err := s.db.SSTables()
+	if err != nil {
+		return err
+	}
+	hot := make(map[string]struct{})
+	for _, tables := range levels {
+		for _, t := range tables {
+			for _, shard := range shards {
+				start := keysutil.EncodeShardStart(shard.Start, nil)
+				end := keysutil.EncodeShardEnd(shard.End, nil)
+				if bytes.Compare(t.Smallest.UserKey, end) < 0 &&
+					bytes.Compare(t.Largest.UserKey, start) >= 0 {
+					hot[t.FileNum.String()+sstSuffix] = struct{}{}
+					break
+				}
+			}
+		}
+	}
+	s.shared.setHot(hot)
+	return nil
+}
+
+type cachedSST struct {
+	size     int64
+	refs     int
+	lastUsed uint64
+}
+
+// sharedSSTFS is a pebble vfs.FS, the SSTs in the data dir are uploaded to the
+// object storage after written, and downloaded to the local cache on open.
+type sharedSSTFS struct {
+	pbvfs.FS
+	dir  string
+	opts SharedSSTOptions
+
+	mu struct {
+		sync.Mutex
+		clock  uint64
+		bytes  int64
+		hot    map[string]struct{}
+		cached map[string]*cachedSST
+	}
+}
+
+func newSharedSSTFS(fs pbvfs.FS, dir string, opts SharedSSTOptions) *sharedSSTFS {
+	s := &sharedSSTFS{FS: fs, dir: filepath.Clean(dir), opts: opts}
+	s.mu.hot = make(map[string]struct{})
+	s.mu.cached = make(map[string]*cachedSST)
+	return s
+}
+
+func (s *sharedSSTFS) isSST(name string) bool {
+	return strings.HasSuffix(name, sstSuffix) &&
+		filepath.Clean(s.FS.PathDir(name)) == s.dir
+}
+
+func (s *sharedSSTFS) objectName(name string) string {
+	return s.opts.Prefix + s.FS.PathBase(name)
+}
+
+func (s *sharedSSTFS) Create(name string) (pbvfs.File, error) {
+	f, err := s.FS.Create(name)
+	if err != nil || !s.isSST(name) {
+		return f, err
+	}
+	return &sharedSSTFile{File: f, fs: s, name: name}, nil
+}
+
+func (s *sharedSSTFS) Link(oldname, newname string) error {
+	if err := s.FS.Link(oldname, newname); err != nil {
+		return err
+	}
+	if !s.isSST(newname) {
+		return nil
+	}
+	return s.upload(newname)
+}
+
+func (s *sharedSSTFS) Open(name string, opts ...pbvfs.OpenOption) (pbvfs.File, error) {
+	if !s.isSST(name) {
+		return s.FS.Open(name, opts...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.ensureCachedLocked(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := s.FS.Open(name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.refs++
+	s.mu.clock++
+	c.lastUsed = s.mu.clock
+	return &cachedSSTFile{File: f, fs: s, name: name}, nil
+}
+
+func (s *sharedSSTFS) Remove(name string) error {
+	if !s.isSST(name) {
+		return s.FS.Remove(name)
+	}
+
+	if err := s.opts.Objects.Delete(s.objectName(name)); err != nil && !vfs.IsNotExist(err) {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.mu.cached[name]; ok {
+		s.mu.bytes -= c.size
+		delete(s.mu.cached, name)
+	}
+	if err := s.FS.Remove(name); err != nil && !vfs.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *sharedSSTFS) List(dir string) ([]string, error) {
+	names, err := s.FS.List(dir)
+	if err != nil || filepath.Clean(dir) != s.dir {
+		return names, err
+	}
+
+	objects, err := s.opts.Objects.List()
+	if err != nil {
+		return nil, err
+	}
+	local := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		local[name] = struct{}{}
+	}
+	for _, object := range objects {
+		if !strings.HasPrefix(object, s.opts.Prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(object, s.opts.Prefix)
+		if _, ok := local[name]; !ok && strings.HasSuffix(name, sstSuffix) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (s *sharedSSTFS) Stat(name string) (os.FileInfo, error) {
+	info, err := s.FS.Stat(name)
+	if err == nil || !vfs.IsNotExist(err) || !s.isSST(name) {
+		return info, err
+	}
+
+	size, err := s.opts.Objects.Size(s.objectName(name))
+	if err != nil {
+		return nil, err
+	}
+	return objectFileInfo{name: s.FS.PathBase(name), size: size}, nil
+}
+
+func (s *sharedSSTFS) setHot(hot map[string]struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mu.hot = hot
+	s.evictLocked()
+}
+
+// upload uploads the written SST to the object storage and adds it to the cache
+func (s *sharedSSTFS) upload(name string) error {
+	f, err := s.FS.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := s.opts.Objects.Put(s.objectName(name), f); err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addCachedLocked(name, info.Size())
+	s.evictLocked()
+	return nil
+}
+
+func (s *sharedSSTFS) ensureCachedLocked(name string) (*cachedSST, error) {
+	if c, ok := s.mu.cached[name]; ok {
+		return c, nil
+	}
+
+	// cached by the previous process
+	if info, err := s.FS.Stat(name); err == nil {
+		return s.addCachedLocked(name, info.Size()), nil
+	}
+
+	r, err := s.opts.Objects.Get(s.objectName(name))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	tmp := name + ".tmp"
+	f, err := s.FS.Create(tmp)
+	if err != nil {
+		return nil, err
+	}
+	size, err := io.Copy(f, r)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = s.FS.Rename(tmp, name)
+	}
+	if err != nil {
+		_ = s.FS.Remove(tmp)
+		return nil, err
+	}
+	c := s.addCachedLocked(name, size)
+	s.evictLocked()
+	return c, nil
+}
+
+func (s *sharedSSTFS) addCachedLocked(name string, size int64) *cachedSST {
+	if c, ok := s.mu.cached[name]; ok {
+		return c
+	}
+	s.mu.clock++
+	c := &cachedSST{size: size, lastUsed: s.mu.clock}
+	s.mu.cached[name] = c
+	s.mu.bytes += size
+	return c
+}
+
+func (s *sharedSSTFS) release(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.mu.cached[name]; ok {
+		c.refs--
+	}
+	s.evictLocked()
+}
+
+// evictLocked removes the least recently used SSTs which are not in use from
+// the local cache until the cache bytes is under the limit, the SSTs of the hot
+// shards are evicted after all the others.
+func (s *sharedSSTFS) evictLocked() {
+	if s.opts.CacheBytes == 0 {
+		return
+	}
+	for s.mu.bytes > int64(s.opts.CacheBytes) {
+		victim := ""
+		var victimCached *cachedSST
+		victimHot := true
+		for name, c := range s.mu.cached {
+			if c.refs > 0 {
+				continue
+			}
+			_, hot := s.mu.hot[s.FS.PathBase(name)]
+			if victimCached == nil ||
+				(victimHot && !hot) ||
+				(victimHot == hot && c.lastUsed < victimCached.lastUsed) {
+				victim, victimCached, victimHot = name, c, hot
+			}
+		}
+		if victimCached == nil {
+			return
+		}
+		if err := s.FS.Remove(victim); err != nil && !vfs.IsNotExist(err) {
+			return
+		}
+		s.mu.bytes -= victimCached.size
+		delete(s.mu.cached, victim)
+	}
+}
+
+// sharedSSTFile is the SST being written, it's uploaded on close. Pebble syncs
+// and closes the SST before it's added to the MANIFEST, so the SST in the
+// MANIFEST is always in the object storage.
+type sharedSSTFile struct {
+	pbvfs.File
+	fs   *sharedSSTFS
+	name string
+}
+
+func (f *sharedSSTFile) Close() error {
+	if err := f.File.Close(); err != nil {
+		return err
+	}
+	return f.fs.upload(f.name)
+}
+
+// cachedSSTFile is the SST opened from the local cache, it can not be evicted
+// until closed.
+type cachedSSTFile struct {
+	pbvfs.File
+	fs   *sharedSSTFS
+	name string
+	once sync.Once
+}
+
+func (f *cachedSSTFile) Close() error {
+	err := f.File.Close()
+	f.once.Do(func() { f.fs.release(f.name) })
+	return err
+}
+
+type objectFileInfo struct {
+	name string
+	size int64
+}
+
+func (i objectFileInfo) Name() string       { return i.name }
+func (i objectFileInfo) Size() int64        { return i.size }
+func (i objectFileInfo) Mode() os.FileMode  { return 0644 }
+func (i objectFileInfo) ModTime() time.Time { return time.Time{} }
+func (i objectFileInfo) IsDir() bool        { return false }
+func (i objectFileInfo) Sys() interface{}   { return nil }
+
+// fsObjectStorage is an ObjectStorage backed by a directory, e.g. a NFS mount.
+type fsObjectStorage struct {
+	fs  vfs.FS
+	dir string
+}
+
+// NewFSObjectStorage returns an ObjectStorage which stores the objects in the
+// dir of the fs.
+func NewFSObjectStorage(fs vfs.FS, dir string) (ObjectStorage, error) {
+	if err := fs.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &fsObjectStorage{fs: fs, dir: dir}, nil
+}
+
+func (s *fsObjectStorage) Put(name string, r io.Reader) error {
+	tmp := s.fs.PathJoin(s.dir, name+".tmp")
+	f, err := s.fs.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = s.fs.Remove(tmp)
+		return err
+	}
+	return s.fs.Rename(tmp, s.fs.PathJoin(s.dir, name))
+}
+
+func (s *fsObjectStorage) Get(name string) (io.ReadCloser, error) {
+	return s.fs.Open(s.fs.PathJoin(s.dir, name))
+}
+
+func (s *fsObjectStorage) Size(name string) (int64, error) {
+	info, err := s.fs.Stat(s.fs.PathJoin(s.dir, name))
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (s *fsObjectStorage) Delete(name string) error {
+	err := s.fs.Remove(s.fs.PathJoin(s.dir, name))
+	if err != nil && vfs.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *fsObjectStorage) List() ([]string, error) {
+	names, err := s.fs.List(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	values := names[:0]
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".tmp") {
+			values = append(values, name)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pebble
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	cpebble "github.com/cockroachdb/pebble"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSharedDataDir   = "/tmp/shared-sst-data-safe-to-delete"
+	testSharedObjectDir = "/tmp/shared-sst-objects-safe-to-delete"
+)
+
+func newTestSharedSSTStorage(t *testing.T, fs vfs.FS, objects ObjectStorage, cacheBytes uint64) *Storage {
+	s, err := NewSharedSSTStorage(testSharedDataDir, nil,
+		&cpebble.Options{FS: vfs.NewPebbleFS(fs)},
+		SharedSSTOptions{Objects: objects, Prefix: "store1-", CacheBytes: cacheBytes})
+	require.NoError(t, err)
+	return s
+}
+
+func writeTestSharedSST(t *testing.T, s *Storage, start, end int) {
+	for i := start; i < end; i++ {
+		key := keysutil.EncodeDataKey([]byte(fmt.Sprintf("k%04d", i)), nil)
+		require.NoError(t, s.Set(key, []byte(fmt.Sprintf("v%04d", i)), false))
+	}
+	require.NoError(t, s.db.Flush())
+}
+
+func getTestLocalSSTs(t *testing.T, fs vfs.FS) []string {
+	names, err := fs.List(testSharedDataDir)
+	require.NoError(t, err)
+	var values []string
+	for _, name := range names {
+		if strings.HasSuffix(name, sstSuffix) {
+			values = append(values, name)
+		}
+	}
+	return values
+}
+
+func TestSharedSSTStorageUploadsSSTs(t *testing.T) {
+	fs := vfs.NewMemFS()
+	objects, err := NewFSObjectStorage(fs, testSharedObjectDir)
+	require.NoError(t, err)
+	s := newTestSharedSSTStorage(t, fs, objects, 0)
+	writeTestSharedSST(t, s, 0, 10)
+
+	names, err := objects.List()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(names))
+	assert.True(t, strings.HasPrefix(names[0], "store1-"))
+	assert.Equal(t, getTestLocalSSTs(t, fs), []string{strings.TrimPrefix(names[0], "store1-")})
+	require.NoError(t, s.Close())
+}
+
+func TestSharedSSTStorageReopenWithoutLocalSSTs(t *testing.T) {
+	fs := vfs.NewMemFS()
+	objects, err := NewFSObjectStorage(fs, testSharedObjectDir)
+	require.NoError(t, err)
+	s := newTestSharedSSTStorage(t, fs, objects, 0)
+	writeTestSharedSST(t, s, 0, 10)
+	require.NoError(t, s.Close())
+
+	// the node is replaced, only the local metadata files are kept
+	for _, name := range getTestLocalSSTs(t, fs) {
+		require.NoError(t, fs.Remove(fs.PathJoin(testSharedDataDir, name)))
+	}
+	require.Empty(t, getTestLocalSSTs(t, fs))
+
+	s = newTestSharedSSTStorage(t, fs, objects, 0)
+	defer s.Close()
+	for i := 0; i < 10; i++ {
+		key := keysutil.EncodeDataKey([]byte(fmt.Sprintf("k%04d", i)), nil)
+		v, err := s.Get(key)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("v%04d", i), string(v))
+	}
+	assert.Equal(t, 1, len(getTestLocalSSTs(t, fs)))
+}
+
+func TestSharedSSTStorageRemoveDeletesObjects(t *testing.T) {
+	fs := vfs.NewMemFS()
+	objects, err := NewFSObjectStorage(fs, testSharedObjectDir)
+	require.NoError(t, err)
+	s := newTestSharedSSTStorage(t, fs, objects, 0)
+	defer s.Close()
+	writeTestSharedSST(t, s, 0, 10)
+	writeTestSharedSST(t, s, 10, 20)
+	require.NoError(t, s.db.Compact([]byte{0}, []byte{0xff}))
+
+	levels, err := s.db.SSTables()
+	require.NoError(t, err)
+	n := 0
+	for _, tables := range levels {
+		n += len(tables)
+	}
+	names, err := objects.List()
+	require.NoError(t, err)
+	assert.Equal(t, n, len(names))
+}
+
+func TestSharedSSTCacheEviction(t *testing.T) {
+	fs := vfs.NewMemFS()
+	objects, err := NewFSObjectStorage(fs, testSharedObjectDir)
+	require.NoError(t, err)
+	sfs := newSharedSSTFS(vfs.NewPebbleFS(fs), testSharedDataDir, SharedSSTOptions{
+		Objects:    objects,
+		CacheBytes: 10,
+	})
+	require.NoError(t, fs.MkdirAll(testSharedDataDir, 0755))
+
+	create := func(name string) string {
+		name = fs.PathJoin(testSharedDataDir, name)
+		f, err := sfs.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte("12345"))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		return name
+	}
+	cached := func(name string) bool {
+		_, err := fs.Stat(name)
+		return err == nil
+	}
+
+	f1 := create("000001.sst")
+	f2 := create("000002.sst")
+	assert.True(t, cached(f1))
+	assert.True(t, cached(f2))
+
+	// f1 is hot, so f2 is evicted even if used later
+	sfs.setHot(map[string]struct{}{"000001.sst": {}})
+	f3 := create("000003.sst")
+	assert.True(t, cached(f1))
+	assert.False(t, cached(f2))
+	assert.True(t, cached(f3))
+
+	// the file in use can not be evicted
+	sfs.setHot(nil)
+	f, err := sfs.Open(f3)
+	require.NoError(t, err)
+	f4 := create("000004.sst")
+	assert.False(t, cached(f1))
+	assert.True(t, cached(f3))
+	assert.True(t, cached(f4))
+	require.NoError(t, f.Close())
+
+	// download on open
+	f, err = sfs.Open(f2)
+	require.NoError(t, err)
+	assert.True(t, cached(f2))
+	assert.False(t, cached(f3))
+	data := make([]byte, 5)
+	_, err = f.ReadAt(data, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "12345", string(data))
+	require.NoError(t, f.Close())
+
+	names, err := sfs.List(testSharedDataDir)
+	require.NoError(t, err)
+	assert.Equal(t, 4, len(names))
+	info, err := sfs.Stat(f1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), info.Size())
+}
+
+func TestSetHotShards(t *testing.T) {
+	fs := vfs.NewMemFS()
+	objects, err := NewFSObjectStorage(fs, testSharedObjectDir)
+	require.NoError(t, err)
+	s := newTestSharedSSTStorage(t, fs, objects, 0)
+	defer s.Close()
+	writeTestSharedSST(t, s, 0, 10)
+	writeTestSharedSST(t, s, 10, 20)
+
+	require.NoError(t, s.SetHotShards([]metapb.Shard{{Start: []byte("k0015"), End: []byte("k0016")}}))
+	assert.Equal(t, 1, len(s.shared.mu.hot))
+	require.NoError(t, s.SetHotShards([]metapb.Shard{{}}))
+	assert.Equal(t, 2, len(s.shared.mu.hot))
+	require.NoError(t, s.SetHotShards(nil))
+	assert.Empty(t, s.shared.mu.hot)
+}
@@ -15,9 +15,12 @@ package pebble
 
 import (
 	"bytes"
+	"sort"
 	"sync/atomic"
+	"time"
 
 	"github.com/cockroachdb/pebble"
+	pbvfs "github.com/cockroachdb/pebble/vfs"
 	"github.com/matrixorigin/matrixcube/components/log"
 	"github.com/matrixorigin/matrixcube/keys"
 	"github.com/matrixorigin/matrixcube/storage"
@@ -42,15 +45,59 @@ func (v *view) Raw() interface{} {
 
 // Storage returns a kv storage based on badger
 type Storage struct {
-	db    *pebble.DB
-	stats stats.Stats
+	// slowReadThreshold the reads slower than it are traced, see
+	// SetSlowReadThreshold.
+	slowReadThreshold int64
+
+	db     *pebble.DB
+	dir    string
+	fs     pbvfs.FS
+	logger *zap.Logger
+	stats  stats.Stats
+	// walDisabled the storage is opened with the WAL disabled, the writes are
+	// persistent only after the memtable flushed.
+	walDisabled bool
+	// shared the SSTs are stored in the shared object storage, see
+	// NewSharedSSTStorage.
+	shared *sharedSSTFS
+	// stallStart the unix nanos when the current write stall began, 0 if the
+	// writes are not stalled
+	stallStart int64
+	// memTableSize and maxConcurrentCompactions the options the storage is
+	// opened with, reported by Stats
+	memTableSize             uint64
+	maxConcurrentCompactions uint64
+	// opts the options the storage is opened with, the checkpoints of the
+	// background views are opened with the same options.
+	opts *pebble.Options
+	// scanCache the block cache of the background views, see
+	// SetBackgroundScanCacheSize.
+	scanCache *pebble.Cache
+	scanSeq   uint64
 }
 
 var _ storage.KVStorage = (*Storage)(nil)
+var _ storage.RangeCompactor = (*Storage)(nil)
+
+// LogDBOptions the options of the underlying storage of the LogDB
+type LogDBOptions struct {
+	// WALDir the directory of the WAL, default is the directory of the LogDB
+	WALDir string
+	// DisableWALRecycle deletes the obsolete WAL files instead of recycling them,
+	// see DisableWALRecycle.
+	DisableWALRecycle bool
+}
 
 // CreateLogDBStorage creates the underlying storage that will be used by the
 // LogDB.
 func CreateLogDBStorage(rootDir string, fs vfs.FS, logger *zap.Logger) storage.KVStorage {
+	return CreateLogDBStorageWithOptions(rootDir, fs, logger, LogDBOptions{})
+}
+
+// CreateLogDBStorageWithOptions is the same as CreateLogDBStorage, but with the
+// WAL options.
+func CreateLogDBStorageWithOptions(rootDir string, fs vfs.FS, logger *zap.Logger,
+	logDBOpts LogDBOptions) storage.KVStorage {
 	path := fs.PathJoin(rootDir, "logdb")
 	opts := &pebble.Options{
 		FS:                          vfs.NewPebbleFS(fs),
@@ -60,6 +107,10 @@ func CreateLogDBStorage(rootDir string, fs vfs.FS, logger *zap.Logger) storage.K
 		MaxConcurrentCompactions:    2,
 		EventListener:               getEventListener(log.Adjust(logger).Named("pebble")),
 		MaxOpenFiles:                1024,
+		WALDir:                      logDBOpts.WALDir,
+	}
+	if logDBOpts.DisableWALRecycle {
+		DisableWALRecycle(opts)
 	}
 	kv, err := NewStorage(path, logger, opts)
 	if err != nil {
@@ -68,19 +119,91 @@ func CreateLogDBStorage(rootDir string, fs vfs.FS, logger *zap.Logger) storage.K
 	return kv
 }
 
+// DisableWALRecycle makes pebble delete the obsolete WAL files instead of
+// reusing them as the new WAL files. The WAL files are recycled by default, up
+// to MemTableStopWritesThreshold+1 files, which saves the file system metadata
+// updates on the WAL fsync, but the recycled files are full sized on the disk.
+func DisableWALRecycle(opts *pebble.Options) {
+	opts.Cleaner = noRecycleCleaner{}
+}
+
+// UseIOScheduler makes the reads and writes of pebble scheduled by the
+// IOScheduler, the WAL writes are scheduled as the foreground writes, and the
+// flushes and the compactions are scheduled as the background IO. The deletions
+// of the obsolete files are paced by the compaction budget, to avoid the bursts
+// of the discards after the large compactions.
+func UseIOScheduler(opts *pebble.Options, fs vfs.FS, scheduler *vfs.IOScheduler) {
+	opts.FS = vfs.NewPebbleFS(vfs.NewThrottledFS(fs, scheduler))
+	if budget := scheduler.Budget(vfs.Compaction); budget > 0 {
+		opts.Experimental.MinDeletionRate = int(budget)
+	}
+}
+
+// noRecycleCleaner deletes the obsolete files. The pebble never recycles the WAL
+// files if the cleaner needs the contents of the files, which is implied by the
+// embedded ArchiveCleaner, and its Clean is shadowed by the DeleteCleaner.
+type noRecycleCleaner struct {
+	pebble.DeleteCleaner
+	archiveCleaner
+}
+
+type archiveCleaner struct {
+	pebble.ArchiveCleaner
+}
+
 // NewStorage returns a pebble backed kv store.
 func NewStorage(dir string, logger *zap.Logger, opts *pebble.Options) (*Storage, error) {
+	logger = log.Adjust(logger).Named("pebble")
 	if !hasEventListener(opts.EventListener) {
-		opts.EventListener = getEventListener(log.Adjust(logger).Named("pebble"))
-	}
-	db, err := pebble.Open(dir, opts)
+		opts.EventListener = getEventListener(logger)
+	}
+	defaults := opts.Clone().EnsureDefaults()
+	s := &Storage{
+		dir:                      dir,
+		fs:                       defaults.FS,
+		logger:                   logger,
+		walDisabled:              opts.DisableWAL,
+		memTableSize:             uint64(defaults.MemTableSize),
+		maxConcurrentCompactions: uint64(defaults.MaxConcurrentCompactions),
+	}
+	listener := opts.EventListener
+	listener.WriteStallBegin = s.onWriteStallBegin(listener.WriteStallBegin)
+	listener.WriteStallEnd = s.onWriteStallEnd(listener.WriteStallEnd)
+	withListener := *opts
+	withListener.EventListener = listener
+	s.opts = withListener.Clone()
+	db, err := pebble.Open(dir, &withListener)
 	if err != nil {
 		return nil, err
 	}
+	s.db = db
+	return s, nil
+}
 
-	return &Storage{
-		db: db,
-	}, nil
+// onWriteStallBegin counts the write stalls, and calls the fn of the event
+// listener of the options
+func (s *Storage) onWriteStallBegin(fn func(pebble.WriteStallBeginInfo)) func(pebble.WriteStallBeginInfo) {
+	return func(info pebble.WriteStallBeginInfo) {
+		atomic.AddUint64(&s.stats.WriteStalls, 1)
+		atomic.StoreInt64(&s.stallStart, time.Now().UnixNano())
+		if fn != nil {
+			fn(info)
+		}
+	}
+}
+
+// onWriteStallEnd accumulates the duration of the write stalls
+func (s *Storage) onWriteStallEnd(fn func()) func() {
+	return func() {
+		if start := atomic.SwapInt64(&s.stallStart, 0); start > 0 {
+			if d := time.Now().UnixNano() - start; d > 0 {
+				atomic.AddUint64(&s.stats.WriteStallNanos, uint64(d))
+			}
+		}
+		if fn != nil {
+			fn()
+		}
+	}
 }
 
 func (s *Storage) GetView() storage.View {
@@ -89,24 +212,30 @@ func (s *Storage) GetView() storage.View {
 
 // Close close the storage
 func (s *Storage) Close() error {
+	if s.scanCache != nil {
+		s.scanCache.Unref()
+		s.scanCache = nil
+	}
 	return s.db.Close()
 }
 
 // Write write the data in batch
 func (s *Storage) Write(uwb util.WriteBatch, sync bool) error {
 	wb := uwb.(*writeBatch)
-	return s.db.Apply(wb.batch, toWriteOptions(sync))
+	return s.flushIfSync(s.db.Apply(wb.batch, s.writeOptions(sync)), sync)
 }
 
 // Set put the key, value pair to the storage
 func (s *Storage) Set(key, value []byte, sync bool) error {
 	atomic.AddUint64(&s.stats.WrittenKeys, 1)
 	atomic.AddUint64(&s.stats.WrittenBytes, uint64(len(value)+len(key)))
-	return s.db.Set(key, value, toWriteOptions(sync))
+	return s.flushIfSync(s.db.Set(key, value, s.writeOptions(sync)), sync)
 }
 
 // Get returns the value of the key
 func (s *Storage) Get(key []byte) ([]byte, error) {
+	t := s.startReadTrace("get")
+	defer s.finishPointReadTrace(t, key)
 	value, closer, err := s.db.Get(key)
 	if err == pebble.ErrNotFound {
 		return nil, nil
@@ -125,8 +254,47 @@ func (s *Storage) Get(key []byte) ([]byte, error) {
 	return v, nil
 }
 
+// MultiGet seeks all the keys in ascending order with a single iterator, the
+// iterator reads from an implicit snapshot of the db.
+func (s *Storage) MultiGet(keys [][]byte) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return bytes.Compare(keys[order[i]], keys[order[j]]) < 0
+	})
+
+	iter := s.db.NewIter(&pebble.IterOptions{})
+	defer iter.Close()
+	t := s.startReadTrace("multi-get")
+	defer s.finishReadTrace(t, keys[order[0]],
+		keysutil.NextKey(keys[order[len(order)-1]], nil), iter)
+	for _, i := range order {
+		key := keys[i]
+		if !iter.SeekGE(key) || !bytes.Equal(iter.Key(), key) {
+			continue
+		}
+		value := iter.Value()
+		if len(value) == 0 {
+			continue
+		}
+		values[i] = keysutil.Clone(value)
+		atomic.AddUint64(&s.stats.ReadKeys, 1)
+		atomic.AddUint64(&s.stats.ReadBytes, uint64(len(key)+len(value)))
+	}
+	return values, iter.Error()
+}
+
 // GetWithFunc is similer to Get, but avoid clone the value
 func (s *Storage) GetWithFunc(key []byte, fn func([]byte) error) error {
+	t := s.startReadTrace("get")
+	defer s.finishPointReadTrace(t, key)
 	value, closer, err := s.db.Get(key)
 	if err == pebble.ErrNotFound {
 		return nil
@@ -144,7 +312,7 @@ func (s *Storage) GetWithFunc(key []byte, fn func([]byte) error) error {
 func (s *Storage) Delete(key []byte, sync bool) error {
 	atomic.AddUint64(&s.stats.WrittenKeys, 1)
 	atomic.AddUint64(&s.stats.WrittenBytes, uint64(len(key)))
-	return s.db.Delete(key, toWriteOptions(sync))
+	return s.flushIfSync(s.db.Delete(key, s.writeOptions(sync)), sync)
 }
 
 // RangeDelete remove data in [start,end)
@@ -188,7 +356,13 @@ func (s *Storage) RangeDelete(start, end []byte, sync bool) error {
 		return nil
 	}
 
-	return s.db.DeleteRange(start, end, toWriteOptions(sync))
+	return s.flushIfSync(s.db.DeleteRange(start, end, s.writeOptions(sync)), sync)
+}
+
+// CompactRange compacts [start, end) manually, the range tombstones and the
+// deleted keys in the range are dropped from the sstables.
+func (s *Storage) CompactRange(start, end []byte) error {
+	return s.db.Compact(start, end)
 }
 
 // Scan scans the key-value pairs in [start, end), and perform with a handler function, if the function
@@ -204,6 +378,8 @@ func (s *Storage) Scan(start, end []byte, handler func(key, value []byte) (bool,
 	}
 	iter := s.db.NewIter(ios)
 	defer iter.Close()
+	t := s.startReadTrace("scan")
+	defer s.finishReadTrace(t, start, end, iter)
 
 	iter.First()
 	for iter.Valid() {
@@ -240,10 +416,12 @@ func (s *Storage) ScanInView(view storage.View,
 	if len(end) > 0 {
 		ios.UpperBound = end
 	}
-	ss := view.Raw().(*pebble.Snapshot)
+	ss := viewReader(view)
 	iter := ss.NewIter(ios)
 
 	defer iter.Close()
+	t := s.startReadTrace("scan")
+	defer s.finishReadTrace(t, start, end, iter)
 
 	iter.First()
 	for iter.Valid() {
@@ -271,6 +449,50 @@ func (s *Storage) ScanInView(view storage.View,
 	return nil
 }
 
+// ScanReverse scans the key-value pairs in [start, end) in descending key
+// order, and perform with a handler function, if the function returns false,
+// the scan will be terminated.
+// The Handler func will received a cloned the key and value, if the `cloneResult` is true.
+func (s *Storage) ScanReverse(start, end []byte, handler func(key, value []byte) (bool, error), cloneResult bool) error {
+	iter := s.db.NewIter(newIterOptions(start, end))
+	defer iter.Close()
+	t := s.startReadTrace("scan-reverse")
+	defer s.finishReadTrace(t, start, end, iter)
+	return s.scanReverse(iter, handler, cloneResult)
+}
+
+func (s *Storage) ScanReverseInView(view storage.View,
+	start, end []byte, handler func(key, value []byte) (bool, error), cloneResult bool) error {
+	ss := viewReader(view)
+	iter := ss.NewIter(newIterOptions(start, end))
+	defer iter.Close()
+	t := s.startReadTrace("scan-reverse")
+	defer s.finishReadTrace(t, start, end, iter)
+	return s.scanReverse(iter, handler, cloneResult)
+}
+
+func (s *Storage) scanReverse(iter *pebble.Iterator,
+	handler func(key, value []byte) (bool, error), cloneResult bool) error {
+	for iter.Last(); iter.Valid(); iter.Prev() {
+		var ok bool
+		var err error
+		if cloneResult {
+			ok, err = handler(keysutil.Clone(iter.Key()), keysutil.Clone(iter.Value()))
+		} else {
+			ok, err = handler(iter.Key(), iter.Value())
+		}
+		if err != nil {
+			return err
+		}
+		atomic.AddUint64(&s.stats.ReadKeys, 1)
+		atomic.AddUint64(&s.stats.ReadBytes, uint64(len(iter.Key())+len(iter.Value())))
+		if !ok {
+			break
+		}
+	}
+	return iter.Error()
+}
+
 func (s *Storage) ScanInViewWithOptions(view storage.View, start, end []byte, handler func(key, value []byte) (storage.NextIterOptions, error)) error {
 	ios := &pebble.IterOptions{}
 	if len(start) > 0 {
@@ -279,7 +501,7 @@ func (s *Storage) ScanInViewWithOptions(view storage.View, start, end []byte, ha
 	if len(end) > 0 {
 		ios.UpperBound = end
 	}
-	ss := view.Raw().(*pebble.Snapshot)
+	ss := viewReader(view)
 	iter := ss.NewIter(ios)
 	defer iter.Close()
 
@@ -319,7 +541,7 @@ func (s *Storage) ReverseScanInViewWithOptions(view storage.View, start, end []b
 	if len(end) > 0 {
 		ios.UpperBound = end
 	}
-	ss := view.Raw().(*pebble.Snapshot)
+	ss := viewReader(view)
 	iter := ss.NewIter(ios)
 	defer iter.Close()
 
@@ -357,6 +579,8 @@ func (s *Storage) ReverseScanInViewWithOptions(view storage.View, start, end []b
 func (s *Storage) PrefixScan(prefix []byte, handler func(key, value []byte) (bool, error), cloneResult bool) error {
 	iter := s.db.NewIter(&pebble.IterOptions{LowerBound: prefix})
 	defer iter.Close()
+	t := s.startReadTrace("prefix-scan")
+	defer s.finishReadTrace(t, prefix, nil, iter)
 	iter.First()
 	for iter.Valid() {
 		if err := iter.Error(); err != nil {
@@ -417,6 +641,36 @@ func (s *Storage) SeekLT(upperBound []byte) ([]byte, []byte, error) {
 	return s.SeekLTAndGE(upperBound, nil)
 }
 
+// SeekLTInView returns max(-inf, upperBound) in the view
+func (s *Storage) SeekLTInView(view storage.View, upperBound []byte) ([]byte, []byte, error) {
+	ss := viewReader(view)
+	iter := ss.NewIter(newIterOptions(nil, upperBound))
+	defer iter.Close()
+
+	var key, value []byte
+	if iter.Last() {
+		key = keysutil.Clone(iter.Key())
+		value = keysutil.Clone(iter.Value())
+		atomic.AddUint64(&s.stats.ReadKeys, 1)
+		atomic.AddUint64(&s.stats.ReadBytes, uint64(len(iter.Key())+len(iter.Value())))
+	}
+	if err := iter.Error(); err != nil {
+		return nil, nil, err
+	}
+	return key, value, nil
+}
+
+// ScanPage scans a page of [start, end), see storage.PageScan.
+func (s *Storage) ScanPage(start, end []byte, limit uint64,
+	opts storage.ScanPageOptions) ([]storage.KeyValue, []byte, error) {
+	return storage.PageScan(s, start, end, limit, opts)
+}
+
+// NewIterator returns the Iterator over [start, end) read by ScanPage.
+func (s *Storage) NewIterator(start, end []byte, opts storage.ScanPageOptions) storage.Iterator {
+	return storage.NewPageIterator(s, start, end, opts)
+}
+
 // SeekLTAndGE returns max[lowerBound, upperBound)
 func (s *Storage) SeekLTAndGE(upperBound, lowerBound []byte) ([]byte, []byte, error) {
 	var key, value []byte
@@ -439,24 +693,48 @@ func (s *Storage) SeekLTAndGE(upperBound, lowerBound []byte) ([]byte, []byte, er
 	return key, value, nil
 }
 
-// Sync persist data to disk
+// Sync persist data to disk. If the WAL is disabled, the memtable is flushed to
+// disk.
 func (s *Storage) Sync() error {
 	atomic.AddUint64(&s.stats.SyncCount, 1)
+	if s.walDisabled {
+		return s.db.Flush()
+	}
 	wb := s.db.NewBatch()
 	defer wb.Close()
 	if err := wb.Set(keys.ForcedSyncKey, keys.ForcedSyncKey, nil); err != nil {
 		return err
 	}
+	atomic.AddUint64(&s.stats.WALSyncs, 1)
 	return s.db.Apply(wb, pebble.Sync)
 }
 
+// WALDisabled returns true if the storage is opened with the WAL disabled.
+func (s *Storage) WALDisabled() bool {
+	return s.walDisabled
+}
+
 func (s *Storage) Stats() stats.Stats {
+	m := s.db.Metrics()
 	return stats.Stats{
 		WrittenKeys:  atomic.LoadUint64(&s.stats.WrittenKeys),
 		WrittenBytes: atomic.LoadUint64(&s.stats.WrittenBytes),
 		ReadKeys:     atomic.LoadUint64(&s.stats.ReadKeys),
 		ReadBytes:    atomic.LoadUint64(&s.stats.ReadBytes),
 		SyncCount:    atomic.LoadUint64(&s.stats.SyncCount),
+
+		WALSyncs:        atomic.LoadUint64(&s.stats.WALSyncs),
+		WriteStalls:     atomic.LoadUint64(&s.stats.WriteStalls),
+		WriteStallNanos: atomic.LoadUint64(&s.stats.WriteStallNanos),
+
+		CompactionDebt:           m.Compact.EstimatedDebt,
+		L0Files:                  uint64(m.Levels[0].NumFiles),
+		MemTableSize:             s.memTableSize,
+		MaxConcurrentCompactions: s.maxConcurrentCompactions,
+
+		BlockCacheSize:   uint64(m.BlockCache.Size),
+		BlockCacheHits:   uint64(m.BlockCache.Hits),
+		BlockCacheMisses: uint64(m.BlockCache.Misses),
 	}
 }
 
@@ -465,18 +743,43 @@ func (s *Storage) NewWriteBatch() storage.Resetable {
 	return newWriteBatch(s.db.NewBatch(), &s.stats)
 }
 
-func toWriteOptions(sync bool) *pebble.WriteOptions {
-	if sync {
+// writeOptions the sync writes are not allowed if the WAL is disabled, they are
+// made persistent by flushIfSync.
+func (s *Storage) writeOptions(sync bool) *pebble.WriteOptions {
+	if sync && !s.walDisabled {
+		atomic.AddUint64(&s.stats.WALSyncs, 1)
 		return pebble.Sync
 	}
 	return pebble.NoSync
 }
 
+// flushIfSync flushes the memtable after the sync write if the WAL is disabled.
+func (s *Storage) flushIfSync(err error, sync bool) error {
+	if err != nil || !sync || !s.walDisabled {
+		return err
+	}
+	return s.db.Flush()
+}
+
+// newIterOptions returns the options of the iterator over [start, end), the
+// empty start or end means no bound.
+func newIterOptions(start, end []byte) *pebble.IterOptions {
+	ios := &pebble.IterOptions{}
+	if len(start) > 0 {
+		ios.LowerBound = start
+	}
+	if len(end) > 0 {
+		ios.UpperBound = end
+	}
+	return ios
+}
+
 func newWriteBatch(batch *pebble.Batch, stats *stats.Stats) util.WriteBatch {
 	return &writeBatch{batch: batch, stats: stats}
 }
 
 type writeBatch struct {
+	util.WriteConditions
 	batch *pebble.Batch
 	stats *stats.Stats
 }
@@ -519,8 +822,18 @@ func (wb *writeBatch) SetDeferred(keyLen, valueLen int, setter func(key, value [
 	setter(op.Key, op.Value)
 }
 
+func (wb *writeBatch) SetIfAbsent(key []byte, value []byte) {
+	wb.CompareAndSet(key, nil, value)
+}
+
+func (wb *writeBatch) CompareAndSet(key, expected, value []byte) {
+	wb.AddCondition(key, expected)
+	wb.Set(key, value)
+}
+
 func (wb *writeBatch) Reset() {
 	wb.batch.Reset()
+	wb.ResetConditions()
 }
 
 func (wb *writeBatch) Close() {
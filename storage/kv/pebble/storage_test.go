@@ -0,0 +1,207 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pebble
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	cpebble "github.com/cockroachdb/pebble"
+	"github.com/matrixorigin/matrixcube/storage"
+	"github.com/matrixorigin/matrixcube/storage/kv/kvtest"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageConformance(t *testing.T) {
+	kvtest.RunKVStorageTests(t, func(t *testing.T) storage.KVStorage {
+		opts := &cpebble.Options{FS: vfs.NewPebbleFS(vfs.NewMemFS())}
+		kv, err := NewStorage("test-data", nil, opts)
+		require.NoError(t, err)
+		return kv
+	})
+}
+
+func newTestEncryptedFS(t *testing.T, fs vfs.FS) *vfs.EncryptedFS {
+	efs, err := vfs.NewEncryptedFS(fs, vfs.EncryptionOptions{
+		Keys:        map[uint32][]byte{1: bytes.Repeat([]byte{1}, 32)},
+		ActiveKeyID: 1,
+	})
+	require.NoError(t, err)
+	return efs
+}
+
+func TestEncryptedStorageConformance(t *testing.T) {
+	kvtest.RunKVStorageTests(t, func(t *testing.T) storage.KVStorage {
+		efs := newTestEncryptedFS(t, vfs.NewMemFS())
+		opts := &cpebble.Options{FS: vfs.NewPebbleFS(efs)}
+		kv, err := NewStorage("test-data", nil, opts)
+		require.NoError(t, err)
+		return kv
+	})
+}
+
+func TestEncryptedStorage(t *testing.T) {
+	fs := vfs.NewMemFS()
+	efs := newTestEncryptedFS(t, fs)
+	value := bytes.Repeat([]byte("plaintext-value"), 10)
+	open := func() *Storage {
+		kv, err := NewStorage("test-data", nil, &cpebble.Options{FS: vfs.NewPebbleFS(efs)})
+		require.NoError(t, err)
+		return kv
+	}
+
+	kv := open()
+	require.NoError(t, kv.Set([]byte("k1"), value, true))
+	require.NoError(t, kv.db.Flush())
+	require.NoError(t, kv.Set([]byte("k2"), value, true))
+	require.NoError(t, kv.Close())
+
+	names, err := fs.List("test-data")
+	require.NoError(t, err)
+	for _, name := range names {
+		f, err := fs.Open(fs.PathJoin("test-data", name))
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(f)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		assert.False(t, bytes.Contains(data, value[:32]), name)
+	}
+
+	// the flushed SST and the WAL are decrypted after restart
+	kv = open()
+	defer kv.Close()
+	for _, key := range []string{"k1", "k2"} {
+		v, err := kv.Get([]byte(key))
+		require.NoError(t, err)
+		assert.Equal(t, value, v)
+	}
+}
+
+func TestLogDBWALOptions(t *testing.T) {
+	fs := vfs.NewMemFS()
+	kv := CreateLogDBStorageWithOptions("test-data", fs, nil, LogDBOptions{
+		WALDir: "test-wal",
+	}).(*Storage)
+	defer kv.Close()
+
+	require.NoError(t, kv.Set([]byte("k"), []byte("v"), true))
+	files, err := fs.List("test-wal")
+	require.NoError(t, err)
+	assert.NotEmpty(t, files, "WAL in the separate dir")
+}
+
+func TestDisableWALRecycle(t *testing.T) {
+	for _, disabled := range []bool{false, true} {
+		opts := &cpebble.Options{FS: vfs.NewPebbleFS(vfs.NewMemFS())}
+		if disabled {
+			DisableWALRecycle(opts)
+		}
+		kv, err := NewStorage("test-data", nil, opts)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, kv.Set([]byte("k"), []byte("v"), true))
+			require.NoError(t, kv.db.Flush())
+		}
+		recycled := kv.db.Metrics().WAL.ObsoleteFiles
+		if disabled {
+			assert.Equal(t, int64(0), recycled)
+		} else {
+			assert.NotEqual(t, int64(0), recycled)
+		}
+		require.NoError(t, kv.Close())
+	}
+}
+
+func TestUseIOScheduler(t *testing.T) {
+	scheduler := vfs.NewIOScheduler(vfs.IOSchedulerOptions{
+		BytesPerSecond: map[vfs.IOClass]int64{vfs.Compaction: 1024 * 1024 * 1024},
+	})
+	opts := &cpebble.Options{}
+	UseIOScheduler(opts, vfs.NewMemFS(), scheduler)
+	assert.Equal(t, 1024*1024*1024, opts.Experimental.MinDeletionRate)
+	kv, err := NewStorage("test-data", nil, opts)
+	require.NoError(t, err)
+	defer kv.Close()
+
+	require.NoError(t, kv.Set([]byte("k"), []byte("v"), true))
+	require.NoError(t, kv.db.Flush())
+	v, err := kv.Get([]byte("k"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v"), v)
+	assert.NotEqual(t, uint64(0), scheduler.Stats(vfs.ForegroundWrite).Bytes, "WAL")
+	assert.NotEqual(t, uint64(0), scheduler.Stats(vfs.Compaction).Bytes, "flush")
+}
+
+func TestWriteStats(t *testing.T) {
+	opts := &cpebble.Options{
+		FS:                       vfs.NewPebbleFS(vfs.NewMemFS()),
+		MemTableSize:             1024 * 1024,
+		MaxConcurrentCompactions: 3,
+	}
+	kv, err := NewStorage("test-data", nil, opts)
+	require.NoError(t, err)
+	defer kv.Close()
+
+	require.NoError(t, kv.Set([]byte("k1"), []byte("v"), true))
+	require.NoError(t, kv.Set([]byte("k2"), []byte("v"), false))
+	require.NoError(t, kv.Sync())
+	require.NoError(t, kv.db.Flush())
+
+	// the stalls are reported by the event listener of pebble
+	kv.onWriteStallBegin(nil)(cpebble.WriteStallBeginInfo{})
+	time.Sleep(time.Millisecond)
+	kv.onWriteStallEnd(nil)()
+
+	st := kv.Stats()
+	assert.Equal(t, uint64(2), st.WALSyncs)
+	assert.Equal(t, uint64(1), st.WriteStalls)
+	assert.True(t, st.WriteStallNanos >= uint64(time.Millisecond))
+	assert.Equal(t, uint64(1), st.L0Files)
+	assert.Equal(t, uint64(1024*1024), st.MemTableSize)
+	assert.Equal(t, uint64(3), st.MaxConcurrentCompactions)
+}
+
+func TestBlockCacheStats(t *testing.T) {
+	cache := cpebble.NewCache(1024 * 1024)
+	defer cache.Unref()
+	opts := &cpebble.Options{
+		FS:    vfs.NewPebbleFS(vfs.NewMemFS()),
+		Cache: cache,
+	}
+	kv, err := NewStorage("test-data", nil, opts)
+	require.NoError(t, err)
+	defer kv.Close()
+
+	require.NoError(t, kv.Set([]byte("k"), []byte("v"), false))
+	require.NoError(t, kv.db.Flush())
+	st := kv.Stats()
+	hits, misses := st.BlockCacheHits, st.BlockCacheMisses
+
+	// the first read loads the data block into the cache, and the second one
+	// is served by the cache
+	for i := 0; i < 2; i++ {
+		v, err := kv.Get([]byte("k"))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v"), v)
+	}
+	st = kv.Stats()
+	assert.True(t, st.BlockCacheMisses > misses)
+	assert.True(t, st.BlockCacheHits > hits)
+	assert.NotEqual(t, uint64(0), st.BlockCacheSize)
+}
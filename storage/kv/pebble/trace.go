@@ -0,0 +1,119 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pebble
+
+import (
+	"bytes"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/matrixorigin/matrixcube/components/log"
+	keysutil "github.com/matrixorigin/matrixcube/util/keys"
+	"go.uber.org/zap"
+)
+
+// SetSlowReadThreshold enables the read tracing, the Get and the Scan slower
+// than the threshold are logged with the pebble level detail, i.e. the levels
+// touched, the read amplification, the blocks read and the bloom filter misses.
+// The pebble in use has no per iterator stats, the block and the filter counters
+// are the deltas of the db wide metrics during the read, which include the
+// concurrent reads and compactions, and collecting the metrics twice per read
+// is not free, so enable it only during the investigations. 0 disables it.
+func (s *Storage) SetSlowReadThreshold(threshold time.Duration) {
+	atomic.StoreInt64(&s.slowReadThreshold, int64(threshold))
+}
+
+// readTrace is the pebble level detail of a read, the nil readTrace means the
+// tracing is disabled.
+type readTrace struct {
+	op        string
+	started   time.Time
+	threshold time.Duration
+	before    *pebble.Metrics
+}
+
+func (s *Storage) startReadTrace(op string) *readTrace {
+	threshold := time.Duration(atomic.LoadInt64(&s.slowReadThreshold))
+	if threshold <= 0 {
+		return nil
+	}
+	return &readTrace{
+		op:        op,
+		started:   time.Now(),
+		threshold: threshold,
+		before:    s.db.Metrics(),
+	}
+}
+
+// finishReadTrace logs the read over [start, end) if it's slow, the empty end
+// means no bound. The read amplification is taken from the iterator if the read
+// is an iteration.
+func (s *Storage) finishReadTrace(t *readTrace, start, end []byte,
+	iter *pebble.Iterator) {
+	if t == nil {
+		return
+	}
+	elapsed := time.Since(t.started)
+	if elapsed < t.threshold {
+		return
+	}
+
+	after := s.db.Metrics()
+	readAmp := after.ReadAmp() + int(after.MemTable.Count)
+	if iter != nil {
+		readAmp = iter.Metrics().ReadAmp
+	}
+	s.logger.Warn("slow read",
+		zap.String("op", t.op),
+		log.HexField("start", start),
+		log.HexField("end", end),
+		zap.Duration("elapsed", elapsed),
+		zap.Ints("levels", s.levelsTouched(start, end)),
+		zap.Int("read-amp", readAmp),
+		zap.Int64("blocks-read", after.BlockCache.Misses-t.before.BlockCache.Misses),
+		zap.Int64("block-cache-hits", after.BlockCache.Hits-t.before.BlockCache.Hits),
+		zap.Int64("bloom-filter-hits", after.Filter.Hits-t.before.Filter.Hits),
+		zap.Int64("bloom-filter-misses", after.Filter.Misses-t.before.Filter.Misses))
+}
+
+// finishPointReadTrace is the same as finishReadTrace, but for the read of the
+// key.
+func (s *Storage) finishPointReadTrace(t *readTrace, key []byte) {
+	if t == nil {
+		return
+	}
+	s.finishReadTrace(t, key, keysutil.NextKey(key, nil), nil)
+}
+
+// levelsTouched returns the levels which have the SSTs overlapping with
+// [start, end), the empty end means no bound.
+func (s *Storage) levelsTouched(start, end []byte) []int {
+	tables, err := s.db.SSTables()
+	if err != nil {
+		return nil
+	}
+	var levels []int
+	for level, files := range tables {
+		for _, f := range files {
+			if (len(end) > 0 && bytes.Compare(f.Smallest.UserKey, end) >= 0) ||
+				bytes.Compare(f.Largest.UserKey, start) < 0 {
+				continue
+			}
+			levels = append(levels, level)
+			break
+		}
+	}
+	return levels
+}
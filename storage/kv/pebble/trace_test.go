@@ -0,0 +1,69 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pebble
+
+import (
+	"testing"
+	"time"
+
+	cpebble "github.com/cockroachdb/pebble"
+	"github.com/matrixorigin/matrixcube/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlowReadTrace(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	opts := &cpebble.Options{FS: vfs.NewPebbleFS(vfs.NewMemFS())}
+	kv, err := NewStorage("test-data", zap.New(core), opts)
+	require.NoError(t, err)
+	defer kv.Close()
+
+	require.NoError(t, kv.Set([]byte("a"), []byte("1"), false))
+	require.NoError(t, kv.Set([]byte("b"), []byte("2"), false))
+	require.NoError(t, kv.db.Flush())
+	require.NoError(t, kv.Set([]byte("c"), []byte("3"), false))
+
+	_, err = kv.Get([]byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, logs.Len(), "tracing disabled by default")
+
+	kv.SetSlowReadThreshold(time.Nanosecond)
+	_, err = kv.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "get", fields["op"])
+	assert.Equal(t, "61", fields["start"])
+	assert.Contains(t, fields, "elapsed")
+	assert.Contains(t, fields, "blocks-read")
+	assert.Contains(t, fields, "bloom-filter-misses")
+	assert.Equal(t, []interface{}{0}, fields["levels"], "flushed to L0")
+
+	require.NoError(t, kv.Scan([]byte("c"), nil, func(key, value []byte) (bool, error) {
+		return true, nil
+	}, false))
+	require.Equal(t, 2, logs.Len())
+	fields = logs.All()[1].ContextMap()
+	assert.Equal(t, "scan", fields["op"])
+	assert.Equal(t, int64(2), fields["read-amp"], "memtable and L0")
+
+	kv.SetSlowReadThreshold(time.Hour)
+	_, err = kv.Get([]byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, logs.Len(), "fast reads not traced")
+}
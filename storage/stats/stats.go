@@ -25,6 +25,37 @@ type Stats struct {
 	ReadBytes    uint64
 	// SyncCount number of `Sync` method called
 	SyncCount uint64
+	// GCPendingRanges number of the data ranges of the destroyed shards waiting
+	// to be removed in background
+	GCPendingRanges uint64
+	// GCRemovedRanges number of the data ranges of the destroyed shards removed
+	GCRemovedRanges uint64
+	// GCCompactedRanges number of the removed ranges compacted afterwards
+	GCCompactedRanges uint64
+
+	// WALSyncs number of the WAL fsyncs, i.e. the synced writes and the `Sync`
+	// calls
+	WALSyncs uint64
+	// WriteStalls number of the write stalls of the LSM tree
+	WriteStalls uint64
+	// WriteStallNanos total duration of the write stalls in nanoseconds
+	WriteStallNanos uint64
+	// CompactionDebt the estimated bytes to compact to make the LSM tree stable
+	CompactionDebt uint64
+	// L0Files number of the files in the L0 of the LSM tree
+	L0Files uint64
+	// MemTableSize the configured size of the memtable, 0 if unknown
+	MemTableSize uint64
+	// MaxConcurrentCompactions the configured max concurrent compactions, 0 if
+	// unknown
+	MaxConcurrentCompactions uint64
+	// BlockCacheSize the bytes in use by the block cache
+	BlockCacheSize uint64
+	// BlockCacheHits number of the block reads served by the block cache
+	BlockCacheHits uint64
+	// BlockCacheMisses number of the block reads missed the block cache, the
+	// blocks read by the large scans are counted here
+	BlockCacheMisses uint64
 }
 
 // Copy returns another instance for rough statistics.
@@ -36,5 +67,22 @@ func (s *Stats) Copy() Stats {
 		ReadKeys:     atomic.LoadUint64(&s.ReadKeys),
 		ReadBytes:    atomic.LoadUint64(&s.ReadBytes),
 		SyncCount:    atomic.LoadUint64(&s.SyncCount),
+
+		GCPendingRanges:   atomic.LoadUint64(&s.GCPendingRanges),
+		GCRemovedRanges:   atomic.LoadUint64(&s.GCRemovedRanges),
+		GCCompactedRanges: atomic.LoadUint64(&s.GCCompactedRanges),
+
+		WALSyncs:        atomic.LoadUint64(&s.WALSyncs),
+		WriteStalls:     atomic.LoadUint64(&s.WriteStalls),
+		WriteStallNanos: atomic.LoadUint64(&s.WriteStallNanos),
+
+		CompactionDebt:           s.CompactionDebt,
+		L0Files:                  s.L0Files,
+		MemTableSize:             s.MemTableSize,
+		MaxConcurrentCompactions: s.MaxConcurrentCompactions,
+
+		BlockCacheSize:   s.BlockCacheSize,
+		BlockCacheHits:   s.BlockCacheHits,
+		BlockCacheMisses: s.BlockCacheMisses,
 	}
 }
@@ -27,6 +27,23 @@ func TestCopy(t *testing.T) {
 		ReadKeys:     3,
 		ReadBytes:    4,
 		SyncCount:    5,
+
+		GCPendingRanges:   6,
+		GCRemovedRanges:   7,
+		GCCompactedRanges: 8,
+
+		WALSyncs:        9,
+		WriteStalls:     10,
+		WriteStallNanos: 11,
+
+		CompactionDebt:           12,
+		L0Files:                  13,
+		MemTableSize:             14,
+		MaxConcurrentCompactions: 15,
+
+		BlockCacheSize:   16,
+		BlockCacheHits:   17,
+		BlockCacheMisses: 18,
 	}
 	actual := stats.Copy()
 
@@ -36,4 +53,8 @@ func TestCopy(t *testing.T) {
 	assert.Equal(t, stats.ReadKeys, actual.ReadKeys)
 	assert.Equal(t, stats.ReadBytes, actual.ReadBytes)
 	assert.Equal(t, stats.SyncCount, actual.SyncCount)
+	assert.Equal(t, stats.GCPendingRanges, actual.GCPendingRanges)
+	assert.Equal(t, stats.GCRemovedRanges, actual.GCRemovedRanges)
+	assert.Equal(t, stats.GCCompactedRanges, actual.GCCompactedRanges)
+	assert.Equal(t, stats, actual)
 }
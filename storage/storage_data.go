@@ -14,7 +14,11 @@
 package storage
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"syscall"
 	"time"
 
 	"github.com/matrixorigin/matrixcube/pb/hlcpb"
@@ -32,8 +36,56 @@ var (
 	// ErrShardNotFound is returned by the data storage to indicate that the
 	// requested shard is not found.
 	ErrShardNotFound = errors.New("shard not found")
+	// ErrSnapshotCorrupted is returned when the snapshot is truncated or its
+	// checksum mismatches. The snapshot should be dropped and received again
+	// instead of being applied.
+	ErrSnapshotCorrupted = errors.New("snapshot corrupted")
+	// ErrEstimateNotSupported is returned when the size of the data can not be
+	// estimated by the underlying storage.
+	ErrEstimateNotSupported = errors.New("size estimate not supported")
+	// ErrCompactNotSupported is returned when the range can not be compacted by
+	// the underlying storage.
+	ErrCompactNotSupported = errors.New("range compaction not supported")
+	// ErrSampleNotSupported is returned when the keys of the shard can not be
+	// sampled by the data storage.
+	ErrSampleNotSupported = errors.New("key sampling not supported")
+	// ErrInvalidTTL is returned when the TTL of the key is not positive.
+	ErrInvalidTTL = errors.New("invalid ttl")
+	// ErrConditionFailed is returned when any condition of the write batch is
+	// not met, see ConditionFailedError.
+	ErrConditionFailed = errors.New("write condition failed")
+	// ErrBackupNotSupported is returned when the shards can not be backed up
+	// by the data storage.
+	ErrBackupNotSupported = errors.New("backup not supported")
+	// ErrDiskFull is returned when the write can not be applied because the
+	// disk is full. The write is not applied, and can be retried once the space
+	// is reclaimed.
+	ErrDiskFull = errors.New("disk full")
 )
 
+// IsDiskFull returns true if the error is caused by the disk full, either
+// ErrDiskFull or ENOSPC returned by the file system.
+func IsDiskFull(err error) bool {
+	return errors.Is(err, ErrDiskFull) || errors.Is(err, syscall.ENOSPC)
+}
+
+// ConditionFailedError is returned by writing the write batch with the failed
+// conditions, nothing in the batch is written.
+type ConditionFailedError struct {
+	// Failed the indexes of the failed conditions in the Conditions of the write
+	// batch
+	Failed []int
+}
+
+func (e *ConditionFailedError) Error() string {
+	return fmt.Sprintf("%s, failed conditions %v", ErrConditionFailed, e.Failed)
+}
+
+// Unwrap returns ErrConditionFailed
+func (e *ConditionFailedError) Unwrap() error {
+	return ErrConditionFailed
+}
+
 // Closeable is an instance that can be closed.
 type Closeable interface {
 	// Close closes the instance.
@@ -88,6 +140,109 @@ type BaseStorage interface {
 	ApplySnapshot(shardID uint64, path string) error
 }
 
+// SnapshotVerifier is implemented by the storage which is able to verify the
+// received snapshot before it's applied.
+type SnapshotVerifier interface {
+	// VerifySnapshot verifies the snapshot stored in the given path of the
+	// specified shard, ErrSnapshotCorrupted is returned if the snapshot is
+	// corrupted.
+	VerifySnapshot(shardID uint64, path string) error
+}
+
+// KeySampler is implemented by the storage which is able to sample the keys of
+// a shard, e.g. for the optimizers building the histograms of the data.
+type KeySampler interface {
+	// SampleKeys returns at most n keys uniformly sampled from the data of the
+	// specified shard in ascending order, all the keys of the shard are returned
+	// if there are no more than n keys.
+	SampleKeys(shardID uint64, n int) ([][]byte, error)
+}
+
+// ShardCompactor is implemented by the storage which is able to compact the
+// data of a shard manually, e.g. to reclaim the space of the deleted ranges.
+type ShardCompactor interface {
+	// CompactShard compacts all the data of the shard, ErrCompactNotSupported is
+	// returned if the underlying storage can not compact the range.
+	CompactShard(shard metapb.Shard) error
+}
+
+// ShardSizeEstimator is implemented by the storage which is able to estimate
+// the size of a shard by the metadata of the underlying storage.
+type ShardSizeEstimator interface {
+	// EstimateShardSize returns the approximate bytes and the approximate number
+	// of keys of the shard, ErrEstimateNotSupported is returned if the
+	// underlying storage can not estimate the size.
+	EstimateShardSize(shard metapb.Shard) (bytes uint64, keys uint64, err error)
+}
+
+// ShardBackuper is implemented by the data storage which is able to back up
+// the shards and restore them on a fresh cluster.
+type ShardBackuper interface {
+	// GetAppliedIndex returns the applied index of the shard, ErrShardNotFound
+	// is returned if the shard is unknown to the data storage.
+	GetAppliedIndex(shardID uint64) (uint64, error)
+	// BackupShard writes the snapshot stream of the shard into the writer in the
+	// same format of KVBaseStorage.CreateSnapshotTo, the returned manifest has
+	// the applied index captured by the snapshot.
+	BackupShard(shardID uint64, w io.Writer, opts SnapshotStreamOptions) (metapb.SnapshotManifest, error)
+	// RestoreShardData writes the key-value pairs of the snapshot stream of the
+	// backupShardID written by BackupShard into the range of the shard. The
+	// applied index and the metadata in the stream are dropped since the shard
+	// is created by the fresh cluster, the range of the stream must be the same
+	// as the shard.
+	RestoreShardData(backupShardID uint64, shard metapb.Shard, r io.Reader, opts SnapshotStreamOptions) error
+	// ReadShardData reads the key-value pairs of the snapshot stream of the
+	// backupShardID written by BackupShard, the keys passed to the fn are the
+	// origin keys. The key and the value are only valid in the fn.
+	ReadShardData(backupShardID uint64, r io.Reader, opts SnapshotStreamOptions, fn func(key, value []byte) error) error
+}
+
+// MutationType the type of the Mutation
+type MutationType int
+
+const (
+	// MutationSet the key is set to the value
+	MutationSet MutationType = iota
+	// MutationDelete the key is deleted
+	MutationDelete
+	// MutationDeleteRange the keys in [Key, End) are deleted
+	MutationDeleteRange
+)
+
+// Mutation is a change of the data of a shard made by a committed write batch.
+// The keys are the origin keys of the requests.
+type Mutation struct {
+	Type  MutationType
+	Key   []byte
+	Value []byte
+	// End the end key of the MutationDeleteRange, an empty End means no upper
+	// bound
+	End []byte
+}
+
+// CommitHook maintains the in-memory state derived from the data of a shard,
+// e.g. the secondary indexes or the caches, so the state is always consistent
+// with the persisted data. The methods of the hook of a shard are never called
+// concurrently.
+type CommitHook interface {
+	// OnCommit is called with the mutations of the write batch of the raft log
+	// once it's atomically committed into the underlying storage, before the
+	// Write returns.
+	OnCommit(index uint64, mutations []Mutation)
+	// Rebuild drops the current state and rebuilds it from the persisted data of
+	// the shard, the scan function scans the origin keys of the shard in order,
+	// the key and the value passed to the handler are only valid in the handler.
+	// It's called on restart and whenever the data of the shard is replaced,
+	// e.g. a snapshot is applied or the shard is split.
+	Rebuild(shard metapb.Shard, scan func(handler func(key, value []byte) (bool, error)) error) error
+	// Close is called once the shard is removed from the data storage.
+	Close()
+}
+
+// CommitHookFactory creates the CommitHook of the shard, the hook is rebuilt
+// before any write batch of the shard is committed.
+type CommitHookFactory func(shard metapb.Shard) CommitHook
+
 // DataStorage is the interface to be implemented by data engines for storing
 // both table shards data and shards metadata. We assume that data engines are
 // WAL-less engines meaning some of its most recent writes will be lost on
@@ -108,9 +263,9 @@ type DataStorage interface {
 	// multiple Raft logs together as possible. The implementation must ensure
 	// that the content of each LogRequest instance provided by the WriteContext
 	// is atomically applied into the underlying storage. The implementation
-	// should call the `SetWrittenBytes` and `SetDiffBytes` methods of the
-	// `WriteContext` to report the statistical changes involved in applying
-	// the specified `WriteContext` before returning.
+	// should call the `SetWrittenBytes`, `SetWrittenKeys` and `SetDiffBytes`
+	// methods of the `WriteContext` to report the statistical changes involved
+	// in applying the specified `WriteContext` before returning.
 	Write(WriteContext) error
 	// TODO: refactor this method again to consider what is the best approach
 	// to avoid extra allocation.
@@ -159,6 +314,16 @@ type DataStorage interface {
 	Feature() Feature
 }
 
+// ContextSplitChecker is implemented by the DataStorage which is able to abort
+// the running split check, e.g. the epoch of the shard changed or the
+// leadership moved during the check.
+type ContextSplitChecker interface {
+	// SplitCheckWithContext is the same as SplitCheck, but returns the error of
+	// the ctx as soon as the ctx is done.
+	SplitCheckWithContext(ctx context.Context, shard metapb.Shard, size uint64) (currentApproximateSize uint64,
+		currentApproximateKeys uint64, splitKeys [][]byte, splitCtx []byte, err error)
+}
+
 // Feature the feature for data
 type Feature struct {
 	// ShardSplitCheckDuration duration to check if the Shard needs to be split.
@@ -170,8 +335,31 @@ type Feature struct {
 	// value that changes after each Write call. Whenever this value exceeds the size set by the
 	// current field, a real check is made to see if a split is needed, involving real IO operations.
 	ShardSplitCheckBytes uint64
+	// ShardSplitCheckApproximateBytes if the size of the Shard estimated by the metadata of the
+	// underlying storage is no less than this value, the split keys are estimated by the metadata
+	// too instead of scanning all the data of the Shard. The smaller Shards are always scanned
+	// since the estimate is inaccurate for them. Default is ShardCapacityBytes.
+	ShardSplitCheckApproximateBytes uint64
 	// DisableShardSplit disable shard split
 	DisableShardSplit bool
+	// ShardSplitLoadQPS the Shard is split by the load even if it's small, once the read and
+	// write requests per second of the Shard stay above this value in ShardSplitLoadWindows
+	// consecutive ShardSplitCheckDuration. The split key is picked from the sampled keys of the
+	// requests. The load based split is disabled if both it and ShardSplitLoadBytes are 0.
+	ShardSplitLoadQPS uint64
+	// ShardSplitLoadBytes the same as ShardSplitLoadQPS, but the read and written bytes per second.
+	ShardSplitLoadBytes uint64
+	// ShardSplitLoadWindows the number of the consecutive ShardSplitCheckDuration that the load
+	// of the Shard stays above the threshold before it's split by the load. Default is 3.
+	ShardSplitLoadWindows int
+	// ShardBuckets the number of the buckets of each Shard, the read and write stats of the
+	// buckets are reported to the prophet to find the hot keys inside the Shard. The boundaries
+	// of the buckets are sampled by the KeySampler, the buckets are disabled if it's 0 or the
+	// DataStorage is not a KeySampler.
+	ShardBuckets int
+	// ShardBucketRefreshDuration duration to sample the boundaries of the buckets of the Shard
+	// again. Default is 10 minutes.
+	ShardBucketRefreshDuration time.Duration
 	// ForceCompactCount force compaction when the number of Raft logs reaches the specified number
 	ForceCompactCount uint64
 	// ForceCompactBytes force compaction when the number of Raft logs reaches the specified bytes
@@ -179,11 +367,28 @@ type Feature struct {
 	// SplitKeyAdjustFunc based on the implementation-specific encoding rules, a final SplitKey is
 	// returned that can be applied to ensure that the relevant data cannot be split into 2 shards.
 	SplitKeyAdjustFunc func([]byte) []byte
+	// ShardSplitKeyAdjustFunc the same as SplitKeyAdjustFunc, but the shard being split is
+	// provided, and nil is returned if the shard can't be split around the key, e.g. the
+	// adjusted key is not greater than the start key of the shard. It takes precedence over
+	// SplitKeyAdjustFunc.
+	ShardSplitKeyAdjustFunc func(shard metapb.Shard, splitKey []byte) []byte
 	// SupportTransaction whether to support Transaction, if support transaction, the current DataStorage
 	// need to implement TransactionalDataStorage, used to handle transaction-related consensus commands.
 	SupportTransaction bool
 }
 
+// AdjustSplitKey adjusts the split key of the shard by the ShardSplitKeyAdjustFunc or the
+// SplitKeyAdjustFunc, nil is returned if the shard can't be split around the key.
+func (f Feature) AdjustSplitKey(shard metapb.Shard, splitKey []byte) []byte {
+	if f.ShardSplitKeyAdjustFunc != nil {
+		return f.ShardSplitKeyAdjustFunc(shard, splitKey)
+	}
+	if f.SplitKeyAdjustFunc != nil {
+		return f.SplitKeyAdjustFunc(splitKey)
+	}
+	return splitKey
+}
+
 // TransactionalDataStorage is a `DataStorage` that supports transaction operations.  Where all write data
 // methods must be called by the Cube after completing the consensus, and read data methods can be read
 // directly in the LeaseHolder.
@@ -265,6 +470,10 @@ type WriteContext interface {
 	// contributes to the scheduler's auto-rebalancing feature.
 	// This method must be called before `Read` or `Write` returns.
 	SetWrittenBytes(uint64)
+	// SetWrittenKeys set the number of keys written to storage for all requests
+	// in the current Context instance, a range deletion is counted as one key.
+	// It contributes to the hot shard statistics and the load based split.
+	SetWrittenKeys(uint64)
 	// SetDiffBytes set the diff of the bytes stored in storage after Write is
 	// executed. This is an approximation value used to modify the approximate
 	// amount of data in the `Shard` which is used for triggering the auto-split
@@ -291,6 +500,15 @@ type InternalContext interface {
 	ByteBuf() *buf.ByteBuf
 }
 
+// MirrorContext is implemented by the read contexts of the shards mirrored in
+// memory by the data storage, the executors serve the reads by the mirror
+// instead of the base storage.
+type MirrorContext interface {
+	// MirrorKVStorage returns the in-memory mirror of the shard, nil if the
+	// shard is not mirrored.
+	MirrorKVStorage() KVStorage
+}
+
 // Batch contains a list of requests. For write batches, all requests are from
 // the same raft log specified by the Index value. They must be atomically
 // applied into the data storage together with the Index value itself. For
@@ -298,6 +516,12 @@ type InternalContext interface {
 type Batch struct {
 	// Index is the corresponding raft log index of the batch.
 	Index uint64
+	// Timestamp is the HLC timestamp assigned by the leader when the raft log
+	// proposed. The timestamps are not guaranteed to be monotonic in the index
+	// order, since a new leader may propose before the raft logs of the previous
+	// terms are applied. It is empty for the raft logs proposed before the HLC
+	// timestamp introduced.
+	Timestamp hlcpb.Timestamp
 	// Requests is the requests included in the batch.
 	Requests []Request
 }
@@ -320,6 +544,7 @@ type SimpleWriteContext struct {
 	batch        Batch
 	responses    [][]byte
 	writtenBytes uint64
+	writtenKeys  uint64
 	diffBytes    int64
 }
 
@@ -345,8 +570,10 @@ func (ctx *SimpleWriteContext) AppendResponse(value []byte) {
 	ctx.responses = append(ctx.responses, value)
 }
 func (ctx *SimpleWriteContext) SetWrittenBytes(value uint64) { ctx.writtenBytes = value }
+func (ctx *SimpleWriteContext) SetWrittenKeys(value uint64)  { ctx.writtenKeys = value }
 func (ctx *SimpleWriteContext) SetDiffBytes(value int64)     { ctx.diffBytes = value }
 func (ctx *SimpleWriteContext) GetWrittenBytes() uint64      { return ctx.writtenBytes }
+func (ctx *SimpleWriteContext) GetWrittenKeys() uint64       { return ctx.writtenKeys }
 func (ctx *SimpleWriteContext) GetDiffBytes() int64          { return ctx.diffBytes }
 func (ctx *SimpleWriteContext) Responses() [][]byte          { return ctx.responses }
 
@@ -14,7 +14,10 @@
 package storage
 
 import (
+	"io"
+
 	"github.com/matrixorigin/matrixcube/util"
+	"github.com/matrixorigin/matrixcube/vfs"
 )
 
 // View is a point in time view of the KVStore.
@@ -35,6 +38,10 @@ type KVStore interface {
 	Get(key []byte) ([]byte, error)
 	// GetWithFunc is similer to Get, but avoid clone the value
 	GetWithFunc(key []byte, fn func(value []byte) error) error
+	// MultiGet returns the values associated with the keys in the same order
+	// as the keys, the value of a missing key is nil. All the keys are read
+	// from a single point in time view of the KVStore.
+	MultiGet(keys [][]byte) ([][]byte, error)
 	// Delete removes the key-value pair specified by the key.
 	Delete(key []byte, sync bool) error
 	// Scan scans the key-value paire in the specified [start, end) range, the
@@ -50,6 +57,14 @@ type KVStore interface {
 	// specified view.
 	ScanInView(view View, start, end []byte,
 		handler func(key, value []byte) (bool, error), clone bool) error
+	// ScanReverse is similar to Scan, but the key-value pairs in the specified
+	// [start, end) range are scanned in descending key order.
+	ScanReverse(start, end []byte,
+		handler func(key, value []byte) (bool, error), clone bool) error
+	// ScanReverseInView is similar to ScanReverse, it performs the ScanReverse
+	// operation on the specified view.
+	ScanReverseInView(view View, start, end []byte,
+		handler func(key, value []byte) (bool, error), clone bool) error
 	// Deprecated: PrefixScan scans all key-value pairs that share the specified prefix, the
 	// specified handler function will be invoked on each such key-value pairs
 	// until false is returned by the handler function. Depending on the clone
@@ -72,10 +87,23 @@ type KVStore interface {
 	Seek(lowerBound []byte) ([]byte, []byte, error)
 	// SeekAndLT returns min[lowerBound, upperBound)
 	SeekAndLT(lowerBound, upperBound []byte) ([]byte, []byte, error)
-	// Seek returns max(-inf, upperBound)
+	// SeekLT returns max(-inf, upperBound)
 	SeekLT(upperBound []byte) ([]byte, []byte, error)
+	// SeekLTInView is similar to SeekLT, it performs the SeekLT operation on
+	// the specified view.
+	SeekLTInView(view View, upperBound []byte) ([]byte, []byte, error)
 	// SeekLTAndGE returns max[lowerBound, upperBound)
 	SeekLTAndGE(upperBound, lowerBound []byte) ([]byte, []byte, error)
+	// ScanPage scans a page of the key-value pairs in the specified [start,
+	// end) range, the page holds at most limit pairs, 0 means no limit, and is
+	// bounded by the options. The returned pairs are cloned, and the returned
+	// next key is the start of the next page, it's empty if the range is
+	// exhausted. The underlying iterator is released before return, so a large
+	// range can be read page by page without holding the iterator.
+	ScanPage(start, end []byte, limit uint64, opts ScanPageOptions) ([]KeyValue, []byte, error)
+	// NewIterator returns an Iterator over the key-value pairs in the specified
+	// [start, end) range, the pairs are read by ScanPage with the options.
+	NewIterator(start, end []byte, opts ScanPageOptions) Iterator
 	// Sync synchronize the storage's in-core state with that on disk.
 	Sync() error
 }
@@ -90,6 +118,41 @@ type NextIterOptions struct {
 	SeekLT []byte
 }
 
+// KeyValue is a key-value pair returned by ScanPage.
+type KeyValue struct {
+	Key   []byte
+	Value []byte
+}
+
+// ScanPageOptions options of ScanPage and the Iterator
+type ScanPageOptions struct {
+	// View if set, the page is scanned in the view
+	View View
+	// MaxBytes the page is ended once the bytes of the keys and values in the
+	// page reach it, 0 means no limit. The page always holds at least one pair
+	// if the range is not exhausted.
+	MaxBytes uint64
+	// PageSize the max pairs of the pages read by the Iterator, 0 means
+	// DefaultIteratorPageSize. It's not used by ScanPage.
+	PageSize uint64
+}
+
+// Iterator is a cursor over the key-value pairs, the pairs are read page by
+// page, no iterator of the underlying storage is held between the pages.
+type Iterator interface {
+	// Next moves to the next key-value pair, returns false if the range is
+	// exhausted or an error occurred.
+	Next() bool
+	// Key returns the key of the current pair.
+	Key() []byte
+	// Value returns the value of the current pair.
+	Value() []byte
+	// Error returns the error occurred during the iteration.
+	Error() error
+	// Close releases the iterator.
+	Close() error
+}
+
 // KVStorage is key-value based storage.
 type KVStorage interface {
 	Closeable
@@ -98,6 +161,62 @@ type KVStorage interface {
 	KVStore
 }
 
+// SSTIngester is implemented by the KVStorage which is able to ingest external
+// SST files. The snapshots of the shards are created and applied as SST files
+// if the KVStorage is a SSTIngester, instead of writing key by key.
+type SSTIngester interface {
+	// IngestExternalFiles atomically ingests the SST files in the fs into the
+	// storage, the ingested key-value pairs are persistent on return. The files
+	// are not modified and it's the caller's responsibility to remove them.
+	IngestExternalFiles(fs vfs.FS, files []string) error
+}
+
+// SizeEstimator is implemented by the KVStorage which is able to estimate the
+// size of the data in a range by its metadata, e.g. the table properties of
+// the LSM tree, without reading the key-value pairs.
+type SizeEstimator interface {
+	// EstimateSize returns the approximate bytes and the approximate number of
+	// keys in [start, end).
+	EstimateSize(start, end []byte) (bytes uint64, keys uint64, err error)
+	// EstimateSplitKeys returns the approximate keys splitting [start, end) into
+	// ranges that each holds about size bytes. The returned keys are in
+	// ascending order and within (start, end).
+	EstimateSplitKeys(start, end []byte, size uint64) ([][]byte, error)
+}
+
+// RangeCompactor is implemented by the KVStorage which is able to compact a
+// range manually, e.g. the LSM tree, to drop the deleted keys and the range
+// tombstones of the range.
+type RangeCompactor interface {
+	// CompactRange compacts the data in [start, end).
+	CompactRange(start, end []byte) error
+}
+
+// WALDisabledStore is implemented by the KVStorage which may be opened with the
+// WAL disabled, the writes are persistent only after the storage is synced.
+type WALDisabledStore interface {
+	// WALDisabled returns true if the WAL of the storage is disabled.
+	WALDisabled() bool
+}
+
+// BackgroundScanStore is implemented by the KVStorage which can serve the large
+// background scans, e.g. the split check, the stats and the checksum scans,
+// without filling the block cache of the foreground reads.
+type BackgroundScanStore interface {
+	// GetBackgroundView returns a point in time view for the background scans,
+	// the view is only scanned by the ScanInView family of the same storage.
+	GetBackgroundView() (View, error)
+}
+
+// GetBackgroundView returns the view of the background scans of the kv, or the
+// GetView of the kv if it's not a BackgroundScanStore.
+func GetBackgroundView(kv KVStore) (View, error) {
+	if bs, ok := kv.(BackgroundScanStore); ok {
+		return bs.GetBackgroundView()
+	}
+	return kv.GetView(), nil
+}
+
 // KVMetadataStore is a KV based data store for storing MatrixCube metadata.
 type KVMetadataStore interface {
 	// not allowed to close the store
@@ -105,8 +224,87 @@ type KVMetadataStore interface {
 	KVStore
 }
 
+// SnapshotCompression is the compression of the snapshot stream
+type SnapshotCompression byte
+
+const (
+	// NoCompression the snapshot stream is not compressed
+	NoCompression SnapshotCompression = iota
+	// SnappyCompression the snapshot stream is compressed by snappy
+	SnappyCompression
+	// ZstdCompression the snapshot stream is compressed by zstd, it's only
+	// supported if built with cgo.
+	ZstdCompression
+)
+
+// SnapshotStreamOptions the options of streaming the snapshot
+type SnapshotStreamOptions struct {
+	// Compression the compression of the written snapshot stream, the reader
+	// detects the compression from the stream.
+	Compression SnapshotCompression
+	// BytesPerSecond limits the rate of the stream, 0 means unlimited. The
+	// rate is of the compressed bytes.
+	BytesPerSecond int64
+}
+
 // KVBaseStorage is a KV based base storage.
 type KVBaseStorage interface {
 	BaseStorage
 	KVStore
+	// CreateSnapshotTo writes the snapshot of the specified shard into the
+	// writer, no local file is written.
+	CreateSnapshotTo(shardID uint64, w io.Writer, opts SnapshotStreamOptions) error
+	// ApplySnapshotFrom applies the snapshot read from the reader into the
+	// specified shard, the compression is detected from the stream and
+	// ErrSnapshotCorrupted is returned if the stream is corrupted.
+	ApplySnapshotFrom(shardID uint64, r io.Reader, opts SnapshotStreamOptions) error
+	// SizeEstimator estimates the size of the data by the underlying storage,
+	// ErrEstimateNotSupported is returned if the underlying storage is not a
+	// SizeEstimator.
+	SizeEstimator
+	// RangeCompactor compacts the range by the underlying storage,
+	// ErrCompactNotSupported is returned if the underlying storage is not a
+	// RangeCompactor.
+	RangeCompactor
+	// TTLStore stores the keys expire after the TTL.
+	TTLStore
+}
+
+// ExpiredKey the key expired with the expiration of its TTL, the expiration is
+// the unix nanoseconds.
+type ExpiredKey struct {
+	Key        []byte
+	Expiration int64
+}
+
+// TTLStore stores the keys expire after the TTL. The expired keys are never
+// returned by the reads and scans of the KVStore, but they are kept in the
+// storage until the removal replicated by the purge writes, so all replicas
+// apply the writes on the same data regardless of their local clocks.
+//
+// The expiry metadata of a key is stored with the 0x02 prefix followed by the
+// key, the metadata of the keys in a shard are in the range mirroring the data
+// range of the shard, which is included in the shard snapshots and removed with
+// the shard data.
+type TTLStore interface {
+	// SetTTL puts the key-value pair which expires at the expiration into the
+	// write batch, the expiration is the unix nanoseconds. The TTL is cleared
+	// once the key is overwritten by other writes.
+	SetTTL(wb util.WriteBatch, key, value []byte, expiration int64)
+	// PurgeExpired puts the removal of the expired keys into the write batch,
+	// the current values and the metadata are read by the get func, so the keys
+	// written in the batch before are checked. A key is removed only if its TTL
+	// and value are not changed since it's found expired, the stale metadata of
+	// the overwritten keys are removed. Returns the number and the bytes of the
+	// removed key-value pairs.
+	PurgeExpired(wb util.WriteBatch, get func(key []byte) ([]byte, error),
+		expired []ExpiredKey) (int, uint64, error)
+	// ExpiredKeys returns at most limit keys in [start, end) whose TTL expired
+	// by the local clock, including the keys overwritten after their TTL set,
+	// whose stale metadata is to be removed.
+	ExpiredKeys(start, end []byte, limit int) ([]ExpiredKey, error)
+	// WithoutTTL returns the KVStore which ignores the TTL, the expired keys are
+	// returned until they're purged. It's used to apply the writes, so the
+	// writes reading the data get the same result on all replicas.
+	WithoutTTL() KVStorage
 }
@@ -0,0 +1,105 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+// DefaultIteratorPageSize the default page size of the Iterator
+const DefaultIteratorPageSize = 256
+
+// PageScan implements KVStore.ScanPage by the Scan and the ScanInView of the
+// kv, so the pages see the same key-value pairs as the scans.
+func PageScan(kv KVStore, start, end []byte, limit uint64,
+	opts ScanPageOptions) ([]KeyValue, []byte, error) {
+	var pairs []KeyValue
+	var next []byte
+	size := uint64(0)
+	handler := func(key, value []byte) (bool, error) {
+		if (limit > 0 && uint64(len(pairs)) >= limit) ||
+			(opts.MaxBytes > 0 && size >= opts.MaxBytes) {
+			next = key
+			return false, nil
+		}
+		pairs = append(pairs, KeyValue{Key: key, Value: value})
+		size += uint64(len(key) + len(value))
+		return true, nil
+	}
+
+	var err error
+	if opts.View != nil {
+		err = kv.ScanInView(opts.View, start, end, handler, true)
+	} else {
+		err = kv.Scan(start, end, handler, true)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return pairs, next, nil
+}
+
+// NewPageIterator implements KVStore.NewIterator by the ScanPage of the kv.
+func NewPageIterator(kv KVStore, start, end []byte, opts ScanPageOptions) Iterator {
+	if opts.PageSize == 0 {
+		opts.PageSize = DefaultIteratorPageSize
+	}
+	return &pageIterator{kv: kv, next: start, end: end, opts: opts, idx: -1}
+}
+
+type pageIterator struct {
+	kv   KVStore
+	next []byte
+	end  []byte
+	opts ScanPageOptions
+	page []KeyValue
+	idx  int
+	done bool
+	err  error
+}
+
+func (it *pageIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.idx++
+	for it.idx >= len(it.page) {
+		if it.done {
+			return false
+		}
+		it.page, it.next, it.err = it.kv.ScanPage(it.next, it.end,
+			it.opts.PageSize, it.opts)
+		if it.err != nil {
+			it.page = nil
+			return false
+		}
+		it.idx = 0
+		it.done = len(it.next) == 0
+	}
+	return true
+}
+
+func (it *pageIterator) Key() []byte {
+	return it.page[it.idx].Key
+}
+
+func (it *pageIterator) Value() []byte {
+	return it.page[it.idx].Value
+}
+
+func (it *pageIterator) Error() error {
+	return it.err
+}
+
+func (it *pageIterator) Close() error {
+	it.page = nil
+	it.done = true
+	return nil
+}
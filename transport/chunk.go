@@ -34,6 +34,7 @@ import (
 	"io"
 	"sync"
 	"sync/atomic"
+	"syscall"
 
 	"github.com/cockroachdb/errors"
 	"github.com/fagongzi/util/protoc"
@@ -82,18 +83,23 @@ func (l *ssLock) unlock() {
 
 // Chunk managed on the receiving side
 type Chunk struct {
-	logger    *zap.Logger
-	fs        vfs.FS
-	dir       snapshot.SnapshotDirFunc
-	onReceive func(metapb.RaftMessageBatch)
-	timeout   uint64
-	tick      uint64
-	gcTick    uint64
+	logger         *zap.Logger
+	fs             vfs.FS
+	dir            snapshot.SnapshotDirFunc
+	onReceive      func(metapb.RaftMessageBatch)
+	availableSpace AvailableSpaceFunc
+	timeout        uint64
+	tick           uint64
+	gcTick         uint64
+	// maxSlots the max number of the snapshots received at the same time
+	maxSlots uint64
 
 	mu struct {
 		sync.Mutex
 		tracked map[string]*tracked
 		locks   map[string]*ssLock
+		// reserved the space reserved by the snapshots being received
+		reserved map[string]uint64
 	}
 }
 
@@ -106,11 +112,13 @@ func NewChunk(logger *zap.Logger,
 		onReceive: onReceive,
 		timeout:   snapshotChunkTimeoutTick,
 		gcTick:    gcIntervalTick,
+		maxSlots:  maxConcurrentSlot,
 		dir:       dir,
 		fs:        fs,
 	}
 	c.mu.tracked = make(map[string]*tracked)
 	c.mu.locks = make(map[string]*ssLock)
+	c.mu.reserved = make(map[string]uint64)
 
 	return c
 }
@@ -184,6 +192,7 @@ func (c *Chunk) getTracked() map[string]*tracked {
 
 func (c *Chunk) resetLocked(key string) {
 	delete(c.mu.tracked, key)
+	delete(c.mu.reserved, key)
 }
 
 func (c *Chunk) getSnapshotLock(key string) *ssLock {
@@ -198,7 +207,13 @@ func (c *Chunk) getSnapshotLock(key string) *ssLock {
 }
 
 func (c *Chunk) isFull() bool {
-	return uint64(len(c.mu.tracked)) >= maxConcurrentSlot
+	return uint64(len(c.mu.tracked)) >= c.maxSlots
+}
+
+func (c *Chunk) trackedCount() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return uint64(len(c.mu.tracked))
 }
 
 func (c *Chunk) record(chunk metapb.SnapshotChunk) *tracked {
@@ -275,8 +290,39 @@ func (c *Chunk) addLocked(chunk metapb.SnapshotChunk) bool {
 			zap.String("key", key))
 		return false
 	}
+	if chunk.ChunkID == 0 && !c.reserve(key, chunk) {
+		// the sender is expected to pick another target
+		c.reset(key)
+		c.onReceive(c.toFailedMessage(td.first, metapb.SnapshotFailureReason_InsufficientSpace))
+		return false
+	}
+	data, err := decodeChunkData(chunk)
+	if err != nil {
+		c.removeTempDir(chunk)
+		c.logger.Error("failed to decode chunk, snapshot rejected",
+			zap.String("key", key),
+			zap.Error(err))
+		c.reset(key)
+		reason := metapb.SnapshotFailureReason_ChecksumMismatch
+		if errors.Is(err, ErrUnsupportedSnapshotFormat) {
+			reason = metapb.SnapshotFailureReason_UnsupportedFormat
+		}
+		c.onReceive(c.toFailedMessage(td.first, reason))
+		return false
+	}
+	chunk.Data = data
 	if err := c.save(chunk); err != nil {
 		c.removeTempDir(chunk)
+		if errors.Is(err, syscall.ENOSPC) {
+			// reject the snapshot and report the failure to the sender, the store
+			// is expected to recover once the space is released
+			c.logger.Error("no space to save chunk, snapshot rejected",
+				zap.String("key", key),
+				zap.Error(err))
+			c.reset(key)
+			c.onReceive(c.toFailedMessage(td.first, metapb.SnapshotFailureReason_DiskFull))
+			return false
+		}
 		c.logger.Fatal("failed to save chunk",
 			zap.String("key", key),
 			zap.Error(err))
@@ -396,9 +442,18 @@ func (c *Chunk) toMessage(chunk metapb.SnapshotChunk) metapb.RaftMessageBatch {
 			{
 				ShardID: chunk.ShardID,
 				To:      metapb.Replica{ID: chunk.ReplicaID, StoreID: chunk.StoreID},
-				From:    metapb.Replica{ID: chunk.From},
+				From:    metapb.Replica{ID: chunk.From, StoreID: chunk.FromStoreID},
 				Message: m,
 			},
 		},
 	}
 }
+
+// toFailedMessage returns the snapshot message marked with the reason of the
+// failure, the local replica reports the failure to the sender of the snapshot.
+func (c *Chunk) toFailedMessage(chunk metapb.SnapshotChunk,
+	reason metapb.SnapshotFailureReason) metapb.RaftMessageBatch {
+	msg := c.toMessage(chunk)
+	msg.Messages[0].SnapshotFailure = reason
+	return msg
+}
@@ -32,7 +32,9 @@ package transport
 import (
 	"crypto/rand"
 	"fmt"
+	"os"
 	"reflect"
+	"syscall"
 	"testing"
 
 	"github.com/fagongzi/util/protoc"
@@ -144,6 +146,23 @@ func TestMaxSlotIsEnforced(t *testing.T) {
 	runChunkTest(t, fn, fs)
 }
 
+func TestMaxSlotIsConfigurable(t *testing.T) {
+	fn := func(t *testing.T, chunks *Chunk, handler *testMessageHandler) {
+		chunks.maxSlots = 1
+		inputs := getTestChunks()
+		c := inputs[0]
+		require.True(t, chunks.addLocked(c))
+		assert.Equal(t, uint64(1), chunks.trackedCount())
+
+		c.ShardID++
+		require.NoError(t, chunks.fs.MkdirAll(chunks.dir(c.ShardID, c.ReplicaID), 0755))
+		assert.False(t, chunks.addLocked(c))
+		assert.Equal(t, uint64(1), chunks.trackedCount())
+	}
+	fs := vfs.GetTestFS()
+	runChunkTest(t, fn, fs)
+}
+
 func TestOutOfOrderChunkWillBeIgnored(t *testing.T) {
 	fn := func(t *testing.T, chunks *Chunk, handler *testMessageHandler) {
 		inputs := getTestChunks()
@@ -354,6 +373,128 @@ func TestOutOfDateChunkCanBeHandled(t *testing.T) {
 	runChunkTest(t, fn, fs)
 }
 
+type noSpaceFS struct {
+	vfs.FS
+}
+
+func (fs noSpaceFS) Create(name string) (vfs.File, error) {
+	f, err := fs.FS.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return noSpaceFile{f}, nil
+}
+
+type noSpaceFile struct {
+	vfs.File
+}
+
+func (f noSpaceFile) Write(p []byte) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: "test", Err: syscall.ENOSPC}
+}
+
+func TestChunkRejectedWhenDiskFull(t *testing.T) {
+	fn := func(t *testing.T, chunks *Chunk, handler *testMessageHandler) {
+		var received []metapb.RaftMessageBatch
+		chunks.onReceive = func(batch metapb.RaftMessageBatch) {
+			received = append(received, batch)
+		}
+		inputs := getTestChunks()
+		assert.False(t, chunks.addLocked(inputs[0]))
+		assert.Empty(t, chunks.getTracked())
+		assert.False(t, hasSnapshotTempDir(chunks, inputs[0]))
+		require.Equal(t, 1, len(received))
+		require.Equal(t, 1, len(received[0].Messages))
+		msg := received[0].Messages[0]
+		assert.Equal(t, metapb.SnapshotFailureReason_DiskFull, msg.SnapshotFailure)
+		assert.Equal(t, raftpb.MsgSnap, msg.Message.Type)
+		assert.Equal(t, inputs[0].Index, msg.Message.Snapshot.Metadata.Index)
+	}
+	runChunkTest(t, fn, noSpaceFS{vfs.GetTestFS()})
+}
+
+func TestChunkRejectedWithInsufficientSpace(t *testing.T) {
+	fn := func(t *testing.T, chunks *Chunk, handler *testMessageHandler) {
+		var received []metapb.RaftMessageBatch
+		chunks.onReceive = func(batch metapb.RaftMessageBatch) {
+			received = append(received, batch)
+		}
+		available := uint64(20480)
+		chunks.availableSpace = func() (uint64, error) {
+			return available, nil
+		}
+		inputs := getTestChunks()
+		for idx := range inputs {
+			inputs[idx].SnapshotSize = 10240
+		}
+		require.True(t, chunks.addLocked(inputs[0]))
+		assert.Equal(t, uint64(10240), chunks.getReserved())
+
+		// the space is reserved by the first snapshot
+		other := inputs[0]
+		other.ShardID = 101
+		require.NoError(t, chunks.fs.MkdirAll(snapshotDirFunc(other.ShardID, other.ReplicaID), 0755))
+		available = 15000
+		assert.False(t, chunks.addLocked(other))
+		require.Equal(t, 1, len(received))
+		assert.Equal(t, metapb.SnapshotFailureReason_InsufficientSpace,
+			received[0].Messages[0].SnapshotFailure)
+		assert.False(t, hasSnapshotTempDir(chunks, other))
+		assert.Equal(t, 1, len(chunks.getTracked()))
+
+		// the reservation is released once the snapshot is received
+		for _, c := range inputs[1:] {
+			require.True(t, chunks.addLocked(c))
+		}
+		require.Equal(t, 2, len(received))
+		assert.Equal(t, metapb.SnapshotFailureReason_NoFailure,
+			received[1].Messages[0].SnapshotFailure)
+		assert.Equal(t, uint64(0), chunks.getReserved())
+		require.True(t, chunks.addLocked(other))
+	}
+	runChunkTest(t, fn, vfs.GetTestFS())
+}
+
+func TestReceiveSnappyChunks(t *testing.T) {
+	fn := func(t *testing.T, chunks *Chunk, handler *testMessageHandler) {
+		inputs := getTestChunks()
+		for _, c := range inputs {
+			data, err := encodeChunkData(SnapshotFormatSnappy, nil, c.Data)
+			require.NoError(t, err)
+			c.Data = data
+			c.Format = SnapshotFormatSnappy
+			require.True(t, chunks.addLocked(c))
+		}
+		assert.Equal(t, uint64(1), handler.getSnapshotCount(100, 2))
+		checkTestSnapshotFile(t, chunks, inputs[0], 10240)
+	}
+	runChunkTest(t, fn, vfs.GetTestFS())
+}
+
+func TestChunkRejectedWithUnsupportedFormat(t *testing.T) {
+	fn := func(t *testing.T, chunks *Chunk, handler *testMessageHandler) {
+		var received []metapb.RaftMessageBatch
+		chunks.onReceive = func(batch metapb.RaftMessageBatch) {
+			received = append(received, batch)
+		}
+		inputs := getTestChunks()
+		inputs[0].Format = SnapshotFormatLatest + 1
+		assert.False(t, chunks.addLocked(inputs[0]))
+		assert.Empty(t, chunks.getTracked())
+		assert.False(t, hasSnapshotTempDir(chunks, inputs[0]))
+		require.Equal(t, 1, len(received))
+		assert.Equal(t, metapb.SnapshotFailureReason_UnsupportedFormat,
+			received[0].Messages[0].SnapshotFailure)
+
+		inputs[0].Format = SnapshotFormatSnappy
+		assert.False(t, chunks.addLocked(inputs[0]), "corrupted")
+		require.Equal(t, 2, len(received))
+		assert.Equal(t, metapb.SnapshotFailureReason_ChecksumMismatch,
+			received[1].Messages[0].SnapshotFailure)
+	}
+	runChunkTest(t, fn, vfs.GetTestFS())
+}
+
 func TestSignificantlyDelayedNonFirstChunkAreIgnored(t *testing.T) {
 	fn := func(t *testing.T, chunks *Chunk, handler *testMessageHandler) {
 		inputs := getTestChunks()
@@ -441,12 +582,13 @@ func TestToMessageFromChunk(t *testing.T) {
 		Extra: 12345,
 	}
 	chunk := metapb.SnapshotChunk{
-		ShardID:   123,
-		ReplicaID: 45,
-		From:      23,
-		Index:     100,
-		Term:      200,
-		Extra:     protoc.MustMarshal(si),
+		ShardID:     123,
+		ReplicaID:   45,
+		From:        23,
+		FromStoreID: 3,
+		Index:       100,
+		Term:        200,
+		Extra:       protoc.MustMarshal(si),
 	}
 	rsi := &metapb.SnapshotInfo{
 		Extra: chunk.From,
@@ -458,6 +600,7 @@ func TestToMessageFromChunk(t *testing.T) {
 	assert.Equal(t, chunk.ShardID, msg.ShardID)
 	assert.Equal(t, chunk.ReplicaID, msg.To.ID)
 	assert.Equal(t, chunk.From, msg.From.ID)
+	assert.Equal(t, chunk.FromStoreID, msg.From.StoreID)
 	assert.Equal(t, raftpb.MsgSnap, msg.Message.Type)
 	assert.Equal(t, chunk.Index, msg.Message.Snapshot.Metadata.Index)
 	assert.Equal(t, chunk.Term, msg.Message.Snapshot.Metadata.Term)
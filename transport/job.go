@@ -31,6 +31,7 @@ package transport
 
 import (
 	"context"
+	"sync/atomic"
 
 	"github.com/cockroachdb/errors"
 	"github.com/fagongzi/util/protoc"
@@ -61,6 +62,9 @@ type job struct {
 	shardID           uint64
 	replicaID         uint64
 	snapshotChunkSize uint64
+	progress          *snapshotProgress
+	// format the format of the chunks negotiated with the receiver
+	format uint64
 }
 
 func newJob(logger *zap.Logger,
@@ -139,6 +143,7 @@ func (j *job) sendSnapshot() error {
 
 func (j *job) sendChunks(chunks []metapb.SnapshotChunk) error {
 	chunkData := make([]byte, j.snapshotChunkSize)
+	var encoded []byte
 	for _, chunk := range chunks {
 		select {
 		case <-j.stopc:
@@ -152,10 +157,18 @@ func (j *job) sendChunks(chunks []metapb.SnapshotChunk) error {
 			j.logger.Fatal("failed to load chunk data",
 				zap.Error(err))
 		}
-		chunk.Data = data
+		encoded, err = encodeChunkData(j.format, encoded, data)
+		if err != nil {
+			return err
+		}
+		chunk.Data = encoded
+		chunk.Format = j.format
 		if err := j.conn.SendChunk(chunk); err != nil {
 			return err
 		}
+		if j.progress != nil {
+			atomic.AddUint64(&j.progress.sent, chunk.ChunkSize)
+		}
 	}
 	return nil
 }
@@ -87,7 +87,14 @@ func (t *Transport) sendSnapshot(m metapb.RaftMessage) bool {
 	if job == nil {
 		return false
 	}
+	job.format = t.negotiateSnapshotFormat(storeID)
+	key := nodeInfo{ShardID: m.ShardID, ReplicaID: m.To.ID}
+	job.progress = newSnapshotProgress(chunks)
+	t.sendings.Store(key, job.progress)
 	shutdown := func() {
+		if v, ok := t.sendings.Load(key); ok && v == job.progress {
+			t.sendings.Delete(key)
+		}
 		atomic.AddUint64(&t.jobs, ^uint64(0))
 	}
 	t.stopper.RunWorker(func() {
@@ -98,6 +105,20 @@ func (t *Transport) sendSnapshot(m metapb.RaftMessage) bool {
 	return true
 }
 
+// snapshotProgress the progress of the snapshot being sent
+type snapshotProgress struct {
+	sent  uint64
+	total uint64
+}
+
+func newSnapshotProgress(chunks []metapb.SnapshotChunk) *snapshotProgress {
+	p := &snapshotProgress{}
+	for _, chunk := range chunks {
+		p.total += chunk.ChunkSize
+	}
+	return p
+}
+
 func (t *Transport) getEnv(m metapb.RaftMessage) snapshot.SSEnv {
 	ss := m.Message.Snapshot
 	si := metapb.SnapshotInfo{}
@@ -110,7 +131,11 @@ func (t *Transport) getEnv(m metapb.RaftMessage) snapshot.SSEnv {
 
 func (t *Transport) createJob(shardID uint64, toReplicaID uint64,
 	addr string, streaming bool, sz int) *job {
-	if v := atomic.AddUint64(&t.jobs, 1); v > maxConnectionCount {
+	max := maxConnectionCount
+	if t.maxSnapshots > 0 {
+		max = t.maxSnapshots
+	}
+	if v := atomic.AddUint64(&t.jobs, 1); v > max {
 		r := atomic.AddUint64(&t.jobs, ^uint64(0))
 		t.logger.Warn("job count is rate limited",
 			zap.Uint64("job-count", r))
@@ -206,8 +231,15 @@ func getChunks(m metapb.RaftMessage,
 		results = append(results, chunks...)
 	}
 
+	// the size of the snapshot is advertised by all chunks, the receiver reserves
+	// the space on the first chunk
+	var size uint64
+	for _, chunk := range results {
+		size += chunk.ChunkSize
+	}
 	for idx := range results {
 		results[idx].ChunkCount = uint64(len(results))
+		results[idx].SnapshotSize = size
 	}
 	return results, nil
 }
@@ -233,6 +265,7 @@ func splitBySnapshotFile(msg metapb.RaftMessage,
 			ShardID:        msg.ShardID,
 			ReplicaID:      msg.To.ID,
 			From:           msg.From.ID,
+			FromStoreID:    msg.From.StoreID,
 			FileChunkID:    i,
 			FileChunkCount: chunkCount,
 			ChunkID:        startChunkID + i,
@@ -0,0 +1,102 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"github.com/cockroachdb/errors"
+	"github.com/golang/snappy"
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/metric"
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+const (
+	// SnapshotFormatRaw the data of the chunk is the raw bytes of the snapshot
+	// file, it's the only format received by the stores before the format
+	// negotiation.
+	SnapshotFormatRaw uint64 = 0
+	// SnapshotFormatSnappy the data of the chunk is compressed by snappy
+	SnapshotFormatSnappy uint64 = 1
+	// SnapshotFormatLatest the newest format of the snapshot chunks, which is
+	// advertised by the store to the senders.
+	SnapshotFormatLatest = SnapshotFormatSnappy
+)
+
+var (
+	// ErrUnsupportedSnapshotFormat is returned when the format of the snapshot
+	// chunk is unknown to the receiver.
+	ErrUnsupportedSnapshotFormat = errors.New("unsupported snapshot format")
+)
+
+// SnapshotFormatResolver returns the newest snapshot format advertised by the
+// store, the raw format is used if it's 0 or the store can't be resolved.
+type SnapshotFormatResolver func(storeID uint64) (uint64, error)
+
+// WithSnapshotFormatResolver negotiates the format of the snapshots sent to the
+// stores with the formats advertised by them, the stores of the older versions
+// keep receiving the snapshots during the rolling upgrades. The snapshots are
+// always sent in the raw format without the resolver.
+func WithSnapshotFormatResolver(resolver SnapshotFormatResolver) Option {
+	return func(t *Transport) {
+		t.formatResolver = resolver
+	}
+}
+
+// negotiateSnapshotFormat returns the newest format supported by both sides
+func (t *Transport) negotiateSnapshotFormat(storeID uint64) uint64 {
+	if t.formatResolver == nil {
+		return SnapshotFormatRaw
+	}
+	format, err := t.formatResolver(storeID)
+	if err != nil {
+		t.logger.Warn("failed to resolve snapshot format, use the raw format",
+			zap.Uint64("store", storeID),
+			zap.Error(err))
+		format = SnapshotFormatRaw
+	}
+	if format >= SnapshotFormatLatest {
+		return SnapshotFormatLatest
+	}
+	t.logger.Debug("snapshot format downgraded",
+		zap.Uint64("store", storeID),
+		zap.Uint64("format", format))
+	metric.IncSnapshotFormatDowngradeCount(format)
+	return format
+}
+
+// encodeChunkData encodes the raw data of the chunk in the format, the buf is
+// reused if it's large enough.
+func encodeChunkData(format uint64, buf, data []byte) ([]byte, error) {
+	switch format {
+	case SnapshotFormatRaw:
+		return data, nil
+	case SnapshotFormatSnappy:
+		return snappy.Encode(buf[:cap(buf)], data), nil
+	default:
+		return nil, errors.Wrapf(ErrUnsupportedSnapshotFormat, "format %d", format)
+	}
+}
+
+// decodeChunkData returns the raw data of the received chunk
+func decodeChunkData(chunk metapb.SnapshotChunk) ([]byte, error) {
+	switch chunk.Format {
+	case SnapshotFormatRaw:
+		return chunk.Data, nil
+	case SnapshotFormatSnappy:
+		return snappy.Decode(nil, chunk.Data)
+	default:
+		return nil, errors.Wrapf(ErrUnsupportedSnapshotFormat, "format %d", chunk.Format)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+func TestNegotiateSnapshotFormat(t *testing.T) {
+	trans := &Transport{logger: zap.NewNop()}
+	assert.Equal(t, SnapshotFormatRaw, trans.negotiateSnapshotFormat(1), "no resolver")
+
+	formats := map[uint64]uint64{1: SnapshotFormatRaw, 2: SnapshotFormatSnappy, 3: SnapshotFormatLatest + 1}
+	WithSnapshotFormatResolver(func(storeID uint64) (uint64, error) {
+		format, ok := formats[storeID]
+		if !ok {
+			return 0, errors.New("store not found")
+		}
+		return format, nil
+	})(trans)
+	assert.Equal(t, SnapshotFormatRaw, trans.negotiateSnapshotFormat(1))
+	assert.Equal(t, SnapshotFormatSnappy, trans.negotiateSnapshotFormat(2))
+	assert.Equal(t, SnapshotFormatLatest, trans.negotiateSnapshotFormat(3), "newer receiver")
+	assert.Equal(t, SnapshotFormatRaw, trans.negotiateSnapshotFormat(4))
+}
+
+func TestEncodeChunkData(t *testing.T) {
+	data := make([]byte, 1024)
+	encoded, err := encodeChunkData(SnapshotFormatRaw, nil, data)
+	require.NoError(t, err)
+	assert.Equal(t, data, encoded)
+
+	encoded, err = encodeChunkData(SnapshotFormatSnappy, nil, data)
+	require.NoError(t, err)
+	assert.True(t, len(encoded) < len(data))
+	decoded, err := decodeChunkData(metapb.SnapshotChunk{Format: SnapshotFormatSnappy, Data: encoded})
+	require.NoError(t, err)
+	assert.Equal(t, data, decoded)
+
+	_, err = encodeChunkData(SnapshotFormatLatest+1, nil, data)
+	assert.True(t, errors.Is(err, ErrUnsupportedSnapshotFormat))
+	_, err = decodeChunkData(metapb.SnapshotChunk{Format: SnapshotFormatLatest + 1})
+	assert.True(t, errors.Is(err, ErrUnsupportedSnapshotFormat))
+}
@@ -0,0 +1,82 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+// AvailableSpaceFunc returns the available space in bytes of the disk storing
+// the received snapshots.
+type AvailableSpaceFunc func() (uint64, error)
+
+// WithSnapshotSpaceChecker checks the available space before receiving the
+// snapshots. The size of the snapshot advertised in the first chunk is reserved
+// until the snapshot is received or dropped, and the snapshot is rejected with
+// the InsufficientSpace failure if the space minus the reserved space of the
+// other snapshots can't hold it. The snapshots from the senders of the older
+// versions advertise no size and are always accepted.
+func WithSnapshotSpaceChecker(fn AvailableSpaceFunc) Option {
+	return func(t *Transport) {
+		t.availableSpace = fn
+	}
+}
+
+// reserve reserves the space of the snapshot started by the first chunk, false
+// is returned if there is no enough space.
+func (c *Chunk) reserve(key string, chunk metapb.SnapshotChunk) bool {
+	if c.availableSpace == nil || chunk.SnapshotSize == 0 {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	available, err := c.availableSpace()
+	if err != nil {
+		// the snapshot is not blocked by the failure of the checker, the disk full
+		// error is still handled when saving the chunks
+		c.logger.Warn("failed to get available space, snapshot accepted",
+			zap.String("key", key),
+			zap.Error(err))
+		return true
+	}
+	reserved := uint64(0)
+	for k, v := range c.mu.reserved {
+		if k != key {
+			reserved += v
+		}
+	}
+	if available < reserved || available-reserved < chunk.SnapshotSize {
+		c.logger.Error("insufficient space, snapshot rejected",
+			zap.String("key", key),
+			zap.Uint64("size", chunk.SnapshotSize),
+			zap.Uint64("available", available),
+			zap.Uint64("reserved", reserved))
+		return false
+	}
+	c.mu.reserved[key] = chunk.SnapshotSize
+	return true
+}
+
+// getReserved returns the total reserved space of the snapshots being received
+func (c *Chunk) getReserved() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	reserved := uint64(0)
+	for _, v := range c.mu.reserved {
+		reserved += v
+	}
+	return reserved
+}
@@ -354,6 +354,16 @@ func (t *testTransportStatus) waitCount(tt *testing.T, value int, p *uint64, tim
 }
 
 func TestSnapshotCanBeTransported(t *testing.T) {
+	testSnapshotCanBeTransported(t)
+}
+
+func TestSnappySnapshotCanBeTransported(t *testing.T) {
+	testSnapshotCanBeTransported(t, WithSnapshotFormatResolver(func(uint64) (uint64, error) {
+		return SnapshotFormatSnappy, nil
+	}))
+}
+
+func testSnapshotCanBeTransported(t *testing.T, opts ...Option) {
 	// shard 1 replica 1 wants to send a snapshot at index 100 to replica 2, extra value is 12345
 	defer leaktest.AfterTest(t)()
 	fs := vfs.GetTestFS()
@@ -399,10 +409,11 @@ func TestSnapshotCanBeTransported(t *testing.T) {
 	status := &testTransportStatus{}
 	trans := NewTransport(logger, testTransportAddr, 2,
 		status.MessageHandler, status.UnreachableHandler, status.SnapshotStatusHandler,
-		getTestSnapshotDir, testStoreResolver, fs)
+		getTestSnapshotDir, testStoreResolver, fs, opts...)
 	require.NoError(t, trans.Start())
 	defer trans.Close()
 	assert.True(t, trans.SendSnapshot(raftMsg))
 	status.waitMessageCount(t, 1, 10*time.Second)
 	status.waitStatusCount(t, 1, 10*time.Second)
+	assert.False(t, status.rejected)
 }
@@ -61,10 +61,49 @@ type Trans interface {
 	SendSnapshot(metapb.RaftMessage) bool
 	SetFilter(func(metapb.RaftMessage) bool)
 	SendingSnapshotCount() uint64
+	// ReceivingSnapshotCount returns the number of the snapshots being received
+	ReceivingSnapshotCount() uint64
+	// SnapshotProgress returns the sent bytes and the total bytes of the
+	// snapshot being sent to the replica, false if no snapshot is being sent.
+	SnapshotProgress(shardID, replicaID uint64) (sent, total uint64, ok bool)
 	Start() error
 	Close() error
 }
 
+// Option transport option
+type Option func(*Transport)
+
+// WithMessageBatch sets how the raft messages sent to the same store are
+// batched. The messages arrived within the window are sent in one frame, unless
+// the frame exceeds maxSize bytes. 0 window means the messages are sent as soon
+// as they arrive, 0 maxSize means the default 8MB.
+func WithMessageBatch(window time.Duration, maxSize uint64) Option {
+	return func(t *Transport) {
+		t.batchWindow = window
+		if maxSize > 0 {
+			t.maxBatchSize = maxSize
+		}
+	}
+}
+
+// WithMaxConcurrentSnapshots limits the number of the snapshots sent and the
+// number of the snapshots received at the same time, the snapshots beyond the
+// limit are rejected and sent again by raft later. 0 means the default limits.
+func WithMaxConcurrentSnapshots(max uint64) Option {
+	return func(t *Transport) {
+		t.maxSnapshots = max
+	}
+}
+
+// WithUDPHeartbeat sends the heartbeat messages in udp datagrams on the same
+// address of the tcp transport, it is experimental. The other messages and the
+// snapshots are still sent by the tcp connections.
+func WithUDPHeartbeat() Option {
+	return func(t *Transport) {
+		t.udpHeartbeat = true
+	}
+}
+
 type StoreResolver func(storeID uint64) (string, error)
 
 type MessageHandler func(metapb.RaftMessageBatch)
@@ -152,20 +191,28 @@ type Transport struct {
 	unreachable    UnreachableHandler
 	snapshotStatus SnapshotStatusHandler
 	resolver       StoreResolver
+	formatResolver SnapshotFormatResolver
+	availableSpace AvailableSpaceFunc
 	trans          TransImpl
 	dir            snapshot.SnapshotDirFunc
 	chunks         *Chunk
 	stopper        *syncutil.Stopper
 	addrs          sync.Map // storeID -> targetInfo
 	addrsRevert    sync.Map // addr -> storeID
+	sendings       sync.Map // nodeInfo -> *snapshotProgress
 	fs             vfs.FS
+	batchWindow    time.Duration
+	maxBatchSize   uint64
+	udpHeartbeat   bool
+	udp            *udpChannel
+	maxSnapshots   uint64
 }
 
 func NewTransport(logger *zap.Logger, addr string,
 	storeID uint64, handler MessageHandler,
 	unreachable UnreachableHandler, snapshotStatus SnapshotStatusHandler,
 	dir snapshot.SnapshotDirFunc,
-	resolver StoreResolver, fs vfs.FS) *Transport {
+	resolver StoreResolver, fs vfs.FS, opts ...Option) *Transport {
 	t := &Transport{
 		logger:         log.Adjust(logger),
 		storeID:        storeID,
@@ -176,9 +223,20 @@ func NewTransport(logger *zap.Logger, addr string,
 		resolver:       resolver,
 		stopper:        syncutil.NewStopper(),
 		fs:             fs,
+		maxBatchSize:   maxMsgBatchSize,
+	}
+	for _, opt := range opts {
+		opt(t)
 	}
 	t.chunks = NewChunk(t.logger, t.handler, t.dir, fs)
+	t.chunks.availableSpace = t.availableSpace
+	if t.maxSnapshots > 0 {
+		t.chunks.maxSlots = t.maxSnapshots
+	}
 	t.trans = NewTCPTransport(logger, addr, handler, t.chunks.Add)
+	if t.udpHeartbeat {
+		t.udp = newUDPChannel(t.logger, addr, handler)
+	}
 	t.mu.queues = make(map[string]chan metapb.RaftMessage)
 	t.mu.breakers = make(map[string]*circuit.Breaker)
 	t.ctx, t.cancel = context.WithCancel(context.Background())
@@ -200,13 +258,25 @@ func NewTransport(logger *zap.Logger, addr string,
 }
 
 func (t *Transport) Start() error {
-	return t.trans.Start()
+	if err := t.trans.Start(); err != nil {
+		return err
+	}
+	if t.udp != nil {
+		return t.udp.start()
+	}
+	return nil
 }
 
 // Close closes the Transport object.
 func (t *Transport) Close() error {
 	t.cancel()
 	t.stopper.Stop()
+	if t.udp != nil {
+		if err := t.udp.close(); err != nil {
+			t.logger.Error("failed to close the udp channel",
+				zap.Error(err))
+		}
+	}
 	return t.trans.Close()
 }
 
@@ -223,7 +293,20 @@ func (t *Transport) SetFilter(f func(metapb.RaftMessage) bool) {
 }
 
 func (t *Transport) SendingSnapshotCount() uint64 {
-	return 0
+	return atomic.LoadUint64(&t.jobs)
+}
+
+func (t *Transport) ReceivingSnapshotCount() uint64 {
+	return t.chunks.trackedCount()
+}
+
+func (t *Transport) SnapshotProgress(shardID, replicaID uint64) (uint64, uint64, bool) {
+	v, ok := t.sendings.Load(nodeInfo{ShardID: shardID, ReplicaID: replicaID})
+	if !ok {
+		return 0, 0, false
+	}
+	progress := v.(*snapshotProgress)
+	return atomic.LoadUint64(&progress.sent), progress.total, true
 }
 
 func (t *Transport) Send(m metapb.RaftMessage) bool {
@@ -252,6 +335,10 @@ func (t *Transport) Send(m metapb.RaftMessage) bool {
 		return false
 	}
 
+	if t.udp != nil && isHeartbeatMessage(m) && t.udp.send(targetInfo.addr, m) {
+		return true
+	}
+
 	t.mu.Lock()
 	ch, ok := t.mu.queues[targetInfo.key]
 	if !ok {
@@ -328,9 +415,9 @@ func (t *Transport) processMessages(addr string,
 	ch chan metapb.RaftMessage, conn Connection, affected nodeMap) error {
 	idleTimer := time.NewTimer(idleTimeout)
 	defer idleTimer.Stop()
-	sz := uint64(0)
 	batch := metapb.RaftMessageBatch{}
 	requests := make([]metapb.RaftMessage, 0)
+	sizes := make([]uint64, 0)
 	for {
 		if !idleTimer.Stop() {
 			select {
@@ -345,40 +432,16 @@ func (t *Transport) processMessages(addr string,
 		case <-idleTimer.C:
 			return nil
 		case req := <-ch:
-			n := nodeInfo{
-				ShardID:   req.ShardID,
-				ReplicaID: req.From.ID,
+			requests, sizes = append(requests, req), append(sizes, uint64(req.Size()))
+			var ok bool
+			if requests, sizes, ok = t.collectMessages(ch, requests, sizes); !ok {
+				return nil
 			}
-			affected[n] = struct{}{}
-			// TODO: this is slow
-			sz += uint64(req.Size())
-			requests = append(requests, req)
-			for done := false; !done && sz < maxMsgBatchSize; {
-				select {
-				case req = <-ch:
-					sz += uint64(req.Size())
-					requests = append(requests, req)
-				case <-t.stopper.ShouldStop():
-					return nil
-				default:
-					done = true
-				}
+			for _, req := range requests {
+				affected[nodeInfo{ShardID: req.ShardID, ReplicaID: req.From.ID}] = struct{}{}
 			}
-			twoBatch := false
-			if sz < maxMsgBatchSize || len(requests) == 1 {
-				batch.Messages = requests
-			} else {
-				twoBatch = true
-				batch.Messages = requests[:len(requests)-1]
-			}
-			if err := t.sendMessageBatch(conn, batch); err != nil {
-				t.logger.Error("send batch failed",
-					zap.String("target", addr),
-					zap.Error(err))
-				return err
-			}
-			if twoBatch {
-				batch.Messages = []metapb.RaftMessage{requests[len(requests)-1]}
+			for _, msgs := range splitMessages(requests, sizes, t.maxBatchSize) {
+				batch.Messages = msgs
 				if err := t.sendMessageBatch(conn, batch); err != nil {
 					t.logger.Error("send batch failed",
 						zap.String("target", addr),
@@ -386,11 +449,70 @@ func (t *Transport) processMessages(addr string,
 					return err
 				}
 			}
-			sz = 0
 			requests, batch = lazyFree(requests, batch)
-			requests = requests[:0]
+			requests, sizes = requests[:0], sizes[:0]
+		}
+	}
+}
+
+// collectMessages collects the queued messages, and the messages arrived within
+// the batch window if the window is set, until the max batch size is reached.
+// Returns false if the transport is stopped.
+func (t *Transport) collectMessages(ch chan metapb.RaftMessage,
+	requests []metapb.RaftMessage, sizes []uint64) ([]metapb.RaftMessage, []uint64, bool) {
+	sz := uint64(0)
+	for _, v := range sizes {
+		sz += v
+	}
+	var window <-chan time.Time
+	if t.batchWindow > 0 {
+		timer := time.NewTimer(t.batchWindow)
+		defer timer.Stop()
+		window = timer.C
+	}
+	for sz < t.maxBatchSize {
+		select {
+		case req := <-ch:
+			// TODO: this is slow
+			v := uint64(req.Size())
+			sz += v
+			requests, sizes = append(requests, req), append(sizes, v)
+			continue
+		case <-t.stopper.ShouldStop():
+			return requests, sizes, false
+		default:
+		}
+		if window == nil {
+			break
+		}
+		select {
+		case req := <-ch:
+			v := uint64(req.Size())
+			sz += v
+			requests, sizes = append(requests, req), append(sizes, v)
+		case <-window:
+			return requests, sizes, true
+		case <-t.stopper.ShouldStop():
+			return requests, sizes, false
+		}
+	}
+	return requests, sizes, true
+}
+
+// splitMessages splits the messages into frames no larger than maxSize bytes,
+// a message larger than maxSize is sent in its own frame.
+func splitMessages(requests []metapb.RaftMessage, sizes []uint64,
+	maxSize uint64) [][]metapb.RaftMessage {
+	var frames [][]metapb.RaftMessage
+	start, sz := 0, uint64(0)
+	for i, v := range sizes {
+		if i > start && sz+v > maxSize {
+			frames = append(frames, requests[start:i])
+			start, sz = i, 0
 		}
+		sz += v
 	}
+	return append(frames, requests[start:])
 }
 
 func lazyFree(reqs []metapb.RaftMessage,
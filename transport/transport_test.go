@@ -33,7 +33,9 @@ import (
 	"errors"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/lni/goutils/syncutil"
 	"go.etcd.io/etcd/raft/v3/raftpb"
 
 	"github.com/matrixorigin/matrixcube/pb/metapb"
@@ -203,3 +205,51 @@ func TestSetNilFilter(t *testing.T) {
 	}()
 	assert.True(t, hasPanic)
 }
+
+func TestSplitMessages(t *testing.T) {
+	requests := []metapb.RaftMessage{{ShardID: 1}, {ShardID: 2}, {ShardID: 3}, {ShardID: 4}}
+
+	frames := splitMessages(requests, []uint64{1, 1, 1, 1}, 10)
+	assert.Equal(t, [][]metapb.RaftMessage{requests}, frames)
+
+	frames = splitMessages(requests, []uint64{4, 4, 4, 4}, 10)
+	assert.Equal(t, [][]metapb.RaftMessage{requests[:2], requests[2:]}, frames)
+
+	// the large message is sent in its own frame
+	frames = splitMessages(requests, []uint64{1, 20, 1, 1}, 10)
+	assert.Equal(t, [][]metapb.RaftMessage{requests[:1], requests[1:2], requests[2:]}, frames)
+}
+
+func TestCollectMessages(t *testing.T) {
+	trans := &Transport{stopper: syncutil.NewStopper(), maxBatchSize: maxMsgBatchSize}
+	defer trans.stopper.Stop()
+
+	ch := make(chan metapb.RaftMessage, 10)
+	ch <- metapb.RaftMessage{ShardID: 2}
+	requests, sizes, ok := trans.collectMessages(ch,
+		[]metapb.RaftMessage{{ShardID: 1}}, []uint64{1})
+	assert.True(t, ok)
+	assert.Equal(t, 2, len(requests))
+	assert.Equal(t, 2, len(sizes))
+
+	// the messages arrived within the window are collected
+	trans.batchWindow = time.Second
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		ch <- metapb.RaftMessage{ShardID: 2}
+		ch <- metapb.RaftMessage{ShardID: 3}
+	}()
+	requests, _, ok = trans.collectMessages(ch,
+		[]metapb.RaftMessage{{ShardID: 1}}, []uint64{1})
+	assert.True(t, ok)
+	assert.Equal(t, 3, len(requests))
+
+	// the max batch size is reached
+	trans.maxBatchSize = 1
+	ch <- metapb.RaftMessage{ShardID: 2}
+	requests, _, ok = trans.collectMessages(ch,
+		[]metapb.RaftMessage{{ShardID: 1}}, []uint64{1})
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(requests))
+	assert.Equal(t, 1, len(ch))
+}
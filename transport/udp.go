@@ -0,0 +1,178 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"net"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/fagongzi/util/protoc"
+	"github.com/lni/goutils/syncutil"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+const (
+	udpHeaderSize = 6
+	// maxUDPMessageSize keeps the datagram within the common MTU to avoid the ip
+	// fragmentation, the larger messages are sent by the tcp connection.
+	maxUDPMessageSize = 1400
+)
+
+// isHeartbeatMessage returns true if the message is small and loss-tolerant,
+// the lost heartbeats are retransmitted by the raft on the next heartbeat tick.
+func isHeartbeatMessage(m metapb.RaftMessage) bool {
+	return m.Message.Type == raftpb.MsgHeartbeat ||
+		m.Message.Type == raftpb.MsgHeartbeatResp
+}
+
+// udpChannel is an experimental channel to send the heartbeat messages in udp
+// datagrams, so that the heartbeats are not blocked by the appends and
+// snapshots queued in the tcp connections on the lossy links. It listens on the
+// same address as the tcp transport, and each datagram is a magic number, a
+// crc32 checksum of the payload and a RaftMessageBatch with one message.
+type udpChannel struct {
+	logger  *zap.Logger
+	addr    string
+	handler MessageHandler
+	stopper *syncutil.Stopper
+	conn    *net.UDPConn
+	addrs   sync.Map // addr -> *net.UDPAddr
+}
+
+func newUDPChannel(logger *zap.Logger, addr string, handler MessageHandler) *udpChannel {
+	return &udpChannel{
+		logger:  logger,
+		addr:    addr,
+		handler: handler,
+		stopper: syncutil.NewStopper(),
+	}
+}
+
+func (c *udpChannel) start() error {
+	addr, err := net.ResolveUDPAddr("udp", c.addr)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+	c.stopper.RunWorker(c.serve)
+	return nil
+}
+
+func (c *udpChannel) close() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.stopper.Stop()
+	return err
+}
+
+func (c *udpChannel) serve() {
+	buf := make([]byte, maxUDPMessageSize+udpHeaderSize)
+	for {
+		n, _, err := c.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			c.logger.Error("failed to read udp message",
+				zap.Error(err))
+			continue
+		}
+		batch, ok := decodeUDPMessage(buf[:n])
+		if !ok {
+			c.logger.Error("invalid udp message")
+			continue
+		}
+		c.handler(batch)
+	}
+}
+
+// send returns false if the message is not sent, the caller should send it by
+// the tcp connection.
+func (c *udpChannel) send(addr string, m metapb.RaftMessage) bool {
+	if c.conn == nil {
+		return false
+	}
+	buf, ok := encodeUDPMessage(m)
+	if !ok {
+		return false
+	}
+	target, err := c.resolve(addr)
+	if err != nil {
+		c.logger.Error("failed to resolve udp addr",
+			zap.String("addr", addr),
+			zap.Error(err))
+		return false
+	}
+	if _, err := c.conn.WriteToUDP(buf, target); err != nil {
+		c.logger.Debug("failed to send udp message",
+			zap.String("addr", addr),
+			zap.Error(err))
+		return false
+	}
+	return true
+}
+
+func (c *udpChannel) resolve(addr string) (*net.UDPAddr, error) {
+	if v, ok := c.addrs.Load(addr); ok {
+		return v.(*net.UDPAddr), nil
+	}
+	target, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c.addrs.Store(addr, target)
+	return target, nil
+}
+
+func encodeUDPMessage(m metapb.RaftMessage) ([]byte, bool) {
+	batch := metapb.RaftMessageBatch{Messages: []metapb.RaftMessage{m}}
+	if batch.Size() > maxUDPMessageSize {
+		return nil, false
+	}
+	payload := protoc.MustMarshal(&batch)
+	buf := make([]byte, udpHeaderSize+len(payload))
+	copy(buf, magicNumber[:])
+	binary.BigEndian.PutUint32(buf[2:], crc32.ChecksumIEEE(payload))
+	copy(buf[udpHeaderSize:], payload)
+	return buf, true
+}
+
+func decodeUDPMessage(buf []byte) (metapb.RaftMessageBatch, bool) {
+	batch := metapb.RaftMessageBatch{}
+	if len(buf) <= udpHeaderSize ||
+		!bytes.Equal(buf[:2], magicNumber[:]) {
+		return batch, false
+	}
+	payload := buf[udpHeaderSize:]
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(buf[2:]) {
+		return batch, false
+	}
+	if err := batch.Unmarshal(payload); err != nil {
+		return batch, false
+	}
+	return batch, true
+}
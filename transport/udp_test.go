@@ -0,0 +1,73 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/pb/metapb"
+)
+
+func TestIsHeartbeatMessage(t *testing.T) {
+	assert.True(t, isHeartbeatMessage(metapb.RaftMessage{Message: raftpb.Message{Type: raftpb.MsgHeartbeat}}))
+	assert.True(t, isHeartbeatMessage(metapb.RaftMessage{Message: raftpb.Message{Type: raftpb.MsgHeartbeatResp}}))
+	assert.False(t, isHeartbeatMessage(metapb.RaftMessage{Message: raftpb.Message{Type: raftpb.MsgApp}}))
+}
+
+func TestEncodeAndDecodeUDPMessage(t *testing.T) {
+	m := metapb.RaftMessage{ShardID: 1, Message: raftpb.Message{Type: raftpb.MsgHeartbeat, Commit: 10}}
+	buf, ok := encodeUDPMessage(m)
+	assert.True(t, ok)
+	batch, ok := decodeUDPMessage(buf)
+	assert.True(t, ok)
+	assert.Equal(t, []metapb.RaftMessage{m}, batch.Messages)
+
+	buf[len(buf)-1]++
+	_, ok = decodeUDPMessage(buf)
+	assert.False(t, ok)
+	_, ok = decodeUDPMessage(buf[:udpHeaderSize])
+	assert.False(t, ok)
+
+	// too large for a datagram
+	m.Message.Context = make([]byte, maxUDPMessageSize)
+	_, ok = encodeUDPMessage(m)
+	assert.False(t, ok)
+}
+
+func TestUDPChannel(t *testing.T) {
+	received := make(chan metapb.RaftMessageBatch, 1)
+	c1 := newUDPChannel(zap.NewNop(), "127.0.0.1:36011", func(batch metapb.RaftMessageBatch) {})
+	c2 := newUDPChannel(zap.NewNop(), "127.0.0.1:36012", func(batch metapb.RaftMessageBatch) {
+		received <- batch
+	})
+	require.NoError(t, c1.start())
+	defer c1.close()
+	require.NoError(t, c2.start())
+	defer c2.close()
+
+	m := metapb.RaftMessage{ShardID: 1, Message: raftpb.Message{Type: raftpb.MsgHeartbeat}}
+	assert.True(t, c1.send("127.0.0.1:36012", m))
+	select {
+	case batch := <-received:
+		assert.Equal(t, []metapb.RaftMessage{m}, batch.Messages)
+	case <-time.After(time.Second * 5):
+		assert.Fail(t, "udp message not received")
+	}
+}
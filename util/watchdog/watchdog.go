@@ -0,0 +1,319 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchdog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/matrixorigin/matrixcube/components/log"
+)
+
+const (
+	defaultCheckInterval = 5 * time.Second
+	defaultDeadline      = time.Minute
+	// labelKey the pprof label of the goroutines of the tasks, the stacks of a
+	// stalled task are picked from the goroutine profile by the label
+	labelKey = "watchdog-task"
+)
+
+// Option watchdog option
+type Option func(*options)
+
+type options struct {
+	checkInterval time.Duration
+	deadline      time.Duration
+	stalledFunc   func(component string, stalled int)
+}
+
+func (opts *options) adjust() {
+	if opts.checkInterval <= 0 {
+		opts.checkInterval = defaultCheckInterval
+	}
+	if opts.deadline <= 0 {
+		opts.deadline = defaultDeadline
+	}
+	if opts.stalledFunc == nil {
+		opts.stalledFunc = func(string, int) {}
+	}
+}
+
+// WithCheckInterval set the interval to check the stalled tasks
+func WithCheckInterval(interval time.Duration) Option {
+	return func(opts *options) {
+		opts.checkInterval = interval
+	}
+}
+
+// WithDeadline set the default deadline of the tasks, a task is stalled if it's
+// not ended within the deadline after it began
+func WithDeadline(deadline time.Duration) Option {
+	return func(opts *options) {
+		opts.deadline = deadline
+	}
+}
+
+// WithStalledFunc set the func called with the number of the stalled tasks of
+// each component after every check, it's used to export the metrics
+func WithStalledFunc(fn func(component string, stalled int)) Option {
+	return func(opts *options) {
+		opts.stalledFunc = fn
+	}
+}
+
+// Watchdog monitors the critical loops, e.g. the raft workers and the prophet
+// coordinator, to turn the silent hangs into the actionable alerts. Each loop
+// registers a Task, and marks the beginning and the end of each iteration. The
+// Watchdog checks the tasks periodically, the goroutine stacks of a task are
+// logged once if its iteration is not ended within the deadline, and the
+// number of the stalled tasks of each component is reported by the stalled
+// func.
+type Watchdog struct {
+	logger *zap.Logger
+	opts   options
+
+	mu struct {
+		sync.Mutex
+		id    uint64
+		tasks map[*Task]struct{}
+		// components all the components ever registered, the stalled func is
+		// called with 0 after all the tasks of a component are unregistered
+		components map[string]struct{}
+	}
+	stopOnce sync.Once
+	stopC    chan struct{}
+	doneC    chan struct{}
+}
+
+// New returns a Watchdog
+func New(logger *zap.Logger, opts ...Option) *Watchdog {
+	w := &Watchdog{
+		logger: log.Adjust(logger).Named("watchdog"),
+		stopC:  make(chan struct{}),
+		doneC:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&w.opts)
+	}
+	w.opts.adjust()
+	w.mu.tasks = make(map[*Task]struct{})
+	w.mu.components = make(map[string]struct{})
+	return w
+}
+
+// Start starts checking the tasks
+func (w *Watchdog) Start() {
+	go func() {
+		defer close(w.doneC)
+		ticker := time.NewTicker(w.opts.checkInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stopC:
+				return
+			case now := <-ticker.C:
+				w.check(now)
+			}
+		}
+	}()
+}
+
+// Stop stops the Watchdog
+func (w *Watchdog) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopC)
+		<-w.doneC
+	})
+}
+
+// Register returns a Task of the component with the default deadline. A nil
+// Task is returned by a nil Watchdog, i.e. the watchdog is disabled, and the
+// methods of a nil Task do nothing.
+func (w *Watchdog) Register(component, name string) *Task {
+	if w == nil {
+		return nil
+	}
+	return w.RegisterWithDeadline(component, name, w.opts.deadline)
+}
+
+// RegisterWithDeadline returns a Task of the component with the deadline
+func (w *Watchdog) RegisterWithDeadline(component, name string, deadline time.Duration) *Task {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.mu.id++
+	t := &Task{
+		component: component,
+		name:      name,
+		deadline:  deadline,
+		label:     fmt.Sprintf("%s/%s#%d", component, name, w.mu.id),
+	}
+	w.mu.tasks[t] = struct{}{}
+	w.mu.components[component] = struct{}{}
+	return t
+}
+
+// Unregister stops monitoring the task, e.g. the loop is stopped
+func (w *Watchdog) Unregister(t *Task) {
+	if w == nil || t == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.mu.tasks, t)
+}
+
+// Stalled returns the names of the stalled tasks
+func (w *Watchdog) Stalled() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var names []string
+	for t := range w.mu.tasks {
+		if t.stalled {
+			names = append(names, t.component+"/"+t.name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (w *Watchdog) check(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var stalled []*Task
+	counts := make(map[string]int)
+	for t := range w.mu.tasks {
+		began := atomic.LoadInt64(&t.began)
+		elapsed := now.Sub(time.Unix(0, began))
+		if began == 0 || elapsed < t.deadline {
+			if t.stalled {
+				t.stalled = false
+				w.logger.Info("task recovered",
+					zap.String("component", t.component),
+					zap.String("task", t.name))
+			}
+			continue
+		}
+
+		counts[t.component]++
+		if !t.stalled {
+			t.stalled = true
+			t.stalledFor = elapsed
+			stalled = append(stalled, t)
+		}
+	}
+	for component := range w.mu.components {
+		w.opts.stalledFunc(component, counts[component])
+	}
+	if len(stalled) == 0 {
+		return
+	}
+
+	stacks := goroutineStacks()
+	for _, t := range stalled {
+		w.logger.Error("task stalled",
+			zap.String("component", t.component),
+			zap.String("task", t.name),
+			zap.Duration("deadline", t.deadline),
+			zap.Duration("stalled", t.stalledFor),
+			zap.String("stacks", stacks[t.label]))
+	}
+}
+
+// goroutineStacks returns the stacks of the goroutines of the tasks by the
+// label of the task
+func goroutineStacks() map[string]string {
+	var buf bytes.Buffer
+	// debug=1 groups the goroutines with the same stack, and prints the labels
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return nil
+	}
+
+	stacks := make(map[string]string)
+	for _, record := range strings.Split(buf.String(), "\n\n") {
+		idx := strings.Index(record, "# labels: ")
+		if idx < 0 {
+			continue
+		}
+		labels := record[idx:]
+		if end := strings.IndexByte(labels, '\n'); end >= 0 {
+			labels = labels[:end]
+		}
+		prefix := fmt.Sprintf("%q:", labelKey)
+		start := strings.Index(labels, prefix)
+		if start < 0 {
+			continue
+		}
+		var label string
+		if _, err := fmt.Sscanf(labels[start+len(prefix):], "%q", &label); err != nil {
+			continue
+		}
+		stacks[label] += record + "\n"
+	}
+	return stacks
+}
+
+// Task a monitored loop of a component. A Task is used by a single goroutine,
+// which calls Begin and End around each iteration of the loop.
+type Task struct {
+	component string
+	name      string
+	deadline  time.Duration
+	label     string
+	// began the unix nanos of the beginning of the current iteration, 0 if the
+	// task is idle
+	began   int64
+	labeled bool
+
+	// stalled and stalledFor are protected by the mutex of the Watchdog
+	stalled    bool
+	stalledFor time.Duration
+}
+
+// Begin marks the beginning of an iteration. The goroutine is labeled on the
+// first call, so its stacks can be found once it's stalled.
+func (t *Task) Begin() {
+	if t == nil {
+		return
+	}
+	if !t.labeled {
+		pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(),
+			pprof.Labels(labelKey, t.label)))
+		t.labeled = true
+	}
+	atomic.StoreInt64(&t.began, time.Now().UnixNano())
+}
+
+// End marks the end of the iteration
+func (t *Task) End() {
+	if t == nil {
+		return
+	}
+	atomic.StoreInt64(&t.began, 0)
+}
@@ -0,0 +1,118 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchdog
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckStalledTask(t *testing.T) {
+	var mu sync.Mutex
+	stalled := make(map[string]int)
+	w := New(nil, WithDeadline(time.Second), WithStalledFunc(func(component string, n int) {
+		mu.Lock()
+		defer mu.Unlock()
+		stalled[component] = n
+	}))
+
+	busy := w.Register("c1", "busy")
+	idle := w.Register("c2", "idle")
+	now := time.Now()
+
+	w.check(now)
+	assert.Empty(t, w.Stalled())
+	assert.Equal(t, map[string]int{"c1": 0, "c2": 0}, stalled)
+
+	busy.Begin()
+	idle.Begin()
+	idle.End()
+	w.check(now.Add(time.Millisecond * 10))
+	assert.Empty(t, w.Stalled())
+
+	w.check(time.Now().Add(time.Second * 2))
+	assert.Equal(t, []string{"c1/busy"}, w.Stalled())
+	assert.Equal(t, map[string]int{"c1": 1, "c2": 0}, stalled)
+
+	// recovered
+	busy.End()
+	w.check(time.Now().Add(time.Second * 2))
+	assert.Empty(t, w.Stalled())
+	assert.Equal(t, map[string]int{"c1": 0, "c2": 0}, stalled)
+
+	// unregistered
+	busy.Begin()
+	w.Unregister(busy)
+	w.check(time.Now().Add(time.Second * 2))
+	assert.Empty(t, w.Stalled())
+	assert.Equal(t, map[string]int{"c1": 0, "c2": 0}, stalled)
+}
+
+func TestRegisterWithDeadline(t *testing.T) {
+	w := New(nil, WithDeadline(time.Hour))
+	task := w.RegisterWithDeadline("c1", "short", time.Second)
+	task.Begin()
+	w.check(time.Now().Add(time.Second * 2))
+	assert.Equal(t, []string{"c1/short"}, w.Stalled())
+}
+
+func TestGoroutineStacksOfStalledTask(t *testing.T) {
+	w := New(nil)
+	task := w.Register("c1", "blocked")
+
+	blockC := make(chan struct{})
+	startedC := make(chan struct{})
+	go func() {
+		task.Begin()
+		close(startedC)
+		<-blockC
+		task.End()
+	}()
+	defer close(blockC)
+	<-startedC
+
+	stacks := goroutineStacks()
+	require.Contains(t, stacks, task.label)
+	assert.True(t, strings.Contains(stacks[task.label], "TestGoroutineStacksOfStalledTask"))
+}
+
+func TestStartAndStop(t *testing.T) {
+	w := New(nil, WithCheckInterval(time.Millisecond*10), WithDeadline(time.Millisecond*10))
+	w.Start()
+	defer w.Stop()
+
+	task := w.Register("c1", "blocked")
+	task.Begin()
+	for i := 0; i < 100; i++ {
+		if len(w.Stalled()) > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Equal(t, []string{"c1/blocked"}, w.Stalled())
+}
+
+func TestNilWatchdog(t *testing.T) {
+	var w *Watchdog
+	task := w.Register("c1", "t1")
+	assert.Nil(t, task)
+	task.Begin()
+	task.End()
+	w.Unregister(task)
+}
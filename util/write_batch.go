@@ -13,6 +13,10 @@
 
 package util
 
+import (
+	"bytes"
+)
+
 // WriteBatch write batch
 type WriteBatch interface {
 	// Set set kv-value to the batch
@@ -27,8 +31,130 @@ type WriteBatch interface {
 	DeleteRange([]byte, []byte)
 	// DeleteRangeDeferred is similar to DeleteRange, but avoid clone key and value twice.
 	DeleteRangeDeferred(startLen, endLen int, setter func(start, end []byte))
+	// SetIfAbsent set kv-value to the batch with the condition that the key does
+	// not exist.
+	SetIfAbsent([]byte, []byte)
+	// CompareAndSet set kv-value to the batch with the condition that the current
+	// value of the key is the expected one, the empty expected value means the
+	// key does not exist.
+	CompareAndSet(key, expected, value []byte)
+	// Conditions returns the conditions added by SetIfAbsent and CompareAndSet in
+	// order. The conditions are evaluated by the kv.BaseStorage against the data
+	// before the batch atomically, nothing in the batch is written if any of the
+	// conditions failed. The KVStorage implementations write the batch without
+	// checking the conditions.
+	Conditions() []Condition
 	// Reset reset the batch
 	Reset()
 	// Close close the batch
 	Close()
 }
+
+// CountingWriteBatch wraps a WriteBatch and counts the keys written to it, a
+// range deletion is counted as one key.
+type CountingWriteBatch struct {
+	WriteBatch
+	keys uint64
+}
+
+// NewCountingWriteBatch returns a CountingWriteBatch over the WriteBatch
+func NewCountingWriteBatch(wb WriteBatch) *CountingWriteBatch {
+	return &CountingWriteBatch{WriteBatch: wb}
+}
+
+// Keys returns the number of keys written to the batch
+func (wb *CountingWriteBatch) Keys() uint64 {
+	return wb.keys
+}
+
+// Set implements WriteBatch
+func (wb *CountingWriteBatch) Set(key, value []byte) {
+	wb.keys++
+	wb.WriteBatch.Set(key, value)
+}
+
+// SetDeferred implements WriteBatch
+func (wb *CountingWriteBatch) SetDeferred(keyLen, valueLen int, setter func(key, value []byte)) {
+	wb.keys++
+	wb.WriteBatch.SetDeferred(keyLen, valueLen, setter)
+}
+
+// Delete implements WriteBatch
+func (wb *CountingWriteBatch) Delete(key []byte) {
+	wb.keys++
+	wb.WriteBatch.Delete(key)
+}
+
+// DeleteDeferred implements WriteBatch
+func (wb *CountingWriteBatch) DeleteDeferred(keyLen int, setter func(key []byte)) {
+	wb.keys++
+	wb.WriteBatch.DeleteDeferred(keyLen, setter)
+}
+
+// DeleteRange implements WriteBatch
+func (wb *CountingWriteBatch) DeleteRange(start, end []byte) {
+	wb.keys++
+	wb.WriteBatch.DeleteRange(start, end)
+}
+
+// DeleteRangeDeferred implements WriteBatch
+func (wb *CountingWriteBatch) DeleteRangeDeferred(startLen, endLen int, setter func(start, end []byte)) {
+	wb.keys++
+	wb.WriteBatch.DeleteRangeDeferred(startLen, endLen, setter)
+}
+
+// SetIfAbsent implements WriteBatch
+func (wb *CountingWriteBatch) SetIfAbsent(key, value []byte) {
+	wb.keys++
+	wb.WriteBatch.SetIfAbsent(key, value)
+}
+
+// CompareAndSet implements WriteBatch
+func (wb *CountingWriteBatch) CompareAndSet(key, expected, value []byte) {
+	wb.keys++
+	wb.WriteBatch.CompareAndSet(key, expected, value)
+}
+
+// Reset implements WriteBatch
+func (wb *CountingWriteBatch) Reset() {
+	wb.keys = 0
+	wb.WriteBatch.Reset()
+}
+
+// Condition the condition of a write in the WriteBatch, the empty Expected value
+// means the key does not exist.
+type Condition struct {
+	Key      []byte
+	Expected []byte
+}
+
+// Check returns true if the condition is met by the current value of the key,
+// the empty value means the key does not exist.
+func (c Condition) Check(value []byte) bool {
+	return bytes.Equal(c.Expected, value)
+}
+
+// WriteConditions records the conditions of a WriteBatch, it's embedded by the
+// WriteBatch implementations.
+type WriteConditions struct {
+	conditions []Condition
+}
+
+// AddCondition adds a condition, the key and expected value are cloned.
+func (c *WriteConditions) AddCondition(key, expected []byte) {
+	cond := Condition{Key: append([]byte(nil), key...)}
+	if len(expected) > 0 {
+		cond.Expected = append([]byte(nil), expected...)
+	}
+	c.conditions = append(c.conditions, cond)
+}
+
+// Conditions returns the added conditions
+func (c *WriteConditions) Conditions() []Condition {
+	return c.conditions
+}
+
+// ResetConditions removes all the conditions
+func (c *WriteConditions) ResetConditions() {
+	c.conditions = c.conditions[:0]
+}
@@ -0,0 +1,452 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vfs
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	encryptionVersion = 1
+	// encryptionHeaderSize the size of the header written at the beginning of
+	// every encrypted file, the offsets seen by the users of the FS exclude it.
+	//
+	// magic(4) + version(1) + reserved(3) + key ID(4) + reserved(4) + IV(16)
+	encryptionHeaderSize = 32
+	encryptionBlockSize  = aes.BlockSize
+)
+
+var (
+	encryptionMagic = []byte("MCEF")
+
+	// ErrNotEncrypted the file is not written by the encrypted FS
+	ErrNotEncrypted = errors.New("file not encrypted")
+	// ErrUnknownEncryptionKey the file is encrypted by a key not provided
+	ErrUnknownEncryptionKey = errors.New("unknown encryption key")
+	// ErrInvalidEncryptionKey the key can not be used to create the cipher
+	ErrInvalidEncryptionKey = errors.New("invalid encryption key")
+)
+
+// EncryptionOptions the options of the encrypted FS
+type EncryptionOptions struct {
+	// Keys the encryption keys by ID. The key ID of every file is recorded in
+	// its header, so the rotated keys must be kept until all the files encrypted
+	// by them are removed, see EncryptedFS.KeyID.
+	Keys map[uint32][]byte
+	// ActiveKeyID the ID of the key used to encrypt the new files
+	ActiveKeyID uint32
+	// NewCipher creates the block cipher of the key, the block size must be 16
+	// bytes. aes.NewCipher is used if not set.
+	NewCipher func(key []byte) (cipher.Block, error)
+}
+
+// EncryptedFS is a FS encrypting the content of all the files in the CTR mode
+// of the block cipher, e.g. AES-CTR. Each file is encrypted by the key active
+// when it's created with a random IV, both recorded in the plaintext header of
+// the file. The directories, the file names and the file sizes are not
+// encrypted.
+//
+// The same FS should be set as the Config.FS and used to create the pebble
+// storage by NewPebbleFS and the KVBaseStorage, so the data, the WAL and the
+// shard snapshots are all encrypted on disk. The snapshots are decrypted when
+// read by the transport, and encrypted again by the receiving store. After the
+// active key is rotated, the existing files are still readable, the SSTs and
+// the WAL are rewritten with the new key by the compactions and the WAL
+// recycling.
+type EncryptedFS struct {
+	FS
+	newCipher func(key []byte) (cipher.Block, error)
+
+	mu struct {
+		sync.RWMutex
+		active  uint32
+		ciphers map[uint32]cipher.Block
+	}
+}
+
+var _ FS = (*EncryptedFS)(nil)
+
+// NewEncryptedFS returns an encrypted FS backed by the fs.
+func NewEncryptedFS(fs FS, opts EncryptionOptions) (*EncryptedFS, error) {
+	e := &EncryptedFS{FS: fs, newCipher: opts.NewCipher}
+	if e.newCipher == nil {
+		e.newCipher = aes.NewCipher
+	}
+	e.mu.ciphers = make(map[uint32]cipher.Block, len(opts.Keys))
+	for id, key := range opts.Keys {
+		if err := e.addKeyLocked(id, key); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := e.mu.ciphers[opts.ActiveKeyID]; !ok {
+		return nil, fmt.Errorf("%w: active key %d", ErrUnknownEncryptionKey, opts.ActiveKeyID)
+	}
+	e.mu.active = opts.ActiveKeyID
+	return e, nil
+}
+
+// RotateKey adds the key and uses it to encrypt the new files. The existing
+// files are still decrypted by the keys they're encrypted with.
+func (e *EncryptedFS) RotateKey(id uint32, key []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.addKeyLocked(id, key); err != nil {
+		return err
+	}
+	e.mu.active = id
+	return nil
+}
+
+// ActiveKeyID returns the ID of the key used to encrypt the new files.
+func (e *EncryptedFS) ActiveKeyID() uint32 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.mu.active
+}
+
+// KeyID returns the ID of the key the file is encrypted with, it can be used to
+// check whether a rotated key is still in use.
+func (e *EncryptedFS) KeyID(name string) (uint32, error) {
+	f, err := e.FS.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	id, _, err := readEncryptionHeader(f, name)
+	return id, err
+}
+
+func (e *EncryptedFS) addKeyLocked(id uint32, key []byte) error {
+	if _, ok := e.mu.ciphers[id]; ok {
+		return fmt.Errorf("%w: duplicated key %d", ErrInvalidEncryptionKey, id)
+	}
+	block, err := e.newCipher(key)
+	if err != nil {
+		return fmt.Errorf("%w: key %d: %v", ErrInvalidEncryptionKey, id, err)
+	}
+	if block.BlockSize() != encryptionBlockSize {
+		return fmt.Errorf("%w: key %d: block size %d", ErrInvalidEncryptionKey,
+			id, block.BlockSize())
+	}
+	e.mu.ciphers[id] = block
+	return nil
+}
+
+func (e *EncryptedFS) getCipher(id uint32) (cipher.Block, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	block, ok := e.mu.ciphers[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: key %d", ErrUnknownEncryptionKey, id)
+	}
+	return block, nil
+}
+
+func (e *EncryptedFS) newFileCipher() (*fileCipher, []byte, error) {
+	e.mu.RLock()
+	id := e.mu.active
+	block := e.mu.ciphers[id]
+	e.mu.RUnlock()
+
+	c := &fileCipher{block: block}
+	if _, err := io.ReadFull(rand.Reader, c.iv[:]); err != nil {
+		return nil, nil, err
+	}
+	return c, encodeEncryptionHeader(id, c.iv[:]), nil
+}
+
+// Create creates the file with a new IV, the header is written immediately.
+func (e *EncryptedFS) Create(name string) (File, error) {
+	f, err := e.FS.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	ef, err := e.initFile(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return ef, nil
+}
+
+// Open opens the file for reading, the empty file is left by a crash before
+// the header written and has no content.
+func (e *EncryptedFS) Open(name string, opts ...OpenOption) (File, error) {
+	f, err := e.FS.Open(name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if info.IsDir() || info.Size() == 0 {
+		return f, nil
+	}
+	ef, err := e.openFile(f, f, name)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return ef, nil
+}
+
+// OpenForAppend opens the file for appending, the header is written if the
+// file is empty.
+func (e *EncryptedFS) OpenForAppend(name string) (File, error) {
+	f, err := e.FS.OpenForAppend(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	var ef *encryptedFile
+	if info.Size() == 0 {
+		ef, err = e.initFile(f)
+	} else {
+		ef, err = e.openAppendFile(f, name)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	ef.pos = info.Size() - encryptionHeaderSize
+	if ef.pos < 0 {
+		ef.pos = 0
+	}
+	ef.append = true
+	return ef, nil
+}
+
+// ReuseForWrite creates the newname file and removes the oldname file instead
+// of reusing it, the reused file would be encrypted with the same IV again.
+func (e *EncryptedFS) ReuseForWrite(oldname, newname string) (File, error) {
+	f, err := e.Create(newname)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.FS.Remove(oldname); err != nil && !IsNotExist(err) {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Stat returns the info of the file, the size excludes the header.
+func (e *EncryptedFS) Stat(name string) (os.FileInfo, error) {
+	info, err := e.FS.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	return encryptedFileInfo{info}, nil
+}
+
+func (e *EncryptedFS) initFile(f File) (*encryptedFile, error) {
+	c, header, err := e.newFileCipher()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptedFile{File: f, c: c}, nil
+}
+
+// openFile returns the encrypted file of the f, the header is read from the r
+// as the file opened for appending may be not readable.
+func (e *EncryptedFS) openFile(f File, r io.ReaderAt, name string) (*encryptedFile, error) {
+	id, iv, err := readEncryptionHeader(r, name)
+	if err != nil {
+		return nil, err
+	}
+	block, err := e.getCipher(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	c := &fileCipher{block: block}
+	copy(c.iv[:], iv)
+	return &encryptedFile{File: f, c: c}, nil
+}
+
+func (e *EncryptedFS) openAppendFile(f File, name string) (*encryptedFile, error) {
+	r, err := e.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return e.openFile(f, r, name)
+}
+
+func readEncryptionHeader(r io.ReaderAt, name string) (uint32, []byte, error) {
+	var header [encryptionHeaderSize]byte
+	if _, err := r.ReadAt(header[:], 0); err != nil {
+		return 0, nil, fmt.Errorf("%w: %s: %v", ErrNotEncrypted, name, err)
+	}
+	id, iv, err := decodeEncryptionHeader(header[:])
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return id, iv, nil
+}
+
+func encodeEncryptionHeader(id uint32, iv []byte) []byte {
+	header := make([]byte, encryptionHeaderSize)
+	copy(header, encryptionMagic)
+	header[4] = encryptionVersion
+	binary.BigEndian.PutUint32(header[8:], id)
+	copy(header[16:], iv)
+	return header
+}
+
+func decodeEncryptionHeader(header []byte) (uint32, []byte, error) {
+	if !bytes.Equal(header[:4], encryptionMagic) {
+		return 0, nil, ErrNotEncrypted
+	}
+	if header[4] != encryptionVersion {
+		return 0, nil, fmt.Errorf("%w: version %d", ErrNotEncrypted, header[4])
+	}
+	return binary.BigEndian.Uint32(header[8:]), header[16:], nil
+}
+
+// fileCipher encrypts and decrypts the content of a file at any offset.
+type fileCipher struct {
+	block cipher.Block
+	iv    [encryptionBlockSize]byte
+}
+
+// xorAt xors the key stream at the offset of the file into the dst.
+func (c *fileCipher) xorAt(dst, src []byte, offset int64) {
+	if len(src) == 0 {
+		return
+	}
+	// the IV is the initial counter, incremented as a 128 bits big endian
+	// integer by the blocks before the offset
+	var counter [encryptionBlockSize]byte
+	hi := binary.BigEndian.Uint64(c.iv[:8])
+	lo := binary.BigEndian.Uint64(c.iv[8:])
+	blocks := uint64(offset / encryptionBlockSize)
+	if lo+blocks < lo {
+		hi++
+	}
+	binary.BigEndian.PutUint64(counter[:8], hi)
+	binary.BigEndian.PutUint64(counter[8:], lo+blocks)
+
+	stream := cipher.NewCTR(c.block, counter[:])
+	if skip := int(offset % encryptionBlockSize); skip > 0 {
+		var discard [encryptionBlockSize]byte
+		stream.XORKeyStream(discard[:skip], discard[:skip])
+	}
+	stream.XORKeyStream(dst, src)
+}
+
+// encryptedFile tracks the position itself, the content is read and written
+// at the offsets of the underlying file except appending.
+type encryptedFile struct {
+	File
+	c      *fileCipher
+	pos    int64
+	append bool
+}
+
+func (f *encryptedFile) Read(p []byte) (int, error) {
+	n, err := f.ReadAt(p, f.pos)
+	f.pos += int64(n)
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *encryptedFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.File.ReadAt(p, off+encryptionHeaderSize)
+	f.c.xorAt(p[:n], p[:n], off)
+	return n, err
+}
+
+func (f *encryptedFile) Write(p []byte) (int, error) {
+	var n int
+	var err error
+	if f.append {
+		buf := make([]byte, len(p))
+		f.c.xorAt(buf, p, f.pos)
+		n, err = f.File.Write(buf)
+	} else {
+		n, err = f.WriteAt(p, f.pos)
+	}
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *encryptedFile) WriteAt(p []byte, off int64) (int, error) {
+	buf := make([]byte, len(p))
+	f.c.xorAt(buf, p, off)
+	return f.File.WriteAt(buf, off+encryptionHeaderSize)
+}
+
+func (f *encryptedFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		info, err := f.Stat()
+		if err != nil {
+			return 0, err
+		}
+		offset += info.Size()
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("seek to negative offset %d", offset)
+	}
+	f.pos = offset
+	return f.pos, nil
+}
+
+func (f *encryptedFile) Stat() (os.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return encryptedFileInfo{info}, nil
+}
+
+// encryptedFileInfo excludes the header from the size of the file
+type encryptedFileInfo struct {
+	os.FileInfo
+}
+
+func (i encryptedFileInfo) Size() int64 {
+	if i.IsDir() {
+		return i.FileInfo.Size()
+	}
+	if i.FileInfo.Size() < encryptionHeaderSize {
+		return 0
+	}
+	return i.FileInfo.Size() - encryptionHeaderSize
+}
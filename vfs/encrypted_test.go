@@ -0,0 +1,262 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vfs
+
+import (
+	"bytes"
+	"crypto/des"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testEncryptionKey1 = bytes.Repeat([]byte{1}, 16)
+	testEncryptionKey2 = bytes.Repeat([]byte{2}, 32)
+)
+
+func newTestEncryptedFS(t *testing.T, fs FS) *EncryptedFS {
+	efs, err := NewEncryptedFS(fs, EncryptionOptions{
+		Keys:        map[uint32][]byte{1: testEncryptionKey1},
+		ActiveKeyID: 1,
+	})
+	require.NoError(t, err)
+	return efs
+}
+
+func readRawFile(t *testing.T, fs FS, name string) []byte {
+	f, err := fs.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	require.NoError(t, err)
+	return data
+}
+
+func TestNewEncryptedFS(t *testing.T) {
+	fs := NewMemFS()
+	_, err := NewEncryptedFS(fs, EncryptionOptions{ActiveKeyID: 1})
+	assert.ErrorIs(t, err, ErrUnknownEncryptionKey)
+	_, err = NewEncryptedFS(fs, EncryptionOptions{
+		Keys:        map[uint32][]byte{1: []byte("short")},
+		ActiveKeyID: 1,
+	})
+	assert.ErrorIs(t, err, ErrInvalidEncryptionKey)
+	// the block size of DES is 8 bytes
+	_, err = NewEncryptedFS(fs, EncryptionOptions{
+		Keys:        map[uint32][]byte{1: []byte("8bytekey")},
+		ActiveKeyID: 1,
+		NewCipher:   des.NewCipher,
+	})
+	assert.ErrorIs(t, err, ErrInvalidEncryptionKey)
+
+	efs := newTestEncryptedFS(t, fs)
+	assert.Equal(t, uint32(1), efs.ActiveKeyID())
+	assert.ErrorIs(t, efs.RotateKey(1, testEncryptionKey2), ErrInvalidEncryptionKey)
+}
+
+func TestEncryptedFile(t *testing.T) {
+	fs := NewMemFS()
+	efs := newTestEncryptedFS(t, fs)
+	data := bytes.Repeat([]byte("0123456789abcdefghij"), 100)
+
+	f, err := efs.Create("test")
+	require.NoError(t, err)
+	// unaligned writes
+	for offset := 0; offset < len(data); {
+		n := 7 + offset%13
+		if offset+n > len(data) {
+			n = len(data) - offset
+		}
+		_, err := f.Write(data[offset : offset+n])
+		require.NoError(t, err)
+		offset += n
+	}
+	_, err = f.WriteAt([]byte("XYZ"), 100)
+	require.NoError(t, err)
+	copy(data[100:], "XYZ")
+	require.NoError(t, f.Sync())
+	require.NoError(t, f.Close())
+
+	info, err := efs.Stat("test")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), info.Size())
+	raw := readRawFile(t, fs, "test")
+	assert.Equal(t, len(data)+encryptionHeaderSize, len(raw))
+	assert.False(t, bytes.Contains(raw, []byte("0123456789")))
+
+	f, err = efs.Open("test")
+	require.NoError(t, err)
+	defer f.Close()
+	info, err = f.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), info.Size())
+	v, err := ioutil.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, data, v)
+
+	for _, offset := range []int64{0, 1, 15, 16, 17, 333, int64(len(data)) - 5} {
+		buf := make([]byte, 5)
+		_, err := f.ReadAt(buf, offset)
+		require.NoError(t, err)
+		assert.Equal(t, data[offset:offset+5], buf, "offset %d", offset)
+	}
+
+	n, err := f.Seek(21, io.SeekStart)
+	require.NoError(t, err)
+	assert.Equal(t, int64(21), n)
+	buf := make([]byte, 10)
+	_, err = io.ReadFull(f, buf)
+	require.NoError(t, err)
+	assert.Equal(t, data[21:31], buf)
+	n, err = f.Seek(-10, io.SeekEnd)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)-10), n)
+	_, err = io.ReadFull(f, buf)
+	require.NoError(t, err)
+	assert.Equal(t, data[len(data)-10:], buf)
+	_, err = f.Seek(-int64(len(data))-1, io.SeekEnd)
+	assert.Error(t, err)
+	n, err = f.Seek(0, io.SeekCurrent)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), n)
+}
+
+func TestEncryptedFileAppendAndReuse(t *testing.T) {
+	fs := NewMemFS()
+	efs := newTestEncryptedFS(t, fs)
+
+	f, err := fs.Create("append")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	for _, v := range []string{"hello ", "world"} {
+		f, err := efs.OpenForAppend("append")
+		require.NoError(t, err)
+		_, err = f.Write([]byte(v))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+	f, err = efs.Open("append")
+	require.NoError(t, err)
+	v, err := ioutil.ReadAll(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "hello world", string(v))
+
+	// the reused file is encrypted with a new IV
+	header := readRawFile(t, fs, "append")[:encryptionHeaderSize]
+	f, err = efs.ReuseForWrite("append", "reused")
+	require.NoError(t, err)
+	_, err = f.Write([]byte("reused"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	_, err = efs.Stat("append")
+	assert.True(t, IsNotExist(err))
+	assert.NotEqual(t, header, readRawFile(t, fs, "reused")[:encryptionHeaderSize])
+	f, err = efs.Open("reused")
+	require.NoError(t, err)
+	v, err = ioutil.ReadAll(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "reused", string(v))
+
+	// the empty file left by crash
+	f, err = fs.Create("empty")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	f, err = efs.Open("empty")
+	require.NoError(t, err)
+	v, err = ioutil.ReadAll(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Empty(t, v)
+}
+
+func TestEncryptionKeyRotation(t *testing.T) {
+	fs := NewMemFS()
+	efs := newTestEncryptedFS(t, fs)
+	write := func(name, value string) {
+		f, err := efs.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(value))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+	read := func(efs *EncryptedFS, name string) (string, error) {
+		f, err := efs.Open(name)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		v, err := ioutil.ReadAll(f)
+		return string(v), err
+	}
+
+	write("old", "old value")
+	require.NoError(t, efs.RotateKey(2, testEncryptionKey2))
+	assert.Equal(t, uint32(2), efs.ActiveKeyID())
+	write("new", "new value")
+
+	id, err := efs.KeyID("old")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), id)
+	id, err = efs.KeyID("new")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), id)
+	v, err := read(efs, "old")
+	require.NoError(t, err)
+	assert.Equal(t, "old value", v)
+	v, err = read(efs, "new")
+	require.NoError(t, err)
+	assert.Equal(t, "new value", v)
+
+	// the old key is removed after rotation
+	efs2, err := NewEncryptedFS(fs, EncryptionOptions{
+		Keys:        map[uint32][]byte{2: testEncryptionKey2},
+		ActiveKeyID: 2,
+	})
+	require.NoError(t, err)
+	_, err = read(efs2, "old")
+	assert.ErrorIs(t, err, ErrUnknownEncryptionKey)
+	v, err = read(efs2, "new")
+	require.NoError(t, err)
+	assert.Equal(t, "new value", v)
+
+	f, err := fs.Create("plain")
+	require.NoError(t, err)
+	_, err = f.Write(bytes.Repeat([]byte("plain"), 10))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	_, err = read(efs, "plain")
+	assert.ErrorIs(t, err, ErrNotEncrypted)
+}
+
+func TestEncryptedPebbleFS(t *testing.T) {
+	fs := NewMemFS()
+	efs := newTestEncryptedFS(t, fs)
+	pfs := NewPebbleFS(efs)
+	f, err := pfs.Create("test")
+	require.NoError(t, err)
+	_, err = f.Write([]byte("pebble value"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	info, err := pfs.Stat("test")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("pebble value")), info.Size())
+	assert.False(t, bytes.Contains(readRawFile(t, fs, "test"), []byte("pebble value")))
+}
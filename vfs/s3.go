@@ -0,0 +1,591 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vfs
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultS3PartSize the default size of the parts of the multipart upload
+	DefaultS3PartSize = 16 * 1024 * 1024
+	// DefaultS3ReadAheadSize the default size read from the object storage
+	// ahead of the reads of the opened files
+	DefaultS3ReadAheadSize = 1024 * 1024
+)
+
+var (
+	// ErrS3NotSupported the operation is not supported by the object storage
+	ErrS3NotSupported = errors.New("operation not supported by the s3 FS")
+	// ErrS3FileClosed the file is closed
+	ErrS3FileClosed = errors.New("s3 file closed")
+)
+
+// S3Options the options of the S3 FS
+type S3Options struct {
+	// Endpoint the endpoint of the object storage, e.g. https://s3.amazonaws.com
+	// or http://127.0.0.1:9000 of MinIO. The path style URLs are used.
+	Endpoint string
+	// Region the region of the bucket, e.g. us-east-1
+	Region string
+	// Bucket the bucket of the files
+	Bucket string
+	// Prefix the prefix of the keys of all the files in the bucket
+	Prefix string
+	// AccessKeyID, SecretAccessKey and SessionToken are the credentials, the
+	// SessionToken is only required by the temporary credentials.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// PartSize the size of the parts of the multipart upload, the files smaller
+	// than it are uploaded by a single request. S3 requires at least 5MiB.
+	// DefaultS3PartSize is used if not set.
+	PartSize int
+	// ReadAheadSize the min size of the range read from the object storage by
+	// the opened files. DefaultS3ReadAheadSize is used if not set.
+	ReadAheadSize int
+	// HTTPClient the client sends the requests, http.DefaultClient is used if
+	// not set.
+	HTTPClient *http.Client
+}
+
+// S3FS is a FS backed by the S3 compatible object storage, so the snapshots
+// and the backups can be written to the shared storage directly. The files
+// are objects, a file is written sequentially and uploaded by the multipart
+// upload when it's larger than the part size, the file is invisible until
+// closed, so the Sync of the created file only reports the error of the
+// uploaded parts. The opened files are read by the ranged reads with the
+// read ahead.
+//
+// The directories are the key prefixes, MkdirAll writes an empty marker object
+// so the empty directories can be listed. The Rename and the Link copy the
+// objects. The OpenForAppend and the Lock are not supported, so the S3FS can
+// not be used by the pebble storage or the raft log.
+type S3FS struct {
+	client *s3Client
+	opts   S3Options
+}
+
+var _ FS = (*S3FS)(nil)
+
+// NewS3FS returns a FS backed by the bucket of the object storage
+func NewS3FS(opts S3Options) (*S3FS, error) {
+	if opts.Bucket == "" {
+		return nil, errors.New("s3 bucket not set")
+	}
+	if opts.PartSize <= 0 {
+		opts.PartSize = DefaultS3PartSize
+	}
+	if opts.ReadAheadSize <= 0 {
+		opts.ReadAheadSize = DefaultS3ReadAheadSize
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	opts.Prefix = strings.Trim(opts.Prefix, "/")
+	client, err := newS3Client(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &S3FS{client: client, opts: opts}, nil
+}
+
+// objectKey returns the key of the file, the root has the empty key
+func (s *S3FS) objectKey(name string) string {
+	key := strings.TrimPrefix(path.Clean("/"+name), "/")
+	if s.opts.Prefix == "" {
+		return key
+	}
+	if key == "" {
+		return s.opts.Prefix
+	}
+	return s.opts.Prefix + "/" + key
+}
+
+// dirPrefix returns the prefix of the keys in the directory
+func dirPrefix(key string) string {
+	if key == "" {
+		return ""
+	}
+	return key + "/"
+}
+
+func (s *S3FS) Create(name string) (File, error) {
+	return &s3WriteFile{fs: s, name: name, key: s.objectKey(name), modTime: time.Now()}, nil
+}
+
+func (s *S3FS) Link(oldname, newname string) error {
+	return s.client.copyObject(s.objectKey(oldname), s.objectKey(newname))
+}
+
+func (s *S3FS) Open(name string, opts ...OpenOption) (File, error) {
+	key := s.objectKey(name)
+	size, modTime, err := s.client.headObject(key)
+	if err != nil {
+		return nil, withPath(err, name)
+	}
+	f := &s3ReadFile{fs: s, name: name, key: key, size: size, modTime: modTime}
+	for _, opt := range opts {
+		opt.Apply(f)
+	}
+	return f, nil
+}
+
+func (s *S3FS) OpenDir(name string) (File, error) {
+	info, err := s.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &os.PathError{Op: "open", Path: name, Err: errors.New("not a directory")}
+	}
+	return &s3DirFile{info: info}, nil
+}
+
+func (s *S3FS) OpenForAppend(name string) (File, error) {
+	return nil, &os.PathError{Op: "open", Path: name, Err: ErrS3NotSupported}
+}
+
+func (s *S3FS) Remove(name string) error {
+	key := s.objectKey(name)
+	if _, _, err := s.client.headObject(key); err == nil {
+		return s.client.deleteObject(key)
+	} else if !IsNotExist(err) {
+		return err
+	}
+
+	objects, _, err := s.client.listObjects(dirPrefix(key), "")
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(objects) == 0:
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	case len(objects) > 1 || objects[0].Key != dirPrefix(key):
+		return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+	}
+	return s.client.deleteObject(dirPrefix(key))
+}
+
+func (s *S3FS) RemoveAll(name string) error {
+	key := s.objectKey(name)
+	objects, _, err := s.client.listObjects(dirPrefix(key), "")
+	if err != nil {
+		return err
+	}
+	for _, o := range objects {
+		if err := s.client.deleteObject(o.Key); err != nil && !IsNotExist(err) {
+			return err
+		}
+	}
+	if err := s.client.deleteObject(key); err != nil && !IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *S3FS) Rename(oldname, newname string) error {
+	oldKey, newKey := s.objectKey(oldname), s.objectKey(newname)
+	if oldKey == newKey {
+		return nil
+	}
+	if _, _, err := s.client.headObject(oldKey); err == nil {
+		if err := s.client.copyObject(oldKey, newKey); err != nil {
+			return err
+		}
+		return s.client.deleteObject(oldKey)
+	} else if !IsNotExist(err) {
+		return err
+	}
+
+	// rename the directory
+	objects, _, err := s.client.listObjects(dirPrefix(oldKey), "")
+	if err != nil {
+		return err
+	}
+	if len(objects) == 0 {
+		return &os.PathError{Op: "rename", Path: oldname, Err: os.ErrNotExist}
+	}
+	if err := s.RemoveAll(newname); err != nil {
+		return err
+	}
+	for _, o := range objects {
+		if err := s.client.copyObject(o.Key, dirPrefix(newKey)+strings.TrimPrefix(o.Key, dirPrefix(oldKey))); err != nil {
+			return err
+		}
+	}
+	for _, o := range objects {
+		if err := s.client.deleteObject(o.Key); err != nil && !IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *S3FS) ReuseForWrite(oldname, newname string) (File, error) {
+	if err := s.Remove(oldname); err != nil && !IsNotExist(err) {
+		return nil, err
+	}
+	return s.Create(newname)
+}
+
+func (s *S3FS) MkdirAll(dir string, perm os.FileMode) error {
+	key := s.objectKey(dir)
+	if key == "" {
+		return nil
+	}
+	return s.client.putObject(dirPrefix(key), nil)
+}
+
+func (s *S3FS) Lock(name string) (io.Closer, error) {
+	return nil, &os.PathError{Op: "lock", Path: name, Err: ErrS3NotSupported}
+}
+
+func (s *S3FS) List(dir string) ([]string, error) {
+	prefix := dirPrefix(s.objectKey(dir))
+	objects, prefixes, err := s.client.listObjects(prefix, "/")
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 && len(prefixes) == 0 && prefix != "" {
+		return nil, &os.PathError{Op: "list", Path: dir, Err: os.ErrNotExist}
+	}
+
+	names := make([]string, 0, len(objects)+len(prefixes))
+	for _, o := range objects {
+		// the marker of the directory
+		if name := strings.TrimPrefix(o.Key, prefix); name != "" {
+			names = append(names, name)
+		}
+	}
+	for _, p := range prefixes {
+		names = append(names, strings.TrimSuffix(strings.TrimPrefix(p, prefix), "/"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *S3FS) Stat(name string) (os.FileInfo, error) {
+	key := s.objectKey(name)
+	base := s.PathBase(name)
+	if key == s.opts.Prefix {
+		return &s3FileInfo{name: base, dir: true}, nil
+	}
+	size, modTime, err := s.client.headObject(key)
+	if err == nil {
+		return &s3FileInfo{name: base, size: size, modTime: modTime}, nil
+	}
+	if !IsNotExist(err) {
+		return nil, err
+	}
+
+	// the directory exists if any key has the prefix
+	objects, prefixes, err := s.client.listObjects(dirPrefix(key), "/")
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 && len(prefixes) == 0 {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+	return &s3FileInfo{name: base, dir: true}, nil
+}
+
+func (s *S3FS) PathBase(p string) string {
+	return path.Base(p)
+}
+
+func (s *S3FS) PathJoin(elem ...string) string {
+	return path.Join(elem...)
+}
+
+func (s *S3FS) PathDir(p string) string {
+	return path.Dir(p)
+}
+
+// GetFreeSpace the object storage has no limit of the space
+func (s *S3FS) GetFreeSpace(path string) (uint64, error) {
+	return math.MaxUint64, nil
+}
+
+func withPath(err error, name string) error {
+	var pe *os.PathError
+	if errors.As(err, &pe) {
+		return &os.PathError{Op: "open", Path: name, Err: pe.Err}
+	}
+	return err
+}
+
+type s3FileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (fi *s3FileInfo) Name() string       { return fi.name }
+func (fi *s3FileInfo) Size() int64        { return fi.size }
+func (fi *s3FileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *s3FileInfo) IsDir() bool        { return fi.dir }
+func (fi *s3FileInfo) Sys() interface{}   { return nil }
+func (fi *s3FileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// s3WriteFile is the file created, the data is buffered until the part size is
+// reached, the first part starts the multipart upload.
+type s3WriteFile struct {
+	fs       *S3FS
+	name     string
+	key      string
+	modTime  time.Time
+	buf      []byte
+	size     int64
+	uploadID string
+	parts    []s3Part
+	closed   bool
+	err      error
+}
+
+func (f *s3WriteFile) Write(p []byte) (int, error) {
+	if f.closed {
+		return 0, ErrS3FileClosed
+	}
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.buf = append(f.buf, p...)
+	f.size += int64(len(p))
+	for len(f.buf) >= f.fs.opts.PartSize {
+		if f.err = f.uploadPart(f.buf[:f.fs.opts.PartSize]); f.err != nil {
+			return 0, f.err
+		}
+		f.buf = append(f.buf[:0:0], f.buf[f.fs.opts.PartSize:]...)
+	}
+	return len(p), nil
+}
+
+// WriteAt only the sequential writes are supported
+func (f *s3WriteFile) WriteAt(p []byte, off int64) (int, error) {
+	if off != f.size {
+		return 0, ErrS3NotSupported
+	}
+	return f.Write(p)
+}
+
+func (f *s3WriteFile) uploadPart(data []byte) error {
+	if f.uploadID == "" {
+		id, err := f.fs.client.createMultipartUpload(f.key)
+		if err != nil {
+			return err
+		}
+		f.uploadID = id
+	}
+	number := len(f.parts) + 1
+	etag, err := f.fs.client.uploadPart(f.key, f.uploadID, number, data)
+	if err != nil {
+		return err
+	}
+	f.parts = append(f.parts, s3Part{PartNumber: number, ETag: etag})
+	return nil
+}
+
+func (f *s3WriteFile) Read(p []byte) (int, error) {
+	return 0, ErrS3NotSupported
+}
+
+func (f *s3WriteFile) ReadAt(p []byte, off int64) (int, error) {
+	return 0, ErrS3NotSupported
+}
+
+// Seek only returns the current offset, the file can not be written randomly
+func (f *s3WriteFile) Seek(offset int64, whence int) (int64, error) {
+	if (whence == io.SeekCurrent && offset == 0) ||
+		(whence == io.SeekStart && offset == f.size) ||
+		(whence == io.SeekEnd && offset == 0) {
+		return f.size, nil
+	}
+	return 0, ErrS3NotSupported
+}
+
+func (f *s3WriteFile) Stat() (os.FileInfo, error) {
+	return &s3FileInfo{name: f.fs.PathBase(f.name), size: f.size, modTime: f.modTime}, nil
+}
+
+// Sync the data is persistent after the file is closed
+func (f *s3WriteFile) Sync() error {
+	if f.closed {
+		return ErrS3FileClosed
+	}
+	return f.err
+}
+
+func (f *s3WriteFile) Close() error {
+	if f.closed {
+		return ErrS3FileClosed
+	}
+	f.closed = true
+	if f.err == nil {
+		f.err = f.complete()
+	}
+	if f.err != nil && f.uploadID != "" {
+		_ = f.fs.client.abortMultipartUpload(f.key, f.uploadID)
+	}
+	f.buf = nil
+	return f.err
+}
+
+func (f *s3WriteFile) complete() error {
+	if f.uploadID == "" {
+		return f.fs.client.putObject(f.key, f.buf)
+	}
+	if len(f.buf) > 0 {
+		if err := f.uploadPart(f.buf); err != nil {
+			return err
+		}
+	}
+	return f.fs.client.completeMultipartUpload(f.key, f.uploadID, f.parts)
+}
+
+// s3ReadFile is the file opened, the data is read by the ranged reads of at
+// least the read ahead size, the last range read is kept to serve the reads.
+type s3ReadFile struct {
+	fs      *S3FS
+	name    string
+	key     string
+	size    int64
+	modTime time.Time
+
+	mu     sync.Mutex
+	pos    int64
+	buf    []byte
+	bufOff int64
+}
+
+func (f *s3ReadFile) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, err := f.readAtLocked(p, f.pos)
+	f.pos += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *s3ReadFile) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.readAtLocked(p, off)
+}
+
+func (f *s3ReadFile) readAtLocked(p []byte, off int64) (int, error) {
+	n := 0
+	for n < len(p) && off < f.size {
+		if off >= f.bufOff && off < f.bufOff+int64(len(f.buf)) {
+			c := copy(p[n:], f.buf[off-f.bufOff:])
+			n += c
+			off += int64(c)
+			continue
+		}
+
+		size := int64(len(p) - n)
+		if size < int64(f.fs.opts.ReadAheadSize) {
+			size = int64(f.fs.opts.ReadAheadSize)
+		}
+		if size > f.size-off {
+			size = f.size - off
+		}
+		data, err := f.fs.client.getObject(f.key, off, size)
+		if err != nil {
+			return n, err
+		}
+		if len(data) == 0 {
+			return n, io.ErrUnexpectedEOF
+		}
+		f.buf, f.bufOff = data, off
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *s3ReadFile) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		offset += f.size
+	default:
+		return 0, os.ErrInvalid
+	}
+	if offset < 0 {
+		return 0, os.ErrInvalid
+	}
+	f.pos = offset
+	return offset, nil
+}
+
+func (f *s3ReadFile) Write(p []byte) (int, error) {
+	return 0, ErrS3NotSupported
+}
+
+func (f *s3ReadFile) WriteAt(p []byte, off int64) (int, error) {
+	return 0, ErrS3NotSupported
+}
+
+func (f *s3ReadFile) Stat() (os.FileInfo, error) {
+	return &s3FileInfo{name: f.fs.PathBase(f.name), size: f.size, modTime: f.modTime}, nil
+}
+
+func (f *s3ReadFile) Sync() error {
+	return nil
+}
+
+func (f *s3ReadFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.buf = nil
+	return nil
+}
+
+// s3DirFile is the directory opened for syncing, the object storage has
+// nothing to sync.
+type s3DirFile struct {
+	info os.FileInfo
+}
+
+func (f *s3DirFile) Read(p []byte) (int, error)                   { return 0, ErrS3NotSupported }
+func (f *s3DirFile) ReadAt(p []byte, off int64) (int, error)      { return 0, ErrS3NotSupported }
+func (f *s3DirFile) Write(p []byte) (int, error)                  { return 0, ErrS3NotSupported }
+func (f *s3DirFile) WriteAt(p []byte, off int64) (int, error)     { return 0, ErrS3NotSupported }
+func (f *s3DirFile) Seek(offset int64, whence int) (int64, error) { return 0, ErrS3NotSupported }
+func (f *s3DirFile) Stat() (os.FileInfo, error)                   { return f.info, nil }
+func (f *s3DirFile) Sync() error                                  { return nil }
+func (f *s3DirFile) Close() error                                 { return nil }
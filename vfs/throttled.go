@@ -0,0 +1,340 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vfs
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/juju/ratelimit"
+)
+
+// IOClass the class of the IO scheduled by the IOScheduler
+type IOClass int
+
+const (
+	// ForegroundRead the reads of the data files serving the requests
+	ForegroundRead IOClass = iota
+	// ForegroundWrite the writes of the WAL on the critical path of the writes
+	ForegroundWrite
+	// Compaction the writes of the flushes and the compactions
+	Compaction
+	// Snapshot the reads and writes of the shard snapshots
+	Snapshot
+
+	numIOClasses
+)
+
+var ioClassNames = [numIOClasses]string{
+	"foreground-read",
+	"foreground-write",
+	"compaction",
+	"snapshot",
+}
+
+func (c IOClass) String() string {
+	if c < 0 || c >= numIOClasses {
+		return "unknown"
+	}
+	return ioClassNames[c]
+}
+
+// background returns true if the IO of the class yields to the foreground IO
+func (c IOClass) background() bool {
+	return c == Compaction || c == Snapshot
+}
+
+const (
+	// snapshotDirName the name of the directory of the shard snapshots of the
+	// store, the files under it are classified as Snapshot.
+	snapshotDirName = "snapshots"
+	// maxYieldStep the max interval of checking whether the foreground IO is
+	// completed while the background IO is yielding
+	maxYieldStep = time.Millisecond
+)
+
+// DefaultIOClassifier classifies the IO by the file names of pebble and the
+// store. The files under the snapshots directory are Snapshot, the writes of
+// the WAL are ForegroundWrite, the writes of the other files, e.g. the SSTs
+// written by the flushes and the compactions, are Compaction, and the reads are
+// ForegroundRead. The reads of the compactions share the file handles with the
+// foreground reads, so they're charged to ForegroundRead.
+func DefaultIOClassifier(name string, write bool) IOClass {
+	for _, elem := range strings.Split(filepath.ToSlash(name), "/") {
+		if elem == snapshotDirName {
+			return Snapshot
+		}
+	}
+	if !write {
+		return ForegroundRead
+	}
+	if strings.HasSuffix(name, ".log") {
+		return ForegroundWrite
+	}
+	return Compaction
+}
+
+// IOSchedulerOptions the options of the IOScheduler
+type IOSchedulerOptions struct {
+	// BytesPerSecond the IO budgets of the classes, the classes without a budget
+	// are not limited.
+	BytesPerSecond map[IOClass]int64
+	// BackgroundYield the max duration the background IO, i.e. Compaction and
+	// Snapshot, waits for the in-flight foreground IO to complete before it's
+	// issued. 0 means the background IO never yields.
+	BackgroundYield time.Duration
+	// Classify returns the class of the IO of the file, DefaultIOClassifier is
+	// used if not set.
+	Classify func(name string, write bool) IOClass
+}
+
+// IOStats the IO stats of a class
+type IOStats struct {
+	// Bytes the bytes read or written
+	Bytes uint64
+	// Ops the count of the reads and writes
+	Ops uint64
+	// Throttled the total duration the IO waited for the budget or yielded to
+	// the foreground IO
+	Throttled time.Duration
+}
+
+type ioClassState struct {
+	bytes     uint64
+	ops       uint64
+	throttled int64
+}
+
+// IOScheduler schedules the IO of the ThrottledFS by the classes, so the
+// background work, e.g. the compactions and the snapshot transfers, can not
+// push the latency of the foreground reads and writes past the SLOs. Each class
+// is limited by its own budget, and the background IO yields to the in-flight
+// foreground IO.
+type IOScheduler struct {
+	yield    time.Duration
+	classify func(name string, write bool) IOClass
+	// foreground the count of the in-flight foreground IO
+	foreground int64
+	states     [numIOClasses]ioClassState
+
+	mu      sync.RWMutex
+	budgets [numIOClasses]int64
+	buckets [numIOClasses]*ratelimit.Bucket
+}
+
+// NewIOScheduler returns an IOScheduler
+func NewIOScheduler(opts IOSchedulerOptions) *IOScheduler {
+	s := &IOScheduler{
+		yield:    opts.BackgroundYield,
+		classify: opts.Classify,
+	}
+	if s.classify == nil {
+		s.classify = DefaultIOClassifier
+	}
+	for class, v := range opts.BytesPerSecond {
+		s.SetBudget(class, v)
+	}
+	return s
+}
+
+// SetBudget updates the budget of the class, 0 means no limit.
+func (s *IOScheduler) SetBudget(class IOClass, bytesPerSecond int64) {
+	if class < 0 || class >= numIOClasses {
+		return
+	}
+	if bytesPerSecond < 0 {
+		bytesPerSecond = 0
+	}
+	var b *ratelimit.Bucket
+	if bytesPerSecond > 0 {
+		b = ratelimit.NewBucketWithRate(float64(bytesPerSecond), bytesPerSecond)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.budgets[class] = bytesPerSecond
+	s.buckets[class] = b
+}
+
+// Budget returns the budget of the class in bytes per second, 0 means no limit.
+func (s *IOScheduler) Budget(class IOClass) int64 {
+	if class < 0 || class >= numIOClasses {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.budgets[class]
+}
+
+// Stats returns the IO stats of the class
+func (s *IOScheduler) Stats(class IOClass) IOStats {
+	if class < 0 || class >= numIOClasses {
+		return IOStats{}
+	}
+	st := &s.states[class]
+	return IOStats{
+		Bytes:     atomic.LoadUint64(&st.bytes),
+		Ops:       atomic.LoadUint64(&st.ops),
+		Throttled: time.Duration(atomic.LoadInt64(&st.throttled)),
+	}
+}
+
+// begin waits until the IO of n bytes can be issued, end must be called once
+// the IO is completed.
+func (s *IOScheduler) begin(class IOClass, n int) {
+	start := time.Now()
+	throttled := false
+	if class.background() && s.yield > 0 {
+		step := s.yield
+		if step > maxYieldStep {
+			step = maxYieldStep
+		}
+		for atomic.LoadInt64(&s.foreground) > 0 && time.Since(start) < s.yield {
+			time.Sleep(step)
+			throttled = true
+		}
+	}
+
+	s.mu.RLock()
+	b := s.buckets[class]
+	s.mu.RUnlock()
+	if b != nil && n > 0 {
+		if d := b.Take(int64(n)); d > 0 {
+			time.Sleep(d)
+			throttled = true
+		}
+	}
+
+	st := &s.states[class]
+	if throttled {
+		atomic.AddInt64(&st.throttled, int64(time.Since(start)))
+	}
+	if !class.background() {
+		atomic.AddInt64(&s.foreground, 1)
+	}
+}
+
+func (s *IOScheduler) end(class IOClass, n int) {
+	if !class.background() {
+		atomic.AddInt64(&s.foreground, -1)
+	}
+	st := &s.states[class]
+	atomic.AddUint64(&st.ops, 1)
+	if n > 0 {
+		atomic.AddUint64(&st.bytes, uint64(n))
+	}
+}
+
+// ThrottledFS is a FS whose file reads and writes are scheduled by the
+// IOScheduler. The same FS should be set as the Config.FS and used to create
+// the pebble storage by NewPebbleFS, so the WAL, the flushes, the compactions
+// and the snapshots of the store are all scheduled by the same IOScheduler.
+type ThrottledFS struct {
+	FS
+	scheduler *IOScheduler
+}
+
+var _ FS = (*ThrottledFS)(nil)
+
+// NewThrottledFS returns a ThrottledFS backed by the fs.
+func NewThrottledFS(fs FS, scheduler *IOScheduler) *ThrottledFS {
+	return &ThrottledFS{FS: fs, scheduler: scheduler}
+}
+
+// Scheduler returns the IOScheduler of the FS
+func (t *ThrottledFS) Scheduler() *IOScheduler {
+	return t.scheduler
+}
+
+// Create creates the file
+func (t *ThrottledFS) Create(name string) (File, error) {
+	f, err := t.FS.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return t.newFile(f, name), nil
+}
+
+// Open opens the file for reading
+func (t *ThrottledFS) Open(name string, opts ...OpenOption) (File, error) {
+	f, err := t.FS.Open(name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return t.newFile(f, name), nil
+}
+
+// OpenForAppend opens the file for appending
+func (t *ThrottledFS) OpenForAppend(name string) (File, error) {
+	f, err := t.FS.OpenForAppend(name)
+	if err != nil {
+		return nil, err
+	}
+	return t.newFile(f, name), nil
+}
+
+// ReuseForWrite reuses the oldname file as the newname file
+func (t *ThrottledFS) ReuseForWrite(oldname, newname string) (File, error) {
+	f, err := t.FS.ReuseForWrite(oldname, newname)
+	if err != nil {
+		return nil, err
+	}
+	return t.newFile(f, newname), nil
+}
+
+func (t *ThrottledFS) newFile(f File, name string) File {
+	return &throttledFile{
+		File:      f,
+		scheduler: t.scheduler,
+		read:      t.scheduler.classify(name, false),
+		write:     t.scheduler.classify(name, true),
+	}
+}
+
+// throttledFile classifies its reads and writes when it's opened
+type throttledFile struct {
+	File
+	scheduler   *IOScheduler
+	read, write IOClass
+}
+
+func (f *throttledFile) Read(p []byte) (int, error) {
+	f.scheduler.begin(f.read, len(p))
+	n, err := f.File.Read(p)
+	f.scheduler.end(f.read, n)
+	return n, err
+}
+
+func (f *throttledFile) ReadAt(p []byte, off int64) (int, error) {
+	f.scheduler.begin(f.read, len(p))
+	n, err := f.File.ReadAt(p, off)
+	f.scheduler.end(f.read, n)
+	return n, err
+}
+
+func (f *throttledFile) Write(p []byte) (int, error) {
+	f.scheduler.begin(f.write, len(p))
+	n, err := f.File.Write(p)
+	f.scheduler.end(f.write, n)
+	return n, err
+}
+
+func (f *throttledFile) WriteAt(p []byte, off int64) (int, error) {
+	f.scheduler.begin(f.write, len(p))
+	n, err := f.File.WriteAt(p, off)
+	f.scheduler.end(f.write, n)
+	return n, err
+}
@@ -0,0 +1,122 @@
+// Copyright 2022 MatrixOrigin.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vfs
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultIOClassifier(t *testing.T) {
+	tests := []struct {
+		name  string
+		write bool
+		class IOClass
+	}{
+		{"data/000001.log", true, ForegroundWrite},
+		{"data/000001.log", false, ForegroundRead},
+		{"data/000002.sst", true, Compaction},
+		{"data/000002.sst", false, ForegroundRead},
+		{"data/MANIFEST-000003", true, Compaction},
+		{"data/snapshots/shard-1/000004.sst", true, Snapshot},
+		{"data/snapshots/shard-1/000004.sst", false, Snapshot},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.class, DefaultIOClassifier(tt.name, tt.write), tt.name)
+	}
+}
+
+func TestThrottledFS(t *testing.T) {
+	s := NewIOScheduler(IOSchedulerOptions{})
+	fs := NewThrottledFS(NewMemFS(), s)
+	f, err := fs.Create("000001.log")
+	require.NoError(t, err)
+	_, err = f.Write([]byte("wal"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	f, err = fs.Open("000001.log")
+	require.NoError(t, err)
+	buf := make([]byte, 3)
+	_, err = f.ReadAt(buf, 0)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "wal", string(buf))
+
+	assert.Equal(t, IOStats{Bytes: 3, Ops: 1}, s.Stats(ForegroundWrite))
+	assert.Equal(t, IOStats{Bytes: 3, Ops: 1}, s.Stats(ForegroundRead))
+	assert.Equal(t, IOStats{}, s.Stats(Compaction))
+}
+
+func TestIOSchedulerBudget(t *testing.T) {
+	s := NewIOScheduler(IOSchedulerOptions{
+		BytesPerSecond: map[IOClass]int64{Compaction: 1000},
+	})
+	assert.Equal(t, int64(1000), s.Budget(Compaction))
+	assert.Equal(t, int64(0), s.Budget(ForegroundWrite))
+
+	// the tokens of the first second are available at once
+	s.begin(Compaction, 1000)
+	s.end(Compaction, 1000)
+	start := time.Now()
+	s.begin(Compaction, 200)
+	s.end(Compaction, 200)
+	assert.True(t, time.Since(start) >= 150*time.Millisecond)
+	assert.NotEqual(t, time.Duration(0), s.Stats(Compaction).Throttled)
+
+	// the other classes are not limited
+	start = time.Now()
+	s.begin(ForegroundWrite, 1000000)
+	s.end(ForegroundWrite, 1000000)
+	assert.True(t, time.Since(start) < 100*time.Millisecond)
+
+	s.SetBudget(Compaction, 0)
+	assert.Equal(t, int64(0), s.Budget(Compaction))
+	start = time.Now()
+	s.begin(Compaction, 1000000)
+	s.end(Compaction, 1000000)
+	assert.True(t, time.Since(start) < 100*time.Millisecond)
+}
+
+func TestIOSchedulerBackgroundYield(t *testing.T) {
+	s := NewIOScheduler(IOSchedulerOptions{BackgroundYield: time.Second})
+	s.begin(ForegroundRead, 1)
+
+	var done int32
+	go func() {
+		s.begin(Snapshot, 1)
+		s.end(Snapshot, 1)
+		atomic.StoreInt32(&done, 1)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&done), "yield to the foreground")
+
+	s.end(ForegroundRead, 1)
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&done) == 1
+	}, time.Second, time.Millisecond)
+	assert.NotEqual(t, time.Duration(0), s.Stats(Snapshot).Throttled)
+
+	// the foreground IO never yields
+	s.begin(Snapshot, 1)
+	start := time.Now()
+	s.begin(ForegroundWrite, 1)
+	s.end(ForegroundWrite, 1)
+	s.end(Snapshot, 1)
+	assert.True(t, time.Since(start) < 100*time.Millisecond)
+}
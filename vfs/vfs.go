@@ -30,6 +30,9 @@ type File = pvfs.File
 // FS is a vfs type
 type FS = pvfs.FS
 
+// OpenOption is the option of FS.Open
+type OpenOption = pvfs.OpenOption
+
 // MemFS is the in memory fs type
 type MemFS = pvfs.MemFS
 